@@ -717,6 +717,16 @@ func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	return nil
 }
 
+// Quorum
+//
+// PendingBlockAndState returns the pending block and a copy of the pending state.
+func (b *SimulatedBackend) PendingBlockAndState() (*types.Block, *state.StateDB) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.pendingBlock, b.pendingState.Copy()
+}
+
 // Blockchain returns the underlying blockchain.
 func (b *SimulatedBackend) Blockchain() *core.BlockChain {
 	return b.blockchain
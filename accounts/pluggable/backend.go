@@ -11,6 +11,10 @@ import (
 
 var BackendType = reflect.TypeOf(&Backend{})
 
+// WalletScheme is the URL scheme of the plugin wallet, and of the HD wallets of
+// the plugin.
+const WalletScheme = "plugin"
+
 type Backend struct {
 	wallets []accounts.Wallet
 }
@@ -20,7 +24,7 @@ func NewBackend() *Backend {
 		wallets: []accounts.Wallet{
 			&wallet{
 				url: accounts.URL{
-					Scheme: "plugin",
+					Scheme: WalletScheme,
 					Path:   "account",
 				},
 			},
@@ -54,6 +58,12 @@ func (b *Backend) Lock(account accounts.Account) error {
 	return b.wallet().lock(account)
 }
 
+// OpenWallet opens the HD wallet of the plugin at the url, to derive accounts
+// from with the Derive of the plugin wallet.
+func (b *Backend) OpenWallet(url string, passphrase string) error {
+	return b.wallet().openWallet(url, passphrase)
+}
+
 // AccountCreator is the interface that wraps the plugin account creation methods.
 // This interface is used to simplify the pluggable.Backend API available to the account plugin CLI and enables easier testing.
 type AccountCreator interface {
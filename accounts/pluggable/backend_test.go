@@ -88,6 +88,23 @@ func TestBackend_Lock(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBackend_OpenWallet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mock_plugin.NewMockService(ctrl)
+	mockClient.
+		EXPECT().
+		OpenWallet(gomock.Any(), gomock.Eq("plugin://account/hd"), gomock.Eq("pwd")).
+		Return(nil)
+
+	b := NewBackend()
+	b.wallets[0].(*wallet).pluginService = mockClient
+
+	err := b.OpenWallet("plugin://account/hd", "pwd")
+	require.NoError(t, err)
+}
+
 func TestBackend_NewAccount(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
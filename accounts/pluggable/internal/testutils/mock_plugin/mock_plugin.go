@@ -78,6 +78,21 @@ func (mr *MockServiceMockRecorder) Contains(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Contains", reflect.TypeOf((*MockService)(nil).Contains), arg0, arg1)
 }
 
+// DeriveAccount mocks base method
+func (m *MockService) DeriveAccount(arg0 context.Context, arg1 string, arg2 bool) (accounts.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeriveAccount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(accounts.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeriveAccount indicates an expected call of DeriveAccount
+func (mr *MockServiceMockRecorder) DeriveAccount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeriveAccount", reflect.TypeOf((*MockService)(nil).DeriveAccount), arg0, arg1, arg2)
+}
+
 // ImportRawKey mocks base method
 func (m *MockService) ImportRawKey(arg0 context.Context, arg1 string, arg2 interface{}) (accounts.Account, error) {
 	m.ctrl.T.Helper()
@@ -136,6 +151,20 @@ func (mr *MockServiceMockRecorder) Open(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockService)(nil).Open), arg0, arg1)
 }
 
+// OpenWallet mocks base method
+func (m *MockService) OpenWallet(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenWallet", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OpenWallet indicates an expected call of OpenWallet
+func (mr *MockServiceMockRecorder) OpenWallet(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenWallet", reflect.TypeOf((*MockService)(nil).OpenWallet), arg0, arg1, arg2)
+}
+
 // Sign mocks base method
 func (m *MockService) Sign(arg0 context.Context, arg1 accounts.Account, arg2 []byte) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -53,8 +53,11 @@ func (w *wallet) Contains(account accounts.Account) bool {
 	return w.pluginService.Contains(context.Background(), account)
 }
 
-func (w *wallet) Derive(_ accounts.DerivationPath, _ bool) (accounts.Account, error) {
-	return accounts.Account{}, accounts.ErrNotSupported
+// Derive implements accounts.Wallet, deriving the account from the HD wallet
+// opened in the plugin. Once pinned, the account is listed and signed with by
+// the plugin as its other accounts, the requests naming it by its address.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return w.pluginService.DeriveAccount(context.Background(), path.String(), pin)
 }
 
 func (w *wallet) SelfDerive(_ []accounts.DerivationPath, _ ethereum.ChainStateReader) {}
@@ -105,6 +108,10 @@ func (w *wallet) lock(account accounts.Account) error {
 	return w.pluginService.Lock(context.Background(), account)
 }
 
+func (w *wallet) openWallet(url string, passphrase string) error {
+	return w.pluginService.OpenWallet(context.Background(), url, passphrase)
+}
+
 func (w *wallet) newAccount(newAccountConfig interface{}) (accounts.Account, error) {
 	return w.pluginService.NewAccount(context.Background(), newAccountConfig)
 }
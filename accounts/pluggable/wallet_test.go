@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	plugin "github.com/ethereum/go-ethereum/plugin/account"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -141,11 +142,51 @@ func TestWallet_Contains(t *testing.T) {
 }
 
 func TestWallet_Derive(t *testing.T) {
-	w := validWallet(nil)
-	_, err := w.Derive(accounts.DerivationPath{}, true)
-	if assert.Error(t, err) {
-		assert.Equal(t, accounts.ErrNotSupported, err)
-	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	path, err := accounts.ParseDerivationPath("m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	derived := accounts.Account{Address: acct3.Address, URL: accounts.URL{Scheme: scheme, Path: "hd/m/44'/60'/0'/0/1"}}
+
+	mockClient := mock_plugin.NewMockService(ctrl)
+	mockClient.
+		EXPECT().
+		DeriveAccount(gomock.Any(), "m/44'/60'/0'/0/1", true).
+		Return(derived, nil)
+
+	w := validWallet(mockClient)
+	got, err := w.Derive(path, true)
+	require.NoError(t, err)
+	assert.Equal(t, derived, got)
+
+	// the derived account is signed with by the plugin
+	toSign := types.NewTransaction(1, acct2.Address, big.NewInt(1), 0, big.NewInt(1), nil)
+	signer := types.NewEIP155Signer(big.NewInt(20))
+	mockSig := make([]byte, 65)
+	rand.Read(mockSig)
+	mockClient.
+		EXPECT().
+		Sign(gomock.Any(), derived, signer.Hash(toSign).Bytes()).
+		Return(mockSig, nil)
+
+	_, err = w.SignTx(got, toSign, big.NewInt(20))
+	require.NoError(t, err)
+}
+
+func TestWallet_Derive_NotSupportedByPlugin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mock_plugin.NewMockService(ctrl)
+	mockClient.
+		EXPECT().
+		DeriveAccount(gomock.Any(), "m/44'/60'/0'/0/0", false).
+		Return(accounts.Account{}, plugin.ErrNotSupportedByPlugin)
+
+	w := validWallet(mockClient)
+	_, err := w.Derive(accounts.DefaultBaseDerivationPath, false)
+	assert.Equal(t, plugin.ErrNotSupportedByPlugin, err)
 }
 
 func TestWallet_SelfDerive(t *testing.T) {
@@ -0,0 +1,75 @@
+// fixturegen synthesizes a deterministic chain with a private transaction
+// workload into a data directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/privatefixtures"
+	"github.com/ethereum/go-ethereum/private/engine"
+)
+
+var (
+	defaults = privatefixtures.DefaultConfig
+
+	datadir      = flag.String("datadir", "", "empty data directory to generate the chain into")
+	seed         = flag.Int64("seed", defaults.Seed, "seed of the workload")
+	blocks       = flag.Int("blocks", defaults.Blocks, "number of blocks")
+	txsPerBlock  = flag.Int("txs", defaults.TxsPerBlock, "number of transactions per block")
+	privateRatio = flag.Float64("private", defaults.PrivateRatio, "share of private transactions, in [0, 1]")
+	payloadSize  = flag.Int("payload", defaults.PayloadSize, "size of the private payloads in bytes")
+	contracts    = flag.Int("contracts", defaults.Contracts, "number of distinct private contracts")
+	recipients   = flag.Int("recipients", defaults.Recipients, "number of recipients of each private contract")
+	privacyFlags = flag.String("privacyflags", "0", "comma-separated privacy flags assigned to the contracts in turn (0, 1 or 3)")
+	consensus    = flag.String("engine", defaults.Engine, "consensus engine, ethash or clique")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "-datadir <dir> [options]")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, `
+Generates a chain with a private transaction workload, the same options always
+yielding the same chain. The private payloads are written next to the chain, to
+be served by the loopback private transaction manager of the fixtures.`)
+	}
+}
+
+func main() {
+	flag.Parse()
+	if *datadir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cfg := privatefixtures.Config{
+		Seed:         *seed,
+		Blocks:       *blocks,
+		TxsPerBlock:  *txsPerBlock,
+		PrivateRatio: *privateRatio,
+		PayloadSize:  *payloadSize,
+		Contracts:    *contracts,
+		Recipients:   *recipients,
+		Engine:       *consensus,
+	}
+	for _, s := range strings.Split(*privacyFlags, ",") {
+		value, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			die("invalid privacy flag", s)
+		}
+		cfg.PrivacyFlags = append(cfg.PrivacyFlags, engine.PrivacyFlagType(value))
+	}
+	fixture, err := privatefixtures.Generate(*datadir, cfg)
+	if err != nil {
+		die(err)
+	}
+	fmt.Println("Generated", *blocks, "blocks and", fixture.PTM.Len(), "private payloads into", fixture.Dir)
+}
+
+func die(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+	os.Exit(1)
+}
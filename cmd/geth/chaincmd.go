@@ -18,6 +18,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -50,6 +51,7 @@ var (
 		ArgsUsage: "<genesisPath>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.MigrateConfigFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -57,7 +59,12 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument.
+
+With --migrate-config over an existing datadir, the fields of the chain config
+missing from the stored one are added and the transitions appended, the stored
+fields missing from the genesis file being kept. The migration is refused if it
+rewrites a stored value, and its report is printed.`,
 	}
 	dumpGenesisCommand = cli.Command{
 		Action:    utils.MigrateFlags(dumpGenesis),
@@ -280,6 +287,22 @@ func initGenesis(ctx *cli.Context) error {
 		if err != nil {
 			utils.Fatalf("Failed to open database: %v", err)
 		}
+		// Quorum
+		if ctx.Bool(utils.MigrateConfigFlag.Name) {
+			report, err := core.MigrateChainConfig(chaindb, genesis)
+			if err == nil {
+				printConfigMigration(name, report)
+				chaindb.Close()
+				continue
+			}
+			if report != nil {
+				printConfigMigration(name, report)
+			}
+			if !errors.Is(err, core.ErrNoChainConfig) {
+				utils.Fatalf("Failed to migrate chain config: %v", err)
+			}
+		}
+		// End Quorum
 		_, hash, err := core.SetupGenesisBlock(chaindb, genesis)
 		if err != nil {
 			utils.Fatalf("Failed to write genesis block: %v", err)
@@ -290,6 +313,18 @@ func initGenesis(ctx *cli.Context) error {
 	return nil
 }
 
+// Quorum
+// printConfigMigration prints the report of a chain config migration.
+func printConfigMigration(database string, report *core.ConfigMigrationReport) {
+	fmt.Printf("Chain config migration of %s at block %d (applied: %t)\n", database, report.Head, report.Applied)
+	for _, change := range report.Changes {
+		fmt.Printf("  %-8s %s: %v -> %v\n", change.Action, change.Field, change.Stored, change.Incoming)
+	}
+	if report.Error != "" {
+		fmt.Printf("  error: %s\n", report.Error)
+	}
+}
+
 func dumpGenesis(ctx *cli.Context) error {
 	genesis := utils.MakeGenesis(ctx)
 	if genesis == nil {
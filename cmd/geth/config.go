@@ -27,13 +27,13 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common/http"
 	"github.com/ethereum/go-ethereum/eth"
-	"github.com/ethereum/go-ethereum/extension/privacyExtension"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/raft"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
 	"github.com/naoina/toml"
 	"gopkg.in/urfave/cli.v1"
@@ -168,17 +168,22 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 		utils.RegisterPermissionService(stack, ctx.Bool(utils.RaftDNSEnabledFlag.Name))
 	}
 
+	var raftService *raft.RaftService
 	if ctx.GlobalBool(utils.RaftModeFlag.Name) {
-		utils.RegisterRaftService(stack, ctx, &cfg.Node, ethService)
+		raftService = utils.RegisterRaftService(stack, ctx, &cfg.Node, ethService)
 	}
 
 	//Must occur before registering the extension service, as it needs an initialised PTM to be enabled
-	if err := quorumInitialisePrivacy(ctx); err != nil {
+	if err := quorumInitialisePrivacy(ctx, stack); err != nil {
 		utils.Fatalf("Error initialising Private Transaction Manager: %s", err.Error())
 	}
 
 	if private.IsQuorumPrivacyEnabled() {
-		utils.RegisterExtensionService(stack, ethService)
+		utils.RegisterExtensionService(stack, ctx, ethService)
+	}
+
+	if ctx.GlobalBool(utils.HealthEnabledFlag.Name) {
+		utils.RegisterHealthService(stack, ctx, ethService, raftService, cfg.Eth.Istanbul)
 	}
 	// End Quorum
 
@@ -198,7 +203,7 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 	// Configure GraphQL if requested
-	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
+	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) || cfg.Node.GraphQLIPC {
 		utils.RegisterGraphQLService(stack, backend, cfg.Node)
 	}
 	// Add the Ethereum Stats daemon if requested.
@@ -271,19 +276,34 @@ func quorumValidatePrivacyEnhancements(ethereum *eth.Ethereum) {
 }
 
 // configure and set up quorum transaction privacy
-func quorumInitialisePrivacy(ctx *cli.Context) error {
-	cfg, err := QuorumSetupPrivacyConfiguration(ctx)
+func quorumInitialisePrivacy(ctx *cli.Context, stack *node.Node) error {
+	cfg, err := quorumPrivacyConfig(ctx)
 	if err != nil {
 		return err
 	}
+	_, err = eth.SetupPrivacy(stack, cfg)
+	return err
+}
 
-	err = private.InitialiseConnection(cfg)
+// quorumPrivacyConfig returns the privacy configuration of the legacy
+// environment variable and the command line parameters.
+func quorumPrivacyConfig(ctx *cli.Context) (*private.Config, error) {
+	ptm, err := QuorumSetupPrivacyConfiguration(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	privacyExtension.Init()
-
-	return nil
+	cfg := &private.Config{
+		TransactionManager:  ptm,
+		PrivateFromCheck:    private.PrivateFromCheck(ctx.GlobalString(utils.QuorumPTMPrivateFromCheckFlag.Name)),
+		CacheSize:           ctx.GlobalInt(utils.QuorumPTMCacheSizeFlag.Name),
+		CacheTTL:            ctx.GlobalDuration(utils.QuorumPTMCacheTTLFlag.Name),
+		CacheMissingTTL:     ctx.GlobalDuration(utils.QuorumPTMCacheMissingTTLFlag.Name),
+		HealthCheckInterval: ctx.GlobalDuration(utils.QuorumPTMHealthCheckFlag.Name),
+	}
+	if size := ctx.GlobalInt(utils.QuorumPTMCacheDiskFlag.Name); size > 0 {
+		cfg.CacheDiskSize = int64(size) * 1024 * 1024
+	}
+	return cfg, nil
 }
 
 // Get private transaction manager configuration
@@ -301,12 +321,21 @@ func QuorumSetupPrivacyConfiguration(ctx *cli.Context) (http.Config, error) {
 	if ctx.GlobalIsSet(utils.QuorumPTMUrlFlag.Name) {
 		cfg.SetHttpUrl(ctx.GlobalString(utils.QuorumPTMUrlFlag.Name))
 	}
+	if ctx.GlobalIsSet(utils.QuorumPTMUrlsFlag.Name) {
+		cfg.SetHttpUrls(splitAndTrim(ctx.GlobalString(utils.QuorumPTMUrlsFlag.Name)))
+	}
 	if ctx.GlobalIsSet(utils.QuorumPTMTimeoutFlag.Name) {
 		cfg.SetTimeout(ctx.GlobalUint(utils.QuorumPTMTimeoutFlag.Name))
 	}
 	if ctx.GlobalIsSet(utils.QuorumPTMDialTimeoutFlag.Name) {
 		cfg.SetDialTimeout(ctx.GlobalUint(utils.QuorumPTMDialTimeoutFlag.Name))
 	}
+	if ctx.GlobalIsSet(utils.QuorumPTMRetryAttemptsFlag.Name) {
+		cfg.SetRetryAttempts(ctx.GlobalUint(utils.QuorumPTMRetryAttemptsFlag.Name))
+	}
+	if ctx.GlobalIsSet(utils.QuorumPTMRetryBackoffFlag.Name) {
+		cfg.SetRetryBackoff(ctx.GlobalUint(utils.QuorumPTMRetryBackoffFlag.Name))
+	}
 	if ctx.GlobalIsSet(utils.QuorumPTMHttpIdleTimeoutFlag.Name) {
 		cfg.SetHttpIdleConnTimeout(ctx.GlobalUint(utils.QuorumPTMHttpIdleTimeoutFlag.Name))
 	}
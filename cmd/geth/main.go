@@ -165,15 +165,23 @@ var (
 		configFileFlag,
 		// Quorum
 		utils.QuorumImmutabilityThreshold,
+		utils.SealPruningFlag,
+		utils.SealPruningConfirmFlag,
+		utils.SealPruningDepthFlag,
+		utils.SealPruningCheckpointsFlag,
 		utils.EnableNodePermissionFlag,
 		utils.RaftModeFlag,
 		utils.RaftBlockTimeFlag,
 		utils.RaftJoinExistingFlag,
 		utils.RaftPortFlag,
 		utils.RaftDNSEnabledFlag,
+		utils.RaftLearnerMaxLagFlag,
 		utils.EmitCheckpointsFlag,
 		utils.IstanbulRequestTimeoutFlag,
 		utils.IstanbulBlockPeriodFlag,
+		utils.IstanbulExportDirFlag,
+		utils.IstanbulStandbyFlag,
+		utils.IstanbulStandbyObservationWindowFlag,
 		utils.PluginSettingsFlag,
 		utils.PluginSkipVerifyFlag,
 		utils.PluginLocalVerifyFlag,
@@ -181,10 +189,49 @@ var (
 		utils.AllowedFutureBlockTimeFlag,
 		utils.EVMCallTimeOutFlag,
 		utils.MultitenancyFlag,
+		utils.ReadOnlyFlag,
+		utils.ReadOnlyHeadFileFlag,
+		utils.ReadOnlyRefreshFlag,
+		utils.PublishHeadFileFlag,
+		utils.PrivateParallelismFlag,
+		utils.SlowImportThresholdFlag,
+		utils.MaxReorgDepthFlag,
+		utils.SlotPolicyFlag,
+		utils.StorageLayoutsFlag,
+		utils.SendDefaultsTTLFlag,
+		utils.ConsistencySecretFlag,
+		utils.ConsistencyWaitFlag,
+		utils.SubscriptionReplayBlocksFlag,
+		utils.SubscriptionReplaySizeFlag,
+		utils.FilterQuotaFlag,
+		utils.SubscriptionQuotaFlag,
+		utils.FilterLogsTimeoutFlag,
+		utils.FilterBlocksTimeoutFlag,
+		utils.FilterPendingTxsTimeoutFlag,
+		utils.LogsMaxResultsFlag,
+		utils.LogsMaxBytesFlag,
+		utils.LogsSpillFlag,
+		utils.HealthEnabledFlag,
+		utils.HealthReadyPathFlag,
+		utils.HealthLivePathFlag,
+		utils.HealthAuthFlag,
+		utils.HealthComponentsFlag,
+		utils.ReceiptVerifyFlag,
+		utils.ReceiptVerifyDegradeFlag,
+		utils.InternalCallIndexFlag,
+		utils.BlockStatsFlag,
+		utils.AddressTxIndexFlag,
+		utils.AccessLogContractsFlag,
+		utils.AccessLogSampleFlag,
+		utils.AccessLogMaxRateFlag,
+		utils.ExtensionAutoResendFlag,
 		utils.QuorumPTMUnixSocketFlag,
 		utils.QuorumPTMUrlFlag,
+		utils.QuorumPTMUrlsFlag,
 		utils.QuorumPTMTimeoutFlag,
 		utils.QuorumPTMDialTimeoutFlag,
+		utils.QuorumPTMRetryAttemptsFlag,
+		utils.QuorumPTMRetryBackoffFlag,
 		utils.QuorumPTMHttpIdleTimeoutFlag,
 		utils.QuorumPTMHttpWriteBufferSizeFlag,
 		utils.QuorumPTMHttpReadBufferSizeFlag,
@@ -193,6 +240,16 @@ var (
 		utils.QuorumPTMTlsClientCertFlag,
 		utils.QuorumPTMTlsClientKeyFlag,
 		utils.QuorumPTMTlsInsecureSkipVerify,
+		utils.QuorumPTMPrefetchFlag,
+		utils.QuorumPTMPrivateHintsFlag,
+		utils.PrivateAuditorsFlag,
+		utils.PrivateAuditFetchFlag,
+		utils.QuorumPTMCacheSizeFlag,
+		utils.QuorumPTMCacheTTLFlag,
+		utils.QuorumPTMCacheMissingTTLFlag,
+		utils.QuorumPTMCacheDiskFlag,
+		utils.QuorumPTMPrivateFromCheckFlag,
+		utils.QuorumPTMHealthCheckFlag,
 		// End-Quorum
 	}
 
@@ -202,6 +259,12 @@ var (
 		utils.HTTPPortFlag,
 		utils.HTTPCORSDomainFlag,
 		utils.HTTPVirtualHostsFlag,
+		utils.HTTPMaxBodyFlag,
+		utils.RPCDeprecationWarningsFlag,
+		utils.HTTPReadHeaderTimeoutFlag,
+		utils.HTTPReadTimeoutFlag,
+		utils.HTTPIdleTimeoutFlag,
+		utils.HTTPMaxConnectionsFlag,
 		utils.LegacyRPCEnabledFlag,
 		utils.LegacyRPCListenAddrFlag,
 		utils.LegacyRPCPortFlag,
@@ -210,6 +273,11 @@ var (
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
+		utils.GraphQLMaxBodyFlag,
+		utils.GraphQLStrictChecksumFlag,
+		utils.GraphQLAllowListFlag,
+		utils.GraphQLMaxTransactionsRangeFlag,
+		utils.GraphQLIPCFlag,
 		utils.HTTPApiFlag,
 		utils.LegacyRPCApiFlag,
 		utils.WSEnabledFlag,
@@ -220,6 +288,16 @@ var (
 		utils.WSApiFlag,
 		utils.LegacyWSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.WSMaxMessageFlag,
+		utils.WSAuthCheckIntervalFlag,
+		utils.WSMaxConnectionsFlag,
+		utils.WSPingIntervalFlag,
+		utils.WSIdleTimeoutFlag,
+		utils.APIKeysFileFlag,
+		utils.RedactionPolicyFileFlag,
+		utils.RequestLogFileFlag,
+		utils.RequestLogSampleFlag,
+		utils.RequestLogParamsFlag,
 		utils.LegacyWSAllowedOriginsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
@@ -141,14 +141,35 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.HTTPApiFlag,
 			utils.HTTPCORSDomainFlag,
 			utils.HTTPVirtualHostsFlag,
+			utils.HTTPMaxBodyFlag,
+			utils.RPCDeprecationWarningsFlag,
+			utils.HTTPReadHeaderTimeoutFlag,
+			utils.HTTPReadTimeoutFlag,
+			utils.HTTPIdleTimeoutFlag,
+			utils.HTTPMaxConnectionsFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.WSMaxMessageFlag,
+			utils.WSAuthCheckIntervalFlag,
+			utils.WSMaxConnectionsFlag,
+			utils.WSPingIntervalFlag,
+			utils.WSIdleTimeoutFlag,
+			utils.APIKeysFileFlag,
+			utils.RedactionPolicyFileFlag,
+			utils.RequestLogFileFlag,
+			utils.RequestLogSampleFlag,
+			utils.RequestLogParamsFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
+			utils.GraphQLMaxBodyFlag,
+			utils.GraphQLStrictChecksumFlag,
+			utils.GraphQLAllowListFlag,
+			utils.GraphQLMaxTransactionsRangeFlag,
+			utils.GraphQLIPCFlag,
 			utils.RPCGlobalGasCap,
 			utils.RPCGlobalTxFeeCap,
 			utils.JSpathFlag,
@@ -250,6 +271,10 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Name: "QUORUM",
 		Flags: []cli.Flag{
 			utils.QuorumImmutabilityThreshold,
+			utils.SealPruningFlag,
+			utils.SealPruningConfirmFlag,
+			utils.SealPruningDepthFlag,
+			utils.SealPruningCheckpointsFlag,
 			utils.EnableNodePermissionFlag,
 			utils.PluginSettingsFlag,
 			utils.PluginSkipVerifyFlag,
@@ -257,6 +282,42 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.PluginPublicKeyFlag,
 			utils.AllowedFutureBlockTimeFlag,
 			utils.MultitenancyFlag,
+			utils.ReadOnlyFlag,
+			utils.ReadOnlyHeadFileFlag,
+			utils.ReadOnlyRefreshFlag,
+			utils.PublishHeadFileFlag,
+			utils.PrivateParallelismFlag,
+			utils.SlowImportThresholdFlag,
+			utils.MaxReorgDepthFlag,
+			utils.SlotPolicyFlag,
+			utils.StorageLayoutsFlag,
+			utils.SendDefaultsTTLFlag,
+			utils.ConsistencySecretFlag,
+			utils.ConsistencyWaitFlag,
+			utils.SubscriptionReplayBlocksFlag,
+			utils.SubscriptionReplaySizeFlag,
+			utils.FilterQuotaFlag,
+			utils.SubscriptionQuotaFlag,
+			utils.FilterLogsTimeoutFlag,
+			utils.FilterBlocksTimeoutFlag,
+			utils.FilterPendingTxsTimeoutFlag,
+			utils.LogsMaxResultsFlag,
+			utils.LogsMaxBytesFlag,
+			utils.LogsSpillFlag,
+			utils.HealthEnabledFlag,
+			utils.HealthReadyPathFlag,
+			utils.HealthLivePathFlag,
+			utils.HealthAuthFlag,
+			utils.HealthComponentsFlag,
+			utils.ReceiptVerifyFlag,
+			utils.ReceiptVerifyDegradeFlag,
+			utils.InternalCallIndexFlag,
+			utils.BlockStatsFlag,
+			utils.AddressTxIndexFlag,
+			utils.AccessLogContractsFlag,
+			utils.AccessLogSampleFlag,
+			utils.AccessLogMaxRateFlag,
+			utils.ExtensionAutoResendFlag,
 		},
 	},
 	{
@@ -264,8 +325,11 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Flags: []cli.Flag{
 			utils.QuorumPTMUnixSocketFlag,
 			utils.QuorumPTMUrlFlag,
+			utils.QuorumPTMUrlsFlag,
 			utils.QuorumPTMTimeoutFlag,
 			utils.QuorumPTMDialTimeoutFlag,
+			utils.QuorumPTMRetryAttemptsFlag,
+			utils.QuorumPTMRetryBackoffFlag,
 			utils.QuorumPTMHttpIdleTimeoutFlag,
 			utils.QuorumPTMHttpWriteBufferSizeFlag,
 			utils.QuorumPTMHttpReadBufferSizeFlag,
@@ -274,6 +338,16 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.QuorumPTMTlsClientCertFlag,
 			utils.QuorumPTMTlsClientKeyFlag,
 			utils.QuorumPTMTlsInsecureSkipVerify,
+			utils.QuorumPTMPrefetchFlag,
+			utils.QuorumPTMPrivateHintsFlag,
+			utils.PrivateAuditorsFlag,
+			utils.PrivateAuditFetchFlag,
+			utils.QuorumPTMCacheSizeFlag,
+			utils.QuorumPTMCacheTTLFlag,
+			utils.QuorumPTMCacheMissingTTLFlag,
+			utils.QuorumPTMCacheDiskFlag,
+			utils.QuorumPTMPrivateFromCheckFlag,
+			utils.QuorumPTMHealthCheckFlag,
 		},
 	},
 	{
@@ -290,6 +364,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.RaftJoinExistingFlag,
 			utils.RaftPortFlag,
 			utils.RaftDNSEnabledFlag,
+			utils.RaftLearnerMaxLagFlag,
 		},
 	},
 	{
@@ -297,6 +372,9 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Flags: []cli.Flag{
 			utils.IstanbulRequestTimeoutFlag,
 			utils.IstanbulBlockPeriodFlag,
+			utils.IstanbulExportDirFlag,
+			utils.IstanbulStandbyFlag,
+			utils.IstanbulStandbyObservationWindowFlag,
 		},
 	},
 	// END QUORUM
@@ -49,11 +49,13 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethstats"
 	"github.com/ethereum/go-ethereum/extension"
 	"github.com/ethereum/go-ethereum/graphql"
+	"github.com/ethereum/go-ethereum/health"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/les"
@@ -73,7 +75,9 @@ import (
 	"github.com/ethereum/go-ethereum/permission/core/types"
 	"github.com/ethereum/go-ethereum/plugin"
 	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/cache"
 	"github.com/ethereum/go-ethereum/raft"
+	"github.com/ethereum/go-ethereum/rpc"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	"gopkg.in/urfave/cli.v1"
@@ -548,6 +552,36 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	// Quorum
+	HTTPMaxBodyFlag = cli.Int64Flag{
+		Name:  "http.maxbody",
+		Usage: "Maximum size in bytes of a request body accepted by the HTTP-RPC server",
+		Value: rpc.DefaultBodyLimit,
+	}
+	RPCDeprecationWarningsFlag = cli.BoolFlag{
+		Name:  "rpc.deprecationwarnings",
+		Usage: "Answer the calls of legacy Quorum methods by their deprecated name with a warning, over HTTP-RPC and WS-RPC",
+	}
+	HTTPReadHeaderTimeoutFlag = cli.DurationFlag{
+		Name:  "http.readheadertimeout",
+		Usage: "Maximum duration for reading the headers of a request by the HTTP-RPC server",
+		Value: rpc.DefaultHTTPTimeouts.ReadHeaderTimeout,
+	}
+	HTTPReadTimeoutFlag = cli.DurationFlag{
+		Name:  "http.readtimeout",
+		Usage: "Maximum duration for reading an entire request, body included, by the HTTP-RPC server",
+		Value: rpc.DefaultHTTPTimeouts.ReadTimeout,
+	}
+	HTTPIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "http.idletimeout",
+		Usage: "Maximum duration a keep-alive connection to the HTTP-RPC server stays idle between requests",
+		Value: rpc.DefaultHTTPTimeouts.IdleTimeout,
+	}
+	HTTPMaxConnectionsFlag = cli.IntFlag{
+		Name:  "http.maxconns",
+		Usage: "Maximum number of connections open at once to the HTTP-RPC server (0 = unlimited)",
+		Value: node.DefaultMaxConnections,
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -562,6 +596,28 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 	}
+	// Quorum
+	GraphQLMaxBodyFlag = cli.Int64Flag{
+		Name:  "graphql.maxbody",
+		Usage: "Maximum size in bytes of a request body accepted by the GraphQL server (0 = unlimited)",
+	}
+	GraphQLStrictChecksumFlag = cli.BoolFlag{
+		Name:  "graphql.strictchecksum",
+		Usage: "Reject mixed-case addresses failing the EIP-55 checksum in GraphQL queries",
+	}
+	GraphQLAllowListFlag = DirectoryFlag{
+		Name:  "graphql.allowlist",
+		Usage: "Directory of the <name>.graphql query documents the GraphQL server only answers, reloaded when modified",
+	}
+	GraphQLMaxTransactionsRangeFlag = cli.IntFlag{
+		Name:  "graphql.maxtxrange",
+		Usage: "Maximum number of transactions returned by a GraphQL transactionsRange query",
+		Value: graphql.DefaultMaxTransactionsRange,
+	}
+	GraphQLIPCFlag = cli.BoolFlag{
+		Name:  "graphql.ipc",
+		Usage: "Serve GraphQL over HTTP on the <ipcpath>.graphql unix socket, without requiring the HTTP-RPC server",
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -586,6 +642,52 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	// Quorum
+	WSMaxMessageFlag = cli.Int64Flag{
+		Name:  "ws.maxmessage",
+		Usage: "Maximum size in bytes of a message accepted by the WS-RPC server",
+		Value: rpc.DefaultBodyLimit,
+	}
+	WSAuthCheckIntervalFlag = cli.DurationFlag{
+		Name:  "ws.authcheckinterval",
+		Usage: "How often the access token of a WS-RPC connection with subscriptions is checked for expiration and revocation",
+		Value: rpc.DefaultAuthCheckInterval,
+	}
+	WSMaxConnectionsFlag = cli.IntFlag{
+		Name:  "ws.maxconns",
+		Usage: "Maximum number of connections open at once to the WS-RPC server (0 = unlimited)",
+		Value: node.DefaultMaxConnections,
+	}
+	WSPingIntervalFlag = cli.DurationFlag{
+		Name:  "ws.pinginterval",
+		Usage: "How long a WS-RPC connection stays idle before it is pinged",
+		Value: rpc.DefaultWSPingInterval,
+	}
+	WSIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "ws.idletimeout",
+		Usage: "How long a pinged WS-RPC connection has to answer before it is closed",
+		Value: rpc.DefaultWSIdleTimeout,
+	}
+	APIKeysFileFlag = cli.StringFlag{
+		Name:  "rpc.apikeys",
+		Usage: "JSON file of the API keys authenticating the HTTP-RPC, WS-RPC and GraphQL clients with the X-API-Key header, reloaded when modified",
+	}
+	RedactionPolicyFileFlag = cli.StringFlag{
+		Name:  "rpc.redactionpolicy",
+		Usage: "JSON file of the rules nulling response fields of the RPC methods and GraphQL fields for selected clients, reloaded when modified",
+	}
+	RequestLogFileFlag = cli.StringFlag{
+		Name:  "rpc.requestlog",
+		Usage: "File the HTTP-RPC, WS-RPC and GraphQL requests are logged to as JSON lines, or stderr",
+	}
+	RequestLogSampleFlag = cli.UintFlag{
+		Name:  "rpc.requestlog.sample",
+		Usage: "Log one in N requests of --rpc.requestlog",
+	}
+	RequestLogParamsFlag = cli.StringFlag{
+		Name:  "rpc.requestlog.params",
+		Usage: "Comma separated list of the RPC methods and GraphQL variables whose params are logged by --rpc.requestlog",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -765,6 +867,27 @@ var (
 		Usage: "overrides the default immutability threshold for Quorum nodes. Its the threshold beyond which block data will be moved to ancient db",
 		Value: 3162240,
 	}
+	SealPruningFlag = cli.BoolFlag{
+		Name:  "ancient.pruneseals",
+		Usage: "Strip the committed Istanbul seals of the headers moved to the ancient store, irreversibly (requires --ancient.pruneseals.confirm)",
+	}
+	SealPruningConfirmFlag = cli.BoolFlag{
+		Name:  "ancient.pruneseals.confirm",
+		Usage: "Acknowledge that the committed seals pruned can only be restored by re-syncing the headers from a node retaining them",
+	}
+	SealPruningDepthFlag = cli.Uint64Flag{
+		Name:  "ancient.pruneseals.depth",
+		Usage: "Number of blocks behind the head the headers are pruned from, the immutability threshold if lower",
+	}
+	SealPruningCheckpointsFlag = cli.Uint64Flag{
+		Name:  "ancient.pruneseals.checkpoints",
+		Usage: "Interval of the blocks keeping their committed seals (0 = none)",
+		Value: 30000,
+	}
+	MigrateConfigFlag = cli.BoolFlag{
+		Name:  "migrate-config",
+		Usage: "Merge the missing fields of the genesis chain config into the stored one, refusing to rewrite stored values",
+	}
 	// Raft flags
 	RaftModeFlag = cli.BoolFlag{
 		Name:  "raft",
@@ -794,6 +917,11 @@ var (
 		Name:  "raftdnsenable",
 		Usage: "Enable DNS resolution of peers",
 	}
+	RaftLearnerMaxLagFlag = cli.Uint64Flag{
+		Name:  "raftlearnermaxlag",
+		Usage: "Number of raft entries a learner may lag behind the leader to be promoted to peer",
+		Value: raft.DefaultLearnerMaxLag,
+	}
 
 	// Permission
 	EnableNodePermissionFlag = cli.BoolFlag{
@@ -833,16 +961,185 @@ var (
 		Usage: "Timeout for each Istanbul round in milliseconds",
 		Value: eth.DefaultConfig.Istanbul.RequestTimeout,
 	}
+	IstanbulExportDirFlag = DirectoryFlag{
+		Name:  "istanbul.exportdir",
+		Usage: "Directory istanbul_exportValidatorHistory writes server-side exports of the validator history to",
+	}
 	IstanbulBlockPeriodFlag = cli.Uint64Flag{
 		Name:  "istanbul.blockperiod",
 		Usage: "Default minimum difference between two consecutive block's timestamps in seconds",
 		Value: eth.DefaultConfig.Istanbul.BlockPeriod,
 	}
+	IstanbulStandbyFlag = cli.BoolFlag{
+		Name:  "istanbul.standby",
+		Usage: "Follow the chain with the validator key without taking part in consensus until promoted by istanbul_promoteStandby",
+	}
+	IstanbulStandbyObservationWindowFlag = cli.Uint64Flag{
+		Name:  "istanbul.standby.observationwindow",
+		Usage: "Seconds the validator key must be inactive before promoting a standby without fencing token",
+		Value: eth.DefaultConfig.Istanbul.StandbyObservationWindow,
+	}
 	// Multitenancy setting
 	MultitenancyFlag = cli.BoolFlag{
 		Name:  "multitenancy",
 		Usage: "Enable multitenancy support for this node. This requires RPC Security Plugin to also be configured.",
 	}
+	// Read-only (shared database) settings
+	ReadOnlyFlag = cli.BoolFlag{
+		Name:  "readonly",
+		Usage: "Serve RPC/GraphQL reads from a chain database replicated from a writer node. Mining, networking and transaction submission are disabled",
+	}
+	ReadOnlyHeadFileFlag = cli.StringFlag{
+		Name:  "readonly.headfile",
+		Usage: "Head file published by the writer node (see --publishhead). If not set, the head marker stored in the replicated database is followed",
+	}
+	ReadOnlyRefreshFlag = cli.DurationFlag{
+		Name:  "readonly.refresh",
+		Usage: "Interval at which a read-only node refreshes its view of the replicated database",
+		Value: time.Second,
+	}
+	ReceiptVerifyFlag = cli.Uint64Flag{
+		Name:  "receipts.verify",
+		Usage: "Verify stored receipts of every new block and of one in N historical blocks against the block headers (0 = disabled)",
+	}
+	ReceiptVerifyDegradeFlag = cli.BoolFlag{
+		Name:  "receipts.degrade",
+		Usage: "Refuse to serve receipts of blocks found corrupted by --receipts.verify",
+	}
+	InternalCallIndexFlag = cli.BoolFlag{
+		Name:  "internalcalls",
+		Usage: "Index the internal calls made by contracts during transaction execution",
+	}
+	BlockStatsFlag = cli.BoolFlag{
+		Name:  "blockstats",
+		Usage: "Aggregate per block and per day statistics of the canonical chain, served by quorum_blockStats and quorum_dailyStats",
+	}
+	AddressTxIndexFlag = cli.BoolFlag{
+		Name:  "addresstxindex",
+		Usage: "Index the canonical transactions by sender and by recipient, served by the transactions of the GraphQL accounts",
+	}
+	AccessLogContractsFlag = cli.StringFlag{
+		Name:  "accesslog.contracts",
+		Usage: "Comma separated list of private contracts whose state reads over RPC and GraphQL are logged",
+	}
+	AccessLogSampleFlag = cli.UintFlag{
+		Name:  "accesslog.sample",
+		Usage: "Log one in N reads of the contracts of --accesslog.contracts, all of them are counted in the summaries",
+		Value: 1,
+	}
+	AccessLogMaxRateFlag = cli.UintFlag{
+		Name:  "accesslog.maxrate",
+		Usage: "Maximum number of reads logged per contract and second (0 = unlimited)",
+		Value: 10,
+	}
+	ExtensionAutoResendFlag = cli.BoolFlag{
+		Name:  "extension.autoresend",
+		Usage: "Request the historic payloads of contracts extended to this node from the extension initiator once their state is shared",
+	}
+	PublishHeadFileFlag = cli.StringFlag{
+		Name:  "publishhead",
+		Usage: "File to keep up to date with the current chain head, for read-only nodes sharing this node's database",
+	}
+	PrivateParallelismFlag = cli.IntFlag{
+		Name:  "private.parallel",
+		Usage: "Maximum private transactions with execution hints executed concurrently when importing a block (0 = serially)",
+	}
+	SubscriptionReplayBlocksFlag = cli.Uint64Flag{
+		Name:  "ws.replayblocks",
+		Usage: "Number of blocks of newHeads and logs events kept for durable subscriptions to resume from (0 = durable subscriptions disabled)",
+	}
+	SubscriptionReplaySizeFlag = cli.IntFlag{
+		Name:  "ws.replaysize",
+		Usage: "Maximum size in bytes of the events of each type kept for durable subscriptions (0 = unlimited)",
+	}
+	FilterQuotaFlag = cli.IntFlag{
+		Name:  "rpc.filters.max",
+		Usage: "Maximum number of polled filters installed by each client, identified by its authenticated identity or else its IP address (0 = unlimited)",
+	}
+	SubscriptionQuotaFlag = cli.IntFlag{
+		Name:  "rpc.subscriptions.max",
+		Usage: "Maximum number of subscriptions of each client, identified by its authenticated identity or else its IP address (0 = unlimited)",
+	}
+	FilterLogsTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.filters.logstimeout",
+		Usage: "Time after which the log filters which have not been polled are removed",
+		Value: 5 * time.Minute,
+	}
+	FilterBlocksTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.filters.blockstimeout",
+		Usage: "Time after which the block filters which have not been polled are removed",
+		Value: 5 * time.Minute,
+	}
+	FilterPendingTxsTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.filters.pendingtxtimeout",
+		Usage: "Time after which the pending transaction filters which have not been polled are removed",
+		Value: 5 * time.Minute,
+	}
+	LogsMaxResultsFlag = cli.IntFlag{
+		Name:  "rpc.logs.maxresults",
+		Usage: "Maximum number of logs returned by eth_getLogs and eth_getFilterLogs without a page size, larger queries failing as soon as found (0 = unlimited)",
+	}
+	LogsMaxBytesFlag = cli.IntFlag{
+		Name:  "rpc.logs.maxbytes",
+		Usage: "Maximum estimated size in bytes of the logs returned by eth_getLogs and eth_getFilterLogs without a page size (0 = unlimited)",
+	}
+	LogsSpillFlag = cli.BoolFlag{
+		Name:  "rpc.logs.spill",
+		Usage: "Spill the logs found past a page of eth_getLogs to the data directory to serve the next page without searching them again",
+	}
+	MaxReorgDepthFlag = cli.Uint64Flag{
+		Name:  "reorg.maxdepth",
+		Usage: "Maximum number of canonical blocks a reorg may drop, deeper reorgs being rejected (default = 0 for Istanbul and Raft, unlimited otherwise)",
+	}
+	SlotPolicyFlag = cli.StringFlag{
+		Name:  "rpc.slotpolicy",
+		Usage: "JSON file of the contract storage slots only the listed senders may write with the transactions submitted over RPC, reloaded when modified (local policy, not enforced by consensus)",
+	}
+	StorageLayoutsFlag = cli.StringFlag{
+		Name:  "private.storagelayouts",
+		Usage: "JSON file of the solc storage layouts of private contracts, by address, decoding their state with quorum_describePrivateState",
+	}
+	SendDefaultsTTLFlag = cli.DurationFlag{
+		Name:  "rpc.senddefaults.ttl",
+		Usage: "Maximum time the private transaction defaults set by a client with quorum_setSendDefaults are kept, in memory only (0 = disabled)",
+		Value: time.Hour,
+	}
+	ConsistencySecretFlag = cli.StringFlag{
+		Name:  "rpc.consistencysecret",
+		Usage: "File of the hex secret shared by the nodes behind a load balancer, signing the read-your-writes consistency tokens of the HTTP writes",
+	}
+	ConsistencyWaitFlag = cli.DurationFlag{
+		Name:  "rpc.consistencywait",
+		Usage: "Maximum time the HTTP requests presenting a consistency token wait for the node to catch up with it",
+		Value: 2 * time.Second,
+	}
+	SlowImportThresholdFlag = cli.DurationFlag{
+		Name:  "import.slowthreshold",
+		Usage: "Import time above which the per-phase breakdown of a block is logged at debug level (0 = disabled)",
+	}
+	HealthEnabledFlag = cli.BoolFlag{
+		Name:  "health",
+		Usage: "Enable the readiness and liveness endpoints on the HTTP-RPC server",
+	}
+	HealthReadyPathFlag = cli.StringFlag{
+		Name:  "health.readypath",
+		Usage: "Path of the readiness endpoint",
+		Value: health.DefaultConfig.ReadyPath,
+	}
+	HealthLivePathFlag = cli.StringFlag{
+		Name:  "health.livepath",
+		Usage: "Path of the liveness endpoint",
+		Value: health.DefaultConfig.LivePath,
+	}
+	HealthAuthFlag = cli.BoolFlag{
+		Name:  "health.auth",
+		Usage: "Require the authentication of the security plugin on the health endpoints, which are exempt by default",
+	}
+	HealthComponentsFlag = cli.StringFlag{
+		Name:  "health.components",
+		Usage: "Comma separated list of the components the readiness is made of (sync, consensus, ptm)",
+		Value: strings.Join(health.DefaultConfig.Components, ","),
+	}
 
 	// Quorum Private Transaction Manager connection options
 	QuorumPTMUnixSocketFlag = DirectoryFlag{
@@ -851,7 +1148,11 @@ var (
 	}
 	QuorumPTMUrlFlag = cli.StringFlag{
 		Name:  "ptm.url",
-		Usage: "URL when using http connection to private transaction manager",
+		Usage: "URL when using http connection to private transaction manager, failed over to when the --ptm.socket is unavailable if both are set",
+	}
+	QuorumPTMUrlsFlag = cli.StringFlag{
+		Name:  "ptm.urls",
+		Usage: "Comma separated list of further URLs of a clustered private transaction manager, requests being routed to the endpoints by latency and health",
 	}
 	QuorumPTMTimeoutFlag = cli.UintFlag{
 		Name:  "ptm.timeout",
@@ -863,6 +1164,16 @@ var (
 		Usage: "Dial timeout (seconds) for the private transaction manager connection. Zero value means timeout disabled.",
 		Value: http2.DefaultConfig.DialTimeout,
 	}
+	QuorumPTMRetryAttemptsFlag = cli.UintFlag{
+		Name:  "ptm.retry.attempts",
+		Usage: "Maximum attempts of the calls to the private transaction manager, retried on connection errors and 5xx statuses (0 or 1 = no retry)",
+		Value: http2.DefaultConfig.RetryAttempts,
+	}
+	QuorumPTMRetryBackoffFlag = cli.UintFlag{
+		Name:  "ptm.retry.backoff",
+		Usage: "Delay (milliseconds) before the first retry of a call to the private transaction manager, doubled on each further retry",
+		Value: http2.DefaultConfig.RetryBackoff,
+	}
 	QuorumPTMHttpIdleTimeoutFlag = cli.UintFlag{
 		Name:  "ptm.http.idletimeout",
 		Usage: "Idle timeout (seconds) for the private transaction manager connection. Zero value means timeout disabled.",
@@ -898,6 +1209,52 @@ var (
 		Name:  "ptm.tls.insecureskipverify",
 		Usage: "Disable verification of server's TLS certificate on connection to private transaction manager",
 	}
+	QuorumPTMPrefetchFlag = cli.IntFlag{
+		Name:  "ptm.prefetch",
+		Usage: "Maximum concurrent requests prefetching the private payloads of blocks received ahead of their import (0 = disabled)",
+		Value: 4,
+	}
+	QuorumPTMPrivateHintsFlag = cli.BoolFlag{
+		Name:  "ptm.hints",
+		Usage: "Hint the peers party to the private transactions of the imported blocks to prefetch their payloads, and prefetch the payloads hinted by the peers",
+	}
+	PrivateAuditorsFlag = cli.StringFlag{
+		Name:  "privateaudit.auditors",
+		Usage: "Comma separated enode URLs of the auditor nodes served the internals of the private transactions the node is party to",
+	}
+	PrivateAuditFetchFlag = cli.BoolFlag{
+		Name:  "privateaudit.fetch",
+		Usage: "Fetch the internals of the private transactions from the audited peers (quorum_fetchMarkerInternals)",
+	}
+	QuorumPTMCacheSizeFlag = cli.IntFlag{
+		Name:  "ptm.cache.size",
+		Usage: "Number of decrypted private payloads cached in memory",
+		Value: cache.DefaultSize,
+	}
+	QuorumPTMCacheTTLFlag = cli.DurationFlag{
+		Name:  "ptm.cache.ttl",
+		Usage: "Time the decrypted private payloads are cached in memory for",
+		Value: cache.DefaultExpiration,
+	}
+	QuorumPTMCacheMissingTTLFlag = cli.DurationFlag{
+		Name:  "ptm.cache.missingttl",
+		Usage: "Time the private payloads the node is not a party to are cached as missing for",
+		Value: cache.DefaultMissingExpiration,
+	}
+	QuorumPTMCacheDiskFlag = cli.IntFlag{
+		Name:  "ptm.cache.disk",
+		Usage: "Megabytes of decrypted private payloads kept on disk across restarts, encrypted with a node-local key (0 = disabled)",
+	}
+	QuorumPTMPrivateFromCheckFlag = cli.StringFlag{
+		Name:  "ptm.privatefrom.check",
+		Usage: "Check that the privateFrom of the private transactions sent is a key of the private transaction manager (off, warn or strict, strict becoming the default in the next release)",
+		Value: string(private.PrivateFromCheckWarn),
+	}
+	QuorumPTMHealthCheckFlag = cli.DurationFlag{
+		Name:  "ptm.healthcheck",
+		Usage: "Interval the private transaction manager is upchecked at for the quorum/ptm metrics, backing off while it is down (0 = disabled)",
+		Value: 15 * time.Second,
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1115,6 +1472,26 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(HTTPVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(HTTPVirtualHostsFlag.Name))
 	}
+
+	// Quorum
+	if ctx.GlobalIsSet(HTTPMaxBodyFlag.Name) {
+		cfg.HTTPBodyLimit = ctx.GlobalInt64(HTTPMaxBodyFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCDeprecationWarningsFlag.Name) {
+		cfg.RPCDeprecationWarnings = ctx.GlobalBool(RPCDeprecationWarningsFlag.Name)
+	}
+	if ctx.GlobalIsSet(HTTPReadHeaderTimeoutFlag.Name) {
+		cfg.HTTPTimeouts.ReadHeaderTimeout = ctx.GlobalDuration(HTTPReadHeaderTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(HTTPReadTimeoutFlag.Name) {
+		cfg.HTTPTimeouts.ReadTimeout = ctx.GlobalDuration(HTTPReadTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(HTTPIdleTimeoutFlag.Name) {
+		cfg.HTTPTimeouts.IdleTimeout = ctx.GlobalDuration(HTTPIdleTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(HTTPMaxConnectionsFlag.Name) {
+		cfg.HTTPMaxConnections = ctx.GlobalInt(HTTPMaxConnectionsFlag.Name)
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1126,6 +1503,22 @@ func setGraphQL(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(GraphQLVirtualHostsFlag.Name) {
 		cfg.GraphQLVirtualHosts = splitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
 	}
+	// Quorum
+	if ctx.GlobalIsSet(GraphQLMaxBodyFlag.Name) {
+		cfg.GraphQLBodyLimit = ctx.GlobalInt64(GraphQLMaxBodyFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLStrictChecksumFlag.Name) {
+		cfg.GraphQLStrictChecksum = ctx.GlobalBool(GraphQLStrictChecksumFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLAllowListFlag.Name) {
+		cfg.GraphQLAllowList = ctx.GlobalString(GraphQLAllowListFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLMaxTransactionsRangeFlag.Name) {
+		cfg.GraphQLMaxTransactionsRange = ctx.GlobalInt(GraphQLMaxTransactionsRangeFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLIPCFlag.Name) {
+		cfg.GraphQLIPC = ctx.GlobalBool(GraphQLIPCFlag.Name)
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -1164,6 +1557,23 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSApiFlag.Name) {
 		cfg.WSModules = splitAndTrim(ctx.GlobalString(WSApiFlag.Name))
 	}
+
+	// Quorum
+	if ctx.GlobalIsSet(WSMaxMessageFlag.Name) {
+		cfg.WSMessageLimit = ctx.GlobalInt64(WSMaxMessageFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSAuthCheckIntervalFlag.Name) {
+		cfg.WSAuthCheckInterval = ctx.GlobalDuration(WSAuthCheckIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSMaxConnectionsFlag.Name) {
+		cfg.WSMaxConnections = ctx.GlobalInt(WSMaxConnectionsFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSPingIntervalFlag.Name) {
+		cfg.WSPingInterval = ctx.GlobalDuration(WSPingIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(WSIdleTimeoutFlag.Name) {
+		cfg.WSIdleTimeout = ctx.GlobalDuration(WSIdleTimeoutFlag.Name)
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -1409,6 +1819,21 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(EnableNodePermissionFlag.Name) {
 		cfg.EnableNodePermission = ctx.GlobalBool(EnableNodePermissionFlag.Name)
 	}
+	if ctx.GlobalIsSet(APIKeysFileFlag.Name) {
+		cfg.APIKeysFile = ctx.GlobalString(APIKeysFileFlag.Name)
+	}
+	if ctx.GlobalIsSet(RedactionPolicyFileFlag.Name) {
+		cfg.RedactionPolicyFile = ctx.GlobalString(RedactionPolicyFileFlag.Name)
+	}
+	if ctx.GlobalIsSet(RequestLogFileFlag.Name) {
+		cfg.RequestLogFile = ctx.GlobalString(RequestLogFileFlag.Name)
+	}
+	if ctx.GlobalIsSet(RequestLogSampleFlag.Name) {
+		cfg.RequestLogSampleRate = ctx.GlobalUint(RequestLogSampleFlag.Name)
+	}
+	if ctx.GlobalIsSet(RequestLogParamsFlag.Name) {
+		cfg.RequestLogParams = splitAndTrim(ctx.GlobalString(RequestLogParamsFlag.Name))
+	}
 
 }
 
@@ -1657,6 +2082,21 @@ func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
 	}
 }
 
+// Quorum
+func setAccessLog(ctx *cli.Context, cfg *eth.Config) {
+	if !ctx.GlobalIsSet(AccessLogContractsFlag.Name) {
+		return
+	}
+	for _, contract := range splitAndTrim(ctx.GlobalString(AccessLogContractsFlag.Name)) {
+		if !common.IsHexAddress(contract) {
+			Fatalf("Invalid contract address in --%s: %s", AccessLogContractsFlag.Name, contract)
+		}
+		cfg.AccessLog.Contracts = append(cfg.AccessLog.Contracts, common.HexToAddress(contract))
+	}
+	cfg.AccessLog.SampleRate = ctx.GlobalUint(AccessLogSampleFlag.Name)
+	cfg.AccessLog.MaxPerSecond = ctx.GlobalUint(AccessLogMaxRateFlag.Name)
+}
+
 // Quorum
 func setIstanbul(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(IstanbulRequestTimeoutFlag.Name) {
@@ -1665,6 +2105,15 @@ func setIstanbul(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(IstanbulBlockPeriodFlag.Name) {
 		cfg.Istanbul.BlockPeriod = ctx.GlobalUint64(IstanbulBlockPeriodFlag.Name)
 	}
+	if ctx.GlobalIsSet(IstanbulExportDirFlag.Name) {
+		cfg.Istanbul.ExportDir = ctx.GlobalString(IstanbulExportDirFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulStandbyFlag.Name) {
+		cfg.Istanbul.Standby = ctx.GlobalBool(IstanbulStandbyFlag.Name)
+	}
+	if ctx.GlobalIsSet(IstanbulStandbyObservationWindowFlag.Name) {
+		cfg.Istanbul.StandbyObservationWindow = ctx.GlobalUint64(IstanbulStandbyObservationWindowFlag.Name)
+	}
 }
 
 func setRaft(ctx *cli.Context, cfg *eth.Config) {
@@ -1674,6 +2123,56 @@ func setRaft(ctx *cli.Context, cfg *eth.Config) {
 func setQuorumConfig(ctx *cli.Context, cfg *eth.Config) {
 	cfg.EVMCallTimeOut = time.Duration(ctx.GlobalInt(EVMCallTimeOutFlag.Name)) * time.Second
 	cfg.EnableMultitenancy = ctx.GlobalBool(MultitenancyFlag.Name)
+	cfg.ReadOnly = ctx.GlobalBool(ReadOnlyFlag.Name)
+	cfg.ReadOnlyHeadFile = ctx.GlobalString(ReadOnlyHeadFileFlag.Name)
+	cfg.ReadOnlyRefreshInterval = ctx.GlobalDuration(ReadOnlyRefreshFlag.Name)
+	cfg.PublishHeadFile = ctx.GlobalString(PublishHeadFileFlag.Name)
+	cfg.ReceiptVerifySampleRate = ctx.GlobalUint64(ReceiptVerifyFlag.Name)
+	cfg.ReceiptVerifyDegrade = ctx.GlobalBool(ReceiptVerifyDegradeFlag.Name)
+	cfg.InternalCallIndex = ctx.GlobalBool(InternalCallIndexFlag.Name)
+	cfg.BlockStats = ctx.GlobalBool(BlockStatsFlag.Name)
+	cfg.AddressTxIndex = ctx.GlobalBool(AddressTxIndexFlag.Name)
+	cfg.PrivatePayloadPrefetch = ctx.GlobalInt(QuorumPTMPrefetchFlag.Name)
+	cfg.PrivateHints = ctx.GlobalBool(QuorumPTMPrivateHintsFlag.Name)
+	if ctx.GlobalIsSet(PrivateAuditorsFlag.Name) {
+		for _, url := range splitAndTrim(ctx.GlobalString(PrivateAuditorsFlag.Name)) {
+			node, err := enode.ParseV4(url)
+			if err != nil {
+				Fatalf("Invalid auditor enode %q: %v", url, err)
+			}
+			cfg.PrivateAuditors = append(cfg.PrivateAuditors, node.ID())
+		}
+	}
+	cfg.PrivateAuditFetch = ctx.GlobalBool(PrivateAuditFetchFlag.Name)
+	cfg.PrivateParallelism = ctx.GlobalInt(PrivateParallelismFlag.Name)
+	cfg.SlowImportThreshold = ctx.GlobalDuration(SlowImportThresholdFlag.Name)
+	if ctx.GlobalIsSet(MaxReorgDepthFlag.Name) {
+		depth := ctx.GlobalUint64(MaxReorgDepthFlag.Name)
+		cfg.MaxReorgDepth = &depth
+	}
+	cfg.SlotPolicy = ctx.GlobalString(SlotPolicyFlag.Name)
+	cfg.SendDefaultsTTL = ctx.GlobalDuration(SendDefaultsTTLFlag.Name)
+	cfg.StorageLayouts = ctx.GlobalString(StorageLayoutsFlag.Name)
+	cfg.ConsistencySecret = ctx.GlobalString(ConsistencySecretFlag.Name)
+	cfg.ConsistencyWait = ctx.GlobalDuration(ConsistencyWaitFlag.Name)
+	cfg.SealPruning = ctx.GlobalBool(SealPruningFlag.Name)
+	cfg.SealPruningConfirmed = ctx.GlobalBool(SealPruningConfirmFlag.Name)
+	cfg.SealPruningDepth = ctx.GlobalUint64(SealPruningDepthFlag.Name)
+	cfg.SealPruningCheckpoints = ctx.GlobalUint64(SealPruningCheckpointsFlag.Name)
+	cfg.SubscriptionReplayBlocks = ctx.GlobalUint64(SubscriptionReplayBlocksFlag.Name)
+	cfg.SubscriptionReplaySize = ctx.GlobalInt(SubscriptionReplaySizeFlag.Name)
+	cfg.FilterQuota = filters.QuotaConfig{
+		MaxFilters:        ctx.GlobalInt(FilterQuotaFlag.Name),
+		MaxSubscriptions:  ctx.GlobalInt(SubscriptionQuotaFlag.Name),
+		LogsTimeout:       ctx.GlobalDuration(FilterLogsTimeoutFlag.Name),
+		BlocksTimeout:     ctx.GlobalDuration(FilterBlocksTimeoutFlag.Name),
+		PendingTxsTimeout: ctx.GlobalDuration(FilterPendingTxsTimeoutFlag.Name),
+	}
+	cfg.LogsBudget = filters.LogsBudget{
+		MaxResults: ctx.GlobalInt(LogsMaxResultsFlag.Name),
+		MaxBytes:   ctx.GlobalInt(LogsMaxBytesFlag.Name),
+	}
+	setAccessLog(ctx, cfg)
 	setIstanbul(ctx, cfg)
 	setRaft(ctx, cfg)
 }
@@ -1757,6 +2256,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 
 	// Quorum
 	setQuorumConfig(ctx, cfg)
+	if ctx.GlobalBool(LogsSpillFlag.Name) {
+		cfg.LogsBudget.SpillDir = stack.ResolvePath("logspill")
+	}
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
@@ -2013,12 +2515,13 @@ func RegisterPermissionService(stack *node.Node, useDns bool) {
 	log.Info("permission service registered")
 }
 
-func RegisterRaftService(stack *node.Node, ctx *cli.Context, nodeCfg *node.Config, ethService *eth.Ethereum) {
+func RegisterRaftService(stack *node.Node, ctx *cli.Context, nodeCfg *node.Config, ethService *eth.Ethereum) *raft.RaftService {
 	blockTimeMillis := ctx.GlobalInt(RaftBlockTimeFlag.Name)
 	datadir := ctx.GlobalString(DataDirFlag.Name)
 	joinExistingId := ctx.GlobalInt(RaftJoinExistingFlag.Name)
 	useDns := ctx.GlobalBool(RaftDNSEnabledFlag.Name)
 	raftPort := uint16(ctx.GlobalInt(RaftPortFlag.Name))
+	learnerMaxLag := ctx.GlobalUint64(RaftLearnerMaxLagFlag.Name)
 
 	privkey := nodeCfg.NodeKey()
 	strId := enode.PubkeyToIDV4(&privkey.PublicKey).String()
@@ -2053,16 +2556,18 @@ func RegisterRaftService(stack *node.Node, ctx *cli.Context, nodeCfg *node.Confi
 		}
 	}
 
-	_, err := raft.New(stack, ethService.BlockChain().Config(), myId, raftPort, joinExisting, blockTimeNanos, ethService, peers, datadir, useDns)
+	raftService, err := raft.New(stack, ethService.BlockChain().Config(), myId, raftPort, joinExisting, blockTimeNanos, ethService, peers, datadir, useDns, learnerMaxLag)
 	if err != nil {
 		Fatalf("raft: Failed to register the Raft service: %v", err)
 	}
 
 	log.Info("raft service registered")
+	return raftService
 }
 
-func RegisterExtensionService(stack *node.Node, ethService *eth.Ethereum) {
-	_, err := extension.NewServicesFactory(stack, private.P, ethService)
+func RegisterExtensionService(stack *node.Node, ctx *cli.Context, ethService *eth.Ethereum) {
+	config := extension.Config{AutoResend: ctx.GlobalBool(ExtensionAutoResendFlag.Name)}
+	_, err := extension.NewServicesFactory(stack, config, private.P, ethService)
 	if err != nil {
 		Fatalf("Failed to register the Extension service: %v", err)
 	}
@@ -2070,6 +2575,23 @@ func RegisterExtensionService(stack *node.Node, ethService *eth.Ethereum) {
 	log.Info("extension service registered")
 }
 
+// RegisterHealthService serves the readiness and liveness endpoints of the node,
+// raftService being nil if not in raft mode
+func RegisterHealthService(stack *node.Node, ctx *cli.Context, ethService *eth.Ethereum, raftService *raft.RaftService, istanbulCfg istanbul.Config) {
+	config := health.Config{
+		ReadyPath:   ctx.GlobalString(HealthReadyPathFlag.Name),
+		LivePath:    ctx.GlobalString(HealthLivePathFlag.Name),
+		RequireAuth: ctx.GlobalBool(HealthAuthFlag.Name),
+		Components:  splitAndTrim(ctx.GlobalString(HealthComponentsFlag.Name)),
+		BlockPeriod: time.Duration(istanbulCfg.BlockPeriod) * time.Second,
+	}
+	if _, err := health.New(stack, ethService, raftService, config); err != nil {
+		Fatalf("Failed to register the health service: %v", err)
+	}
+
+	log.Info("health service registered")
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -2186,6 +2708,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readOnly bool, useExist bool)
 		}
 		istanbulConfig.ProposerPolicy = istanbul.ProposerPolicy(config.Istanbul.ProposerPolicy)
 		istanbulConfig.Ceil2Nby3Block = config.Istanbul.Ceil2Nby3Block
+		istanbulConfig.KeyRotationBlock = config.Istanbul.KeyRotationBlock
 		engine = istanbulBackend.New(istanbulConfig, stack.GetNodeKey(), chainDb)
 	} else if config.IsQuorum {
 		// for Raft
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+)
+
+// Quorum
+//
+// The GraphQL scalars are decoded with errors telling apart a wrong length,
+// invalid hex and, in strict checksum mode, mixed-case addresses which are not
+// EIP-55 checksummed, as the EIP-55 checksum is the same on every chain.
+// Addresses can also be given in the direct ICAP form.
+
+// strictGraphQLChecksum is 1 if mixed-case GraphQL addresses must be EIP-55
+// checksummed.
+var strictGraphQLChecksum uint32
+
+// SetGraphQLStrictChecksum sets whether mixed-case addresses of GraphQL inputs
+// failing the EIP-55 checksum are rejected rather than accepted as is.
+// All-lowercase and all-uppercase addresses are accepted either way.
+func SetGraphQLStrictChecksum(strict bool) {
+	var value uint32
+	if strict {
+		value = 1
+	}
+	atomic.StoreUint32(&strictGraphQLChecksum, value)
+}
+
+// parseGraphQLHex decodes the 0x-prefixed hex input of the GraphQL scalar of
+// the given length in bytes.
+func parseGraphQLHex(scalar, input string, length int) ([]byte, error) {
+	if !has0xPrefix(input) {
+		return nil, fmt.Errorf("invalid %s %q: missing 0x prefix", scalar, input)
+	}
+	digits := input[2:]
+	for i := 0; i < len(digits); i++ {
+		if !isHexCharacter(digits[i]) {
+			return nil, fmt.Errorf("invalid %s %q: invalid hex character %q at position %d", scalar, input, digits[i], i+2)
+		}
+	}
+	if len(digits) != 2*length {
+		return nil, fmt.Errorf("invalid %s %q: got %d hex digits, want %d for %d bytes", scalar, input, len(digits), 2*length, length)
+	}
+	return Hex2Bytes(digits), nil
+}
+
+// parseGraphQLAddress decodes an address given as hex or in the direct ICAP
+// form, checking the EIP-55 checksum of mixed-case hex in strict mode.
+func parseGraphQLAddress(input string) (Address, error) {
+	if len(input) >= 2 && strings.EqualFold(input[:2], "XE") {
+		return parseDirectICAP(input)
+	}
+	b, err := parseGraphQLHex("Address", input, AddressLength)
+	if err != nil {
+		return Address{}, err
+	}
+	address := BytesToAddress(b)
+	digits := input[2:]
+	mixedCase := strings.ToLower(digits) != digits && strings.ToUpper(digits) != digits
+	if mixedCase && atomic.LoadUint32(&strictGraphQLChecksum) == 1 && digits != address.Hex()[2:] {
+		return Address{}, fmt.Errorf("invalid Address %q: EIP-55 checksum mismatch, did you mean %s?", input, address.Hex())
+	}
+	return address, nil
+}
+
+// parseDirectICAP decodes a direct ICAP address: XE, two check digits and the
+// address in base 36. Indirect ICAP addresses are resolved through a registry
+// and are not supported.
+func parseDirectICAP(input string) (Address, error) {
+	icap := strings.ToUpper(input)
+	if len(icap) != 34 && len(icap) != 35 {
+		return Address{}, fmt.Errorf("invalid Address %q: only direct ICAP addresses of 34 or 35 characters are supported", input)
+	}
+	// IBAN check digits: the code moved to the end, taken as a number with the
+	// letters as 10 to 35, is 1 modulo 97
+	mod := 0
+	for _, c := range icap[4:] + icap[:4] {
+		switch {
+		case '0' <= c && c <= '9':
+			mod = (mod*10 + int(c-'0')) % 97
+		case 'A' <= c && c <= 'Z':
+			mod = (mod*100 + int(c-'A'+10)) % 97
+		default:
+			return Address{}, fmt.Errorf("invalid Address %q: invalid ICAP character %q", input, c)
+		}
+	}
+	if mod != 1 {
+		return Address{}, fmt.Errorf("invalid Address %q: ICAP check digits mismatch", input)
+	}
+	number, ok := new(big.Int).SetString(icap[4:], 36)
+	if !ok || number.BitLen() > 8*AddressLength {
+		return Address{}, fmt.Errorf("invalid Address %q: ICAP number larger than an address", input)
+	}
+	return BigToAddress(number), nil
+}
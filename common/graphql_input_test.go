@@ -0,0 +1,74 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checksummedAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+func TestAddressUnmarshalGraphQL(t *testing.T) {
+	expected := HexToAddress(checksummedAddress)
+	for _, input := range []string{
+		checksummedAddress,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+		"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", // checksum mismatch accepted out of strict mode
+	} {
+		var a Address
+		assert.NoError(t, a.UnmarshalGraphQL(input), input)
+		assert.Equal(t, expected, a, input)
+	}
+
+	for input, message := range map[string]string{
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed":     `invalid Address "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed": missing 0x prefix`,
+		"0x5aaeb6053f3e94c9":                           `invalid Address "0x5aaeb6053f3e94c9": got 16 hex digits, want 40 for 20 bytes`,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaedaa": `invalid Address "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaedaa": got 42 hex digits, want 40 for 20 bytes`,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaez":   `invalid Address "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaez": invalid hex character 'z' at position 41`,
+	} {
+		var a Address
+		assert.EqualError(t, a.UnmarshalGraphQL(input), message)
+	}
+	var a Address
+	assert.EqualError(t, a.UnmarshalGraphQL(1), "unexpected type int for Address")
+}
+
+func TestAddressUnmarshalGraphQL_StrictChecksum(t *testing.T) {
+	SetGraphQLStrictChecksum(true)
+	defer SetGraphQLStrictChecksum(false)
+
+	var a Address
+	assert.EqualError(t, a.UnmarshalGraphQL("0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"),
+		`invalid Address "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed": EIP-55 checksum mismatch, did you mean `+checksummedAddress+`?`)
+	for _, input := range []string{
+		checksummedAddress,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+	} {
+		assert.NoError(t, a.UnmarshalGraphQL(input), input)
+	}
+}
+
+func TestAddressUnmarshalGraphQL_ICAP(t *testing.T) {
+	var a Address
+	require.NoError(t, a.UnmarshalGraphQL("XE7338O073KYGTWWZN0F2WZ0R8PX5ZPPZS"))
+	assert.Equal(t, HexToAddress("0x00c5496aee77c1ba1f0854206a26dda82a81d6d8"), a)
+
+	assert.EqualError(t, a.UnmarshalGraphQL("XE7438O073KYGTWWZN0F2WZ0R8PX5ZPPZS"),
+		`invalid Address "XE7438O073KYGTWWZN0F2WZ0R8PX5ZPPZS": ICAP check digits mismatch`)
+	assert.EqualError(t, a.UnmarshalGraphQL("XE81ETHXREGGAVOFYORK"),
+		`invalid Address "XE81ETHXREGGAVOFYORK": only direct ICAP addresses of 34 or 35 characters are supported`)
+}
+
+func TestHashUnmarshalGraphQL(t *testing.T) {
+	input := "0x" + "ab" + "00000000000000000000000000000000000000000000000000000000000012"
+	var h Hash
+	require.NoError(t, h.UnmarshalGraphQL(input))
+	assert.Equal(t, HexToHash(input), h)
+
+	assert.EqualError(t, h.UnmarshalGraphQL("0x1234"), `invalid Bytes32 "0x1234": got 4 hex digits, want 64 for 32 bytes`)
+	assert.EqualError(t, h.UnmarshalGraphQL("0xg234"), `invalid Bytes32 "0xg234": invalid hex character 'g' at position 2`)
+	assert.EqualError(t, h.UnmarshalGraphQL("1234"), `invalid Bytes32 "1234": missing 0x prefix`)
+}
@@ -20,19 +20,29 @@ func CreateClient(cfg Config) (*engine.Client, error) {
 			},
 			BaseURL: "http+unix://c",
 		}
+		// Quorum
+		if len(cfg.HttpUrl) > 0 {
+			transport, err := tlsTransport(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create http.client to private tx manager due to: %s", err)
+			}
+			log.Info("Failing over to private tx manager HTTP endpoint when IPC socket is unavailable", "url", cfg.HttpUrl, "tls", cfg.TlsMode)
+			client.HttpClient = &http.Client{
+				Timeout:   time.Duration(cfg.Timeout) * time.Second,
+				Transport: NewFailover(client.HttpClient.Transport, client.BaseURL, transport, cfg.HttpUrl),
+			}
+		}
 
 	} else {
 
-		transport := httpTransport(cfg)
 		if cfg.TlsMode == TlsOff {
 			log.Info("Connecting to private tx manager using HTTP")
 		} else {
 			log.Info("Connecting to private tx manager using HTTPS")
-			tlsConfig, err := newTLSConfig(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("unable to create http.client to private tx manager due to: %s", err)
-			}
-			transport.TLSClientConfig = tlsConfig
+		}
+		transport, err := tlsTransport(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create http.client to private tx manager due to: %s", err)
 		}
 
 		client = &engine.Client{
@@ -42,8 +52,17 @@ func CreateClient(cfg Config) (*engine.Client, error) {
 			},
 			BaseURL: cfg.HttpUrl,
 		}
+		// Quorum
+		if len(cfg.HttpUrls) > 0 {
+			log.Info("Routing private tx manager requests by latency", "endpoints", len(cfg.HttpUrls)+1)
+			client.HttpClient.Transport = NewRouter(append([]string{cfg.HttpUrl}, cfg.HttpUrls...), transport, time.Duration(cfg.ProbeInterval)*time.Second)
+		}
 
 	}
+	// Quorum
+	if cfg.RetryAttempts > 1 {
+		client.HttpClient.Transport = NewRetry(client.HttpClient.Transport, int(cfg.RetryAttempts), time.Duration(cfg.RetryBackoff)*time.Millisecond)
+	}
 
 	return client, nil
 }
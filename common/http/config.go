@@ -24,7 +24,7 @@ type Config struct {
 	ConnectionType        string `toml:"-"` // connection type is not loaded from toml
 	Socket                string // filename for unix domain socket
 	WorkDir               string // directory for unix domain socket
-	HttpUrl               string // transaction manager URL for HTTP connection
+	HttpUrl               string // transaction manager URL for HTTP connection, failed over to if the unix domain socket is unavailable
 	Timeout               uint   // timeout for overall client call (seconds), zero means timeout disabled
 	DialTimeout           uint   // timeout for connecting to unix socket (seconds)
 	HttpIdleConnTimeout   uint   // timeout for idle http connection (seconds), zero means timeout disabled
@@ -35,6 +35,19 @@ type Config struct {
 	TlsClientCert         string // path to file containing client certificate (or chain of certs)
 	TlsClientKey          string // path to file containing client's private key
 	TlsInsecureSkipVerify bool   // if true then does not verify that server certificate is CA signed
+
+	// Quorum
+	//
+	// further URLs of a clustered transaction manager, e.g. in other regions,
+	// the requests being routed to the endpoints by latency and health
+	HttpUrls      []string
+	ProbeInterval uint // interval (seconds) between the latency probes of the endpoints
+
+	// attempts of a call, retried on connection errors and 5xx statuses with
+	// an exponential backoff from RetryBackoff (milliseconds), 0 or 1 meaning
+	// no retry
+	RetryAttempts uint
+	RetryBackoff  uint
 }
 
 var NoConnectionConfig = Config{
@@ -47,6 +60,9 @@ var DefaultConfig = Config{
 	DialTimeout:         1,
 	HttpIdleConnTimeout: 10,
 	TlsMode:             TlsOff,
+	ProbeInterval:       5,
+	RetryAttempts:       3,
+	RetryBackoff:        100,
 }
 
 func IsSocketConfigured(cfg Config) bool {
@@ -116,8 +132,12 @@ func (cfg *Config) Validate() error {
 		if len(cfg.Socket) == 0 { //sanity check - should never occur
 			return fmt.Errorf("ipc file configuration is missing for private transaction manager connection")
 		}
+		if len(cfg.HttpUrls) != 0 {
+			return fmt.Errorf("HTTP URLs and unix ipc file cannot both be specified for private transaction manager connection")
+		}
+		// Quorum - the HTTP URL is the fallback of the socket
 		if len(cfg.HttpUrl) != 0 {
-			return fmt.Errorf("HTTP URL and unix ipc file cannot both be specified for private transaction manager connection")
+			return cfg.validateTls()
 		}
 		if cfg.TlsMode != TlsOff {
 			return fmt.Errorf("TLS is not supported over unix domain socket for private transaction manager connection")
@@ -129,21 +149,28 @@ func (cfg *Config) Validate() error {
 		if len(cfg.HttpUrl) == 0 { //sanity check - should never occur
 			return fmt.Errorf("URL configuration is missing for private transaction manager HTTP connection")
 		}
-		switch cfg.TlsMode {
-		case TlsOff:
-			//no action needed
-		case TlsStrict:
-			if !strings.Contains(strings.ToLower(cfg.HttpUrl), "https") {
+		return cfg.validateTls()
+	}
+
+	return nil
+}
+
+func (cfg *Config) validateTls() error {
+	switch cfg.TlsMode {
+	case TlsOff:
+		//no action needed
+	case TlsStrict:
+		for _, httpUrl := range append([]string{cfg.HttpUrl}, cfg.HttpUrls...) {
+			if !strings.Contains(strings.ToLower(httpUrl), "https") {
 				return fmt.Errorf("connection is configured with TLS but HTTPS url is not specified")
 			}
-			if (len(cfg.TlsClientCert) == 0 && len(cfg.TlsClientKey) != 0) || (len(cfg.TlsClientCert) != 0 && len(cfg.TlsClientKey) == 0) {
-				return fmt.Errorf("invalid details for HTTP connection with TLS, configuration must specify both clientCert and clientKey, or neither one")
-			}
-		default:
-			return fmt.Errorf("invalid value for TLS mode in config file, must be either OFF or STRICT")
 		}
+		if (len(cfg.TlsClientCert) == 0 && len(cfg.TlsClientKey) != 0) || (len(cfg.TlsClientCert) != 0 && len(cfg.TlsClientKey) == 0) {
+			return fmt.Errorf("invalid details for HTTP connection with TLS, configuration must specify both clientCert and clientKey, or neither one")
+		}
+	default:
+		return fmt.Errorf("invalid value for TLS mode in config file, must be either OFF or STRICT")
 	}
-
 	return nil
 }
 
@@ -160,11 +187,19 @@ func (cfg *Config) SetSocket(socketPath string) {
 	cfg.Socket = socketFilename
 }
 
+// SetHttpUrl sets the URL of the HTTP connection, which is the fallback of the
+// unix domain socket if one is set.
 func (cfg *Config) SetHttpUrl(httpUrl string) {
-	cfg.ConnectionType = HttpConnection
+	if cfg.ConnectionType != UnixDomainSocketConnection {
+		cfg.ConnectionType = HttpConnection
+	}
 	cfg.HttpUrl = httpUrl
 }
 
+func (cfg *Config) SetHttpUrls(httpUrls []string) {
+	cfg.HttpUrls = httpUrls
+}
+
 func (cfg *Config) SetTimeout(timeout uint) {
 	cfg.Timeout = timeout
 }
@@ -201,6 +236,14 @@ func (cfg *Config) SetTlsClientKey(tlsClientKey string) {
 	cfg.TlsClientKey = tlsClientKey
 }
 
+func (cfg *Config) SetRetryAttempts(retryAttempts uint) {
+	cfg.RetryAttempts = retryAttempts
+}
+
+func (cfg *Config) SetRetryBackoff(retryBackoff uint) {
+	cfg.RetryBackoff = retryBackoff
+}
+
 func (cfg *Config) SetTlsInsecureSkipVerify(tlsInsecureSkipVerify bool) {
 	cfg.TlsInsecureSkipVerify = tlsInsecureSkipVerify
 }
@@ -83,11 +83,23 @@ tlsRootCA = "mydir/rootca.cert.pem"
 tlsClientCert = "mydir/client.cert.pem"
 tlsClientKey = "mydir/client.key.pem"
 `
-var invalidConfigWithSocketAndHttp = `
+var configWithSocketAndHttp = `
 socket = "tm.ipc"
 workdir = "qdata/c1"
 httpUrl = "http:localhost:9101"
 `
+var configWithSocketAndHttpTls = `
+socket = "tm.ipc"
+workdir = "qdata/c1"
+httpUrl = "https:localhost:9101"
+tlsMode = "strict"
+tlsRootCA = "mydir/rootca.cert.pem"
+`
+var invalidConfigWithSocketAndTls = `
+socket = "tm.ipc"
+workdir = "qdata/c1"
+tlsMode = "strict"
+`
 var invalidConfigWithNoSocketOrHttp = `
 `
 
@@ -312,9 +324,27 @@ func TestTlsWithHTTPUrlOnly(t *testing.T) {
 	}
 }
 
-func TestSocketWithHTTPNotAllowed(t *testing.T) {
-	configFile := filepath.Join(os.TempDir(), "invalidConfigWithSocketAndHttp.toml")
-	if err := ioutil.WriteFile(configFile, []byte(invalidConfigWithSocketAndHttp), 0600); err != nil {
+func TestSocketWithHTTPFallback(t *testing.T) {
+	for name, config := range map[string]string{
+		"configWithSocketAndHttp":    configWithSocketAndHttp,
+		"configWithSocketAndHttpTls": configWithSocketAndHttpTls,
+	} {
+		configFile := filepath.Join(os.TempDir(), name+".toml")
+		if err := ioutil.WriteFile(configFile, []byte(config), 0600); err != nil {
+			t.Fatalf("Failed to create config file for unit test, error: %v", err)
+		}
+		defer os.Remove(configFile)
+
+		cfg, err := FetchConfig(configFile)
+		assert.NoError(t, err)
+		assert.Equal(t, UnixDomainSocketConnection, cfg.ConnectionType, name)
+		assert.NoError(t, cfg.Validate(), name)
+	}
+}
+
+func TestSocketWithTlsNotAllowed(t *testing.T) {
+	configFile := filepath.Join(os.TempDir(), "invalidConfigWithSocketAndTls.toml")
+	if err := ioutil.WriteFile(configFile, []byte(invalidConfigWithSocketAndTls), 0600); err != nil {
 		t.Fatalf("Failed to create config file for unit test, error: %v", err)
 	}
 	defer os.Remove(configFile)
@@ -324,7 +354,15 @@ func TestSocketWithHTTPNotAllowed(t *testing.T) {
 
 	err = cfg.Validate()
 	if assert.Error(t, err) {
-		assert.Contains(t, err.Error(), "HTTP URL and unix ipc file cannot both be specified for private transaction manager connection")
+		assert.Contains(t, err.Error(), "TLS is not supported over unix domain socket for private transaction manager connection")
+	}
+
+	// the fallback must be HTTPS
+	cfg.SetHttpUrl("http://localhost:9101")
+	assert.Equal(t, UnixDomainSocketConnection, cfg.ConnectionType)
+	err = cfg.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "connection is configured with TLS but HTTPS url is not specified")
 	}
 }
 
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	failoverMinBackoff = time.Second      // delay before the first upcheck of the socket after failing over
+	failoverMaxBackoff = 30 * time.Second // longest delay between the upchecks of the socket
+	failoverUpcheck    = time.Second      // timeout of the upchecks of the socket
+)
+
+// Failover is a http.RoundTripper sending the requests over the unix domain
+// socket of the transaction manager, failing over to its HTTP(S) endpoint as
+// soon as the socket is unavailable. While failed over, the upcheck of the
+// socket is retried with an exponential backoff and the requests fail back to
+// the socket once it answers again.
+type Failover struct {
+	socket      http.RoundTripper
+	socketURL   string // base URL of the requests over the socket
+	fallback    http.RoundTripper
+	fallbackURL string
+
+	minBackoff, maxBackoff time.Duration
+
+	lock       sync.Mutex
+	failedOver bool
+	backoff    time.Duration // delay before the next upcheck of the socket
+	retryAt    time.Time     // time of the next upcheck of the socket
+}
+
+// NewFailover creates a failover from the socket, the requests being made to
+// its base URL, to the fallback URL.
+func NewFailover(socket http.RoundTripper, socketURL string, fallback http.RoundTripper, fallbackURL string) *Failover {
+	return &Failover{
+		socket:      socket,
+		socketURL:   strings.TrimSuffix(socketURL, "/"),
+		fallback:    fallback,
+		fallbackURL: strings.TrimSuffix(fallbackURL, "/"),
+		minBackoff:  failoverMinBackoff,
+		maxBackoff:  failoverMaxBackoff,
+	}
+}
+
+// FailedOver returns whether the requests are sent to the fallback URL.
+func (f *Failover) FailedOver() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.failedOver
+}
+
+// RoundTrip implements http.RoundTripper.
+func (f *Failover) RoundTrip(req *http.Request) (*http.Response, error) {
+	if f.useSocket(req.Context()) {
+		res, err := f.socket.RoundTrip(req)
+		if err == nil {
+			return res, nil
+		}
+		// the body of the failed attempt has been consumed, or the caller gave up
+		if (req.Body != nil && req.GetBody == nil) || req.Context().Err() != nil {
+			return nil, err
+		}
+		f.failOver(err)
+	}
+
+	attempt := req.Clone(req.Context())
+	if path := strings.TrimPrefix(req.URL.String(), f.socketURL); path != req.URL.String() {
+		url, err := req.URL.Parse(f.fallbackURL + path)
+		if err != nil {
+			return nil, err
+		}
+		attempt.URL, attempt.Host = url, ""
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attempt.Body = body
+	}
+	return f.fallback.RoundTrip(attempt)
+}
+
+// useSocket returns whether to send a request over the socket, checking it is
+// up again if failed over and its retry is due.
+func (f *Failover) useSocket(ctx context.Context) bool {
+	f.lock.Lock()
+	if !f.failedOver {
+		f.lock.Unlock()
+		return true
+	}
+	if time.Now().Before(f.retryAt) {
+		f.lock.Unlock()
+		return false
+	}
+	// hold the next retry back while this one is under way
+	f.retryAt = time.Now().Add(f.backoff)
+	f.lock.Unlock()
+
+	err := f.upcheck(ctx)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.failedOver {
+		return true
+	}
+	if err != nil {
+		if f.backoff *= 2; f.backoff > f.maxBackoff {
+			f.backoff = f.maxBackoff
+		}
+		f.retryAt = time.Now().Add(f.backoff)
+		log.Debug("Private transaction manager unix socket is still unavailable", "err", err, "retry", f.backoff)
+		return false
+	}
+	f.failedOver = false
+	log.Info("Private transaction manager unix socket is available again, failing back", "socket", f.socketURL)
+	return true
+}
+
+// failOver sends the requests to the fallback URL after the failure of one over
+// the socket.
+func (f *Failover) failOver(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.failedOver {
+		return
+	}
+	f.failedOver = true
+	f.backoff = f.minBackoff
+	f.retryAt = time.Now().Add(f.backoff)
+	log.Warn("Private transaction manager unix socket is unavailable, failing over", "url", f.fallbackURL, "err", err, "retry", f.backoff)
+}
+
+// upcheck checks the transaction manager answers over the socket.
+func (f *Failover) upcheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, failoverUpcheck)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", f.socketURL+"/upcheck", nil)
+	if err != nil {
+		return err
+	}
+	res, err := f.socket.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("upcheck status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUnixEchoServer returns an echo server listening on the unix domain socket.
+func newUnixEchoServer(t *testing.T, name, socket string) *httptest.Server {
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	s := httptest.NewUnstartedServer(echoHandler(name, http.StatusOK))
+	s.Listener = l
+	s.Start()
+	return s
+}
+
+func post(t *testing.T, client *http.Client, url, body string) string {
+	res, err := client.Post(url, "text/plain", strings.NewReader(body))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	reply, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(reply)
+}
+
+func TestFailover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "failover")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := newUnixEchoServer(t, "socket", filepath.Join(dir, "tm.ipc"))
+	defer socket.Close()
+	https := httptest.NewTLSServer(echoHandler("https", http.StatusOK))
+	defer https.Close()
+
+	cfg := DefaultConfig
+	cfg.SetSocket(filepath.Join(dir, "tm.ipc"))
+	cfg.SetHttpUrl(https.URL)
+	cfg.TlsMode = TlsStrict
+	cfg.TlsInsecureSkipVerify = true
+	require.NoError(t, cfg.Validate())
+	client, err := CreateClient(cfg)
+	require.NoError(t, err)
+	retry, ok := client.HttpClient.Transport.(*Retry)
+	require.True(t, ok)
+	failover, ok := retry.next.(*Failover)
+	require.True(t, ok)
+	failover.minBackoff = 20 * time.Millisecond
+
+	assert.Equal(t, "socket:/send:a", post(t, client.HttpClient, client.FullPath("/send"), "a"))
+	assert.False(t, failover.FailedOver())
+
+	// the body of the request failed over the socket is replayed
+	socket.Close()
+	assert.Equal(t, "https:/send:b", post(t, client.HttpClient, client.FullPath("/send"), "b"))
+	assert.True(t, failover.FailedOver())
+
+	// the socket is retried with a backoff
+	time.Sleep(failover.minBackoff)
+	assert.Equal(t, "https:/send:c", post(t, client.HttpClient, client.FullPath("/send"), "c"))
+	failover.lock.Lock()
+	assert.Equal(t, 2*failover.minBackoff, failover.backoff)
+	failover.lock.Unlock()
+
+	socket = newUnixEchoServer(t, "socket", filepath.Join(dir, "tm.ipc"))
+	defer socket.Close()
+	assert.Equal(t, "https:/send:d", post(t, client.HttpClient, client.FullPath("/send"), "d"), "retry not due")
+	assert.Eventually(t, func() bool {
+		return post(t, client.HttpClient, client.FullPath("/send"), "e") == "socket:/send:e"
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, failover.FailedOver())
+}
+
+func TestFailover_backoffCapped(t *testing.T) {
+	f := NewFailover(http.DefaultTransport, "http+unix://c", http.DefaultTransport, "http://localhost")
+	f.failOver(assert.AnError)
+	f.backoff, f.retryAt = f.maxBackoff, time.Time{}
+
+	// the socket transport does not know the scheme
+	assert.False(t, f.useSocket(context.Background()))
+	assert.Equal(t, f.maxBackoff, f.backoff)
+}
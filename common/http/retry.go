@@ -0,0 +1,68 @@
+package http
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const retryMaxBackoff = 5 * time.Second // longest delay between the attempts of a request
+
+// Retry is a http.RoundTripper retrying the requests to the transaction manager
+// which fail with a connection error or a 5xx status, e.g. while it restarts,
+// with an exponential backoff. The 4xx statuses are never retried, and the
+// retries stop as soon as the context of the request is done.
+type Retry struct {
+	next     http.RoundTripper
+	attempts int
+	backoff  time.Duration // delay before the first retry
+}
+
+// NewRetry creates a retry of the requests of the transport, making at most
+// the attempts.
+func NewRetry(next http.RoundTripper, attempts int, backoff time.Duration) *Retry {
+	return &Retry{next: next, attempts: attempts, backoff: backoff}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, backoff := req.Context(), r.backoff
+	attempt := req
+	for i := 1; ; i++ {
+		res, err := r.next.RoundTrip(attempt)
+		retriable := err != nil || res.StatusCode >= http.StatusInternalServerError
+		// the body of the failed attempt has been consumed, or the caller gave up
+		if !retriable || i >= r.attempts || (req.Body != nil && req.GetBody == nil) || ctx.Err() != nil {
+			return res, err
+		}
+		if err == nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+			log.Debug("Retrying private transaction manager request", "url", req.URL, "status", res.StatusCode, "attempt", i, "backoff", backoff)
+		} else {
+			log.Debug("Retrying private transaction manager request", "url", req.URL, "err", err, "attempt", i, "backoff", backoff)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+		attempt = req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingServer answers the status to the first failures requests, then
+// echoes them.
+func failingServer(failures int32, status int) (*httptest.Server, *int32) {
+	var attempts int32
+	echo := echoHandler("tm", http.StatusOK)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		echo.ServeHTTP(w, r)
+	})), &attempts
+}
+
+func newRetryClient(t *testing.T, url string, attempts uint) *http.Client {
+	cfg := DefaultConfig
+	cfg.SetHttpUrl(url)
+	cfg.SetRetryAttempts(attempts)
+	cfg.SetRetryBackoff(1)
+	client, err := CreateClient(cfg)
+	require.NoError(t, err)
+	return client.HttpClient
+}
+
+func TestRetry(t *testing.T) {
+	server, attempts := failingServer(2, http.StatusBadGateway)
+	defer server.Close()
+
+	// the body is replayed on each attempt
+	assert.Equal(t, "tm:/send:payload", post(t, newRetryClient(t, server.URL, 3), server.URL+"/send", "payload"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(attempts))
+}
+
+func TestRetry_exhausted(t *testing.T) {
+	server, attempts := failingServer(3, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	res, err := newRetryClient(t, server.URL, 3).Post(server.URL+"/send", "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(attempts))
+}
+
+func TestRetry_clientErrorsNotRetried(t *testing.T) {
+	server, attempts := failingServer(1, http.StatusNotFound)
+	defer server.Close()
+
+	res, err := newRetryClient(t, server.URL, 3).Post(server.URL+"/send", "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+}
+
+func TestRetry_deadline(t *testing.T) {
+	server, attempts := failingServer(2, http.StatusInternalServerError)
+	defer server.Close()
+	client := &http.Client{Transport: NewRetry(http.DefaultTransport, 3, time.Hour)}
+
+	// the caller giving up during the backoff stops the retries
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/send", strings.NewReader("payload"))
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = client.Do(req)
+	assert.True(t, strings.HasSuffix(err.Error(), context.DeadlineExceeded.Error()), err.Error())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+
+	// the bodies which cannot be replayed are not retried
+	req, err = http.NewRequest(http.MethodPost, server.URL+"/send", ioutil.NopCloser(strings.NewReader("payload")))
+	require.NoError(t, err)
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+}
@@ -0,0 +1,347 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// defaultProbeInterval is the interval between the latency probes when the
+	// configuration has none
+	defaultProbeInterval = 5 * time.Second
+
+	latencySmoothing   = 0.3 // weight of the last probe in the latency of an endpoint
+	errorRateSmoothing = 0.2 // weight of the last outcome in the error rate of an endpoint
+)
+
+var errUnknownEndpoint = errors.New("unknown private transaction manager endpoint")
+
+// endpoint is an endpoint of a clustered transaction manager.
+type endpoint struct {
+	url string
+
+	latency   time.Duration // smoothed round trip time of the probes, zero until probed
+	errorRate float64       // smoothed rate of failed probes and requests
+	healthy   bool          // whether the endpoint answered the last probe or request
+	lastError string
+
+	requests  uint64 // requests routed to the endpoint first
+	failures  uint64 // requests which failed on the endpoint
+	fallbacks uint64 // requests served by the endpoint after the failure of another
+}
+
+// weight is the share of the traffic of the endpoint before normalisation,
+// inversely proportional to its latency and penalising its errors.
+func (e *endpoint) weight() float64 {
+	if !e.healthy {
+		return 0
+	}
+	latency := e.latency
+	if latency < time.Millisecond {
+		latency = time.Millisecond
+	}
+	health := 1 - e.errorRate
+	return health * health / latency.Seconds()
+}
+
+// record accounts a probe or request outcome.
+func (e *endpoint) record(err error) {
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		e.lastError = err.Error()
+	}
+	e.errorRate = (1-errorRateSmoothing)*e.errorRate + errorRateSmoothing*failed
+	e.healthy = err == nil
+}
+
+// EndpointStats is the routing state of an endpoint of a clustered transaction
+// manager.
+type EndpointStats struct {
+	URL       string  `json:"url"`
+	LatencyMs float64 `json:"latencyMs"` // smoothed round trip time of the probes
+	ErrorRate float64 `json:"errorRate"`
+	Healthy   bool    `json:"healthy"`
+	Share     float64 `json:"share"` // share of the new requests routed to the endpoint
+	Pinned    bool    `json:"pinned"`
+	Requests  uint64  `json:"requests"`
+	Failures  uint64  `json:"failures"`
+	Fallbacks uint64  `json:"fallbacks"`
+	LastError string  `json:"lastError,omitempty"`
+}
+
+// Router is a http.RoundTripper routing the requests to the endpoints of a
+// clustered transaction manager. Endpoints are picked at random in proportion
+// to their weight, requests failing on one being retried at once on the next
+// best one. The latency of the endpoints is measured by probing their upcheck
+// periodically.
+type Router struct {
+	transport http.RoundTripper
+	interval  time.Duration
+
+	lock      sync.Mutex
+	endpoints []*endpoint // the first one is the base URL of the client
+	pinned    *endpoint   // endpoint all the requests are routed to, for debugging
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRouter creates a router between the URLs, the first one being the base
+// URL of the requests.
+func NewRouter(urls []string, transport http.RoundTripper, interval time.Duration) *Router {
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	r := &Router{transport: transport, interval: interval}
+	for _, url := range urls {
+		// endpoints are healthy until probed
+		r.endpoints = append(r.endpoints, &endpoint{url: strings.TrimSuffix(url, "/"), healthy: true})
+	}
+	return r
+}
+
+// Start probes the endpoints, then keeps probing them in the background. It
+// implements node.Lifecycle.
+func (r *Router) Start() error {
+	r.lock.Lock()
+	if r.quit != nil {
+		r.lock.Unlock()
+		return nil
+	}
+	r.quit = make(chan struct{})
+	r.wg.Add(1)
+	go r.loop(r.quit)
+	r.lock.Unlock()
+
+	r.probe()
+	return nil
+}
+
+// Stop stops the probes. It implements node.Lifecycle.
+func (r *Router) Stop() error {
+	r.lock.Lock()
+	if r.quit == nil {
+		r.lock.Unlock()
+		return nil
+	}
+	close(r.quit)
+	r.quit = nil
+	r.lock.Unlock()
+
+	r.wg.Wait()
+	return nil
+}
+
+func (r *Router) loop(quit chan struct{}) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.probe()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// probe measures the round trip time of the upcheck of the endpoints
+// concurrently.
+func (r *Router) probe() {
+	client := &http.Client{Transport: r.transport, Timeout: r.interval}
+	var wg sync.WaitGroup
+	results := make([]error, len(r.endpoints))
+	latencies := make([]time.Duration, len(r.endpoints))
+	// the endpoints are fixed, only their state is guarded
+	for i, e := range r.endpoints {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			start := time.Now()
+			res, err := client.Get(url + "/upcheck")
+			if err == nil {
+				ioutil.ReadAll(res.Body)
+				res.Body.Close()
+				if res.StatusCode != http.StatusOK {
+					err = fmt.Errorf("upcheck status %d", res.StatusCode)
+				}
+			}
+			results[i], latencies[i] = err, time.Since(start)
+		}(i, e.url)
+	}
+	wg.Wait()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, e := range r.endpoints {
+		if results[i] == nil {
+			if e.latency == 0 {
+				e.latency = latencies[i]
+			} else {
+				e.latency = time.Duration((1-latencySmoothing)*float64(e.latency) + latencySmoothing*float64(latencies[i]))
+			}
+		} else if e.healthy {
+			log.Warn("Private transaction manager endpoint is down", "url", e.url, "err", results[i])
+		}
+		e.record(results[i])
+	}
+}
+
+// route returns the endpoints to try in order: the pinned one, otherwise one
+// picked at random by weight followed by the others by decreasing weight.
+func (r *Router) route() []*endpoint {
+	if r.pinned != nil {
+		return []*endpoint{r.pinned}
+	}
+	order := make([]*endpoint, len(r.endpoints))
+	copy(order, r.endpoints)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].weight() > order[j].weight() })
+
+	shares := r.shares()
+	pick := rand.Float64()
+	for i, e := range order {
+		if pick < shares[e] || i == len(order)-1 {
+			copy(order[1:i+1], order[:i])
+			order[0] = e
+			break
+		}
+		pick -= shares[e]
+	}
+	return order
+}
+
+// shares returns the share of the new requests routed first to each endpoint,
+// the endpoints being shared evenly while none is healthy.
+func (r *Router) shares() map[*endpoint]float64 {
+	shares := make(map[*endpoint]float64, len(r.endpoints))
+	total := 0.0
+	for _, e := range r.endpoints {
+		total += e.weight()
+	}
+	for _, e := range r.endpoints {
+		if r.pinned != nil {
+			if e == r.pinned {
+				shares[e] = 1
+			}
+		} else if total == 0 {
+			shares[e] = 1 / float64(len(r.endpoints))
+		} else {
+			shares[e] = e.weight() / total
+		}
+	}
+	return shares
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Router) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.lock.Lock()
+	order := r.route()
+	base := r.endpoints[0].url
+	order[0].requests++
+	r.lock.Unlock()
+
+	path := strings.TrimPrefix(req.URL.String(), base)
+	var (
+		res *http.Response
+		err error
+	)
+	for i, e := range order {
+		attempt := req.Clone(req.Context())
+		if path != req.URL.String() {
+			if attempt.URL, err = req.URL.Parse(e.url + path); err != nil {
+				return nil, err
+			}
+			attempt.Host = ""
+		}
+		if i > 0 {
+			// the body of the failed attempt has been consumed
+			if req.Body != nil && req.GetBody == nil {
+				break
+			}
+			if req.GetBody != nil {
+				if attempt.Body, err = req.GetBody(); err != nil {
+					break
+				}
+			}
+		}
+		res, err = r.transport.RoundTrip(attempt)
+		if err == nil && res.StatusCode >= http.StatusBadGateway && res.StatusCode <= http.StatusGatewayTimeout {
+			err = fmt.Errorf("%d status", res.StatusCode)
+			if i < len(order)-1 {
+				res.Body.Close()
+				res = nil
+			}
+		}
+		r.lock.Lock()
+		e.record(err)
+		if err != nil {
+			e.failures++
+		} else if i > 0 {
+			e.fallbacks++
+		}
+		r.lock.Unlock()
+		if err == nil {
+			return res, nil
+		}
+		log.Debug("Private transaction manager request failed", "url", e.url, "path", path, "err", err)
+		if res != nil {
+			// last endpoint, hand its response over
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// Pin routes all the requests to the endpoint of the URL, without falling back
+// on errors, an empty URL restoring the routing.
+func (r *Router) Pin(url string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if url == "" {
+		r.pinned = nil
+		return nil
+	}
+	url = strings.TrimSuffix(url, "/")
+	for _, e := range r.endpoints {
+		if e.url == url {
+			r.pinned = e
+			return nil
+		}
+	}
+	return errUnknownEndpoint
+}
+
+// Stats returns the routing state of the endpoints.
+func (r *Router) Stats() []EndpointStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	shares := r.shares()
+	stats := make([]EndpointStats, len(r.endpoints))
+	for i, e := range r.endpoints {
+		stats[i] = EndpointStats{
+			URL:       e.url,
+			LatencyMs: float64(e.latency) / float64(time.Millisecond),
+			ErrorRate: e.errorRate,
+			Healthy:   e.healthy,
+			Share:     shares[e],
+			Pinned:    e == r.pinned,
+			Requests:  e.requests,
+			Failures:  e.failures,
+			Fallbacks: e.fallbacks,
+			LastError: e.lastError,
+		}
+	}
+	return stats
+}
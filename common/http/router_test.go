@@ -0,0 +1,104 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoServer returns a server answering the upchecks and echoing the body of
+// the other requests, prefixed with its name.
+func newEchoServer(name string, upcheck int) *httptest.Server {
+	return httptest.NewServer(echoHandler(name, upcheck))
+}
+
+func echoHandler(name string, upcheck int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/upcheck" {
+			w.WriteHeader(upcheck)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte(name + ":" + r.URL.Path + ":" + string(body)))
+	})
+}
+
+func TestRouter_shares(t *testing.T) {
+	r := NewRouter([]string{"http://near", "http://far/", "http://down"}, http.DefaultTransport, 0)
+	r.endpoints[0].latency = time.Millisecond
+	r.endpoints[1].latency = 81 * time.Millisecond
+	r.endpoints[2].record(assert.AnError)
+
+	stats := r.Stats()
+	require.Len(t, stats, 3)
+	assert.Equal(t, "http://far", stats[1].URL)
+	assert.InDelta(t, 81.0/82, stats[0].Share, 1e-9)
+	assert.InDelta(t, 1.0/82, stats[1].Share, 1e-9)
+	assert.Zero(t, stats[2].Share)
+	assert.False(t, stats[2].Healthy)
+	assert.Equal(t, assert.AnError.Error(), stats[2].LastError)
+
+	// errors lower the share of an endpoint
+	r.endpoints[2].record(nil)
+	r.endpoints[2].latency = time.Millisecond
+	stats = r.Stats()
+	assert.Less(t, stats[2].Share, stats[0].Share)
+
+	assert.Equal(t, errUnknownEndpoint, r.Pin("http://elsewhere"))
+	require.NoError(t, r.Pin("http://far/"))
+	stats = r.Stats()
+	assert.True(t, stats[1].Pinned)
+	assert.Equal(t, 1.0, stats[1].Share)
+	assert.Equal(t, []*endpoint{r.endpoints[1]}, r.route())
+}
+
+func TestRouter_fallback(t *testing.T) {
+	down := newEchoServer("down", http.StatusOK)
+	down.Close()
+	up := newEchoServer("up", http.StatusOK)
+	defer up.Close()
+
+	r := NewRouter([]string{down.URL, up.URL}, http.DefaultTransport, time.Second)
+	client := &http.Client{Transport: r}
+	for i := 0; i < 5; i++ {
+		res, err := client.Post(down.URL+"/send", "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "up:/send:payload", string(body))
+	}
+	stats := r.Stats()
+	assert.False(t, stats[0].Healthy)
+	assert.Equal(t, uint64(5), stats[0].Requests+stats[1].Requests)
+	assert.Equal(t, stats[0].Failures, stats[1].Fallbacks)
+	assert.NotZero(t, stats[0].Failures)
+
+	// pinned endpoints do not fall back
+	require.NoError(t, r.Pin(down.URL))
+	_, err := client.Get(down.URL + "/upcheck")
+	assert.Error(t, err)
+}
+
+func TestRouter_probe(t *testing.T) {
+	healthy := newEchoServer("healthy", http.StatusOK)
+	defer healthy.Close()
+	failing := newEchoServer("failing", http.StatusServiceUnavailable)
+	defer failing.Close()
+
+	r := NewRouter([]string{healthy.URL, failing.URL}, http.DefaultTransport, time.Hour)
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	stats := r.Stats()
+	assert.True(t, stats[0].Healthy)
+	assert.NotZero(t, stats[0].LatencyMs)
+	assert.Equal(t, 1.0, stats[0].Share)
+	assert.False(t, stats[1].Healthy)
+	assert.Contains(t, stats[1].LastError, "503")
+}
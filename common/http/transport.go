@@ -30,6 +30,21 @@ func httpTransport(cfg Config) *http.Transport {
 	return t
 }
 
+// tlsTransport returns the transport of the HTTP connection, with TLS unless
+// it is off.
+func tlsTransport(cfg Config) (*http.Transport, error) {
+	t := httpTransport(cfg)
+	if cfg.TlsMode == TlsOff {
+		return t, nil
+	}
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
 func newTLSConfig(cfg Config) (*tls.Config, error) {
 	rootCAPool, err := loadRootCaCerts(cfg.TlsRootCA)
 	if err != nil {
@@ -49,6 +49,7 @@ type Clock interface {
 	NewTimer(time.Duration) ChanTimer
 	After(time.Duration) <-chan AbsTime
 	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(time.Duration) Ticker // Quorum
 }
 
 // Timer is a cancellable event created by AfterFunc.
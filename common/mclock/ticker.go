@@ -0,0 +1,85 @@
+package mclock
+
+import (
+	"sync"
+	"time"
+)
+
+// Quorum
+//
+// The tickers of the clocks are scheduled with AfterFunc, so that the ticks of
+// a Simulated clock are sent by Run as its other timers.
+
+// Ticker delivers the ticks of a clock at intervals, like time.Ticker.
+type Ticker interface {
+	// The channel returned by C receives the time of the ticks, dropping the
+	// ticks while the previous one was not received.
+	C() <-chan AbsTime
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// NewTicker creates a ticker ticking every d.
+func (c System) NewTicker(d time.Duration) Ticker {
+	return newClockTicker(c, d)
+}
+
+// NewTicker creates a ticker ticking every time the clock has advanced by d.
+func (s *Simulated) NewTicker(d time.Duration) Ticker {
+	return newClockTicker(s, d)
+}
+
+type clockTicker struct {
+	clock Clock
+	d     time.Duration
+	ch    chan AbsTime
+
+	mu    sync.Mutex
+	next  AbsTime // time of the next tick
+	timer Timer   // nil once stopped
+}
+
+func newClockTicker(clock Clock, d time.Duration) *clockTicker {
+	if d <= 0 {
+		panic("mclock: non-positive interval for NewTicker")
+	}
+	t := &clockTicker{clock: clock, d: d, ch: make(chan AbsTime, 1)}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.next = clock.Now().Add(d)
+	t.timer = clock.AfterFunc(d, t.tick)
+	return t
+}
+
+func (t *clockTicker) tick() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer == nil {
+		return
+	}
+	now := t.clock.Now()
+	select {
+	case t.ch <- now:
+	default:
+	}
+	// skip the ticks missed, the clock having advanced past them
+	for t.next <= now {
+		t.next = t.next.Add(t.d)
+	}
+	t.timer = t.clock.AfterFunc(t.next.Sub(now), t.tick)
+}
+
+func (t *clockTicker) C() <-chan AbsTime {
+	return t.ch
+}
+
+func (t *clockTicker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
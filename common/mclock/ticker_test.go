@@ -0,0 +1,61 @@
+package mclock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulatedTicker(t *testing.T) {
+	var c Simulated
+	ticker := c.NewTicker(10 * time.Second)
+
+	c.Run(9 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticked early")
+	default:
+	}
+	c.Run(time.Second)
+	select {
+	case at := <-ticker.C():
+		if at != AbsTime(10*time.Second) {
+			t.Fatalf("wrong tick time: have %v, want %v", at, AbsTime(10*time.Second))
+		}
+	default:
+		t.Fatal("not ticked")
+	}
+
+	// the ticks missed are dropped, the next ones keeping the interval
+	c.Run(35 * time.Second)
+	if at := <-ticker.C(); at != AbsTime(45*time.Second) {
+		t.Fatalf("wrong tick time: have %v, want %v", at, AbsTime(45*time.Second))
+	}
+	c.Run(5 * time.Second)
+	if at := <-ticker.C(); at != AbsTime(50*time.Second) {
+		t.Fatalf("wrong tick time: have %v, want %v", at, AbsTime(50*time.Second))
+	}
+
+	ticker.Stop()
+	if n := c.ActiveTimers(); n != 0 {
+		t.Fatalf("%d timers active after Stop", n)
+	}
+	c.Run(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticked after Stop")
+	default:
+	}
+}
+
+func TestSystemTicker(t *testing.T) {
+	ticker := System{}.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ticker.C():
+		case <-time.After(time.Second):
+			t.Fatal("not ticked")
+		}
+	}
+}
@@ -238,7 +238,10 @@ func (h *Hash) UnmarshalGraphQL(input interface{}) error {
 	var err error
 	switch input := input.(type) {
 	case string:
-		err = h.UnmarshalText([]byte(input))
+		var b []byte
+		if b, err = parseGraphQLHex("Bytes32", input, HashLength); err == nil {
+			h.SetBytes(b)
+		}
 	default:
 		err = fmt.Errorf("unexpected type %T for Hash", input)
 	}
@@ -421,7 +424,7 @@ func (a *Address) UnmarshalGraphQL(input interface{}) error {
 	var err error
 	switch input := input.(type) {
 	case string:
-		err = a.UnmarshalText([]byte(input))
+		*a, err = parseGraphQLAddress(input)
 	default:
 		err = fmt.Errorf("unexpected type %T for Address", input)
 	}
@@ -156,3 +156,28 @@ type Istanbul interface {
 	// Stop stops the engine
 	Stop() error
 }
+
+// Quorum
+
+// MaintenanceWindow is a range of upcoming blocks which the node is not
+// expected to seal, so that it can be restarted without delaying the chain.
+type MaintenanceWindow struct {
+	Head         uint64  `json:"head"`                   // head block the window was computed from
+	Start        uint64  `json:"start"`                  // first block of the window
+	End          uint64  `json:"end"`                    // last block of the window
+	Position     int     `json:"position"`               // index of the node in the proposer rotation, -1 if it does not seal
+	Validators   int     `json:"validators"`             // size of the proposer rotation
+	NextProposal *uint64 `json:"nextProposal,omitempty"` // next block the node is expected to seal
+	Policy       string  `json:"policy,omitempty"`       // proposer selection policy
+}
+
+// MaintenanceScheduler is implemented by consensus engines electing a single
+// sealer per block, allowing operators to find when a node can be restarted.
+type MaintenanceScheduler interface {
+	// MaintenanceWindow returns the soonest window of at least the given
+	// number of consecutive blocks following the current head which the node
+	// is not expected to seal, assuming no round changes.
+	MaintenanceWindow(chain ChainHeaderReader, blocks uint64) (*MaintenanceWindow, error)
+}
+
+// /Quorum
@@ -21,6 +21,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -40,8 +42,15 @@ type BlockSigners struct {
 }
 
 type Status struct {
-	SigningStatus map[common.Address]int `json:"sealerActivity"`
+	SigningStatus map[common.Address]int `json:"sealerActivity"` // blocks proposed by the validators
 	NumBlocks     uint64                 `json:"numBlocks"`
+
+	// Quorum
+	StartBlock  uint64                             `json:"startBlock"`
+	EndBlock    uint64                             `json:"endBlock"`
+	Signers     map[common.Address]*SignerActivity `json:"signers"`
+	SealsPruned uint64                             `json:"sealsPruned,omitempty"` // blocks whose committed seals were pruned, not counted as sealed or missed
+	Consensus   *istanbul.CoreStatus               `json:"consensus,omitempty"`   // nil if the node is not validating
 }
 
 // NodeAddress returns the public address that is used to sign block headers in IBFT
@@ -49,19 +58,13 @@ func (api *API) NodeAddress() common.Address {
 	return api.istanbul.Address()
 }
 
-// GetSignersFromBlock returns the signers and minter for a given block number, or the
+// GetSignersFromBlock returns the signers and minter for a given block number or hash, or the
 // latest block available if none is specified
-func (api *API) GetSignersFromBlock(number *rpc.BlockNumber) (*BlockSigners, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-
-	if header == nil {
-		return nil, errUnknownBlock
+func (api *API) GetSignersFromBlock(blockNrOrHash *rpc.BlockNumberOrHash) (*BlockSigners, error) {
+	// Retrieve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 
 	return api.signers(header)
@@ -96,18 +99,12 @@ func (api *API) signers(header *types.Header) (*BlockSigners, error) {
 	}, nil
 }
 
-// GetSnapshot retrieves the state snapshot at a given block.
-func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	// Ensure we have an actually valid block and return its snapshot
-	if header == nil {
-		return nil, errUnknownBlock
+// GetSnapshot retrieves the state snapshot at a given block number or hash.
+func (api *API) GetSnapshot(blockNrOrHash *rpc.BlockNumberOrHash) (*Snapshot, error) {
+	// Retrieve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	return api.istanbul.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 }
@@ -121,18 +118,12 @@ func (api *API) GetSnapshotAtHash(hash common.Hash) (*Snapshot, error) {
 	return api.istanbul.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 }
 
-// GetValidators retrieves the list of authorized validators at the specified block.
-func (api *API) GetValidators(number *rpc.BlockNumber) ([]common.Address, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	// Ensure we have an actually valid block and return the validators from its snapshot
-	if header == nil {
-		return nil, errUnknownBlock
+// GetValidators retrieves the list of authorized validators at the specified block number or hash.
+func (api *API) GetValidators(blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Address, error) {
+	// Retrieve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	snap, err := api.istanbul.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 	if err != nil {
@@ -184,14 +175,36 @@ func (api *API) Discard(address common.Address) {
 	delete(api.istanbul.candidates, address)
 }
 
+// AnnounceKeyRotation signs a rotation of this validator's key to the given
+// address, activated at the given block once a majority of the validators
+// voted for it, and gossips it to the validators. The node must be restarted
+// with the new key for blocks from the activation one.
+func (api *API) AnnounceKeyRotation(newAddress common.Address, activation uint64) (*types.IstanbulKeyRotation, error) {
+	return api.istanbul.announceKeyRotation(api.chain, newAddress, activation)
+}
+
+// MintFencingToken stops the participation of this validator in consensus,
+// putting it in standby, and returns the token promoting its standby without
+// waiting for the observation window.
+func (api *API) MintFencingToken() (*FencingToken, error) {
+	return api.istanbul.mintFencingToken()
+}
+
+// PromoteStandby starts the participation of this standby in consensus once
+// the validator key was inactive for two block periods, or for the standby
+// observation window if no fencing token is given.
+func (api *API) PromoteStandby(fencingToken *FencingToken) (bool, error) {
+	if err := api.istanbul.promoteStandby(fencingToken); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Status returns the activity of the validators in the blocks between the
+// start and end ones, the last 64 blocks by default, and the current state of
+// the consensus if the node is validating. A range past the head is truncated
+// to it.
 func (api *API) Status(startBlockNum *rpc.BlockNumber, endBlockNum *rpc.BlockNumber) (*Status, error) {
-	var (
-		numBlocks   uint64
-		header      = api.chain.CurrentHeader()
-		start       uint64
-		end         uint64
-		blockNumber rpc.BlockNumber
-	)
 	if startBlockNum != nil && endBlockNum == nil {
 		return nil, errors.New("pass the end block number")
 	}
@@ -200,68 +213,62 @@ func (api *API) Status(startBlockNum *rpc.BlockNumber, endBlockNum *rpc.BlockNum
 		return nil, errors.New("pass the start block number")
 	}
 
+	var (
+		head  = api.chain.CurrentHeader().Number.Uint64()
+		start uint64
+		end   uint64
+	)
 	if startBlockNum == nil && endBlockNum == nil {
-		numBlocks = uint64(64)
-		header = api.chain.CurrentHeader()
-		end = header.Number.Uint64()
-		start = end - numBlocks
-		blockNumber = rpc.BlockNumber(header.Number.Int64())
+		end = head
+		if end >= 64 {
+			start = end - 63
+		}
 	} else {
-		end = uint64(*endBlockNum)
-		start = uint64(*startBlockNum)
+		start, end = api.blockNumber(*startBlockNum), api.blockNumber(*endBlockNum)
 		if start > end {
 			return nil, errors.New("start block number should be less than end block number")
 		}
-
-		if end > api.chain.CurrentHeader().Number.Uint64() {
-			return nil, errors.New("end block number should be less than or equal to current block height")
+		if end > head {
+			end = head
 		}
-
-		numBlocks = end - start
-		header = api.chain.GetHeaderByNumber(end)
-		blockNumber = rpc.BlockNumber(end)
+	}
+	// the genesis block is not sealed
+	if start == 0 {
+		start = 1
 	}
 
-	signers, err := api.GetValidators(&blockNumber)
-
+	numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(end))
+	signers, err := api.GetValidators(&numberOrHash)
 	if err != nil {
 		return nil, err
 	}
-
-	if numBlocks >= end {
-		start = 1
-		if end > start {
-			numBlocks = end - start
-		} else {
-			numBlocks = 0
-		}
+	status := &Status{
+		SigningStatus: make(map[common.Address]int),
+		StartBlock:    start,
+		EndBlock:      end,
+		Signers:       make(map[common.Address]*SignerActivity),
+		Consensus:     api.istanbul.core.Status(),
 	}
-	signStatus := make(map[common.Address]int)
 	for _, s := range signers {
-		signStatus[s] = 0
+		status.SigningStatus[s] = 0
 	}
-
-	for n := start; n < end; n++ {
-		blockNum := rpc.BlockNumber(int64(n))
-		s, _ := api.GetSignersFromBlock(&blockNum)
-		signStatus[s.Author]++
-
+	if start <= end {
+		status.NumBlocks = end - start + 1
+		if status.Signers, status.SealsPruned, err = api.istanbul.signerActivity(api.chain, start, end); err != nil {
+			return nil, err
+		}
+		for signer, activity := range status.Signers {
+			status.SigningStatus[signer] = int(activity.Proposed)
+		}
 	}
-	return &Status{
-		SigningStatus: signStatus,
-		NumBlocks:     numBlocks,
-	}, nil
+	return status, nil
 }
 
-func (api *API) IsValidator(blockNum *rpc.BlockNumber) (bool, error) {
-	var blockNumber rpc.BlockNumber
-	if blockNum != nil {
-		blockNumber = *blockNum
-	} else {
-		header := api.chain.CurrentHeader()
-		blockNumber = rpc.BlockNumber(header.Number.Int64())
+func (api *API) IsValidator(blockNrOrHash *rpc.BlockNumberOrHash) (bool, error) {
+	s, err := api.GetValidators(blockNrOrHash)
+	if errors.Is(err, core.ErrHashNotCanonical) {
+		return false, err
 	}
-	s, _ := api.GetValidators(&blockNumber)
 
 	for _, v := range s {
 		if v == api.istanbul.address {
@@ -270,3 +277,62 @@ func (api *API) IsValidator(blockNum *rpc.BlockNumber) (bool, error) {
 	}
 	return false, nil
 }
+
+// ExportValidatorHistory returns the proposer, round, eligible validators and
+// validator set changes of the blocks in [fromBlock, toBlock], reconstructed
+// from the headers without executing the blocks. At most 1000 blocks are
+// returned per call, along with the first block of the next page.
+//
+// With options, the history is instead exported server-side as CSV or JSON
+// lines to the export directory by a job whose progress is returned by
+// istanbul_validatorHistoryExportProgress, and which can be resumed, after a
+// failure or a restart, by passing its id as the job option.
+func (api *API) ExportValidatorHistory(fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber, options *ValidatorHistoryOptions) (*ValidatorHistoryPage, error) {
+	from, to := api.blockNumber(fromBlock), api.blockNumber(toBlock)
+	if options != nil && (options.Format != "" || options.Job != "") {
+		job, err := api.istanbul.exports.start(api.istanbul, api.chain, from, to, options)
+		if err != nil {
+			return nil, err
+		}
+		return &ValidatorHistoryPage{Job: job}, nil
+	}
+	return api.istanbul.validatorHistory(api.chain, from, to)
+}
+
+// ValidatorHistoryExportProgress returns the progress of a server-side export
+// of the validator history.
+func (api *API) ValidatorHistoryExportProgress(job string) (*ExportJob, error) {
+	return api.istanbul.exports.progress(job)
+}
+
+// header returns the header of the block number or hash, the current one if
+// nil, see core.HeaderByNumberOrHash.
+func (api *API) header(blockNrOrHash *rpc.BlockNumberOrHash) (*types.Header, error) {
+	if blockNrOrHash == nil {
+		return api.chain.CurrentHeader(), nil
+	}
+	header, err := core.HeaderByNumberOrHash(api.chain, *blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+func (api *API) blockNumber(number rpc.BlockNumber) uint64 {
+	if number < 0 {
+		return api.chain.CurrentHeader().Number.Uint64()
+	}
+	return uint64(number)
+}
+
+// ClockSkewReport returns the recent deltas between the timestamps of the
+// proposals received from each validator and the local clock.
+func (api *API) ClockSkewReport() *ClockSkewReport {
+	return &ClockSkewReport{
+		AllowedFutureBlockTime: api.istanbul.config.AllowedFutureBlockTime,
+		Validators:             api.istanbul.clockSkew.report(),
+	}
+}
@@ -58,9 +58,15 @@ func New(config *istanbul.Config, privateKey *ecdsa.PrivateKey, db ethdb.Databas
 		commitCh:         make(chan *types.Block, 1),
 		recents:          recents,
 		candidates:       make(map[common.Address]bool),
+		keyRotations:     make(map[common.Address]*types.IstanbulKeyRotation),
 		coreStarted:      false,
 		recentMessages:   recentMessages,
 		knownMessages:    knownMessages,
+		clockSkew:        newClockSkewTracker(),
+		standby:          newStandbyState(config.Standby),
+	}
+	if config.ExportDir != "" {
+		backend.exports = newHistoryExporter(config.ExportDir)
 	}
 	backend.core = istanbulCore.New(backend, backend.config)
 	return backend
@@ -89,6 +95,8 @@ type backend struct {
 
 	// Current list of candidates we are pushing
 	candidates map[common.Address]bool
+	// Quorum: key rotations announced by the validators, by old address
+	keyRotations map[common.Address]*types.IstanbulKeyRotation
 	// Protects the signer fields
 	candidatesLock sync.RWMutex
 	// Snapshots for recent block to speed up reorgs
@@ -99,6 +107,10 @@ type backend struct {
 
 	recentMessages *lru.ARCCache // the cache of peer's messages
 	knownMessages  *lru.ARCCache // the cache of self messages
+
+	clockSkew *clockSkewTracker // Quorum: recent timestamp deltas of the proposals
+	exports   *historyExporter  // Quorum: server-side exports of the validator history, nil if disabled
+	standby   *standbyState     // Quorum: activity of the validator keys, and whether this node is a standby
 }
 
 // zekun: HACK
@@ -230,6 +242,8 @@ func (sb *backend) Verify(proposal istanbul.Proposal) (time.Duration, error) {
 
 	// verify the header of proposed block
 	err := sb.VerifyHeader(sb.chain, block.Header(), false)
+	// Quorum: track how far the proposer's clock is from ours
+	sb.recordClockSkew(block.Header(), err == consensus.ErrFutureBlock)
 	// ignore errEmptyCommittedSeals error because we don't have the committed seals yet
 	if err == nil || err == errEmptyCommittedSeals {
 		return 0, nil
@@ -239,8 +253,30 @@ func (sb *backend) Verify(proposal istanbul.Proposal) (time.Duration, error) {
 	return 0, err
 }
 
+// recordClockSkew records the delta between the timestamp of a proposal and the
+// local clock, warning about the proposer if the proposal is rejected for
+// being in the future. It only observes the check, the tolerance applied is
+// the allowed future block time.
+func (sb *backend) recordClockSkew(header *types.Header, future bool) {
+	proposer, err := ecrecover(header)
+	if err != nil {
+		return
+	}
+	local := now()
+	delta := time.Unix(int64(header.Time), 0).Sub(local)
+	sb.clockSkew.record(proposer, delta, future, local)
+	if future {
+		sb.logger.Warn("Proposal timestamp ahead of the local clock", "proposer", proposer, "number", header.Number, "hash", header.Hash(),
+			"delta", delta, "allowed", time.Duration(sb.config.AllowedFutureBlockTime)*time.Second)
+	}
+}
+
 // Sign implements istanbul.Backend.Sign
 func (sb *backend) Sign(data []byte) ([]byte, error) {
+	// Quorum: a standby never signs with the key of the validator
+	if sb.standby.isEnabled() {
+		return nil, errStandby
+	}
 	hashData := crypto.Keccak256(data)
 	return crypto.Sign(hashData, sb.privateKey)
 }
@@ -314,5 +350,6 @@ func (sb *backend) HasBadProposal(hash common.Hash) bool {
 }
 
 func (sb *backend) Close() error {
+	sb.exports.close()
 	return nil
 }
@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// clockSkewSamples is the number of recent timestamp deltas kept per validator.
+const clockSkewSamples = 128
+
+var (
+	// clockSkewHistogram records the difference in milliseconds between the
+	// timestamp of the proposals received and the local clock.
+	clockSkewHistogram = metrics.NewRegisteredHistogram("consensus/istanbul/clockskew", nil, metrics.NewExpDecaySample(1028, 0.015))
+	// futureProposalMeter counts the proposals rejected for being ahead of the
+	// local clock by more than the allowed future block time.
+	futureProposalMeter = metrics.NewRegisteredMeter("consensus/istanbul/clockskew/future", nil)
+)
+
+// ClockSkew summarizes the recent timestamp deltas of the proposals of a
+// validator, in milliseconds. A positive delta is a proposal timestamp ahead of
+// the local clock.
+type ClockSkew struct {
+	Samples  int    `json:"samples"`
+	Last     int64  `json:"last"`
+	Min      int64  `json:"min"`
+	Max      int64  `json:"max"`
+	Mean     int64  `json:"mean"`
+	Rejected uint64 `json:"rejected"` // proposals rejected for being in the future
+	LastSeen uint64 `json:"lastSeen"` // local time of the last proposal, in seconds
+}
+
+// ClockSkewReport is the recent clock skew of the validators with the allowed
+// future block time it is checked against.
+type ClockSkewReport struct {
+	AllowedFutureBlockTime uint64                        `json:"allowedFutureBlockTime"` // in seconds
+	Validators             map[common.Address]*ClockSkew `json:"validators"`
+}
+
+// clockSkewTracker keeps the recent timestamp deltas of the proposals per
+// validator.
+type clockSkewTracker struct {
+	lock       sync.Mutex
+	validators map[common.Address]*validatorSkew
+}
+
+type validatorSkew struct {
+	deltas   []int64 // ring buffer of the last clockSkewSamples deltas
+	next     int
+	rejected uint64
+	lastSeen time.Time
+}
+
+func newClockSkewTracker() *clockSkewTracker {
+	return &clockSkewTracker{validators: make(map[common.Address]*validatorSkew)}
+}
+
+// record adds the timestamp delta of a proposal of the validator.
+func (t *clockSkewTracker) record(validator common.Address, delta time.Duration, rejected bool, seen time.Time) {
+	clockSkewHistogram.Update(delta.Milliseconds())
+	if rejected {
+		futureProposalMeter.Mark(1)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	v, ok := t.validators[validator]
+	if !ok {
+		v = &validatorSkew{deltas: make([]int64, 0, clockSkewSamples)}
+		t.validators[validator] = v
+	}
+	if len(v.deltas) < clockSkewSamples {
+		v.deltas = append(v.deltas, delta.Milliseconds())
+	} else {
+		v.deltas[v.next] = delta.Milliseconds()
+	}
+	v.next = (v.next + 1) % clockSkewSamples
+	if rejected {
+		v.rejected++
+	}
+	v.lastSeen = seen
+}
+
+// report summarizes the deltas recorded for every validator.
+func (t *clockSkewTracker) report() map[common.Address]*ClockSkew {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	report := make(map[common.Address]*ClockSkew, len(t.validators))
+	for validator, v := range t.validators {
+		last := v.next - 1
+		if last < 0 {
+			last = len(v.deltas) - 1
+		}
+		skew := &ClockSkew{
+			Samples:  len(v.deltas),
+			Last:     v.deltas[last],
+			Min:      v.deltas[0],
+			Max:      v.deltas[0],
+			Rejected: v.rejected,
+			LastSeen: uint64(v.lastSeen.Unix()),
+		}
+		var sum int64
+		for _, delta := range v.deltas {
+			if delta < skew.Min {
+				skew.Min = delta
+			}
+			if delta > skew.Max {
+				skew.Max = delta
+			}
+			sum += delta
+		}
+		skew.Mean = sum / int64(len(v.deltas))
+		report[validator] = skew
+	}
+	return report
+}
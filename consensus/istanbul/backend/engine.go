@@ -211,6 +211,10 @@ func (sb *backend) verifyCascadingFields(chain consensus.ChainHeaderReader, head
 	if err := sb.verifySigner(chain, header, parents); err != nil {
 		return err
 	}
+	// Quorum: validate the key rotation votes
+	if err := sb.verifyKeyRotations(chain, header, snap); err != nil {
+		return err
+	}
 
 	return sb.verifyCommittedSeals(chain, header, parents)
 }
@@ -398,6 +402,13 @@ func (sb *backend) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 	}
 	header.Extra = extra
 
+	// Quorum: vote on one of the key rotations announced by the validators
+	if rotation := sb.pendingKeyRotation(chain, snap, number); rotation != nil {
+		if err := writeKeyRotations(header, []*types.IstanbulKeyRotation{rotation}); err != nil {
+			return err
+		}
+	}
+
 	// set header's timestamp
 	header.Time = parent.Time + sb.config.BlockPeriod
 	if header.Time < uint64(time.Now().Unix()) {
@@ -434,6 +445,11 @@ func (sb *backend) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 func (sb *backend) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
 
 	// update the block header timestamp and signature and propose the block to core engine
+	// Quorum: a standby does not propose blocks until promoted
+	if sb.standby.isEnabled() {
+		return errStandby
+	}
+
 	header := block.Header()
 	number := header.Number.Uint64()
 	// Bail out if we're unauthorized to sign a block
@@ -541,6 +557,11 @@ func (sb *backend) Start(chain consensus.ChainHeaderReader, currentBlock func()
 	sb.currentBlock = currentBlock
 	sb.hasBadBlock = hasBadBlock
 
+	// Quorum: the core of a standby is started on promotion
+	if sb.standby.isEnabled() {
+		return nil
+	}
+
 	if err := sb.core.Start(); err != nil {
 		return err
 	}
@@ -685,26 +706,11 @@ func ecrecover(header *types.Header) (common.Address, error) {
 
 // prepareExtra returns a extra-data of the given header and validators
 func prepareExtra(header *types.Header, vals []common.Address) ([]byte, error) {
-	var buf bytes.Buffer
-
 	// compensate the lack bytes if header.Extra is not enough IstanbulExtraVanity bytes.
 	if len(header.Extra) < types.IstanbulExtraVanity {
 		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, types.IstanbulExtraVanity-len(header.Extra))...)
 	}
-	buf.Write(header.Extra[:types.IstanbulExtraVanity])
-
-	ist := &types.IstanbulExtra{
-		Validators:    vals,
-		Seal:          []byte{},
-		CommittedSeal: [][]byte{},
-	}
-
-	payload, err := rlp.EncodeToBytes(&ist)
-	if err != nil {
-		return nil, err
-	}
-
-	return append(buf.Bytes(), payload...), nil
+	return types.PrepareIstanbulExtra(header.Extra[:types.IstanbulExtraVanity], vals)
 }
 
 // writeSeal writes the extra-data field of the given header with the given seals.
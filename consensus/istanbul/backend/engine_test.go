@@ -370,6 +370,41 @@ func TestVerifySeal(t *testing.T) {
 	}
 }
 
+func TestClockSkewReport(t *testing.T) {
+	chain, engine := newBlockChain(1)
+	defer engine.Stop()
+
+	proposal := func(time uint64) *types.Block {
+		block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+		header := block.Header()
+		header.Time = time
+		block, err := engine.updateBlock(chain.Genesis().Header(), block.WithSeal(header))
+		if err != nil {
+			t.Fatalf("failed to seal the proposal: %v", err)
+		}
+		return block
+	}
+
+	if _, err := engine.Verify(proposal(uint64(now().Unix()))); err != nil {
+		t.Fatalf("error mismatch: have %v, want nil", err)
+	}
+	if _, err := engine.Verify(proposal(uint64(now().Unix() + 10))); err != consensus.ErrFutureBlock {
+		t.Fatalf("error mismatch: have %v, want %v", err, consensus.ErrFutureBlock)
+	}
+
+	report := (&API{chain: chain, istanbul: engine}).ClockSkewReport()
+	skew, ok := report.Validators[engine.Address()]
+	if !ok || len(report.Validators) != 1 {
+		t.Fatalf("validators mismatch: have %v, want %v", report.Validators, engine.Address())
+	}
+	if skew.Samples != 2 || skew.Rejected != 1 {
+		t.Errorf("samples mismatch: have %d samples and %d rejected, want 2 and 1", skew.Samples, skew.Rejected)
+	}
+	if skew.Last != skew.Max || skew.Max < 9000 || skew.Min > 0 {
+		t.Errorf("deltas mismatch: have last %d, min %d, max %d", skew.Last, skew.Min, skew.Max)
+	}
+}
+
 func TestVerifyHeaders(t *testing.T) {
 	chain, engine := newBlockChain(1)
 	genesis := chain.Genesis()
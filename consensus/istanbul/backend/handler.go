@@ -61,7 +61,9 @@ func (sb *backend) HandleMsg(addr common.Address, msg p2p.Msg) (bool, error) {
 	sb.coreMu.Lock()
 	defer sb.coreMu.Unlock()
 	if msg.Code == istanbulMsg {
-		if !sb.coreStarted {
+		// Quorum: a standby follows the messages without its core started
+		standby := sb.standby.isEnabled()
+		if !sb.coreStarted && !standby {
 			return true, istanbul.ErrStoppedEngine
 		}
 
@@ -69,6 +71,9 @@ func (sb *backend) HandleMsg(addr common.Address, msg p2p.Msg) (bool, error) {
 		if err != nil {
 			return true, errDecodeFailed
 		}
+		if isConsensusMsg(data) {
+			sb.standby.received(addr)
+		}
 		// Mark peer's message
 		ms, ok := sb.recentMessages.Get(addr)
 		var m *lru.ARCCache
@@ -86,6 +91,25 @@ func (sb *backend) HandleMsg(addr common.Address, msg p2p.Msg) (bool, error) {
 		}
 		sb.knownMessages.Add(hash, true)
 
+		// Quorum: key rotation announcements are handled by the backend
+		if len(data) > 0 && data[0] == keyRotationMsgPrefix {
+			go sb.handleKeyRotationMsg(data)
+			return true, nil
+		}
+		// Quorum: standby queries are answered by the backend
+		if len(data) > 0 && data[0] == standbyQueryMsgPrefix {
+			go sb.handleStandbyQuery(addr, data)
+			return true, nil
+		}
+		if len(data) > 0 && data[0] == standbyAnswerMsgPrefix {
+			go sb.handleStandbyAnswer(data)
+			return true, nil
+		}
+		if standby {
+			go sb.observe(data)
+			return true, nil
+		}
+
 		go sb.istanbulEventMux.Post(istanbul.MessageEvent{
 			Payload: data,
 		})
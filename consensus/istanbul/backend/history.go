@@ -0,0 +1,254 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// maxValidatorHistoryPage caps the number of blocks returned per call of
+// istanbul_exportValidatorHistory, larger ranges being paginated.
+const maxValidatorHistoryPage = 1000
+
+var errHistoryReorg = errors.New("chain reorganised during the validator history walk")
+
+// ValidatorHistoryEntry records who was eligible to seal a block and who
+// proposed it.
+//
+// Headers do not record the round a block was sealed in. Round is the lowest
+// round in which the proposer selection policy elects the proposer, rounds
+// being only recoverable modulo the size of the validator set, nil if the
+// policy elects the proposer in no round.
+type ValidatorHistoryEntry struct {
+	Number           uint64             `json:"number"`
+	Hash             common.Hash        `json:"hash"`
+	Proposer         common.Address     `json:"proposer"`
+	Round            *uint64            `json:"round"`
+	ValidatorSetHash common.Hash        `json:"validatorSetHash"`     // hash of the sorted eligible validators
+	Validators       []common.Address   `json:"validators,omitempty"` // eligible validators, when they changed since the previous entry
+	Vote             *Vote              `json:"vote,omitempty"`       // vote cast by the proposer in the block
+	Changes          []*ValidatorChange `json:"changes,omitempty"`    // changes of the validator set from the next block
+}
+
+// ValidatorChange is the addition or removal of a validator, caused either by
+// the votes cast in the headers or by a key rotation.
+type ValidatorChange struct {
+	Address  common.Address             `json:"address"`
+	Added    bool                       `json:"added"`
+	Votes    []*Vote                    `json:"votes,omitempty"`
+	Rotation *types.IstanbulKeyRotation `json:"rotation,omitempty"`
+}
+
+// ValidatorHistoryPage is a page of the validator history of a range of blocks.
+type ValidatorHistoryPage struct {
+	Entries []*ValidatorHistoryEntry `json:"entries,omitempty"`
+	Next    *uint64                  `json:"next,omitempty"` // first block of the next page, nil on the last page
+	Job     *ExportJob               `json:"job,omitempty"`  // server-side export started instead of returning the entries
+}
+
+// historyWalker reconstructs the validator history of consecutive blocks from
+// their headers and the voting snapshots, without executing them.
+type historyWalker struct {
+	sb    *backend
+	chain consensus.ChainHeaderReader
+
+	number       uint64         // next block of the walk
+	parent       *Snapshot      // snapshot of the previous block
+	lastProposer common.Address // proposer of the previous block
+	lastSetHash  common.Hash    // validator set hash of the previous entry
+}
+
+// newHistoryWalker starts a walk at the given block, the genesis block having
+// no proposer.
+func newHistoryWalker(sb *backend, chain consensus.ChainHeaderReader, from uint64) (*historyWalker, error) {
+	if from == 0 {
+		from = 1
+	}
+	parent := chain.GetHeaderByNumber(from - 1)
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := sb.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	w := &historyWalker{sb: sb, chain: chain, number: from, parent: snap}
+	if parent.Number.Sign() > 0 {
+		if w.lastProposer, err = sb.Author(parent); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// next returns the entry of the next block of the walk.
+func (w *historyWalker) next() (*ValidatorHistoryEntry, error) {
+	header := w.chain.GetHeaderByNumber(w.number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	if header.ParentHash != w.parent.Hash {
+		return nil, errHistoryReorg
+	}
+	proposer, err := w.sb.Author(header)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := w.sb.snapshot(w.chain, w.number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	eligible := w.parent.validators()
+	entry := &ValidatorHistoryEntry{
+		Number:           w.number,
+		Hash:             header.Hash(),
+		Proposer:         proposer,
+		Round:            proposalRound(w.parent.ValSet, w.lastProposer, proposer),
+		ValidatorSetHash: validatorSetHash(eligible),
+	}
+	if entry.ValidatorSetHash != w.lastSetHash {
+		entry.Validators = eligible
+	}
+	if header.Coinbase != (common.Address{}) {
+		entry.Vote = &Vote{
+			Validator: proposer,
+			Block:     w.number,
+			Address:   header.Coinbase,
+			Authorize: bytes.Equal(header.Nonce[:], nonceAuthVote),
+		}
+	}
+	entry.Changes = validatorChanges(w.parent, snap, entry.Vote, w.number)
+
+	w.number++
+	w.parent, w.lastProposer, w.lastSetHash = snap, proposer, entry.ValidatorSetHash
+	return entry, nil
+}
+
+// proposalRound returns the lowest round the policy elects the proposer in.
+func proposalRound(valSet istanbul.ValidatorSet, lastProposer, proposer common.Address) *uint64 {
+	valSet = valSet.Copy()
+	for round := uint64(0); round < uint64(valSet.Size()); round++ {
+		valSet.CalcProposer(lastProposer, round)
+		if valSet.GetProposer().Address() == proposer {
+			return &round
+		}
+	}
+	return nil
+}
+
+func validatorSetHash(validators []common.Address) common.Hash {
+	blob := make([]byte, 0, len(validators)*common.AddressLength)
+	for _, validator := range validators {
+		blob = append(blob, validator.Bytes()...)
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// validatorChanges returns the changes between the snapshots of a block and of
+// its parent, along with the votes or key rotation which passed them.
+func validatorChanges(parent, snap *Snapshot, vote *Vote, number uint64) []*ValidatorChange {
+	var changes []*ValidatorChange
+	diff := func(from, to *Snapshot, added bool) {
+		for _, validator := range from.validators() {
+			if _, v := to.ValSet.GetByAddress(validator); v != nil {
+				continue
+			}
+			change := &ValidatorChange{Address: validator, Added: added}
+			for old, rotation := range parent.KeyRotations {
+				if rotation.Scheduled && rotation.Activation == number+1 && (old == validator || rotation.New == validator) {
+					change.Rotation = &types.IstanbulKeyRotation{Old: old, New: rotation.New, Activation: rotation.Activation}
+				}
+			}
+			if change.Rotation == nil {
+				for _, cast := range parent.Votes {
+					if cast.Address == validator && cast.Authorize == added {
+						change.Votes = append(change.Votes, cast)
+					}
+				}
+				if vote != nil && vote.Address == validator && vote.Authorize == added {
+					change.Votes = append(change.Votes, vote)
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+	diff(snap, parent, true)
+	diff(parent, snap, false)
+	return changes
+}
+
+// validatorHistory returns the page of the validator history starting at the
+// from block and ending at the to block at most.
+func (sb *backend) validatorHistory(chain consensus.ChainHeaderReader, from, to uint64) (*ValidatorHistoryPage, error) {
+	if from > to {
+		return nil, errors.New("start block number should be less than end block number")
+	}
+	w, err := newHistoryWalker(sb, chain, from)
+	if err != nil {
+		return nil, err
+	}
+	page := new(ValidatorHistoryPage)
+	for w.number <= to {
+		if len(page.Entries) == maxValidatorHistoryPage {
+			next := w.number
+			page.Next = &next
+			break
+		}
+		entry, err := w.next()
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, nil
+}
+
+// csvHeader is the header of the CSV exports of the validator history. The
+// validators are only listed when they changed since the previous row, each
+// change being the added (+) or removed (-) address followed by the votes
+// which passed it, as validator@block, or by the key rotation old>new.
+const csvHeader = "number,hash,proposer,round,validator_set_hash,validators,vote,changes\n"
+
+// csvRow returns the CSV row of the entry.
+func (e *ValidatorHistoryEntry) csvRow() string {
+	round := ""
+	if e.Round != nil {
+		round = strconv.FormatUint(*e.Round, 10)
+	}
+	validators := make([]string, len(e.Validators))
+	for i, validator := range e.Validators {
+		validators[i] = validator.Hex()
+	}
+	vote := ""
+	if e.Vote != nil {
+		vote = fmt.Sprintf("%s%s", voteSign(e.Vote.Authorize), e.Vote.Address.Hex())
+	}
+	changes := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		var causes []string
+		if change.Rotation != nil {
+			causes = append(causes, fmt.Sprintf("%s>%s", change.Rotation.Old.Hex(), change.Rotation.New.Hex()))
+		}
+		for _, cast := range change.Votes {
+			causes = append(causes, fmt.Sprintf("%s@%d", cast.Validator.Hex(), cast.Block))
+		}
+		changes[i] = fmt.Sprintf("%s%s[%s]", voteSign(change.Added), change.Address.Hex(), strings.Join(causes, " "))
+	}
+	return fmt.Sprintf("%d,%s,%s,%s,%s,%s,%s,%s\n", e.Number, e.Hash.Hex(), e.Proposer.Hex(), round,
+		e.ValidatorSetHash.Hex(), strings.Join(validators, ";"), vote, strings.Join(changes, ";"))
+}
+
+func voteSign(authorize bool) string {
+	if authorize {
+		return "+"
+	}
+	return "-"
+}
@@ -0,0 +1,281 @@
+package backend
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// exportCheckpointBlocks is the number of blocks written between two
+	// checkpoints of the progress of an export job, which resumes from the last
+	// checkpoint.
+	exportCheckpointBlocks = 1000
+
+	exportFormatCSV        = "csv"
+	exportFormatJSONLines  = "jsonl"
+	exportJobFileExtension = ".job"
+)
+
+var (
+	errExportDisabled   = errors.New("no validator history export directory configured")
+	errUnknownExportJob = errors.New("unknown validator history export job")
+	errExportJobRunning = errors.New("validator history export job is running")
+)
+
+// ValidatorHistoryOptions selects a server-side export of the validator
+// history, written to the export directory, rather than a page of results.
+type ValidatorHistoryOptions struct {
+	Format string `json:"format"` // csv or jsonl
+	Job    string `json:"job"`    // job to resume, the range being the one of the job
+}
+
+// ExportJob is the progress of a server-side export of the validator history.
+type ExportJob struct {
+	ID      string    `json:"id"`
+	Format  string    `json:"format"`
+	File    string    `json:"file"` // path of the export
+	From    uint64    `json:"from"`
+	To      uint64    `json:"to"`
+	Next    uint64    `json:"next"`   // next block to export
+	Offset  int64     `json:"offset"` // size of the export up to the next block
+	Done    bool      `json:"done"`
+	Running bool      `json:"running"`
+	Error   string    `json:"error,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// historyExporter runs the export jobs, whose progress is checkpointed next to
+// the exports so they can be resumed, including after a restart.
+type historyExporter struct {
+	dir  string
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	lock    sync.Mutex
+	running map[string]*ExportJob
+	closed  bool
+}
+
+func newHistoryExporter(dir string) *historyExporter {
+	return &historyExporter{dir: dir, quit: make(chan struct{}), running: make(map[string]*ExportJob)}
+}
+
+func (e *historyExporter) jobPath(id string) string {
+	return filepath.Join(e.dir, id+exportJobFileExtension)
+}
+
+// start starts a new export job of the range, or resumes the given one.
+func (e *historyExporter) start(sb *backend, chain consensus.ChainHeaderReader, from, to uint64, options *ValidatorHistoryOptions) (*ExportJob, error) {
+	if e == nil {
+		return nil, errExportDisabled
+	}
+	var job *ExportJob
+	if options.Job != "" {
+		var err error
+		if job, err = e.load(options.Job); err != nil {
+			return nil, err
+		}
+		if job.Done {
+			return job, nil
+		}
+	} else {
+		if options.Format != exportFormatCSV && options.Format != exportFormatJSONLines {
+			return nil, fmt.Errorf("unsupported validator history export format %q", options.Format)
+		}
+		if from > to {
+			return nil, errors.New("start block number should be less than end block number")
+		}
+		if from == 0 {
+			from = 1
+		}
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		job = &ExportJob{ID: hex.EncodeToString(id), Format: options.Format, From: from, To: to, Next: from}
+		job.File = filepath.Join(e.dir, fmt.Sprintf("validators-%d-%d-%s.%s", from, to, job.ID, job.Format))
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.closed {
+		return nil, errExportDisabled
+	}
+	if _, ok := e.running[job.ID]; ok {
+		return nil, errExportJobRunning
+	}
+	if err := os.MkdirAll(e.dir, 0700); err != nil {
+		return nil, err
+	}
+	job.Running, job.Error = true, ""
+	if err := e.checkpoint(job); err != nil {
+		return nil, err
+	}
+	e.running[job.ID] = job
+	snapshot := *job
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		err := e.run(sb, chain, job)
+
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.running, job.ID)
+		job.Running = false
+		if err != nil {
+			job.Error = err.Error()
+			log.Error("Validator history export failed", "job", job.ID, "next", job.Next, "err", err)
+		} else if job.Done {
+			log.Info("Validator history exported", "job", job.ID, "file", job.File)
+		}
+		if err := e.checkpoint(job); err != nil {
+			log.Error("Failed to record the validator history export progress", "job", job.ID, "err", err)
+		}
+	}()
+	return &snapshot, nil
+}
+
+// run writes the entries of the job from its last checkpoint.
+func (e *historyExporter) run(sb *backend, chain consensus.ChainHeaderReader, job *ExportJob) error {
+	f, err := os.OpenFile(job.File, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// drop what was written after the last checkpoint
+	if err := f.Truncate(job.Offset); err != nil {
+		return err
+	}
+	if _, err := f.Seek(job.Offset, 0); err != nil {
+		return err
+	}
+	out := bufio.NewWriter(f)
+	offset := job.Offset
+	if offset == 0 && job.Format == exportFormatCSV {
+		n, _ := out.WriteString(csvHeader)
+		offset += int64(n)
+	}
+	w, err := newHistoryWalker(sb, chain, job.Next)
+	if err != nil {
+		return err
+	}
+	for w.number <= job.To {
+		select {
+		case <-e.quit:
+			return errors.New("node stopped")
+		default:
+		}
+		entry, err := w.next()
+		if err != nil {
+			return err
+		}
+		var row []byte
+		if job.Format == exportFormatCSV {
+			row = []byte(entry.csvRow())
+		} else {
+			if row, err = json.Marshal(entry); err != nil {
+				return err
+			}
+			row = append(row, '\n')
+		}
+		n, err := out.Write(row)
+		if err != nil {
+			return err
+		}
+		offset += int64(n)
+		if w.number > job.To || (w.number-job.From)%exportCheckpointBlocks == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			if err := f.Sync(); err != nil {
+				return err
+			}
+			e.lock.Lock()
+			job.Next, job.Offset, job.Done = w.number, offset, w.number > job.To
+			err := e.checkpoint(job)
+			e.lock.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkpoint records the progress of the job, replacing the previous record
+// atomically.
+func (e *historyExporter) checkpoint(job *ExportJob) error {
+	job.Updated = time.Now()
+	blob, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	tmp := e.jobPath(job.ID) + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, e.jobPath(job.ID))
+}
+
+// load reads the last checkpoint of the job.
+func (e *historyExporter) load(id string) (*ExportJob, error) {
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 16 {
+		return nil, errUnknownExportJob
+	}
+	blob, err := ioutil.ReadFile(e.jobPath(id))
+	if os.IsNotExist(err) {
+		return nil, errUnknownExportJob
+	} else if err != nil {
+		return nil, err
+	}
+	job := new(ExportJob)
+	if err := json.Unmarshal(blob, job); err != nil {
+		return nil, err
+	}
+	job.Running = false
+	return job, nil
+}
+
+// progress returns the progress of the job.
+func (e *historyExporter) progress(id string) (*ExportJob, error) {
+	if e == nil {
+		return nil, errExportDisabled
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if job, ok := e.running[id]; ok {
+		snapshot := *job
+		return &snapshot, nil
+	}
+	return e.load(id)
+}
+
+// close stops the running jobs, which can be resumed from their last
+// checkpoint.
+func (e *historyExporter) close() {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	if e.closed {
+		e.lock.Unlock()
+		return
+	}
+	e.closed = true
+	close(e.quit)
+	e.lock.Unlock()
+	e.wg.Wait()
+}
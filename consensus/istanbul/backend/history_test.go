@@ -0,0 +1,174 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryChain returns a chain of three headers over which the validators
+// A, B and C vote D in, the first two being sealed in round 0.
+func newHistoryChain(t *testing.T, exportDir string) (*core.BlockChain, *backend, []common.Address, []*types.Header, common.Address) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+	validators := make([]common.Address, len(names))
+	for i, name := range names {
+		validators[i] = accounts.address(name)
+	}
+	// order the names as the validator set does
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			if bytes.Compare(validators[i][:], validators[j][:]) > 0 {
+				validators[i], validators[j] = validators[j], validators[i]
+				names[i], names[j] = names[j], names[i]
+			}
+		}
+	}
+	genesis := &core.Genesis{
+		Difficulty: defaultDifficulty,
+		Mixhash:    types.IstanbulDigest,
+	}
+	genesis.ExtraData, _ = prepareExtra(genesis.ToBlock(nil).Header(), validators)
+	db := rawdb.NewMemoryDatabase()
+	genesis.MustCommit(db)
+
+	config := *istanbul.DefaultConfig
+	config.ExportDir = exportDir
+	engine := New(&config, accounts.accounts[names[0]], db).(*backend)
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil, nil)
+	require.NoError(t, err)
+
+	candidate := accounts.address("D")
+	parent := chain.GetHeaderByNumber(0)
+	var headers []*types.Header
+	for i, sealer := range []string{names[0], names[1], names[0]} {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     big.NewInt(int64(i) + 1),
+			Difficulty: defaultDifficulty,
+			MixDigest:  types.IstanbulDigest,
+		}
+		if i < 2 {
+			header.Coinbase = candidate
+			copy(header.Nonce[:], nonceAuthVote)
+		}
+		header.Extra, _ = prepareExtra(header, validators)
+		accounts.sign(header, sealer)
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		headers = append(headers, header)
+		parent = header
+	}
+	return chain, engine, validators, headers, candidate
+}
+
+func TestValidatorHistory(t *testing.T) {
+	chain, engine, validators, headers, candidate := newHistoryChain(t, "")
+	defer chain.Stop()
+
+	_, err := engine.validatorHistory(chain, 0, 5)
+	assert.Equal(t, errUnknownBlock, err)
+	page, err := engine.validatorHistory(chain, 0, 3)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 3)
+	assert.Nil(t, page.Next)
+
+	first, second, third := page.Entries[0], page.Entries[1], page.Entries[2]
+	assert.Equal(t, uint64(1), first.Number)
+	assert.Equal(t, headers[0].Hash(), first.Hash)
+	assert.Equal(t, validators[0], first.Proposer)
+	require.NotNil(t, first.Round)
+	assert.Equal(t, uint64(0), *first.Round)
+	assert.Equal(t, validators, first.Validators)
+	assert.Equal(t, validatorSetHash(validators), first.ValidatorSetHash)
+	assert.Equal(t, &Vote{Validator: validators[0], Block: 1, Address: candidate, Authorize: true}, first.Vote)
+	assert.Empty(t, first.Changes)
+
+	// the second vote passes, D is eligible from the third block
+	require.NotNil(t, second.Round)
+	assert.Equal(t, uint64(0), *second.Round)
+	assert.Nil(t, second.Validators, "unchanged validators")
+	require.Len(t, second.Changes, 1)
+	assert.Equal(t, candidate, second.Changes[0].Address)
+	assert.True(t, second.Changes[0].Added)
+	assert.Equal(t, []*Vote{first.Vote, second.Vote}, second.Changes[0].Votes)
+
+	assert.Len(t, third.Validators, 4)
+	assert.NotEqual(t, first.ValidatorSetHash, third.ValidatorSetHash)
+	assert.Nil(t, third.Vote)
+	require.NotNil(t, third.Round)
+	assert.Equal(t, third.Proposer, validators[0])
+
+	row := third.csvRow()
+	assert.True(t, strings.HasPrefix(row, "3,"+headers[2].Hash().Hex()+","), row)
+	assert.Regexp(t, `,\+`+candidate.Hex()+`\[`+validators[0].Hex()+`@1 `+validators[1].Hex()+`@2\]\n$`, second.csvRow())
+}
+
+func TestValidatorHistoryExport(t *testing.T) {
+	chain, engine, _, _, _ := newHistoryChain(t, t.TempDir())
+	defer chain.Stop()
+	defer engine.Close()
+
+	_, err := engine.exports.start(engine, chain, 1, 3, &ValidatorHistoryOptions{Format: "xml"})
+	assert.Error(t, err)
+	_, err = engine.exports.progress("0011223344556677")
+	assert.Equal(t, errUnknownExportJob, err)
+
+	wait := func(id string) *ExportJob {
+		for i := 0; i < 100; i++ {
+			job, err := engine.exports.progress(id)
+			require.NoError(t, err)
+			if !job.Running {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("export job %s still running", id)
+		return nil
+	}
+	for format, lines := range map[string]int{exportFormatCSV: 4, exportFormatJSONLines: 3} {
+		job, err := engine.exports.start(engine, chain, 1, 3, &ValidatorHistoryOptions{Format: format})
+		require.NoError(t, err, format)
+		job = wait(job.ID)
+		require.Empty(t, job.Error, format)
+		assert.True(t, job.Done, format)
+		assert.Equal(t, uint64(4), job.Next, format)
+
+		blob, err := ioutil.ReadFile(job.File)
+		require.NoError(t, err, format)
+		assert.Equal(t, int64(len(blob)), job.Offset, format)
+		rows := strings.Split(strings.TrimSuffix(string(blob), "\n"), "\n")
+		assert.Len(t, rows, lines, format)
+		if format == exportFormatJSONLines {
+			var entry ValidatorHistoryEntry
+			require.NoError(t, json.Unmarshal([]byte(rows[2]), &entry))
+			assert.Equal(t, uint64(3), entry.Number)
+		}
+
+		// resuming a completed job leaves it untouched
+		resumed, err := engine.exports.start(engine, chain, 0, 0, &ValidatorHistoryOptions{Job: job.ID})
+		require.NoError(t, err)
+		assert.True(t, resumed.Done)
+	}
+
+	// failed jobs resume from their last checkpoint
+	job, err := engine.exports.start(engine, chain, 1, 5, &ValidatorHistoryOptions{Format: exportFormatJSONLines})
+	require.NoError(t, err)
+	job = wait(job.ID)
+	assert.Equal(t, errUnknownBlock.Error(), job.Error)
+	assert.False(t, job.Done)
+	assert.Equal(t, uint64(1), job.Next)
+}
@@ -0,0 +1,300 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// keyRotationMsgPrefix marks key rotation announcements gossiped over the
+// istanbul protocol. Consensus messages are RLP lists and never start with it.
+const keyRotationMsgPrefix = 0x00
+
+var (
+	// errKeyRotationDisabled is returned when a key rotation is announced or
+	// voted on before the key rotation fork block.
+	errKeyRotationDisabled = errors.New("istanbul key rotation is not enabled")
+	// errInvalidKeyRotation is returned if a key rotation is malformed, stale or
+	// not signed by the rotated validator.
+	errInvalidKeyRotation = errors.New("invalid key rotation")
+)
+
+// KeyRotation is a validator key rotation being voted on. Once a majority of
+// the validators voted for it, it is scheduled and the validator moves to the
+// new address atomically at the activation block.
+type KeyRotation struct {
+	New        common.Address   `json:"new"`        // Address the validator rotates to
+	Activation uint64           `json:"activation"` // First block to be sealed with the new key
+	Votes      []common.Address `json:"votes"`      // Validators that voted for the rotation
+	Scheduled  bool             `json:"scheduled"`  // Whether the rotation reached a majority
+}
+
+// keyRotationSigData returns the data signed by the old key of a validator to
+// announce a key rotation. It is bound to the chain and the activation block so
+// announcements cannot be replayed.
+func keyRotationSigData(chainID *big.Int, rotation *types.IstanbulKeyRotation) []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{chainID, rotation.Old, rotation.New, rotation.Activation})
+	return data
+}
+
+// keyRotationEnabled reports whether key rotations may be voted on in the
+// given block.
+func (sb *backend) keyRotationEnabled(number uint64) bool {
+	return sb.config.KeyRotationBlock != nil && sb.config.KeyRotationBlock.Cmp(new(big.Int).SetUint64(number)) <= 0
+}
+
+// verifyKeyRotation checks that an announcement can be voted on in the given
+// block on top of the given snapshot.
+func verifyKeyRotation(snap *Snapshot, chainID *big.Int, rotation *types.IstanbulKeyRotation, number uint64) error {
+	if rotation.Activation <= number || rotation.New == (common.Address{}) {
+		return errInvalidKeyRotation
+	}
+	if _, v := snap.ValSet.GetByAddress(rotation.Old); v == nil {
+		return errInvalidKeyRotation
+	}
+	if _, v := snap.ValSet.GetByAddress(rotation.New); v != nil {
+		return errInvalidKeyRotation
+	}
+	if pending, ok := snap.KeyRotations[rotation.Old]; ok && pending.Scheduled && !pending.matches(rotation) {
+		return errInvalidKeyRotation
+	}
+	signer, err := istanbul.GetSignatureAddress(keyRotationSigData(chainID, rotation), rotation.Signature)
+	if err != nil || signer != rotation.Old {
+		return errInvalidKeyRotation
+	}
+	return nil
+}
+
+// verifyKeyRotations checks the key rotation votes of a header against the
+// snapshot of its parent.
+func (sb *backend) verifyKeyRotations(chain consensus.ChainHeaderReader, header *types.Header, snap *Snapshot) error {
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return err
+	}
+	if len(extra.KeyRotations) == 0 {
+		return nil
+	}
+	number := header.Number.Uint64()
+	if !sb.keyRotationEnabled(number) {
+		return errKeyRotationDisabled
+	}
+	if len(extra.KeyRotations) > 1 {
+		return errInvalidKeyRotation
+	}
+	return verifyKeyRotation(snap, chain.Config().ChainID, extra.KeyRotations[0], number)
+}
+
+// writeKeyRotations writes the key rotation votes into the extra-data field of
+// the given header.
+func writeKeyRotations(h *types.Header, rotations []*types.IstanbulKeyRotation) error {
+	istanbulExtra, err := types.ExtractIstanbulExtra(h)
+	if err != nil {
+		return err
+	}
+
+	istanbulExtra.KeyRotations = rotations
+	payload, err := rlp.EncodeToBytes(&istanbulExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.IstanbulExtraVanity], payload...)
+	return nil
+}
+
+// addKeyRotation adds a verified announcement to the ones this node votes on.
+func (sb *backend) addKeyRotation(rotation *types.IstanbulKeyRotation) {
+	sb.candidatesLock.Lock()
+	defer sb.candidatesLock.Unlock()
+
+	sb.keyRotations[rotation.Old] = rotation
+}
+
+// pendingKeyRotation returns an announcement this node has not voted on yet,
+// dropping the ones which became stale.
+func (sb *backend) pendingKeyRotation(chain consensus.ChainHeaderReader, snap *Snapshot, number uint64) *types.IstanbulKeyRotation {
+	if !sb.keyRotationEnabled(number) {
+		return nil
+	}
+	sb.candidatesLock.Lock()
+	defer sb.candidatesLock.Unlock()
+
+	for old, rotation := range sb.keyRotations {
+		if err := verifyKeyRotation(snap, chain.Config().ChainID, rotation, number); err != nil {
+			delete(sb.keyRotations, old)
+			continue
+		}
+		if pending, ok := snap.KeyRotations[old]; ok && pending.matches(rotation) && (pending.Scheduled || pending.hasVote(sb.Address())) {
+			continue
+		}
+		return rotation
+	}
+	return nil
+}
+
+// announceKeyRotation signs a rotation of this node's key to the given address
+// and gossips it to the validators.
+func (sb *backend) announceKeyRotation(chain consensus.ChainHeaderReader, newAddress common.Address, activation uint64) (*types.IstanbulKeyRotation, error) {
+	head := chain.CurrentHeader()
+	if !sb.keyRotationEnabled(head.Number.Uint64() + 1) {
+		return nil, errKeyRotationDisabled
+	}
+	snap, err := sb.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	rotation := &types.IstanbulKeyRotation{
+		Old:        sb.Address(),
+		New:        newAddress,
+		Activation: activation,
+	}
+	if rotation.Signature, err = sb.Sign(keyRotationSigData(chain.Config().ChainID, rotation)); err != nil {
+		return nil, err
+	}
+	if err := verifyKeyRotation(snap, chain.Config().ChainID, rotation, head.Number.Uint64()+1); err != nil {
+		return nil, err
+	}
+	sb.addKeyRotation(rotation)
+
+	payload, err := rlp.EncodeToBytes(rotation)
+	if err != nil {
+		return nil, err
+	}
+	return rotation, sb.Gossip(snap.ValSet, append([]byte{keyRotationMsgPrefix}, payload...))
+}
+
+// handleKeyRotationMsg handles an announcement gossiped by another validator,
+// relaying it if it is valid on top of the current head.
+func (sb *backend) handleKeyRotationMsg(data []byte) {
+	rotation := new(types.IstanbulKeyRotation)
+	if err := rlp.DecodeBytes(data[1:], rotation); err != nil {
+		sb.logger.Debug("Failed to decode key rotation announcement", "err", err)
+		return
+	}
+	head := sb.chain.CurrentHeader()
+	snap, err := sb.snapshot(sb.chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return
+	}
+	number := head.Number.Uint64() + 1
+	if !sb.keyRotationEnabled(number) {
+		return
+	}
+	if err := verifyKeyRotation(snap, sb.chain.Config().ChainID, rotation, number); err != nil {
+		sb.logger.Debug("Discarding key rotation announcement", "old", rotation.Old, "new", rotation.New, "activation", rotation.Activation, "err", err)
+		return
+	}
+	sb.logger.Info("Received key rotation announcement", "old", rotation.Old, "new", rotation.New, "activation", rotation.Activation)
+	sb.addKeyRotation(rotation)
+	sb.Gossip(snap.ValSet, data)
+}
+
+func (r *KeyRotation) matches(rotation *types.IstanbulKeyRotation) bool {
+	return r.New == rotation.New && r.Activation == rotation.Activation
+}
+
+func (r *KeyRotation) hasVote(validator common.Address) bool {
+	for _, vote := range r.Votes {
+		if vote == validator {
+			return true
+		}
+	}
+	return false
+}
+
+// applyKeyRotations tallies the key rotation votes of a header sealed by the
+// given validator and activates the rotations scheduled for the next block.
+func (s *Snapshot) applyKeyRotations(header *types.Header, validator common.Address) error {
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	if len(extra.KeyRotations) > 1 {
+		return errInvalidKeyRotation
+	}
+	for _, rotation := range extra.KeyRotations {
+		if _, v := s.ValSet.GetByAddress(rotation.Old); v == nil || rotation.Activation <= number {
+			return errInvalidKeyRotation
+		}
+		pending, ok := s.KeyRotations[rotation.Old]
+		if !ok || !pending.matches(rotation) {
+			if ok && pending.Scheduled {
+				return errInvalidKeyRotation
+			}
+			pending = &KeyRotation{New: rotation.New, Activation: rotation.Activation}
+			s.KeyRotations[rotation.Old] = pending
+		}
+		if !pending.hasVote(validator) {
+			pending.Votes = append(pending.Votes, validator)
+		}
+		if len(pending.Votes) > s.ValSet.Size()/2 {
+			pending.Scheduled = true
+		}
+	}
+	// Activate rotations in address order so the resulting set is deterministic
+	olds := make([]common.Address, 0, len(s.KeyRotations))
+	for old := range s.KeyRotations {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool { return bytes.Compare(olds[i][:], olds[j][:]) < 0 })
+
+	for _, old := range olds {
+		rotation := s.KeyRotations[old]
+		switch {
+		case rotation.Scheduled && rotation.Activation == number+1:
+			s.rotateKey(old, rotation.New)
+			delete(s.KeyRotations, old)
+		case rotation.Activation <= number+1:
+			// expired before reaching a majority
+			delete(s.KeyRotations, old)
+		}
+	}
+	return nil
+}
+
+// rotateKey replaces a validator by its new address, carrying over the votes
+// it cast and the ones cast on it.
+func (s *Snapshot) rotateKey(old, new common.Address) {
+	if _, v := s.ValSet.GetByAddress(old); v == nil {
+		return
+	}
+	if _, v := s.ValSet.GetByAddress(new); v != nil {
+		return
+	}
+	s.ValSet.RemoveValidator(old)
+	s.ValSet.AddValidator(new)
+
+	// Votes are shared between snapshots, so they are replaced rather than updated
+	for i, vote := range s.Votes {
+		if vote.Validator == old || vote.Address == old {
+			rotated := *vote
+			if rotated.Validator == old {
+				rotated.Validator = new
+			}
+			if rotated.Address == old {
+				rotated.Address = new
+			}
+			s.Votes[i] = &rotated
+		}
+	}
+	if tally, ok := s.Tally[old]; ok {
+		s.Tally[new] = tally
+		delete(s.Tally, old)
+	}
+	for _, rotation := range s.KeyRotations {
+		for i, vote := range rotation.Votes {
+			if vote == old {
+				rotation.Votes[i] = new
+			}
+		}
+	}
+}
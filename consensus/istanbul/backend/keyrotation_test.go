@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/istanbul/validator"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRotation(t *testing.T) {
+	var (
+		accounts   = newTesterAccountPool()
+		chainID    = big.NewInt(1337)
+		validators = []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+		snap       = newSnapshot(30000, 0, common.Hash{}, validator.NewSet(validators, istanbul.RoundRobin))
+	)
+	rotation := &types.IstanbulKeyRotation{Old: accounts.address("A"), New: accounts.address("N"), Activation: 4}
+	sig, err := crypto.Sign(crypto.Keccak256(keyRotationSigData(chainID, rotation)), accounts.accounts["A"])
+	require.NoError(t, err)
+	rotation.Signature = sig
+
+	assert.NoError(t, verifyKeyRotation(snap, chainID, rotation, 1))
+	assert.Equal(t, errInvalidKeyRotation, verifyKeyRotation(snap, big.NewInt(1), rotation, 1), "signed for another chain")
+	assert.Equal(t, errInvalidKeyRotation, verifyKeyRotation(snap, chainID, rotation, 4), "activation reached")
+	forged := *rotation
+	forged.New = accounts.address("M")
+	assert.Equal(t, errInvalidKeyRotation, verifyKeyRotation(snap, chainID, &forged, 1), "not signed by the old key")
+
+	var parent *types.Header
+	makeHeader := func(number int64, sealer string, rotations ...*types.IstanbulKeyRotation) *types.Header {
+		header := &types.Header{
+			Number:     big.NewInt(number),
+			Difficulty: defaultDifficulty,
+			MixDigest:  types.IstanbulDigest,
+		}
+		if parent != nil {
+			header.ParentHash = parent.Hash()
+		}
+		header.Extra, _ = prepareExtra(header, validators)
+		require.NoError(t, writeKeyRotations(header, rotations))
+		accounts.sign(header, sealer)
+		return header
+	}
+	// two of the three validators vote for the rotation
+	h1 := makeHeader(1, "A", rotation)
+	parent = h1
+	h2 := makeHeader(2, "B", rotation)
+	parent = h2
+	h3 := makeHeader(3, "C")
+
+	voted, err := snap.apply([]*types.Header{h1, h2})
+	require.NoError(t, err)
+	require.Contains(t, voted.KeyRotations, accounts.address("A"))
+	assert.True(t, voted.KeyRotations[accounts.address("A")].Scheduled)
+	_, v := voted.ValSet.GetByAddress(accounts.address("A"))
+	assert.NotNil(t, v, "old key valid before the activation block")
+	assert.Empty(t, snap.KeyRotations, "parent snapshot untouched")
+
+	rotated, err := voted.apply([]*types.Header{h3})
+	require.NoError(t, err)
+	_, v = rotated.ValSet.GetByAddress(accounts.address("A"))
+	assert.Nil(t, v, "old key invalid from the activation block")
+	_, v = rotated.ValSet.GetByAddress(accounts.address("N"))
+	assert.NotNil(t, v, "new key valid from the activation block")
+	assert.Empty(t, rotated.KeyRotations)
+
+	// the new key seals the activation block, the old one cannot
+	parent = h3
+	_, err = rotated.apply([]*types.Header{makeHeader(4, "N")})
+	assert.NoError(t, err)
+	_, err = rotated.apply([]*types.Header{makeHeader(4, "A")})
+	assert.Equal(t, errUnauthorized, err)
+
+	// replaying the stale announcement is rejected
+	_, err = rotated.apply([]*types.Header{makeHeader(4, "B", rotation)})
+	assert.Equal(t, errInvalidKeyRotation, err)
+	assert.Equal(t, errInvalidKeyRotation, verifyKeyRotation(rotated, chainID, rotation, 4))
+}
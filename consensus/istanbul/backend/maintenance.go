@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+var errZeroMaintenanceBlocks = errors.New("maintenance window must span at least one block")
+
+// MaintenanceWindow implements consensus.MaintenanceScheduler. The proposers
+// of the upcoming blocks are derived from the validator set at the head and
+// the proposer selection policy, assuming every block is sealed in round 0.
+// A round change or a validator vote reschedules the rotation, callers should
+// recompute the window when the head moves.
+func (sb *backend) MaintenanceWindow(chain consensus.ChainHeaderReader, blocks uint64) (*consensus.MaintenanceWindow, error) {
+	if blocks == 0 {
+		return nil, errZeroMaintenanceBlocks
+	}
+	head := chain.CurrentHeader()
+	snap, err := sb.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var lastProposer common.Address
+	if head.Number.Sign() > 0 {
+		if lastProposer, err = sb.Author(head); err != nil {
+			return nil, err
+		}
+	}
+	valSet := snap.ValSet.Copy()
+	position, _ := valSet.GetByAddress(sb.Address())
+
+	window := &consensus.MaintenanceWindow{
+		Head:       head.Number.Uint64(),
+		Start:      head.Number.Uint64() + 1,
+		End:        head.Number.Uint64() + blocks,
+		Position:   position,
+		Validators: valSet.Size(),
+		Policy:     proposerPolicyName(valSet.Policy()),
+	}
+	if position < 0 {
+		return window, nil
+	}
+	// walk the rotation until enough consecutive blocks are proposed by other
+	// validators, a round robin rotation comes back to the node every size blocks
+	var run uint64
+	for offset := uint64(1); offset <= blocks+uint64(valSet.Size()); offset++ {
+		valSet.CalcProposer(lastProposer, 0)
+		lastProposer = valSet.GetProposer().Address()
+
+		number := head.Number.Uint64() + offset
+		if lastProposer == sb.Address() {
+			if window.NextProposal == nil {
+				window.NextProposal = &number
+			}
+			run = 0
+			continue
+		}
+		if run++; run == blocks {
+			window.Start, window.End = number-blocks+1, number
+			return window, nil
+		}
+	}
+	return nil, fmt.Errorf("no window of %d blocks without proposing under the %s policy", blocks, window.Policy)
+}
+
+func proposerPolicyName(policy istanbul.ProposerPolicy) string {
+	switch policy {
+	case istanbul.RoundRobin:
+		return "roundrobin"
+	case istanbul.Sticky:
+		return "sticky"
+	}
+	return fmt.Sprintf("unknown(%d)", policy)
+}
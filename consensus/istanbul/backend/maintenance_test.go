@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	chain, engine := newBlockChain(4)
+	defer engine.Stop()
+
+	// the node proposes block 1 and is followed by the other three validators
+	window, err := engine.MaintenanceWindow(chain, 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), window.Start)
+	assert.Equal(t, uint64(4), window.End)
+	assert.Equal(t, 0, window.Position)
+	assert.Equal(t, 4, window.Validators)
+	assert.Equal(t, "roundrobin", window.Policy)
+	require.NotNil(t, window.NextProposal)
+	assert.Equal(t, uint64(1), *window.NextProposal)
+
+	_, err = engine.MaintenanceWindow(chain, 4)
+	assert.Error(t, err, "no window longer than the rotation")
+
+	_, err = engine.MaintenanceWindow(chain, 0)
+	assert.Equal(t, errZeroMaintenanceBlocks, err)
+
+	// a node outside of the validator set is always safe to restart
+	engine.address = common.Address{0x01}
+	window, err = engine.MaintenanceWindow(chain, 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), window.Start)
+	assert.Equal(t, uint64(10), window.End)
+	assert.Equal(t, -1, window.Position)
+	assert.Nil(t, window.NextProposal)
+}
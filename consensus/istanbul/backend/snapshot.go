@@ -56,6 +56,8 @@ type Snapshot struct {
 	Votes  []*Vote                  // List of votes cast in chronological order
 	Tally  map[common.Address]Tally // Current vote tally to avoid recalculating
 	ValSet istanbul.ValidatorSet    // Set of authorized validators at this moment
+
+	KeyRotations map[common.Address]*KeyRotation // Quorum: key rotations voted on or scheduled, by old address
 }
 
 // newSnapshot create a new snapshot with the specified startup parameters. This
@@ -68,6 +70,8 @@ func newSnapshot(epoch uint64, number uint64, hash common.Hash, valSet istanbul.
 		Hash:   hash,
 		ValSet: valSet,
 		Tally:  make(map[common.Address]Tally),
+
+		KeyRotations: make(map[common.Address]*KeyRotation),
 	}
 	return snap
 }
@@ -105,12 +109,19 @@ func (s *Snapshot) copy() *Snapshot {
 		ValSet: s.ValSet.Copy(),
 		Votes:  make([]*Vote, len(s.Votes)),
 		Tally:  make(map[common.Address]Tally),
+
+		KeyRotations: make(map[common.Address]*KeyRotation, len(s.KeyRotations)),
 	}
 
 	for address, tally := range s.Tally {
 		cpy.Tally[address] = tally
 	}
 	copy(cpy.Votes, s.Votes)
+	for address, rotation := range s.KeyRotations {
+		rotationCpy := *rotation
+		rotationCpy.Votes = append([]common.Address{}, rotation.Votes...)
+		cpy.KeyRotations[address] = &rotationCpy
+	}
 
 	return cpy
 }
@@ -183,6 +194,13 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		if number%s.Epoch == 0 {
 			snap.Votes = nil
 			snap.Tally = make(map[common.Address]Tally)
+
+			// Quorum: drop the key rotations which have not reached a majority
+			for old, rotation := range snap.KeyRotations {
+				if !rotation.Scheduled {
+					delete(snap.KeyRotations, old)
+				}
+			}
 		}
 		// Resolve the authorization key and check against validators
 		validator, err := ecrecover(header)
@@ -251,6 +269,10 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 			}
 			delete(snap.Tally, header.Coinbase)
 		}
+		// Quorum: tally the key rotation votes and activate the scheduled ones
+		if err := snap.applyKeyRotations(header, validator); err != nil {
+			return nil, err
+		}
 	}
 	snap.Number += uint64(len(headers))
 	snap.Hash = headers[len(headers)-1].Hash()
@@ -284,6 +306,8 @@ type snapshotJSON struct {
 	// for validator set
 	Validators []common.Address        `json:"validators"`
 	Policy     istanbul.ProposerPolicy `json:"policy"`
+
+	KeyRotations map[common.Address]*KeyRotation `json:"keyRotations,omitempty"`
 }
 
 func (s *Snapshot) toJSONStruct() *snapshotJSON {
@@ -295,6 +319,8 @@ func (s *Snapshot) toJSONStruct() *snapshotJSON {
 		Tally:      s.Tally,
 		Validators: s.validators(),
 		Policy:     s.ValSet.Policy(),
+
+		KeyRotations: s.KeyRotations,
 	}
 }
 
@@ -311,6 +337,10 @@ func (s *Snapshot) UnmarshalJSON(b []byte) error {
 	s.Votes = j.Votes
 	s.Tally = j.Tally
 	s.ValSet = validator.NewSet(j.Validators, j.Policy)
+	s.KeyRotations = j.KeyRotations
+	if s.KeyRotations == nil {
+		s.KeyRotations = make(map[common.Address]*KeyRotation)
+	}
 	return nil
 }
 
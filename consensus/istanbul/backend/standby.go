@@ -0,0 +1,370 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	istanbulCore "github.com/ethereum/go-ethereum/consensus/istanbul/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// A standby validator runs with the key of a validator, follows the chain and
+// observes the consensus messages without signing any, until it is promoted.
+// Other validators answer the standby queries with the time they last received
+// a consensus message from a node with the key, so a standby is only promoted
+// once the key has been silent for long enough on both its side and theirs.
+const (
+	standbyQueryMsgPrefix  = 0x01 // query of a standby for the activity of its key
+	standbyAnswerMsgPrefix = 0x02 // answer of a validator to a standby query
+
+	// standbyQueryTimeout is how long a standby waits for the answers of the
+	// validators to its query.
+	standbyQueryTimeout = 2 * time.Second
+)
+
+var (
+	// errStandby is returned when the node is asked to sign or seal while in
+	// standby.
+	errStandby = errors.New("validator is in standby, consensus participation is disabled")
+	// errNotStandby is returned when promoting a node which is not in standby.
+	errNotStandby = errors.New("validator is not in standby")
+	// errInvalidFencingToken is returned if a fencing token is not signed by the
+	// validator key or does not match the local chain.
+	errInvalidFencingToken = errors.New("invalid fencing token")
+	// errNoStandbyAnswer is returned when no validator answered the query of a
+	// standby being promoted.
+	errNoStandbyAnswer = errors.New("no validator answered the standby query")
+)
+
+// FencingToken is minted by a validator stepping down in favour of its
+// standby, recording the head it stopped at.
+type FencingToken struct {
+	Number    uint64        `json:"number"`
+	Hash      common.Hash   `json:"hash"`
+	Time      uint64        `json:"time"`      // unix time the validator stepped down
+	Signature hexutil.Bytes `json:"signature"` // by the validator key
+}
+
+// fencingTokenSigData returns the data signed by a validator stepping down,
+// bound to the chain so tokens cannot be replayed on other networks.
+func fencingTokenSigData(chainID *big.Int, token *FencingToken) []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{chainID, "fence", token.Number, token.Hash, token.Time})
+	return data
+}
+
+type standbyQuery struct {
+	Nonce uint64
+}
+
+type standbyAnswer struct {
+	Nonce uint64
+	Since uint64 // seconds since the last consensus message of the queried key, math.MaxUint64 if none
+}
+
+// standbyState tracks the activity of the validator keys for the standby
+// queries, and that of the local key while in standby.
+type standbyState struct {
+	lock     sync.Mutex
+	enabled  bool
+	since    time.Time // start of the standby
+	lastSeen time.Time // last consensus message signed by the local key observed in standby
+
+	senders *lru.ARCCache                 // time of the last consensus message received from each peer
+	answers map[uint64]chan standbyAnswer // answers to the pending queries by nonce
+}
+
+func newStandbyState(enabled bool) *standbyState {
+	senders, _ := lru.NewARC(inmemoryPeers)
+	return &standbyState{
+		enabled: enabled,
+		since:   now(),
+		senders: senders,
+		answers: make(map[uint64]chan standbyAnswer),
+	}
+}
+
+func (s *standbyState) isEnabled() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.enabled
+}
+
+// received records a consensus message received from the peer.
+func (s *standbyState) received(addr common.Address) {
+	s.senders.Add(addr, now())
+}
+
+// lastReceived returns the time of the last consensus message received from
+// the peer, the zero time if none.
+func (s *standbyState) lastReceived(addr common.Address) time.Time {
+	if t, ok := s.senders.Get(addr); ok {
+		return t.(time.Time)
+	}
+	return time.Time{}
+}
+
+// isConsensusMsg reports whether the istanbul message is a consensus message,
+// which are RLP lists, rather than one handled by the backend.
+func isConsensusMsg(data []byte) bool {
+	return len(data) > 0 && data[0] >= 0xc0
+}
+
+// observe checks whether a consensus message received in standby is signed by
+// the local key.
+func (sb *backend) observe(data []byte) {
+	signer, err := istanbulCore.MessageSigner(data)
+	if err != nil || signer != sb.address {
+		return
+	}
+	sb.standby.lock.Lock()
+	sb.standby.lastSeen = now()
+	sb.standby.lock.Unlock()
+	sb.logger.Warn("Observed a consensus message signed by the validator key in standby", "address", sb.address)
+}
+
+// handleStandbyQuery answers the query of a standby with the time this node
+// last received a consensus message from a node with its key.
+func (sb *backend) handleStandbyQuery(addr common.Address, data []byte) {
+	var query standbyQuery
+	if err := rlp.DecodeBytes(data[1:], &query); err != nil {
+		sb.logger.Debug("Failed to decode standby query", "err", err)
+		return
+	}
+	answer := standbyAnswer{Nonce: query.Nonce, Since: math.MaxUint64}
+	if last := sb.standby.lastReceived(addr); !last.IsZero() {
+		answer.Since = uint64(now().Sub(last) / time.Second)
+	}
+	payload, err := rlp.EncodeToBytes(&answer)
+	if err != nil || sb.broadcaster == nil {
+		return
+	}
+	for _, p := range sb.broadcaster.FindPeers(map[common.Address]bool{addr: true}) {
+		go p.SendConsensus(istanbulMsg, append([]byte{standbyAnswerMsgPrefix}, payload...))
+	}
+}
+
+// handleStandbyAnswer passes the answer to its pending query, if any.
+func (sb *backend) handleStandbyAnswer(data []byte) {
+	var answer standbyAnswer
+	if err := rlp.DecodeBytes(data[1:], &answer); err != nil {
+		sb.logger.Debug("Failed to decode standby answer", "err", err)
+		return
+	}
+	sb.standby.lock.Lock()
+	ch, ok := sb.standby.answers[answer.Nonce]
+	sb.standby.lock.Unlock()
+	if ok {
+		select {
+		case ch <- answer:
+		default:
+		}
+	}
+}
+
+// queryValidators asks the other validators when they last received a
+// consensus message from a node with the local key, returning the most recent
+// time any did, or errNoStandbyAnswer if none of them answered.
+func (sb *backend) queryValidators(chain consensus.ChainHeaderReader) (time.Time, error) {
+	head := chain.CurrentHeader()
+	snap, err := sb.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	others := snap.ValSet.Size()
+	if _, v := snap.ValSet.GetByAddress(sb.address); v != nil {
+		others--
+	}
+	if others == 0 {
+		return time.Time{}, nil
+	}
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return time.Time{}, err
+	}
+	query := standbyQuery{Nonce: binary.BigEndian.Uint64(nonce[:])}
+	payload, err := rlp.EncodeToBytes(&query)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ch := make(chan standbyAnswer, others)
+	sb.standby.lock.Lock()
+	sb.standby.answers[query.Nonce] = ch
+	sb.standby.lock.Unlock()
+	defer func() {
+		sb.standby.lock.Lock()
+		delete(sb.standby.answers, query.Nonce)
+		sb.standby.lock.Unlock()
+	}()
+	sb.Gossip(snap.ValSet, append([]byte{standbyQueryMsgPrefix}, payload...))
+
+	var (
+		last     time.Time
+		answered int
+		timeout  = time.After(standbyQueryTimeout)
+	)
+	for answered < others {
+		select {
+		case answer := <-ch:
+			answered++
+			if answer.Since != math.MaxUint64 {
+				if seen := now().Add(-time.Duration(answer.Since) * time.Second); seen.After(last) {
+					last = seen
+				}
+			}
+		case <-timeout:
+			if answered == 0 {
+				return time.Time{}, errNoStandbyAnswer
+			}
+			return last, nil
+		}
+	}
+	return last, nil
+}
+
+// lastSignedBlock returns the time of the most recent block within the window
+// proposed or committed by the local key, the zero time if none.
+func (sb *backend) lastSignedBlock(chain consensus.ChainHeaderReader, window time.Duration) time.Time {
+	cutoff := now().Add(-window)
+	for header := chain.CurrentHeader(); header != nil && header.Number.Sign() > 0; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+		sealed := time.Unix(int64(header.Time), 0)
+		if sealed.Before(cutoff) {
+			break
+		}
+		if author, err := sb.Author(header); err == nil && author == sb.address {
+			return sealed
+		}
+		committers, _ := sb.Signers(header)
+		for _, committer := range committers {
+			if committer == sb.address {
+				return sealed
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// mintFencingToken stops the participation of the validator in consensus,
+// putting it in standby, and returns the token promoting its standby.
+func (sb *backend) mintFencingToken() (*FencingToken, error) {
+	sb.coreMu.Lock()
+	defer sb.coreMu.Unlock()
+	if sb.standby.isEnabled() {
+		return nil, errStandby
+	}
+	if sb.chain == nil {
+		return nil, istanbul.ErrStoppedEngine
+	}
+	if sb.coreStarted {
+		if err := sb.core.Stop(); err != nil {
+			return nil, err
+		}
+		sb.coreStarted = false
+	}
+	head := sb.chain.CurrentHeader()
+	token := &FencingToken{Number: head.Number.Uint64(), Hash: head.Hash(), Time: uint64(now().Unix())}
+	sig, err := crypto.Sign(crypto.Keccak256(fencingTokenSigData(sb.chain.Config().ChainID, token)), sb.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	token.Signature = sig
+
+	sb.standby.lock.Lock()
+	sb.standby.enabled, sb.standby.since, sb.standby.lastSeen = true, now(), time.Time{}
+	sb.standby.lock.Unlock()
+	sb.logger.Info("Validator stepped down to standby", "address", sb.address, "number", token.Number, "hash", token.Hash)
+	return token, nil
+}
+
+// verifyFencingToken checks the token was minted with the local key on the
+// local chain.
+func (sb *backend) verifyFencingToken(chain consensus.ChainHeaderReader, token *FencingToken) error {
+	signer, err := istanbul.GetSignatureAddress(fencingTokenSigData(chain.Config().ChainID, token), token.Signature)
+	if err != nil || signer != sb.address {
+		return errInvalidFencingToken
+	}
+	if header := chain.GetHeaderByNumber(token.Number); header == nil || header.Hash() != token.Hash {
+		return fmt.Errorf("%w: block %d %s is not in the local chain", errInvalidFencingToken, token.Number, token.Hash.TerminalString())
+	}
+	return nil
+}
+
+// promoteStandby starts the participation of the standby in consensus once no
+// block or consensus message signed by the key was observed for two block
+// periods, locally and by the other validators. Without a fencing token the
+// key must also have been observed for the standby observation window.
+func (sb *backend) promoteStandby(token *FencingToken) error {
+	sb.coreMu.RLock()
+	chain := sb.chain
+	sb.coreMu.RUnlock()
+	if !sb.standby.isEnabled() {
+		return errNotStandby
+	}
+	if chain == nil {
+		return istanbul.ErrStoppedEngine
+	}
+	window := 2 * time.Duration(sb.config.BlockPeriod) * time.Second
+	if token != nil {
+		if err := sb.verifyFencingToken(chain, token); err != nil {
+			return err
+		}
+	} else if observation := time.Duration(sb.config.StandbyObservationWindow) * time.Second; observation > window {
+		window = observation
+	}
+
+	sb.standby.lock.Lock()
+	since, last := sb.standby.since, sb.standby.lastSeen
+	sb.standby.lock.Unlock()
+	if token != nil {
+		if stepped := time.Unix(int64(token.Time), 0); stepped.After(last) {
+			last = stepped
+		}
+	}
+	if observed := now().Sub(since); observed < window && token == nil {
+		return fmt.Errorf("key observed for %v in standby, %v required", observed.Round(time.Second), window)
+	}
+	if block := sb.lastSignedBlock(chain, window); block.After(last) {
+		last = block
+	}
+	peers, err := sb.queryValidators(chain)
+	if err != nil {
+		return err
+	}
+	if peers.After(last) {
+		last = peers
+	}
+	if !last.IsZero() {
+		if silent := now().Sub(last); silent < window {
+			return fmt.Errorf("key active %v ago, %v of inactivity required", silent.Round(time.Second), window)
+		}
+	}
+
+	sb.coreMu.Lock()
+	defer sb.coreMu.Unlock()
+	sb.standby.lock.Lock()
+	if !sb.standby.enabled {
+		sb.standby.lock.Unlock()
+		return errNotStandby
+	}
+	sb.standby.enabled = false
+	sb.standby.lock.Unlock()
+	if !sb.coreStarted {
+		if err := sb.core.Start(); err != nil {
+			return err
+		}
+		sb.coreStarted = true
+	}
+	sb.logger.Info("Standby promoted to validator", "address", sb.address, "fenced", token != nil)
+	return nil
+}
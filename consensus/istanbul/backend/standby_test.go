@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandbyPromotion(t *testing.T) {
+	chain, engine := newBlockChain(1)
+	defer engine.Stop()
+	defer func(original func() time.Time) { now = original }(now)
+	var clock time.Time
+	now = func() time.Time { return clock }
+	clock = time.Now()
+
+	token, err := engine.mintFencingToken()
+	require.NoError(t, err)
+	assert.Equal(t, chain.CurrentHeader().Hash(), token.Hash)
+	assert.False(t, engine.coreStarted)
+
+	// a standby neither seals nor signs
+	block := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+	assert.Equal(t, errStandby, engine.Seal(chain, block, make(chan *types.Block, 1), make(chan struct{})))
+	_, err = engine.Sign([]byte("data"))
+	assert.Equal(t, errStandby, err)
+	_, err = engine.mintFencingToken()
+	assert.Equal(t, errStandby, err)
+
+	forged := *token
+	forged.Hash = common.Hash{0x01}
+	assert.True(t, errors.Is(engine.promoteStandby(&forged), errInvalidFencingToken))
+
+	// the key stepped down with the token less than two block periods ago
+	clock = clock.Add(time.Second)
+	assert.Error(t, engine.promoteStandby(token))
+	// without token the observation window applies
+	clock = clock.Add(2 * time.Second)
+	assert.Error(t, engine.promoteStandby(nil))
+
+	require.NoError(t, engine.promoteStandby(token))
+	assert.True(t, engine.coreStarted)
+	_, err = engine.Sign([]byte("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, errNotStandby, engine.promoteStandby(token))
+}
+
+func TestStandbyObservationWindow(t *testing.T) {
+	_, engine := newBlockChain(1)
+	defer engine.Stop()
+	defer func(original func() time.Time) { now = original }(now)
+	var clock time.Time
+	now = func() time.Time { return clock }
+	clock = time.Now()
+
+	_, err := engine.mintFencingToken()
+	require.NoError(t, err)
+
+	window := time.Duration(engine.config.StandbyObservationWindow) * time.Second
+	clock = clock.Add(window - time.Second)
+	assert.Error(t, engine.promoteStandby(nil))
+
+	// a consensus message of the key observed in standby restarts the window
+	clock = clock.Add(time.Second)
+	engine.standby.lastSeen = clock.Add(-time.Second)
+	assert.Error(t, engine.promoteStandby(nil))
+	clock = clock.Add(window)
+	assert.NoError(t, engine.promoteStandby(nil))
+}
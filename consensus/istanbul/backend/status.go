@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SignerActivity is the participation of a validator in the blocks of a range
+// it was eligible to seal.
+type SignerActivity struct {
+	Proposed uint64 `json:"proposed"`
+	Sealed   uint64 `json:"sealed"` // blocks its committed seal is part of
+	Missed   uint64 `json:"missed"` // blocks committed without its seal
+}
+
+// signerActivity returns the activity of the validators in the blocks
+// [from, to], read from the committed seals of the headers and the voting
+// snapshots, and the number of blocks whose committed seals were pruned.
+func (sb *backend) signerActivity(chain consensus.ChainHeaderReader, from, to uint64) (map[common.Address]*SignerActivity, uint64, error) {
+	w, err := newHistoryWalker(sb, chain, from)
+	if err != nil {
+		return nil, 0, err
+	}
+	activity := make(map[common.Address]*SignerActivity)
+	get := func(validator common.Address) *SignerActivity {
+		if activity[validator] == nil {
+			activity[validator] = new(SignerActivity)
+		}
+		return activity[validator]
+	}
+	pruned := uint64(0)
+	for w.number <= to {
+		eligible := w.parent.validators()
+		entry, err := w.next()
+		if err != nil {
+			return nil, 0, err
+		}
+		get(entry.Proposer).Proposed++
+
+		header := chain.GetHeader(entry.Hash, entry.Number)
+		if header == nil {
+			return nil, 0, errUnknownBlock
+		}
+		extra, err := types.ExtractIstanbulExtra(header)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(extra.CommittedSeal) == 0 {
+			// the committed seals of the ancient headers may be pruned
+			pruned++
+			continue
+		}
+		committers, err := sb.Signers(header)
+		if err != nil {
+			return nil, 0, err
+		}
+		sealed := make(map[common.Address]bool, len(committers))
+		for _, committer := range committers {
+			sealed[committer] = true
+		}
+		for _, validator := range eligible {
+			if sealed[validator] {
+				get(validator).Sealed++
+			} else {
+				get(validator).Missed++
+			}
+		}
+	}
+	return activity, pruned, nil
+}
@@ -0,0 +1,153 @@
+package backend
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	istanbulCore "github.com/ethereum/go-ethereum/consensus/istanbul/core"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headChain is a chain whose head is the last header written.
+type headChain struct {
+	*core.BlockChain
+	head *types.Header
+}
+
+func (c *headChain) CurrentHeader() *types.Header { return c.head }
+
+// newStatusChain returns a chain of six headers proposed in turn by the
+// validators A, B and C, with an epoch of four blocks. A and B vote D in
+// over the first two blocks. The committed seals are those of the eligible
+// validators but C in the second block and D in the third and fourth, the
+// committed seals of the fifth being pruned.
+func newStatusChain(t *testing.T) (consensus.ChainHeaderReader, *backend, []common.Address, common.Address) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+	validators := make([]common.Address, len(names))
+	for i, name := range names {
+		validators[i] = accounts.address(name)
+	}
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			if bytes.Compare(validators[i][:], validators[j][:]) > 0 {
+				validators[i], validators[j] = validators[j], validators[i]
+				names[i], names[j] = names[j], names[i]
+			}
+		}
+	}
+	genesis := &core.Genesis{
+		Difficulty: defaultDifficulty,
+		Mixhash:    types.IstanbulDigest,
+	}
+	genesis.ExtraData, _ = prepareExtra(genesis.ToBlock(nil).Header(), validators)
+	db := rawdb.NewMemoryDatabase()
+	genesis.MustCommit(db)
+
+	config := *istanbul.DefaultConfig
+	config.Epoch = 4
+	engine := New(&config, accounts.accounts[names[0]], db).(*backend)
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(chain.Stop)
+
+	candidate := accounts.address("D")
+	missing := map[uint64]string{2: names[2], 3: "D", 4: "D"}
+	parent := chain.GetHeaderByNumber(0)
+	for number := uint64(1); number <= 6; number++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: defaultDifficulty,
+			MixDigest:  types.IstanbulDigest,
+		}
+		if number <= 2 {
+			header.Coinbase = candidate
+			copy(header.Nonce[:], nonceAuthVote)
+		}
+		header.Extra, _ = prepareExtra(header, validators)
+		accounts.sign(header, names[(number-1)%3])
+
+		if number != 5 {
+			sealers := append([]string{}, names...)
+			if number > 2 {
+				sealers = append(sealers, "D")
+			}
+			var seals [][]byte
+			for _, sealer := range sealers {
+				if sealer == missing[number] {
+					continue
+				}
+				seal, err := crypto.Sign(crypto.Keccak256(istanbulCore.PrepareCommittedSeal(header.Hash())), accounts.accounts[sealer])
+				require.NoError(t, err)
+				seals = append(seals, seal)
+			}
+			require.NoError(t, writeCommittedSeals(header, seals))
+		}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), number)
+		parent = header
+	}
+	return &headChain{BlockChain: chain, head: parent}, engine, validators, candidate
+}
+
+func TestSignerActivity(t *testing.T) {
+	chain, engine, validators, candidate := newStatusChain(t)
+
+	activity, pruned, err := engine.signerActivity(chain, 1, 6)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), pruned)
+	assert.Equal(t, map[common.Address]*SignerActivity{
+		validators[0]: {Proposed: 2, Sealed: 5},
+		validators[1]: {Proposed: 2, Sealed: 5},
+		validators[2]: {Proposed: 2, Sealed: 4, Missed: 1},
+		candidate:     {Sealed: 1, Missed: 2},
+	}, activity)
+
+	// the activity over the epoch boundary matches that of the blocks
+	activity, _, err = engine.signerActivity(chain, 4, 4)
+	require.NoError(t, err)
+	assert.Equal(t, &SignerActivity{Proposed: 1, Sealed: 1}, activity[validators[0]])
+	assert.Equal(t, &SignerActivity{Missed: 1}, activity[candidate])
+}
+
+func TestStatus(t *testing.T) {
+	chain, engine, validators, candidate := newStatusChain(t)
+	api := &API{chain: chain, istanbul: engine}
+
+	// the range past the head is truncated
+	start, end := rpc.BlockNumber(0), rpc.BlockNumber(100)
+	status, err := api.Status(&start, &end)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), status.StartBlock)
+	assert.Equal(t, uint64(6), status.EndBlock)
+	assert.Equal(t, uint64(6), status.NumBlocks)
+	assert.Equal(t, uint64(1), status.SealsPruned)
+	assert.Equal(t, map[common.Address]int{validators[0]: 2, validators[1]: 2, validators[2]: 2, candidate: 0}, status.SigningStatus)
+	assert.Equal(t, &SignerActivity{Proposed: 2, Sealed: 4, Missed: 1}, status.Signers[validators[2]])
+	assert.Nil(t, status.Consensus, "not validating")
+
+	defaults, err := api.Status(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, status.Signers, defaults.Signers)
+
+	start, end = rpc.BlockNumber(7), rpc.BlockNumber(9)
+	status, err = api.Status(&start, &end)
+	require.NoError(t, err)
+	assert.Zero(t, status.NumBlocks)
+	assert.Empty(t, status.Signers)
+
+	_, err = api.Status(&end, &start)
+	assert.Error(t, err)
+}
@@ -16,7 +16,11 @@
 
 package istanbul
 
-import "math/big"
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
 
 type ProposerPolicy uint64
 
@@ -26,19 +30,25 @@ const (
 )
 
 type Config struct {
-	RequestTimeout         uint64         `toml:",omitempty"` // The timeout for each Istanbul round in milliseconds.
-	BlockPeriod            uint64         `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
-	ProposerPolicy         ProposerPolicy `toml:",omitempty"` // The policy for proposer selection
-	Epoch                  uint64         `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
-	Ceil2Nby3Block         *big.Int       `toml:",omitempty"` // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
-	AllowedFutureBlockTime uint64         `toml:",omitempty"` // Max time (in seconds) from current time allowed for blocks, before they're considered future blocks
+	RequestTimeout           uint64         `toml:",omitempty"` // The timeout for each Istanbul round in milliseconds.
+	BlockPeriod              uint64         `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
+	ProposerPolicy           ProposerPolicy `toml:",omitempty"` // The policy for proposer selection
+	Epoch                    uint64         `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
+	Ceil2Nby3Block           *big.Int       `toml:",omitempty"` // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
+	AllowedFutureBlockTime   uint64         `toml:",omitempty"` // Max time (in seconds) from current time allowed for blocks, before they're considered future blocks
+	KeyRotationBlock         *big.Int       `toml:",omitempty"` // Block from which validators may rotate their keys, nil disables key rotations
+	ExportDir                string         `toml:",omitempty"` // Directory the validator history is exported to server-side, empty disables such exports
+	Standby                  bool           `toml:",omitempty"` // Follow the chain with the validator key without taking part in consensus until promoted
+	StandbyObservationWindow uint64         `toml:",omitempty"` // Seconds the key must be inactive before promoting a standby without fencing token
+	Clock                    mclock.Clock   `toml:"-"`          // Clock timing the rounds, the system clock if nil
 }
 
 var DefaultConfig = &Config{
-	RequestTimeout:         10000,
-	BlockPeriod:            1,
-	ProposerPolicy:         RoundRobin,
-	Epoch:                  30000,
-	Ceil2Nby3Block:         big.NewInt(0),
-	AllowedFutureBlockTime: 0,
+	RequestTimeout:           10000,
+	BlockPeriod:              1,
+	ProposerPolicy:           RoundRobin,
+	Epoch:                    30000,
+	Ceil2Nby3Block:           big.NewInt(0),
+	AllowedFutureBlockTime:   0,
+	StandbyObservationWindow: 60,
 }
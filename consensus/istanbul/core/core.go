@@ -21,9 +21,11 @@ import (
 	"math"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
@@ -35,8 +37,13 @@ import (
 // New creates an Istanbul consensus core
 func New(backend istanbul.Backend, config *istanbul.Config) Engine {
 	r := metrics.NewRegistry()
+	clock := config.Clock
+	if clock == nil {
+		clock = mclock.System{}
+	}
 	c := &core{
 		config:             config,
+		clock:              clock,
 		address:            backend.Address(),
 		state:              StateAcceptRequest,
 		handlerWg:          new(sync.WaitGroup),
@@ -64,6 +71,7 @@ func New(backend istanbul.Backend, config *istanbul.Config) Engine {
 
 type core struct {
 	config  *istanbul.Config
+	clock   mclock.Clock // Quorum - times the rounds
 	address common.Address
 	state   State
 	logger  log.Logger
@@ -72,7 +80,7 @@ type core struct {
 	events                *event.TypeMuxSubscription
 	finalCommittedSub     *event.TypeMuxSubscription
 	timeoutSub            *event.TypeMuxSubscription
-	futurePreprepareTimer *time.Timer
+	futurePreprepareTimer mclock.Timer
 
 	valSet                istanbul.ValidatorSet
 	waitingForRoundChange bool
@@ -85,7 +93,7 @@ type core struct {
 	handlerWg *sync.WaitGroup
 
 	roundChangeSet   *roundChangeSet
-	roundChangeTimer *time.Timer
+	roundChangeTimer mclock.Timer
 
 	pendingRequests   *prque.Prque
 	pendingRequestsMu *sync.Mutex
@@ -97,6 +105,9 @@ type core struct {
 	sequenceMeter metrics.Meter
 	// the timer to record consensus duration (from accepting a preprepare to final committed stage)
 	consensusTimer metrics.Timer
+
+	// Quorum: the state published by the handler goroutine, see Status
+	status atomic.Value // *istanbul.CoreStatus
 }
 
 func (c *core) finalizeMessage(msg *message) ([]byte, error) {
@@ -332,7 +343,7 @@ func (c *core) newRoundChangeTimer() {
 	if round > 0 {
 		timeout += time.Duration(math.Pow(2, float64(round))) * time.Second
 	}
-	c.roundChangeTimer = time.AfterFunc(timeout, func() {
+	c.roundChangeTimer = c.clock.AfterFunc(timeout, func() {
 		c.sendEvent(timeoutEvent{})
 	})
 }
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core/types"
 	elog "github.com/ethereum/go-ethereum/log"
@@ -95,3 +96,40 @@ func TestQuorumSize(t *testing.T) {
 		}
 	}
 }
+
+func TestRoundChangeTimer(t *testing.T) {
+	clock := new(mclock.Simulated)
+	config := *istanbul.DefaultConfig
+	config.Clock = clock
+
+	sys := newTestSystem(1)
+	backend := sys.NewBackend(0)
+	c := New(backend, &config).(*core)
+	c.current = newRoundState(&istanbul.View{Round: big.NewInt(1), Sequence: big.NewInt(1)}, newTestValidatorSet(1), common.Hash{}, nil, nil, func(common.Hash) bool { return false })
+	timeouts := backend.EventMux().Subscribe(timeoutEvent{})
+	defer timeouts.Unsubscribe()
+
+	// the request timeout, plus 2^round seconds
+	c.newRoundChangeTimer()
+	clock.Run(12*time.Second - time.Millisecond)
+	if clock.ActiveTimers() != 1 {
+		t.Fatal("round change timer fired early")
+	}
+	go clock.Run(time.Millisecond)
+	select {
+	case <-timeouts.Chan():
+	case <-time.After(time.Second):
+		t.Fatal("round change timer not fired")
+	}
+
+	// restarting the timer stops the previous one
+	c.newRoundChangeTimer()
+	c.newRoundChangeTimer()
+	if n := clock.ActiveTimers(); n != 1 {
+		t.Fatalf("active timers mismatch: have %d, want 1", n)
+	}
+	c.stopTimer()
+	if n := clock.ActiveTimers(); n != 0 {
+		t.Fatalf("active timers mismatch: have %d, want 0", n)
+	}
+}
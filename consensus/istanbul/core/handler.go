@@ -74,11 +74,15 @@ func (c *core) handleEvents() {
 	// Clear state
 	defer func() {
 		c.current = nil
+		c.publishStatus()
 		c.handlerWg.Done()
 	}()
 
 	c.handlerWg.Add(1)
 	for {
+		// Quorum: publish the state left by the previous event
+		c.publishStatus()
+
 		select {
 		case event, ok := <-c.events.Chan():
 			if !ok {
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+// Quorum
+//
+// The state of the consensus is only ever modified by the handler goroutine,
+// which publishes a copy of it before waiting for each event so that it can be
+// read concurrently by the RPC API.
+
+// Status implements core.Engine.Status
+func (c *core) Status() *istanbul.CoreStatus {
+	status, _ := c.status.Load().(*istanbul.CoreStatus)
+	return status
+}
+
+// publishStatus publishes the current state of the consensus, to be called
+// by the handler goroutine only.
+func (c *core) publishStatus() {
+	if c.current == nil {
+		c.status.Store((*istanbul.CoreStatus)(nil))
+		return
+	}
+	status := &istanbul.CoreStatus{
+		Sequence:              c.current.Sequence().Uint64(),
+		Round:                 c.current.Round().Uint64(),
+		State:                 c.state.String(),
+		WaitingForRoundChange: c.waitingForRoundChange,
+	}
+	if c.valSet != nil {
+		if proposer := c.valSet.GetProposer(); proposer != nil {
+			status.Proposer = proposer.Address()
+		}
+	}
+	if c.roundChangeSet != nil {
+		status.RoundChanges = c.roundChangeSet.votes()
+	}
+	c.status.Store(status)
+}
+
+// votes returns the validators who sent a round change message for each round.
+func (rcs *roundChangeSet) votes() map[uint64][]common.Address {
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+
+	votes := make(map[uint64][]common.Address, len(rcs.roundChanges))
+	for round, messages := range rcs.roundChanges {
+		for _, msg := range messages.Values() {
+			votes[round] = append(votes[round], msg.Address)
+		}
+	}
+	return votes
+}
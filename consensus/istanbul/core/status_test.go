@@ -0,0 +1,48 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+)
+
+func TestPublishStatus(t *testing.T) {
+	sys := NewTestSystemWithBackend(4, 1)
+	c := sys.backends[0].engine.(*core)
+	if status := c.Status(); status != nil {
+		t.Errorf("status published before the engine started: %v", status)
+	}
+
+	c.roundChangeSet = newRoundChangeSet(c.valSet)
+	view := &istanbul.View{Sequence: big.NewInt(1), Round: big.NewInt(1)}
+	m, _ := Encode(&istanbul.Subject{View: view, Digest: common.Hash{}})
+	for _, v := range c.valSet.List()[1:3] {
+		c.roundChangeSet.Add(view.Round, &message{Code: msgRoundChange, Msg: m, Address: v.Address()})
+	}
+	c.publishStatus()
+
+	status := c.Status()
+	if status == nil {
+		t.Fatal("status not published")
+	}
+	if status.Sequence != 1 || status.Round != 0 {
+		t.Errorf("view mismatch: have (%d, %d), want (1, 0)", status.Sequence, status.Round)
+	}
+	if status.State != StateAcceptRequest.String() {
+		t.Errorf("state mismatch: have %s, want %s", status.State, StateAcceptRequest)
+	}
+	if want := c.valSet.GetProposer().Address(); status.Proposer != want {
+		t.Errorf("proposer mismatch: have %x, want %x", status.Proposer, want)
+	}
+	if votes := status.RoundChanges[1]; len(votes) != 2 {
+		t.Errorf("round change votes mismatch: have %v, want 2 votes", votes)
+	}
+
+	c.current = nil
+	c.publishStatus()
+	if status := c.Status(); status != nil {
+		t.Errorf("status published once the engine stopped: %v", status)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -38,6 +39,9 @@ type Engine interface {
 	// pending request is populated right at the preprepare stage so this would give us the earliest verification
 	// to avoid any race condition of coming propagated blocks
 	IsCurrentProposal(blockHash common.Hash) bool
+
+	// Status returns the state of the consensus, nil while the engine is stopped
+	Status() *istanbul.CoreStatus
 }
 
 type State uint64
@@ -178,3 +182,12 @@ func (m *message) String() string {
 func Encode(val interface{}) ([]byte, error) {
 	return rlp.EncodeToBytes(val)
 }
+
+// MessageSigner returns the address which signed the consensus message payload.
+func MessageSigner(payload []byte) (common.Address, error) {
+	msg := new(message)
+	if err := msg.FromPayload(payload, istanbul.GetSignatureAddress); err != nil {
+		return common.Address{}, err
+	}
+	return msg.Address, nil
+}
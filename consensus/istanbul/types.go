@@ -145,3 +145,13 @@ func (b *Subject) DecodeRLP(s *rlp.Stream) error {
 func (b *Subject) String() string {
 	return fmt.Sprintf("{View: %v, Digest: %v}", b.View, b.Digest.String())
 }
+
+// CoreStatus is the state of the consensus on the block being sealed.
+type CoreStatus struct {
+	Sequence              uint64                      `json:"sequence"`
+	Round                 uint64                      `json:"round"`
+	State                 string                      `json:"state"`
+	Proposer              common.Address              `json:"proposer"`
+	WaitingForRoundChange bool                        `json:"waitingForRoundChange"`
+	RoundChanges          map[uint64][]common.Address `json:"roundChanges"` // validators voting for the rounds
+}
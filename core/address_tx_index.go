@@ -0,0 +1,218 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// addressTxTipInterval is the number of blocks indexed between the progress
+// stored while catching up with the head.
+const addressTxTipInterval = 1000
+
+var addressTxIndexTimer = metrics.NewRegisteredTimer("chain/addresstx/index", nil)
+
+// AddressTxPosition is the position of a transaction in the canonical chain.
+type AddressTxPosition struct {
+	Number uint64
+	Index  uint32
+}
+
+// AddressTxIndexer indexes the canonical transactions by sender and by
+// recipient, a contract creation having none. The index is first built from
+// genesis in the background, then each block is indexed as it becomes
+// canonical. When a reorg replaces indexed blocks, their transactions are
+// removed from the index before those of the new blocks are added.
+type AddressTxIndexer struct {
+	bc *BlockChain
+	db ethdb.Database
+
+	lock sync.Mutex // serializes the updates of the index
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAddressTxIndexer creates the address transaction index of a chain.
+func NewAddressTxIndexer(bc *BlockChain) *AddressTxIndexer {
+	return &AddressTxIndexer{
+		bc:   bc,
+		db:   bc.db,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start catches up with the head, resuming from the last block indexed, then
+// indexes the new canonical blocks.
+func (ix *AddressTxIndexer) Start() {
+	ix.wg.Add(1)
+	go ix.loop()
+}
+
+// Stop terminates the indexer, the progress of an unfinished catch up being
+// kept.
+func (ix *AddressTxIndexer) Stop() {
+	close(ix.quit)
+	ix.wg.Wait()
+}
+
+func (ix *AddressTxIndexer) loop() {
+	defer ix.wg.Done()
+
+	headCh := make(chan ChainHeadEvent, 64)
+	sub := ix.bc.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	ix.update(ix.bc.CurrentBlock())
+	for {
+		select {
+		case ev := <-headCh:
+			ix.update(ev.Block)
+		case <-sub.Err():
+			return
+		case <-ix.quit:
+			return
+		}
+	}
+}
+
+// update indexes the canonical blocks up to the given head, removing first the
+// transactions of the blocks replaced by a reorg.
+func (ix *AddressTxIndexer) update(head *types.Block) {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	start := time.Now()
+	next := uint64(0)
+	if tip := rawdb.ReadAddressTxTip(ix.db); tip != nil {
+		// the blocks beyond a shorter new head left the canonical chain
+		for number := tip.Number; number > head.NumberU64(); number-- {
+			ix.unindex(number)
+		}
+		// walk back to the last block whose transactions are up to date
+		next = tip.Number + 1
+		if next > head.NumberU64() {
+			next = head.NumberU64() + 1
+		}
+		for next > 0 && rawdb.ReadAddressTxBlock(ix.db, next-1) != rawdb.ReadCanonicalHash(ix.db, next-1) {
+			ix.unindex(next - 1)
+			next--
+		}
+	}
+	if next > head.NumberU64() {
+		return
+	}
+	if head.NumberU64()-next > addressTxTipInterval {
+		log.Info("Indexing transactions by account", "from", next, "to", head.NumberU64())
+	}
+	for number := next; number <= head.NumberU64(); number++ {
+		select {
+		case <-ix.quit:
+			return
+		default:
+		}
+		block := ix.bc.GetBlockByNumber(number)
+		if block == nil {
+			// the chain changed meanwhile, the next head event resumes
+			return
+		}
+		ix.index(block)
+		if number == head.NumberU64() || (number+1)%addressTxTipInterval == 0 {
+			rawdb.WriteAddressTxTip(ix.db, &rawdb.AddressTxTip{Number: number, Hash: block.Hash()})
+		}
+	}
+	addressTxIndexTimer.UpdateSince(start)
+}
+
+// index adds the transactions of a canonical block, replacing those of the
+// block previously indexed at its number if any. The caller must hold the lock.
+func (ix *AddressTxIndexer) index(block *types.Block) {
+	if hash := rawdb.ReadAddressTxBlock(ix.db, block.NumberU64()); hash == block.Hash() {
+		return
+	}
+	ix.unindex(block.NumberU64())
+
+	batch := ix.db.NewBatch()
+	ix.forEachParty(block, func(account common.Address, received bool, index uint32, tx *types.Transaction) {
+		rawdb.WriteAddressTx(batch, account, received, &rawdb.AddressTx{
+			Number:    block.NumberU64(),
+			Index:     index,
+			BlockHash: block.Hash(),
+			TxHash:    tx.Hash(),
+		})
+	})
+	rawdb.WriteAddressTxBlock(batch, block.NumberU64(), block.Hash())
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to index address transactions", "err", err)
+	}
+}
+
+// unindex removes the transactions of the block indexed at the given number.
+// If the block is no longer stored, its entries are left to be skipped by the
+// reads as not canonical. The caller must hold the lock.
+func (ix *AddressTxIndexer) unindex(number uint64) {
+	hash := rawdb.ReadAddressTxBlock(ix.db, number)
+	if hash == (common.Hash{}) {
+		return
+	}
+	batch := ix.db.NewBatch()
+	if block := ix.bc.GetBlock(hash, number); block != nil {
+		ix.forEachParty(block, func(account common.Address, received bool, index uint32, _ *types.Transaction) {
+			rawdb.DeleteAddressTx(batch, account, received, number, index)
+		})
+	}
+	rawdb.DeleteAddressTxBlock(batch, number)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to unindex address transactions", "err", err)
+	}
+}
+
+// forEachParty calls fn with the sender and the recipient of every transaction
+// of the block.
+func (ix *AddressTxIndexer) forEachParty(block *types.Block, fn func(account common.Address, received bool, index uint32, tx *types.Transaction)) {
+	signer := types.MakeSigner(ix.bc.Config(), block.Number())
+	for i, tx := range block.Transactions() {
+		if sender, err := types.Sender(signer, tx); err == nil {
+			fn(sender, false, uint32(i), tx)
+		}
+		if to := tx.To(); to != nil {
+			fn(*to, true, uint32(i), tx)
+		}
+	}
+}
+
+// Transactions returns at most limit canonical transactions sent or received
+// by the account, the most recent first, starting after the given position if
+// not nil. It also reports whether more transactions follow.
+func (ix *AddressTxIndexer) Transactions(account common.Address, received bool, after *AddressTxPosition, limit int) ([]*rawdb.AddressTx, bool) {
+	number, index := ^uint64(0), ^uint32(0)
+	if after != nil {
+		number, index = after.Number, after.Index
+	}
+	var (
+		txs  []*rawdb.AddressTx
+		more bool
+	)
+	rawdb.IterateAddressTxs(ix.db, account, received, number, index, func(tx *rawdb.AddressTx) bool {
+		if after != nil && tx.Number == after.Number && tx.Index == after.Index {
+			return true
+		}
+		// skip the transactions of the blocks replaced by a reorg not yet indexed
+		if rawdb.ReadCanonicalHash(ix.db, tx.Number) != tx.BlockHash {
+			return true
+		}
+		if len(txs) == limit {
+			more = true
+			return false
+		}
+		txs = append(txs, tx)
+		return true
+	})
+	return txs, more
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressTxIndexer(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{0x01}
+		gspec     = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{sender: {Balance: big.NewInt(1000000000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	send := func(block *BlockGen, n int) {
+		for i := 0; i < n; i++ {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), recipient, common.Big1, 21000, nil, nil), signer, key)
+			require.NoError(t, err)
+			block.AddTx(tx)
+		}
+	}
+	// two transactions in each of four blocks
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 4, func(i int, block *BlockGen) {
+		send(block, 2)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	ix := NewAddressTxIndexer(chain)
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+	ix.update(chain.CurrentBlock())
+	assert.Equal(t, &rawdb.AddressTxTip{Number: 4, Hash: blocks[3].Hash()}, rawdb.ReadAddressTxTip(db))
+
+	positions := func(txs []*rawdb.AddressTx) []AddressTxPosition {
+		ret := []AddressTxPosition{}
+		for _, tx := range txs {
+			ret = append(ret, AddressTxPosition{tx.Number, tx.Index})
+		}
+		return ret
+	}
+	txs, more := ix.Transactions(sender, false, nil, 3)
+	assert.True(t, more)
+	assert.Equal(t, []AddressTxPosition{{4, 1}, {4, 0}, {3, 1}}, positions(txs))
+	assert.Equal(t, blocks[3].Transactions()[1].Hash(), txs[0].TxHash)
+	assert.Equal(t, blocks[3].Hash(), txs[0].BlockHash)
+
+	txs, more = ix.Transactions(sender, false, &AddressTxPosition{3, 1}, 5)
+	assert.False(t, more, "exactly the last page")
+	assert.Equal(t, []AddressTxPosition{{3, 0}, {2, 1}, {2, 0}, {1, 1}, {1, 0}}, positions(txs))
+	txs, more = ix.Transactions(sender, false, &AddressTxPosition{1, 0}, 5)
+	assert.False(t, more)
+	assert.Empty(t, txs, "after the oldest")
+
+	received, _ := ix.Transactions(recipient, true, nil, 10)
+	assert.Len(t, received, 8)
+	none, _ := ix.Transactions(recipient, false, nil, 10)
+	assert.Empty(t, none, "nothing sent by the recipient")
+
+	// a longer fork replacing the last two blocks with a single transaction
+	fork, _ := GenerateChain(gspec.Config, blocks[1], ethash.NewFaker(), db, 3, func(i int, block *BlockGen) {
+		block.SetExtra([]byte("fork"))
+		if i == 1 {
+			send(block, 1)
+		}
+	})
+	_, err = chain.InsertChain(fork)
+	require.NoError(t, err)
+	require.Equal(t, fork[2].Hash(), chain.CurrentBlock().Hash())
+
+	// the replaced transactions are skipped before the index catches up
+	txs, _ = ix.Transactions(sender, false, nil, 10)
+	assert.Equal(t, []AddressTxPosition{{2, 1}, {2, 0}, {1, 1}, {1, 0}}, positions(txs))
+
+	ix.update(chain.CurrentBlock())
+	assert.Equal(t, fork[0].Hash(), rawdb.ReadAddressTxBlock(db, 3))
+	txs, _ = ix.Transactions(sender, false, nil, 10)
+	assert.Equal(t, []AddressTxPosition{{4, 0}, {2, 1}, {2, 0}, {1, 1}, {1, 0}}, positions(txs))
+	assert.Equal(t, fork[1].Transactions()[0].Hash(), txs[0].TxHash)
+
+	// a position in a block reorged away resumes with the transactions
+	// preceding it in the canonical chain
+	txs, more = ix.Transactions(sender, false, &AddressTxPosition{4, 1}, 2)
+	assert.True(t, more)
+	assert.Equal(t, []AddressTxPosition{{4, 0}, {2, 1}}, positions(txs))
+	txs, _ = ix.Transactions(sender, false, &AddressTxPosition{3, 0}, 2)
+	assert.Equal(t, []AddressTxPosition{{2, 1}, {2, 0}}, positions(txs))
+
+	// updating again to the same head changes nothing
+	ix.update(chain.CurrentBlock())
+	received, _ = ix.Transactions(recipient, true, nil, 10)
+	assert.Len(t, received, 5)
+}
@@ -0,0 +1,98 @@
+package core_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/privatefixtures"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/private/engine"
+)
+
+// Quorum
+//
+// The benchmarks importing the chains synthesized by the private fixtures,
+// the generation being left out of the timings.
+
+func BenchmarkInsertChain_privateFixture_standard(b *testing.B) {
+	benchInsertFixture(b, fixtureConfig(256, engine.PrivacyFlagStandardPrivate), archiveCache(), 0)
+}
+func BenchmarkInsertChain_privateFixture_psv(b *testing.B) {
+	benchInsertFixture(b, fixtureConfig(256, engine.PrivacyFlagStateValidation), archiveCache(), 0)
+}
+func BenchmarkInsertChain_privateFixture_16kB(b *testing.B) {
+	cfg := fixtureConfig(256, engine.PrivacyFlagStandardPrivate)
+	cfg.PayloadSize = 16 * 1024
+	benchInsertFixture(b, cfg, archiveCache(), 0)
+}
+func BenchmarkInsertChain_privateFixture_clique(b *testing.B) {
+	cfg := fixtureConfig(256, engine.PrivacyFlagStandardPrivate)
+	cfg.Engine = privatefixtures.EngineClique
+	benchInsertFixture(b, cfg, archiveCache(), 0)
+}
+
+// The pruning benchmarks import more blocks than the tries kept in memory, so
+// that the stale state is pruned, as do the transaction indices over the limit.
+func BenchmarkPruneState_privateFixture(b *testing.B) {
+	cache := archiveCache()
+	cache.TrieDirtyDisabled = false
+	cache.TrieTimeLimit = time.Millisecond
+	benchInsertFixture(b, fixtureConfig(512, engine.PrivacyFlagStandardPrivate), cache, 0)
+}
+func BenchmarkPruneTxIndex_privateFixture(b *testing.B) {
+	benchInsertFixture(b, fixtureConfig(512, engine.PrivacyFlagStandardPrivate), archiveCache(), 64)
+}
+
+func fixtureConfig(blocks int, flag engine.PrivacyFlagType) privatefixtures.Config {
+	cfg := privatefixtures.DefaultConfig
+	cfg.Blocks = blocks
+	cfg.PrivacyFlags = []engine.PrivacyFlagType{flag}
+	return cfg
+}
+
+func archiveCache() *core.CacheConfig {
+	return &core.CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieDirtyDisabled: true, TrieTimeLimit: 5 * time.Minute}
+}
+
+func benchInsertFixture(b *testing.B, cfg privatefixtures.Config, cache *core.CacheConfig, txLookupLimit uint64) {
+	dir, err := ioutil.TempDir("", "fixture-bench-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fixture, err := privatefixtures.Generate(dir, cfg)
+	if err != nil {
+		b.Fatalf("could not generate the fixture: %v", err)
+	}
+	blocks, err := fixture.Blocks()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer fixture.InstallPTM()()
+
+	var limit *uint64
+	if txLookupLimit > 0 {
+		limit = &txLookupLimit
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db := rawdb.NewMemoryDatabase()
+		fixture.Genesis.MustCommit(db)
+		chain, err := core.NewBlockChain(db, cache, fixture.Genesis.Config, fixture.Engine(db), vm.Config{}, nil, limit)
+		if err != nil {
+			b.Fatalf("could not create the chain: %v", err)
+		}
+		b.StartTimer()
+		if _, err := chain.InsertChain(blocks); err != nil {
+			b.Fatalf("could not import the fixture: %v", err)
+		}
+		b.StopTimer()
+		chain.Stop()
+	}
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Quorum
+
+// HeaderReader reads the headers of the canonical chain, as implemented by
+// BlockChain, HeaderChain and consensus.ChainHeaderReader.
+type HeaderReader interface {
+	CurrentHeader() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	GetHeaderByHash(hash common.Hash) *types.Header
+}
+
+// HeaderByNumberOrHash returns the header of the block number or hash of an RPC
+// call, nil if unknown. The latest and pending numbers both resolve to the
+// current header. Hashes of blocks which are not currently canonical are
+// rejected with ErrHashNotCanonical if the call requires a canonical block.
+func HeaderByNumberOrHash(chain HeaderReader, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		if number < 0 {
+			return chain.CurrentHeader(), nil
+		}
+		return chain.GetHeaderByNumber(uint64(number)), nil
+	}
+	hash, ok := blockNrOrHash.Hash()
+	if !ok {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	header := chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, nil
+	}
+	if blockNrOrHash.RequireCanonical {
+		if canonical := chain.GetHeaderByNumber(header.Number.Uint64()); canonical == nil || canonical.Hash() != hash {
+			return nil, ErrHashNotCanonical
+		}
+	}
+	return header, nil
+}
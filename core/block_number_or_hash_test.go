@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestHeaderByNumberOrHash(t *testing.T) {
+	db, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	easyBlocks, _ := GenerateChain(params.TestChainConfig, blockchain.CurrentBlock(), ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.OffsetTime(10)
+	})
+	diffBlocks, _ := GenerateChain(params.TestChainConfig, blockchain.CurrentBlock(), ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.OffsetTime(-9)
+	})
+	if _, err := blockchain.InsertChain(easyBlocks); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	easyHash := easyBlocks[1].Hash()
+	for _, requireCanonical := range []bool{false, true} {
+		header, err := HeaderByNumberOrHash(blockchain, rpc.BlockNumberOrHashWithHash(easyHash, requireCanonical))
+		if err != nil || header == nil || header.Hash() != easyHash {
+			t.Fatalf("canonical hash, requireCanonical %v: have %v, %v", requireCanonical, header, err)
+		}
+	}
+
+	// the reorg makes the easy blocks non-canonical
+	if _, err := blockchain.InsertChain(diffBlocks); err != nil {
+		t.Fatalf("failed to insert difficult chain: %v", err)
+	}
+	if _, err := HeaderByNumberOrHash(blockchain, rpc.BlockNumberOrHashWithHash(easyHash, true)); err != ErrHashNotCanonical {
+		t.Fatalf("non-canonical hash: have %v, want %v", err, ErrHashNotCanonical)
+	}
+	if header, err := HeaderByNumberOrHash(blockchain, rpc.BlockNumberOrHashWithHash(easyHash, false)); err != nil || header.Hash() != easyHash {
+		t.Fatalf("non-canonical hash not required to be canonical: have %v, %v", header, err)
+	}
+	if header, _ := HeaderByNumberOrHash(blockchain, rpc.BlockNumberOrHashWithNumber(2)); header.Hash() != diffBlocks[1].Hash() {
+		t.Fatalf("number resolved to %x, want %x", header.Hash(), diffBlocks[1].Hash())
+	}
+	if header, _ := HeaderByNumberOrHash(blockchain, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)); header.Hash() != diffBlocks[2].Hash() {
+		t.Fatalf("pending resolved to %x, want the head %x", header.Hash(), diffBlocks[2].Hash())
+	}
+	if header, err := HeaderByNumberOrHash(blockchain, rpc.BlockNumberOrHashWithNumber(10)); header != nil || err != nil {
+		t.Fatalf("unknown number: have %v, %v", header, err)
+	}
+}
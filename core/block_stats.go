@@ -0,0 +1,413 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// MaxBlockStatsRange caps the number of blocks whose statistics are
+	// returned at once.
+	MaxBlockStatsRange = 10000
+
+	secondsPerDay = 86400
+	statsDate     = "2006-01-02"
+)
+
+var (
+	blockStatsIndexTimer = metrics.NewRegisteredTimer("chain/blockstats/index", nil)
+
+	errBlockStatsBackfillRunning = errors.New("block statistics backfill already running")
+)
+
+// statsTime returns a block timestamp in seconds. Raft timestamps are in
+// nanoseconds.
+func statsTime(timestamp uint64) uint64 {
+	if timestamp > 1<<40 {
+		return timestamp / uint64(time.Second)
+	}
+	return timestamp
+}
+
+func statsDay(timestamp uint64) uint64 {
+	return statsTime(timestamp) / secondsPerDay
+}
+
+// BlockStatsRange returns the statistics of the indexed canonical blocks in
+// [from, to].
+func BlockStatsRange(db ethdb.KeyValueReader, from, to uint64) ([]*types.BlockStats, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range %d - %d", from, to)
+	}
+	if to-from >= MaxBlockStatsRange {
+		return nil, fmt.Errorf("range of %d blocks exceeds the maximum of %d", to-from+1, MaxBlockStatsRange)
+	}
+	stats := make([]*types.BlockStats, 0)
+	for number := from; number <= to; number++ {
+		if s := rawdb.ReadBlockStats(db, number); s != nil {
+			stats = append(stats, s)
+		}
+	}
+	return stats, nil
+}
+
+// DailyStatsRange returns the statistics of the days in [fromDate, toDate],
+// formatted as YYYY-MM-DD, which had indexed blocks.
+func DailyStatsRange(db ethdb.KeyValueReader, fromDate, toDate string) ([]*types.DailyStats, error) {
+	from, err := time.Parse(statsDate, fromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", fromDate)
+	}
+	to, err := time.Parse(statsDate, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", toDate)
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("invalid range %s - %s", fromDate, toDate)
+	}
+	stats := make([]*types.DailyStats, 0)
+	for day := uint64(from.Unix()) / secondsPerDay; day <= uint64(to.Unix())/secondsPerDay; day++ {
+		if s := rawdb.ReadDailyStats(db, day); s != nil {
+			stats = append(stats, s)
+		}
+	}
+	return stats, nil
+}
+
+// BlockStatsBackfillStatus is the progress of a backfill of the statistics.
+type BlockStatsBackfillStatus struct {
+	Running bool   `json:"running"`
+	Next    uint64 `json:"next"`
+	Last    uint64 `json:"last"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BlockStatsIndexer aggregates statistics of the canonical blocks as they are
+// imported, with a row per block and a rollup per UTC day. When a reorg
+// replaces indexed blocks, their rows are rewritten and the rollups of their
+// days are rebuilt from the canonical blocks of the day. Older blocks are
+// indexed by backfills, which record their progress so that they resume after
+// a restart.
+type BlockStatsIndexer struct {
+	bc *BlockChain
+	db ethdb.Database
+
+	lock sync.Mutex // serializes the updates of the index
+
+	backfillLock sync.Mutex
+	backfill     *BlockStatsBackfillStatus
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBlockStatsIndexer creates the block statistics index of a chain.
+func NewBlockStatsIndexer(bc *BlockChain) *BlockStatsIndexer {
+	return &BlockStatsIndexer{
+		bc:   bc,
+		db:   bc.db,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start indexes the new canonical blocks and resumes any unfinished backfill.
+// The blocks preceding the head when the index is first started are only
+// indexed by backfills.
+func (ix *BlockStatsIndexer) Start() {
+	if rawdb.ReadBlockStatsTip(ix.db) == nil {
+		head := ix.bc.CurrentBlock()
+		rawdb.WriteBlockStatsTip(ix.db, &rawdb.BlockStatsTip{Number: head.NumberU64(), Hash: head.Hash()})
+	}
+	ix.wg.Add(1)
+	go ix.loop()
+
+	if progress := rawdb.ReadBlockStatsBackfill(ix.db); progress != nil {
+		log.Info("Resuming block statistics backfill", "next", progress.Next, "last", progress.Last)
+		ix.startBackfill(progress.Next, progress.Last)
+	}
+}
+
+// Stop terminates the indexer, interrupting any running backfill.
+func (ix *BlockStatsIndexer) Stop() {
+	close(ix.quit)
+	ix.wg.Wait()
+}
+
+func (ix *BlockStatsIndexer) loop() {
+	defer ix.wg.Done()
+
+	headCh := make(chan ChainHeadEvent, 64)
+	sub := ix.bc.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			ix.update(ev.Block)
+		case <-sub.Err():
+			return
+		case <-ix.quit:
+			return
+		}
+	}
+}
+
+// update indexes the blocks which became canonical up to the given head,
+// correcting the statistics of the blocks replaced by a reorg.
+func (ix *BlockStatsIndexer) update(head *types.Block) {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	start := time.Now()
+	tip := rawdb.ReadBlockStatsTip(ix.db)
+	if tip == nil {
+		return
+	}
+	// walk back to the last block whose statistics are up to date, the blocks
+	// at or below the tip which were never indexed are left to backfills
+	var added []*types.Block
+	for block := head; block != nil && block.NumberU64() > 0; block = ix.bc.GetBlock(block.ParentHash(), block.NumberU64()-1) {
+		if block.NumberU64() <= tip.Number {
+			if stored := rawdb.ReadBlockStats(ix.db, block.NumberU64()); stored == nil || stored.Hash == block.Hash() {
+				break
+			}
+		}
+		added = append(added, block)
+	}
+	dirty := make(map[uint64]uint64) // days to rebuild -> a block of the day
+	for i := len(added) - 1; i >= 0; i-- {
+		ix.index(added[i], dirty)
+	}
+	// the blocks beyond a shorter new head left the canonical chain
+	for number := head.NumberU64() + 1; number <= tip.Number; number++ {
+		if stored := rawdb.ReadBlockStats(ix.db, number); stored != nil {
+			markDirty(dirty, stored)
+			rawdb.DeleteBlockStats(ix.db, number)
+		}
+	}
+	for day, number := range dirty {
+		ix.rebuildDay(day, number)
+	}
+	rawdb.WriteBlockStatsTip(ix.db, &rawdb.BlockStatsTip{Number: head.NumberU64(), Hash: head.Hash()})
+	blockStatsIndexTimer.UpdateSince(start)
+}
+
+func markDirty(dirty map[uint64]uint64, stats *types.BlockStats) {
+	day := statsDay(stats.Time)
+	if number, ok := dirty[day]; !ok || stats.Number < number {
+		dirty[day] = stats.Number
+	}
+}
+
+// index writes the statistics of a canonical block and adds them to the
+// rollup of its day. If the block replaces an indexed one, the days of both
+// are marked for a rebuild instead. The caller must hold the lock.
+func (ix *BlockStatsIndexer) index(block *types.Block, dirty map[uint64]uint64) {
+	stored := rawdb.ReadBlockStats(ix.db, block.NumberU64())
+	if stored != nil && stored.Hash == block.Hash() {
+		return
+	}
+	stats, senders := ix.blockStats(block)
+	rawdb.WriteBlockStats(ix.db, stats)
+	if stored != nil {
+		markDirty(dirty, stored)
+		markDirty(dirty, stats)
+		return
+	}
+	day := statsDay(stats.Time)
+	if _, ok := dirty[day]; ok {
+		return
+	}
+	daily := rawdb.ReadDailyStats(ix.db, day)
+	if daily == nil {
+		daily = &types.DailyStats{Day: day, FirstBlock: stats.Number, LastBlock: stats.Number}
+	}
+	addToDay(daily, stats)
+	for _, sender := range senders {
+		if !rawdb.HasDailyActiveAccount(ix.db, day, sender) {
+			rawdb.WriteDailyActiveAccount(ix.db, day, sender)
+			daily.ActiveAccounts++
+		}
+	}
+	rawdb.WriteDailyStats(ix.db, daily)
+}
+
+func addToDay(daily *types.DailyStats, stats *types.BlockStats) {
+	daily.Blocks++
+	if stats.Number < daily.FirstBlock {
+		daily.FirstBlock = stats.Number
+	}
+	if stats.Number > daily.LastBlock {
+		daily.LastBlock = stats.Number
+	}
+	daily.TxCount += stats.TxCount
+	daily.PrivateTxCount += stats.PrivateTxCount
+	daily.GasUsed += stats.GasUsed
+}
+
+// blockStats computes the statistics of a block and returns the distinct
+// senders of its transactions.
+func (ix *BlockStatsIndexer) blockStats(block *types.Block) (*types.BlockStats, []common.Address) {
+	stats := &types.BlockStats{
+		Number:   block.NumberU64(),
+		Hash:     block.Hash(),
+		Time:     statsTime(block.Time()),
+		TxCount:  uint64(len(block.Transactions())),
+		GasUsed:  block.GasUsed(),
+		GasLimit: block.GasLimit(),
+	}
+	signer := types.MakeSigner(ix.bc.Config(), block.Number())
+	seen := make(map[common.Address]struct{})
+	var senders []common.Address
+	for _, tx := range block.Transactions() {
+		if tx.IsPrivate() {
+			stats.PrivateTxCount++
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[sender]; !ok {
+			seen[sender] = struct{}{}
+			senders = append(senders, sender)
+		}
+	}
+	stats.ActiveAccounts = uint64(len(senders))
+	return stats, senders
+}
+
+// rebuildDay recomputes the rollup of a day from the indexed canonical blocks
+// whose timestamp falls within it, starting the search from one of them. The
+// caller must hold the lock.
+func (ix *BlockStatsIndexer) rebuildDay(day uint64, number uint64) {
+	rawdb.DeleteDailyActiveAccounts(ix.db, day)
+
+	sameDay := func(number uint64) bool {
+		header := ix.bc.GetHeaderByNumber(number)
+		return header != nil && statsDay(header.Time) == day
+	}
+	first := number
+	for first > 0 && sameDay(first-1) {
+		first--
+	}
+	var daily *types.DailyStats
+	accounts := make(map[common.Address]struct{})
+	for n := first; ; n++ {
+		block := ix.bc.GetBlockByNumber(n)
+		if block == nil || statsDay(block.Time()) > day {
+			break
+		}
+		if statsDay(block.Time()) < day || rawdb.ReadBlockStats(ix.db, n) == nil {
+			continue
+		}
+		stats, senders := ix.blockStats(block)
+		if daily == nil {
+			daily = &types.DailyStats{Day: day, FirstBlock: n, LastBlock: n}
+		}
+		addToDay(daily, stats)
+		for _, sender := range senders {
+			if _, ok := accounts[sender]; !ok {
+				accounts[sender] = struct{}{}
+				rawdb.WriteDailyActiveAccount(ix.db, day, sender)
+			}
+		}
+	}
+	if daily == nil {
+		rawdb.DeleteDailyStats(ix.db, day)
+		return
+	}
+	daily.ActiveAccounts = uint64(len(accounts))
+	rawdb.WriteDailyStats(ix.db, daily)
+}
+
+// Backfill starts indexing the canonical blocks in [from, to] in the
+// background. Blocks already indexed are skipped.
+func (ix *BlockStatsIndexer) Backfill(from, to uint64) (*BlockStatsBackfillStatus, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range %d - %d", from, to)
+	}
+	if head := ix.bc.CurrentBlock().NumberU64(); to > head {
+		return nil, fmt.Errorf("block #%d beyond head #%d", to, head)
+	}
+	return ix.startBackfill(from, to)
+}
+
+func (ix *BlockStatsIndexer) startBackfill(from, to uint64) (*BlockStatsBackfillStatus, error) {
+	ix.backfillLock.Lock()
+	defer ix.backfillLock.Unlock()
+
+	if ix.backfill != nil && ix.backfill.Running {
+		return nil, errBlockStatsBackfillRunning
+	}
+	ix.backfill = &BlockStatsBackfillStatus{Running: true, Next: from, Last: to}
+	rawdb.WriteBlockStatsBackfill(ix.db, &rawdb.BlockStatsBackfill{Next: from, Last: to})
+
+	ix.wg.Add(1)
+	go ix.runBackfill(from, to)
+
+	status := *ix.backfill
+	return &status, nil
+}
+
+func (ix *BlockStatsIndexer) runBackfill(from, to uint64) {
+	defer ix.wg.Done()
+
+	var err error
+	for number := from; number <= to; number++ {
+		select {
+		case <-ix.quit:
+			return
+		default:
+		}
+		block := ix.bc.GetBlockByNumber(number)
+		if block == nil {
+			err = fmt.Errorf("block #%d not found", number)
+			break
+		}
+		ix.lock.Lock()
+		dirty := make(map[uint64]uint64)
+		ix.index(block, dirty)
+		for day, number := range dirty {
+			ix.rebuildDay(day, number)
+		}
+		ix.lock.Unlock()
+		rawdb.WriteBlockStatsBackfill(ix.db, &rawdb.BlockStatsBackfill{Next: number + 1, Last: to})
+
+		ix.backfillLock.Lock()
+		ix.backfill.Next = number + 1
+		ix.backfillLock.Unlock()
+	}
+	ix.backfillLock.Lock()
+	defer ix.backfillLock.Unlock()
+
+	ix.backfill.Running = false
+	if err != nil {
+		// the progress is kept so that the backfill resumes after a restart
+		ix.backfill.Error = err.Error()
+		log.Warn("Block statistics backfill failed", "err", err)
+		return
+	}
+	rawdb.DeleteBlockStatsBackfill(ix.db)
+	log.Info("Block statistics backfill done", "from", from, "to", to)
+}
+
+// BackfillStatus returns the progress of the last backfill, nil if none ran.
+func (ix *BlockStatsIndexer) BackfillStatus() *BlockStatsBackfillStatus {
+	ix.backfillLock.Lock()
+	defer ix.backfillLock.Unlock()
+
+	if ix.backfill == nil {
+		return nil
+	}
+	status := *ix.backfill
+	return &status
+}
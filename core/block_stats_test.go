@@ -0,0 +1,126 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockStatsIndexer(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		key1, _   = crypto.GenerateKey()
+		key2, _   = crypto.GenerateKey()
+		address1  = crypto.PubkeyToAddress(key1.PublicKey)
+		address2  = crypto.PubkeyToAddress(key2.PublicKey)
+		recipient = common.Address{0x01}
+		gspec     = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				address1: {Balance: big.NewInt(1000000000000000)},
+				address2: {Balance: big.NewInt(1000000000000000)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	send := func(block *BlockGen, key *ecdsa.PrivateKey, n int) {
+		for i := 0; i < n; i++ {
+			from := crypto.PubkeyToAddress(key.PublicKey)
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(from), recipient, common.Big1, 21000, nil, nil), signer, key)
+			require.NoError(t, err)
+			block.AddTx(tx)
+		}
+	}
+	// two blocks on day 0 and one on day 1
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, block *BlockGen) {
+		switch i {
+		case 0:
+			send(block, key1, 2)
+		case 1:
+			send(block, key1, 1)
+			send(block, key2, 1)
+		case 2:
+			block.OffsetTime(secondsPerDay)
+			send(block, key2, 1)
+		}
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	ix := NewBlockStatsIndexer(chain)
+	rawdb.WriteBlockStatsTip(db, &rawdb.BlockStatsTip{Number: 0, Hash: genesis.Hash()})
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+	ix.update(chain.CurrentBlock())
+
+	stats, err := BlockStatsRange(db, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, &types.BlockStats{Number: 2, Hash: blocks[1].Hash(), Time: blocks[1].Time(), TxCount: 2, GasUsed: 42000, GasLimit: blocks[1].GasLimit(), ActiveAccounts: 2}, stats[1])
+
+	day0 := time.Unix(0, 0).UTC().Format(statsDate)
+	day1 := time.Unix(secondsPerDay, 0).UTC().Format(statsDate)
+	daily, err := DailyStatsRange(db, day0, day1)
+	require.NoError(t, err)
+	require.Len(t, daily, 2)
+	assert.Equal(t, &types.DailyStats{Day: 0, Blocks: 2, FirstBlock: 1, LastBlock: 2, TxCount: 4, GasUsed: 84000, ActiveAccounts: 2}, daily[0])
+	assert.Equal(t, &types.DailyStats{Day: 1, Blocks: 1, FirstBlock: 3, LastBlock: 3, TxCount: 1, GasUsed: 21000, ActiveAccounts: 1}, daily[1])
+	assert.Equal(t, day1, daily[1].Date())
+
+	// a longer fork replacing the last two blocks moves every block to day 0
+	fork, _ := GenerateChain(gspec.Config, blocks[0], ethash.NewFaker(), db, 3, func(i int, block *BlockGen) {
+		block.SetExtra([]byte("fork"))
+		if i == 0 {
+			send(block, key1, 1)
+		}
+	})
+	_, err = chain.InsertChain(fork)
+	require.NoError(t, err)
+	require.Equal(t, fork[2].Hash(), chain.CurrentBlock().Hash())
+	ix.update(chain.CurrentBlock())
+
+	stats, err = BlockStatsRange(db, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, stats, 4)
+	assert.Equal(t, fork[0].Hash(), stats[1].Hash)
+	assert.Equal(t, uint64(1), stats[1].TxCount)
+
+	daily, err = DailyStatsRange(db, day0, day1)
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, &types.DailyStats{Day: 0, Blocks: 4, FirstBlock: 1, LastBlock: 4, TxCount: 3, GasUsed: 63000, ActiveAccounts: 1}, daily[0])
+
+	// a backfill of blocks indexed is a no-op, other blocks are added
+	rawdb.DeleteBlockStats(db, 1)
+	rawdb.DeleteDailyStats(db, 0)
+	rawdb.DeleteDailyActiveAccounts(db, 0)
+	_, err = ix.Backfill(1, 1)
+	require.NoError(t, err)
+	for status := ix.BackfillStatus(); status.Running; status = ix.BackfillStatus() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Empty(t, ix.BackfillStatus().Error)
+	assert.Nil(t, rawdb.ReadBlockStatsBackfill(db))
+	daily, err = DailyStatsRange(db, day0, day0)
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, &types.DailyStats{Day: 0, Blocks: 1, FirstBlock: 1, LastBlock: 1, TxCount: 2, GasUsed: 42000, ActiveAccounts: 1}, daily[0])
+
+	_, err = BlockStatsRange(db, 0, MaxBlockStatsRange)
+	assert.Error(t, err)
+	_, err = DailyStatsRange(db, "yesterday", day0)
+	assert.Error(t, err)
+}
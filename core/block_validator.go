@@ -65,12 +65,59 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.DeriveSha(block.Transactions(), new(trie.Trie)); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
+	// Quorum
+	if v.config.IsPrivatePayloadHashEnforced(block.Number()) {
+		for i, tx := range block.Transactions() {
+			if err := ValidatePrivatePayloadHash(tx); err != nil {
+				return fmt.Errorf("transaction %d (%s): %w", i, tx.Hash().Hex(), err)
+			}
+		}
+	}
+	// End Quorum
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
 		if !v.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
 			return consensus.ErrUnknownAncestor
 		}
 		return consensus.ErrPrunedAncestor
 	}
+	// Quorum
+	if v.config.IsDeployerAllowListEnabled(block.Number()) {
+		return v.validateDeployers(block)
+	}
+	// End Quorum
+	return nil
+}
+
+// Quorum
+//
+// validateDeployers checks the senders of the contract creation transactions
+// of the block against the deployer allow-list, at the state of the parent.
+func (v *BlockValidator) validateDeployers(block *types.Block) error {
+	parent := v.bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	var parentState *state.StateDB
+	if NeedsParentState(v.config, block.Number()) {
+		var err error
+		if parentState, _, err = v.bc.StateAt(parent.Root); err != nil {
+			return err
+		}
+	}
+	deployers := NewDeployerAllowList(v.config, parent, parentState)
+	signer := types.MakeSigner(v.config, block.Number())
+	for i, tx := range block.Transactions() {
+		if tx.To() != nil {
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("transaction %d (%s): %w", i, tx.Hash().Hex(), err)
+		}
+		if err := deployers.Check(tx, sender); err != nil {
+			return fmt.Errorf("transaction %d (%s): %w", i, tx.Hash().Hex(), err)
+		}
+	}
 	return nil
 }
 
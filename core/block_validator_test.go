@@ -17,15 +17,19 @@
 package core
 
 import (
+	"errors"
+	"math/big"
 	"runtime"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // Tests that simple header verification works, for both good and bad blocks.
@@ -197,3 +201,34 @@ func testHeaderConcurrentAbortion(t *testing.T, threads int) {
 		t.Errorf("verification count too large: have %d, want below %d", verified, 2*threads)
 	}
 }
+
+// Quorum
+//
+// Tests that private transactions whose data is not an encrypted payload hash
+// are only invalid from the transition block.
+func TestValidateBodyPrivatePayloadHash(t *testing.T) {
+	var (
+		testdb  = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(testdb)
+	)
+	chain, _ := NewBlockChain(testdb, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	tx := types.NewTransaction(0, common.Address{}, common.Big0, 100000, common.Big0, []byte("arbitrary bytecode"))
+	tx.SetPrivate()
+	header := &types.Header{ParentHash: genesis.Hash(), Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	block := types.NewBlock(header, []*types.Transaction{tx}, nil, nil, new(trie.Trie))
+
+	for fork, invalid := range map[int64]bool{2: false, 1: true} {
+		config := *params.TestChainConfig
+		config.PrivatePayloadHashBlock = big.NewInt(fork)
+		err := NewBlockValidator(&config, chain, ethash.NewFaker()).ValidateBody(block)
+		if invalid != errors.Is(err, ErrInvalidPrivatePayloadHash) {
+			t.Errorf("transition at %d: unexpected error: %v", fork, err)
+		}
+		if !invalid && err != nil {
+			t.Errorf("transition at %d: expected no error; got: %v", fork, err)
+		}
+	}
+}
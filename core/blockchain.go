@@ -216,8 +216,20 @@ type BlockChain struct {
 	terminateInsert func(common.Hash, uint64) bool     // Testing hook used to terminate ancient receipt chain insertion.
 	setPrivateState func([]*types.Log, *state.StateDB) // Function to check extension and set private state
 
-	privateStateCache state.Database // Private state database to reuse between imports (contains state cache)
-	isMultitenant     bool           // if this blockchain supports multitenancy
+	privateStateCache  state.Database     // Private state database to reuse between imports (contains state cache)
+	isMultitenant      bool               // if this blockchain supports multitenancy
+	indexInternalCalls uint32             // 1 if the internal calls of imported blocks are indexed
+	privatePrefetcher  *PrivatePrefetcher // Private payload prefetcher, nil if disabled
+	privateParallelism int                // Private transactions with execution hints executed concurrently, 0 if disabled
+
+	importTimings       importTimingsRing // Breakdown of the import time of the last blocks
+	slowImportThreshold time.Duration     // Import time above which the breakdown of a block is logged, 0 if disabled
+
+	maxReorgDepth  *uint64    // Maximum number of canonical blocks a reorg may drop, nil if unlimited
+	rejectedReorgs *lru.Cache // Heads of the reorgs rejected as too deep
+	acceptedReorgs *lru.Cache // Blocks of the rejected reorgs accepted by the operator
+
+	headFeed headFeed // Heads enriched with their receipts, for the subscribers needing them
 }
 
 // function pointer for updating private state
@@ -246,6 +258,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	txLookupCache, _ := lru.New(txLookupCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
+	rejectedReorgs, _ := lru.New(rejectedReorgLimit) // Quorum
+	acceptedReorgs, _ := lru.New(rejectedReorgLimit) // Quorum
 
 	bc := &BlockChain{
 		chainConfig:       chainConfig,
@@ -265,6 +279,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		vmConfig:          vmConfig,
 		badBlocks:         badBlocks,
 		privateStateCache: state.NewDatabase(db),
+		rejectedReorgs:    rejectedReorgs,
+		acceptedReorgs:    acceptedReorgs,
 	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
@@ -786,6 +802,26 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	headBlockGauge.Update(int64(block.NumberU64()))
 }
 
+// Quorum
+//
+// FollowHead moves all in-memory chain markers to the given block without
+// writing to the database and announces it as the new chain head. It is used
+// by read-only nodes whose database is maintained by another process, which
+// already wrote the canonical chain the block belongs to.
+func (bc *BlockChain) FollowHead(block *types.Block) {
+	bc.chainmu.Lock()
+	bc.hc.SetCurrentHeader(block.Header())
+	bc.currentFastBlock.Store(block)
+	headFastBlockGauge.Update(int64(block.NumberU64()))
+	bc.currentBlock.Store(block)
+	headBlockGauge.Update(int64(block.NumberU64()))
+	bc.chainmu.Unlock()
+
+	bc.chainHeadFeed.Send(ChainHeadEvent{Block: block})
+}
+
+// End Quorum
+
 // Genesis retrieves the chain's genesis block.
 func (bc *BlockChain) Genesis() *types.Block {
 	return bc.genesisBlock
@@ -1477,7 +1513,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
-	return bc.writeBlockWithState(block, receipts, logs, state, privateState, emitHeadEvent)
+	return bc.writeBlockWithState(block, receipts, logs, state, privateState, emitHeadEvent, new(ImportTimings))
 }
 
 // QUORUM
@@ -1509,8 +1545,9 @@ func (bc *BlockChain) CommitBlockWithState(deleteEmptyObjects bool, state, priva
 // END QUORUM
 
 // writeBlockWithState writes the block and all associated state to the database,
-// but is expects the chain mutex to be held.
-func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state, privateState *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
+// but is expects the chain mutex to be held. The time spent writing is recorded
+// into the timings of the block.
+func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state, privateState *state.StateDB, emitHeadEvent bool, timings *ImportTimings) (status WriteStatus, err error) {
 	bc.wg.Add(1)
 	defer bc.wg.Done()
 
@@ -1522,6 +1559,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Make sure no inconsistent state is leaked during insertion
 	// Quorum
 	// Write private state changes to database
+	substart := time.Now()
 	privateRoot, err := privateState.Commit(bc.chainConfig.IsEIP158(block.Number()))
 	if err != nil {
 		return NonStatTy, err
@@ -1535,6 +1573,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if err := privateTriedb.Commit(privateRoot, false, nil); err != nil {
 		return NonStatTy, err
 	}
+	timings.PrivateTrieCommit = time.Since(substart)
 	// End Quorum
 
 	currentBlock := bc.CurrentBlock()
@@ -1545,6 +1584,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	//
 	// Note all the components of block(td, hash->number map, header, body, receipts)
 	// should be written aeth/downloader/downloader.gotomically. BlockBatch is used for containing all components.
+	substart = time.Now()
 	blockBatch := bc.db.NewBatch()
 	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
 	rawdb.WriteBlock(blockBatch, block)
@@ -1553,7 +1593,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
+	timings.ReceiptWrite = time.Since(substart)
+
 	// Commit all cached state changes into underlying memory database.
+	substart = time.Now()
 	root, err := state.Commit(bc.chainConfig.IsEIP158(block.Number()))
 
 	if err != nil {
@@ -1614,6 +1657,11 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 			}
 		}
 	}
+	timings.PublicTrieCommit = time.Since(substart)
+
+	substart = time.Now()
+	defer func() { timings.ConsensusPostProcessing = time.Since(substart) }()
+
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
@@ -1764,6 +1812,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 	}
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	// Quorum: fetch the private payloads of the batch while its first blocks execute
+	bc.PrefetchPrivatePayloads(chain...)
 
 	var (
 		stats     = insertStats{startTime: mclock.Now()}
@@ -1918,6 +1968,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		}
 		// Retrieve the parent block and it's state to execute on top
 		start := time.Now()
+		timings := &ImportTimings{HeaderValidation: it.validation} // Quorum
 
 		parent := it.previous()
 		if parent == nil {
@@ -1955,7 +2006,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		}
 		// Process block using the parent state as reference point
 		substart := time.Now()
-		receipts, privateReceipts, logs, usedGas, err := bc.processor.Process(block, statedb, privateState, bc.vmConfig)
+		if bc.privatePrefetcher != nil {
+			bc.privatePrefetcher.consume(block)
+		}
+		vmConfig, recorder := bc.internalCallsConfig()
+		receipts, privateReceipts, logs, usedGas, err := bc.processor.Process(block, statedb, privateState, vmConfig)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
@@ -1975,6 +2030,12 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		blockExecutionTimer.Update(time.Since(substart) - trieproc - triehash)
 
+		// Quorum
+		timings.PublicExecution = time.Since(substart) - statedb.PrivateExecutions
+		timings.PrivatePayloadFetch = statedb.PrivatePayloadFetches
+		timings.PrivateExecution = statedb.PrivateExecutions - statedb.PrivatePayloadFetches
+		// End Quorum
+
 		// Validate the state using the default validator
 		substart = time.Now()
 		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
@@ -1991,16 +2052,25 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		blockValidationTimer.Update(time.Since(substart) - (statedb.AccountHashes + statedb.StorageHashes - triehash))
 
+		// Quorum
+		if recorder != nil {
+			// written ahead of the block so the indexer does not re-execute it
+			bc.writeInternalCalls(block, recorder)
+		}
+		// End Quorum
+
 		// Write the block to the chain and get the status.
 		substart = time.Now()
-		status, err := bc.writeBlockWithState(block, allReceipts, logs, statedb, privateState, false)
+		status, err := bc.writeBlockWithState(block, allReceipts, logs, statedb, privateState, false, timings)
 		atomic.StoreUint32(&followupInterrupt, 1)
 		if err != nil {
 			return it.index, err
 		}
+		bloomstart := time.Now()
 		if err := rawdb.WritePrivateBlockBloom(bc.db, block.NumberU64(), privateReceipts); err != nil {
 			return it.index, err
 		}
+		timings.ReceiptWrite += time.Since(bloomstart)
 		// Update the metrics touched during block commit
 		accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
 		storageCommitTimer.Update(statedb.StorageCommits)   // Storage commits are complete, we can mark them
@@ -2009,6 +2079,13 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		blockWriteTimer.Update(time.Since(substart) - statedb.AccountCommits - statedb.StorageCommits - statedb.SnapshotCommits)
 		blockInsertTimer.UpdateSince(start)
 
+		// Quorum
+		timings.Number, timings.Hash = block.NumberU64(), block.Hash()
+		timings.Txs, timings.PrivateTxs = len(block.Transactions()), len(privateReceipts)
+		timings.Total = timings.HeaderValidation + time.Since(start)
+		bc.recordImportTimings(timings)
+		// End Quorum
+
 		switch status {
 		case CanonStatTy:
 			log.Debug("Inserted new block", "number", block.Number(), "hash", block.Hash(),
@@ -2188,6 +2265,8 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 // potential missing transactions and post an event about them.
 func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	var (
+		oldHead, newHead = oldBlock, newBlock // Quorum
+
 		newChain    types.Blocks
 		oldChain    types.Blocks
 		commonBlock *types.Block
@@ -2287,6 +2366,11 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("invalid new chain")
 		}
 	}
+	// Quorum
+	if err := bc.checkReorgDepth(oldHead, newHead, commonBlock, oldChain, newChain); err != nil {
+		return err
+	}
+	// End Quorum
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Info
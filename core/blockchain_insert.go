@@ -87,6 +87,8 @@ type insertIterator struct {
 
 	index     int       // Current offset of the iterator
 	validator Validator // Validator to run if verification succeeds
+
+	validation time.Duration // Time spent validating the current block, Quorum
 }
 
 // newInsertIterator creates a new iterator based on the given blocks, which are
@@ -104,6 +106,8 @@ func newInsertIterator(chain types.Blocks, results <-chan error, validator Valid
 // next returns the next block in the iterator, along with any potential validation
 // error for that block. When the end is reached, it will return (nil, nil).
 func (it *insertIterator) next() (*types.Block, error) {
+	defer func(start time.Time) { it.validation = time.Since(start) }(time.Now()) // Quorum
+
 	// If we reached the end of the chain, abort
 	if it.index+1 >= len(it.chain) {
 		it.index = len(it.chain)
@@ -0,0 +1,303 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Quorum
+//
+// The chain config of a node upgraded across versions may lack the fields the
+// new version expects. Migrating it merges the config of the genesis file into
+// the stored one field by field: the fields the stored config lacks are added
+// and the lists extending the stored ones are appended, while the fields the
+// genesis file lacks keep their stored value. Rewriting a stored value is not
+// a migration, and refused.
+//
+// The report of the last migration is stored along with the config, so that
+// the later runs find it already migrated and fleet tooling can retrieve it.
+
+// Actions of the fields of a config migration.
+const (
+	ConfigFieldAdd     = "add"     // missing from the stored config
+	ConfigFieldAppend  = "append"  // list extending the stored one
+	ConfigFieldKeep    = "keep"    // missing from the genesis file
+	ConfigFieldRewrite = "rewrite" // changing a stored value, refused
+)
+
+var (
+	// ErrUnsafeConfigMigration is returned for the migrations rewriting a
+	// stored value of the chain config.
+	ErrUnsafeConfigMigration = errors.New("unsafe chain config migration")
+	// ErrNoChainConfig is returned when migrating the config of a database
+	// without chain.
+	ErrNoChainConfig = errors.New("no stored chain config")
+)
+
+// ConfigFieldChange is a field of the chain config differing between the
+// stored config and the genesis file. Nested fields are separated by dots.
+type ConfigFieldChange struct {
+	Field    string      `json:"field"`
+	Stored   interface{} `json:"stored"`
+	Incoming interface{} `json:"incoming"`
+	Action   string      `json:"action"`
+}
+
+// ConfigMigrationReport is the outcome of a migration of the chain config.
+type ConfigMigrationReport struct {
+	Version string              `json:"version"` // Quorum version running the migration
+	Time    uint64              `json:"time"`
+	Head    uint64              `json:"head"` // head block when migrated
+	Applied bool                `json:"applied"`
+	Error   string              `json:"error,omitempty"`
+	Changes []ConfigFieldChange `json:"changes"`
+}
+
+// ReadConfigMigrationReport returns the report of the last migration of the
+// chain config, nil if none ran.
+func ReadConfigMigrationReport(db ethdb.KeyValueReader) (*ConfigMigrationReport, error) {
+	data := rawdb.ReadConfigMigration(db)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	report := new(ConfigMigrationReport)
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// MigrateChainConfig merges the chain config of the genesis into the stored
+// one, provided it is compatible with the chain and does not rewrite any
+// stored value. The report is stored unless there was nothing to migrate, the
+// report of the previous migration applied being returned then.
+func MigrateChainConfig(db ethdb.Database, genesis *Genesis) (*ConfigMigrationReport, error) {
+	if genesis == nil || genesis.Config == nil {
+		return nil, errGenesisNoConfig
+	}
+	stored := rawdb.ReadCanonicalHash(db, 0)
+	if (stored == common.Hash{}) {
+		return nil, ErrNoChainConfig
+	}
+	if hash := genesis.ToBlock(nil).Hash(); hash != stored {
+		return nil, &GenesisMismatchError{stored, hash}
+	}
+	storedcfg := rawdb.ReadChainConfig(db, stored)
+	if storedcfg == nil {
+		return nil, ErrNoChainConfig
+	}
+	height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	if height == nil {
+		return nil, fmt.Errorf("missing block number for head header hash")
+	}
+	merged, changes, err := mergeChainConfig(storedcfg, genesis.Config)
+	if err != nil {
+		return nil, err
+	}
+	report := &ConfigMigrationReport{Version: params.QuorumVersion, Time: uint64(time.Now().Unix()), Head: *height, Changes: changes}
+	if !migrates(changes) {
+		log.Info("Chain config already migrated")
+		last, err := ReadConfigMigrationReport(db)
+		if err != nil || (last != nil && last.Applied) {
+			return last, err
+		}
+		report.Applied = true
+		return report, nil
+	}
+	err = checkConfigMigration(storedcfg, merged, changes, *height, rawdb.GetIsQuorumEIP155Activated(db))
+	if err == nil {
+		rawdb.WriteChainConfig(db, stored, merged)
+		report.Applied = true
+	} else {
+		report.Error = err.Error()
+	}
+	for _, change := range changes {
+		log.Info("Chain config field", "field", change.Field, "action", change.Action, "stored", change.Stored, "incoming", change.Incoming)
+	}
+	data, jsonErr := json.Marshal(report)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	rawdb.WriteConfigMigration(db, data)
+	return report, err
+}
+
+// migrates reports whether the changes add or append fields.
+func migrates(changes []ConfigFieldChange) bool {
+	for _, change := range changes {
+		if change.Action != ConfigFieldKeep {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConfigMigration fails if the migration rewrites stored values or the
+// merged config is invalid or incompatible with the chain.
+func checkConfigMigration(storedcfg, merged *params.ChainConfig, changes []ConfigFieldChange, height uint64, isQuorumEIP155Activated bool) error {
+	var rewrites []string
+	for _, change := range changes {
+		if change.Action == ConfigFieldRewrite {
+			rewrites = append(rewrites, change.Field)
+		}
+	}
+	if len(rewrites) > 0 {
+		return fmt.Errorf("%w: rewrites %s", ErrUnsafeConfigMigration, strings.Join(rewrites, ", "))
+	}
+	if err := merged.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	if err := merged.IsValid(); err != nil {
+		return err
+	}
+	if compatErr := storedcfg.CheckCompatible(merged, height, isQuorumEIP155Activated); compatErr != nil && height != 0 && compatErr.RewindTo != 0 {
+		return compatErr
+	}
+	return nil
+}
+
+// mergeChainConfig returns the stored config with the fields added and
+// appended by the incoming one, and the fields differing between them.
+func mergeChainConfig(stored, incoming *params.ChainConfig) (*params.ChainConfig, []ConfigFieldChange, error) {
+	storedFields, err := configFields(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	incomingFields, err := configFields(incoming)
+	if err != nil {
+		return nil, nil, err
+	}
+	var changes []ConfigFieldChange
+	mergedFields := mergeConfigFields("", reflect.TypeOf(params.ChainConfig{}), storedFields, incomingFields, &changes)
+	enc, err := json.Marshal(mergedFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := new(params.ChainConfig)
+	if err := json.Unmarshal(enc, merged); err != nil {
+		return nil, nil, err
+	}
+	return merged, changes, nil
+}
+
+// configFields returns the JSON fields of the config, keeping the precision
+// of the numbers.
+func configFields(config *params.ChainConfig) (map[string]interface{}, error) {
+	enc, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(enc))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// mergeConfigFields merges the incoming fields of the struct type into a copy
+// of the stored ones, recording the changes of the fields under the prefix.
+func mergeConfigFields(prefix string, typ reflect.Type, stored, incoming map[string]interface{}, changes *[]ConfigFieldChange) map[string]interface{} {
+	types := configFieldTypes(typ)
+	merged := make(map[string]interface{}, len(stored))
+	for name, value := range stored {
+		merged[name] = value
+	}
+	names := make([]string, 0, len(stored)+len(incoming))
+	for name := range stored {
+		names = append(names, name)
+	}
+	for name := range incoming {
+		if _, ok := stored[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s, i, typ := stored[name], incoming[name], types[name]
+		change := ConfigFieldChange{Field: prefix + name, Stored: s, Incoming: i}
+		switch {
+		case isZeroConfigValue(i, typ) && isZeroConfigValue(s, typ):
+			continue
+		case isZeroConfigValue(i, typ):
+			change.Action = ConfigFieldKeep
+		case isZeroConfigValue(s, typ):
+			change.Action = ConfigFieldAdd
+			merged[name] = i
+		default:
+			sObject, sIsObject := s.(map[string]interface{})
+			iObject, iIsObject := i.(map[string]interface{})
+			if sIsObject && iIsObject {
+				merged[name] = mergeConfigFields(prefix+name+".", typ, sObject, iObject, changes)
+				continue
+			}
+			if reflect.DeepEqual(s, i) {
+				continue
+			}
+			sList, sIsList := s.([]interface{})
+			iList, iIsList := i.([]interface{})
+			if sIsList && iIsList && len(iList) > len(sList) && reflect.DeepEqual(sList, iList[:len(sList)]) {
+				change.Action = ConfigFieldAppend
+				merged[name] = i
+			} else {
+				change.Action = ConfigFieldRewrite
+			}
+		}
+		*changes = append(*changes, change)
+	}
+	return merged
+}
+
+// configFieldTypes returns the types of the fields of the struct type by JSON
+// name, nil if it is not a struct.
+func configFieldTypes(typ reflect.Type) map[string]reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+	types := make(map[string]reflect.Type, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			types[name] = field.Type
+		}
+	}
+	return types
+}
+
+// isZeroConfigValue reports whether the JSON value leaves a field of the type
+// unset. A zero block number is set, being the genesis block, while a zero
+// limit is not, standing for the default.
+func isZeroConfigValue(v interface{}, typ reflect.Type) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == "" || v == (common.Hash{}).Hex()
+	case json.Number:
+		return v.String() == "0" && (typ == nil || typ.Kind() != reflect.Ptr)
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
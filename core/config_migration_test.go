@@ -0,0 +1,100 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func migrationGenesis(config *params.ChainConfig) *Genesis {
+	return &Genesis{Config: config, Alloc: GenesisAlloc{{1}: {Balance: big.NewInt(1)}}}
+}
+
+func TestMigrateChainConfig_AddsAndAppends(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	migrationGenesis(&params.ChainConfig{
+		ChainID:           big.NewInt(1337),
+		HomesteadBlock:    big.NewInt(0),
+		IsQuorum:          true,
+		MaxCodeSizeConfig: []params.MaxCodeConfigStruct{{Block: big.NewInt(0), Size: 32}},
+	}).MustCommit(db)
+
+	report, err := MigrateChainConfig(db, migrationGenesis(&params.ChainConfig{
+		ChainID:                  big.NewInt(1337),
+		IsQuorum:                 true,
+		TransactionSizeLimit:     64,
+		PrivacyEnhancementsBlock: big.NewInt(0),
+		MaxCodeSizeConfig:        []params.MaxCodeConfigStruct{{Block: big.NewInt(0), Size: 32}, {Block: big.NewInt(100), Size: 64}},
+	}))
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	actions := make(map[string]string)
+	for _, change := range report.Changes {
+		actions[change.Field] = change.Action
+	}
+	assert.Equal(t, map[string]string{
+		"homesteadBlock":           ConfigFieldKeep,
+		"maxCodeSizeConfig":        ConfigFieldAppend,
+		"privacyEnhancementsBlock": ConfigFieldAdd,
+		"txnSizeLimit":             ConfigFieldAdd,
+	}, actions)
+
+	config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+	assert.Equal(t, big.NewInt(0), config.HomesteadBlock, "kept")
+	assert.Equal(t, big.NewInt(0), config.PrivacyEnhancementsBlock)
+	assert.Equal(t, uint64(64), config.TransactionSizeLimit)
+	assert.Len(t, config.MaxCodeSizeConfig, 2)
+
+	stored, err := ReadConfigMigrationReport(db)
+	require.NoError(t, err)
+	assert.True(t, stored.Applied)
+	assert.Len(t, stored.Changes, len(report.Changes))
+}
+
+func TestMigrateChainConfig_RefusesRewrites(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	storedConfig := &params.ChainConfig{ChainID: big.NewInt(1337), HomesteadBlock: big.NewInt(0), IsQuorum: true}
+	migrationGenesis(storedConfig).MustCommit(db)
+
+	report, err := MigrateChainConfig(db, migrationGenesis(&params.ChainConfig{
+		ChainID:              big.NewInt(1337),
+		HomesteadBlock:       big.NewInt(5),
+		IsQuorum:             true,
+		TransactionSizeLimit: 64,
+	}))
+	assert.True(t, errors.Is(err, ErrUnsafeConfigMigration), "unexpected error %v", err)
+	require.NotNil(t, report)
+	assert.False(t, report.Applied)
+	assert.Contains(t, report.Error, "homesteadBlock")
+
+	assert.Equal(t, uint64(0), rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0)).TransactionSizeLimit, "not migrated")
+	stored, err := ReadConfigMigrationReport(db)
+	require.NoError(t, err)
+	assert.False(t, stored.Applied)
+}
+
+func TestMigrateChainConfig_AlreadyMigrated(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	migrationGenesis(&params.ChainConfig{ChainID: big.NewInt(1337), IsQuorum: true, TransactionSizeLimit: 64}).MustCommit(db)
+	incoming := migrationGenesis(&params.ChainConfig{ChainID: big.NewInt(1337), IsQuorum: true, TransactionSizeLimit: 64, MaxCodeSize: 48})
+
+	first, err := MigrateChainConfig(db, incoming)
+	require.NoError(t, err)
+	require.Len(t, first.Changes, 1)
+
+	second, err := MigrateChainConfig(db, incoming)
+	require.NoError(t, err)
+	assert.True(t, second.Applied)
+	assert.Equal(t, first.Time, second.Time, "previous report")
+	assert.Equal(t, "maxCodeSize", second.Changes[0].Field)
+}
+
+func TestMigrateChainConfig_WithoutChain(t *testing.T) {
+	_, err := MigrateChainConfig(rawdb.NewMemoryDatabase(), migrationGenesis(&params.ChainConfig{ChainID: big.NewInt(1337)}))
+	assert.Equal(t, ErrNoChainConfig, err)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Quorum
+//
+// deployerRegistryGas is the gas available to a view call of the deployer
+// registry, deployers being unauthorized if the registry runs out of gas.
+const deployerRegistryGas = 100000
+
+var isAllowedDeployerSelector = crypto.Keccak256([]byte("isAllowedDeployer(address)"))[:4]
+
+// UnauthorizedDeployerError is returned for contract creation transactions
+// whose sender is not in the deployer allow-list.
+type UnauthorizedDeployerError struct {
+	Sender common.Address
+}
+
+func (e *UnauthorizedDeployerError) Error() string {
+	return fmt.Sprintf("%s is not allowed to create contracts", e.Sender.Hex())
+}
+
+// Is makes the errors match ErrUnauthorizedDeployer.
+func (e *UnauthorizedDeployerError) Is(target error) bool {
+	return target == ErrUnauthorizedDeployer
+}
+
+// DeployerAllowList authorizes the senders of the contract creation
+// transactions of a block. The registry of the deployers is read at the state
+// of the parent of the block, so that every node reaches the same verdict.
+type DeployerAllowList struct {
+	config *params.ChainConfig
+	number *big.Int // block of the transactions
+	parent *types.Header
+
+	lock    sync.Mutex
+	state   *state.StateDB          // state of the parent, nil unless consulting the registry
+	allowed map[common.Address]bool // answers of the registry
+}
+
+// NewDeployerAllowList returns the allow-list of the child of the parent block,
+// whose state is only needed when the deployers are listed by a registry and
+// is not modified.
+func NewDeployerAllowList(config *params.ChainConfig, parent *types.Header, parentState *state.StateDB) *DeployerAllowList {
+	l := &DeployerAllowList{
+		config: config,
+		number: new(big.Int).Add(parent.Number, common.Big1),
+		parent: parent,
+	}
+	if l.config.IsDeployerAllowListEnabled(l.number) && config.DeployerAllowList.Registry != nil && parentState != nil {
+		l.state = parentState.Copy()
+		l.allowed = make(map[common.Address]bool)
+	}
+	return l
+}
+
+// NeedsParentState returns whether the allow-list of the block consults the
+// registry at the state of its parent.
+func NeedsParentState(config *params.ChainConfig, number *big.Int) bool {
+	return config.IsDeployerAllowListEnabled(number) && config.DeployerAllowList.Registry != nil
+}
+
+// Check returns an *UnauthorizedDeployerError if the transaction creates a
+// contract and its sender is not allowed to. Nil allow-lists allow everyone.
+func (l *DeployerAllowList) Check(tx *types.Transaction, sender common.Address) error {
+	if l == nil || tx.To() != nil || !l.config.IsDeployerAllowListEnabled(l.number) {
+		return nil
+	}
+	for _, deployer := range l.config.DeployerAllowList.Deployers {
+		if deployer == sender {
+			return nil
+		}
+	}
+	if l.config.DeployerAllowList.Registry != nil {
+		allowed, err := l.registryAllows(sender)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+	return &UnauthorizedDeployerError{Sender: sender}
+}
+
+// registryAllows calls isAllowedDeployer(sender) on the registry, a failing
+// call denying the sender.
+func (l *DeployerAllowList) registryAllows(sender common.Address) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.state == nil {
+		return false, fmt.Errorf("state of block %d unavailable to the deployer allow-list", l.parent.Number)
+	}
+	if allowed, ok := l.allowed[sender]; ok {
+		return allowed, nil
+	}
+	context := vm.Context{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		// the registry must not depend on the block hashes
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		BlockNumber: new(big.Int).Set(l.parent.Number),
+		Time:        new(big.Int).SetUint64(l.parent.Time),
+		Difficulty:  new(big.Int).Set(l.parent.Difficulty),
+		GasLimit:    l.parent.GasLimit,
+		GasPrice:    new(big.Int),
+	}
+	evm := vm.NewEVM(context, l.state, l.state, l.config, vm.Config{})
+	input := append(append([]byte{}, isAllowedDeployerSelector...), common.LeftPadBytes(sender.Bytes(), 32)...)
+	ret, _, err := evm.StaticCall(vm.AccountRef(common.Address{}), *l.config.DeployerAllowList.Registry, input, deployerRegistryGas)
+	allowed := err == nil && len(ret) == 32 && new(big.Int).SetBytes(ret).Cmp(common.Big1) == 0
+	l.allowed[sender] = allowed
+	return allowed, nil
+}
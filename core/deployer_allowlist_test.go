@@ -0,0 +1,94 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testDeployer = common.HexToAddress("0x1932c48b2bf8102ba33b4a6b545c32236e342f34")
+	testRegistry = common.HexToAddress("0x000000000000000000000000000000000000d3b1")
+)
+
+// registryCode returns whether the address argument is testDeployer.
+func registryCode() []byte {
+	code := []byte{byte(vm.PUSH1), 0x04, byte(vm.CALLDATALOAD), byte(vm.PUSH20)}
+	code = append(code, testDeployer.Bytes()...)
+	return append(code, byte(vm.EQ), byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.RETURN))
+}
+
+func newDeployerChain(t *testing.T, allowList *params.DeployerAllowListConfig) (*BlockChain, *params.ChainConfig) {
+	config := *params.TestChainConfig
+	config.DeployerAllowList = allowList
+	db := rawdb.NewMemoryDatabase()
+	gspec := &Genesis{
+		Config: &config,
+		Alloc:  GenesisAlloc{testRegistry: {Code: registryCode(), Balance: common.Big0}},
+	}
+	gspec.MustCommit(db)
+	chain, err := NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	return chain, &config
+}
+
+func TestDeployerAllowList_Check(t *testing.T) {
+	other := common.HexToAddress("0xca843569e3427144cead5e4d5999a3d0ccf92b8e")
+	creation := types.NewContractCreation(0, common.Big0, 100000, common.Big0, nil)
+	call := types.NewTransaction(0, testRegistry, common.Big0, 100000, common.Big0, nil)
+
+	for name, allowList := range map[string]*params.DeployerAllowListConfig{
+		"static":   {Block: big.NewInt(1), Deployers: []common.Address{testDeployer}},
+		"registry": {Block: big.NewInt(1), Registry: &testRegistry},
+	} {
+		chain, config := newDeployerChain(t, allowList)
+		genesis := chain.Genesis().Header()
+		statedb, _, err := chain.StateAt(genesis.Root)
+		require.NoError(t, err, name)
+
+		deployers := NewDeployerAllowList(config, genesis, statedb)
+		assert.NoError(t, deployers.Check(creation, testDeployer), name)
+		assert.NoError(t, deployers.Check(call, other), name)
+		err = deployers.Check(creation, other)
+		assert.True(t, errors.Is(err, ErrUnauthorizedDeployer), name)
+		assert.Equal(t, &UnauthorizedDeployerError{Sender: other}, err, name)
+
+		// the allow-list is only enforced from its block
+		allowList.Block = big.NewInt(2)
+		assert.NoError(t, NewDeployerAllowList(config, genesis, statedb).Check(creation, other), name)
+		chain.Stop()
+	}
+	var none *DeployerAllowList
+	assert.NoError(t, none.Check(creation, testDeployer))
+}
+
+func TestValidateBodyDeployers(t *testing.T) {
+	chain, config := newDeployerChain(t, &params.DeployerAllowListConfig{Block: big.NewInt(1), Registry: &testRegistry})
+	defer chain.Stop()
+
+	key, _ := crypto.GenerateKey()
+	signer := types.MakeSigner(config, big.NewInt(1))
+	tx, err := types.SignTx(types.NewContractCreation(0, common.Big0, 100000, common.Big0, nil), signer, key)
+	require.NoError(t, err)
+	header := &types.Header{ParentHash: chain.Genesis().Hash(), Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	block := types.NewBlock(header, []*types.Transaction{tx}, nil, nil, new(trie.Trie))
+
+	err = chain.Validator().ValidateBody(block)
+	assert.True(t, errors.Is(err, ErrUnauthorizedDeployer), "unexpected error: %v", err)
+
+	// blocks before the allow-list are valid
+	config.DeployerAllowList.Block = big.NewInt(2)
+	assert.NoError(t, chain.Validator().ValidateBody(block))
+}
@@ -0,0 +1,131 @@
+// Package emergency implements the emergency read-only mode set by the operator
+// during incident response, freezing the state-changing activity of the node
+// without stopping it.
+//
+// While the mode is enabled the transaction pool admits no transaction, be it
+// submitted over RPC or gossiped by the peers, the private payloads of the
+// transactions submitted are not distributed and the node does not seal
+// blocks. It keeps importing the blocks of the network, relaying the consensus
+// messages and serving the reads.
+//
+// The mode is persisted in the chain database so that it survives restarts,
+// and is only cleared by the operator. It is unrelated to the nodes serving a
+// database replicated from a writer node, see eth.Config.ReadOnly.
+package emergency
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// ErrReadOnly is matched by the errors returned while the mode is enabled.
+var ErrReadOnly = errors.New("node is in emergency read-only mode")
+
+var readOnlyGauge = metrics.NewRegisteredGauge("node/readonly", nil)
+
+// State is the emergency read-only mode of the node.
+type State struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	SetBy   string     `json:"setBy,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// Error is returned for the state-changing requests while the mode is
+// enabled.
+type Error struct {
+	State State
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s", ErrReadOnly, e.State.Reason)
+}
+
+// ErrorCode returns the JSON-RPC error code of the rejections.
+func (e *Error) ErrorCode() int {
+	return -32011
+}
+
+// ErrorData returns the mode, with the reason and who set it.
+func (e *Error) ErrorData() interface{} {
+	return e.State
+}
+
+// Is makes the errors match ErrReadOnly.
+func (e *Error) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+var (
+	current     State
+	currentLock sync.RWMutex
+	changeFeed  event.Feed
+)
+
+// Load restores the mode persisted in the database.
+func Load(db ethdb.KeyValueReader) {
+	state := State{}
+	if mode := rawdb.ReadEmergencyReadOnly(db); mode != nil {
+		since := time.Unix(int64(mode.Since), 0)
+		state = State{Enabled: true, Reason: mode.Reason, SetBy: mode.SetBy, Since: &since}
+		log.Warn("Node is in emergency read-only mode", "reason", mode.Reason, "setBy", mode.SetBy, "since", since)
+	}
+	update(state)
+}
+
+// Set enables or disables the mode, persisting it in the database.
+func Set(db ethdb.KeyValueWriter, enabled bool, reason, setBy string) State {
+	state := State{}
+	if enabled {
+		since := time.Now().Truncate(time.Second)
+		state = State{Enabled: true, Reason: reason, SetBy: setBy, Since: &since}
+		rawdb.WriteEmergencyReadOnly(db, &rawdb.EmergencyReadOnly{Reason: reason, SetBy: setBy, Since: uint64(since.Unix())})
+		log.Warn("Emergency read-only mode enabled", "reason", reason, "setBy", setBy)
+	} else {
+		rawdb.DeleteEmergencyReadOnly(db)
+		log.Warn("Emergency read-only mode cleared", "setBy", setBy)
+	}
+	update(state)
+	return state
+}
+
+func update(state State) {
+	currentLock.Lock()
+	current = state
+	currentLock.Unlock()
+
+	if state.Enabled {
+		readOnlyGauge.Update(1)
+	} else {
+		readOnlyGauge.Update(0)
+	}
+	changeFeed.Send(state)
+}
+
+// Get returns the current mode.
+func Get() State {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
+
+// Check returns an *Error while the mode is enabled.
+func Check() error {
+	if state := Get(); state.Enabled {
+		return &Error{State: state}
+	}
+	return nil
+}
+
+// Subscribe notifies the changes of the mode.
+func Subscribe(ch chan<- State) event.Subscription {
+	return changeFeed.Subscribe(ch)
+}
@@ -0,0 +1,46 @@
+package emergency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet_PersistedAcrossLoad(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	defer Set(db, false, "", "test")
+
+	ch := make(chan State, 1)
+	sub := Subscribe(ch)
+	Set(db, true, "incident 42", "user:alice")
+	select {
+	case state := <-ch:
+		assert.True(t, state.Enabled)
+	case <-time.After(time.Second):
+		t.Fatal("change not notified")
+	}
+	sub.Unsubscribe()
+
+	// a restarted node restores the mode
+	update(State{})
+	require.NoError(t, Check())
+	Load(db)
+	err := Check()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrReadOnly))
+	var roErr *Error
+	require.True(t, errors.As(err, &roErr))
+	assert.Equal(t, -32011, roErr.ErrorCode())
+	assert.Equal(t, "incident 42", roErr.State.Reason)
+	assert.Equal(t, "user:alice", roErr.State.SetBy)
+	assert.NotNil(t, roErr.State.Since)
+
+	Set(db, false, "", "user:bob")
+	assert.NoError(t, Check())
+	Load(db)
+	assert.False(t, Get().Enabled)
+}
@@ -79,5 +79,19 @@ var (
 
 	// ErrPrivateContractInteractionVerificationFailed is returned if the verification of contract interaction differs from the one returned by Tessera (check pmh.verify(...))
 	ErrPrivateContractInteractionVerificationFailed = errors.New("verification of contract interaction differs from the one returned by Tessera")
+
+	// ErrInvalidPrivatePayloadHash is matched by the errors returned for private
+	// transactions whose data is not an encrypted payload hash, see
+	// PrivatePayloadHashError.
+	ErrInvalidPrivatePayloadHash = errors.New("private transaction data is not an encrypted payload hash")
+
+	// ErrUnauthorizedDeployer is matched by the errors returned for contract
+	// creation transactions whose sender is not in the deployer allow-list, see
+	// UnauthorizedDeployerError.
+	ErrUnauthorizedDeployer = errors.New("sender is not allowed to create contracts")
+
+	// ErrHashNotCanonical is returned for the block hashes of RPC calls which
+	// require a canonical block if the block is not currently canonical.
+	ErrHashNotCanonical = errors.New("hash is not currently canonical")
 	// End Quorum
 )
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// The new heads are enriched once, their block and receipts being read for all
+// the subscribers of the HeadEvents instead of each of them. The subscribers
+// only needing the header keep subscribing to the ChainHeadEvents.
+
+var headFeedReceiptsMeter = metrics.NewRegisteredMeter("chain/headfeed/receipts", nil)
+
+// HeadEvent is a new head of the chain, with its receipts and the blocks of
+// the reorg it completes, if any. It is shared by all the subscribers and must
+// not be modified, its accessors returning copies of its slices.
+type HeadEvent struct {
+	block    *types.Block
+	receipts types.Receipts
+	oldChain []*types.Block // newest first
+	newChain []*types.Block // newest first, the head first
+}
+
+// Block returns the head.
+func (ev *HeadEvent) Block() *types.Block {
+	return ev.block
+}
+
+// Receipts returns the receipts of the transactions of the head, those of its
+// private transactions being the private receipts.
+func (ev *HeadEvent) Receipts() types.Receipts {
+	return append(types.Receipts(nil), ev.receipts...)
+}
+
+// PrivateReceipts returns the receipts of the private transactions of the head.
+func (ev *HeadEvent) PrivateReceipts() types.Receipts {
+	var receipts types.Receipts
+	for i, tx := range ev.block.Transactions() {
+		if tx.IsPrivate() && i < len(ev.receipts) {
+			receipts = append(receipts, ev.receipts[i])
+		}
+	}
+	return receipts
+}
+
+// Reorg returns the blocks which left and joined the canonical chain, newest
+// first, if the head completes a reorg. Both are empty otherwise.
+func (ev *HeadEvent) Reorg() (oldChain, newChain []*types.Block) {
+	return append([]*types.Block(nil), ev.oldChain...), append([]*types.Block(nil), ev.newChain...)
+}
+
+// headFeed enriches the ChainHeadEvents of a chain into HeadEvents. It is
+// started by the first subscription.
+type headFeed struct {
+	feed  event.Feed
+	scope event.SubscriptionScope
+	start sync.Once
+	prev  *types.Header // previous head
+}
+
+// SubscribeHeadEvent registers a subscription of HeadEvent.
+func (bc *BlockChain) SubscribeHeadEvent(ch chan<- *HeadEvent) event.Subscription {
+	bc.headFeed.start.Do(func() {
+		headCh := make(chan ChainHeadEvent, 64)
+		sub := bc.SubscribeChainHeadEvent(headCh)
+		bc.headFeed.prev = bc.CurrentHeader()
+		bc.wg.Add(1)
+		go bc.headFeedLoop(headCh, sub)
+	})
+	return bc.headFeed.scope.Track(bc.headFeed.feed.Subscribe(ch))
+}
+
+func (bc *BlockChain) headFeedLoop(headCh <-chan ChainHeadEvent, sub event.Subscription) {
+	defer bc.wg.Done()
+	defer sub.Unsubscribe()
+	defer bc.headFeed.scope.Close()
+
+	for {
+		select {
+		case ev := <-headCh:
+			// the subscribers may all be gone
+			if bc.headFeed.scope.Count() > 0 {
+				bc.headFeed.feed.Send(bc.enrichHead(ev.Block))
+			}
+			bc.headFeed.prev = ev.Block.Header()
+		case <-sub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// enrichHead reads the receipts of the head, and the blocks of the reorg
+// from the previous head if it is no longer canonical.
+func (bc *BlockChain) enrichHead(block *types.Block) *HeadEvent {
+	ev := &HeadEvent{block: block, receipts: bc.GetReceiptsByHash(block.Hash())}
+	headFeedReceiptsMeter.Mark(1)
+
+	prev := bc.headFeed.prev
+	if prev == nil || prev.Hash() == block.Hash() || bc.GetCanonicalHash(prev.Number.Uint64()) == prev.Hash() {
+		return ev
+	}
+	oldBlock, newBlock := bc.GetBlock(prev.Hash(), prev.Number.Uint64()), block
+	for oldBlock != nil && newBlock != nil && oldBlock.Hash() != newBlock.Hash() {
+		switch {
+		case oldBlock.NumberU64() > newBlock.NumberU64():
+			ev.oldChain = append(ev.oldChain, oldBlock)
+			oldBlock = bc.GetBlock(oldBlock.ParentHash(), oldBlock.NumberU64()-1)
+		case newBlock.NumberU64() > oldBlock.NumberU64():
+			ev.newChain = append(ev.newChain, newBlock)
+			newBlock = bc.GetBlock(newBlock.ParentHash(), newBlock.NumberU64()-1)
+		default:
+			ev.oldChain, ev.newChain = append(ev.oldChain, oldBlock), append(ev.newChain, newBlock)
+			oldBlock = bc.GetBlock(oldBlock.ParentHash(), oldBlock.NumberU64()-1)
+			newBlock = bc.GetBlock(newBlock.ParentHash(), newBlock.NumberU64()-1)
+		}
+	}
+	return ev
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveHeadEvent(t *testing.T, events <-chan *HeadEvent) *HeadEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no head event")
+		return nil
+	}
+}
+
+func TestHeadFeed(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	// a chain of 3 blocks with a transaction each, and a longer fork from the
+	// first block without transactions
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, common.Big1, 21000, nil, nil), signer, key)
+		require.NoError(t, err)
+		block.AddTx(tx)
+	})
+	fork, _ := GenerateChain(gspec.Config, blocks[0], ethash.NewFaker(), db, 3, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0x02})
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	events := make(chan *HeadEvent, 10)
+	sub := chain.SubscribeHeadEvent(events)
+	defer sub.Unsubscribe()
+
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+	ev := receiveHeadEvent(t, events)
+	assert.Equal(t, blocks[2].Hash(), ev.Block().Hash())
+	require.Len(t, ev.Receipts(), 1)
+	assert.Equal(t, blocks[2].Transactions()[0].Hash(), ev.Receipts()[0].TxHash)
+	assert.Empty(t, ev.PrivateReceipts())
+	oldChain, newChain := ev.Reorg()
+	assert.Empty(t, oldChain)
+	assert.Empty(t, newChain)
+
+	// the accessors return copies
+	ev.Receipts()[0] = nil
+	assert.NotNil(t, ev.Receipts()[0])
+
+	_, err = chain.InsertChain(fork)
+	require.NoError(t, err)
+	ev = receiveHeadEvent(t, events)
+	assert.Equal(t, fork[2].Hash(), ev.Block().Hash())
+	assert.Empty(t, ev.Receipts())
+	oldChain, newChain = ev.Reorg()
+	require.Len(t, oldChain, 2)
+	assert.Equal(t, blocks[2].Hash(), oldChain[0].Hash())
+	assert.Equal(t, blocks[1].Hash(), oldChain[1].Hash())
+	require.Len(t, newChain, 3)
+	for i, block := range newChain {
+		assert.Equal(t, fork[2-i].Hash(), block.Hash())
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	importHeaderTimer        = metrics.NewRegisteredTimer("chain/quorum/import/header", nil)
+	importPublicTimer        = metrics.NewRegisteredTimer("chain/quorum/import/public", nil)
+	importPrivateFetchTimer  = metrics.NewRegisteredTimer("chain/quorum/import/private/fetch", nil)
+	importPrivateTimer       = metrics.NewRegisteredTimer("chain/quorum/import/private", nil)
+	importReceiptsTimer      = metrics.NewRegisteredTimer("chain/quorum/import/receipts", nil)
+	importPublicCommitTimer  = metrics.NewRegisteredTimer("chain/quorum/import/commit/public", nil)
+	importPrivateCommitTimer = metrics.NewRegisteredTimer("chain/quorum/import/commit/private", nil)
+	importConsensusTimer     = metrics.NewRegisteredTimer("chain/quorum/import/consensus", nil)
+	importTotalTimer         = metrics.NewRegisteredTimer("chain/quorum/import/total", nil)
+	importSlowBlockMeter     = metrics.NewRegisteredMeter("chain/quorum/import/slow", nil)
+)
+
+const importTimingsLimit = 256 // Number of blocks kept for LastImportTimings
+
+// ImportTimings is the breakdown of the time spent importing a block, in
+// nanoseconds.
+type ImportTimings struct {
+	Number     uint64      `json:"number"`
+	Hash       common.Hash `json:"hash"`
+	Txs        int         `json:"txs"`
+	PrivateTxs int         `json:"privateTxs"`
+
+	HeaderValidation    time.Duration `json:"headerValidation"`    // Waiting for the header and body to be validated
+	PublicExecution     time.Duration `json:"publicExecution"`     // Applying the public transactions and finalizing the block
+	PrivatePayloadFetch time.Duration `json:"privatePayloadFetch"` // Waiting for the private transaction manager
+	PrivateExecution    time.Duration `json:"privateExecution"`    // Applying the private transactions, fetches excluded
+	ReceiptWrite        time.Duration `json:"receiptWrite"`        // Writing the block, receipts and private bloom
+	PublicTrieCommit    time.Duration `json:"publicTrieCommit"`
+	PrivateTrieCommit   time.Duration `json:"privateTrieCommit"`
+	// Choosing the fork and delivering the chain events the raft and istanbul
+	// engines act upon
+	ConsensusPostProcessing time.Duration `json:"consensusPostProcessing"`
+	Total                   time.Duration `json:"total"`
+}
+
+// importTimingsRing keeps the timings of the last imported blocks.
+type importTimingsRing struct {
+	lock     sync.Mutex
+	timings  []*ImportTimings
+	recorded uint64 // Number of timings recorded so far
+}
+
+func (r *importTimingsRing) add(timings *ImportTimings) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.timings == nil {
+		r.timings = make([]*ImportTimings, importTimingsLimit)
+	}
+	r.timings[r.recorded%importTimingsLimit] = timings
+	r.recorded++
+}
+
+// last returns up to the n last timings, oldest first.
+func (r *importTimingsRing) last(n int) []*ImportTimings {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	count := uint64(n)
+	if n < 0 || count > r.recorded {
+		count = r.recorded
+	}
+	if count > importTimingsLimit {
+		count = importTimingsLimit
+	}
+	last := make([]*ImportTimings, 0, count)
+	for i := r.recorded - count; i < r.recorded; i++ {
+		copied := *r.timings[i%importTimingsLimit]
+		last = append(last, &copied)
+	}
+	return last
+}
+
+// SetSlowImportThreshold sets the import time above which the breakdown of the
+// import of a block is logged, 0 to never log it. It must be set before the
+// chain is imported into.
+func (bc *BlockChain) SetSlowImportThreshold(threshold time.Duration) {
+	bc.slowImportThreshold = threshold
+}
+
+// LastImportTimings returns the breakdown of the import time of up to the n last
+// blocks imported by the node, oldest first. Blocks written by the local miner
+// or minter are not part of it.
+func (bc *BlockChain) LastImportTimings(n int) []*ImportTimings {
+	return bc.importTimings.last(n)
+}
+
+// recordImportTimings updates the import metrics with the timings of the block
+// and logs them if it was slow to import.
+func (bc *BlockChain) recordImportTimings(timings *ImportTimings) {
+	importHeaderTimer.Update(timings.HeaderValidation)
+	importPublicTimer.Update(timings.PublicExecution)
+	importPrivateFetchTimer.Update(timings.PrivatePayloadFetch)
+	importPrivateTimer.Update(timings.PrivateExecution)
+	importReceiptsTimer.Update(timings.ReceiptWrite)
+	importPublicCommitTimer.Update(timings.PublicTrieCommit)
+	importPrivateCommitTimer.Update(timings.PrivateTrieCommit)
+	importConsensusTimer.Update(timings.ConsensusPostProcessing)
+	importTotalTimer.Update(timings.Total)
+
+	bc.importTimings.add(timings)
+
+	if bc.slowImportThreshold > 0 && timings.Total > bc.slowImportThreshold {
+		importSlowBlockMeter.Mark(1)
+		log.Debug("Slow block import", "number", timings.Number, "hash", timings.Hash,
+			"txs", timings.Txs, "private", timings.PrivateTxs,
+			"header", common.PrettyDuration(timings.HeaderValidation),
+			"public", common.PrettyDuration(timings.PublicExecution),
+			"ptm", common.PrettyDuration(timings.PrivatePayloadFetch),
+			"privateevm", common.PrettyDuration(timings.PrivateExecution),
+			"receipts", common.PrettyDuration(timings.ReceiptWrite),
+			"publiccommit", common.PrettyDuration(timings.PublicTrieCommit),
+			"privatecommit", common.PrettyDuration(timings.PrivateTrieCommit),
+			"consensus", common.PrettyDuration(timings.ConsensusPostProcessing),
+			"elapsed", common.PrettyDuration(timings.Total))
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastImportTimings(t *testing.T) {
+	_, chain, err := newCanonical(ethash.NewFaker(), importTimingsLimit+10, true)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	last := chain.LastImportTimings(3)
+	require.Len(t, last, 3)
+	for i, timings := range last {
+		number := uint64(importTimingsLimit + 8 + i)
+		assert.Equal(t, number, timings.Number)
+		assert.Equal(t, chain.GetBlockByNumber(number).Hash(), timings.Hash)
+		assert.NotZero(t, timings.PublicTrieCommit)
+		assert.True(t, timings.Total >= timings.HeaderValidation+timings.PublicExecution+timings.PublicTrieCommit+timings.PrivateTrieCommit)
+	}
+
+	// only the last blocks are kept
+	all := chain.LastImportTimings(importTimingsLimit * 2)
+	require.Len(t, all, importTimingsLimit)
+	assert.Equal(t, uint64(11), all[0].Number)
+
+	// the timings returned are copies
+	last[0].Total = 0
+	assert.NotZero(t, chain.LastImportTimings(3)[0].Total)
+}
+
+func TestImportTimingsRing_Empty(t *testing.T) {
+	var ring importTimingsRing
+	assert.Empty(t, ring.last(10))
+
+	ring.add(&ImportTimings{Number: 1})
+	assert.Len(t, ring.last(-1), 1)
+	assert.Empty(t, ring.last(0))
+}
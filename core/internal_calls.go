@@ -0,0 +1,343 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	internalCallIndexTimer = metrics.NewRegisteredTimer("chain/internalcalls/index", nil)
+
+	// ErrInternalCallsNotIndexed is returned when the internal call index is
+	// disabled or a transaction was not indexed yet.
+	ErrInternalCallsNotIndexed = errors.New("internal calls not indexed")
+
+	errBackfillRunning = errors.New("internal call backfill already running")
+)
+
+// InternalCallRecorder is a vm.Tracer recording the calls and contract
+// creations made by contracts, grouped by transaction. It only inspects call
+// opcodes, so it adds little overhead to the execution.
+type InternalCallRecorder struct {
+	calls   map[common.Hash][]*types.InternalCall
+	pending []pendingInternalCall // calls waiting for their result, innermost last
+}
+
+type pendingInternalCall struct {
+	call  *types.InternalCall
+	depth int
+}
+
+// NewInternalCallRecorder creates a recorder for the transactions of a block.
+func NewInternalCallRecorder() *InternalCallRecorder {
+	return &InternalCallRecorder{calls: make(map[common.Hash][]*types.InternalCall)}
+}
+
+// Calls returns the recorded calls by transaction hash. Transactions which did
+// not make any internal call are not part of it.
+func (r *InternalCallRecorder) Calls() map[common.Hash][]*types.InternalCall {
+	return r.calls
+}
+
+func (r *InternalCallRecorder) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	r.pending = r.pending[:0]
+	return nil
+}
+
+func (r *InternalCallRecorder) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if err != nil {
+		// the opcode failed, which aborts the frame
+		return nil
+	}
+	r.resolve(stack, depth)
+
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+	default:
+		return nil
+	}
+	tx := env.CurrentTx()
+	if tx == nil {
+		return nil
+	}
+	call := &types.InternalCall{
+		Type:  op.String(),
+		From:  contract.Address(),
+		Value: new(big.Int),
+		Depth: uint64(depth),
+	}
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		call.To = stack.Back(1).Bytes20()
+		call.Value = stack.Back(2).ToBig()
+	case vm.DELEGATECALL, vm.STATICCALL:
+		call.To = stack.Back(1).Bytes20()
+	case vm.CREATE, vm.CREATE2:
+		call.Value = stack.Back(0).ToBig()
+	}
+	r.calls[tx.Hash()] = append(r.calls[tx.Hash()], call)
+	r.pending = append(r.pending, pendingInternalCall{call: call, depth: depth})
+	return nil
+}
+
+// resolve completes the pending calls made from frames at or below the given
+// depth. Back in the calling frame the result of a call is on top of the
+// stack: zero on failure, the new contract address for creations.
+func (r *InternalCallRecorder) resolve(stack *vm.Stack, depth int) {
+	for len(r.pending) > 0 {
+		pending := r.pending[len(r.pending)-1]
+		if pending.depth < depth {
+			return
+		}
+		r.pending = r.pending[:len(r.pending)-1]
+		if pending.depth > depth {
+			// the frame making the call left without resuming
+			pending.call.Error = "execution aborted"
+			continue
+		}
+		result := stack.Back(0)
+		switch {
+		case result.IsZero():
+			pending.call.Error = "execution failed"
+		case pending.call.Type == vm.CREATE.String() || pending.call.Type == vm.CREATE2.String():
+			pending.call.To = result.Bytes20()
+		}
+	}
+}
+
+func (r *InternalCallRecorder) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (r *InternalCallRecorder) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	return nil
+}
+
+// internalCallsConfig returns the VM configuration to import a block with and
+// the recorder of its internal calls, nil if the index is disabled or another
+// tracer is configured.
+func (bc *BlockChain) internalCallsConfig() (vm.Config, *InternalCallRecorder) {
+	cfg := bc.vmConfig
+	if atomic.LoadUint32(&bc.indexInternalCalls) == 0 || cfg.Tracer != nil {
+		return cfg, nil
+	}
+	recorder := NewInternalCallRecorder()
+	cfg.Debug, cfg.Tracer = true, recorder
+	return cfg, recorder
+}
+
+// writeInternalCalls stores the internal calls recorded for a block.
+func (bc *BlockChain) writeInternalCalls(block *types.Block, recorder *InternalCallRecorder) {
+	batch := bc.db.NewBatch()
+	for _, tx := range block.Transactions() {
+		calls := recorder.Calls()[tx.Hash()]
+		if calls == nil {
+			calls = []*types.InternalCall{}
+		}
+		rawdb.WriteInternalCalls(batch, tx.Hash(), calls)
+	}
+	rawdb.WriteInternalCallsIndexed(batch, block.Hash())
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write internal calls", "err", err)
+	}
+}
+
+// IndexInternalCalls re-executes a block on top of its parent state to index
+// the internal calls of its transactions. Private transactions are executed
+// against the private state, so their internal calls are only indexed on the
+// nodes party to them. The parent state must be available.
+func (bc *BlockChain) IndexInternalCalls(block *types.Block) error {
+	start := time.Now()
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return fmt.Errorf("parent of block #%d not found", block.NumberU64())
+	}
+	statedb, privateState, err := bc.StateAt(parent.Root)
+	if err != nil {
+		return err
+	}
+	cfg := bc.vmConfig
+	recorder := NewInternalCallRecorder()
+	cfg.Debug, cfg.Tracer = true, recorder
+	if _, _, _, _, err := bc.processor.Process(block, statedb, privateState, cfg); err != nil {
+		return err
+	}
+	bc.writeInternalCalls(block, recorder)
+	internalCallIndexTimer.UpdateSince(start)
+	return nil
+}
+
+// InternalCallsBackfillStatus is the progress of a backfill of the index.
+type InternalCallsBackfillStatus struct {
+	Running bool   `json:"running"`
+	Next    uint64 `json:"next"`
+	Last    uint64 `json:"last"`
+	Error   string `json:"error,omitempty"`
+}
+
+// InternalCallIndexer maintains the internal call index. Blocks imported from
+// the network are indexed during import, the indexer re-executes the blocks
+// sealed locally and runs backfills over ranges of older blocks. Backfills
+// record their progress so that they resume after a restart.
+type InternalCallIndexer struct {
+	bc *BlockChain
+
+	lock     sync.Mutex
+	backfill *InternalCallsBackfillStatus
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewInternalCallIndexer enables the internal call index of a chain.
+func NewInternalCallIndexer(bc *BlockChain) *InternalCallIndexer {
+	atomic.StoreUint32(&bc.indexInternalCalls, 1)
+	return &InternalCallIndexer{
+		bc:   bc,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start indexes new blocks not indexed during import and resumes any
+// unfinished backfill.
+func (ix *InternalCallIndexer) Start() {
+	ix.wg.Add(1)
+	go ix.loop()
+
+	if progress := rawdb.ReadInternalCallsBackfill(ix.bc.db); progress != nil {
+		log.Info("Resuming internal call backfill", "next", progress.Next, "last", progress.Last)
+		ix.startBackfill(progress.Next, progress.Last)
+	}
+}
+
+// Stop terminates the indexer, interrupting any running backfill.
+func (ix *InternalCallIndexer) Stop() {
+	close(ix.quit)
+	ix.wg.Wait()
+}
+
+func (ix *InternalCallIndexer) loop() {
+	defer ix.wg.Done()
+
+	chainCh := make(chan ChainEvent, 64)
+	sub := ix.bc.SubscribeChainEvent(chainCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			if rawdb.HasInternalCallsIndexed(ix.bc.db, ev.Block.Hash()) {
+				continue
+			}
+			if err := ix.bc.IndexInternalCalls(ev.Block); err != nil {
+				log.Warn("Failed to index internal calls", "number", ev.Block.Number(), "hash", ev.Block.Hash(), "err", err)
+			}
+		case <-sub.Err():
+			return
+		case <-ix.quit:
+			return
+		}
+	}
+}
+
+// Backfill starts indexing the canonical blocks in [from, to] in the
+// background. Blocks already indexed are skipped.
+func (ix *InternalCallIndexer) Backfill(from, to uint64) (*InternalCallsBackfillStatus, error) {
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("invalid range %d - %d", from, to)
+	}
+	if head := ix.bc.CurrentBlock().NumberU64(); to > head {
+		return nil, fmt.Errorf("block #%d beyond head #%d", to, head)
+	}
+	return ix.startBackfill(from, to)
+}
+
+func (ix *InternalCallIndexer) startBackfill(from, to uint64) (*InternalCallsBackfillStatus, error) {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	if ix.backfill != nil && ix.backfill.Running {
+		return nil, errBackfillRunning
+	}
+	ix.backfill = &InternalCallsBackfillStatus{Running: true, Next: from, Last: to}
+	rawdb.WriteInternalCallsBackfill(ix.bc.db, &rawdb.InternalCallsBackfill{Next: from, Last: to})
+
+	ix.wg.Add(1)
+	go ix.runBackfill(from, to)
+
+	status := *ix.backfill
+	return &status, nil
+}
+
+func (ix *InternalCallIndexer) runBackfill(from, to uint64) {
+	defer ix.wg.Done()
+
+	var err error
+	for number := from; number <= to; number++ {
+		select {
+		case <-ix.quit:
+			return
+		default:
+		}
+		block := ix.bc.GetBlockByNumber(number)
+		if block == nil {
+			err = fmt.Errorf("block #%d not found", number)
+			break
+		}
+		if !rawdb.HasInternalCallsIndexed(ix.bc.db, block.Hash()) {
+			if err = ix.bc.IndexInternalCalls(block); err != nil {
+				err = fmt.Errorf("block #%d: %v", number, err)
+				break
+			}
+		}
+		rawdb.WriteInternalCallsBackfill(ix.bc.db, &rawdb.InternalCallsBackfill{Next: number + 1, Last: to})
+
+		ix.lock.Lock()
+		ix.backfill.Next = number + 1
+		ix.lock.Unlock()
+	}
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	ix.backfill.Running = false
+	if err != nil {
+		// the progress is kept so that the backfill resumes after a restart
+		ix.backfill.Error = err.Error()
+		log.Warn("Internal call backfill failed", "err", err)
+		return
+	}
+	rawdb.DeleteInternalCallsBackfill(ix.bc.db)
+	log.Info("Internal call backfill done", "from", from, "to", to)
+}
+
+// BackfillStatus returns the progress of the last backfill, nil if none ran.
+func (ix *InternalCallIndexer) BackfillStatus() *InternalCallsBackfillStatus {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	if ix.backfill == nil {
+		return nil
+	}
+	status := *ix.backfill
+	return &status
+}
+
+// InternalCalls returns the internal calls made by a transaction.
+func (ix *InternalCallIndexer) InternalCalls(txHash common.Hash) ([]*types.InternalCall, error) {
+	calls, ok := rawdb.ReadInternalCalls(ix.bc.db, txHash)
+	if !ok {
+		return nil, ErrInternalCallsNotIndexed
+	}
+	return calls, nil
+}
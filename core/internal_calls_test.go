@@ -0,0 +1,167 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	internalCallsCaller   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	internalCallsCallee   = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	internalCallsReverter = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+)
+
+// internalCallCode returns code calling the given address with the given value
+// and discarding the result.
+func internalCallCode(to common.Address, value byte) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), value, byte(vm.PUSH20),
+	}
+	code = append(code, to.Bytes()...)
+	return append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+}
+
+// newInternalCallsChain generates blocks, each calling a contract which calls
+// a contract succeeding and one reverting.
+func newInternalCallsChain(tb testing.TB, n int) (ethdb.Database, *Genesis, []*types.Block) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		code    = append(internalCallCode(internalCallsCallee, 1), append(internalCallCode(internalCallsReverter, 0), byte(vm.STOP))...)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				address:               {Balance: big.NewInt(1000000000000000)},
+				internalCallsCaller:   {Balance: big.NewInt(1000000), Code: code},
+				internalCallsCallee:   {Balance: common.Big0, Code: []byte{byte(vm.STOP)}},
+				internalCallsReverter: {Balance: common.Big0, Code: []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, n, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), internalCallsCaller, common.Big0, 100000, nil, nil), signer, key)
+		require.NoError(tb, err)
+		block.AddTx(tx)
+	})
+	return db, gspec, blocks
+}
+
+func newInternalCallsBlockChain(tb testing.TB, gspec *Genesis) (ethdb.Database, *BlockChain) {
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(tb, err)
+	return db, chain
+}
+
+func assertInternalCalls(t *testing.T, db ethdb.Database, tx *types.Transaction) {
+	calls, ok := rawdb.ReadInternalCalls(db, tx.Hash())
+	require.True(t, ok, "internal calls not indexed")
+	require.Len(t, calls, 2)
+
+	assert.Equal(t, &types.InternalCall{Type: "CALL", From: internalCallsCaller, To: internalCallsCallee, Value: big.NewInt(1), Depth: 1}, calls[0])
+	assert.Equal(t, &types.InternalCall{Type: "CALL", From: internalCallsCaller, To: internalCallsReverter, Value: new(big.Int), Depth: 1, Error: "execution failed"}, calls[1])
+}
+
+func TestInternalCallIndexer_Import(t *testing.T) {
+	_, gspec, blocks := newInternalCallsChain(t, 4)
+	db, chain := newInternalCallsBlockChain(t, gspec)
+	defer chain.Stop()
+
+	indexer := NewInternalCallIndexer(chain)
+	_, err := chain.InsertChain(blocks)
+	require.NoError(t, err)
+
+	for _, block := range blocks {
+		assert.True(t, rawdb.HasInternalCallsIndexed(db, block.Hash()))
+		assertInternalCalls(t, db, block.Transactions()[0])
+	}
+	_, err = indexer.InternalCalls(common.Hash{0x01})
+	assert.Equal(t, ErrInternalCallsNotIndexed, err)
+}
+
+func TestInternalCallIndexer_Backfill(t *testing.T) {
+	_, gspec, blocks := newInternalCallsChain(t, 6)
+	db, chain := newInternalCallsBlockChain(t, gspec)
+	defer chain.Stop()
+
+	_, err := chain.InsertChain(blocks)
+	require.NoError(t, err)
+	_, ok := rawdb.ReadInternalCalls(db, blocks[0].Transactions()[0].Hash())
+	require.False(t, ok, "indexed while disabled")
+
+	// a backfill interrupted by a restart resumes from its progress
+	rawdb.WriteInternalCallsBackfill(db, &rawdb.InternalCallsBackfill{Next: 3, Last: 5})
+	indexer := NewInternalCallIndexer(chain)
+	indexer.Start()
+	defer indexer.Stop()
+
+	waitBackfill(t, indexer)
+	assert.Nil(t, rawdb.ReadInternalCallsBackfill(db))
+	for _, block := range blocks[:6] {
+		indexed := block.NumberU64() >= 3 && block.NumberU64() <= 5
+		assert.Equal(t, indexed, rawdb.HasInternalCallsIndexed(db, block.Hash()), "block #%d", block.NumberU64())
+	}
+
+	_, err = indexer.Backfill(4, 7)
+	assert.Error(t, err, "beyond head")
+
+	status, err := indexer.Backfill(1, 6)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), status.Next)
+
+	status = waitBackfill(t, indexer)
+	assert.Equal(t, &InternalCallsBackfillStatus{Next: 7, Last: 6}, status)
+	for _, block := range blocks {
+		assertInternalCalls(t, db, block.Transactions()[0])
+	}
+}
+
+func waitBackfill(t *testing.T, indexer *InternalCallIndexer) *InternalCallsBackfillStatus {
+	for i := 0; i < 100; i++ {
+		if status := indexer.BackfillStatus(); status != nil && !status.Running {
+			return status
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("backfill did not complete")
+	return nil
+}
+
+func BenchmarkImportInternalCalls(b *testing.B) {
+	_, gspec, blocks := newInternalCallsChain(b, 128)
+
+	bench := func(b *testing.B, index bool) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			_, chain := newInternalCallsBlockChain(b, gspec)
+			if index {
+				NewInternalCallIndexer(chain)
+			}
+			b.StartTimer()
+			if _, err := chain.InsertChain(blocks); err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+			chain.Stop()
+		}
+	}
+	b.Run("disabled", func(b *testing.B) { bench(b, false) })
+	b.Run("enabled", func(b *testing.B) { bench(b, true) })
+}
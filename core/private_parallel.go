@@ -0,0 +1,209 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/private"
+)
+
+var (
+	// privateParallelAppliedMeter counts the private transactions whose
+	// concurrent execution was merged into the block state.
+	privateParallelAppliedMeter = metrics.NewRegisteredMeter("private/parallel/applied", nil)
+	// privateParallelReexecutedMeter counts the private transactions with
+	// execution hints executed again serially, as they touched more than their
+	// hints or accounts modified earlier in the block.
+	privateParallelReexecutedMeter = metrics.NewRegisteredMeter("private/parallel/reexecuted", nil)
+)
+
+var errNoExecutionHints = errors.New("private transaction has no execution hints")
+
+// SetPrivateParallelism sets the number of private transactions with execution
+// hints executed concurrently while processing a block, 0 to execute all of
+// them serially. It must be set before the chain is imported into.
+func (bc *BlockChain) SetPrivateParallelism(workers int) {
+	bc.privateParallelism = workers
+}
+
+// privateSpeculation is the execution of a private transaction against copies
+// of the states at the start of its block. Its result is only merged into the
+// states of the block if the serial execution would have observed the same
+// accounts, which the execution hints are never trusted for.
+type privateSpeculation struct {
+	done chan struct{} // closed once executed
+
+	from           common.Address
+	hints          map[common.Address]struct{}
+	publicState    *state.StateDB
+	privateState   *state.StateDB
+	receipt        *types.Receipt
+	privateReceipt *types.Receipt
+	err            error // set if the transaction must be executed serially
+}
+
+// speculatePrivate starts executing the private transactions of the block
+// concurrently, returning their speculations indexed as the transactions or
+// nil if the block is to be executed serially. It must be called before any
+// transaction of the block is applied to the states.
+func (p *StateProcessor) speculatePrivate(block *types.Block, statedb, privateState *state.StateDB, cfg vm.Config) []*privateSpeculation {
+	if p.bc == nil || p.bc.privateParallelism <= 0 || cfg.Debug || cfg.EnablePreimageRecording ||
+		!p.config.IsQuorum || !p.config.IsByzantium(block.Number()) {
+		return nil
+	}
+	txs := block.Transactions()
+	var candidates []int
+	for i, tx := range txs {
+		// value transfers depend on the balance of the sender, left to the serial execution
+		if tx.IsPrivate() && tx.Value().Sign() == 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+	specs := make([]*privateSpeculation, len(txs))
+	tasks := make(chan int, len(candidates))
+	for _, i := range candidates {
+		specs[i] = &privateSpeculation{
+			done:         make(chan struct{}),
+			publicState:  statedb.Copy(),
+			privateState: privateState.Copy(),
+		}
+		tasks <- i
+	}
+	close(tasks)
+
+	workers := p.bc.privateParallelism
+	if workers > len(candidates) {
+		workers = len(candidates)
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range tasks {
+				p.speculate(specs[i], block, i, cfg)
+				close(specs[i].done)
+			}
+		}()
+	}
+	return specs
+}
+
+// speculate executes the private transaction at the given index of the block
+// against the copies of the states of its speculation.
+func (p *StateProcessor) speculate(spec *privateSpeculation, block *types.Block, index int, cfg vm.Config) {
+	tx, header := block.Transactions()[index], block.Header()
+	msg, err := tx.AsMessage(types.MakeSigner(p.config, header.Number))
+	if err != nil {
+		spec.err = err
+		return
+	}
+	_, _, _, extra, err := private.P.Receive(context.Background(), common.BytesToEncryptedPayloadHash(tx.Data()))
+	if err != nil {
+		spec.err = err
+		return
+	}
+	if extra == nil || len(extra.ExecutionHints) == 0 {
+		spec.err = errNoExecutionHints
+		return
+	}
+	spec.from = msg.From()
+	spec.hints = make(map[common.Address]struct{}, len(extra.ExecutionHints))
+	for _, addr := range extra.ExecutionHints {
+		spec.hints[addr] = struct{}{}
+	}
+
+	spec.publicState.TrackAccesses()
+	spec.privateState.TrackAccesses()
+	// the nonce of the sender is checked against the block state when merging
+	spec.publicState.SetNonce(spec.from, tx.Nonce())
+	spec.publicState.Prepare(tx.Hash(), block.Hash(), index)
+	spec.privateState.Prepare(tx.Hash(), block.Hash(), index)
+
+	var usedGas uint64
+	gp := new(GasPool).AddGas(block.GasLimit())
+	spec.receipt, spec.privateReceipt, spec.err = ApplyTransaction(p.config, p.bc, nil, gp, spec.publicState, spec.privateState, header, tx, &usedGas, cfg)
+}
+
+// applySpeculation merges the result of the speculation of the transaction
+// into the states of the block if its serial execution, at this point of the
+// block, would have produced the same result. It returns false, leaving the
+// states untouched, if the transaction must be executed serially instead.
+//
+// The result is the same if the transaction read nothing but the sender and
+// the coinbase from the public state, and if the private accounts it touched
+// are within its hints and unmodified so far in the block. Accounts which did
+// not exist before and after the speculation, e.g. the sender in the private
+// state, only need to not exist yet.
+func (p *StateProcessor) applySpeculation(spec *privateSpeculation, tx *types.Transaction, header *types.Header, initialPrivateState, statedb, privateState *state.StateDB, gp *GasPool, usedGas *uint64) (*types.Receipt, *types.Receipt, bool) {
+	<-spec.done
+	if spec.err == errNoExecutionHints {
+		return nil, nil, false
+	}
+	if spec.err != nil || spec.privateReceipt == nil {
+		privateParallelReexecutedMeter.Mark(1)
+		return nil, nil, false
+	}
+	if !p.mergeable(spec, tx, header, initialPrivateState, statedb, privateState, gp) {
+		privateParallelReexecutedMeter.Mark(1)
+		return nil, nil, false
+	}
+	if err := privateState.MergeAccounts(spec.privateState, spec.privateState.AccessedAddresses()); err != nil {
+		privateParallelReexecutedMeter.Mark(1)
+		return nil, nil, false
+	}
+	for _, addr := range spec.publicState.AccessedAddresses() {
+		if addr == spec.from {
+			statedb.SetNonce(addr, spec.publicState.GetNonce(addr))
+		} else {
+			// the coinbase is only ever touched by a zero fee
+			statedb.AddBalance(addr, common.Big0)
+		}
+	}
+	statedb.Finalise(true)
+	for _, log := range spec.privateReceipt.Logs {
+		copied := *log
+		privateState.AddLog(&copied)
+	}
+	privateState.Finalise(true)
+
+	gp.SubGas(spec.receipt.GasUsed)
+	*usedGas += spec.receipt.GasUsed
+	receipt, privateReceipt := spec.receipt, spec.privateReceipt
+	receipt.CumulativeGasUsed = *usedGas
+	privateReceipt.CumulativeGasUsed = *usedGas
+	privateReceipt.Logs = privateState.GetLogs(tx.Hash())
+
+	privateParallelAppliedMeter.Mark(1)
+	return receipt, privateReceipt, true
+}
+
+// mergeable reports whether the speculation observed the accounts its serial
+// execution would, see applySpeculation.
+func (p *StateProcessor) mergeable(spec *privateSpeculation, tx *types.Transaction, header *types.Header, initialPrivateState, statedb, privateState *state.StateDB, gp *GasPool) bool {
+	for _, addr := range spec.publicState.AccessedAddresses() {
+		if addr != spec.from && addr != header.Coinbase {
+			return false
+		}
+	}
+	if statedb.GetNonce(spec.from) != tx.Nonce() || gp.Gas() < tx.Gas() {
+		return false
+	}
+	for _, addr := range spec.privateState.AccessedAddresses() {
+		if !initialPrivateState.Exist(addr) && !spec.privateState.Exist(addr) {
+			if privateState.Exist(addr) {
+				return false
+			}
+			continue
+		}
+		if _, hinted := spec.hints[addr]; !hinted || privateState.IsModified(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hashingContract hashes 1024 times, then stores the first word of its input
+// in slot 0 and logs.
+var hashingContract = common.FromHex("0x6104005b60019003806000526020600020508060035760003560005560006000a000")
+
+// hintingPrivateTransactionManager returns the payloads and execution hints of
+// the private transactions.
+type hintingPrivateTransactionManager struct {
+	notinuse.PrivateTransactionManager
+	payloads map[common.EncryptedPayloadHash][]byte
+	hints    map[common.EncryptedPayloadHash][]common.Address
+}
+
+func (ptm *hintingPrivateTransactionManager) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	return "", nil, ptm.payloads[hash], &engine.ExtraMetadata{ExecutionHints: ptm.hints[hash]}, nil
+}
+
+type parallelTestEnv struct {
+	t         testing.TB
+	ptm       *hintingPrivateTransactionManager
+	chain     *BlockChain
+	db        state.Database
+	root      common.Hash // private state root with the contracts
+	contracts []common.Address
+	txs       []*types.Transaction
+}
+
+func newParallelTestEnv(t testing.TB, contracts int) *parallelTestEnv {
+	db := rawdb.NewMemoryDatabase()
+	(&Genesis{Config: params.QuorumTestChainConfig}).MustCommit(db)
+	chain, err := NewBlockChain(db, nil, params.QuorumTestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+
+	env := &parallelTestEnv{
+		t: t,
+		ptm: &hintingPrivateTransactionManager{
+			payloads: make(map[common.EncryptedPayloadHash][]byte),
+			hints:    make(map[common.EncryptedPayloadHash][]common.Address),
+		},
+		chain: chain,
+		db:    state.NewDatabase(rawdb.NewMemoryDatabase()),
+	}
+	privateState, _ := state.New(common.Hash{}, env.db, nil)
+	for i := 0; i < contracts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		privateState.SetCode(addr, hashingContract)
+		env.contracts = append(env.contracts, addr)
+	}
+	env.root, err = privateState.Commit(true)
+	require.NoError(t, err)
+	require.NoError(t, env.db.TrieDB().Commit(env.root, false, nil))
+	return env
+}
+
+// addPrivate adds a private transaction calling the contract, with the given
+// contracts as execution hints.
+func (env *parallelTestEnv) addPrivate(contract int, hints ...int) {
+	nonce := uint64(len(env.txs))
+	payload := common.BigToHash(big.NewInt(int64(nonce + 1))).Bytes()
+	hash := common.BytesToEncryptedPayloadHash(crypto.Keccak512(payload))
+	env.ptm.payloads[hash] = payload
+	for _, hint := range hints {
+		env.ptm.hints[hash] = append(env.ptm.hints[hash], env.contracts[hint])
+	}
+	tx := types.NewTransaction(nonce, env.contracts[contract], common.Big0, 200000, common.Big0, hash.Bytes())
+	tx.SetPrivate()
+	signed, err := types.SignTx(tx, types.QuorumPrivateTxSigner{}, benchRootKey)
+	require.NoError(env.t, err)
+	env.txs = append(env.txs, signed)
+}
+
+func (env *parallelTestEnv) addPublic() {
+	tx := types.NewTransaction(uint64(len(env.txs)), common.Address{0x01}, common.Big0, 21000, common.Big0, nil)
+	signed, err := types.SignTx(tx, types.HomesteadSigner{}, benchRootKey)
+	require.NoError(env.t, err)
+	env.txs = append(env.txs, signed)
+}
+
+func (env *parallelTestEnv) block() *types.Block {
+	header := &types.Header{
+		ParentHash: env.chain.Genesis().Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   1 << 40,
+		Coinbase:   common.Address{0xc0},
+		Difficulty: big.NewInt(1),
+	}
+	return types.NewBlock(header, env.txs, nil, nil, new(trie.Trie))
+}
+
+type parallelTestResult struct {
+	publicRoot, privateRoot   common.Hash
+	receipts, privateReceipts types.Receipts
+	logs                      []*types.Log
+	usedGas                   uint64
+}
+
+func (env *parallelTestEnv) process(block *types.Block, parallelism int) *parallelTestResult {
+	saved := private.P
+	defer func() { private.P = saved }()
+	private.P = env.ptm
+
+	env.chain.SetPrivateParallelism(parallelism)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	privateState, err := state.New(env.root, env.db, nil)
+	require.NoError(env.t, err)
+
+	processor := NewStateProcessor(env.chain.Config(), env.chain, ethash.NewFaker())
+	receipts, privateReceipts, logs, usedGas, err := processor.Process(block, statedb, privateState, vm.Config{})
+	require.NoError(env.t, err)
+	return &parallelTestResult{
+		publicRoot:      statedb.IntermediateRoot(true),
+		privateRoot:     privateState.IntermediateRoot(true),
+		receipts:        receipts,
+		privateReceipts: privateReceipts,
+		logs:            logs,
+		usedGas:         usedGas,
+	}
+}
+
+func TestPrivateParallel_SameResultAsSerial(t *testing.T) {
+	env := newParallelTestEnv(t, 8)
+	defer env.chain.Stop()
+
+	for i := 0; i < 4; i++ {
+		env.addPrivate(i, i)
+	}
+	env.addPublic()
+	env.addPrivate(4, 4)
+	env.addPrivate(0, 0) // modifies a contract modified earlier in the block
+	env.addPrivate(6, 7) // calls a contract outside of its hints
+	env.addPrivate(5)    // no hints
+	block := env.block()
+
+	applied, reexecuted := privateParallelAppliedMeter, privateParallelReexecutedMeter
+	defer func() { privateParallelAppliedMeter, privateParallelReexecutedMeter = applied, reexecuted }()
+	privateParallelAppliedMeter, privateParallelReexecutedMeter = metrics.NewMeterForced(), metrics.NewMeterForced()
+
+	serial := env.process(block, 0)
+	assert.Zero(t, privateParallelAppliedMeter.Count())
+
+	parallel := env.process(block, 4)
+	assert.Equal(t, int64(5), privateParallelAppliedMeter.Count())
+	assert.Equal(t, int64(2), privateParallelReexecutedMeter.Count())
+
+	assert.Equal(t, serial.publicRoot, parallel.publicRoot)
+	assert.Equal(t, serial.privateRoot, parallel.privateRoot)
+	assert.Equal(t, serial.usedGas, parallel.usedGas)
+	assert.Equal(t, serial.receipts, parallel.receipts)
+	assert.Equal(t, serial.privateReceipts, parallel.privateReceipts)
+	assert.Equal(t, serial.logs, parallel.logs)
+	require.Len(t, parallel.logs, 8)
+	for i, log := range parallel.logs {
+		assert.Equal(t, uint(i), log.Index)
+	}
+}
+
+func BenchmarkPrivateParallel(b *testing.B) {
+	env := newParallelTestEnv(b, 200)
+	defer env.chain.Stop()
+	for i := range env.contracts {
+		env.addPrivate(i, i)
+	}
+	block := env.block()
+
+	for _, parallelism := range []int{0, 4, 8} {
+		b.Run(fmt.Sprintf("parallelism-%d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				env.process(block, parallelism)
+			}
+		})
+	}
+}
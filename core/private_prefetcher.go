@@ -0,0 +1,271 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/private"
+)
+
+const (
+	// maxPrefetchBlocks is the number of blocks tracked by the private payload
+	// prefetcher, blocks beyond it are not prefetched.
+	maxPrefetchBlocks = 256
+	// prefetchQueueSize is the number of payloads waiting to be fetched.
+	prefetchQueueSize = 4096
+	// maxHintedBlocksAhead is the number of blocks ahead of the head the
+	// payloads hinted by the peers are prefetched for.
+	maxHintedBlocksAhead = 8
+)
+
+var (
+	privatePrefetchScheduledMeter = metrics.NewRegisteredMeter("private/prefetch/scheduled", nil)
+	privatePrefetchDroppedMeter   = metrics.NewRegisteredMeter("private/prefetch/dropped", nil)
+	privatePrefetchFetchedMeter   = metrics.NewRegisteredMeter("private/prefetch/fetched", nil)
+	privatePrefetchFailedMeter    = metrics.NewRegisteredMeter("private/prefetch/failed", nil)
+	privatePrefetchCancelledMeter = metrics.NewRegisteredMeter("private/prefetch/cancelled", nil)
+	privatePrefetchStaleHintMeter = metrics.NewRegisteredMeter("private/prefetch/stalehints", nil)
+
+	// the hit rate is hits / (hits + pending + misses) at execution time
+	privatePrefetchHitMeter     = metrics.NewRegisteredMeter("private/prefetch/hits", nil)
+	privatePrefetchPendingMeter = metrics.NewRegisteredMeter("private/prefetch/pending", nil)
+	privatePrefetchMissMeter    = metrics.NewRegisteredMeter("private/prefetch/misses", nil)
+)
+
+// PrivatePrefetcher warms the private transaction manager cache with the
+// payloads of the private transactions of blocks received ahead of their
+// execution, so that the state processor does not wait on sequential fetches.
+// Scheduling never blocks: work beyond the queue capacity is dropped and left
+// to the state processor.
+type PrivatePrefetcher struct {
+	bc      *BlockChain
+	workers int
+	queue   chan *prefetchTask
+
+	lock   sync.Mutex
+	blocks map[common.Hash]*prefetchBlock
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// prefetchBlock tracks the payloads of a block being prefetched.
+type prefetchBlock struct {
+	number   uint64
+	ctx      context.Context
+	cancel   context.CancelFunc
+	payloads map[common.EncryptedPayloadHash]bool // true once fetched
+}
+
+type prefetchTask struct {
+	block *prefetchBlock
+	hash  common.EncryptedPayloadHash
+}
+
+// NewPrivatePrefetcher creates a prefetcher issuing at most the given number
+// of concurrent requests to the private transaction manager, and attaches it
+// to the chain.
+func NewPrivatePrefetcher(bc *BlockChain, workers int) *PrivatePrefetcher {
+	p := &PrivatePrefetcher{
+		bc:      bc,
+		workers: workers,
+		queue:   make(chan *prefetchTask, prefetchQueueSize),
+		blocks:  make(map[common.Hash]*prefetchBlock),
+		quit:    make(chan struct{}),
+	}
+	bc.privatePrefetcher = p
+	return p
+}
+
+// Start launches the workers fetching the payloads.
+func (p *PrivatePrefetcher) Start() {
+	headCh := make(chan ChainHeadEvent, 16)
+	sub := p.bc.SubscribeChainHeadEvent(headCh)
+
+	p.wg.Add(p.workers + 1)
+	for i := 0; i < p.workers; i++ {
+		go p.fetch()
+	}
+	go p.loop(headCh, sub)
+}
+
+// Stop terminates the workers, abandoning the queued payloads.
+func (p *PrivatePrefetcher) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// Prefetch schedules the payloads of the private transactions of the given
+// blocks to be fetched. Payloads already scheduled are ignored.
+func (p *PrivatePrefetcher) Prefetch(blocks ...*types.Block) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, block := range blocks {
+		var hashes []common.EncryptedPayloadHash
+		for _, tx := range block.Transactions() {
+			if tx.IsPrivate() {
+				hashes = append(hashes, common.BytesToEncryptedPayloadHash(tx.Data()))
+			}
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+		p.schedule(block.Hash(), block.NumberU64(), hashes)
+	}
+}
+
+// PrefetchHinted schedules a payload of a block not received yet to be
+// fetched, as hinted by a peer party to it. Hints for blocks at or behind the
+// head, or too far ahead of it, are ignored.
+func (p *PrivatePrefetcher) PrefetchHinted(blockHash common.Hash, number uint64, hash common.EncryptedPayloadHash) {
+	head := p.bc.CurrentBlock().NumberU64()
+	if number <= head || number > head+maxHintedBlocksAhead {
+		privatePrefetchStaleHintMeter.Mark(1)
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.schedule(blockHash, number, []common.EncryptedPayloadHash{hash})
+}
+
+// schedule queues the payloads of the block which are not scheduled yet, the
+// lock being held.
+func (p *PrivatePrefetcher) schedule(blockHash common.Hash, number uint64, hashes []common.EncryptedPayloadHash) {
+	pb, ok := p.blocks[blockHash]
+	if !ok {
+		if len(p.blocks) >= maxPrefetchBlocks {
+			privatePrefetchDroppedMeter.Mark(int64(len(hashes)))
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		pb = &prefetchBlock{
+			number:   number,
+			ctx:      ctx,
+			cancel:   cancel,
+			payloads: make(map[common.EncryptedPayloadHash]bool, len(hashes)),
+		}
+		p.blocks[blockHash] = pb
+	}
+	for i, hash := range hashes {
+		if _, ok := pb.payloads[hash]; ok {
+			continue
+		}
+		select {
+		case p.queue <- &prefetchTask{block: pb, hash: hash}:
+			pb.payloads[hash] = false
+			privatePrefetchScheduledMeter.Mark(1)
+		default:
+			privatePrefetchDroppedMeter.Mark(int64(len(hashes) - i))
+			return
+		}
+	}
+}
+
+// consume reports how many of the payloads of a block about to be executed
+// were prefetched, and stops prefetching the block.
+func (p *PrivatePrefetcher) consume(block *types.Block) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pb := p.blocks[block.Hash()]
+	for _, tx := range block.Transactions() {
+		if !tx.IsPrivate() {
+			continue
+		}
+		fetched, scheduled := false, false
+		if pb != nil {
+			fetched, scheduled = pb.payloads[common.BytesToEncryptedPayloadHash(tx.Data())]
+		}
+		switch {
+		case fetched:
+			privatePrefetchHitMeter.Mark(1)
+		case scheduled:
+			privatePrefetchPendingMeter.Mark(1)
+		default:
+			privatePrefetchMissMeter.Mark(1)
+		}
+	}
+	if pb != nil {
+		pb.cancel()
+		delete(p.blocks, block.Hash())
+	}
+}
+
+// fetch retrieves the queued payloads, caching them in the private
+// transaction manager.
+func (p *PrivatePrefetcher) fetch() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case task := <-p.queue:
+			if task.block.ctx.Err() != nil {
+				privatePrefetchCancelledMeter.Mark(1)
+				continue
+			}
+			if _, _, _, _, err := private.P.Receive(task.block.ctx, task.hash); err != nil {
+				privatePrefetchFailedMeter.Mark(1)
+				log.Debug("Failed to prefetch private payload", "hash", task.hash, "err", err)
+				continue
+			}
+			privatePrefetchFetchedMeter.Mark(1)
+
+			p.lock.Lock()
+			if _, ok := task.block.payloads[task.hash]; ok {
+				task.block.payloads[task.hash] = true
+			}
+			p.lock.Unlock()
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// loop cancels the prefetching of blocks overtaken by the chain head, either
+// imported or having lost a reorg race.
+func (p *PrivatePrefetcher) loop(headCh <-chan ChainHeadEvent, sub event.Subscription) {
+	defer p.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			head := ev.Block.NumberU64()
+			p.lock.Lock()
+			for hash, pb := range p.blocks {
+				if pb.number <= head {
+					pb.cancel()
+					delete(p.blocks, hash)
+				}
+			}
+			p.lock.Unlock()
+		case <-sub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// PrefetchPrivatePayloads schedules the private payloads of blocks received
+// ahead of their import to be fetched, if the prefetcher is enabled.
+func (bc *BlockChain) PrefetchPrivatePayloads(blocks ...*types.Block) {
+	if bc.privatePrefetcher != nil {
+		bc.privatePrefetcher.Prefetch(blocks...)
+	}
+}
+
+// PrefetchHintedPrivatePayload schedules a private payload hinted by a peer to
+// be fetched ahead of the block, if the prefetcher is enabled.
+func (bc *BlockChain) PrefetchHintedPrivatePayload(blockHash common.Hash, number uint64, hash common.EncryptedPayloadHash) {
+	if bc.privatePrefetcher != nil {
+		bc.privatePrefetcher.PrefetchHinted(blockHash, number, hash)
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPrivateTransactionManager records the payloads received, blocking
+// until released if a gate is set.
+type countingPrivateTransactionManager struct {
+	notinuse.PrivateTransactionManager
+	gate chan struct{}
+
+	lock     sync.Mutex
+	received map[common.EncryptedPayloadHash]int
+}
+
+func (ptm *countingPrivateTransactionManager) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	if ptm.gate != nil {
+		<-ptm.gate
+	}
+	ptm.lock.Lock()
+	defer ptm.lock.Unlock()
+	ptm.received[hash]++
+	return "", nil, []byte{0x01}, &engine.ExtraMetadata{}, nil
+}
+
+func (ptm *countingPrivateTransactionManager) count(hash common.EncryptedPayloadHash) int {
+	ptm.lock.Lock()
+	defer ptm.lock.Unlock()
+	return ptm.received[hash]
+}
+
+func newPrefetchBlock(number int64, payloads ...byte) *types.Block {
+	var txs []*types.Transaction
+	for i, payload := range payloads {
+		tx := types.NewTransaction(uint64(i), common.Address{0x01}, common.Big0, 100000, common.Big0, common.BytesToEncryptedPayloadHash([]byte{payload}).Bytes())
+		tx.SetPrivate()
+		txs = append(txs, tx)
+	}
+	txs = append(txs, types.NewTransaction(uint64(len(payloads)), common.Address{0x01}, common.Big0, 21000, common.Big0, nil))
+	return types.NewBlock(&types.Header{Number: big.NewInt(number)}, txs, nil, nil, new(trie.Trie))
+}
+
+func newPrefetchTestChain(t *testing.T) *BlockChain {
+	db := rawdb.NewMemoryDatabase()
+	(&Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	return chain
+}
+
+func TestPrivatePrefetcher_Prefetch(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	ptm := &countingPrivateTransactionManager{received: make(map[common.EncryptedPayloadHash]int)}
+	private.P = ptm
+
+	chain := newPrefetchTestChain(t)
+	defer chain.Stop()
+	prefetcher := NewPrivatePrefetcher(chain, 2)
+	prefetcher.Start()
+	defer prefetcher.Stop()
+
+	block := newPrefetchBlock(1, 0x01, 0x02, 0x01)
+	chain.PrefetchPrivatePayloads(block, newPrefetchBlock(2))
+	chain.PrefetchPrivatePayloads(block)
+
+	first, second := common.BytesToEncryptedPayloadHash([]byte{0x01}), common.BytesToEncryptedPayloadHash([]byte{0x02})
+	require.Eventually(t, func() bool {
+		prefetcher.lock.Lock()
+		defer prefetcher.lock.Unlock()
+		pb := prefetcher.blocks[block.Hash()]
+		return pb.payloads[first] && pb.payloads[second]
+	}, time.Second, 10*time.Millisecond)
+
+	// payloads are fetched once per block, blocks without private transactions are not tracked
+	assert.Equal(t, 1, ptm.count(first))
+	assert.Equal(t, 1, ptm.count(second))
+	assert.Len(t, prefetcher.blocks, 1)
+
+	prefetcher.consume(block)
+	assert.Empty(t, prefetcher.blocks)
+}
+
+func TestPrivatePrefetcher_CancelOvertakenBlocks(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	ptm := &countingPrivateTransactionManager{gate: make(chan struct{}), received: make(map[common.EncryptedPayloadHash]int)}
+	private.P = ptm
+
+	chain := newPrefetchTestChain(t)
+	defer chain.Stop()
+	prefetcher := NewPrivatePrefetcher(chain, 1)
+	prefetcher.Start()
+	defer prefetcher.Stop()
+
+	// the single worker is held up by the first payload of the first block
+	lost, next := newPrefetchBlock(1, 0x01, 0x02), newPrefetchBlock(2, 0x03)
+	chain.PrefetchPrivatePayloads(lost, next)
+
+	// a competing block 1 becomes the head
+	chain.chainHeadFeed.Send(ChainHeadEvent{Block: newPrefetchBlock(1)})
+	require.Eventually(t, func() bool {
+		prefetcher.lock.Lock()
+		defer prefetcher.lock.Unlock()
+		_, ok := prefetcher.blocks[lost.Hash()]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	close(ptm.gate)
+
+	third := common.BytesToEncryptedPayloadHash([]byte{0x03})
+	require.Eventually(t, func() bool { return ptm.count(third) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, ptm.count(common.BytesToEncryptedPayloadHash([]byte{0x02})), "cancelled payload fetched")
+}
+
+func TestPrivatePrefetcher_Hinted(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	ptm := &countingPrivateTransactionManager{received: make(map[common.EncryptedPayloadHash]int)}
+	private.P = ptm
+
+	chain := newPrefetchTestChain(t)
+	defer chain.Stop()
+	prefetcher := NewPrivatePrefetcher(chain, 2)
+	prefetcher.Start()
+	defer prefetcher.Stop()
+
+	first, second := common.BytesToEncryptedPayloadHash([]byte{0x01}), common.BytesToEncryptedPayloadHash([]byte{0x02})
+	block := newPrefetchBlock(1, 0x01, 0x02)
+	chain.PrefetchHintedPrivatePayload(block.Hash(), 1, first)
+	// hints at the head or too far ahead of it are ignored
+	chain.PrefetchHintedPrivatePayload(common.Hash{0x01}, 0, second)
+	chain.PrefetchHintedPrivatePayload(common.Hash{0x02}, maxHintedBlocksAhead+1, second)
+	require.Eventually(t, func() bool { return ptm.count(first) == 1 }, time.Second, 10*time.Millisecond)
+
+	// the block received later only schedules the payloads not hinted
+	chain.PrefetchPrivatePayloads(block)
+	require.Eventually(t, func() bool { return ptm.count(second) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, ptm.count(first))
+	prefetcher.lock.Lock()
+	assert.Len(t, prefetcher.blocks, 1)
+	prefetcher.lock.Unlock()
+}
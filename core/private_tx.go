@@ -0,0 +1,35 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Quorum
+//
+// PrivatePayloadHashError is returned for private transactions whose data is
+// not an encrypted payload hash, which the transaction manager of no party
+// could resolve.
+type PrivatePayloadHashError struct {
+	Length int // length of the data of the transaction
+}
+
+func (e *PrivatePayloadHashError) Error() string {
+	return fmt.Sprintf("private transaction data is %d bytes, not a %d bytes encrypted payload hash", e.Length, common.EncryptedPayloadHashLength)
+}
+
+// Is makes the errors match ErrInvalidPrivatePayloadHash.
+func (e *PrivatePayloadHashError) Is(target error) bool {
+	return target == ErrInvalidPrivatePayloadHash
+}
+
+// ValidatePrivatePayloadHash returns a *PrivatePayloadHashError if the
+// transaction is private and its data not an encrypted payload hash.
+func ValidatePrivatePayloadHash(tx *types.Transaction) error {
+	if tx.IsPrivate() && len(tx.Data()) != common.EncryptedPayloadHashLength {
+		return &PrivatePayloadHashError{Length: len(tx.Data())}
+	}
+	return nil
+}
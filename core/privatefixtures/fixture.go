@@ -0,0 +1,183 @@
+// Package privatefixtures synthesizes deterministic chains with private
+// transaction workloads, for benchmarks and tests which need realistic chain
+// data without running a network and a private transaction manager.
+//
+// A fixture is a data directory holding:
+//
+//	geth/chaindata  the database of the chain, openable by a node
+//	genesis.json    the genesis of the chain
+//	ptm.json        the private payloads, served by the embedded LoopbackPTM
+//	chain.rlp       the blocks after the genesis, in the format of geth export
+//
+// The same configuration, seed included, always yields byte-identical
+// genesis.json, ptm.json and chain.rlp files.
+package privatefixtures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	EngineEthash = "ethash"
+	EngineClique = "clique"
+
+	genesisFile = "genesis.json"
+	ptmFile     = "ptm.json"
+	chainFile   = "chain.rlp"
+)
+
+// Config is the workload of a fixture.
+type Config struct {
+	Seed         int64   // seed of all the randomness of the workload
+	Blocks       int     // number of blocks after the genesis
+	TxsPerBlock  int     // number of transactions in each block
+	PrivateRatio float64 // share of the transactions which are private, in [0, 1]
+	PayloadSize  int     // size in bytes of the private payloads, at least 64
+	Contracts    int     // number of distinct private contracts
+	Recipients   int     // number of recipients of each private contract
+
+	// PrivacyFlags are assigned to the private contracts in turn, all of them
+	// being standard private if empty.
+	PrivacyFlags []engine.PrivacyFlagType
+
+	// Engine is the consensus engine sealing the blocks, EngineEthash (no
+	// proof-of-work) or EngineClique with a single signer.
+	Engine string
+}
+
+// DefaultConfig is a small mixed workload.
+var DefaultConfig = Config{
+	Seed:         1,
+	Blocks:       64,
+	TxsPerBlock:  16,
+	PrivateRatio: 0.5,
+	PayloadSize:  256,
+	Contracts:    8,
+	Recipients:   2,
+	Engine:       EngineEthash,
+}
+
+func (c *Config) validate() error {
+	switch {
+	case c.Blocks < 0 || c.TxsPerBlock < 0:
+		return errors.New("negative number of blocks or transactions")
+	case c.PrivateRatio < 0 || c.PrivateRatio > 1:
+		return fmt.Errorf("private ratio %v out of [0, 1]", c.PrivateRatio)
+	case c.PrivateRatio > 0 && c.Contracts < 1:
+		return errors.New("private transactions need at least one private contract")
+	case c.Recipients < 0:
+		return errors.New("negative number of recipients")
+	case c.PayloadSize < minPayloadSize:
+		return fmt.Errorf("private payloads of %d bytes, must be at least %d", c.PayloadSize, minPayloadSize)
+	}
+	for _, flag := range c.PrivacyFlags {
+		if err := flag.Validate(); err != nil {
+			return err
+		}
+	}
+	switch c.Engine {
+	case EngineEthash, EngineClique:
+	case "istanbul":
+		return errors.New("istanbul fixtures are not supported, use ethash or clique")
+	default:
+		return fmt.Errorf("unknown consensus engine %q", c.Engine)
+	}
+	return nil
+}
+
+// Fixture is a generated data directory.
+type Fixture struct {
+	Dir     string
+	Genesis *core.Genesis
+	PTM     *LoopbackPTM
+}
+
+// Open loads the fixture generated in dir.
+func Open(dir string) (*Fixture, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(dir, genesisFile))
+	if err != nil {
+		return nil, err
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(blob, genesis); err != nil {
+		return nil, fmt.Errorf("invalid fixture genesis: %v", err)
+	}
+	ptm, err := LoadLoopbackPTM(filepath.Join(dir, ptmFile))
+	if err != nil {
+		return nil, err
+	}
+	return &Fixture{Dir: dir, Genesis: genesis, PTM: ptm}, nil
+}
+
+// ChainDataDir returns the directory of the database of the chain, the one of
+// a node with the fixture as its data directory.
+func (f *Fixture) ChainDataDir() string {
+	return filepath.Join(f.Dir, "geth", "chaindata")
+}
+
+// OpenDatabase opens the database of the chain.
+func (f *Fixture) OpenDatabase() (ethdb.Database, error) {
+	return openDatabase(f.ChainDataDir())
+}
+
+func openDatabase(dir string) (ethdb.Database, error) {
+	return rawdb.NewLevelDBDatabaseWithFreezer(dir, 16, 16, filepath.Join(dir, "ancient"), "")
+}
+
+// Engine returns the consensus engine verifying the chain.
+func (f *Fixture) Engine(db ethdb.Database) consensus.Engine {
+	return newEngine(f.Genesis, db)
+}
+
+func newEngine(genesis *core.Genesis, db ethdb.Database) consensus.Engine {
+	if genesis.Config.Clique != nil {
+		return clique.New(genesis.Config.Clique, db)
+	}
+	return ethash.NewFaker()
+}
+
+// Blocks reads the blocks after the genesis from the chain export.
+func (f *Fixture) Blocks() (types.Blocks, error) {
+	fh, err := os.Open(filepath.Join(f.Dir, chainFile))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var blocks types.Blocks
+	stream := rlp.NewStream(fh, 0)
+	for {
+		block := new(types.Block)
+		if err := stream.Decode(block); err == io.EOF {
+			return blocks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid fixture block %d: %v", len(blocks)+1, err)
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+// InstallPTM makes the loopback private transaction manager of the fixture
+// the one of the process, returning the function restoring the previous one.
+func (f *Fixture) InstallPTM() func() {
+	saved := private.P
+	private.P = f.PTM
+	return func() { private.P = saved }
+}
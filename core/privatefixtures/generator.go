@@ -0,0 +1,412 @@
+package privatefixtures
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	senderCount         = 4
+	publicRecipientPool = 16
+	slotsPerContract    = 64
+	minPayloadSize      = 64 // the key and the value stored by a call
+
+	blockPeriod  = 10 // seconds between blocks
+	gasLimit     = 1000000000
+	creationGas  = 200000
+	callGas      = 100000
+	transferGas  = 21000
+	cliqueEpoch  = 30000
+	extraVanity  = 32
+	cliqueSealed = 65
+)
+
+var (
+	// creationCode deploys storageCode, the payload of a creation is this code
+	// padded with zeros, which are never executed
+	creationCode = common.FromHex("600880600b6000396000f3")
+	// storageCode stores the second word of the call data at the slot of the
+	// first one
+	storageCode = common.FromHex("6020356000355500")
+)
+
+// privateContract is a private contract created by the workload.
+type privateContract struct {
+	address    common.Address
+	flag       engine.PrivacyFlagType
+	recipients []string
+}
+
+// generator builds the blocks of a fixture on top of its chain.
+type generator struct {
+	cfg    Config
+	rand   *rand.Rand
+	config *params.ChainConfig
+
+	signer        *ecdsa.PrivateKey // clique signer and ethash coinbase
+	senders       []*ecdsa.PrivateKey
+	transferees   []common.Address
+	localParty    string // the key of the node in the private transaction manager
+	recipientPool []string
+
+	ptm       *LoopbackPTM
+	contracts []*privateContract
+
+	bc     *core.BlockChain
+	engine consensus.Engine
+}
+
+func newGenerator(cfg Config) (*generator, error) {
+	g := &generator{
+		cfg:  cfg,
+		rand: rand.New(rand.NewSource(cfg.Seed)),
+		ptm:  NewLoopbackPTM(),
+	}
+	var err error
+	if g.signer, err = g.key("signer", 0); err != nil {
+		return nil, err
+	}
+	for i := 0; i < senderCount; i++ {
+		key, err := g.key("sender", i)
+		if err != nil {
+			return nil, err
+		}
+		g.senders = append(g.senders, key)
+	}
+	for i := 0; i < publicRecipientPool; i++ {
+		g.transferees = append(g.transferees, common.BytesToAddress(g.derive("transferee", i)))
+	}
+	g.localParty = base64.StdEncoding.EncodeToString(g.derive("party", 0))
+	// twice as many parties as the recipients of a contract, so that the
+	// contracts have different parties
+	for i := 0; i < 2*cfg.Recipients; i++ {
+		g.recipientPool = append(g.recipientPool, base64.StdEncoding.EncodeToString(g.derive("party", i+1)))
+	}
+	return g, nil
+}
+
+// derive returns 32 bytes derived from the seed, the label and the index.
+func (g *generator) derive(label string, i int) []byte {
+	var seed, index [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(g.cfg.Seed))
+	binary.BigEndian.PutUint64(index[:], uint64(i))
+	return crypto.Keccak256(seed[:], []byte(label), index[:])
+}
+
+func (g *generator) key(label string, i int) (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(g.derive(label, i))
+}
+
+func (g *generator) genesis() *core.Genesis {
+	config := *params.QuorumTestChainConfig
+	genesis := &core.Genesis{
+		Config:     &config,
+		GasLimit:   gasLimit,
+		Difficulty: params.GenesisDifficulty,
+		Alloc:      make(core.GenesisAlloc),
+	}
+	if g.cfg.Engine == EngineClique {
+		config.Ethash = nil
+		config.Clique = &params.CliqueConfig{Period: blockPeriod, Epoch: cliqueEpoch}
+		genesis.Difficulty = big.NewInt(1)
+		genesis.ExtraData = make([]byte, extraVanity+common.AddressLength+cliqueSealed)
+		copy(genesis.ExtraData[extraVanity:], crypto.PubkeyToAddress(g.signer.PublicKey).Bytes())
+	}
+	for _, key := range g.senders {
+		genesis.Alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: new(big.Int).Lsh(big.NewInt(1), 100)}
+	}
+	g.config = &config
+	return genesis
+}
+
+// Generate synthesizes the chain of the workload into dir, which must not hold
+// a chain already.
+func Generate(dir string, cfg Config) (*Fixture, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	chainData := filepath.Join(dir, "geth", "chaindata")
+	if _, err := os.Stat(chainData); err == nil {
+		return nil, fmt.Errorf("%s already holds a chain", dir)
+	}
+	g, err := newGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	genesis := g.genesis()
+
+	db, err := openDatabase(chainData)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	genesisBlock, err := genesis.Commit(db)
+	if err != nil {
+		return nil, err
+	}
+	g.engine = newEngine(genesis, db)
+	// an archive chain, so that the state of every block can be queried
+	cacheConfig := &core.CacheConfig{TrieCleanLimit: 16, TrieDirtyLimit: 16, TrieDirtyDisabled: true, TrieTimeLimit: 5 * time.Minute}
+	if g.bc, err = core.NewBlockChain(db, cacheConfig, g.config, g.engine, vm.Config{}, nil, nil); err != nil {
+		return nil, err
+	}
+	defer g.bc.Stop()
+
+	saved := private.P
+	private.P = g.ptm
+	defer func() { private.P = saved }()
+
+	out, err := os.Create(filepath.Join(dir, chainFile))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+
+	parent := genesisBlock
+	for i := 0; i < cfg.Blocks; i++ {
+		block, err := g.block(parent)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", i+1, err)
+		}
+		if _, err := g.bc.InsertChain(types.Blocks{block}); err != nil {
+			return nil, fmt.Errorf("block %d not imported: %v", i+1, err)
+		}
+		if err := rlp.Encode(w, block); err != nil {
+			return nil, err
+		}
+		parent = block
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	blob, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, genesisFile), blob, 0644); err != nil {
+		return nil, err
+	}
+	if err := g.ptm.Save(filepath.Join(dir, ptmFile)); err != nil {
+		return nil, err
+	}
+	return &Fixture{Dir: dir, Genesis: genesis, PTM: g.ptm}, nil
+}
+
+// block applies the transactions of the block after parent, returning it sealed.
+func (g *generator) block(parent *types.Block) (*types.Block, error) {
+	publicState, privateState, err := g.bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + blockPeriod,
+	}
+	if g.cfg.Engine == EngineClique {
+		header.Difficulty = big.NewInt(2)
+		header.Extra = make([]byte, extraVanity+cliqueSealed)
+		if header.Number.Uint64()%cliqueEpoch == 0 {
+			header.Extra = make([]byte, extraVanity+common.AddressLength+cliqueSealed)
+			copy(header.Extra[extraVanity:], crypto.PubkeyToAddress(g.signer.PublicKey).Bytes())
+		}
+	} else {
+		header.Coinbase = crypto.PubkeyToAddress(g.signer.PublicKey)
+		if err := g.engine.Prepare(g.bc, header); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+	)
+	for i := 0; i < g.cfg.TxsPerBlock; i++ {
+		tx, contract, err := g.transaction(header, publicState, privateState)
+		if err != nil {
+			return nil, err
+		}
+		publicState.Prepare(tx.Hash(), common.Hash{}, i)
+		privateState.Prepare(tx.Hash(), common.Hash{}, i)
+		receipt, privateReceipt, err := core.ApplyTransaction(g.config, g.bc, &header.Coinbase, gp, publicState, privateState, header, tx, &header.GasUsed, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if privateReceipt != nil {
+			if privateReceipt.Status != types.ReceiptStatusSuccessful {
+				return nil, fmt.Errorf("private transaction %d failed", i)
+			}
+			if contract != nil {
+				contract.address = privateReceipt.ContractAddress
+				g.contracts = append(g.contracts, contract)
+			}
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+	}
+	block, err := g.engine.FinalizeAndAssemble(g.bc, header, publicState, txs, nil, receipts)
+	if err != nil {
+		return nil, err
+	}
+	if g.cfg.Engine == EngineClique {
+		sealed := block.Header()
+		sig, err := crypto.Sign(clique.SealHash(sealed).Bytes(), g.signer)
+		if err != nil {
+			return nil, err
+		}
+		copy(sealed.Extra[len(sealed.Extra)-cliqueSealed:], sig)
+		block = block.WithSeal(sealed)
+	}
+	return block, nil
+}
+
+// transaction returns the next transaction of the workload, along with the
+// contract it creates if it is a private contract creation.
+func (g *generator) transaction(header *types.Header, publicState, privateState *state.StateDB) (*types.Transaction, *privateContract, error) {
+	key := g.senders[g.rand.Intn(len(g.senders))]
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce := publicState.GetNonce(from)
+
+	if g.rand.Float64() >= g.cfg.PrivateRatio {
+		to := g.transferees[g.rand.Intn(len(g.transferees))]
+		tx := types.NewTransaction(nonce, to, common.Big1, transferGas, common.Big0, nil)
+		signed, err := types.SignTx(tx, types.NewEIP155Signer(g.config.ChainID), key)
+		return signed, nil, err
+	}
+
+	var (
+		created, called *privateContract
+		to              *common.Address
+		payload         = make([]byte, g.cfg.PayloadSize)
+		gas             uint64
+	)
+	if len(g.contracts) < g.cfg.Contracts {
+		created = &privateContract{
+			flag:       engine.PrivacyFlagStandardPrivate,
+			recipients: g.pickRecipients(),
+		}
+		if flags := g.cfg.PrivacyFlags; len(flags) > 0 {
+			created.flag = flags[len(g.contracts)%len(flags)]
+		}
+		copy(payload, creationCode)
+		copy(payload[len(creationCode):], storageCode)
+		gas = creationGas
+	} else {
+		called = g.contracts[g.rand.Intn(len(g.contracts))]
+		to = &called.address
+		binary.BigEndian.PutUint64(payload[24:32], uint64(g.rand.Intn(slotsPerContract)))
+		binary.BigEndian.PutUint64(payload[56:64], g.rand.Uint64()|1)
+		gas = callGas
+	}
+	contract := created
+	if contract == nil {
+		contract = called
+	}
+
+	extra := &engine.ExtraMetadata{PrivacyFlag: contract.flag}
+	if to != nil {
+		extra.ExecutionHints = []common.Address{*to}
+	}
+	if contract.flag.IsNotStandardPrivate() {
+		var err error
+		msg := types.NewMessage(from, to, nonce, common.Big0, gas, common.Big0, payload, false)
+		if extra.ACHashes, extra.ACMerkleRoot, err = g.simulate(msg, header, publicState, privateState, contract.flag); err != nil {
+			return nil, nil, err
+		}
+	}
+	hash := g.ptm.store(payload, g.localParty, contract.recipients, extra)
+
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(nonce, common.Big0, gas, common.Big0, hash.Bytes())
+	} else {
+		tx = types.NewTransaction(nonce, *to, common.Big0, gas, common.Big0, hash.Bytes())
+	}
+	signed, err := types.SignTx(tx, types.HomesteadSigner{}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	signed.SetPrivate()
+	return signed, created, nil
+}
+
+func (g *generator) pickRecipients() []string {
+	var recipients []string
+	for _, i := range g.rand.Perm(len(g.recipientPool))[:g.cfg.Recipients] {
+		recipients = append(recipients, g.recipientPool[i])
+	}
+	return recipients
+}
+
+// simulate executes the private payload against copies of the states, as the
+// node sending a privacy enhanced transaction does, returning the hashes of the
+// creation payloads of the affected contracts and their merkle root.
+func (g *generator) simulate(msg types.Message, header *types.Header, publicState, privateState *state.StateDB, flag engine.PrivacyFlagType) (common.EncryptedPayloadHashes, common.Hash, error) {
+	publicCopy, privateCopy := publicState.Copy(), privateState.Copy()
+	var target common.Address
+	if msg.To() != nil {
+		target = *msg.To()
+	}
+	stateDB := privateCopy
+	if !privateCopy.Exist(target) {
+		stateDB = publicCopy
+	}
+	evm := vm.NewEVM(core.NewEVMContext(msg, header, g.bc, &header.Coinbase), publicCopy, stateDB, g.config, vm.Config{})
+
+	var err error
+	if msg.To() != nil {
+		_, _, err = evm.Call(vm.AccountRef(msg.From()), target, msg.Data(), msg.Gas(), msg.Value())
+	} else {
+		_, _, _, err = evm.Create(vm.AccountRef(msg.From()), msg.Data(), msg.Gas(), msg.Value())
+	}
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("simulation failed: %v", err)
+	}
+	hashes := make(common.EncryptedPayloadHashes)
+	for _, addr := range evm.AffectedContracts() {
+		metadata, err := evm.StateDB.GetPrivacyMetadata(addr)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		if metadata == nil {
+			continue
+		}
+		if metadata.PrivacyFlag != flag {
+			return nil, common.Hash{}, errors.New("privacy flag of the transaction differs from the affected contracts")
+		}
+		hashes.Add(metadata.CreationTxHash)
+	}
+	if !flag.Has(engine.PrivacyFlagStateValidation) {
+		return hashes, common.Hash{}, nil
+	}
+	root, err := evm.CalculateMerkleRoot()
+	return hashes, root, err
+}
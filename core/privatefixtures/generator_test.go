@@ -0,0 +1,109 @@
+package privatefixtures
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(consensus string) Config {
+	cfg := DefaultConfig
+	cfg.Blocks = 8
+	cfg.TxsPerBlock = 6
+	cfg.Contracts = 4
+	cfg.PrivacyFlags = []engine.PrivacyFlagType{engine.PrivacyFlagStandardPrivate, engine.PrivacyFlagPartyProtection, engine.PrivacyFlagStateValidation}
+	cfg.Engine = consensus
+	return cfg
+}
+
+func generate(t *testing.T, cfg Config) *Fixture {
+	dir, err := ioutil.TempDir("", "privatefixtures-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	fixture, err := Generate(dir, cfg)
+	require.NoError(t, err)
+	return fixture
+}
+
+func readFile(t *testing.T, fixture *Fixture, name string) []byte {
+	blob, err := ioutil.ReadFile(filepath.Join(fixture.Dir, name))
+	require.NoError(t, err)
+	return blob
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	for _, consensus := range []string{EngineEthash, EngineClique} {
+		t.Run(consensus, func(t *testing.T) {
+			a, b := generate(t, testConfig(consensus)), generate(t, testConfig(consensus))
+			for _, name := range []string{genesisFile, ptmFile, chainFile} {
+				assert.Equal(t, readFile(t, a, name), readFile(t, b, name), name)
+			}
+
+			other := testConfig(consensus)
+			other.Seed++
+			assert.NotEqual(t, readFile(t, a, chainFile), readFile(t, generate(t, other), chainFile))
+		})
+	}
+}
+
+func TestGenerate_Import(t *testing.T) {
+	generated := generate(t, testConfig(EngineEthash))
+	fixture, err := Open(generated.Dir)
+	require.NoError(t, err)
+	blocks, err := fixture.Blocks()
+	require.NoError(t, err)
+	require.Len(t, blocks, 8)
+	assert.NotZero(t, fixture.PTM.Len())
+	defer fixture.InstallPTM()()
+
+	db := rawdb.NewMemoryDatabase()
+	fixture.Genesis.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, fixture.Genesis.Config, fixture.Engine(db), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+
+	// the private contracts exist once the chain is imported
+	_, privateState, err := chain.State()
+	require.NoError(t, err)
+	dataDB, err := fixture.OpenDatabase()
+	require.NoError(t, err)
+	defer dataDB.Close()
+	assert.Equal(t, rawdb.ReadHeadBlockHash(dataDB), chain.CurrentBlock().Hash())
+	contracts := 0
+	for _, block := range blocks {
+		signer := types.MakeSigner(fixture.Genesis.Config, block.Number())
+		for _, tx := range block.Transactions() {
+			if !tx.IsPrivate() || tx.To() != nil {
+				continue
+			}
+			from, err := types.Sender(signer, tx)
+			require.NoError(t, err)
+			assert.Equal(t, storageCode, privateState.GetCode(crypto.CreateAddress(from, tx.Nonce())))
+			contracts++
+		}
+	}
+	assert.Equal(t, 4, contracts)
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	cfg := testConfig("istanbul")
+	_, err := Generate("", cfg)
+	assert.Error(t, err)
+
+	cfg = testConfig(EngineEthash)
+	cfg.PayloadSize = 32
+	_, err = Generate("", cfg)
+	assert.Error(t, err)
+}
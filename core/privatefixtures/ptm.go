@@ -0,0 +1,172 @@
+package privatefixtures
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+)
+
+// LoopbackPTM is an in-process private transaction manager serving the
+// payloads of a fixture, the node being party to all of them. The hashes of
+// the payloads are derived from their content and the order they were stored
+// in, so that the same workload always yields the same transactions.
+type LoopbackPTM struct {
+	notinuse.PrivateTransactionManager
+
+	mu       sync.RWMutex
+	payloads map[common.EncryptedPayloadHash]*loopbackPayload
+}
+
+// loopbackPayload is a stored payload along with the metadata returned with it.
+type loopbackPayload struct {
+	Payload        hexutil.Bytes          `json:"payload"`
+	From           string                 `json:"from"`
+	Recipients     []string               `json:"recipients"`
+	PrivacyFlag    engine.PrivacyFlagType `json:"privacyFlag"`
+	ACHashes       []string               `json:"affectedContracts,omitempty"`
+	ACMerkleRoot   common.Hash            `json:"merkleRoot"`
+	ExecutionHints []common.Address       `json:"executionHints,omitempty"`
+}
+
+// NewLoopbackPTM creates an empty loopback private transaction manager.
+func NewLoopbackPTM() *LoopbackPTM {
+	return &LoopbackPTM{payloads: make(map[common.EncryptedPayloadHash]*loopbackPayload)}
+}
+
+// LoadLoopbackPTM reads the payloads saved to the file at path.
+func LoadLoopbackPTM(path string) (*LoopbackPTM, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var saved map[string]*loopbackPayload
+	if err := json.Unmarshal(blob, &saved); err != nil {
+		return nil, err
+	}
+	ptm := NewLoopbackPTM()
+	for b64, payload := range saved {
+		hash, err := common.Base64ToEncryptedPayloadHash(b64)
+		if err != nil {
+			return nil, err
+		}
+		ptm.payloads[hash] = payload
+	}
+	return ptm, nil
+}
+
+// Save writes the payloads to the file at path, in a stable order.
+func (ptm *LoopbackPTM) Save(path string) error {
+	ptm.mu.RLock()
+	saved := make(map[string]*loopbackPayload, len(ptm.payloads))
+	for hash, payload := range ptm.payloads {
+		saved[hash.ToBase64()] = payload
+	}
+	ptm.mu.RUnlock()
+
+	// maps are encoded with sorted keys
+	blob, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, blob, 0644)
+}
+
+// Len returns the number of stored payloads.
+func (ptm *LoopbackPTM) Len() int {
+	ptm.mu.RLock()
+	defer ptm.mu.RUnlock()
+	return len(ptm.payloads)
+}
+
+// store records the payload, returning its hash.
+func (ptm *LoopbackPTM) store(data []byte, from string, to []string, extra *engine.ExtraMetadata) common.EncryptedPayloadHash {
+	ptm.mu.Lock()
+	defer ptm.mu.Unlock()
+
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], uint64(len(ptm.payloads)))
+	hash := common.BytesToEncryptedPayloadHash(crypto.Keccak512(seq[:], data))
+
+	payload := &loopbackPayload{
+		Payload:        common.CopyBytes(data),
+		From:           from,
+		Recipients:     to,
+		PrivacyFlag:    extra.PrivacyFlag,
+		ACMerkleRoot:   extra.ACMerkleRoot,
+		ExecutionHints: extra.ExecutionHints,
+	}
+	if len(extra.ACHashes) > 0 {
+		payload.ACHashes = extra.ACHashes.ToBase64s()
+		sort.Strings(payload.ACHashes)
+	}
+	ptm.payloads[hash] = payload
+	return hash
+}
+
+func (ptm *LoopbackPTM) lookup(hash common.EncryptedPayloadHash) (*loopbackPayload, *engine.ExtraMetadata, error) {
+	ptm.mu.RLock()
+	payload, ok := ptm.payloads[hash]
+	ptm.mu.RUnlock()
+	if !ok {
+		return nil, nil, nil
+	}
+	acHashes, err := common.Base64sToEncryptedPayloadHashes(payload.ACHashes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, &engine.ExtraMetadata{
+		ACHashes:       acHashes,
+		ACMerkleRoot:   payload.ACMerkleRoot,
+		PrivacyFlag:    payload.PrivacyFlag,
+		ManagedParties: payload.Recipients,
+		Sender:         payload.From,
+		ExecutionHints: payload.ExecutionHints,
+	}, nil
+}
+
+func (ptm *LoopbackPTM) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	payload, extra, err := ptm.lookup(hash)
+	if payload == nil || err != nil {
+		// not party, as for the other private transaction managers
+		return "", nil, nil, nil, err
+	}
+	return payload.From, payload.Recipients, common.CopyBytes(payload.Payload), extra, nil
+}
+
+func (ptm *LoopbackPTM) ReceiveRaw(ctx context.Context, hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+	payload, extra, err := ptm.lookup(hash)
+	if payload == nil || err != nil {
+		return nil, "", nil, err
+	}
+	return common.CopyBytes(payload.Payload), payload.From, extra, nil
+}
+
+func (ptm *LoopbackPTM) IsSender(ctx context.Context, hash common.EncryptedPayloadHash) (bool, error) {
+	payload, _, err := ptm.lookup(hash)
+	return payload != nil, err
+}
+
+func (ptm *LoopbackPTM) GetParticipants(ctx context.Context, hash common.EncryptedPayloadHash) ([]string, error) {
+	payload, _, err := ptm.lookup(hash)
+	if payload == nil || err != nil {
+		return nil, err
+	}
+	return payload.Recipients, nil
+}
+
+func (ptm *LoopbackPTM) HasFeature(f engine.PrivateTransactionManagerFeature) bool {
+	return f == engine.PrivacyEnhancements
+}
+
+func (ptm *LoopbackPTM) Name() string {
+	return "Loopback"
+}
@@ -0,0 +1,272 @@
+// Package quorumgenesis builds validated genesis blocks for Quorum networks,
+// so that tooling standing up networks does not need to hand-craft the
+// consensus extra-data and the Quorum specific chain configuration.
+package quorumgenesis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type consensus int
+
+const (
+	consensusNone consensus = iota
+	consensusRaft
+	consensusIstanbul
+)
+
+// Builder assembles a Quorum genesis block. The zero value is not usable,
+// builders are created with New and finalised with Build or BuildJSON.
+type Builder struct {
+	config     *params.ChainConfig
+	consensus  consensus
+	validators []common.Address
+	vanity     []byte
+	alloc      core.GenesisAlloc
+	gasLimit   uint64
+	timestamp  uint64
+}
+
+// New returns a builder for a Quorum network with the given chain id. All
+// upstream forks up to and including Istanbul are active from the genesis
+// block and the transaction size limit defaults to the transaction pool one.
+func New(chainID *big.Int) *Builder {
+	return &Builder{
+		config: &params.ChainConfig{
+			ChainID:              chainID,
+			HomesteadBlock:       big.NewInt(0),
+			EIP150Block:          big.NewInt(0),
+			EIP155Block:          big.NewInt(0),
+			EIP158Block:          big.NewInt(0),
+			ByzantiumBlock:       big.NewInt(0),
+			ConstantinopleBlock:  big.NewInt(0),
+			PetersburgBlock:      big.NewInt(0),
+			IstanbulBlock:        big.NewInt(0),
+			IsQuorum:             true,
+			TransactionSizeLimit: core.DefaultTxPoolConfig.TransactionSizeLimit,
+		},
+		alloc:    make(core.GenesisAlloc),
+		gasLimit: params.GenesisGasLimit,
+	}
+}
+
+// Raft selects Raft consensus. Raft cluster membership is not part of the
+// genesis block, so no validators are recorded.
+func (b *Builder) Raft() *Builder {
+	b.consensus = consensusRaft
+	b.config.Istanbul = nil
+	b.validators = nil
+	return b
+}
+
+// Istanbul selects Istanbul BFT consensus with the given engine settings and
+// initial validator set, encoded into the genesis extra-data.
+func (b *Builder) Istanbul(config params.IstanbulConfig, validators ...common.Address) *Builder {
+	b.consensus = consensusIstanbul
+	b.config.Istanbul = &config
+	b.validators = append([]common.Address{}, validators...)
+	return b
+}
+
+// Vanity sets the vanity prefix of the Istanbul extra-data.
+func (b *Builder) Vanity(vanity []byte) *Builder {
+	b.vanity = common.CopyBytes(vanity)
+	return b
+}
+
+// Account funds an externally owned account with the given balance.
+func (b *Builder) Account(address common.Address, balance *big.Int) *Builder {
+	b.alloc[address] = core.GenesisAccount{Balance: balance}
+	return b
+}
+
+// Contract deploys a pre-initialised account, e.g. a permissions contract.
+func (b *Builder) Contract(address common.Address, account core.GenesisAccount) *Builder {
+	if account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	b.alloc[address] = account
+	return b
+}
+
+// Permissions activates the smart contract based permission model (QIP714)
+// at the given block.
+func (b *Builder) Permissions(block *big.Int) *Builder {
+	b.config.QIP714Block = block
+	return b
+}
+
+// PrivacyEnhancements activates party protection and private state
+// validation at the given block.
+func (b *Builder) PrivacyEnhancements(block *big.Int) *Builder {
+	b.config.PrivacyEnhancementsBlock = block
+	return b
+}
+
+// MaxCodeSize schedules a maximum contract code size, in kilobytes, from the
+// given block. Calls must be made in ascending block order.
+func (b *Builder) MaxCodeSize(block *big.Int, size uint64) *Builder {
+	b.config.MaxCodeSizeConfig = append(b.config.MaxCodeSizeConfig, params.MaxCodeConfigStruct{Block: block, Size: size})
+	return b
+}
+
+// TransactionSizeLimit sets the maximum transaction size in kilobytes.
+func (b *Builder) TransactionSizeLimit(size uint64) *Builder {
+	b.config.TransactionSizeLimit = size
+	return b
+}
+
+// GasLimit sets the gas limit of the genesis block.
+func (b *Builder) GasLimit(gasLimit uint64) *Builder {
+	b.gasLimit = gasLimit
+	return b
+}
+
+// Timestamp sets the timestamp of the genesis block.
+func (b *Builder) Timestamp(timestamp uint64) *Builder {
+	b.timestamp = timestamp
+	return b
+}
+
+// Build assembles and validates the genesis block.
+func (b *Builder) Build() (*core.Genesis, error) {
+	config := *b.config
+	config.MaxCodeSizeConfig = append([]params.MaxCodeConfigStruct{}, b.config.MaxCodeSizeConfig...)
+
+	genesis := &core.Genesis{
+		Config:     &config,
+		Timestamp:  b.timestamp,
+		GasLimit:   b.gasLimit,
+		Difficulty: new(big.Int),
+		Alloc:      make(core.GenesisAlloc, len(b.alloc)),
+	}
+	for address, account := range b.alloc {
+		genesis.Alloc[address] = account
+	}
+	switch b.consensus {
+	case consensusRaft:
+		genesis.ExtraData = make([]byte, types.IstanbulExtraVanity)
+	case consensusIstanbul:
+		extra, err := types.PrepareIstanbulExtra(b.vanity, b.validators)
+		if err != nil {
+			return nil, err
+		}
+		genesis.ExtraData = extra
+		genesis.Difficulty = big.NewInt(1)
+		genesis.Mixhash = types.IstanbulDigest
+	default:
+		return nil, errors.New("no consensus selected")
+	}
+	if err := Validate(genesis); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// BuildJSON assembles and validates the genesis block, returning it in the
+// genesis.json format accepted by geth init.
+func (b *Builder) BuildJSON() ([]byte, error) {
+	genesis, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(genesis, "", "  ")
+}
+
+// Validate checks that a genesis block, built or loaded from a file, is
+// accepted by a Quorum node. It applies the chain configuration checks run
+// by the node at start up along with consensus specific checks.
+func Validate(genesis *core.Genesis) error {
+	config := genesis.Config
+	if config == nil {
+		return errors.New("missing chain configuration")
+	}
+	if !config.IsQuorum {
+		return errors.New("isQuorum must be set")
+	}
+	if config.ChainID == nil || config.ChainID.Sign() <= 0 {
+		return errors.New("chain id must be positive")
+	}
+	if config.ChainID.Cmp(big.NewInt(1)) == 0 {
+		return errors.New("chain id 1 is reserved for the Ethereum main network")
+	}
+	if err := config.IsValid(); err != nil {
+		return err
+	}
+	if len(config.MaxCodeSizeConfig) > 0 {
+		if err := config.CheckMaxCodeConfigData(); err != nil {
+			return err
+		}
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	if genesis.GasLimit < params.MinGasLimit {
+		return fmt.Errorf("gas limit %d below minimum %d", genesis.GasLimit, params.MinGasLimit)
+	}
+	if config.Ethash != nil || config.Clique != nil {
+		return errors.New("only Raft and Istanbul consensus are supported")
+	}
+	if config.Istanbul != nil {
+		return validateIstanbul(genesis)
+	}
+	return nil
+}
+
+func validateIstanbul(genesis *core.Genesis) error {
+	if genesis.Mixhash != types.IstanbulDigest {
+		return fmt.Errorf("istanbul genesis mixHash must be %s", types.IstanbulDigest.Hex())
+	}
+	if genesis.Difficulty == nil || genesis.Difficulty.Cmp(big.NewInt(1)) != 0 {
+		return errors.New("istanbul genesis difficulty must be 1")
+	}
+	extra, err := types.ExtractIstanbulExtra(&types.Header{Extra: genesis.ExtraData})
+	if err != nil {
+		return fmt.Errorf("invalid istanbul extra-data: %v", err)
+	}
+	if len(extra.Validators) == 0 {
+		return errors.New("istanbul genesis has no validators")
+	}
+	seen := make(map[common.Address]bool, len(extra.Validators))
+	for _, validator := range extra.Validators {
+		if seen[validator] {
+			return fmt.Errorf("duplicate istanbul validator %s", validator.Hex())
+		}
+		seen[validator] = true
+	}
+	return nil
+}
+
+// ContractFromDump converts a contract dump, as written by
+// debug_dumpPrivateContract, into a genesis account after checking it against
+// the storage root recorded in it. The contract becomes part of the genesis
+// state of the new network, which every node of that network holds.
+func ContractFromDump(r io.Reader) (common.Address, core.GenesisAccount, error) {
+	reader, err := state.NewContractDumpReader(r)
+	if err != nil {
+		return common.Address{}, core.GenesisAccount{}, err
+	}
+	header := reader.Header()
+	storage := make(map[common.Hash]common.Hash)
+	if err := reader.Verify(header.StorageRoot, func(slot *state.ContractDumpSlot) {
+		storage[slot.Key] = slot.Value
+	}); err != nil {
+		return common.Address{}, core.GenesisAccount{}, err
+	}
+	return header.Address, core.GenesisAccount{
+		Code:    header.Code,
+		Storage: storage,
+		Balance: new(big.Int),
+		Nonce:   header.Nonce,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package quorumgenesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	validator1 = common.HexToAddress("0x44add0ec310f115a0e603b2d7db9f067778eaf8a")
+	validator2 = common.HexToAddress("0x294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212")
+	account    = common.HexToAddress("0xed9d02e382b34818e88b88a309c7fe71e65f419d")
+)
+
+func roundTrip(t *testing.T, b *Builder) (*core.Genesis, *core.Genesis) {
+	built, err := b.Build()
+	require.NoError(t, err)
+	blob, err := b.BuildJSON()
+	require.NoError(t, err)
+
+	loaded := new(core.Genesis)
+	require.NoError(t, json.Unmarshal(blob, loaded))
+	require.NoError(t, Validate(loaded))
+	assert.Equal(t, built.ToBlock(nil).Hash(), loaded.ToBlock(nil).Hash())
+	return built, loaded
+}
+
+func TestBuilder_Istanbul(t *testing.T) {
+	built, loaded := roundTrip(t, New(big.NewInt(10)).
+		Istanbul(params.IstanbulConfig{Epoch: 30000, ProposerPolicy: 0}, validator1, validator2).
+		Account(account, big.NewInt(1000000)).
+		Permissions(big.NewInt(0)).
+		PrivacyEnhancements(big.NewInt(5)).
+		MaxCodeSize(big.NewInt(0), 32).
+		MaxCodeSize(big.NewInt(100), 64))
+
+	extra, err := types.ExtractIstanbulExtra(&types.Header{Extra: loaded.ExtraData})
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{validator1, validator2}, extra.Validators)
+	assert.Equal(t, types.IstanbulDigest, loaded.Mixhash)
+	assert.Equal(t, uint64(30000), loaded.Config.Istanbul.Epoch)
+	assert.Equal(t, big.NewInt(5), loaded.Config.PrivacyEnhancementsBlock)
+	assert.Equal(t, 64*1024, loaded.Config.GetMaxCodeSize(big.NewInt(100)))
+	assert.Equal(t, big.NewInt(1000000), built.Alloc[account].Balance)
+
+	// the genesis must be accepted by a node
+	_, _, err = core.SetupGenesisBlock(rawdb.NewMemoryDatabase(), loaded)
+	assert.NoError(t, err)
+}
+
+func TestBuilder_Raft(t *testing.T) {
+	_, loaded := roundTrip(t, New(big.NewInt(10)).Raft().Account(account, big.NewInt(1)))
+
+	assert.Nil(t, loaded.Config.Istanbul)
+	assert.True(t, loaded.Config.IsQuorum)
+	assert.Equal(t, uint64(64), loaded.Config.TransactionSizeLimit)
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	tests := map[string]*Builder{
+		"no consensus":          New(big.NewInt(10)),
+		"no validators":         New(big.NewInt(10)).Istanbul(params.IstanbulConfig{}),
+		"duplicate validators":  New(big.NewInt(10)).Istanbul(params.IstanbulConfig{}, validator1, validator1),
+		"mainnet chain id":      New(big.NewInt(1)).Raft(),
+		"missing chain id":      New(nil).Raft(),
+		"transaction size":      New(big.NewInt(10)).Raft().TransactionSizeLimit(256),
+		"code size":             New(big.NewInt(10)).Raft().MaxCodeSize(big.NewInt(0), 16),
+		"code size block order": New(big.NewInt(10)).Raft().MaxCodeSize(big.NewInt(10), 32).MaxCodeSize(big.NewInt(5), 48),
+		"gas limit":             New(big.NewInt(10)).Raft().GasLimit(params.MinGasLimit - 1),
+	}
+	for name, b := range tests {
+		_, err := b.Build()
+		assert.Error(t, err, name)
+	}
+}
+
+func TestContractFromDump(t *testing.T) {
+	contract := common.HexToAddress("0x1932c48b2bf8102ba33b4a6b545c32236e342f34")
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	source, _ := state.New(common.Hash{}, db, nil)
+	source.SetCode(contract, []byte{0x60, 0x00})
+	source.SetState(contract, common.Hash{0x01}, common.Hash{0x02})
+	source.SetState(contract, common.Hash{0x03}, common.BytesToHash([]byte{0x04}))
+	root, err := source.Commit(false)
+	require.NoError(t, err)
+	source, _ = state.New(root, db, nil)
+	var dump bytes.Buffer
+	require.NoError(t, source.DumpContract(contract, 1, common.Hash{}, state.ContractDumpRLP, &dump))
+
+	address, alloc, err := ContractFromDump(&dump)
+	require.NoError(t, err)
+	assert.Equal(t, contract, address)
+
+	genesis, err := New(big.NewInt(10)).Raft().Contract(address, alloc).Build()
+	require.NoError(t, err)
+	chainDb := rawdb.NewMemoryDatabase()
+	block := genesis.MustCommit(chainDb)
+	imported, err := state.New(block.Root(), state.NewDatabase(chainDb), nil)
+	require.NoError(t, err)
+	assert.Equal(t, source.StorageTrie(contract).Hash(), imported.StorageTrie(contract).Hash())
+	assert.Equal(t, source.GetCode(contract), imported.GetCode(contract))
+}
@@ -273,8 +273,10 @@ func ReadHeaderRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValu
 	// First try to look up the data in ancient database. Extra hash
 	// comparison is necessary since ancient database only maintains
 	// the canonical data.
+	// Quorum: the hash of the Istanbul headers is not the hash of their RLP,
+	// which does not cover the committed seals, the frozen hash is compared
 	data, _ := db.Ancient(freezerHeaderTable, number)
-	if len(data) > 0 && crypto.Keccak256Hash(data) == hash {
+	if len(data) > 0 && isAncientHash(db, hash, number, data) {
 		return data
 	}
 	// Then try to look up the data in leveldb.
@@ -287,12 +289,22 @@ func ReadHeaderRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValu
 	// but when we reach into leveldb, the data was already moved. That would
 	// result in a not found error.
 	data, _ = db.Ancient(freezerHeaderTable, number)
-	if len(data) > 0 && crypto.Keccak256Hash(data) == hash {
+	if len(data) > 0 && isAncientHash(db, hash, number, data) {
 		return data
 	}
 	return nil // Can't find the data anywhere.
 }
 
+// Quorum
+// isAncientHash reports whether hash is the one of the header frozen.
+func isAncientHash(db ethdb.AncientReader, hash common.Hash, number uint64, header rlp.RawValue) bool {
+	if crypto.Keccak256Hash(header) == hash {
+		return true
+	}
+	h, _ := db.Ancient(freezerHashTable, number)
+	return common.BytesToHash(h) == hash
+}
+
 // HasHeader verifies the existence of a block header corresponding to the hash.
 func HasHeader(db ethdb.Reader, hash common.Hash, number uint64) bool {
 	if has, err := db.Ancient(freezerHashTable, number); err == nil && common.BytesToHash(has) == hash {
@@ -223,6 +223,17 @@ func NewLevelDBDatabase(file string, cache int, handles int, namespace string) (
 	return NewDatabase(db), nil
 }
 
+// NewReadOnlyLevelDBDatabase opens a LevelDB database maintained by another
+// process without write access and without a freezer. The returned function
+// re-opens the store so that reads observe data replicated in the meantime.
+func NewReadOnlyLevelDBDatabase(file string, cache int, handles int) (ethdb.Database, func() error, error) {
+	db, err := leveldb.NewReadOnly(file, cache, handles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewDatabase(db), db.Refresh, nil
+}
+
 // NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
 // freezer moving immutable chain segments into cold storage.
 func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (ethdb.Database, error) {
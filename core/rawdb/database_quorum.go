@@ -21,15 +21,34 @@
 package rawdb
 
 import (
+	"encoding/binary"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 var (
 	privateRootPrefix           = []byte("P")
 	privateBloomPrefix          = []byte("Pb")
 	quorumEIP155ActivatedPrefix = []byte("quorum155active")
+	internalCallsPrefix         = []byte("Pic")  // internalCallsPrefix + tx hash -> internal calls
+	internalCallsIndexedPrefix  = []byte("Picb") // internalCallsIndexedPrefix + block hash -> indexed flag
+	internalCallsBackfillKey    = []byte("InternalCallsBackfill")
+	blockStatsPrefix            = []byte("Pbs") // blockStatsPrefix + num (uint64 big endian) -> block statistics
+	dailyStatsPrefix            = []byte("Pds") // dailyStatsPrefix + day (uint64 big endian) -> daily statistics
+	dailyAccountPrefix          = []byte("Pda") // dailyAccountPrefix + day (uint64 big endian) + address -> active account flag
+	blockStatsTipKey            = []byte("BlockStatsTip")
+	blockStatsBackfillKey       = []byte("BlockStatsBackfill")
+	addressTxPrefix             = []byte("Pat") // addressTxPrefix + address + direction + ^num (uint64 big endian) + ^tx index (uint32 big endian) -> block hash + tx hash
+	addressTxBlockPrefix        = []byte("Pab") // addressTxBlockPrefix + num (uint64 big endian) -> hash of the indexed block
+	addressTxTipKey             = []byte("AddressTxTip")
+	sealAttestationPrefix       = []byte("Psa") // sealAttestationPrefix + num (uint64 big endian) + hash -> hash of the committed seals pruned
+	sealsPrunedKey              = []byte("SealsPruned")
+	emergencyReadOnlyKey        = []byte("EmergencyReadOnly")
+	configMigrationKey          = []byte("ConfigMigration")
 	// Quorum
 	// we introduce a generic approach to store extra data for an account. PrivacyMetadata is wrapped.
 	// However, this value is kept as-is to support backward compatibility
@@ -86,6 +105,378 @@ func GetPrivateBlockBloom(db ethdb.Database, number uint64) (bloom types.Bloom)
 	return bloom
 }
 
+// WriteInternalCalls stores the internal calls made by a transaction.
+func WriteInternalCalls(db ethdb.KeyValueWriter, txHash common.Hash, calls []*types.InternalCall) {
+	data, err := rlp.EncodeToBytes(calls)
+	if err != nil {
+		log.Crit("Failed to encode internal calls", "err", err)
+	}
+	if err := db.Put(append(internalCallsPrefix, txHash[:]...), data); err != nil {
+		log.Crit("Failed to store internal calls", "err", err)
+	}
+}
+
+// ReadInternalCalls retrieves the internal calls made by a transaction, and
+// whether they were indexed.
+func ReadInternalCalls(db ethdb.KeyValueReader, txHash common.Hash) ([]*types.InternalCall, bool) {
+	data, _ := db.Get(append(internalCallsPrefix, txHash[:]...))
+	if len(data) == 0 {
+		return nil, false
+	}
+	var calls []*types.InternalCall
+	if err := rlp.DecodeBytes(data, &calls); err != nil {
+		log.Error("Invalid internal calls RLP", "hash", txHash, "err", err)
+		return nil, false
+	}
+	return calls, true
+}
+
+// WriteInternalCallsIndexed marks the internal calls of all the transactions
+// of a block as indexed.
+func WriteInternalCallsIndexed(db ethdb.KeyValueWriter, blockHash common.Hash) {
+	if err := db.Put(append(internalCallsIndexedPrefix, blockHash[:]...), []byte{1}); err != nil {
+		log.Crit("Failed to store internal calls indexed flag", "err", err)
+	}
+}
+
+// HasInternalCallsIndexed reports whether the internal calls of a block were indexed.
+func HasInternalCallsIndexed(db ethdb.KeyValueReader, blockHash common.Hash) bool {
+	ok, _ := db.Has(append(internalCallsIndexedPrefix, blockHash[:]...))
+	return ok
+}
+
+// InternalCallsBackfill is the progress of a backfill of the internal call index.
+type InternalCallsBackfill struct {
+	Next uint64 // next block to index
+	Last uint64 // last block to index
+}
+
+// ReadInternalCallsBackfill retrieves the progress of an unfinished backfill.
+func ReadInternalCallsBackfill(db ethdb.KeyValueReader) *InternalCallsBackfill {
+	data, _ := db.Get(internalCallsBackfillKey)
+	if len(data) == 0 {
+		return nil
+	}
+	progress := new(InternalCallsBackfill)
+	if err := rlp.DecodeBytes(data, progress); err != nil {
+		log.Error("Invalid internal calls backfill RLP", "err", err)
+		return nil
+	}
+	return progress
+}
+
+// WriteInternalCallsBackfill stores the progress of a backfill.
+func WriteInternalCallsBackfill(db ethdb.KeyValueWriter, progress *InternalCallsBackfill) {
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode internal calls backfill", "err", err)
+	}
+	if err := db.Put(internalCallsBackfillKey, data); err != nil {
+		log.Crit("Failed to store internal calls backfill", "err", err)
+	}
+}
+
+// DeleteInternalCallsBackfill removes the progress of a finished backfill.
+func DeleteInternalCallsBackfill(db ethdb.KeyValueWriter) {
+	if err := db.Delete(internalCallsBackfillKey); err != nil {
+		log.Crit("Failed to delete internal calls backfill", "err", err)
+	}
+}
+
+// ReadBlockStats retrieves the statistics of the canonical block with the
+// given number, nil if it was not indexed.
+func ReadBlockStats(db ethdb.KeyValueReader, number uint64) *types.BlockStats {
+	data, _ := db.Get(append(blockStatsPrefix, encodeBlockNumber(number)...))
+	if len(data) == 0 {
+		return nil
+	}
+	stats := new(types.BlockStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		log.Error("Invalid block statistics RLP", "number", number, "err", err)
+		return nil
+	}
+	return stats
+}
+
+// WriteBlockStats stores the statistics of a canonical block.
+func WriteBlockStats(db ethdb.KeyValueWriter, stats *types.BlockStats) {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		log.Crit("Failed to encode block statistics", "err", err)
+	}
+	if err := db.Put(append(blockStatsPrefix, encodeBlockNumber(stats.Number)...), data); err != nil {
+		log.Crit("Failed to store block statistics", "err", err)
+	}
+}
+
+// DeleteBlockStats removes the statistics of a block no longer canonical.
+func DeleteBlockStats(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(append(blockStatsPrefix, encodeBlockNumber(number)...)); err != nil {
+		log.Crit("Failed to delete block statistics", "err", err)
+	}
+}
+
+// ReadDailyStats retrieves the statistics of the given day, counted from the
+// unix epoch, nil if no block of the day was indexed.
+func ReadDailyStats(db ethdb.KeyValueReader, day uint64) *types.DailyStats {
+	data, _ := db.Get(append(dailyStatsPrefix, encodeBlockNumber(day)...))
+	if len(data) == 0 {
+		return nil
+	}
+	stats := new(types.DailyStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		log.Error("Invalid daily statistics RLP", "day", day, "err", err)
+		return nil
+	}
+	return stats
+}
+
+// WriteDailyStats stores the statistics of a day.
+func WriteDailyStats(db ethdb.KeyValueWriter, stats *types.DailyStats) {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		log.Crit("Failed to encode daily statistics", "err", err)
+	}
+	if err := db.Put(append(dailyStatsPrefix, encodeBlockNumber(stats.Day)...), data); err != nil {
+		log.Crit("Failed to store daily statistics", "err", err)
+	}
+}
+
+// DeleteDailyStats removes the statistics of a day without indexed blocks.
+func DeleteDailyStats(db ethdb.KeyValueWriter, day uint64) {
+	if err := db.Delete(append(dailyStatsPrefix, encodeBlockNumber(day)...)); err != nil {
+		log.Crit("Failed to delete daily statistics", "err", err)
+	}
+}
+
+func dailyAccountKey(day uint64, account common.Address) []byte {
+	return append(append(dailyAccountPrefix, encodeBlockNumber(day)...), account.Bytes()...)
+}
+
+// HasDailyActiveAccount reports whether the account sent a transaction during
+// the day.
+func HasDailyActiveAccount(db ethdb.KeyValueReader, day uint64, account common.Address) bool {
+	ok, _ := db.Has(dailyAccountKey(day, account))
+	return ok
+}
+
+// WriteDailyActiveAccount marks the account as active during the day.
+func WriteDailyActiveAccount(db ethdb.KeyValueWriter, day uint64, account common.Address) {
+	if err := db.Put(dailyAccountKey(day, account), []byte{1}); err != nil {
+		log.Crit("Failed to store daily active account", "err", err)
+	}
+}
+
+// DeleteDailyActiveAccounts removes the active account marks of a day.
+func DeleteDailyActiveAccounts(db ethdb.KeyValueStore, day uint64) {
+	prefix := append(dailyAccountPrefix, encodeBlockNumber(day)...)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		// skip the private state roots sharing the prefix
+		if len(it.Key()) == len(prefix)+common.AddressLength {
+			batch.Delete(it.Key())
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete daily active accounts", "err", err)
+	}
+}
+
+// BlockStatsTip is the last canonical head whose statistics were indexed.
+type BlockStatsTip struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// ReadBlockStatsTip retrieves the last head indexed, nil if the index was
+// never started.
+func ReadBlockStatsTip(db ethdb.KeyValueReader) *BlockStatsTip {
+	data, _ := db.Get(blockStatsTipKey)
+	if len(data) == 0 {
+		return nil
+	}
+	tip := new(BlockStatsTip)
+	if err := rlp.DecodeBytes(data, tip); err != nil {
+		log.Error("Invalid block statistics tip RLP", "err", err)
+		return nil
+	}
+	return tip
+}
+
+// WriteBlockStatsTip stores the last head indexed.
+func WriteBlockStatsTip(db ethdb.KeyValueWriter, tip *BlockStatsTip) {
+	data, err := rlp.EncodeToBytes(tip)
+	if err != nil {
+		log.Crit("Failed to encode block statistics tip", "err", err)
+	}
+	if err := db.Put(blockStatsTipKey, data); err != nil {
+		log.Crit("Failed to store block statistics tip", "err", err)
+	}
+}
+
+// BlockStatsBackfill is the progress of a backfill of the block statistics.
+type BlockStatsBackfill struct {
+	Next uint64 // next block to index
+	Last uint64 // last block to index
+}
+
+// ReadBlockStatsBackfill retrieves the progress of an unfinished backfill.
+func ReadBlockStatsBackfill(db ethdb.KeyValueReader) *BlockStatsBackfill {
+	data, _ := db.Get(blockStatsBackfillKey)
+	if len(data) == 0 {
+		return nil
+	}
+	progress := new(BlockStatsBackfill)
+	if err := rlp.DecodeBytes(data, progress); err != nil {
+		log.Error("Invalid block statistics backfill RLP", "err", err)
+		return nil
+	}
+	return progress
+}
+
+// WriteBlockStatsBackfill stores the progress of a backfill.
+func WriteBlockStatsBackfill(db ethdb.KeyValueWriter, progress *BlockStatsBackfill) {
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode block statistics backfill", "err", err)
+	}
+	if err := db.Put(blockStatsBackfillKey, data); err != nil {
+		log.Crit("Failed to store block statistics backfill", "err", err)
+	}
+}
+
+// DeleteBlockStatsBackfill removes the progress of a finished backfill.
+func DeleteBlockStatsBackfill(db ethdb.KeyValueWriter) {
+	if err := db.Delete(blockStatsBackfillKey); err != nil {
+		log.Crit("Failed to delete block statistics backfill", "err", err)
+	}
+}
+
+// AddressTx is a canonical transaction sent or received by an account.
+type AddressTx struct {
+	Number    uint64
+	Index     uint32
+	BlockHash common.Hash
+	TxHash    common.Hash
+}
+
+func addressTxAccountPrefix(account common.Address, received bool) []byte {
+	direction := byte(0)
+	if received {
+		direction = 1
+	}
+	return append(append(append([]byte{}, addressTxPrefix...), account.Bytes()...), direction)
+}
+
+// addressTxPosition encodes the position of a transaction so that the most
+// recent transactions of an account come first.
+func addressTxPosition(number uint64, index uint32) []byte {
+	enc := make([]byte, 12)
+	binary.BigEndian.PutUint64(enc, ^number)
+	binary.BigEndian.PutUint32(enc[8:], ^index)
+	return enc
+}
+
+// WriteAddressTx indexes a transaction sent or received by the account.
+func WriteAddressTx(db ethdb.KeyValueWriter, account common.Address, received bool, tx *AddressTx) {
+	key := append(addressTxAccountPrefix(account, received), addressTxPosition(tx.Number, tx.Index)...)
+	if err := db.Put(key, append(tx.BlockHash.Bytes(), tx.TxHash.Bytes()...)); err != nil {
+		log.Crit("Failed to store address transaction", "err", err)
+	}
+}
+
+// DeleteAddressTx removes a transaction no longer canonical from the index of
+// the account.
+func DeleteAddressTx(db ethdb.KeyValueWriter, account common.Address, received bool, number uint64, index uint32) {
+	key := append(addressTxAccountPrefix(account, received), addressTxPosition(number, index)...)
+	if err := db.Delete(key); err != nil {
+		log.Crit("Failed to delete address transaction", "err", err)
+	}
+}
+
+// IterateAddressTxs calls fn with the indexed transactions sent or received by
+// the account, the most recent first, starting at the given position, until
+// it returns false.
+func IterateAddressTxs(db ethdb.Iteratee, account common.Address, received bool, number uint64, index uint32, fn func(*AddressTx) bool) {
+	prefix := addressTxAccountPrefix(account, received)
+	it := db.NewIterator(prefix, addressTxPosition(number, index))
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		// skip the private state roots sharing the prefix
+		if len(key) != len(prefix)+12 || len(value) != 2*common.HashLength {
+			continue
+		}
+		tx := &AddressTx{
+			Number:    ^binary.BigEndian.Uint64(key[len(prefix):]),
+			Index:     ^binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			BlockHash: common.BytesToHash(value[:common.HashLength]),
+			TxHash:    common.BytesToHash(value[common.HashLength:]),
+		}
+		if !fn(tx) {
+			return
+		}
+	}
+}
+
+// ReadAddressTxBlock retrieves the hash of the block indexed at the given
+// number, the zero hash if none was.
+func ReadAddressTxBlock(db ethdb.KeyValueReader, number uint64) common.Hash {
+	data, _ := db.Get(append(addressTxBlockPrefix, encodeBlockNumber(number)...))
+	return common.BytesToHash(data)
+}
+
+// WriteAddressTxBlock marks the transactions of the block as indexed.
+func WriteAddressTxBlock(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Put(append(addressTxBlockPrefix, encodeBlockNumber(number)...), hash.Bytes()); err != nil {
+		log.Crit("Failed to store address transactions block", "err", err)
+	}
+}
+
+// DeleteAddressTxBlock removes the mark of a block no longer canonical.
+func DeleteAddressTxBlock(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(append(addressTxBlockPrefix, encodeBlockNumber(number)...)); err != nil {
+		log.Crit("Failed to delete address transactions block", "err", err)
+	}
+}
+
+// AddressTxTip is the last canonical block whose transactions were indexed by
+// account.
+type AddressTxTip struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// ReadAddressTxTip retrieves the last block indexed, nil if the index was
+// never started.
+func ReadAddressTxTip(db ethdb.KeyValueReader) *AddressTxTip {
+	data, _ := db.Get(addressTxTipKey)
+	if len(data) == 0 {
+		return nil
+	}
+	tip := new(AddressTxTip)
+	if err := rlp.DecodeBytes(data, tip); err != nil {
+		log.Error("Invalid address transactions tip RLP", "err", err)
+		return nil
+	}
+	return tip
+}
+
+// WriteAddressTxTip stores the last block indexed.
+func WriteAddressTxTip(db ethdb.KeyValueWriter, tip *AddressTxTip) {
+	data, err := rlp.EncodeToBytes(tip)
+	if err != nil {
+		log.Crit("Failed to encode address transactions tip", "err", err)
+	}
+	if err := db.Put(addressTxTipKey, data); err != nil {
+		log.Crit("Failed to store address transactions tip", "err", err)
+	}
+}
+
 // AccountExtraDataLinker maintains mapping between root hash of the state trie
 // and root hash of state.AccountExtraData trie
 type AccountExtraDataLinker interface {
@@ -122,3 +513,58 @@ func (pml *ethdbAccountExtraDataLinker) Link(stateRoot, extraDataRoot common.Has
 	}
 	return nil
 }
+
+// EmergencyReadOnly is the emergency read-only mode set by the operator.
+type EmergencyReadOnly struct {
+	Reason string
+	SetBy  string
+	Since  uint64 // unix time
+}
+
+// ReadEmergencyReadOnly retrieves the emergency read-only mode, nil if the node
+// is not in it.
+func ReadEmergencyReadOnly(db ethdb.KeyValueReader) *EmergencyReadOnly {
+	data, _ := db.Get(emergencyReadOnlyKey)
+	if len(data) == 0 {
+		return nil
+	}
+	mode := new(EmergencyReadOnly)
+	if err := rlp.DecodeBytes(data, mode); err != nil {
+		log.Error("Invalid emergency read-only mode RLP", "err", err)
+		return nil
+	}
+	return mode
+}
+
+// WriteEmergencyReadOnly stores the emergency read-only mode.
+func WriteEmergencyReadOnly(db ethdb.KeyValueWriter, mode *EmergencyReadOnly) {
+	data, err := rlp.EncodeToBytes(mode)
+	if err != nil {
+		log.Crit("Failed to encode emergency read-only mode", "err", err)
+	}
+	if err := db.Put(emergencyReadOnlyKey, data); err != nil {
+		log.Crit("Failed to store emergency read-only mode", "err", err)
+	}
+}
+
+// DeleteEmergencyReadOnly clears the emergency read-only mode.
+func DeleteEmergencyReadOnly(db ethdb.KeyValueWriter) {
+	if err := db.Delete(emergencyReadOnlyKey); err != nil {
+		log.Crit("Failed to delete emergency read-only mode", "err", err)
+	}
+}
+
+// ReadConfigMigration retrieves the JSON report of the last migration of the
+// chain config, nil if none ran.
+func ReadConfigMigration(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(configMigrationKey)
+	return data
+}
+
+// WriteConfigMigration stores the JSON report of a migration of the chain
+// config.
+func WriteConfigMigration(db ethdb.KeyValueWriter, report []byte) {
+	if err := db.Put(configMigrationKey, report); err != nil {
+		log.Crit("Failed to store config migration report", "err", err)
+	}
+}
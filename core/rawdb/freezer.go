@@ -302,6 +302,9 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 		}
 		number := ReadHeaderNumber(nfdb, hash)
 		threshold := int(atomic.LoadUint64(&f.threshold))
+		// Quorum: the headers are only frozen once older than the depth of the seal pruning
+		pruning := currentSealPruning()
+		delay := pruning.delay(uint64(params.GetImmutabilityThresholdWithDefault(threshold)))
 
 		switch {
 		case number == nil:
@@ -309,12 +312,12 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 			backoff = true
 			continue
 
-		case *number < uint64(params.GetImmutabilityThresholdWithDefault(threshold)):
-			log.Debug("Current full block not old enough", "number", *number, "hash", hash, "delay", params.GetImmutabilityThresholdWithDefault(threshold))
+		case *number < delay:
+			log.Debug("Current full block not old enough", "number", *number, "hash", hash, "delay", delay)
 			backoff = true
 			continue
 
-		case *number-uint64(params.GetImmutabilityThresholdWithDefault(threshold)) <= f.frozen:
+		case *number-delay <= f.frozen:
 			log.Debug("Ancient blocks frozen already", "number", *number, "hash", hash, "frozen", f.frozen)
 			backoff = true
 			continue
@@ -326,7 +329,7 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 			continue
 		}
 		// Seems we have data ready to be frozen, process in usable batches
-		limit := *number - uint64(params.GetImmutabilityThresholdWithDefault(threshold))
+		limit := *number - delay
 		if limit-f.frozen > freezerBatchLimit {
 			limit = f.frozen + freezerBatchLimit
 		}
@@ -347,6 +350,13 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 				log.Error("Block header missing, can't freeze", "number", f.frozen, "hash", hash)
 				break
 			}
+			// Quorum
+			header, err := pruning.strip(db, f.frozen, hash, header)
+			if err != nil {
+				log.Error("Committed seals not pruned, can't freeze", "number", f.frozen, "hash", hash, "err", err)
+				break
+			}
+			// End Quorum
 			body := ReadBodyRLP(nfdb, hash, f.frozen)
 			if len(body) == 0 {
 				log.Error("Block body missing, can't freeze", "number", f.frozen, "hash", hash)
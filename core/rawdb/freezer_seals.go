@@ -0,0 +1,124 @@
+package rawdb
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Quorum
+//
+// The committed seals of the Istanbul headers are only needed to verify them
+// once, but make up most of the size of the ancient store of long-running
+// chains. As they are not part of the hash of the headers, the freezer may
+// strip them from the headers it moves to the ancient store, recording the hash
+// of the seals pruned instead.
+//
+// The ancient store being append-only, the pruning cannot be undone: the seals
+// can only be restored by re-syncing the headers from a node retaining them.
+
+// SealPruning configures the stripping of the committed seals of the Istanbul
+// headers moved to the ancient store.
+type SealPruning struct {
+	Depth       uint64 // blocks behind the head the headers are frozen from, the immutability threshold if lower
+	Checkpoints uint64 // interval of the blocks keeping their seals, 0 for none
+
+	// Verify checks the committed seals of a header before they are pruned,
+	// those of the headers failing it being retained.
+	Verify func(header *types.Header) error
+}
+
+var sealPruning atomic.Value // *SealPruning
+
+// SetSealPruning sets the seal pruning of the freezers, nil to disable it.
+func SetSealPruning(pruning *SealPruning) {
+	sealPruning.Store(pruning)
+}
+
+func currentSealPruning() *SealPruning {
+	pruning, _ := sealPruning.Load().(*SealPruning)
+	return pruning
+}
+
+// delay returns the number of recent blocks not to freeze.
+func (p *SealPruning) delay(threshold uint64) uint64 {
+	if p != nil && p.Depth > threshold {
+		return p.Depth
+	}
+	return threshold
+}
+
+// strip returns the RLP of the header without its committed seals, recording
+// the hash of the seals pruned. The headers of other engines, the checkpoints
+// and the headers failing the verification are returned as they are.
+func (p *SealPruning) strip(db ethdb.KeyValueWriter, number uint64, hash common.Hash, blob []byte) ([]byte, error) {
+	if p == nil || number == 0 || (p.Checkpoints > 0 && number%p.Checkpoints == 0) {
+		return blob, nil
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err != nil {
+		return nil, err
+	}
+	if header.MixDigest != types.IstanbulDigest {
+		return blob, nil
+	}
+	extra, err := types.ExtractIstanbulExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(extra.CommittedSeal) == 0 {
+		return blob, nil
+	}
+	if p.Verify != nil {
+		if err := p.Verify(header); err != nil {
+			log.Warn("Retaining unverified committed seals", "number", number, "hash", hash, "err", err)
+			return blob, nil
+		}
+	}
+	stripped := types.IstanbulFilteredHeader(header, true)
+	if stripped == nil || stripped.Hash() != hash {
+		return nil, fmt.Errorf("header %d changes hash without its committed seals", number)
+	}
+	seals, err := rlp.EncodeToBytes(extra.CommittedSeal)
+	if err != nil {
+		return nil, err
+	}
+	WriteSealAttestation(db, hash, number, crypto.Keccak256Hash(seals))
+	return rlp.EncodeToBytes(stripped)
+}
+
+// sealAttestationKey = sealAttestationPrefix + num (uint64 big endian) + hash
+func sealAttestationKey(number uint64, hash common.Hash) []byte {
+	return append(append(sealAttestationPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// WriteSealAttestation stores the hash of the RLP of the committed seals pruned
+// from a header, and that seals were pruned.
+func WriteSealAttestation(db ethdb.KeyValueWriter, hash common.Hash, number uint64, attestation common.Hash) {
+	if err := db.Put(sealAttestationKey(number, hash), attestation[:]); err != nil {
+		log.Crit("Failed to store seal attestation", "err", err)
+	}
+	if err := db.Put(sealsPrunedKey, []byte{1}); err != nil {
+		log.Crit("Failed to store seals pruned flag", "err", err)
+	}
+}
+
+// ReadSealAttestation retrieves the hash of the RLP of the committed seals
+// pruned from a header, the zero hash if they were not.
+func ReadSealAttestation(db ethdb.KeyValueReader, hash common.Hash, number uint64) common.Hash {
+	data, _ := db.Get(sealAttestationKey(number, hash))
+	return common.BytesToHash(data)
+}
+
+// HasPrunedSeals reports whether committed seals were pruned from the ancient
+// store.
+func HasPrunedSeals(db ethdb.KeyValueReader) bool {
+	ok, _ := db.Has(sealsPrunedKey)
+	return ok
+}
@@ -0,0 +1,88 @@
+package rawdb
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sealedIstanbulHeader returns an Istanbul header with committed seals.
+func sealedIstanbulHeader(t *testing.T, number uint64) *types.Header {
+	extra, err := rlp.EncodeToBytes(&types.IstanbulExtra{
+		Validators:    []common.Address{{0x01}},
+		Seal:          make([]byte, types.IstanbulExtraSeal),
+		CommittedSeal: [][]byte{{byte(number), 0x01}, {byte(number), 0x02}},
+	})
+	require.NoError(t, err)
+	return &types.Header{
+		Number:    new(big.Int).SetUint64(number),
+		MixDigest: types.IstanbulDigest,
+		Extra:     append(make([]byte, types.IstanbulExtraVanity), extra...),
+	}
+}
+
+func TestFreezerSealPruning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), dir, "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	headers := make([]*types.Header, 7)
+	for i := range headers {
+		headers[i] = sealedIstanbulHeader(t, uint64(i))
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		hash := headers[i].Hash()
+		WriteHeader(db, headers[i])
+		WriteBody(db, hash, uint64(i), &types.Body{})
+		WriteReceipts(db, hash, uint64(i), nil)
+		WriteTd(db, hash, uint64(i), big.NewInt(int64(i)))
+		WriteCanonicalHash(db, hash, uint64(i))
+	}
+	WriteHeadBlockHash(db, headers[6].Hash())
+
+	// the seals of block 2 fail the verification, block 3 is a checkpoint
+	unverified := headers[2].Hash()
+	SetSealPruning(&SealPruning{Depth: 2, Checkpoints: 3, Verify: func(header *types.Header) error {
+		if header.Hash() == unverified {
+			return errors.New("invalid committed seals")
+		}
+		return nil
+	}})
+	defer SetSealPruning(nil)
+	db.(*freezerdb).Freeze(0)
+
+	frozen, err := db.Ancients()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), frozen, "blocks older than the depth frozen")
+	for i, pruned := range []bool{false, true, false, false, true} {
+		hash := headers[i].Hash()
+		header := ReadHeader(db, hash, uint64(i))
+		require.NotNil(t, header)
+		assert.Equal(t, hash, header.Hash())
+		extra, err := types.ExtractIstanbulExtra(header)
+		require.NoError(t, err)
+		if !pruned {
+			assert.Len(t, extra.CommittedSeal, 2, "block %d", i)
+			assert.Equal(t, common.Hash{}, ReadSealAttestation(db, hash, uint64(i)), "block %d", i)
+			continue
+		}
+		assert.Empty(t, extra.CommittedSeal, "block %d", i)
+		original, _ := types.ExtractIstanbulExtra(headers[i])
+		seals, _ := rlp.EncodeToBytes(original.CommittedSeal)
+		assert.Equal(t, crypto.Keccak256Hash(seals), ReadSealAttestation(db, hash, uint64(i)), "block %d", i)
+	}
+	assert.True(t, HasPrunedSeals(db))
+}
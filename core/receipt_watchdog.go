@@ -0,0 +1,229 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	receiptVerifiedCounter = metrics.NewRegisteredCounter("chain/receipts/verified", nil)
+	receiptSkippedCounter  = metrics.NewRegisteredCounter("chain/receipts/skipped", nil)
+	receiptMismatchCounter = metrics.NewRegisteredCounter("chain/receipts/mismatch", nil)
+
+	// ErrCorruptedReceipts is returned when serving receipts of a block whose
+	// stored receipts were found to diverge from its header.
+	ErrCorruptedReceipts = errors.New("stored receipts are corrupted")
+
+	// errPrivateReceiptRoot is returned by VerifyBlockReceipts for blocks with
+	// private transactions. Stored receipts of private transactions are the
+	// private ones, so the receipt root of those blocks cannot be recomputed.
+	errPrivateReceiptRoot = errors.New("receipt root not verifiable for blocks with private transactions")
+)
+
+// VerifyBlockReceipts checks the receipts stored for a block against the
+// block. For public-only blocks the receipt trie root is recomputed and
+// compared to the header. Stored receipts of private transactions are the
+// private ones, so for blocks with private transactions the receipt root
+// cannot be recomputed. Instead every stored receipt must match a transaction
+// of the block and the private receipts must match the stored private bloom;
+// errPrivateReceiptRoot is returned if they do.
+func (bc *BlockChain) VerifyBlockReceipts(block *types.Block) error {
+	txs := block.Transactions()
+	receipts := rawdb.ReadRawReceipts(bc.db, block.Hash(), block.NumberU64())
+	if receipts == nil {
+		if len(txs) == 0 {
+			return nil
+		}
+		return fmt.Errorf("missing receipts for %d transactions", len(txs))
+	}
+	if err := receipts.DeriveFields(bc.chainConfig, block.Hash(), block.NumberU64(), txs); err != nil {
+		return err
+	}
+	var privateReceipts types.Receipts
+	for i, tx := range txs {
+		if tx.IsPrivate() {
+			privateReceipts = append(privateReceipts, receipts[i])
+		}
+	}
+	if len(privateReceipts) > 0 {
+		if bloom := types.CreateBloom(privateReceipts); bloom != rawdb.GetPrivateBlockBloom(bc.db, block.NumberU64()) {
+			return fmt.Errorf("private receipts do not match private bloom of %d transactions", len(privateReceipts))
+		}
+		return errPrivateReceiptRoot
+	}
+	if root := types.DeriveSha(receipts, new(trie.Trie)); root != block.ReceiptHash() {
+		return fmt.Errorf("invalid receipt root hash (header: %x local: %x)", block.ReceiptHash(), root)
+	}
+	return nil
+}
+
+// ReceiptMismatch describes a block whose stored receipts diverge from it.
+type ReceiptMismatch struct {
+	Number uint64
+	Hash   string
+	Error  string
+}
+
+// ReceiptVerification is the outcome of verifying a range of blocks.
+type ReceiptVerification struct {
+	Checked    uint64            // blocks whose receipts were fully verified
+	Skipped    uint64            // blocks with private transactions, only partially verified
+	Mismatches []ReceiptMismatch // blocks whose receipts diverge
+}
+
+// ReceiptWatchdog verifies stored receipts of new blocks and of a sample of
+// historical blocks in the background, reporting divergence between stored
+// receipts and block headers. If configured to degrade, the corrupted blocks
+// are remembered so their receipts are refused instead of served.
+type ReceiptWatchdog struct {
+	bc         *BlockChain
+	sampleRate uint64
+	degrade    bool
+
+	lock      sync.RWMutex
+	corrupted map[uint64]struct{}
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReceiptWatchdog creates a receipt watchdog. A sample rate of N verifies
+// one in N historical blocks, zero disables background verification.
+func NewReceiptWatchdog(bc *BlockChain, sampleRate uint64, degrade bool) *ReceiptWatchdog {
+	return &ReceiptWatchdog{
+		bc:         bc,
+		sampleRate: sampleRate,
+		degrade:    degrade,
+		corrupted:  make(map[uint64]struct{}),
+		quit:       make(chan struct{}),
+	}
+}
+
+// Start launches background verification if enabled.
+func (w *ReceiptWatchdog) Start() {
+	if w.sampleRate == 0 {
+		return
+	}
+	head := w.bc.CurrentBlock().NumberU64()
+
+	w.wg.Add(2)
+	go w.newBlockLoop()
+	go w.historicalLoop(head)
+}
+
+// Stop terminates background verification.
+func (w *ReceiptWatchdog) Stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+// newBlockLoop verifies every block added to the canonical chain.
+func (w *ReceiptWatchdog) newBlockLoop() {
+	defer w.wg.Done()
+
+	chainCh := make(chan ChainEvent, 64)
+	sub := w.bc.SubscribeChainEvent(chainCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			w.verify(ev.Block)
+		case <-sub.Err():
+			return
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// historicalLoop verifies one in sampleRate blocks up to the given head,
+// starting at a random offset so restarts cover different blocks.
+func (w *ReceiptWatchdog) historicalLoop(head uint64) {
+	defer w.wg.Done()
+
+	for number := uint64(rand.Int63n(int64(w.sampleRate))); number <= head; number += w.sampleRate {
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+		if block := w.bc.GetBlockByNumber(number); block != nil {
+			w.verify(block)
+		}
+	}
+	log.Debug("Historical receipt verification done", "head", head, "rate", w.sampleRate)
+}
+
+// VerifyRange verifies the receipts of all canonical blocks in [from, to].
+func (w *ReceiptWatchdog) VerifyRange(from, to uint64) (*ReceiptVerification, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range %d > %d", from, to)
+	}
+	result := &ReceiptVerification{Mismatches: []ReceiptMismatch{}}
+	for number := from; number <= to; number++ {
+		block := w.bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		switch err := w.verify(block); err {
+		case nil:
+			result.Checked++
+		case errPrivateReceiptRoot:
+			result.Skipped++
+		default:
+			result.Mismatches = append(result.Mismatches, ReceiptMismatch{
+				Number: number,
+				Hash:   block.Hash().Hex(),
+				Error:  err.Error(),
+			})
+		}
+	}
+	return result, nil
+}
+
+// verify checks a single block, reporting and recording any divergence.
+func (w *ReceiptWatchdog) verify(block *types.Block) error {
+	err := w.bc.VerifyBlockReceipts(block)
+	switch err {
+	case nil:
+		receiptVerifiedCounter.Inc(1)
+	case errPrivateReceiptRoot:
+		receiptSkippedCounter.Inc(1)
+	default:
+		receiptMismatchCounter.Inc(1)
+		log.Error(fmt.Sprintf(`
+########## CORRUPTED RECEIPTS #########
+Number: %v
+Hash: 0x%x
+
+Error: %v
+#######################################
+`, block.Number(), block.Hash(), err))
+		if w.degrade {
+			w.lock.Lock()
+			w.corrupted[block.NumberU64()] = struct{}{}
+			w.lock.Unlock()
+		}
+	}
+	return err
+}
+
+// IsCorrupted reports whether the receipts of the given block were found
+// corrupted and must not be served. It always returns false unless the
+// watchdog was configured to degrade.
+func (w *ReceiptWatchdog) IsCorrupted(number uint64) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	_, ok := w.corrupted[number]
+	return ok
+}
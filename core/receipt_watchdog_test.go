@@ -0,0 +1,64 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptWatchdog_VerifyRange(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 8, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		require.NoError(t, err)
+		block.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+
+	watchdog := NewReceiptWatchdog(chain, 0, true)
+
+	result, err := watchdog.VerifyRange(0, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), result.Checked)
+	assert.Empty(t, result.Mismatches)
+
+	// corrupt the stored receipt of block 5
+	corrupted := blocks[4]
+	receipts := rawdb.ReadRawReceipts(db, corrupted.Hash(), corrupted.NumberU64())
+	receipts[0].CumulativeGasUsed++
+	rawdb.WriteReceipts(db, corrupted.Hash(), corrupted.NumberU64(), receipts)
+
+	result, err = watchdog.VerifyRange(0, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), result.Checked)
+	require.Len(t, result.Mismatches, 1)
+	assert.Equal(t, uint64(5), result.Mismatches[0].Number)
+	assert.True(t, watchdog.IsCorrupted(5))
+	assert.False(t, watchdog.IsCorrupted(4))
+
+	_, err = watchdog.VerifyRange(0, 9)
+	assert.Error(t, err, "block out of range")
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// rejectedReorgLimit is the number of rejected reorgs kept for an operator to
+// accept.
+const rejectedReorgLimit = 16
+
+var (
+	// blockReorgRejectedMeter counts the reorgs rejected as deeper than the
+	// maximum accepted depth.
+	blockReorgRejectedMeter = metrics.NewRegisteredMeter("chain/reorg/rejected", nil)
+
+	// ErrReorgTooDeep is returned when switching to a chain would drop more
+	// canonical blocks than the maximum accepted reorg depth.
+	ErrReorgTooDeep = errors.New("reorg deeper than the maximum accepted depth")
+
+	errUnknownRejectedReorg = errors.New("no rejected reorg to the block")
+)
+
+// SetMaxReorgDepth sets the maximum number of canonical blocks a reorg may drop,
+// deeper reorgs being rejected and the current head kept. The depth is
+// unlimited unless set.
+func (bc *BlockChain) SetMaxReorgDepth(depth uint64) {
+	bc.maxReorgDepth = &depth
+}
+
+// AcceptDeepReorg permits the rejected reorg to the block, which is adopted the
+// next time the chain ending in, or extending, it is imported.
+func (bc *BlockChain) AcceptDeepReorg(hash common.Hash) error {
+	if !bc.rejectedReorgs.Contains(hash) {
+		return errUnknownRejectedReorg
+	}
+	bc.rejectedReorgs.Remove(hash)
+	bc.acceptedReorgs.Add(hash, struct{}{})
+	log.Warn("Accepted deep chain reorg", "hash", hash)
+	return nil
+}
+
+// checkReorgDepth rejects the reorg from the old head dropping oldChain to the
+// new head adding newChain, unless within the maximum depth or accepted.
+func (bc *BlockChain) checkReorgDepth(oldHead, newHead, commonBlock *types.Block, oldChain, newChain types.Blocks) error {
+	if bc.maxReorgDepth == nil || uint64(len(oldChain)) <= *bc.maxReorgDepth {
+		return nil
+	}
+	for _, block := range newChain {
+		if bc.acceptedReorgs.Contains(block.Hash()) {
+			bc.acceptedReorgs.Remove(block.Hash())
+			return nil
+		}
+	}
+	log.Error("Rejected chain reorg deeper than the maximum accepted depth", "maxdepth", *bc.maxReorgDepth, "drop", len(oldChain), "add", len(newChain),
+		"oldnumber", oldHead.Number(), "oldhash", oldHead.Hash(), "newnumber", newHead.Number(), "newhash", newHead.Hash(),
+		"forknumber", commonBlock.Number(), "forkhash", commonBlock.Hash())
+	blockReorgRejectedMeter.Mark(1)
+	bc.rejectedReorgs.Add(newHead.Hash(), struct{}{})
+	return fmt.Errorf("%w: dropping %d blocks from #%d [%x…], new head #%d [%x…]", ErrReorgTooDeep,
+		len(oldChain), commonBlock.NumberU64(), commonBlock.Hash().Bytes()[:4], newHead.NumberU64(), newHead.Hash().Bytes()[:4])
+}
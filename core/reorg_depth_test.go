@@ -0,0 +1,47 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestMaxReorgDepth(t *testing.T) {
+	db, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+	blockchain.SetMaxReorgDepth(1)
+
+	easyBlocks, _ := GenerateChain(params.TestChainConfig, blockchain.CurrentBlock(), ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.OffsetTime(10)
+	})
+	diffBlocks, _ := GenerateChain(params.TestChainConfig, blockchain.CurrentBlock(), ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.OffsetTime(-9)
+	})
+	if _, err := blockchain.InsertChain(easyBlocks); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	if _, err := blockchain.InsertChain(diffBlocks); !errors.Is(err, ErrReorgTooDeep) {
+		t.Fatalf("inserting the difficult chain: have %v, want %v", err, ErrReorgTooDeep)
+	}
+	if head := blockchain.CurrentBlock().Hash(); head != easyBlocks[2].Hash() {
+		t.Fatalf("head changed to %x after the rejected reorg", head)
+	}
+
+	if err := blockchain.AcceptDeepReorg(easyBlocks[1].Hash()); err != errUnknownRejectedReorg {
+		t.Fatalf("accepting an unknown reorg: have %v, want %v", err, errUnknownRejectedReorg)
+	}
+	if err := blockchain.AcceptDeepReorg(diffBlocks[2].Hash()); err != nil {
+		t.Fatalf("failed to accept the rejected reorg: %v", err)
+	}
+	if _, err := blockchain.InsertChain(diffBlocks); err != nil {
+		t.Fatalf("failed to insert difficult chain once accepted: %v", err)
+	}
+	if head := blockchain.CurrentBlock().Hash(); head != diffBlocks[2].Hash() {
+		t.Fatalf("head mismatch after the accepted reorg: have %x, want %x", head, diffBlocks[2].Hash())
+	}
+}
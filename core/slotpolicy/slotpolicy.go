@@ -0,0 +1,280 @@
+// Package slotpolicy implements a node-local policy protecting storage slots
+// of contracts from being written by the transactions of unauthorized senders.
+//
+// The policy is only enforced on the transactions submitted to the node over
+// RPC, which are simulated against the current state before being admitted to
+// the transaction pool. It is not part of consensus: transactions submitted
+// through other nodes, or whose effect differs once mined, are not restricted.
+package slotpolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// reloadInterval is the interval the policy file is checked for changes at.
+const reloadInterval = 5 * time.Second
+
+// Config is the policy file, e.g.
+//
+//	{
+//	  "contracts": [{
+//	    "address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34",
+//	    "slots": [{"from": "0x0", "to": "0x3"}, {"from": "0x10"}],
+//	    "senders": ["0xed9d02e382b34818e88b88a309c7fe71e65f419d"]
+//	  }]
+//	}
+//
+// where a range without to is a single slot.
+type Config struct {
+	Contracts []ContractConfig `json:"contracts"`
+}
+
+// ContractConfig protects the slots of a contract, writable only by the senders.
+type ContractConfig struct {
+	Address common.Address   `json:"address"`
+	Slots   []SlotRange      `json:"slots"`
+	Senders []common.Address `json:"senders"`
+}
+
+// SlotRange is an inclusive range of storage slots.
+type SlotRange struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to,omitempty"`
+}
+
+// ProtectedSlotError is returned for the transactions writing a protected slot
+// of a contract from a sender not allowed to.
+type ProtectedSlotError struct {
+	Contract common.Address `json:"contract"`
+	Slot     common.Hash    `json:"slot"`
+	Sender   common.Address `json:"sender"`
+}
+
+func (e *ProtectedSlotError) Error() string {
+	return fmt.Sprintf("slot %s of contract %s is protected, writes by %s are not allowed", e.Slot.Hex(), e.Contract.Hex(), e.Sender.Hex())
+}
+
+// ErrorCode returns the JSON-RPC error code of the rejections.
+func (e *ProtectedSlotError) ErrorCode() int {
+	return -32000
+}
+
+// ErrorData returns the contract and slot of the rejection.
+func (e *ProtectedSlotError) ErrorData() interface{} {
+	return e
+}
+
+// slotRange is a validated SlotRange.
+type slotRange struct {
+	from, to *big.Int
+}
+
+type contractPolicy struct {
+	slots   []slotRange
+	senders map[common.Address]struct{}
+}
+
+// Policy is the policy of a file, reloaded when the file changes.
+type Policy struct {
+	path string
+	quit chan struct{}
+
+	lock      sync.RWMutex
+	contracts map[common.Address]*contractPolicy
+	modTime   time.Time
+	size      int64
+}
+
+// Load reads the policy of the file.
+func Load(path string) (*Policy, error) {
+	p := &Policy{path: path, quit: make(chan struct{})}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parse validates the configuration of the file.
+func parse(blob []byte) (map[common.Address]*contractPolicy, error) {
+	var config Config
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, err
+	}
+	contracts := make(map[common.Address]*contractPolicy, len(config.Contracts))
+	maxSlot := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	for _, contract := range config.Contracts {
+		if _, ok := contracts[contract.Address]; ok {
+			return nil, fmt.Errorf("contract %s is listed twice", contract.Address.Hex())
+		}
+		policy := &contractPolicy{senders: make(map[common.Address]struct{}, len(contract.Senders))}
+		for _, slots := range contract.Slots {
+			if slots.From == nil {
+				return nil, fmt.Errorf("contract %s: slot range without start", contract.Address.Hex())
+			}
+			r := slotRange{from: slots.From.ToInt(), to: slots.From.ToInt()}
+			if slots.To != nil {
+				r.to = slots.To.ToInt()
+			}
+			if r.to.Cmp(maxSlot) > 0 || r.from.Cmp(r.to) > 0 {
+				return nil, fmt.Errorf("contract %s: invalid slot range [%#x, %#x]", contract.Address.Hex(), r.from, r.to)
+			}
+			policy.slots = append(policy.slots, r)
+		}
+		for _, sender := range contract.Senders {
+			policy.senders[sender] = struct{}{}
+		}
+		contracts[contract.Address] = policy
+	}
+	return contracts, nil
+}
+
+// reload reads the file, keeping the current policy if it is invalid.
+func (p *Policy) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	blob, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	contracts, err := parse(blob)
+	if err != nil {
+		return fmt.Errorf("invalid slot policy %s: %v", p.path, err)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.contracts = contracts
+	p.modTime, p.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// Start implements node.Lifecycle, reloading the policy whenever the file
+// changes.
+func (p *Policy) Start() error {
+	go p.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle.
+func (p *Policy) Stop() error {
+	close(p.quit)
+	return nil
+}
+
+func (p *Policy) loop() {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(p.path)
+			if err != nil {
+				log.Error("Failed to check the slot policy", "path", p.path, "err", err)
+				continue
+			}
+			p.lock.RLock()
+			modified := !info.ModTime().Equal(p.modTime) || info.Size() != p.size
+			p.lock.RUnlock()
+			if !modified {
+				continue
+			}
+			if err := p.reload(); err != nil {
+				log.Error("Keeping the previous slot policy", "err", err)
+			} else {
+				log.Info("Reloaded the slot policy", "path", p.path)
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Contracts returns the protected contracts.
+func (p *Policy) Contracts() []common.Address {
+	if p == nil {
+		return nil
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	contracts := make([]common.Address, 0, len(p.contracts))
+	for contract := range p.contracts {
+		contracts = append(contracts, contract)
+	}
+	return contracts
+}
+
+// Check returns a *ProtectedSlotError if the slot of the contract is
+// protected and the sender is not allowed to write it.
+func (p *Policy) Check(sender, contract common.Address, slot common.Hash) error {
+	if p == nil {
+		return nil
+	}
+	p.lock.RLock()
+	policy := p.contracts[contract]
+	p.lock.RUnlock()
+	if policy == nil {
+		return nil
+	}
+	if _, ok := policy.senders[sender]; ok {
+		return nil
+	}
+	n := slot.Big()
+	for _, r := range policy.slots {
+		if n.Cmp(r.from) >= 0 && n.Cmp(r.to) <= 0 {
+			return &ProtectedSlotError{Contract: contract, Slot: slot, Sender: sender}
+		}
+	}
+	return nil
+}
+
+// CheckDestruct returns a *ProtectedSlotError if the contract has protected
+// slots the sender is not allowed to clear by destroying it.
+func (p *Policy) CheckDestruct(sender, contract common.Address) error {
+	if p == nil {
+		return nil
+	}
+	p.lock.RLock()
+	policy := p.contracts[contract]
+	p.lock.RUnlock()
+	if policy == nil || len(policy.slots) == 0 {
+		return nil
+	}
+	if _, ok := policy.senders[sender]; ok {
+		return nil
+	}
+	return &ProtectedSlotError{Contract: contract, Slot: common.BigToHash(policy.slots[0].from), Sender: sender}
+}
+
+var (
+	current     *Policy
+	currentLock sync.RWMutex
+)
+
+// Set installs the policy enforced on the submitted transactions, nil
+// disables it.
+func Set(p *Policy) {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	current = p
+}
+
+// Get returns the installed policy, nil if there is none.
+func Get() *Policy {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
@@ -0,0 +1,81 @@
+package slotpolicy
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	contract = common.HexToAddress("0x1932c48b2bf8102ba33b4a6b545c32236e342f34")
+	admin    = common.HexToAddress("0xed9d02e382b34818e88b88a309c7fe71e65f419d")
+	other    = common.HexToAddress("0xca843569e3427144cead5e4d5999a3d0ccf92b8e")
+)
+
+const testPolicy = `{
+  "contracts": [{
+    "address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34",
+    "slots": [{"from": "0x0", "to": "0x3"}, {"from": "0x10"}],
+    "senders": ["0xed9d02e382b34818e88b88a309c7fe71e65f419d"]
+  }]
+}`
+
+func writePolicy(t *testing.T, path, policy string, modTime time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(policy), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestPolicy_Check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, path, testPolicy, time.Now())
+	policy, err := Load(path)
+	require.NoError(t, err)
+
+	for slot, protected := range map[int64]bool{0: true, 3: true, 4: false, 0x10: true, 0x11: false} {
+		err := policy.Check(other, contract, common.BigToHash(big.NewInt(slot)))
+		if !protected {
+			assert.NoError(t, err, "slot %d", slot)
+			continue
+		}
+		var slotErr *ProtectedSlotError
+		require.True(t, errors.As(err, &slotErr), "slot %d", slot)
+		assert.Equal(t, contract, slotErr.Contract)
+		assert.Equal(t, other, slotErr.Sender)
+		assert.NoError(t, policy.Check(admin, contract, common.BigToHash(big.NewInt(slot))), "slot %d", slot)
+	}
+	assert.NoError(t, policy.Check(other, other, common.Hash{}))
+	assert.Error(t, policy.CheckDestruct(other, contract))
+	assert.NoError(t, policy.CheckDestruct(admin, contract))
+
+	var none *Policy
+	assert.NoError(t, none.Check(other, contract, common.Hash{}))
+	assert.Empty(t, none.Contracts())
+}
+
+func TestPolicy_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	start := time.Now()
+	writePolicy(t, path, testPolicy, start)
+	policy, err := Load(path)
+	require.NoError(t, err)
+
+	// invalid policies are rejected, keeping the current one
+	writePolicy(t, path, `{"contracts": [{"address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34", "slots": [{"from": "0x3", "to": "0x1"}]}]}`, start.Add(time.Second))
+	assert.Error(t, policy.reload())
+	assert.Error(t, policy.Check(other, contract, common.Hash{}))
+	_, err = Load(path)
+	assert.Error(t, err)
+
+	writePolicy(t, path, `{"contracts": []}`, start.Add(2*time.Second))
+	require.NoError(t, policy.reload())
+	assert.NoError(t, policy.Check(other, contract, common.Hash{}))
+	assert.Empty(t, policy.Contracts())
+}
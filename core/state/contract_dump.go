@@ -0,0 +1,212 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Quorum
+//
+// A contract dump holds the code and complete storage of a single contract, so
+// that it can be moved to another network. It is a stream of a header followed
+// by one record per storage slot, in the order of the storage trie, either as
+// JSON values separated by newlines or as RLP items, so that it is written and
+// read as a stream.
+
+// ContractDumpVersion is the version of the contract dump format.
+const ContractDumpVersion = 1
+
+// ContractDumpFormat is the encoding of a contract dump.
+type ContractDumpFormat string
+
+const (
+	ContractDumpJSON ContractDumpFormat = "json"
+	ContractDumpRLP  ContractDumpFormat = "rlp"
+)
+
+var (
+	ErrContractNotFound = errors.New("contract not found")
+	errSlotsOutOfOrder  = errors.New("storage slots out of order")
+)
+
+// ContractDumpHeader is the first record of a contract dump.
+type ContractDumpHeader struct {
+	Version     uint64         `json:"version"`
+	Address     common.Address `json:"address"`
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Nonce       uint64         `json:"nonce"`
+	Code        hexutil.Bytes  `json:"code"`
+	CodeHash    common.Hash    `json:"codeHash"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+}
+
+// ContractDumpSlot is a storage slot of a contract dump.
+type ContractDumpSlot struct {
+	Key   common.Hash `json:"key"`
+	Value common.Hash `json:"value"`
+}
+
+// DumpContract writes the dump of the contract at addr to w, recording the
+// block of the state in its header. It fails if the preimage of a storage key
+// is missing, as the dump could not be re-imported.
+func (s *StateDB) DumpContract(addr common.Address, blockNumber uint64, blockHash common.Hash, format ContractDumpFormat, w io.Writer) error {
+	var encode func(interface{}) error
+	switch format {
+	case ContractDumpJSON, "":
+		encode = json.NewEncoder(w).Encode
+	case ContractDumpRLP:
+		encode = func(val interface{}) error { return rlp.Encode(w, val) }
+	default:
+		return fmt.Errorf("unknown contract dump format %q", format)
+	}
+	obj := s.getStateObject(addr)
+	if obj == nil || len(obj.Code(s.db)) == 0 {
+		return ErrContractNotFound
+	}
+	// the storage trie is opened at the root of the account, leaving out the
+	// changes not committed yet
+	storageTrie, err := s.db.OpenStorageTrie(obj.addrHash, obj.data.Root)
+	if err != nil {
+		return err
+	}
+	header := &ContractDumpHeader{
+		Version:     ContractDumpVersion,
+		Address:     addr,
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		Nonce:       obj.Nonce(),
+		Code:        obj.Code(s.db),
+		CodeHash:    common.BytesToHash(obj.CodeHash()),
+		StorageRoot: obj.data.Root,
+	}
+	if err := encode(header); err != nil {
+		return err
+	}
+	it := trie.NewIterator(storageTrie.NodeIterator(nil))
+	for it.Next() {
+		key := storageTrie.GetKey(it.Key)
+		if key == nil {
+			return fmt.Errorf("missing preimage of storage key %#x", it.Key)
+		}
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return err
+		}
+		if err := encode(&ContractDumpSlot{Key: common.BytesToHash(key), Value: common.BytesToHash(content)}); err != nil {
+			return err
+		}
+	}
+	return it.Err
+}
+
+// ContractDumpReader decodes a contract dump in either format.
+type ContractDumpReader struct {
+	header ContractDumpHeader
+	decode func(interface{}) error
+}
+
+// NewContractDumpReader reads the header of the dump.
+func NewContractDumpReader(r io.Reader) (*ContractDumpReader, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract dump: %v", err)
+	}
+	reader := new(ContractDumpReader)
+	// RLP lists never start with a brace
+	if first[0] == '{' {
+		reader.decode = json.NewDecoder(br).Decode
+	} else {
+		reader.decode = rlp.NewStream(br, 0).Decode
+	}
+	if err := reader.decode(&reader.header); err != nil {
+		return nil, fmt.Errorf("invalid contract dump header: %v", err)
+	}
+	if reader.header.Version != ContractDumpVersion {
+		return nil, fmt.Errorf("unsupported contract dump version %d", reader.header.Version)
+	}
+	return reader, nil
+}
+
+// Header returns the header of the dump.
+func (r *ContractDumpReader) Header() *ContractDumpHeader {
+	return &r.header
+}
+
+// Next returns the next storage slot of the dump, io.EOF once all were read.
+func (r *ContractDumpReader) Next() (*ContractDumpSlot, error) {
+	slot := new(ContractDumpSlot)
+	if err := r.decode(slot); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid contract dump slot: %v", err)
+	}
+	return slot, nil
+}
+
+// Verify reads the remaining storage slots of the dump, checking that the code
+// matches the code hash of the header and that the storage matches the given
+// storage root. The slots are passed to onSlot, if not nil, as they are read.
+func (r *ContractDumpReader) Verify(storageRoot common.Hash, onSlot func(*ContractDumpSlot)) error {
+	if codeHash := crypto.Keccak256Hash(r.header.Code); codeHash != r.header.CodeHash {
+		return fmt.Errorf("code hash mismatch: have %x, want %x", codeHash, r.header.CodeHash)
+	}
+	storage, err := trie.New(common.Hash{}, trie.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		return err
+	}
+	var previous []byte
+	for {
+		slot, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// the slots are in the order of the hashed keys, which also rules out
+		// duplicates
+		hashed := crypto.Keccak256(slot.Key[:])
+		if previous != nil && bytes.Compare(previous, hashed) >= 0 {
+			return errSlotsOutOfOrder
+		}
+		previous = hashed
+		value, _ := rlp.EncodeToBytes(common.TrimLeftZeroes(slot.Value[:]))
+		if err := storage.TryUpdate(hashed, value); err != nil {
+			return err
+		}
+		if onSlot != nil {
+			onSlot(slot)
+		}
+	}
+	if root := storage.Hash(); root != storageRoot {
+		return fmt.Errorf("storage root mismatch: have %x, want %x", root, storageRoot)
+	}
+	return nil
+}
+
+// VerifyContractDump checks that the code of the dump matches its code hash and
+// that its storage matches the given storage root, returning its header.
+func VerifyContractDump(r io.Reader, storageRoot common.Hash) (*ContractDumpHeader, error) {
+	reader, err := NewContractDumpReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := reader.Verify(storageRoot, nil); err != nil {
+		return nil, err
+	}
+	return reader.Header(), nil
+}
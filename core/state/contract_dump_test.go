@@ -0,0 +1,82 @@
+package state
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dumpedContract = common.HexToAddress("0x1932c48b2bf8102ba33b4a6b545c32236e342f34")
+
+func newDumpTestState(t *testing.T) *StateDB {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := New(common.Hash{}, db, nil)
+	statedb.SetCode(dumpedContract, []byte{0x60, 0x00})
+	statedb.SetNonce(dumpedContract, 1)
+	for i := byte(1); i <= 20; i++ {
+		statedb.SetState(dumpedContract, common.Hash{i}, common.BytesToHash([]byte{i}))
+	}
+	root, err := statedb.Commit(false)
+	require.NoError(t, err)
+	statedb, err = New(root, db, nil)
+	require.NoError(t, err)
+	return statedb
+}
+
+func TestContractDump_RoundTrip(t *testing.T) {
+	statedb := newDumpTestState(t)
+	storageRoot := statedb.StorageTrie(dumpedContract).Hash()
+
+	for _, format := range []ContractDumpFormat{ContractDumpJSON, ContractDumpRLP} {
+		var dump bytes.Buffer
+		require.NoError(t, statedb.DumpContract(dumpedContract, 5, common.Hash{0x05}, format, &dump), format)
+
+		header, err := VerifyContractDump(bytes.NewReader(dump.Bytes()), storageRoot)
+		require.NoError(t, err, format)
+		assert.Equal(t, dumpedContract, header.Address)
+		assert.Equal(t, uint64(5), header.BlockNumber)
+		assert.Equal(t, uint64(1), header.Nonce)
+		assert.Equal(t, storageRoot, header.StorageRoot)
+
+		reader, err := NewContractDumpReader(bytes.NewReader(dump.Bytes()))
+		require.NoError(t, err)
+		slots := 0
+		for {
+			slot, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			assert.Equal(t, statedb.GetState(dumpedContract, slot.Key), slot.Value)
+			slots++
+		}
+		assert.Equal(t, 20, slots, format)
+	}
+}
+
+func TestContractDump_Mismatch(t *testing.T) {
+	statedb := newDumpTestState(t)
+	var dump bytes.Buffer
+	require.NoError(t, statedb.DumpContract(dumpedContract, 5, common.Hash{}, ContractDumpJSON, &dump))
+
+	_, err := VerifyContractDump(bytes.NewReader(dump.Bytes()), common.Hash{0x01})
+	assert.Contains(t, err.Error(), "storage root mismatch")
+
+	// a truncated dump
+	lines := bytes.SplitAfter(dump.Bytes(), []byte("\n"))
+	truncated := bytes.Join(lines[:len(lines)-2], nil)
+	_, err = VerifyContractDump(bytes.NewReader(truncated), statedb.StorageTrie(dumpedContract).Hash())
+	assert.Contains(t, err.Error(), "storage root mismatch")
+
+	// slots out of order
+	lines[1], lines[2] = lines[2], lines[1]
+	_, err = VerifyContractDump(bytes.NewReader(bytes.Join(lines, nil)), statedb.StorageTrie(dumpedContract).Hash())
+	assert.Equal(t, errSlotsOutOfOrder, err)
+
+	assert.Equal(t, ErrContractNotFound, statedb.DumpContract(common.Address{0x01}, 5, common.Hash{}, ContractDumpJSON, &dump))
+}
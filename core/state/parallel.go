@@ -0,0 +1,73 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Quorum
+//
+// The functions below let transactions be executed against copies of a state
+// and their results be merged back into it, see core.StateProcessor.
+
+var errMergeNotFinalised = errors.New("state to merge from has unfinalised changes")
+
+// TrackAccesses starts recording the addresses of the accounts read or written,
+// including those read while not existing.
+func (s *StateDB) TrackAccesses() {
+	s.accessed = make(map[common.Address]struct{})
+}
+
+// AccessedAddresses returns the addresses recorded since TrackAccesses.
+func (s *StateDB) AccessedAddresses() []common.Address {
+	addrs := make([]common.Address, 0, len(s.accessed))
+	for addr := range s.accessed {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// IsModified reports whether the account was modified since the state was
+// opened, whether or not the changes were finalised.
+func (s *StateDB) IsModified(addr common.Address) bool {
+	if _, ok := s.stateObjectsPending[addr]; ok {
+		return true
+	}
+	_, ok := s.journal.dirties[addr]
+	return ok
+}
+
+// MergeAccounts copies those of the given accounts which were modified in src
+// into the state, replacing its version of them. The changes of src must have
+// been finalised, and the accounts must be unmodified in s for the result to
+// be the one of applying the changes of src to s.
+func (s *StateDB) MergeAccounts(src *StateDB, addrs []common.Address) error {
+	if len(src.journal.dirties) > 0 {
+		return errMergeNotFinalised
+	}
+	var objs []*stateObject
+	for _, addr := range addrs {
+		if _, ok := src.stateObjectsPending[addr]; !ok {
+			continue
+		}
+		obj := src.stateObjects[addr]
+		if obj == nil {
+			continue
+		}
+		if _, destructed := src.snapDestructs[obj.addrHash]; destructed {
+			return fmt.Errorf("account %x was recreated", addr)
+		}
+		objs = append(objs, obj)
+	}
+	for _, obj := range objs {
+		s.stateObjects[obj.address] = obj.deepCopy(s)
+		s.stateObjectsPending[obj.address] = struct{}{}
+		s.stateObjectsDirty[obj.address] = struct{}{}
+		if s.accessed != nil {
+			s.accessed[obj.address] = struct{}{}
+		}
+	}
+	return nil
+}
@@ -75,6 +75,9 @@ type StateDB struct {
 	// Quorum - a trie to hold extra account information that cannot be stored in the accounts trie
 	accountExtraDataTrie Trie
 
+	// Quorum - addresses of the accounts read or written, nil unless tracked, see TrackAccesses
+	accessed map[common.Address]struct{}
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects        map[common.Address]*stateObject
 	stateObjectsPending map[common.Address]struct{} // State objects finalized but not yet written to the trie
@@ -115,6 +118,11 @@ type StateDB struct {
 	SnapshotAccountReads time.Duration
 	SnapshotStorageReads time.Duration
 	SnapshotCommits      time.Duration
+
+	// Quorum
+	PrivatePayloadFetches time.Duration // Waiting for the private transaction manager
+	PrivateExecutions     time.Duration // Applying private transactions, fetches included
+	// /Quorum
 }
 
 // New creates a new state from a given trie.
@@ -441,6 +449,23 @@ func (s *StateDB) GetStorageRoot(addr common.Address) (common.Hash, error) {
 	return so.storageRoot(s.db), nil
 }
 
+// Quorum
+// ModifiedStorage returns the slots of the account written since the last
+// Finalise to a value other than their committed one, along with these values.
+func (s *StateDB) ModifiedStorage(addr common.Address) map[common.Hash]common.Hash {
+	so := s.getStateObject(addr)
+	if so == nil {
+		return nil
+	}
+	modified := make(map[common.Hash]common.Hash)
+	for key, value := range so.dirtyStorage {
+		if value != so.GetCommittedState(s.db, key) {
+			modified[key] = value
+		}
+	}
+	return modified
+}
+
 /*
  * SETTERS
  */
@@ -615,6 +640,11 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 // flag set. This is needed by the state journal to revert to the correct s-
 // destructed object instead of wiping all knowledge about the state object.
 func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
+	// Quorum
+	if s.accessed != nil {
+		s.accessed[addr] = struct{}{}
+	}
+	// End Quorum
 	// Prefer live objects if any is available
 	if obj := s.stateObjects[addr]; obj != nil {
 		return obj
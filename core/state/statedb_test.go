@@ -986,3 +986,26 @@ func TestPrivacyMetadataChangesAreRolledBackOnRevert(t *testing.T) {
 }
 
 // End Quorum - Privacy Enhancements
+
+func TestModifiedStorage(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	addr := common.Address{1}
+	state.SetState(addr, common.Hash{1}, common.Hash{1})
+	state.SetState(addr, common.Hash{2}, common.Hash{2})
+	root, _ := state.Commit(false)
+	state, _ = New(root, state.db, nil)
+
+	state.SetState(addr, common.Hash{1}, common.Hash{3})
+	state.SetState(addr, common.Hash{2}, common.Hash{2})
+	snapshot := state.Snapshot()
+	state.SetState(addr, common.Hash{4}, common.Hash{4})
+	state.RevertToSnapshot(snapshot)
+
+	modified := state.ModifiedStorage(addr)
+	if len(modified) != 1 || modified[common.Hash{1}] != (common.Hash{3}) {
+		t.Errorf("modified storage mismatch: have %v, want only slot 1", modified)
+	}
+	if modified := state.ModifiedStorage(common.Address{2}); len(modified) != 0 {
+		t.Errorf("modified storage of unknown account: %v", modified)
+	}
+}
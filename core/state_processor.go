@@ -17,6 +17,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
@@ -69,14 +71,34 @@ func (p *StateProcessor) Process(block *types.Block, statedb, privateState *stat
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
+	// Quorum - execute the private transactions with execution hints concurrently
+	var initialPrivateState *state.StateDB
+	specs := p.speculatePrivate(block, statedb, privateState, cfg)
+	if specs != nil {
+		initialPrivateState = privateState.Copy()
+	}
+	// /Quorum
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions() {
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
 		privateState.Prepare(tx.Hash(), block.Hash(), i)
 
-		receipt, privateReceipt, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, privateState, header, tx, usedGas, cfg)
-		if err != nil {
-			return nil, nil, nil, 0, err
+		var (
+			receipt, privateReceipt *types.Receipt
+			applied                 bool
+			err                     error
+			txStart                 = time.Now() // Quorum
+		)
+		// Quorum
+		if specs != nil && specs[i] != nil {
+			receipt, privateReceipt, applied = p.applySpeculation(specs[i], tx, header, initialPrivateState, statedb, privateState, gp, usedGas)
+		}
+		// /Quorum
+		if !applied {
+			receipt, privateReceipt, err = ApplyTransaction(p.config, p.bc, nil, gp, statedb, privateState, header, tx, usedGas, cfg)
+			if err != nil {
+				return nil, nil, nil, 0, err
+			}
 		}
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
@@ -87,6 +109,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb, privateState *stat
 			privateReceipts = append(privateReceipts, privateReceipt)
 			allLogs = append(allLogs, privateReceipt.Logs...)
 			p.bc.CheckAndSetPrivateState(privateReceipt.Logs, privateState)
+			statedb.PrivateExecutions += time.Since(txStart) // Quorum
 		}
 	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
@@ -17,10 +17,12 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"math"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -269,7 +271,11 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		isPrivate = true
 		pmh.snapshot = snapshot
 		pmh.eph = common.BytesToEncryptedPayloadHash(st.data)
-		_, managedPartiesInTx, data, pmh.receivedPrivacyMetadata, err = private.P.Receive(pmh.eph)
+		fetchStart := time.Now()
+		_, managedPartiesInTx, data, pmh.receivedPrivacyMetadata, err = private.P.Receive(context.Background(), pmh.eph)
+		if statedb, ok := publicState.(*state.StateDB); ok {
+			statedb.PrivatePayloadFetches += time.Since(fetchStart)
+		}
 		// Increment the public account nonce if:
 		// 1. Tx is private and *not* a participant of the group and either call or create
 		// 2. Tx is private we are part of the group and is a call
@@ -277,6 +283,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 			publicState.SetNonce(sender.Address(), publicState.GetNonce(sender.Address())+1)
 		}
 		if err != nil {
+			st.logger().Debug("Failed to retrieve the private payload", "hash", pmh.eph, "err", err)
 			return &ExecutionResult{
 				UsedGas:    0,
 				Err:        nil,
@@ -353,7 +360,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		ret, leftoverGas, vmerr = evm.Call(sender, to, data, st.gas, st.value)
 	}
 	if vmerr != nil {
-		log.Info("VM returned with error", "err", vmerr)
+		st.logger().Info("VM returned with error", "err", vmerr)
 		// The only possible consensus-error would be if there wasn't
 		// sufficient balance to make the transfer happen. The first
 		// balance transfer may never fail.
@@ -466,6 +473,16 @@ func (st *StateTransition) refundGas() {
 	st.gp.AddGas(st.gas)
 }
 
+// Quorum
+// logger returns the logger of the transition, logging the ID of the RPC
+// request it serves if any.
+func (st *StateTransition) logger() log.Logger {
+	if id := st.evm.Context.RequestID; id != "" {
+		return log.New("requestid", id)
+	}
+	return log.Root()
+}
+
 // gasUsed returns the amount of gas used up by the state transition.
 func (st *StateTransition) gasUsed() uint64 {
 	return st.initialGas - st.gas
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"os"
@@ -1093,7 +1094,7 @@ func (mpm *mockPrivateTransactionManager) HasFeature(f engine.PrivateTransaction
 	return true
 }
 
-func (mpm *mockPrivateTransactionManager) Receive(data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+func (mpm *mockPrivateTransactionManager) Receive(ctx context.Context, data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
 	mpm.count["Receive"]++
 	values := mpm.returns["Receive"]
 	var (
@@ -1340,7 +1341,7 @@ type StubPrivateTransactionManager struct {
 	responses map[string][]interface{}
 }
 
-func (spm *StubPrivateTransactionManager) Receive(data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+func (spm *StubPrivateTransactionManager) Receive(ctx context.Context, data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
 	res := spm.responses["Receive"]
 	if err, ok := res[1].(error); ok {
 		return "", nil, nil, nil, err
@@ -1353,8 +1354,8 @@ func (spm *StubPrivateTransactionManager) Receive(data common.EncryptedPayloadHa
 	return "", nil, nil, nil, nil
 }
 
-func (spm *StubPrivateTransactionManager) ReceiveRaw(hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
-	_, sender, data, metadata, err := spm.Receive(hash)
+func (spm *StubPrivateTransactionManager) ReceiveRaw(ctx context.Context, hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+	_, sender, data, metadata, err := spm.Receive(ctx, hash)
 	return data, sender[0], metadata, err
 }
 
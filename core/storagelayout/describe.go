@@ -0,0 +1,288 @@
+package storagelayout
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// maxValueSlots bounds the slots of the mapping values searched for their
+// preimage, the slots past it being kept raw.
+const maxValueSlots = 64
+
+// Dump is the decoded storage of a contract.
+type Dump struct {
+	Contract    common.Address              `json:"contract"`
+	BlockNumber uint64                      `json:"blockNumber"`
+	BlockHash   common.Hash                 `json:"blockHash"`
+	Decoded     bool                        `json:"decoded"` // whether the layout of the contract is registered
+	Variables   []*Value                    `json:"variables"`
+	Slots       map[common.Hash]common.Hash `json:"slots,omitempty"` // slots not decoded
+}
+
+// Value is the value of a variable, or of an element of one, e.g. the name
+// balances[0x1932c48b2bf8102ba33b4a6b545c32236e342f34].
+type Value struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// entry is an entry of a mapping, found with the preimage of its slot.
+type entry struct {
+	key  []byte
+	slot *big.Int
+}
+
+type decoder struct {
+	layout  *Layout
+	slots   map[common.Hash]common.Hash
+	present []*big.Int // sorted
+	entries map[common.Hash][]entry
+	used    map[common.Hash]struct{}
+	values  []*Value
+}
+
+// Describe decodes the slots of the storage of a contract with its layout,
+// the preimage function returning the preimage of a SHA3, nil if unknown.
+// Without layout, all the slots are kept raw.
+func Describe(layout *Layout, slots map[common.Hash]common.Hash, preimage func(common.Hash) []byte) *Dump {
+	dump := &Dump{Decoded: layout != nil, Variables: make([]*Value, 0)}
+	if layout == nil {
+		if len(slots) > 0 {
+			dump.Slots = slots
+		}
+		return dump
+	}
+	d := &decoder{
+		layout:  layout,
+		slots:   slots,
+		present: make([]*big.Int, 0, len(slots)),
+		entries: make(map[common.Hash][]entry),
+		used:    make(map[common.Hash]struct{}),
+	}
+	for slot := range slots {
+		d.present = append(d.present, slot.Big())
+	}
+	sort.Slice(d.present, func(i, j int) bool { return d.present[i].Cmp(d.present[j]) < 0 })
+	d.findEntries(preimage)
+
+	for _, v := range layout.Storage {
+		slot, _ := new(big.Int).SetString(v.Slot, 10)
+		d.decode(v.Label, v.Type, slot, v.Offset)
+	}
+	dump.Variables = d.values
+	for slot, value := range slots {
+		if _, ok := d.used[slot]; !ok {
+			if dump.Slots == nil {
+				dump.Slots = make(map[common.Hash]common.Hash)
+			}
+			dump.Slots[slot] = value
+		}
+	}
+	return dump
+}
+
+// findEntries finds the entries of the mappings from the preimages of the
+// slots present, and of the slots preceding them within the values.
+func (d *decoder) findEntries(preimage func(common.Hash) []byte) {
+	if preimage == nil {
+		return
+	}
+	span := int64(1)
+	for _, t := range d.layout.Types {
+		if t.Encoding == "mapping" {
+			if value := d.layout.Types[t.Value]; value.Encoding == "inplace" {
+				if slots := int64((value.size() + 31) / 32); slots > span {
+					span = slots
+				}
+			}
+		}
+	}
+	if span > maxValueSlots {
+		span = maxValueSlots
+	}
+	seen := make(map[common.Hash]struct{})
+	for _, slot := range d.present {
+		for j := int64(0); j < span && slot.Cmp(big.NewInt(j)) >= 0; j++ {
+			candidate := common.BigToHash(new(big.Int).Sub(slot, big.NewInt(j)))
+			if _, ok := seen[candidate]; ok {
+				continue
+			}
+			seen[candidate] = struct{}{}
+			pre := preimage(candidate)
+			if len(pre) < common.HashLength {
+				continue
+			}
+			mapping := common.BytesToHash(pre[len(pre)-common.HashLength:])
+			d.entries[mapping] = append(d.entries[mapping], entry{key: pre[:len(pre)-common.HashLength], slot: candidate.Big()})
+		}
+	}
+	for _, entries := range d.entries {
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+	}
+}
+
+// read returns the content of the slot, marking it decoded.
+func (d *decoder) read(slot *big.Int) common.Hash {
+	key := common.BigToHash(slot)
+	d.used[key] = struct{}{}
+	return d.slots[key]
+}
+
+func (d *decoder) decode(name, id string, slot *big.Int, offset uint) {
+	t := d.layout.Types[id]
+	switch t.Encoding {
+	case "inplace":
+		switch {
+		case len(t.Members) > 0:
+			for _, m := range t.Members {
+				memberSlot, _ := new(big.Int).SetString(m.Slot, 10)
+				d.decode(name+"."+m.Label, m.Type, memberSlot.Add(memberSlot, slot), m.Offset)
+			}
+		case t.Base != "":
+			length, _ := staticArrayLength(t.Label)
+			d.decodeArray(name, t.Base, slot, new(big.Int).SetUint64(length))
+		default:
+			size := t.size()
+			if uint64(offset)+size > common.HashLength {
+				return // kept raw
+			}
+			word := d.read(slot)
+			value := word[common.HashLength-uint64(offset)-size : common.HashLength-uint64(offset)]
+			d.values = append(d.values, &Value{Name: name, Type: t.Label, Value: formatValue(t.Label, value)})
+		}
+	case "mapping":
+		key := d.layout.Types[t.Key]
+		for _, e := range d.entries[common.BigToHash(slot)] {
+			d.decode(name+"["+formatKey(key, e.key)+"]", t.Value, e.slot, 0)
+		}
+	case "dynamic_array":
+		length := d.read(slot).Big()
+		d.decodeArray(name, t.Base, crypto.Keccak256Hash(common.BigToHash(slot).Bytes()).Big(), length)
+	case "bytes":
+		word := d.read(slot)
+		var data []byte
+		if word[common.HashLength-1]&1 == 0 {
+			// short, stored in place with twice its length
+			length := word[common.HashLength-1] / 2
+			if length >= common.HashLength {
+				return
+			}
+			data = word[:length]
+		} else {
+			length := new(big.Int).Rsh(word.Big(), 1)
+			// only decoded when all the slots of the content are present
+			slots := new(big.Int).Div(new(big.Int).Add(length, big.NewInt(31)), big.NewInt(32))
+			if slots.Cmp(big.NewInt(int64(len(d.present)))) > 0 {
+				return
+			}
+			start := crypto.Keccak256Hash(common.BigToHash(slot).Bytes()).Big()
+			for i := int64(0); i < slots.Int64(); i++ {
+				content := d.read(new(big.Int).Add(start, big.NewInt(i)))
+				data = append(data, content[:]...)
+			}
+			data = data[:length.Int64()]
+		}
+		value := hexutil.Encode(data)
+		if t.Label == "string" {
+			value = strconv.Quote(string(data))
+		}
+		d.values = append(d.values, &Value{Name: name, Type: t.Label, Value: value})
+	}
+}
+
+// decodeArray decodes the elements of an array stored from the start slot
+// which occupy present slots, the others being zero.
+func (d *decoder) decodeArray(name, base string, start, length *big.Int) {
+	if length.Sign() == 0 {
+		return
+	}
+	size := d.layout.Types[base].size()
+	var perSlot, slotsPer uint64 = 1, 1
+	if size < common.HashLength {
+		perSlot = common.HashLength / size
+	} else {
+		slotsPer = (size + 31) / 32
+	}
+	// the slots of the elements are [start, end)
+	end := new(big.Int).Add(length, new(big.Int).SetUint64(perSlot-1))
+	end.Div(end, new(big.Int).SetUint64(perSlot))
+	end.Mul(end, new(big.Int).SetUint64(slotsPer))
+	end.Add(end, start)
+
+	first := sort.Search(len(d.present), func(i int) bool { return d.present[i].Cmp(start) >= 0 })
+	last := new(big.Int).SetInt64(-1)
+	for _, slot := range d.present[first:] {
+		if slot.Cmp(end) >= 0 {
+			break
+		}
+		relative := new(big.Int).Sub(slot, start)
+		if perSlot > 1 {
+			for k := uint64(0); k < perSlot; k++ {
+				index := new(big.Int).Mul(relative, new(big.Int).SetUint64(perSlot))
+				index.Add(index, new(big.Int).SetUint64(k))
+				if index.Cmp(length) >= 0 {
+					break
+				}
+				d.decode(name+"["+index.String()+"]", base, slot, uint(k*size))
+			}
+			continue
+		}
+		index := relative.Div(relative, new(big.Int).SetUint64(slotsPer))
+		if index.Cmp(last) == 0 {
+			continue
+		}
+		last = index
+		elementSlot := new(big.Int).Mul(index, new(big.Int).SetUint64(slotsPer))
+		d.decode(name+"["+index.String()+"]", base, elementSlot.Add(elementSlot, start), 0)
+	}
+}
+
+// formatValue formats the bytes of a value type by its label.
+func formatValue(label string, value []byte) string {
+	switch {
+	case strings.HasPrefix(label, "uint"), strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(value).String()
+	case strings.HasPrefix(label, "int"):
+		n := new(big.Int).SetBytes(value)
+		if len(value) > 0 && value[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(value)*8)))
+		}
+		return n.String()
+	case label == "bool":
+		return strconv.FormatBool(new(big.Int).SetBytes(value).Sign() != 0)
+	case strings.HasPrefix(label, "address"), strings.HasPrefix(label, "contract "):
+		return common.BytesToAddress(value).Hex()
+	}
+	return hexutil.Encode(value)
+}
+
+// formatKey formats the key of a mapping, the value types being padded to 32
+// bytes.
+func formatKey(t *Type, key []byte) string {
+	switch t.Encoding {
+	case "bytes":
+		if t.Label == "string" {
+			return strconv.Quote(string(key))
+		}
+		return hexutil.Encode(key)
+	case "inplace":
+		size := t.size()
+		if uint64(len(key)) != common.HashLength || size > common.HashLength {
+			break
+		}
+		if strings.HasPrefix(t.Label, "bytes") {
+			// left aligned
+			return formatValue(t.Label, key[:size])
+		}
+		return formatValue(t.Label, key[common.HashLength-size:])
+	}
+	return hexutil.Encode(key)
+}
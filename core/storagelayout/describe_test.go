@@ -0,0 +1,204 @@
+package storagelayout
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	contract = common.HexToAddress("0x1932c48b2bf8102ba33b4a6b545c32236e342f34")
+	holder   = common.HexToAddress("0xed9d02e382b34818e88b88a309c7fe71e65f419d")
+)
+
+// testLayouts registers the layout of
+//
+//	contract Trade {
+//	    uint256 total;
+//	    uint8 state; bool open; address owner;
+//	    mapping(address => uint256) balances;
+//	    mapping(uint256 => Order) orders; // struct Order { uint256 amount; int16 delta; }
+//	    uint64[] fills;
+//	    string name;
+//	    uint128[3] limits;
+//	}
+const testLayouts = `{
+  "contracts": [{
+    "address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34",
+    "layout": {
+      "storage": [
+        {"astId": 1, "contract": "Trade.sol:Trade", "label": "total", "offset": 0, "slot": "0", "type": "t_uint256"},
+        {"astId": 2, "contract": "Trade.sol:Trade", "label": "state", "offset": 0, "slot": "1", "type": "t_uint8"},
+        {"astId": 3, "contract": "Trade.sol:Trade", "label": "open", "offset": 1, "slot": "1", "type": "t_bool"},
+        {"astId": 4, "contract": "Trade.sol:Trade", "label": "owner", "offset": 2, "slot": "1", "type": "t_address"},
+        {"astId": 5, "contract": "Trade.sol:Trade", "label": "balances", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_uint256)"},
+        {"astId": 6, "contract": "Trade.sol:Trade", "label": "orders", "offset": 0, "slot": "3", "type": "t_mapping(t_uint256,t_struct(Order)10_storage)"},
+        {"astId": 7, "contract": "Trade.sol:Trade", "label": "fills", "offset": 0, "slot": "4", "type": "t_array(t_uint64)dyn_storage"},
+        {"astId": 8, "contract": "Trade.sol:Trade", "label": "name", "offset": 0, "slot": "5", "type": "t_string_storage"},
+        {"astId": 9, "contract": "Trade.sol:Trade", "label": "limits", "offset": 0, "slot": "6", "type": "t_array(t_uint128)3_storage"}
+      ],
+      "types": {
+        "t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+        "t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+        "t_int16": {"encoding": "inplace", "label": "int16", "numberOfBytes": "2"},
+        "t_uint8": {"encoding": "inplace", "label": "uint8", "numberOfBytes": "1"},
+        "t_uint64": {"encoding": "inplace", "label": "uint64", "numberOfBytes": "8"},
+        "t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+        "t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+        "t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+        "t_array(t_uint64)dyn_storage": {"encoding": "dynamic_array", "label": "uint64[]", "numberOfBytes": "32", "base": "t_uint64"},
+        "t_array(t_uint128)3_storage": {"encoding": "inplace", "label": "uint128[3]", "numberOfBytes": "64", "base": "t_uint128"},
+        "t_mapping(t_address,t_uint256)": {"encoding": "mapping", "label": "mapping(address => uint256)", "numberOfBytes": "32", "key": "t_address", "value": "t_uint256"},
+        "t_mapping(t_uint256,t_struct(Order)10_storage)": {"encoding": "mapping", "label": "mapping(uint256 => struct Trade.Order)", "numberOfBytes": "32", "key": "t_uint256", "value": "t_struct(Order)10_storage"},
+        "t_struct(Order)10_storage": {"encoding": "inplace", "label": "struct Trade.Order", "numberOfBytes": "64", "members": [
+          {"astId": 11, "contract": "Trade.sol:Trade", "label": "amount", "offset": 0, "slot": "0", "type": "t_uint256"},
+          {"astId": 12, "contract": "Trade.sol:Trade", "label": "delta", "offset": 0, "slot": "1", "type": "t_int16"}
+        ]}
+      }
+    }
+  }]
+}`
+
+// testStorage is the storage of a Trade contract, with the preimages of the
+// slots of the mappings.
+type testStorage struct {
+	slots     map[common.Hash]common.Hash
+	preimages map[common.Hash][]byte
+}
+
+func u(n int64) common.Hash {
+	return common.BigToHash(big.NewInt(n))
+}
+
+func (s *testStorage) mappingSlot(key common.Hash, slot int64) common.Hash {
+	preimage := append(key.Bytes(), u(slot).Bytes()...)
+	hash := crypto.Keccak256Hash(preimage)
+	s.preimages[hash] = preimage
+	return hash
+}
+
+func offsetSlot(slot common.Hash, n int64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(slot.Big(), big.NewInt(n)))
+}
+
+func newTestStorage(balance int64, name string) *testStorage {
+	s := &testStorage{slots: make(map[common.Hash]common.Hash), preimages: make(map[common.Hash][]byte)}
+	s.slots[u(0)] = u(1000)
+	// state 2, open, owner
+	var packed common.Hash
+	packed[31] = 2
+	packed[30] = 1
+	copy(packed[10:30], holder.Bytes())
+	s.slots[u(1)] = packed
+	s.slots[s.mappingSlot(common.BytesToHash(holder.Bytes()), 2)] = u(balance)
+	// orders[7] = Order{amount: 0, delta: -2}, the amount being unset
+	order := s.mappingSlot(u(7), 3)
+	s.slots[offsetSlot(order, 1)] = common.BytesToHash([]byte{0xff, 0xfe})
+	// fills = [5, 0, 0, 0, 0, 9]
+	s.slots[u(4)] = u(6)
+	fills := crypto.Keccak256Hash(u(4).Bytes())
+	s.slots[fills] = u(5)
+	var second common.Hash
+	second[23] = 9 // fills[5], second of the slot
+	s.slots[offsetSlot(fills, 1)] = second
+	// short string
+	var short common.Hash
+	copy(short[:], name)
+	short[31] = byte(len(name) * 2)
+	s.slots[u(5)] = short
+	// limits[2]
+	s.slots[u(7)] = u(3)
+	return s
+}
+
+func (s *testStorage) preimage(hash common.Hash) []byte {
+	return s.preimages[hash]
+}
+
+func loadTestLayouts(t *testing.T) *Registry {
+	path := filepath.Join(t.TempDir(), "layouts.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testLayouts), 0600))
+	registry, err := Load(path)
+	require.NoError(t, err)
+	return registry
+}
+
+func values(dump *Dump) map[string]string {
+	values := make(map[string]string, len(dump.Variables))
+	for _, v := range dump.Variables {
+		values[v.Name] = v.Value
+	}
+	return values
+}
+
+func TestDescribe(t *testing.T) {
+	storage := newTestStorage(42, "desk")
+	// an undecodable slot
+	storage.slots[u(100)] = u(1)
+	dump := Describe(loadTestLayouts(t).Layout(contract), storage.slots, storage.preimage)
+
+	assert.True(t, dump.Decoded)
+	assert.Equal(t, map[string]string{
+		"total":                          "1000",
+		"state":                          "2",
+		"open":                           "true",
+		"owner":                          holder.Hex(),
+		"balances[" + holder.Hex() + "]": "42",
+		"orders[7].amount":               "0",
+		"orders[7].delta":                "-2",
+		"fills[0]":                       "5",
+		"fills[1]":                       "0",
+		"fills[2]":                       "0",
+		"fills[3]":                       "0",
+		"fills[4]":                       "0",
+		"fills[5]":                       "9",
+		"name":                           `"desk"`,
+		"limits[2]":                      "3",
+	}, values(dump))
+	assert.Equal(t, map[common.Hash]common.Hash{u(100): u(1)}, dump.Slots)
+}
+
+func TestDescribe_LongString(t *testing.T) {
+	storage := newTestStorage(42, "")
+	name := []byte("a name longer than thirty-one bytes")
+	storage.slots[u(5)] = u(int64(len(name)*2 + 1))
+	start := crypto.Keccak256Hash(u(5).Bytes())
+	storage.slots[start] = common.BytesToHash(name[:32])
+	storage.slots[offsetSlot(start, 1)] = common.BytesToHash(common.RightPadBytes(name[32:], 32))
+
+	dump := Describe(loadTestLayouts(t).Layout(contract), storage.slots, storage.preimage)
+	assert.Equal(t, `"`+string(name)+`"`, values(dump)["name"])
+	assert.Empty(t, dump.Slots)
+}
+
+func TestDescribe_Fallbacks(t *testing.T) {
+	storage := newTestStorage(42, "desk")
+	// without the preimages, the mappings are kept raw
+	dump := Describe(loadTestLayouts(t).Layout(contract), storage.slots, nil)
+	assert.NotContains(t, values(dump), "balances["+holder.Hex()+"]")
+	assert.Len(t, dump.Slots, 2)
+
+	// without layout, all the slots are
+	dump = Describe(nil, storage.slots, storage.preimage)
+	assert.False(t, dump.Decoded)
+	assert.Empty(t, dump.Variables)
+	assert.Equal(t, storage.slots, dump.Slots)
+	assert.Nil(t, loadTestLayouts(t).Layout(holder))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, layouts := range []string{
+		`{"contracts": [{"address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34"}]}`,
+		`{"contracts": [{"address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34", "layout": {"storage": [{"label": "a", "slot": "0", "type": "t_undefined"}]}}]}`,
+		`{"contracts": [{"address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34", "layout": {"storage": [{"label": "a", "slot": "0", "type": "t_a"}], "types": {"t_a": {"encoding": "mapping", "label": "a", "numberOfBytes": "32", "key": "t_a", "value": "t_a"}}}}]}`,
+	} {
+		_, err := parse([]byte(layouts))
+		assert.Error(t, err, layouts)
+	}
+}
@@ -0,0 +1,100 @@
+package storagelayout
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Difference is a variable, or a raw slot, whose value differs between two
+// dumps, empty if absent from one of them.
+type Difference struct {
+	Name  string `json:"name"`
+	Type  string `json:"type,omitempty"`
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+func (d *Difference) String() string {
+	left, right := d.Left, d.Right
+	if left == "" {
+		left = "<unset>"
+	}
+	if right == "" {
+		right = "<unset>"
+	}
+	if d.Type == "" {
+		return fmt.Sprintf("%s: %s != %s", d.Name, left, right)
+	}
+	return fmt.Sprintf("%s (%s): %s != %s", d.Name, d.Type, left, right)
+}
+
+// Compare returns the variables and raw slots differing between the dumps,
+// sorted by name. The raw slots are named by their hex.
+func Compare(left, right *Dump) []*Difference {
+	type pair struct {
+		typ         string
+		left, right string
+	}
+	values := make(map[string]*pair)
+	add := func(name, typ, value string, isLeft bool) {
+		p, ok := values[name]
+		if !ok {
+			p = &pair{typ: typ}
+			values[name] = p
+		}
+		if isLeft {
+			p.left = value
+		} else {
+			p.right = value
+		}
+	}
+	for _, dump := range []struct {
+		*Dump
+		left bool
+	}{{left, true}, {right, false}} {
+		for _, v := range dump.Variables {
+			add(v.Name, v.Type, v.Value, dump.left)
+		}
+		for slot, value := range dump.Slots {
+			if value != (common.Hash{}) {
+				add(slot.Hex(), "", value.Hex(), dump.left)
+			}
+		}
+	}
+	var diffs []*Difference
+	for name, p := range values {
+		if p.left != p.right {
+			diffs = append(diffs, &Difference{Name: name, Type: p.typ, Left: p.left, Right: p.right})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// WriteDiff writes the differences between the dumps, one per line, with a
+// header naming the dumps.
+func WriteDiff(w io.Writer, left, right *Dump) error {
+	if _, err := fmt.Fprintf(w, "contract %s: left at block #%d (%s), right at block #%d (%s)\n",
+		left.Contract.Hex(), left.BlockNumber, left.BlockHash.TerminalString(), right.BlockNumber, right.BlockHash.TerminalString()); err != nil {
+		return err
+	}
+	if left.Decoded != right.Decoded {
+		if _, err := fmt.Fprintln(w, "warning: the layout is registered for only one of the dumps"); err != nil {
+			return err
+		}
+	}
+	diffs := Compare(left, right)
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+	for _, diff := range diffs {
+		if _, err := fmt.Fprintln(w, diff.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package storagelayout
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTrip returns the dump as read back from its JSON, like the dumps
+// returned by the nodes.
+func roundTrip(t *testing.T, dump *Dump) *Dump {
+	enc, err := json.Marshal(dump)
+	require.NoError(t, err)
+	var decoded Dump
+	require.NoError(t, json.Unmarshal(enc, &decoded))
+	return &decoded
+}
+
+func TestCompare(t *testing.T) {
+	layout := loadTestLayouts(t).Layout(contract)
+	leftStorage, rightStorage := newTestStorage(42, "desk"), newTestStorage(40, "desk")
+	rightStorage.slots[u(100)] = u(1)
+	left := roundTrip(t, Describe(layout, leftStorage.slots, leftStorage.preimage))
+	right := roundTrip(t, Describe(layout, rightStorage.slots, rightStorage.preimage))
+
+	diffs := Compare(left, right)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, &Difference{Name: u(100).Hex(), Left: "", Right: u(1).Hex()}, diffs[0])
+	assert.Equal(t, &Difference{Name: "balances[" + holder.Hex() + "]", Type: "uint256", Left: "42", Right: "40"}, diffs[1])
+	assert.Equal(t, "balances["+holder.Hex()+"] (uint256): 42 != 40", diffs[1].String())
+	assert.Empty(t, Compare(left, left))
+
+	var out bytes.Buffer
+	left.Contract, right.Contract = contract, contract
+	require.NoError(t, WriteDiff(&out, left, right))
+	assert.Contains(t, out.String(), "contract "+contract.Hex())
+	assert.Contains(t, out.String(), u(100).Hex()+": <unset> != "+u(1).Hex())
+
+	// raw against decoded
+	out.Reset()
+	require.NoError(t, WriteDiff(&out, left, roundTrip(t, Describe(nil, rightStorage.slots, nil))))
+	assert.Contains(t, out.String(), "warning: the layout is registered for only one of the dumps")
+	out.Reset()
+	require.NoError(t, WriteDiff(&out, left, left))
+	assert.Contains(t, out.String(), "no differences")
+	assert.Empty(t, Compare(&Dump{Slots: map[common.Hash]common.Hash{u(1): {}}}, &Dump{}))
+}
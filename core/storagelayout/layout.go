@@ -0,0 +1,201 @@
+// Package storagelayout decodes the storage of contracts with the storage
+// layouts output by solc (--storage-layout), and compares the decoded states,
+// so that the states of a private contract held by two party nodes can be
+// compared variable by variable when they disagree.
+//
+// The entries of the mappings are found with the preimages of the SHA3 of
+// their slots, recorded by the nodes running with --vmdebug. The slots which
+// cannot be decoded are kept raw.
+package storagelayout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Layout is the storage layout of a contract output by solc.
+type Layout struct {
+	Storage []Variable       `json:"storage"`
+	Types   map[string]*Type `json:"types"`
+}
+
+// Variable is a state variable, or a member of a struct.
+type Variable struct {
+	AstID    int64  `json:"astId,omitempty"`
+	Contract string `json:"contract,omitempty"`
+	Label    string `json:"label"`
+	Offset   uint   `json:"offset"`
+	Slot     string `json:"slot"` // decimal
+	Type     string `json:"type"`
+}
+
+// Type is a type of the layout, e.g. t_uint256.
+type Type struct {
+	Encoding      string     `json:"encoding"` // inplace, mapping, dynamic_array or bytes
+	Label         string     `json:"label"`
+	NumberOfBytes string     `json:"numberOfBytes"` // decimal
+	Key           string     `json:"key,omitempty"`
+	Value         string     `json:"value,omitempty"`
+	Base          string     `json:"base,omitempty"`
+	Members       []Variable `json:"members,omitempty"`
+}
+
+// Config is the file of the layouts of the registered contracts, e.g.
+//
+//	{
+//	  "contracts": [{
+//	    "address": "0x1932c48b2bf8102ba33b4a6b545c32236e342f34",
+//	    "layout": {"storage": [...], "types": {...}}
+//	  }]
+//	}
+//
+// where the layout is the storageLayout output by solc.
+type Config struct {
+	Contracts []ContractConfig `json:"contracts"`
+}
+
+// ContractConfig registers the storage layout of a contract.
+type ContractConfig struct {
+	Address common.Address `json:"address"`
+	Layout  *Layout        `json:"layout"`
+}
+
+// Registry holds the storage layouts of the registered contracts.
+type Registry struct {
+	layouts map[common.Address]*Layout
+}
+
+// Load reads the layouts of the file.
+func Load(path string) (*Registry, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parse(blob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage layouts %s: %v", path, err)
+	}
+	return r, nil
+}
+
+func parse(blob []byte) (*Registry, error) {
+	var config Config
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, err
+	}
+	r := &Registry{layouts: make(map[common.Address]*Layout, len(config.Contracts))}
+	for _, contract := range config.Contracts {
+		if _, ok := r.layouts[contract.Address]; ok {
+			return nil, fmt.Errorf("contract %s is listed twice", contract.Address.Hex())
+		}
+		if contract.Layout == nil {
+			return nil, fmt.Errorf("contract %s: missing layout", contract.Address.Hex())
+		}
+		if err := contract.Layout.validate(); err != nil {
+			return nil, fmt.Errorf("contract %s: %v", contract.Address.Hex(), err)
+		}
+		r.layouts[contract.Address] = contract.Layout
+	}
+	return r, nil
+}
+
+// Layout returns the layout of the contract, nil if not registered.
+func (r *Registry) Layout(contract common.Address) *Layout {
+	if r == nil {
+		return nil
+	}
+	return r.layouts[contract]
+}
+
+// validate checks the types of the variables are defined, so that decoding
+// never fails.
+func (l *Layout) validate() error {
+	for _, v := range l.Storage {
+		if err := l.validateVariable(v, 0); err != nil {
+			return fmt.Errorf("variable %s: %v", v.Label, err)
+		}
+	}
+	return nil
+}
+
+// maxTypeDepth bounds the nesting of the types, recursive types being invalid.
+const maxTypeDepth = 32
+
+func (l *Layout) validateVariable(v Variable, depth int) error {
+	if _, err := strconv.ParseUint(v.Slot, 10, 64); err != nil {
+		return fmt.Errorf("invalid slot %q", v.Slot)
+	}
+	if v.Offset >= 32 {
+		return fmt.Errorf("invalid offset %d", v.Offset)
+	}
+	return l.validateType(v.Type, depth)
+}
+
+func (l *Layout) validateType(id string, depth int) error {
+	if depth > maxTypeDepth {
+		return fmt.Errorf("type %s nested too deeply", id)
+	}
+	t, ok := l.Types[id]
+	if !ok {
+		return fmt.Errorf("undefined type %s", id)
+	}
+	if size, err := strconv.ParseUint(t.NumberOfBytes, 10, 64); err != nil || size == 0 {
+		return fmt.Errorf("type %s: invalid size %q", id, t.NumberOfBytes)
+	}
+	switch t.Encoding {
+	case "inplace":
+		if t.Base != "" {
+			if _, err := staticArrayLength(t.Label); err != nil {
+				return fmt.Errorf("type %s: %v", id, err)
+			}
+			return l.validateType(t.Base, depth+1)
+		}
+		for _, m := range t.Members {
+			if err := l.validateVariable(m, depth+1); err != nil {
+				return fmt.Errorf("member %s: %v", m.Label, err)
+			}
+		}
+	case "mapping":
+		if err := l.validateType(t.Key, depth+1); err != nil {
+			return err
+		}
+		return l.validateType(t.Value, depth+1)
+	case "dynamic_array":
+		return l.validateType(t.Base, depth+1)
+	case "bytes":
+	default:
+		return fmt.Errorf("type %s: unknown encoding %q", id, t.Encoding)
+	}
+	return nil
+}
+
+// size returns the number of bytes of the type.
+func (t *Type) size() uint64 {
+	size, _ := strconv.ParseUint(t.NumberOfBytes, 10, 64)
+	return size
+}
+
+// staticArrayLength returns the length of a static array from its label, e.g.
+// 3 for uint256[3].
+func staticArrayLength(label string) (uint64, error) {
+	if len(label) < 3 || label[len(label)-1] != ']' {
+		return 0, fmt.Errorf("invalid static array %q", label)
+	}
+	open := strings.LastIndexByte(label, '[')
+	if open < 0 {
+		return 0, fmt.Errorf("invalid static array %q", label)
+	}
+	length, err := strconv.ParseUint(label[open+1:len(label)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid static array %q", label)
+	}
+	return length, nil
+}
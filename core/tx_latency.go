@@ -0,0 +1,293 @@
+package core
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// The latency tracker follows the transactions submitted to this node through
+// the RPC APIs from their submission to the import of the block including them,
+// so the time to a receipt can be measured and its regressions localized.
+
+const (
+	// maxTrackedTxs is the number of transactions followed until imported,
+	// the oldest ones being dropped beyond it.
+	maxTrackedTxs = 4096
+	// maxLatencySamples is the number of the last imported transactions whose
+	// latencies are kept for the reports.
+	maxLatencySamples = 4096
+	// maxTrackedImportDepth is the number of blocks imported at once looked
+	// up for tracked transactions.
+	maxTrackedImportDepth = 64
+)
+
+// Segments of the latency of a transaction.
+const (
+	SegmentDistribution = "distribution" // submission to the private transaction manager done, private transactions only
+	SegmentAdmission    = "admission"    // submission, or distribution, to the admission in the pool
+	SegmentProposal     = "proposal"     // admission to the first block proposed by this node including it
+	SegmentImport       = "import"       // proposal to the import of the block including it
+	SegmentInclusion    = "inclusion"    // admission to the import of the block including it
+	SegmentTotal        = "total"        // submission to the import of the block including it
+)
+
+var latencySegments = []string{SegmentDistribution, SegmentAdmission, SegmentProposal, SegmentImport, SegmentInclusion, SegmentTotal}
+
+// TxSubmission records the time a transaction was submitted to this node and
+// the time its private payload was distributed, if private.
+type TxSubmission struct {
+	Submitted   time.Time
+	Distributed time.Time
+}
+
+type txSubmissionKey struct{}
+
+// WithTxSubmission returns a context carrying the submission of the transaction
+// of a request, starting it now unless the context already carries one.
+func WithTxSubmission(ctx context.Context) (context.Context, *TxSubmission) {
+	if sub := TxSubmissionFromContext(ctx); sub != nil {
+		return ctx, sub
+	}
+	sub := &TxSubmission{Submitted: time.Now()}
+	return context.WithValue(ctx, txSubmissionKey{}, sub), sub
+}
+
+// TxSubmissionFromContext returns the submission carried by the context, nil
+// if none.
+func TxSubmissionFromContext(ctx context.Context) *TxSubmission {
+	sub, _ := ctx.Value(txSubmissionKey{}).(*TxSubmission)
+	return sub
+}
+
+// trackedTx is the timeline of a transaction being followed.
+type trackedTx struct {
+	private     bool
+	submitted   time.Time
+	distributed time.Time
+	admitted    time.Time
+	proposed    time.Time
+}
+
+// latencySample is the latencies of an imported transaction by segment.
+type latencySample struct {
+	imported time.Time
+	private  bool
+	segments map[string]time.Duration
+}
+
+// TxLatencyTracker correlates the timelines of the transactions submitted to
+// this node by hash.
+type TxLatencyTracker struct {
+	lock    sync.Mutex
+	txs     map[common.Hash]*trackedTx
+	order   []common.Hash // hashes of the tracked transactions, oldest first
+	samples []*latencySample
+	next    int // index of the oldest sample once the samples are full
+}
+
+func newTxLatencyTracker() *TxLatencyTracker {
+	return &TxLatencyTracker{txs: make(map[common.Hash]*trackedTx)}
+}
+
+// Track starts following a transaction of the submission, admitted in the pool
+// now, dropping the oldest one beyond maxTrackedTxs.
+func (t *TxLatencyTracker) Track(tx *types.Transaction, sub *TxSubmission) {
+	if sub == nil {
+		return
+	}
+	admitted := time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	hash := tx.Hash()
+	if _, ok := t.txs[hash]; ok {
+		return
+	}
+	for len(t.order) >= maxTrackedTxs {
+		delete(t.txs, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.txs[hash] = &trackedTx{private: tx.IsPrivate(), submitted: sub.Submitted, distributed: sub.Distributed, admitted: admitted}
+	t.order = append(t.order, hash)
+}
+
+// Proposed records the first proposal by this node of the tracked transactions
+// of the block.
+func (t *TxLatencyTracker) Proposed(block *types.Block) {
+	now := time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.txs) == 0 {
+		return
+	}
+	for _, tx := range block.Transactions() {
+		if tracked, ok := t.txs[tx.Hash()]; ok && tracked.proposed.IsZero() {
+			tracked.proposed = now
+		}
+	}
+}
+
+// imported completes the timelines of the tracked transactions of the block,
+// accounting their latencies in the metrics and the samples.
+func (t *TxLatencyTracker) imported(block *types.Block) {
+	now := time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.txs) == 0 {
+		return
+	}
+	var done bool
+	for _, tx := range block.Transactions() {
+		tracked, ok := t.txs[tx.Hash()]
+		if !ok {
+			continue
+		}
+		delete(t.txs, tx.Hash())
+		done = true
+		sample := &latencySample{imported: now, private: tracked.private, segments: tracked.segments(now)}
+		kind := "public"
+		if tracked.private {
+			kind = "private"
+		}
+		for segment, latency := range sample.segments {
+			metrics.GetOrRegisterHistogram("txpool/latency/"+kind+"/"+segment, nil, metrics.NewExpDecaySample(1028, 0.015)).Update(latency.Milliseconds())
+		}
+		if len(t.samples) < maxLatencySamples {
+			t.samples = append(t.samples, sample)
+		} else {
+			t.samples[t.next] = sample
+			t.next = (t.next + 1) % maxLatencySamples
+		}
+	}
+	if done {
+		order := t.order[:0]
+		for _, hash := range t.order {
+			if _, ok := t.txs[hash]; ok {
+				order = append(order, hash)
+			}
+		}
+		t.order = order
+	}
+}
+
+// segments returns the latencies of the segments of the timeline whose ends
+// are known, the transaction being imported at the time.
+func (tx *trackedTx) segments(imported time.Time) map[string]time.Duration {
+	segments := map[string]time.Duration{
+		SegmentInclusion: imported.Sub(tx.admitted),
+		SegmentTotal:     imported.Sub(tx.submitted),
+	}
+	if tx.private && !tx.distributed.IsZero() {
+		segments[SegmentDistribution] = tx.distributed.Sub(tx.submitted)
+		segments[SegmentAdmission] = tx.admitted.Sub(tx.distributed)
+	} else {
+		segments[SegmentAdmission] = tx.admitted.Sub(tx.submitted)
+	}
+	if !tx.proposed.IsZero() {
+		segments[SegmentProposal] = tx.proposed.Sub(tx.admitted)
+		segments[SegmentImport] = imported.Sub(tx.proposed)
+	}
+	return segments
+}
+
+// LatencyPercentiles are the percentiles of the latencies of a segment, in
+// milliseconds.
+type LatencyPercentiles struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// LatencyBreakdown are the percentiles of the latencies of some transactions
+// by segment.
+type LatencyBreakdown struct {
+	Transactions int                            `json:"transactions"`
+	Segments     map[string]*LatencyPercentiles `json:"segments"`
+}
+
+// LatencyReport is the breakdown of the latencies of the transactions imported
+// over a recent window, overall and by private and public transactions.
+type LatencyReport struct {
+	Window  uint64            `json:"window"` // seconds
+	Tracked int               `json:"tracked"`
+	All     *LatencyBreakdown `json:"all"`
+	Private *LatencyBreakdown `json:"private"`
+	Public  *LatencyBreakdown `json:"public"`
+}
+
+// Report returns the latencies of the transactions imported within the window,
+// of all the samples kept if zero, and the number of transactions still being
+// followed.
+func (t *TxLatencyTracker) Report(window time.Duration) *LatencyReport {
+	since := time.Now().Add(-window)
+	t.lock.Lock()
+	var all, private, public []*latencySample
+	for _, sample := range t.samples {
+		if window > 0 && sample.imported.Before(since) {
+			continue
+		}
+		all = append(all, sample)
+		if sample.private {
+			private = append(private, sample)
+		} else {
+			public = append(public, sample)
+		}
+	}
+	tracked := len(t.txs)
+	t.lock.Unlock()
+
+	return &LatencyReport{
+		Window:  uint64(window / time.Second),
+		Tracked: tracked,
+		All:     breakdown(all),
+		Private: breakdown(private),
+		Public:  breakdown(public),
+	}
+}
+
+func breakdown(samples []*latencySample) *LatencyBreakdown {
+	b := &LatencyBreakdown{Transactions: len(samples), Segments: make(map[string]*LatencyPercentiles)}
+	for _, segment := range latencySegments {
+		var latencies []time.Duration
+		for _, sample := range samples {
+			if latency, ok := sample.segments[segment]; ok {
+				latencies = append(latencies, latency)
+			}
+		}
+		if len(latencies) == 0 {
+			continue
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		b.Segments[segment] = &LatencyPercentiles{
+			Count: len(latencies),
+			P50:   percentile(latencies, 0.5),
+			P90:   percentile(latencies, 0.9),
+			P99:   percentile(latencies, 0.99),
+			Max:   milliseconds(latencies[len(latencies)-1]),
+		}
+	}
+	return b
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return milliseconds(sorted[rank])
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func latencyTestTx(nonce uint64, private bool) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	if private {
+		tx.SetPrivate()
+	}
+	return tx
+}
+
+func TestTxLatencyTracker(t *testing.T) {
+	tracker := newTxLatencyTracker()
+	public, private, untracked := latencyTestTx(0, false), latencyTestTx(1, true), latencyTestTx(2, false)
+
+	ctx, sub := WithTxSubmission(context.Background())
+	sub.Submitted = time.Now().Add(-3 * time.Second)
+	again, same := WithTxSubmission(ctx)
+	assert.Equal(t, ctx, again)
+	assert.Equal(t, sub, same)
+	tracker.Track(public, sub)
+	tracker.Track(private, &TxSubmission{Submitted: time.Now().Add(-2 * time.Second), Distributed: time.Now().Add(-time.Second)})
+	tracker.Track(untracked, TxSubmissionFromContext(context.Background()))
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}).WithBody(types.Transactions{public, private, untracked}, nil)
+	tracker.Proposed(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}).WithBody(types.Transactions{public}, nil))
+	assert.Equal(t, 2, tracker.Report(0).Tracked)
+	tracker.imported(block)
+
+	report := tracker.Report(time.Minute)
+	assert.Equal(t, uint64(60), report.Window)
+	assert.Equal(t, 0, report.Tracked)
+	assert.Equal(t, 2, report.All.Transactions)
+	assert.Equal(t, 1, report.Private.Transactions)
+	assert.Equal(t, 1, report.Public.Transactions)
+
+	require.Contains(t, report.Public.Segments, SegmentProposal)
+	assert.Contains(t, report.Public.Segments, SegmentImport)
+	assert.NotContains(t, report.Public.Segments, SegmentDistribution)
+	assert.True(t, report.Public.Segments[SegmentTotal].P99 >= 3000)
+
+	require.Contains(t, report.Private.Segments, SegmentDistribution)
+	assert.True(t, report.Private.Segments[SegmentDistribution].P50 >= 1000)
+	assert.NotContains(t, report.Private.Segments, SegmentProposal)
+	assert.Equal(t, 2, report.All.Segments[SegmentTotal].Count)
+	assert.Equal(t, report.Public.Segments[SegmentTotal].Max, report.All.Segments[SegmentTotal].Max)
+}
+
+func TestTxLatencyTracker_Eviction(t *testing.T) {
+	tracker := newTxLatencyTracker()
+	sub := &TxSubmission{Submitted: time.Now()}
+	first := latencyTestTx(0, false)
+	tracker.Track(first, sub)
+	for i := uint64(1); i <= maxTrackedTxs; i++ {
+		tracker.Track(latencyTestTx(i, false), sub)
+	}
+	assert.Equal(t, maxTrackedTxs, tracker.Report(0).Tracked)
+
+	// the oldest transaction was dropped
+	tracker.imported(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}).WithBody(types.Transactions{first, latencyTestTx(1, false)}, nil))
+	report := tracker.Report(0)
+	assert.Equal(t, maxTrackedTxs-1, report.Tracked)
+	assert.Equal(t, 1, report.All.Transactions)
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, float64(50), percentile(latencies, 0.5))
+	assert.Equal(t, float64(99), percentile(latencies, 0.99))
+	assert.Equal(t, float64(1), percentile(latencies[:1], 0.99))
+}
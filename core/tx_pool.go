@@ -25,7 +25,9 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/common/prque"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
@@ -160,6 +162,9 @@ type TxPoolConfig struct {
 	// Quorum
 	TransactionSizeLimit uint64 // Maximum size allowed for valid transaction (in KB)
 	MaxCodeSize          uint64 // Maximum size allowed of contract code that can be deployed (in KB)
+
+	// Quorum
+	Clock mclock.Clock `toml:"-"` // Clock timing the eviction and the journal rotation, the system clock if nil
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -187,6 +192,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 // unreasonable or unworkable.
 func (config *TxPoolConfig) sanitize() TxPoolConfig {
 	conf := *config
+	if conf.Clock == nil {
+		conf.Clock = mclock.System{}
+	}
 	if conf.Rejournal < time.Second {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
@@ -241,18 +249,23 @@ type TxPool struct {
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
 
-	currentState  *state.StateDB // Current state in the blockchain head
-	pendingNonces *txNoncer      // Pending state tracking virtual nonces
-	currentMaxGas uint64         // Current gas limit for transaction caps
+	currentState  *state.StateDB     // Current state in the blockchain head
+	pendingNonces *txNoncer          // Pending state tracking virtual nonces
+	currentMaxGas uint64             // Current gas limit for transaction caps
+	deployers     *DeployerAllowList // Quorum - deployers allowed by the child of the current head
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
 
-	pending map[common.Address]*txList   // All currently processable transactions
-	queue   map[common.Address]*txList   // Queued but non-processable transactions
-	beats   map[common.Address]time.Time // Last heartbeat from each known account
-	all     *txLookup                    // All transactions to allow lookups
-	priced  *txPricedList                // All transactions sorted by price
+	pending map[common.Address]*txList        // All currently processable transactions
+	queue   map[common.Address]*txList        // Queued but non-processable transactions
+	beats   map[common.Address]mclock.AbsTime // Last heartbeat from each known account
+	all     *txLookup                         // All transactions to allow lookups
+	priced  *txPricedList                     // All transactions sorted by price
+
+	latency *TxLatencyTracker // Quorum - latencies of the transactions submitted to this node
+
+	evictTimer mclock.Timer // Quorum - next eviction of the inactive accounts, nil once stopped
 
 	chainHeadCh     chan ChainHeadEvent
 	chainHeadSub    event.Subscription
@@ -282,7 +295,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		signer:          types.NewEIP155Signer(chainconfig.ChainID),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
-		beats:           make(map[common.Address]time.Time),
+		beats:           make(map[common.Address]mclock.AbsTime),
 		all:             newTxLookup(),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
@@ -291,6 +304,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		reorgDoneCh:     make(chan chan struct{}),
 		reorgShutdownCh: make(chan struct{}),
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
+		latency:         newTxLatencyTracker(),
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
@@ -308,7 +322,9 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
 
-		if err := pool.journal.load(pool.AddLocals); err != nil {
+		// Quorum: reloaded in emergency read-only mode as well, not to lose them
+		addLocals := func(txs []*types.Transaction) []error { return pool.addTxs(txs, !pool.config.NoLocals, true) }
+		if err := pool.journal.load(addLocals); err != nil {
 			log.Warn("Failed to load transaction journal", "err", err)
 		}
 		if err := pool.journal.rotate(pool.local()); err != nil {
@@ -321,6 +337,11 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	pool.wg.Add(1)
 	go pool.loop()
 
+	// Quorum - scheduled on the clock, the evictions run as the simulated clocks advance
+	pool.mu.Lock()
+	pool.evictTimer = pool.config.Clock.AfterFunc(evictionInterval, pool.evict)
+	pool.mu.Unlock()
+
 	return pool
 }
 
@@ -332,15 +353,13 @@ func (pool *TxPool) loop() {
 
 	var (
 		prevPending, prevQueued, prevStales int
-		// Start the stats reporting and transaction eviction tickers
-		report  = time.NewTicker(statsReportInterval)
-		evict   = time.NewTicker(evictionInterval)
-		journal = time.NewTicker(pool.config.Rejournal)
+		// Start the stats reporting and journal rotation tickers
+		report  = pool.config.Clock.NewTicker(statsReportInterval)
+		journal = pool.config.Clock.NewTicker(pool.config.Rejournal)
 		// Track the previous head headers for transaction reorgs
 		head = pool.chain.CurrentBlock()
 	)
 	defer report.Stop()
-	defer evict.Stop()
 	defer journal.Stop()
 
 	for {
@@ -349,6 +368,7 @@ func (pool *TxPool) loop() {
 		case ev := <-pool.chainHeadCh:
 			if ev.Block != nil {
 				pool.requestReset(head.Header(), ev.Block.Header())
+				pool.trackImported(head, ev.Block) // Quorum
 				head = ev.Block
 			}
 
@@ -358,7 +378,7 @@ func (pool *TxPool) loop() {
 			return
 
 		// Handle stats reporting ticks
-		case <-report.C:
+		case <-report.C():
 			pool.mu.RLock()
 			pending, queued := pool.stats()
 			stales := pool.priced.stales
@@ -369,27 +389,8 @@ func (pool *TxPool) loop() {
 				prevPending, prevQueued, prevStales = pending, queued, stales
 			}
 
-		// Handle inactive account transaction eviction
-		case <-evict.C:
-			pool.mu.Lock()
-			for addr := range pool.queue {
-				// Skip local transactions from the eviction mechanism
-				if pool.locals.contains(addr) {
-					continue
-				}
-				// Any non-locals old enough should be removed
-				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
-					list := pool.queue[addr].Flatten()
-					for _, tx := range list {
-						pool.removeTx(tx.Hash(), true)
-					}
-					queuedEvictionMeter.Mark(int64(len(list)))
-				}
-			}
-			pool.mu.Unlock()
-
 		// Handle local transaction journal rotation
-		case <-journal.C:
+		case <-journal.C():
 			if pool.journal != nil {
 				pool.mu.Lock()
 				if err := pool.journal.rotate(pool.local()); err != nil {
@@ -401,8 +402,43 @@ func (pool *TxPool) loop() {
 	}
 }
 
+// evict removes the transactions of the inactive accounts, and schedules the
+// next eviction.
+func (pool *TxPool) evict() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.evictTimer == nil {
+		return // stopped
+	}
+	now := pool.config.Clock.Now()
+	for addr := range pool.queue {
+		// Skip local transactions from the eviction mechanism
+		if pool.locals.contains(addr) {
+			continue
+		}
+		// Any non-locals old enough should be removed
+		if now.Sub(pool.beats[addr]) > pool.config.Lifetime {
+			list := pool.queue[addr].Flatten()
+			for _, tx := range list {
+				pool.removeTx(tx.Hash(), true)
+			}
+			queuedEvictionMeter.Mark(int64(len(list)))
+		}
+	}
+	pool.evictTimer = pool.config.Clock.AfterFunc(evictionInterval, pool.evict)
+}
+
 // Stop terminates the transaction pool.
 func (pool *TxPool) Stop() {
+	// Quorum
+	pool.mu.Lock()
+	if pool.evictTimer != nil {
+		pool.evictTimer.Stop()
+		pool.evictTimer = nil
+	}
+	pool.mu.Unlock()
+
 	// Unsubscribe all subscriptions registered from txpool
 	pool.scope.Close()
 
@@ -506,6 +542,26 @@ func (pool *TxPool) Pending() (map[common.Address]types.Transactions, error) {
 	return pending, nil
 }
 
+// Quorum
+// Latency returns the tracker of the latencies of the transactions submitted to
+// this node.
+func (pool *TxPool) Latency() *TxLatencyTracker {
+	return pool.latency
+}
+
+// trackImported completes the latencies of the transactions of the blocks
+// imported from the old head to the new one, several being imported at once
+// when syncing.
+func (pool *TxPool) trackImported(oldHead, newHead *types.Block) {
+	for block, depth := newHead, 0; block != nil && block.Hash() != oldHead.Hash() && depth < maxTrackedImportDepth; depth++ {
+		pool.latency.imported(block)
+		if block.NumberU64() == 0 {
+			break
+		}
+		block = pool.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (pool *TxPool) Locals() []common.Address {
 	pool.mu.Lock()
@@ -568,6 +624,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		if tx.IsPrivate() && (len(tx.Data()) == 0 || tx.Value().Sign() != 0) {
 			return ErrEtherValueUnsupported
 		}
+		// the parties would fail to resolve the private payload of the transaction
+		if err := ValidatePrivatePayloadHash(tx); err != nil {
+			return err
+		}
 		// Quorum - check if the sender account is authorized to perform the transaction
 		if err := pcore.CheckAccountPermission(tx.From(), tx.To(), tx.Value(), tx.Data(), tx.Gas(), tx.GasPrice()); err != nil {
 			return err
@@ -579,6 +639,11 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 			return ErrUnderpriced
 		}
 	}
+	// Quorum
+	if err := pool.deployers.Check(tx, from); err != nil {
+		return err
+	}
+	// /Quorum
 	// Ensure the transaction adheres to nonce ordering
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
@@ -658,7 +723,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
-		pool.beats[from] = time.Now()
+		pool.beats[from] = pool.config.Clock.Now()
 		return old != nil, nil
 	}
 	// New transaction isn't replacing a pending one, push into queue
@@ -712,7 +777,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	}
 	// If we never record the heartbeat, do it right now.
 	if _, exist := pool.beats[from]; !exist {
-		pool.beats[from] = time.Now()
+		pool.beats[from] = pool.config.Clock.Now()
 	}
 	return old != nil, nil
 }
@@ -766,7 +831,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
 
 	// Successful promotion, bump the heartbeat
-	pool.beats[addr] = time.Now()
+	pool.beats[addr] = pool.config.Clock.Now()
 	return true
 }
 
@@ -776,6 +841,9 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 // This method is used to add transactions from the RPC API and performs synchronous pool
 // reorganization and event propagation.
 func (pool *TxPool) AddLocals(txs []*types.Transaction) []error {
+	if errs := rejectReadOnly(txs); errs != nil {
+		return errs
+	}
 	return pool.addTxs(txs, !pool.config.NoLocals, true)
 }
 
@@ -792,11 +860,17 @@ func (pool *TxPool) AddLocal(tx *types.Transaction) error {
 // This method is used to add transactions from the p2p network and does not wait for pool
 // reorganization and internal event propagation.
 func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
+	if errs := rejectReadOnly(txs); errs != nil {
+		return errs
+	}
 	return pool.addTxs(txs, false, false)
 }
 
 // This is like AddRemotes, but waits for pool reorganization. Tests use this method.
 func (pool *TxPool) AddRemotesSync(txs []*types.Transaction) []error {
+	if errs := rejectReadOnly(txs); errs != nil {
+		return errs
+	}
 	return pool.addTxs(txs, false, true)
 }
 
@@ -815,6 +889,21 @@ func (pool *TxPool) AddRemote(tx *types.Transaction) error {
 	return errs[0]
 }
 
+// Quorum
+// rejectReadOnly returns the errors of the transactions in emergency read-only
+// mode, in which none is admitted, nil otherwise.
+func rejectReadOnly(txs []*types.Transaction) []error {
+	err := emergency.Check()
+	if err == nil {
+		return nil
+	}
+	errs := make([]error, len(txs))
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
 // addTxs attempts to queue a batch of transactions if they are valid.
 func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 	// Filter out known ones without obtaining the pool lock or recovering signatures
@@ -1209,6 +1298,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.deployers = NewDeployerAllowList(pool.chainconfig, newHead, statedb)
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -1483,13 +1573,13 @@ func (pool *TxPool) demoteUnexecutables() {
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
-	heartbeat time.Time
+	heartbeat mclock.AbsTime
 }
 
 type addressesByHeartbeat []addressByHeartbeat
 
 func (a addressesByHeartbeat) Len() int           { return len(a) }
-func (a addressesByHeartbeat) Less(i, j int) bool { return a[i].heartbeat.Before(a[j].heartbeat) }
+func (a addressesByHeartbeat) Less(i, j int) bool { return a[i].heartbeat < a[j].heartbeat }
 func (a addressesByHeartbeat) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
 // accountSet is simply a set of addresses to check for existence, and a signer
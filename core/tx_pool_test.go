@@ -29,6 +29,8 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -361,6 +363,27 @@ func TestValidateTx_whenValueNonZeroTransferForPrivateTransaction(t *testing.T)
 	}
 }
 
+func TestValidateTx_whenPrivatePayloadIsNotHash(t *testing.T) {
+	pool, key := setupQuorumTxPool()
+	defer pool.Stop()
+	arbitraryTx, balance, from := newPrivateTransaction(common.Big0, []byte("arbitrary bytecode"), key)
+	pool.currentState.AddBalance(from, balance)
+
+	err := pool.AddRemote(arbitraryTx)
+	if !errors.Is(err, ErrInvalidPrivatePayloadHash) {
+		t.Fatal("expected:", ErrInvalidPrivatePayloadHash, "; got:", err)
+	}
+	if want := (&PrivatePayloadHashError{Length: 18}).Error(); err.Error() != want {
+		t.Error("expected:", want, "; got:", err)
+	}
+
+	hashTx, balance, from := newPrivateTransaction(common.Big0, make([]byte, common.EncryptedPayloadHashLength), key)
+	pool.currentState.AddBalance(from, balance)
+	if err := pool.AddRemote(hashTx); err != nil {
+		t.Error("expected no error; got:", err)
+	}
+}
+
 func newPrivateTransaction(value *big.Int, data []byte, key *ecdsa.PrivateKey) (*types.Transaction, *big.Int, common.Address) {
 	zeroGasPrice := common.Big0
 	defaultTxPoolGasLimit := uint64(1000000)
@@ -985,6 +1008,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	// Reduce the eviction interval to a testable amount
 	defer func(old time.Duration) { evictionInterval = old }(evictionInterval)
 	evictionInterval = time.Millisecond * 100
+	clock := new(mclock.Simulated)
 
 	// Create the pool to test the non-expiration enforcement
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
@@ -993,6 +1017,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	config := testTxPoolConfig
 	config.Lifetime = time.Second
 	config.NoLocals = nolocals
+	config.Clock = clock
 
 	pool := NewTxPool(config, params.TestChainConfig, blockchain)
 	defer pool.Stop()
@@ -1023,7 +1048,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	}
 
 	// Allow the eviction interval to run
-	time.Sleep(2 * evictionInterval)
+	clock.Run(2 * evictionInterval)
 
 	// Transactions should not be evicted from the queue yet since lifetime duration has not passed
 	pending, queued = pool.Stats()
@@ -1038,7 +1063,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	}
 
 	// Wait a bit for eviction to run and clean up any leftovers, and ensure only the local remains
-	time.Sleep(2 * config.Lifetime)
+	clock.Run(2 * config.Lifetime)
 
 	pending, queued = pool.Stats()
 	if pending != 0 {
@@ -1081,7 +1106,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	if err := pool.addRemoteSync(pricedTransaction(4, 100000, big.NewInt(1), remote)); err != nil {
 		t.Fatalf("failed to add remote transaction: %v", err)
 	}
-	time.Sleep(5 * evictionInterval) // A half lifetime pass
+	clock.Run(5 * evictionInterval) // A half lifetime pass
 
 	// Queue executable transactions, the life cycle should be restarted.
 	if err := pool.AddLocal(pricedTransaction(2, 100000, big.NewInt(1), local)); err != nil {
@@ -1090,7 +1115,8 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	if err := pool.addRemoteSync(pricedTransaction(2, 100000, big.NewInt(1), remote)); err != nil {
 		t.Fatalf("failed to add remote transaction: %v", err)
 	}
-	time.Sleep(6 * evictionInterval)
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer)) // the local promoted as well
+	clock.Run(6 * evictionInterval)
 
 	// All gapped transactions shouldn't be kicked out
 	pending, queued = pool.Stats()
@@ -1105,7 +1131,7 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	}
 
 	// The whole life time pass after last promotion, kick out stale transactions
-	time.Sleep(2 * config.Lifetime)
+	clock.Run(2 * config.Lifetime)
 	pending, queued = pool.Stats()
 	if pending != 2 {
 		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
@@ -2167,3 +2193,26 @@ func TestEIP155SignerOnTxPool(t *testing.T) {
 	}
 
 }
+
+// Quorum
+func TestEmergencyReadOnly_TransactionsRejected(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	db := rawdb.NewMemoryDatabase()
+	emergency.Set(db, true, "incident", "test")
+	defer emergency.Set(db, false, "", "test")
+
+	tx := transaction(0, 100000, key)
+	if err := pool.AddLocal(tx); !errors.Is(err, emergency.ErrReadOnly) {
+		t.Fatalf("local transaction error mismatch: have %v, want %v", err, emergency.ErrReadOnly)
+	}
+	if err := pool.AddRemote(tx); !errors.Is(err, emergency.ErrReadOnly) {
+		t.Fatalf("remote transaction error mismatch: have %v, want %v", err, emergency.ErrReadOnly)
+	}
+	emergency.Set(db, false, "", "test")
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("transaction rejected once the mode is cleared: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BlockStats are the statistics of a canonical block, as aggregated by the
+// block statistics index.
+type BlockStats struct {
+	Number         uint64      `json:"number"`
+	Hash           common.Hash `json:"hash"`
+	Time           uint64      `json:"timestamp"` // in seconds, also for Raft blocks
+	TxCount        uint64      `json:"txCount"`
+	PrivateTxCount uint64      `json:"privateTxCount"`
+	GasUsed        uint64      `json:"gasUsed"`
+	GasLimit       uint64      `json:"gasLimit"`
+	ActiveAccounts uint64      `json:"activeAccounts"` // distinct senders of the transactions
+}
+
+// DailyStats are the statistics of the canonical blocks whose timestamp falls
+// within a UTC day.
+type DailyStats struct {
+	Day            uint64 `json:"-"` // days since the unix epoch
+	Blocks         uint64 `json:"blocks"`
+	FirstBlock     uint64 `json:"firstBlock"`
+	LastBlock      uint64 `json:"lastBlock"`
+	TxCount        uint64 `json:"txCount"`
+	PrivateTxCount uint64 `json:"privateTxCount"`
+	GasUsed        uint64 `json:"gasUsed"`
+	ActiveAccounts uint64 `json:"activeAccounts"` // distinct senders over the day
+}
+
+// Date returns the day of the statistics formatted as YYYY-MM-DD.
+func (s *DailyStats) Date() string {
+	return time.Unix(int64(s.Day)*86400, 0).UTC().Format("2006-01-02")
+}
+
+// MarshalJSON encodes the statistics with their date.
+func (s *DailyStats) MarshalJSON() ([]byte, error) {
+	type stats DailyStats
+	return json.Marshal(struct {
+		Date string `json:"date"`
+		*stats
+	}{s.Date(), (*stats)(s)})
+}
@@ -0,0 +1,18 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// InternalCall is a message call or contract creation made by a contract
+// while executing a transaction, as recorded by the internal call index.
+type InternalCall struct {
+	Type  string         // CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE or CREATE2
+	From  common.Address // contract making the call
+	To    common.Address // callee, or the created contract
+	Value *big.Int       // value transferred, zero for DELEGATECALL and STATICCALL
+	Depth uint64         // call depth, 1 for calls made by the contract called by the transaction
+	Error string         // empty if the call succeeded
+}
@@ -21,6 +21,7 @@ import (
 	"io"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -40,31 +41,69 @@ type IstanbulExtra struct {
 	Validators    []common.Address
 	Seal          []byte
 	CommittedSeal [][]byte
+	KeyRotations  []*IstanbulKeyRotation // Quorum: votes on validator key rotations
+}
+
+// IstanbulKeyRotation is the announcement of a validator moving its signing key
+// to a new address from the activation block, signed by the old key.
+type IstanbulKeyRotation struct {
+	Old        common.Address `json:"old"`
+	New        common.Address `json:"new"`
+	Activation uint64         `json:"activation"`
+	Signature  hexutil.Bytes  `json:"signature"`
+}
+
+// istanbulExtraRLP is the encoding of IstanbulExtra. Key rotations are encoded
+// as trailing elements so that extra-data without any keeps its legacy format.
+type istanbulExtraRLP struct {
+	Validators    []common.Address
+	Seal          []byte
+	CommittedSeal [][]byte
+	KeyRotations  []*IstanbulKeyRotation `rlp:"tail"`
 }
 
 // EncodeRLP serializes ist into the Ethereum RLP format.
 func (ist *IstanbulExtra) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{
-		ist.Validators,
-		ist.Seal,
-		ist.CommittedSeal,
+	return rlp.Encode(w, &istanbulExtraRLP{
+		Validators:    ist.Validators,
+		Seal:          ist.Seal,
+		CommittedSeal: ist.CommittedSeal,
+		KeyRotations:  ist.KeyRotations,
 	})
 }
 
 // DecodeRLP implements rlp.Decoder, and load the istanbul fields from a RLP stream.
 func (ist *IstanbulExtra) DecodeRLP(s *rlp.Stream) error {
-	var istanbulExtra struct {
-		Validators    []common.Address
-		Seal          []byte
-		CommittedSeal [][]byte
-	}
+	var istanbulExtra istanbulExtraRLP
 	if err := s.Decode(&istanbulExtra); err != nil {
 		return err
 	}
 	ist.Validators, ist.Seal, ist.CommittedSeal = istanbulExtra.Validators, istanbulExtra.Seal, istanbulExtra.CommittedSeal
+	if len(istanbulExtra.KeyRotations) > 0 {
+		ist.KeyRotations = istanbulExtra.KeyRotations
+	}
 	return nil
 }
 
+// PrepareIstanbulExtra returns the extra-data of a header carrying the given
+// vanity and validator set, with empty seal and committed seals. The vanity is
+// zero padded, or truncated, to IstanbulExtraVanity bytes. This is the format
+// expected in the extraData field of an Istanbul genesis block.
+func PrepareIstanbulExtra(vanity []byte, validators []common.Address) ([]byte, error) {
+	extra := make([]byte, IstanbulExtraVanity)
+	copy(extra, vanity)
+
+	payload, err := rlp.EncodeToBytes(&IstanbulExtra{
+		Validators:    validators,
+		Seal:          []byte{},
+		CommittedSeal: [][]byte{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(extra, payload...), nil
+}
+
 // ExtractIstanbulExtra extracts all values of the IstanbulExtra from the header. It returns an
 // error if the length of the given extra-data is less than 32 bytes or the extra-data can not
 // be decoded.
@@ -23,6 +23,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 func TestHeaderHash(t *testing.T) {
@@ -86,3 +87,58 @@ func TestExtractToIstanbul(t *testing.T) {
 		}
 	}
 }
+
+func TestPrepareIstanbulExtra(t *testing.T) {
+	validators := []common.Address{
+		common.BytesToAddress(hexutil.MustDecode("0x44add0ec310f115a0e603b2d7db9f067778eaf8a")),
+		common.BytesToAddress(hexutil.MustDecode("0x294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212")),
+	}
+	extra, err := PrepareIstanbulExtra([]byte("vanity"), validators)
+	if err != nil {
+		t.Fatalf("failed to prepare extra-data: %v", err)
+	}
+	if len(extra) < IstanbulExtraVanity || !bytes.HasPrefix(extra, []byte("vanity")) {
+		t.Errorf("vanity not preserved: %x", extra)
+	}
+	istanbulExtra, err := ExtractIstanbulExtra(&Header{Extra: extra})
+	if err != nil {
+		t.Fatalf("failed to extract extra-data: %v", err)
+	}
+	expected := &IstanbulExtra{Validators: validators, Seal: []byte{}, CommittedSeal: [][]byte{}}
+	if !reflect.DeepEqual(istanbulExtra, expected) {
+		t.Errorf("expected: %v, but got: %v", expected, istanbulExtra)
+	}
+}
+
+func TestIstanbulExtraKeyRotations(t *testing.T) {
+	legacy := hexutil.MustDecode("0xf858f8549444add0ec310f115a0e603b2d7db9f067778eaf8a94294fc7e8f22b3bcdcf955dd7ff3ba2ed833f8212946beaaed781d2d2ab6350f5c4566a2c6eaac407a6948be76812f765c24641ec63dc2852b378aba2b44080c0")
+	istanbulExtra, err := ExtractIstanbulExtra(&Header{Extra: append(make([]byte, IstanbulExtraVanity), legacy...)})
+	if err != nil {
+		t.Fatalf("failed to extract extra-data: %v", err)
+	}
+	// extra-data without key rotations keeps the legacy encoding
+	encoded, err := rlp.EncodeToBytes(istanbulExtra)
+	if err != nil {
+		t.Fatalf("failed to encode extra-data: %v", err)
+	}
+	if !bytes.Equal(encoded, legacy) {
+		t.Errorf("expected: %x, but got: %x", legacy, encoded)
+	}
+
+	istanbulExtra.KeyRotations = []*IstanbulKeyRotation{{
+		Old:        istanbulExtra.Validators[0],
+		New:        common.HexToAddress("0x01"),
+		Activation: 100,
+		Signature:  []byte{0x01, 0x02},
+	}}
+	if encoded, err = rlp.EncodeToBytes(istanbulExtra); err != nil {
+		t.Fatalf("failed to encode extra-data: %v", err)
+	}
+	decoded, err := ExtractIstanbulExtra(&Header{Extra: append(make([]byte, IstanbulExtraVanity), encoded...)})
+	if err != nil {
+		t.Fatalf("failed to extract extra-data: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, istanbulExtra) {
+		t.Errorf("expected: %v, but got: %v", istanbulExtra, decoded)
+	}
+}
@@ -142,6 +142,9 @@ type Context struct {
 	// AuthorizeMessageCallFunc performs tenancy authorization check for message call to a contract.
 	// It's only injected during simulation/eth_call
 	AuthorizeMessageCallFunc multitenancy.AuthorizeMessageCallFunc
+	// RequestID is the ID of the RPC request served by the execution, if any,
+	// logged with its failures
+	RequestID string
 }
 
 type PublicState StateDB
@@ -766,6 +769,7 @@ func getDualState(evm *EVM, addr common.Address) StateDB {
 func (evm *EVM) PublicState() PublicState           { return evm.publicState }
 func (evm *EVM) PrivateState() PrivateState         { return evm.privateState }
 func (evm *EVM) SetCurrentTX(tx *types.Transaction) { evm.currentTx = tx }
+func (evm *EVM) CurrentTx() *types.Transaction      { return evm.currentTx }
 func (evm *EVM) SetTxPrivacyMetadata(pm *types.PrivacyMetadata) {
 	evm.currentTx.SetTxPrivacyMetadata(pm)
 }
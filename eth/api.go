@@ -17,11 +17,13 @@
 package eth
 
 import (
+	"bufio"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"runtime"
@@ -30,11 +32,18 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/storagelayout"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -62,22 +71,25 @@ func (api *PublicEthereumAPI) Coinbase() (common.Address, error) {
 }
 
 // Quorum
-// StorageRoot returns the storage root of an account on the the given (optional) block height.
+// StorageRoot returns the storage root of an account on the the given (optional) block number or hash.
 // If block number is not given the latest block is used.
-func (s *PublicEthereumAPI) StorageRoot(addr common.Address, blockNr *rpc.BlockNumber) (common.Hash, error) {
+func (s *PublicEthereumAPI) StorageRoot(addr common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (common.Hash, error) {
 	var (
 		pub, priv *state.StateDB
 		err       error
 	)
 
-	if blockNr == nil || blockNr.Int64() == rpc.LatestBlockNumber.Int64() {
+	if blockNrOrHash == nil {
 		pub, priv, err = s.e.blockchain.State()
 	} else {
-		if ch := s.e.blockchain.GetHeaderByNumber(uint64(blockNr.Int64())); ch != nil {
-			pub, priv, err = s.e.blockchain.StateAt(ch.Root)
-		} else {
+		var ch *types.Header
+		if ch, err = core.HeaderByNumberOrHash(s.e.blockchain, *blockNrOrHash); err != nil {
+			return common.Hash{}, err
+		}
+		if ch == nil {
 			return common.Hash{}, fmt.Errorf("invalid block number")
 		}
+		pub, priv, err = s.e.blockchain.StateAt(ch.Root)
 	}
 
 	if err != nil {
@@ -95,6 +107,14 @@ func (api *PublicEthereumAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(api.e.Miner().HashRate())
 }
 
+// Quorum
+//
+// ReadOnlyStatus reports whether the node serves reads from a replicated
+// database and how stale its view of the chain head is.
+func (api *PublicEthereumAPI) ReadOnlyStatus() *ReadOnlyStatus {
+	return api.e.readOnlyStatus()
+}
+
 // ChainId is the EIP-155 replay-protection chain id for the current ethereum chain config.
 func (api *PublicEthereumAPI) ChainId() hexutil.Uint64 {
 	chainID := new(big.Int)
@@ -120,6 +140,187 @@ func (api *PublicMinerAPI) Mining() bool {
 	return api.e.IsMining()
 }
 
+// Quorum
+// PublicBlockProductionAPI provides the production reports of the blocks sealed
+// by this node.
+type PublicBlockProductionAPI struct {
+	e *Ethereum
+}
+
+// NewPublicBlockProductionAPI creates a new PublicBlockProductionAPI instance.
+func NewPublicBlockProductionAPI(e *Ethereum) *PublicBlockProductionAPI {
+	return &PublicBlockProductionAPI{e}
+}
+
+// BlockProductionReport returns the outcome of the candidate transactions of
+// one of the last blocks sealed by this node, nil if the node did not seal it
+// or its report was dropped.
+func (api *PublicBlockProductionAPI) BlockProductionReport(hash common.Hash) *miner.BlockProductionReport {
+	return api.e.Miner().BlockProductionReport(hash)
+}
+
+// Quorum
+// PublicTxLatencyAPI provides the latencies of the transactions submitted to
+// this node.
+type PublicTxLatencyAPI struct {
+	e *Ethereum
+}
+
+// NewPublicTxLatencyAPI creates a new PublicTxLatencyAPI instance.
+func NewPublicTxLatencyAPI(e *Ethereum) *PublicTxLatencyAPI {
+	return &PublicTxLatencyAPI{e}
+}
+
+// LatencyReport returns the percentiles of the latencies, from submission to
+// the import of their block and by segment, of the transactions submitted to
+// this node and imported within the last window seconds, or of the last ones
+// kept if zero.
+func (api *PublicTxLatencyAPI) LatencyReport(window uint64) *core.LatencyReport {
+	return api.e.TxPool().Latency().Report(time.Duration(window) * time.Second)
+}
+
+// Quorum
+// PublicBlocksRangeAPI serves ranges of historical blocks in chunks.
+type PublicBlocksRangeAPI struct {
+	e *Ethereum
+}
+
+// NewPublicBlocksRangeAPI creates a new PublicBlocksRangeAPI instance.
+func NewPublicBlocksRangeAPI(e *Ethereum) *PublicBlocksRangeAPI {
+	return &PublicBlocksRangeAPI{e}
+}
+
+// GetBlocksRange returns the next chunk of the range of blocks with the fields
+// of the query, delayed while the node is busy. The range is resumed with the
+// cursor of the chunk, until it is complete. The ranges are also streamed over
+// HTTP on /quorum/blocksrange.
+func (api *PublicBlocksRangeAPI) GetBlocksRange(ctx context.Context, query BlocksRangeQuery) (*BlocksRangeChunk, error) {
+	return api.e.blocksRange.chunk(ctx, &query)
+}
+
+// Quorum
+// PrivateStateLayoutAPI describes the private state of the contracts with
+// their registered storage layouts.
+type PrivateStateLayoutAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateStateLayoutAPI creates a new PrivateStateLayoutAPI instance.
+func NewPrivateStateLayoutAPI(e *Ethereum) *PrivateStateLayoutAPI {
+	return &PrivateStateLayoutAPI{e}
+}
+
+// DescribePrivateState returns the private state of the contract at the given
+// block, its variables decoded with the registered storage layout. The slots
+// which cannot be decoded, or all of them without layout, are returned raw.
+// Two dumps can be compared with storagelayout.Compare.
+func (api *PrivateStateLayoutAPI) DescribePrivateState(contract common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*storagelayout.Dump, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
+		return nil, errors.New("pending state cannot be described")
+	}
+	header, err := core.HeaderByNumberOrHash(api.e.blockchain, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %s not found", blockNrOrHash.String())
+	}
+	_, privateState, err := api.e.blockchain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	if len(privateState.GetCode(contract)) == 0 {
+		return nil, fmt.Errorf("private contract %s not found at block #%d, the node may not be party to it", contract.Hex(), header.Number.Uint64())
+	}
+	slots := make(map[common.Hash]common.Hash)
+	if err := privateState.ForEachStorage(contract, func(key, value common.Hash) bool {
+		slots[key] = value
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	// the preimages of the secure tries and of the SHA3 share the database
+	preimages, err := privateState.Database().OpenTrie(common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	dump := storagelayout.Describe(api.e.storageLayouts.Layout(contract), slots, func(hash common.Hash) []byte {
+		return preimages.GetKey(hash.Bytes())
+	})
+	dump.Contract, dump.BlockNumber, dump.BlockHash = contract, header.Number.Uint64(), header.Hash()
+	return dump, nil
+}
+
+// Quorum
+// PrivateConfigMigrationAPI reports the migration of the chain config run by
+// geth init --migrate-config.
+type PrivateConfigMigrationAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateConfigMigrationAPI creates a new PrivateConfigMigrationAPI instance.
+func NewPrivateConfigMigrationAPI(e *Ethereum) *PrivateConfigMigrationAPI {
+	return &PrivateConfigMigrationAPI{e}
+}
+
+// ConfigMigrationReport returns the report of the last migration of the chain
+// config, with the fields added, appended, kept or refused, or null if no
+// migration ran over the datadir.
+func (api *PrivateConfigMigrationAPI) ConfigMigrationReport() (*core.ConfigMigrationReport, error) {
+	return core.ReadConfigMigrationReport(api.e.chainDb)
+}
+
+// Quorum
+// PrivateTenantExportAPI exports the private data of a tenant, its private
+// transactions being identified by its transaction manager key, for the tenant
+// to be handed its data when leaving the node.
+type PrivateTenantExportAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateTenantExportAPI creates a new PrivateTenantExportAPI instance.
+func NewPrivateTenantExportAPI(e *Ethereum) *PrivateTenantExportAPI {
+	return &PrivateTenantExportAPI{e}
+}
+
+// ExportTenantData starts exporting, in the background, the private
+// transactions of the tenant key up to the current head, their payload hashes
+// and receipts, and the private state of their contracts, to an archive in the
+// target directory. The progress is reported by TenantExportStatus.
+func (api *PrivateTenantExportAPI) ExportTenantData(tenant string, targetDir string) (*TenantExportStatus, error) {
+	if !private.IsQuorumPrivacyEnabled() {
+		return nil, errors.New("PrivateTransactionManager is not enabled")
+	}
+	return api.e.tenantExport.export(tenant, targetDir)
+}
+
+// TenantExportStatus returns the progress of the last tenant export, or null if
+// none ran since the node started.
+func (api *PrivateTenantExportAPI) TenantExportStatus() *TenantExportStatus {
+	return api.e.tenantExport.exportStatus()
+}
+
+// Quorum
+// PrivateAuditAPI fetches, on an auditor node, the internals of the private
+// transactions disclosed by its peers.
+type PrivateAuditAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateAuditAPI creates a new PrivateAuditAPI instance.
+func NewPrivateAuditAPI(e *Ethereum) *PrivateAuditAPI {
+	return &PrivateAuditAPI{e}
+}
+
+// FetchMarkerInternals returns the private payload and receipt of the private
+// transaction, kept locally once disclosed by a peer party to it.
+func (api *PrivateAuditAPI) FetchMarkerInternals(ctx context.Context, txHash common.Hash) (*PrivateInternals, error) {
+	if api.e.privateAudit == nil {
+		return nil, errPrivateAuditDisabled
+	}
+	return api.e.privateAudit.fetch(ctx, txHash)
+}
+
 // PrivateMinerAPI provides private RPC methods to control the miner.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateMinerAPI struct {
@@ -293,6 +494,162 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// Quorum
+
+// RequestMaintenanceWindow returns the soonest window of at least the given
+// number of consecutive blocks which this node is not expected to seal, so it
+// can be restarted without causing a round change. If wait is set the call
+// only returns once the window has started, recomputing the window on every
+// new head as round changes and validator votes reschedule the rotation.
+//
+// Raft and engines which do not elect a single sealer per block, like clique
+// where out-of-turn signers step in, are always reported safe. Neither of the
+// istanbul proposer policies can skip a proposer without a round change, so
+// the node cannot decline proposing for the duration of the window.
+func (api *PrivateAdminAPI) RequestMaintenanceWindow(ctx context.Context, blocks uint64, wait *bool) (*consensus.MaintenanceWindow, error) {
+	var (
+		headCh = make(chan core.ChainHeadEvent, 16)
+		sub    event.Subscription
+	)
+	if wait != nil && *wait {
+		sub = api.eth.BlockChain().SubscribeChainHeadEvent(headCh)
+		defer sub.Unsubscribe()
+	}
+	for {
+		window, err := api.maintenanceWindow(blocks)
+		if err != nil || sub == nil || window.Start == window.Head+1 {
+			return window, err
+		}
+		select {
+		case <-headCh:
+		case err := <-sub.Err():
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (api *PrivateAdminAPI) maintenanceWindow(blocks uint64) (*consensus.MaintenanceWindow, error) {
+	chain := api.eth.BlockChain()
+	if scheduler, ok := api.eth.Engine().(consensus.MaintenanceScheduler); ok && !api.eth.config.RaftMode {
+		return scheduler.MaintenanceWindow(chain, blocks)
+	}
+	if blocks == 0 {
+		return nil, errors.New("maintenance window must span at least one block")
+	}
+	head := chain.CurrentHeader().Number.Uint64()
+	return &consensus.MaintenanceWindow{
+		Head:     head,
+		Start:    head + 1,
+		End:      head + blocks,
+		Position: -1,
+	}, nil
+}
+
+// SetReadOnly enables or disables the emergency read-only mode of the node,
+// persisted until cleared, see core/emergency. Enabling it requires a reason,
+// clearing it the whole admin scope when the requests are authorized.
+func (api *PrivateAdminAPI) SetReadOnly(ctx context.Context, enabled bool, reason *string) (emergency.State, error) {
+	if api.eth.config.ReadOnly {
+		return emergency.State{}, ErrReadOnlyNode
+	}
+	if !enabled {
+		if !hasAdminScope(ctx) {
+			return emergency.Get(), errors.New("clearing the emergency read-only mode requires the admin scope")
+		}
+		return emergency.Set(api.eth.ChainDb(), false, "", requestor(ctx)), nil
+	}
+	if reason == nil || strings.TrimSpace(*reason) == "" {
+		return emergency.Get(), errors.New("a reason is required to enable the emergency read-only mode")
+	}
+	return emergency.Set(api.eth.ChainDb(), true, *reason, requestor(ctx)), nil
+}
+
+var errInternalCallIndexDisabled = errors.New("internal call index is disabled, see --internalcalls")
+
+// BackfillInternalCalls indexes the internal calls of the canonical blocks in
+// the given range in the background. The state of the parent of each block
+// must be available. The backfill resumes after a restart until completed.
+func (api *PrivateAdminAPI) BackfillInternalCalls(from, to uint64) (*core.InternalCallsBackfillStatus, error) {
+	if api.eth.internalCallIndexer == nil {
+		return nil, errInternalCallIndexDisabled
+	}
+	return api.eth.internalCallIndexer.Backfill(from, to)
+}
+
+// InternalCallsBackfillStatus returns the progress of the last internal call
+// backfill, nil if none ran since the node started.
+func (api *PrivateAdminAPI) InternalCallsBackfillStatus() (*core.InternalCallsBackfillStatus, error) {
+	if api.eth.internalCallIndexer == nil {
+		return nil, errInternalCallIndexDisabled
+	}
+	return api.eth.internalCallIndexer.BackfillStatus(), nil
+}
+
+var errBlockStatsDisabled = errors.New("block statistics are disabled, see --blockstats")
+
+// BackfillBlockStats aggregates the statistics of the canonical blocks in the
+// given range in the background. The backfill resumes after a restart until
+// completed.
+func (api *PrivateAdminAPI) BackfillBlockStats(from, to uint64) (*core.BlockStatsBackfillStatus, error) {
+	if api.eth.blockStatsIndexer == nil {
+		return nil, errBlockStatsDisabled
+	}
+	return api.eth.blockStatsIndexer.Backfill(from, to)
+}
+
+// BlockStatsBackfillStatus returns the progress of the last block statistics
+// backfill, nil if none ran since the node started.
+func (api *PrivateAdminAPI) BlockStatsBackfillStatus() (*core.BlockStatsBackfillStatus, error) {
+	if api.eth.blockStatsIndexer == nil {
+		return nil, errBlockStatsDisabled
+	}
+	return api.eth.blockStatsIndexer.BackfillStatus(), nil
+}
+
+// ConfigDriftReport compares the consensus-critical configuration of the
+// connected peers with the local one.
+func (api *PrivateAdminAPI) ConfigDriftReport() *ConfigDriftReport {
+	return api.eth.configDrift.report()
+}
+
+// AcceptDeepReorg permits the reorg to the block, rejected as deeper than the
+// maximum accepted reorg depth. The reorg happens once the chain is imported
+// again, as when synchronising with the peers.
+func (api *PrivateAdminAPI) AcceptDeepReorg(hash common.Hash) (bool, error) {
+	if err := api.eth.blockchain.AcceptDeepReorg(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PtmPinEndpoint routes all the requests to the private transaction manager to
+// the endpoint of the URL, without falling back on errors, for debugging. An
+// empty URL restores the routing by latency.
+func (api *PrivateAdminAPI) PtmPinEndpoint(url string) (bool, error) {
+	router := private.EndpointRouter()
+	if router == nil {
+		return false, private.ErrSingleEndpoint
+	}
+	if err := router.Pin(url); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PrivacyManagerStatus upchecks the private transaction manager, returning
+// whether it is reachable, the latency of the upcheck, the version it reports
+// and the features the node detected.
+func (api *PrivateAdminAPI) PrivacyManagerStatus() (*private.Status, error) {
+	if !private.IsQuorumPrivacyEnabled() {
+		return nil, errors.New("PrivateTransactionManager is not enabled")
+	}
+	return private.CheckStatus(api.eth.PrivateTransactionManager()), nil
+}
+
+// /Quorum
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -308,7 +665,7 @@ func NewPublicDebugAPI(eth *Ethereum) *PublicDebugAPI {
 // DumpBlock retrieves the entire state of the database at a given block.
 // Quorum adds an additional parameter to support private state dump
 func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber, typ *string) (state.Dump, error) {
-	publicState, privateState, err := api.getStateDbsFromBlockNumber(blockNr)
+	publicState, privateState, err := api.getStateDbsFromBlockNumberOrHash(rpc.BlockNumberOrHashWithNumber(blockNr))
 	if err != nil {
 		return state.Dump{}, err
 	}
@@ -319,8 +676,8 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber, typ *string) (stat
 	return publicState.RawDump(false, false, true), nil
 }
 
-func (api *PublicDebugAPI) PrivateStateRoot(ctx context.Context, blockNr rpc.BlockNumber) (common.Hash, error) {
-	_, privateState, err := api.getStateDbsFromBlockNumber(blockNr)
+func (api *PublicDebugAPI) PrivateStateRoot(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	_, privateState, err := api.getStateDbsFromBlockNumberOrHash(blockNrOrHash)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -330,8 +687,8 @@ func (api *PublicDebugAPI) PrivateStateRoot(ctx context.Context, blockNr rpc.Blo
 // Quorum
 // DumpAddress retrieves the state of an address at a given block.
 // Quorum adds an additional parameter to support private state dump
-func (api *PublicDebugAPI) DumpAddress(address common.Address, blockNr rpc.BlockNumber) (state.DumpAccount, error) {
-	publicState, privateState, err := api.getStateDbsFromBlockNumber(blockNr)
+func (api *PublicDebugAPI) DumpAddress(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (state.DumpAccount, error) {
+	publicState, privateState, err := api.getStateDbsFromBlockNumberOrHash(blockNrOrHash)
 	if err != nil {
 		return state.DumpAccount{}, err
 	}
@@ -348,8 +705,8 @@ func (api *PublicDebugAPI) DumpAddress(address common.Address, blockNr rpc.Block
 //Quorum
 //Taken from DumpBlock, as it was reused in DumpAddress.
 //Contains modifications from the original to return the private state db, as well as public.
-func (api *PublicDebugAPI) getStateDbsFromBlockNumber(blockNr rpc.BlockNumber) (*state.StateDB, *state.StateDB, error) {
-	if blockNr == rpc.PendingBlockNumber {
+func (api *PublicDebugAPI) getStateDbsFromBlockNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *state.StateDB, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
 		// If we're dumping the pending state, we need to request
 		// both the pending block as well as the pending state from
 		// the miner and operate on those
@@ -357,16 +714,14 @@ func (api *PublicDebugAPI) getStateDbsFromBlockNumber(blockNr rpc.BlockNumber) (
 		return publicState, privateState, nil
 	}
 
-	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
-		block = api.eth.blockchain.CurrentBlock()
-	} else {
-		block = api.eth.blockchain.GetBlockByNumber(uint64(blockNr))
+	header, err := core.HeaderByNumberOrHash(api.eth.blockchain, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
 	}
-	if block == nil {
-		return nil, nil, fmt.Errorf("block #%d not found", blockNr)
+	if header == nil {
+		return nil, nil, fmt.Errorf("block %s not found", blockNrOrHash.String())
 	}
-	return api.eth.BlockChain().StateAt(block.Root())
+	return api.eth.BlockChain().StateAt(header.Root)
 }
 
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
@@ -419,6 +774,67 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// Quorum
+// LastImportTimings returns the breakdown of the import time of the last n blocks
+// imported by the node, oldest first.
+func (api *PrivateDebugAPI) LastImportTimings(n int) []*core.ImportTimings {
+	return api.eth.BlockChain().LastImportTimings(n)
+}
+
+// DumpPrivateContractConfig holds the options of DumpPrivateContract.
+type DumpPrivateContractConfig struct {
+	Format state.ContractDumpFormat `json:"format"` // json (default) or rlp
+}
+
+// DumpPrivateContract writes the code and complete private storage of a
+// contract at the given block to a file, returning its name. The dump is
+// streamed to the file, so that large contracts are not held in memory. Only
+// the nodes party to the contract hold its private state.
+func (api *PrivateDebugAPI) DumpPrivateContract(address common.Address, blockNrOrHash rpc.BlockNumberOrHash, config *DumpPrivateContractConfig) (string, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
+		return "", errors.New("pending state cannot be dumped")
+	}
+	header, err := core.HeaderByNumberOrHash(api.eth.blockchain, blockNrOrHash)
+	if err != nil {
+		return "", err
+	}
+	var block *types.Block
+	if header != nil {
+		block = api.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64())
+	}
+	if block == nil {
+		return "", fmt.Errorf("block %s not found", blockNrOrHash.String())
+	}
+	_, privateState, err := api.eth.blockchain.StateAt(block.Root())
+	if err != nil {
+		return "", err
+	}
+	var format state.ContractDumpFormat
+	if config != nil {
+		format = config.Format
+	}
+	prefix := fmt.Sprintf("privatecontract_%#x-%d-", address.Bytes()[:4], block.NumberU64())
+	dump, err := ioutil.TempFile(os.TempDir(), prefix)
+	if err != nil {
+		return "", err
+	}
+	writer := bufio.NewWriter(dump)
+	err = privateState.DumpContract(address, block.NumberU64(), block.Hash(), format, writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	dump.Close()
+	if err != nil {
+		os.Remove(dump.Name())
+		if err == state.ErrContractNotFound {
+			return "", fmt.Errorf("private contract %s not found at block #%d, the node may not be party to it", address.Hex(), block.NumberU64())
+		}
+		return "", err
+	}
+	log.Info("Wrote private contract dump", "address", address, "number", block.NumberU64(), "file", dump.Name())
+	return dump.Name(), nil
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -542,6 +958,25 @@ func (api *PrivateDebugAPI) GetModifiedAccountsByNumber(startNum uint64, endNum
 	return api.getModifiedAccounts(startBlock, endBlock)
 }
 
+// Quorum
+//
+// VerifyReceipts recomputes the receipts of all blocks in the given range and
+// compares them with the block headers, reporting any corrupted block.
+func (api *PrivateDebugAPI) VerifyReceipts(startNum uint64, endNum uint64) (*core.ReceiptVerification, error) {
+	return api.eth.receiptWatchdog.VerifyRange(startNum, endNum)
+}
+
+// Quorum
+//
+// GetInternalCalls returns the calls and contract creations made by contracts
+// while executing the given transaction. It requires the internal call index.
+func (api *PrivateDebugAPI) GetInternalCalls(txHash common.Hash) ([]*types.InternalCall, error) {
+	if api.eth.internalCallIndexer == nil {
+		return nil, errInternalCallIndexDisabled
+	}
+	return api.eth.internalCallIndexer.InternalCalls(txHash)
+}
+
 // GetModifiedAccountsByHash returns all accounts that have changed between the
 // two blocks specified. A change is defined as a difference in nonce, balance,
 // code hash, or storage hash.
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -97,7 +98,7 @@ func (b *EthAPIBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash
 			return nil, errors.New("header for hash not found")
 		}
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
-			return nil, errors.New("hash is not currently canonical")
+			return nil, core.ErrHashNotCanonical
 		}
 		return header, nil
 	}
@@ -139,7 +140,7 @@ func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 			return nil, errors.New("header for hash not found")
 		}
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
-			return nil, errors.New("hash is not currently canonical")
+			return nil, core.ErrHashNotCanonical
 		}
 		block := b.eth.blockchain.GetBlock(hash, header.Number.Uint64())
 		if block == nil {
@@ -192,7 +193,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 			return nil, nil, errors.New("header for hash not found")
 		}
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
-			return nil, nil, errors.New("hash is not currently canonical")
+			return nil, nil, core.ErrHashNotCanonical
 		}
 		stateDb, privateState, err := b.eth.BlockChain().StateAt(header.Root)
 		return EthAPIState{stateDb, privateState}, header, err
@@ -202,10 +203,30 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	if err := b.checkReceiptsServable(hash); err != nil {
+		return nil, err
+	}
 	return b.eth.blockchain.GetReceiptsByHash(hash), nil
 }
 
+// Quorum
+//
+// checkReceiptsServable refuses receipts of blocks found corrupted by the
+// receipt watchdog when the node runs in degraded mode.
+func (b *EthAPIBackend) checkReceiptsServable(hash common.Hash) error {
+	if b.eth.receiptWatchdog == nil {
+		return nil
+	}
+	if number := rawdb.ReadHeaderNumber(b.eth.chainDb, hash); number != nil && b.eth.receiptWatchdog.IsCorrupted(*number) {
+		return core.ErrCorruptedReceipts
+	}
+	return nil
+}
+
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	if err := b.checkReceiptsServable(hash); err != nil {
+		return nil, err
+	}
 	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
 	if receipts == nil {
 		return nil, nil
@@ -226,6 +247,7 @@ func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state vm.M
 	vmError := func() error { return nil }
 
 	evmCtx := core.NewEVMContext(msg, header, b.eth.BlockChain(), nil)
+	evmCtx.RequestID = log.RequestID(ctx) // Quorum
 	if _, ok := b.SupportsMultitenancy(ctx); ok {
 		evmCtx = core.NewMultitenancyAwareEVMContext(ctx, evmCtx)
 	}
@@ -260,6 +282,19 @@ func (b *EthAPIBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) e
 	return b.eth.BlockChain().SubscribeChainHeadEvent(ch)
 }
 
+// Quorum
+// SubscribeHeadEvent subscribes to the new heads enriched with their receipts.
+func (b *EthAPIBackend) SubscribeHeadEvent(ch chan<- *core.HeadEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeHeadEvent(ch)
+}
+
+// Quorum
+// AddressTxIndexer returns the index of the transactions by account, nil if
+// disabled.
+func (b *EthAPIBackend) AddressTxIndexer() *core.AddressTxIndexer {
+	return b.eth.addressTxIndexer
+}
+
 func (b *EthAPIBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeChainSideEvent(ch)
 }
@@ -269,12 +304,19 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if err := b.CheckWritable(); err != nil {
+		return err
+	}
 	// validation for node need to happen here and cannot be done as a part of
 	// validateTx in tx_pool.go as tx_pool validation will happen in every node
 	if b.hexNodeId != "" && !pcore.ValidateNodeForTxn(b.hexNodeId, signedTx.From()) {
 		return errors.New("cannot send transaction from this node")
 	}
-	return b.eth.txPool.AddLocal(signedTx)
+	if err := b.eth.txPool.AddLocal(signedTx); err != nil {
+		return err
+	}
+	b.eth.txPool.Latency().Track(signedTx, core.TxSubmissionFromContext(ctx)) // Quorum
+	return nil
 }
 
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
@@ -354,6 +396,30 @@ func (b *EthAPIBackend) CallTimeOut() time.Duration {
 	return b.evmCallTimeOut
 }
 
+// Quorum
+func (b *EthAPIBackend) CheckWritable() error {
+	if b.eth.config.ReadOnly {
+		return ErrReadOnlyNode
+	}
+	return emergency.Check()
+}
+
+// Quorum
+func (b *EthAPIBackend) ConsensusAlgorithm() string {
+	return b.eth.protocolManager.getConsensusAlgorithm()
+}
+
+// Quorum
+//
+// HeadStaleness returns the time elapsed since a read-only node last
+// refreshed its chain head, and false if the node is not read-only.
+func (b *EthAPIBackend) HeadStaleness() (time.Duration, bool) {
+	if b.eth.headFollower == nil {
+		return 0, false
+	}
+	return b.eth.headFollower.staleness(), true
+}
+
 func (b *EthAPIBackend) RPCGasCap() uint64 {
 	return b.eth.config.RPCGasCap
 }
@@ -418,6 +484,11 @@ type EthAPIState struct {
 	state, privateState *state.StateDB
 }
 
+// Copy returns an independent copy of the public and private states.
+func (s EthAPIState) Copy() vm.MinimalApiState {
+	return EthAPIState{s.state.Copy(), s.privateState.Copy()}
+}
+
 func (s EthAPIState) GetBalance(addr common.Address) *big.Int {
 	if s.privateState.Exist(addr) {
 		return s.privateState.GetBalance(addr)
@@ -35,7 +35,10 @@ import (
 	istanbulBackend "github.com/ethereum/go-ethereum/consensus/istanbul/backend"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/slotpolicy"
+	"github.com/ethereum/go-ethereum/core/storagelayout"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -44,6 +47,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/extension/privacyExtension"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/miner"
@@ -53,6 +57,8 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/accesslog"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -97,6 +103,91 @@ type Ethereum struct {
 
 	// Quorum - consensus as eth-service (e.g. raft)
 	consensusServicePendingLogsFeed *event.Feed
+
+	// Quorum - read-only mode
+	refreshDb     func() error   // re-opens the replicated database, set only in read-only mode
+	headFollower  *headFollower  // follows the writer's head, set only in read-only mode
+	headPublisher *headPublisher // publishes the head for read-only nodes, optional on writers
+
+	// Quorum - verifies stored receipts against block headers
+	receiptWatchdog *core.ReceiptWatchdog
+
+	// Quorum - indexes internal calls, nil if disabled
+	internalCallIndexer *core.InternalCallIndexer
+
+	// Quorum - aggregates block statistics, nil if disabled
+	blockStatsIndexer *core.BlockStatsIndexer
+
+	// Quorum - indexes the transactions by account, nil if disabled
+	addressTxIndexer *core.AddressTxIndexer
+
+	// Quorum - warms the private payload cache ahead of imports, nil if disabled
+	privatePrefetcher *core.PrivatePrefetcher
+
+	// Quorum - reports the sealing in emergency read-only mode
+	emergencySub event.Subscription
+
+	// Quorum - hints the party peers of the private payloads to prefetch, nil if disabled
+	privateHints *privateHints
+
+	// Quorum - serves the private transaction internals to the auditors, nil if disabled
+	privateAudit *privateAudit
+
+	// Quorum - compares the consensus configuration of the peers with the local one
+	configDrift *configDriftDetector
+
+	// Quorum - exchanges the capabilities of the Quorum protocol extensions with the peers
+	capabilities *capabilityRegistry
+
+	// Quorum - storage layouts of the private contracts, nil if none registered
+	storageLayouts *storagelayout.Registry
+
+	// Quorum - serves the ranges of historical blocks in chunks
+	blocksRange *blocksRangeServer
+
+	// Quorum - exports the private data of the tenants leaving the node
+	tenantExport *tenantExporter
+
+	// Quorum - the privacy set up from the configuration, nil if set up by geth
+	privacy *private.Privacy
+
+	// Quorum - calls the hooks of the embedders on the blocks imported
+	hooks *hookDispatcher
+}
+
+// Quorum
+//
+// SetupPrivacy connects to the private transaction manager of the
+// configuration, making it the one of the process, and registers its services
+// with the node.
+func SetupPrivacy(stack *node.Node, config *private.Config) (*private.Privacy, error) {
+	cfg := *config
+	if cfg.CacheDiskSize > 0 && cfg.CacheDir == "" {
+		if cfg.CacheDir = stack.ResolvePath("ptmcache"); cfg.CacheDir == "" {
+			log.Warn("Persistent private payload cache disabled for an ephemeral node")
+		}
+	}
+	privacy, err := private.Open(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	privacy.Install()
+	privacyExtension.Init()
+	for _, service := range privacy.Services() {
+		stack.RegisterLifecycle(service)
+	}
+	return privacy, nil
+}
+
+// Quorum
+//
+// PrivateTransactionManager returns the private transaction manager of the
+// privacy configuration of the node, the one of the process if geth set it up.
+func (s *Ethereum) PrivateTransactionManager() private.PrivateTransactionManager {
+	if s.privacy != nil {
+		return s.privacy.PTM
+	}
+	return private.P
 }
 
 // Quorum
@@ -132,13 +223,36 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	log.Info("Allocated trie memory caches", "clean", common.StorageSize(config.TrieCleanCache)*1024*1024, "dirty", common.StorageSize(config.TrieDirtyCache)*1024*1024)
 
 	// Assemble the Ethereum object
-	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/")
+	var (
+		chainDb   ethdb.Database
+		refreshDb func() error
+		err       error
+	)
+	if config.ReadOnly {
+		// Quorum: serve reads from a database replicated from a writer node
+		chainDb, refreshDb, err = stack.OpenReadOnlyDatabase("chaindata", config.DatabaseCache, config.DatabaseHandles)
+	} else {
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/")
+	}
 	if err != nil {
 		return nil, err
 	}
-	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
-	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
-		return nil, genesisErr
+	// Quorum: restore the emergency read-only mode set by the operator
+	emergency.Load(chainDb)
+	var (
+		chainConfig *params.ChainConfig
+		genesisHash common.Hash
+		genesisErr  error
+	)
+	if config.ReadOnly {
+		if chainConfig, genesisHash, err = loadReadOnlyChainConfig(chainDb); err != nil {
+			return nil, err
+		}
+	} else {
+		chainConfig, genesisHash, genesisErr = core.SetupGenesisBlock(chainDb, config.Genesis)
+		if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+			return nil, genesisErr
+		}
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
@@ -150,11 +264,20 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		}
 	}
 
-	if !rawdb.GetIsQuorumEIP155Activated(chainDb) && chainConfig.ChainID != nil {
+	if !config.ReadOnly && !rawdb.GetIsQuorumEIP155Activated(chainDb) && chainConfig.ChainID != nil {
 		//Upon starting the node, write the flag to disallow changing ChainID/EIP155 block after HF
 		rawdb.WriteQuorumEIP155Activation(chainDb)
 	}
 
+	// Quorum: the private transaction manager is set up before any private
+	// transaction is applied
+	var privacy *private.Privacy
+	if config.Privacy != nil {
+		if privacy, err = SetupPrivacy(stack, config.Privacy); err != nil {
+			return nil, err
+		}
+	}
+
 	eth := &Ethereum{
 		config:                          config,
 		chainDb:                         chainDb,
@@ -169,6 +292,8 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		bloomIndexer:                    NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
 		p2pServer:                       stack.Server(),
 		consensusServicePendingLogsFeed: new(event.Feed),
+		refreshDb:                       refreshDb,
+		privacy:                         privacy,
 	}
 
 	// Quorum: Set protocol Name/Version
@@ -187,6 +312,14 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		quorumConsensusProtocolLengths = quorumProtocol.Lengths
 	}
 
+	// Quorum: the istanbul settings are complete once the engine is created
+	capabilities := localCapabilities
+	if privateHintsEnabled(config) {
+		capabilities |= CapPrivateHints
+	}
+	eth.capabilities = newCapabilityRegistry(capabilities)
+	eth.configDrift = newConfigDriftDetector(consensusConfigSections(chainConfig, &config.Istanbul), stack.Server(), eth.capabilities)
+
 	// force to set the istanbul etherbase to node key address
 	if chainConfig.Istanbul != nil {
 		eth.etherbase = crypto.PubkeyToAddress(stack.GetNodeKey().PublicKey)
@@ -201,7 +334,7 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		log.Info("Initialising Quorum consensus protocol", "name", quorumConsensusProtocolName, "versions", quorumConsensusProtocolVersions, "network", config.NetworkId, "dbversion", dbVer)
 	}
 
-	if !config.SkipBcVersionCheck {
+	if !config.SkipBcVersionCheck && !config.ReadOnly {
 		if bcVersion != nil && *bcVersion > core.BlockChainVersion {
 			return nil, fmt.Errorf("database version is v%d, Geth %s only supports v%d", *bcVersion, params.VersionWithMeta, core.BlockChainVersion)
 		} else if bcVersion == nil || *bcVersion < core.BlockChainVersion {
@@ -226,6 +359,12 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 			SnapshotLimit:       config.SnapshotCache,
 		}
 	)
+	if config.ReadOnly {
+		// Quorum: nothing may be flushed back to the replicated database
+		cacheConfig.TrieCleanJournal = ""
+		cacheConfig.TrieDirtyDisabled = true
+		cacheConfig.SnapshotLimit = 0
+	}
 	newBlockChainFunc := core.NewBlockChain
 	if config.EnableMultitenancy {
 		newBlockChainFunc = core.NewMultitenantBlockChain
@@ -240,8 +379,67 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		eth.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
-	eth.bloomIndexer.Start(eth.blockchain)
+	if !config.ReadOnly {
+		eth.bloomIndexer.Start(eth.blockchain)
+	}
+	// Quorum
+	if err := setupSealPruning(chainDb, chainConfig, config, eth); err != nil {
+		return nil, err
+	}
+	// End Quorum
+	eth.receiptWatchdog = core.NewReceiptWatchdog(eth.blockchain, config.ReceiptVerifySampleRate, config.ReceiptVerifyDegrade)
+	eth.hooks = newHookDispatcher(eth.blockchain)
+	if config.InternalCallIndex && !config.ReadOnly {
+		eth.internalCallIndexer = core.NewInternalCallIndexer(eth.blockchain)
+	}
+	if config.BlockStats && !config.ReadOnly {
+		eth.blockStatsIndexer = core.NewBlockStatsIndexer(eth.blockchain)
+	}
+	if config.AddressTxIndex && !config.ReadOnly {
+		eth.addressTxIndexer = core.NewAddressTxIndexer(eth.blockchain)
+	}
+	if config.PrivatePayloadPrefetch > 0 && private.IsQuorumPrivacyEnabled() && !config.ReadOnly {
+		eth.privatePrefetcher = core.NewPrivatePrefetcher(eth.blockchain, config.PrivatePayloadPrefetch)
+	}
+	if privateHintsEnabled(config) {
+		if eth.privatePrefetcher == nil {
+			log.Warn("Private hints only sent, the private payload prefetching being disabled")
+		}
+		eth.privateHints = newPrivateHints(stack.GetNodeKey(), eth.blockchain, eth.capabilities)
+	}
+	if privateAuditEnabled(config) {
+		eth.privateAudit = newPrivateAudit(eth.chainDb, eth.blockchain.GetReceiptsByHash, config.PrivateAuditors, config.PrivateAuditFetch)
+	}
+	if config.PrivateParallelism > 0 {
+		eth.blockchain.SetPrivateParallelism(config.PrivateParallelism)
+	}
+	if config.SlowImportThreshold > 0 {
+		eth.blockchain.SetSlowImportThreshold(config.SlowImportThreshold)
+	}
+	if depth, limited := maxReorgDepth(chainConfig, config); limited {
+		eth.blockchain.SetMaxReorgDepth(depth)
+	}
+	if len(config.AccessLog.Contracts) > 0 {
+		accesslog.Set(accesslog.New(config.AccessLog, accesslog.LogHook{}))
+	}
+	if config.SlotPolicy != "" {
+		policy, err := slotpolicy.Load(config.SlotPolicy)
+		if err != nil {
+			return nil, err
+		}
+		slotpolicy.Set(policy)
+		stack.RegisterLifecycle(policy)
+	}
+	ethapi.SetSendDefaultsTTL(config.SendDefaultsTTL)
+	if config.StorageLayouts != "" {
+		if eth.storageLayouts, err = storagelayout.Load(config.StorageLayouts); err != nil {
+			return nil, err
+		}
+	}
 
+	if config.ReadOnly {
+		config.TxPool.Journal = ""
+	}
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
@@ -256,6 +454,7 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(chainConfig, checkpoint, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, cacheLimit, config.Whitelist, config.RaftMode); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.capabilities = eth.capabilities
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData, eth.blockchain.Config().IsQuorum))
 
@@ -266,6 +465,15 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		gpoParams.Default = config.Miner.GasPrice
 	}
 	eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
+	// Quorum
+	if config.ConsistencySecret != "" {
+		secret, err := ethapi.LoadConsistencySecret(config.ConsistencySecret)
+		if err != nil {
+			return nil, err
+		}
+		stack.SetConsistencyGuard(ethapi.NewConsistencyGuard(eth.APIBackend, secret, config.ConsistencyWait))
+	}
+	// End Quorum
 
 	eth.dialCandidates, err = eth.setupDiscovery(&stack.Config().P2P)
 	if err != nil {
@@ -275,13 +483,36 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	// Start the RPC service
 	eth.netRPCService = ethapi.NewPublicNetAPI(eth.p2pServer, eth.NetVersion())
 
+	// Quorum: serves the ranges of historical blocks over RPC and HTTP
+	eth.blocksRange = newBlocksRangeServer(eth)
+	stack.RegisterHandler("Blocks range", blocksRangePath, eth.blocksRange)
+	// Quorum: exports the private data of the tenants leaving the node
+	eth.tenantExport = newTenantExporter(eth.blockchain)
+
 	// Register the backend on the node
 	stack.RegisterAPIs(eth.APIs())
-	stack.RegisterProtocols(eth.Protocols())
+	if !config.ReadOnly {
+		stack.RegisterProtocols(eth.Protocols())
+	}
 	stack.RegisterLifecycle(eth)
 	return eth, nil
 }
 
+// Quorum
+//
+// maxReorgDepth returns the maximum reorg depth of the chain, false if
+// unlimited. Istanbul has immediate finality and Raft replays its log, neither
+// reorging a canonical block.
+func maxReorgDepth(chainConfig *params.ChainConfig, config *Config) (uint64, bool) {
+	if config.MaxReorgDepth != nil {
+		return *config.MaxReorgDepth, true
+	}
+	if chainConfig.Istanbul != nil || config.RaftMode {
+		return 0, true
+	}
+	return 0, false
+}
+
 func makeExtraData(extra []byte, isQuorum bool) []byte {
 	if len(extra) == 0 {
 		// create default extradata
@@ -300,6 +531,34 @@ func makeExtraData(extra []byte, isQuorum bool) []byte {
 }
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an Ethereum service
+// Quorum
+//
+// setupSealPruning has the freezer strip the committed seals of the ancient
+// headers, once their verification confirmed, as the pruning is irreversible.
+func setupSealPruning(chainDb ethdb.Database, chainConfig *params.ChainConfig, config *Config, eth *Ethereum) error {
+	if !config.SealPruning {
+		if rawdb.HasPrunedSeals(chainDb) {
+			log.Warn("Committed seals were pruned from the ancient headers, re-sync the headers from a node retaining them to restore them")
+		}
+		return nil
+	}
+	if chainConfig.Istanbul == nil {
+		return errors.New("committed seals can only be pruned on Istanbul chains")
+	}
+	if !config.SealPruningConfirmed {
+		return errors.New("pruning the committed seals of the ancient headers is irreversible, they can only be restored by re-syncing the headers from a node retaining them: the pruning must be confirmed")
+	}
+	rawdb.SetSealPruning(&rawdb.SealPruning{
+		Depth:       config.SealPruningDepth,
+		Checkpoints: config.SealPruningCheckpoints,
+		Verify: func(header *types.Header) error {
+			return eth.engine.VerifyHeader(eth.blockchain, header, true)
+		},
+	})
+	log.Warn("Pruning the committed seals of the ancient headers", "depth", config.SealPruningDepth, "checkpoints", config.SealPruningCheckpoints)
+	return nil
+}
+
 func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, config *Config, notify []string, noverify bool, db ethdb.Database) consensus.Engine {
 	// If proof-of-authority is requested, set it up
 	if chainConfig.Clique != nil {
@@ -313,7 +572,16 @@ func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, co
 		}
 		config.Istanbul.ProposerPolicy = istanbul.ProposerPolicy(chainConfig.Istanbul.ProposerPolicy)
 		config.Istanbul.Ceil2Nby3Block = chainConfig.Istanbul.Ceil2Nby3Block
-		config.Istanbul.AllowedFutureBlockTime = config.Miner.AllowedFutureBlockTime //Quorum
+		config.Istanbul.KeyRotationBlock = chainConfig.Istanbul.KeyRotationBlock
+		// Quorum: the node flag overrides the tolerance of the network
+		config.Istanbul.AllowedFutureBlockTime = istanbul.DefaultConfig.AllowedFutureBlockTime
+		if chainConfig.Istanbul.AllowedFutureBlockTime != nil {
+			config.Istanbul.AllowedFutureBlockTime = *chainConfig.Istanbul.AllowedFutureBlockTime
+		}
+		if config.Miner.AllowedFutureBlockTime != 0 {
+			config.Istanbul.AllowedFutureBlockTime = config.Miner.AllowedFutureBlockTime
+		}
+		log.Info("Istanbul future block tolerance", "seconds", config.Istanbul.AllowedFutureBlockTime)
 
 		return istanbulBackend.New(&config.Istanbul, stack.GetNodeKey(), db)
 	}
@@ -346,6 +614,16 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Quorum
+	filterAPI := filters.NewPublicFilterAPI(s.APIBackend, false)
+	if s.config.SubscriptionReplayBlocks > 0 {
+		filterAPI.EnableReplay(s.config.SubscriptionReplayBlocks, s.config.SubscriptionReplaySize)
+	}
+	filterAPI.SetQuota(s.config.FilterQuota)
+	if err := filterAPI.SetLogsBudget(s.config.LogsBudget); err != nil {
+		log.Warn("Failed to create the log spill directory, pages searched again", "dir", s.config.LogsBudget.SpillDir, "err", err)
+	}
+
 	// Append all the local APIs and return
 	apis = append(apis, []rpc.API{
 		{
@@ -363,6 +641,41 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   downloader.NewPublicDownloaderAPI(s.protocolManager.downloader, s.eventMux),
 			Public:    true,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicBlockProductionAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicTxLatencyAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPrivateStateLayoutAPI(s),
+			Public:    false,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPrivateConfigMigrationAPI(s),
+			Public:    false,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPrivateTenantExportAPI(s),
+			Public:    false,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPrivateAuditAPI(s),
+			Public:    false,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicBlocksRangeAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "miner",
 			Version:   "1.0",
@@ -371,12 +684,18 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.APIBackend, false),
+			Service:   filterAPI,
 			Public:    true,
+			// Quorum - log subscriptions stream private logs
+			SubscriptionScopes: map[string]string{"logs": "eth_getLogs"},
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
 			Service:   NewPrivateAdminAPI(s),
+		}, {
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   filters.NewPrivateFilterAdminAPI(filterAPI),
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
@@ -494,6 +813,9 @@ func (s *Ethereum) SetEtherbase(etherbase common.Address) {
 // is already running, this method adjust the number of threads allowed to use
 // and updates the minimum price required by the transaction pool.
 func (s *Ethereum) StartMining(threads int) error {
+	if s.config.ReadOnly {
+		return ErrReadOnlyNode
+	}
 	// Update the thread count within the consensus engine
 	type threaded interface {
 		SetThreads(threads int)
@@ -594,6 +916,13 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 		quorumProtos := s.quorumConsensusProtocols()
 		protos = append(protos, quorumProtos...)
 	}
+	protos = append(protos, s.capabilities.protocol(), s.configDrift.protocol())
+	if s.privateHints != nil {
+		protos = append(protos, s.privateHints.protocol())
+	}
+	if s.privateAudit != nil {
+		protos = append(protos, s.privateAudit.protocol())
+	}
 	// /end Quorum
 
 	return protos
@@ -607,6 +936,29 @@ func (s *Ethereum) Start() error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers(params.BloomBitsBlocks)
 
+	// Quorum: start verifying stored receipts if enabled
+	s.receiptWatchdog.Start()
+	if s.internalCallIndexer != nil {
+		s.internalCallIndexer.Start()
+	}
+	if s.blockStatsIndexer != nil {
+		s.blockStatsIndexer.Start()
+	}
+	if s.addressTxIndexer != nil {
+		s.addressTxIndexer.Start()
+	}
+	if s.privatePrefetcher != nil {
+		s.privatePrefetcher.Start()
+	}
+	if s.privateHints != nil {
+		s.privateHints.start()
+	}
+	s.hooks.start()
+	emergencyCh := make(chan emergency.State, 1)
+	s.emergencySub = emergency.Subscribe(emergencyCh)
+	go s.watchEmergencyReadOnly(emergencyCh, s.emergencySub)
+	s.reportEmergencySealing(emergency.Get())
+
 	// Figure out a max peers count based on the server limits
 	maxPeers := s.p2pServer.MaxPeers
 	if s.config.LightServ > 0 {
@@ -615,6 +967,17 @@ func (s *Ethereum) Start() error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
+	// Quorum: a read-only node has no networking, it follows the head
+	// published by the writer node sharing its database instead
+	if s.config.ReadOnly {
+		s.headFollower = newHeadFollower(s.config.ReadOnlyHeadFile, s.config.ReadOnlyRefreshInterval, s.refreshDb, s.chainDb, s.blockchain)
+		s.headFollower.start()
+		return nil
+	}
+	if s.config.PublishHeadFile != "" {
+		s.headPublisher = newHeadPublisher(s.config.PublishHeadFile, s.blockchain)
+		s.headPublisher.start()
+	}
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 	return nil
@@ -624,13 +987,41 @@ func (s *Ethereum) Start() error {
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
 	// Stop all the peer-related stuff first.
-	s.protocolManager.Stop()
+	if s.headFollower != nil {
+		s.headFollower.stop()
+	} else {
+		s.protocolManager.Stop()
+	}
+	if s.headPublisher != nil {
+		s.headPublisher.stop()
+	}
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Stop()
+	s.receiptWatchdog.Stop()
+	if s.internalCallIndexer != nil {
+		s.internalCallIndexer.Stop()
+	}
+	if s.blockStatsIndexer != nil {
+		s.blockStatsIndexer.Stop()
+	}
+	if s.tenantExport != nil {
+		s.tenantExport.stop()
+	}
+	if s.addressTxIndexer != nil {
+		s.addressTxIndexer.Stop()
+	}
+	if s.privateHints != nil {
+		s.privateHints.stop()
+	}
+	if s.privatePrefetcher != nil {
+		s.privatePrefetcher.Stop()
+	}
+	s.hooks.stop()
+	s.emergencySub.Unsubscribe()
 	s.blockchain.Stop()
 	s.engine.Close()
 	s.chainDb.Close()
@@ -648,6 +1039,12 @@ func (s *Ethereum) ConsensusServicePendingLogsFeed() *event.Feed {
 	return s.consensusServicePendingLogsFeed
 }
 
+// (Quorum)
+// SendTransactionsToPeer sends the transactions to the peer right away, reporting whether it is connected.
+func (s *Ethereum) SendTransactionsToPeer(id enode.ID, txs types.Transactions) bool {
+	return s.protocolManager.SendTransactionsToPeer(id, txs)
+}
+
 // (Quorum)
 // SubscribePendingLogs starts delivering logs from transactions included in the consensus engine's pending block to the given channel.
 func (s *Ethereum) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
@@ -0,0 +1,329 @@
+package eth
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/private"
+)
+
+// Quorum
+//
+// Ranges of historical blocks are served in chunks, with the requested fields
+// only, by quorum_getBlocksRange and streamed over HTTP on blocksRangePath,
+// each chunk being prefixed by its length as a 4 bytes big endian integer.
+// Every chunk carries the cursor resuming the range after it. The chunks are
+// delayed while the node is busy, importing far behind its peers or using most
+// of the CPU, so that the backfills slow down rather than the node.
+
+const (
+	blocksRangePath = "/quorum/blocksrange"
+
+	blocksRangeMaxBlocks     = 256             // blocks of a chunk
+	blocksRangeSoftMaxBytes  = 4 * 1024 * 1024 // size of a chunk past which no block is added
+	blocksRangeMaxQueryBytes = 64 * 1024
+
+	blocksRangeCPUThreshold  = 0.75 // fraction of the CPU used by the node past which the chunks are delayed
+	blocksRangeLagThreshold  = 32   // blocks behind the peers past which the chunks are delayed
+	blocksRangeThrottleDelay = 250 * time.Millisecond
+	blocksRangeMaxDelay      = 5 * time.Second
+	blocksRangeLoadInterval  = time.Second // minimum interval the CPU is sampled at
+)
+
+var (
+	blocksRangeBytesMeter    = metrics.NewRegisteredMeter("quorum/blocksrange/bytes", nil)
+	blocksRangeThrottleMeter = metrics.NewRegisteredMeter("quorum/blocksrange/throttled", nil)
+
+	errBlocksRangeReorg = errors.New("cursor invalidated by a reorg, resume from an earlier block")
+)
+
+// BlocksRangeQuery selects a range of blocks and their fields.
+type BlocksRangeQuery struct {
+	From   hexutil.Uint64  `json:"from"`
+	To     *hexutil.Uint64 `json:"to"`     // the current head if omitted
+	Fields string          `json:"fields"` // headers (default), transactions, receipts or privateReceipts
+	Cursor string          `json:"cursor"` // resumes the range, from being ignored
+}
+
+// BlocksRangeChunk is a chunk of the blocks of a range.
+type BlocksRangeChunk struct {
+	Blocks   []json.RawMessage `json:"blocks"`
+	Cursor   string            `json:"cursor"`   // resumes the range after the chunk
+	Complete bool              `json:"complete"` // whether the range was served up to its end
+}
+
+// blocksRangeFields are the fields served, each including the previous ones.
+type blocksRangeFields int
+
+const (
+	blocksRangeHeaders blocksRangeFields = iota
+	blocksRangeTransactions
+	blocksRangeReceipts
+	blocksRangePrivateReceipts // receipts of the private transactions the node is party to
+)
+
+func parseBlocksRangeFields(fields string) (blocksRangeFields, error) {
+	switch fields {
+	case "", "headers":
+		return blocksRangeHeaders, nil
+	case "transactions":
+		return blocksRangeTransactions, nil
+	case "receipts":
+		return blocksRangeReceipts, nil
+	case "privateReceipts":
+		return blocksRangePrivateReceipts, nil
+	}
+	return 0, fmt.Errorf("unknown fields %q, expected headers, transactions, receipts or privateReceipts", fields)
+}
+
+// encodeBlocksRangeCursor returns the cursor of the range from next, its
+// previous block being parent.
+func encodeBlocksRangeCursor(next uint64, parent common.Hash) string {
+	cursor := make([]byte, 8+common.HashLength)
+	binary.BigEndian.PutUint64(cursor, next)
+	copy(cursor[8:], parent[:])
+	return hexutil.Encode(cursor)
+}
+
+func decodeBlocksRangeCursor(cursor string) (uint64, common.Hash, error) {
+	dec, err := hexutil.Decode(cursor)
+	if err != nil || len(dec) != 8+common.HashLength {
+		return 0, common.Hash{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return binary.BigEndian.Uint64(dec), common.BytesToHash(dec[8:]), nil
+}
+
+// loadSampler samples the fraction of the CPU used by the node.
+type loadSampler struct {
+	lock    sync.Mutex
+	sampled time.Time
+	cpuTime int64 // centiseconds
+	load    float64
+}
+
+func (s *loadSampler) cpuLoad() float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(s.sampled); elapsed >= blocksRangeLoadInterval {
+		var stats metrics.CPUStats
+		metrics.ReadCPUStats(&stats)
+		if !s.sampled.IsZero() {
+			used := time.Duration(stats.LocalTime-s.cpuTime) * 10 * time.Millisecond
+			s.load = float64(used) / float64(elapsed) / float64(runtime.NumCPU())
+		}
+		s.sampled, s.cpuTime = now, stats.LocalTime
+	}
+	return s.load
+}
+
+// blocksRangeServer serves the ranges of blocks.
+type blocksRangeServer struct {
+	chain   *core.BlockChain
+	lag     func() uint64  // blocks behind the peers
+	cpuLoad func() float64 // fraction of the CPU used
+	isParty func(tx *types.Transaction) bool
+}
+
+func newBlocksRangeServer(eth *Ethereum) *blocksRangeServer {
+	sampler := new(loadSampler)
+	return &blocksRangeServer{
+		chain: eth.blockchain,
+		lag: func() uint64 {
+			// the protocol manager is created after the server
+			if progress := eth.protocolManager.downloader.Progress(); progress.HighestBlock > progress.CurrentBlock {
+				return progress.HighestBlock - progress.CurrentBlock
+			}
+			return 0
+		},
+		cpuLoad: sampler.cpuLoad,
+		isParty: func(tx *types.Transaction) bool {
+			_, _, data, _, err := private.P.Receive(context.Background(), common.BytesToEncryptedPayloadHash(tx.Data()))
+			return err == nil && len(data) > 0
+		},
+	}
+}
+
+// delay returns the time the next chunk is delayed for, zero unless the node
+// is busy.
+func (s *blocksRangeServer) delay() time.Duration {
+	overload := s.cpuLoad() / blocksRangeCPUThreshold
+	if lag := float64(s.lag()) / blocksRangeLagThreshold; lag > overload {
+		overload = lag
+	}
+	if overload <= 1 {
+		return 0
+	}
+	blocksRangeThrottleMeter.Mark(1)
+	if delay := time.Duration(float64(blocksRangeThrottleDelay) * overload); delay < blocksRangeMaxDelay {
+		return delay
+	}
+	return blocksRangeMaxDelay
+}
+
+// chunk serves the next chunk of the range, once paced.
+func (s *blocksRangeServer) chunk(ctx context.Context, query *BlocksRangeQuery) (*BlocksRangeChunk, error) {
+	fields, err := parseBlocksRangeFields(query.Fields)
+	if err != nil {
+		return nil, err
+	}
+	next, parent := uint64(query.From), common.Hash{}
+	if query.Cursor != "" {
+		if next, parent, err = decodeBlocksRangeCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+		if next > 0 && s.chain.GetCanonicalHash(next-1) != parent {
+			return nil, errBlocksRangeReorg
+		}
+	} else if next > 0 {
+		parent = s.chain.GetCanonicalHash(next - 1)
+	}
+	to := s.chain.CurrentHeader().Number.Uint64()
+	if query.To != nil {
+		if uint64(*query.To) < uint64(query.From) {
+			return nil, fmt.Errorf("invalid range [%d, %d]", query.From, *query.To)
+		}
+		if uint64(*query.To) < to {
+			to = uint64(*query.To)
+		}
+	}
+	if delay := s.delay(); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	chunk := &BlocksRangeChunk{Blocks: make([]json.RawMessage, 0)}
+	size := 0
+	for ; next <= to && len(chunk.Blocks) < blocksRangeMaxBlocks && size < blocksRangeSoftMaxBytes; next++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash := s.chain.GetCanonicalHash(next)
+		// the previous block of the chunk was reorged, or the head rewound
+		if hash == (common.Hash{}) || (next > 0 && s.chain.GetHeader(hash, next).ParentHash != parent) {
+			return nil, errBlocksRangeReorg
+		}
+		enc, err := s.encodeBlock(hash, next, fields)
+		if err != nil {
+			return nil, err
+		}
+		chunk.Blocks = append(chunk.Blocks, enc)
+		size += len(enc)
+		parent = hash
+	}
+	blocksRangeBytesMeter.Mark(int64(size))
+	chunk.Cursor = encodeBlocksRangeCursor(next, parent)
+	chunk.Complete = next > to
+	return chunk, nil
+}
+
+// encodeBlock encodes the fields of the block, only reading its body and
+// receipts if requested.
+func (s *blocksRangeServer) encodeBlock(hash common.Hash, number uint64, fields blocksRangeFields) (json.RawMessage, error) {
+	if fields == blocksRangeHeaders {
+		header := s.chain.GetHeader(hash, number)
+		if header == nil {
+			return nil, fmt.Errorf("header #%d not found", number)
+		}
+		return json.Marshal(ethapi.RPCMarshalHeader(header))
+	}
+	block := s.chain.GetBlock(hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	enc, err := ethapi.RPCMarshalBlock(block, true, true)
+	if err != nil {
+		return nil, err
+	}
+	if fields >= blocksRangeReceipts {
+		receipts := s.chain.GetReceiptsByHash(hash)
+		if len(receipts) != len(block.Transactions()) {
+			return nil, fmt.Errorf("receipts of block #%d not found", number)
+		}
+		served := make([]*types.Receipt, len(receipts))
+		for i, tx := range block.Transactions() {
+			// the private receipts are null unless requested, and the node is party
+			if !tx.IsPrivate() || (fields == blocksRangePrivateReceipts && s.isParty(tx)) {
+				served[i] = receipts[i]
+			}
+		}
+		enc["receipts"] = served
+	}
+	return json.Marshal(enc)
+}
+
+// ServeHTTP streams the chunks of the range of the query posted, until it is
+// served or the client disconnects. The errors past the first chunk are sent
+// as a last chunk with an error field, the range being resumable with the
+// cursor of the previous one.
+func (s *blocksRangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var query BlocksRangeQuery
+	if err := json.NewDecoder(io.LimitReader(r.Body, blocksRangeMaxQueryBytes)).Decode(&query); err != nil {
+		http.Error(w, fmt.Sprintf("invalid query: %v", err), http.StatusBadRequest)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	for first := true; ; first = false {
+		chunk, err := s.chunk(r.Context(), &query)
+		if err != nil {
+			if first {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Debug("Blocks range interrupted", "cursor", query.Cursor, "err", err)
+			writeBlocksRangeChunk(w, map[string]string{"error": err.Error()})
+			return
+		}
+		if first {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		if err := writeBlocksRangeChunk(w, chunk); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if chunk.Complete {
+			return
+		}
+		query.Cursor = chunk.Cursor
+	}
+}
+
+// writeBlocksRangeChunk writes the chunk prefixed by its length.
+func writeBlocksRangeChunk(w io.Writer, chunk interface{}) error {
+	enc, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(enc)))
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(enc)
+	return err
+}
@@ -0,0 +1,158 @@
+package eth
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBlocksRangeServer serves a chain of 10 blocks with a transaction
+// each, the node being idle.
+func newTestBlocksRangeServer(t *testing.T) *blocksRangeServer {
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		block.AddTx(tx)
+	}
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 10, generator, nil)
+	t.Cleanup(pm.Stop)
+	return &blocksRangeServer{
+		chain:   pm.blockchain,
+		lag:     func() uint64 { return 0 },
+		cpuLoad: func() float64 { return 0 },
+		isParty: func(*types.Transaction) bool { return true },
+	}
+}
+
+func decodeRangeBlocks(t *testing.T, chunk *BlocksRangeChunk) []map[string]json.RawMessage {
+	blocks := make([]map[string]json.RawMessage, len(chunk.Blocks))
+	for i, enc := range chunk.Blocks {
+		require.NoError(t, json.Unmarshal(enc, &blocks[i]))
+	}
+	return blocks
+}
+
+func TestBlocksRange_Fields(t *testing.T) {
+	server := newTestBlocksRangeServer(t)
+	to := hexutil.Uint64(6)
+
+	chunk, err := server.chunk(context.Background(), &BlocksRangeQuery{From: 2, To: &to, Fields: "receipts"})
+	require.NoError(t, err)
+	assert.True(t, chunk.Complete)
+	blocks := decodeRangeBlocks(t, chunk)
+	require.Len(t, blocks, 5)
+	assert.Equal(t, `"0x2"`, string(blocks[0]["number"]))
+	var receipts []*types.Receipt
+	require.NoError(t, json.Unmarshal(blocks[4]["receipts"], &receipts))
+	require.Len(t, receipts, 1)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipts[0].Status)
+
+	// only the headers are read by default
+	chunk, err = server.chunk(context.Background(), &BlocksRangeQuery{From: 2, To: &to})
+	require.NoError(t, err)
+	blocks = decodeRangeBlocks(t, chunk)
+	assert.Contains(t, blocks[0], "parentHash")
+	assert.NotContains(t, blocks[0], "transactions")
+	assert.NotContains(t, blocks[0], "receipts")
+
+	_, err = server.chunk(context.Background(), &BlocksRangeQuery{Fields: "traces"})
+	assert.Error(t, err)
+	_, err = server.chunk(context.Background(), &BlocksRangeQuery{From: 7, To: &to})
+	assert.Error(t, err)
+}
+
+func TestBlocksRange_Cursor(t *testing.T) {
+	server := newTestBlocksRangeServer(t)
+
+	chunk, err := server.chunk(context.Background(), &BlocksRangeQuery{From: 3, Fields: "transactions"})
+	require.NoError(t, err)
+	assert.True(t, chunk.Complete)
+	assert.Len(t, chunk.Blocks, 8)
+
+	// resumed once the head moves, nothing new meanwhile
+	resumed, err := server.chunk(context.Background(), &BlocksRangeQuery{Cursor: chunk.Cursor})
+	require.NoError(t, err)
+	assert.True(t, resumed.Complete)
+	assert.Empty(t, resumed.Blocks)
+	assert.Equal(t, chunk.Cursor, resumed.Cursor)
+
+	// a cursor on another fork
+	_, err = server.chunk(context.Background(), &BlocksRangeQuery{Cursor: encodeBlocksRangeCursor(4, common.Hash{0x01})})
+	assert.Equal(t, errBlocksRangeReorg, err)
+	_, err = server.chunk(context.Background(), &BlocksRangeQuery{Cursor: "0x01"})
+	assert.Error(t, err)
+}
+
+func TestBlocksRange_Delay(t *testing.T) {
+	server := newTestBlocksRangeServer(t)
+	var (
+		lag  uint64
+		load float64
+	)
+	server.lag = func() uint64 { return lag }
+	server.cpuLoad = func() float64 { return load }
+
+	assert.Zero(t, server.delay())
+	load = 0.5
+	assert.Zero(t, server.delay())
+	load = 1.5
+	assert.Equal(t, 2*blocksRangeThrottleDelay, server.delay())
+	lag = 4 * blocksRangeLagThreshold
+	assert.Equal(t, 4*blocksRangeThrottleDelay, server.delay())
+	lag = 1000 * blocksRangeLagThreshold
+	assert.Equal(t, blocksRangeMaxDelay, server.delay())
+
+	// the delayed chunks are cancelled with the request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := server.chunk(ctx, &BlocksRangeQuery{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestBlocksRange_HTTP(t *testing.T) {
+	ts := httptest.NewServer(newTestBlocksRangeServer(t))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, "application/json", strings.NewReader(`{"from": "0x1", "fields": "receipts"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var chunks []*BlocksRangeChunk
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(resp.Body, length[:]); err == io.EOF {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		enc := make([]byte, binary.BigEndian.Uint32(length[:]))
+		_, err := io.ReadFull(resp.Body, enc)
+		require.NoError(t, err)
+		var chunk BlocksRangeChunk
+		require.NoError(t, json.Unmarshal(enc, &chunk))
+		chunks = append(chunks, &chunk)
+	}
+	require.Len(t, chunks, 1)
+	assert.True(t, chunks[0].Complete)
+	assert.Len(t, chunks[0].Blocks, 10)
+
+	resp, err = http.Post(ts.URL, "application/json", strings.NewReader(`{"fields": "all"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,224 @@
+package eth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Quorum
+//
+// Peers advertise the optional Quorum protocol extensions they support in a
+// bitmap, exchanged over the capabilitiesProtocolName subprotocol when
+// connecting, and the senders of the extension messages check it before
+// sending. The code space of the subprotocol is reserved for the extension
+// messages, as the peers of another version would disagree on the offsets of
+// the subprotocols if its length changed, and the unexpected messages which
+// are well-formed are ignored rather than disconnecting the peer.
+//
+// Peers predating the exchange are assumed to support legacyCapabilities, the
+// extensions whose support is negotiated by their own subprotocol.
+
+const (
+	capabilitiesProtocolName    = "qcap"
+	capabilitiesProtocolVersion = 1
+	capabilitiesProtocolLength  = 16 // never to be changed for this version
+
+	capabilitiesMsg = 0x00
+)
+
+// Capabilities is the bitmap of the optional Quorum protocol extensions.
+type Capabilities uint64
+
+const (
+	CapSelectivePrivateGossip Capabilities = 1 << iota // private transactions only gossiped to their participants
+	CapPrivateResend                                   // requests to resend private transactions
+	CapStateDiff                                       // exchange of state diffs
+	CapConfigDriftHashes                               // exchange of the hashes of the consensus configuration
+	CapPrivateHints                                    // hints of the private payloads to prefetch, advertised if enabled
+)
+
+var capabilityNames = []struct {
+	cap  Capabilities
+	name string
+}{
+	{CapSelectivePrivateGossip, "selectivePrivateGossip"},
+	{CapPrivateResend, "privateResend"},
+	{CapStateDiff, "stateDiff"},
+	{CapConfigDriftHashes, "configDriftHashes"},
+	{CapPrivateHints, "privateHints"},
+}
+
+var (
+	// localCapabilities are the extensions implemented by this version, the
+	// bits of the other ones are reserved for the versions implementing them.
+	localCapabilities = CapConfigDriftHashes
+	// legacyCapabilities are assumed for the peers not exchanging capabilities.
+	legacyCapabilities = CapConfigDriftHashes
+)
+
+var capabilitiesIgnoredMeter = metrics.NewRegisteredMeter("p2p/capabilities/ignored", nil)
+
+// Has returns whether all the capabilities of c are set.
+func (c Capabilities) Has(capabilities Capabilities) bool {
+	return c&capabilities == capabilities
+}
+
+// Names returns the names of the capabilities set, the unknown ones named by
+// their bit.
+func (c Capabilities) Names() []string {
+	names := make([]string, 0)
+	known := Capabilities(0)
+	for _, capability := range capabilityNames {
+		if c.Has(capability.cap) {
+			names = append(names, capability.name)
+		}
+		known |= capability.cap
+	}
+	unknown := c &^ known
+	for bit := uint(0); bit < 64; bit++ {
+		if unknown&(1<<bit) != 0 {
+			names = append(names, fmt.Sprintf("unknown(%d)", bit))
+		}
+	}
+	return names
+}
+
+// capabilitiesPacket is the content of capabilitiesMsg, the fields added by
+// later versions being ignored.
+type capabilitiesPacket struct {
+	Bitmap uint64
+	Rest   []rlp.RawValue `rlp:"tail"`
+}
+
+// PeerCapabilities are the capabilities advertised by a peer.
+type PeerCapabilities struct {
+	Bitmap       hexutil.Uint64 `json:"bitmap"`
+	Capabilities []string       `json:"capabilities"`
+}
+
+// capabilityRegistry exchanges the capabilities with the peers.
+type capabilityRegistry struct {
+	local Capabilities
+
+	lock  sync.RWMutex
+	peers map[enode.ID]Capabilities
+}
+
+func newCapabilityRegistry(local Capabilities) *capabilityRegistry {
+	return &capabilityRegistry{
+		local: local,
+		peers: make(map[enode.ID]Capabilities),
+	}
+}
+
+func (r *capabilityRegistry) protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    capabilitiesProtocolName,
+		Version: capabilitiesProtocolVersion,
+		Length:  capabilitiesProtocolLength,
+		Run:     r.run,
+		NodeInfo: func() interface{} {
+			return &PeerCapabilities{Bitmap: hexutil.Uint64(r.local), Capabilities: r.local.Names()}
+		},
+		PeerInfo: func(id enode.ID) interface{} {
+			if info := r.peerInfo(id); info != nil {
+				return info
+			}
+			return nil
+		},
+	}
+}
+
+// run advertises the local capabilities and records the ones of the peer
+// until it disconnects.
+func (r *capabilityRegistry) run(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	defer r.remove(p.ID())
+
+	// sent concurrently, like the status of the eth handshake
+	go p2p.Send(rw, capabilitiesMsg, &capabilitiesPacket{Bitmap: uint64(r.local)})
+	for {
+		if err := r.handleMsg(p, rw); err != nil {
+			p.Log().Debug("Quorum capabilities message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+func (r *capabilityRegistry) handleMsg(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > protocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	if msg.Code == capabilitiesMsg {
+		var packet capabilitiesPacket
+		if err := msg.Decode(&packet); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		r.lock.Lock()
+		r.peers[p.ID()] = Capabilities(packet.Bitmap)
+		r.lock.Unlock()
+		p.Log().Debug("Quorum capabilities of peer", "capabilities", Capabilities(packet.Bitmap).Names())
+		return nil
+	}
+	// an extension of a later version, or not advertised
+	var raw rlp.RawValue
+	if err := msg.Decode(&raw); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	capabilitiesIgnoredMeter.Mark(1)
+	p.Log().Debug("Ignoring unexpected Quorum extension message", "code", msg.Code, "size", msg.Size)
+	return nil
+}
+
+func (r *capabilityRegistry) remove(id enode.ID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.peers, id)
+}
+
+// capabilities returns the capabilities advertised by the peer, and whether it
+// advertised them.
+func (r *capabilityRegistry) capabilities(id enode.ID) (Capabilities, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	capabilities, ok := r.peers[id]
+	return capabilities, ok
+}
+
+// supports returns whether the peer supports the capabilities, the ones of the
+// peers not advertising any being legacyCapabilities. All capabilities are
+// supported without registry.
+func (r *capabilityRegistry) supports(id enode.ID, capabilities Capabilities) bool {
+	if r == nil {
+		return true
+	}
+	if advertised, ok := r.capabilities(id); ok {
+		return advertised.Has(capabilities)
+	}
+	return legacyCapabilities.Has(capabilities)
+}
+
+// peerInfo returns the capabilities advertised by the peer, nil if none.
+func (r *capabilityRegistry) peerInfo(id enode.ID) *PeerCapabilities {
+	if r == nil {
+		return nil
+	}
+	capabilities, ok := r.capabilities(id)
+	if !ok {
+		return nil
+	}
+	return &PeerCapabilities{Bitmap: hexutil.Uint64(capabilities), Capabilities: capabilities.Names()}
+}
@@ -0,0 +1,108 @@
+package eth
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capFuture is an extension of a later version.
+const capFuture Capabilities = 1 << 10
+
+// connectRegistries runs the protocol of the registries against each other,
+// a being the registry of peer a. It returns the message pipes of a and b,
+// and the channel of the errors stopping the protocols.
+func connectRegistries(a, b *capabilityRegistry) (*p2p.MsgPipeRW, *p2p.MsgPipeRW, chan error) {
+	rwA, rwB := p2p.MsgPipe()
+	errc := make(chan error, 2)
+	go func() { errc <- a.run(p2p.NewPeer(enode.ID{0x0b}, "b", nil), rwA) }()
+	go func() { errc <- b.run(p2p.NewPeer(enode.ID{0x0a}, "a", nil), rwB) }()
+	return rwA, rwB, errc
+}
+
+func waitCapabilities(t *testing.T, r *capabilityRegistry, id enode.ID, want Capabilities) {
+	for i := 0; i < 100; i++ {
+		if capabilities, ok := r.capabilities(id); ok && capabilities == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("capabilities of %v not %v", id, want.Names())
+}
+
+func TestCapabilities_Names(t *testing.T) {
+	assert.Equal(t, []string{"privateResend", "configDriftHashes", "unknown(10)"}, (CapPrivateResend | CapConfigDriftHashes | capFuture).Names())
+	assert.Empty(t, Capabilities(0).Names())
+	assert.True(t, (CapStateDiff | CapConfigDriftHashes).Has(CapStateDiff))
+	assert.False(t, CapConfigDriftHashes.Has(CapStateDiff|CapConfigDriftHashes))
+}
+
+func TestCapabilities_MixedVersions(t *testing.T) {
+	older := newCapabilityRegistry(localCapabilities)
+	newer := newCapabilityRegistry(localCapabilities | CapStateDiff | capFuture)
+	rwOlder, rwNewer, errc := connectRegistries(older, newer)
+	defer rwOlder.Close()
+
+	waitCapabilities(t, older, enode.ID{0x0b}, localCapabilities|CapStateDiff|capFuture)
+	waitCapabilities(t, newer, enode.ID{0x0a}, localCapabilities)
+
+	// the newer peer does not send the extensions unknown to the older one
+	assert.False(t, newer.supports(enode.ID{0x0a}, CapStateDiff))
+	assert.True(t, newer.supports(enode.ID{0x0a}, CapConfigDriftHashes))
+	assert.True(t, older.supports(enode.ID{0x0b}, CapStateDiff))
+	info := older.protocol().PeerInfo(enode.ID{0x0b}).(*PeerCapabilities)
+	assert.Equal(t, []string{"stateDiff", "configDriftHashes", "unknown(10)"}, info.Capabilities)
+
+	// the older peer ignores the extension messages it does not know, and the
+	// fields added to the capabilities
+	require.NoError(t, p2p.Send(rwNewer, 0x05, []interface{}{uint64(1), "state diff"}))
+	require.NoError(t, p2p.Send(rwNewer, capabilitiesMsg, []interface{}{uint64(localCapabilities), "later field"}))
+	waitCapabilities(t, older, enode.ID{0x0b}, localCapabilities)
+	// and the newer one those not advertised
+	require.NoError(t, p2p.Send(rwOlder, 0x05, []interface{}{}))
+	select {
+	case err := <-errc:
+		t.Fatalf("peer disconnected: %v", err)
+	default:
+	}
+
+	// malformed messages still disconnect
+	require.NoError(t, rwNewer.WriteMsg(p2p.Msg{Code: 0x05, Size: 1, Payload: bytes.NewReader([]byte{0xc5})}))
+	select {
+	case err := <-errc:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("peer not disconnected")
+	}
+}
+
+func TestCapabilities_Legacy(t *testing.T) {
+	registry := newCapabilityRegistry(localCapabilities)
+	// a peer predating the exchange
+	assert.True(t, registry.supports(enode.ID{0x0c}, CapConfigDriftHashes))
+	assert.False(t, registry.supports(enode.ID{0x0c}, CapStateDiff))
+	assert.Nil(t, registry.protocol().PeerInfo(enode.ID{0x0c}))
+	assert.True(t, (*capabilityRegistry)(nil).supports(enode.ID{0x0c}, CapStateDiff))
+}
+
+func TestCapabilities_ConfigDriftNotAdvertised(t *testing.T) {
+	config := istanbulChainConfig()
+	sections := consensusConfigSections(config, istanbul.DefaultConfig)
+	// b advertised no configuration drift hashes to a
+	registryA := newCapabilityRegistry(localCapabilities)
+	registryA.peers[enode.ID{0x0b}] = 0
+	a := newConfigDriftDetector(sections, nil, registryA)
+	b := newConfigDriftDetector(sections, nil, newCapabilityRegistry(localCapabilities))
+
+	disconnect := connectDetectors(t, a, b)
+	defer disconnect()
+	waitReport(t, a, 1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, b.report().Peers)
+}
@@ -29,9 +29,13 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/accesslog"
 )
 
 // DefaultFullGPOConfig contains default gasprice oracle settings for full node.
@@ -199,4 +203,137 @@ type Config struct {
 
 	// Quorum
 	EnableMultitenancy bool
+
+	// Quorum
+	// ReadOnly serves RPC/GraphQL from a chain database replicated from a
+	// writer node. The database is never written to and the chain head is
+	// taken from ReadOnlyHeadFile, polled every ReadOnlyRefreshInterval.
+	ReadOnly                bool
+	ReadOnlyHeadFile        string
+	ReadOnlyRefreshInterval time.Duration
+
+	// Quorum
+	// ReceiptVerifySampleRate enables the receipt watchdog, verifying every new
+	// block and one in ReceiptVerifySampleRate historical blocks. With
+	// ReceiptVerifyDegrade, receipts of corrupted blocks are refused.
+	ReceiptVerifySampleRate uint64
+	ReceiptVerifyDegrade    bool
+
+	// Quorum
+	// PublishHeadFile, when set on a writer node, is kept up to date with the
+	// current chain head for read-only nodes sharing its database.
+	PublishHeadFile string
+
+	// Quorum
+	// InternalCallIndex indexes the calls made by contracts during the
+	// execution of transactions, see core.InternalCallIndexer.
+	InternalCallIndex bool
+
+	// Quorum
+	// BlockStats aggregates statistics of the canonical blocks per block and
+	// per day, see core.BlockStatsIndexer.
+	BlockStats bool
+
+	// Quorum
+	// AddressTxIndex indexes the canonical transactions by sender and by
+	// recipient, see core.AddressTxIndexer.
+	AddressTxIndex bool
+
+	// Quorum
+	// PrivatePayloadPrefetch is the number of concurrent requests fetching the
+	// private payloads of blocks ahead of their execution, 0 to disable it.
+	PrivatePayloadPrefetch int
+
+	// Quorum
+	// PrivateHints hints the peers party to the private transactions of the
+	// imported blocks to prefetch their payloads, and prefetches the payloads
+	// hinted by the peers.
+	PrivateHints bool
+
+	// Quorum
+	// PrivateAuditors are the nodes served the internals of the private
+	// transactions the node is party to, a node-local policy.
+	PrivateAuditors []enode.ID
+
+	// Quorum
+	// PrivateAuditFetch fetches the internals of the private transactions from
+	// the peers auditing them, for quorum_fetchMarkerInternals.
+	PrivateAuditFetch bool
+
+	// Quorum
+	// PrivateParallelism is the number of private transactions with execution
+	// hints executed concurrently while importing a block, 0 to disable it.
+	PrivateParallelism int
+
+	// Quorum
+	// SlowImportThreshold is the import time above which the breakdown of the
+	// import of a block is logged, 0 to disable it.
+	SlowImportThreshold time.Duration
+
+	// Quorum
+	// MaxReorgDepth is the maximum number of canonical blocks a reorg may drop,
+	// nil for the default of the consensus: 0 for Istanbul and Raft, which
+	// never reorg, unlimited otherwise.
+	MaxReorgDepth *uint64 `toml:",omitempty"`
+
+	// Quorum
+	// SubscriptionReplayBlocks is the number of blocks of newHeads and logs
+	// events kept for durable subscriptions to resume from, 0 to disable them.
+	// SubscriptionReplaySize bounds the size in bytes of the events kept for
+	// each subscription type, 0 for no bound.
+	SubscriptionReplayBlocks uint64
+	SubscriptionReplaySize   int
+
+	// Quorum
+	// FilterQuota limits the filters and subscriptions of each RPC client.
+	FilterQuota filters.QuotaConfig
+
+	// Quorum
+	// LogsBudget bounds the logs of the queries and where the logs past a page
+	// are spilled to.
+	LogsBudget filters.LogsBudget
+
+	// Quorum
+	// AccessLog selects the private contracts whose state reads are logged,
+	// see accesslog.Logger.
+	AccessLog accesslog.Config
+
+	// Quorum
+	// SlotPolicy is the file of the storage slots protected from the
+	// transactions submitted over RPC by unauthorized senders, see slotpolicy.
+	SlotPolicy string
+
+	// Quorum
+	// SendDefaultsTTL is the maximum time the private transaction defaults of
+	// the RPC clients are kept, 0 disabling them.
+	SendDefaultsTTL time.Duration
+
+	// Quorum
+	// StorageLayouts is the file of the storage layouts of the private
+	// contracts described by quorum_describePrivateState, see storagelayout.
+	StorageLayouts string
+
+	// Quorum
+	// ConsistencySecret is the file of the hex secret shared by the nodes
+	// behind a load balancer, signing the consistency tokens the writes over
+	// HTTP return, see ethapi.ConsistencyGuard. ConsistencyWait is the time
+	// the requests presenting a token are held back at most.
+	ConsistencySecret string
+	ConsistencyWait   time.Duration
+
+	// Quorum
+	// SealPruning strips the committed seals of the Istanbul headers moved to
+	// the ancient store once they are SealPruningDepth blocks behind the head,
+	// the blocks multiple of SealPruningCheckpoints keeping them. The seals
+	// can then only be restored by re-syncing the headers from a node
+	// retaining them, which SealPruningConfirmed acknowledges.
+	SealPruning            bool
+	SealPruningConfirmed   bool
+	SealPruningDepth       uint64
+	SealPruningCheckpoints uint64
+
+	// Quorum
+	// Privacy is the privacy configuration the node sets the private
+	// transaction manager up with, nil if geth sets it up, see private.Config.
+	Privacy *private.Config `toml:"-"`
 }
@@ -0,0 +1,320 @@
+package eth
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Quorum
+//
+// Peers exchange the hashes of the sections of their consensus-critical
+// configuration over the configDriftProtocolName subprotocol, when connecting
+// and every configDriftInterval. Peers with a different configuration are
+// reported but never disconnected, as a drift is not always fatal. Node-local
+// settings are left out of the hashes.
+
+const (
+	configDriftProtocolName    = "cfgdrift"
+	configDriftProtocolVersion = 1
+	configDriftProtocolLength  = 1
+
+	configHashesMsg = 0x00
+
+	configDriftInterval = 5 * time.Minute
+)
+
+var (
+	configDriftPeersGauge      = metrics.NewRegisteredGauge("p2p/configdrift/peers", nil)
+	configDriftMismatchesMeter = metrics.NewRegisteredMeter("p2p/configdrift/mismatches", nil)
+)
+
+// configSectionHash is the hash of a top-level section of the configuration.
+type configSectionHash struct {
+	Name string
+	Hash common.Hash
+}
+
+// configHashesPacket is the content of configHashesMsg.
+type configHashesPacket struct {
+	Sections []configSectionHash
+}
+
+// consensusConfigSections returns the hashes of the consensus-critical sections
+// of the configuration, sorted by name.
+func consensusConfigSections(chainConfig *params.ChainConfig, istanbulConfig *istanbul.Config) []configSectionHash {
+	sections := map[string]interface{}{
+		"chain": map[string]interface{}{
+			"chainId":             chainConfig.ChainID,
+			"homesteadBlock":      chainConfig.HomesteadBlock,
+			"daoForkBlock":        chainConfig.DAOForkBlock,
+			"daoForkSupport":      chainConfig.DAOForkSupport,
+			"eip150Block":         chainConfig.EIP150Block,
+			"eip150Hash":          chainConfig.EIP150Hash,
+			"eip155Block":         chainConfig.EIP155Block,
+			"eip158Block":         chainConfig.EIP158Block,
+			"byzantiumBlock":      chainConfig.ByzantiumBlock,
+			"constantinopleBlock": chainConfig.ConstantinopleBlock,
+			"petersburgBlock":     chainConfig.PetersburgBlock,
+			"istanbulBlock":       chainConfig.IstanbulBlock,
+			"muirGlacierBlock":    chainConfig.MuirGlacierBlock,
+			"yoloV1Block":         chainConfig.YoloV1Block,
+			"ewasmBlock":          chainConfig.EWASMBlock,
+		},
+		"quorum": map[string]interface{}{
+			"isQuorum":                 chainConfig.IsQuorum,
+			"txnSizeLimit":             chainConfig.TransactionSizeLimit,
+			"maxCodeSize":              chainConfig.MaxCodeSize,
+			"maxCodeSizeChangeBlock":   chainConfig.MaxCodeSizeChangeBlock,
+			"maxCodeSizeConfig":        chainConfig.MaxCodeSizeConfig,
+			"qip714Block":              chainConfig.QIP714Block,
+			"privacyEnhancementsBlock": chainConfig.PrivacyEnhancementsBlock,
+		},
+	}
+	if chainConfig.PrivatePayloadHashBlock != nil {
+		// only hashed once scheduled, to agree with the nodes unaware of it
+		sections["quorum"].(map[string]interface{})["privatePayloadHashBlock"] = chainConfig.PrivatePayloadHashBlock
+	}
+	if chainConfig.DeployerAllowList != nil {
+		sections["quorum"].(map[string]interface{})["deployerAllowList"] = chainConfig.DeployerAllowList
+	}
+	consensus := map[string]interface{}{
+		"ethash": chainConfig.Ethash != nil,
+		"clique": chainConfig.Clique,
+	}
+	if chainConfig.Istanbul != nil && istanbulConfig != nil {
+		// the round timeout is left out, it only delays the round changes
+		consensus["istanbul"] = map[string]interface{}{
+			"blockPeriod":            istanbulConfig.BlockPeriod,
+			"policy":                 istanbulConfig.ProposerPolicy,
+			"epoch":                  istanbulConfig.Epoch,
+			"ceil2Nby3Block":         istanbulConfig.Ceil2Nby3Block,
+			"allowedFutureBlockTime": istanbulConfig.AllowedFutureBlockTime,
+			"keyRotationBlock":       istanbulConfig.KeyRotationBlock,
+		}
+	}
+	sections["consensus"] = consensus
+
+	hashes := make([]configSectionHash, 0, len(sections))
+	for name, section := range sections {
+		// maps are encoded with sorted keys
+		enc, err := json.Marshal(section)
+		if err != nil {
+			panic(err)
+		}
+		hashes = append(hashes, configSectionHash{Name: name, Hash: crypto.Keccak256Hash(enc)})
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i].Name < hashes[j].Name })
+	return hashes
+}
+
+// PeerConfigDrift is the comparison of the configuration of a peer with the
+// local one.
+type PeerConfigDrift struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Drifted     bool      `json:"drifted"`
+	Sections    []string  `json:"differingSections,omitempty"`
+	LastChecked time.Time `json:"lastChecked"`
+}
+
+// ConfigDriftReport summarizes the configuration drift of the connected
+// peers.
+type ConfigDriftReport struct {
+	Local       map[string]common.Hash `json:"local"`
+	Peers       int                    `json:"peers"` // connected peers which sent their configuration
+	Drifted     int                    `json:"drifted"`
+	PeerDrifts  []*PeerConfigDrift     `json:"peerDrifts"`
+	Unsupported int                    `json:"unsupported"` // connected peers without the protocol
+}
+
+// configDriftDetector compares the configuration of the peers with the local
+// one.
+type configDriftDetector struct {
+	local        []configSectionHash
+	server       *p2p.Server
+	capabilities *capabilityRegistry // nil to send to all the peers
+
+	lock  sync.Mutex
+	peers map[enode.ID]*PeerConfigDrift
+}
+
+func newConfigDriftDetector(local []configSectionHash, server *p2p.Server, capabilities *capabilityRegistry) *configDriftDetector {
+	return &configDriftDetector{
+		local:        local,
+		server:       server,
+		capabilities: capabilities,
+		peers:        make(map[enode.ID]*PeerConfigDrift),
+	}
+}
+
+func (d *configDriftDetector) protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:     configDriftProtocolName,
+		Version:  configDriftProtocolVersion,
+		Length:   configDriftProtocolLength,
+		Run:      d.run,
+		NodeInfo: func() interface{} { return d.localSections() },
+		PeerInfo: func(id enode.ID) interface{} {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			if drift, ok := d.peers[id]; ok {
+				copied := *drift
+				return &copied
+			}
+			return nil
+		},
+	}
+}
+
+// run exchanges the configuration hashes with the peer until it disconnects.
+// It never returns on its own, as that would disconnect the peer.
+func (d *configDriftDetector) run(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	done := make(chan struct{})
+	defer close(done)
+	defer d.remove(p.ID())
+
+	go func() {
+		ticker := time.NewTicker(configDriftInterval)
+		defer ticker.Stop()
+		for {
+			// the peers without the capability still receive the messages
+			// of the others, so keep the protocol running
+			if d.capabilities.supports(p.ID(), CapConfigDriftHashes) {
+				if err := p2p.Send(rw, configHashesMsg, &configHashesPacket{Sections: d.local}); err != nil {
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Code == configHashesMsg {
+			var packet configHashesPacket
+			if err := msg.Decode(&packet); err != nil {
+				p.Log().Debug("Invalid configuration hashes", "err", err)
+			} else {
+				d.compare(p, packet.Sections)
+			}
+		}
+		msg.Discard()
+	}
+}
+
+// compare records the sections of the configuration of the peer which differ
+// from the local ones, logging them when they change. Sections unknown to
+// either side are not compared.
+func (d *configDriftDetector) compare(p *p2p.Peer, remote []configSectionHash) {
+	remoteHashes := make(map[string]common.Hash, len(remote))
+	for _, section := range remote {
+		remoteHashes[section.Name] = section.Hash
+	}
+	var differing []string
+	for _, section := range d.local {
+		if hash, ok := remoteHashes[section.Name]; ok && hash != section.Hash {
+			differing = append(differing, section.Name)
+		}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	previous := d.peers[p.ID()]
+	drift := &PeerConfigDrift{
+		ID:          p.ID().String(),
+		Name:        p.Name(),
+		Drifted:     len(differing) > 0,
+		Sections:    differing,
+		LastChecked: time.Now(),
+	}
+	d.peers[p.ID()] = drift
+	if drift.Drifted {
+		configDriftMismatchesMeter.Mark(1)
+		if previous == nil || !equalSections(previous.Sections, differing) {
+			log.Warn("Consensus configuration differs from peer", "peer", p.ID(), "name", p.Name(), "addr", p.RemoteAddr(), "sections", differing)
+		}
+	} else if previous != nil && previous.Drifted {
+		log.Info("Consensus configuration of peer matches again", "peer", p.ID(), "name", p.Name())
+	}
+	d.updateGauge()
+}
+
+func (d *configDriftDetector) remove(id enode.ID) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.peers, id)
+	d.updateGauge()
+}
+
+// updateGauge sets the number of drifted peers, the lock being held.
+func (d *configDriftDetector) updateGauge() {
+	drifted := 0
+	for _, drift := range d.peers {
+		if drift.Drifted {
+			drifted++
+		}
+	}
+	configDriftPeersGauge.Update(int64(drifted))
+}
+
+func (d *configDriftDetector) localSections() map[string]common.Hash {
+	sections := make(map[string]common.Hash, len(d.local))
+	for _, section := range d.local {
+		sections[section.Name] = section.Hash
+	}
+	return sections
+}
+
+// report summarizes the drift of the connected peers.
+func (d *configDriftDetector) report() *ConfigDriftReport {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	report := &ConfigDriftReport{Local: d.localSections(), PeerDrifts: make([]*PeerConfigDrift, 0, len(d.peers))}
+	for _, drift := range d.peers {
+		copied := *drift
+		report.PeerDrifts = append(report.PeerDrifts, &copied)
+		if drift.Drifted {
+			report.Drifted++
+		}
+	}
+	sort.Slice(report.PeerDrifts, func(i, j int) bool { return report.PeerDrifts[i].ID < report.PeerDrifts[j].ID })
+	report.Peers = len(report.PeerDrifts)
+	if d.server != nil {
+		if unsupported := d.server.PeerCount() - report.Peers; unsupported > 0 {
+			report.Unsupported = unsupported
+		}
+	}
+	return report
+}
+
+func equalSections(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
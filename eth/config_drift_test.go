@@ -0,0 +1,101 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectDetectors runs the protocol of the detectors against each other,
+// returning a function disconnecting them.
+func connectDetectors(t *testing.T, a, b *configDriftDetector) func() {
+	rwA, rwB := p2p.MsgPipe()
+	peerA := p2p.NewPeer(enode.ID{0x0a}, "a", nil)
+	peerB := p2p.NewPeer(enode.ID{0x0b}, "b", nil)
+	errc := make(chan error, 2)
+	go func() { errc <- a.run(peerB, rwA) }()
+	go func() { errc <- b.run(peerA, rwB) }()
+	return func() {
+		rwA.Close()
+		rwB.Close()
+		for i := 0; i < 2; i++ {
+			select {
+			case <-errc:
+			case <-time.After(time.Second):
+				t.Fatal("protocol not stopped")
+			}
+		}
+	}
+}
+
+func waitReport(t *testing.T, d *configDriftDetector, peers int) *ConfigDriftReport {
+	for i := 0; i < 100; i++ {
+		if report := d.report(); report.Peers == peers {
+			return report
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("peers not reported")
+	return nil
+}
+
+func istanbulChainConfig() *params.ChainConfig {
+	config := *params.QuorumTestChainConfig
+	config.Ethash = nil
+	config.Istanbul = &params.IstanbulConfig{Epoch: 30000}
+	config.MaxCodeSizeConfig = []params.MaxCodeConfigStruct{{Block: big.NewInt(10), Size: 32}}
+	return &config
+}
+
+func TestConfigDrift_Detected(t *testing.T) {
+	localChain, remoteChain := istanbulChainConfig(), istanbulChainConfig()
+	// a validator missing a transition
+	remoteChain.MaxCodeSizeConfig = nil
+	localIstanbul, remoteIstanbul := *istanbul.DefaultConfig, *istanbul.DefaultConfig
+	remoteIstanbul.BlockPeriod = 5
+	// node-local settings are not compared
+	remoteIstanbul.RequestTimeout = 1
+	local := newConfigDriftDetector(consensusConfigSections(localChain, &localIstanbul), nil, nil)
+	remote := newConfigDriftDetector(consensusConfigSections(remoteChain, &remoteIstanbul), nil, nil)
+
+	disconnect := connectDetectors(t, local, remote)
+	report := waitReport(t, local, 1)
+	assert.Equal(t, 1, report.Drifted)
+	require.Len(t, report.PeerDrifts, 1)
+	assert.Equal(t, enode.ID{0x0b}.String(), report.PeerDrifts[0].ID)
+	assert.Equal(t, []string{"consensus", "quorum"}, report.PeerDrifts[0].Sections)
+	assert.Equal(t, report.PeerDrifts[0], local.protocol().PeerInfo(enode.ID{0x0b}))
+
+	// the peers stay connected until they disconnect
+	disconnect()
+	assert.Zero(t, local.report().Peers)
+}
+
+func TestConfigDrift_Matching(t *testing.T) {
+	config := istanbulChainConfig()
+	istanbulConfig := *istanbul.DefaultConfig
+	local := newConfigDriftDetector(consensusConfigSections(config, &istanbulConfig), nil, nil)
+	remote := newConfigDriftDetector(consensusConfigSections(istanbulChainConfig(), &istanbulConfig), nil, nil)
+
+	disconnect := connectDetectors(t, local, remote)
+	defer disconnect()
+	report := waitReport(t, local, 1)
+	assert.Zero(t, report.Drifted)
+	assert.False(t, report.PeerDrifts[0].Drifted)
+	assert.Len(t, report.Local, 3)
+}
+
+func TestConfigDrift_UnknownSectionsIgnored(t *testing.T) {
+	config := istanbulChainConfig()
+	local := newConfigDriftDetector(consensusConfigSections(config, istanbul.DefaultConfig), nil, nil)
+	// a newer version with more sections
+	local.compare(p2p.NewPeer(enode.ID{0x0c}, "c", nil), append(local.local, configSectionHash{Name: "gasfree"}))
+	assert.Zero(t, local.report().Drifted)
+}
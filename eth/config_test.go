@@ -1,9 +1,21 @@
 package eth
 
 import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	http2 "github.com/ethereum/go-ethereum/common/http"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/private"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestQuorumDefautConfig(t *testing.T) {
@@ -19,3 +31,50 @@ func TestQuorumDefautConfig(t *testing.T) {
 		assert.Equal(t, v.expected, v.actual, k+" value mismatch")
 	}
 }
+
+// Tests that two nodes of a process are set up with their own privacy
+// configuration, PRIVATE_CONFIG being left to geth.
+func TestPrivacyConfig_InProcessNodes(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	os.Setenv("PRIVATE_CONFIG", filepath.Join(t.TempDir(), "missing.toml"))
+	defer os.Unsetenv("PRIVATE_CONFIG")
+
+	tessera := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/version":
+			w.Write([]byte("1.0.0"))
+		case "/version/api":
+			w.Write([]byte(`["1.0"]`))
+		}
+	}))
+	defer tessera.Close()
+	ptm := http2.DefaultConfig
+	ptm.SetHttpUrl(tessera.URL)
+
+	newNode := func(privacy *private.Config) (*node.Node, *Ethereum) {
+		stack, err := node.New(&node.Config{DataDir: t.TempDir()})
+		require.NoError(t, err)
+		t.Cleanup(func() { stack.Close() })
+		config := &Config{Genesis: core.DeveloperGenesisBlock(0, common.Address{}), Privacy: privacy}
+		config.Ethash.PowMode = ethash.ModeFake
+		config.Miner.GasPrice = big.NewInt(1)
+		ethereum, err := New(stack, config)
+		require.NoError(t, err)
+		return stack, ethereum
+	}
+	stack, withTessera := newNode(&private.Config{TransactionManager: ptm, CacheDiskSize: 1024 * 1024})
+	assert.Equal(t, "Tessera", withTessera.PrivateTransactionManager().Name())
+	assert.True(t, private.IsQuorumPrivacyEnabled())
+	assert.DirExists(t, stack.ResolvePath("ptmcache"))
+
+	disabled := private.DisabledConfig
+	_, withoutPTM := newNode(&disabled)
+	assert.Equal(t, "NotInUse", withoutPTM.PrivateTransactionManager().Name())
+	assert.Equal(t, "Tessera", withTessera.PrivateTransactionManager().Name())
+	assert.False(t, private.IsQuorumPrivacyEnabled())
+
+	// the legacy environment variable is still read by geth
+	_, err := private.LegacyConfig()
+	assert.Error(t, err)
+}
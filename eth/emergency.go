@@ -0,0 +1,92 @@
+package eth
+
+import (
+	"context"
+	"net"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/emergency"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// The emergency read-only mode, see core/emergency, is set by the operator
+// with admin_setReadOnly. The RPC write paths check it in CheckWritable, the
+// transaction pool and the sealers on their own.
+
+// requestor identifies the caller of a request: the identity of its access
+// token, else its remote address, else local for IPC.
+func requestor(ctx context.Context) string {
+	authToken, _ := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	if identity := multitenancy.TokenIdentity(authToken); identity != "" {
+		return identity
+	}
+	if remote, ok := ctx.Value("remote").(string); ok && remote != "" {
+		if host, _, err := net.SplitHostPort(remote); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + remote
+	}
+	return "local"
+}
+
+// hasAdminScope returns whether the access token of the request, if any,
+// grants all the methods of the admin service.
+func hasAdminScope(ctx context.Context) bool {
+	authToken, ok := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	if !ok {
+		return true
+	}
+	for _, authority := range authToken.GetAuthorities() {
+		if (authority.Service == "*" || authority.Service == "admin") && authority.Method == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// watchEmergencyReadOnly reports how the sealing is affected by the changes of
+// the emergency read-only mode until the subscription ends.
+func (s *Ethereum) watchEmergencyReadOnly(ch <-chan emergency.State, sub event.Subscription) {
+	for {
+		select {
+		case state := <-ch:
+			s.reportEmergencySealing(state)
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+// reportEmergencySealing logs how the sealing stops in emergency read-only
+// mode. An istanbul validator keeps validating, its turns to propose ending in
+// round changes, which stops the chain if it is the only validator. Raft hands
+// the leadership over on its own.
+func (s *Ethereum) reportEmergencySealing(state emergency.State) {
+	if !state.Enabled || s.config.RaftMode {
+		return
+	}
+	if scheduler, ok := s.engine.(consensus.MaintenanceScheduler); ok {
+		window, err := scheduler.MaintenanceWindow(s.blockchain, 1)
+		if err != nil {
+			log.Warn("Failed to get the validators in emergency read-only mode", "err", err)
+			return
+		}
+		switch {
+		case window.Position < 0:
+		case window.Validators == 1:
+			log.Error("Only validator in emergency read-only mode, the chain stops until the mode is cleared")
+		default:
+			log.Warn("Validating without proposing in emergency read-only mode, the turns of the node end in round changes", "validators", window.Validators, "policy", window.Policy)
+		}
+		return
+	}
+	if s.IsMining() {
+		log.Warn("Not sealing blocks in emergency read-only mode")
+	}
+}
@@ -0,0 +1,53 @@
+package eth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/emergency"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withAuthorities(ctx context.Context, authorities ...*proto.GrantedAuthority) context.Context {
+	return context.WithValue(ctx, rpc.CtxPreauthenticatedToken, &proto.PreAuthenticatedAuthenticationToken{Authorities: authorities})
+}
+
+func TestPrivateAdminAPI_SetReadOnly(t *testing.T) {
+	api := NewPrivateAdminAPI(&Ethereum{config: &Config{}, chainDb: rawdb.NewMemoryDatabase()})
+	defer emergency.Set(api.eth.ChainDb(), false, "", "test")
+
+	_, err := api.SetReadOnly(context.Background(), true, nil)
+	assert.Error(t, err, "the reason is required")
+	assert.False(t, emergency.Get().Enabled)
+
+	reason := "incident 42"
+	ctx := context.WithValue(context.Background(), "remote", "10.0.0.1:8545")
+	state, err := api.SetReadOnly(ctx, true, &reason)
+	require.NoError(t, err)
+	assert.True(t, state.Enabled)
+	assert.Equal(t, "ip:10.0.0.1", state.SetBy)
+
+	// clearing requires the whole admin scope
+	narrow := withAuthorities(ctx, &proto.GrantedAuthority{Service: "admin", Method: "setReadOnly"})
+	_, err = api.SetReadOnly(narrow, false, nil)
+	assert.Error(t, err)
+	assert.True(t, emergency.Get().Enabled)
+
+	admin := withAuthorities(ctx, &proto.GrantedAuthority{Service: "admin", Method: "*"})
+	state, err = api.SetReadOnly(admin, false, nil)
+	require.NoError(t, err)
+	assert.False(t, state.Enabled)
+	assert.NoError(t, emergency.Check())
+}
+
+func TestPrivateAdminAPI_SetReadOnly_ReplicatedNode(t *testing.T) {
+	api := NewPrivateAdminAPI(&Ethereum{config: &Config{ReadOnly: true}, chainDb: rawdb.NewMemoryDatabase()})
+
+	reason := "incident"
+	_, err := api.SetReadOnly(context.Background(), true, &reason)
+	assert.Equal(t, ErrReadOnlyNode, err)
+}
@@ -29,8 +29,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/multitenancy"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -48,6 +50,11 @@ type filter struct {
 	crit     FilterCriteria
 	logs     []*types.Log
 	s        *Subscription // associated subscription in event system
+
+	// Quorum
+	owner    string // client which installed the filter, see filterOwner
+	created  time.Time
+	lastPoll time.Time
 }
 
 // PublicFilterAPI offers support to create and manage filters. This will allow external clients to retrieve various
@@ -60,6 +67,10 @@ type PublicFilterAPI struct {
 	events    *EventSystem
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
+	replay    *replayWindows // Quorum - nil unless durable subscriptions are enabled
+	quota     *filterQuota   // Quorum
+	budget    LogsBudget     // Quorum
+	spill     *logSpill      // Quorum - nil unless the logs past a page are spilled to disk
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
@@ -69,25 +80,25 @@ func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
 		chainDb: backend.ChainDb(),
 		events:  NewEventSystem(backend, lightMode),
 		filters: make(map[rpc.ID]*filter),
+		quota:   newFilterQuota(),
 	}
 	go api.timeoutLoop()
 
 	return api
 }
 
-// timeoutLoop runs every 5 minutes and deletes filters that have not been recently used.
+// timeoutLoop runs every 5 minutes, or more often if the timeouts are shorter,
+// and deletes filters that have not been recently used.
 // Tt is started when the api is created.
 func (api *PublicFilterAPI) timeoutLoop() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
 	for {
-		<-ticker.C
+		time.Sleep(api.quota.checkInterval())
 		api.filtersMu.Lock()
 		for id, f := range api.filters {
 			select {
 			case <-f.deadline.C:
 				f.s.Unsubscribe()
-				delete(api.filters, id)
+				api.deleteFilter(id)
 			default:
 				continue
 			}
@@ -96,6 +107,53 @@ func (api *PublicFilterAPI) timeoutLoop() {
 	}
 }
 
+// Quorum
+// SetQuota limits the filters and subscriptions of each client.
+func (api *PublicFilterAPI) SetQuota(config QuotaConfig) {
+	api.quota.setConfig(config)
+}
+
+// SetLogsBudget bounds the logs of the queries, spilling the logs past a page
+// to budget.SpillDir if set. It is called before the API is served.
+func (api *PublicFilterAPI) SetLogsBudget(budget LogsBudget) error {
+	if budget.SpillDir != "" {
+		spill, err := newLogSpill(budget.SpillDir)
+		if err != nil {
+			return err
+		}
+		api.spill = spill
+	}
+	api.budget = budget
+	return nil
+}
+
+// installFilter installs the filter of the subscription for the owner of the
+// context, unless over its quota.
+func (api *PublicFilterAPI) installFilter(ctx context.Context, f *filter) error {
+	f.owner = filterOwner(ctx)
+	if err := api.quota.acquireFilter(f.owner); err != nil {
+		return err
+	}
+	f.created = time.Now()
+	f.lastPoll = f.created
+	f.deadline = time.NewTimer(api.quota.timeout(f.typ))
+	api.filtersMu.Lock()
+	api.filters[f.s.ID] = f
+	api.filtersMu.Unlock()
+	return nil
+}
+
+// deleteFilter removes the filter if installed, releasing its quota. The
+// filters lock must be held.
+func (api *PublicFilterAPI) deleteFilter(id rpc.ID) {
+	if f, found := api.filters[id]; found {
+		delete(api.filters, id)
+		api.quota.releaseFilter(f.owner)
+	}
+}
+
+// /Quorum
+
 // NewPendingTransactionFilter creates a filter that fetches pending transaction hashes
 // as transactions enter the pending state.
 //
@@ -103,15 +161,16 @@ func (api *PublicFilterAPI) timeoutLoop() {
 // `eth_getFilterChanges` polling method that is also used for log filters.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_newpendingtransactionfilter
-func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
+func (api *PublicFilterAPI) NewPendingTransactionFilter(ctx context.Context) (rpc.ID, error) {
 	var (
 		pendingTxs   = make(chan []common.Hash)
 		pendingTxSub = api.events.SubscribePendingTxs(pendingTxs)
 	)
 
-	api.filtersMu.Lock()
-	api.filters[pendingTxSub.ID] = &filter{typ: PendingTransactionsSubscription, deadline: time.NewTimer(deadline), hashes: make([]common.Hash, 0), s: pendingTxSub}
-	api.filtersMu.Unlock()
+	if err := api.installFilter(ctx, &filter{typ: PendingTransactionsSubscription, hashes: make([]common.Hash, 0), s: pendingTxSub}); err != nil {
+		pendingTxSub.Unsubscribe()
+		return rpc.ID(""), err
+	}
 
 	go func() {
 		for {
@@ -124,19 +183,23 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 				api.filtersMu.Unlock()
 			case <-pendingTxSub.Err():
 				api.filtersMu.Lock()
-				delete(api.filters, pendingTxSub.ID)
+				api.deleteFilter(pendingTxSub.ID)
 				api.filtersMu.Unlock()
 				return
 			}
 		}
 	}()
 
-	return pendingTxSub.ID
+	return pendingTxSub.ID, nil
 }
 
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
 // enters the transaction pool and was signed from one of the transactions this nodes manages.
 func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	return api.limitSubscription(ctx, func() (*rpc.Subscription, error) { return api.newPendingTransactions(ctx) })
+}
+
+func (api *PublicFilterAPI) newPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -173,15 +236,16 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_newblockfilter
-func (api *PublicFilterAPI) NewBlockFilter() rpc.ID {
+func (api *PublicFilterAPI) NewBlockFilter(ctx context.Context) (rpc.ID, error) {
 	var (
 		headers   = make(chan *types.Header)
 		headerSub = api.events.SubscribeNewHeads(headers)
 	)
 
-	api.filtersMu.Lock()
-	api.filters[headerSub.ID] = &filter{typ: BlocksSubscription, deadline: time.NewTimer(deadline), hashes: make([]common.Hash, 0), s: headerSub}
-	api.filtersMu.Unlock()
+	if err := api.installFilter(ctx, &filter{typ: BlocksSubscription, hashes: make([]common.Hash, 0), s: headerSub}); err != nil {
+		headerSub.Unsubscribe()
+		return rpc.ID(""), err
+	}
 
 	go func() {
 		for {
@@ -194,18 +258,26 @@ func (api *PublicFilterAPI) NewBlockFilter() rpc.ID {
 				api.filtersMu.Unlock()
 			case <-headerSub.Err():
 				api.filtersMu.Lock()
-				delete(api.filters, headerSub.ID)
+				api.deleteFilter(headerSub.ID)
 				api.filtersMu.Unlock()
 				return
 			}
 		}
 	}()
 
-	return headerSub.ID
+	return headerSub.ID, nil
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+// Quorum: durable subscriptions can be resumed, see ReplayOptions.
+func (api *PublicFilterAPI) NewHeads(ctx context.Context, opts *ReplayOptions) (*rpc.Subscription, error) {
+	return api.limitSubscription(ctx, func() (*rpc.Subscription, error) { return api.newHeads(ctx, opts) })
+}
+
+func (api *PublicFilterAPI) newHeads(ctx context.Context, opts *ReplayOptions) (*rpc.Subscription, error) {
+	if opts.durable() {
+		return api.durableHeads(ctx, opts)
+	}
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -235,7 +307,19 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+// Quorum: durable subscriptions can be resumed, see ReplayOptions.
+func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria, opts *ReplayOptions) (*rpc.Subscription, error) {
+	return api.limitSubscription(ctx, func() (*rpc.Subscription, error) { return api.logs(ctx, crit, opts) })
+}
+
+func (api *PublicFilterAPI) logs(ctx context.Context, crit FilterCriteria, opts *ReplayOptions) (*rpc.Subscription, error) {
+	// Quorum
+	if err := api.checkPrivateParty(ctx, crit.PrivateParty); err != nil {
+		return nil, err
+	}
+	if opts.durable() {
+		return api.durableLogs(ctx, crit, opts)
+	}
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -256,6 +340,12 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 		for {
 			select {
 			case logs := <-matchedLogs:
+				// Quorum: only the logs the subscriber is authorized to read
+				logs, err := api.filterPrivateLogs(ctx, crit.PrivateParty, logs)
+				if err != nil {
+					log.Warn("Failed to authorize subscribed logs", "err", err)
+					continue
+				}
 				for _, log := range logs {
 					notifier.Notify(rpcSub.ID, &log)
 				}
@@ -289,16 +379,21 @@ type FilterCriteria ethereum.FilterQuery
 // In case "fromBlock" > "toBlock" an error is returned.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_newfilter
-func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
+func (api *PublicFilterAPI) NewFilter(ctx context.Context, crit FilterCriteria) (rpc.ID, error) {
+	// Quorum
+	if err := api.checkPrivateParty(ctx, crit.PrivateParty); err != nil {
+		return rpc.ID(""), err
+	}
 	logs := make(chan []*types.Log)
 	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), logs)
 	if err != nil {
 		return rpc.ID(""), err
 	}
 
-	api.filtersMu.Lock()
-	api.filters[logsSub.ID] = &filter{typ: LogsSubscription, crit: crit, deadline: time.NewTimer(deadline), logs: make([]*types.Log, 0), s: logsSub}
-	api.filtersMu.Unlock()
+	if err := api.installFilter(ctx, &filter{typ: LogsSubscription, crit: crit, logs: make([]*types.Log, 0), s: logsSub}); err != nil {
+		logsSub.Unsubscribe()
+		return rpc.ID(""), err
+	}
 
 	go func() {
 		for {
@@ -311,7 +406,7 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 				api.filtersMu.Unlock()
 			case <-logsSub.Err():
 				api.filtersMu.Lock()
-				delete(api.filters, logsSub.ID)
+				api.deleteFilter(logsSub.ID)
 				api.filtersMu.Unlock()
 				return
 			}
@@ -322,9 +417,20 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 }
 
 // GetLogs returns logs matching the given argument that are stored within the state.
+// Quorum: if the argument has a page size, at most that many logs are returned
+// in a LogPage, with a cursor to pass in the same argument for the next page.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
-func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) (interface{}, error) {
+	// Quorum: a page of the logs with a cursor to the next one if requested
+	if err := api.checkPrivateParty(ctx, crit.PrivateParty); err != nil {
+		return nil, err
+	}
+	if crit.PageSize != 0 || crit.Cursor != "" {
+		return pageLogs(ctx, api.backend, crit, func(ctx context.Context, logs []*types.Log) ([]*types.Log, error) {
+			return api.filterPrivateLogs(ctx, crit.PrivateParty, logs)
+		}, api.spill, filterOwner(ctx))
+	}
 	var filter *Filter
 	if crit.BlockHash != nil {
 		// Block filter requested, construct a single-shot filter
@@ -342,12 +448,13 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 		// Construct the range filter
 		filter = NewRangeFilter(api.backend, begin, end, crit.Addresses, crit.Topics)
 	}
+	filter.setBudget(api.budget) // Quorum
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
-	authLogs, err := api.filterUnAuthorized(ctx, logs)
+	authLogs, err := api.filterPrivateLogs(ctx, crit.PrivateParty, logs)
 	if err != nil {
 		return nil, err
 	}
@@ -361,7 +468,7 @@ func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Lock()
 	f, found := api.filters[id]
 	if found {
-		delete(api.filters, id)
+		api.deleteFilter(id)
 	}
 	api.filtersMu.Unlock()
 	if found {
@@ -401,12 +508,13 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 		// Construct the range filter
 		filter = NewRangeFilter(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
 	}
+	filter.setBudget(api.budget) // Quorum
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
-	authLogs, err := api.filterUnAuthorized(ctx, logs)
+	authLogs, err := api.filterPrivateLogs(ctx, f.crit.PrivateParty, logs)
 	if err != nil {
 		return nil, err
 	}
@@ -430,7 +538,8 @@ func (api *PublicFilterAPI) GetFilterChanges(ctx context.Context, id rpc.ID) (in
 			// receive timer value and reset timer
 			<-f.deadline.C
 		}
-		f.deadline.Reset(deadline)
+		f.deadline.Reset(api.quota.timeout(f.typ))
+		f.lastPoll = time.Now()
 
 		switch f.typ {
 		case PendingTransactionsSubscription, BlocksSubscription:
@@ -440,7 +549,7 @@ func (api *PublicFilterAPI) GetFilterChanges(ctx context.Context, id rpc.ID) (in
 		case LogsSubscription, MinedAndPendingLogsSubscription:
 			logs := f.logs
 			f.logs = nil
-			authLogs, err := api.filterUnAuthorized(ctx, logs)
+			authLogs, err := api.filterPrivateLogs(ctx, f.crit.PrivateParty, logs)
 			if err != nil {
 				return nil, err
 			}
@@ -477,6 +586,10 @@ func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 		ToBlock   *rpc.BlockNumber `json:"toBlock"`
 		Addresses interface{}      `json:"address"`
 		Topics    []interface{}    `json:"topics"`
+		PageSize  *hexutil.Uint64  `json:"pageSize"` // Quorum
+		Cursor    *string          `json:"cursor"`   // Quorum
+		// Quorum
+		PrivateParty *string `json:"privateParty"`
 	}
 
 	var raw input
@@ -500,6 +613,18 @@ func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	// Quorum
+	if raw.PageSize != nil {
+		args.PageSize = uint64(*raw.PageSize)
+	}
+	if raw.Cursor != nil {
+		args.Cursor = *raw.Cursor
+	}
+	if raw.PrivateParty != nil {
+		args.PrivateParty = *raw.PrivateParty
+	}
+	// End Quorum
+
 	args.Addresses = []common.Address{}
 
 	if raw.Addresses != nil {
@@ -589,30 +714,84 @@ func decodeTopic(s string) (common.Hash, error) {
 }
 
 // Quorum
-// Perform authorization check for each logs based on the contract addresses
-func (api *PublicFilterAPI) filterUnAuthorized(ctx context.Context, logs []*types.Log) ([]*types.Log, error) {
-	if len(logs) == 0 {
+
+// errPrivatePartyNotOwned is returned for the criteria selecting a private
+// party the tenant of the request does not own.
+var errPrivatePartyNotOwned = fmt.Errorf("private party: %w", multitenancy.ErrNotAuthorized)
+
+// checkPrivateParty fails if the tenant of the request does not own the
+// private party, any being allowed without multitenancy.
+func (api *PublicFilterAPI) checkPrivateParty(ctx context.Context, party string) error {
+	if party == "" {
+		return nil
+	}
+	if authToken, ok := api.backend.SupportsMultitenancy(ctx); ok && !multitenancy.OwnsTMKey(authToken, party) {
+		return errPrivatePartyNotOwned
+	}
+	return nil
+}
+
+// logContract is a contract emitting logs at a block.
+type logContract struct {
+	number  uint64
+	address common.Address
+}
+
+// filterPrivateLogs drops the logs of the contracts the tenant of the request
+// is not authorized to read and, with a private party, the logs of the private
+// contracts the party is not a party to. The contracts are checked once per
+// block.
+func (api *PublicFilterAPI) filterPrivateLogs(ctx context.Context, party string, logs []*types.Log) ([]*types.Log, error) {
+	authToken, isMultitenant := api.backend.SupportsMultitenancy(ctx)
+	if len(logs) == 0 || (!isMultitenant && party == "") {
 		return logs, nil
 	}
-	if authToken, ok := api.backend.SupportsMultitenancy(ctx); ok {
-		filteredLogs := make([]*types.Log, 0)
-		for _, l := range logs {
-			extraDataReader, err := api.backend.AccountExtraDataStateGetterByNumber(ctx, rpc.BlockNumber(l.BlockNumber))
-			if err != nil {
-				return nil, fmt.Errorf("no account extra data reader at block %v: %w", l.BlockNumber, err)
+	if err := api.checkPrivateParty(ctx, party); err != nil {
+		return nil, err
+	}
+	var (
+		readers = make(map[uint64]vm.AccountExtraDataStateGetter)
+		visible = make(map[logContract]bool)
+	)
+	filteredLogs := make([]*types.Log, 0)
+	for _, l := range logs {
+		contract := logContract{l.BlockNumber, l.Address}
+		ok, checked := visible[contract]
+		if !checked {
+			extraDataReader, found := readers[l.BlockNumber]
+			if !found {
+				var err error
+				if extraDataReader, err = api.backend.AccountExtraDataStateGetterByNumber(ctx, rpc.BlockNumber(l.BlockNumber)); err != nil {
+					return nil, fmt.Errorf("no account extra data reader at block %v: %w", l.BlockNumber, err)
+				}
+				readers[l.BlockNumber] = extraDataReader
 			}
 			attrBuilder := multitenancy.NewContractSecurityAttributeBuilder().Read().Private()
 			managedParties, err := extraDataReader.GetManagedParties(l.Address)
-			if errors.Is(err, common.ErrNotPrivateContract) {
+			public := errors.Is(err, common.ErrNotPrivateContract)
+			if public {
 				attrBuilder.Public()
 			} else if err != nil {
 				return nil, fmt.Errorf("contract %s not found in the index due to %s", l.Address.Hex(), err.Error())
 			}
-			if ok, _ := api.backend.IsAuthorized(ctx, authToken, attrBuilder.Parties(managedParties).Build()); ok {
-				filteredLogs = append(filteredLogs, l)
+			ok = public || party == "" || containsParty(managedParties, party)
+			if ok && isMultitenant {
+				ok, _ = api.backend.IsAuthorized(ctx, authToken, attrBuilder.Parties(managedParties).Build())
 			}
+			visible[contract] = ok
+		}
+		if ok {
+			filteredLogs = append(filteredLogs, l)
+		}
+	}
+	return filteredLogs, nil
+}
+
+func containsParty(parties []string, party string) bool {
+	for _, p := range parties {
+		if p == party {
+			return true
 		}
-		return filteredLogs, nil
 	}
-	return logs, nil
+	return false
 }
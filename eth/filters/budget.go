@@ -0,0 +1,161 @@
+package filters
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// The logs of a query are counted as they are found, block by block, so that a
+// query matching more logs than the budget fails before holding them all. The
+// pages of the paginated queries are bounded by their size, but the logs found
+// past the end of a page, up to the end of the block the search stopped at,
+// can be spilled to disk to serve the next page without searching them again.
+// A spill file is removed once its page is served, or after logSpillTTL if the
+// client never asks for it, and the spill directory is cleared on start.
+
+// logSpillTTL is how long the logs spilled for a page are kept.
+const logSpillTTL = 5 * time.Minute
+
+// logOverhead is the estimated size of a log besides its topics and data.
+const logOverhead = 200
+
+// ErrLogsLimitExceeded is matched by the errors of the queries exceeding the
+// logs budget.
+var ErrLogsLimitExceeded = errors.New("logs budget exceeded")
+
+var (
+	budgetExceededMeter = metrics.NewRegisteredMeter("eth/filters/budget/exceeded", nil)
+	spillWriteMeter     = metrics.NewRegisteredMeter("eth/filters/spill/writes", nil)
+	spillHitMeter       = metrics.NewRegisteredMeter("eth/filters/spill/hits", nil)
+)
+
+// LogsBudget bounds the logs a query is allowed to hold in memory.
+type LogsBudget struct {
+	MaxResults int    // logs of a query without page size, 0 for unlimited
+	MaxBytes   int    // estimated size of the logs of a query without page size, 0 for unlimited
+	SpillDir   string // directory the logs past a page are spilled to, empty to search them again
+}
+
+// LogsLimitError is returned to the queries exceeding the logs budget.
+type LogsLimitError struct {
+	Limit int
+	Bytes bool // whether the size rather than the number of logs exceeded the limit
+}
+
+func (e *LogsLimitError) Error() string {
+	if e.Bytes {
+		return fmt.Sprintf("query returned more than %d bytes of logs", e.Limit)
+	}
+	return fmt.Sprintf("query returned more than %d results", e.Limit)
+}
+
+// ErrorCode returns the JSON-RPC error code of exceeded limits.
+func (e *LogsLimitError) ErrorCode() int { return -32005 }
+
+// Is makes the errors match ErrLogsLimitExceeded.
+func (e *LogsLimitError) Is(target error) bool {
+	return target == ErrLogsLimitExceeded
+}
+
+// logSize estimates the memory held by a log.
+func logSize(log *types.Log) int {
+	return logOverhead + len(log.Topics)*32 + len(log.Data)
+}
+
+// logSpill keeps the logs found past the pages on disk, by owner and cursor.
+type logSpill struct {
+	dir string
+
+	lock    sync.Mutex
+	expires map[string]time.Time // spill files by name
+}
+
+// newLogSpill creates the spill directory, removing the files left by a
+// previous run.
+func newLogSpill(dir string) (*logSpill, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &logSpill{dir: dir, expires: make(map[string]time.Time)}, nil
+}
+
+// spilledLogs is the content of a spill file.
+type spilledLogs struct {
+	Next int64        `json:"next"` // block the search resumes at
+	Logs []*types.Log `json:"logs"`
+}
+
+func (s *logSpill) path(owner, cursor string) string {
+	return filepath.Join(s.dir, hex.EncodeToString(crypto.Keccak256([]byte(owner), []byte(cursor))[:16]))
+}
+
+// put spills the logs following the page of the cursor, the search resuming
+// at the next block.
+func (s *logSpill) put(owner, cursor string, next int64, logs []*types.Log) {
+	enc, err := json.Marshal(&spilledLogs{Next: next, Logs: logs})
+	if err == nil {
+		err = ioutil.WriteFile(s.path(owner, cursor), enc, 0600)
+	}
+	if err != nil {
+		log.Warn("Failed to spill logs", "dir", s.dir, "err", err)
+		return
+	}
+	spillWriteMeter.Mark(1)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	s.expires[s.path(owner, cursor)] = now.Add(logSpillTTL)
+	for path, expiry := range s.expires {
+		if now.After(expiry) {
+			os.Remove(path)
+			delete(s.expires, path)
+		}
+	}
+}
+
+// take returns and removes the logs spilled for the page of the cursor, and
+// the block the search resumes at.
+func (s *logSpill) take(owner, cursor string) ([]*types.Log, int64, bool) {
+	path := s.path(owner, cursor)
+	s.lock.Lock()
+	expiry, ok := s.expires[path]
+	delete(s.expires, path)
+	s.lock.Unlock()
+	if !ok {
+		return nil, 0, false
+	}
+	defer os.Remove(path)
+	if time.Now().After(expiry) {
+		return nil, 0, false
+	}
+	enc, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Warn("Failed to read spilled logs", "path", path, "err", err)
+		return nil, 0, false
+	}
+	var spilled spilledLogs
+	if err := json.Unmarshal(enc, &spilled); err != nil {
+		log.Warn("Invalid spilled logs", "path", path, "err", err)
+		return nil, 0, false
+	}
+	spillHitMeter.Mark(1)
+	return spilled.Logs, spilled.Next, true
+}
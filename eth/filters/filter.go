@@ -67,6 +67,13 @@ type Filter struct {
 	begin, end int64       // Range interval if filtering multiple blocks
 
 	matcher *bloombits.Matcher
+
+	// Quorum
+	limit      int // number of logs after which Logs stops at the end of a block, 0 for no limit
+	matched    int // number of logs found so far
+	maxResults int // number of logs after which Logs fails, 0 for no limit
+	maxBytes   int // estimated size of the logs after which Logs fails, 0 for no limit
+	bytes      int // estimated size of the logs found so far
 }
 
 // NewRangeFilter creates a new filter which uses a bloom filter on blocks to
@@ -134,7 +141,15 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		if header == nil {
 			return nil, errors.New("unknown block")
 		}
-		return f.blockLogs(ctx, header)
+		logs, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return nil, err
+		}
+		// Quorum
+		if _, err := f.addMatched(logs); err != nil {
+			return nil, err
+		}
+		return logs, nil
 	}
 	// Figure out the limits of the filter range
 	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
@@ -165,6 +180,10 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		if err != nil {
 			return logs, err
 		}
+		// Quorum
+		if f.limited() {
+			return logs, nil
+		}
 	}
 	rest, err := f.unindexedLogs(ctx, end)
 	logs = append(logs, rest...)
@@ -211,6 +230,10 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 				return logs, err
 			}
 			logs = append(logs, found...)
+			// Quorum
+			if limited, err := f.addMatched(found); limited || err != nil {
+				return logs, err
+			}
 
 		case <-ctx.Done():
 			return logs, ctx.Err()
@@ -233,10 +256,48 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 			return logs, err
 		}
 		logs = append(logs, found...)
+		// Quorum
+		if limited, err := f.addMatched(found); limited || err != nil {
+			f.begin++
+			return logs, err
+		}
 	}
 	return logs, nil
 }
 
+// Quorum
+// addMatched counts the logs found in a block and reports whether the limit of
+// the filter is reached, failing once the logs found exceed its budget.
+func (f *Filter) addMatched(found []*types.Log) (bool, error) {
+	f.matched += len(found)
+	if f.maxResults > 0 && f.matched > f.maxResults {
+		budgetExceededMeter.Mark(1)
+		return false, &LogsLimitError{Limit: f.maxResults}
+	}
+	if f.maxBytes > 0 {
+		for _, log := range found {
+			f.bytes += logSize(log)
+		}
+		if f.bytes > f.maxBytes {
+			budgetExceededMeter.Mark(1)
+			return false, &LogsLimitError{Limit: f.maxBytes, Bytes: true}
+		}
+	}
+	return f.limited(), nil
+}
+
+// setBudget bounds the logs the filter may return.
+func (f *Filter) setBudget(budget LogsBudget) {
+	f.maxResults = budget.MaxResults
+	f.maxBytes = budget.MaxBytes
+}
+
+// limited reports whether the filter stopped at its limit, the blocks from
+// f.begin remaining to be searched.
+func (f *Filter) limited() bool {
+	return f.limit > 0 && f.matched >= f.limit
+}
+
 // blockLogs returns the logs matching the filter criteria within a single block.
 func (f *Filter) blockLogs(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
 	// Quorum
@@ -357,8 +357,10 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	}
 	if es.lightMode && len(filters[LogsSubscription]) > 0 {
 		es.lightFilterNewHead(ev.Block.Header(), func(header *types.Header, remove bool) {
+			// Quorum: the logs are retrieved once for all the filters
+			block := &lightBlockLogs{header: header, remove: remove}
 			for _, f := range filters[LogsSubscription] {
-				if matchedLogs := es.lightFilterLogs(header, f.logsCrit.Addresses, f.logsCrit.Topics, remove); len(matchedLogs) > 0 {
+				if matchedLogs := es.lightFilterLogs(block, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
 					f.logs <- matchedLogs
 				}
 			}
@@ -399,40 +401,55 @@ func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func
 	}
 }
 
+// Quorum
+// lightBlockLogs are the logs of a block in light client mode, retrieved at
+// most once for all the filters matching its bloom.
+type lightBlockLogs struct {
+	header *types.Header
+	remove bool
+
+	logs, derived         []*types.Log // derived have the fields derived from the receipts
+	logsDone, derivedDone bool
+}
+
 // filter logs of a single header in light client mode
-func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.Address, topics [][]common.Hash, remove bool) []*types.Log {
-	if bloomFilter(header.Bloom, addresses, topics) {
+func (es *EventSystem) lightFilterLogs(block *lightBlockLogs, addresses []common.Address, topics [][]common.Hash) []*types.Log {
+	if bloomFilter(block.header.Bloom, addresses, topics) {
 		// Get the logs of the block
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 		defer cancel()
-		logsList, err := es.backend.GetLogs(ctx, header.Hash())
-		if err != nil {
-			return nil
-		}
-		var unfiltered []*types.Log
-		for _, logs := range logsList {
-			for _, log := range logs {
-				logcopy := *log
-				logcopy.Removed = remove
-				unfiltered = append(unfiltered, &logcopy)
-			}
-		}
-		logs := filterLogs(unfiltered, nil, nil, addresses, topics)
-		if len(logs) > 0 && logs[0].TxHash == (common.Hash{}) {
-			// We have matching but non-derived logs
-			receipts, err := es.backend.GetReceipts(ctx, header.Hash())
+		if !block.logsDone {
+			block.logsDone = true
+			logsList, err := es.backend.GetLogs(ctx, block.header.Hash())
 			if err != nil {
 				return nil
 			}
-			unfiltered = unfiltered[:0]
-			for _, receipt := range receipts {
-				for _, log := range receipt.Logs {
+			for _, logs := range logsList {
+				for _, log := range logs {
 					logcopy := *log
-					logcopy.Removed = remove
-					unfiltered = append(unfiltered, &logcopy)
+					logcopy.Removed = block.remove
+					block.logs = append(block.logs, &logcopy)
+				}
+			}
+		}
+		logs := filterLogs(block.logs, nil, nil, addresses, topics)
+		if len(logs) > 0 && logs[0].TxHash == (common.Hash{}) {
+			// We have matching but non-derived logs
+			if !block.derivedDone {
+				block.derivedDone = true
+				receipts, err := es.backend.GetReceipts(ctx, block.header.Hash())
+				if err != nil {
+					return nil
+				}
+				for _, receipt := range receipts {
+					for _, log := range receipt.Logs {
+						logcopy := *log
+						logcopy.Removed = block.remove
+						block.derived = append(block.derived, &logcopy)
+					}
 				}
 			}
-			logs = filterLogs(unfiltered, nil, nil, addresses, topics)
+			logs = filterLogs(block.derived, nil, nil, addresses, topics)
 		}
 		return logs
 	}
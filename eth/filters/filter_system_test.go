@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -243,7 +244,7 @@ func TestPendingTxFilter(t *testing.T) {
 		hashes []common.Hash
 	)
 
-	fid0 := api.NewPendingTransactionFilter()
+	fid0, _ := api.NewPendingTransactionFilter(context.Background())
 
 	time.Sleep(1 * time.Second)
 	backend.txFeed.Send(core.NewTxsEvent{Txs: transactions})
@@ -311,7 +312,7 @@ func TestLogFilterCreation(t *testing.T) {
 	)
 
 	for i, test := range testCases {
-		_, err := api.NewFilter(test.crit)
+		_, err := api.NewFilter(context.Background(), test.crit)
 		if test.success && err != nil {
 			t.Errorf("expected filter creation for case %d to success, got %v", i, err)
 		}
@@ -341,7 +342,7 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 	}
 
 	for i, test := range testCases {
-		if _, err := api.NewFilter(test); err == nil {
+		if _, err := api.NewFilter(context.Background(), test); err == nil {
 			t.Errorf("Expected NewFilter for case #%d to fail", i)
 		}
 	}
@@ -434,7 +435,7 @@ func TestLogFilter(t *testing.T) {
 
 	// create all filters
 	for i := range testCases {
-		testCases[i].id, _ = api.NewFilter(testCases[i].crit)
+		testCases[i].id, _ = api.NewFilter(context.Background(), testCases[i].crit)
 	}
 
 	// raise events
@@ -623,3 +624,66 @@ func flattenLogs(pl [][]*types.Log) []*types.Log {
 	}
 	return logs
 }
+
+func TestFilterQuota(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		admin   = NewPrivateFilterAdminAPI(api)
+		client  = context.WithValue(context.Background(), "remote", "10.0.0.1:5000")
+		other   = context.WithValue(context.Background(), "remote", "10.0.0.2:5000")
+	)
+	api.SetQuota(QuotaConfig{MaxFilters: 2, BlocksTimeout: time.Minute})
+
+	logsID, err := api.NewFilter(client, FilterCriteria{FromBlock: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.NewBlockFilter(client); err != nil {
+		t.Fatal(err)
+	}
+	_, err = api.NewPendingTransactionFilter(client)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	if qerr, ok := err.(*QuotaExceededError); !ok || qerr.Owner != "ip:10.0.0.1" || qerr.Limit != 2 {
+		t.Fatalf("unexpected quota error: %#v", err)
+	}
+	if _, err := api.NewBlockFilter(other); err != nil {
+		t.Fatalf("quota of another client exceeded: %v", err)
+	}
+
+	infos := admin.ListFilters()
+	if len(infos) != 3 {
+		t.Fatalf("expected 3 filters, got %d", len(infos))
+	}
+	for _, info := range infos {
+		if info.ID == logsID && (info.Owner != "ip:10.0.0.1" || info.Type != "logs" || info.Criteria != "blocks 1-latest, 0 addresses, 0 topics") {
+			t.Fatalf("unexpected filter info: %+v", info)
+		}
+	}
+	if timeout := api.quota.timeout(BlocksSubscription); timeout != time.Minute {
+		t.Fatalf("block filter timeout mismatch: have %v, want %v", timeout, time.Minute)
+	}
+	if interval := api.quota.checkInterval(); interval != time.Minute {
+		t.Fatalf("check interval mismatch: have %v, want %v", interval, time.Minute)
+	}
+
+	// uninstalled filters release their quota
+	if !api.UninstallFilter(logsID) {
+		t.Fatal("filter not uninstalled")
+	}
+	if _, err := api.NewPendingTransactionFilter(client); err != nil {
+		t.Fatal(err)
+	}
+	if removed := admin.RemoveFilters("ip:10.0.0.1"); removed != 2 {
+		t.Fatalf("expected 2 removed filters, got %d", removed)
+	}
+	if len(admin.ListFilters()) != 1 {
+		t.Fatal("filters of another client removed")
+	}
+	if _, err := api.NewFilter(client, FilterCriteria{}); err != nil {
+		t.Fatal(err)
+	}
+}
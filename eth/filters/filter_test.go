@@ -18,18 +18,27 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/multitenancy"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -259,3 +268,327 @@ func TestFilters(t *testing.T) {
 	}
 
 }
+
+// writePagedLogsChain writes a chain of 4 blocks with 5 logs of addr, 3 in the
+// first block and 2 in the third.
+func writePagedLogsChain(db ethdb.Database) ([]*types.Block, common.Address) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		topic  = common.BytesToHash([]byte("topic"))
+	)
+	receiptWithLogs := func(n int) *types.Receipt {
+		receipt := types.NewReceipt(nil, false, 0)
+		for i := 0; i < n; i++ {
+			receipt.Logs = append(receipt.Logs, &types.Log{Address: addr, Topics: []common.Hash{topic}})
+		}
+		return receipt
+	}
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 4, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 0:
+			gen.AddUncheckedReceipt(receiptWithLogs(2))
+			gen.AddUncheckedTx(types.NewTransaction(0, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+			// pseudo Quorum private transaction
+			gen.AddUncheckedReceipt(receiptWithLogs(1))
+			gen.AddUncheckedTx(types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		case 2:
+			gen.AddUncheckedReceipt(receiptWithLogs(2))
+			gen.AddUncheckedTx(types.NewTransaction(2, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return chain, addr
+}
+
+func TestPageLogs(t *testing.T) {
+	var (
+		db          = rawdb.NewMemoryDatabase()
+		backend     = &testBackend{db: db}
+		api         = NewPublicFilterAPI(backend, false)
+		chain, addr = writePagedLogsChain(db)
+	)
+
+	crit := FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}, PageSize: 2}
+	var (
+		positions []logPosition
+		pages     int
+	)
+	for {
+		result, err := api.GetLogs(context.Background(), crit)
+		if err != nil {
+			t.Fatalf("page %d: %v", pages, err)
+		}
+		page := result.(*LogPage)
+		if len(page.Logs) > 2 {
+			t.Fatalf("page %d: expected at most 2 logs, got %d", pages, len(page.Logs))
+		}
+		for _, log := range page.Logs {
+			positions = append(positions, positionOf(log))
+		}
+		pages++
+		if page.Cursor == "" {
+			break
+		}
+		crit.Cursor = page.Cursor
+	}
+	expected := []logPosition{{1, 0, 0}, {1, 0, 1}, {1, 1, 2}, {3, 0, 0}, {3, 0, 1}}
+	if pages != 3 || len(positions) != len(expected) {
+		t.Fatalf("expected %v in 3 pages, got %v in %d", expected, positions, pages)
+	}
+	for i := range expected {
+		if positions[i] != expected[i] {
+			t.Errorf("log %d: expected %v, got %v", i, expected[i], positions[i])
+		}
+	}
+
+	// the cursor is bound to the filter criteria
+	first, _ := api.GetLogs(context.Background(), FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}, PageSize: 2})
+	crit = FilterCriteria{FromBlock: big.NewInt(1), Addresses: []common.Address{addr}, PageSize: 2, Cursor: first.(*LogPage).Cursor}
+	if _, err := api.GetLogs(context.Background(), crit); err != errCursorMismatch {
+		t.Errorf("expected %v, got %v", errCursorMismatch, err)
+	}
+	crit.Cursor = "garbage"
+	if _, err := api.GetLogs(context.Background(), crit); err != errInvalidCursor {
+		t.Errorf("expected %v, got %v", errInvalidCursor, err)
+	}
+
+	// a block filter pages within the block
+	hash := chain[0].Hash()
+	result, err := api.GetLogs(context.Background(), FilterCriteria{BlockHash: &hash, PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page := result.(*LogPage); len(page.Logs) != 2 || page.Cursor == "" {
+		t.Errorf("expected 2 logs and a cursor, got %d logs and cursor %q", len(page.Logs), page.Cursor)
+	}
+}
+
+func TestLogsBudget(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		_, addr = writePagedLogsChain(db)
+		crit    = FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}}
+	)
+	for i, test := range []struct {
+		budget LogsBudget
+		err    *LogsLimitError
+	}{
+		{LogsBudget{}, nil},
+		{LogsBudget{MaxResults: 5}, nil},
+		{LogsBudget{MaxResults: 4}, &LogsLimitError{Limit: 4}},
+		{LogsBudget{MaxBytes: 5 * (logOverhead + 32)}, nil},
+		{LogsBudget{MaxBytes: 3 * (logOverhead + 32)}, &LogsLimitError{Limit: 3 * (logOverhead + 32), Bytes: true}},
+	} {
+		api := NewPublicFilterAPI(backend, false)
+		if err := api.SetLogsBudget(test.budget); err != nil {
+			t.Fatal(err)
+		}
+		result, err := api.GetLogs(context.Background(), crit)
+		if test.err == nil {
+			if err != nil {
+				t.Fatalf("test %d: %v", i, err)
+			}
+			if logs := result.([]*types.Log); len(logs) != 5 {
+				t.Errorf("test %d: expected 5 logs, got %d", i, len(logs))
+			}
+			continue
+		}
+		if !reflect.DeepEqual(err, test.err) || !errors.Is(err, ErrLogsLimitExceeded) {
+			t.Errorf("test %d: expected %v, got %v", i, test.err, err)
+		}
+		// the pages are bounded by their size rather than the budget
+		paged := crit
+		paged.PageSize = 5
+		if result, err := api.GetLogs(context.Background(), paged); err != nil || len(result.(*LogPage).Logs) != 5 {
+			t.Errorf("test %d: expected a page of 5 logs, got %v, %v", i, result, err)
+		}
+	}
+}
+
+func TestPageLogs_Spill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logspill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the spill files left by a previous run are removed
+	if err := ioutil.WriteFile(filepath.Join(dir, "stale"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		_, addr = writePagedLogsChain(db)
+	)
+	if err := api.SetLogsBudget(LogsBudget{SpillDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	spilled := func() int {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(files)
+	}
+	if n := spilled(); n != 0 {
+		t.Fatalf("expected an empty spill directory, got %d files", n)
+	}
+
+	crit := FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}, PageSize: 1}
+	var positions []logPosition
+	for pages := 0; ; pages++ {
+		result, err := api.GetLogs(context.Background(), crit)
+		if err != nil {
+			t.Fatalf("page %d: %v", pages, err)
+		}
+		page := result.(*LogPage)
+		for _, log := range page.Logs {
+			positions = append(positions, positionOf(log))
+		}
+		if page.Cursor == "" {
+			break
+		}
+		// the logs left out of the first page are spilled
+		if n := spilled(); pages == 0 && n != 1 {
+			t.Fatalf("expected 1 spill file, got %d", n)
+		}
+		crit.Cursor = page.Cursor
+	}
+	expected := []logPosition{{1, 0, 0}, {1, 0, 1}, {1, 1, 2}, {3, 0, 0}, {3, 0, 1}}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("expected %v, got %v", expected, positions)
+	}
+	if n := spilled(); n != 0 {
+		t.Errorf("expected the spill files removed, got %d", n)
+	}
+}
+
+func TestLogSpill_Owner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logspill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	spill, err := newLogSpill(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spill.put("ip:10.0.0.1", "cursor", 7, []*types.Log{{BlockNumber: 6, Topics: []common.Hash{}}})
+	if _, _, ok := spill.take("ip:10.0.0.2", "cursor"); ok {
+		t.Error("logs spilled for another owner taken")
+	}
+	logs, next, ok := spill.take("ip:10.0.0.1", "cursor")
+	if !ok || next != 7 || len(logs) != 1 || logs[0].BlockNumber != 6 {
+		t.Errorf("expected the spilled log resuming at 7, got %v, %d, %v", logs, next, ok)
+	}
+	if _, _, ok := spill.take("ip:10.0.0.1", "cursor"); ok {
+		t.Error("spilled logs taken twice")
+	}
+}
+
+// partiesState is the private state of the contracts with their parties, the
+// others being public.
+type partiesState map[common.Address][]string
+
+func (s partiesState) GetPrivacyMetadata(addr common.Address) (*state.PrivacyMetadata, error) {
+	return nil, nil
+}
+
+func (s partiesState) GetManagedParties(addr common.Address) ([]string, error) {
+	if parties, ok := s[addr]; ok {
+		return parties, nil
+	}
+	return nil, common.ErrNotPrivateContract
+}
+
+// tenantBackend serves the requests with a token for a tenant.
+type tenantBackend struct {
+	*testBackend
+	state partiesState
+}
+
+func (b *tenantBackend) SupportsMultitenancy(ctx context.Context) (*proto.PreAuthenticatedAuthenticationToken, bool) {
+	authToken, ok := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	return authToken, ok
+}
+
+func (b *tenantBackend) AccountExtraDataStateGetterByNumber(context.Context, rpc.BlockNumber) (vm.AccountExtraDataStateGetter, error) {
+	return b.state, nil
+}
+
+func (b *tenantBackend) IsAuthorized(ctx context.Context, authToken *proto.PreAuthenticatedAuthenticationToken, attributes ...*multitenancy.ContractSecurityAttribute) (bool, error) {
+	return (&multitenancy.DefaultContractAuthorizationProvider{}).IsAuthorized(ctx, authToken, attributes...)
+}
+
+func TestGetLogs_whenPrivateParties(t *testing.T) {
+	var (
+		db                   = rawdb.NewMemoryDatabase()
+		contractA, contractB = common.HexToAddress("0xa"), common.HexToAddress("0xb")
+		public               = common.HexToAddress("0xc")
+		backend              = &tenantBackend{&testBackend{db: db}, partiesState{contractA: {"A"}, contractB: {"B"}}}
+		api                  = NewPublicFilterAPI(backend, false)
+		topic                = common.BytesToHash([]byte("event"))
+	)
+	genesis := new(core.Genesis).MustCommit(db)
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, func(i int, gen *core.BlockGen) {
+		for nonce, addr := range []common.Address{contractA, contractB, public} {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{topic}}}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(nonce), common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	rawdb.WriteBlock(db, chain[0])
+	rawdb.WriteCanonicalHash(db, chain[0].Hash(), 1)
+	rawdb.WriteHeadBlockHash(db, chain[0].Hash())
+	rawdb.WriteReceipts(db, chain[0].Hash(), 1, receipts[0])
+
+	tenant := func(tmKey string) context.Context {
+		return context.WithValue(context.Background(), rpc.CtxPreauthenticatedToken, &proto.PreAuthenticatedAuthenticationToken{
+			Authorities: []*proto.GrantedAuthority{{Raw: "private://0x0/_/contracts?owned.eoa=0x0&from.tm=" + tmKey}},
+		})
+	}
+	getLogs := func(ctx context.Context, party string) ([]common.Address, error) {
+		result, err := api.GetLogs(ctx, FilterCriteria{FromBlock: big.NewInt(0), Topics: [][]common.Hash{{topic}}, PrivateParty: party})
+		if err != nil {
+			return nil, err
+		}
+		var addresses []common.Address
+		for _, log := range result.([]*types.Log) {
+			addresses = append(addresses, log.Address)
+		}
+		return addresses, nil
+	}
+	for i, tt := range []struct {
+		ctx      context.Context
+		party    string
+		expected []common.Address
+	}{
+		{tenant("A"), "", []common.Address{contractA, public}},
+		{tenant("B"), "", []common.Address{contractB, public}},
+		{tenant("B"), "B", []common.Address{contractB, public}},
+		{context.Background(), "", []common.Address{contractA, contractB, public}},
+		{context.Background(), "A", []common.Address{contractA, public}},
+	} {
+		addresses, err := getLogs(tt.ctx, tt.party)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(addresses, tt.expected) {
+			t.Errorf("test %d: expected logs of %v, got %v", i, tt.expected, addresses)
+		}
+	}
+	// the tenants only select their own keys
+	if _, err := getLogs(tenant("A"), "B"); !errors.Is(err, multitenancy.ErrNotAuthorized) {
+		t.Errorf("expected %v, got %v", multitenancy.ErrNotAuthorized, err)
+	}
+}
@@ -0,0 +1,103 @@
+package filters
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// countingBackend counts the retrievals of the logs of the blocks.
+type countingBackend struct {
+	*testBackend
+	fetches int64
+}
+
+func (b *countingBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	atomic.AddInt64(&b.fetches, 1)
+	return b.testBackend.GetLogs(ctx, hash)
+}
+
+// newLightLogsChain writes a chain of blocks with a log each, returning the
+// blocks.
+func newLightLogsChain(n int, addr common.Address) (*countingBackend, []*types.Block) {
+	db := rawdb.NewMemoryDatabase()
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{common.BigToHash(big.NewInt(int64(i)))}}}
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), 1, big.NewInt(1), nil))
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return &countingBackend{testBackend: &testBackend{db: db}}, chain
+}
+
+// newLightLogsFilters indexes n filters of the logs of the address.
+func newLightLogsFilters(n int, addr common.Address) filterIndex {
+	index := filterIndex{LogsSubscription: make(map[rpc.ID]*subscription)}
+	for i := 0; i < n; i++ {
+		id := rpc.NewID()
+		index[LogsSubscription][id] = &subscription{
+			id:       id,
+			typ:      LogsSubscription,
+			logsCrit: ethereum.FilterQuery{Addresses: []common.Address{addr}},
+			logs:     make(chan []*types.Log, 1),
+		}
+	}
+	return index
+}
+
+func TestLightFilterLogs_RetrievedOnce(t *testing.T) {
+	addr := common.Address{0xaa}
+	backend, chain := newLightLogsChain(2, addr)
+	es := NewEventSystem(backend, true)
+	index := newLightLogsFilters(20, addr)
+
+	es.handleChainEvent(index, core.ChainEvent{Block: chain[0]})
+	es.handleChainEvent(index, core.ChainEvent{Block: chain[1]})
+
+	if fetches := atomic.LoadInt64(&backend.fetches); fetches != 1 {
+		t.Fatalf("expected the logs of the block to be retrieved once, got %d", fetches)
+	}
+	for _, f := range index[LogsSubscription] {
+		logs := <-f.logs
+		if len(logs) != 1 || logs[0].BlockHash != chain[1].Hash() {
+			t.Fatalf("unexpected logs %v", logs)
+		}
+	}
+}
+
+// BenchmarkLightFilterLogs20 measures the retrievals of the logs of the new
+// heads with 20 filters installed.
+func BenchmarkLightFilterLogs20(b *testing.B) {
+	addr := common.Address{0xaa}
+	backend, chain := newLightLogsChain(b.N+1, addr)
+	es := NewEventSystem(backend, true)
+	index := newLightLogsFilters(20, addr)
+	es.handleChainEvent(index, core.ChainEvent{Block: chain[0]})
+
+	b.ResetTimer()
+	for i := 1; i <= b.N; i++ {
+		es.handleChainEvent(index, core.ChainEvent{Block: chain[i]})
+		for _, f := range index[LogsSubscription] {
+			<-f.logs
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(atomic.LoadInt64(&backend.fetches))/float64(b.N), "fetches/block")
+}
@@ -0,0 +1,218 @@
+package filters
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// cursorLength is the length of a decoded cursor: the block number, the
+// transaction and log indexes of the last log returned and the digest of the
+// filter criteria.
+const cursorLength = 8 + 4 + 4 + 8
+
+var (
+	errInvalidCursor    = errors.New("invalid log cursor")
+	errCursorMismatch   = errors.New("log cursor does not match the filter criteria")
+	errCursorNoPageSize = errors.New("log cursor requires a page size")
+)
+
+// LogPage is a page of the logs matching a filter.
+type LogPage struct {
+	Logs   []*types.Log `json:"logs"`
+	Cursor string       `json:"cursor,omitempty"` // resumes after the last log, empty once all logs were returned
+}
+
+// logPosition is the position of a log in the ordering of the logs of a
+// filter. Public and private logs are ordered alike, by block, transaction
+// index and log index, so that every page of a party is deterministic.
+type logPosition struct {
+	block    uint64
+	txIndex  uint32
+	logIndex uint32
+}
+
+func positionOf(log *types.Log) logPosition {
+	return logPosition{block: log.BlockNumber, txIndex: uint32(log.TxIndex), logIndex: uint32(log.Index)}
+}
+
+func (p logPosition) before(q logPosition) bool {
+	if p.block != q.block {
+		return p.block < q.block
+	}
+	if p.txIndex != q.txIndex {
+		return p.txIndex < q.txIndex
+	}
+	return p.logIndex < q.logIndex
+}
+
+// criteriaDigest identifies the criteria a cursor is issued for, the page size
+// aside so that it may change between pages.
+func criteriaDigest(crit FilterCriteria) []byte {
+	var from, to string
+	if crit.FromBlock != nil {
+		from = crit.FromBlock.String()
+	}
+	if crit.ToBlock != nil {
+		to = crit.ToBlock.String()
+	}
+	enc, _ := json.Marshal(struct {
+		BlockHash    *common.Hash
+		From, To     string
+		Addresses    []common.Address
+		Topics       [][]common.Hash
+		PrivateParty string `json:",omitempty"`
+	}{crit.BlockHash, from, to, crit.Addresses, crit.Topics, crit.PrivateParty})
+	return crypto.Keccak256(enc)[:8]
+}
+
+func encodeCursor(p logPosition, digest []byte) string {
+	enc := make([]byte, cursorLength)
+	binary.BigEndian.PutUint64(enc, p.block)
+	binary.BigEndian.PutUint32(enc[8:], p.txIndex)
+	binary.BigEndian.PutUint32(enc[12:], p.logIndex)
+	copy(enc[16:], digest)
+	return base64.RawURLEncoding.EncodeToString(enc)
+}
+
+func decodeCursor(cursor string, digest []byte) (logPosition, error) {
+	enc, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(enc) != cursorLength {
+		return logPosition{}, errInvalidCursor
+	}
+	if !bytes.Equal(enc[16:], digest) {
+		return logPosition{}, errCursorMismatch
+	}
+	return logPosition{
+		block:    binary.BigEndian.Uint64(enc),
+		txIndex:  binary.BigEndian.Uint32(enc[8:]),
+		logIndex: binary.BigEndian.Uint32(enc[12:]),
+	}, nil
+}
+
+// PageLogs returns at most crit.PageSize logs matching the criteria, resuming
+// after crit.Cursor if set. The logs are passed to authorize, if not nil, to
+// drop those the caller may not read before the page is cut.
+func PageLogs(ctx context.Context, backend Backend, crit FilterCriteria, authorize func(context.Context, []*types.Log) ([]*types.Log, error)) (*LogPage, error) {
+	return pageLogs(ctx, backend, crit, authorize, nil, "")
+}
+
+// pageLogs returns a page of the logs as PageLogs, the logs left out of the
+// page up to the block the search stopped at being spilled for the owner if
+// spill is not nil, and read back for its next page instead of searching them
+// again.
+func pageLogs(ctx context.Context, backend Backend, crit FilterCriteria, authorize func(context.Context, []*types.Log) ([]*types.Log, error), spill *logSpill, owner string) (*LogPage, error) {
+	if crit.PageSize == 0 {
+		return nil, errCursorNoPageSize
+	}
+	if authorize == nil {
+		authorize = func(_ context.Context, logs []*types.Log) ([]*types.Log, error) { return logs, nil }
+	}
+	digest := criteriaDigest(crit)
+	var after *logPosition
+	if crit.Cursor != "" {
+		position, err := decodeCursor(crit.Cursor, digest)
+		if err != nil {
+			return nil, err
+		}
+		after = &position
+	}
+	page := &LogPage{Logs: []*types.Log{}}
+	collect := func(logs []*types.Log) {
+		sort.SliceStable(logs, func(i, j int) bool {
+			return positionOf(logs[i]).before(positionOf(logs[j]))
+		})
+		for _, log := range logs {
+			if after == nil || after.before(positionOf(log)) {
+				page.Logs = append(page.Logs, log)
+			}
+		}
+	}
+	// cut trims the page to its size, returning whether logs were left out
+	cut := func() bool {
+		if uint64(len(page.Logs)) <= crit.PageSize {
+			return false
+		}
+		page.Logs = page.Logs[:crit.PageSize]
+		return true
+	}
+	// finish cuts the page, spilling the logs left out with the block the
+	// search resumes at
+	finish := func(next int64) {
+		overflow := page.Logs[crit.PageSize:]
+		cut()
+		page.Cursor = encodeCursor(positionOf(page.Logs[len(page.Logs)-1]), digest)
+		if spill != nil && len(overflow) > 0 {
+			spill.put(owner, page.Cursor, next, overflow)
+		}
+	}
+
+	if crit.BlockHash != nil {
+		logs, err := NewBlockFilter(backend, *crit.BlockHash, crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if logs, err = authorize(ctx, logs); err != nil {
+			return nil, err
+		}
+		collect(logs)
+		if cut() {
+			page.Cursor = encodeCursor(positionOf(page.Logs[len(page.Logs)-1]), digest)
+		}
+		return page, nil
+	}
+
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	if after != nil {
+		begin = int64(after.block)
+		if spill != nil {
+			if logs, next, ok := spill.take(owner, crit.Cursor); ok {
+				// the permissions of the owner may have changed since
+				logs, err := authorize(ctx, logs)
+				if err != nil {
+					return nil, err
+				}
+				collect(logs)
+				begin = next
+			}
+		}
+	}
+	for uint64(len(page.Logs)) < crit.PageSize {
+		filter := NewRangeFilter(backend, begin, end, crit.Addresses, crit.Topics)
+		filter.limit = int(crit.PageSize) - len(page.Logs)
+		logs, err := filter.Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if logs, err = authorize(ctx, logs); err != nil {
+			return nil, err
+		}
+		collect(logs)
+		if !filter.limited() {
+			// all blocks searched, resume only if the page is full
+			if uint64(len(page.Logs)) > crit.PageSize {
+				finish(filter.begin)
+			}
+			return page, nil
+		}
+		begin = filter.begin
+	}
+	finish(begin)
+	return page, nil
+}
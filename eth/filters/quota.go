@@ -0,0 +1,321 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// The filters and subscriptions are accounted to their owner, the identity of
+// the authenticated client or else its IP address, so that a client cannot
+// exhaust the node by installing filters it never polls.
+
+// maxTimeoutCheckInterval is the longest interval between two removals of the
+// filters which have not been polled within their timeout.
+const maxTimeoutCheckInterval = 5 * time.Minute
+
+// ErrQuotaExceeded is matched by the errors of the clients installing more
+// filters or subscriptions than their quota.
+var ErrQuotaExceeded = errors.New("filter quota exceeded")
+
+var (
+	installedFiltersGauge = metrics.NewRegisteredGauge("eth/filters/installed", nil)
+	subscriptionsGauge    = metrics.NewRegisteredGauge("eth/filters/subscriptions", nil)
+	quotaExceededMeter    = metrics.NewRegisteredMeter("eth/filters/quotaexceeded", nil)
+)
+
+// QuotaConfig limits the filters of each client.
+type QuotaConfig struct {
+	MaxFilters       int // polled filters installed by a client, 0 for unlimited
+	MaxSubscriptions int // subscriptions of a client, 0 for unlimited
+
+	// Idle timeouts of the polled filters by type, the default being used when 0
+	LogsTimeout       time.Duration
+	BlocksTimeout     time.Duration
+	PendingTxsTimeout time.Duration
+}
+
+// QuotaExceededError is returned to the clients installing more filters or
+// subscriptions than their quota.
+type QuotaExceededError struct {
+	Owner string
+	Kind  string // "filter" or "subscription"
+	Limit int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded for %s", e.Kind, e.Limit, e.Owner)
+}
+
+// ErrorCode returns the JSON-RPC error code of exceeded limits.
+func (e *QuotaExceededError) ErrorCode() int { return -32005 }
+
+// Is makes the errors match ErrQuotaExceeded.
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// filterOwner returns the owner of the filters installed with the context.
+func filterOwner(ctx context.Context) string {
+	authToken, _ := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	if identity := multitenancy.TokenIdentity(authToken); identity != "" {
+		return identity
+	}
+	if remote, ok := ctx.Value("remote").(string); ok && remote != "" {
+		if host, _, err := net.SplitHostPort(remote); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + remote
+	}
+	return "local"
+}
+
+// usage is the number of filters and subscriptions of an owner.
+type usage struct {
+	filters       int
+	subscriptions int
+}
+
+// filterQuota accounts the filters and subscriptions of the owners.
+type filterQuota struct {
+	mu     sync.Mutex
+	config QuotaConfig
+	usage  map[string]*usage
+}
+
+func newFilterQuota() *filterQuota {
+	return &filterQuota{usage: make(map[string]*usage)}
+}
+
+func (q *filterQuota) setConfig(config QuotaConfig) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.config = config
+}
+
+// timeout returns the idle timeout of the filters of the type.
+func (q *filterQuota) timeout(typ Type) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var timeout time.Duration
+	switch typ {
+	case LogsSubscription, MinedAndPendingLogsSubscription, PendingLogsSubscription:
+		timeout = q.config.LogsTimeout
+	case BlocksSubscription:
+		timeout = q.config.BlocksTimeout
+	case PendingTransactionsSubscription:
+		timeout = q.config.PendingTxsTimeout
+	}
+	if timeout <= 0 {
+		timeout = deadline
+	}
+	return timeout
+}
+
+// checkInterval returns the interval between the removals of the idle filters,
+// the shortest timeout capped to maxTimeoutCheckInterval.
+func (q *filterQuota) checkInterval() time.Duration {
+	interval := maxTimeoutCheckInterval
+	for _, typ := range []Type{LogsSubscription, BlocksSubscription, PendingTransactionsSubscription} {
+		if timeout := q.timeout(typ); timeout < interval {
+			interval = timeout
+		}
+	}
+	return interval
+}
+
+// acquireFilter accounts a filter to the owner, unless over its quota.
+func (q *filterQuota) acquireFilter(owner string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.usageOf(owner)
+	if q.config.MaxFilters > 0 && u.filters >= q.config.MaxFilters {
+		quotaExceededMeter.Mark(1)
+		return &QuotaExceededError{Owner: owner, Kind: "filter", Limit: q.config.MaxFilters}
+	}
+	u.filters++
+	installedFiltersGauge.Inc(1)
+	return nil
+}
+
+func (q *filterQuota) releaseFilter(owner string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.usageOf(owner).filters--
+	installedFiltersGauge.Dec(1)
+	q.prune(owner)
+}
+
+// acquireSubscription accounts a subscription to the owner, unless over its
+// quota.
+func (q *filterQuota) acquireSubscription(owner string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.usageOf(owner)
+	if q.config.MaxSubscriptions > 0 && u.subscriptions >= q.config.MaxSubscriptions {
+		quotaExceededMeter.Mark(1)
+		return &QuotaExceededError{Owner: owner, Kind: "subscription", Limit: q.config.MaxSubscriptions}
+	}
+	u.subscriptions++
+	subscriptionsGauge.Inc(1)
+	return nil
+}
+
+func (q *filterQuota) releaseSubscription(owner string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.usageOf(owner).subscriptions--
+	subscriptionsGauge.Dec(1)
+	q.prune(owner)
+}
+
+func (q *filterQuota) usageOf(owner string) *usage {
+	u, ok := q.usage[owner]
+	if !ok {
+		u = new(usage)
+		q.usage[owner] = u
+	}
+	return u
+}
+
+func (q *filterQuota) prune(owner string) {
+	if u := q.usage[owner]; u.filters <= 0 && u.subscriptions <= 0 {
+		delete(q.usage, owner)
+	}
+}
+
+// limitSubscription creates the subscription of the context unless its owner
+// is over quota, accounting it until it is unsubscribed or its connection
+// closed.
+func (api *PublicFilterAPI) limitSubscription(ctx context.Context, subscribe func() (*rpc.Subscription, error)) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	owner := filterOwner(ctx)
+	if err := api.quota.acquireSubscription(owner); err != nil {
+		return nil, err
+	}
+	sub, err := subscribe()
+	if err != nil {
+		api.quota.releaseSubscription(owner)
+		return sub, err
+	}
+	go func() {
+		select {
+		case <-sub.Err():
+		case <-notifier.Closed():
+		}
+		api.quota.releaseSubscription(owner)
+	}()
+	return sub, nil
+}
+
+// FilterInfo describes an installed filter.
+type FilterInfo struct {
+	ID       rpc.ID    `json:"id"`
+	Owner    string    `json:"owner"`
+	Type     string    `json:"type"`
+	Criteria string    `json:"criteria,omitempty"` // summary of the criteria of log filters
+	Created  time.Time `json:"created"`
+	Age      string    `json:"age"`
+	LastPoll time.Time `json:"lastPoll"`
+}
+
+func typeName(typ Type) string {
+	switch typ {
+	case LogsSubscription, MinedAndPendingLogsSubscription, PendingLogsSubscription:
+		return "logs"
+	case BlocksSubscription:
+		return "blocks"
+	case PendingTransactionsSubscription:
+		return "pendingTransactions"
+	default:
+		return "unknown"
+	}
+}
+
+// summary describes the criteria without listing every address and topic.
+func (crit FilterCriteria) summary() string {
+	var blocks string
+	if crit.BlockHash != nil {
+		blocks = "block " + crit.BlockHash.Hex()
+	} else {
+		blocks = fmt.Sprintf("blocks %s-%s", blockLabel(crit.FromBlock), blockLabel(crit.ToBlock))
+	}
+	return fmt.Sprintf("%s, %d addresses, %d topics", blocks, len(crit.Addresses), len(crit.Topics))
+}
+
+func blockLabel(number *big.Int) string {
+	switch {
+	case number == nil || number.Int64() == rpc.LatestBlockNumber.Int64():
+		return "latest"
+	case number.Int64() == rpc.PendingBlockNumber.Int64():
+		return "pending"
+	default:
+		return number.String()
+	}
+}
+
+// PrivateFilterAdminAPI offers the administration of the installed filters.
+type PrivateFilterAdminAPI struct {
+	api *PublicFilterAPI
+}
+
+// NewPrivateFilterAdminAPI creates the administration API of the filters of api.
+func NewPrivateFilterAdminAPI(api *PublicFilterAPI) *PrivateFilterAdminAPI {
+	return &PrivateFilterAdminAPI{api}
+}
+
+// ListFilters returns the installed polled filters, the subscriptions being
+// bound to their connection.
+func (admin *PrivateFilterAdminAPI) ListFilters() []FilterInfo {
+	api := admin.api
+	api.filtersMu.Lock()
+	defer api.filtersMu.Unlock()
+	infos := make([]FilterInfo, 0, len(api.filters))
+	for id, f := range api.filters {
+		info := FilterInfo{
+			ID:       id,
+			Owner:    f.owner,
+			Type:     typeName(f.typ),
+			Created:  f.created,
+			Age:      time.Since(f.created).Round(time.Second).String(),
+			LastPoll: f.lastPoll,
+		}
+		if f.typ == LogsSubscription {
+			info.Criteria = f.crit.summary()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// RemoveFilters uninstalls the filters of the owner, returning how many were.
+func (admin *PrivateFilterAdminAPI) RemoveFilters(owner string) int {
+	api := admin.api
+	api.filtersMu.Lock()
+	var removed []*filter
+	for id, f := range api.filters {
+		if f.owner == owner {
+			api.deleteFilter(id)
+			removed = append(removed, f)
+		}
+	}
+	api.filtersMu.Unlock()
+	for _, f := range removed {
+		f.s.Unsubscribe()
+	}
+	return len(removed)
+}
@@ -0,0 +1,292 @@
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Quorum
+//
+// Durable subscriptions deliver every event with a cursor, the block number of
+// the event and its sequence among the events of the block. The last events of
+// newHeads and logs are kept in replay windows, so that a client re-subscribing
+// with the cursor of the last event it received gets the events it missed
+// before the live ones.
+
+var (
+	errReplayDisabled       = errors.New("durable subscriptions are not enabled")
+	errInvalidReplayCursor  = errors.New("invalid replay cursor")
+	errReplayWindowExceeded = errors.New("replay window exceeded, re-sync with a range query")
+)
+
+// ReplayOptions makes a subscription durable.
+type ReplayOptions struct {
+	Durable    bool    `json:"durable"`
+	ResumeFrom *string `json:"resumeFrom"` // cursor of the last event received, implies durable
+}
+
+func (o *ReplayOptions) durable() bool {
+	return o != nil && (o.Durable || o.ResumeFrom != nil)
+}
+
+// DurableEvent is the notification of a durable subscription.
+type DurableEvent struct {
+	Cursor string          `json:"cursor"`
+	Event  json.RawMessage `json:"event"`
+}
+
+type replayCursor struct {
+	block, seq uint64
+}
+
+func (c replayCursor) String() string {
+	return fmt.Sprintf("%d-%d", c.block, c.seq)
+}
+
+func parseReplayCursor(s string) (replayCursor, error) {
+	var c replayCursor
+	if n, err := fmt.Sscanf(s, "%d-%d", &c.block, &c.seq); err != nil || n != 2 || c.String() != s {
+		return replayCursor{}, errInvalidReplayCursor
+	}
+	return c, nil
+}
+
+type replayEvent struct {
+	cursor replayCursor
+	log    *types.Log // nil for headers
+	data   json.RawMessage
+}
+
+// replayWindow keeps the last events of a subscription type, the events of at
+// most blocks blocks and size bytes.
+type replayWindow struct {
+	blocks uint64
+	limit  int
+
+	mu      sync.Mutex
+	events  []*replayEvent
+	first   uint64            // position of events[0] among all the events ever added
+	size    int               // encoded size of the events
+	latest  uint64            // highest block number of the events
+	seqs    map[uint64]uint64 // next sequence of the blocks in the window
+	changed chan struct{}     // closed when events are added
+}
+
+func newReplayWindow(blocks uint64, limit int) *replayWindow {
+	return &replayWindow{
+		blocks:  blocks,
+		limit:   limit,
+		seqs:    make(map[uint64]uint64),
+		changed: make(chan struct{}),
+	}
+}
+
+// add appends the event of the given block to the window. The sequence of the
+// events of a block keeps increasing across reorgs, so that cursors are never
+// reused.
+func (w *replayWindow) add(block uint64, log *types.Log, event interface{}) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seq := w.seqs[block]
+	w.seqs[block] = seq + 1
+	w.events = append(w.events, &replayEvent{cursor: replayCursor{block, seq}, log: log, data: data})
+	w.size += len(data)
+	if block > w.latest {
+		w.latest = block
+	}
+	for len(w.events) > 1 {
+		oldest := w.events[0]
+		if (w.limit <= 0 || w.size <= w.limit) && oldest.cursor.block+w.blocks > w.latest {
+			break
+		}
+		w.events[0] = nil
+		w.events = w.events[1:]
+		w.first++
+		w.size -= len(oldest.data)
+		if oldest.cursor.block+w.blocks <= w.latest {
+			delete(w.seqs, oldest.cursor.block)
+		}
+	}
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+// position returns the position of the first event to deliver to a durable
+// subscription: the next event to be added, or the event after the cursor if
+// the subscription is resumed.
+func (w *replayWindow) position(resumeFrom *string) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if resumeFrom == nil {
+		return w.first + uint64(len(w.events)), nil
+	}
+	cursor, err := parseReplayCursor(*resumeFrom)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range w.events {
+		if event.cursor == cursor {
+			return w.first + uint64(i) + 1, nil
+		}
+	}
+	return 0, errReplayWindowExceeded
+}
+
+// since returns the events from the position on and a channel closed once more
+// are added, or errReplayWindowExceeded if some of them were dropped.
+func (w *replayWindow) since(pos uint64) ([]*replayEvent, <-chan struct{}, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if pos < w.first {
+		return nil, nil, errReplayWindowExceeded
+	}
+	return w.events[pos-w.first:], w.changed, nil
+}
+
+// replayWindows feeds the replay windows with the chain events.
+type replayWindows struct {
+	heads *replayWindow
+	logs  *replayWindow
+}
+
+// EnableReplay makes durable newHeads and logs subscriptions available,
+// retaining the events of the last blocks, and at most size bytes of events of
+// each type if size is positive. It must be called before the API is served.
+func (api *PublicFilterAPI) EnableReplay(blocks uint64, size int) {
+	api.replay = &replayWindows{
+		heads: newReplayWindow(blocks, size),
+		logs:  newReplayWindow(blocks, size),
+	}
+	go api.replay.feedHeads(api.backend)
+	go api.replay.feedLogs(api.backend)
+}
+
+func (r *replayWindows) feedHeads(backend Backend) {
+	chainCh := make(chan core.ChainEvent)
+	sub := backend.SubscribeChainEvent(chainCh)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case ev := <-chainCh:
+			r.heads.add(ev.Block.NumberU64(), nil, ev.Block.Header())
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+// feedLogs adds the logs in the order of the live subscriptions, as the logs
+// removed by a reorg are sent before the ones of the new chain.
+func (r *replayWindows) feedLogs(backend Backend) {
+	logsCh := make(chan []*types.Log)
+	rmLogsCh := make(chan core.RemovedLogsEvent)
+	logsSub := backend.SubscribeLogsEvent(logsCh)
+	rmLogsSub := backend.SubscribeRemovedLogsEvent(rmLogsCh)
+	defer logsSub.Unsubscribe()
+	defer rmLogsSub.Unsubscribe()
+	for {
+		select {
+		case logs := <-logsCh:
+			for _, log := range logs {
+				r.logs.add(log.BlockNumber, log, log)
+			}
+		case ev := <-rmLogsCh:
+			for _, log := range ev.Logs {
+				r.logs.add(log.BlockNumber, log, log)
+			}
+		case <-logsSub.Err():
+			return
+		case <-rmLogsSub.Err():
+			return
+		}
+	}
+}
+
+// subscribeDurable creates a durable subscription delivering the events of
+// the window accepted by the filter, returning an error rather than creating
+// it if the events after the cursor are no longer in the window.
+func (api *PublicFilterAPI) subscribeDurable(ctx context.Context, window func(*replayWindows) *replayWindow, opts *ReplayOptions, filter func([]*replayEvent) ([]*replayEvent, error)) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if api.replay == nil {
+		return nil, errReplayDisabled
+	}
+	w := window(api.replay)
+	pos, err := w.position(opts.ResumeFrom)
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		for {
+			events, changed, err := w.since(pos)
+			if err == nil {
+				pos += uint64(len(events))
+				events, err = filter(events)
+			}
+			if err != nil {
+				// the client re-syncs or resumes from the last cursor it received
+				notifier.Stop(err)
+				return
+			}
+			for _, event := range events {
+				if err := notifier.Notify(rpcSub.ID, &DurableEvent{Cursor: event.cursor.String(), Event: event.data}); err != nil {
+					return
+				}
+			}
+			select {
+			case <-changed:
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// durableHeads creates a durable newHeads subscription.
+func (api *PublicFilterAPI) durableHeads(ctx context.Context, opts *ReplayOptions) (*rpc.Subscription, error) {
+	return api.subscribeDurable(ctx, func(r *replayWindows) *replayWindow { return r.heads }, opts,
+		func(events []*replayEvent) ([]*replayEvent, error) { return events, nil })
+}
+
+// durableLogs creates a durable logs subscription, delivering the logs matching
+// the criteria which the caller is authorized to read.
+func (api *PublicFilterAPI) durableLogs(ctx context.Context, crit FilterCriteria, opts *ReplayOptions) (*rpc.Subscription, error) {
+	return api.subscribeDurable(ctx, func(r *replayWindows) *replayWindow { return r.logs }, opts,
+		func(events []*replayEvent) ([]*replayEvent, error) {
+			var matched []*replayEvent
+			for _, event := range events {
+				if len(filterLogs([]*types.Log{event.log}, crit.FromBlock, crit.ToBlock, crit.Addresses, crit.Topics)) == 0 {
+					continue
+				}
+				authorized, err := api.filterPrivateLogs(ctx, crit.PrivateParty, []*types.Log{event.log})
+				if err != nil {
+					return nil, err
+				}
+				if len(authorized) > 0 {
+					matched = append(matched, event)
+				}
+			}
+			return matched, nil
+		})
+}
@@ -0,0 +1,213 @@
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayWindow(t *testing.T) {
+	w := newReplayWindow(3, 0)
+	for block := uint64(1); block <= 4; block++ {
+		w.add(block, nil, block)
+	}
+	// a reorg adds more events for a block already in the window
+	w.add(3, nil, "reorged")
+
+	cursors := func(events []*replayEvent) (cs []string) {
+		for _, event := range events {
+			cs = append(cs, event.cursor.String())
+		}
+		return cs
+	}
+	pos, err := w.position(nil)
+	require.NoError(t, err)
+	events, _, err := w.since(pos)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	resumeFrom := "2-0"
+	pos, err = w.position(&resumeFrom)
+	require.NoError(t, err)
+	events, _, err = w.since(pos)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3-0", "4-0", "3-1"}, cursors(events))
+
+	// the first block is out of the window
+	resumeFrom = "1-0"
+	_, err = w.position(&resumeFrom)
+	assert.Equal(t, errReplayWindowExceeded, err)
+	resumeFrom = "garbage"
+	_, err = w.position(&resumeFrom)
+	assert.Equal(t, errInvalidReplayCursor, err)
+
+	// subscriptions behind the window cannot catch up
+	w.add(6, nil, 6)
+	_, _, err = w.since(pos)
+	assert.Equal(t, errReplayWindowExceeded, err)
+}
+
+func TestReplayWindow_SizeLimit(t *testing.T) {
+	w := newReplayWindow(100, 6)
+	for block := uint64(10); block < 15; block++ {
+		w.add(block, nil, block) // 2 bytes each
+	}
+	events, _, err := w.since(w.first)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "12-0", events[0].cursor.String())
+}
+
+type durableTestEnv struct {
+	t       *testing.T
+	backend *testBackend
+	client  *rpc.Client
+}
+
+func newDurableTestEnv(t *testing.T) *durableTestEnv {
+	backend := &testBackend{mux: new(event.TypeMux), db: rawdb.NewMemoryDatabase()}
+	api := NewPublicFilterAPI(backend, false)
+	api.EnableReplay(10, 0)
+
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("eth", api))
+	client := rpc.DialInProc(srv)
+	t.Cleanup(func() {
+		client.Close()
+		srv.Stop()
+	})
+	return &durableTestEnv{t: t, backend: backend, client: client}
+}
+
+// send sends the event once the replay windows are subscribed.
+func send(feed *event.Feed, ev interface{}) {
+	for feed.Send(ev) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (env *durableTestEnv) head(number int64) {
+	send(&env.backend.chainFeed, core.ChainEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number)})})
+}
+
+func (env *durableTestEnv) subscribe(args ...interface{}) (*rpc.ClientSubscription, chan DurableEvent) {
+	ch := make(chan DurableEvent, 100)
+	sub, err := env.client.EthSubscribe(context.Background(), ch, args...)
+	require.NoError(env.t, err)
+	return sub, ch
+}
+
+func receive(t *testing.T, ch chan DurableEvent, n int) (events []DurableEvent) {
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d events out of %d", i, n)
+		}
+	}
+	return events
+}
+
+func headNumbers(t *testing.T, events []DurableEvent) (numbers []string) {
+	for _, event := range events {
+		var header types.Header
+		require.NoError(t, json.Unmarshal(event.Event, &header))
+		numbers = append(numbers, event.Cursor+"="+header.Number.String())
+	}
+	return numbers
+}
+
+func TestDurableHeads_Resume(t *testing.T) {
+	env := newDurableTestEnv(t)
+	env.head(1)
+
+	sub, ch := env.subscribe("newHeads", &ReplayOptions{Durable: true})
+	env.head(2)
+	env.head(3)
+	assert.Equal(t, []string{"2-0=2", "3-0=3"}, headNumbers(t, receive(t, ch, 2)))
+	sub.Unsubscribe()
+
+	// missed while disconnected
+	env.head(4)
+	env.head(5)
+
+	resumeFrom := "3-0"
+	_, ch = env.subscribe("newHeads", &ReplayOptions{ResumeFrom: &resumeFrom})
+	assert.Equal(t, []string{"4-0=4", "5-0=5"}, headNumbers(t, receive(t, ch, 2)))
+	env.head(6)
+	assert.Equal(t, []string{"6-0=6"}, headNumbers(t, receive(t, ch, 1)))
+
+	resumeFrom = "0-0"
+	_, err := env.client.EthSubscribe(context.Background(), make(chan DurableEvent), "newHeads", &ReplayOptions{ResumeFrom: &resumeFrom})
+	assert.EqualError(t, err, errReplayWindowExceeded.Error())
+}
+
+// replayedLog holds the fields of a replayed log checked by the tests.
+type replayedLog struct {
+	BlockNumber hexutil.Uint64
+	Removed     bool
+}
+
+func TestDurableLogs_ResumeWithCriteria(t *testing.T) {
+	env := newDurableTestEnv(t)
+	var (
+		watched = common.Address{0x01}
+		other   = common.Address{0x02}
+	)
+	sub, ch := env.subscribe("logs", map[string]interface{}{"address": watched}, &ReplayOptions{Durable: true})
+	send(&env.backend.logsFeed, []*types.Log{
+		{Address: watched, BlockNumber: 1},
+		{Address: other, BlockNumber: 1},
+		{Address: watched, BlockNumber: 1, Index: 2},
+	})
+	events := receive(t, ch, 2)
+	assert.Equal(t, "1-0", events[0].Cursor)
+	assert.Equal(t, "1-2", events[1].Cursor)
+	sub.Unsubscribe()
+
+	// logs removed by a reorg and of the new chain, missed while disconnected
+	send(&env.backend.rmLogsFeed, core.RemovedLogsEvent{Logs: []*types.Log{{Address: watched, BlockNumber: 1, Index: 2, Removed: true}}})
+	send(&env.backend.logsFeed, []*types.Log{{Address: other, BlockNumber: 1}, {Address: watched, BlockNumber: 2}})
+
+	resumeFrom := events[0].Cursor
+	_, ch = env.subscribe("logs", map[string]interface{}{"address": watched}, &ReplayOptions{ResumeFrom: &resumeFrom})
+	events = receive(t, ch, 3)
+	var logs []replayedLog
+	for _, event := range events {
+		var log replayedLog
+		require.NoError(t, json.Unmarshal(event.Event, &log))
+		logs = append(logs, log)
+	}
+	assert.Equal(t, []string{"1-2", "1-3", "2-0"}, []string{events[0].Cursor, events[1].Cursor, events[2].Cursor})
+	assert.False(t, logs[0].Removed)
+	assert.True(t, logs[1].Removed)
+	assert.Equal(t, hexutil.Uint64(2), logs[2].BlockNumber)
+}
+
+func TestDurableSubscription_Disabled(t *testing.T) {
+	backend := &testBackend{mux: new(event.TypeMux), db: rawdb.NewMemoryDatabase()}
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("eth", NewPublicFilterAPI(backend, false)))
+	client := rpc.DialInProc(srv)
+	defer client.Close()
+
+	_, err := client.EthSubscribe(context.Background(), make(chan DurableEvent), "newHeads", &ReplayOptions{Durable: true})
+	assert.EqualError(t, err, errReplayDisabled.Error())
+	// without options
+	sub, err := client.EthSubscribe(context.Background(), make(chan *types.Header), "newHeads")
+	require.NoError(t, err)
+	sub.Unsubscribe()
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -99,8 +100,9 @@ type ProtocolManager struct {
 	peerWG    sync.WaitGroup
 
 	// Quorum
-	raftMode bool
-	engine   consensus.Engine
+	raftMode     bool
+	engine       consensus.Engine
+	capabilities *capabilityRegistry
 
 	// Test fields or hooks
 	broadcastTxAnnouncesOnly bool // Testing field, disable transaction propagation
@@ -236,12 +238,7 @@ func (pm *ProtocolManager) makeProtocol(version uint) p2p.Protocol {
 		NodeInfo: func() interface{} {
 			return pm.NodeInfo()
 		},
-		PeerInfo: func(id enode.ID) interface{} {
-			if p := pm.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
-				return p.Info()
-			}
-			return nil
-		},
+		PeerInfo: pm.peerInfo,
 	}
 }
 
@@ -794,6 +791,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
+		pm.blockchain.PrefetchPrivatePayloads(request.Block) // Quorum
 		pm.blockFetcher.Enqueue(p.id, request.Block)
 
 		// Assuming the block is importable by the peer, but possibly not yet done so,
@@ -969,6 +967,21 @@ func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propaga
 	}
 }
 
+// Quorum
+// SendTransactionsToPeer sends the transactions to the peer right away, even
+// those it is known to have, reporting whether the peer is connected.
+func (pm *ProtocolManager) SendTransactionsToPeer(id enode.ID, txs types.Transactions) bool {
+	peer := pm.peers.Peer(fmt.Sprintf("%x", id[:8]))
+	if peer == nil {
+		return false
+	}
+	if err := peer.SendTransactions64(txs); err != nil {
+		peer.Log().Debug("Failed to send transactions", "count", len(txs), "err", err)
+		return false
+	}
+	return true
+}
+
 // minedBroadcastLoop sends mined blocks to connected peers.
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	defer pm.wg.Done()
@@ -1011,6 +1024,8 @@ type NodeInfo struct {
 	Config     *params.ChainConfig `json:"config"`     // Chain configuration for the fork rules
 	Head       common.Hash         `json:"head"`       // SHA3 hash of the host's best owned block
 	Consensus  string              `json:"consensus"`  // Consensus mechanism in use
+
+	EmergencyReadOnly *emergency.State `json:"emergencyReadOnly,omitempty"` // Quorum: set while in emergency read-only mode
 }
 
 // NodeInfo retrieves some protocol metadata about the running host node.
@@ -1024,7 +1039,7 @@ func (pm *ProtocolManager) NodeInfo() *NodeInfo {
 	chainConfig := pm.blockchain.Config()
 	chainConfig.MaxCodeSize = uint64(chainConfig.GetMaxCodeSize(pm.blockchain.CurrentBlock().Number()) / 1024)
 
-	return &NodeInfo{
+	info := &NodeInfo{
 		Network:    pm.networkID,
 		Difficulty: pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64()),
 		Genesis:    pm.blockchain.Genesis().Hash(),
@@ -1032,6 +1047,10 @@ func (pm *ProtocolManager) NodeInfo() *NodeInfo {
 		Head:       currentBlock.Hash(),
 		Consensus:  pm.getConsensusAlgorithm(),
 	}
+	if state := emergency.Get(); state.Enabled {
+		info.EmergencyReadOnly = &state
+	}
+	return info
 }
 
 // Quorum
@@ -85,7 +85,7 @@ func newTestProtocolManager(mode downloader.SyncMode, blocks int, generator func
 // that uses the specified consensus mechanism.
 func newTestProtocolManagerConsensus(consensusAlgo string, cliqueConfig *params.CliqueConfig, istanbulConfig *params.IstanbulConfig, raftMode bool) (*ProtocolManager, ethdb.Database, error) {
 
-	config := params.QuorumTestChainConfig
+	config := *params.QuorumTestChainConfig // Quorum: not leaking the consensus into the other tests
 	config.Clique = cliqueConfig
 	config.Istanbul = istanbulConfig
 
@@ -125,7 +125,7 @@ func newTestProtocolManagerConsensus(consensusAlgo string, cliqueConfig *params.
 		engine = ethash.NewFaker()
 	}
 
-	pm, err := NewProtocolManager(config, nil, downloader.FullSync, DefaultConfig.NetworkId, evmux, &testTxPool{added: nil}, engine, blockchain, db, 1, nil, raftMode)
+	pm, err := NewProtocolManager(&config, nil, downloader.FullSync, DefaultConfig.NetworkId, evmux, &testTxPool{added: nil}, engine, blockchain, db, 1, nil, raftMode)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,264 @@
+package eth
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// Services embedding the node register hooks called back on the blocks imported
+// into the canonical chain, instead of polling the RPC APIs. The hooks of all
+// the registrations are called in turn on a dedicated goroutine, so that they
+// never slow the import down by more than their time budget: a registration
+// whose call overruns it has its calls dropped until that call returns.
+
+// PrivateStateIdentifier identifies the private state passed to the hooks, the
+// node having a single one.
+const PrivateStateIdentifier = "private"
+
+const (
+	defaultHookBudget = time.Second
+	hookEventChanSize = 64 // chain events buffered ahead of the hooks
+)
+
+var (
+	hookPanicMeter    = metrics.NewRegisteredMeter("eth/hooks/panics", nil)
+	hookOverrunMeter  = metrics.NewRegisteredMeter("eth/hooks/overruns", nil)
+	hookDroppedMeter  = metrics.NewRegisteredMeter("eth/hooks/dropped", nil)
+	hookDispatchTimer = metrics.NewRegisteredTimer("eth/hooks/dispatch", nil)
+)
+
+// Hooks are the callbacks of an embedder on the blocks imported into the
+// canonical chain, any of them being optional. For each block they are called
+// in this order:
+//
+//	OnPrivateTransactionProcessed for each private transaction, in block order
+//	OnPrivateStateUpdated once, if the private transactions touched contracts
+//	OnBlockFinalized once
+//
+// The hooks must not modify their arguments.
+type Hooks struct {
+	OnPrivateTransactionProcessed func(txHash common.Hash, psi string, receiptStatus uint64)
+
+	// OnPrivateStateUpdated is passed the contracts created and called by the
+	// successful private transactions of the block, and those which logged, in
+	// the order they were first touched.
+	OnPrivateStateUpdated func(psi string, contractAddresses []common.Address, blockHash common.Hash)
+
+	// OnBlockFinalized is called once the block is imported. The block is final
+	// with the raft and istanbul consensus, it may leave the chain in a reorg
+	// with the others.
+	OnBlockFinalized func(header *types.Header)
+
+	// Budget is the time all the hooks of a block may take, one second if zero.
+	Budget time.Duration
+}
+
+// privateTransaction is a private transaction of a block and the status of its
+// receipt.
+type privateTransaction struct {
+	hash   common.Hash
+	status uint64
+}
+
+type hookRegistration struct {
+	hooks Hooks
+	busy  int32 // set while a call overruns its budget
+}
+
+// run calls the hooks on the block, isolating their panics.
+func (r *hookRegistration) run(header *types.Header, txs []privateTransaction, contracts []common.Address) {
+	if hook := r.hooks.OnPrivateTransactionProcessed; hook != nil {
+		for _, tx := range txs {
+			safely("OnPrivateTransactionProcessed", header, func() { hook(tx.hash, PrivateStateIdentifier, tx.status) })
+		}
+	}
+	if hook := r.hooks.OnPrivateStateUpdated; hook != nil && len(contracts) > 0 {
+		safely("OnPrivateStateUpdated", header, func() { hook(PrivateStateIdentifier, contracts, header.Hash()) })
+	}
+	if hook := r.hooks.OnBlockFinalized; hook != nil {
+		safely("OnBlockFinalized", header, func() { hook(header) })
+	}
+}
+
+func safely(name string, header *types.Header, hook func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			hookPanicMeter.Mark(1)
+			log.Error("Hook panicked", "hook", name, "number", header.Number, "hash", header.Hash(), "err", err)
+		}
+	}()
+	hook()
+}
+
+// hookDispatcher calls the hooks of the registrations on the blocks imported.
+type hookDispatcher struct {
+	chain *core.BlockChain
+
+	lock          sync.Mutex
+	registrations []*hookRegistration
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newHookDispatcher(chain *core.BlockChain) *hookDispatcher {
+	return &hookDispatcher{
+		chain: chain,
+		quit:  make(chan struct{}),
+	}
+}
+
+func (d *hookDispatcher) start() {
+	d.wg.Add(1)
+	go d.loop()
+}
+
+// stop stops the dispatch, without waiting for the calls overrunning their
+// budget.
+func (d *hookDispatcher) stop() {
+	close(d.quit)
+	d.wg.Wait()
+}
+
+func (d *hookDispatcher) register(hooks Hooks) func() {
+	if hooks.Budget <= 0 {
+		hooks.Budget = defaultHookBudget
+	}
+	r := &hookRegistration{hooks: hooks}
+	d.lock.Lock()
+	d.registrations = append(d.registrations, r)
+	d.lock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			for i, registered := range d.registrations {
+				if registered == r {
+					d.registrations = append(d.registrations[:i:i], d.registrations[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+func (d *hookDispatcher) loop() {
+	defer d.wg.Done()
+
+	chainCh := make(chan core.ChainEvent, hookEventChanSize)
+	sub := d.chain.SubscribeChainEvent(chainCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			d.dispatch(ev.Block)
+		case <-sub.Err():
+			return
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// dispatch calls the hooks of the registrations in turn on the block.
+func (d *hookDispatcher) dispatch(block *types.Block) {
+	d.lock.Lock()
+	registrations := d.registrations
+	d.lock.Unlock()
+	if len(registrations) == 0 {
+		return
+	}
+	defer hookDispatchTimer.UpdateSince(time.Now())
+
+	header := block.Header()
+	txs, contracts := d.privateActivity(block)
+	for _, r := range registrations {
+		if atomic.LoadInt32(&r.busy) == 1 {
+			hookDroppedMeter.Mark(1)
+			log.Debug("Dropped hooks of a slow consumer", "number", block.Number(), "hash", block.Hash())
+			continue
+		}
+		done := make(chan struct{})
+		go func(r *hookRegistration) {
+			defer close(done)
+			r.run(header, txs, contracts)
+		}(r)
+
+		timer := time.NewTimer(r.hooks.Budget)
+		select {
+		case <-done:
+		case <-timer.C:
+			hookOverrunMeter.Mark(1)
+			log.Warn("Hooks overran their time budget, dropping their calls until they return", "number", block.Number(), "hash", block.Hash(), "budget", r.hooks.Budget)
+			atomic.StoreInt32(&r.busy, 1)
+			go func(r *hookRegistration) {
+				<-done
+				atomic.StoreInt32(&r.busy, 0)
+			}(r)
+		case <-d.quit:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+	}
+}
+
+// privateActivity returns the private transactions of the block, and the
+// contracts they touched.
+func (d *hookDispatcher) privateActivity(block *types.Block) ([]privateTransaction, []common.Address) {
+	var (
+		receipts  types.Receipts
+		txs       []privateTransaction
+		contracts []common.Address
+		touched   = make(map[common.Address]bool)
+	)
+	touch := func(address common.Address) {
+		if !touched[address] {
+			touched[address] = true
+			contracts = append(contracts, address)
+		}
+	}
+	for i, tx := range block.Transactions() {
+		if !tx.IsPrivate() {
+			continue
+		}
+		if receipts == nil {
+			if receipts = d.chain.GetReceiptsByHash(block.Hash()); len(receipts) != len(block.Transactions()) {
+				log.Error("Hooks missing the receipts of a block", "number", block.Number(), "hash", block.Hash(), "receipts", len(receipts), "txs", len(block.Transactions()))
+				return nil, nil
+			}
+		}
+		receipt := receipts[i]
+		txs = append(txs, privateTransaction{hash: tx.Hash(), status: receipt.Status})
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		if tx.To() == nil {
+			touch(receipt.ContractAddress)
+		} else {
+			touch(*tx.To())
+		}
+		for _, l := range receipt.Logs {
+			touch(l.Address)
+		}
+	}
+	return txs, contracts
+}
+
+// RegisterHooks registers the hooks with the node, returning the function
+// deregistering them. A call under way when they are deregistered completes.
+func (s *Ethereum) RegisterHooks(hooks Hooks) (deregister func()) {
+	return s.hooks.register(hooks)
+}
@@ -0,0 +1,47 @@
+package eth_test
+
+import (
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// This example reacts to the private transactions of the blocks imported by an
+// embedded node, as they are imported.
+func ExampleEthereum_RegisterHooks() {
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		log.Fatalf("Failed to create network node: %v", err)
+	}
+	defer stack.Close()
+	config := eth.DefaultConfig
+	config.Genesis = core.DefaultGenesisBlock()
+	ethereum, err := eth.New(stack, &config)
+	if err != nil {
+		log.Fatalf("Failed to create the eth service: %v", err)
+	}
+
+	deregister := ethereum.RegisterHooks(eth.Hooks{
+		OnPrivateTransactionProcessed: func(txHash common.Hash, psi string, receiptStatus uint64) {
+			log.Printf("private transaction %x processed in %s with status %d", txHash, psi, receiptStatus)
+		},
+		OnPrivateStateUpdated: func(psi string, contractAddresses []common.Address, blockHash common.Hash) {
+			log.Printf("%d contracts of %s updated in block %x", len(contractAddresses), psi, blockHash)
+		},
+		OnBlockFinalized: func(header *types.Header) {
+			log.Printf("block %d imported", header.Number)
+		},
+		// the hooks are not called on the next blocks while they take longer
+		Budget: 100 * time.Millisecond,
+	})
+	defer deregister()
+
+	if err := stack.Start(); err != nil {
+		log.Fatalf("Failed to start the protocol stack: %v", err)
+	}
+}
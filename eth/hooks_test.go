@@ -0,0 +1,244 @@
+package eth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/privatefixtures"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHooksChain returns an empty chain and the blocks of a fixture with private
+// transactions to import into it.
+func newHooksChain(t *testing.T, blocks int) (*core.BlockChain, types.Blocks) {
+	dir, err := ioutil.TempDir("", "eth-hooks-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	cfg := privatefixtures.DefaultConfig
+	cfg.Blocks, cfg.TxsPerBlock = blocks, 4
+	fixture, err := privatefixtures.Generate(dir, cfg)
+	require.NoError(t, err)
+	t.Cleanup(fixture.InstallPTM())
+	fixtureBlocks, err := fixture.Blocks()
+	require.NoError(t, err)
+
+	db := rawdb.NewMemoryDatabase()
+	fixture.Genesis.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, fixture.Genesis.Config, fixture.Engine(db), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(chain.Stop)
+	return chain, fixtureBlocks
+}
+
+// hookRecorder records the calls of the hooks.
+type hookRecorder struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (r *hookRecorder) record(call string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *hookRecorder) recorded() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func (r *hookRecorder) hooks() Hooks {
+	return Hooks{
+		OnPrivateTransactionProcessed: func(txHash common.Hash, psi string, receiptStatus uint64) {
+			r.record(fmt.Sprintf("tx %x %s %d", txHash[:4], psi, receiptStatus))
+		},
+		OnPrivateStateUpdated: func(psi string, contractAddresses []common.Address, blockHash common.Hash) {
+			r.record(fmt.Sprintf("state %s %d %x", psi, len(contractAddresses), blockHash[:4]))
+		},
+		OnBlockFinalized: func(header *types.Header) {
+			r.record(fmt.Sprintf("block %d", header.Number))
+		},
+	}
+}
+
+func (r *hookRecorder) waitFor(t *testing.T, call string) {
+	waitUntil(t, func() bool {
+		for _, recorded := range r.recorded() {
+			if recorded == call {
+				return true
+			}
+		}
+		return false
+	}, "no %q", call)
+}
+
+// waitUntil polls the condition, as assert.Eventually of this testify version
+// races with it.
+func waitUntil(t *testing.T, condition func() bool, msgAndArgs ...interface{}) {
+	for deadline := time.Now().Add(5 * time.Second); !condition(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			require.FailNow(t, "condition not met", msgAndArgs...)
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	chain, blocks := newHooksChain(t, 4)
+	d := newHookDispatcher(chain)
+	d.start()
+	defer d.stop()
+
+	var panics int32
+	defer d.register(Hooks{
+		OnPrivateTransactionProcessed: func(common.Hash, string, uint64) {
+			atomic.AddInt32(&panics, 1)
+			panic("private transaction")
+		},
+		OnBlockFinalized: func(*types.Header) { panic("block") },
+	})()
+	recorder := new(hookRecorder)
+	defer d.register(recorder.hooks())()
+
+	_, err := chain.InsertChain(blocks)
+	require.NoError(t, err)
+	recorder.waitFor(t, fmt.Sprintf("block %d", len(blocks)))
+
+	// the calls of each block in order, the panics being isolated
+	var expected []string
+	privateTxs := 0
+	for _, block := range blocks {
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		contracts := make(map[common.Address]bool)
+		for i, tx := range block.Transactions() {
+			if !tx.IsPrivate() {
+				continue
+			}
+			privateTxs++
+			hash := tx.Hash()
+			expected = append(expected, fmt.Sprintf("tx %x %s %d", hash[:4], PrivateStateIdentifier, receipts[i].Status))
+			if receipts[i].Status == types.ReceiptStatusSuccessful {
+				if tx.To() == nil {
+					contracts[receipts[i].ContractAddress] = true
+				} else {
+					contracts[*tx.To()] = true
+				}
+			}
+		}
+		if len(contracts) > 0 {
+			hash := block.Hash()
+			expected = append(expected, fmt.Sprintf("state %s %d %x", PrivateStateIdentifier, len(contracts), hash[:4]))
+		}
+		expected = append(expected, fmt.Sprintf("block %d", block.Number()))
+	}
+	require.NotZero(t, privateTxs)
+	assert.Equal(t, expected, recorder.recorded())
+	assert.Equal(t, int32(privateTxs), atomic.LoadInt32(&panics))
+}
+
+func TestHooks_SlowConsumer(t *testing.T) {
+	chain, blocks := newHooksChain(t, 4)
+	d := newHookDispatcher(chain)
+	d.start()
+	defer d.stop()
+
+	release := make(chan struct{})
+	slow := new(hookRecorder)
+	d.register(Hooks{
+		OnBlockFinalized: func(header *types.Header) {
+			if header.Number.Uint64() == 1 {
+				<-release
+			}
+			slow.record(fmt.Sprintf("block %d", header.Number))
+		},
+		Budget: 20 * time.Millisecond,
+	})
+	slowRegistration := d.registrations[0]
+	fast := new(hookRecorder)
+	deregister := d.register(fast.hooks())
+
+	// the slow consumer overruns its budget, then misses the next block
+	_, err := chain.InsertChain(blocks[:1])
+	require.NoError(t, err)
+	fast.waitFor(t, "block 1")
+	_, err = chain.InsertChain(blocks[1:2])
+	require.NoError(t, err)
+	fast.waitFor(t, "block 2")
+	assert.Empty(t, slow.recorded())
+
+	// it gets the blocks again once its call returns
+	close(release)
+	slow.waitFor(t, "block 1")
+	waitUntil(t, func() bool { return atomic.LoadInt32(&slowRegistration.busy) == 0 })
+	_, err = chain.InsertChain(blocks[2:3])
+	require.NoError(t, err)
+	slow.waitFor(t, "block 3")
+	assert.Equal(t, []string{"block 1", "block 3"}, slow.recorded())
+
+	// deregistered hooks are no longer called
+	deregister()
+	deregister()
+	_, err = chain.InsertChain(blocks[3:])
+	require.NoError(t, err)
+	slow.waitFor(t, "block 4")
+	assert.Equal(t, []string{"block 1", "block 2", "block 3"}, filterCalls(fast.recorded(), "block"))
+	assert.Len(t, d.registrations, 1)
+}
+
+func TestHooks_ConcurrentRegistrations(t *testing.T) {
+	chain, blocks := newHooksChain(t, 8)
+	d := newHookDispatcher(chain)
+	d.start()
+	defer d.stop()
+
+	recorder := new(hookRecorder)
+	defer d.register(recorder.hooks())()
+
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-quit:
+					return
+				default:
+				}
+				r := new(hookRecorder)
+				d.register(r.hooks())()
+			}
+		}()
+	}
+	for i := range blocks {
+		_, err := chain.InsertChain(blocks[i : i+1])
+		require.NoError(t, err)
+	}
+	recorder.waitFor(t, fmt.Sprintf("block %d", len(blocks)))
+	close(quit)
+	wg.Wait()
+	assert.Len(t, filterCalls(recorder.recorded(), "block"), len(blocks))
+}
+
+// filterCalls returns the calls of the hook.
+func filterCalls(calls []string, hook string) []string {
+	var filtered []string
+	for _, call := range calls {
+		if len(call) > len(hook) && call[:len(hook)+1] == hook+" " {
+			filtered = append(filtered, call)
+		}
+	}
+	return filtered
+}
@@ -77,6 +77,8 @@ type PeerInfo struct {
 	Version    int      `json:"version"`    // Ethereum protocol version negotiated
 	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
 	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
+
+	QuorumCapabilities *PeerCapabilities `json:"quorumCapabilities,omitempty"` // Quorum: nil if not advertised
 }
 
 // propEvent is a block propagation, waiting for its turn in the broadcast queue.
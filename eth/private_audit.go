@@ -0,0 +1,347 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"golang.org/x/time/rate"
+)
+
+// Quorum
+//
+// A node serves the internals of the private transactions it is party to, the
+// private payload and the private receipt, to the auditor nodes listed in its
+// PrivateAuditors over the privateAuditProtocolName subprotocol, the peers
+// being authenticated and the messages encrypted by the RLPx transport. The
+// list is a node-local policy, never consensus: the requests of the other
+// peers are refused, those of the auditors rate-limited, and every disclosure
+// is logged.
+//
+// An auditor asks its peers for the internals with quorum_fetchMarkerInternals,
+// the private transaction being the public marker of its payload, and keeps
+// those disclosed in its database to answer the next requests locally.
+
+const (
+	privateAuditProtocolName    = "paudit"
+	privateAuditProtocolVersion = 1
+	privateAuditProtocolLength  = 2
+
+	getPrivateInternalsMsg = 0x00
+	privateInternalsMsg    = 0x01
+
+	privateAuditRate    = 2                // requests per second served to an auditor
+	privateAuditBurst   = 16               // requests served at once to an auditor
+	privateAuditTimeout = 10 * time.Second // timeout of a fetch from the peers
+)
+
+// privateInternalsPrefix + tx hash -> JSON of PrivateInternals, on the auditor
+var privateInternalsPrefix = []byte("quorum-private-internals-")
+
+var (
+	privateAuditServedMeter  = metrics.NewRegisteredMeter("p2p/privateaudit/served", nil)
+	privateAuditRefusedMeter = metrics.NewRegisteredMeter("p2p/privateaudit/refused", nil)
+	privateAuditFetchedMeter = metrics.NewRegisteredMeter("p2p/privateaudit/fetched", nil)
+)
+
+var errPrivateAuditDisabled = errors.New("fetching the private transaction internals is not enabled")
+
+// privateAuditEnabled returns whether the node runs the subprotocol, to serve
+// its auditors or to fetch from its peers.
+func privateAuditEnabled(config *Config) bool {
+	return (len(config.PrivateAuditors) > 0 && private.IsQuorumPrivacyEnabled()) || config.PrivateAuditFetch
+}
+
+// PrivateInternals are the internals of a private transaction disclosed to an
+// auditor.
+type PrivateInternals struct {
+	TxHash      common.Hash            `json:"txHash"`
+	BlockHash   common.Hash            `json:"blockHash"`
+	BlockNumber hexutil.Uint64         `json:"blockNumber"`
+	Payload     hexutil.Bytes          `json:"payload"` // data of the inner transaction
+	PrivacyFlag engine.PrivacyFlagType `json:"privacyFlag"`
+	Receipt     *types.Receipt         `json:"receipt"` // private receipt
+	Source      enode.ID               `json:"source"`  // node which disclosed them
+}
+
+// getPrivateInternalsPacket is the content of getPrivateInternalsMsg.
+type getPrivateInternalsPacket struct {
+	ID     uint64
+	TxHash common.Hash
+}
+
+// privateInternalsPacket is the content of privateInternalsMsg.
+type privateInternalsPacket struct {
+	ID        uint64
+	Internals []byte // JSON of PrivateInternals, empty if refused
+	Error     string
+}
+
+// privateAuditPeer is a peer running the subprotocol.
+type privateAuditPeer struct {
+	rw      p2p.MsgReadWriter
+	limiter *rate.Limiter
+}
+
+// privateAuditRequest is a request awaiting the response of a peer.
+type privateAuditRequest struct {
+	peer enode.ID
+	wait chan *privateInternalsPacket
+}
+
+// privateAudit serves the internals of the private transactions to the
+// auditors, and fetches them from the peers for an auditor.
+type privateAudit struct {
+	db       ethdb.Database
+	receipts func(blockHash common.Hash) types.Receipts // of the blocks, private ones included
+	auditors map[enode.ID]bool                          // served
+	fetching bool
+
+	lock    sync.Mutex
+	peers   map[enode.ID]*privateAuditPeer
+	pending map[uint64]*privateAuditRequest // by ID
+	nextID  uint64
+}
+
+func newPrivateAudit(db ethdb.Database, receipts func(common.Hash) types.Receipts, auditors []enode.ID, fetching bool) *privateAudit {
+	a := &privateAudit{
+		db:       db,
+		receipts: receipts,
+		fetching: fetching,
+		peers:    make(map[enode.ID]*privateAuditPeer),
+		pending:  make(map[uint64]*privateAuditRequest),
+		auditors: make(map[enode.ID]bool, len(auditors)),
+	}
+	for _, id := range auditors {
+		a.auditors[id] = true
+	}
+	return a
+}
+
+func (a *privateAudit) protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    privateAuditProtocolName,
+		Version: privateAuditProtocolVersion,
+		Length:  privateAuditProtocolLength,
+		Run:     a.run,
+	}
+}
+
+// run handles the requests and responses of the peer until it disconnects.
+func (a *privateAudit) run(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peer := &privateAuditPeer{rw: rw, limiter: rate.NewLimiter(privateAuditRate, privateAuditBurst)}
+	a.lock.Lock()
+	a.peers[p.ID()] = peer
+	a.lock.Unlock()
+	defer a.remove(p.ID())
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > protocolMaxMsgSize {
+			return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
+		}
+		switch msg.Code {
+		case getPrivateInternalsMsg:
+			var req getPrivateInternalsPacket
+			if err := msg.Decode(&req); err != nil {
+				return errResp(ErrDecode, "%v: %v", msg, err)
+			}
+			if refusal := a.checkRequest(p.ID(), peer, &req); refusal != nil {
+				go p2p.Send(rw, privateInternalsMsg, refusal)
+				break
+			}
+			go p2p.Send(rw, privateInternalsMsg, a.serve(p.ID(), &req))
+		case privateInternalsMsg:
+			var res privateInternalsPacket
+			if err := msg.Decode(&res); err != nil {
+				return errResp(ErrDecode, "%v: %v", msg, err)
+			}
+			a.deliver(p.ID(), &res)
+		}
+		msg.Discard()
+	}
+}
+
+// checkRequest returns the refusal of a request, unless from an auditor within
+// its rate.
+func (a *privateAudit) checkRequest(id enode.ID, peer *privateAuditPeer, req *getPrivateInternalsPacket) *privateInternalsPacket {
+	if !a.auditors[id] {
+		privateAuditRefusedMeter.Mark(1)
+		log.Debug("Private transaction internals refused to a peer not an auditor", "peer", id, "tx", req.TxHash)
+		return &privateInternalsPacket{ID: req.ID, Error: "not an auditor of the node"}
+	}
+	if !peer.limiter.Allow() {
+		privateAuditRefusedMeter.Mark(1)
+		return &privateInternalsPacket{ID: req.ID, Error: "request rate exceeded"}
+	}
+	return nil
+}
+
+// serve returns the internals requested by an auditor, logging the disclosure.
+func (a *privateAudit) serve(id enode.ID, req *getPrivateInternalsPacket) *privateInternalsPacket {
+	res := &privateInternalsPacket{ID: req.ID}
+	ctx, cancel := context.WithTimeout(context.Background(), privateAuditTimeout)
+	defer cancel()
+	internals, err := a.internals(ctx, req.TxHash)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if res.Internals, err = json.Marshal(internals); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	privateAuditServedMeter.Mark(1)
+	log.Info("Private transaction internals disclosed to auditor", "auditor", id, "tx", req.TxHash, "number", uint64(internals.BlockNumber))
+	return res
+}
+
+// internals returns the internals of a private transaction the node is party
+// to.
+func (a *privateAudit) internals(ctx context.Context, hash common.Hash) (*PrivateInternals, error) {
+	tx, blockHash, number, index := rawdb.ReadTransaction(a.db, hash)
+	if tx == nil {
+		return nil, errors.New("unknown transaction")
+	}
+	if !tx.IsPrivate() {
+		return nil, errors.New("not a private transaction")
+	}
+	_, _, payload, extra, err := private.P.Receive(ctx, common.BytesToEncryptedPayloadHash(tx.Data()))
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) == 0 {
+		return nil, errors.New("not a party to the transaction")
+	}
+	receipts := a.receipts(blockHash)
+	if uint64(len(receipts)) <= index {
+		return nil, errors.New("receipt not found")
+	}
+	internals := &PrivateInternals{
+		TxHash:      hash,
+		BlockHash:   blockHash,
+		BlockNumber: hexutil.Uint64(number),
+		Payload:     payload,
+		Receipt:     receipts[index],
+	}
+	if extra != nil {
+		internals.PrivacyFlag = extra.PrivacyFlag
+	}
+	return internals, nil
+}
+
+// fetch returns the internals of a private transaction kept locally, or else
+// asks all the peers for them, keeping the first disclosed.
+func (a *privateAudit) fetch(ctx context.Context, hash common.Hash) (*PrivateInternals, error) {
+	if !a.fetching {
+		return nil, errPrivateAuditDisabled
+	}
+	if enc, err := a.db.Get(append(privateInternalsPrefix, hash.Bytes()...)); err == nil {
+		var internals PrivateInternals
+		if err := json.Unmarshal(enc, &internals); err == nil {
+			return &internals, nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, privateAuditTimeout)
+	defer cancel()
+
+	responses := make(chan *privateInternalsPacket, 1)
+	asked := make(map[uint64]enode.ID)
+	a.lock.Lock()
+	for id, peer := range a.peers {
+		a.nextID++
+		req := &privateAuditRequest{peer: id, wait: make(chan *privateInternalsPacket, 1)}
+		a.pending[a.nextID] = req
+		asked[a.nextID] = id
+		go func(rw p2p.MsgReadWriter, reqID uint64, wait chan *privateInternalsPacket) {
+			if err := p2p.Send(rw, getPrivateInternalsMsg, &getPrivateInternalsPacket{ID: reqID, TxHash: hash}); err != nil {
+				wait <- &privateInternalsPacket{ID: reqID, Error: err.Error()}
+			}
+			select {
+			case res := <-wait:
+				select {
+				case responses <- res:
+				case <-ctx.Done():
+				}
+			case <-ctx.Done():
+			}
+		}(peer.rw, a.nextID, req.wait)
+	}
+	a.lock.Unlock()
+	defer func() {
+		a.lock.Lock()
+		for reqID := range asked {
+			delete(a.pending, reqID)
+		}
+		a.lock.Unlock()
+	}()
+	if len(asked) == 0 {
+		return nil, errors.New("no peer serving the private transaction internals")
+	}
+
+	var refusals []string
+	for len(refusals) < len(asked) {
+		select {
+		case res := <-responses:
+			source := asked[res.ID]
+			if res.Error != "" {
+				refusals = append(refusals, fmt.Sprintf("%s: %s", source.TerminalString(), res.Error))
+				continue
+			}
+			var internals PrivateInternals
+			if err := json.Unmarshal(res.Internals, &internals); err != nil || internals.TxHash != hash {
+				refusals = append(refusals, fmt.Sprintf("%s: invalid internals", source.TerminalString()))
+				continue
+			}
+			internals.Source = source
+			if enc, err := json.Marshal(&internals); err == nil {
+				if err := a.db.Put(append(privateInternalsPrefix, hash.Bytes()...), enc); err != nil {
+					log.Warn("Failed to store the private transaction internals", "tx", hash, "err", err)
+				}
+			}
+			privateAuditFetchedMeter.Mark(1)
+			return &internals, nil
+		case <-ctx.Done():
+			return nil, fmt.Errorf("private transaction internals not disclosed: %v", ctx.Err())
+		}
+	}
+	return nil, fmt.Errorf("private transaction internals not disclosed: %s", strings.Join(refusals, ", "))
+}
+
+// deliver hands a response of a peer over to the fetch awaiting it.
+func (a *privateAudit) deliver(id enode.ID, res *privateInternalsPacket) {
+	a.lock.Lock()
+	req, ok := a.pending[res.ID]
+	if ok && req.peer == id {
+		delete(a.pending, res.ID)
+	}
+	a.lock.Unlock()
+	if ok && req.peer == id {
+		req.wait <- res
+	}
+}
+
+func (a *privateAudit) remove(id enode.ID) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.peers, id)
+}
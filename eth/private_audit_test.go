@@ -0,0 +1,132 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// auditPTM is party to the payloads it knows.
+type auditPTM struct {
+	notinuse.PrivateTransactionManager
+	payloads map[common.EncryptedPayloadHash][]byte
+}
+
+func (ptm *auditPTM) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	return "", nil, ptm.payloads[hash], &engine.ExtraMetadata{PrivacyFlag: engine.PrivacyFlagPartyProtection}, nil
+}
+
+// writeAuditedBlock writes a block of a private transaction with its private
+// receipt, returning the transaction and the receipts.
+func writeAuditedBlock(db ethdb.Database, payload common.EncryptedPayloadHash) (*types.Transaction, func(common.Hash) types.Receipts) {
+	tx := types.NewTransaction(0, common.Address{0x01}, common.Big0, 100000, common.Big0, payload.Bytes())
+	tx.SetPrivate()
+	block := types.NewBlock(&types.Header{Number: big.NewInt(7)}, []*types.Transaction{tx}, nil, nil, new(trie.Trie))
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	rawdb.WriteTxLookupEntries(db, block)
+	receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash(), Logs: []*types.Log{}}}
+	return tx, func(hash common.Hash) types.Receipts {
+		if hash != block.Hash() {
+			return nil
+		}
+		return receipts
+	}
+}
+
+func TestPrivateAudit_FetchFromParty(t *testing.T) {
+	payload := common.EncryptedPayloadHash{0x01}
+	saved := private.P
+	defer func() { private.P = saved }()
+	private.P = &auditPTM{payloads: map[common.EncryptedPayloadHash][]byte{payload: {0xca, 0xfe}}}
+
+	partyID, auditorID := enode.ID{0x01}, enode.ID{0x02}
+	partyDb := rawdb.NewMemoryDatabase()
+	tx, receipts := writeAuditedBlock(partyDb, payload)
+	party := newPrivateAudit(partyDb, receipts, []enode.ID{auditorID}, false)
+	auditorDb := rawdb.NewMemoryDatabase()
+	auditor := newPrivateAudit(auditorDb, nil, nil, true)
+
+	rwParty, rwAuditor := p2p.MsgPipe()
+	go party.run(p2p.NewPeer(auditorID, "auditor", nil), rwParty)
+	go auditor.run(p2p.NewPeer(partyID, "party", nil), rwAuditor)
+	require.Eventually(t, func() bool {
+		auditor.lock.Lock()
+		defer auditor.lock.Unlock()
+		return len(auditor.peers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	internals, err := auditor.fetch(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash(), internals.TxHash)
+	assert.Equal(t, uint64(7), uint64(internals.BlockNumber))
+	assert.Equal(t, []byte{0xca, 0xfe}, []byte(internals.Payload))
+	assert.Equal(t, engine.PrivacyFlagPartyProtection, internals.PrivacyFlag)
+	assert.Equal(t, tx.Hash(), internals.Receipt.TxHash)
+	assert.Equal(t, partyID, internals.Source)
+
+	// kept locally once disclosed
+	rwParty.Close()
+	rwAuditor.Close()
+	kept, err := auditor.fetch(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, internals, kept)
+
+	_, err = party.fetch(context.Background(), tx.Hash())
+	assert.Equal(t, errPrivateAuditDisabled, err)
+}
+
+func TestPrivateAudit_RefusedToNonAuditor(t *testing.T) {
+	payload := common.EncryptedPayloadHash{0x01}
+	saved := private.P
+	defer func() { private.P = saved }()
+	private.P = &auditPTM{payloads: map[common.EncryptedPayloadHash][]byte{payload: {0xca, 0xfe}}}
+
+	partyDb := rawdb.NewMemoryDatabase()
+	tx, receipts := writeAuditedBlock(partyDb, payload)
+	party := newPrivateAudit(partyDb, receipts, []enode.ID{{0x03}}, false)
+	peer := newPrivateAudit(rawdb.NewMemoryDatabase(), nil, nil, true)
+
+	rwParty, rwPeer := p2p.MsgPipe()
+	defer rwParty.Close()
+	defer rwPeer.Close()
+	go party.run(p2p.NewPeer(enode.ID{0x02}, "peer", nil), rwParty)
+	go peer.run(p2p.NewPeer(enode.ID{0x01}, "party", nil), rwPeer)
+	require.Eventually(t, func() bool {
+		peer.lock.Lock()
+		defer peer.lock.Unlock()
+		return len(peer.peers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := peer.fetch(context.Background(), tx.Hash())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not an auditor of the node")
+}
+
+func TestPrivateAudit_RateLimited(t *testing.T) {
+	auditorID := enode.ID{0x02}
+	a := newPrivateAudit(rawdb.NewMemoryDatabase(), nil, []enode.ID{auditorID}, false)
+	peer := &privateAuditPeer{limiter: rate.NewLimiter(rate.Every(time.Hour), 1)}
+	req := &getPrivateInternalsPacket{ID: 1}
+
+	assert.Nil(t, a.checkRequest(auditorID, peer, req))
+	refusal := a.checkRequest(auditorID, peer, req)
+	require.NotNil(t, refusal)
+	assert.Equal(t, "request rate exceeded", refusal.Error)
+	assert.Equal(t, uint64(1), refusal.ID)
+}
@@ -0,0 +1,349 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/time/rate"
+)
+
+// Quorum
+//
+// Once a block is imported, the node hints the peers party to its private
+// transactions over the privateHintsProtocolName subprotocol, so that they
+// prefetch the payloads from their own transaction manager before the block
+// reaches them. The peers announce the public keys of their transaction
+// manager when connecting, and are hinted the payloads one of their keys
+// participates in.
+//
+// A hint only carries the payload hash, already public as the data of the
+// transaction, and the block. It is signed with the node key, rate-limited
+// by the receivers, and only ever schedules a prefetch: the invalid hints are
+// ignored without disconnecting the peer, and the import never depends on
+// them.
+
+const (
+	privateHintsProtocolName    = "phint"
+	privateHintsProtocolVersion = 1
+	privateHintsProtocolLength  = 2
+
+	privateKeysMsg = 0x00
+	privateHintMsg = 0x01
+
+	privateHintsPerBlock = 256              // private transactions of a block hinted at most
+	privateHintsRate     = 64               // hints per second accepted from a peer
+	privateHintsBurst    = 256              // hints accepted at once from a peer
+	privateHintsMaxKeys  = 64               // keys of a peer matched at most
+	privateHintsTimeout  = 10 * time.Second // timeout of the transaction manager requests for a block
+)
+
+var (
+	privateHintsSentMeter     = metrics.NewRegisteredMeter("p2p/privatehints/sent", nil)
+	privateHintsReceivedMeter = metrics.NewRegisteredMeter("p2p/privatehints/received", nil)
+	privateHintsDroppedMeter  = metrics.NewRegisteredMeter("p2p/privatehints/dropped", nil)
+	privateHintsInvalidMeter  = metrics.NewRegisteredMeter("p2p/privatehints/invalid", nil)
+	privateHintsSkippedMeter  = metrics.NewRegisteredMeter("p2p/privatehints/skipped", nil)
+)
+
+// privateHintsEnabled returns whether the private hints are exchanged with
+// the peers, only by the nodes processing private transactions.
+func privateHintsEnabled(config *Config) bool {
+	return config.PrivateHints && private.IsQuorumPrivacyEnabled() && !config.ReadOnly
+}
+
+// privateKeysPacket is the content of privateKeysMsg.
+type privateKeysPacket struct {
+	Keys []string
+}
+
+// privateHintPacket is the content of privateHintMsg.
+type privateHintPacket struct {
+	PayloadHash common.EncryptedPayloadHash
+	BlockHash   common.Hash
+	Number      uint64
+	Signature   []byte // signature of the node key over the hash of the other fields
+}
+
+// sigHash returns the hash the hint is signed over.
+func (h *privateHintPacket) sigHash() common.Hash {
+	enc, err := rlp.EncodeToBytes([]interface{}{h.PayloadHash, h.BlockHash, h.Number})
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(enc)
+}
+
+// privateHintsPeer is a peer running the subprotocol.
+type privateHintsPeer struct {
+	rw      p2p.MsgReadWriter
+	limiter *rate.Limiter
+
+	lock sync.RWMutex
+	keys map[string]bool // announced keys of the transaction manager
+}
+
+// participates returns whether one of the announced keys is a participant.
+func (p *privateHintsPeer) participates(participants []string) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for _, participant := range participants {
+		if p.keys[participant] {
+			return true
+		}
+	}
+	return false
+}
+
+// privateHints hints the party peers of the private payloads of the imported
+// blocks, and schedules the payloads hinted by the peers to be prefetched.
+type privateHints struct {
+	key          *ecdsa.PrivateKey
+	chain        *core.BlockChain
+	capabilities *capabilityRegistry // nil to hint all the party peers
+
+	// prefetch schedules a hinted payload, the prefetcher of the chain
+	prefetch func(blockHash common.Hash, number uint64, hash common.EncryptedPayloadHash)
+
+	lock  sync.RWMutex
+	peers map[enode.ID]*privateHintsPeer
+
+	blocks chan *types.Block // the latest imported block waiting to be hinted
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newPrivateHints(key *ecdsa.PrivateKey, chain *core.BlockChain, capabilities *capabilityRegistry) *privateHints {
+	return &privateHints{
+		key:          key,
+		chain:        chain,
+		capabilities: capabilities,
+		prefetch:     chain.PrefetchHintedPrivatePayload,
+		peers:        make(map[enode.ID]*privateHintsPeer),
+		blocks:       make(chan *types.Block, 1),
+		quit:         make(chan struct{}),
+	}
+}
+
+func (h *privateHints) protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    privateHintsProtocolName,
+		Version: privateHintsProtocolVersion,
+		Length:  privateHintsProtocolLength,
+		Run:     h.run,
+	}
+}
+
+// start hints the party peers of the imported blocks.
+func (h *privateHints) start() {
+	headCh := make(chan core.ChainHeadEvent, 16)
+	sub := h.chain.SubscribeChainHeadEvent(headCh)
+
+	h.wg.Add(2)
+	go h.loop(headCh, sub)
+	go h.hintLoop()
+}
+
+func (h *privateHints) stop() {
+	close(h.quit)
+	h.wg.Wait()
+}
+
+// loop hands the new heads over to hintLoop, the blocks it is still busy
+// with being skipped rather than holding up the chain.
+func (h *privateHints) loop(headCh <-chan core.ChainHeadEvent, sub event.Subscription) {
+	defer h.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			select {
+			case h.blocks <- ev.Block:
+			default:
+				privateHintsSkippedMeter.Mark(1)
+			}
+		case <-sub.Err():
+			return
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+func (h *privateHints) hintLoop() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case block := <-h.blocks:
+			h.hint(block)
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// hint sends the hints of the private transactions of the block the node is
+// party to, to the peers participating in them.
+func (h *privateHints) hint(block *types.Block) {
+	if h.peerCount() == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), privateHintsTimeout)
+	defer cancel()
+
+	hinted := 0
+	for _, tx := range block.Transactions() {
+		if !tx.IsPrivate() {
+			continue
+		}
+		if hinted >= privateHintsPerBlock || ctx.Err() != nil {
+			break
+		}
+		hash := common.BytesToEncryptedPayloadHash(tx.Data())
+		// only the parties processed the payload
+		if _, _, data, _, err := private.P.Receive(ctx, hash); err != nil || len(data) == 0 {
+			continue
+		}
+		participants, err := private.P.GetParticipants(ctx, hash)
+		if err != nil {
+			log.Debug("Failed to get the participants of private payload", "hash", hash, "err", err)
+			continue
+		}
+		peers := h.partyPeers(participants)
+		if len(peers) == 0 {
+			continue
+		}
+		packet := &privateHintPacket{PayloadHash: hash, BlockHash: block.Hash(), Number: block.NumberU64()}
+		if packet.Signature, err = crypto.Sign(packet.sigHash().Bytes(), h.key); err != nil {
+			log.Warn("Failed to sign private hint", "err", err)
+			return
+		}
+		for _, peer := range peers {
+			if err := p2p.Send(peer.rw, privateHintMsg, packet); err == nil {
+				privateHintsSentMeter.Mark(1)
+			}
+		}
+		hinted++
+	}
+}
+
+// partyPeers returns the peers supporting the hints which announced a key of
+// the participants.
+func (h *privateHints) partyPeers(participants []string) []*privateHintsPeer {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	var peers []*privateHintsPeer
+	for id, peer := range h.peers {
+		if h.capabilities.supports(id, CapPrivateHints) && peer.participates(participants) {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
+func (h *privateHints) peerCount() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return len(h.peers)
+}
+
+// run announces the local keys and handles the hints of the peer until it
+// disconnects. It never returns on its own, as that would disconnect the peer.
+func (h *privateHints) run(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peer := &privateHintsPeer{
+		rw:      rw,
+		limiter: rate.NewLimiter(privateHintsRate, privateHintsBurst),
+		keys:    make(map[string]bool),
+	}
+	h.lock.Lock()
+	h.peers[p.ID()] = peer
+	h.lock.Unlock()
+	defer h.remove(p.ID())
+
+	// the peers running the subprotocol implement it, whether or not they
+	// advertised the capability yet
+	if lister, ok := private.P.(private.KeyLister); ok {
+		if keys, err := lister.Keys(); err != nil {
+			p.Log().Debug("Failed to list the private transaction manager keys", "err", err)
+		} else {
+			go p2p.Send(rw, privateKeysMsg, &privateKeysPacket{Keys: keys})
+		}
+	}
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > protocolMaxMsgSize {
+			return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, protocolMaxMsgSize)
+		}
+		switch msg.Code {
+		case privateKeysMsg:
+			var packet privateKeysPacket
+			if err := msg.Decode(&packet); err != nil {
+				p.Log().Debug("Invalid private transaction manager keys", "err", err)
+				break
+			}
+			if len(packet.Keys) > privateHintsMaxKeys {
+				packet.Keys = packet.Keys[:privateHintsMaxKeys]
+			}
+			keys := make(map[string]bool, len(packet.Keys))
+			for _, key := range packet.Keys {
+				keys[key] = true
+			}
+			peer.lock.Lock()
+			peer.keys = keys
+			peer.lock.Unlock()
+		case privateHintMsg:
+			var packet privateHintPacket
+			if err := msg.Decode(&packet); err != nil {
+				privateHintsInvalidMeter.Mark(1)
+				p.Log().Debug("Invalid private hint", "err", err)
+				break
+			}
+			h.handleHint(p, peer, &packet)
+		}
+		msg.Discard()
+	}
+}
+
+// handleHint schedules the prefetch of a hint signed by the peer, unless it
+// exceeds the rate of the peer.
+func (h *privateHints) handleHint(p *p2p.Peer, peer *privateHintsPeer, packet *privateHintPacket) {
+	if !peer.limiter.Allow() {
+		privateHintsDroppedMeter.Mark(1)
+		return
+	}
+	pub, err := crypto.SigToPub(packet.sigHash().Bytes(), packet.Signature)
+	if err != nil || enode.PubkeyToIDV4(pub) != p.ID() {
+		privateHintsInvalidMeter.Mark(1)
+		p.Log().Debug("Invalid private hint signature", "hash", packet.PayloadHash, "err", err)
+		return
+	}
+	privateHintsReceivedMeter.Mark(1)
+	h.prefetch(packet.BlockHash, packet.Number, packet.PayloadHash)
+}
+
+func (h *privateHints) remove(id enode.ID) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.peers, id)
+}
@@ -0,0 +1,142 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// hintsPTM is party to the payloads it knows the participants of.
+type hintsPTM struct {
+	notinuse.PrivateTransactionManager
+	keys         []string
+	participants map[common.EncryptedPayloadHash][]string
+}
+
+func (ptm *hintsPTM) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	if _, ok := ptm.participants[hash]; !ok {
+		return "", nil, nil, nil, nil
+	}
+	return "", nil, []byte{0x01}, &engine.ExtraMetadata{}, nil
+}
+
+func (ptm *hintsPTM) GetParticipants(ctx context.Context, hash common.EncryptedPayloadHash) ([]string, error) {
+	return ptm.participants[hash], nil
+}
+
+func (ptm *hintsPTM) Keys() ([]string, error) {
+	return ptm.keys, nil
+}
+
+type hintedPayload struct {
+	blockHash common.Hash
+	number    uint64
+	hash      common.EncryptedPayloadHash
+}
+
+// newTestPrivateHints returns hints recording the payloads hinted to prefetch.
+func newTestPrivateHints(t *testing.T) (*privateHints, chan hintedPayload) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	h := newPrivateHints(key, nil, nil)
+	hinted := make(chan hintedPayload, 16)
+	h.prefetch = func(blockHash common.Hash, number uint64, hash common.EncryptedPayloadHash) {
+		hinted <- hintedPayload{blockHash, number, hash}
+	}
+	return h, hinted
+}
+
+func newHintsPeer(h *privateHints, name string) *p2p.Peer {
+	return p2p.NewPeer(enode.PubkeyToIDV4(&h.key.PublicKey), name, nil)
+}
+
+func newHintsBlock(payloads ...common.EncryptedPayloadHash) *types.Block {
+	var txs []*types.Transaction
+	for i, payload := range payloads {
+		tx := types.NewTransaction(uint64(i), common.Address{0x01}, common.Big0, 100000, common.Big0, payload.Bytes())
+		tx.SetPrivate()
+		txs = append(txs, tx)
+	}
+	return types.NewBlock(&types.Header{Number: big.NewInt(7)}, txs, nil, nil, new(trie.Trie))
+}
+
+func TestPrivateHints_PartyPeersHinted(t *testing.T) {
+	party, other, notParty := common.EncryptedPayloadHash{0x01}, common.EncryptedPayloadHash{0x02}, common.EncryptedPayloadHash{0x03}
+	saved := private.P
+	defer func() { private.P = saved }()
+	// both nodes share the transaction manager, announcing the key B
+	private.P = &hintsPTM{
+		keys: []string{"B"},
+		participants: map[common.EncryptedPayloadHash][]string{
+			party: {"A", "B"},
+			other: {"A", "C"},
+		},
+	}
+
+	a, _ := newTestPrivateHints(t)
+	b, hinted := newTestPrivateHints(t)
+	rwA, rwB := p2p.MsgPipe()
+	defer rwA.Close()
+	defer rwB.Close()
+	go a.run(newHintsPeer(b, "b"), rwA)
+	go b.run(newHintsPeer(a, "a"), rwB)
+
+	require.Eventually(t, func() bool {
+		peers := a.partyPeers([]string{"B"})
+		return len(peers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	block := newHintsBlock(notParty, other, party)
+	a.hint(block)
+	select {
+	case payload := <-hinted:
+		assert.Equal(t, hintedPayload{block.Hash(), 7, party}, payload)
+	case <-time.After(time.Second):
+		t.Fatal("payload not hinted")
+	}
+	select {
+	case payload := <-hinted:
+		t.Fatalf("unexpected hint %v", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the peers without the capability are not hinted
+	a.capabilities = newCapabilityRegistry(localCapabilities)
+	assert.Empty(t, a.partyPeers([]string{"B"}))
+}
+
+func TestPrivateHints_InvalidHintsIgnored(t *testing.T) {
+	h, hinted := newTestPrivateHints(t)
+	sender, _ := newTestPrivateHints(t)
+	p := newHintsPeer(sender, "sender")
+	peer := &privateHintsPeer{limiter: rate.NewLimiter(rate.Every(time.Hour), 1)}
+
+	packet := &privateHintPacket{PayloadHash: common.EncryptedPayloadHash{0x01}, BlockHash: common.Hash{0x02}, Number: 7}
+	forger, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	packet.Signature, err = crypto.Sign(packet.sigHash().Bytes(), forger)
+	require.NoError(t, err)
+	h.handleHint(p, &privateHintsPeer{limiter: rate.NewLimiter(1, 1)}, packet)
+	assert.Empty(t, hinted, "hint signed by another node")
+
+	// hints beyond the rate of the peer are dropped
+	packet.Signature, err = crypto.Sign(packet.sigHash().Bytes(), sender.key)
+	require.NoError(t, err)
+	h.handleHint(p, peer, packet)
+	h.handleHint(p, peer, packet)
+	assert.Len(t, hinted, 1)
+}
@@ -76,12 +76,9 @@ func (pm *ProtocolManager) makeQuorumConsensusProtocol(ProtoName string, version
 		NodeInfo: func() interface{} {
 			return pm.NodeInfo()
 		},
-		PeerInfo: func(id enode.ID) interface{} {
-			if p := pm.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
-				return p.Info()
-			}
-			return nil
-		},
+		PeerInfo: pm.peerInfo,
+		// the consensus messages are written before the gossip of the "eth" protocol
+		Prioritize: func(uint64) bool { return true },
 	}
 }
 
@@ -146,15 +143,17 @@ func (pm *ProtocolManager) makeLegacyProtocol(protoName string, version uint, le
 		NodeInfo: func() interface{} {
 			return pm.NodeInfo()
 		},
-		PeerInfo: func(id enode.ID) interface{} {
-			if p := pm.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
-				return p.Info()
-			}
-			return nil
-		},
+		PeerInfo:   pm.peerInfo,
+		Prioritize: isLegacyConsensusMsg,
 	}
 }
 
+// isLegacyConsensusMsg reports whether a message of a legacy subprotocol is a
+// consensus message, those following the "eth" messages, e.g. istanbulMsg.
+func isLegacyConsensusMsg(code uint64) bool {
+	return code >= protocolLengths[eth65]
+}
+
 func (s *Ethereum) quorumConsensusProtocols() []p2p.Protocol {
 	protos := make([]p2p.Protocol, len(quorumConsensusProtocolVersions))
 	for i, vsn := range quorumConsensusProtocolVersions {
@@ -195,6 +194,17 @@ func isLegacyProtocol(name string, version uint) bool {
 	return false
 }
 
+// peerInfo returns the metadata of the peer, with the Quorum capabilities it
+// advertised.
+func (pm *ProtocolManager) peerInfo(id enode.ID) interface{} {
+	if p := pm.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+		info := p.Info()
+		info.QuorumCapabilities = pm.capabilities.peerInfo(id)
+		return info
+	}
+	return nil
+}
+
 // Used to send consensus subprotocol messages from an "eth" peer, e.g.  "istanbul/100" subprotocol messages.
 func (p *peer) SendConsensus(msgcode uint64, data interface{}) error {
 	if p.consensusRw == nil {
@@ -0,0 +1,277 @@
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ErrReadOnlyNode is returned by every write path (mining, transaction
+// submission, private transaction manager sends) of a node started with
+// Config.ReadOnly.
+var ErrReadOnlyNode = errors.New("node is running in read-only mode")
+
+// defaultReadOnlyRefreshInterval is used when Config.ReadOnlyRefreshInterval
+// is not set.
+const defaultReadOnlyRefreshInterval = time.Second
+
+// headPointer is the content of the head file published by a writer node and
+// consumed by the read-only nodes sharing its database.
+type headPointer struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+func readHeadPointer(path string) (*headPointer, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ptr := new(headPointer)
+	if err := json.Unmarshal(blob, ptr); err != nil {
+		return nil, fmt.Errorf("invalid head file %s: %v", path, err)
+	}
+	return ptr, nil
+}
+
+// writeHeadPointer replaces the head file atomically so readers never observe
+// a partially written pointer.
+func writeHeadPointer(path string, ptr *headPointer) error {
+	blob, err := json.Marshal(ptr)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadReadOnlyChainConfig retrieves the chain configuration stored by the
+// writer node, as a read-only node cannot run the genesis setup.
+func loadReadOnlyChainConfig(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+	genesisHash := rawdb.ReadCanonicalHash(db, 0)
+	if genesisHash == (common.Hash{}) {
+		return nil, common.Hash{}, errors.New("read-only database has no genesis block")
+	}
+	chainConfig := rawdb.ReadChainConfig(db, genesisHash)
+	if chainConfig == nil {
+		return nil, common.Hash{}, errors.New("read-only database has no stored chain configuration")
+	}
+	return chainConfig, genesisHash, nil
+}
+
+// headPublisher writes the current chain head of a writer node to a file
+// every time it changes.
+type headPublisher struct {
+	path  string
+	chain *core.BlockChain
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newHeadPublisher(path string, chain *core.BlockChain) *headPublisher {
+	return &headPublisher{
+		path:  path,
+		chain: chain,
+		quit:  make(chan struct{}),
+	}
+}
+
+func (p *headPublisher) start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+func (p *headPublisher) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+func (p *headPublisher) loop() {
+	defer p.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := p.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	p.publish(p.chain.CurrentBlock().NumberU64(), p.chain.CurrentBlock().Hash())
+	for {
+		select {
+		case ev := <-headCh:
+			p.publish(ev.Block.NumberU64(), ev.Block.Hash())
+		case <-sub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *headPublisher) publish(number uint64, hash common.Hash) {
+	if err := writeHeadPointer(p.path, &headPointer{Number: hexutil.Uint64(number), Hash: hash}); err != nil {
+		log.Warn("Failed to publish chain head", "path", p.path, "number", number, "hash", hash, "err", err)
+	}
+}
+
+// headFollower periodically refreshes the view of a replicated database and
+// moves the chain head of a read-only node to the head published by the
+// writer. If no head file is configured the head block marker stored in the
+// database itself is followed.
+type headFollower struct {
+	path     string
+	interval time.Duration
+	refresh  func() error
+	db       ethdb.Database
+	chain    *core.BlockChain
+
+	lock        sync.RWMutex
+	lastRefresh time.Time
+	lastErr     error
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newHeadFollower(path string, interval time.Duration, refresh func() error, db ethdb.Database, chain *core.BlockChain) *headFollower {
+	if interval <= 0 {
+		interval = defaultReadOnlyRefreshInterval
+	}
+	return &headFollower{
+		path:        path,
+		interval:    interval,
+		refresh:     refresh,
+		db:          db,
+		chain:       chain,
+		lastRefresh: time.Now(),
+		quit:        make(chan struct{}),
+	}
+}
+
+func (f *headFollower) start() {
+	f.wg.Add(1)
+	go f.loop()
+}
+
+func (f *headFollower) stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
+
+func (f *headFollower) loop() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := f.update()
+			if err != nil {
+				log.Debug("Failed to refresh read-only chain head", "err", err)
+			}
+			f.lock.Lock()
+			if err == nil {
+				f.lastRefresh = time.Now()
+			}
+			f.lastErr = err
+			f.lock.Unlock()
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// update re-opens the database and follows the published head if it moved.
+func (f *headFollower) update() error {
+	if err := f.refresh(); err != nil {
+		return err
+	}
+	var hash common.Hash
+	if f.path != "" {
+		ptr, err := readHeadPointer(f.path)
+		if err != nil {
+			return err
+		}
+		hash = ptr.Hash
+	} else {
+		hash = rawdb.ReadHeadBlockHash(f.db)
+	}
+	if hash == (common.Hash{}) || hash == f.chain.CurrentBlock().Hash() {
+		return nil
+	}
+	block := f.chain.GetBlockByHash(hash)
+	if block == nil {
+		return fmt.Errorf("published head %x not yet replicated", hash)
+	}
+	f.chain.FollowHead(block)
+	return nil
+}
+
+// staleness returns the time elapsed since the last successful refresh.
+func (f *headFollower) staleness() time.Duration {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return time.Since(f.lastRefresh)
+}
+
+// ReadOnlyStatus describes the chain view of a read-only node.
+type ReadOnlyStatus struct {
+	ReadOnly        bool           `json:"readOnly"`
+	Number          hexutil.Uint64 `json:"number"`
+	Hash            common.Hash    `json:"hash"`
+	RefreshInterval string         `json:"refreshInterval,omitempty"`
+	LastRefresh     *time.Time     `json:"lastRefresh,omitempty"`
+	StalenessMillis int64          `json:"stalenessMillis"`
+	LastError       string         `json:"lastError,omitempty"`
+}
+
+func (s *Ethereum) readOnlyStatus() *ReadOnlyStatus {
+	head := s.blockchain.CurrentBlock()
+	status := &ReadOnlyStatus{
+		Number: hexutil.Uint64(head.NumberU64()),
+		Hash:   head.Hash(),
+	}
+	if s.headFollower == nil {
+		return status
+	}
+	f := s.headFollower
+	f.lock.RLock()
+	lastRefresh := f.lastRefresh
+	if f.lastErr != nil {
+		status.LastError = f.lastErr.Error()
+	}
+	f.lock.RUnlock()
+
+	status.ReadOnly = true
+	status.RefreshInterval = f.interval.String()
+	status.LastRefresh = &lastRefresh
+	status.StalenessMillis = time.Since(lastRefresh).Milliseconds()
+	return status
+}
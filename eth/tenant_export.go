@@ -0,0 +1,300 @@
+package eth
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/private"
+)
+
+// Quorum
+//
+// The private data of a tenant, the parties of the private transactions being
+// its transaction manager key, is exported by quorum_exportTenantData to a
+// gzipped tar archive, in the background. The canonical blocks are scanned up
+// to the head when the export starts, an entry being written for each block
+// with transactions of the tenant, with their payload hashes and receipts. The
+// private state of the contracts they created or called follows, as of that
+// head, then the manifest. The archive is named with a .partial suffix until
+// complete.
+
+const tenantExportVersion = 1
+
+var errTenantExportRunning = errors.New("tenant export already running")
+
+// TenantExportStatus is the progress of the last tenant export.
+type TenantExportStatus struct {
+	Running      bool   `json:"running"`
+	Tenant       string `json:"tenant"`
+	Archive      string `json:"archive"`
+	Next         uint64 `json:"next"`
+	Last         uint64 `json:"last"`
+	Transactions uint64 `json:"transactions"`
+	Accounts     uint64 `json:"accounts"`
+	Error        string `json:"error,omitempty"`
+}
+
+// TenantExportManifest is the last entry of an export archive.
+type TenantExportManifest struct {
+	Version      int         `json:"version"`
+	Tenant       string      `json:"tenant"`
+	BlockNumber  uint64      `json:"blockNumber"`
+	BlockHash    common.Hash `json:"blockHash"`
+	Transactions uint64      `json:"transactions"`
+	Accounts     uint64      `json:"accounts"`
+}
+
+// TenantExportTransaction is a private transaction of the tenant.
+type TenantExportTransaction struct {
+	Hash        common.Hash    `json:"hash"`
+	PayloadHash string         `json:"payloadHash"` // base64, as known to the transaction manager
+	Receipt     *types.Receipt `json:"receipt"`
+}
+
+// TenantExportBlock is the entry of a block with transactions of the tenant.
+type TenantExportBlock struct {
+	Number       uint64                    `json:"number"`
+	Hash         common.Hash               `json:"hash"`
+	Transactions []TenantExportTransaction `json:"transactions"`
+}
+
+type tenantExporter struct {
+	chain *core.BlockChain
+	// participants returns the parties of a private payload
+	participants func(hash common.EncryptedPayloadHash) ([]string, error)
+
+	lock   sync.Mutex
+	status *TenantExportStatus
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newTenantExporter(chain *core.BlockChain) *tenantExporter {
+	return &tenantExporter{
+		chain: chain,
+		participants: func(hash common.EncryptedPayloadHash) ([]string, error) {
+			return private.P.GetParticipants(context.Background(), hash)
+		},
+		quit: make(chan struct{}),
+	}
+}
+
+// stop interrupts any running export, leaving its partial archive.
+func (x *tenantExporter) stop() {
+	close(x.quit)
+	x.wg.Wait()
+}
+
+// export starts exporting the data of the tenant to an archive in the target
+// directory.
+func (x *tenantExporter) export(tenant, targetDir string) (*TenantExportStatus, error) {
+	if tenant == "" {
+		return nil, errors.New("tenant key required")
+	}
+	if info, err := os.Stat(targetDir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", targetDir)
+	}
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if x.status != nil && x.status.Running {
+		return nil, errTenantExportRunning
+	}
+	head := x.chain.CurrentBlock()
+	// the keys are base64, the archive is named after their hash
+	name := fmt.Sprintf("tenant-%x-%d.tar.gz", crypto.Keccak256([]byte(tenant))[:8], head.NumberU64())
+	archive := filepath.Join(targetDir, name)
+	if _, err := os.Stat(archive); err == nil {
+		return nil, fmt.Errorf("archive %s already exists", archive)
+	}
+	file, err := os.OpenFile(archive+".partial", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	x.status = &TenantExportStatus{Running: true, Tenant: tenant, Archive: archive, Last: head.NumberU64()}
+	log.Info("Exporting tenant data", "tenant", tenant, "archive", archive, "head", head.NumberU64())
+
+	x.wg.Add(1)
+	go x.run(tenant, head, file, archive)
+
+	status := *x.status
+	return &status, nil
+}
+
+// exportStatus returns the progress of the last export, nil if none ran.
+func (x *tenantExporter) exportStatus() *TenantExportStatus {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if x.status == nil {
+		return nil
+	}
+	status := *x.status
+	return &status
+}
+
+func (x *tenantExporter) run(tenant string, head *types.Block, file *os.File, archive string) {
+	defer x.wg.Done()
+
+	start := time.Now()
+	err := x.write(tenant, head, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), archive)
+	}
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.status.Running = false
+	if err != nil {
+		x.status.Error = err.Error()
+		log.Error("Tenant export failed", "tenant", tenant, "archive", file.Name(), "err", err)
+		return
+	}
+	log.Info("Exported tenant data", "tenant", tenant, "archive", archive, "transactions", x.status.Transactions, "accounts", x.status.Accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+func (x *tenantExporter) write(tenant string, head *types.Block, file *os.File) error {
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	manifest := &TenantExportManifest{Version: tenantExportVersion, Tenant: tenant, BlockNumber: head.NumberU64(), BlockHash: head.Hash()}
+
+	contracts := make(map[common.Address]struct{})
+	logged := time.Now()
+	for number := uint64(0); number <= head.NumberU64(); number++ {
+		select {
+		case <-x.quit:
+			return errors.New("interrupted")
+		default:
+		}
+		block := x.chain.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("block #%d not found", number)
+		}
+		entry, err := x.scan(tenant, block, contracts)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			if err := writeTarJSON(tw, fmt.Sprintf("blocks/%012d.json", number), entry); err != nil {
+				return err
+			}
+			manifest.Transactions += uint64(len(entry.Transactions))
+		}
+		x.lock.Lock()
+		x.status.Next, x.status.Transactions = number+1, manifest.Transactions
+		x.lock.Unlock()
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting tenant data", "tenant", tenant, "block", number, "last", head.NumberU64(), "transactions", manifest.Transactions)
+			logged = time.Now()
+		}
+	}
+
+	_, privateState, err := x.chain.StateAt(head.Root())
+	if err != nil {
+		return err
+	}
+	addresses := make([]common.Address, 0, len(contracts))
+	for address := range contracts {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Hex() < addresses[j].Hex() })
+	for _, address := range addresses {
+		// a contract may have been called without being created
+		account, ok := privateState.DumpAddress(address)
+		if !ok || account.Code == "" {
+			continue
+		}
+		if err := writeTarJSON(tw, fmt.Sprintf("state/%s.json", address.Hex()), account); err != nil {
+			return err
+		}
+		manifest.Accounts++
+		x.lock.Lock()
+		x.status.Accounts = manifest.Accounts
+		x.lock.Unlock()
+	}
+
+	if err := writeTarJSON(tw, "manifest.json", manifest); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// scan returns the entry of the block, nil if it has no transaction of the
+// tenant, collecting the contracts they created or called.
+func (x *tenantExporter) scan(tenant string, block *types.Block, contracts map[common.Address]struct{}) (*TenantExportBlock, error) {
+	var (
+		entry    *TenantExportBlock
+		receipts types.Receipts
+	)
+	for i, tx := range block.Transactions() {
+		if !tx.IsPrivate() {
+			continue
+		}
+		payloadHash := common.BytesToEncryptedPayloadHash(tx.Data())
+		parties, err := x.participants(payloadHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the participants of %s: %v", tx.Hash().Hex(), err)
+		}
+		if !containsKey(parties, tenant) {
+			continue
+		}
+		if entry == nil {
+			entry = &TenantExportBlock{Number: block.NumberU64(), Hash: block.Hash()}
+			if receipts = x.chain.GetReceiptsByHash(block.Hash()); len(receipts) != len(block.Transactions()) {
+				return nil, fmt.Errorf("receipts of block #%d not found", block.NumberU64())
+			}
+		}
+		receipt := receipts[i]
+		entry.Transactions = append(entry.Transactions, TenantExportTransaction{Hash: tx.Hash(), PayloadHash: payloadHash.ToBase64(), Receipt: receipt})
+		if tx.To() != nil {
+			contracts[*tx.To()] = struct{}{}
+		} else if receipt.ContractAddress != (common.Address{}) {
+			contracts[receipt.ContractAddress] = struct{}{}
+		}
+	}
+	return entry, nil
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTarJSON(tw *tar.Writer, name string, v interface{}) error {
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(enc)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	_, err = tw.Write(enc)
+	return err
+}
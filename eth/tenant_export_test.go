@@ -0,0 +1,107 @@
+package eth
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readTarJSON returns the entries of a gzipped tar archive by name.
+func readTarJSON(t *testing.T, archive string) (map[string]json.RawMessage, []string) {
+	file, err := os.Open(archive)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	entries := make(map[string]json.RawMessage)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, names
+		}
+		require.NoError(t, err)
+		enc, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		entries[header.Name] = enc
+		names = append(names, header.Name)
+	}
+}
+
+func TestTenantExport(t *testing.T) {
+	// the private transactions of the blocks 2 and 5 are the tenant's, the one
+	// of the block 4 another tenant's
+	parties := make(map[common.EncryptedPayloadHash][]string)
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), 21000, nil, nil), types.HomesteadSigner{}, testBankKey)
+		block.AddTx(tx)
+		if i == 1 || i == 3 || i == 4 {
+			payload := common.BytesToEncryptedPayloadHash([]byte{byte(i + 1)})
+			parties[payload] = []string{"other"}
+			if i != 3 {
+				parties[payload] = append(parties[payload], "tenant")
+			}
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x02}, common.Big0, 100000, nil, payload.Bytes()), types.HomesteadSigner{}, testBankKey)
+			tx.SetPrivate()
+			block.AddTx(tx)
+		}
+	}
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 6, generator, nil)
+	defer pm.Stop()
+	x := newTenantExporter(pm.blockchain)
+	defer x.stop()
+	x.participants = func(hash common.EncryptedPayloadHash) ([]string, error) {
+		return parties[hash], nil
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	status, err := x.export("tenant", dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), status.Last)
+	require.Eventually(t, func() bool { return !x.exportStatus().Running }, 5*time.Second, 10*time.Millisecond)
+	status = x.exportStatus()
+	require.Empty(t, status.Error)
+	assert.Equal(t, uint64(7), status.Next)
+	assert.Equal(t, uint64(2), status.Transactions)
+
+	entries, names := readTarJSON(t, status.Archive)
+	assert.Equal(t, []string{"blocks/000000000002.json", "blocks/000000000005.json", "manifest.json"}, names)
+	var block TenantExportBlock
+	require.NoError(t, json.Unmarshal(entries["blocks/000000000005.json"], &block))
+	require.Len(t, block.Transactions, 1)
+	tx := pm.blockchain.GetBlockByNumber(5).Transactions()[1]
+	assert.Equal(t, tx.Hash(), block.Transactions[0].Hash)
+	assert.Equal(t, common.BytesToEncryptedPayloadHash([]byte{5}).ToBase64(), block.Transactions[0].PayloadHash)
+	assert.Equal(t, tx.Hash(), block.Transactions[0].Receipt.TxHash)
+	var manifest TenantExportManifest
+	require.NoError(t, json.Unmarshal(entries["manifest.json"], &manifest))
+	assert.Equal(t, TenantExportManifest{
+		Version:      tenantExportVersion,
+		Tenant:       "tenant",
+		BlockNumber:  6,
+		BlockHash:    pm.blockchain.CurrentBlock().Hash(),
+		Transactions: 2,
+	}, manifest)
+
+	// an archive is never overwritten
+	_, err = x.export("tenant", dir)
+	assert.Error(t, err)
+	_, err = x.export("tenant", status.Archive)
+	assert.Error(t, err)
+}
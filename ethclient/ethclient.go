@@ -553,7 +553,7 @@ func (ec *Client) PreparePrivateTransaction(data []byte, privateFrom string) (co
 	if ec.pc == nil {
 		return common.EncryptedPayloadHash{}, errors.New("missing private transaction manager client configuration")
 	}
-	payLoadHash, err := ec.pc.StoreRaw(data, privateFrom)
+	payLoadHash, err := ec.pc.StoreRaw(context.Background(), data, privateFrom)
 	return payLoadHash, err
 }
 
@@ -378,6 +378,6 @@ type privateTransactionManagerStubClient struct {
 	expectedData []byte
 }
 
-func (s *privateTransactionManagerStubClient) StoreRaw(data []byte, from string) (common.EncryptedPayloadHash, error) {
+func (s *privateTransactionManagerStubClient) StoreRaw(_ context.Context, data []byte, from string) (common.EncryptedPayloadHash, error) {
 	return common.BytesToEncryptedPayloadHash(data), nil
 }
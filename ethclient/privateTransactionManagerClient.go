@@ -2,6 +2,7 @@ package ethclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -12,7 +13,7 @@ import (
 )
 
 type privateTransactionManagerClient interface {
-	StoreRaw(data []byte, privateFrom string) (common.EncryptedPayloadHash, error)
+	StoreRaw(ctx context.Context, data []byte, privateFrom string) (common.EncryptedPayloadHash, error)
 }
 
 type privateTransactionManagerDefaultClient struct {
@@ -41,7 +42,7 @@ type storeRawResp struct {
 	Key string `json:"key"`
 }
 
-func (pc *privateTransactionManagerDefaultClient) StoreRaw(data []byte, privateFrom string) (common.EncryptedPayloadHash, error) {
+func (pc *privateTransactionManagerDefaultClient) StoreRaw(ctx context.Context, data []byte, privateFrom string) (common.EncryptedPayloadHash, error) {
 	storeRawReq := &storeRawReq{
 		Payload: base64.StdEncoding.EncodeToString(data),
 		From:    privateFrom,
@@ -50,7 +51,12 @@ func (pc *privateTransactionManagerDefaultClient) StoreRaw(data []byte, privateF
 	if err := json.NewEncoder(reqBodyBuf).Encode(storeRawReq); err != nil {
 		return common.EncryptedPayloadHash{}, err
 	}
-	resp, err := pc.httpClient.Post(pc.rawurl+"/storeraw", "application/json", reqBodyBuf)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pc.rawurl+"/storeraw", reqBodyBuf)
+	if err != nil {
+		return common.EncryptedPayloadHash{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := pc.httpClient.Do(req)
 	if err != nil {
 		return common.EncryptedPayloadHash{}, fmt.Errorf("unable to invoke /storeraw due to %s", err)
 	}
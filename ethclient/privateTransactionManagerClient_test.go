@@ -1,6 +1,7 @@
 package ethclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,7 +25,7 @@ func TestPrivateTransactionManagerClient_storeRaw(t *testing.T) {
 	testObject, err := newPrivateTransactionManagerClient(arbitraryServer.URL)
 	assert.NoError(t, err)
 
-	key, err := testObject.StoreRaw([]byte("arbitrary payload"), "arbitrary private from")
+	key, err := testObject.StoreRaw(context.Background(), []byte("arbitrary payload"), "arbitrary private from")
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedDataEPH, key)
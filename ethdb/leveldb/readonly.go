@@ -0,0 +1,185 @@
+// +build !js
+
+package leveldb
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// ErrReadOnly is returned by every write operation on a ReadOnlyDatabase.
+var ErrReadOnly = errors.New("leveldb: database is opened read-only")
+
+// ReadOnlyDatabase is a key-value store over a LevelDB directory that is
+// maintained by another process, typically a replica of a writer node's
+// chaindata. The files are opened without write access and the handle can be
+// re-opened with Refresh so that reads observe data replicated since the
+// previous refresh.
+type ReadOnlyDatabase struct {
+	fn      string
+	cache   int
+	handles int
+
+	lock    sync.RWMutex
+	db      *leveldb.DB // handle serving new reads
+	retired *leveldb.DB // previous handle, kept open one refresh longer for in-flight iterators
+
+	log log.Logger
+}
+
+// NewReadOnly opens the LevelDB database at the given path without write
+// access. Unlike New, it never attempts to recover a corrupted database.
+func NewReadOnly(file string, cache int, handles int) (*ReadOnlyDatabase, error) {
+	if cache < minCache {
+		cache = minCache
+	}
+	if handles < minHandles {
+		handles = minHandles
+	}
+	ro := &ReadOnlyDatabase{
+		fn:      file,
+		cache:   cache,
+		handles: handles,
+		log:     log.New("database", file, "readonly", true),
+	}
+	db, err := ro.open()
+	if err != nil {
+		return nil, err
+	}
+	ro.db = db
+	ro.log.Info("Opened read-only database", "cache", common.StorageSize(cache*1024*1024), "handles", handles)
+	return ro, nil
+}
+
+func (db *ReadOnlyDatabase) open() (*leveldb.DB, error) {
+	return leveldb.OpenFile(db.fn, &opt.Options{
+		OpenFilesCacheCapacity: db.handles,
+		BlockCacheCapacity:     db.cache / 2 * opt.MiB,
+		Filter:                 filter.NewBloomFilter(10),
+		ReadOnly:               true,
+	})
+}
+
+// Refresh re-opens the underlying database so that subsequent reads observe
+// the current content of the directory. The previously active handle is kept
+// open until the next refresh so iterators created from it stay valid.
+func (db *ReadOnlyDatabase) Refresh() error {
+	fresh, err := db.open()
+	if err != nil {
+		return err
+	}
+	db.lock.Lock()
+	retired := db.retired
+	db.retired, db.db = db.db, fresh
+	db.lock.Unlock()
+
+	if retired != nil {
+		if err := retired.Close(); err != nil {
+			db.log.Warn("Failed to close retired read-only handle", "err", err)
+		}
+	}
+	return nil
+}
+
+func (db *ReadOnlyDatabase) handle() *leveldb.DB {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	return db.db
+}
+
+// Close closes all handles to the underlying database.
+func (db *ReadOnlyDatabase) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.retired != nil {
+		db.retired.Close()
+		db.retired = nil
+	}
+	return db.db.Close()
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *ReadOnlyDatabase) Has(key []byte) (bool, error) {
+	return db.handle().Has(key, nil)
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *ReadOnlyDatabase) Get(key []byte) ([]byte, error) {
+	return db.handle().Get(key, nil)
+}
+
+// Put always fails with ErrReadOnly.
+func (db *ReadOnlyDatabase) Put(key []byte, value []byte) error {
+	return ErrReadOnly
+}
+
+// Delete always fails with ErrReadOnly.
+func (db *ReadOnlyDatabase) Delete(key []byte) error {
+	return ErrReadOnly
+}
+
+// NewBatch returns a batch whose Write always fails with ErrReadOnly.
+func (db *ReadOnlyDatabase) NewBatch() ethdb.Batch {
+	return new(readOnlyBatch)
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix, starting at a particular
+// initial key (or after, if it does not exist).
+func (db *ReadOnlyDatabase) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	return db.handle().NewIterator(bytesPrefixRange(prefix, start), nil)
+}
+
+// Stat returns a particular internal stat of the database.
+func (db *ReadOnlyDatabase) Stat(property string) (string, error) {
+	return db.handle().GetProperty(property)
+}
+
+// Compact always fails with ErrReadOnly.
+func (db *ReadOnlyDatabase) Compact(start []byte, limit []byte) error {
+	return ErrReadOnly
+}
+
+// Path returns the path to the database directory.
+func (db *ReadOnlyDatabase) Path() string {
+	return db.fn
+}
+
+// readOnlyBatch accumulates nothing and refuses to be written.
+type readOnlyBatch struct {
+	size int
+}
+
+func (b *readOnlyBatch) Put(key, value []byte) error {
+	b.size += len(value)
+	return nil
+}
+
+func (b *readOnlyBatch) Delete(key []byte) error {
+	b.size++
+	return nil
+}
+
+func (b *readOnlyBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *readOnlyBatch) Write() error {
+	return ErrReadOnly
+}
+
+func (b *readOnlyBatch) Reset() {
+	b.size = 0
+}
+
+func (b *readOnlyBatch) Replay(w ethdb.KeyValueWriter) error {
+	return nil
+}
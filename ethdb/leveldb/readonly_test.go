@@ -0,0 +1,83 @@
+// +build !js
+
+package leveldb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicate copies the files of a closed database into the replica directory,
+// replacing existing files atomically like a file-level replication would.
+func replicate(t *testing.T, src, dst string) {
+	files, err := ioutil.ReadDir(src)
+	require.NoError(t, err)
+	for _, f := range files {
+		if f.Name() == "LOCK" {
+			continue
+		}
+		blob, err := ioutil.ReadFile(filepath.Join(src, f.Name()))
+		require.NoError(t, err)
+		tmp := filepath.Join(dst, "."+f.Name())
+		require.NoError(t, ioutil.WriteFile(tmp, blob, 0644))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dst, f.Name())))
+	}
+}
+
+func writeKeys(t *testing.T, dir string, kv map[string]string) {
+	db, err := New(dir, 0, 0, "")
+	require.NoError(t, err)
+	for k, v := range kv {
+		require.NoError(t, db.Put([]byte(k), []byte(v)))
+	}
+	require.NoError(t, db.Close())
+}
+
+func TestReadOnlyDatabase(t *testing.T) {
+	root, err := ioutil.TempDir("", "leveldb-readonly")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	writer, replica := filepath.Join(root, "writer"), filepath.Join(root, "replica")
+	require.NoError(t, os.MkdirAll(replica, 0755))
+
+	writeKeys(t, writer, map[string]string{"a": "1"})
+	replicate(t, writer, replica)
+
+	// Several read-only handles may share the replica
+	first, err := NewReadOnly(replica, 0, 0)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := NewReadOnly(replica, 0, 0)
+	require.NoError(t, err)
+	defer second.Close()
+
+	v, err := second.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), v)
+
+	assert.Equal(t, ErrReadOnly, first.Put([]byte("b"), []byte("2")))
+	assert.Equal(t, ErrReadOnly, first.Delete([]byte("a")))
+	assert.Equal(t, ErrReadOnly, first.Compact(nil, nil))
+	batch := first.NewBatch()
+	require.NoError(t, batch.Put([]byte("b"), []byte("2")))
+	assert.Equal(t, ErrReadOnly, batch.Write())
+
+	// Replicated changes become visible after a refresh only
+	writeKeys(t, writer, map[string]string{"b": "2"})
+	replicate(t, writer, replica)
+
+	has, err := first.Has([]byte("b"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	require.NoError(t, first.Refresh())
+	v, err = first.Get([]byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), v)
+}
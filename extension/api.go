@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/multitenancy"
 	"github.com/ethereum/go-ethereum/permission/core"
+	"github.com/ethereum/go-ethereum/private/engine"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -19,8 +20,11 @@ var (
 	errNotCreator  = errors.New("account is not the creator of this extension request")
 )
 
-const extensionCompleted = "DONE"
-const extensionInProgress = "ACTIVE"
+// statuses of the management contracts
+const (
+	ExtensionCompleted  = "DONE"
+	ExtensionInProgress = "ACTIVE"
+)
 
 type PrivateExtensionAPI struct {
 	privacyService *PrivacyService
@@ -184,7 +188,7 @@ func (api *PrivateExtensionAPI) ApproveExtension(ctx context.Context, addressToV
 	if api.checkAlreadyVoted(addressToVoteOn, txArgs.From) {
 		return "", errors.New("already voted")
 	}
-	uuid, err := generateUuid(addressToVoteOn, txArgs.PrivateFrom, txArgs.PrivateFor, api.privacyService.ptm)
+	uuid, err := generateUuid(ctx, addressToVoteOn, txArgs.PrivateFrom, txArgs.PrivateFor, api.privacyService.ptm)
 	if err != nil {
 		return "", err
 	}
@@ -213,88 +217,131 @@ func (api *PrivateExtensionAPI) ApproveExtension(ctx context.Context, addressToV
 // - the new PTM public key
 // - the Ethereum addresses of who can vote to extend the contract
 func (api *PrivateExtensionAPI) ExtendContract(ctx context.Context, toExtend common.Address, newRecipientPtmPublicKey string, recipientAddr common.Address, txa ethapi.SendTxArgs) (string, error) {
+	// the checks are the ones of DryRun
+	report := api.validateExtension(ctx, toExtend, newRecipientPtmPublicKey, recipientAddr, txa)
+	if !report.Valid {
+		return "", report.errs[0]
+	}
+	txa.PrivateFor = report.PrivateFor
+
+	//generate some valid transaction options for sending in the transaction
+	txArgs, err := api.privacyService.GenerateTransactOptions(txa)
+	if err != nil {
+		return "", err
+	}
+
+	//Deploy the contract
+	tx, err := api.privacyService.managementContractFacade.Deploy(txArgs, toExtend, recipientAddr, newRecipientPtmPublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	//Return the transaction hash for later lookup
+	msg := fmt.Sprintf("0x%x", tx.Hash())
+	return msg, nil
+}
+
+// DryRun runs the checks of ExtendContract without sending any transaction, reporting
+// all the reasons the extension would be refused along with the size of the state
+// which would be shared with the new recipient.
+func (api *PrivateExtensionAPI) DryRun(ctx context.Context, toExtend common.Address, newRecipientPtmPublicKey string, recipientAddr common.Address, txa ethapi.SendTxArgs) (*ExtensionDryRun, error) {
+	return api.validateExtension(ctx, toExtend, newRecipientPtmPublicKey, recipientAddr, txa), nil
+}
+
+// validateExtension checks that the contract can be extended to the new recipient, in
+// the order of the errors ExtendContract returns. It keeps checking after a failure so
+// that a dry run reports every problem at once.
+func (api *PrivateExtensionAPI) validateExtension(ctx context.Context, toExtend common.Address, newRecipientPtmPublicKey string, recipientAddr common.Address, txa ethapi.SendTxArgs) *ExtensionDryRun {
+	report := &ExtensionDryRun{}
+	blockHash := api.privacyService.stateFetcher.getCurrentBlockHash()
+
 	// check if the contract to be extended is already under extension
 	// if yes throw an error
 	if api.checkIfContractUnderExtension(toExtend) {
-		return "", errors.New("contract extension in progress for the given contract address")
+		report.fail(errors.New("contract extension in progress for the given contract address"))
 	}
 
 	// check if a public contract is being extended
 	if api.checkIfPublicContract(toExtend) {
-		return "", errors.New("extending a public contract!!! not allowed")
+		report.fail(errors.New("extending a public contract!!! not allowed"))
 	}
 
 	// check if a public contract is being extended
-	if !api.checkIfPrivateStateExists(toExtend) {
-		return "", errors.New("extending a non-existent private contract!!! not allowed")
+	privateStateExists := api.checkIfPrivateStateExists(toExtend)
+	if !privateStateExists {
+		report.fail(errors.New("extending a non-existent private contract!!! not allowed"))
 	}
 
-	err := api.doMultiTenantChecks(ctx, toExtend, txa)
-	if err != nil {
-		return "", err
+	if err := api.doMultiTenantChecks(ctx, toExtend, txa); err != nil {
+		report.fail(err)
 	}
 
 	// check if recipient address is 0x0
 	if recipientAddr == (common.Address{0}) {
-		return "", errors.New("invalid recipient address")
+		report.fail(errors.New("invalid recipient address"))
 	}
 
-	// check if contract creator
-	if !api.privacyService.CheckIfContractCreator(api.privacyService.stateFetcher.getCurrentBlockHash(), toExtend) {
-		return "", errors.New("operation not allowed")
+	// check if contract creator, which needs the private state
+	if privateStateExists && !api.privacyService.CheckIfContractCreator(blockHash, toExtend) {
+		report.fail(errors.New("operation not allowed"))
 	}
 
 	// if running in permissioned mode with new permissions model
 	// ensure that the account extending the contract is an admin
 	// account and recipient account is an admin account as well
 	if txa.From == recipientAddr {
-		return "", errors.New("account accepting the extension cannot be the account initiating extension")
+		report.fail(errors.New("account accepting the extension cannot be the account initiating extension"))
 	}
 	if !core.CheckIfAdminAccount(txa.From) {
-		return "", errors.New("account not an org admin account, cannot initiate extension")
+		report.fail(errors.New("account not an org admin account, cannot initiate extension"))
 	}
 	if !core.CheckIfAdminAccount(recipientAddr) {
-		return "", errors.New("recipient account address is not an org admin account. cannot accept extension")
+		report.fail(errors.New("recipient account address is not an org admin account. cannot accept extension"))
 	}
 
-	// check the new key is valid
+	// check the new key is valid, and accepted by the transaction manager
 	if _, err := base64.StdEncoding.DecodeString(newRecipientPtmPublicKey); err != nil {
-		return "", errors.New("invalid new recipient transaction manager key provided")
+		report.fail(errors.New("invalid new recipient transaction manager key provided"))
+	} else {
+		_, err := api.privacyService.ptm.EncryptPayload(ctx, []byte{0}, txa.PrivateFrom, []string{newRecipientPtmPublicKey}, &engine.ExtraMetadata{PrivacyFlag: engine.PrivacyFlagStandardPrivate})
+		switch {
+		case err == engine.ErrPrivateTxManagerNotSupported:
+			report.Warnings = append(report.Warnings, "new recipient transaction manager key not checked by "+api.privacyService.ptm.Name())
+		case err != nil:
+			report.fail(fmt.Errorf("new recipient transaction manager key refused: %v", err))
+		}
 	}
 
 	// check the the intended new recipient will actually receive the extension request
 	switch len(txa.PrivateFor) {
 	case 0:
-		txa.PrivateFor = append(txa.PrivateFor, newRecipientPtmPublicKey)
+		report.PrivateFor = []string{newRecipientPtmPublicKey}
 	case 1:
 		if txa.PrivateFor[0] != newRecipientPtmPublicKey {
-			return "", errors.New("mismatch between recipient transaction manager key and privateFor argument")
+			report.fail(errors.New("mismatch between recipient transaction manager key and privateFor argument"))
 		}
+		report.PrivateFor = append(report.PrivateFor, txa.PrivateFor...)
 	default:
-		return "", errors.New("invalid transaction manager keys given in privateFor argument")
-	}
-
-	// get all participants for the contract being extended
-	participants, err := api.privacyService.GetAllParticipants(api.privacyService.stateFetcher.getCurrentBlockHash(), toExtend)
-	if err == nil {
-		txa.PrivateFor = append(txa.PrivateFor, participants...)
+		report.fail(errors.New("invalid transaction manager keys given in privateFor argument"))
 	}
 
-	//generate some valid transaction options for sending in the transaction
-	txArgs, err := api.privacyService.GenerateTransactOptions(txa)
-	if err != nil {
-		return "", err
-	}
+	if privateStateExists {
+		// get all participants for the contract being extended
+		participants, err := api.privacyService.GetAllParticipants(blockHash, toExtend)
+		if err == nil {
+			report.PrivateFor = append(report.PrivateFor, participants...)
+		}
 
-	//Deploy the contract
-	tx, err := api.privacyService.managementContractFacade.Deploy(txArgs, toExtend, recipientAddr, newRecipientPtmPublicKey)
-	if err != nil {
-		return "", err
+		// estimate the state shared once the extension is accepted
+		if size, slots, err := api.privacyService.stateFetcher.stateShareSize(blockHash, toExtend); err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("state share size not estimated: %v", err))
+		} else {
+			report.StateShareSize, report.StorageSlots = size, slots
+		}
 	}
 
-	//Return the transaction hash for later lookup
-	msg := fmt.Sprintf("0x%x", tx.Hash())
-	return msg, nil
+	report.Valid = len(report.errs) == 0
+	return report
 }
 
 // CancelExtension allows the creator to cancel the given extension contract, ensuring
@@ -356,30 +403,66 @@ func (api *PrivateExtensionAPI) CancelExtension(ctx context.Context, extensionCo
 
 // Returns the extension status from management contract
 func (api *PrivateExtensionAPI) GetExtensionStatus(ctx context.Context, extensionContract common.Address) (string, error) {
+	if err := api.checkReadAccess(ctx, extensionContract); err != nil {
+		return "", err
+	}
+	status, err := api.checkIfExtensionComplete(extensionContract, common.Address{})
+	if err != nil {
+		return "", err
+	}
+
+	if status {
+		return ExtensionCompleted, nil
+	}
+
+	return ExtensionInProgress, nil
+}
+
+// RequestHistoricResend asks the initiator of a completed extension to have its transaction manager resend the payloads
+// of the transactions of the extended contract since fromBlock to the recipient, which replays them once received.
+// privateFor must hold the transaction manager key of the initiator.
+func (api *PrivateExtensionAPI) RequestHistoricResend(ctx context.Context, extensionContract common.Address, fromBlock uint64, txa ethapi.SendTxArgs) (string, error) {
+	if err := api.doMultiTenantChecks(ctx, extensionContract, txa); err != nil {
+		return "", err
+	}
+	tx, err := api.privacyService.requestHistoricResend(extensionContract, fromBlock, txa)
+	if err != nil {
+		return "", err
+	}
+	msg := fmt.Sprintf("0x%x", tx.Hash())
+	return msg, nil
+}
+
+// HistoricResendStatus returns the progress of the historic resend of the given extension contract, requested or
+// served by this node
+func (api *PrivateExtensionAPI) HistoricResendStatus(ctx context.Context, extensionContract common.Address) (*HistoricResend, error) {
+	if err := api.checkReadAccess(ctx, extensionContract); err != nil {
+		return nil, err
+	}
+	resend := api.privacyService.historicResends.get(extensionContract)
+	if resend == nil {
+		return nil, errNoHistoricResend
+	}
+	return resend, nil
+}
+
+// checks if the caller may read the state of the management contract when running in a multitenant node
+func (api *PrivateExtensionAPI) checkReadAccess(ctx context.Context, extensionContract common.Address) error {
 	apiHelper := api.privacyService.apiBackendHelper
 	if authToken, ok := apiHelper.SupportsMultitenancy(ctx); ok {
 		currentBlock := apiHelper.CurrentBlock().Number().Int64()
 		extraDataReader, err := apiHelper.AccountExtraDataStateGetterByNumber(ctx, rpc.BlockNumber(currentBlock))
 		if err != nil {
-			return "", fmt.Errorf("no account extra data reader at block %v: %w", currentBlock, err)
+			return fmt.Errorf("no account extra data reader at block %v: %w", currentBlock, err)
 		}
 		managedParties, err := extraDataReader.GetManagedParties(extensionContract)
 		if err != nil {
-			return "", err
+			return err
 		}
 		if authorized, _ := apiHelper.IsAuthorized(ctx, authToken,
 			multitenancy.NewContractSecurityAttributeBuilder().Private().Read().Parties(managedParties).Build()); !authorized {
-			return "", multitenancy.ErrNotAuthorized
+			return multitenancy.ErrNotAuthorized
 		}
 	}
-	status, err := api.checkIfExtensionComplete(extensionContract, common.Address{})
-	if err != nil {
-		return "", err
-	}
-
-	if status {
-		return extensionCompleted, nil
-	}
-
-	return extensionInProgress, nil
+	return nil
 }
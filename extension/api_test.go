@@ -0,0 +1,145 @@
+package extension
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var refusedPtmKey = base64.StdEncoding.EncodeToString([]byte("refused"))
+
+// dryRunTestPTM is the sender of all the transactions, refusing refusedPtmKey.
+type dryRunTestPTM struct {
+	notinuse.PrivateTransactionManager
+}
+
+func (ptm *dryRunTestPTM) IsSender(context.Context, common.EncryptedPayloadHash) (bool, error) {
+	return true, nil
+}
+
+func (ptm *dryRunTestPTM) EncryptPayload(_ context.Context, _ []byte, _ string, to []string, _ *engine.ExtraMetadata) ([]byte, error) {
+	if to[0] == refusedPtmKey {
+		return nil, errors.New("unknown recipient")
+	}
+	return nil, nil
+}
+
+// dryRunTestChain has a single block which private state is kept in the
+// state database.
+type dryRunTestChain struct {
+	resendTestChain
+	privateRoot common.Hash
+}
+
+func (c *dryRunTestChain) StateAt(root common.Hash) (*state.StateDB, *state.StateDB, error) {
+	publicState, err := state.New(root, c.db, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateState, err := state.New(c.privateRoot, c.db, nil)
+	return publicState, privateState, err
+}
+
+func (c *dryRunTestChain) State() (*state.StateDB, *state.StateDB, error) {
+	return c.StateAt(c.CurrentBlock().Root())
+}
+
+type dryRunTestBackend struct{}
+
+func (b *dryRunTestBackend) SupportsMultitenancy(context.Context) (*proto.PreAuthenticatedAuthenticationToken, bool) {
+	return nil, false
+}
+
+func (b *dryRunTestBackend) IsAuthorized(context.Context, *proto.PreAuthenticatedAuthenticationToken, ...*multitenancy.ContractSecurityAttribute) (bool, error) {
+	return true, nil
+}
+
+func (b *dryRunTestBackend) AccountExtraDataStateGetterByNumber(context.Context, rpc.BlockNumber) (vm.AccountExtraDataStateGetter, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *dryRunTestBackend) CurrentBlock() *types.Block { return nil }
+
+func newDryRunTestAPI(t *testing.T, contract common.Address) *PrivateExtensionAPI {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	publicState, _ := state.New(common.Hash{}, db, nil)
+	publicRoot, err := publicState.Commit(false)
+	require.NoError(t, err)
+	privateState, _ := state.New(common.Hash{}, db, nil)
+	privateState.SetCode(contract, []byte{0x00})
+	privateState.SetState(contract, common.Hash{0x01}, common.Hash{0x02})
+	privateState.SetState(contract, common.Hash{0x03}, common.Hash{0x04})
+	privateRoot, err := privateState.Commit(false)
+	require.NoError(t, err)
+	require.NoError(t, db.TrieDB().Commit(privateRoot, false, nil))
+
+	chain := &dryRunTestChain{
+		resendTestChain: resendTestChain{db: db, blocks: []*types.Block{types.NewBlock(&types.Header{Number: common.Big0, Root: publicRoot}, nil, nil, nil, new(trie.Trie))}},
+		privateRoot:     privateRoot,
+	}
+	return NewPrivateExtensionAPI(&PrivacyService{
+		ptm:              &dryRunTestPTM{},
+		stateFetcher:     NewStateFetcher(chain),
+		apiBackendHelper: &dryRunTestBackend{},
+		currentContracts: make(map[common.Address]*ExtensionContract),
+	})
+}
+
+func TestDryRun_Valid(t *testing.T) {
+	contract := common.Address{0xc0}
+	api := newDryRunTestAPI(t, contract)
+	key := base64.StdEncoding.EncodeToString([]byte("recipient"))
+
+	report, err := api.DryRun(context.Background(), contract, key, common.Address{0x02}, ethapi.SendTxArgs{From: common.Address{0x01}})
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, []string{key}, report.PrivateFor)
+	assert.Equal(t, 2, report.StorageSlots)
+	assert.NotZero(t, report.StateShareSize)
+}
+
+func TestDryRun_ReportsAllErrors(t *testing.T) {
+	contract := common.Address{0xc0}
+	api := newDryRunTestAPI(t, contract)
+	api.privacyService.currentContracts[common.Address{0xee}] = &ExtensionContract{ContractExtended: contract}
+	from := common.Address{0x01}
+
+	report, err := api.DryRun(context.Background(), contract, refusedPtmKey, from, ethapi.SendTxArgs{From: from})
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []string{
+		"contract extension in progress for the given contract address",
+		"account accepting the extension cannot be the account initiating extension",
+		"new recipient transaction manager key refused: unknown recipient",
+	}, report.Errors)
+
+	// the real extension fails on the first of them without sending anything
+	_, err = api.ExtendContract(context.Background(), contract, refusedPtmKey, from, ethapi.SendTxArgs{From: from})
+	assert.EqualError(t, err, "contract extension in progress for the given contract address")
+
+	// a contract which does not exist has no state to share
+	report, err = api.DryRun(context.Background(), common.Address{0xc1}, "not base64!", common.Address{0x02}, ethapi.SendTxArgs{From: from})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"extending a non-existent private contract!!! not allowed",
+		"invalid new recipient transaction manager key provided",
+	}, report.Errors)
+	assert.Zero(t, report.StateShareSize)
+}
@@ -1,11 +1,13 @@
 package extension
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/node"
@@ -19,12 +21,21 @@ import (
 	"github.com/ethereum/go-ethereum/extension/extensionContracts"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/multitenancy"
 	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/private/engine"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// Config holds the settings of the extension service.
+type Config struct {
+	// AutoResend makes the recipient of a contract extension request the
+	// historic resend of the contract once its state is shared.
+	AutoResend bool
+}
+
 type PrivacyService struct {
+	config                   Config
 	ptm                      private.PrivateTransactionManager
 	stateFetcher             *StateFetcher
 	accountManager           *accounts.Manager
@@ -37,6 +48,8 @@ type PrivacyService struct {
 	mu               sync.Mutex
 	currentContracts map[common.Address]*ExtensionContract
 
+	historicResends historicResends
+
 	rpcClient *rpc.Client
 }
 
@@ -60,13 +73,14 @@ func (service *PrivacyService) subscribeStopEvent() (chan stopEvent, event.Subsc
 	return c, s
 }
 
-func New(stack *node.Node, ptm private.PrivateTransactionManager, manager *accounts.Manager, handler DataHandler, fetcher *StateFetcher, apiBackendHelper APIBackendHelper) (*PrivacyService, error) {
+func New(stack *node.Node, config Config, ptm private.PrivateTransactionManager, manager *accounts.Manager, handler DataHandler, fetcher *StateFetcher, apiBackendHelper APIBackendHelper) (*PrivacyService, error) {
 	rpcClient, err := stack.Attach()
 	if err != nil {
 		panic("extension: could not connect to ethereum client rpc")
 	}
 
 	service := &PrivacyService{
+		config:           config,
 		currentContracts: make(map[common.Address]*ExtensionContract),
 		ptm:              ptm,
 		dataHandler:      handler,
@@ -125,6 +139,7 @@ func (service *PrivacyService) watchForNewContracts() error {
 					RecipientPtmKey:           newExtensionEvent.RecipientPTMKey,
 					ManagementContractAddress: foundLog.Address,
 					CreationData:              tx.Data(),
+					CreationBlock:             foundLog.BlockNumber,
 				}
 
 				service.currentContracts[foundLog.Address] = &newContractExtension
@@ -138,16 +153,16 @@ func (service *PrivacyService) watchForNewContracts() error {
 
 				// if party is sender then complete self voting
 				data := common.BytesToEncryptedPayloadHash(newContractExtension.CreationData)
-				isSender, _ := service.ptm.IsSender(data)
+				isSender, _ := service.ptm.IsSender(context.Background(), data)
 
 				if isSender {
-					fetchedParties, err := service.ptm.GetParticipants(data)
+					fetchedParties, err := service.ptm.GetParticipants(context.Background(), data)
 					if err != nil || len(fetchedParties) == 0 {
 						log.Error("Extension: unable to fetch all parties for extension management contract", "error", err)
 						continue
 					}
 
-					privateFrom, _, _, _, err := service.ptm.Receive(data)
+					privateFrom, _, _, _, err := service.ptm.Receive(context.Background(), data)
 					if err != nil || len(privateFrom) == 0 {
 						log.Error("Extension: unable to fetch privateFrom(sender) for extension management contract", "error", err)
 						continue
@@ -255,14 +270,14 @@ func (service *PrivacyService) watchForCompletionEvents() error {
 
 					// fetch all the participants and send
 					payload := common.BytesToEncryptedPayloadHash(extensionEntry.CreationData)
-					fetchedParties, err := service.ptm.GetParticipants(payload)
+					fetchedParties, err := service.ptm.GetParticipants(context.Background(), payload)
 					if err != nil || len(fetchedParties) == 0 {
 						log.Error("Extension: Unable to fetch all parties for extension management contract", "error", err)
 						return
 					}
 					log.Debug("Extension: able to fetch all parties", "parties", fetchedParties)
 
-					privateFrom, _, _, _, err := service.ptm.Receive(payload)
+					privateFrom, _, _, _, err := service.ptm.Receive(context.Background(), payload)
 					if err != nil || len(privateFrom) == 0 {
 						log.Error("Extension: unable to fetch privateFrom(sender) for extension management contract", "error", err)
 						return
@@ -306,7 +321,7 @@ func (service *PrivacyService) watchForCompletionEvents() error {
 							extraMetaData.ACMerkleRoot = storageRoot
 						}
 					}
-					_, _, hashOfStateData, err := service.ptm.Send(entireStateData, privateFrom, fetchedParties, &extraMetaData)
+					_, _, hashOfStateData, err := service.ptm.Send(context.Background(), entireStateData, privateFrom, fetchedParties, &extraMetaData)
 
 					if err != nil {
 						log.Error("[ptm] service.ptm.Send", "stateDataInHex", hex.EncodeToString(entireStateData[:]), "recipients", fetchedParties, "error", err)
@@ -335,6 +350,33 @@ func (service *PrivacyService) watchForCompletionEvents() error {
 	return nil
 }
 
+// ContractExtensions returns the extensions of the data store with the status
+// of their management contract, oldest first. On multitenant nodes, those the
+// caller may not read are left out.
+func (service *PrivacyService) ContractExtensions(ctx context.Context) ([]ExtensionContractStatus, error) {
+	api := NewPrivateExtensionAPI(service)
+	contracts := api.ActiveExtensionContracts()
+	sort.Slice(contracts, func(i, j int) bool {
+		if contracts[i].CreationBlock != contracts[j].CreationBlock {
+			return contracts[i].CreationBlock < contracts[j].CreationBlock
+		}
+		return bytes.Compare(contracts[i].ManagementContractAddress[:], contracts[j].ManagementContractAddress[:]) < 0
+	})
+
+	extensions := make([]ExtensionContractStatus, 0, len(contracts))
+	for _, contract := range contracts {
+		status, err := api.GetExtensionStatus(ctx, contract.ManagementContractAddress)
+		if err == multitenancy.ErrNotAuthorized {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the status of extension %s: %v", contract.ManagementContractAddress.Hex(), err)
+		}
+		extensions = append(extensions, ExtensionContractStatus{ExtensionContract: contract, Status: status})
+	}
+	return extensions, nil
+}
+
 // utility methods
 func (service *PrivacyService) apis() []rpc.API {
 	return []rpc.API{
@@ -362,6 +404,8 @@ func (service *PrivacyService) Start() error {
 		service.watchForNewContracts,       // watch for new extension contract creation event
 		service.watchForCancelledContracts, // watch for extension contract cancellation event
 		service.watchForCompletionEvents,   // watch for extension contract voting complete event
+		service.watchForSharedStates,       // watch for extension contract state share event
+		service.watchForResendMessages,     // watch for historic resend requests and reports
 	} {
 		if err := f(); err != nil {
 			return err
@@ -415,7 +459,7 @@ func (service *PrivacyService) GetAllParticipants(blockHash common.Hash, address
 		return nil, nil
 	}
 
-	participants, err := service.ptm.GetParticipants(privacyMetaData.CreationTxHash)
+	participants, err := service.ptm.GetParticipants(context.Background(), privacyMetaData.CreationTxHash)
 	if err != nil {
 		return nil, err
 	}
@@ -429,7 +473,7 @@ func (service *PrivacyService) CheckIfContractCreator(blockHash common.Hash, add
 		return true
 	}
 
-	isCreator, err := service.ptm.IsSender(privacyMetaData.CreationTxHash)
+	isCreator, err := service.ptm.IsSender(context.Background(), privacyMetaData.CreationTxHash)
 	if err != nil {
 		return false
 	}
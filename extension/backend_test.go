@@ -1,6 +1,8 @@
 package extension
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -10,7 +12,10 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/extension/extensionContracts"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type MockBackend struct {
@@ -209,3 +214,73 @@ func TestGenerateTransactionOptionsGivesNonDefaultsWhenSpecified(t *testing.T) {
 		return
 	}
 }
+
+// statusTestCaller is the pending state of management contracts, finished or
+// not.
+type statusTestCaller struct {
+	finished map[common.Address]bool
+}
+
+func (c *statusTestCaller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (c *statusTestCaller) CallContract(ctx context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	return c.PendingCallContract(ctx, call)
+}
+
+func (c *statusTestCaller) PendingCodeAt(context.Context, common.Address) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (c *statusTestCaller) PendingCallContract(_ context.Context, call ethereum.CallMsg) ([]byte, error) {
+	finished, ok := c.finished[*call.To]
+	if !ok {
+		return nil, errors.New("no management contract")
+	}
+	if finished {
+		return common.LeftPadBytes([]byte{0x01}, 32), nil
+	}
+	return make([]byte, 32), nil
+}
+
+type statusTestFacade struct {
+	ManagementContractFacade
+	caller *statusTestCaller
+}
+
+func (f *statusTestFacade) Caller(managementAddress common.Address) (*extensionContracts.ContractExtenderCaller, error) {
+	return extensionContracts.NewContractExtenderCaller(managementAddress, f.caller)
+}
+
+func TestContractExtensions(t *testing.T) {
+	legacy := &ExtensionContract{ManagementContractAddress: common.HexToAddress("0x3")}
+	older := &ExtensionContract{ManagementContractAddress: common.HexToAddress("0x2"), CreationBlock: 5}
+	newer := &ExtensionContract{ManagementContractAddress: common.HexToAddress("0x1"), CreationBlock: 7}
+	caller := &statusTestCaller{finished: map[common.Address]bool{
+		legacy.ManagementContractAddress: false,
+		older.ManagementContractAddress:  true,
+		newer.ManagementContractAddress:  false,
+	}}
+	service := &PrivacyService{
+		apiBackendHelper:         &dryRunTestBackend{},
+		managementContractFacade: &statusTestFacade{caller: caller},
+		currentContracts: map[common.Address]*ExtensionContract{
+			newer.ManagementContractAddress:  newer,
+			legacy.ManagementContractAddress: legacy,
+			older.ManagementContractAddress:  older,
+		},
+	}
+
+	extensions, err := service.ContractExtensions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []ExtensionContractStatus{
+		{ExtensionContract: *legacy, Status: ExtensionInProgress},
+		{ExtensionContract: *older, Status: ExtensionCompleted},
+		{ExtensionContract: *newer, Status: ExtensionInProgress},
+	}, extensions)
+
+	delete(caller.finished, newer.ManagementContractAddress)
+	_, err = service.ContractExtensions(context.Background())
+	assert.EqualError(t, err, "unable to read the status of extension "+newer.ManagementContractAddress.Hex()+": no management contract")
+}
@@ -3,6 +3,7 @@ package extension
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -16,6 +17,10 @@ type ManagementContractFacade interface {
 	Deploy(args *bind.TransactOpts, toExtend common.Address, recipientAddress common.Address, recipientHash string) (*types.Transaction, error)
 
 	GetAllVoters(addressToVoteOn common.Address) ([]common.Address, error)
+	// SendMessage sends a transaction with the given data to the management
+	// contract, which does not execute it, for the parties of the transaction
+	// to read it
+	SendMessage(args *bind.TransactOpts, managementAddress common.Address, data []byte) (*types.Transaction, error)
 }
 
 type EthclientManagementContractFacade struct {
@@ -39,6 +44,10 @@ func (facade EthclientManagementContractFacade) Deploy(args *bind.TransactOpts,
 	return tx, err
 }
 
+func (facade EthclientManagementContractFacade) SendMessage(args *bind.TransactOpts, managementAddress common.Address, data []byte) (*types.Transaction, error) {
+	return bind.NewBoundContract(managementAddress, abi.ABI{}, facade.client, facade.client, facade.client).RawTransact(args, data)
+}
+
 func (facade EthclientManagementContractFacade) GetAllVoters(addressToVoteOn common.Address) ([]common.Address, error) {
 	caller, err := facade.Caller(addressToVoteOn)
 	if err != nil {
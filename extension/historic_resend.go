@@ -0,0 +1,628 @@
+package extension
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/extension/extensionContracts"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// A historic resend gives the recipient of an extended contract the payloads of
+// the private transactions which built the contract before it was a party to
+// them. The recipient asks the initiator of the extension, with a private
+// transaction to the management contract carrying a resend request. The
+// initiator has its transaction manager resend the payloads of the transactions
+// sent to or creating the contract since the requested block, and reports them
+// with the storage root of the contract in the same way. The recipient then
+// replays the transactions on a scratch copy of its private state and compares
+// the storage root obtained with the one reported.
+//
+// The management contract does not execute the messages, they are only read by
+// the parties of the transactions carrying them.
+
+const (
+	resendRequestPrefix = "quorum-extension:resend-request:"
+	resendReportPrefix  = "quorum-extension:resend-report:"
+)
+
+// status of a historic resend
+const (
+	HistoricResendRequested = "REQUESTED" // waiting for the report of the initiator
+	HistoricResendServed    = "SERVED"    // payloads resent and reported, on the initiator
+	HistoricResendVerified  = "VERIFIED"  // replayed state matches the initiator's
+	HistoricResendMismatch  = "MISMATCH"  // replayed state differs from the initiator's
+	HistoricResendFailed    = "FAILED"
+)
+
+var (
+	errNoHistoricResend      = errors.New("no historic resend for this extension contract")
+	errHistoricResendPending = errors.New("historic resend already in progress for this extension contract")
+
+	managementContractCode = common.FromHex(extensionContracts.ContractExtenderBin)
+)
+
+type resendRequest struct {
+	FromBlock uint64 `json:"fromBlock"`
+}
+
+type resendReport struct {
+	FromBlock   uint64           `json:"fromBlock"`
+	ToBlock     uint64           `json:"toBlock"`
+	StorageRoot common.Hash      `json:"storageRoot"`
+	Payloads    []*ResentPayload `json:"payloads"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// ResentPayload is the payload of a transaction of the history of an extended
+// contract.
+type ResentPayload struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	TxHash      common.Hash `json:"txHash"`
+	PayloadHash string      `json:"payloadHash"` // base64-encoded
+	Resent      bool        `json:"resent"`      // resent by the initiator
+	Received    bool        `json:"received"`    // available to the recipient
+	Error       string      `json:"error,omitempty"`
+}
+
+// HistoricResend is the progress of a historic resend, on the recipient which
+// requested it or on the initiator which served it.
+type HistoricResend struct {
+	ManagementContractAddress common.Address   `json:"managementContractAddress"`
+	ContractExtended          common.Address   `json:"contractExtended"`
+	InitiatorPtmKey           string           `json:"initiatorPtmKey,omitempty"`
+	RecipientPtmKey           string           `json:"recipientPtmKey"`
+	FromBlock                 uint64           `json:"fromBlock"`
+	ToBlock                   uint64           `json:"toBlock"`
+	Status                    string           `json:"status"`
+	Total                     int              `json:"total"`
+	Resent                    int              `json:"resent"`
+	Received                  int              `json:"received"`
+	Payloads                  []*ResentPayload `json:"payloads"`
+	InitiatorStorageRoot      common.Hash      `json:"initiatorStorageRoot"`
+	ReplayedStorageRoot       common.Hash      `json:"replayedStorageRoot"`
+	Error                     string           `json:"error,omitempty"`
+}
+
+func (r *HistoricResend) copy() *HistoricResend {
+	cpy := *r
+	cpy.Payloads = make([]*ResentPayload, len(r.Payloads))
+	for i, p := range r.Payloads {
+		pcpy := *p
+		cpy.Payloads[i] = &pcpy
+	}
+	return &cpy
+}
+
+func (r *HistoricResend) fail(err error) {
+	r.Status, r.Error = HistoricResendFailed, err.Error()
+}
+
+// historicResends tracks the historic resends of the node, in memory.
+type historicResends struct {
+	mu      sync.Mutex
+	resends map[common.Address]*HistoricResend
+}
+
+func (h *historicResends) get(managementContract common.Address) *HistoricResend {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if r, ok := h.resends[managementContract]; ok {
+		return r.copy()
+	}
+	return nil
+}
+
+func (h *historicResends) set(r *HistoricResend) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.resends == nil {
+		h.resends = make(map[common.Address]*HistoricResend)
+	}
+	h.resends[r.ManagementContractAddress] = r.copy()
+}
+
+func encodeResendMessage(prefix string, msg interface{}) ([]byte, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(prefix), data...), nil
+}
+
+// decodeResendMessage returns the prefix and the JSON body of a resend
+// message, an empty prefix if the payload is not one.
+func decodeResendMessage(payload []byte) (string, []byte) {
+	for _, prefix := range []string{resendRequestPrefix, resendReportPrefix} {
+		if bytes.HasPrefix(payload, []byte(prefix)) {
+			return prefix, payload[len(prefix):]
+		}
+	}
+	return "", nil
+}
+
+// isManagementContractCode reports whether the code is the one of an extension
+// management contract, the runtime code being the tail of the creation code.
+func isManagementContractCode(code []byte) bool {
+	return len(code) > 0 && bytes.HasSuffix(managementContractCode, code)
+}
+
+// contractTransactions returns the private transactions sent to or creating
+// the contract between the given blocks. Transactions reaching the contract
+// through other contracts are not found.
+func (fetcher *StateFetcher) contractTransactions(contract common.Address, fromBlock, toBlock uint64) []*ResentPayload {
+	var payloads []*ResentPayload
+	for number := fromBlock; number <= toBlock; number++ {
+		block := fetcher.chainAccessor.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		for _, tx := range block.Transactions() {
+			if !tx.IsPrivate() {
+				continue
+			}
+			if to := tx.To(); to == nil {
+				from, err := types.QuorumPrivateTxSigner{}.Sender(tx)
+				if err != nil || crypto.CreateAddress(from, tx.Nonce()) != contract {
+					continue
+				}
+			} else if *to != contract {
+				continue
+			}
+			payloads = append(payloads, &ResentPayload{
+				BlockNumber: number,
+				TxHash:      tx.Hash(),
+				PayloadHash: common.BytesToEncryptedPayloadHash(tx.Data()).ToBase64(),
+			})
+		}
+	}
+	return payloads
+}
+
+// replayMessage executes a private transaction whatever the nonce of its
+// sender, which is not at the value it had in the block.
+type replayMessage struct {
+	types.Message
+}
+
+func (replayMessage) CheckNonce() bool { return false }
+func (replayMessage) IsPrivate() bool  { return true }
+
+// replayContract replays the transactions on a scratch copy of the private
+// state of the block preceding fromBlock, and returns the storage root of the
+// contract obtained. Each transaction is executed on the public state of the
+// block preceding its own, so public state written earlier in the same block
+// is not seen. Errors of individual transactions are recorded on their
+// payloads.
+func (fetcher *StateFetcher) replayContract(contract common.Address, fromBlock uint64, payloads []*ResentPayload) (common.Hash, error) {
+	chain := fetcher.chainAccessor
+	if fromBlock == 0 {
+		fromBlock = 1
+	}
+	start := chain.GetBlockByNumber(fromBlock - 1)
+	if start == nil {
+		return common.Hash{}, fmt.Errorf("block %d not found", fromBlock-1)
+	}
+	_, privateState, err := chain.StateAt(start.Root())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		block       *types.Block
+		publicState *state.StateDB
+	)
+	for _, p := range payloads {
+		if block == nil || block.NumberU64() != p.BlockNumber {
+			if block = chain.GetBlockByNumber(p.BlockNumber); block == nil {
+				return common.Hash{}, fmt.Errorf("block %d not found", p.BlockNumber)
+			}
+			parent := chain.GetBlockByHash(block.ParentHash())
+			if parent == nil {
+				return common.Hash{}, fmt.Errorf("parent of block %d not found", p.BlockNumber)
+			}
+			if publicState, _, err = chain.StateAt(parent.Root()); err != nil {
+				return common.Hash{}, err
+			}
+		}
+		index, tx := -1, (*types.Transaction)(nil)
+		for i, candidate := range block.Transactions() {
+			if candidate.Hash() == p.TxHash {
+				index, tx = i, candidate
+				break
+			}
+		}
+		if tx == nil {
+			p.Error = "transaction not found in block"
+			continue
+		}
+		from, err := types.QuorumPrivateTxSigner{}.Sender(tx)
+		if err != nil {
+			p.Error = err.Error()
+			continue
+		}
+		// contracts are created at the address given by the nonce of the transaction
+		publicState.SetNonce(from, tx.Nonce())
+		msg := replayMessage{types.NewMessage(from, tx.To(), tx.Nonce(), tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data(), false)}
+
+		header := block.Header()
+		evm := vm.NewEVM(core.NewEVMContext(msg, header, chain, nil), publicState, privateState, chain.Config(), vm.Config{})
+		evm.SetCurrentTX(tx)
+		privateState.Prepare(tx.Hash(), block.Hash(), index)
+		result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(header.GasLimit))
+		if err != nil {
+			p.Error = err.Error()
+		} else if result.Err != nil {
+			p.Error = result.Err.Error()
+		}
+	}
+	privateState.IntermediateRoot(true)
+	return privateState.GetStorageRoot(contract)
+}
+
+// watchForResendMessages handles the resend messages of the imported blocks.
+func (service *PrivacyService) watchForResendMessages() error {
+	chain := service.stateFetcher.chainAccessor
+	heads := make(chan core.ChainHeadEvent, 16)
+	subscription := chain.SubscribeChainHeadEvent(heads)
+	last := chain.CurrentBlock().NumberU64()
+
+	go func() {
+		stopChan, stopSubscription := service.subscribeStopEvent()
+		defer stopSubscription.Unsubscribe()
+		defer subscription.Unsubscribe()
+		for {
+			select {
+			case err := <-subscription.Err():
+				log.Error("Historic resend watcher subscription error", "error", err)
+				return
+			case head := <-heads:
+				// blocks imported in a batch have a single head event
+				number := head.Block.NumberU64()
+				// after a reorg, the messages are handled from the new head on
+				for n := last + 1; n < number; n++ {
+					if block := chain.GetBlockByNumber(n); block != nil {
+						service.handleResendMessages(block)
+					}
+				}
+				service.handleResendMessages(head.Block)
+				last = number
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// handleResendMessages serves the resend requests and replays the resend
+// reports of the block this node is a party of.
+func (service *PrivacyService) handleResendMessages(block *types.Block) {
+	var privateState *state.StateDB
+	for _, tx := range block.Transactions() {
+		if !tx.IsPrivate() || tx.To() == nil {
+			continue
+		}
+		if privateState == nil {
+			var err error
+			if _, privateState, err = service.stateFetcher.chainAccessor.StateAt(block.Root()); err != nil {
+				log.Error("Historic resend: private state not found", "block", block.Hash(), "error", err)
+				return
+			}
+		}
+		managementContract := *tx.To()
+		if !isManagementContractCode(privateState.GetCode(managementContract)) {
+			continue
+		}
+		sender, managedParties, payload, _, err := service.ptm.Receive(context.Background(), common.BytesToEncryptedPayloadHash(tx.Data()))
+		if err != nil || payload == nil {
+			continue
+		}
+		prefix, body := decodeResendMessage(payload)
+		switch prefix {
+		case resendRequestPrefix:
+			var request resendRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				log.Warn("Historic resend: invalid request", "tx", tx.Hash(), "error", err)
+				continue
+			}
+			var privateFrom string
+			if len(managedParties) > 0 {
+				privateFrom = managedParties[0]
+			}
+			service.serveHistoricResend(block, managementContract, sender, privateFrom, &request)
+		case resendReportPrefix:
+			var report resendReport
+			if err := json.Unmarshal(body, &report); err != nil {
+				log.Warn("Historic resend: invalid report", "tx", tx.Hash(), "error", err)
+				continue
+			}
+			service.replayHistoricResend(managementContract, sender, &report)
+		}
+	}
+}
+
+// serveHistoricResend resends the payloads of the history of the contract
+// extended by the management contract to the recipient of the extension, if
+// this node initiated it, and reports them to the recipient.
+func (service *PrivacyService) serveHistoricResend(block *types.Block, managementContract common.Address, requester, privateFrom string, request *resendRequest) {
+	caller, err := service.managementContractFacade.Caller(managementContract)
+	if err != nil {
+		log.Error("Historic resend: management contract not found", "address", managementContract, "error", err)
+		return
+	}
+	creator, err := caller.Creator(nil)
+	if err != nil {
+		log.Error("Historic resend: creator not found", "address", managementContract, "error", err)
+		return
+	}
+	if _, err := service.accountManager.Find(accounts.Account{Address: creator}); err != nil {
+		// the extension was initiated by another node
+		return
+	}
+	recipient, err := caller.TargetRecipientPTMKey(nil)
+	if err != nil {
+		log.Error("Historic resend: recipient not found", "address", managementContract, "error", err)
+		return
+	}
+	if requester != recipient {
+		log.Warn("Historic resend: request not sent by the extension recipient", "address", managementContract, "sender", requester)
+		return
+	}
+	contract, err := caller.ContractToExtend(nil)
+	if err != nil {
+		log.Error("Historic resend: extended contract not found", "address", managementContract, "error", err)
+		return
+	}
+
+	resend := &HistoricResend{
+		ManagementContractAddress: managementContract,
+		ContractExtended:          contract,
+		InitiatorPtmKey:           privateFrom,
+		RecipientPtmKey:           recipient,
+		FromBlock:                 request.FromBlock,
+		ToBlock:                   block.NumberU64(),
+		Status:                    HistoricResendServed,
+	}
+	report := &resendReport{FromBlock: request.FromBlock, ToBlock: block.NumberU64()}
+	if finished, err := caller.CheckIfExtensionFinished(nil); err != nil || !finished {
+		report.Error = "contract extension not completed"
+		resend.fail(errors.New(report.Error))
+	} else if report.StorageRoot, err = service.stateFetcher.GetStorageRoot(block.Hash(), contract); err != nil {
+		report.Error = err.Error()
+		resend.fail(err)
+	} else {
+		report.Payloads = service.stateFetcher.contractTransactions(contract, request.FromBlock, block.NumberU64())
+		for _, p := range report.Payloads {
+			hash, err := common.Base64ToEncryptedPayloadHash(p.PayloadHash)
+			if err == nil {
+				err = service.ptm.Resend(context.Background(), hash, recipient)
+			}
+			if err != nil {
+				p.Error = err.Error()
+				continue
+			}
+			p.Resent = true
+			resend.Resent++
+		}
+		resend.Total, resend.Payloads, resend.InitiatorStorageRoot = len(report.Payloads), report.Payloads, report.StorageRoot
+	}
+	service.historicResends.set(resend)
+	log.Info("Historic resend: payloads resent", "contract", contract, "recipient", recipient, "total", resend.Total, "resent", resend.Resent)
+
+	txa := ethapi.SendTxArgs{From: creator, PrivateTxArgs: ethapi.PrivateTxArgs{PrivateFrom: privateFrom, PrivateFor: []string{recipient}}}
+	if _, err := service.sendResendMessage(managementContract, txa, resendReportPrefix, report); err != nil {
+		log.Error("Historic resend: failed to send the report", "address", managementContract, "error", err)
+		resend.fail(err)
+		service.historicResends.set(resend)
+	}
+}
+
+// replayHistoricResend checks that the payloads reported by the initiator were
+// received and replays them, if this node requested the resend.
+func (service *PrivacyService) replayHistoricResend(managementContract common.Address, sender string, report *resendReport) {
+	resend := service.historicResends.get(managementContract)
+	if resend == nil || resend.Status != HistoricResendRequested {
+		return
+	}
+	if sender != resend.InitiatorPtmKey {
+		log.Warn("Historic resend: report not sent by the extension initiator", "address", managementContract, "sender", sender)
+		return
+	}
+	defer service.historicResends.set(resend)
+
+	resend.ToBlock, resend.InitiatorStorageRoot = report.ToBlock, report.StorageRoot
+	resend.Payloads, resend.Total = report.Payloads, len(report.Payloads)
+	if report.Error != "" {
+		resend.fail(errors.New(report.Error))
+		return
+	}
+	for _, p := range report.Payloads {
+		if p.Resent {
+			resend.Resent++
+		}
+		hash, err := common.Base64ToEncryptedPayloadHash(p.PayloadHash)
+		if err != nil {
+			p.Error = err.Error()
+			continue
+		}
+		_, _, payload, _, err := service.ptm.Receive(context.Background(), hash)
+		switch {
+		case err != nil:
+			p.Error = err.Error()
+		case payload == nil:
+			p.Error = "payload not received"
+		default:
+			p.Received, p.Error = true, ""
+			resend.Received++
+		}
+	}
+	root, err := service.stateFetcher.replayContract(resend.ContractExtended, resend.FromBlock, resend.Payloads)
+	if err != nil {
+		resend.fail(err)
+		return
+	}
+	resend.ReplayedStorageRoot = root
+	if root == resend.InitiatorStorageRoot {
+		resend.Status = HistoricResendVerified
+	} else {
+		resend.Status = HistoricResendMismatch
+	}
+	log.Info("Historic resend: payloads replayed", "contract", resend.ContractExtended, "status", resend.Status,
+		"total", resend.Total, "received", resend.Received, "root", root, "initiatorRoot", resend.InitiatorStorageRoot)
+}
+
+// requestHistoricResend asks the initiator of a completed extension, whose
+// transaction manager key is the single privateFor of the arguments, to resend
+// the payloads of the history of the extended contract since fromBlock.
+func (service *PrivacyService) requestHistoricResend(managementContract common.Address, fromBlock uint64, txa ethapi.SendTxArgs) (*types.Transaction, error) {
+	if len(txa.PrivateFor) != 1 {
+		return nil, errors.New("privateFor must hold the transaction manager key of the extension initiator only")
+	}
+	if resend := service.historicResends.get(managementContract); resend != nil && resend.Status == HistoricResendRequested {
+		return nil, errHistoricResendPending
+	}
+	caller, err := service.managementContractFacade.Caller(managementContract)
+	if err != nil {
+		return nil, err
+	}
+	finished, err := caller.CheckIfExtensionFinished(nil)
+	if err != nil {
+		return nil, err
+	}
+	if !finished {
+		return nil, errors.New("contract extension not completed")
+	}
+	recipient, err := caller.TargetRecipientPTMKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	switch txa.PrivateFrom {
+	case "":
+		txa.PrivateFrom = recipient
+	case recipient:
+	default:
+		return nil, errors.New("privateFrom must be the transaction manager key of the extension recipient")
+	}
+	if txa.PrivateFor[0] == recipient {
+		return nil, errors.New("historic resend must be requested from the extension initiator")
+	}
+	contract, err := caller.ContractToExtend(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := service.sendResendMessage(managementContract, txa, resendRequestPrefix, &resendRequest{FromBlock: fromBlock})
+	if err != nil {
+		return nil, err
+	}
+	service.historicResends.set(&HistoricResend{
+		ManagementContractAddress: managementContract,
+		ContractExtended:          contract,
+		InitiatorPtmKey:           txa.PrivateFor[0],
+		RecipientPtmKey:           recipient,
+		FromBlock:                 fromBlock,
+		Status:                    HistoricResendRequested,
+	})
+	return tx, nil
+}
+
+func (service *PrivacyService) sendResendMessage(managementContract common.Address, txa ethapi.SendTxArgs, prefix string, msg interface{}) (*types.Transaction, error) {
+	data, err := encodeResendMessage(prefix, msg)
+	if err != nil {
+		return nil, err
+	}
+	txArgs, err := service.GenerateTransactOptions(txa)
+	if err != nil {
+		return nil, err
+	}
+	return service.managementContractFacade.SendMessage(txArgs, managementContract, data)
+}
+
+// watchForSharedStates requests the historic resend of the contracts whose
+// state is shared with this node, if automatic resends are enabled.
+func (service *PrivacyService) watchForSharedStates() error {
+	if !service.config.AutoResend {
+		return nil
+	}
+	incomingLogs, subscription, err := service.extClient.SubscribeToLogs(stateSharedQuery)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		stopChan, stopSubscription := service.subscribeStopEvent()
+		defer stopSubscription.Unsubscribe()
+		for {
+			select {
+			case err := <-subscription.Err():
+				log.Error("Shared state watcher subscription error", "error", err)
+				return
+			case l := <-incomingLogs:
+				if err := service.autoRequestHistoricResend(l); err != nil {
+					log.Error("Historic resend: automatic request failed", "address", l.Address, "error", err)
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// autoRequestHistoricResend requests the resend of the whole history of the
+// contract whose state was shared by the log, from the node which shared it,
+// if this node is the recipient of the extension.
+func (service *PrivacyService) autoRequestHistoricResend(l types.Log) error {
+	tx, err := service.extClient.TransactionByHash(l.TxHash)
+	if err != nil {
+		return err
+	}
+	payload := common.BytesToEncryptedPayloadHash(tx.Data())
+	if isSender, _ := service.ptm.IsSender(context.Background(), payload); isSender {
+		return nil
+	}
+	initiator, _, _, _, err := service.ptm.Receive(context.Background(), payload)
+	if err != nil || initiator == "" {
+		return fmt.Errorf("initiator transaction manager key not found: %v", err)
+	}
+	caller, err := service.managementContractFacade.Caller(l.Address)
+	if err != nil {
+		return err
+	}
+	creator, err := caller.Creator(nil)
+	if err != nil {
+		return err
+	}
+	voters, err := service.managementContractFacade.GetAllVoters(l.Address)
+	if err != nil {
+		return err
+	}
+	for _, voter := range voters {
+		if voter == creator {
+			continue
+		}
+		if _, err := service.accountManager.Find(accounts.Account{Address: voter}); err != nil {
+			continue
+		}
+		txa := ethapi.SendTxArgs{From: voter, PrivateTxArgs: ethapi.PrivateTxArgs{PrivateFor: []string{initiator}}}
+		tx, err := service.requestHistoricResend(l.Address, 0, txa)
+		if err == nil {
+			log.Info("Historic resend: requested", "address", l.Address, "tx", tx.Hash())
+		}
+		return err
+	}
+	// not the recipient of the extension
+	return nil
+}
@@ -0,0 +1,199 @@
+package extension
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/extension/extensionContracts"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resendTestPTM holds the payloads resent to the node.
+type resendTestPTM struct {
+	notinuse.PrivateTransactionManager
+	payloads map[common.EncryptedPayloadHash][]byte
+}
+
+func (ptm *resendTestPTM) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	return "", nil, ptm.payloads[hash], nil, nil
+}
+
+// resendTestChain is a chain of blocks whose states are all empty but the
+// public states, which are kept in the state database.
+type resendTestChain struct {
+	db     state.Database
+	blocks []*types.Block
+}
+
+func (c *resendTestChain) SupportsMultitenancy(context.Context) (*proto.PreAuthenticatedAuthenticationToken, bool) {
+	return nil, false
+}
+
+func (c *resendTestChain) GetBlockByHash(hash common.Hash) *types.Block {
+	for _, block := range c.blocks {
+		if block.Hash() == hash {
+			return block
+		}
+	}
+	return nil
+}
+
+func (c *resendTestChain) StateAt(root common.Hash) (*state.StateDB, *state.StateDB, error) {
+	publicState, err := state.New(root, c.db, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateState, err := state.New(common.Hash{}, c.db, nil)
+	return publicState, privateState, err
+}
+
+func (c *resendTestChain) State() (*state.StateDB, *state.StateDB, error) {
+	return c.StateAt(c.CurrentBlock().Root())
+}
+
+func (c *resendTestChain) CurrentBlock() *types.Block { return c.blocks[len(c.blocks)-1] }
+
+func (c *resendTestChain) Engine() consensus.Engine { return ethash.NewFaker() }
+
+func (c *resendTestChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if block := c.GetBlockByHash(hash); block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+func (c *resendTestChain) Config() *params.ChainConfig { return params.QuorumTestChainConfig }
+
+func (c *resendTestChain) GetBlockByNumber(number uint64) *types.Block {
+	if number < uint64(len(c.blocks)) {
+		return c.blocks[number]
+	}
+	return nil
+}
+
+func (c *resendTestChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return new(event.Feed).Subscribe(ch)
+}
+
+func (c *resendTestChain) addBlock(txs ...*types.Transaction) {
+	parent := c.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   params.GenesisGasLimit,
+		Root:       parent.Root(),
+	}
+	c.blocks = append(c.blocks, types.NewBlock(header, txs, nil, nil, new(trie.Trie)))
+}
+
+func TestHistoricResend_ReplayContract(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	ptm := &resendTestPTM{payloads: make(map[common.EncryptedPayloadHash][]byte)}
+	private.P = ptm
+
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	genesis, _ := state.New(common.Hash{}, db, nil)
+	root, _ := genesis.Commit(false)
+	chain := &resendTestChain{db: db, blocks: []*types.Block{types.NewBlock(&types.Header{Number: common.Big0, Root: root}, nil, nil, nil, new(trie.Trie))}}
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	contract := crypto.CreateAddress(from, 0)
+	privateTx := func(nonce uint64, to *common.Address, payload []byte) *types.Transaction {
+		hash := common.BytesToEncryptedPayloadHash(crypto.Keccak512(payload))
+		ptm.payloads[hash] = payload
+		var tx *types.Transaction
+		if to == nil {
+			tx = types.NewContractCreation(nonce, common.Big0, 1000000, common.Big0, hash.Bytes())
+		} else {
+			tx = types.NewTransaction(nonce, *to, common.Big0, 1000000, common.Big0, hash.Bytes())
+		}
+		tx, err := types.SignTx(tx, types.QuorumPrivateTxSigner{}, key)
+		require.NoError(t, err)
+		return tx
+	}
+
+	// the creation code stores 42 in slot 0, the contract stores its input in slot 1
+	creation := privateTx(0, nil, hexutil.MustDecode("0x602a6000556006601160003960066000f3600035600155"))
+	call := privateTx(1, &contract, common.LeftPadBytes([]byte{7}, 32))
+	other := privateTx(2, &common.Address{0x01}, []byte{0x01})
+	chain.addBlock(creation)
+	chain.addBlock(other, call)
+
+	fetcher := NewStateFetcher(chain)
+	payloads := fetcher.contractTransactions(contract, 1, 2)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, creation.Hash(), payloads[0].TxHash)
+	assert.Equal(t, uint64(1), payloads[0].BlockNumber)
+	assert.Equal(t, call.Hash(), payloads[1].TxHash)
+	assert.Equal(t, common.BytesToEncryptedPayloadHash(call.Data()).ToBase64(), payloads[1].PayloadHash)
+
+	replayed, err := fetcher.replayContract(contract, 1, payloads)
+	require.NoError(t, err)
+	for _, p := range payloads {
+		assert.Empty(t, p.Error)
+	}
+
+	expected, _ := state.New(common.Hash{}, db, nil)
+	expected.SetNonce(contract, 1)
+	expected.SetState(contract, common.Hash{}, common.BigToHash(big.NewInt(42)))
+	expected.SetState(contract, common.BigToHash(common.Big1), common.BigToHash(big.NewInt(7)))
+	expected.IntermediateRoot(true)
+	expectedRoot, err := expected.GetStorageRoot(contract)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRoot, replayed)
+
+	// without the creation, the contract is not found
+	_, err = fetcher.replayContract(contract, 2, payloads[1:])
+	assert.Error(t, err)
+}
+
+func TestHistoricResend_Messages(t *testing.T) {
+	data, err := encodeResendMessage(resendReportPrefix, &resendReport{FromBlock: 3, Payloads: []*ResentPayload{{BlockNumber: 4}}})
+	require.NoError(t, err)
+
+	prefix, body := decodeResendMessage(data)
+	assert.Equal(t, resendReportPrefix, prefix)
+	assert.JSONEq(t, `{"fromBlock":3,"toBlock":0,"storageRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","payloads":[{"blockNumber":4,"txHash":"0x0000000000000000000000000000000000000000000000000000000000000000","payloadHash":"","resent":false,"received":false}]}`, string(body))
+
+	prefix, _ = decodeResendMessage([]byte("arbitrary payload"))
+	assert.Empty(t, prefix)
+
+	code := common.FromHex(extensionContracts.ContractExtenderBin)
+	assert.True(t, isManagementContractCode(code[len(code)-100:]))
+	assert.False(t, isManagementContractCode(code[:100]))
+	assert.False(t, isManagementContractCode(nil))
+}
+
+func TestHistoricResend_StatusIsCopied(t *testing.T) {
+	var resends historicResends
+	managementContract := common.Address{0x01}
+	assert.Nil(t, resends.get(managementContract))
+
+	resend := &HistoricResend{ManagementContractAddress: managementContract, Status: HistoricResendRequested, Payloads: []*ResentPayload{{BlockNumber: 1}}}
+	resends.set(resend)
+	resend.Status, resend.Payloads[0].Received = HistoricResendVerified, true
+
+	stored := resends.get(managementContract)
+	assert.Equal(t, HistoricResendRequested, stored.Status)
+	assert.False(t, stored.Payloads[0].Received)
+}
@@ -1,6 +1,7 @@
 package privacyExtension
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -68,7 +69,7 @@ func setManagedParties(ptm private.PrivateTransactionManager, privateState *stat
 		return
 	}
 
-	_, managedParties, _, _, _ := ptm.Receive(ptmHash)
+	_, managedParties, _, _, _ := ptm.Receive(context.Background(), ptmHash)
 	newManagedParties := common.AppendSkipDuplicates(existingManagedParties, managedParties...)
 	privateState.SetManagedParties(address, newManagedParties)
 }
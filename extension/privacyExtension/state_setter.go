@@ -2,6 +2,7 @@ package privacyExtension
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -103,7 +104,7 @@ func (handler *ExtensionHandler) FetchStateData(address common.Address, hash str
 
 func (handler *ExtensionHandler) FetchDataFromPTM(hash string) ([]string, []byte, *state.PrivacyMetadata, bool) {
 	ptmHash, _ := common.Base64ToEncryptedPayloadHash(hash)
-	_, managedParties, stateData, extraMetaData, err := handler.ptm.Receive(ptmHash)
+	_, managedParties, stateData, extraMetaData, err := handler.ptm.Receive(context.Background(), ptmHash)
 
 	if stateData == nil {
 		log.Error("No state data found in PTM", "ptm hash", hash)
@@ -125,14 +126,14 @@ func (handler *ExtensionHandler) UuidIsOwn(address common.Address, uuid string)
 		return false
 	}
 	encryptedTxHash := common.BytesToEncryptedPayloadHash(common.FromHex(uuid))
-	isSender, err := handler.ptm.IsSender(encryptedTxHash)
+	isSender, err := handler.ptm.IsSender(context.Background(), encryptedTxHash)
 	if isSender {
 		if err != nil {
 			log.Debug("Extension: could not determine if we are sender", "err", err.Error())
 			return false
 		}
 
-		_, _, encryptedPayload, _, err := handler.ptm.Receive(encryptedTxHash)
+		_, _, encryptedPayload, _, err := handler.ptm.Receive(context.Background(), encryptedTxHash)
 		if err != nil {
 			log.Debug("Extension: payload not found", "err", err)
 			return false
@@ -143,7 +144,7 @@ func (handler *ExtensionHandler) UuidIsOwn(address common.Address, uuid string)
 			log.Debug("Extension: payload unmarshal failed", "err", err)
 		}
 
-		contractDetails, _, err := handler.ptm.DecryptPayload(payload)
+		contractDetails, _, err := handler.ptm.DecryptPayload(context.Background(), payload)
 		if err != nil {
 			log.Debug("Extension: payload decrypt failed", "err", err)
 		}
@@ -23,14 +23,14 @@ type DefaultServicesFactory struct {
 	stateFetcher   *StateFetcher
 }
 
-func NewServicesFactory(stack *node.Node, ptm private.PrivateTransactionManager, ethService *eth.Ethereum) (*DefaultServicesFactory, error) {
+func NewServicesFactory(stack *node.Node, config Config, ptm private.PrivateTransactionManager, ethService *eth.Ethereum) (*DefaultServicesFactory, error) {
 	factory := &DefaultServicesFactory{}
 
 	factory.accountManager = ethService.AccountManager()
 	factory.dataHandler = NewJsonFileDataHandler(stack.InstanceDir())
 	factory.stateFetcher = NewStateFetcher(ethService.BlockChain())
 
-	backendService, err := New(stack, ptm, factory.AccountManager(), factory.DataHandler(), factory.StateFetcher(), ethService.APIBackend)
+	backendService, err := New(stack, config, ptm, factory.AccountManager(), factory.DataHandler(), factory.StateFetcher(), ethService.APIBackend)
 	if err != nil {
 		return nil, err
 	}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/extension/extensionContracts"
 	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -22,6 +25,12 @@ type ChainAccessor interface {
 	StateAt(root common.Hash) (*state.StateDB, *state.StateDB, error)
 	State() (*state.StateDB, *state.StateDB, error)
 	CurrentBlock() *types.Block
+
+	// used to find and replay the transactions of historic resends
+	core.ChainContext
+	Config() *params.ChainConfig
+	GetBlockByNumber(number uint64) *types.Block
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 }
 
 // Only extract required methods from ethService.APIBackend
@@ -89,6 +98,22 @@ func (fetcher *StateFetcher) addressStateAsJson(privateState *state.StateDB, add
 	return out, nil
 }
 
+// stateShareSize returns the size in bytes of the state of the address shared by
+// a contract extension, as of the given block, and its number of storage entries.
+func (fetcher *StateFetcher) stateShareSize(blockHash common.Hash, address common.Address) (int, int, error) {
+	privateState, err := fetcher.privateState(blockHash)
+	if err != nil {
+		return 0, 0, err
+	}
+	account, found := privateState.DumpAddress(address)
+	if !found {
+		return 0, 0, fmt.Errorf("error in contract state fetch")
+	}
+	//types can be marshalled, so errors can't occur
+	out, _ := json.Marshal(map[string]extensionContracts.AccountWithMetadata{address.Hex(): {State: account}})
+	return len(out), len(account.Storage), nil
+}
+
 // returns the privacy metadata
 func (fetcher *StateFetcher) GetPrivacyMetaData(blockHash common.Hash, address common.Address) (*state.PrivacyMetadata, error) {
 	privateState, err := fetcher.privateState(blockHash)
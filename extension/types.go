@@ -28,8 +28,32 @@ var (
 		Topics:    [][]common.Hash{{common.HexToHash(extensionContracts.CanPerformStateShareTopicHash)}},
 		Addresses: []common.Address{},
 	}
+
+	stateSharedQuery = ethereum.FilterQuery{
+		FromBlock: nil,
+		ToBlock:   nil,
+		Topics:    [][]common.Hash{{common.HexToHash(extensionContracts.StateSharedTopicHash)}},
+		Addresses: []common.Address{},
+	}
 )
 
+// ExtensionDryRun is the outcome of the checks made before extending a contract.
+type ExtensionDryRun struct {
+	Valid          bool     `json:"valid"`
+	Errors         []string `json:"errors,omitempty"`     // reasons the extension would be refused, in the order they are checked
+	Warnings       []string `json:"warnings,omitempty"`   // checks which could not be made
+	StateShareSize int      `json:"stateShareSize"`       // size in bytes of the contract state shared with the new recipient
+	StorageSlots   int      `json:"storageSlots"`         // number of storage entries of the contract
+	PrivateFor     []string `json:"privateFor,omitempty"` // recipients of the management contract
+
+	errs []error
+}
+
+func (r *ExtensionDryRun) fail(err error) {
+	r.errs = append(r.errs, err)
+	r.Errors = append(r.Errors, err.Error())
+}
+
 type ExtensionContract struct {
 	ContractExtended          common.Address `json:"contractExtended"`
 	Initiator                 common.Address `json:"initiator"`
@@ -37,4 +61,12 @@ type ExtensionContract struct {
 	ManagementContractAddress common.Address `json:"managementContractAddress"`
 	RecipientPtmKey           string         `json:"recipientPtmKey"`
 	CreationData              []byte         `json:"creationData"`
+	CreationBlock             uint64         `json:"creationBlock,omitempty"` // of the management contract, unknown for the extensions stored before it was recorded
+}
+
+// ExtensionContractStatus is an extension contract with the status of its
+// management contract, ACTIVE or DONE.
+type ExtensionContractStatus struct {
+	ExtensionContract
+	Status string `json:"status"`
 }
@@ -0,0 +1,133 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Quorum
+//
+// The transactions of an account are paged through the index of the node,
+// from the head back to genesis. A cursor is the position of the last
+// transaction of its page, so that it remains valid when the block of that
+// transaction is replaced by a reorg: the next page starts with the canonical
+// transactions preceding the position.
+
+const (
+	defaultAccountTransactions = 100
+	maxAccountTransactions     = 1000
+
+	// accountCursorLength is the length of a decoded cursor: the block number
+	// and the transaction index of the last transaction returned, and the
+	// digest of the account and the direction.
+	accountCursorLength = 8 + 4 + 8
+)
+
+var (
+	errAddressTxIndexDisabled = errors.New("transactions of accounts are not indexed, enable the index with --addresstxindex")
+	errInvalidAccountCursor   = errors.New("invalid transaction cursor")
+	errAccountCursorMismatch  = errors.New("transaction cursor does not match the account and direction")
+)
+
+// addressTxIndexed is implemented by the backends indexing the transactions by
+// account.
+type addressTxIndexed interface {
+	AddressTxIndexer() *core.AddressTxIndexer
+}
+
+// AccountTransactionPage is a page of the transactions of an account.
+type AccountTransactionPage struct {
+	transactions []*Transaction
+	cursor       *string
+}
+
+func (p *AccountTransactionPage) Transactions() []*Transaction {
+	return p.transactions
+}
+
+func (p *AccountTransactionPage) Cursor() *string {
+	return p.cursor
+}
+
+func accountCursorDigest(account common.Address, received bool) []byte {
+	direction := byte(0)
+	if received {
+		direction = 1
+	}
+	return crypto.Keccak256(account.Bytes(), []byte{direction})[:8]
+}
+
+func encodeAccountCursor(p core.AddressTxPosition, digest []byte) string {
+	enc := make([]byte, accountCursorLength)
+	binary.BigEndian.PutUint64(enc, p.Number)
+	binary.BigEndian.PutUint32(enc[8:], p.Index)
+	copy(enc[12:], digest)
+	return base64.RawURLEncoding.EncodeToString(enc)
+}
+
+func decodeAccountCursor(cursor string, digest []byte) (core.AddressTxPosition, error) {
+	enc, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(enc) != accountCursorLength {
+		return core.AddressTxPosition{}, errInvalidAccountCursor
+	}
+	if !bytes.Equal(enc[12:], digest) {
+		return core.AddressTxPosition{}, errAccountCursorMismatch
+	}
+	return core.AddressTxPosition{
+		Number: binary.BigEndian.Uint64(enc),
+		Index:  binary.BigEndian.Uint32(enc[8:]),
+	}, nil
+}
+
+// Transactions returns the first canonical transactions sent or received by
+// the account, the most recent first, resuming after the cursor of the
+// previous page if any.
+func (a *Account) Transactions(ctx context.Context, args struct {
+	First     *int32
+	After     *string
+	Direction *string
+}) (*AccountTransactionPage, error) {
+	var indexer *core.AddressTxIndexer
+	if indexed, ok := a.backend.(addressTxIndexed); ok {
+		indexer = indexed.AddressTxIndexer()
+	}
+	if indexer == nil {
+		return nil, errAddressTxIndexDisabled
+	}
+	first := defaultAccountTransactions
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	if first <= 0 || first > maxAccountTransactions {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxAccountTransactions)
+	}
+	received := args.Direction != nil && *args.Direction == "RECEIVED"
+	digest := accountCursorDigest(a.address, received)
+	var after *core.AddressTxPosition
+	if args.After != nil {
+		position, err := decodeAccountCursor(*args.After, digest)
+		if err != nil {
+			return nil, err
+		}
+		after = &position
+	}
+	txs, more := indexer.Transactions(a.address, received, after, first)
+	page := &AccountTransactionPage{transactions: make([]*Transaction, 0, len(txs))}
+	for _, tx := range txs {
+		page.transactions = append(page.transactions, &Transaction{backend: a.backend, hash: tx.TxHash})
+	}
+	if more {
+		last := txs[len(txs)-1]
+		cursor := encodeAccountCursor(core.AddressTxPosition{Number: last.Number, Index: last.Index}, digest)
+		page.cursor = &cursor
+	}
+	return page, nil
+}
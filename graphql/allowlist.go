@@ -0,0 +1,612 @@
+package graphql
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// Quorum
+//
+// The allow-list restricts the GraphQL server to the vetted queries of a
+// directory, each <name>.graphql file holding the document of a single
+// operation. A request selects a document by name with the queryName
+// extension, by the hex SHA-256 hash of the document with the persistedQuery
+// extension of automatic persisted queries, or sends a query identical to a
+// document once normalized. The directory is reloaded when it changes.
+
+const (
+	allowListExt            = ".graphql"
+	allowListReloadInterval = 5 * time.Second
+
+	// queryNotAllowedCode is the error code of the requests for queries which
+	// are not on the allow-list.
+	queryNotAllowedCode = "QUERY_NOT_ALLOWED"
+	// invalidVariablesCode is the error code of the requests for allowed queries
+	// with variables not matching the types declared by the document.
+	invalidVariablesCode = "INVALID_VARIABLES"
+)
+
+var errQueryNotAllowed = errors.New("query not on the allow-list")
+
+// allowedQuery is a document of the allow-list.
+type allowedQuery struct {
+	name     string
+	document string
+	vars     map[string]*variableType // declared variables by name
+}
+
+// variableType is the declared type of a variable, either a named type or a
+// list of elem.
+type variableType struct {
+	name       string
+	elem       *variableType
+	nonNull    bool
+	hasDefault bool
+}
+
+type allowList struct {
+	dir  string
+	quit chan struct{}
+
+	lock         sync.RWMutex
+	byName       map[string]*allowedQuery
+	byHash       map[string]*allowedQuery
+	byNormalized map[string]*allowedQuery
+	signature    string // names, sizes and modification times of the documents
+}
+
+func newAllowList(dir string) (*allowList, error) {
+	l := &allowList{dir: dir, quit: make(chan struct{})}
+	if err := l.reload(); err != nil {
+		return nil, fmt.Errorf("invalid GraphQL allow-list %s: %v", dir, err)
+	}
+	return l, nil
+}
+
+// documents returns the paths of the documents and the signature of the
+// directory.
+func (l *allowList) documents() ([]string, string, error) {
+	infos, err := ioutil.ReadDir(l.dir)
+	if err != nil {
+		return nil, "", err
+	}
+	var (
+		paths     []string
+		signature strings.Builder
+	)
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != allowListExt {
+			continue
+		}
+		paths = append(paths, filepath.Join(l.dir, info.Name()))
+		fmt.Fprintf(&signature, "%s:%d:%d;", info.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	sort.Strings(paths)
+	return paths, signature.String(), nil
+}
+
+// reload reads the documents, keeping the current ones if any is invalid.
+func (l *allowList) reload() error {
+	paths, signature, err := l.documents()
+	if err != nil {
+		return err
+	}
+	var (
+		byName       = make(map[string]*allowedQuery, len(paths))
+		byHash       = make(map[string]*allowedQuery, len(paths))
+		byNormalized = make(map[string]*allowedQuery, len(paths))
+	)
+	for _, path := range paths {
+		blob, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), allowListExt)
+		tokens, err := lexQuery(string(blob))
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		vars, err := parseVariables(tokens)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		query := &allowedQuery{name: name, document: string(blob), vars: vars}
+		hash := sha256.Sum256(blob)
+		byName[name] = query
+		byHash[hex.EncodeToString(hash[:])] = query
+		if _, ok := byNormalized[strings.Join(tokens, " ")]; !ok {
+			byNormalized[strings.Join(tokens, " ")] = query
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.byName, l.byHash, l.byNormalized = byName, byHash, byNormalized
+	l.signature = signature
+	return nil
+}
+
+// Start implements node.Lifecycle, reloading the documents whenever the
+// directory changes.
+func (l *allowList) Start() error {
+	go l.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle.
+func (l *allowList) Stop() error {
+	close(l.quit)
+	return nil
+}
+
+func (l *allowList) loop() {
+	ticker := time.NewTicker(allowListReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_, signature, err := l.documents()
+			if err != nil {
+				log.Error("Failed to check the GraphQL allow-list", "dir", l.dir, "err", err)
+				continue
+			}
+			l.lock.RLock()
+			modified := signature != l.signature
+			l.lock.RUnlock()
+			if !modified {
+				continue
+			}
+			if err := l.reload(); err != nil {
+				log.Error("Invalid GraphQL allow-list, keeping the previous queries", "dir", l.dir, "err", err)
+			} else {
+				log.Info("Reloaded the GraphQL allow-list", "dir", l.dir)
+			}
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// allowListRequest is a GraphQL request, with the extensions selecting a
+// document of the allow-list.
+type allowListRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    struct {
+		QueryName      string `json:"queryName"`
+		PersistedQuery *struct {
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// lookup returns the document of the allow-list requested.
+func (l *allowList) lookup(req *allowListRequest) (*allowedQuery, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	var query *allowedQuery
+	switch {
+	case req.Extensions.QueryName != "":
+		query = l.byName[req.Extensions.QueryName]
+	case req.Extensions.PersistedQuery != nil:
+		query = l.byHash[strings.ToLower(req.Extensions.PersistedQuery.SHA256Hash)]
+	default:
+		if tokens, err := lexQuery(req.Query); err == nil {
+			query = l.byNormalized[strings.Join(tokens, " ")]
+		}
+	}
+	if query == nil {
+		return nil, errQueryNotAllowed
+	}
+	return query, nil
+}
+
+// validateVariables checks the variables of a request against the types the
+// document declares.
+func (q *allowedQuery) validateVariables(vars map[string]interface{}) error {
+	for name := range vars {
+		if _, ok := q.vars[name]; !ok {
+			return fmt.Errorf("variable $%s is not declared by query %s", name, q.name)
+		}
+	}
+	for name, typ := range q.vars {
+		value, ok := vars[name]
+		if !ok && (typ.hasDefault || !typ.nonNull) {
+			continue
+		}
+		if err := typ.validate(value); err != nil {
+			return fmt.Errorf("variable $%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the JSON value against the type. Input objects are only
+// checked to be objects, their fields being checked by the schema.
+func (t *variableType) validate(value interface{}) error {
+	if value == nil {
+		if t.nonNull {
+			return fmt.Errorf("null value for %s", t)
+		}
+		return nil
+	}
+	if t.elem != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			// a single value is coerced to a list
+			return t.elem.validate(value)
+		}
+		for _, elem := range list {
+			if err := t.elem.validate(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var ok bool
+	switch t.name {
+	case "Int":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32
+	case "Float":
+		_, ok = value.(float64)
+	case "Boolean":
+		_, ok = value.(bool)
+	case "String", "Bytes32", "Address", "Bytes", "BigInt":
+		_, ok = value.(string)
+	case "ID", "Long":
+		switch v := value.(type) {
+		case string:
+			ok = true
+		case float64:
+			ok = v == math.Trunc(v)
+		}
+	default:
+		_, ok = value.(map[string]interface{})
+	}
+	if !ok {
+		return fmt.Errorf("invalid value %v for %s", value, t)
+	}
+	return nil
+}
+
+func (t *variableType) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// allowListHandler only serves the requests for queries of the allow-list.
+type allowListHandler struct {
+	next http.Handler
+	list *allowList
+}
+
+func newAllowListHandler(next http.Handler, list *allowList) http.Handler {
+	return &allowListHandler{next: next, list: list}
+}
+
+// ServeHTTP hands over the request with its query replaced by the document
+// of the allow-list, rejecting it with status 403 if there is none or 400 if
+// its variables do not match the document.
+func (h *allowListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req allowListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query, err := h.list.lookup(&req)
+	if err != nil {
+		metrics.GetOrRegisterCounter("graphql/allowlist/misses/"+allowListClient(r), nil).Inc(1)
+		rejectRequest(w, http.StatusForbidden, queryNotAllowedCode, err)
+		return
+	}
+	if err := query.validateVariables(req.Variables); err != nil {
+		rejectRequest(w, http.StatusBadRequest, invalidVariablesCode, err)
+		return
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query":         query.document,
+		"operationName": req.OperationName,
+		"variables":     req.Variables,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	h.next.ServeHTTP(w, r)
+}
+
+// rejectRequest answers the GraphQL error of a request rejected before it is
+// executed.
+func rejectRequest(w http.ResponseWriter, status int, code string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{"message": err.Error(), "extensions": map[string]string{"code": code}}},
+	})
+}
+
+// allowListClient identifies the client of the request in the metrics, by
+// its API key if any, its address otherwise.
+func allowListClient(r *http.Request) string {
+	if id := node.APIKeyID(r.Context()); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// lexQuery returns the tokens of a GraphQL document, leaving out the
+// whitespace, commas and comments, which are insignificant.
+func lexQuery(doc string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := strings.Index(doc[i+3:], `"""`)
+			for end >= 0 && doc[i+3+end-1] == '\\' {
+				next := strings.Index(doc[i+3+end+3:], `"""`)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += 3 + next
+			}
+			if end < 0 {
+				return nil, errors.New("unterminated block string")
+			}
+			tokens = append(tokens, doc[i:i+3+end+3])
+			i += 3 + end + 3
+		case c == '"':
+			j := i + 1
+			for ; j < len(doc) && doc[j] != '"'; j++ {
+				if doc[j] == '\\' {
+					j++
+				} else if doc[j] == '\n' || doc[j] == '\r' {
+					break
+				}
+			}
+			if j >= len(doc) || doc[j] != '"' {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, doc[i:j+1])
+			i = j + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(doc) && (isNameChar(doc[j]) || doc[j] == '.' || doc[j] == '-' || doc[j] == '+') {
+				j++
+			}
+			tokens = append(tokens, doc[i:j])
+			i = j
+		case isNameChar(c):
+			j := i + 1
+			for j < len(doc) && isNameChar(doc[j]) {
+				j++
+			}
+			tokens = append(tokens, doc[i:j])
+			i = j
+		case strings.HasPrefix(doc[i:], "\ufeff"):
+			i += len("\ufeff")
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// parseVariables returns the variables declared by the single operation of
+// the document, whose fragments are skipped.
+func parseVariables(tokens []string) (map[string]*variableType, error) {
+	var (
+		vars        map[string]*variableType
+		operations  int
+		inOperation bool // the header of an operation was parsed, not its body
+	)
+	for i := 0; i < len(tokens); {
+		switch tokens[i] {
+		case "query", "mutation", "subscription":
+			operations++
+			inOperation = true
+			i++
+			if i < len(tokens) && isName(tokens[i]) {
+				i++
+			}
+			vars = make(map[string]*variableType)
+			if i < len(tokens) && tokens[i] == "(" {
+				var err error
+				if i, err = parseVariableDefinitions(tokens, i+1, vars); err != nil {
+					return nil, err
+				}
+			}
+		case "fragment":
+			for i < len(tokens) && tokens[i] != "{" {
+				i++
+			}
+			end, err := skipBalanced(tokens, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case "{":
+			if !inOperation {
+				// query shorthand
+				operations++
+				vars = make(map[string]*variableType)
+			}
+			inOperation = false
+			end, err := skipBalanced(tokens, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	if operations != 1 {
+		return nil, fmt.Errorf("document must hold a single operation, found %d", operations)
+	}
+	return vars, nil
+}
+
+// parseVariableDefinitions parses the definitions from tokens[i] to the
+// closing parenthesis, returning the index following it.
+func parseVariableDefinitions(tokens []string, i int, vars map[string]*variableType) (int, error) {
+	for i < len(tokens) && tokens[i] != ")" {
+		if tokens[i] != "$" || i+2 >= len(tokens) || !isName(tokens[i+1]) || tokens[i+2] != ":" {
+			return 0, errors.New("invalid variable definition")
+		}
+		name := tokens[i+1]
+		typ, next, err := parseType(tokens, i+3)
+		if err != nil {
+			return 0, fmt.Errorf("variable $%s: %v", name, err)
+		}
+		i = next
+		if i < len(tokens) && tokens[i] == "=" {
+			typ.hasDefault = true
+			if i, err = skipValue(tokens, i+1); err != nil {
+				return 0, fmt.Errorf("variable $%s: %v", name, err)
+			}
+		}
+		for i < len(tokens) && tokens[i] == "@" {
+			i += 2
+			if i < len(tokens) && tokens[i] == "(" {
+				if i, err = skipBalanced(tokens, i); err != nil {
+					return 0, err
+				}
+			}
+		}
+		vars[name] = typ
+	}
+	if i >= len(tokens) {
+		return 0, errors.New("unterminated variable definitions")
+	}
+	return i + 1, nil
+}
+
+func parseType(tokens []string, i int) (*variableType, int, error) {
+	if i >= len(tokens) {
+		return nil, 0, errors.New("missing type")
+	}
+	typ := new(variableType)
+	switch {
+	case tokens[i] == "[":
+		elem, next, err := parseType(tokens, i+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if next >= len(tokens) || tokens[next] != "]" {
+			return nil, 0, errors.New("unterminated list type")
+		}
+		typ.elem, i = elem, next+1
+	case isName(tokens[i]):
+		typ.name = tokens[i]
+		i++
+	default:
+		return nil, 0, fmt.Errorf("invalid type %s", tokens[i])
+	}
+	if i < len(tokens) && tokens[i] == "!" {
+		typ.nonNull = true
+		i++
+	}
+	return typ, i, nil
+}
+
+// skipValue returns the index following the literal value at tokens[i].
+func skipValue(tokens []string, i int) (int, error) {
+	if i >= len(tokens) {
+		return 0, errors.New("missing value")
+	}
+	if tokens[i] == "[" || tokens[i] == "{" {
+		return skipBalanced(tokens, i)
+	}
+	return i + 1, nil
+}
+
+// skipBalanced returns the index following the bracket closing the one at
+// tokens[i].
+func skipBalanced(tokens []string, i int) (int, error) {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, errors.New("unbalanced brackets")
+}
+
+func isName(token string) bool {
+	if token == "" || (token[0] >= '0' && token[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(token); i++ {
+		if !isNameChar(token[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// registerAllowList loads the allow-list of the directory, reloading it for as
+// long as the node runs.
+func registerAllowList(stack *node.Node, dir string) (*allowList, error) {
+	list, err := newAllowList(dir)
+	if err != nil {
+		return nil, err
+	}
+	stack.RegisterLifecycle(list)
+	return list, nil
+}
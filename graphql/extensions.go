@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/extension"
+)
+
+// Quorum
+//
+// contractExtensionSource is the extension service of the node, as read by the
+// contractExtensions query.
+type contractExtensionSource interface {
+	ContractExtensions(ctx context.Context) ([]extension.ExtensionContractStatus, error)
+}
+
+// ContractExtension is the extension of a private contract to a new party.
+type ContractExtension struct {
+	extension extension.ExtensionContractStatus
+}
+
+func (e *ContractExtension) ManagementContract() common.Address {
+	return e.extension.ManagementContractAddress
+}
+
+func (e *ContractExtension) Contract() common.Address { return e.extension.ContractExtended }
+
+func (e *ContractExtension) Initiator() common.Address { return e.extension.Initiator }
+
+func (e *ContractExtension) Recipient() common.Address { return e.extension.Recipient }
+
+func (e *ContractExtension) RecipientPtmKey() string { return e.extension.RecipientPtmKey }
+
+func (e *ContractExtension) Status() string { return e.extension.Status }
+
+func (e *ContractExtension) CreationBlock() *hexutil.Uint64 {
+	if e.extension.CreationBlock == 0 {
+		return nil
+	}
+	number := hexutil.Uint64(e.extension.CreationBlock)
+	return &number
+}
+
+// ContractExtensions returns the contract extensions known to the extension
+// service, only the active ones or the others if active is given, and none if
+// the service is not enabled on the node.
+func (r *Resolver) ContractExtensions(ctx context.Context, args struct{ Active *bool }) ([]*ContractExtension, error) {
+	ret := make([]*ContractExtension, 0)
+	if r.extensions == nil {
+		return ret, nil
+	}
+	extensions, err := r.extensions.ContractExtensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range extensions {
+		if args.Active != nil && *args.Active != (e.Status == extension.ExtensionInProgress) {
+			continue
+		}
+		ret = append(ret, &ContractExtension{extension: e})
+	}
+	return ret, nil
+}
@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// FeeHistory is the fee market history of a range of blocks, the same as
+// returned by eth_feeHistory.
+type FeeHistory struct {
+	history *ethapi.FeeHistory
+}
+
+func (h *FeeHistory) OldestBlock() hexutil.Uint64 {
+	return hexutil.Uint64(h.history.OldestBlock.ToInt().Uint64())
+}
+
+func (h *FeeHistory) BaseFeePerGas() []hexutil.Big {
+	fees := make([]hexutil.Big, len(h.history.BaseFee))
+	for i, fee := range h.history.BaseFee {
+		fees[i] = *fee
+	}
+	return fees
+}
+
+func (h *FeeHistory) GasUsedRatio() []float64 { return h.history.GasUsedRatio }
+
+func (h *FeeHistory) Reward() *[][]hexutil.Big {
+	if h.history.Reward == nil {
+		return nil
+	}
+	rewards := make([][]hexutil.Big, len(h.history.Reward))
+	for i, block := range h.history.Reward {
+		rewards[i] = make([]hexutil.Big, len(block))
+		for j, reward := range block {
+			rewards[i][j] = *reward
+		}
+	}
+	return &rewards
+}
+
+// FeeHistory returns the fee history of the blockCount blocks ending with
+// newestBlock, or the most recent known block.
+func (r *Resolver) FeeHistory(ctx context.Context, args struct {
+	BlockCount        int32
+	NewestBlock       *hexutil.Uint64
+	RewardPercentiles *[]float64
+}) (*FeeHistory, error) {
+	newest := rpc.LatestBlockNumber
+	if args.NewestBlock != nil {
+		newest = rpc.BlockNumber(*args.NewestBlock)
+	}
+	var percentiles []float64
+	if args.RewardPercentiles != nil {
+		percentiles = *args.RewardPercentiles
+	}
+	if args.BlockCount < 0 {
+		args.BlockCount = 0
+	}
+	history, err := ethapi.GetFeeHistory(ctx, r.snapshot(ctx), uint64(args.BlockCount), newest, percentiles)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeHistory{history: history}, nil
+}
@@ -18,8 +18,11 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -29,7 +32,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
-	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/private/engine"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -76,6 +80,7 @@ func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
 	if err != nil {
 		return hexutil.Bytes{}, err
 	}
+	ethapi.LogContractAccess(ctx, a.backend, "graphql:code", a.address, nil, a.blockNrOrHash) // Quorum
 	return hexutil.Bytes(state.GetCode(a.address)), nil
 }
 
@@ -84,6 +89,7 @@ func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash })
 	if err != nil {
 		return common.Hash{}, err
 	}
+	ethapi.LogContractAccess(ctx, a.backend, "graphql:storage", a.address, nil, a.blockNrOrHash) // Quorum
 	return state.GetState(a.address, args.Slot), nil
 }
 
@@ -212,17 +218,21 @@ func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account,
 	if err != nil || tx == nil {
 		return nil, err
 	}
+	return &Account{
+		backend:       t.backend,
+		address:       sender(tx),
+		blockNrOrHash: args.NumberOrLatest(),
+	}, nil
+}
+
+// sender returns the address the transaction was signed by.
+func sender(tx *types.Transaction) common.Address {
 	var signer types.Signer = types.HomesteadSigner{}
 	if tx.Protected() {
 		signer = types.NewEIP155Signer(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
-
-	return &Account{
-		backend:       t.backend,
-		address:       from,
-		blockNrOrHash: args.NumberOrLatest(),
-	}, nil
+	return from
 }
 
 func (t *Transaction) Block(ctx context.Context) (*Block, error) {
@@ -251,6 +261,10 @@ func (t *Transaction) getReceipt(ctx context.Context) (*types.Receipt, error) {
 	if t.block == nil {
 		return nil, nil
 	}
+	// Quorum: the receipts of private transactions are only served to their parties
+	if err := t.authorize(ctx); err != nil {
+		return nil, err
+	}
 	receipts, err := t.block.resolveReceipts(ctx)
 	if err != nil {
 		return nil, err
@@ -287,6 +301,11 @@ func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*hexutil.Uint64, e
 
 func (t *Transaction) CreatedContract(ctx context.Context, args BlockNumberArgs) (*Account, error) {
 	receipt, err := t.getReceipt(ctx)
+	// Quorum: the tenants not party to a private creation are served no
+	// contract, as by a non-party node
+	if errors.Is(err, multitenancy.ErrNotAuthorized) {
+		return nil, nil
+	}
 	if err != nil || receipt == nil || receipt.ContractAddress == (common.Address{}) {
 		return nil, err
 	}
@@ -325,19 +344,117 @@ func (t *Transaction) IsPrivate(ctx context.Context) (*bool, error) {
 }
 
 func (t *Transaction) PrivateInputData(ctx context.Context) (*hexutil.Bytes, error) {
-	tx, err := t.resolve(ctx)
-	if err != nil || tx == nil {
+	payload, err := t.receivePrivate(ctx)
+	if err != nil {
 		return &hexutil.Bytes{}, err
 	}
-	if tx.IsPrivate() {
-		_, _, privateInputData, _, err := private.P.Receive(common.BytesToEncryptedPayloadHash(tx.Data()))
-		if err != nil || tx == nil {
-			return &hexutil.Bytes{}, err
-		}
-		ret := hexutil.Bytes(privateInputData)
-		return &ret, nil
+	ret := hexutil.Bytes{}
+	if payload != nil {
+		ret = payload.data
+	}
+	return &ret, nil
+}
+
+func (t *Transaction) PrivateFrom(ctx context.Context) (*string, error) {
+	payload, err := t.receivePrivate(ctx)
+	if err != nil || payload == nil || payload.sender == "" {
+		return nil, err
+	}
+	return &payload.sender, nil
+}
+
+func (t *Transaction) PrivateFor(ctx context.Context) (*[]string, error) {
+	payload, err := t.receivePrivate(ctx)
+	if err != nil || payload == nil {
+		return nil, err
+	}
+	ret := append([]string{}, payload.managedParties...)
+	return &ret, nil
+}
+
+// privateMetadata returns the metadata of the private transaction, nil if the
+// transaction is public or the node is not a party to it.
+func (t *Transaction) privateMetadata(ctx context.Context) (*engine.ExtraMetadata, error) {
+	payload, err := t.receivePrivate(ctx)
+	if err != nil || payload == nil {
+		return nil, err
+	}
+	return payload.metadata, nil
+}
+
+func (t *Transaction) PrivacyFlag(ctx context.Context) (*int32, error) {
+	metadata, err := t.privateMetadata(ctx)
+	if err != nil || metadata == nil {
+		return nil, err
+	}
+	ret := int32(metadata.PrivacyFlag)
+	return &ret, nil
+}
+
+func (t *Transaction) AffectedContractTransactions(ctx context.Context) (*[]hexutil.Bytes, error) {
+	metadata, err := t.privateMetadata(ctx)
+	if err != nil || metadata == nil {
+		return nil, err
+	}
+	ret := make([]hexutil.Bytes, 0, len(metadata.ACHashes))
+	for hash := range metadata.ACHashes {
+		ret = append(ret, hash.Bytes())
+	}
+	sort.Slice(ret, func(i, j int) bool { return bytes.Compare(ret[i], ret[j]) < 0 })
+	return &ret, nil
+}
+
+func (t *Transaction) InternalCalls(ctx context.Context) (*[]*InternalCall, error) {
+	calls, ok := rawdb.ReadInternalCalls(t.backend.ChainDb(), t.hash)
+	if !ok {
+		return nil, nil
+	}
+	ret := make([]*InternalCall, 0, len(calls))
+	for _, call := range calls {
+		ret = append(ret, &InternalCall{backend: t.backend, call: call})
+	}
+	return &ret, nil
+}
+
+// InternalCall represents a call or a contract creation made by a contract.
+type InternalCall struct {
+	backend ethapi.Backend
+	call    *types.InternalCall
+}
+
+func (c *InternalCall) Type(ctx context.Context) string {
+	return c.call.Type
+}
+
+func (c *InternalCall) From(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{
+		backend:       c.backend,
+		address:       c.call.From,
+		blockNrOrHash: args.NumberOrLatest(),
+	}
+}
+
+func (c *InternalCall) To(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{
+		backend:       c.backend,
+		address:       c.call.To,
+		blockNrOrHash: args.NumberOrLatest(),
 	}
-	return &hexutil.Bytes{}, nil
+}
+
+func (c *InternalCall) Value(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*c.call.Value)
+}
+
+func (c *InternalCall) Depth(ctx context.Context) int32 {
+	return int32(c.call.Depth)
+}
+
+func (c *InternalCall) Error(ctx context.Context) *string {
+	if c.call.Error == "" {
+		return nil
+	}
+	return &c.call.Error
 }
 
 // END QUORUM
@@ -381,6 +498,7 @@ type Block struct {
 	header       *types.Header
 	block        *types.Block
 	receipts     []*types.Receipt
+	rawTxs       []byte // Quorum: RLP of the transactions of the stored body
 }
 
 // resolve returns the internal Block object representing this block, fetching
@@ -545,6 +663,19 @@ func (b *Block) StateRoot(ctx context.Context) (common.Hash, error) {
 	return header.Root, nil
 }
 
+// Quorum
+func (b *Block) PrivateStateRoot(ctx context.Context) (*common.Hash, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root, err := ethapi.PrivateStateRoot(b.backend, header)
+	if err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
 func (b *Block) ReceiptsRoot(ctx context.Context) (common.Hash, error) {
 	header, err := b.resolveHeader(ctx)
 	if err != nil {
@@ -561,7 +692,30 @@ func (b *Block) OmmerHash(ctx context.Context) (common.Hash, error) {
 	return header.UncleHash, nil
 }
 
+// Quorum
+// ConsensusEngine returns the consensus algorithm of the chain.
+func (b *Block) ConsensusEngine() string {
+	return b.backend.ConsensusAlgorithm()
+}
+
+// Quorum
+// producesOmmers reports whether the consensus of the chain can produce
+// ommers, the blocks of the others never being looked up for them.
+func (b *Block) producesOmmers() bool {
+	switch b.backend.ConsensusAlgorithm() {
+	case "raft", "istanbul", "clique":
+		return false
+	}
+	return true
+}
+
 func (b *Block) OmmerCount(ctx context.Context) (*int32, error) {
+	// Quorum
+	if !b.producesOmmers() {
+		count := int32(0)
+		return &count, nil
+	}
+	// End Quorum
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
 		return nil, err
@@ -571,6 +725,11 @@ func (b *Block) OmmerCount(ctx context.Context) (*int32, error) {
 }
 
 func (b *Block) Ommers(ctx context.Context) (*[]*Block, error) {
+	// Quorum
+	if !b.producesOmmers() {
+		return &[]*Block{}, nil
+	}
+	// End Quorum
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
 		return nil, err
@@ -652,21 +811,50 @@ func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) (*Account, erro
 }
 
 func (b *Block) TransactionCount(ctx context.Context) (*int32, error) {
-	block, err := b.resolve(ctx)
-	if err != nil || block == nil {
+	// Quorum: counted without decoding the transactions
+	count, err := b.transactionCount(ctx)
+	if err != nil || count == nil {
 		return nil, err
 	}
-	count := int32(len(block.Transactions()))
-	return &count, err
+	ret := int32(*count)
+	return &ret, nil
 }
 
-func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
+// Quorum
+// TransactionFilter restricts the transactions of a block to those matching
+// all the criteria set.
+type TransactionFilter struct {
+	IsPrivate *bool
+	From      *common.Address
+	To        *common.Address // never matching the contract creations
+}
+
+func (f *TransactionFilter) matches(tx *types.Transaction) bool {
+	if f.IsPrivate != nil && tx.IsPrivate() != *f.IsPrivate {
+		return false
+	}
+	if f.To != nil && (tx.To() == nil || *tx.To() != *f.To) {
+		return false
+	}
+	if f.From != nil && sender(tx) != *f.From {
+		return false
+	}
+	return true
+}
+
+// End Quorum
+
+func (b *Block) Transactions(ctx context.Context, args struct{ Filter *TransactionFilter }) (*[]*Transaction, error) {
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
 		return nil, err
 	}
 	ret := make([]*Transaction, 0, len(block.Transactions()))
 	for i, tx := range block.Transactions() {
+		// Quorum: the index stays the position in the block
+		if args.Filter != nil && !args.Filter.matches(tx) {
+			continue
+		}
 		ret = append(ret, &Transaction{
 			backend: b.backend,
 			hash:    tx.Hash(),
@@ -679,25 +867,23 @@ func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
 }
 
 func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (*Transaction, error) {
-	block, err := b.resolve(ctx)
-	if err != nil || block == nil {
-		return nil, err
-	}
-	txs := block.Transactions()
-	if args.Index < 0 || int(args.Index) >= len(txs) {
+	// Quorum: only the transaction is decoded
+	if args.Index < 0 {
 		return nil, nil
 	}
-	tx := txs[args.Index]
-	return &Transaction{
-		backend: b.backend,
-		hash:    tx.Hash(),
-		tx:      tx,
-		block:   b,
-		index:   uint64(args.Index),
-	}, nil
+	txs, err := b.transactionsRange(ctx, int(args.Index), 1)
+	if err != nil || len(txs) == 0 {
+		return nil, err
+	}
+	return txs[0], nil
 }
 
 func (b *Block) OmmerAt(ctx context.Context, args struct{ Index int32 }) (*Block, error) {
+	// Quorum
+	if !b.producesOmmers() {
+		return nil, nil
+	}
+	// End Quorum
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
 		return nil, err
@@ -749,7 +935,8 @@ func runFilter(ctx context.Context, be ethapi.Backend, filter *filters.Filter) (
 			log:         log,
 		})
 	}
-	return ret, nil
+	// Quorum
+	return filterAuthorizedLogs(ctx, ret)
 }
 
 func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
@@ -832,6 +1019,11 @@ func (b *Block) Call(ctx context.Context, args struct {
 		}
 	}
 
+	// Quorum
+	if args.Data.To != nil && args.Data.Data != nil {
+		ethapi.LogContractAccess(ctx, b.backend, "graphql:call", *args.Data.To, *args.Data.Data, *b.numberOrHash)
+	}
+
 	// Quorum - replaced the default 5s time out with the value passed in vm.calltimeout
 	result, err := ethapi.DoCall(ctx, b.backend, args.Data, *b.numberOrHash, nil, vm.Config{}, b.backend.CallTimeOut(), b.backend.RPCGasCap())
 	if err != nil {
@@ -904,6 +1096,11 @@ func (p *Pending) Call(ctx context.Context, args struct {
 }) (*CallResult, error) {
 	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 
+	// Quorum
+	if args.Data.To != nil && args.Data.Data != nil {
+		ethapi.LogContractAccess(ctx, p.backend, "graphql:call", *args.Data.To, *args.Data.Data, pendingBlockNr)
+	}
+
 	// Quorum - replaced the default 5s time out with the value passed in vm.calltimeout
 	result, err := ethapi.DoCall(ctx, p.backend, args.Data, pendingBlockNr, nil, vm.Config{}, p.backend.CallTimeOut(), p.backend.RPCGasCap())
 	if err != nil {
@@ -930,31 +1127,34 @@ func (p *Pending) EstimateGas(ctx context.Context, args struct {
 
 // Resolver is the top-level object in the GraphQL hierarchy.
 type Resolver struct {
-	backend ethapi.Backend
+	backend    ethapi.Backend
+	extensions contractExtensionSource // Quorum: nil if the extension service is not enabled
 }
 
 func (r *Resolver) Block(ctx context.Context, args struct {
-	Number *hexutil.Uint64
-	Hash   *common.Hash
+	Number           *hexutil.Uint64
+	Hash             *common.Hash
+	RequireCanonical *bool // Quorum
 }) (*Block, error) {
+	backend := r.snapshot(ctx)
 	var block *Block
 	if args.Number != nil {
 		number := rpc.BlockNumber(uint64(*args.Number))
 		numberOrHash := rpc.BlockNumberOrHashWithNumber(number)
 		block = &Block{
-			backend:      r.backend,
+			backend:      backend,
 			numberOrHash: &numberOrHash,
 		}
 	} else if args.Hash != nil {
-		numberOrHash := rpc.BlockNumberOrHashWithHash(*args.Hash, false)
+		numberOrHash := rpc.BlockNumberOrHashWithHash(*args.Hash, args.RequireCanonical != nil && *args.RequireCanonical)
 		block = &Block{
-			backend:      r.backend,
+			backend:      backend,
 			numberOrHash: &numberOrHash,
 		}
 	} else {
 		numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
 		block = &Block{
-			backend:      r.backend,
+			backend:      backend,
 			numberOrHash: &numberOrHash,
 		}
 	}
@@ -974,13 +1174,14 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 	From hexutil.Uint64
 	To   *hexutil.Uint64
 }) ([]*Block, error) {
+	backend := r.snapshot(ctx)
 	from := rpc.BlockNumber(args.From)
 
 	var to rpc.BlockNumber
 	if args.To != nil {
 		to = rpc.BlockNumber(*args.To)
 	} else {
-		to = rpc.BlockNumber(r.backend.CurrentBlock().Number().Int64())
+		to = rpc.BlockNumber(backend.CurrentBlock().Number().Int64())
 	}
 	if to < from {
 		return []*Block{}, nil
@@ -989,7 +1190,7 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 	for i := from; i <= to; i++ {
 		numberOrHash := rpc.BlockNumberOrHashWithNumber(i)
 		ret = append(ret, &Block{
-			backend:      r.backend,
+			backend:      backend,
 			numberOrHash: &numberOrHash,
 		})
 	}
@@ -997,12 +1198,12 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 }
 
 func (r *Resolver) Pending(ctx context.Context) *Pending {
-	return &Pending{r.backend}
+	return &Pending{r.snapshot(ctx)}
 }
 
 func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
 	tx := &Transaction{
-		backend: r.backend,
+		backend: r.snapshot(ctx),
 		hash:    args.Hash,
 	}
 	// Resolve the transaction; if it doesn't exist, return nil.
@@ -1024,6 +1225,21 @@ func (r *Resolver) SendRawTransaction(ctx context.Context, args struct{ Data hex
 	return hash, err
 }
 
+// Quorum
+// SendRawPrivateTransaction sends a signed private transaction, its data the
+// hash of a payload stored with storeraw, as eth_sendRawPrivateTransaction.
+func (r *Resolver) SendRawPrivateTransaction(ctx context.Context, args struct {
+	Data        hexutil.Bytes
+	PrivateFor  []string
+	PrivacyFlag *int32
+}) (common.Hash, error) {
+	sendArgs := ethapi.SendRawTxArgs{PrivateTxArgs: ethapi.PrivateTxArgs{PrivateFor: args.PrivateFor}}
+	if args.PrivacyFlag != nil {
+		sendArgs.PrivacyFlag = engine.PrivacyFlagType(*args.PrivacyFlag)
+	}
+	return ethapi.SendRawPrivateTransaction(ctx, r.backend, args.Data, sendArgs)
+}
+
 // FilterCriteria encapsulates the arguments to `logs` on the root resolver object.
 type FilterCriteria struct {
 	FromBlock *hexutil.Uint64   // beginning of the queried range, nil means genesis block
@@ -1063,10 +1279,70 @@ func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria
 		topics = *args.Filter.Topics
 	}
 	// Construct the range filter
-	filter := filters.NewRangeFilter(filters.Backend(r.backend), begin, end, addresses, topics)
-	return runFilter(ctx, r.backend, filter)
+	backend := r.snapshot(ctx)
+	filter := filters.NewRangeFilter(filters.Backend(backend), begin, end, addresses, topics)
+	return runFilter(ctx, backend, filter)
 }
 
+// Quorum
+// LogPage is a page of the log entries matching a filter.
+type LogPage struct {
+	logs   []*Log
+	cursor *string
+}
+
+func (p *LogPage) Logs() []*Log {
+	return p.logs
+}
+
+func (p *LogPage) Cursor() *string {
+	return p.cursor
+}
+
+// LogsPage returns at most pageSize log entries matching the filter, resuming
+// after the cursor returned with the previous page if any.
+func (r *Resolver) LogsPage(ctx context.Context, args struct {
+	Filter   FilterCriteria
+	PageSize hexutil.Uint64
+	Cursor   *string
+}) (*LogPage, error) {
+	crit := filters.FilterCriteria{PageSize: uint64(args.PageSize)}
+	if args.Filter.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(uint64(*args.Filter.FromBlock))
+	}
+	if args.Filter.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(uint64(*args.Filter.ToBlock))
+	}
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	if args.Cursor != nil {
+		crit.Cursor = *args.Cursor
+	}
+	backend := r.snapshot(ctx)
+	page, err := filters.PageLogs(ctx, filters.Backend(backend), crit, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := &LogPage{logs: make([]*Log, 0, len(page.Logs))}
+	for _, log := range page.Logs {
+		ret.logs = append(ret.logs, &Log{
+			backend:     backend,
+			transaction: &Transaction{backend: backend, hash: log.TxHash},
+			log:         log,
+		})
+	}
+	if page.Cursor != "" {
+		ret.cursor = &page.Cursor
+	}
+	return ret, nil
+}
+
+// End Quorum
+
 func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
 	price, err := r.backend.SuggestPrice(ctx)
 	return hexutil.Big(*price), err
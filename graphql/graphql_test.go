@@ -17,23 +17,58 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/extension"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/plugin/security"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/privatefixtures"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/private/engine"
 	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
 )
 
 func TestBuildSchema(t *testing.T) {
@@ -98,6 +133,67 @@ func TestGraphQLHTTPOnSamePort_GQLRequest_Unsuccessful(t *testing.T) {
 	assert.Equal(t, "404 page not found\n", string(bodyBytes))
 }
 
+// Tests that a graphQL request is handled over IPC, with the HTTP server disabled
+func TestGraphQLIPC_GQLRequest_Successful(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graphql-ipc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	endpoint := filepath.Join(dir, "geth.ipc")
+	stack, err := node.New(&node.Config{IPCPath: endpoint, GraphQLIPC: true})
+	require.NoError(t, err)
+	defer stack.Close()
+	createGQLService(t, stack, "")
+	require.NoError(t, stack.Start())
+
+	info, err := os.Stat(endpoint + ".graphql")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	client := ipcHTTPClient(endpoint + ".graphql")
+	resp, err := client.Post("http://ipc/graphql", "application/json", strings.NewReader(`{"query": "{block{number}}","variables": null}`))
+	require.NoError(t, err)
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"block":{"number":"0x0"}}}`, string(bodyBytes))
+
+	// the socket is removed once the node stopped
+	require.NoError(t, stack.Close())
+	_, err = os.Stat(endpoint + ".graphql")
+	assert.True(t, os.IsNotExist(err))
+}
+
+// Tests that GraphQL is not served over IPC unless enabled
+func TestGraphQLIPC_Disabled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graphql-ipc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	endpoint := filepath.Join(dir, "geth.ipc")
+	stack, err := node.New(&node.Config{IPCPath: endpoint})
+	require.NoError(t, err)
+	defer stack.Close()
+	createGQLService(t, stack, "")
+	require.NoError(t, stack.Start())
+
+	_, err = os.Stat(endpoint + ".graphql")
+	assert.True(t, os.IsNotExist(err))
+
+	// and not without the IPC endpoint
+	stack, err = node.New(&node.Config{GraphQLIPC: true})
+	require.NoError(t, err)
+	defer stack.Close()
+	assert.EqualError(t, newHandler(stack, nil, []string{}, []string{}), "GraphQL over IPC requires the IPC endpoint")
+}
+
+// ipcHTTPClient returns an HTTP client connecting to the unix socket.
+func ipcHTTPClient(socket string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
 func createNode(t *testing.T, gqlEnabled bool) *node.Node {
 	stack, err := node.New(&node.Config{
 		HTTPHost: "127.0.0.1",
@@ -160,7 +256,7 @@ func TestQuorumSchema(t *testing.T) {
 	// Test private transaction
 	privateTx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), arbitraryPayloadHash.Bytes())
 	privateTx.SetPrivate()
-	privateTxQuery := &Transaction{tx: privateTx}
+	privateTxQuery := &Transaction{backend: tenantBackend{}, tx: privateTx}
 	isPrivate, err := privateTxQuery.IsPrivate(context.Background())
 	if err != nil {
 		t.Fatalf("Expect no error: %v", err)
@@ -177,7 +273,7 @@ func TestQuorumSchema(t *testing.T) {
 	}
 	// Test public transaction
 	publicTx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), []byte("key"))
-	publicTxQuery := &Transaction{tx: publicTx}
+	publicTxQuery := &Transaction{backend: tenantBackend{}, tx: publicTx}
 	isPrivate, err = publicTxQuery.IsPrivate(context.Background())
 	if err != nil {
 		t.Fatalf("Expect no error: %v", err)
@@ -194,29 +290,1313 @@ func TestQuorumSchema(t *testing.T) {
 	}
 }
 
+func TestQuorumSchema_PrivacyMetadata(t *testing.T) {
+	saved := private.P
+	defer func() {
+		private.P = saved
+	}()
+	var (
+		standardHash   = common.BytesToEncryptedPayloadHash([]byte("standard"))
+		protectedHash  = common.BytesToEncryptedPayloadHash([]byte("protected"))
+		notPartyHash   = common.BytesToEncryptedPayloadHash([]byte("not a party"))
+		affectedHashes = []common.EncryptedPayloadHash{common.BytesToEncryptedPayloadHash([]byte{0x02}), common.BytesToEncryptedPayloadHash([]byte{0x01})}
+	)
+	private.P = &StubPrivateTransactionManager{
+		responses: map[common.EncryptedPayloadHash][]interface{}{
+			standardHash: {[]byte("payload"), nil},
+			protectedHash: {[]byte("payload"), nil, &engine.ExtraMetadata{
+				PrivacyFlag: engine.PrivacyFlagStateValidation,
+				ACHashes:    common.EncryptedPayloadHashes{affectedHashes[0]: {}, affectedHashes[1]: {}},
+			}},
+		},
+	}
+	privateTx := func(hash common.EncryptedPayloadHash) *Transaction {
+		tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), hash.Bytes())
+		tx.SetPrivate()
+		return &Transaction{backend: tenantBackend{}, tx: tx}
+	}
+
+	flag, err := privateTx(protectedHash).PrivacyFlag(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, flag)
+	assert.Equal(t, int32(engine.PrivacyFlagStateValidation), *flag)
+	affected, err := privateTx(protectedHash).AffectedContractTransactions(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, affected)
+	assert.Equal(t, []hexutil.Bytes{affectedHashes[1].Bytes(), affectedHashes[0].Bytes()}, *affected)
+
+	flag, err = privateTx(standardHash).PrivacyFlag(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(engine.PrivacyFlagStandardPrivate), *flag)
+	affected, err = privateTx(standardHash).AffectedContractTransactions(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, *affected)
+
+	// unknown when the node is not a party
+	flag, err = privateTx(notPartyHash).PrivacyFlag(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, flag)
+	affected, err = privateTx(notPartyHash).AffectedContractTransactions(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, affected)
+
+	// null for public transactions
+	publicTx := &Transaction{backend: tenantBackend{}, tx: types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), []byte("key"))}
+	flag, err = publicTx.PrivacyFlag(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, flag)
+	affected, err = publicTx.AffectedContractTransactions(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, affected)
+}
+
+func TestQuorumSchema_PrivateFromFor(t *testing.T) {
+	saved := private.P
+	defer func() {
+		private.P = saved
+	}()
+	var (
+		partyHash    = common.BytesToEncryptedPayloadHash([]byte("party"))
+		notPartyHash = common.BytesToEncryptedPayloadHash([]byte("not a party"))
+	)
+	private.P = &StubPrivateTransactionManager{
+		responses: map[common.EncryptedPayloadHash][]interface{}{
+			partyHash: {[]byte("payload"), nil},
+		},
+		sender:     "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=",
+		recipients: []string{"QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc=", "1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="},
+	}
+	privateTx := func(hash common.EncryptedPayloadHash) *Transaction {
+		tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), hash.Bytes())
+		tx.SetPrivate()
+		return &Transaction{backend: tenantBackend{}, tx: tx}
+	}
+
+	from, err := privateTx(partyHash).PrivateFrom(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, from)
+	assert.Equal(t, "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=", *from)
+	to, err := privateTx(partyHash).PrivateFor(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, to)
+	assert.Equal(t, []string{"QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc=", "1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="}, *to)
+
+	// null when the node is not a party
+	from, err = privateTx(notPartyHash).PrivateFrom(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, from)
+	to, err = privateTx(notPartyHash).PrivateFor(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, to)
+
+	// null for public transactions
+	publicTx := &Transaction{backend: tenantBackend{}, tx: types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), []byte("key"))}
+	from, err = publicTx.PrivateFrom(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, from)
+	to, err = publicTx.PrivateFor(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, to)
+}
+
 type StubPrivateTransactionManager struct {
 	notinuse.PrivateTransactionManager
 	responses map[common.EncryptedPayloadHash][]interface{}
+	// sender and recipients of the payloads the node is party to
+	sender     string
+	recipients []string
 }
 
 func (spm *StubPrivateTransactionManager) HasFeature(f engine.PrivateTransactionManagerFeature) bool {
 	return true
 }
 
-func (spm *StubPrivateTransactionManager) Receive(txHash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+func (spm *StubPrivateTransactionManager) Receive(ctx context.Context, txHash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
 	res := spm.responses[txHash]
+	if len(res) == 0 {
+		// not a party
+		return "", nil, nil, nil, nil
+	}
 	if err, ok := res[1].(error); ok {
 		return "", nil, nil, nil, err
 	}
 	if ret, ok := res[0].([]byte); ok {
-		return "", nil, ret, &engine.ExtraMetadata{
+		if len(res) > 2 {
+			return spm.sender, spm.recipients, ret, res[2].(*engine.ExtraMetadata), nil
+		}
+		return spm.sender, spm.recipients, ret, &engine.ExtraMetadata{
 			PrivacyFlag: engine.PrivacyFlagStandardPrivate,
 		}, nil
 	}
 	return "", nil, nil, nil, nil
 }
 
-func (spm *StubPrivateTransactionManager) ReceiveRaw(hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
-	_, sender, data, metadata, err := spm.Receive(hash)
-	return data, sender[0], metadata, err
+func (spm *StubPrivateTransactionManager) ReceiveRaw(ctx context.Context, hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+	sender, _, data, metadata, err := spm.Receive(ctx, hash)
+	return data, sender, metadata, err
+}
+
+func TestTokenBalances_TooManyTokens(t *testing.T) {
+	r := &Resolver{}
+	_, err := r.TokenBalances(context.Background(), struct {
+		Owner  common.Address
+		Tokens []common.Address
+		Block  *hexutil.Uint64
+	}{Tokens: make([]common.Address, maxTokenBalanceTokens+1)})
+	if err == nil {
+		t.Fatalf("Expect error when requesting more than %d tokens", maxTokenBalanceTokens)
+	}
+}
+
+func TestDecodeTokenSymbol(t *testing.T) {
+	// ABI encoded string "TKN"
+	encoded := common.FromHex("0x00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000003544b4e0000000000000000000000000000000000000000000000000000000000")
+	if symbol := decodeTokenSymbol(encoded); symbol == nil || *symbol != "TKN" {
+		t.Fatalf("Expect symbol TKN from string encoding, actual: %v", symbol)
+	}
+	// bytes32 encoded "MKR"
+	if symbol := decodeTokenSymbol(common.RightPadBytes([]byte("MKR"), 32)); symbol == nil || *symbol != "MKR" {
+		t.Fatalf("Expect symbol MKR from bytes32 encoding, actual: %v", symbol)
+	}
+	if symbol := decodeTokenSymbol([]byte{1, 2, 3}); symbol != nil {
+		t.Fatalf("Expect no symbol for invalid encoding, actual: %v", *symbol)
+	}
+}
+
+func TestBodyLimitHandler(t *testing.T) {
+	var served []string
+	h := newBodyLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		served = append(served, string(body))
+	}), 16)
+
+	for _, body := range []string{`{"query":"{}"}`, `{"query": "{ block { number } }"}`} {
+		for _, chunked := range []bool{false, true} {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+			if chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if len(body) <= 16 {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				continue
+			}
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"limit":16`)
+		}
+	}
+	assert.Equal(t, []string{`{"query":"{}"}`, `{"query":"{}"}`}, served)
+}
+
+// consistencyGuard fails the tokens other than "caught-up".
+type consistencyGuard struct{}
+
+func (consistencyGuard) Await(_ context.Context, token string) error {
+	if token != "caught-up" {
+		return &ethapi.NotConsistentError{Wanted: ethapi.ConsistencyPosition{Number: 2}, Current: ethapi.ConsistencyPosition{Number: 1}}
+	}
+	return nil
+}
+
+func TestConsistencyHandler(t *testing.T) {
+	h := newConsistencyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rpc.IssueConsistencyToken(r.Context(), "issued")
+		w.Write([]byte(`{"data":{}}`))
+	}), consistencyGuard{})
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{}"}`))
+		if token != "" {
+			req.Header.Set(rpc.ConsistencyTokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "caught-up"} {
+		rec := serve(token)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "issued", rec.Header().Get(rpc.ConsistencyTokenHeader))
+	}
+	rec := serve("ahead")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, rec.Header().Get(rpc.ConsistencyTokenHeader))
+	assert.Contains(t, rec.Body.String(), `"extensions":{"wanted":{"number":"0x2"`)
+}
+
+// Tests that the resolvers of a query observe a single chain head while blocks
+// are imported concurrently.
+func TestGraphQLSnapshotIsolation(t *testing.T) {
+	genesis := &core.Genesis{Config: params.AllEthashProtocolChanges}
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := core.GenerateChain(genesis.Config, genesis.MustCommit(db), ethash.NewFaker(), db, 200, nil)
+
+	stack, err := node.New(&node.Config{HTTPHost: "127.0.0.1", HTTPPort: 9394})
+	if err != nil {
+		t.Fatalf("could not create node: %v", err)
+	}
+	defer stack.Close()
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	if err := New(stack, ethBackend.APIBackend, []string{}, []string{}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, block := range blocks {
+			if _, err := ethBackend.BlockChain().InsertChain(types.Blocks{block}); err != nil {
+				t.Errorf("could not import block: %v", err)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	// "latest" is resolved again after every list of blocks
+	var fields []string
+	for i := 0; i < 8; i++ {
+		fields = append(fields, fmt.Sprintf("b%d: block { number } l%d: blocks(from: 0) { hash }", i, i))
+	}
+	query, _ := json.Marshal(map[string]string{"query": "{ " + strings.Join(fields, " ") + " pending { transactionCount } }"})
+
+	client := &http.Client{Transport: new(http.Transport)}
+	defer client.CloseIdleConnections()
+	for queries := 0; ; queries++ {
+		select {
+		case <-done:
+			if queries == 0 {
+				t.Fatal("no query ran during the import")
+			}
+			return
+		default:
+		}
+		resp, err := client.Post("http://127.0.0.1:9394/graphql", "application/json", bytes.NewReader(query))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		var result struct {
+			Data   map[string]json.RawMessage
+			Errors []interface{}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		if len(result.Errors) > 0 {
+			t.Fatalf("query failed: %v", result.Errors)
+		}
+		var heads []uint64
+		for i := 0; i < 8; i++ {
+			var block struct{ Number hexutil.Uint64 }
+			var list []struct{ Hash common.Hash }
+			if err := json.Unmarshal(result.Data[fmt.Sprintf("b%d", i)], &block); err != nil {
+				t.Fatalf("invalid block: %v", err)
+			}
+			if err := json.Unmarshal(result.Data[fmt.Sprintf("l%d", i)], &list); err != nil {
+				t.Fatalf("invalid blocks: %v", err)
+			}
+			heads = append(heads, uint64(block.Number), uint64(len(list)-1))
+		}
+		for _, head := range heads {
+			if head != heads[0] {
+				t.Fatalf("query %d observed different heads: %v", queries, heads)
+			}
+		}
+	}
+}
+
+// Tests that the blocks imported are pushed to the subscriptions over
+// WebSocket, and that the connections are closed with the node.
+func TestGraphQLSubscriptions(t *testing.T) {
+	genesis := &core.Genesis{Config: params.AllEthashProtocolChanges}
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := core.GenerateChain(genesis.Config, genesis.MustCommit(db), ethash.NewFaker(), db, 2, nil)
+
+	stack, err := node.New(&node.Config{HTTPHost: "127.0.0.1", HTTPPort: 9395})
+	require.NoError(t, err)
+	defer stack.Close()
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	require.NoError(t, err)
+	require.NoError(t, New(stack, ethBackend.APIBackend, []string{"http://allowed.example"}, []string{"localhost", "127.0.0.1"}))
+	require.NoError(t, stack.Start())
+
+	dialer := websocket.Dialer{Subprotocols: []string{subscriptionProtocol}}
+	// the origins are checked against the CORS domains
+	_, resp, err := dialer.Dial("ws://127.0.0.1:9395/graphql", http.Header{"Origin": {"http://evil.example"}})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := dialer.Dial("ws://127.0.0.1:9395/graphql", http.Header{"Origin": {"http://allowed.example"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	read := func() subscriptionMessage {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg subscriptionMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+	require.NoError(t, conn.WriteJSON(subscriptionMessage{Type: gqlConnectionInit}))
+	assert.Equal(t, gqlConnectionAck, read().Type)
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"id": "1", "type": gqlStart, "payload": map[string]string{"query": "subscription { newBlock { number hash } }"},
+	}))
+	// queries are served over the connection too
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"id": "2", "type": gqlStart, "payload": map[string]string{"query": "{ block { number } }"},
+	}))
+	msg := read()
+	assert.Equal(t, subscriptionMessage{ID: "2", Type: gqlData, Payload: json.RawMessage(`{"data":{"block":{"number":"0x0"}}}`)}, msg)
+	assert.Equal(t, subscriptionMessage{ID: "2", Type: gqlComplete}, read())
+
+	for _, block := range blocks {
+		_, err = ethBackend.BlockChain().InsertChain(types.Blocks{block})
+		require.NoError(t, err)
+		msg = read()
+		assert.Equal(t, "1", msg.ID)
+		assert.Equal(t, gqlData, msg.Type)
+		assert.JSONEq(t, fmt.Sprintf(`{"data":{"newBlock":{"number":"%#x","hash":"%s"}}}`, block.NumberU64(), block.Hash().Hex()), string(msg.Payload))
+	}
+
+	require.NoError(t, stack.Close())
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	require.Error(t, err)
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		t.Fatal("connection not closed with the node")
+	}
+}
+
+// Quorum
+// Benchmarks the queries of the private transactions of a synthesized chain,
+// served by a node opening the fixture as its data directory.
+func BenchmarkGraphQLPrivateFixture(b *testing.B) {
+	dir, err := ioutil.TempDir("", "graphql-fixture-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := privatefixtures.DefaultConfig
+	cfg.PrivacyFlags = []engine.PrivacyFlagType{engine.PrivacyFlagStandardPrivate, engine.PrivacyFlagPartyProtection}
+	fixture, err := privatefixtures.Generate(dir, cfg)
+	if err != nil {
+		b.Fatalf("could not generate the fixture: %v", err)
+	}
+	defer fixture.InstallPTM()()
+
+	stack, err := node.New(&node.Config{Name: "geth", DataDir: dir, HTTPHost: "127.0.0.1", HTTPPort: 9395})
+	if err != nil {
+		b.Fatalf("could not create node: %v", err)
+	}
+	defer stack.Close()
+	config := &eth.Config{Genesis: fixture.Genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	if err != nil {
+		b.Fatalf("could not create eth backend: %v", err)
+	}
+	if head := ethBackend.BlockChain().CurrentBlock().NumberU64(); head != uint64(cfg.Blocks) {
+		b.Fatalf("fixture not opened: head %d, want %d", head, cfg.Blocks)
+	}
+	if err := New(stack, ethBackend.APIBackend, []string{}, []string{}); err != nil {
+		b.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		b.Fatalf("could not start node: %v", err)
+	}
+
+	client := &http.Client{Transport: new(http.Transport)}
+	defer client.CloseIdleConnections()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		query, _ := json.Marshal(map[string]string{
+			"query": fmt.Sprintf("{ block(number: %d) { transactions { isPrivate privateInputData status } } }", i%cfg.Blocks+1),
+		})
+		resp, err := client.Post("http://127.0.0.1:9395/graphql", "application/json", bytes.NewReader(query))
+		if err != nil {
+			b.Fatalf("request failed: %v", err)
+		}
+		var result struct {
+			Errors []interface{}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil || len(result.Errors) > 0 {
+			b.Fatalf("query failed: %v %v", err, result.Errors)
+		}
+	}
+}
+
+func TestGraphQLAllowList(t *testing.T) {
+	dir := t.TempDir()
+	document := "# the balance of an account\nquery balance($address: Address!, $block: Long) {\n  block(number: $block) { account(address: $address) { balance } }\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "balance.graphql"), []byte(document), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := newAllowList(dir)
+	if err != nil {
+		t.Fatalf("could not load the allow-list: %v", err)
+	}
+	var served string
+	handler := newAllowListHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req allowListRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		served = req.Query
+	}), list)
+	serve := func(body string) (int, string) {
+		served = ""
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		handler.ServeHTTP(rec, req)
+		var result struct {
+			Errors []struct {
+				Extensions struct{ Code string }
+			}
+		}
+		json.NewDecoder(rec.Body).Decode(&result)
+		if len(result.Errors) > 0 {
+			return rec.Code, result.Errors[0].Extensions.Code
+		}
+		return rec.Code, ""
+	}
+	hash := sha256.Sum256([]byte(document))
+	vars := `"variables":{"address":"0x0000000000000000000000000000000000000001"}`
+
+	// by name, hash and normalized document
+	for _, body := range []string{
+		`{"extensions":{"queryName":"balance"},` + vars + `}`,
+		`{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hex.EncodeToString(hash[:]) + `"}},` + vars + `}`,
+		`{"query":"query balance($address: Address!, $block: Long) { block(number: $block) { account(address: $address) { balance } } }",` + vars + `}`,
+	} {
+		status, _ := serve(body)
+		assert.Equal(t, http.StatusOK, status, body)
+		assert.Equal(t, document, served, body)
+	}
+
+	misses := metrics.GetOrRegisterCounter("graphql/allowlist/misses/10.0.0.1", nil)
+	before := misses.Count()
+	status, code := serve(`{"query":"{ block { number } }"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, queryNotAllowedCode, code)
+	assert.Empty(t, served)
+	if metrics.Enabled {
+		assert.Equal(t, before+1, misses.Count())
+	}
+	status, code = serve(`{"extensions":{"queryName":"unknown"}}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, queryNotAllowedCode, code)
+
+	for _, body := range []string{
+		`{"extensions":{"queryName":"balance"}}`,
+		`{"extensions":{"queryName":"balance"},"variables":{"address":1}}`,
+		`{"extensions":{"queryName":"balance"},"variables":{"address":"0x01","block":"latest","other":1}}`,
+		`{"extensions":{"queryName":"balance"},"variables":{"address":"0x01","block":1.5}}`,
+	} {
+		status, code := serve(body)
+		assert.Equal(t, http.StatusBadRequest, status, body)
+		assert.Equal(t, invalidVariablesCode, code, body)
+	}
+
+	// an invalid document keeps the previous ones
+	if err := ioutil.WriteFile(filepath.Join(dir, "invalid.graphql"), []byte("query a { block { number } } query b { block { hash } }"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, list.reload())
+	status, _ = serve(`{"extensions":{"queryName":"balance"},` + vars + `}`)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestGraphQLRedaction(t *testing.T) {
+	s, err := graphql.ParseSchema(schema, &Resolver{})
+	if err != nil {
+		t.Fatalf("could not parse the schema: %v", err)
+	}
+	types := newSchemaTypes(s)
+	fields := map[string]bool{"Transaction.privateInputData": true, "Log.data": true}
+	query := `query tx($hash: Bytes32!) {
+  transaction(hash: $hash) { hash payload: privateInputData ...receipt }
+  block { transactions { ... on Transaction { privateInputData } } }
+}
+fragment receipt on Transaction { logs { data @include(if: true) index } }`
+	data := json.RawMessage(`{"transaction":{"hash":"0x01","payload":"0x0102","logs":[{"data":"0x03","index":0}]},"block":{"transactions":[{"privateInputData":"0x04"},{"privateInputData":null}]}}`)
+
+	redacted, coordinates, err := redactData(types, query, "", data, fields)
+	if err != nil {
+		t.Fatalf("could not redact the data: %v", err)
+	}
+	assert.Equal(t, []string{"Log.data", "Transaction.privateInputData"}, coordinates)
+	assert.JSONEq(t, `{"transaction":{"hash":"0x01","payload":null,"logs":[{"data":null,"index":0}]},"block":{"transactions":[{"privateInputData":null},{"privateInputData":null}]}}`, string(redacted))
+
+	// fields of other types with the same name are served
+	data = json.RawMessage(`{"block":{"logs":[{"data":"0x03"}]}}`)
+	redacted, coordinates, err = redactData(types, `{ block { logs(filter: {}) { data } } }`, "", data, map[string]bool{"Transaction.data": true})
+	assert.NoError(t, err)
+	assert.Empty(t, coordinates)
+	assert.Equal(t, data, redacted)
+
+	_, _, err = redactData(types, `query a { block { number } }`, "b", json.RawMessage(`{}`), fields)
+	assert.Error(t, err)
+}
+
+// consensusBackend serves the consensus algorithm only, any lookup in the
+// chain panicking.
+type consensusBackend struct {
+	ethapi.Backend
+	algorithm string
+}
+
+func (b consensusBackend) ConsensusAlgorithm() string { return b.algorithm }
+
+func TestOmmers_ConsensusEngines(t *testing.T) {
+	number := rpc.BlockNumberOrHashWithNumber(1)
+	for _, algorithm := range []string{"raft", "istanbul", "clique"} {
+		block := &Block{backend: consensusBackend{algorithm: algorithm}, numberOrHash: &number}
+		assert.Equal(t, algorithm, block.ConsensusEngine())
+
+		count, err := block.OmmerCount(context.Background())
+		assert.NoError(t, err, algorithm)
+		assert.Equal(t, int32(0), *count, algorithm)
+		ommers, err := block.Ommers(context.Background())
+		assert.NoError(t, err, algorithm)
+		assert.NotNil(t, ommers, algorithm)
+		assert.Empty(t, *ommers, algorithm)
+		ommer, err := block.OmmerAt(context.Background(), struct{ Index int32 }{0})
+		assert.NoError(t, err, algorithm)
+		assert.Nil(t, ommer, algorithm)
+	}
+
+	uncle := &types.Header{Number: big.NewInt(0), Extra: []byte("uncle")}
+	block := &Block{
+		backend: consensusBackend{algorithm: "ethash"},
+		block:   types.NewBlock(&types.Header{Number: big.NewInt(1)}, nil, []*types.Header{uncle}, nil, new(trie.Trie)),
+	}
+	assert.Equal(t, "ethash", block.ConsensusEngine())
+	count, err := block.OmmerCount(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), *count)
+	ommers, err := block.Ommers(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, *ommers, 1)
+	ommer, err := block.OmmerAt(context.Background(), struct{ Index int32 }{0})
+	assert.NoError(t, err)
+	assert.Equal(t, uncle.Hash(), ommer.header.Hash())
+}
+
+func TestBlock_TransactionFilter(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	var (
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{0x01}
+		txs       []*types.Transaction
+	)
+	for i, private := range []bool{false, true, false, true} {
+		tx := types.NewTransaction(uint64(i), recipient, big.NewInt(0), 21000, big.NewInt(0), nil)
+		if i == 3 {
+			tx = types.NewContractCreation(uint64(i), big.NewInt(0), 21000, big.NewInt(0), nil)
+		}
+		var signer types.Signer = types.HomesteadSigner{}
+		if private {
+			tx.SetPrivate()
+			signer = types.QuorumPrivateTxSigner{}
+		}
+		// the first one sent by another account
+		if i > 0 {
+			tx, _ = types.SignTx(tx, signer, key)
+		} else {
+			tx, _ = types.SignTx(tx, signer, other)
+		}
+		txs = append(txs, tx)
+	}
+	block := &Block{block: types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, nil, new(trie.Trie))}
+	indexes := func(filter *TransactionFilter) []uint64 {
+		ret, err := block.Transactions(context.Background(), struct{ Filter *TransactionFilter }{filter})
+		require.NoError(t, err)
+		var indexes []uint64
+		for _, tx := range *ret {
+			indexes = append(indexes, tx.index)
+		}
+		return indexes
+	}
+	isPrivate, isPublic := true, false
+
+	assert.Equal(t, []uint64{0, 1, 2, 3}, indexes(nil))
+	assert.Equal(t, []uint64{1, 3}, indexes(&TransactionFilter{IsPrivate: &isPrivate}))
+	assert.Equal(t, []uint64{0, 2}, indexes(&TransactionFilter{IsPrivate: &isPublic}))
+	assert.Equal(t, []uint64{1, 2, 3}, indexes(&TransactionFilter{From: &sender}))
+	assert.Equal(t, []uint64{0, 1, 2}, indexes(&TransactionFilter{To: &recipient}))
+	assert.Equal(t, []uint64{1}, indexes(&TransactionFilter{IsPrivate: &isPrivate, From: &sender, To: &recipient}))
+	assert.Empty(t, indexes(&TransactionFilter{From: &recipient}))
+}
+
+func TestGraphQLRequestID(t *testing.T) {
+	var served string
+	handler := newRequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = log.RequestID(r.Context())
+		if strings.Contains(served, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"failed"}],"extensions":{"redacted":["Log.data"]}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"block":null}}`))
+	}))
+	serve := func(header, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set(rpc.RequestIDHeader, header)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("dapp-1", `{"query":"{ block { number } }"}`)
+	assert.Equal(t, "dapp-1", served)
+	assert.Equal(t, "dapp-1", rec.Header().Get(rpc.RequestIDHeader))
+	assert.JSONEq(t, `{"data":{"block":null},"extensions":{"requestId":"dapp-1"}}`, rec.Body.String())
+
+	rec = serve("", `{"query":"{ block { number } }","extensions":{"requestId":"dapp-fail"}}`)
+	assert.Equal(t, "dapp-fail", served)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"failed"}],"extensions":{"redacted":["Log.data"],"requestId":"dapp-fail"}}`, rec.Body.String())
+
+	// generated IDs are only echoed in the header of the successful responses
+	rec = serve("", `{"query":"{ block { number } }"}`)
+	assert.Len(t, served, 32)
+	assert.Equal(t, served, rec.Header().Get(rpc.RequestIDHeader))
+	assert.Equal(t, `{"data":{"block":null}}`, rec.Body.String())
+}
+
+// recordingAccessLogger samples every request, logging the variable addr.
+type recordingAccessLogger struct {
+	entries []*rpc.AccessLogEntry
+}
+
+func (l *recordingAccessLogger) Sample() bool { return true }
+
+func (l *recordingAccessLogger) LogsParams(name string) bool { return name == "addr" }
+
+func (l *recordingAccessLogger) LogAccess(ctx context.Context, e *rpc.AccessLogEntry) {
+	l.entries = append(l.entries, e)
+}
+
+func TestGraphQLRequestLog(t *testing.T) {
+	logger := new(recordingAccessLogger)
+	handler := newRequestLogHandler(newRequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("X-Test"), "fail") {
+			w.Write([]byte(`{"errors":[{"message":"failed"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"block":null}}`))
+	})), logger)
+	serve := func(header, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("X-Test", header)
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("", `{"query":"query Balance($addr: Address!, $block: Long) { account(address: $addr) { balance } }","variables":{"addr":"0x01","block":"0x2"}}`)
+	assert.Equal(t, `{"data":{"block":null}}`, rec.Body.String())
+	rec = serve("fail", `{"query":"{ block { number } } mutation Send { sendRawTransaction(data: \"0x\") }","operationName":"Send"}`)
+	assert.Contains(t, rec.Body.String(), `"errors":[{"message":"failed"}]`)
+
+	require.Len(t, logger.entries, 2)
+	e := logger.entries[0]
+	assert.Equal(t, "graphql", e.Protocol)
+	assert.Equal(t, []string{"Balance"}, e.Operations)
+	assert.Equal(t, map[string]json.RawMessage{"addr": json.RawMessage(`"0x01"`)}, e.Params)
+	assert.Equal(t, len(`{"data":{"block":null}}`), e.Size)
+	assert.Empty(t, e.ErrorClass)
+	assert.Len(t, e.RequestID, 32)
+	e = logger.entries[1]
+	assert.Equal(t, []string{"Send"}, e.Operations)
+	assert.Equal(t, "graphql", e.ErrorClass)
+
+	assert.Equal(t, []string{"query"}, operationNames("{ block { number } }", ""))
+	assert.Equal(t, []string{"query", "Send"}, operationNames("query { block { number } } mutation Send @live { x }", ""))
+}
+
+func TestBlock_PrivateStateRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graphql-fixture-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cfg := privatefixtures.DefaultConfig
+	cfg.Blocks, cfg.TxsPerBlock, cfg.PrivateRatio = 2, 4, 1
+	fixture, err := privatefixtures.Generate(dir, cfg)
+	require.NoError(t, err)
+	defer fixture.InstallPTM()()
+
+	stack, err := node.New(&node.Config{Name: "geth", DataDir: dir})
+	require.NoError(t, err)
+	defer stack.Close()
+	config := &eth.Config{Genesis: fixture.Genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	require.NoError(t, err)
+	s, err := graphql.ParseSchema(schema, &Resolver{backend: ethBackend.APIBackend})
+	require.NoError(t, err)
+	api := ethapi.NewPublicBlockChainAPI(ethBackend.APIBackend)
+
+	privateStateRoot := func(number int) (*common.Hash, []*gqlerrors.QueryError) {
+		res := s.Exec(context.Background(), fmt.Sprintf("{ block(number: %d) { stateRoot privateStateRoot } }", number), "", nil)
+		var data struct {
+			Block struct {
+				StateRoot        common.Hash
+				PrivateStateRoot *common.Hash
+			}
+		}
+		require.NoError(t, json.Unmarshal(res.Data, &data))
+		return data.Block.PrivateStateRoot, res.Errors
+	}
+	for number := 1; number <= cfg.Blocks; number++ {
+		root, errs := privateStateRoot(number)
+		require.Empty(t, errs)
+		require.NotNil(t, root)
+		assert.NotEqual(t, common.Hash{}, *root)
+		assert.NotEqual(t, types.EmptyRootHash, *root, "private contracts created")
+
+		again, _ := privateStateRoot(number)
+		assert.Equal(t, root, again)
+		rpcRoot, err := api.GetPrivateStateRoot(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		require.NoError(t, err)
+		assert.Equal(t, *root, rpcRoot)
+	}
+	first, _ := privateStateRoot(1)
+	second, _ := privateStateRoot(2)
+	assert.NotEqual(t, first, second)
+
+	// the node has no private state for the genesis
+	root, errs := privateStateRoot(0)
+	assert.Nil(t, root)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Message, "private state root of block 0 is unavailable")
+	_, err = api.GetPrivateStateRoot(context.Background(), rpc.BlockNumberOrHashWithNumber(0))
+	assert.Error(t, err)
+	_, err = api.GetPrivateStateRoot(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(cfg.Blocks+1)))
+	assert.Error(t, err)
+}
+
+// stubContractExtensions is an extension service holding the extensions.
+type stubContractExtensions []extension.ExtensionContractStatus
+
+func (s stubContractExtensions) ContractExtensions(context.Context) ([]extension.ExtensionContractStatus, error) {
+	return s, nil
+}
+
+func TestGraphQLContractExtensions(t *testing.T) {
+	query := `query extensions($active: Boolean) {
+  contractExtensions(active: $active) { managementContract contract initiator recipient recipientPtmKey status creationBlock }
+}`
+
+	// not enabled on the node
+	stack := createNode(t, true)
+	defer stack.Close()
+	require.NoError(t, stack.Start())
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	require.NoError(t, err)
+	resp, err := http.Post("http://127.0.0.1:9393/graphql", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	reply, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"contractExtensions":[]}}`, string(reply))
+
+	// enabled
+	s, err := graphql.ParseSchema(schema, &Resolver{extensions: stubContractExtensions{
+		{
+			ExtensionContract: extension.ExtensionContract{
+				ManagementContractAddress: common.HexToAddress("0x1"),
+				ContractExtended:          common.HexToAddress("0x2"),
+				Initiator:                 common.HexToAddress("0x3"),
+				Recipient:                 common.HexToAddress("0x4"),
+				RecipientPtmKey:           "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=",
+				CreationBlock:             12,
+			},
+			Status: extension.ExtensionInProgress,
+		},
+		{
+			ExtensionContract: extension.ExtensionContract{ManagementContractAddress: common.HexToAddress("0x5")},
+			Status:            extension.ExtensionCompleted,
+		},
+	}})
+	require.NoError(t, err)
+	active := `{"managementContract":"0x0000000000000000000000000000000000000001","contract":"0x0000000000000000000000000000000000000002","initiator":"0x0000000000000000000000000000000000000003","recipient":"0x0000000000000000000000000000000000000004","recipientPtmKey":"BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=","status":"ACTIVE","creationBlock":"0xc"}`
+	done := `{"managementContract":"0x0000000000000000000000000000000000000005","contract":"0x0000000000000000000000000000000000000000","initiator":"0x0000000000000000000000000000000000000000","recipient":"0x0000000000000000000000000000000000000000","recipientPtmKey":"","status":"DONE","creationBlock":null}`
+	for _, tt := range []struct {
+		variables map[string]interface{}
+		expected  string
+	}{
+		{nil, `{"contractExtensions":[` + active + `,` + done + `]}`},
+		{map[string]interface{}{"active": true}, `{"contractExtensions":[` + active + `]}`},
+		{map[string]interface{}{"active": false}, `{"contractExtensions":[` + done + `]}`},
+	} {
+		res := s.Exec(context.Background(), query, "", tt.variables)
+		require.Empty(t, res.Errors)
+		assert.JSONEq(t, tt.expected, string(res.Data))
+	}
+}
+
+// storedBodyBackend serves a block from its stored header and body, resolving
+// the whole block panicking.
+type storedBodyBackend struct {
+	ethapi.Backend
+	db     ethdb.Database
+	header *types.Header
+}
+
+func (b storedBodyBackend) ChainDb() ethdb.Database { return b.db }
+
+func (b storedBodyBackend) HeaderByNumberOrHash(context.Context, rpc.BlockNumberOrHash) (*types.Header, error) {
+	return b.header, nil
+}
+
+func TestBlock_TransactionsRange(t *testing.T) {
+	var txs []*types.Transaction
+	for i := 0; i < 5; i++ {
+		txs = append(txs, types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), nil))
+	}
+	stored := types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, nil, new(trie.Trie))
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteBlock(db, stored)
+	number := rpc.BlockNumberOrHashWithNumber(1)
+	backend := storedBodyBackend{db: db, header: stored.Header()}
+
+	blocks := map[string]*Block{
+		"stored":   {backend: backend, numberOrHash: &number},
+		"resolved": {backend: backend, block: stored},
+	}
+	for name, block := range blocks {
+		count, err := block.TransactionCount(context.Background())
+		require.NoError(t, err, name)
+		assert.Equal(t, int32(5), *count, name)
+
+		indexes := func(ctx context.Context, from, count int32) []uint64 {
+			ret, err := block.TransactionsRange(ctx, struct{ From, Count int32 }{from, count})
+			require.NoError(t, err, name)
+			require.NotNil(t, ret, name)
+			indexes := []uint64{}
+			for _, tx := range *ret {
+				assert.Equal(t, txs[tx.index].Hash(), tx.hash, name)
+				indexes = append(indexes, tx.index)
+			}
+			return indexes
+		}
+		assert.Equal(t, []uint64{1, 2}, indexes(context.Background(), 1, 2), name)
+		assert.Equal(t, []uint64{3, 4}, indexes(context.Background(), 3, 10), name)
+		assert.Empty(t, indexes(context.Background(), 5, 1), name)
+		assert.Empty(t, indexes(context.Background(), -1, 1), name)
+		assert.Empty(t, indexes(context.Background(), 0, 0), name)
+		// the count is capped by the handler
+		limited := context.WithValue(context.Background(), maxTransactionsRangeKey{}, 2)
+		assert.Equal(t, []uint64{0, 1}, indexes(limited, 0, 5), name)
+
+		tx, err := block.TransactionAt(context.Background(), struct{ Index int32 }{4})
+		require.NoError(t, err, name)
+		assert.Equal(t, txs[4].Hash(), tx.hash, name)
+		tx, err = block.TransactionAt(context.Background(), struct{ Index int32 }{5})
+		assert.NoError(t, err, name)
+		assert.Nil(t, tx, name)
+	}
+}
+
+// tenantBackend serves the transactions of a database and their receipts, the
+// requests with a token being made for a tenant if multitenancy is enabled.
+type tenantBackend struct {
+	ethapi.Backend
+	db           ethdb.Database
+	multitenancy bool
+}
+
+func (b tenantBackend) SupportsMultitenancy(ctx context.Context) (*proto.PreAuthenticatedAuthenticationToken, bool) {
+	authToken, ok := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	return authToken, ok && b.multitenancy
+}
+
+func (b tenantBackend) ChainDb() ethdb.Database { return b.db }
+
+func (b tenantBackend) HeaderByNumberOrHash(_ context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	hash, _ := blockNrOrHash.Hash()
+	return rawdb.ReadHeader(b.db, hash, *rawdb.ReadHeaderNumber(b.db, hash)), nil
+}
+
+func (b tenantBackend) GetReceipts(context.Context, common.Hash) (types.Receipts, error) {
+	return types.Receipts{{Status: types.ReceiptStatusSuccessful}, {Status: types.ReceiptStatusSuccessful}}, nil
+}
+
+// stubTokenValidator authenticates the tokens it knows.
+type stubTokenValidator map[string]*proto.PreAuthenticatedAuthenticationToken
+
+func (v stubTokenValidator) Authenticate(_ context.Context, token string) (*proto.PreAuthenticatedAuthenticationToken, error) {
+	if authToken, ok := v[token]; ok {
+		return authToken, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+func (v stubTokenValidator) IsEnabled(context.Context) (bool, error) { return true, nil }
+
+// partiesPTM is a transaction manager managing the parties of the payloads.
+type partiesPTM struct {
+	notinuse.PrivateTransactionManager
+	parties map[common.EncryptedPayloadHash][]string
+}
+
+func (p *partiesPTM) Receive(_ context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	parties, ok := p.parties[hash]
+	if !ok {
+		return "", nil, nil, nil, nil
+	}
+	return parties[0], parties, []byte("payload"), &engine.ExtraMetadata{PrivacyFlag: engine.PrivacyFlagStandardPrivate}, nil
+}
+
+// tenantToken returns the token of a tenant owning the transaction manager key.
+func tenantToken(t *testing.T, tmKey string) *proto.PreAuthenticatedAuthenticationToken {
+	expiredAt, err := ptypes.TimestampProto(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return &proto.PreAuthenticatedAuthenticationToken{
+		ExpiredAt:   expiredAt,
+		Authorities: []*proto.GrantedAuthority{{Raw: "private://0x0/_/contracts?owned.eoa=0x0&from.tm=" + tmKey}},
+	}
+}
+
+func TestAuthenticationHandler_PrivateData(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	payload := common.BytesToEncryptedPayloadHash([]byte("tenant A"))
+	private.P = &partiesPTM{parties: map[common.EncryptedPayloadHash][]string{payload: {"A"}}}
+
+	privateTx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), payload.Bytes())
+	privateTx.SetPrivate()
+	publicTx := types.NewTransaction(1, common.Address{0x01}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{privateTx, publicTx}, nil, nil, new(trie.Trie))
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteCanonicalHash(db, block.Hash(), 1)
+	rawdb.WriteTxLookupEntries(db, block)
+	backend := tenantBackend{db: db, multitenancy: true}
+
+	s, err := graphql.ParseSchema(schema, &Resolver{backend: backend})
+	require.NoError(t, err)
+	validator := stubTokenValidator{"token-a": tenantToken(t, "A"), "token-b": tenantToken(t, "B")}
+	server := httptest.NewServer(newAuthenticationHandler(&relay.Handler{Schema: s}, func() (security.AuthenticationManager, error) {
+		return validator, nil
+	}))
+	defer server.Close()
+
+	query := func(token string, hash common.Hash) (int, map[string]interface{}) {
+		body, _ := json.Marshal(map[string]string{"query": fmt.Sprintf(`{ transaction(hash: "%s") { privateInputData status } }`, hash.Hex())})
+		req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set(rpc.HttpAuthorizationHeader, token)
+		}
+		resp := doHTTPRequest(t, req)
+		defer resp.Body.Close()
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return resp.StatusCode, result
+	}
+	errorCodes := func(result map[string]interface{}) []string {
+		errs, _ := result["errors"].([]interface{})
+		var codes []string
+		for _, e := range errs {
+			extensions, _ := e.(map[string]interface{})["extensions"].(map[string]interface{})
+			code, _ := extensions["code"].(string)
+			codes = append(codes, code)
+		}
+		return codes
+	}
+
+	// party to the private transaction
+	status, result := query("token-a", privateTx.Hash())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result["errors"])
+	assert.Equal(t, map[string]interface{}{"privateInputData": hexutil.Encode([]byte("payload")), "status": "0x1"}, result["data"].(map[string]interface{})["transaction"])
+
+	// not a party, the public transactions being readable
+	status, result = query("token-b", privateTx.Hash())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{notAuthorizedCode, notAuthorizedCode}, errorCodes(result))
+	status, result = query("token-b", publicTx.Hash())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result["errors"])
+	assert.Equal(t, map[string]interface{}{"privateInputData": "0x", "status": "0x1"}, result["data"].(map[string]interface{})["transaction"])
+
+	// missing and invalid tokens
+	status, result = query("", privateTx.Hash())
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, []string{unauthenticatedCode}, errorCodes(result))
+	status, result = query("token-c", privateTx.Hash())
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, []string{unauthenticatedCode}, errorCodes(result))
+
+	// the logs of the private transactions are dropped
+	logs := []*Log{
+		{backend: backend, transaction: &Transaction{backend: backend, hash: privateTx.Hash()}, log: &types.Log{TxHash: privateTx.Hash()}},
+		{backend: backend, transaction: &Transaction{backend: backend, hash: publicTx.Hash()}, log: &types.Log{TxHash: publicTx.Hash()}},
+	}
+	for token, expected := range map[string]int{"token-a": 2, "token-b": 1} {
+		ctx := context.WithValue(context.Background(), rpc.CtxPreauthenticatedToken, validator[token])
+		filtered, err := filterAuthorizedLogs(ctx, logs)
+		require.NoError(t, err)
+		assert.Len(t, filtered, expected, token)
+	}
+}
+
+// creationBackend serves the receipts of contract creations.
+type creationBackend struct {
+	tenantBackend
+	contract common.Address
+}
+
+func (b creationBackend) GetReceipts(context.Context, common.Hash) (types.Receipts, error) {
+	return types.Receipts{{Status: types.ReceiptStatusSuccessful, ContractAddress: b.contract}}, nil
+}
+
+func TestTransaction_CreatedContract_whenMultitenancy(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	payload := common.BytesToEncryptedPayloadHash([]byte("tenant A"))
+	private.P = &partiesPTM{parties: map[common.EncryptedPayloadHash][]string{payload: {"A"}}}
+
+	tx := types.NewContractCreation(0, big.NewInt(0), 100000, big.NewInt(0), payload.Bytes())
+	tx.SetPrivate()
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil, new(trie.Trie))
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteCanonicalHash(db, block.Hash(), 1)
+	rawdb.WriteTxLookupEntries(db, block)
+	contract := common.Address{0x02}
+	backend := creationBackend{tenantBackend: tenantBackend{db: db, multitenancy: true}, contract: contract}
+
+	for tmKey, party := range map[string]bool{"A": true, "B": false} {
+		ctx := context.WithValue(context.Background(), rpc.CtxPreauthenticatedToken, tenantToken(t, tmKey))
+		account, err := (&Transaction{backend: backend, hash: tx.Hash()}).CreatedContract(ctx, BlockNumberArgs{})
+		require.NoError(t, err, tmKey)
+		if !party {
+			assert.Nil(t, account, tmKey)
+			continue
+		}
+		require.NotNil(t, account, tmKey)
+		assert.Equal(t, contract, account.address, tmKey)
+	}
+}
+
+// addressTxBackend serves the transactions of a chain indexed by account.
+type addressTxBackend struct {
+	ethapi.Backend
+	indexer *core.AddressTxIndexer
+}
+
+func (b addressTxBackend) AddressTxIndexer() *core.AddressTxIndexer { return b.indexer }
+
+func TestAccount_Transactions(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{0x01}
+		gspec     = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{sender: {Balance: big.NewInt(1000000000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	send := func(block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), recipient, common.Big1, 21000, nil, nil), signer, key)
+		require.NoError(t, err)
+		block.AddTx(tx)
+	}
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, block *core.BlockGen) { send(block) })
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+
+	indexer := core.NewAddressTxIndexer(chain)
+	indexer.Start()
+	defer indexer.Stop()
+	indexed := func(head *types.Block) {
+		require.Eventually(t, func() bool {
+			tip := rawdb.ReadAddressTxTip(db)
+			return tip != nil && tip.Hash == head.Hash()
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	indexed(blocks[2])
+
+	type args = struct {
+		First     *int32
+		After     *string
+		Direction *string
+	}
+	one, received := int32(1), "RECEIVED"
+	account := &Account{backend: addressTxBackend{indexer: indexer}, address: sender}
+	hashes := func(page *AccountTransactionPage) []common.Hash {
+		ret := []common.Hash{}
+		for _, tx := range page.Transactions() {
+			ret = append(ret, tx.hash)
+		}
+		return ret
+	}
+
+	page, err := account.Transactions(context.Background(), args{First: &one})
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{blocks[2].Transactions()[0].Hash()}, hashes(page))
+	require.NotNil(t, page.Cursor())
+	cursor := *page.Cursor()
+	page, err = account.Transactions(context.Background(), args{After: &cursor})
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{blocks[1].Transactions()[0].Hash(), blocks[0].Transactions()[0].Hash()}, hashes(page))
+	assert.Nil(t, page.Cursor(), "last page")
+
+	page, err = account.Transactions(context.Background(), args{Direction: &received})
+	require.NoError(t, err)
+	assert.Empty(t, hashes(page), "nothing received")
+	_, err = account.Transactions(context.Background(), args{After: &cursor, Direction: &received})
+	assert.Equal(t, errAccountCursorMismatch, err)
+	invalid := "not a cursor"
+	_, err = account.Transactions(context.Background(), args{After: &invalid})
+	assert.Equal(t, errInvalidAccountCursor, err)
+	zero := int32(0)
+	_, err = account.Transactions(context.Background(), args{First: &zero})
+	assert.Error(t, err)
+
+	// a cursor into a block reorged away resumes before its position
+	fork, _ := core.GenerateChain(gspec.Config, blocks[1], ethash.NewFaker(), db, 2, func(i int, block *core.BlockGen) {
+		block.SetExtra([]byte("fork"))
+	})
+	_, err = chain.InsertChain(fork)
+	require.NoError(t, err)
+	indexed(fork[1])
+	page, err = account.Transactions(context.Background(), args{After: &cursor})
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{blocks[1].Transactions()[0].Hash(), blocks[0].Transactions()[0].Hash()}, hashes(page))
+	page, err = account.Transactions(context.Background(), args{})
+	require.NoError(t, err)
+	assert.Len(t, hashes(page), 2, "the replaced transaction is dropped")
+
+	_, err = (&Account{backend: tenantBackend{db: db}, address: sender}).Transactions(context.Background(), args{})
+	assert.Equal(t, errAddressTxIndexDisabled, err)
+}
+
+// sendingPTM distributes the payloads stored with storeraw, rejecting the
+// recipients which are not keys.
+type sendingPTM struct {
+	StubPrivateTransactionManager
+	sentTo [][]string
+}
+
+func (s *sendingPTM) SendSignedTx(ctx context.Context, data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
+	for _, key := range to {
+		if raw, err := base64.StdEncoding.DecodeString(key); err != nil || len(raw) != 32 {
+			return "", nil, nil, fmt.Errorf("invalid recipient key %s", key)
+		}
+	}
+	s.sentTo = append(s.sentTo, to)
+	return "", to, data.Bytes(), nil
+}
+
+func TestGraphQLSendRawPrivateTransaction(t *testing.T) {
+	saved := private.P
+	defer func() { private.P = saved }()
+	payloadHash := common.BytesToEncryptedPayloadHash([]byte("stored raw"))
+	ptm := &sendingPTM{StubPrivateTransactionManager: StubPrivateTransactionManager{
+		responses: map[common.EncryptedPayloadHash][]interface{}{payloadHash: {[]byte("private payload"), nil}},
+	}}
+	private.P = ptm
+
+	key, _ := crypto.GenerateKey()
+	genesis := &core.Genesis{
+		Config: params.AllEthashProtocolChanges,
+		Alloc:  core.GenesisAlloc{crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)}},
+	}
+	stack, err := node.New(&node.Config{})
+	require.NoError(t, err)
+	defer stack.Close()
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, config)
+	require.NoError(t, err)
+	s, err := graphql.ParseSchema(schema, &Resolver{backend: ethBackend.APIBackend})
+	require.NoError(t, err)
+	server := httptest.NewServer(&relay.Handler{Schema: s})
+	defer server.Close()
+	rpcAPI := ethapi.NewPublicTransactionPoolAPI(ethBackend.APIBackend, nil)
+
+	sign := func(nonce uint64) (*types.Transaction, hexutil.Bytes) {
+		tx := types.NewTransaction(nonce, common.HexToAddress("0x1"), big.NewInt(0), 100000, big.NewInt(params.GWei), payloadHash.Bytes())
+		tx.SetPrivate()
+		signed, err := types.SignTx(tx, types.QuorumPrivateTxSigner{}, key)
+		require.NoError(t, err)
+		raw, err := rlp.EncodeToBytes(signed)
+		require.NoError(t, err)
+		return signed, raw
+	}
+	send := func(raw hexutil.Bytes, privateFor []string, privacyFlag *int) (common.Hash, []string) {
+		query, _ := json.Marshal(map[string]interface{}{
+			"query":     `mutation send($data: Bytes!, $privateFor: [String!]!, $privacyFlag: Int) { sendRawPrivateTransaction(data: $data, privateFor: $privateFor, privacyFlag: $privacyFlag) }`,
+			"variables": map[string]interface{}{"data": raw, "privateFor": privateFor, "privacyFlag": privacyFlag},
+		})
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(query))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var result struct {
+			Data *struct {
+				SendRawPrivateTransaction common.Hash
+			}
+			Errors []struct{ Message string }
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		var messages []string
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		if result.Data == nil {
+			return common.Hash{}, messages
+		}
+		return result.Data.SendRawPrivateTransaction, messages
+	}
+	recipient := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	tx, raw := sign(0)
+	hash, errs := send(raw, []string{recipient}, nil)
+	require.Empty(t, errs)
+	assert.Equal(t, tx.Hash(), hash)
+	assert.Equal(t, [][]string{{recipient}}, ptm.sentTo)
+	assert.NotNil(t, ethBackend.TxPool().Get(hash), "the transaction is submitted to the pool")
+
+	// the validation failures are those of eth_sendRawPrivateTransaction
+	_, raw = sign(1)
+	partyProtection := int(engine.PrivacyFlagPartyProtection)
+	for _, args := range []ethapi.SendRawTxArgs{
+		{PrivateTxArgs: ethapi.PrivateTxArgs{PrivateFor: []string{"not a key"}}},
+		{PrivateTxArgs: ethapi.PrivateTxArgs{PrivateFor: []string{recipient}, PrivacyFlag: engine.PrivacyFlagPartyProtection}},
+	} {
+		var flag *int
+		if args.PrivacyFlag != engine.PrivacyFlagStandardPrivate {
+			flag = &partyProtection
+		}
+		_, rpcErr := rpcAPI.SendRawPrivateTransaction(context.Background(), raw, args)
+		require.Error(t, rpcErr)
+		_, errs := send(raw, args.PrivateFor, flag)
+		assert.Equal(t, []string{rpcErr.Error()}, errs)
+	}
+	assert.Len(t, ptm.sentTo, 1)
 }
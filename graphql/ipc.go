@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Quorum
+//
+// The local tools can query GraphQL over HTTP on the unix socket of the IPC
+// endpoint suffixed with .graphql rather than on a network port. As for the
+// JSON-RPC over IPC, the clients are those the socket file permissions let in,
+// so the requests are neither checked for an API key nor for an access token
+// and are resolved as those of the HTTP server without them.
+
+// ipcSuffix is appended to the IPC endpoint of the node for the GraphQL socket.
+const ipcSuffix = ".graphql"
+
+// ipcServer serves GraphQL on the IPC socket while the node runs.
+type ipcServer struct {
+	endpoint string
+	handler  http.Handler
+
+	mu     sync.Mutex
+	server *http.Server
+}
+
+func newIPCServer(endpoint string, handler http.Handler) *ipcServer {
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", handler)
+	mux.Handle("/graphql/", handler)
+	return &ipcServer{endpoint: endpoint, handler: mux}
+}
+
+// Start implements node.Lifecycle, listening on the IPC socket.
+func (s *ipcServer) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listener, err := rpc.IPCListen(s.endpoint)
+	if err != nil {
+		return err
+	}
+	s.server = &http.Server{Handler: s.handler}
+	go s.server.Serve(listener)
+	log.Info("GraphQL endpoint opened", "url", s.endpoint)
+	return nil
+}
+
+// Stop implements node.Lifecycle, closing the IPC socket and its connections.
+func (s *ipcServer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	s.server = nil
+	log.Info("GraphQL endpoint closed", "url", s.endpoint)
+	return err
+}
@@ -0,0 +1,189 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/plugin/security"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// Quorum
+//
+// Under multitenancy the GraphQL requests are authenticated with the access
+// tokens of the security plugin as the JSON-RPC ones, and the tenants are only
+// served the private payloads, receipts and logs of the private transactions
+// one of their transaction manager keys is party to.
+
+const (
+	// unauthenticatedCode is the error code of the requests without a valid
+	// access token.
+	unauthenticatedCode = "UNAUTHENTICATED"
+	// notAuthorizedCode is the error code of the fields of the private
+	// transactions the tenant of the request is not party to.
+	notAuthorizedCode = "NOT_AUTHORIZED"
+)
+
+// notAuthorizedError is returned for the private transactions the tenant of
+// the request is not party to, rather than the empty payload of a public one.
+type notAuthorizedError struct {
+	hash common.Hash
+}
+
+func (e *notAuthorizedError) Error() string {
+	return fmt.Sprintf("private transaction %s: %v", e.hash.Hex(), multitenancy.ErrNotAuthorized)
+}
+
+func (e *notAuthorizedError) Unwrap() error { return multitenancy.ErrNotAuthorized }
+
+// Extensions implements the extensions of the GraphQL errors.
+func (e *notAuthorizedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": notAuthorizedCode}
+}
+
+// authenticationHandler attaches the token the access token of the requests
+// is authenticated as to their context, rejecting those without a valid one.
+// The authentication manager is resolved with the first request, the plugins
+// being started along with the node.
+type authenticationHandler struct {
+	next    http.Handler
+	resolve func() (security.AuthenticationManager, error)
+
+	once        sync.Once
+	authManager security.AuthenticationManager
+	err         error
+}
+
+func newAuthenticationHandler(next http.Handler, resolve func() (security.AuthenticationManager, error)) http.Handler {
+	return &authenticationHandler{next: next, resolve: resolve}
+}
+
+func (h *authenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.authManager, h.err = h.resolve()
+	})
+	if h.err != nil {
+		log.Error("failure when resolving the authentication manager", "err", h.err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if h.authManager == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	enabled, err := h.authManager.IsEnabled(r.Context())
+	if err != nil {
+		log.Error("failure when checking if authentication manager is enabled", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !enabled {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	token := r.Header.Get(rpc.HttpAuthorizationHeader)
+	if m, ok := h.authManager.(security.HeaderAuthenticationManager); ok {
+		token = r.Header.Get(m.TokenHeader())
+	}
+	if token == "" {
+		rejectRequest(w, http.StatusUnauthorized, unauthenticatedCode, errors.New("missing access token"))
+		return
+	}
+	authToken, err := h.authManager.Authenticate(r.Context(), token)
+	if err != nil {
+		rejectRequest(w, http.StatusUnauthorized, unauthenticatedCode, err)
+		return
+	}
+	if expiredAt, err := ptypes.Timestamp(authToken.GetExpiredAt()); err != nil || !time.Now().Before(expiredAt) {
+		rejectRequest(w, http.StatusUnauthorized, unauthenticatedCode, errors.New("token expired"))
+		return
+	}
+	h.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rpc.CtxPreauthenticatedToken, authToken)))
+}
+
+// privatePayload is a private payload received from the private transaction
+// manager.
+type privatePayload struct {
+	sender         string
+	managedParties []string // recipients managed by the private transaction manager of the node
+	data           []byte
+	metadata       *engine.ExtraMetadata
+}
+
+// receivePrivate returns the private payload of the transaction, nil for a
+// public one or if the node is not a party to it, failing if the tenant of the
+// request is not party to it. The node not being a party, no tenant is.
+func (t *Transaction) receivePrivate(ctx context.Context) (*privatePayload, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || !tx.IsPrivate() {
+		return nil, err
+	}
+	sender, managedParties, data, metadata, err := private.P.Receive(ctx, common.BytesToEncryptedPayloadHash(tx.Data()))
+	if err != nil {
+		return nil, err
+	}
+	if authToken, isMultitenant := t.backend.SupportsMultitenancy(ctx); isMultitenant {
+		authorized := false
+		for _, party := range managedParties {
+			if multitenancy.OwnsTMKey(authToken, party) {
+				authorized = true
+				break
+			}
+		}
+		if !authorized {
+			return nil, &notAuthorizedError{hash: tx.Hash()}
+		}
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return &privatePayload{sender: sender, managedParties: managedParties, data: data, metadata: metadata}, nil
+}
+
+// authorize fails if the transaction is private and the tenant of the request
+// is not party to it.
+func (t *Transaction) authorize(ctx context.Context) error {
+	if _, isMultitenant := t.backend.SupportsMultitenancy(ctx); !isMultitenant {
+		return nil
+	}
+	_, err := t.receivePrivate(ctx)
+	return err
+}
+
+// filterAuthorizedLogs drops the logs of the private transactions the tenant
+// of the request is not party to, as the JSON-RPC filters do.
+func filterAuthorizedLogs(ctx context.Context, logs []*Log) ([]*Log, error) {
+	if len(logs) == 0 {
+		return logs, nil
+	}
+	if _, isMultitenant := logs[0].backend.SupportsMultitenancy(ctx); !isMultitenant {
+		return logs, nil
+	}
+	authorized := make(map[common.Hash]bool)
+	ret := make([]*Log, 0, len(logs))
+	for _, l := range logs {
+		ok, checked := authorized[l.log.TxHash]
+		if !checked {
+			err := l.transaction.authorize(ctx)
+			if err != nil && !errors.Is(err, multitenancy.ErrNotAuthorized) {
+				return nil, err
+			}
+			ok = err == nil
+			authorized[l.log.TxHash] = ok
+		}
+		if ok {
+			ret = append(ret, l)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,396 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/node"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// Quorum
+//
+// The fields of the redaction policy of the node are coordinates Type.field of
+// the schema. The response keys being aliases, the document of the request is
+// walked along the data to find the type of each of them.
+
+// errRedactionFailed is returned instead of the data which could not be
+// redacted for the client.
+var errRedactionFailed = errors.New("response could not be redacted for the client")
+
+// selection is a field, a fragment spread or an inline fragment of a
+// selection set.
+type selection struct {
+	field      string
+	alias      string
+	fragment   string // name of the spread fragment
+	typeCond   string // type condition of the inline fragment
+	selections []*selection
+}
+
+type fragment struct {
+	typeCond   string
+	selections []*selection
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	selections []*selection
+}
+
+// document is the structure of a GraphQL document needed to map the keys of a
+// response to the fields of the schema.
+type document struct {
+	operations map[string]*operation
+	fragments  map[string]*fragment
+}
+
+// parseDocument parses the selections of the operations and fragments of the
+// tokens of a document, which is valid as it was executed.
+func parseDocument(tokens []string) (*document, error) {
+	doc := &document{operations: make(map[string]*operation), fragments: make(map[string]*fragment)}
+	p := &documentParser{tokens: tokens}
+	for p.i < len(tokens) {
+		switch p.peek() {
+		case "query", "mutation", "subscription":
+			op := &operation{kind: p.next()}
+			var name string
+			if isName(p.peek()) {
+				name = p.next()
+			}
+			if p.peek() == "(" {
+				if err := p.skipBalanced(); err != nil {
+					return nil, err
+				}
+			}
+			p.skipDirectives()
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.selections = selections
+			doc.operations[name] = op
+		case "{":
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations[""] = &operation{kind: "query", selections: selections}
+		case "fragment":
+			p.next()
+			name := p.next()
+			if p.next() != "on" {
+				return nil, fmt.Errorf("fragment %s has no type condition", name)
+			}
+			frag := &fragment{typeCond: p.next()}
+			p.skipDirectives()
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			frag.selections = selections
+			doc.fragments[name] = frag
+		default:
+			return nil, fmt.Errorf("unexpected %q", p.peek())
+		}
+	}
+	return doc, nil
+}
+
+type documentParser struct {
+	tokens []string
+	i      int
+}
+
+func (p *documentParser) peek() string {
+	if p.i < len(p.tokens) {
+		return p.tokens[p.i]
+	}
+	return ""
+}
+
+func (p *documentParser) next() string {
+	token := p.peek()
+	p.i++
+	return token
+}
+
+func (p *documentParser) skipBalanced() error {
+	end, err := skipBalanced(p.tokens, p.i)
+	p.i = end
+	return err
+}
+
+func (p *documentParser) skipDirectives() {
+	for p.peek() == "@" {
+		p.i += 2
+		if p.peek() == "(" {
+			p.skipBalanced()
+		}
+	}
+}
+
+// selectionSet parses the selection set at the current token.
+func (p *documentParser) selectionSet() ([]*selection, error) {
+	if p.next() != "{" {
+		return nil, errors.New("expected a selection set")
+	}
+	var selections []*selection
+	for p.peek() != "}" {
+		if p.i >= len(p.tokens) {
+			return nil, errors.New("unterminated selection set")
+		}
+		sel := new(selection)
+		if p.peek() == "..." {
+			p.next()
+			switch {
+			case p.peek() == "on":
+				p.next()
+				sel.typeCond = p.next()
+				fallthrough
+			case p.peek() == "@" || p.peek() == "{":
+				p.skipDirectives()
+				children, err := p.selectionSet()
+				if err != nil {
+					return nil, err
+				}
+				sel.selections = children
+			default:
+				sel.fragment = p.next()
+				p.skipDirectives()
+			}
+			selections = append(selections, sel)
+			continue
+		}
+		sel.field = p.next()
+		if p.peek() == ":" {
+			p.next()
+			sel.alias, sel.field = sel.field, p.next()
+		}
+		if p.peek() == "(" {
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+		}
+		p.skipDirectives()
+		if p.peek() == "{" {
+			children, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			sel.selections = children
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+	return selections, nil
+}
+
+// schemaTypes maps the fields of the object types of the schema to the named
+// type of their values.
+type schemaTypes struct {
+	fields map[string]map[string]string
+	roots  map[string]string // root type by operation kind
+}
+
+func newSchemaTypes(s *graphql.Schema) *schemaTypes {
+	inspected := s.Inspect()
+	types := &schemaTypes{fields: make(map[string]map[string]string), roots: make(map[string]string)}
+	for _, typ := range inspected.Types() {
+		fields := typ.Fields(&struct{ IncludeDeprecated bool }{true})
+		if typ.Name() == nil || fields == nil {
+			continue
+		}
+		byName := make(map[string]string, len(*fields))
+		for _, field := range *fields {
+			fieldType := field.Type()
+			for fieldType.Name() == nil && fieldType.OfType() != nil {
+				fieldType = fieldType.OfType()
+			}
+			if fieldType.Name() != nil {
+				byName[field.Name()] = *fieldType.Name()
+			}
+		}
+		types.fields[*typ.Name()] = byName
+	}
+	for kind, typ := range map[string]*introspection.Type{
+		"query":        inspected.QueryType(),
+		"mutation":     inspected.MutationType(),
+		"subscription": inspected.SubscriptionType(),
+	} {
+		if typ != nil && typ.Name() != nil {
+			types.roots[kind] = *typ.Name()
+		}
+	}
+	return types
+}
+
+// redactor nulls the redacted fields of the data of a response.
+type redactor struct {
+	types    *schemaTypes
+	doc      *document
+	fields   map[string]bool // coordinates of the redacted fields
+	redacted map[string]bool // coordinates of the fields nulled
+	visiting map[string]bool // fragments being walked
+}
+
+// redactData returns the data of a response to the query with the fields
+// nulled, and the coordinates of the nulled fields.
+func redactData(types *schemaTypes, query, operationName string, data json.RawMessage, fields map[string]bool) (json.RawMessage, []string, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := parseDocument(tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	op, ok := doc.operations[operationName]
+	if !ok && operationName == "" && len(doc.operations) == 1 {
+		for _, only := range doc.operations {
+			op, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, nil, err
+	}
+	r := &redactor{types: types, doc: doc, fields: fields, redacted: make(map[string]bool), visiting: make(map[string]bool)}
+	r.redact(value, types.roots[op.kind], op.selections)
+	if len(r.redacted) == 0 {
+		return data, nil, nil
+	}
+	redacted := make([]string, 0, len(r.redacted))
+	for field := range r.redacted {
+		redacted = append(redacted, field)
+	}
+	sort.Strings(redacted)
+	enc, err := json.Marshal(value)
+	return enc, redacted, err
+}
+
+func (r *redactor) redact(value interface{}, typ string, selections []*selection) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			r.redact(elem, typ, selections)
+		}
+	case map[string]interface{}:
+		for _, sel := range selections {
+			switch {
+			case sel.fragment != "":
+				frag, ok := r.doc.fragments[sel.fragment]
+				if ok && !r.visiting[sel.fragment] && fragmentApplies(frag.typeCond, typ) {
+					r.visiting[sel.fragment] = true
+					r.redact(v, typ, frag.selections)
+					delete(r.visiting, sel.fragment)
+				}
+			case sel.field == "":
+				if fragmentApplies(sel.typeCond, typ) {
+					r.redact(v, typ, sel.selections)
+				}
+			default:
+				key := sel.field
+				if sel.alias != "" {
+					key = sel.alias
+				}
+				child, ok := v[key]
+				if !ok || child == nil {
+					continue
+				}
+				if coordinate := typ + "." + sel.field; r.fields[coordinate] {
+					v[key] = nil
+					r.redacted[coordinate] = true
+					continue
+				}
+				if len(sel.selections) > 0 {
+					r.redact(child, r.types.fields[typ][sel.field], sel.selections)
+				}
+			}
+		}
+	}
+}
+
+// fragmentApplies reports whether a fragment with the type condition applies to
+// values of the type, the schema having no abstract types.
+func fragmentApplies(typeCond, typ string) bool {
+	return typeCond == "" || typeCond == typ
+}
+
+// redactionHandler serves the GraphQL requests as relay.Handler does, with the
+// fields of the redaction policy nulled and listed in the "redacted" extension.
+type redactionHandler struct {
+	schema *graphql.Schema
+	types  *schemaTypes
+	policy *node.RedactionPolicy
+}
+
+func newRedactionHandler(s *graphql.Schema, policy *node.RedactionPolicy) http.Handler {
+	return &redactionHandler{schema: s, types: newSchemaTypes(s), policy: policy}
+}
+
+func (h *redactionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := h.schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+	if err := redactResponse(r.Context(), h.types, h.policy, params.Query, params.OperationName, response); err != nil {
+		// never serve data which could not be redacted
+		h.reject(w, err)
+		return
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseJSON)
+}
+
+// redactResponse redacts the data of the response of the query for the client
+// of the context, listing the fields redacted in the redacted extension.
+func redactResponse(ctx context.Context, types *schemaTypes, policy *node.RedactionPolicy, query, operationName string, response *graphql.Response) error {
+	identity, fields := policy.GraphQLFields(ctx)
+	if len(fields) == 0 || len(response.Data) == 0 {
+		return nil
+	}
+	data, redacted, err := redactData(types, query, operationName, response.Data, fields)
+	if err != nil {
+		return err
+	}
+	if len(redacted) > 0 {
+		response.Data = data
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["redacted"] = redacted
+		node.MarkRedacted(identity)
+	}
+	return nil
+}
+
+func (h *redactionHandler) reject(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{"message": fmt.Sprintf("%v: %v", errRedactionFailed, err)}},
+	})
+}
@@ -0,0 +1,97 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Quorum
+//
+// A GraphQL request is identified by its X-Request-ID header, or the requestId
+// of its extensions, or a generated ID. The ID is returned in the header of the
+// response and, when supplied by the client or with errors, in the requestId
+// of the extensions of the response.
+
+type requestIDHandler struct {
+	next http.Handler
+}
+
+func newRequestIDHandler(next http.Handler) http.Handler {
+	return &requestIDHandler{next: next}
+}
+
+func (h *requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := rpc.SanitizeRequestID(r.Header.Get(rpc.RequestIDHeader))
+	if id == "" && r.Body != nil {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		var req struct {
+			Extensions struct {
+				RequestID string `json:"requestId"`
+			} `json:"extensions"`
+		}
+		if json.Unmarshal(body, &req) == nil {
+			id = rpc.SanitizeRequestID(req.Extensions.RequestID)
+		}
+	}
+	supplied := id != ""
+	if !supplied {
+		id = rpc.NewRequestID()
+	}
+	w.Header().Set(rpc.RequestIDHeader, id)
+
+	response := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(response, r.WithContext(log.WithRequestID(r.Context(), id)))
+	body := response.body.Bytes()
+	if echoed, ok := withRequestIDExtension(body, id, supplied); ok {
+		body = echoed
+	}
+	w.WriteHeader(response.status)
+	w.Write(body)
+}
+
+// withRequestIDExtension returns the JSON response with the request ID in its
+// extensions, if supplied by the client or the response has errors.
+func withRequestIDExtension(body []byte, id string, supplied bool) ([]byte, bool) {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false
+	}
+	if _, failed := response["errors"]; !supplied && !failed {
+		return nil, false
+	}
+	extensions := make(map[string]json.RawMessage)
+	if raw, ok := response["extensions"]; ok {
+		if err := json.Unmarshal(raw, &extensions); err != nil {
+			return nil, false
+		}
+	}
+	extensions["requestId"], _ = json.Marshal(id)
+	response["extensions"], _ = json.Marshal(extensions)
+	enc, err := json.Marshal(response)
+	return enc, err == nil
+}
+
+// bufferedResponse holds the response of a handler to be amended.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
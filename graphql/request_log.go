@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Quorum
+//
+// The GraphQL requests sampled by the request log of the node are logged with
+// the names of their operations, the anonymous ones as their type, and the
+// variables allowed by the log. Their response is buffered to tell its size
+// and whether it has errors.
+
+// operationPattern matches the operation definitions of a query document.
+var operationPattern = regexp.MustCompile(`(?m)(?:^|[\s}])(query|mutation|subscription)\s*([_A-Za-z][_0-9A-Za-z]*)?\s*[({@]`)
+
+type requestLogHandler struct {
+	next   http.Handler
+	logger rpc.AccessLogger
+}
+
+func newRequestLogHandler(next http.Handler, logger rpc.AccessLogger) http.Handler {
+	return &requestLogHandler{next: next, logger: logger}
+}
+
+func (h *requestLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.logger.Sample() {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	entry := &rpc.AccessLogEntry{Time: time.Now(), Protocol: "graphql", Operations: []string{}}
+	if r.Body != nil {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		var req struct {
+			Query         string                     `json:"query"`
+			OperationName string                     `json:"operationName"`
+			Variables     map[string]json.RawMessage `json:"variables"`
+		}
+		if json.Unmarshal(body, &req) == nil {
+			entry.Operations = operationNames(req.Query, req.OperationName)
+			for name, value := range req.Variables {
+				if h.logger.LogsParams(name) {
+					if entry.Params == nil {
+						entry.Params = make(map[string]json.RawMessage)
+					}
+					entry.Params[name] = value
+				}
+			}
+		}
+	}
+
+	response := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(response, r)
+	body := response.body.Bytes()
+	w.WriteHeader(response.status)
+	w.Write(body)
+
+	entry.Size = len(body)
+	entry.DurationMs = float64(time.Since(entry.Time).Microseconds()) / 1000
+	entry.RequestID = w.Header().Get(rpc.RequestIDHeader)
+	entry.Remote = r.RemoteAddr
+	if response.status >= http.StatusBadRequest {
+		entry.ErrorClass = "http_" + strconv.Itoa(response.status)
+	} else {
+		var result struct {
+			Errors []json.RawMessage `json:"errors"`
+		}
+		if json.Unmarshal(body, &result) == nil && len(result.Errors) > 0 {
+			entry.ErrorClass = "graphql"
+		}
+	}
+	h.logger.LogAccess(r.Context(), entry)
+}
+
+// operationNames returns the name of the operation of a request, or the names
+// of the operations of its document, the anonymous ones named by their type.
+func operationNames(query, operationName string) []string {
+	if operationName != "" {
+		return []string{operationName}
+	}
+	var names []string
+	for _, match := range operationPattern.FindAllStringSubmatch(query, -1) {
+		if match[2] != "" {
+			names = append(names, match[2])
+		} else {
+			names = append(names, match[1])
+		}
+	}
+	if len(names) == 0 {
+		// the query shorthand
+		names = append(names, "query")
+	}
+	return names
+}
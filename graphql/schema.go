@@ -34,6 +34,7 @@ const schema string = `
     schema {
         query: Query
         mutation: Mutation
+        subscription: Subscription
     }
 
     # Account is an Ethereum account at a particular block.
@@ -52,6 +53,28 @@ const schema string = `
         # Storage provides access to the storage of a contract account, indexed
         # by its 32 byte slot identifier.
         storage(slot: Bytes32!): Bytes32!
+        # Transactions returns the first canonical transactions sent, or
+        # received, by this account, the most recent first, resuming after the
+        # cursor of the previous page if supplied. At most 1000 transactions are
+        # returned, 100 by default. The node must index the transactions by
+        # account.
+        transactions(first: Int, after: String, direction: TransactionDirection): AccountTransactionPage!
+    }
+
+    # TransactionDirection selects the transactions sent or received by an
+    # account.
+    enum TransactionDirection {
+        SENT
+        RECEIVED
+    }
+
+    # AccountTransactionPage is a page of the transactions of an account.
+    type AccountTransactionPage {
+        # Transactions are the transactions of the page, the most recent first.
+        transactions: [Transaction!]!
+        # Cursor resumes the search after the last transaction of the page, null
+        # once all the transactions were returned.
+        cursor: String
     }
 
     # Log is an Ethereum event log.
@@ -69,6 +92,52 @@ const schema string = `
         transaction: Transaction!
     }
 
+    # InternalCall is a call or a contract creation made by a contract.
+    type InternalCall {
+        # Type is the opcode of the call, e.g. CALL, DELEGATECALL or CREATE2.
+        type: String!
+        # From is the contract making the call.
+        from(block: Long): Account!
+        # To is the account called, or the contract created. This is the zero
+        # address for a failed contract creation.
+        to(block: Long): Account!
+        # Value is the value, in wei, sent along with the call.
+        value: BigInt!
+        # Depth is the call depth of the contract making the call, the
+        # transaction executing at depth 1.
+        depth: Int!
+        # Error is the reason the call failed, null if it succeeded.
+        error: String
+    }
+
+    # BlockStats are the statistics of a canonical block.
+    type BlockStats {
+        number: Long!
+        hash: Bytes32!
+        # Timestamp is the block timestamp in seconds, also for Raft blocks.
+        timestamp: Long!
+        transactionCount: Long!
+        privateTransactionCount: Long!
+        gasUsed: Long!
+        gasLimit: Long!
+        # ActiveAccounts is the number of distinct senders of the transactions.
+        activeAccounts: Long!
+    }
+
+    # DailyStats are the statistics of the canonical blocks of a UTC day.
+    type DailyStats {
+        # Date is the day, formatted as YYYY-MM-DD.
+        date: String!
+        blocks: Long!
+        firstBlock: Long!
+        lastBlock: Long!
+        transactionCount: Long!
+        privateTransactionCount: Long!
+        gasUsed: Long!
+        # ActiveAccounts is the number of distinct senders over the day.
+        activeAccounts: Long!
+    }
+
     # Transaction is an Ethereum transaction.
     type Transaction {
         # Hash is the hash of this transaction.
@@ -119,6 +188,29 @@ const schema string = `
 		isPrivate: Boolean
 		# PrivateInputData is the actual payload of Quorum private transaction
 		privateInputData: Bytes
+		# PrivateFrom is the public key of the private transaction manager which
+		# sent Quorum private transaction. This will be null for public
+		# transactions, or if the node is not a party to the transaction.
+		privateFrom: String
+		# PrivateFor are the public keys of the recipients of Quorum private
+		# transaction managed by the private transaction manager of the node.
+		# This will be null for public transactions, or if the node is not a
+		# party to the transaction.
+		privateFor: [String!]
+		# PrivacyFlag is the privacy flag of Quorum private transaction: 0 for
+		# standard private, 1 for party protection and 3 for private state
+		# validation. This will be null for public transactions, or if the node
+		# is not a party to the transaction.
+		privacyFlag: Int
+		# AffectedContractTransactions are the hashes of the encrypted payloads
+		# of the transactions creating the contracts affected by Quorum private
+		# transaction. This will be null for public transactions, or if the node
+		# is not a party to the transaction.
+		affectedContractTransactions: [Bytes!]
+		# InternalCalls is the list of calls and contract creations made by
+		# contracts while executing this transaction. This will be null if the
+		# internal calls of the transaction were not indexed.
+		internalCalls: [InternalCall!]
         r: BigInt!
         s: BigInt!
         v: BigInt!
@@ -144,6 +236,18 @@ const schema string = `
         topics: [[Bytes32!]!]
     }
 
+    # TransactionFilter restricts the transactions of a block to those matching
+    # all the criteria supplied.
+    input TransactionFilter {
+        # IsPrivate matches the Quorum private, or public, transactions.
+        isPrivate: Boolean
+        # From matches the transactions sent from the address.
+        from: Address
+        # To matches the transactions sent to the address, contract creations
+        # never matching.
+        to: Address
+    }
+
     # Block is an Ethereum block.
     type Block {
         # Number is the number of this block, starting at 0 for the genesis block.
@@ -161,6 +265,10 @@ const schema string = `
         transactionCount: Int
         # StateRoot is the keccak256 hash of the state trie after this block was processed.
         stateRoot: Bytes32!
+		# PrivateStateRoot is the root of the Quorum private state trie the node
+		# computed after processing this block. It is null, with an error, if the
+		# node has no private state for the block, e.g. as it predates its sync.
+		privateStateRoot: Bytes32
         # ReceiptsRoot is the keccak256 hash of the trie of transaction receipts in this block.
         receiptsRoot: Bytes32!
         # Miner is the account that mined this block.
@@ -184,26 +292,38 @@ const schema string = `
         # this block.
         totalDifficulty: BigInt!
         # OmmerCount is the number of ommers (AKA uncles) associated with this
-        # block. If ommers are unavailable, this field will be null.
+        # block. If ommers are unavailable, this field will be null. It is zero
+        # on chains whose consensus engine never produces ommers.
         ommerCount: Int
         # Ommers is a list of ommer (AKA uncle) blocks associated with this block.
         # If ommers are unavailable, this field will be null. Depending on your
         # node, the transactions, transactionAt, transactionCount, ommers,
         # ommerCount and ommerAt fields may not be available on any ommer blocks.
+        # It is empty on chains whose consensus engine never produces ommers.
         ommers: [Block]
         # OmmerAt returns the ommer (AKA uncle) at the specified index. If ommers
         # are unavailable, or the index is out of bounds, this field will be null.
         ommerAt(index: Int!): Block
+		# ConsensusEngine is the consensus engine of the chain, raft, istanbul,
+		# clique or ethash. Only ethash produces ommers.
+		consensusEngine: String!
         # OmmerHash is the keccak256 hash of all the ommers (AKA uncles)
         # associated with this block.
         ommerHash: Bytes32!
         # Transactions is a list of transactions associated with this block. If
         # transactions are unavailable for this block, this field will be null.
-        transactions: [Transaction!]
+        # The filter restricts the list, the transactions keeping their index
+        # in the block.
+        transactions(filter: TransactionFilter): [Transaction!]
         # TransactionAt returns the transaction at the specified index. If
         # transactions are unavailable for this block, or if the index is out of
         # bounds, this field will be null.
         transactionAt(index: Int!): Transaction
+        # TransactionsRange returns the count transactions from the specified
+        # index, fewer if the block has fewer or the count exceeds the limit of
+        # the server. If transactions are unavailable for this block, this field
+        # will be null, an index out of bounds returns an empty list.
+        transactionsRange(from: Int!, count: Int!): [Transaction!]
         # Logs returns a filtered set of logs from this block.
         logs(filter: BlockFilterCriteria!): [Log!]!
         # Account fetches an Ethereum account at the current block's state.
@@ -242,6 +362,16 @@ const schema string = `
         status: Long!
     }
 
+    # LogPage is a page of the log entries matching a filter.
+    type LogPage {
+        # Logs are the log entries of the page, ordered by block, transaction
+        # index and log index.
+        logs: [Log!]!
+        # Cursor resumes the search after the last log entry of the page, null
+        # once all matching log entries were returned.
+        cursor: String
+    }
+
     # FilterCriteria encapsulates log filter criteria for searching log entries.
     input FilterCriteria {
         # FromBlock is the block at which to start searching, inclusive. Defaults
@@ -298,10 +428,63 @@ const schema string = `
       estimateGas(data: CallData!): Long!
     }
 
+    # TokenBalance is the balance of an account in a token contract.
+    type TokenBalance {
+        # Token is the address of the token contract.
+        token: Address!
+        # Balance is the balanceOf result, null if error is set.
+        balance: BigInt
+        # Decimals of the token, null if the contract does not provide them.
+        decimals: Int
+        # Symbol of the token, null if the contract does not provide it.
+        symbol: String
+        # Error explains why the balance could not be retrieved, e.g. the
+        # contract does not implement balanceOf.
+        error: String
+    }
+
+    # ContractExtension is the extension of a Quorum private contract to a new
+    # party, run by its management contract.
+    type ContractExtension {
+        # ManagementContract is the address of the management contract.
+        managementContract: Address!
+        # Contract is the address of the private contract extended.
+        contract: Address!
+        # Initiator is the account which requested the extension.
+        initiator: Address!
+        # Recipient is the account which approves the extension for the new party.
+        recipient: Address!
+        # RecipientPtmKey is the private transaction manager key of the new party.
+        recipientPtmKey: String!
+        # Status is ACTIVE until the extension is completed or cancelled, DONE
+        # after.
+        status: String!
+        # CreationBlock is the number of the block creating the management
+        # contract, null if the node did not record it.
+        creationBlock: Long
+    }
+
+    # FeeHistory is the fee market history of a range of blocks. The chains
+    # have no base fee, the gas price of a transaction being its priority fee,
+    # and the fees of gas-free Quorum chains are all zero.
+    type FeeHistory {
+        # OldestBlock is the number of the first block of the range.
+        oldestBlock: Long!
+        # BaseFeePerGas are the base fees of the blocks of the range and of the
+        # block following it.
+        baseFeePerGas: [BigInt!]!
+        # GasUsedRatio are the ratios of gas used to the gas limit of the blocks.
+        gasUsedRatio: [Float!]!
+        # Reward are the requested percentiles of the priority fees of the
+        # transactions of each block, weighted by the gas they used.
+        reward: [[BigInt!]!]
+    }
+
     type Query {
         # Block fetches an Ethereum block by number or by hash. If neither is
-        # supplied, the most recent known block is returned.
-        block(number: Long, hash: Bytes32): Block
+        # supplied, the most recent known block is returned. A block fetched by
+        # hash with requireCanonical must be on the canonical chain.
+        block(number: Long, hash: Bytes32, requireCanonical: Boolean): Block
         # Blocks returns all the blocks between two numbers, inclusive. If
         # to is not supplied, it defaults to the most recent known block.
         blocks(from: Long!, to: Long): [Block!]!
@@ -311,19 +494,55 @@ const schema string = `
         transaction(hash: Bytes32!): Transaction
         # Logs returns log entries matching the provided filter.
         logs(filter: FilterCriteria!): [Log!]!
+        # LogsPage returns at most pageSize log entries matching the provided
+        # filter, resuming after the cursor of the previous page if supplied.
+        logsPage(filter: FilterCriteria!, pageSize: Long!, cursor: String): LogPage!
         # GasPrice returns the node's estimate of a gas price sufficient to
         # ensure a transaction is mined in a timely fashion.
         gasPrice: BigInt!
+        # FeeHistory returns the fee history of the blockCount blocks ending
+        # with newestBlock, or the most recent known block. At most 1024 blocks
+        # are returned.
+        feeHistory(blockCount: Int!, newestBlock: Long, rewardPercentiles: [Float!]): FeeHistory!
         # ProtocolVersion returns the current wire protocol version number.
         protocolVersion: Int!
         # Syncing returns information on the current synchronisation state.
         syncing: SyncState
         # ChainID returns the current chain ID for transaction replay protection.
         chainID: BigInt!
+        # TokenBalances returns the balances of owner in the given ERC-20 or
+        # ERC-721 contracts, private ones included, at the given block or the
+        # most recent known block. At most 100 tokens may be requested.
+        tokenBalances(owner: Address!, tokens: [Address!]!, block: Long): [TokenBalance!]!
+        # BlockStats returns the statistics of the canonical blocks between two
+        # numbers, inclusive, as aggregated with --blockstats. Blocks not
+        # indexed are omitted.
+        blockStats(from: Long!, to: Long!): [BlockStats!]!
+        # DailyStats returns the statistics of the canonical blocks per UTC day
+        # between two YYYY-MM-DD dates, inclusive.
+        dailyStats(fromDate: String!, toDate: String!): [DailyStats!]!
+        # ContractExtensions returns the Quorum contract extensions known to
+        # the extension service of the node, only the active ones or only the
+        # others if active is supplied. The list is empty if the service is not
+        # enabled.
+        contractExtensions(active: Boolean): [ContractExtension!]!
     }
 
     type Mutation {
         # SendRawTransaction sends an RLP-encoded transaction to the network.
         sendRawTransaction(data: Bytes!): Bytes32!
+        # SendRawPrivateTransaction sends a signed private transaction, its data
+        # the hash of a payload stored with storeraw, distributing the payload to
+        # the parties of privateFor, as eth_sendRawPrivateTransaction.
+        sendRawPrivateTransaction(data: Bytes!, privateFor: [String!]!, privacyFlag: Int): Bytes32!
+    }
+
+    # Subscription streams the events of the node, over WebSocket with the
+    # graphql-ws protocol.
+    type Subscription {
+        # NewBlock is each block becoming the head of the chain.
+        newBlock: Block!
+        # PendingTransactions is each transaction added to the pending pool.
+        pendingTransactions: Transaction!
     }
 `
@@ -17,8 +17,22 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/extension"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/plugin"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 )
@@ -35,14 +49,69 @@ func New(stack *node.Node, backend ethapi.Backend, cors, vhosts []string) error
 // newHandler returns a new `http.Handler` that will answer GraphQL queries.
 // It additionally exports an interactive query browser on the / endpoint.
 func newHandler(stack *node.Node, backend ethapi.Backend, cors, vhosts []string) error {
-	q := Resolver{backend}
+	q := Resolver{backend: backend}
+	// Quorum: the extension service is registered before, if enabled
+	var extensions *extension.PrivacyService
+	if stack.Lifecycle(&extensions) == nil {
+		q.extensions = extensions
+	}
 
 	s, err := graphql.ParseSchema(schema, &q)
 	if err != nil {
 		return err
 	}
-	h := &relay.Handler{Schema: s}
+	var h http.Handler = &relay.Handler{Schema: s}
+	if policy := stack.RedactionPolicy(); policy != nil {
+		h = newRedactionHandler(s, policy)
+	}
+	if sr, ok := backend.(stalenessReporter); ok {
+		h = newStalenessHandler(h, sr)
+	}
+	var list *allowList
+	if dir := stack.Config().GraphQLAllowList; dir != "" {
+		if list, err = registerAllowList(stack, dir); err != nil {
+			return err
+		}
+		h = newAllowListHandler(h, list)
+	}
+	if guard := stack.ConsistencyGuard(); guard != nil {
+		h = newConsistencyHandler(h, guard)
+	}
+	h = newRequestIDHandler(h)
+	// Quorum: the requests are logged once authenticated, their body limited
+	if logger := stack.RequestLogger(); logger != nil {
+		h = newRequestLogHandler(h, logger)
+	}
+	if limit := stack.Config().GraphQLBodyLimit; limit > 0 {
+		h = newBodyLimitHandler(h, limit)
+	}
+	// the scalars are decoded without the request, the mode applies to every handler
+	common.SetGraphQLStrictChecksum(stack.Config().GraphQLStrictChecksum)
+	h = withMaxTransactionsRange(h, stack.Config().GraphQLMaxTransactionsRange)
+	// Quorum: the IPC clients are authorized by the socket file permissions
+	ipcHandler := withRemoteAddr(withSnapshot(h, backend))
+	// Quorum: the tokens of the security plugin identify the tenants, as for JSON-RPC
+	authenticate := stack.PluginManager().IsEnabled(plugin.SecurityPluginInterfaceName)
+	if authenticate {
+		h = newAuthenticationHandler(h, stack.AuthenticationManager)
+	}
+	h = withRemoteAddr(withSnapshot(stack.APIKeyHandler("graphql", h), backend))
+	// Quorum: the subscriptions are served on the same endpoints
+	subscriptions := newSubscriptionServer(s, list, stack.RedactionPolicy(), cors, stack.Config().GraphQLBodyLimit)
+	stack.RegisterLifecycle(subscriptions)
+	sh := withMaxTransactionsRange(subscriptions, stack.Config().GraphQLMaxTransactionsRange)
+	if authenticate {
+		sh = newAuthenticationHandler(sh, stack.AuthenticationManager)
+	}
+	h = withSubscriptions(h, withRemoteAddr(stack.APIKeyHandler("graphql", sh)))
 	handler := node.NewHTTPHandlerStack(h, cors, vhosts)
+	if stack.Config().GraphQLIPC {
+		if stack.IPCEndpoint() == "" {
+			return errors.New("GraphQL over IPC requires the IPC endpoint")
+		}
+		ipcHandler = withSubscriptions(ipcHandler, withRemoteAddr(withMaxTransactionsRange(subscriptions, stack.Config().GraphQLMaxTransactionsRange)))
+		stack.RegisterLifecycle(newIPCServer(stack.IPCEndpoint()+ipcSuffix, ipcHandler))
+	}
 
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
 	stack.RegisterHandler("GraphQL", "/graphql", handler)
@@ -50,3 +119,138 @@ func newHandler(stack *node.Node, backend ethapi.Backend, cors, vhosts []string)
 
 	return nil
 }
+
+// Quorum
+//
+// stalenessHeader reports, in milliseconds, how long ago a read-only node
+// last refreshed the chain head its responses are consistent with.
+const stalenessHeader = "X-Head-Staleness-Ms"
+
+// stalenessReporter is implemented by backends which may serve reads from a
+// database replicated from another node.
+type stalenessReporter interface {
+	HeadStaleness() (time.Duration, bool)
+}
+
+type stalenessHandler struct {
+	next     http.Handler
+	reporter stalenessReporter
+}
+
+func newStalenessHandler(next http.Handler, reporter stalenessReporter) http.Handler {
+	return &stalenessHandler{next: next, reporter: reporter}
+}
+
+func (h *stalenessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if staleness, ok := h.reporter.HeadStaleness(); ok {
+		w.Header().Set(stalenessHeader, strconv.FormatInt(staleness.Milliseconds(), 10))
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// consistencyHandler holds the requests presenting a consistency token back
+// until the node caught up with it, as the JSON-RPC server does, and returns
+// the token of the transactions sent by their mutations.
+type consistencyHandler struct {
+	next  http.Handler
+	guard rpc.ConsistencyGuard
+}
+
+func newConsistencyHandler(next http.Handler, guard rpc.ConsistencyGuard) http.Handler {
+	return &consistencyHandler{next: next, guard: guard}
+}
+
+// ServeHTTP replies with status 503 to the requests the node did not catch up
+// with in time, and with status 400 to those presenting an invalid token.
+func (h *consistencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := rpc.WithConsistency(r.Context(), h.guard, r)
+	if err := rpc.AwaitConsistency(ctx); err != nil {
+		status := http.StatusBadRequest
+		var notConsistent *ethapi.NotConsistentError
+		if errors.As(err, &notConsistent) {
+			status = http.StatusServiceUnavailable
+		}
+		gqlErr := map[string]interface{}{"message": err.Error()}
+		if de, ok := err.(rpc.DataError); ok {
+			gqlErr["extensions"] = de.ErrorData()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []interface{}{gqlErr}})
+		return
+	}
+	h.next.ServeHTTP(&consistencyWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+}
+
+// consistencyWriter sets the consistency token issued while serving a request
+// in the header of its response.
+type consistencyWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+}
+
+func (w *consistencyWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if token := rpc.ConsistencyTokenIssued(w.ctx); token != "" {
+			w.Header().Set(rpc.ConsistencyTokenHeader, token)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *consistencyWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// bodyLimitHandler rejects requests with a body larger than the limit.
+type bodyLimitHandler struct {
+	next  http.Handler
+	limit int64
+}
+
+func newBodyLimitHandler(next http.Handler, limit int64) http.Handler {
+	return &bodyLimitHandler{next: next, limit: limit}
+}
+
+// ServeHTTP buffers at most limit bytes of the body before handing the request
+// over, replying with status 413 and an error naming the limit beyond them.
+func (h *bodyLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > h.limit {
+		h.reject(w)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, h.limit+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > h.limit {
+		h.reject(w)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *bodyLimitHandler) reject(w http.ResponseWriter) {
+	rpc.MarkRequestTooLarge("graphql")
+	err := &rpc.RequestTooLargeError{Endpoint: "graphql", Limit: h.limit}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{"message": err.Error(), "extensions": err.ErrorData()}},
+	})
+}
+
+// withRemoteAddr makes the address of the client available to the resolvers,
+// as the JSON-RPC server does.
+func withRemoteAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "remote", r.RemoteAddr)))
+	})
+}
@@ -0,0 +1,158 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type snapshotKey struct{}
+
+// snapshotBackend serves the resolvers of a single GraphQL request from one
+// chain state. The head is captured when the request starts and "latest"
+// resolves to it whatever blocks are imported meanwhile, the pending block,
+// state and transactions are captured the first time they are needed.
+type snapshotBackend struct {
+	ethapi.Backend
+	head *types.Block
+
+	pendingOnce   sync.Once
+	pendingBlock  *types.Block
+	pendingState  vm.MinimalApiState
+	pendingHeader *types.Header
+	pendingErr    error
+
+	poolOnce sync.Once
+	poolTxs  types.Transactions
+	poolErr  error
+}
+
+// copyableState is implemented by the states which can be copied, so that a
+// captured pending state is served to every resolver unchanged.
+type copyableState interface {
+	Copy() vm.MinimalApiState
+}
+
+func newSnapshotBackend(backend ethapi.Backend) *snapshotBackend {
+	return &snapshotBackend{Backend: backend, head: backend.CurrentBlock()}
+}
+
+// withSnapshot captures the chain state a request is served from.
+func withSnapshot(next http.Handler, backend ethapi.Backend) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), snapshotKey{}, newSnapshotBackend(backend))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// snapshot returns the backend serving the request, the resolver backend if the
+// request has no snapshot.
+func (r *Resolver) snapshot(ctx context.Context) ethapi.Backend {
+	if sb, ok := ctx.Value(snapshotKey{}).(*snapshotBackend); ok {
+		return sb
+	}
+	return r.backend
+}
+
+// latest returns the captured head as a block number or hash.
+func (b *snapshotBackend) latest() rpc.BlockNumberOrHash {
+	return rpc.BlockNumberOrHashWithHash(b.head.Hash(), false)
+}
+
+func (b *snapshotBackend) pending(ctx context.Context) {
+	b.pendingOnce.Do(func() {
+		if b.pendingBlock, b.pendingErr = b.Backend.BlockByNumber(ctx, rpc.PendingBlockNumber); b.pendingErr != nil {
+			return
+		}
+		b.pendingState, b.pendingHeader, b.pendingErr = b.Backend.StateAndHeaderByNumber(ctx, rpc.PendingBlockNumber)
+	})
+}
+
+func (b *snapshotBackend) CurrentBlock() *types.Block {
+	return b.head
+}
+
+func (b *snapshotBackend) CurrentHeader() *types.Header {
+	return b.head.Header()
+}
+
+func (b *snapshotBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	switch number {
+	case rpc.LatestBlockNumber:
+		return b.head.Header(), nil
+	case rpc.PendingBlockNumber:
+		b.pending(ctx)
+		if b.pendingBlock == nil {
+			return nil, b.pendingErr
+		}
+		return b.pendingBlock.Header(), b.pendingErr
+	}
+	return b.Backend.HeaderByNumber(ctx, number)
+}
+
+func (b *snapshotBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.HeaderByNumber(ctx, number)
+	}
+	return b.Backend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+}
+
+func (b *snapshotBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	switch number {
+	case rpc.LatestBlockNumber:
+		return b.head, nil
+	case rpc.PendingBlockNumber:
+		b.pending(ctx)
+		return b.pendingBlock, b.pendingErr
+	}
+	return b.Backend.BlockByNumber(ctx, number)
+}
+
+func (b *snapshotBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.BlockByNumber(ctx, number)
+	}
+	return b.Backend.BlockByNumberOrHash(ctx, blockNrOrHash)
+}
+
+func (b *snapshotBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (vm.MinimalApiState, *types.Header, error) {
+	switch number {
+	case rpc.LatestBlockNumber:
+		return b.Backend.StateAndHeaderByNumberOrHash(ctx, b.latest())
+	case rpc.PendingBlockNumber:
+		b.pending(ctx)
+		if b.pendingErr != nil {
+			return nil, nil, b.pendingErr
+		}
+		// calls modify the state they execute on
+		if state, ok := b.pendingState.(copyableState); ok {
+			return state.Copy(), b.pendingHeader, nil
+		}
+		return b.Backend.StateAndHeaderByNumber(ctx, number)
+	}
+	return b.Backend.StateAndHeaderByNumber(ctx, number)
+}
+
+func (b *snapshotBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (vm.MinimalApiState, *types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.StateAndHeaderByNumber(ctx, number)
+	}
+	return b.Backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+}
+
+func (b *snapshotBackend) AccountExtraDataStateGetterByNumber(ctx context.Context, number rpc.BlockNumber) (vm.AccountExtraDataStateGetter, error) {
+	state, _, err := b.StateAndHeaderByNumber(ctx, number)
+	return state, err
+}
+
+func (b *snapshotBackend) GetPoolTransactions() (types.Transactions, error) {
+	b.poolOnce.Do(func() {
+		b.poolTxs, b.poolErr = b.Backend.GetPoolTransactions()
+	})
+	return b.poolTxs, b.poolErr
+}
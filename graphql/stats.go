@@ -0,0 +1,91 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockStats are the statistics of a canonical block aggregated by the block
+// statistics index.
+type BlockStats struct {
+	stats *types.BlockStats
+}
+
+func (s *BlockStats) Number() hexutil.Uint64 { return hexutil.Uint64(s.stats.Number) }
+
+func (s *BlockStats) Hash() common.Hash { return s.stats.Hash }
+
+func (s *BlockStats) Timestamp() hexutil.Uint64 { return hexutil.Uint64(s.stats.Time) }
+
+func (s *BlockStats) TransactionCount() hexutil.Uint64 { return hexutil.Uint64(s.stats.TxCount) }
+
+func (s *BlockStats) PrivateTransactionCount() hexutil.Uint64 {
+	return hexutil.Uint64(s.stats.PrivateTxCount)
+}
+
+func (s *BlockStats) GasUsed() hexutil.Uint64 { return hexutil.Uint64(s.stats.GasUsed) }
+
+func (s *BlockStats) GasLimit() hexutil.Uint64 { return hexutil.Uint64(s.stats.GasLimit) }
+
+func (s *BlockStats) ActiveAccounts() hexutil.Uint64 { return hexutil.Uint64(s.stats.ActiveAccounts) }
+
+// DailyStats are the statistics of the canonical blocks of a UTC day.
+type DailyStats struct {
+	stats *types.DailyStats
+}
+
+func (s *DailyStats) Date() string { return s.stats.Date() }
+
+func (s *DailyStats) Blocks() hexutil.Uint64 { return hexutil.Uint64(s.stats.Blocks) }
+
+func (s *DailyStats) FirstBlock() hexutil.Uint64 { return hexutil.Uint64(s.stats.FirstBlock) }
+
+func (s *DailyStats) LastBlock() hexutil.Uint64 { return hexutil.Uint64(s.stats.LastBlock) }
+
+func (s *DailyStats) TransactionCount() hexutil.Uint64 { return hexutil.Uint64(s.stats.TxCount) }
+
+func (s *DailyStats) PrivateTransactionCount() hexutil.Uint64 {
+	return hexutil.Uint64(s.stats.PrivateTxCount)
+}
+
+func (s *DailyStats) GasUsed() hexutil.Uint64 { return hexutil.Uint64(s.stats.GasUsed) }
+
+func (s *DailyStats) ActiveAccounts() hexutil.Uint64 { return hexutil.Uint64(s.stats.ActiveAccounts) }
+
+// BlockStats returns the statistics of the indexed canonical blocks between
+// two numbers, inclusive.
+func (r *Resolver) BlockStats(ctx context.Context, args struct {
+	From hexutil.Uint64
+	To   hexutil.Uint64
+}) ([]*BlockStats, error) {
+	stats, err := core.BlockStatsRange(r.backend.ChainDb(), uint64(args.From), uint64(args.To))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*BlockStats, len(stats))
+	for i, s := range stats {
+		ret[i] = &BlockStats{stats: s}
+	}
+	return ret, nil
+}
+
+// DailyStats returns the statistics of the canonical blocks per UTC day
+// between two YYYY-MM-DD dates, inclusive.
+func (r *Resolver) DailyStats(ctx context.Context, args struct {
+	FromDate string
+	ToDate   string
+}) ([]*DailyStats, error) {
+	stats, err := core.DailyStatsRange(r.backend.ChainDb(), args.FromDate, args.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*DailyStats, len(stats))
+	for i, s := range stats {
+		ret[i] = &DailyStats{stats: s}
+	}
+	return ret, nil
+}
@@ -0,0 +1,473 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// Quorum
+//
+// The subscriptions are served on the GraphQL endpoints to the requests
+// upgraded to WebSocket, with the graphql-ws protocol of
+// subscriptions-transport-ws. Queries and mutations may be sent over the
+// connections too, each operation having the id chosen by the client. The
+// connections go through the virtual hosts, the origins of the CORS domains,
+// the API keys, the allow-list and the redaction policy like the requests.
+
+const (
+	subscriptionProtocol = "graphql-ws"
+
+	gqlConnectionInit      = "connection_init"
+	gqlConnectionAck       = "connection_ack"
+	gqlConnectionError     = "connection_error"
+	gqlConnectionTerminate = "connection_terminate"
+	gqlKeepAlive           = "ka"
+	gqlStart               = "start"
+	gqlStop                = "stop"
+	gqlData                = "data"
+	gqlError               = "error"
+	gqlComplete            = "complete"
+
+	subscriptionInitTimeout     = 10 * time.Second
+	subscriptionKeepAlive       = 15 * time.Second
+	subscriptionWriteTimeout    = 10 * time.Second
+	subscriptionMaxMessageBytes = 1024 * 1024 // unless the body limit is set
+	subscriptionMaxOperations   = 64          // running operations of a connection
+	subscriptionBuffer          = 128         // events of a subscription not yet sent, dropped past it
+)
+
+var (
+	subscriptionDroppedMeter = metrics.NewRegisteredMeter("graphql/subscriptions/dropped", nil)
+
+	errSubscriptionServerStopped = errors.New("server stopped")
+)
+
+// headEventSubscriber is implemented by the backends enriching the new heads
+// with their receipts, read once for all the subscriptions.
+type headEventSubscriber interface {
+	SubscribeHeadEvent(ch chan<- *core.HeadEvent) event.Subscription
+}
+
+// NewBlock streams the blocks becoming the head of the chain.
+func (r *Resolver) NewBlock(ctx context.Context) (<-chan *Block, error) {
+	subscriber, enriched := r.backend.(headEventSubscriber)
+	var (
+		heads         = make(chan core.ChainHeadEvent, subscriptionBuffer)
+		enrichedHeads = make(chan *core.HeadEvent, subscriptionBuffer)
+		sub           event.Subscription
+	)
+	if enriched {
+		sub = subscriber.SubscribeHeadEvent(enrichedHeads)
+	} else {
+		sub = r.backend.SubscribeChainHeadEvent(heads)
+	}
+	blocks := make(chan *Block, subscriptionBuffer)
+	go func() {
+		defer close(blocks)
+		defer sub.Unsubscribe()
+		for {
+			var block *Block
+			select {
+			case ev := <-heads:
+				block = r.headBlock(ev.Block, nil)
+			case ev := <-enrichedHeads:
+				block = r.headBlock(ev.Block(), ev.Receipts())
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case blocks <- block:
+			default:
+				subscriptionDroppedMeter.Mark(1)
+			}
+		}
+	}()
+	return blocks, nil
+}
+
+// headBlock resolves a new head, with its receipts if known.
+func (r *Resolver) headBlock(head *types.Block, receipts types.Receipts) *Block {
+	numberOrHash := rpc.BlockNumberOrHashWithHash(head.Hash(), false)
+	return &Block{
+		backend:      r.backend,
+		numberOrHash: &numberOrHash,
+		hash:         head.Hash(),
+		header:       head.Header(),
+		block:        head,
+		receipts:     receipts,
+	}
+}
+
+// PendingTransactions streams the transactions added to the pending pool.
+func (r *Resolver) PendingTransactions(ctx context.Context) (<-chan *Transaction, error) {
+	events := make(chan core.NewTxsEvent, subscriptionBuffer)
+	sub := r.backend.SubscribeNewTxsEvent(events)
+	txs := make(chan *Transaction, subscriptionBuffer)
+	go func() {
+		defer close(txs)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-events:
+				for _, tx := range ev.Txs {
+					select {
+					case txs <- &Transaction{backend: r.backend, hash: tx.Hash(), tx: tx}:
+					default:
+						subscriptionDroppedMeter.Mark(1)
+					}
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return txs, nil
+}
+
+// subscriptionMessage is a message of the graphql-ws protocol.
+type subscriptionMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// withSubscriptions hands the WebSocket upgrades over to the subscriptions and
+// the other requests to next.
+func withSubscriptions(next, subscriptions http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			subscriptions.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// subscriptionServer serves the WebSocket connections, closing them when the
+// node stops.
+type subscriptionServer struct {
+	schema    *graphql.Schema
+	list      *allowList // nil without allow-list
+	types     *schemaTypes
+	policy    *node.RedactionPolicy // nil without redaction
+	upgrader  websocket.Upgrader
+	readLimit int64
+
+	lock    sync.Mutex
+	conns   map[*subscriptionConn]struct{}
+	stopped bool
+}
+
+func newSubscriptionServer(s *graphql.Schema, list *allowList, policy *node.RedactionPolicy, cors []string, readLimit int64) *subscriptionServer {
+	if readLimit <= 0 {
+		readLimit = subscriptionMaxMessageBytes
+	}
+	server := &subscriptionServer{
+		schema:    s,
+		list:      list,
+		policy:    policy,
+		readLimit: readLimit,
+		conns:     make(map[*subscriptionConn]struct{}),
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{subscriptionProtocol},
+			CheckOrigin:  subscriptionOriginChecker(cors),
+		},
+	}
+	if policy != nil {
+		server.types = newSchemaTypes(s)
+	}
+	return server
+}
+
+// subscriptionOriginChecker allows the browsers of the origins of the CORS
+// domains, or of the origin of the node, to connect. The clients which are not
+// browsers do not send the origin.
+func subscriptionOriginChecker(cors []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range cors {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		log.Warn("Rejected GraphQL WebSocket connection", "origin", origin)
+		return false
+	}
+}
+
+// Start implements node.Lifecycle.
+func (s *subscriptionServer) Start() error {
+	return nil
+}
+
+// Stop closes the connections, ending their operations.
+func (s *subscriptionServer) Stop() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stopped = true
+	for conn := range s.conns {
+		conn.ws.Close()
+	}
+	return nil
+}
+
+func (s *subscriptionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader replied with the error
+		log.Debug("GraphQL WebSocket upgrade failed", "err", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	conn := &subscriptionConn{
+		server: s,
+		ws:     ws,
+		client: allowListClient(r),
+		ops:    make(map[string]*subscriptionOp),
+	}
+	defer func() {
+		cancel()
+		ws.Close()
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+	}()
+
+	s.lock.Lock()
+	if s.stopped {
+		s.lock.Unlock()
+		conn.send(gqlConnectionError, "", errorPayload(errSubscriptionServerStopped))
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.lock.Unlock()
+
+	conn.serve(ctx)
+}
+
+// subscriptionConn is a WebSocket connection and its running operations.
+type subscriptionConn struct {
+	server *subscriptionServer
+	ws     *websocket.Conn
+	client string // identifies the client in the metrics
+
+	writeLock sync.Mutex
+
+	opsLock sync.Mutex
+	ops     map[string]*subscriptionOp
+}
+
+// subscriptionOp is a running operation.
+type subscriptionOp struct {
+	cancel context.CancelFunc
+}
+
+// serve acknowledges the connection and runs the operations started by the
+// client until it terminates the connection, or it is closed.
+func (c *subscriptionConn) serve(ctx context.Context) {
+	c.ws.SetReadLimit(c.server.readLimit)
+	c.ws.SetReadDeadline(time.Now().Add(subscriptionInitTimeout))
+	var msg subscriptionMessage
+	if err := c.ws.ReadJSON(&msg); err != nil {
+		return
+	}
+	if msg.Type != gqlConnectionInit {
+		c.send(gqlConnectionError, "", errorPayload(fmt.Errorf("expected %s, got %q", gqlConnectionInit, msg.Type)))
+		return
+	}
+	c.ws.SetReadDeadline(time.Time{})
+	if err := c.send(gqlConnectionAck, "", nil); err != nil {
+		return
+	}
+	go c.keepAlive(ctx)
+
+	for {
+		var msg subscriptionMessage
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				c.send(gqlConnectionError, "", errorPayload(err))
+			}
+			return
+		}
+		switch msg.Type {
+		case gqlStart:
+			if err := c.start(ctx, &msg); err != nil {
+				c.send(gqlError, msg.ID, queryErrorsPayload(err, ""))
+			}
+		case gqlStop:
+			c.stop(msg.ID)
+		case gqlConnectionTerminate:
+			return
+		default:
+			c.send(gqlError, msg.ID, queryErrorsPayload(fmt.Errorf("unknown message type %q", msg.Type), ""))
+		}
+	}
+}
+
+func (c *subscriptionConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(subscriptionKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.send(gqlKeepAlive, "", nil); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// start runs the operation of the message, sending its responses until it
+// completes or is stopped.
+func (c *subscriptionConn) start(ctx context.Context, msg *subscriptionMessage) error {
+	if msg.ID == "" {
+		return errors.New("missing operation id")
+	}
+	var req allowListRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		return fmt.Errorf("invalid operation: %v", err)
+	}
+	if list := c.server.list; list != nil {
+		query, err := list.lookup(&req)
+		if err != nil {
+			metrics.GetOrRegisterCounter("graphql/allowlist/misses/"+c.client, nil).Inc(1)
+			c.send(gqlError, msg.ID, queryErrorsPayload(err, queryNotAllowedCode))
+			return nil
+		}
+		if err := query.validateVariables(req.Variables); err != nil {
+			c.send(gqlError, msg.ID, queryErrorsPayload(err, invalidVariablesCode))
+			return nil
+		}
+		req.Query = query.document
+	}
+
+	c.opsLock.Lock()
+	if _, ok := c.ops[msg.ID]; ok {
+		c.opsLock.Unlock()
+		return fmt.Errorf("operation %q already started", msg.ID)
+	}
+	if len(c.ops) >= subscriptionMaxOperations {
+		c.opsLock.Unlock()
+		return fmt.Errorf("too many operations, at most %d may run", subscriptionMaxOperations)
+	}
+	opCtx, cancel := context.WithCancel(ctx)
+	op := &subscriptionOp{cancel: cancel}
+	c.ops[msg.ID] = op
+	c.opsLock.Unlock()
+
+	responses, err := c.server.schema.Subscribe(opCtx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		c.finish(msg.ID, op)
+		return err
+	}
+	go func() {
+		defer c.finish(msg.ID, op)
+		for r := range responses {
+			response := r.(*graphql.Response)
+			if c.server.policy != nil {
+				if err := redactResponse(ctx, c.server.types, c.server.policy, req.Query, req.OperationName, response); err != nil {
+					// never send data which could not be redacted, the rest
+					// of the responses being drained once cancelled
+					c.send(gqlError, msg.ID, queryErrorsPayload(fmt.Errorf("%v: %v", errRedactionFailed, err), ""))
+					cancel()
+					for range responses {
+					}
+					return
+				}
+			}
+			if err := c.send(gqlData, msg.ID, response); err != nil {
+				cancel()
+				for range responses {
+				}
+				return
+			}
+		}
+		if opCtx.Err() == nil {
+			c.send(gqlComplete, msg.ID, nil)
+		}
+	}()
+	return nil
+}
+
+// stop cancels the operation, if running.
+func (c *subscriptionConn) stop(id string) {
+	c.opsLock.Lock()
+	defer c.opsLock.Unlock()
+
+	if op, ok := c.ops[id]; ok {
+		op.cancel()
+		delete(c.ops, id)
+	}
+}
+
+// finish releases the operation once its responses are sent, unless it was
+// stopped and its id reused meanwhile.
+func (c *subscriptionConn) finish(id string, op *subscriptionOp) {
+	c.opsLock.Lock()
+	defer c.opsLock.Unlock()
+
+	op.cancel()
+	if c.ops[id] == op {
+		delete(c.ops, id)
+	}
+}
+
+func (c *subscriptionConn) send(typ, id string, payload interface{}) error {
+	msg := subscriptionMessage{ID: id, Type: typ}
+	if payload != nil {
+		enc, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		msg.Payload = enc
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.ws.SetWriteDeadline(time.Now().Add(subscriptionWriteTimeout))
+	return c.ws.WriteJSON(&msg)
+}
+
+func errorPayload(err error) map[string]string {
+	return map[string]string{"message": err.Error()}
+}
+
+// queryErrorsPayload returns the errors of an operation failing, with the code
+// of the error if any.
+func queryErrorsPayload(err error, code string) []map[string]interface{} {
+	e := map[string]interface{}{"message": err.Error()}
+	if code != "" {
+		e["extensions"] = map[string]string{"code": code}
+	}
+	return []map[string]interface{}{e}
+}
@@ -0,0 +1,194 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	// maxTokenBalanceTokens caps the number of token contracts of a single
+	// tokenBalances query.
+	maxTokenBalanceTokens = 100
+	// tokenCallGas is the gas allowance of each call made against a token.
+	tokenCallGas = 100000
+	// tokenBalancesGasBudget is the total gas a tokenBalances query may use,
+	// entries beyond the budget are returned with an error.
+	tokenBalancesGasBudget = 5000000
+	// tokenMetadataTTL is how long decimals and symbol of a token are cached.
+	tokenMetadataTTL = 10 * time.Minute
+)
+
+var (
+	balanceOfSelector = common.FromHex("0x70a08231") // balanceOf(address)
+	decimalsSelector  = common.FromHex("0x313ce567") // decimals()
+	symbolSelector    = common.FromHex("0x95d89b41") // symbol()
+
+	errNotERC20           = errors.New("contract does not implement ERC-20/721 balanceOf")
+	errTokenBudgetReached = errors.New("execution budget of the query exhausted")
+	errTokenBlockNotFound = errors.New("block not found")
+
+	stringType, _ = abi.NewType("string", "", nil)
+
+	tokenMetadataCache = cache.New(tokenMetadataTTL, 2*tokenMetadataTTL)
+)
+
+// TokenBalance is the balance of an owner in a single token contract.
+type TokenBalance struct {
+	token    common.Address
+	balance  *hexutil.Big
+	decimals *int32
+	symbol   *string
+	err      error
+}
+
+func (t *TokenBalance) Token() common.Address { return t.token }
+func (t *TokenBalance) Balance() *hexutil.Big { return t.balance }
+func (t *TokenBalance) Decimals() *int32      { return t.decimals }
+func (t *TokenBalance) Symbol() *string       { return t.symbol }
+
+func (t *TokenBalance) Error() *string {
+	if t.err == nil {
+		return nil
+	}
+	msg := t.err.Error()
+	return &msg
+}
+
+type tokenMetadata struct {
+	decimals *int32
+	symbol   *string
+}
+
+// tokenCaller runs read-only calls against token contracts at a block,
+// accounting the gas used against the budget of the query.
+type tokenCaller struct {
+	backend      ethapi.Backend
+	numberOrHash rpc.BlockNumberOrHash
+	budget       uint64
+}
+
+func (c *tokenCaller) call(ctx context.Context, token common.Address, data []byte) ([]byte, error) {
+	if c.budget < tokenCallGas {
+		return nil, errTokenBudgetReached
+	}
+	gas := hexutil.Uint64(tokenCallGas)
+	input := hexutil.Bytes(data)
+	result, err := ethapi.DoCall(ctx, c.backend, ethapi.CallArgs{To: &token, Gas: &gas, Data: &input}, c.numberOrHash, nil, vm.Config{}, c.backend.CallTimeOut(), c.backend.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	c.budget -= result.UsedGas
+	if result.Failed() {
+		return nil, result.Err
+	}
+	return result.ReturnData, nil
+}
+
+func (c *tokenCaller) balance(ctx context.Context, token, owner common.Address) *TokenBalance {
+	entry := &TokenBalance{token: token}
+	ret, err := c.call(ctx, token, append(common.CopyBytes(balanceOfSelector), common.LeftPadBytes(owner.Bytes(), 32)...))
+	switch {
+	case err == errTokenBudgetReached:
+		entry.err = err
+		return entry
+	case err != nil || len(ret) < 32:
+		entry.err = errNotERC20
+		return entry
+	}
+	entry.balance = (*hexutil.Big)(new(big.Int).SetBytes(ret[:32]))
+
+	metadata, cached := c.metadata(ctx, token)
+	entry.decimals, entry.symbol = metadata.decimals, metadata.symbol
+	// metadata of private contracts is retrieved with the caller's permissions
+	// under multitenancy and must not be shared through the cache
+	if _, isMultitenant := c.backend.SupportsMultitenancy(ctx); !cached && !isMultitenant {
+		tokenMetadataCache.SetDefault(token.Hex(), metadata)
+	}
+	return entry
+}
+
+// metadata returns the optional decimals and symbol of a token, and whether
+// they were served from the cache.
+func (c *tokenCaller) metadata(ctx context.Context, token common.Address) (*tokenMetadata, bool) {
+	if _, isMultitenant := c.backend.SupportsMultitenancy(ctx); !isMultitenant {
+		if cached, ok := tokenMetadataCache.Get(token.Hex()); ok {
+			return cached.(*tokenMetadata), true
+		}
+	}
+	metadata := new(tokenMetadata)
+	if ret, err := c.call(ctx, token, decimalsSelector); err == nil && len(ret) >= 32 {
+		if decimals := new(big.Int).SetBytes(ret[:32]); decimals.IsUint64() && decimals.Uint64() <= 255 {
+			value := int32(decimals.Uint64())
+			metadata.decimals = &value
+		}
+	}
+	if ret, err := c.call(ctx, token, symbolSelector); err == nil {
+		metadata.symbol = decodeTokenSymbol(ret)
+	}
+	return metadata, false
+}
+
+// decodeTokenSymbol decodes an ABI encoded string, falling back to the
+// bytes32 encoding used by some early tokens.
+func decodeTokenSymbol(ret []byte) *string {
+	if values, err := (abi.Arguments{{Type: stringType}}).UnpackValues(ret); err == nil && len(values) == 1 {
+		if symbol, ok := values[0].(string); ok {
+			return &symbol
+		}
+	}
+	if len(ret) == 32 {
+		symbol := strings.TrimRight(string(bytes.TrimRight(ret, "\x00")), " ")
+		return &symbol
+	}
+	return nil
+}
+
+// TokenBalances returns the balances of an owner in the given ERC-20 or
+// ERC-721 contracts at the given block, or the latest one. Calls are routed
+// to the private state for contracts only existing privately on this node.
+// Contracts not implementing balanceOf are reported per entry.
+func (r *Resolver) TokenBalances(ctx context.Context, args struct {
+	Owner  common.Address
+	Tokens []common.Address
+	Block  *hexutil.Uint64
+}) ([]*TokenBalance, error) {
+	if len(args.Tokens) > maxTokenBalanceTokens {
+		return nil, fmt.Errorf("too many tokens requested: %d, maximum is %d", len(args.Tokens), maxTokenBalanceTokens)
+	}
+	numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if args.Block != nil {
+		numberOrHash = rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(*args.Block))
+	}
+	backend := r.snapshot(ctx)
+	header, err := backend.HeaderByNumberOrHash(ctx, numberOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errTokenBlockNotFound
+	}
+	// pin the block so that all entries are consistent with each other
+	caller := &tokenCaller{
+		backend:      backend,
+		numberOrHash: rpc.BlockNumberOrHashWithHash(header.Hash(), false),
+		budget:       tokenBalancesGasBudget,
+	}
+	balances := make([]*TokenBalance, len(args.Tokens))
+	for i, token := range args.Tokens {
+		balances[i] = caller.balance(ctx, token, args.Owner)
+	}
+	return balances, nil
+}
@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Quorum
+//
+// The transactions of the blocks paged through with transactionAt and
+// transactionsRange are decoded one by one from the stored body rather than
+// along with the whole block, and transactionCount only counts them.
+
+// DefaultMaxTransactionsRange is the number of transactions transactionsRange
+// returns at most if the handler sets no limit.
+const DefaultMaxTransactionsRange = 1000
+
+type maxTransactionsRangeKey struct{}
+
+// withMaxTransactionsRange sets the number of transactions transactionsRange
+// returns at most while serving the requests, zero for the default.
+func withMaxTransactionsRange(next http.Handler, max int) http.Handler {
+	if max <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), maxTransactionsRangeKey{}, max)))
+	})
+}
+
+func maxTransactionsRange(ctx context.Context) int {
+	if max, ok := ctx.Value(maxTransactionsRangeKey{}).(int); ok {
+		return max
+	}
+	return DefaultMaxTransactionsRange
+}
+
+// resolveRawTransactions returns the RLP list of the transactions of the stored
+// body of the block, nil if the block is already resolved or its body is not
+// stored, e.g. the pending block or a light client.
+func (b *Block) resolveRawTransactions(ctx context.Context) ([]byte, error) {
+	if b.rawTxs != nil {
+		return b.rawTxs, nil
+	}
+	if b.block != nil || (b.numberOrHash == nil && b.hash == (common.Hash{})) {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	hash := b.hash
+	if hash == (common.Hash{}) {
+		hash = header.Hash()
+	}
+	body := rawdb.ReadBodyRLP(b.backend.ChainDb(), hash, header.Number.Uint64())
+	if len(body) == 0 {
+		return nil, nil
+	}
+	fields, _, err := rlp.SplitList(body)
+	if err != nil {
+		return nil, err
+	}
+	if _, b.rawTxs, _, err = rlp.Split(fields); err != nil {
+		return nil, err
+	}
+	return b.rawTxs, nil
+}
+
+// transactionCount returns the number of transactions of the block, nil if the
+// block is not found.
+func (b *Block) transactionCount(ctx context.Context) (*int, error) {
+	raw, err := b.resolveRawTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		count, err := rlp.CountValues(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &count, nil
+	}
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	count := len(block.Transactions())
+	return &count, nil
+}
+
+// transactionsRange returns the count transactions of the block from the
+// index, fewer if the block has fewer, nil if the block is not found.
+func (b *Block) transactionsRange(ctx context.Context, from, count int) ([]*Transaction, error) {
+	raw, err := b.resolveRawTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var txs types.Transactions
+	if raw != nil {
+		for i := 0; len(raw) > 0 && i < from+count; i++ {
+			var item []byte
+			if item, raw, err = splitValue(raw); err != nil {
+				return nil, err
+			}
+			if i < from {
+				continue
+			}
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(item, tx); err != nil {
+				return nil, err
+			}
+			txs = append(txs, tx)
+		}
+	} else {
+		block, err := b.resolve(ctx)
+		if err != nil || block == nil {
+			return nil, err
+		}
+		if all := block.Transactions(); from < len(all) {
+			if from+count > len(all) {
+				count = len(all) - from
+			}
+			txs = all[from : from+count]
+		}
+	}
+	ret := make([]*Transaction, len(txs))
+	for i, tx := range txs {
+		ret[i] = &Transaction{
+			backend: b.backend,
+			hash:    tx.Hash(),
+			tx:      tx,
+			block:   b,
+			index:   uint64(from + i),
+		}
+	}
+	return ret, nil
+}
+
+// splitValue returns the first RLP value of b, with its prefix, and the rest.
+func splitValue(b []byte) (value, rest []byte, err error) {
+	if _, _, rest, err = rlp.Split(b); err != nil {
+		return nil, nil, err
+	}
+	return b[:len(b)-len(rest)], rest, nil
+}
+
+// TransactionsRange returns the count transactions of the block from the index,
+// at most the limit of the handler. The indexes out of the block return none.
+func (b *Block) TransactionsRange(ctx context.Context, args struct{ From, Count int32 }) (*[]*Transaction, error) {
+	from, count := int(args.From), int(args.Count)
+	if max := maxTransactionsRange(ctx); count > max {
+		count = max
+	}
+	if from < 0 || count <= 0 {
+		// null if the block does not exist, as for the other indexes
+		if n, err := b.transactionCount(ctx); err != nil || n == nil {
+			return nil, err
+		}
+		ret := []*Transaction{}
+		return &ret, nil
+	}
+	ret, err := b.transactionsRange(ctx, from, count)
+	if err != nil || ret == nil {
+		return nil, err
+	}
+	return &ret, nil
+}
@@ -0,0 +1,356 @@
+// Package health serves the readiness and liveness endpoints of a node.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/plugin/security"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/raft"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// The components the readiness of a node may be made of.
+const (
+	ComponentSync      = "sync"      // the chain is synchronised
+	ComponentConsensus = "consensus" // the node takes part in the consensus
+	ComponentPTM       = "ptm"       // the private transaction manager is up
+)
+
+const (
+	// refreshInterval is how often the status of the components is refreshed
+	// regardless of the chain events.
+	refreshInterval = 2 * time.Second
+
+	// liveTimeout is how long the status may go without a refresh before the
+	// node is not considered live anymore.
+	liveTimeout = 5 * refreshInterval
+)
+
+// Config are the settings of the health endpoints.
+type Config struct {
+	ReadyPath   string        // path of the readiness endpoint
+	LivePath    string        // path of the liveness endpoint
+	RequireAuth bool          // whether the endpoints require the authentication of the security plugin
+	Components  []string      // components the readiness is made of
+	BlockPeriod time.Duration // Istanbul block period, a block is expected within twice of it
+}
+
+// DefaultConfig contains the default settings of the health endpoints.
+var DefaultConfig = Config{
+	ReadyPath:   "/readyz",
+	LivePath:    "/livez",
+	Components:  []string{ComponentSync, ComponentConsensus, ComponentPTM},
+	BlockPeriod: time.Second,
+}
+
+// ComponentStatus is the last known status of a component.
+type ComponentStatus struct {
+	Ready   bool   `json:"ready"`
+	Detail  string `json:"detail,omitempty"`
+	Updated uint64 `json:"updated"` // local time of the last check, in seconds
+}
+
+// Readiness is the response of the readiness endpoint, ready when all of its
+// components are.
+type Readiness struct {
+	Ready      bool                        `json:"ready"`
+	Components map[string]*ComponentStatus `json:"components"`
+}
+
+// Liveness is the response of the liveness endpoint.
+type Liveness struct {
+	Live      bool   `json:"live"`
+	Refreshed uint64 `json:"refreshed"` // local time of the last refresh of the status, in seconds
+}
+
+// Service keeps the status of the components of the readiness of a node up to
+// date, following the chain and sync events, so that the endpoints only serve
+// the last known status.
+type Service struct {
+	config      Config
+	stack       *node.Node
+	eth         *eth.Ethereum
+	raft        *raft.RaftService // nil if not in raft mode
+	authManager security.AuthenticationManager
+
+	lock      sync.RWMutex
+	status    map[string]*ComponentStatus
+	refreshed time.Time
+	lastHead  time.Time // local time the last chain head was received
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New registers the health endpoints on the HTTP server of the node.
+func New(stack *node.Node, ethService *eth.Ethereum, raftService *raft.RaftService, config Config) (*Service, error) {
+	if config.ReadyPath == "" || config.LivePath == "" || config.ReadyPath == config.LivePath {
+		return nil, fmt.Errorf("invalid health endpoint paths %q and %q", config.ReadyPath, config.LivePath)
+	}
+	status := make(map[string]*ComponentStatus, len(config.Components))
+	for _, component := range config.Components {
+		switch component {
+		case ComponentSync, ComponentConsensus, ComponentPTM:
+			status[component] = &ComponentStatus{Detail: "not checked yet"}
+		default:
+			return nil, fmt.Errorf("unknown health component %q", component)
+		}
+	}
+	if stack.Config().HTTPHost == "" {
+		log.Warn("Health endpoints are served on the HTTP-RPC server, which is not enabled")
+	}
+	s := &Service{
+		config: config,
+		stack:  stack,
+		eth:    ethService,
+		raft:   raftService,
+		status: status,
+		quit:   make(chan struct{}),
+	}
+	stack.RegisterHandler("Readiness", config.ReadyPath, http.HandlerFunc(s.serveReady))
+	stack.RegisterHandler("Liveness", config.LivePath, http.HandlerFunc(s.serveLive))
+	stack.RegisterLifecycle(s)
+	return s, nil
+}
+
+// Start implements node.Lifecycle, starting to follow the status of the
+// components.
+func (s *Service) Start() error {
+	if s.config.RequireAuth {
+		authManager, err := s.stack.AuthenticationManager()
+		if err != nil {
+			return err
+		}
+		s.authManager = authManager
+	}
+	s.lastHead = time.Now()
+	s.refresh(s.config.Components...)
+
+	s.wg.Add(1)
+	go s.loop()
+	log.Info("Health endpoints started", "ready", s.config.ReadyPath, "live", s.config.LivePath, "components", s.config.Components)
+	return nil
+}
+
+// Stop implements node.Lifecycle.
+func (s *Service) Stop() error {
+	close(s.quit)
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Service) loop() {
+	defer s.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	headSub := s.eth.BlockChain().SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+	syncSub := s.eth.EventMux().Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+	defer syncSub.Unsubscribe()
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-headCh:
+			s.lock.Lock()
+			s.lastHead = time.Now()
+			s.lock.Unlock()
+			s.refresh(ComponentSync, ComponentConsensus)
+		case _, ok := <-syncSub.Chan():
+			if !ok {
+				return
+			}
+			s.refresh(ComponentSync)
+		case <-ticker.C:
+			s.refresh(s.config.Components...)
+		case <-headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// refresh checks the given components, those not part of the readiness aside.
+func (s *Service) refresh(components ...string) {
+	now := time.Now()
+	for _, component := range components {
+		s.lock.RLock()
+		_, enabled := s.status[component]
+		lastHead := s.lastHead
+		s.lock.RUnlock()
+		if !enabled {
+			continue
+		}
+		var status *ComponentStatus
+		switch component {
+		case ComponentSync:
+			status = s.checkSync()
+		case ComponentConsensus:
+			status = s.checkConsensus(now.Sub(lastHead))
+		case ComponentPTM:
+			status = checkPTM()
+		}
+		status.Updated = uint64(now.Unix())
+
+		s.lock.Lock()
+		s.status[component] = status
+		s.lock.Unlock()
+	}
+	s.lock.Lock()
+	s.refreshed = now
+	s.lock.Unlock()
+}
+
+func (s *Service) checkSync() *ComponentStatus {
+	if d := s.eth.Downloader(); d.Synchronising() {
+		progress := d.Progress()
+		return &ComponentStatus{Detail: fmt.Sprintf("syncing, at block %d of %d", progress.CurrentBlock, progress.HighestBlock)}
+	}
+	if !s.eth.Synced() {
+		return &ComponentStatus{Detail: "initial sync not done"}
+	}
+	return &ComponentStatus{Ready: true, Detail: fmt.Sprintf("at block %d", s.eth.BlockChain().CurrentBlock().NumberU64())}
+}
+
+// checkConsensus reports whether a raft node is a member of a cluster with a
+// leader, or whether an Istanbul node receives blocks.
+func (s *Service) checkConsensus(sinceHead time.Duration) *ComponentStatus {
+	if s.raft != nil {
+		api := raft.NewPublicRaftAPI(s.raft)
+		role := api.Role()
+		if role == "" {
+			return &ComponentStatus{Detail: "no raft leader or not a member of the cluster"}
+		}
+		leader, err := api.Leader()
+		if err != nil {
+			return &ComponentStatus{Detail: err.Error()}
+		}
+		return &ComponentStatus{Ready: true, Detail: fmt.Sprintf("raft %s, leader %s", role, leader)}
+	}
+	if _, ok := s.eth.Engine().(consensus.Istanbul); ok {
+		return istanbulStatus(sinceHead, s.config.BlockPeriod)
+	}
+	return &ComponentStatus{Ready: true, Detail: "consensus participation is not checked for the engine"}
+}
+
+// istanbulStatus expects a block within twice the block period.
+func istanbulStatus(sinceHead, period time.Duration) *ComponentStatus {
+	if sinceHead > 2*period {
+		return &ComponentStatus{Detail: fmt.Sprintf("no block received for %v", sinceHead.Round(time.Second))}
+	}
+	return &ComponentStatus{Ready: true, Detail: fmt.Sprintf("block received %v ago", sinceHead.Round(time.Millisecond))}
+}
+
+func checkPTM() *ComponentStatus {
+	if !private.IsQuorumPrivacyEnabled() {
+		return &ComponentStatus{Ready: true, Detail: "private transaction manager not in use"}
+	}
+	upchecker, ok := private.P.(private.Upchecker)
+	if !ok {
+		return &ComponentStatus{Ready: true, Detail: fmt.Sprintf("%s cannot be probed", private.P.Name())}
+	}
+	if err := upchecker.Upcheck(); err != nil {
+		return &ComponentStatus{Detail: err.Error()}
+	}
+	return &ComponentStatus{Ready: true, Detail: fmt.Sprintf("%s is up", private.P.Name())}
+}
+
+// Readiness returns the last known status of the components.
+func (s *Service) Readiness() *Readiness {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	readiness := &Readiness{Ready: true, Components: make(map[string]*ComponentStatus, len(s.status))}
+	for component, status := range s.status {
+		copied := *status
+		readiness.Components[component] = &copied
+		readiness.Ready = readiness.Ready && status.Ready
+	}
+	return readiness
+}
+
+// Liveness reports whether the status of the components is still refreshed.
+func (s *Service) Liveness() *Liveness {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return &Liveness{
+		Live:      time.Since(s.refreshed) < liveTimeout,
+		Refreshed: uint64(s.refreshed.Unix()),
+	}
+}
+
+func (s *Service) serveReady(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticate(w, r) {
+		return
+	}
+	readiness := s.Readiness()
+	writeStatus(w, readiness.Ready, readiness)
+}
+
+func (s *Service) serveLive(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticate(w, r) {
+		return
+	}
+	liveness := s.Liveness()
+	writeStatus(w, liveness.Live, liveness)
+}
+
+// authenticate checks the access token of the request if the endpoints are not
+// exempt from authentication, writing the error response if it fails.
+func (s *Service) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if s.authManager == nil {
+		return true
+	}
+	enabled, err := s.authManager.IsEnabled(context.Background())
+	if err != nil {
+		log.Error("failure when checking if authentication manager is enabled", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return false
+	}
+	if !enabled {
+		return true
+	}
+	token := r.Header.Get(rpc.HttpAuthorizationHeader)
+	if m, ok := s.authManager.(security.HeaderAuthenticationManager); ok {
+		token = r.Header.Get(m.TokenHeader())
+	}
+	if token == "" {
+		http.Error(w, "missing access token", http.StatusUnauthorized)
+		return false
+	}
+	authToken, err := s.authManager.Authenticate(context.Background(), token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return false
+	}
+	if expiredAt, err := ptypes.Timestamp(authToken.GetExpiredAt()); err != nil || !time.Now().Before(expiredAt) {
+		http.Error(w, "token expired", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func writeStatus(w http.ResponseWriter, ok bool, status interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
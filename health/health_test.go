@@ -0,0 +1,61 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	s := &Service{
+		status: map[string]*ComponentStatus{
+			ComponentSync: {Ready: true, Detail: "at block 10"},
+			ComponentPTM:  {Ready: true, Detail: "Tessera is up"},
+		},
+		refreshed: time.Now(),
+	}
+
+	get := func(handler http.HandlerFunc) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", "/", nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := get(s.serveReady)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, body["ready"])
+	assert.Len(t, body["components"], 2)
+
+	// a single component not ready makes the node not ready
+	s.status[ComponentPTM] = &ComponentStatus{Detail: "private transaction manager is not ready"}
+	code, body = get(s.serveReady)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, false, body["ready"])
+	components := body["components"].(map[string]interface{})
+	assert.Equal(t, true, components[ComponentSync].(map[string]interface{})["ready"])
+	assert.Equal(t, "private transaction manager is not ready", components[ComponentPTM].(map[string]interface{})["detail"])
+
+	// the returned status is a copy
+	s.Readiness().Components[ComponentSync].Ready = false
+	assert.True(t, s.status[ComponentSync].Ready)
+
+	code, _ = get(s.serveLive)
+	assert.Equal(t, http.StatusOK, code)
+	s.refreshed = time.Now().Add(-2 * liveTimeout)
+	code, body = get(s.serveLive)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, false, body["live"])
+}
+
+func TestIstanbulStatus(t *testing.T) {
+	assert.True(t, istanbulStatus(1500*time.Millisecond, time.Second).Ready)
+	assert.False(t, istanbulStatus(2500*time.Millisecond, time.Second).Ready)
+	assert.True(t, istanbulStatus(9*time.Second, 5*time.Second).Ready)
+}
@@ -148,6 +148,19 @@ type FilterQuery struct {
 	// {{A}, {B}}         matches topic A in first position AND B in second position
 	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
 	Topics [][]common.Hash
+
+	// Quorum
+	// PageSize bounds the number of logs returned by eth_getLogs, which then
+	// returns a cursor resuming after the last of them. 0 disables pagination.
+	PageSize uint64
+	// Cursor is returned by a paginated eth_getLogs to resume after its last
+	// log, empty for the first page.
+	Cursor string
+	// PrivateParty restricts the private logs to those of the contracts the
+	// transaction manager key is party to, which under multitenancy must be
+	// one of the tenant. Empty for all the private logs readable.
+	PrivateParty string
+	// End Quorum
 }
 
 // LogFilterer provides access to contract log events using a one-off query or continuous
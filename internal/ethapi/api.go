@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -41,11 +42,13 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/multitenancy"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -269,14 +272,20 @@ func (s *PrivateAccountAPI) ListWallets() []rawWallet {
 // the method may return an extra challenge requiring a second open (e.g. the
 // Trezor PIN matrix challenge).
 func (s *PrivateAccountAPI) OpenWallet(url string, passphrase *string) error {
-	wallet, err := s.am.Wallet(url)
-	if err != nil {
-		return err
-	}
 	pass := ""
 	if passphrase != nil {
 		pass = *passphrase
 	}
+	wallet, err := s.am.Wallet(url)
+	// Quorum - the HD wallets of the account plugin are opened by the plugin
+	if err == accounts.ErrUnknownWallet && strings.HasPrefix(url, pluggable.WalletScheme+"://") {
+		if backends := s.am.Backends(pluggable.BackendType); len(backends) > 0 {
+			return backends[0].(*pluggable.Backend).OpenWallet(url, pass)
+		}
+	}
+	if err != nil {
+		return err
+	}
 	return wallet.Open(pass)
 }
 
@@ -358,7 +367,7 @@ func (s *PrivateAccountAPI) UnlockAccount(ctx context.Context, addr common.Addre
 	}
 	err := s.unlockAccount(addr, password, d)
 	if err != nil {
-		log.Warn("Failed account unlock attempt", "address", addr, "err", err)
+		log.FromContext(ctx).Warn("Failed account unlock attempt", "address", addr, "err", err)
 	}
 	return err == nil, err
 }
@@ -456,6 +465,9 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 	}
 
 	// Quorum
+	if err := checkArgsSlotPolicy(ctx, s.b, &args); err != nil {
+		return common.Hash{}, err
+	}
 	isPrivate, data, err := checkAndHandlePrivateTransaction(ctx, s.b, args.toTransaction(), &args.PrivateTxArgs, args.From, NormalTransaction)
 	if err != nil {
 		return common.Hash{}, err
@@ -468,7 +480,7 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 
 	signed, err := s.signTransaction(ctx, &args, passwd)
 	if err != nil {
-		log.Warn("Failed transaction send attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
+		log.FromContext(ctx).Warn("Failed transaction send attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
 		return common.Hash{}, err
 	}
 	return SubmitTransaction(ctx, s.b, signed, args.PrivateFrom, args.PrivateFor, false)
@@ -496,14 +508,14 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 	}
 	signed, err := s.signTransaction(ctx, &args, passwd)
 	if err != nil {
-		log.Warn("Failed transaction sign attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
+		log.FromContext(ctx).Warn("Failed transaction sign attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
 		return nil, err
 	}
 	data, err := rlp.EncodeToBytes(signed)
 	if err != nil {
 		return nil, err
 	}
-	return &SignTransactionResult{data, signed}, nil
+	return &SignTransactionResult{Raw: data, Tx: signed}, nil
 }
 
 // Sign calculates an Ethereum ECDSA signature for:
@@ -526,7 +538,7 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	// Assemble sign the data with the wallet
 	signature, err := wallet.SignTextWithPassphrase(account, passwd, data)
 	if err != nil {
-		log.Warn("Failed data sign attempt", "address", addr, "err", err)
+		log.FromContext(ctx).Warn("Failed data sign attempt", "address", addr, "err", err)
 		return nil, err
 	}
 	signature[crypto.RecoveryIDOffset] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
@@ -610,12 +622,13 @@ func (s *PrivateAccountAPI) Unpair(ctx context.Context, url string, pin string)
 // PublicBlockChainAPI provides an API to access the Ethereum blockchain.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicBlockChainAPI struct {
-	b Backend
+	b         Backend
+	estimator *gasEstimator // Quorum
 }
 
 // NewPublicBlockChainAPI creates a new Ethereum blockchain API.
 func NewPublicBlockChainAPI(b Backend) *PublicBlockChainAPI {
-	return &PublicBlockChainAPI{b}
+	return &PublicBlockChainAPI{b: b, estimator: newGasEstimator(estimateGasWorkers, estimateGasCacheSize, estimateGasCacheTTL)}
 }
 
 // ChainId returns the chainID value for transaction replay protection.
@@ -770,7 +783,7 @@ func (s *PublicBlockChainAPI) GetUncleByBlockNumberAndIndex(ctx context.Context,
 	if block != nil {
 		uncles := block.Uncles()
 		if index >= hexutil.Uint(len(uncles)) {
-			log.Debug("Requested uncle not found", "number", blockNr, "hash", block.Hash(), "index", index)
+			log.FromContext(ctx).Debug("Requested uncle not found", "number", blockNr, "hash", block.Hash(), "index", index)
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
@@ -786,7 +799,7 @@ func (s *PublicBlockChainAPI) GetUncleByBlockHashAndIndex(ctx context.Context, b
 	if block != nil {
 		uncles := block.Uncles()
 		if index >= hexutil.Uint(len(uncles)) {
-			log.Debug("Requested uncle not found", "number", block.Number(), "hash", blockHash, "index", index)
+			log.FromContext(ctx).Debug("Requested uncle not found", "number", block.Number(), "hash", blockHash, "index", index)
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
@@ -819,6 +832,7 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 	if state == nil || err != nil {
 		return nil, err
 	}
+	LogContractAccess(ctx, s.b, "eth_getCode", address, nil, blockNrOrHash) // Quorum
 	code := state.GetCode(address)
 	return code, state.Error()
 }
@@ -831,6 +845,7 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	if state == nil || err != nil {
 		return nil, err
 	}
+	LogContractAccess(ctx, s.b, "eth_getStorageAt", address, nil, blockNrOrHash) // Quorum
 	res := state.GetState(address, common.HexToHash(key))
 	return res[:], state.Error()
 }
@@ -902,7 +917,7 @@ type account struct {
 // Before returning the result, we need to inspect the EVM and
 // perform verification check
 func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+	defer func(start time.Time) { log.FromContext(ctx).Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
@@ -973,7 +988,7 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 				readSecAttr = multitenancy.NewContractSecurityAttributeBuilder().FromEOA(msg.From()).PrivateIf(isPrivate).PartiesOnlyIf(isPrivate, managedParties).Read().Build()
 			}
 			authorizedRead, _ := b.IsAuthorized(ctx, authToken, readSecAttr)
-			log.Trace("Authorized Message Call", "read", authorizedRead, "address", contractAddress.Hex(), "securityAttribute", readSecAttr)
+			log.FromContext(ctx).Trace("Authorized Message Call", "read", authorizedRead, "address", contractAddress.Hex(), "securityAttribute", readSecAttr)
 			return authorizedRead, false, nil
 		}
 		enrichedCtx = context.WithValue(enrichedCtx, multitenancy.CtxKeyAuthorizeMessageCallFunc, authorizeMessageCallFunc)
@@ -1052,6 +1067,11 @@ func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNrOr
 	if overrides != nil {
 		accounts = *overrides
 	}
+	// Quorum
+	if args.To != nil && args.Data != nil {
+		LogContractAccess(ctx, s.b, "eth_call", *args.To, *args.Data, blockNrOrHash)
+	}
+	// End Quorum
 
 	result, err := DoCall(ctx, s.b, args, blockNrOrHash, accounts, vm.Config{}, s.b.CallTimeOut(), s.b.RPCGasCap())
 	if err != nil {
@@ -1108,14 +1128,14 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 			if transfer == nil {
 				transfer = new(hexutil.Big)
 			}
-			log.Warn("Gas estimation capped by limited funds", "original", hi, "balance", balance,
+			log.FromContext(ctx).Warn("Gas estimation capped by limited funds", "original", hi, "balance", balance,
 				"sent", transfer.ToInt(), "gasprice", args.GasPrice.ToInt(), "fundable", allowance)
 			hi = allowance.Uint64()
 		}
 	}
 	// Recap the highest gas allowance with specified gascap.
 	if gasCap != 0 && hi > gasCap {
-		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", gasCap)
+		log.FromContext(ctx).Warn("Caller gas above allowance, capping", "requested", hi, "cap", gasCap)
 		hi = gasCap
 	}
 	cap = hi
@@ -1205,9 +1225,22 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
+// Quorum: the estimations run on workers of their own, the identical ones
+// against the same block being shared and briefly cached.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
 	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
-	return DoEstimateGas(ctx, s.b, args, blockNrOrHash, s.b.RPCGasCap())
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return DoEstimateGas(ctx, s.b, args, blockNrOrHash, s.b.RPCGasCap())
+	}
+	authToken, _ := s.b.SupportsMultitenancy(ctx)
+	key := estimationKey(args, header, multitenancy.TokenIdentity(authToken))
+	return s.estimator.estimate(ctx, key, func(ctx context.Context) (hexutil.Uint64, error) {
+		return DoEstimateGas(ctx, s.b, args, blockNrOrHash, s.b.RPCGasCap())
+	})
 }
 
 // ExecutionResult groups all structured logs emitted by the EVM
@@ -1335,6 +1368,7 @@ func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool) (map[string]i
 func (s *PublicBlockChainAPI) rpcMarshalHeader(ctx context.Context, header *types.Header) map[string]interface{} {
 	fields := RPCMarshalHeader(header)
 	fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(ctx, header.Hash()))
+	s.markPrunedSeals(fields, header)
 	return fields
 }
 
@@ -1348,9 +1382,26 @@ func (s *PublicBlockChainAPI) rpcMarshalBlock(ctx context.Context, b *types.Bloc
 	if inclTx {
 		fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(ctx, b.Hash()))
 	}
+	s.markPrunedSeals(fields, b.Header())
 	return fields, err
 }
 
+// Quorum
+// markPrunedSeals sets sealsPruned in the fields of an Istanbul header whose
+// committed seals were stripped from the ancient store, for the clients to
+// tell them from headers never sealed.
+func (s *PublicBlockChainAPI) markPrunedSeals(fields map[string]interface{}, header *types.Header) {
+	if header.MixDigest != types.IstanbulDigest {
+		return
+	}
+	if extra, err := types.ExtractIstanbulExtra(header); err != nil || len(extra.CommittedSeal) > 0 {
+		return
+	}
+	if rawdb.ReadSealAttestation(s.b.ChainDb(), header.Hash(), header.Number.Uint64()) != (common.Hash{}) {
+		fields["sealsPruned"] = true
+	}
+}
+
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
 	BlockHash        *common.Hash    `json:"blockHash"`
@@ -1629,6 +1680,18 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		fields["logs"] = filteredLogs
 		receiptClone := &types.Receipt{PostState: receipt.PostState, Status: receipt.Status, Logs: filteredLogs}
 		fields["logsBloom"] = types.CreateBloom(types.Receipts{receiptClone})
+
+		// the tenants not party to a private contract creation are served the
+		// receipt without the address of the contract, as a non-party node
+		if tx.IsPrivate() && receipt.ContractAddress != (common.Address{}) {
+			ok, err := s.isContractAuthorized(ctx, authToken, extraDataReader, receipt.ContractAddress)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				fields["contractAddress"] = nil
+			}
+		}
 	}
 	return fields, nil
 }
@@ -1685,6 +1748,12 @@ type SendTxArgs struct {
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	// Quorum
+	// DeferPrivateSend makes eth_signTransaction return the unsigned private
+	// transaction with its original payload, for the payload to be stored in
+	// the private transaction manager later.
+	DeferPrivateSend bool `json:"deferPrivateSend"`
 }
 
 func (s SendTxArgs) IsPrivate() bool {
@@ -1708,6 +1777,13 @@ type PrivateTxArgs struct {
 	PrivateFor    []string               `json:"privateFor"`
 	PrivateTxType string                 `json:"restriction"`
 	PrivacyFlag   engine.PrivacyFlagType `json:"privacyFlag"`
+	// MandatoryFor is the list of public keys which must always receive the
+	// payloads of the contract, with PrivacyFlag=2(MandatoryRecipients).
+	MandatoryFor []string `json:"mandatoryFor"`
+	// ExecutionHints are the contracts the transaction is expected to touch,
+	// letting party nodes execute it concurrently with private transactions
+	// touching other contracts.
+	ExecutionHints []common.Address `json:"executionHints"`
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
@@ -1765,7 +1841,7 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 			return err
 		}
 		args.Gas = &estimated
-		log.Trace("Estimate gas usage automatically", "gas", args.Gas)
+		log.FromContext(ctx).Trace("Estimate gas usage automatically", "gas", args.Gas)
 	}
 	//Quorum
 	if args.PrivateTxType == "" {
@@ -1795,6 +1871,7 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 // TODO: this submits a signed transaction, if it is a signed private transaction that should already be recorded in the tx.
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction, privateFrom string, privateFor []string, isRaw bool) (common.Hash, error) {
+	ctx, _ = core.WithTxSubmission(ctx) // Quorum
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
@@ -1818,12 +1895,12 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction, pr
 		if tx.IsPrivate() {
 			if isRaw {
 				// for raw private transaction, the privateFrom will be derived when retrieving the private payload from Tessera
-				originalTx, privateFrom, err = buildPrivateTransactionFromRaw(tx)
+				originalTx, privateFrom, err = buildPrivateTransactionFromRaw(ctx, tx)
 				if err != nil {
 					return common.Hash{}, err
 				}
 			} else {
-				originalTx, err = buildPrivateTransaction(tx)
+				originalTx, err = buildPrivateTransaction(ctx, tx)
 				if err != nil {
 					return common.Hash{}, err
 				}
@@ -1847,12 +1924,17 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction, pr
 	if err := b.SendTx(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
+	// Quorum
+	if guard, ok := rpc.ConsistencyGuardOf(ctx).(*ConsistencyGuard); ok {
+		rpc.IssueConsistencyToken(ctx, guard.Issue(from, tx.Nonce()))
+	}
+	// End Quorum
 	if tx.To() == nil {
 		addr := crypto.CreateAddress(from, tx.Nonce())
-		log.Info("Submitted contract creation", "fullhash", tx.Hash().Hex(), "to", addr.Hex())
+		log.FromContext(ctx).Info("Submitted contract creation", "fullhash", tx.Hash().Hex(), "to", addr.Hex())
 		log.EmitCheckpoint(log.TxCreated, "tx", tx.Hash().Hex(), "to", addr.Hex())
 	} else {
-		log.Info("Submitted transaction", "fullhash", tx.Hash().Hex(), "recipient", tx.To())
+		log.FromContext(ctx).Info("Submitted transaction", "fullhash", tx.Hash().Hex(), "recipient", tx.To())
 		log.EmitCheckpoint(log.TxCreated, "tx", tx.Hash().Hex(), "to", tx.To().Hex())
 	}
 	return tx.Hash(), nil
@@ -1885,7 +1967,7 @@ func performMultitenancyChecks(ctx context.Context, authToken *proto.PreAuthenti
 	createContractSA := multitenancy.NewContractSecurityAttributeBuilder().
 		FromEOA(fromEOA).PrivateIf(tx.IsPrivate()).Create().PrivateFromOnlyIf(tx.IsPrivate(), privateArgs.PrivateFrom).Build()
 	authorizedCreate, _ := b.IsAuthorized(ctx, authToken, createContractSA)
-	log.Debug("Authorized Contract Creation", "create", authorizedCreate, "securityAttribute", createContractSA)
+	log.FromContext(ctx).Debug("Authorized Contract Creation", "create", authorizedCreate, "securityAttribute", createContractSA)
 	var authorizeCreateFunc multitenancy.AuthorizeCreateFunc = func() bool {
 		return authorizedCreate
 	}
@@ -1899,17 +1981,17 @@ func performMultitenancyChecks(ctx context.Context, authToken *proto.PreAuthenti
 		}
 		readSecAttr := multitenancy.NewContractSecurityAttributeBuilder().FromEOA(fromEOA).PrivateIf(isPrivate).PartiesOnlyIf(isPrivate, managedParties).Read().Build()
 		authorizedRead, _ := b.IsAuthorized(ctx, authToken, readSecAttr)
-		log.Trace("Authorized Message Call", "read", authorizedRead, "address", contractAddress.Hex(), "securityAttribute", readSecAttr)
+		log.FromContext(ctx).Trace("Authorized Message Call", "read", authorizedRead, "address", contractAddress.Hex(), "securityAttribute", readSecAttr)
 		writeSecAttr := multitenancy.NewContractSecurityAttributeBuilder().FromEOA(fromEOA).PrivateIf(isPrivate).PartiesOnlyIf(isPrivate, managedParties).Write().Build()
 		authorizedWrite, _ := b.IsAuthorized(ctx, authToken, writeSecAttr)
-		log.Trace("Authorized Message Call", "write", authorizedWrite, "address", contractAddress.Hex(), "securityAttribute", writeSecAttr)
+		log.FromContext(ctx).Trace("Authorized Message Call", "write", authorizedWrite, "address", contractAddress.Hex(), "securityAttribute", writeSecAttr)
 		return authorizedRead, authorizedWrite, nil
 	}
 	enrichedCtx := ctx
 	enrichedCtx = context.WithValue(enrichedCtx, multitenancy.CtxKeyAuthorizeCreateFunc, authorizeCreateFunc)
 	enrichedCtx = context.WithValue(enrichedCtx, multitenancy.CtxKeyAuthorizeMessageCallFunc, authorizeMessageCallFunc)
 	if _, err := runSimulation(enrichedCtx, b, fromEOA, tx); err != nil {
-		log.Error("Simulated execution for multitenancy", "error", err)
+		log.FromContext(ctx).Error("Simulated execution for multitenancy", "error", err)
 		return err
 	}
 	return nil
@@ -1918,8 +2000,8 @@ func performMultitenancyChecks(ctx context.Context, authToken *proto.PreAuthenti
 // Quorum
 //
 // Retrieve private payload and construct the original transaction
-func buildPrivateTransaction(tx *types.Transaction) (*types.Transaction, error) {
-	_, _, privatePayload, _, revErr := private.P.Receive(common.BytesToEncryptedPayloadHash(tx.Data()))
+func buildPrivateTransaction(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	_, _, privatePayload, _, revErr := private.P.Receive(ctx, common.BytesToEncryptedPayloadHash(tx.Data()))
 	if revErr != nil {
 		return nil, revErr
 	}
@@ -1935,8 +2017,8 @@ func buildPrivateTransaction(tx *types.Transaction) (*types.Transaction, error)
 // Quorum
 //
 // Retrieve private payload and construct the original transaction along with privateFrom information
-func buildPrivateTransactionFromRaw(tx *types.Transaction) (*types.Transaction, string, error) {
-	privatePayload, privateFrom, _, revErr := private.P.ReceiveRaw(common.BytesToEncryptedPayloadHash(tx.Data()))
+func buildPrivateTransactionFromRaw(ctx context.Context, tx *types.Transaction) (*types.Transaction, string, error) {
+	privatePayload, privateFrom, _, revErr := private.P.ReceiveRaw(ctx, common.BytesToEncryptedPayloadHash(tx.Data()))
 	if revErr != nil {
 		return nil, "", revErr
 	}
@@ -1953,7 +2035,7 @@ func buildPrivateTransactionFromRaw(tx *types.Transaction) (*types.Transaction,
 // It returns the EVM instance upon completion
 func runSimulation(ctx context.Context, b Backend, from common.Address, tx *types.Transaction) (*vm.EVM, error) {
 	defer func(start time.Time) {
-		log.Debug("Simulated Execution EVM call finished", "runtime", time.Since(start))
+		log.FromContext(ctx).Debug("Simulated Execution EVM call finished", "runtime", time.Since(start))
 	}(time.Now())
 
 	// Set sender address or use a default if none specified
@@ -2014,6 +2096,8 @@ func runSimulation(ctx context.Context, b Backend, from common.Address, tx *type
 // SendTransaction creates a transaction for the given argument, sign it and submit it to the
 // transaction pool.
 func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
+	ctx, _ = core.WithTxSubmission(ctx)         // Quorum
+	applySendDefaults(ctx, &args.PrivateTxArgs) // Quorum
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: args.From}
 
@@ -2035,6 +2119,9 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	}
 
 	// Quorum
+	if err := checkArgsSlotPolicy(ctx, s.b, &args); err != nil {
+		return common.Hash{}, err
+	}
 	isPrivate, data, err := checkAndHandlePrivateTransaction(ctx, s.b, args.toTransaction(), &args.PrivateTxArgs, args.From, NormalTransaction)
 
 	if err != nil {
@@ -2070,6 +2157,10 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 
 // FillTransaction fills the defaults (nonce, gas, gasPrice) on a given unsigned transaction,
 // and returns it to the caller for further processing (signing + broadcast)
+//
+// Quorum: the payload of a private transaction is stored in the private
+// transaction manager and replaced by its hash, also returned in the private
+// summary, for an external signer to sign the transaction as is.
 func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
 	// Set some sanity defaults and terminate on failure
 	if err := args.setDefaults(ctx, s.b); err != nil {
@@ -2079,11 +2170,17 @@ func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args Sen
 	// Quorum
 	isPrivate, hash, err := checkAndHandlePrivateTransaction(ctx, s.b, args.toTransaction(), &args.PrivateTxArgs, args.From, FillTransaction)
 	if err != nil {
-		return nil, err
+		return nil, newStoredPayloadError(hash, err)
 	}
-	if isPrivate && !common.EmptyEncryptedPayloadHash(hash) {
-		// replace the original payload with encrypted payload hash
-		args.Data = hash.BytesTypeRef()
+	var summary *PrivateTransactionSummary
+	if isPrivate {
+		var payloadHash *hexutil.Bytes
+		if !common.EmptyEncryptedPayloadHash(hash) {
+			// replace the original payload with encrypted payload hash
+			payloadHash = hash.BytesTypeRef()
+			args.Data, args.Input = payloadHash, nil
+		}
+		summary = newPrivateTransactionSummary(payloadHash, &args.PrivateTxArgs)
 	}
 	// /Quorum
 
@@ -2097,9 +2194,9 @@ func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args Sen
 
 	data, err := rlp.EncodeToBytes(tx)
 	if err != nil {
-		return nil, err
+		return nil, newStoredPayloadError(hash, err)
 	}
-	return &SignTransactionResult{data, tx}, nil
+	return &SignTransactionResult{Raw: data, Tx: tx, Private: summary}, nil
 }
 
 // SendRawTransaction will add the signed transaction to the transaction pool.
@@ -2109,6 +2206,11 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
 		return common.Hash{}, err
 	}
+	// Quorum
+	if err := checkSignedSlotPolicy(ctx, s.b, tx, false); err != nil {
+		return common.Hash{}, err
+	}
+	// /Quorum
 	return SubmitTransaction(ctx, s.b, tx, "", nil, false)
 }
 
@@ -2117,6 +2219,14 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 // SendRawPrivateTransaction will add the signed transaction to the transaction pool.
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicTransactionPoolAPI) SendRawPrivateTransaction(ctx context.Context, encodedTx hexutil.Bytes, args SendRawTxArgs) (common.Hash, error) {
+	return SendRawPrivateTransaction(ctx, s.b, encodedTx, args)
+}
+
+// SendRawPrivateTransaction distributes the payload of the signed private
+// transaction, stored with storeraw, to the parties of args and adds the
+// transaction to the pool, for eth_sendRawPrivateTransaction and GraphQL.
+func SendRawPrivateTransaction(ctx context.Context, b Backend, encodedTx hexutil.Bytes, args SendRawTxArgs) (common.Hash, error) {
+	ctx, _ = core.WithTxSubmission(ctx)
 
 	tx := new(types.Transaction)
 	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
@@ -2124,7 +2234,10 @@ func (s *PublicTransactionPoolAPI) SendRawPrivateTransaction(ctx context.Context
 	}
 
 	// Quorum
-	isPrivate, _, err := checkAndHandlePrivateTransaction(ctx, s.b, tx, &args.PrivateTxArgs, common.Address{}, RawTransaction)
+	if err := checkSignedSlotPolicy(ctx, b, tx, true); err != nil {
+		return common.Hash{}, err
+	}
+	isPrivate, _, err := checkAndHandlePrivateTransaction(ctx, b, tx, &args.PrivateTxArgs, common.Address{}, RawTransaction)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -2133,7 +2246,7 @@ func (s *PublicTransactionPoolAPI) SendRawPrivateTransaction(ctx context.Context
 		return common.Hash{}, fmt.Errorf("transaction is not private")
 	}
 	// /Quorum
-	return SubmitTransaction(ctx, s.b, tx, "", args.PrivateFor, true)
+	return SubmitTransaction(ctx, b, tx, "", args.PrivateFor, true)
 }
 
 // Sign calculates an ECDSA signature for:
@@ -2165,11 +2278,65 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 type SignTransactionResult struct {
 	Raw hexutil.Bytes      `json:"raw"`
 	Tx  *types.Transaction `json:"tx"`
+
+	// Quorum
+	Private *PrivateTransactionSummary `json:"private,omitempty"`
+}
+
+// Quorum
+//
+// PrivateTransactionSummary describes a private transaction for review on a
+// signing device.
+type PrivateTransactionSummary struct {
+	// PayloadHash is the hash of the encrypted payload in the transaction data,
+	// nil for a transaction whose payload is yet to be stored.
+	PayloadHash *hexutil.Bytes         `json:"payloadHash"`
+	Recipients  int                    `json:"recipients"`
+	PrivacyFlag engine.PrivacyFlagType `json:"privacyFlag"`
+}
+
+func newPrivateTransactionSummary(hash *hexutil.Bytes, args *PrivateTxArgs) *PrivateTransactionSummary {
+	return &PrivateTransactionSummary{
+		PayloadHash: hash,
+		Recipients:  len(args.PrivateFor),
+		PrivacyFlag: args.PrivacyFlag,
+	}
+}
+
+// Quorum
+//
+// storedPayloadError is the error of a transaction failing once its private
+// payload is stored, with the hash of the payload for it to be cleaned up.
+type storedPayloadError struct {
+	error
+	hash common.EncryptedPayloadHash
+}
+
+// newStoredPayloadError returns err as is if no payload was stored.
+func newStoredPayloadError(hash common.EncryptedPayloadHash, err error) error {
+	if common.EmptyEncryptedPayloadHash(hash) {
+		return err
+	}
+	return &storedPayloadError{
+		error: fmt.Errorf("private payload %s stored: %v", hash.Hex(), err),
+		hash:  hash,
+	}
+}
+
+// ErrorData returns the hex encoded hash of the stored payload.
+func (e *storedPayloadError) ErrorData() interface{} {
+	return e.hash.Hex()
 }
 
 // SignTransaction will sign the given transaction with the from account.
 // The node needs to have the private key of the account corresponding with
 // the given from address and it needs to be unlocked.
+//
+// Quorum: the payload of a private transaction is stored in the private
+// transaction manager and replaced by its hash before signing. The signed
+// transaction is then distributed with eth_sendRawPrivateTransaction and the
+// same privateFor. With deferPrivateSend, the transaction is returned unsigned
+// with its original payload instead.
 func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
@@ -2197,6 +2364,32 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	}
 
 	// Quorum
+	var summary *PrivateTransactionSummary
+	if args.IsPrivate() {
+		if args.DeferPrivateSend {
+			if err := args.PrivacyFlag.Validate(); err != nil {
+				return nil, err
+			}
+			template := args.toTransaction()
+			template.SetPrivate()
+			data, err := rlp.EncodeToBytes(template)
+			if err != nil {
+				return nil, err
+			}
+			return &SignTransactionResult{Raw: data, Tx: template, Private: newPrivateTransactionSummary(nil, &args.PrivateTxArgs)}, nil
+		}
+		_, hash, err := checkAndHandlePrivateTransaction(ctx, s.b, args.toTransaction(), &args.PrivateTxArgs, args.From, FillTransaction)
+		if err != nil {
+			return nil, err
+		}
+		var payloadHash *hexutil.Bytes
+		if !common.EmptyEncryptedPayloadHash(hash) {
+			// replace the original payload with encrypted payload hash
+			payloadHash = hash.BytesTypeRef()
+			args.Data, args.Input = payloadHash, nil
+		}
+		summary = newPrivateTransactionSummary(payloadHash, &args.PrivateTxArgs)
+	}
 	toSign := args.toTransaction()
 	if args.IsPrivate() {
 		toSign.SetPrivate()
@@ -2211,7 +2404,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	return &SignTransactionResult{data, tx}, nil
+	return &SignTransactionResult{Raw: data, Tx: tx, Private: summary}, nil
 }
 
 // PendingTransactions returns the transactions that are in the transaction pool
@@ -2362,7 +2555,7 @@ func (api *PublicDebugAPI) TestSignCliqueBlock(ctx context.Context, address comm
 		return common.Address{}, err
 	}
 	sealHash := clique.SealHash(header).Bytes()
-	log.Info("test signing of clique block",
+	log.FromContext(ctx).Info("test signing of clique block",
 		"Sealhash", fmt.Sprintf("%x", sealHash),
 		"signature", fmt.Sprintf("%x", signature))
 	pubkey, err := crypto.Ecrecover(sealHash, signature)
@@ -2497,11 +2690,85 @@ type AsyncResultFailure struct {
 type Async struct {
 	sync.Mutex
 	sem chan struct{}
+
+	// in-flight sends, keyed by a sequence number reflecting submission order
+	pending map[uint64]*PendingDistribution
+	nextId  uint64
+}
+
+// PendingDistribution describes an asynchronous send whose private payload is
+// being distributed. It deliberately carries no payload data.
+type PendingDistribution struct {
+	Id          uint64         `json:"id"`
+	Submitted   time.Time      `json:"submitted"`
+	From        common.Address `json:"from"`
+	Recipients  int            `json:"recipients"`
+	HasCallback bool           `json:"hasCallback"`
+}
+
+var (
+	asyncInFlightGauge  = metrics.NewRegisteredGauge("quorum/async/inflight", nil)
+	asyncSucceededMeter = metrics.NewRegisteredMeter("quorum/async/succeeded", nil)
+	asyncFailedMeter    = metrics.NewRegisteredMeter("quorum/async/failed", nil)
+)
+
+// track registers an accepted asynchronous send.
+func (a *Async) track(args *AsyncSendTxArgs) uint64 {
+	a.Lock()
+	defer a.Unlock()
+
+	a.nextId++
+	a.pending[a.nextId] = &PendingDistribution{
+		Id:          a.nextId,
+		Submitted:   time.Now(),
+		From:        args.From,
+		Recipients:  len(args.PrivateFor),
+		HasCallback: args.CallbackUrl != "",
+	}
+	asyncInFlightGauge.Update(int64(len(a.pending)))
+	return a.nextId
+}
+
+// untrack removes a send once its transaction reached the pool or failed.
+func (a *Async) untrack(id uint64, err error) {
+	a.Lock()
+	defer a.Unlock()
+
+	delete(a.pending, id)
+	asyncInFlightGauge.Update(int64(len(a.pending)))
+	if err != nil {
+		asyncFailedMeter.Mark(1)
+	} else {
+		asyncSucceededMeter.Mark(1)
+	}
+}
+
+// snapshot returns up to limit in-flight sends, oldest first, skipping the
+// first offset ones, along with the total number of in-flight sends.
+func (a *Async) snapshot(offset, limit int) ([]PendingDistribution, int) {
+	a.Lock()
+	entries := make([]PendingDistribution, 0, len(a.pending))
+	for _, entry := range a.pending {
+		entries = append(entries, *entry)
+	}
+	a.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Id < entries[j].Id })
+	total := len(entries)
+	if offset >= total {
+		return []PendingDistribution{}, total
+	}
+	entries = entries[offset:]
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, total
 }
 
-func (s *PublicTransactionPoolAPI) send(ctx context.Context, asyncArgs AsyncSendTxArgs) {
+func (s *PublicTransactionPoolAPI) send(ctx context.Context, asyncArgs AsyncSendTxArgs, id uint64) {
 
 	txHash, err := s.SendTransaction(ctx, asyncArgs.SendTxArgs)
+	async.untrack(id, err)
 
 	if asyncArgs.CallbackUrl != "" {
 
@@ -2520,12 +2787,12 @@ func (s *PublicTransactionPoolAPI) send(ctx context.Context, asyncArgs AsyncSend
 		buf := new(bytes.Buffer)
 		err := json.NewEncoder(buf).Encode(resultResponse)
 		if err != nil {
-			log.Info("Error encoding callback JSON", "err", err.Error())
+			log.FromContext(ctx).Info("Error encoding callback JSON", "err", err.Error())
 			return
 		}
 		_, err = http.Post(asyncArgs.CallbackUrl, "application/json", buf)
 		if err != nil {
-			log.Info("Error sending callback", "err", err.Error())
+			log.FromContext(ctx).Info("Error sending callback", "err", err.Error())
 			return
 		}
 	}
@@ -2534,7 +2801,8 @@ func (s *PublicTransactionPoolAPI) send(ctx context.Context, asyncArgs AsyncSend
 
 func newAsync(n int) *Async {
 	a := &Async{
-		sem: make(chan struct{}, n),
+		sem:     make(chan struct{}, n),
+		pending: make(map[uint64]*PendingDistribution),
 	}
 	return a
 }
@@ -2556,8 +2824,9 @@ func (s *PublicTransactionPoolAPI) SendTransactionAsync(ctx context.Context, arg
 
 	select {
 	case async.sem <- struct{}{}:
+		id := async.track(&args)
 		go func() {
-			s.send(ctx, args)
+			s.send(ctx, args, id)
 			<-async.sem
 		}()
 		return common.Hash{}, nil
@@ -2567,28 +2836,109 @@ func (s *PublicTransactionPoolAPI) SendTransactionAsync(ctx context.Context, arg
 }
 
 // GetQuorumPayload returns the contents of a private transaction
-func (s *PublicBlockChainAPI) GetQuorumPayload(digestHex string) (string, error) {
+func (s *PublicBlockChainAPI) GetQuorumPayload(ctx context.Context, digestHex string) (string, error) {
 	if !private.IsQuorumPrivacyEnabled() {
 		return "", fmt.Errorf("PrivateTransactionManager is not enabled")
 	}
+	hash, err := parseQuorumDigest(digestHex)
+	if err != nil {
+		return "", err
+	}
+	_, _, data, _, err := private.P.Receive(ctx, hash)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("0x%x", data), nil
+}
+
+// GetPrivateStateRoot returns the root of the private state the node computed
+// for the block.
+func (s *PublicBlockChainAPI) GetPrivateStateRoot(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if header == nil {
+		return common.Hash{}, errors.New("block not found")
+	}
+	return PrivateStateRoot(s.b, header)
+}
+
+// PrivateStateRoot returns the root of the private state the node computed
+// for the block of the header. The node has none for the genesis, the blocks
+// it did not process, e.g. as they predate its sync, and the pending block.
+func PrivateStateRoot(b Backend, header *types.Header) (common.Hash, error) {
+	root := rawdb.GetPrivateStateRoot(b.ChainDb(), header.Root)
+	if root == (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("private state root of block %d is unavailable, the node did not process the block", header.Number)
+	}
+	return root, nil
+}
+
+// PrivatePayloadResult is the contents of a private transaction of a batch,
+// or the error retrieving it.
+type PrivatePayloadResult struct {
+	Payload hexutil.Bytes `json:"payload"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// GetPrivatePayloads returns the contents of private transactions in the order
+// of their digests, retrieving them in one round trip to the transaction
+// manager if it supports it. The digests which are invalid or unknown to the
+// transaction manager only fail their own result.
+func (s *PublicBlockChainAPI) GetPrivatePayloads(ctx context.Context, digestsHex []string) ([]PrivatePayloadResult, error) {
+	if !private.IsQuorumPrivacyEnabled() {
+		return nil, fmt.Errorf("PrivateTransactionManager is not enabled")
+	}
+	results := make([]PrivatePayloadResult, len(digestsHex))
+	hashes := make([]common.EncryptedPayloadHash, 0, len(digestsHex))
+	indexes := make([]int, 0, len(digestsHex)) // of the results of the hashes
+	for i, digestHex := range digestsHex {
+		hash, err := parseQuorumDigest(digestHex)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		hashes = append(hashes, hash)
+		indexes = append(indexes, i)
+	}
+	if len(hashes) == 0 {
+		return results, nil
+	}
+	received, err := private.ReceiveBatch(ctx, private.P, hashes)
+	if err != nil {
+		return nil, err
+	}
+	for j, payload := range received {
+		result := &results[indexes[j]]
+		switch {
+		case payload.Err != nil:
+			result.Error = payload.Err.Error()
+		case payload.Payload == nil:
+			result.Error = "private payload not found"
+		default:
+			result.Payload = payload.Payload
+		}
+	}
+	return results, nil
+}
+
+// parseQuorumDigest parses the hex encoded hash of an encrypted payload.
+func parseQuorumDigest(digestHex string) (common.EncryptedPayloadHash, error) {
 	if len(digestHex) < 3 {
-		return "", fmt.Errorf("Invalid digest hex")
+		return common.EncryptedPayloadHash{}, fmt.Errorf("Invalid digest hex")
 	}
 	if digestHex[:2] == "0x" {
 		digestHex = digestHex[2:]
 	}
 	b, err := hex.DecodeString(digestHex)
 	if err != nil {
-		return "", err
+		return common.EncryptedPayloadHash{}, err
 	}
 	if len(b) != common.EncryptedPayloadHashLength {
-		return "", fmt.Errorf("Expected a Quorum digest of length 64, but got %d", len(b))
-	}
-	_, _, data, _, err := private.P.Receive(common.BytesToEncryptedPayloadHash(b))
-	if err != nil {
-		return "", err
+		return common.EncryptedPayloadHash{}, fmt.Errorf("Expected a Quorum digest of length 64, but got %d", len(b))
 	}
-	return fmt.Sprintf("0x%x", data), nil
+	return common.BytesToEncryptedPayloadHash(b), nil
 }
 
 func checkAndHandlePrivateTransaction(ctx context.Context, b Backend, tx *types.Transaction, privateTxArgs *PrivateTxArgs, from common.Address, txnType TransactionType) (isPrivate bool, hash common.EncryptedPayloadHash, err error) {
@@ -2597,6 +2947,10 @@ func checkAndHandlePrivateTransaction(ctx context.Context, b Backend, tx *types.
 		return
 	}
 
+	if err = b.CheckWritable(); err != nil {
+		return
+	}
+
 	if err = privateTxArgs.PrivacyFlag.Validate(); err != nil {
 		return
 	}
@@ -2606,6 +2960,10 @@ func checkAndHandlePrivateTransaction(ctx context.Context, b Backend, tx *types.
 		return
 	}
 
+	if err = checkMandatoryRecipients(privateTxArgs); err != nil {
+		return
+	}
+
 	if len(tx.Data()) > 0 {
 		// check private contract exists on the node initiating the transaction
 		if tx.To() != nil && privateTxArgs.PrivacyFlag.IsNotStandardPrivate() {
@@ -2621,13 +2979,34 @@ func checkAndHandlePrivateTransaction(ctx context.Context, b Backend, tx *types.
 		}
 
 		hash, err = handlePrivateTransaction(ctx, b, tx, privateTxArgs, from, txnType)
-
+		if sub := core.TxSubmissionFromContext(ctx); sub != nil && err == nil {
+			sub.Distributed = time.Now()
+		}
 		return
 	}
 
 	return
 }
 
+// checkMandatoryRecipients fails unless the mandatory recipients are given
+// exactly with PrivacyFlag=2(MandatoryRecipients), and the private transaction
+// manager supports them.
+func checkMandatoryRecipients(privateTxArgs *PrivateTxArgs) error {
+	if privateTxArgs.PrivacyFlag != engine.PrivacyFlagMandatoryRecipients {
+		if len(privateTxArgs.MandatoryFor) > 0 {
+			return errors.New("privacy metadata invalid. mandatory recipients are only applicable for PrivacyFlag=2(MandatoryRecipients)")
+		}
+		return nil
+	}
+	if len(privateTxArgs.MandatoryFor) == 0 {
+		return errors.New("missing mandatory recipients data. if no mandatory recipients required consider using PrivacyFlag=1(PartyProtection)")
+	}
+	if !private.P.HasFeature(engine.MandatoryRecipients) {
+		return engine.ErrPrivateTxManagerDoesNotSupportMandatoryRecipients
+	}
+	return nil
+}
+
 // If transaction is raw, the tx payload is indeed the hash of the encrypted payload
 //
 // For private transaction, run a simulated execution in order to
@@ -2637,26 +3016,33 @@ func checkAndHandlePrivateTransaction(ctx context.Context, b Backend, tx *types.
 // to obtain hash of the encrypted private payload
 func handlePrivateTransaction(ctx context.Context, b Backend, tx *types.Transaction, privateTxArgs *PrivateTxArgs, from common.Address, txnType TransactionType) (hash common.EncryptedPayloadHash, err error) {
 	defer func(start time.Time) {
-		log.Debug("Handle Private Transaction finished", "took", time.Since(start))
+		log.FromContext(ctx).Debug("Handle Private Transaction finished", "took", time.Since(start))
 	}(time.Now())
 
 	data := tx.Data()
 
 	var affectedCATxHashes common.EncryptedPayloadHashes // of affected contract accounts
 	var merkleRoot common.Hash
-	log.Debug("sending private tx", "txnType", txnType, "data", common.FormatTerminalString(data), "privatefrom", privateTxArgs.PrivateFrom, "privatefor", privateTxArgs.PrivateFor, "privacyFlag", privateTxArgs.PrivacyFlag)
+	log.FromContext(ctx).Debug("sending private tx", "txnType", txnType, "data", common.FormatTerminalString(data), "privatefrom", privateTxArgs.PrivateFrom, "privatefor", privateTxArgs.PrivateFor, "privacyFlag", privateTxArgs.PrivacyFlag)
+
+	// the payload of a raw transaction was stored from its privateFrom already
+	if txnType != RawTransaction {
+		if err = private.CheckPrivateFrom(privateTxArgs.PrivateFrom); err != nil {
+			return
+		}
+	}
 
 	switch txnType {
 	case FillTransaction:
-		hash, err = private.P.StoreRaw(data, privateTxArgs.PrivateFrom)
+		hash, err = private.P.StoreRaw(ctx, data, privateTxArgs.PrivateFrom)
 		return
 	case RawTransaction:
 		hash = common.BytesToEncryptedPayloadHash(data)
-		privatePayload, _, _, revErr := private.P.ReceiveRaw(hash)
+		privatePayload, _, _, revErr := private.P.ReceiveRaw(ctx, hash)
 		if revErr != nil {
 			return common.EncryptedPayloadHash{}, revErr
 		}
-		log.Trace("received raw payload", "hash", hash, "privatepayload", common.FormatTerminalString(privatePayload))
+		log.FromContext(ctx).Trace("received raw payload", "hash", hash, "privatepayload", common.FormatTerminalString(privatePayload))
 		var privateTx *types.Transaction
 		if tx.To() == nil {
 			privateTx = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), tx.GasPrice(), privatePayload)
@@ -2664,15 +3050,17 @@ func handlePrivateTransaction(ctx context.Context, b Backend, tx *types.Transact
 			privateTx = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), tx.GasPrice(), privatePayload)
 		}
 		affectedCATxHashes, merkleRoot, err = simulateExecutionForPE(ctx, b, from, privateTx, privateTxArgs)
-		log.Trace("after simulation", "affectedCATxHashes", affectedCATxHashes, "merkleRoot", merkleRoot, "privacyFlag", privateTxArgs.PrivacyFlag, "error", err)
+		log.FromContext(ctx).Trace("after simulation", "affectedCATxHashes", affectedCATxHashes, "merkleRoot", merkleRoot, "privacyFlag", privateTxArgs.PrivacyFlag, "error", err)
 		if err != nil {
 			return
 		}
 
-		_, _, data, err = private.P.SendSignedTx(hash, privateTxArgs.PrivateFor, &engine.ExtraMetadata{
-			ACHashes:     affectedCATxHashes,
-			ACMerkleRoot: merkleRoot,
-			PrivacyFlag:  privateTxArgs.PrivacyFlag,
+		_, _, data, err = private.P.SendSignedTx(ctx, hash, privateTxArgs.PrivateFor, &engine.ExtraMetadata{
+			ACHashes:            affectedCATxHashes,
+			ACMerkleRoot:        merkleRoot,
+			PrivacyFlag:         privateTxArgs.PrivacyFlag,
+			MandatoryRecipients: privateTxArgs.MandatoryFor,
+			ExecutionHints:      privateTxArgs.ExecutionHints,
 		})
 		if err != nil {
 			return
@@ -2680,26 +3068,29 @@ func handlePrivateTransaction(ctx context.Context, b Backend, tx *types.Transact
 
 	case NormalTransaction:
 		affectedCATxHashes, merkleRoot, err = simulateExecutionForPE(ctx, b, from, tx, privateTxArgs)
-		log.Trace("after simulation", "affectedCATxHashes", affectedCATxHashes, "merkleRoot", merkleRoot, "privacyFlag", privateTxArgs.PrivacyFlag, "error", err)
+		log.FromContext(ctx).Trace("after simulation", "affectedCATxHashes", affectedCATxHashes, "merkleRoot", merkleRoot, "privacyFlag", privateTxArgs.PrivacyFlag, "error", err)
 		if err != nil {
 			return
 		}
 
-		_, _, hash, err = private.P.Send(data, privateTxArgs.PrivateFrom, privateTxArgs.PrivateFor, &engine.ExtraMetadata{
-			ACHashes:     affectedCATxHashes,
-			ACMerkleRoot: merkleRoot,
-			PrivacyFlag:  privateTxArgs.PrivacyFlag,
+		_, _, hash, err = private.P.Send(ctx, data, privateTxArgs.PrivateFrom, privateTxArgs.PrivateFor, &engine.ExtraMetadata{
+			ACHashes:            affectedCATxHashes,
+			ACMerkleRoot:        merkleRoot,
+			PrivacyFlag:         privateTxArgs.PrivacyFlag,
+			MandatoryRecipients: privateTxArgs.MandatoryFor,
+			ExecutionHints:      privateTxArgs.ExecutionHints,
 		})
 		if err != nil {
 			return
 		}
 	}
 
-	log.Info("sent private signed tx",
+	log.FromContext(ctx).Info("sent private signed tx",
 		"data", common.FormatTerminalString(data),
 		"hash", hash,
 		"privatefrom", privateTxArgs.PrivateFrom,
 		"privatefor", privateTxArgs.PrivateFor,
+		"mandatoryfor", privateTxArgs.MandatoryFor,
 		"affectedCATxHashes", affectedCATxHashes,
 		"merkleroot", merkleRoot,
 		"privacyflag", privateTxArgs.PrivacyFlag)
@@ -2719,15 +3110,15 @@ func simulateExecutionForPE(ctx context.Context, b Backend, from common.Address,
 
 	evm, err := runSimulation(ctx, b, from, privateTx)
 	if evm == nil {
-		log.Debug("TX Simulation setup failed", "error", err)
+		log.FromContext(ctx).Debug("TX Simulation setup failed", "error", err)
 		return nil, common.Hash{}, err
 	}
 	if err != nil {
 		if privateTxArgs.PrivacyFlag.IsStandardPrivate() {
-			log.Debug("An error occurred during StandardPrivate transaction simulation. "+
+			log.FromContext(ctx).Debug("An error occurred during StandardPrivate transaction simulation. "+
 				"Continuing to simulation checks.", "error", err)
 		} else {
-			log.Trace("Simulated execution", "error", err)
+			log.FromContext(ctx).Trace("Simulated execution", "error", err)
 			return nil, common.Hash{}, err
 		}
 	}
@@ -2735,7 +3126,7 @@ func simulateExecutionForPE(ctx context.Context, b Backend, from common.Address,
 	var merkleRoot common.Hash
 	addresses := evm.AffectedContracts()
 	privacyFlag := privateTxArgs.PrivacyFlag
-	log.Trace("after simulation run", "numberOfAffectedContracts", len(addresses), "privacyFlag", privacyFlag)
+	log.FromContext(ctx).Trace("after simulation run", "numberOfAffectedContracts", len(addresses), "privacyFlag", privacyFlag)
 	for _, addr := range addresses {
 		// GetPrivacyMetadata is invoked directly on the privateState (as the tx is private) and it returns:
 		// 1. public contacts: privacyMetadata = nil, err = nil
@@ -2743,7 +3134,7 @@ func simulateExecutionForPE(ctx context.Context, b Backend, from common.Address,
 		// 2.1. StandardPrivate:     privacyMetadata = nil, err = "The provided contract does not have privacy metadata"
 		// 2.2. PartyProtection/PSV: privacyMetadata = <data>, err = nil
 		privacyMetadata, err := evm.StateDB.GetPrivacyMetadata(addr)
-		log.Debug("Found affected contract", "address", addr.Hex(), "privacyMetadata", privacyMetadata)
+		log.FromContext(ctx).Debug("Found affected contract", "address", addr.Hex(), "privacyMetadata", privacyMetadata)
 		//privacyMetadata not found=non-party, or another db error
 		if err != nil && privacyFlag.IsNotStandardPrivate() {
 			return nil, common.Hash{}, errors.New("PrivacyMetadata not found: " + err.Error())
@@ -2768,7 +3159,7 @@ func simulateExecutionForPE(ctx context.Context, b Backend, from common.Address,
 			return nil, common.Hash{}, err
 		}
 	}
-	log.Trace("post-execution run", "merkleRoot", merkleRoot, "affectedhashes", affectedContractsHashes)
+	log.FromContext(ctx).Trace("post-execution run", "merkleRoot", merkleRoot, "affectedhashes", affectedContractsHashes)
 	return affectedContractsHashes, merkleRoot, nil
 }
 
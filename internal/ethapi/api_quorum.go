@@ -0,0 +1,116 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/http"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/accesslog"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxPendingDistributions caps the number of entries returned per call of
+// quorum_pendingDistributions.
+const maxPendingDistributions = 100
+
+var errAccessLogDisabled = errors.New("accesses to the contract are not logged")
+
+// PublicQuorumAPI provides Quorum specific operational information.
+type PublicQuorumAPI struct {
+	b Backend
+}
+
+// NewPublicQuorumAPI creates a new Quorum API.
+func NewPublicQuorumAPI(b Backend) *PublicQuorumAPI {
+	return &PublicQuorumAPI{b}
+}
+
+// PendingDistributionsResult is a page of in-flight asynchronous sends.
+type PendingDistributionsResult struct {
+	Total   int                   `json:"total"`
+	Offset  int                   `json:"offset"`
+	Entries []PendingDistribution `json:"entries"`
+}
+
+// PendingDistributions returns the eth_sendTransactionAsync requests whose
+// private payloads are being distributed and whose transactions have not yet
+// reached the transaction pool, oldest first. At most limit entries, capped to
+// maxPendingDistributions, are returned after skipping the first offset ones.
+func (api *PublicQuorumAPI) PendingDistributions(offset *int, limit *int) *PendingDistributionsResult {
+	from, count := 0, maxPendingDistributions
+	if offset != nil && *offset > 0 {
+		from = *offset
+	}
+	if limit != nil && *limit > 0 && *limit < maxPendingDistributions {
+		count = *limit
+	}
+	entries, total := async.snapshot(from, count)
+	return &PendingDistributionsResult{
+		Total:   total,
+		Offset:  from,
+		Entries: entries,
+	}
+}
+
+// PtmEndpointStats returns the latency, error rate and share of the traffic of
+// the endpoints of a clustered private transaction manager.
+func (api *PublicQuorumAPI) PtmEndpointStats() ([]http.EndpointStats, error) {
+	router := private.EndpointRouter()
+	if router == nil {
+		return nil, private.ErrSingleEndpoint
+	}
+	return router.Stats(), nil
+}
+
+// AccessLogSummary returns the number of reads of the state of a contract
+// whose accesses are logged between the given unix times, the current time if
+// to is 0. The summary does not contain the individual accesses, which are only
+// available to the node operator.
+func (api *PublicQuorumAPI) AccessLogSummary(contract common.Address, from uint64, to uint64) (*accesslog.Summary, error) {
+	end := time.Now()
+	if to != 0 {
+		end = time.Unix(int64(to), 0)
+	}
+	summary := accesslog.Get().Summary(contract, time.Unix(int64(from), 0), end)
+	if summary == nil {
+		return nil, errAccessLogDisabled
+	}
+	return summary, nil
+}
+
+// BlockStats returns the statistics of the canonical blocks in [from, to]
+// aggregated by the block statistics index. Blocks not indexed are omitted.
+func (api *PublicQuorumAPI) BlockStats(from uint64, to uint64) ([]*types.BlockStats, error) {
+	return core.BlockStatsRange(api.b.ChainDb(), from, to)
+}
+
+// DailyStats returns the statistics of the canonical blocks per UTC day
+// between the given YYYY-MM-DD dates, inclusive. Days without indexed blocks
+// are omitted.
+func (api *PublicQuorumAPI) DailyStats(fromDate string, toDate string) ([]*types.DailyStats, error) {
+	return core.DailyStatsRange(api.b.ChainDb(), fromDate, toDate)
+}
+
+// LogContractAccess records a read of the state of a contract at the given
+// block if its accesses are logged. The selector of the method called is taken
+// from the call data, if any.
+func LogContractAccess(ctx context.Context, b Backend, source string, contract common.Address, data []byte, blockNrOrHash rpc.BlockNumberOrHash) {
+	logger := accesslog.Get()
+	if !logger.Enabled(contract) {
+		return
+	}
+	entry := &accesslog.Entry{Source: source, Contract: contract}
+	if len(data) >= 4 {
+		entry.Method = hexutil.Encode(data[:4])
+	}
+	if header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash); err == nil && header != nil {
+		entry.BlockNumber, entry.BlockHash = header.Number.Uint64(), header.Hash()
+	}
+	logger.Record(ctx, entry)
+}
@@ -0,0 +1,187 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicQuorumAPI_PendingDistributions(t *testing.T) {
+	api := NewPublicQuorumAPI(nil)
+	from := common.HexToAddress("0x1")
+
+	var ids []uint64
+	for i := 0; i < maxPendingDistributions+5; i++ {
+		args := &AsyncSendTxArgs{CallbackUrl: "http://localhost"}
+		args.From = from
+		args.PrivateFor = []string{"a", "b"}
+		ids = append(ids, async.track(args))
+	}
+	defer func() {
+		for _, id := range ids {
+			async.untrack(id, nil)
+		}
+	}()
+
+	page := api.PendingDistributions(nil, nil)
+	assert.Equal(t, maxPendingDistributions+5, page.Total)
+	assert.Len(t, page.Entries, maxPendingDistributions, "page must be capped")
+	assert.Equal(t, ids[0], page.Entries[0].Id)
+	assert.Equal(t, from, page.Entries[0].From)
+	assert.Equal(t, 2, page.Entries[0].Recipients)
+	assert.True(t, page.Entries[0].HasCallback)
+
+	offset, limit := maxPendingDistributions, 10
+	page = api.PendingDistributions(&offset, &limit)
+	assert.Len(t, page.Entries, 5)
+	assert.Equal(t, ids[maxPendingDistributions], page.Entries[0].Id)
+
+	// entries are removed once the send completes, successfully or not
+	async.untrack(ids[0], nil)
+	async.untrack(ids[1], errors.New("failed"))
+	page = api.PendingDistributions(nil, &limit)
+	assert.Equal(t, maxPendingDistributions+3, page.Total)
+	assert.Equal(t, ids[2], page.Entries[0].Id)
+}
+
+// feeHistoryBackend serves a chain of blocks whose transactions use the gas
+// priced by their index.
+type feeHistoryBackend struct {
+	StubBackend
+	config *params.ChainConfig
+	blocks []*types.Block
+}
+
+func newFeeHistoryBackend(config *params.ChainConfig) *feeHistoryBackend {
+	b := &feeHistoryBackend{config: config}
+	for n := int64(0); n < 4; n++ {
+		var txs []*types.Transaction
+		for i := int64(0); i < n; i++ {
+			txs = append(txs, types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 21000, big.NewInt(10*(i+1)), nil))
+		}
+		header := &types.Header{Number: big.NewInt(n), GasLimit: 84000, GasUsed: uint64(n) * 21000}
+		b.blocks = append(b.blocks, types.NewBlock(header, txs, nil, nil, new(trie.Trie)))
+	}
+	return b
+}
+
+func (b *feeHistoryBackend) ChainConfig() *params.ChainConfig { return b.config }
+
+func (b *feeHistoryBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *feeHistoryBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(len(b.blocks) - 1)
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *feeHistoryBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			receipts := make(types.Receipts, len(block.Transactions()))
+			for i := range receipts {
+				receipts[i] = &types.Receipt{GasUsed: 21000}
+			}
+			return receipts, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestGetFeeHistory(t *testing.T) {
+	zero := (*hexutil.Big)(big.NewInt(0))
+	price := func(p int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(p)) }
+
+	history, err := GetFeeHistory(context.Background(), newFeeHistoryBackend(params.TestChainConfig), 3, rpc.LatestBlockNumber, []float64{0, 50, 100})
+	require.NoError(t, err)
+	assert.Equal(t, price(1), history.OldestBlock)
+	assert.Equal(t, []*hexutil.Big{zero, zero, zero, zero}, history.BaseFee)
+	assert.Equal(t, []float64{0.25, 0.5, 0.75}, history.GasUsedRatio)
+	assert.Equal(t, [][]*hexutil.Big{
+		{price(10), price(10), price(10)},
+		{price(10), price(10), price(20)},
+		{price(10), price(20), price(30)},
+	}, history.Reward)
+
+	// gas-free chains have no fees
+	history, err = GetFeeHistory(context.Background(), newFeeHistoryBackend(params.QuorumTestChainConfig), 10, rpc.PendingBlockNumber, []float64{25, 75})
+	require.NoError(t, err)
+	assert.Equal(t, price(0), history.OldestBlock)
+	assert.Len(t, history.BaseFee, 5)
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75}, history.GasUsedRatio)
+	for _, reward := range history.Reward {
+		assert.Equal(t, []*hexutil.Big{zero, zero}, reward)
+	}
+
+	_, err = GetFeeHistory(context.Background(), newFeeHistoryBackend(params.TestChainConfig), 3, rpc.LatestBlockNumber, []float64{50, 10})
+	assert.True(t, errors.Is(err, errInvalidPercentile))
+}
+
+func TestDecimalOrHex(t *testing.T) {
+	for _, input := range []string{`4`, `"0x4"`, `"4"`} {
+		var n DecimalOrHex
+		require.NoError(t, json.Unmarshal([]byte(input), &n), input)
+		assert.Equal(t, DecimalOrHex(4), n, input)
+	}
+}
+
+type prunedSealsBackend struct {
+	StubBackend
+	db ethdb.Database
+}
+
+func (b *prunedSealsBackend) ChainDb() ethdb.Database { return b.db }
+
+func TestMarkPrunedSeals(t *testing.T) {
+	istanbulHeader := func(number int64, committedSeals [][]byte) *types.Header {
+		extra, err := rlp.EncodeToBytes(&types.IstanbulExtra{Seal: []byte{0x01}, CommittedSeal: committedSeals})
+		require.NoError(t, err)
+		return &types.Header{
+			Number:    big.NewInt(number),
+			MixDigest: types.IstanbulDigest,
+			Extra:     append(make([]byte, types.IstanbulExtraVanity), extra...),
+		}
+	}
+	pruned, unsealed, sealed := istanbulHeader(1, nil), istanbulHeader(2, nil), istanbulHeader(3, [][]byte{{0x02}})
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteSealAttestation(db, pruned.Hash(), 1, common.Hash{0x03})
+	api := NewPublicBlockChainAPI(&prunedSealsBackend{db: db})
+
+	for _, tt := range []struct {
+		header *types.Header
+		marked bool
+	}{
+		{pruned, true},
+		{unsealed, false},
+		{sealed, false},
+		{&types.Header{Number: big.NewInt(1)}, false},
+	} {
+		fields := map[string]interface{}{}
+		api.markPrunedSeals(fields, tt.header)
+		assert.Equal(t, tt.marked, fields["sealsPruned"] == true, "block %d", tt.header.Number)
+	}
+}
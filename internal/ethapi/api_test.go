@@ -2,12 +2,16 @@ package ethapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -27,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/private/engine"
 	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
@@ -263,6 +268,26 @@ func TestSimulateExecution_whenStateValidationMessageCall(t *testing.T) {
 	assert.True(len(affectedCACreationTxHashes) == len(expectedCACreationTxHashes))
 }
 
+func TestSimulateExecution_whenMandatoryRecipientsMessageCall(t *testing.T) {
+	assert := assert.New(t)
+	privateTxArgs.PrivacyFlag = engine.PrivacyFlagMandatoryRecipients
+
+	privateStateDB.SetCode(arbitrarySimpleStorageContractAddress, hexutil.MustDecode("0x608060405234801561001057600080fd5b506040516020806101618339810180604052602081101561003057600080fd5b81019080805190602001909291905050508060008190555050610109806100586000396000f3fe6080604052600436106049576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806360fe47b114604e5780636d4ce63c146099575b600080fd5b348015605957600080fd5b50608360048036036020811015606e57600080fd5b810190808035906020019092919050505060c1565b6040518082815260200191505060405180910390f35b34801560a457600080fd5b5060ab60d4565b6040518082815260200191505060405180910390f35b6000816000819055506000549050919050565b6000805490509056fea165627a7a723058203624ca2e3479d3fa5a12d97cf3dae0d9a6de3a3b8a53c8605b9cd398d9766b9f00290000000000000000000000000000000000000000000000000000000000000001"))
+	privateStateDB.SetPrivacyMetadata(arbitrarySimpleStorageContractAddress, &state.PrivacyMetadata{
+		PrivacyFlag:    privateTxArgs.PrivacyFlag,
+		CreationTxHash: arbitrarySimpleStorageContractEncryptedPayloadHash,
+	})
+
+	privateStateDB.SetState(arbitrarySimpleStorageContractAddress, common.Hash{0}, common.Hash{100})
+	privateStateDB.Commit(true)
+
+	affectedCACreationTxHashes, merkleRoot, err := simulateExecutionForPE(arbitraryCtx, &StubBackend{}, arbitraryFrom, simpleStorageContractMessageCallTx, privateTxArgs)
+
+	assert.NoError(err, "simulate execution")
+	assert.Equal(common.EncryptedPayloadHashes{arbitrarySimpleStorageContractEncryptedPayloadHash: struct{}{}}, affectedCACreationTxHashes, "affected contract accounts' creation transacton hashes")
+	assert.Equal(common.Hash{}, merkleRoot, "no private state validation")
+}
+
 //mix and match flags
 func TestSimulateExecution_PrivacyFlagPartyProtectionCallingStandardPrivateContract_Error(t *testing.T) {
 	assert := assert.New(t)
@@ -425,6 +450,51 @@ func TestHandlePrivateTransaction_whenPartyProtectionCallingStandardPrivate(t *t
 	assert.True(isPrivate, "must be a private transaction")
 }
 
+func TestHandlePrivateTransaction_whenMandatoryRecipientsMissing(t *testing.T) {
+	assert := assert.New(t)
+	privateTxArgs.PrivacyFlag = engine.PrivacyFlagMandatoryRecipients
+
+	_, _, err := checkAndHandlePrivateTransaction(arbitraryCtx, &StubBackend{}, simpleStorageContractCreationTx, privateTxArgs, arbitraryFrom, NormalTransaction)
+
+	assert.EqualError(err, "missing mandatory recipients data. if no mandatory recipients required consider using PrivacyFlag=1(PartyProtection)")
+}
+
+func TestHandlePrivateTransaction_whenMandatoryRecipientsWithoutFlag(t *testing.T) {
+	assert := assert.New(t)
+	privateTxArgs.PrivacyFlag = engine.PrivacyFlagPartyProtection
+	privateTxArgs.MandatoryFor = []string{"arbitrary party 1"}
+	defer func() { privateTxArgs.MandatoryFor = nil }()
+
+	_, _, err := checkAndHandlePrivateTransaction(arbitraryCtx, &StubBackend{}, simpleStorageContractCreationTx, privateTxArgs, arbitraryFrom, NormalTransaction)
+
+	assert.EqualError(err, "privacy metadata invalid. mandatory recipients are only applicable for PrivacyFlag=2(MandatoryRecipients)")
+}
+
+func TestHandlePrivateTransaction_whenMandatoryRecipientsNotSupported(t *testing.T) {
+	assert := assert.New(t)
+	private.P = &noMandatoryRecipientsPrivateTransactionManager{}
+	defer func() { private.P = &StubPrivateTransactionManager{} }()
+	privateTxArgs.PrivacyFlag = engine.PrivacyFlagMandatoryRecipients
+	privateTxArgs.MandatoryFor = []string{"arbitrary party 1"}
+	defer func() { privateTxArgs.MandatoryFor = nil }()
+
+	_, _, err := checkAndHandlePrivateTransaction(arbitraryCtx, &StubBackend{}, simpleStorageContractCreationTx, privateTxArgs, arbitraryFrom, NormalTransaction)
+
+	assert.Equal(engine.ErrPrivateTxManagerDoesNotSupportMandatoryRecipients, err)
+}
+
+func TestHandlePrivateTransaction_whenMandatoryRecipientsCreation(t *testing.T) {
+	assert := assert.New(t)
+	privateTxArgs.PrivacyFlag = engine.PrivacyFlagMandatoryRecipients
+	privateTxArgs.MandatoryFor = []string{"arbitrary party 1"}
+	defer func() { privateTxArgs.MandatoryFor = nil }()
+
+	isPrivate, _, err := checkAndHandlePrivateTransaction(arbitraryCtx, &StubBackend{}, simpleStorageContractCreationTx, privateTxArgs, arbitraryFrom, NormalTransaction)
+
+	assert.NoError(err, "mandatory recipients creation succeeded")
+	assert.True(isPrivate, "must be a private transaction")
+}
+
 func TestHandlePrivateTransaction_whenRawStandardPrivateCreation(t *testing.T) {
 	assert := assert.New(t)
 	private.P = &StubPrivateTransactionManager{creation: true}
@@ -447,9 +517,205 @@ func TestHandlePrivateTransaction_whenRawStandardPrivateMessageCall(t *testing.T
 
 }
 
+func newPrivateSignTxArgs(from common.Address) SendTxArgs {
+	gas, nonce := hexutil.Uint64(90000), hexutil.Uint64(0)
+	data := hexutil.Bytes(standardPrivateSimpleStorageContractMessageCallTx.Data())
+	return SendTxArgs{
+		PrivateTxArgs: PrivateTxArgs{
+			PrivateFrom: arbitraryPrivateFrom,
+			PrivateFor:  []string{"arbitrary party 1", "arbitrary party 2"},
+		},
+		From:     from,
+		To:       &arbitrarySimpleStorageContractAddress,
+		Gas:      &gas,
+		GasPrice: (*hexutil.Big)(big.NewInt(0)),
+		Nonce:    &nonce,
+		Data:     &data,
+	}
+}
+
+func TestSignTransaction_whenPrivate(t *testing.T) {
+	assert := assert.New(t)
+	private.P = &StubPrivateTransactionManager{}
+
+	dir, err := ioutil.TempDir("", "ethapi-sign-test")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("")
+	assert.NoError(err)
+	assert.NoError(ks.Unlock(account, ""))
+	api := NewPublicTransactionPoolAPI(&StubBackend{accountManager: accounts.NewManager(&accounts.Config{}, ks)}, new(AddrLocker))
+
+	result, err := api.SignTransaction(arbitraryCtx, newPrivateSignTxArgs(account.Address))
+
+	assert.NoError(err, "sign private transaction")
+	assert.True(result.Tx.IsPrivate(), "must be a private transaction")
+	assert.Equal(arbitrarySimpleStorageContractEncryptedPayloadHash.Bytes(), result.Tx.Data(), "payload replaced by its hash")
+	v, _, _ := result.Tx.RawSignatureValues()
+	assert.True(v.Uint64() == 37 || v.Uint64() == 38, "private signature V")
+	sender, err := types.QuorumPrivateTxSigner{}.Sender(result.Tx)
+	assert.NoError(err)
+	assert.Equal(account.Address, sender)
+	assert.Equal(&PrivateTransactionSummary{
+		PayloadHash: arbitrarySimpleStorageContractEncryptedPayloadHash.BytesTypeRef(),
+		Recipients:  2,
+		PrivacyFlag: engine.PrivacyFlagStandardPrivate,
+	}, result.Private)
+
+	decoded := new(types.Transaction)
+	assert.NoError(rlp.DecodeBytes(result.Raw, decoded))
+	assert.Equal(result.Tx.Hash(), decoded.Hash())
+}
+
+func TestSignTransaction_whenPrivateSendDeferred(t *testing.T) {
+	assert := assert.New(t)
+	args := newPrivateSignTxArgs(arbitraryFrom)
+	args.DeferPrivateSend = true
+
+	result, err := NewPublicTransactionPoolAPI(&StubBackend{}, new(AddrLocker)).SignTransaction(arbitraryCtx, args)
+
+	assert.NoError(err, "private transaction template")
+	assert.True(result.Tx.IsPrivate(), "must be a private transaction")
+	assert.Equal([]byte(*args.Data), result.Tx.Data(), "original payload kept")
+	_, r, s := result.Tx.RawSignatureValues()
+	assert.Zero(r.Sign(), "must not be signed")
+	assert.Zero(s.Sign(), "must not be signed")
+	assert.Equal(&PrivateTransactionSummary{Recipients: 2}, result.Private)
+}
+
+func TestFillTransaction_whenPrivate(t *testing.T) {
+	assert := assert.New(t)
+	private.P = &StubPrivateTransactionManager{}
+	api := NewPublicTransactionPoolAPI(&StubBackend{}, new(AddrLocker))
+
+	args := newPrivateSignTxArgs(arbitraryFrom)
+	result, err := api.FillTransaction(arbitraryCtx, args)
+
+	assert.NoError(err, "fill private transaction")
+	assert.True(result.Tx.IsPrivate(), "must be a private transaction")
+	assert.Equal(arbitrarySimpleStorageContractEncryptedPayloadHash.Bytes(), result.Tx.Data(), "payload replaced by its hash")
+	_, r, s := result.Tx.RawSignatureValues()
+	assert.Zero(r.Sign(), "must not be signed")
+	assert.Zero(s.Sign(), "must not be signed")
+	assert.Equal(&PrivateTransactionSummary{
+		PayloadHash: arbitrarySimpleStorageContractEncryptedPayloadHash.BytesTypeRef(),
+		Recipients:  2,
+		PrivacyFlag: engine.PrivacyFlagStandardPrivate,
+	}, result.Private)
+	decoded := new(types.Transaction)
+	assert.NoError(rlp.DecodeBytes(result.Raw, decoded))
+	assert.Equal(result.Tx.Hash(), decoded.Hash())
+
+	// the payload given as input is replaced as well
+	args = newPrivateSignTxArgs(arbitraryFrom)
+	args.Input, args.Data = args.Data, nil
+	result, err = api.FillTransaction(arbitraryCtx, args)
+
+	assert.NoError(err, "fill private transaction")
+	assert.Equal(arbitrarySimpleStorageContractEncryptedPayloadHash.Bytes(), result.Tx.Data(), "input replaced by its hash")
+}
+
+func TestFillTransaction_whenPublic(t *testing.T) {
+	assert := assert.New(t)
+	private.P = &failingStoreRawPrivateTransactionManager{}
+	defer func() { private.P = &StubPrivateTransactionManager{} }()
+	args := newPrivateSignTxArgs(arbitraryFrom)
+	args.PrivateTxArgs = PrivateTxArgs{}
+
+	result, err := NewPublicTransactionPoolAPI(&StubBackend{}, new(AddrLocker)).FillTransaction(arbitraryCtx, args)
+
+	assert.NoError(err, "fill public transaction")
+	assert.False(result.Tx.IsPrivate(), "must be a public transaction")
+	assert.Equal([]byte(*args.Data), result.Tx.Data(), "original payload kept")
+	assert.Nil(result.Private)
+}
+
+func TestFillTransaction_whenStoreRawFails(t *testing.T) {
+	assert := assert.New(t)
+	private.P = &failingStoreRawPrivateTransactionManager{}
+	defer func() { private.P = &StubPrivateTransactionManager{} }()
+
+	result, err := NewPublicTransactionPoolAPI(&StubBackend{}, new(AddrLocker)).FillTransaction(arbitraryCtx, newPrivateSignTxArgs(arbitraryFrom))
+
+	assert.EqualError(err, "storeraw failed")
+	assert.Nil(result)
+}
+
+func TestStoredPayloadError(t *testing.T) {
+	assert := assert.New(t)
+	cause := errors.New("rlp: failed")
+
+	assert.Equal(cause, newStoredPayloadError(common.EncryptedPayloadHash{}, cause), "no payload stored")
+	err := newStoredPayloadError(arbitrarySimpleStorageContractEncryptedPayloadHash, cause)
+	assert.EqualError(err, fmt.Sprintf("private payload %s stored: rlp: failed", arbitrarySimpleStorageContractEncryptedPayloadHash.Hex()))
+	assert.Equal(arbitrarySimpleStorageContractEncryptedPayloadHash.Hex(), err.(rpc.DataError).ErrorData())
+}
+
+// receiptBackend serves a private contract creation and its receipt, the
+// contract being in the private state of its parties.
+type receiptBackend struct {
+	*StubBackend
+	tx      *types.Transaction
+	receipt *types.Receipt
+	state   vm.AccountExtraDataStateGetter
+}
+
+func (b *receiptBackend) GetTransaction(context.Context, common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return b.tx, common.Hash{0x01}, 1, 0, nil
+}
+
+func (b *receiptBackend) GetReceipts(context.Context, common.Hash) (types.Receipts, error) {
+	return types.Receipts{b.receipt}, nil
+}
+
+func (b *receiptBackend) AccountExtraDataStateGetterByNumber(context.Context, rpc.BlockNumber) (vm.AccountExtraDataStateGetter, error) {
+	return b.state, nil
+}
+
+func (b *receiptBackend) IsAuthorized(ctx context.Context, authToken *proto.PreAuthenticatedAuthenticationToken, attributes ...*multitenancy.ContractSecurityAttribute) (bool, error) {
+	return (&multitenancy.DefaultContractAuthorizationProvider{}).IsAuthorized(ctx, authToken, attributes...)
+}
+
+func TestGetTransactionReceipt_whenPrivateCreationAndMultitenancy(t *testing.T) {
+	assert := assert.New(t)
+	contract := common.Address{0x02}
+	privateState, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	assert.NoError(err)
+	privateState.SetManagedParties(contract, []string{"A"})
+	tx := types.NewContractCreation(0, big.NewInt(0), 100000, big.NewInt(0), arbitrarySimpleStorageContractEncryptedPayloadHash.Bytes())
+	tx.SetPrivate()
+	backend := &receiptBackend{
+		StubBackend: &StubBackend{multitenancy: true},
+		tx:          tx,
+		receipt:     &types.Receipt{Status: types.ReceiptStatusSuccessful, ContractAddress: contract},
+		state:       privateState,
+	}
+	api := NewPublicTransactionPoolAPI(backend, new(AddrLocker))
+	tenant := func(tmKey string) context.Context {
+		return context.WithValue(arbitraryCtx, rpc.CtxPreauthenticatedToken, &proto.PreAuthenticatedAuthenticationToken{
+			Authorities: []*proto.GrantedAuthority{{Raw: "private://0x0/_/contracts?owned.eoa=0x0&from.tm=" + tmKey}},
+		})
+	}
+
+	fields, err := api.GetTransactionReceipt(tenant("A"), tx.Hash())
+
+	assert.NoError(err)
+	assert.Equal(contract, fields["contractAddress"], "party to the creation")
+
+	fields, err = api.GetTransactionReceipt(tenant("B"), tx.Hash())
+
+	assert.NoError(err)
+	assert.Nil(fields["contractAddress"], "not party to the creation")
+	assert.Equal(hexutil.Uint(types.ReceiptStatusSuccessful), fields["status"])
+	assert.Empty(fields["logs"])
+}
+
 type StubBackend struct {
 	getEVMCalled                    bool
 	mockAccountExtraDataStateGetter *vm.MockAccountExtraDataStateGetter
+	accountManager                  *accounts.Manager
+	multitenancy                    bool
 }
 
 func (sb *StubBackend) CurrentHeader() *types.Header {
@@ -461,7 +727,11 @@ func (sb *StubBackend) Engine() consensus.Engine {
 }
 
 func (sb *StubBackend) SupportsMultitenancy(rpcCtx context.Context) (*proto.PreAuthenticatedAuthenticationToken, bool) {
-	panic("implement me")
+	if !sb.multitenancy {
+		return nil, false
+	}
+	authToken, ok := rpcCtx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	return authToken, ok
 }
 
 func (sb *StubBackend) AccountExtraDataStateGetterByNumber(context.Context, rpc.BlockNumber) (vm.AccountExtraDataStateGetter, error) {
@@ -511,7 +781,10 @@ func (sb *StubBackend) EventMux() *event.TypeMux {
 }
 
 func (sb *StubBackend) AccountManager() *accounts.Manager {
-	panic("implement me")
+	if sb.accountManager == nil {
+		panic("implement me")
+	}
+	return sb.accountManager
 }
 
 func (sb *StubBackend) ExtRPCEnabled() bool {
@@ -522,8 +795,16 @@ func (sb *StubBackend) CallTimeOut() time.Duration {
 	panic("implement me")
 }
 
+func (sb *StubBackend) CheckWritable() error {
+	return nil
+}
+
+func (sb *StubBackend) ConsensusAlgorithm() string {
+	return "ethash"
+}
+
 func (sb *StubBackend) RPCTxFeeCap() float64 {
-	panic("implement me")
+	return 0
 }
 
 func (sb *StubBackend) RPCGasCap() uint64 {
@@ -722,27 +1003,27 @@ type StubPrivateTransactionManager struct {
 	creation bool
 }
 
-func (sptm *StubPrivateTransactionManager) Send(data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
+func (sptm *StubPrivateTransactionManager) Send(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
 	return "", nil, arbitrarySimpleStorageContractEncryptedPayloadHash, nil
 }
 
-func (sptm *StubPrivateTransactionManager) EncryptPayload(data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
+func (sptm *StubPrivateTransactionManager) EncryptPayload(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
 	return nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (sptm *StubPrivateTransactionManager) DecryptPayload(payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
+func (sptm *StubPrivateTransactionManager) DecryptPayload(ctx context.Context, payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
 	return nil, nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (sptm *StubPrivateTransactionManager) StoreRaw(data []byte, from string) (common.EncryptedPayloadHash, error) {
+func (sptm *StubPrivateTransactionManager) StoreRaw(ctx context.Context, data []byte, from string) (common.EncryptedPayloadHash, error) {
 	return arbitrarySimpleStorageContractEncryptedPayloadHash, nil
 }
 
-func (sptm *StubPrivateTransactionManager) SendSignedTx(data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
+func (sptm *StubPrivateTransactionManager) SendSignedTx(ctx context.Context, data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
 	return "", nil, arbitrarySimpleStorageContractEncryptedPayloadHash.Bytes(), nil
 }
 
-func (sptm *StubPrivateTransactionManager) ReceiveRaw(data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+func (sptm *StubPrivateTransactionManager) ReceiveRaw(ctx context.Context, data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
 	if sptm.creation {
 		return hexutil.MustDecode("0x6060604052341561000f57600080fd5b604051602080610149833981016040528080519060200190919050505b806000819055505b505b610104806100456000396000f30060606040526000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff1680632a1afcd914605157806360fe47b11460775780636d4ce63c146097575b600080fd5b3415605b57600080fd5b606160bd565b6040518082815260200191505060405180910390f35b3415608157600080fd5b6095600480803590602001909190505060c3565b005b341560a157600080fd5b60a760ce565b6040518082815260200191505060405180910390f35b60005481565b806000819055505b50565b6000805490505b905600a165627a7a72305820d5851baab720bba574474de3d09dbeaabc674a15f4dd93b974908476542c23f00029"), "", nil, nil
 	} else {
@@ -753,3 +1034,19 @@ func (sptm *StubPrivateTransactionManager) ReceiveRaw(data common.EncryptedPaylo
 func (sptm *StubPrivateTransactionManager) HasFeature(f engine.PrivateTransactionManagerFeature) bool {
 	return true
 }
+
+type failingStoreRawPrivateTransactionManager struct {
+	StubPrivateTransactionManager
+}
+
+func (ptm *failingStoreRawPrivateTransactionManager) StoreRaw(ctx context.Context, data []byte, from string) (common.EncryptedPayloadHash, error) {
+	return common.EncryptedPayloadHash{}, errors.New("storeraw failed")
+}
+
+type noMandatoryRecipientsPrivateTransactionManager struct {
+	StubPrivateTransactionManager
+}
+
+func (ptm *noMandatoryRecipientsPrivateTransactionManager) HasFeature(f engine.PrivateTransactionManagerFeature) bool {
+	return f != engine.MandatoryRecipients
+}
@@ -95,6 +95,12 @@ type Backend interface {
 	// Quorum
 	// AccountExtraDataStateGetterByNumber returns state getter at a given block height
 	AccountExtraDataStateGetterByNumber(ctx context.Context, number rpc.BlockNumber) (vm.AccountExtraDataStateGetter, error)
+	// CheckWritable returns an error if the node refuses state changing
+	// operations such as transaction submission and private payload sends
+	CheckWritable() error
+	// ConsensusAlgorithm returns the consensus algorithm of the chain, raft,
+	// istanbul, clique, ethash or unknown
+	ConsensusAlgorithm() string
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -139,6 +145,16 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPrivateAccountAPI(apiBackend, nonceLock),
 			Public:    false,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicQuorumAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicPrivateBatchAPI(apiBackend, nonceLock),
+			Public:    true,
 		},
 	}
 }
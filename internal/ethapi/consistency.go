@@ -0,0 +1,185 @@
+package ethapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// Quorum
+//
+// The consistency tokens issued by the writes encode the position of the node
+// accepting them: its head block and the pool nonce of the sender. They are
+// signed with the secret shared by the nodes behind the load balancer, so that
+// a node only waits for the positions of the others.
+
+const (
+	consistencyTokenVersion = 1
+	consistencyPayloadSize  = 1 + 8 + common.AddressLength + 8
+	consistencyEventsSize   = 16 // events buffered while waiting
+)
+
+var errInvalidConsistencyToken = errors.New("invalid consistency token")
+
+// ConsistencyPosition is the position of a node a client is consistent with.
+type ConsistencyPosition struct {
+	Number hexutil.Uint64 `json:"number"` // of the head block
+	Sender common.Address `json:"sender"`
+	Nonce  hexutil.Uint64 `json:"nonce"` // next pool nonce of the sender
+}
+
+// NotConsistentError is returned to the requests presenting a consistency
+// token the node did not catch up with in time.
+type NotConsistentError struct {
+	Wanted  ConsistencyPosition `json:"wanted"`
+	Current ConsistencyPosition `json:"current"`
+}
+
+func (e *NotConsistentError) Error() string {
+	return fmt.Sprintf("not yet consistent: at block %d and nonce %d of %s, wanted block %d and nonce %d",
+		e.Current.Number, e.Current.Nonce, e.Wanted.Sender.Hex(), e.Wanted.Number, e.Wanted.Nonce)
+}
+
+// ErrorCode returns the JSON-RPC error code of the requests not yet consistent.
+func (e *NotConsistentError) ErrorCode() int {
+	return -32010
+}
+
+// ErrorData returns the position wanted and the current one of the node.
+func (e *NotConsistentError) ErrorData() interface{} {
+	return e
+}
+
+// LoadConsistencySecret returns the hex secret of the file, of 16 bytes at
+// least.
+func LoadConsistencySecret(path string) ([]byte, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the consistency secret: %v", err)
+	}
+	secret, err := hexutil.Decode(strings.TrimSpace(string(text)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid consistency secret in %s: %v", path, err)
+	}
+	if len(secret) < 16 {
+		return nil, fmt.Errorf("consistency secret in %s too short: %d bytes, 16 at least", path, len(secret))
+	}
+	return secret, nil
+}
+
+// ConsistencyGuard issues the consistency tokens of the transactions accepted,
+// and holds the requests presenting one back until the node caught up with it.
+type ConsistencyGuard struct {
+	b      Backend
+	secret []byte
+	wait   time.Duration
+}
+
+// NewConsistencyGuard returns a guard signing the tokens with the secret, and
+// holding the requests back for up to the wait.
+func NewConsistencyGuard(b Backend, secret []byte, wait time.Duration) *ConsistencyGuard {
+	return &ConsistencyGuard{b: b, secret: secret, wait: wait}
+}
+
+// Issue returns the token of the transaction of the sender with the nonce,
+// accepted at the current head.
+func (g *ConsistencyGuard) Issue(sender common.Address, nonce uint64) string {
+	payload := make([]byte, consistencyPayloadSize)
+	payload[0] = consistencyTokenVersion
+	binary.BigEndian.PutUint64(payload[1:], g.b.CurrentBlock().NumberU64())
+	copy(payload[9:], sender[:])
+	binary.BigEndian.PutUint64(payload[9+common.AddressLength:], nonce+1)
+	return base64.RawURLEncoding.EncodeToString(append(payload, g.sign(payload)...))
+}
+
+func (g *ConsistencyGuard) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// position returns the position of the token, verifying its signature.
+func (g *ConsistencyGuard) position(token string) (ConsistencyPosition, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != consistencyPayloadSize+sha256.Size || raw[0] != consistencyTokenVersion {
+		return ConsistencyPosition{}, errInvalidConsistencyToken
+	}
+	payload := raw[:consistencyPayloadSize]
+	if !hmac.Equal(raw[consistencyPayloadSize:], g.sign(payload)) {
+		return ConsistencyPosition{}, errInvalidConsistencyToken
+	}
+	return ConsistencyPosition{
+		Number: hexutil.Uint64(binary.BigEndian.Uint64(payload[1:])),
+		Sender: common.BytesToAddress(payload[9 : 9+common.AddressLength]),
+		Nonce:  hexutil.Uint64(binary.BigEndian.Uint64(payload[9+common.AddressLength:])),
+	}, nil
+}
+
+// current returns the position of the node for the sender.
+func (g *ConsistencyGuard) current(ctx context.Context, sender common.Address) (ConsistencyPosition, error) {
+	nonce, err := g.b.GetPoolNonce(ctx, sender)
+	if err != nil {
+		return ConsistencyPosition{}, err
+	}
+	return ConsistencyPosition{
+		Number: hexutil.Uint64(g.b.CurrentBlock().NumberU64()),
+		Sender: sender,
+		Nonce:  hexutil.Uint64(nonce),
+	}, nil
+}
+
+// Await returns once the node caught up with the token, a *NotConsistentError
+// if it does not within the wait of the guard or the lifetime of the context.
+func (g *ConsistencyGuard) Await(ctx context.Context, token string) error {
+	wanted, err := g.position(token)
+	if err != nil {
+		return err
+	}
+	current, err := g.current(ctx, wanted.Sender)
+	if err != nil || consistent(current, wanted) {
+		return err
+	}
+	if g.wait <= 0 {
+		return &NotConsistentError{Wanted: wanted, Current: current}
+	}
+
+	// the position moves with the heads imported and the transactions pooled
+	headCh := make(chan core.ChainHeadEvent, consistencyEventsSize)
+	headSub := g.b.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+	txsCh := make(chan core.NewTxsEvent, consistencyEventsSize)
+	txsSub := g.b.SubscribeNewTxsEvent(txsCh)
+	defer txsSub.Unsubscribe()
+
+	timer := time.NewTimer(g.wait)
+	defer timer.Stop()
+	for {
+		// the position may have moved as the subscriptions were made
+		if current, err = g.current(ctx, wanted.Sender); err != nil || consistent(current, wanted) {
+			return err
+		}
+		select {
+		case <-headCh:
+		case <-txsCh:
+		case <-timer.C:
+			return &NotConsistentError{Wanted: wanted, Current: current}
+		case <-ctx.Done():
+			return &NotConsistentError{Wanted: wanted, Current: current}
+		}
+	}
+}
+
+func consistent(current, wanted ConsistencyPosition) bool {
+	return current.Number >= wanted.Number && current.Nonce >= wanted.Nonce
+}
@@ -0,0 +1,136 @@
+package ethapi
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consistencyBackend is a node whose head and pool nonce are moved by the tests.
+type consistencyBackend struct {
+	StubBackend
+	lock   sync.Mutex
+	number uint64
+	nonce  uint64
+
+	headFeed event.Feed
+	txsFeed  event.Feed
+}
+
+func (b *consistencyBackend) CurrentBlock() *types.Block {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(b.number)}, nil, nil, nil, new(trie.Trie))
+}
+
+func (b *consistencyBackend) GetPoolNonce(context.Context, common.Address) (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.nonce, nil
+}
+
+func (b *consistencyBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.headFeed.Subscribe(ch)
+}
+
+func (b *consistencyBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	return b.txsFeed.Subscribe(ch)
+}
+
+func (b *consistencyBackend) move(number, nonce uint64) {
+	b.lock.Lock()
+	b.number, b.nonce = number, nonce
+	b.lock.Unlock()
+	b.headFeed.Send(core.ChainHeadEvent{})
+}
+
+var consistencySecret = []byte("0123456789abcdef")
+
+func TestConsistencyGuard_Issue(t *testing.T) {
+	sender := common.HexToAddress("0x1")
+	writer := &consistencyBackend{number: 10, nonce: 3}
+	token := NewConsistencyGuard(writer, consistencySecret, 0).Issue(sender, 3)
+
+	replica := &consistencyBackend{number: 10, nonce: 4}
+	guard := NewConsistencyGuard(replica, consistencySecret, 0)
+	position, err := guard.position(token)
+	require.NoError(t, err)
+	assert.Equal(t, ConsistencyPosition{Number: 10, Sender: sender, Nonce: 4}, position)
+	assert.NoError(t, guard.Await(context.Background(), token))
+
+	// the tokens are only trusted if signed with the shared secret
+	assert.Equal(t, errInvalidConsistencyToken, NewConsistencyGuard(replica, []byte("fedcba9876543210"), 0).Await(context.Background(), token))
+	raw := []byte(token)
+	raw[2] ^= 1
+	assert.Equal(t, errInvalidConsistencyToken, guard.Await(context.Background(), string(raw)))
+	assert.Equal(t, errInvalidConsistencyToken, guard.Await(context.Background(), "not a token"))
+}
+
+func TestConsistencyGuard_Await(t *testing.T) {
+	sender := common.HexToAddress("0x1")
+	token := NewConsistencyGuard(&consistencyBackend{number: 10}, consistencySecret, 0).Issue(sender, 3)
+
+	// behind and not waiting
+	replica := &consistencyBackend{number: 9, nonce: 4}
+	err := NewConsistencyGuard(replica, consistencySecret, 0).Await(context.Background(), token)
+	require.IsType(t, &NotConsistentError{}, err)
+	assert.Equal(t, &NotConsistentError{
+		Wanted:  ConsistencyPosition{Number: 10, Sender: sender, Nonce: 4},
+		Current: ConsistencyPosition{Number: 9, Sender: sender, Nonce: 4},
+	}, err)
+	assert.Equal(t, -32010, err.(*NotConsistentError).ErrorCode())
+
+	// catching up while waiting
+	guard := NewConsistencyGuard(replica, consistencySecret, 5*time.Second)
+	done := make(chan error)
+	go func() { done <- guard.Await(context.Background(), token) }()
+	time.Sleep(10 * time.Millisecond)
+	replica.move(10, 3)
+	replica.move(10, 4)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("not consistent once caught up")
+	}
+
+	// the wait is bounded
+	replica.move(10, 3)
+	guard = NewConsistencyGuard(replica, consistencySecret, 20*time.Millisecond)
+	start := time.Now()
+	err = guard.Await(context.Background(), token)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	require.IsType(t, &NotConsistentError{}, err)
+	assert.Equal(t, hexutil.Uint64(3), err.(*NotConsistentError).Current.Nonce)
+	assert.Zero(t, replica.headFeed.Send(core.ChainHeadEvent{}), "subscriptions left")
+}
+
+func TestLoadConsistencySecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "consistency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secret")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("0x30313233343536373839616263646566\n"), 0600))
+	secret, err := LoadConsistencySecret(path)
+	require.NoError(t, err)
+	assert.Equal(t, consistencySecret, secret)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("0x0102"), 0600))
+	_, err = LoadConsistencySecret(path)
+	assert.EqualError(t, err, "consistency secret in "+path+" too short: 2 bytes, 16 at least")
+}
@@ -0,0 +1,123 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/sync/singleflight"
+)
+
+// Quorum
+//
+// The estimations of eth_estimateGas run on a pool of workers of their own, so
+// that a storm of them does not hold up the other calls. An estimation is keyed
+// by its arguments, the hash of the block it runs against and the tenant of the
+// request: the concurrent identical ones share a single run, and its result is
+// cached for a few seconds. The block hash committing to its state and its
+// transactions, public and private, the estimations against another state never
+// hit the cache.
+
+const (
+	estimateGasWorkers   = 8                // estimations run at once
+	estimateGasCacheSize = 4096             // estimations cached at most
+	estimateGasCacheTTL  = 3 * time.Second  // lifetime of the cached estimations
+	estimateGasTimeout   = 30 * time.Second // timeout of a shared estimation
+)
+
+var (
+	estimateGasHitMeter   = metrics.NewRegisteredMeter("quorum/estimategas/cache/hit", nil)
+	estimateGasMissMeter  = metrics.NewRegisteredMeter("quorum/estimategas/cache/miss", nil)
+	estimateGasQueueGauge = metrics.NewRegisteredGauge("quorum/estimategas/queue", nil)
+)
+
+// cachedEstimate is an estimation cached until it expires.
+type cachedEstimate struct {
+	gas     hexutil.Uint64
+	expires time.Time
+}
+
+// gasEstimator runs the estimations on its workers, sharing and caching those
+// with the same key.
+type gasEstimator struct {
+	workers chan struct{}
+	ttl     time.Duration
+	timeout time.Duration
+	cache   *lru.Cache // common.Hash -> *cachedEstimate
+	flights singleflight.Group
+}
+
+func newGasEstimator(workers, cacheSize int, ttl time.Duration) *gasEstimator {
+	cache, _ := lru.New(cacheSize)
+	return &gasEstimator{
+		workers: make(chan struct{}, workers),
+		ttl:     ttl,
+		timeout: estimateGasTimeout,
+		cache:   cache,
+	}
+}
+
+// estimationKey returns the key of an estimation against the block for the
+// tenant identity, empty without multitenancy.
+func estimationKey(args CallArgs, header *types.Header, identity string) common.Hash {
+	enc, _ := json.Marshal(args)
+	return crypto.Keccak256Hash(enc, header.Hash().Bytes(), []byte(identity))
+}
+
+// estimate returns the cached estimation of the key, or the result of run once
+// a worker is free. The run is shared by the concurrent estimations of the key
+// and does not depend on the context of any of them, only on its values.
+func (e *gasEstimator) estimate(ctx context.Context, key common.Hash, run func(ctx context.Context) (hexutil.Uint64, error)) (hexutil.Uint64, error) {
+	if cached, ok := e.cache.Get(key); ok && time.Now().Before(cached.(*cachedEstimate).expires) {
+		estimateGasHitMeter.Mark(1)
+		return cached.(*cachedEstimate).gas, nil
+	}
+	estimateGasMissMeter.Mark(1)
+
+	flight := e.flights.DoChan(key.Hex(), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, e.timeout)
+		defer cancel()
+
+		estimateGasQueueGauge.Inc(1)
+		select {
+		case e.workers <- struct{}{}:
+			estimateGasQueueGauge.Dec(1)
+		case <-ctx.Done():
+			estimateGasQueueGauge.Dec(1)
+			return nil, ctx.Err()
+		}
+		defer func() { <-e.workers }()
+
+		gas, err := run(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.cache.Add(key, &cachedEstimate{gas: gas, expires: time.Now().Add(e.ttl)})
+		return gas, nil
+	})
+	select {
+	case result := <-flight:
+		if result.Err != nil {
+			return 0, result.Err
+		}
+		return result.Val.(hexutil.Uint64), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// detachedContext carries the values of a context, but neither its deadline
+// nor its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,118 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasEstimator_CachedPerState(t *testing.T) {
+	e := newGasEstimator(1, 16, time.Minute)
+	to := common.Address{0x01}
+	args := CallArgs{To: &to}
+	before := &types.Header{Number: big.NewInt(1), Root: common.Hash{0x01}}
+	after := &types.Header{Number: big.NewInt(1), Root: common.Hash{0x02}}
+	var runs int32
+	estimateWith := func(gas hexutil.Uint64) func(context.Context) (hexutil.Uint64, error) {
+		return func(context.Context) (hexutil.Uint64, error) {
+			atomic.AddInt32(&runs, 1)
+			return gas, nil
+		}
+	}
+
+	gas, err := e.estimate(context.Background(), estimationKey(args, before, ""), estimateWith(21000))
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(21000), gas)
+	gas, err = e.estimate(context.Background(), estimationKey(args, before, ""), estimateWith(42000))
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(21000), gas, "cached")
+
+	// the estimation is not cached across a state change, nor across tenants
+	gas, err = e.estimate(context.Background(), estimationKey(args, after, ""), estimateWith(42000))
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(42000), gas, "state changed")
+	gas, err = e.estimate(context.Background(), estimationKey(args, before, "tenant"), estimateWith(63000))
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(63000), gas, "other tenant")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+}
+
+func TestGasEstimator_ErrorsAndExpiredNotCached(t *testing.T) {
+	e := newGasEstimator(1, 16, 10*time.Millisecond)
+	key := common.Hash{0x01}
+	failure := errors.New("execution reverted")
+
+	_, err := e.estimate(context.Background(), key, func(context.Context) (hexutil.Uint64, error) { return 0, failure })
+	assert.Equal(t, failure, err)
+	gas, err := e.estimate(context.Background(), key, func(context.Context) (hexutil.Uint64, error) { return 21000, nil })
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(21000), gas)
+
+	time.Sleep(20 * time.Millisecond)
+	gas, err = e.estimate(context.Background(), key, func(context.Context) (hexutil.Uint64, error) { return 42000, nil })
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Uint64(42000), gas, "expired")
+}
+
+func TestGasEstimator_ConcurrentShared(t *testing.T) {
+	e := newGasEstimator(1, 16, time.Minute)
+	var (
+		runs    int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	run := func(context.Context) (hexutil.Uint64, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return 21000, nil
+	}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gas, err := e.estimate(context.Background(), common.Hash{0x01}, run)
+			assert.NoError(t, err)
+			assert.Equal(t, hexutil.Uint64(21000), gas)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+}
+
+func TestGasEstimator_WorkersBounded(t *testing.T) {
+	e := newGasEstimator(1, 16, time.Minute)
+	e.timeout = 30 * time.Millisecond
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go e.estimate(context.Background(), common.Hash{0x01}, func(context.Context) (hexutil.Uint64, error) {
+		close(started)
+		<-release
+		return 21000, nil
+	})
+	<-started
+
+	// no worker is free for another estimation until it times out
+	var ran int32
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := e.estimate(ctx, common.Hash{0x02}, func(context.Context) (hexutil.Uint64, error) {
+		atomic.StoreInt32(&ran, 1)
+		return 0, nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	assert.Zero(t, atomic.LoadInt32(&ran))
+}
@@ -0,0 +1,191 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Quorum
+//
+// The chains have no base fee, the whole gas price of a transaction being its
+// priority fee. Quorum chains are gas-free, their fees are all zero, while the
+// rewards of the other chains are the gas prices of their transactions.
+
+const (
+	// maxFeeHistoryBlocks caps the number of blocks of a fee history.
+	maxFeeHistoryBlocks = 1024
+	// maxFeeHistoryPercentiles caps the number of reward percentiles.
+	maxFeeHistoryPercentiles = 100
+)
+
+var errInvalidPercentile = errors.New("invalid reward percentile")
+
+// FeeHistory is the fee market history of a range of blocks, as returned by
+// eth_feeHistory.
+type FeeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// DecimalOrHex unmarshals a number given either as a decimal or a hex string.
+type DecimalOrHex uint64
+
+func (n *DecimalOrHex) UnmarshalJSON(input []byte) error {
+	var number uint64
+	if err := json.Unmarshal(input, &number); err == nil {
+		*n = DecimalOrHex(number)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	number, err := hexutil.DecodeUint64(s)
+	if err != nil {
+		if number, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return fmt.Errorf("invalid block count %s", s)
+		}
+	}
+	*n = DecimalOrHex(number)
+	return nil
+}
+
+// MaxPriorityFeePerGas returns a suggestion of the priority fee of a
+// transaction, zero on gas-free chains.
+func (s *PublicEthereumAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	if s.b.ChainConfig().IsQuorum {
+		return (*hexutil.Big)(new(big.Int)), nil
+	}
+	tip, err := s.b.SuggestPrice(ctx)
+	return (*hexutil.Big)(tip), err
+}
+
+// FeeHistory returns the base fees, the gas used ratios and the given
+// percentiles of the priority fees, weighted by gas used, of the blockCount
+// blocks ending with lastBlock.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount DecimalOrHex, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistory, error) {
+	return GetFeeHistory(ctx, s.b, uint64(blockCount), lastBlock, rewardPercentiles)
+}
+
+// GetFeeHistory returns the fee history of the blockCount blocks ending with
+// lastBlock, capped to the known blocks.
+func GetFeeHistory(ctx context.Context, b Backend, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistory, error) {
+	if len(rewardPercentiles) > maxFeeHistoryPercentiles {
+		return nil, fmt.Errorf("%w: more than %d percentiles", errInvalidPercentile, maxFeeHistoryPercentiles)
+	}
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 || (i > 0 && p < rewardPercentiles[i-1]) {
+			return nil, fmt.Errorf("%w: %f, the percentiles must be increasing within [0, 100]", errInvalidPercentile, p)
+		}
+	}
+	if blockCount > maxFeeHistoryBlocks {
+		blockCount = maxFeeHistoryBlocks
+	}
+	if lastBlock == rpc.PendingBlockNumber {
+		lastBlock = rpc.LatestBlockNumber
+	}
+	head, err := b.HeaderByNumber(ctx, lastBlock)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, fmt.Errorf("block %d not found", lastBlock)
+	}
+	last := head.Number.Uint64()
+	if blockCount > last+1 {
+		blockCount = last + 1
+	}
+	oldest := last + 1 - blockCount
+	history := &FeeHistory{
+		OldestBlock:  (*hexutil.Big)(new(big.Int).SetUint64(oldest)),
+		GasUsedRatio: make([]float64, blockCount),
+	}
+	if blockCount == 0 {
+		return history, nil
+	}
+	history.BaseFee = make([]*hexutil.Big, blockCount+1)
+	for i := range history.BaseFee {
+		history.BaseFee[i] = (*hexutil.Big)(new(big.Int))
+	}
+	if len(rewardPercentiles) > 0 {
+		history.Reward = make([][]*hexutil.Big, blockCount)
+	}
+	gasFree := b.ChainConfig().IsQuorum
+	for i := uint64(0); i < blockCount; i++ {
+		block, err := b.BlockByNumber(ctx, rpc.BlockNumber(oldest+i))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", oldest+i)
+		}
+		if block.GasLimit() > 0 {
+			history.GasUsedRatio[i] = float64(block.GasUsed()) / float64(block.GasLimit())
+		}
+		if history.Reward == nil {
+			continue
+		}
+		if gasFree || len(block.Transactions()) == 0 {
+			history.Reward[i] = make([]*hexutil.Big, len(rewardPercentiles))
+			for j := range history.Reward[i] {
+				history.Reward[i][j] = (*hexutil.Big)(new(big.Int))
+			}
+			continue
+		}
+		receipts, err := b.GetReceipts(ctx, block.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if history.Reward[i], err = blockRewards(block, receipts, rewardPercentiles); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+type txGasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+// blockRewards returns the percentiles of the gas prices of the transactions
+// of the block, weighted by the gas they used.
+func blockRewards(block *types.Block, receipts types.Receipts, percentiles []float64) ([]*hexutil.Big, error) {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts of block %d not found", block.NumberU64())
+	}
+	sorted := make([]txGasAndReward, len(txs))
+	for i, tx := range txs {
+		sorted[i] = txGasAndReward{gasUsed: receipts[i].GasUsed, reward: tx.GasPrice()}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].reward.Cmp(sorted[j].reward) < 0
+	})
+	var (
+		rewards  = make([]*hexutil.Big, len(percentiles))
+		txIndex  int
+		sumGas   = sorted[0].gasUsed
+		gasTotal = block.GasUsed()
+	)
+	for i, p := range percentiles {
+		threshold := uint64(float64(gasTotal) * p / 100)
+		for sumGas < threshold && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGas += sorted[txIndex].gasUsed
+		}
+		rewards[i] = (*hexutil.Big)(sorted[txIndex].reward)
+	}
+	return rewards, nil
+}
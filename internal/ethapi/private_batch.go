@@ -0,0 +1,301 @@
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// A batch of dependent private transactions, typically deploying a private
+// contract and calling it, is sent in three phases. All the entries are first
+// validated and given contiguous nonces, then all their payloads distributed
+// to the transaction manager and their transactions signed, and only then are
+// the transactions submitted to the pool, in order. A failure before the
+// submission submits nothing: the payloads already distributed are orphaned,
+// the transaction manager being unable to take them back, and recorded for
+// their later reconciliation.
+//
+// The batch takes a slot of the asynchronous sends for its whole duration, so
+// that the distributions of the node are bounded together. The entries being
+// distributed before any is submitted, the calls of a batch to a contract it
+// deploys must be standard private.
+
+const (
+	maxPrivateTransactionBatch = 64   // entries of a batch at most
+	maxOrphanedPayloads        = 1024 // orphaned payloads recorded at most
+)
+
+// Statuses of the entries of a private transaction batch.
+const (
+	BatchEntrySubmitted = "submitted" // submitted to the pool
+	BatchEntryOrphaned  = "orphaned"  // distributed, not submitted
+	BatchEntryFailed    = "failed"    // failing the batch
+	BatchEntrySkipped   = "skipped"   // neither distributed nor submitted
+)
+
+var privateBatchOrphanedMeter = metrics.NewRegisteredMeter("quorum/privatebatch/orphaned", nil)
+
+// PrivateBatchEntry is the outcome of an entry of a private transaction batch.
+type PrivateBatchEntry struct {
+	Status      string         `json:"status"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	PayloadHash string         `json:"payloadHash,omitempty"`
+	TxHash      *common.Hash   `json:"txHash,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// PrivateBatchResult is the outcome of a private transaction batch, submitted
+// if all its entries are.
+type PrivateBatchResult struct {
+	Submitted bool                `json:"submitted"`
+	Entries   []PrivateBatchEntry `json:"entries"`
+}
+
+// OrphanedPayload is a private payload distributed by a batch whose
+// transaction was never submitted.
+type OrphanedPayload struct {
+	PayloadHash string         `json:"payloadHash"`
+	From        common.Address `json:"from"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Recorded    time.Time      `json:"recorded"`
+	Reason      string         `json:"reason"`
+}
+
+// orphanedPayloads records the latest orphaned payloads, oldest first.
+type orphanedPayloads struct {
+	mu      sync.Mutex
+	entries []OrphanedPayload
+}
+
+var orphaned = new(orphanedPayloads)
+
+func (o *orphanedPayloads) record(entry OrphanedPayload) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.entries = append(o.entries, entry)
+	if len(o.entries) > maxOrphanedPayloads {
+		o.entries = o.entries[len(o.entries)-maxOrphanedPayloads:]
+	}
+	privateBatchOrphanedMeter.Mark(1)
+	log.Warn("Orphaned private payload of a batch", "hash", entry.PayloadHash, "from", entry.From, "nonce", uint64(entry.Nonce), "reason", entry.Reason)
+}
+
+func (o *orphanedPayloads) list() []OrphanedPayload {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]OrphanedPayload{}, o.entries...)
+}
+
+// PublicPrivateBatchAPI sends batches of private transactions.
+type PublicPrivateBatchAPI struct {
+	b         Backend
+	nonceLock *AddrLocker
+}
+
+// NewPublicPrivateBatchAPI creates a new private transaction batch API.
+func NewPublicPrivateBatchAPI(b Backend, nonceLock *AddrLocker) *PublicPrivateBatchAPI {
+	return &PublicPrivateBatchAPI{b, nonceLock}
+}
+
+// OrphanedPrivatePayloads returns the latest private payloads distributed by
+// the batches which failed before submitting their transactions, oldest first.
+func (api *PublicPrivateBatchAPI) OrphanedPrivatePayloads() []OrphanedPayload {
+	return orphaned.list()
+}
+
+// SendPrivateTransactionBatch distributes the payloads of the private
+// transactions and submits them in order, or submits none if any fails. The
+// nonces of the transactions of an account must be contiguous, those not given
+// following the pending nonce of the account or the previous transaction.
+func (api *PublicPrivateBatchAPI) SendPrivateTransactionBatch(ctx context.Context, batch []SendTxArgs) (*PrivateBatchResult, error) {
+	if len(batch) == 0 {
+		return nil, errors.New("empty private transaction batch")
+	}
+	if len(batch) > maxPrivateTransactionBatch {
+		return nil, fmt.Errorf("private transaction batch of %d transactions exceeds the limit of %d", len(batch), maxPrivateTransactionBatch)
+	}
+	select {
+	case async.sem <- struct{}{}:
+		defer func() { <-async.sem }()
+	default:
+		return nil, errors.New("too many concurrent requests")
+	}
+	if err := api.b.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	// validate all the entries while holding the nonces of their accounts
+	senders := make([]common.Address, 0, len(batch))
+	wallets := make(map[common.Address]accounts.Wallet)
+	for _, args := range batch {
+		if _, ok := wallets[args.From]; ok {
+			continue
+		}
+		wallet, err := api.b.AccountManager().Find(accounts.Account{Address: args.From})
+		if err != nil {
+			return nil, err
+		}
+		wallets[args.From] = wallet
+		senders = append(senders, args.From)
+	}
+	sort.Slice(senders, func(i, j int) bool { return bytes.Compare(senders[i][:], senders[j][:]) < 0 })
+	for _, sender := range senders {
+		api.nonceLock.LockAddr(sender)
+		defer api.nonceLock.UnlockAddr(sender)
+	}
+	if err := api.validateBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	// distribute all the payloads and sign all the transactions
+	result := &PrivateBatchResult{Entries: make([]PrivateBatchEntry, len(batch))}
+	for i := range batch {
+		result.Entries[i] = PrivateBatchEntry{Status: BatchEntrySkipped, Nonce: *batch[i].Nonce}
+	}
+	signed := make([]*types.Transaction, len(batch))
+	contexts := make([]context.Context, len(batch)) // tracking the latency of the entries
+	for i := range batch {
+		args := &batch[i]
+		entry := &result.Entries[i]
+		contexts[i], _ = core.WithTxSubmission(ctx)
+		_, hash, err := checkAndHandlePrivateTransaction(contexts[i], api.b, args.toTransaction(), &args.PrivateTxArgs, args.From, NormalTransaction)
+		if err == nil {
+			if !common.EmptyEncryptedPayloadHash(hash) {
+				entry.PayloadHash = hash.ToBase64()
+				args.Data, args.Input = hash.BytesTypeRef(), nil
+			}
+			signed[i], err = api.sign(wallets[args.From], args)
+		}
+		if err != nil {
+			entry.Status, entry.Error = BatchEntryFailed, err.Error()
+			api.orphan(batch, result, fmt.Sprintf("transaction %d of the batch failed: %v", i, err))
+			return result, nil
+		}
+		entry.Status = BatchEntryOrphaned
+	}
+
+	// submit all the transactions, the first failure orphaning the next ones
+	for i, tx := range signed {
+		args := &batch[i]
+		entry := &result.Entries[i]
+		txHash, err := SubmitTransaction(contexts[i], api.b, tx, args.PrivateFrom, args.PrivateFor, false)
+		if err != nil {
+			entry.Status, entry.Error = BatchEntryFailed, err.Error()
+			api.orphan(batch, result, fmt.Sprintf("transaction %d of the batch was rejected: %v", i, err))
+			return result, nil
+		}
+		entry.Status, entry.TxHash = BatchEntrySubmitted, &txHash
+	}
+	result.Submitted = true
+	return result, nil
+}
+
+// validateBatch validates the entries of the batch before any is distributed,
+// and sets their defaults and contiguous nonces.
+func (api *PublicPrivateBatchAPI) validateBatch(ctx context.Context, batch []SendTxArgs) error {
+	next := make(map[common.Address]uint64)
+	for i := range batch {
+		args := &batch[i]
+		applySendDefaults(ctx, &args.PrivateTxArgs)
+		if !args.IsPrivate() {
+			return fmt.Errorf("transaction %d of the batch is not private", i)
+		}
+		if err := validateBatchRecipients(&args.PrivateTxArgs); err != nil {
+			return fmt.Errorf("transaction %d of the batch: %v", i, err)
+		}
+		if err := args.PrivacyFlag.Validate(); err != nil {
+			return fmt.Errorf("transaction %d of the batch: %v", i, err)
+		}
+		if !api.b.ChainConfig().IsPrivacyEnhancementsEnabled(api.b.CurrentBlock().Number()) && args.PrivacyFlag.IsNotStandardPrivate() {
+			return fmt.Errorf("transaction %d of the batch: PrivacyEnhancements are disabled. Can only accept transactions with PrivacyFlag=0(StandardPrivate).", i)
+		}
+		if err := checkMandatoryRecipients(&args.PrivateTxArgs); err != nil {
+			return fmt.Errorf("transaction %d of the batch: %v", i, err)
+		}
+
+		nonce, seen := next[args.From]
+		if !seen {
+			pending, err := api.b.GetPoolNonce(ctx, args.From)
+			if err != nil {
+				return err
+			}
+			nonce = pending
+			if args.Nonce != nil {
+				if uint64(*args.Nonce) < pending {
+					return fmt.Errorf("transaction %d of the batch: nonce %d of %s too low, pending nonce %d", i, uint64(*args.Nonce), args.From.Hex(), pending)
+				}
+				nonce = uint64(*args.Nonce)
+			}
+		}
+		if args.Nonce != nil && uint64(*args.Nonce) != nonce {
+			return fmt.Errorf("transaction %d of the batch: nonce %d of %s not contiguous, expected %d", i, uint64(*args.Nonce), args.From.Hex(), nonce)
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
+		next[args.From] = nonce + 1
+
+		if err := args.setDefaults(ctx, api.b); err != nil {
+			return fmt.Errorf("transaction %d of the batch: %v", i, err)
+		}
+		if err := checkArgsSlotPolicy(ctx, api.b, args); err != nil {
+			return fmt.Errorf("transaction %d of the batch: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// validateBatchRecipients fails unless the sender and the recipients are
+// public keys of the transaction manager.
+func validateBatchRecipients(args *PrivateTxArgs) error {
+	if len(args.PrivateFor) == 0 {
+		return errors.New("no private recipients")
+	}
+	keys := append([]string{}, args.PrivateFor...)
+	if args.PrivateFrom != "" {
+		keys = append(keys, args.PrivateFrom)
+	}
+	for _, key := range keys {
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 32 {
+			return fmt.Errorf("invalid private recipient %q", key)
+		}
+	}
+	return nil
+}
+
+// sign signs the private transaction of the entry with the wallet of its sender.
+func (api *PublicPrivateBatchAPI) sign(wallet accounts.Wallet, args *SendTxArgs) (*types.Transaction, error) {
+	tx := args.toTransaction()
+	tx.SetPrivate()
+	// private transactions are signed without chain id
+	return wallet.SignTx(accounts.Account{Address: args.From}, tx, nil)
+}
+
+// orphan records the payloads of the entries distributed but not submitted.
+func (api *PublicPrivateBatchAPI) orphan(batch []SendTxArgs, result *PrivateBatchResult, reason string) {
+	for i, entry := range result.Entries {
+		if entry.Status == BatchEntryOrphaned && entry.PayloadHash != "" {
+			orphaned.record(OrphanedPayload{
+				PayloadHash: entry.PayloadHash,
+				From:        batch[i].From,
+				Nonce:       entry.Nonce,
+				Recorded:    time.Now(),
+				Reason:      reason,
+			})
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchBackend struct {
+	StubBackend
+	poolNonce uint64
+	sent      []*types.Transaction
+}
+
+func (b *batchBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return b.poolNonce, nil
+}
+
+func (b *batchBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	b.sent = append(b.sent, signedTx)
+	return nil
+}
+
+// failingSendPrivateTransactionManager fails the failAt-th distribution.
+type failingSendPrivateTransactionManager struct {
+	StubPrivateTransactionManager
+	sends, failAt int
+}
+
+func (ptm *failingSendPrivateTransactionManager) Send(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
+	ptm.sends++
+	if ptm.sends == ptm.failAt {
+		return "", nil, common.EncryptedPayloadHash{}, errors.New("transaction manager unavailable")
+	}
+	return ptm.StubPrivateTransactionManager.Send(ctx, data, from, to, extra)
+}
+
+func newBatchAPI(t *testing.T, poolNonce uint64) (*PublicPrivateBatchAPI, *batchBackend, common.Address) {
+	dir, err := ioutil.TempDir("", "ethapi-batch-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("")
+	require.NoError(t, err)
+	require.NoError(t, ks.Unlock(account, ""))
+	b := &batchBackend{StubBackend: StubBackend{accountManager: accounts.NewManager(&accounts.Config{}, ks)}, poolNonce: poolNonce}
+	return NewPublicPrivateBatchAPI(b, new(AddrLocker)), b, account.Address
+}
+
+// newBatchArgs deploys a private contract and calls it.
+func newBatchArgs(from common.Address, nonce uint64) []SendTxArgs {
+	recipient := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, 32))
+	gas := hexutil.Uint64(90000)
+	deploy := hexutil.Bytes(hexutil.MustDecode("0x6060604052"))
+	call := hexutil.Bytes(standardPrivateSimpleStorageContractMessageCallTx.Data())
+	contract := crypto.CreateAddress(from, nonce)
+	return []SendTxArgs{{
+		PrivateTxArgs: PrivateTxArgs{PrivateFor: []string{recipient}},
+		From:          from,
+		Gas:           &gas,
+		GasPrice:      (*hexutil.Big)(big.NewInt(0)),
+		Data:          &deploy,
+	}, {
+		PrivateTxArgs: PrivateTxArgs{PrivateFor: []string{recipient}},
+		From:          from,
+		To:            &contract,
+		Gas:           &gas,
+		GasPrice:      (*hexutil.Big)(big.NewInt(0)),
+		Data:          &call,
+	}}
+}
+
+func TestSendPrivateTransactionBatch(t *testing.T) {
+	private.P = &StubPrivateTransactionManager{}
+	api, b, from := newBatchAPI(t, 3)
+
+	result, err := api.SendPrivateTransactionBatch(arbitraryCtx, newBatchArgs(from, 3))
+	require.NoError(t, err)
+	assert.True(t, result.Submitted)
+	require.Len(t, b.sent, 2)
+	for i, entry := range result.Entries {
+		assert.Equal(t, BatchEntrySubmitted, entry.Status)
+		assert.Equal(t, hexutil.Uint64(3+i), entry.Nonce, "contiguous from the pool nonce")
+		assert.Equal(t, arbitrarySimpleStorageContractEncryptedPayloadHash.ToBase64(), entry.PayloadHash)
+		require.NotNil(t, entry.TxHash)
+		assert.Equal(t, b.sent[i].Hash(), *entry.TxHash)
+		assert.True(t, b.sent[i].IsPrivate())
+		assert.Equal(t, arbitrarySimpleStorageContractEncryptedPayloadHash.Bytes(), b.sent[i].Data())
+	}
+	assert.Nil(t, b.sent[0].To(), "submitted in order")
+}
+
+func TestSendPrivateTransactionBatch_whenDistributionFails(t *testing.T) {
+	ptm := &failingSendPrivateTransactionManager{failAt: 2}
+	private.P = ptm
+	defer func() { private.P = &StubPrivateTransactionManager{} }()
+	api, b, from := newBatchAPI(t, 0)
+	before := len(orphaned.list())
+
+	result, err := api.SendPrivateTransactionBatch(arbitraryCtx, newBatchArgs(from, 0))
+	require.NoError(t, err)
+	assert.False(t, result.Submitted)
+	assert.Empty(t, b.sent, "nothing submitted")
+	assert.Equal(t, BatchEntryOrphaned, result.Entries[0].Status)
+	assert.Equal(t, BatchEntryFailed, result.Entries[1].Status)
+	assert.Contains(t, result.Entries[1].Error, "transaction manager unavailable")
+
+	recorded := api.OrphanedPrivatePayloads()
+	require.Len(t, recorded, before+1)
+	assert.Equal(t, result.Entries[0].PayloadHash, recorded[before].PayloadHash)
+	assert.Equal(t, from, recorded[before].From)
+}
+
+func TestSendPrivateTransactionBatch_validatedFirst(t *testing.T) {
+	ptm := &failingSendPrivateTransactionManager{}
+	private.P = ptm
+	defer func() { private.P = &StubPrivateTransactionManager{} }()
+	api, b, from := newBatchAPI(t, 5)
+
+	gap := newBatchArgs(from, 5)
+	nonce := hexutil.Uint64(7)
+	gap[1].Nonce = &nonce
+	_, err := api.SendPrivateTransactionBatch(arbitraryCtx, gap)
+	assert.EqualError(t, err, "transaction 1 of the batch: nonce 7 of "+from.Hex()+" not contiguous, expected 6")
+
+	low := newBatchArgs(from, 4)
+	nonce = hexutil.Uint64(4)
+	low[0].Nonce = &nonce
+	_, err = api.SendPrivateTransactionBatch(arbitraryCtx, low)
+	assert.Error(t, err, "nonce below the pool nonce")
+
+	invalid := newBatchArgs(from, 5)
+	invalid[1].PrivateFor = []string{"arbitrary party 1"}
+	_, err = api.SendPrivateTransactionBatch(arbitraryCtx, invalid)
+	assert.EqualError(t, err, `transaction 1 of the batch: invalid private recipient "arbitrary party 1"`)
+
+	public := newBatchArgs(from, 5)
+	public[0].PrivateFor = nil
+	_, err = api.SendPrivateTransactionBatch(arbitraryCtx, public)
+	assert.EqualError(t, err, "transaction 0 of the batch is not private")
+
+	_, err = api.SendPrivateTransactionBatch(arbitraryCtx, make([]SendTxArgs, maxPrivateTransactionBatch+1))
+	assert.Error(t, err, "too large")
+
+	assert.Zero(t, ptm.sends, "nothing distributed")
+	assert.Empty(t, b.sent)
+}
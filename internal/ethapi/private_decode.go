@@ -0,0 +1,194 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/private"
+)
+
+// Quorum
+//
+// A private transaction is decoded from its payload as received from the
+// transaction manager, the node having to be a party to it. The ABI is never
+// stored: it is supplied by the caller, e.g. the compiler output of the
+// contract. The arguments of a contract creation are appended to the bytecode,
+// hence only known to start before the end of the payload if their encoding
+// has a static size.
+
+var errPrivacyDisabled = errors.New("PrivateTransactionManager is not enabled")
+
+// DecodedArgument is an argument of a decoded private transaction.
+type DecodedArgument struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// DecodedPrivateTransaction is the call of a private transaction decoded
+// against the ABI of its contract.
+type DecodedPrivateTransaction struct {
+	Creation bool              `json:"creation"`
+	Method   string            `json:"method"`
+	Selector hexutil.Bytes     `json:"selector,omitempty"`
+	Args     []DecodedArgument `json:"args"`
+}
+
+// DecodePrivateTransaction decodes the call of a private transaction, from
+// its private payload, against the ABI of the contract it creates or calls.
+func (api *PublicQuorumAPI) DecodePrivateTransaction(ctx context.Context, txHash common.Hash, abiJSON string) (*DecodedPrivateTransaction, error) {
+	if !private.IsQuorumPrivacyEnabled() {
+		return nil, errPrivacyDisabled
+	}
+	return decodePrivateTransaction(ctx, api.b, txHash, abiJSON)
+}
+
+func decodePrivateTransaction(ctx context.Context, b Backend, txHash common.Hash, abiJSON string) (*DecodedPrivateTransaction, error) {
+	contractABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("malformed ABI: %v", err)
+	}
+	tx, _, _, _, err := b.GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		if tx = b.GetPoolTransaction(txHash); tx == nil {
+			return nil, fmt.Errorf("transaction %s not found", txHash.Hex())
+		}
+	}
+	if !tx.IsPrivate() {
+		return nil, fmt.Errorf("transaction %s is not private", txHash.Hex())
+	}
+	_, _, payload, _, err := private.P.Receive(ctx, common.BytesToEncryptedPayloadHash(tx.Data()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive the private payload of %s: %v", txHash.Hex(), err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("not a party to private transaction %s", txHash.Hex())
+	}
+	if tx.To() == nil {
+		return decodeCreation(&contractABI, payload)
+	}
+	return decodeCall(&contractABI, payload)
+}
+
+func decodeCall(contractABI *abi.ABI, payload []byte) (*DecodedPrivateTransaction, error) {
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("payload of %d bytes has no method selector", len(payload))
+	}
+	method, err := contractABI.MethodById(payload[:4])
+	if err != nil {
+		return nil, fmt.Errorf("method selector %s not in the ABI", hexutil.Encode(payload[:4]))
+	}
+	args, err := decodeArguments(method.Inputs, payload[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the arguments of %s: %v", method.Sig, err)
+	}
+	return &DecodedPrivateTransaction{Method: method.RawName, Selector: payload[:4], Args: args}, nil
+}
+
+func decodeCreation(contractABI *abi.ABI, payload []byte) (*DecodedPrivateTransaction, error) {
+	decoded := &DecodedPrivateTransaction{Creation: true, Method: "constructor", Args: []DecodedArgument{}}
+	inputs := contractABI.Constructor.Inputs
+	if len(inputs) == 0 {
+		return decoded, nil
+	}
+	size := 0
+	for _, input := range inputs {
+		if isDynamicType(input.Type) {
+			return nil, fmt.Errorf("constructor argument %s of dynamic type %s cannot be told from the bytecode", input.Name, input.Type)
+		}
+		size += staticTypeSize(input.Type)
+	}
+	if len(payload) < size {
+		return nil, fmt.Errorf("payload of %d bytes shorter than the constructor arguments", len(payload))
+	}
+	args, err := decodeArguments(inputs, payload[len(payload)-size:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the constructor arguments: %v", err)
+	}
+	decoded.Args = args
+	return decoded, nil
+}
+
+func decodeArguments(inputs abi.Arguments, data []byte) ([]DecodedArgument, error) {
+	values, err := inputs.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]DecodedArgument, len(inputs))
+	for i, input := range inputs {
+		args[i] = DecodedArgument{Name: input.Name, Type: input.Type.String(), Value: formatDecodedValue(values[i])}
+	}
+	return args, nil
+}
+
+// formatDecodedValue encodes the bytes in hex rather than in base64 or as
+// arrays of numbers.
+func formatDecodedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return hexutil.Bytes(v)
+	case *big.Int:
+		return (*hexutil.Big)(v)
+	case common.Address, common.Hash, bool, string:
+		return v
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Bytes(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		elems := make([]interface{}, rv.Len())
+		for i := range elems {
+			elems[i] = formatDecodedValue(rv.Index(i).Interface())
+		}
+		return elems
+	}
+	return value
+}
+
+// isDynamicType reports whether the encoding of the type has a dynamic size.
+func isDynamicType(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy:
+		return true
+	case abi.ArrayTy:
+		return isDynamicType(*t.Elem)
+	case abi.TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// staticTypeSize returns the size of the encoding of a type of static size.
+func staticTypeSize(t abi.Type) int {
+	switch t.T {
+	case abi.ArrayTy:
+		return t.Size * staticTypeSize(*t.Elem)
+	case abi.TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += staticTypeSize(*elem)
+		}
+		return size
+	}
+	return 32
+}
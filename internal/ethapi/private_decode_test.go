@@ -0,0 +1,128 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// simpleStorageABI is the ABI of
+//
+//	contract SimpleStorage {
+//	    uint public storedData;
+//	    constructor(uint initVal) public { storedData = initVal; }
+//	    function set(uint x) public { storedData = x; }
+//	    function get() public view returns (uint retVal) { return storedData; }
+//	}
+const simpleStorageABI = `[{"constant":true,"inputs":[],"name":"storedData","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":false,"inputs":[{"name":"x","type":"uint256"}],"name":"set","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"retVal","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},{"inputs":[{"name":"initVal","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"constructor"}]`
+
+type decodeBackend struct {
+	StubBackend
+	txs map[common.Hash]*types.Transaction
+}
+
+func (b *decodeBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return b.txs[txHash], common.Hash{}, 0, 0, nil
+}
+
+func (b *decodeBackend) GetPoolTransaction(txHash common.Hash) *types.Transaction {
+	return nil
+}
+
+// receivingPrivateTransactionManager returns the payloads it holds, none for
+// the others as if the node was not a party to them.
+type receivingPrivateTransactionManager struct {
+	StubPrivateTransactionManager
+	payloads map[common.EncryptedPayloadHash][]byte
+}
+
+func (ptm *receivingPrivateTransactionManager) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	return "", nil, ptm.payloads[hash], nil, nil
+}
+
+func TestDecodePrivateTransaction(t *testing.T) {
+	ptm := &receivingPrivateTransactionManager{payloads: make(map[common.EncryptedPayloadHash][]byte)}
+	private.P = ptm
+	defer func() { private.P = &StubPrivateTransactionManager{} }()
+	b := &decodeBackend{txs: make(map[common.Hash]*types.Transaction)}
+
+	// a private transaction with the payload, nil for a transaction the node
+	// is not a party to
+	add := func(to *common.Address, payload []byte) common.Hash {
+		hash := common.BytesToEncryptedPayloadHash(common.LeftPadBytes(big.NewInt(int64(len(b.txs)+1)).Bytes(), 64))
+		if payload != nil {
+			ptm.payloads[hash] = payload
+		}
+		var tx *types.Transaction
+		if to == nil {
+			tx = types.NewContractCreation(uint64(len(b.txs)), common.Big0, 1000000, common.Big0, hash.Bytes())
+		} else {
+			tx = types.NewTransaction(uint64(len(b.txs)), *to, common.Big0, 1000000, common.Big0, hash.Bytes())
+		}
+		tx.SetPrivate()
+		b.txs[tx.Hash()] = tx
+		return tx.Hash()
+	}
+	contract := common.HexToAddress("0x1932c48b2bf8102ba33b4a6b545c32236e342f34")
+	decode := func(txHash common.Hash, abiJSON string) (*DecodedPrivateTransaction, error) {
+		return decodePrivateTransaction(context.Background(), b, txHash, abiJSON)
+	}
+
+	call := add(&contract, hexutil.MustDecode("0x60fe47b1000000000000000000000000000000000000000000000000000000000000000e"))
+	decoded, err := decode(call, simpleStorageABI)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"creation":false,"method":"set","selector":"0x60fe47b1","args":[{"name":"x","type":"uint256","value":"0xe"}]}`, string(encoded))
+
+	creation := add(nil, append(hexutil.MustDecode("0x6080604052348015600f57600080fd5b50"), common.LeftPadBytes([]byte{42}, 32)...))
+	decoded, err = decode(creation, simpleStorageABI)
+	require.NoError(t, err)
+	encoded, err = json.Marshal(decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"creation":true,"method":"constructor","args":[{"name":"initVal","type":"uint256","value":"0x2a"}]}`, string(encoded))
+
+	notParty := add(&contract, nil)
+	_, err = decode(notParty, simpleStorageABI)
+	assert.EqualError(t, err, "not a party to private transaction "+notParty.Hex())
+
+	_, err = decode(add(&contract, hexutil.MustDecode("0xdeadbeef")), simpleStorageABI)
+	assert.EqualError(t, err, "method selector 0xdeadbeef not in the ABI")
+
+	_, err = decode(add(&contract, hexutil.MustDecode("0x60fe47b1")), simpleStorageABI)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decode the arguments of set(uint256)")
+
+	_, err = decode(call, `[{"type":"function"`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed ABI")
+
+	public := types.NewTransaction(100, contract, common.Big0, 21000, common.Big0, nil)
+	b.txs[public.Hash()] = public
+	_, err = decode(public.Hash(), simpleStorageABI)
+	assert.EqualError(t, err, "transaction "+public.Hash().Hex()+" is not private")
+
+	_, err = decode(common.Hash{1}, simpleStorageABI)
+	assert.EqualError(t, err, "transaction "+common.Hash{1}.Hex()+" not found")
+}
+
+func TestFormatDecodedValue(t *testing.T) {
+	encoded, err := json.Marshal([]interface{}{
+		formatDecodedValue([4]byte{0xde, 0xad, 0xbe, 0xef}),
+		formatDecodedValue([]byte{1, 2}),
+		formatDecodedValue([]*big.Int{big.NewInt(1), big.NewInt(255)}),
+		formatDecodedValue(common.HexToAddress("0x1")),
+		formatDecodedValue(uint8(7)),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["0xdeadbeef","0x0102",["0x1","0xff"],"0x0000000000000000000000000000000000000001",7]`, string(encoded))
+}
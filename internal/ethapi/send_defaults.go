@@ -0,0 +1,196 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// maxSendDefaults bounds the number of identities with send defaults.
+const maxSendDefaults = 10000
+
+var (
+	errSendDefaultsDisabled        = errors.New("send defaults are disabled")
+	errSendDefaultsUnauthenticated = errors.New("send defaults require an authenticated client")
+	errTooManySendDefaults         = errors.New("too many clients with send defaults")
+)
+
+// SendDefaults are the private transaction settings of an authenticated
+// client, applied to its eth_sendTransaction calls which omit them. The
+// privacy flag is only applied to standard private calls, and the recipients
+// to calls without privateFor: calls with an empty privateFor are private to
+// the sender.
+type SendDefaults struct {
+	PrivacyFlag engine.PrivacyFlagType `json:"privacyFlag"`
+	PrivateFor  []string               `json:"privateFor,omitempty"`
+	PrivateFrom string                 `json:"privateFrom,omitempty"`
+	Expiry      time.Time              `json:"expiry"` // set by the node
+}
+
+// sendDefaultsStore keeps the send defaults of the clients in memory, by
+// identity, for at most the ttl.
+type sendDefaultsStore struct {
+	lock     sync.Mutex
+	ttl      time.Duration
+	defaults map[string]*SendDefaults
+}
+
+var sendDefaults = &sendDefaultsStore{defaults: make(map[string]*SendDefaults)}
+
+// SetSendDefaultsTTL sets the time the send defaults are kept for after being
+// set, 0 disabling them.
+func SetSendDefaultsTTL(ttl time.Duration) {
+	sendDefaults.lock.Lock()
+	defer sendDefaults.lock.Unlock()
+	sendDefaults.ttl = ttl
+	if ttl == 0 {
+		sendDefaults.defaults = make(map[string]*SendDefaults)
+	}
+}
+
+func (s *sendDefaultsStore) set(identity string, defaults *SendDefaults, ttl time.Duration) (*SendDefaults, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ttl == 0 {
+		return nil, errSendDefaultsDisabled
+	}
+	if ttl <= 0 || ttl > s.ttl {
+		ttl = s.ttl
+	}
+	now := time.Now()
+	if _, ok := s.defaults[identity]; !ok && len(s.defaults) >= maxSendDefaults {
+		for id, d := range s.defaults {
+			if !now.Before(d.Expiry) {
+				delete(s.defaults, id)
+			}
+		}
+		if len(s.defaults) >= maxSendDefaults {
+			return nil, errTooManySendDefaults
+		}
+	}
+	stored := *defaults
+	stored.PrivateFor = append([]string(nil), defaults.PrivateFor...)
+	if defaults.PrivateFor != nil && len(defaults.PrivateFor) == 0 {
+		stored.PrivateFor = []string{}
+	}
+	stored.Expiry = now.Add(ttl)
+	s.defaults[identity] = &stored
+	result := stored
+	return &result, nil
+}
+
+// get returns a copy of the unexpired defaults of the identity, nil if none.
+func (s *sendDefaultsStore) get(identity string) *SendDefaults {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	d, ok := s.defaults[identity]
+	if !ok {
+		return nil
+	}
+	if !time.Now().Before(d.Expiry) {
+		delete(s.defaults, identity)
+		return nil
+	}
+	result := *d
+	return &result
+}
+
+func (s *sendDefaultsStore) clear(identity string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, ok := s.defaults[identity]
+	delete(s.defaults, identity)
+	return ok
+}
+
+// sendIdentity returns the identity of the authenticated client of the
+// context, see multitenancy.TokenIdentity.
+func sendIdentity(ctx context.Context) (string, error) {
+	authToken, _ := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	if identity := multitenancy.TokenIdentity(authToken); identity != "" {
+		return identity, nil
+	}
+	return "", errSendDefaultsUnauthenticated
+}
+
+// applySendDefaults completes the private arguments omitted by the call with
+// the send defaults of its client, if any.
+func applySendDefaults(ctx context.Context, args *PrivateTxArgs) {
+	identity, err := sendIdentity(ctx)
+	if err != nil {
+		return
+	}
+	defaults := sendDefaults.get(identity)
+	if defaults == nil {
+		return
+	}
+	if args.PrivateFor == nil && defaults.PrivateFor != nil {
+		args.PrivateFor = defaults.PrivateFor
+	}
+	if args.PrivateFor == nil {
+		// public transactions have no privacy settings
+		return
+	}
+	if args.PrivateFrom == "" {
+		args.PrivateFrom = defaults.PrivateFrom
+	}
+	if args.PrivacyFlag.IsStandardPrivate() {
+		args.PrivacyFlag = defaults.PrivacyFlag
+	}
+}
+
+// SetSendDefaults binds the private transaction settings to the authenticated
+// client, for its eth_sendTransaction calls omitting them during the ttl in
+// seconds, capped to the ttl of the node. In multitenant mode the client must
+// own privateFrom.
+func (api *PublicQuorumAPI) SetSendDefaults(ctx context.Context, defaults SendDefaults, ttl *uint64) (*SendDefaults, error) {
+	identity, err := sendIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := defaults.PrivacyFlag.Validate(); err != nil {
+		return nil, err
+	}
+	if authToken, ok := api.b.SupportsMultitenancy(ctx); ok {
+		if defaults.PrivateFrom == "" && (defaults.PrivateFor != nil || defaults.PrivacyFlag.IsNotStandardPrivate()) {
+			return nil, errors.New("privateFrom is required in multitenant mode")
+		}
+		if defaults.PrivateFrom != "" && !multitenancy.OwnsTMKey(authToken, defaults.PrivateFrom) {
+			return nil, fmt.Errorf("privateFrom %s: %w", defaults.PrivateFrom, multitenancy.ErrNotAuthorized)
+		}
+	}
+	var lifetime time.Duration
+	if ttl != nil {
+		lifetime = time.Duration(*ttl) * time.Second
+	}
+	return sendDefaults.set(identity, &defaults, lifetime)
+}
+
+// GetSendDefaults returns the send defaults of the authenticated client, nil
+// if none or expired.
+func (api *PublicQuorumAPI) GetSendDefaults(ctx context.Context) (*SendDefaults, error) {
+	identity, err := sendIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sendDefaults.get(identity), nil
+}
+
+// ClearSendDefaults removes the send defaults of the authenticated client,
+// returning whether it had any.
+func (api *PublicQuorumAPI) ClearSendDefaults(ctx context.Context) (bool, error) {
+	identity, err := sendIdentity(ctx)
+	if err != nil {
+		return false, err
+	}
+	return sendDefaults.clear(identity), nil
+}
@@ -0,0 +1,107 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenContext(raw string, authorities ...string) context.Context {
+	authToken := &proto.PreAuthenticatedAuthenticationToken{RawToken: []byte(raw)}
+	for _, authority := range authorities {
+		authToken.Authorities = append(authToken.Authorities, &proto.GrantedAuthority{Raw: authority})
+	}
+	return context.WithValue(context.Background(), rpc.CtxPreauthenticatedToken, authToken)
+}
+
+func TestSendIdentity(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"app1"}`))
+	for raw, expected := range map[string]string{
+		"apikey:app2":                    "apikey:app2",
+		"Bearer header." + claims + ".s": "sub:app1",
+		"header." + claims + ".s":        "sub:app1",
+	} {
+		identity, err := sendIdentity(tokenContext(raw))
+		require.NoError(t, err, raw)
+		assert.Equal(t, expected, identity, raw)
+	}
+	identity, err := sendIdentity(tokenContext("opaque"))
+	require.NoError(t, err)
+	assert.Contains(t, identity, "token:")
+	assert.NotContains(t, identity, "opaque")
+
+	_, err = sendIdentity(context.Background())
+	assert.Equal(t, errSendDefaultsUnauthenticated, err)
+}
+
+func TestPublicQuorumAPI_SendDefaults(t *testing.T) {
+	SetSendDefaultsTTL(time.Hour)
+	defer SetSendDefaultsTTL(0)
+	api := NewPublicQuorumAPI(&StubBackend{})
+	ctx, other := tokenContext("apikey:app1"), tokenContext("apikey:app2")
+
+	set, err := api.SetSendDefaults(ctx, SendDefaults{PrivacyFlag: engine.PrivacyFlagPartyProtection, PrivateFor: []string{"B"}, PrivateFrom: "A"}, nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), set.Expiry, time.Minute)
+	got, err := api.GetSendDefaults(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, set, got)
+	got, err = api.GetSendDefaults(other)
+	require.NoError(t, err)
+	assert.Nil(t, got, "defaults are per identity")
+
+	// omitted fields are defaulted, explicit ones win
+	args := PrivateTxArgs{}
+	applySendDefaults(ctx, &args)
+	assert.Equal(t, PrivateTxArgs{PrivateFor: []string{"B"}, PrivateFrom: "A", PrivacyFlag: engine.PrivacyFlagPartyProtection}, args)
+	args = PrivateTxArgs{PrivateFor: []string{"C"}, PrivacyFlag: engine.PrivacyFlagStateValidation}
+	applySendDefaults(ctx, &args)
+	assert.Equal(t, PrivateTxArgs{PrivateFor: []string{"C"}, PrivateFrom: "A", PrivacyFlag: engine.PrivacyFlagStateValidation}, args)
+	args = PrivateTxArgs{}
+	applySendDefaults(other, &args)
+	assert.Equal(t, PrivateTxArgs{}, args)
+
+	cleared, err := api.ClearSendDefaults(ctx)
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	got, _ = api.GetSendDefaults(ctx)
+	assert.Nil(t, got)
+
+	// the ttl of the client is capped and expires the defaults
+	ttl := uint64(0)
+	set, err = api.SetSendDefaults(ctx, SendDefaults{PrivateFrom: "A"}, &ttl)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), set.Expiry, time.Minute)
+	sendDefaults.defaults["apikey:app1"].Expiry = time.Now()
+	got, _ = api.GetSendDefaults(ctx)
+	assert.Nil(t, got)
+
+	_, err = api.SetSendDefaults(ctx, SendDefaults{PrivacyFlag: 4}, nil)
+	assert.Error(t, err)
+	SetSendDefaultsTTL(0)
+	_, err = api.SetSendDefaults(ctx, SendDefaults{}, nil)
+	assert.Equal(t, errSendDefaultsDisabled, err)
+}
+
+func TestPublicQuorumAPI_SetSendDefaults_multitenancy(t *testing.T) {
+	SetSendDefaultsTTL(time.Hour)
+	defer SetSendDefaultsTTL(0)
+	api := NewPublicQuorumAPI(&StubBackend{multitenancy: true})
+	ctx := tokenContext("apikey:app1", "private://0x0/_/contracts?owned.eoa=0x0&from.tm=A")
+
+	_, err := api.SetSendDefaults(ctx, SendDefaults{PrivateFor: []string{"B"}, PrivateFrom: "A"}, nil)
+	assert.NoError(t, err)
+	_, err = api.SetSendDefaults(ctx, SendDefaults{PrivateFor: []string{"A"}, PrivateFrom: "B"}, nil)
+	assert.True(t, errors.Is(err, multitenancy.ErrNotAuthorized), "unexpected error: %v", err)
+	_, err = api.SetSendDefaults(ctx, SendDefaults{PrivateFor: []string{"B"}}, nil)
+	assert.Error(t, err)
+}
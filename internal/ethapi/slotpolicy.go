@@ -0,0 +1,124 @@
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/slotpolicy"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Quorum
+//
+// modifiedStorage is implemented by the states of the simulations.
+type modifiedStorage interface {
+	ModifiedStorage(addr common.Address) map[common.Hash]common.Hash
+	HasSuicided(addr common.Address) bool
+}
+
+// checkArgsSlotPolicy enforces the slot policy on the transaction of the
+// arguments, before the payload of private transactions is distributed.
+func checkArgsSlotPolicy(ctx context.Context, b Backend, args *SendTxArgs) error {
+	if len(slotpolicy.Get().Contracts()) == 0 {
+		return nil
+	}
+	tx := args.toTransaction()
+	if args.IsPrivate() {
+		tx.SetPrivate()
+	}
+	return checkSlotPolicy(ctx, b, args.From, tx)
+}
+
+// checkSignedSlotPolicy enforces the slot policy on a signed transaction,
+// retrieving the payload of private transactions from the transaction manager.
+func checkSignedSlotPolicy(ctx context.Context, b Backend, tx *types.Transaction, isRaw bool) error {
+	if len(slotpolicy.Get().Contracts()) == 0 {
+		return nil
+	}
+	var signer types.Signer
+	if tx.IsPrivate() {
+		signer = types.QuorumPrivateTxSigner{}
+	} else {
+		signer = types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	if tx.IsPrivate() {
+		if isRaw {
+			tx, _, err = buildPrivateTransactionFromRaw(ctx, tx)
+		} else {
+			tx, err = buildPrivateTransaction(ctx, tx)
+		}
+		if err != nil {
+			return err
+		}
+		tx.SetPrivate()
+	}
+	return checkSlotPolicy(ctx, b, from, tx)
+}
+
+// checkSlotPolicy simulates the transaction, whose data is the plain payload
+// of private transactions, and returns a *slotpolicy.ProtectedSlotError if it
+// writes a protected slot the sender is not allowed to. The simulation is
+// bounded by the gas cap of the calls.
+func checkSlotPolicy(ctx context.Context, b Backend, from common.Address, tx *types.Transaction) error {
+	policy := slotpolicy.Get()
+	contracts := policy.Contracts()
+	if len(contracts) == 0 {
+		return nil
+	}
+	if gasCap := b.RPCGasCap(); gasCap != 0 && tx.Gas() > gasCap {
+		var capped *types.Transaction
+		if tx.To() == nil {
+			capped = types.NewContractCreation(tx.Nonce(), tx.Value(), gasCap, tx.GasPrice(), tx.Data())
+		} else {
+			capped = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), gasCap, tx.GasPrice(), tx.Data())
+		}
+		if tx.IsPrivate() {
+			capped.SetPrivate()
+		}
+		tx = capped
+	}
+	evm, err := runSimulation(ctx, b, from, tx)
+	if evm == nil {
+		return err
+	}
+	if err != nil {
+		// the writes of failed transactions are reverted
+		log.Debug("Simulated execution for the slot policy failed", "err", err)
+	}
+	states := []interface{}{evm.PublicState()}
+	if evm.PrivateState() != nil && evm.PrivateState() != evm.PublicState() {
+		states = append(states, evm.PrivateState())
+	}
+	for _, contract := range contracts {
+		for _, state := range states {
+			state, ok := state.(modifiedStorage)
+			if !ok {
+				continue
+			}
+			if state.HasSuicided(contract) {
+				if err := policy.CheckDestruct(from, contract); err != nil {
+					return err
+				}
+			}
+			modified := state.ModifiedStorage(contract)
+			slots := make([]common.Hash, 0, len(modified))
+			for slot := range modified {
+				slots = append(slots, slot)
+			}
+			sort.Slice(slots, func(i, j int) bool { return bytes.Compare(slots[i][:], slots[j][:]) < 0 })
+			for _, slot := range slots {
+				if err := policy.Check(from, contract, slot); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
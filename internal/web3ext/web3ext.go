@@ -39,6 +39,7 @@ var Modules = map[string]string{
 	"quorumPermission": QUORUM_NODE_JS,
 	"quorumExtension":  Extension_JS,
 	"plugin_account":   Account_Plugin_Js,
+	"quorum":           Quorum_JS,
 }
 
 const ChequebookJs = `
@@ -181,6 +182,19 @@ web3._extend({
 			call: 'admin_removeTrustedPeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'configDriftReport',
+			call: 'admin_configDriftReport',
+		}),
+		new web3._extend.Method({
+			name: 'connectionLimits',
+			call: 'admin_connectionLimits',
+		}),
+		new web3._extend.Method({
+			name: 'setConnectionLimits',
+			call: 'admin_setConnectionLimits',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -217,6 +231,61 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'requestMaintenanceWindow',
+			call: 'admin_requestMaintenanceWindow',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'setReadOnly',
+			call: 'admin_setReadOnly',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'backfillInternalCalls',
+			call: 'admin_backfillInternalCalls',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'internalCallsBackfillStatus',
+			call: 'admin_internalCallsBackfillStatus',
+		}),
+		new web3._extend.Method({
+			name: 'backfillBlockStats',
+			call: 'admin_backfillBlockStats',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'blockStatsBackfillStatus',
+			call: 'admin_blockStatsBackfillStatus',
+		}),
+		new web3._extend.Method({
+			name: 'acceptDeepReorg',
+			call: 'admin_acceptDeepReorg',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'ptmPinEndpoint',
+			call: 'admin_ptmPinEndpoint',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'privacyManagerStatus',
+			call: 'admin_privacyManagerStatus',
+		}),
+		new web3._extend.Method({
+			name: 'listFilters',
+			call: 'admin_listFilters',
+		}),
+		new web3._extend.Method({
+			name: 'removeFilters',
+			call: 'admin_removeFilters',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -289,6 +358,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'dumpPrivateContract',
+			call: 'debug_dumpPrivateContract',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'chaindbProperty',
 			call: 'debug_chaindbProperty',
@@ -464,6 +539,11 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'lastImportTimings',
+			call: 'debug_lastImportTimings',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -475,6 +555,17 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null],
 		}),
+		new web3._extend.Method({
+			name: 'verifyReceipts',
+			call: 'debug_verifyReceipts',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
+		new web3._extend.Method({
+			name: 'getInternalCalls',
+			call: 'debug_getInternalCalls',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'getModifiedAccountsByHash',
 			call: 'debug_getModifiedAccountsByHash',
@@ -586,6 +677,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'eth_feeHistory',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		// QUORUM
 		new web3._extend.Method({
 			name: 'sendTransactionAsync',
@@ -599,9 +696,26 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'getPrivateStateRoot',
+			call: 'eth_getPrivateStateRoot',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getPrivatePayloads',
+			call: 'eth_getPrivatePayloads',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		// END-QUORUM
 	],
 	properties: [
+		new web3._extend.Property({
+			name: 'maxPriorityFeePerGas',
+			getter: 'eth_maxPriorityFeePerGas',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'eth_pendingTransactions',
@@ -973,6 +1087,23 @@ web3._extend({
                        params: 3,
                        inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputTransactionFormatter]
                }),
+               new web3._extend.Method({
+                       name: 'simulate',
+                       call: 'quorumPermission_simulate',
+                       params: 3,
+                       inputFormatter: [null, null, web3._extend.formatters.inputTransactionFormatter]
+               }),
+               new web3._extend.Method({
+                       name: 'exportModel',
+                       call: 'quorumPermission_exportModel',
+                       params: 0
+               }),
+               new web3._extend.Method({
+                       name: 'importModel',
+                       call: 'quorumPermission_importModel',
+                       params: 3,
+                       inputFormatter: [null, web3._extend.formatters.inputTransactionFormatter, null]
+               }),
                new web3._extend.Method({
                        name: 'getOrgDetails',
                        call: 'quorumPermission_getOrgDetails',
@@ -1052,6 +1183,11 @@ web3._extend({
 			call: 'istanbul_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'announceKeyRotation',
+			call: 'istanbul_announceKeyRotation',
+			params: 2
+		}),
 
 		new web3._extend.Method({
 			name: 'getSignersFromBlock',
@@ -1076,6 +1212,33 @@ web3._extend({
 			params: 1,
             inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'clockSkewReport',
+			call: 'istanbul_clockSkewReport',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'exportValidatorHistory',
+			call: 'istanbul_exportValidatorHistory',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'validatorHistoryExportProgress',
+			call: 'istanbul_validatorHistoryExportProgress',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'mintFencingToken',
+			call: 'istanbul_mintFencingToken',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'promoteStandby',
+			call: 'istanbul_promoteStandby',
+			params: 1,
+			inputFormatter: [null]
+		}),
 
 	],
 	properties:
@@ -1178,6 +1341,12 @@ web3._extend({
 			params: 4,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'dryRun',
+			call: 'quorumExtension_dryRun',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputTransactionFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'cancelExtension',
 			call: 'quorumExtension_cancelExtension',
@@ -1190,6 +1359,18 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'requestHistoricResend',
+			call: 'quorumExtension_requestHistoricResend',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputTransactionFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'historicResendStatus',
+			call: 'quorumExtension_historicResendStatus',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 
 	],
 	properties:
@@ -1202,6 +1383,110 @@ web3._extend({
 });
 `
 
+const Quorum_JS = `
+web3._extend({
+	property: 'quorum',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'pendingDistributions',
+			call: 'quorum_pendingDistributions',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'accessLogSummary',
+			call: 'quorum_accessLogSummary',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'blockStats',
+			call: 'quorum_blockStats',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'dailyStats',
+			call: 'quorum_dailyStats',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'ptmEndpointStats',
+			call: 'quorum_ptmEndpointStats',
+		}),
+		new web3._extend.Method({
+			name: 'setSendDefaults',
+			call: 'quorum_setSendDefaults',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getSendDefaults',
+			call: 'quorum_getSendDefaults',
+		}),
+		new web3._extend.Method({
+			name: 'clearSendDefaults',
+			call: 'quorum_clearSendDefaults',
+		}),
+		new web3._extend.Method({
+			name: 'blockProductionReport',
+			call: 'quorum_blockProductionReport',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'latencyReport',
+			call: 'quorum_latencyReport',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBlocksRange',
+			call: 'quorum_getBlocksRange',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'describePrivateState',
+			call: 'quorum_describePrivateState',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'configMigrationReport',
+			call: 'quorum_configMigrationReport',
+		}),
+		new web3._extend.Method({
+			name: 'sendPrivateTransactionBatch',
+			call: 'quorum_sendPrivateTransactionBatch',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'orphanedPrivatePayloads',
+			call: 'quorum_orphanedPrivatePayloads',
+		}),
+		new web3._extend.Method({
+			name: 'decodePrivateTransaction',
+			call: 'quorum_decodePrivateTransaction',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'exportTenantData',
+			call: 'quorum_exportTenantData',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'fetchMarkerInternals',
+			call: 'quorum_fetchMarkerInternals',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'tenantExportStatus',
+			call: 'quorum_tenantExportStatus',
+		}),
+	]
+});
+`
+
 const Account_Plugin_Js = `
 web3._extend({
 	property: 'plugin_account',
@@ -25,6 +25,8 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -36,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/multitenancy"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -81,7 +84,7 @@ func (b *LesApiBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash
 			return nil, errors.New("header for hash not found")
 		}
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
-			return nil, errors.New("hash is not currently canonical")
+			return nil, core.ErrHashNotCanonical
 		}
 		return header, nil
 	}
@@ -117,7 +120,7 @@ func (b *LesApiBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 			return nil, errors.New("header found, but block body is missing")
 		}
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(block.NumberU64()) != hash {
-			return nil, errors.New("hash is not currently canonical")
+			return nil, core.ErrHashNotCanonical
 		}
 		return block, nil
 	}
@@ -145,7 +148,7 @@ func (b *LesApiBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 			return nil, nil, errors.New("header for hash not found")
 		}
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
-			return nil, nil, errors.New("hash is not currently canonical")
+			return nil, nil, core.ErrHashNotCanonical
 		}
 		return light.NewState(ctx, header, b.eth.odr), header, nil
 	}
@@ -176,6 +179,7 @@ func (b *LesApiBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
 func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, apiState vm.MinimalApiState, header *types.Header) (*vm.EVM, func() error, error) {
 	statedb := apiState.(*state.StateDB)
 	context := core.NewEVMContext(msg, header, b.eth.blockchain, nil)
+	context.RequestID = log.RequestID(ctx) // Quorum
 	return vm.NewEVM(context, statedb, statedb, b.eth.chainConfig, vm.Config{}), statedb.Error, nil
 }
 
@@ -271,6 +275,22 @@ func (b *LesApiBackend) CallTimeOut() time.Duration {
 	return b.eth.config.EVMCallTimeOut
 }
 
+func (b *LesApiBackend) CheckWritable() error {
+	return nil
+}
+
+func (b *LesApiBackend) ConsensusAlgorithm() string {
+	switch b.eth.engine.(type) {
+	case consensus.Istanbul:
+		return "istanbul"
+	case *clique.Clique:
+		return "clique"
+	case *ethash.Ethash:
+		return "ethash"
+	}
+	return "unknown"
+}
+
 // End Quorum
 
 func (b *LesApiBackend) RPCGasCap() uint64 {
@@ -257,6 +257,8 @@ func (s *LightEthereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true),
 			Public:    true,
+			// Quorum - log subscriptions stream private logs
+			SubscriptionScopes: map[string]string{"logs": "eth_getLogs"},
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -0,0 +1,30 @@
+package log
+
+import "context"
+
+// Quorum
+//
+// The ID of the request being served is carried by its context, so the lines
+// logged while serving it can be correlated with the client.
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request served.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request served with the context, "" if none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns the root logger, logging the ID of the request served
+// with the context if any.
+func FromContext(ctx context.Context) Logger {
+	if id := RequestID(ctx); id != "" {
+		return root.New("requestid", id)
+	}
+	return root
+}
@@ -203,6 +203,12 @@ func (miner *Miner) DisablePreseal() {
 	miner.worker.disablePreseal()
 }
 
+// BlockProductionReport returns the production report of one of the last
+// blocks sealed by the miner, nil if unknown.
+func (miner *Miner) BlockProductionReport(hash common.Hash) *BlockProductionReport {
+	return miner.worker.productionReports.get(hash)
+}
+
 // SubscribePendingLogs starts delivering logs from pending transactions
 // to the given channel.
 func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
@@ -0,0 +1,152 @@
+package miner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// maxProductionReports is the number of the last sealed blocks whose
+// production report is kept.
+const maxProductionReports = 128
+
+// ExclusionReason is the reason a candidate transaction was left out of a
+// block.
+type ExclusionReason string
+
+const (
+	ExcludedReplayProtected       ExclusionReason = "replayProtected"       // EIP-155 transaction before the fork
+	ExcludedInvalidPrivatePayload ExclusionReason = "invalidPrivatePayload" // private data is not a payload hash
+	ExcludedUnauthorizedDeployer  ExclusionReason = "unauthorizedDeployer"  // sender not in the deployer allow-list
+	ExcludedGasLimit              ExclusionReason = "gasLimit"              // not enough gas left in the block
+	ExcludedNonceTooLow           ExclusionReason = "nonceTooLow"
+	ExcludedNonceTooHigh          ExclusionReason = "nonceTooHigh"
+	ExcludedExecutionError        ExclusionReason = "executionError" // the transaction could not be applied
+)
+
+// Reasons the miner stopped considering candidate transactions.
+const (
+	StopNoCandidates = "noCandidates" // every candidate was considered
+	StopGasExhausted = "gasExhausted" // the block cannot fit another transaction
+	StopInterrupted  = "interrupted"  // new head or recommit, the remaining candidates were not considered
+)
+
+// CandidateOutcome is the outcome of a candidate transaction of a block, without
+// its payload.
+type CandidateOutcome struct {
+	Hash    common.Hash     `json:"hash"`
+	From    common.Address  `json:"from"`
+	Nonce   uint64          `json:"nonce"`
+	Gas     uint64          `json:"gas"`
+	Private bool            `json:"private"`
+	Index   *int            `json:"index,omitempty"`  // position in the block of the included transactions
+	Reason  ExclusionReason `json:"reason,omitempty"` // reason of the excluded transactions
+	Error   string          `json:"error,omitempty"`
+}
+
+// BlockProductionReport lists the candidate transactions considered by the
+// miner for a block it sealed, in the order they were considered.
+type BlockProductionReport struct {
+	Number     uint64                  `json:"number"`
+	Hash       common.Hash             `json:"hash"`
+	Sealed     time.Time               `json:"sealed"`
+	Candidates []CandidateOutcome      `json:"candidates"`
+	Included   int                     `json:"included"`
+	Excluded   map[ExclusionReason]int `json:"excluded"` // number of excluded candidates by reason
+	StopReason string                  `json:"stopReason,omitempty"`
+}
+
+// productionRecorder records the outcome of the candidate transactions of a
+// block being built.
+type productionRecorder struct {
+	candidates []CandidateOutcome
+	stopReason string
+}
+
+func (r *productionRecorder) include(tx *types.Transaction, from common.Address, index int) {
+	r.candidates = append(r.candidates, CandidateOutcome{
+		Hash: tx.Hash(), From: from, Nonce: tx.Nonce(), Gas: tx.Gas(), Private: tx.IsPrivate(), Index: &index,
+	})
+}
+
+func (r *productionRecorder) exclude(tx *types.Transaction, from common.Address, reason ExclusionReason, err error) {
+	outcome := CandidateOutcome{
+		Hash: tx.Hash(), From: from, Nonce: tx.Nonce(), Gas: tx.Gas(), Private: tx.IsPrivate(), Reason: reason,
+	}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	r.candidates = append(r.candidates, outcome)
+}
+
+// copy returns a copy of the recorder, the candidates being appended to by
+// the later commits of the same block.
+func (r *productionRecorder) copy() *productionRecorder {
+	return &productionRecorder{
+		candidates: append([]CandidateOutcome(nil), r.candidates...),
+		stopReason: r.stopReason,
+	}
+}
+
+// report summarises the recorded outcomes as the report of the sealed block.
+func (r *productionRecorder) report(block *types.Block) *BlockProductionReport {
+	report := &BlockProductionReport{
+		Number:     block.NumberU64(),
+		Hash:       block.Hash(),
+		Sealed:     time.Now(),
+		Candidates: r.candidates,
+		Excluded:   make(map[ExclusionReason]int),
+		StopReason: r.stopReason,
+	}
+	for _, c := range r.candidates {
+		if c.Reason == "" {
+			report.Included++
+		} else {
+			report.Excluded[c.Reason]++
+		}
+	}
+	return report
+}
+
+// productionReports is a ring buffer of the reports of the last sealed blocks.
+type productionReports struct {
+	lock    sync.RWMutex
+	reports map[common.Hash]*BlockProductionReport
+	order   []common.Hash // hashes of the reports, oldest first
+}
+
+func newProductionReports() *productionReports {
+	return &productionReports{reports: make(map[common.Hash]*BlockProductionReport)}
+}
+
+// add keeps the report, dropping the oldest one beyond maxProductionReports,
+// and accounts its exclusions in the metrics.
+func (p *productionReports) add(report *BlockProductionReport) {
+	for reason, count := range report.Excluded {
+		metrics.GetOrRegisterCounter("miner/excluded/"+string(reason), nil).Inc(int64(count))
+	}
+	metrics.GetOrRegisterCounter("miner/included", nil).Inc(int64(report.Included))
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.reports[report.Hash]; ok {
+		return
+	}
+	if len(p.order) == maxProductionReports {
+		delete(p.reports, p.order[0])
+		p.order = p.order[1:]
+	}
+	p.reports[report.Hash] = report
+	p.order = append(p.order, report.Hash)
+}
+
+func (p *productionReports) get(hash common.Hash) *BlockProductionReport {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.reports[hash]
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -96,6 +97,8 @@ type environment struct {
 	privateReceipts []*types.Receipt
 	// Leave this publicState named state, add privateState which most code paths can just ignore
 	privateState *state.StateDB
+	deployers    *core.DeployerAllowList // Quorum - deployers allowed by the block
+	production   *productionRecorder     // Quorum - outcomes of the candidate transactions
 }
 
 // task contains all information for consensus engine sealing and result submitting.
@@ -108,6 +111,7 @@ type task struct {
 	privateReceipts []*types.Receipt
 	// Leave this publicState named state, add privateState which most code paths can just ignore
 	privateState *state.StateDB
+	production   *productionRecorder // Quorum
 }
 
 const (
@@ -164,6 +168,8 @@ type worker struct {
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
 
+	productionReports *productionReports // Quorum - reports of the last sealed blocks
+
 	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
 	coinbase common.Address
 	extra    []byte
@@ -209,6 +215,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
 		pendingTasks:       make(map[common.Hash]*task),
+		productionReports:  newProductionReports(),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
@@ -578,6 +585,7 @@ func (w *worker) taskLoop() {
 			w.pendingTasks[sealHash] = task
 			w.pendingMu.Unlock()
 
+			w.eth.TxPool().Latency().Proposed(task.block) // Quorum
 			if err := w.engine.Seal(w.chain, task.block, w.resultCh, stopCh); err != nil {
 				log.Warn("Block sealing failed", "err", err)
 			}
@@ -667,6 +675,8 @@ func (w *worker) resultLoop() {
 			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
 				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
 
+			w.productionReports.add(task.production.report(block)) // Quorum
+
 			// Broadcast the block and announce chain insertion event
 			w.mux.Post(core.NewMinedBlockEvent{Block: block})
 
@@ -714,6 +724,8 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		uncles:       mapset.NewSet(),
 		header:       header,
 		privateState: privateState,
+		deployers:    core.NewDeployerAllowList(w.chainConfig, parent.Header(), publicState),
+		production:   new(productionRecorder),
 	}
 
 	// when 08 is processed ancestors contain 07 (quick block)
@@ -828,6 +840,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		// For the first two cases, the semi-finished work will be discarded.
 		// For the third case, the semi-finished work will be submitted to the consensus engine.
 		if interrupt != nil && atomic.LoadInt32(interrupt) != commitInterruptNone {
+			w.current.production.stopReason = StopInterrupted
 			// Notify resubmit loop to increase resubmitting interval due to too frequent commits.
 			if atomic.LoadInt32(interrupt) == commitInterruptResubmit {
 				ratio := float64(w.current.header.GasLimit-w.current.gasPool.Gas()) / float64(w.current.header.GasLimit)
@@ -844,11 +857,13 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		// If we don't have enough gas for any further transactions then we're done
 		if w.current.gasPool.Gas() < params.TxGas {
 			log.Trace("Not enough gas for further transactions", "have", w.current.gasPool, "want", params.TxGas)
+			w.current.production.stopReason = StopGasExhausted
 			break
 		}
 		// Retrieve the next transaction and abort if all done
 		tx := txs.Peek()
 		if tx == nil {
+			w.current.production.stopReason = StopNoCandidates
 			break
 		}
 		// Error may be ignored here. The error has already been checked
@@ -860,6 +875,23 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		// phase, start ignoring the sender until we do.
 		if tx.Protected() && !w.chainConfig.IsEIP155(w.current.header.Number) && !tx.IsPrivate() {
 			log.Trace("Ignoring reply protected transaction", "hash", tx.Hash(), "eip155", w.chainConfig.EIP155Block)
+			w.current.production.exclude(tx, from, ExcludedReplayProtected, nil)
+
+			txs.Pop()
+			continue
+		}
+		// Quorum: exclude the private transactions the parties could not resolve,
+		// and the transactions of the sender following them
+		if err := core.ValidatePrivatePayloadHash(tx); err != nil {
+			log.Warn("Excluding invalid private transaction", "hash", tx.Hash(), "sender", from, "err", err)
+			w.current.production.exclude(tx, from, ExcludedInvalidPrivatePayload, err)
+
+			txs.Pop()
+			continue
+		}
+		if err := w.current.deployers.Check(tx, from); err != nil {
+			log.Warn("Excluding contract creation of unauthorized deployer", "hash", tx.Hash(), "sender", from, "err", err)
+			w.current.production.exclude(tx, from, ExcludedUnauthorizedDeployer, err)
 
 			txs.Pop()
 			continue
@@ -873,21 +905,25 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		case core.ErrGasLimitReached:
 			// Pop the current out-of-gas transaction without shifting in the next from the account
 			log.Trace("Gas limit exceeded for current block", "sender", from)
+			w.current.production.exclude(tx, from, ExcludedGasLimit, err)
 			txs.Pop()
 
 		case core.ErrNonceTooLow:
 			// New head notification data race between the transaction pool and miner, shift
 			log.Trace("Skipping transaction with low nonce", "sender", from, "nonce", tx.Nonce())
+			w.current.production.exclude(tx, from, ExcludedNonceTooLow, err)
 			txs.Shift()
 
 		case core.ErrNonceTooHigh:
 			// Reorg notification data race between the transaction pool and miner, skip account =
 			log.Trace("Skipping account with hight nonce", "sender", from, "nonce", tx.Nonce())
+			w.current.production.exclude(tx, from, ExcludedNonceTooHigh, err)
 			txs.Pop()
 
 		case nil:
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
+			w.current.production.include(tx, from, w.current.tcount)
 			w.current.tcount++
 			txs.Shift()
 
@@ -895,6 +931,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Strange error, discard the transaction and get the next in line (note, the
 			// nonce-too-high clause will prevent us from executing in vain).
 			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
+			w.current.production.exclude(tx, from, ExcludedExecutionError, err)
 			txs.Shift()
 		}
 	}
@@ -1067,12 +1104,13 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 	if err != nil {
 		return err
 	}
-	if w.isRunning() {
+	// Quorum: no block is sealed in emergency read-only mode
+	if w.isRunning() && emergency.Check() == nil {
 		if interval != nil {
 			interval()
 		}
 		select {
-		case w.taskCh <- &task{receipts: receipts, privateReceipts: privateReceipts, state: s, privateState: ps, block: block, createdAt: time.Now()}:
+		case w.taskCh <- &task{receipts: receipts, privateReceipts: privateReceipts, state: s, privateState: ps, block: block, createdAt: time.Now(), production: w.current.production.copy()}:
 			w.unconfirmed.Shift(block.NumberU64() - 1)
 			log.Info("Commit new mining work", "number", block.Number(), "sealhash", w.engine.SealHash(block.Header()),
 				"uncles", len(uncles), "txs", w.current.tcount,
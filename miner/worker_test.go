@@ -502,3 +502,67 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestBlockProductionReport(t *testing.T) {
+	w, _ := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+	w.skipSealHook = func(task *task) bool {
+		return len(task.receipts) == 0
+	}
+	sub := w.mux.Subscribe(core.NewMinedBlockEvent{})
+	defer sub.Unsubscribe()
+
+	w.start()
+	var block *types.Block
+	select {
+	case ev := <-sub.Chan():
+		block = ev.Data.(core.NewMinedBlockEvent).Block
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout")
+	}
+	report := w.productionReports.get(block.Hash())
+	if report == nil {
+		t.Fatalf("no production report for sealed block %d", block.NumberU64())
+	}
+	if report.Number != block.NumberU64() || report.Included != 1 || len(report.Excluded) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if c := report.Candidates[0]; c.Hash != pendingTxs[0].Hash() || c.From != testBankAddress || c.Index == nil || *c.Index != 0 {
+		t.Fatalf("unexpected candidate: %+v", c)
+	}
+	if report.StopReason != StopNoCandidates {
+		t.Fatalf("stop reason mismatch: have %s, want %s", report.StopReason, StopNoCandidates)
+	}
+	if w.productionReports.get(w.chain.Genesis().Hash()) != nil {
+		t.Fatalf("report of a block which was not sealed")
+	}
+}
+
+func TestProductionReports(t *testing.T) {
+	reports := newProductionReports()
+	recorder := new(productionRecorder)
+	tx := pendingTxs[0]
+	recorder.include(tx, testBankAddress, 0)
+	recorder.exclude(newTxs[0], testBankAddress, ExcludedNonceTooHigh, core.ErrNonceTooHigh)
+	snapshot := recorder.copy()
+	recorder.exclude(tx, testBankAddress, ExcludedGasLimit, nil)
+
+	var first *types.Block
+	for i := 0; i < maxProductionReports+1; i++ {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i + 1))})
+		if i == 0 {
+			first = block
+		}
+		reports.add(snapshot.report(block))
+	}
+	if reports.get(first.Hash()) != nil || len(reports.reports) != maxProductionReports {
+		t.Fatalf("oldest report not dropped")
+	}
+	report := reports.get(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2)}).Hash())
+	if report == nil || report.Included != 1 || report.Excluded[ExcludedNonceTooHigh] != 1 || len(report.Candidates) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Candidates[1].Error != core.ErrNonceTooHigh.Error() {
+		t.Fatalf("error mismatch: have %q", report.Candidates[1].Error)
+	}
+}
@@ -220,3 +220,20 @@ func isIntersectionEmpty(grantedValues, askValues []string) bool {
 	}
 	return true
 }
+
+// OwnsTMKey returns whether the token grants private access as the sending
+// party of the transaction manager key, whatever the EOA.
+func OwnsTMKey(authToken *proto.PreAuthenticatedAuthenticationToken, tmPubKey string) bool {
+	for _, granted := range authToken.GetAuthorities() {
+		pi, err := url.Parse(granted.GetRaw())
+		if err != nil || strings.ToLower(pi.Scheme) != string(VisibilityPrivate) {
+			continue
+		}
+		for _, tm := range pi.Query()[QueryFromTM] {
+			if tm == tmPubKey {
+				return true
+			}
+		}
+	}
+	return false
+}
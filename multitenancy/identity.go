@@ -0,0 +1,42 @@
+package multitenancy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// apiKeyTokenPrefix prefixes the raw tokens of the requests authenticated with
+// an API key, see node.APIKeyTokenPrefix.
+const apiKeyTokenPrefix = "apikey:"
+
+// TokenIdentity returns a stable identity of the client of the token: its API
+// key, the subject of JWT access tokens or else a digest of the token, never
+// the secret. It is empty without a token.
+func TokenIdentity(authToken *proto.PreAuthenticatedAuthenticationToken) string {
+	if authToken == nil || len(authToken.RawToken) == 0 {
+		return ""
+	}
+	raw := string(authToken.RawToken)
+	if strings.HasPrefix(raw, apiKeyTokenPrefix) {
+		return raw
+	}
+	token := raw
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = token[7:]
+	}
+	if parts := strings.Split(token, "."); len(parts) == 3 {
+		var claims struct {
+			Subject string `json:"sub"`
+		}
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil && json.Unmarshal(payload, &claims) == nil && claims.Subject != "" {
+			return "sub:" + claims.Subject
+		}
+	}
+	digest := sha256.Sum256(authToken.RawToken)
+	return "token:" + hex.EncodeToString(digest[:])
+}
@@ -20,10 +20,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -184,6 +186,11 @@ func (api *privateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		BodyLimit:          api.node.config.HTTPBodyLimit,
+		Redactor:           api.node.redactor(),                    // Quorum
+		ConsistencyGuard:   api.node.consistency,                   // Quorum
+		WarnDeprecated:     api.node.config.RPCDeprecationWarnings, // Quorum
+		AccessLogger:       api.node.RequestLogger(),               // Quorum
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -246,8 +253,15 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 
 	// Determine config.
 	config := wsConfig{
-		Modules: api.node.config.WSModules,
-		Origins: api.node.config.WSOrigins,
+		Modules:      api.node.config.WSModules,
+		Origins:      api.node.config.WSOrigins,
+		MessageLimit: api.node.config.WSMessageLimit,
+		PingInterval: api.node.config.WSPingInterval, // Quorum
+		IdleTimeout:  api.node.config.WSIdleTimeout,  // Quorum
+		Redactor:     api.node.redactor(),            // Quorum
+		// Quorum
+		WarnDeprecated: api.node.config.RPCDeprecationWarnings,
+		AccessLogger:   api.node.RequestLogger(),
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
@@ -290,6 +304,87 @@ func (api *privateAdminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// Quorum
+// ConnectionLimits are the protections of the HTTP and websocket servers
+// against idle and slow connections, along with the open connections.
+type ConnectionLimits struct {
+	HTTPMaxConnections  int    `json:"httpMaxConnections"`
+	WSMaxConnections    int    `json:"wsMaxConnections"`
+	WSPingInterval      string `json:"wsPingInterval"`
+	WSIdleTimeout       string `json:"wsIdleTimeout"`
+	HTTPOpenConnections int    `json:"httpOpenConnections"`
+	WSOpenConnections   int    `json:"wsOpenConnections"`
+	// the HTTP timeouts are only read by the server when it starts
+	ReadHeaderTimeout string `json:"readHeaderTimeout"`
+	ReadTimeout       string `json:"readTimeout"`
+	IdleTimeout       string `json:"idleTimeout"`
+}
+
+// ConnectionLimitsArgs changes the connection limits, the limits not set being
+// kept.
+type ConnectionLimitsArgs struct {
+	HTTPMaxConnections *int    `json:"httpMaxConnections"`
+	WSMaxConnections   *int    `json:"wsMaxConnections"`
+	WSPingInterval     *string `json:"wsPingInterval"`
+	WSIdleTimeout      *string `json:"wsIdleTimeout"`
+}
+
+// ConnectionLimits returns the connection limits in use.
+func (api *privateAdminAPI) ConnectionLimits() *ConnectionLimits {
+	api.node.lock.Lock()
+	defer api.node.lock.Unlock()
+
+	return api.node.connectionLimits()
+}
+
+// SetConnectionLimits changes the connection limits of the running servers,
+// e.g. during an incident, and of the servers started afterwards. Lowering the
+// maximum numbers of connections does not close the connections already open.
+func (api *privateAdminAPI) SetConnectionLimits(args ConnectionLimitsArgs) (*ConnectionLimits, error) {
+	api.node.lock.Lock()
+	defer api.node.lock.Unlock()
+
+	config := api.node.config
+	parse := func(name string, value *string, d *time.Duration) error {
+		if value == nil {
+			return nil
+		}
+		parsed, err := time.ParseDuration(*value)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid %s %q", name, *value)
+		}
+		*d = parsed
+		return nil
+	}
+	pingInterval, idleTimeout := config.WSPingInterval, config.WSIdleTimeout
+	if err := parse("wsPingInterval", args.WSPingInterval, &pingInterval); err != nil {
+		return nil, err
+	}
+	if err := parse("wsIdleTimeout", args.WSIdleTimeout, &idleTimeout); err != nil {
+		return nil, err
+	}
+	for _, limit := range []*int{args.HTTPMaxConnections, args.WSMaxConnections} {
+		if limit != nil && *limit < 0 {
+			return nil, fmt.Errorf("invalid maximum number of connections %d", *limit)
+		}
+	}
+	if args.HTTPMaxConnections != nil {
+		config.HTTPMaxConnections = *args.HTTPMaxConnections
+		api.node.http.conns.setMaxConnections(config.HTTPMaxConnections)
+	}
+	if args.WSMaxConnections != nil {
+		config.WSMaxConnections = *args.WSMaxConnections
+		api.node.ws.conns.setMaxConnections(config.WSMaxConnections)
+	}
+	config.WSPingInterval, config.WSIdleTimeout = pingInterval, idleTimeout
+	api.node.http.setWebsocketLiveness(pingInterval, idleTimeout)
+	api.node.ws.setWebsocketLiveness(pingInterval, idleTimeout)
+
+	log.Info("Changed RPC connection limits", "httpmaxconns", config.HTTPMaxConnections, "wsmaxconns", config.WSMaxConnections,
+		"wspinginterval", pingInterval, "wsidletimeout", idleTimeout)
+	return api.node.connectionLimits(), nil
+}
+
 // publicAdminAPI is the collection of administrative API methods exposed over
 // both secure and unsecure RPC channels.
 type publicAdminAPI struct {
@@ -0,0 +1,249 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// API keys authenticate the clients of the HTTP, WS and GraphQL servers without
+// a security plugin. Each key is granted a list of namespaces, the same way the
+// security plugin grants the services of a token. The keys file is reloaded when
+// it changes: HTTP requests and new WS connections use the keys at once, open WS
+// connections with subscriptions when they are re-validated.
+
+const (
+	// APIKeyHeader is the HTTP header holding the API key of a request.
+	APIKeyHeader = "X-API-Key"
+
+	apiKeysReloadInterval = 5 * time.Second
+)
+
+// the tokens of the API keys never expire, revoking the key revokes them
+var apiKeyExpiry = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+var errInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyTokenPrefix prefixes the id of the key in the raw token of the requests
+// authenticated with an API key.
+const APIKeyTokenPrefix = "apikey:"
+
+// apiKeyIDKey is the context key of the id of the API key of a request.
+type apiKeyIDKey struct{}
+
+// APIKeyID returns the id of the API key the request of the context was
+// authenticated with by an APIKeyHandler, empty if none.
+func APIKeyID(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyIDKey{}).(string)
+	return id
+}
+
+// APIKeyConfig is a key of the API keys file, which is a JSON object with the
+// list of keys under "keys".
+type APIKeyConfig struct {
+	ID         string   `json:"id"`                // identifies the key in the metrics and logs
+	Hash       string   `json:"hash"`              // hex-encoded SHA-256 hash of the key
+	Namespaces []string `json:"namespaces"`        // namespaces the key is allowed to call, "*" for all
+	PSI        string   `json:"psi,omitempty"`     // private state identifier the key is bound to
+	Revoked    bool     `json:"revoked,omitempty"` // rejects the key while keeping it listed
+}
+
+// HashAPIKey returns the hash of the key, as written to the API keys file.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+type apiKey struct {
+	id       string
+	token    *proto.PreAuthenticatedAuthenticationToken
+	requests metrics.Counter
+}
+
+// apiKeyManager is the authentication manager of the RPC servers when API keys
+// are configured.
+type apiKeyManager struct {
+	path string
+
+	lock    sync.RWMutex
+	keys    map[string]*apiKey // hash of the key -> key, revoked ones left out
+	modTime time.Time
+}
+
+func newAPIKeyManager(path string) (*apiKeyManager, error) {
+	m := &apiKeyManager{path: path}
+	if err := m.reload(); err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// reload reads the keys file, keeping the current keys if it is invalid.
+func (m *apiKeyManager) reload() error {
+	info, err := os.Stat(m.path)
+	if err != nil {
+		return err
+	}
+	blob, err := ioutil.ReadFile(m.path)
+	if err != nil {
+		return err
+	}
+	var file struct {
+		Keys []*APIKeyConfig `json:"keys"`
+	}
+	if err := json.Unmarshal(blob, &file); err != nil {
+		return err
+	}
+	keys := make(map[string]*apiKey, len(file.Keys))
+	ids := make(map[string]bool, len(file.Keys))
+	for i, config := range file.Keys {
+		if config.ID == "" {
+			return fmt.Errorf("key %d has no id", i)
+		}
+		if ids[config.ID] {
+			return fmt.Errorf("duplicate key id %s", config.ID)
+		}
+		ids[config.ID] = true
+		if hash, err := hex.DecodeString(config.Hash); err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("key %s: hash must be a hex-encoded SHA-256 hash", config.ID)
+		}
+		if len(config.Namespaces) == 0 {
+			return fmt.Errorf("key %s: no namespaces", config.ID)
+		}
+		if config.Revoked {
+			continue
+		}
+		token, err := apiKeyToken(config)
+		if err != nil {
+			return err
+		}
+		keys[config.Hash] = &apiKey{
+			id:       config.ID,
+			token:    token,
+			requests: metrics.GetOrRegisterCounter("rpc/apikeys/"+config.ID+"/requests", nil),
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys = keys
+	m.modTime = info.ModTime()
+	return nil
+}
+
+// apiKeyToken returns the token granting the namespaces of the key, carrying
+// its private state identifier as the psi scope. The raw token identifies the
+// key, never the secret.
+func apiKeyToken(config *APIKeyConfig) (*proto.PreAuthenticatedAuthenticationToken, error) {
+	expiredAt, err := ptypes.TimestampProto(apiKeyExpiry)
+	if err != nil {
+		return nil, err
+	}
+	token := &proto.PreAuthenticatedAuthenticationToken{RawToken: []byte(APIKeyTokenPrefix + config.ID), ExpiredAt: expiredAt}
+	for _, namespace := range config.Namespaces {
+		token.Authorities = append(token.Authorities, &proto.GrantedAuthority{Service: namespace, Method: "*"})
+	}
+	if config.PSI != "" {
+		token.Authorities = append(token.Authorities, &proto.GrantedAuthority{Raw: "psi://" + config.PSI})
+	}
+	return token, nil
+}
+
+// loop reloads the keys file whenever it is modified, until stop is closed.
+func (m *apiKeyManager) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(apiKeysReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(m.path)
+			if err != nil {
+				log.Error("Failed to check the API keys file", "path", m.path, "err", err)
+				continue
+			}
+			m.lock.RLock()
+			modified := !info.ModTime().Equal(m.modTime)
+			m.lock.RUnlock()
+			if !modified {
+				continue
+			}
+			if err := m.reload(); err != nil {
+				log.Error("Invalid API keys file, keeping the previous keys", "path", m.path, "err", err)
+			} else {
+				log.Info("Reloaded the API keys", "path", m.path)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Authenticate implements security.AuthenticationManager, counting the
+// authenticated requests, or connections for WS, of the key.
+func (m *apiKeyManager) Authenticate(_ context.Context, token string) (*proto.PreAuthenticatedAuthenticationToken, error) {
+	key, err := m.authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+	return key.token, nil
+}
+
+func (m *apiKeyManager) authenticate(token string) (*apiKey, error) {
+	m.lock.RLock()
+	key, ok := m.keys[HashAPIKey(token)]
+	m.lock.RUnlock()
+	if !ok {
+		return nil, errInvalidAPIKey
+	}
+	key.requests.Inc(1)
+	return key, nil
+}
+
+func (m *apiKeyManager) IsEnabled(context.Context) (bool, error) {
+	return true, nil
+}
+
+// TokenHeader implements security.HeaderAuthenticationManager.
+func (m *apiKeyManager) TokenHeader() string {
+	return APIKeyHeader
+}
+
+// handler requires the requests to next to have an API key allowed the
+// namespace, attaching the token and the id of the key to their context.
+func (m *apiKeyManager) handler(namespace string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := m.authenticate(r.Header.Get(APIKeyHeader))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		allowed := false
+		for _, authority := range key.token.Authorities {
+			if authority.Service == "*" || authority.Service == namespace {
+				allowed = true
+			}
+		}
+		if !allowed {
+			http.Error(w, namespace+" - access denied", http.StatusForbidden)
+			return
+		}
+		ctx := context.WithValue(r.Context(), rpc.CtxPreauthenticatedToken, key.token)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, apiKeyIDKey{}, key.id)))
+	})
+}
@@ -0,0 +1,113 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAPIKeys(t *testing.T, path string, keys ...*APIKeyConfig) {
+	blob, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, blob, 0600))
+}
+
+func testAPIKeys(t *testing.T) (*apiKeyManager, string) {
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	writeAPIKeys(t, path,
+		&APIKeyConfig{ID: "rpc-client", Hash: HashAPIKey("rpc-key"), Namespaces: []string{"rpc"}},
+		&APIKeyConfig{ID: "eth-client", Hash: HashAPIKey("eth-key"), Namespaces: []string{"eth", "graphql"}, PSI: "PS1"},
+		&APIKeyConfig{ID: "revoked", Hash: HashAPIKey("revoked-key"), Namespaces: []string{"*"}, Revoked: true},
+	)
+	m, err := newAPIKeyManager(path)
+	require.NoError(t, err)
+	return m, path
+}
+
+// callModules calls rpc_modules with the key, returning the error message.
+func callModules(t *testing.T, srv *httpServer, key string) string {
+	req, _ := http.NewRequest("POST", "http://"+srv.listenAddr(), bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`)))
+	req.Header.Set("content-type", "application/json")
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var result struct {
+		Result map[string]string
+		Error  *struct{ Message string }
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	if result.Error != nil {
+		return result.Error.Message
+	}
+	assert.Contains(t, result.Result, "rpc")
+	return ""
+}
+
+func TestAPIKeys_Namespaces(t *testing.T) {
+	m, _ := testAPIKeys(t)
+	srv := newHTTPServer(testlog.Logger(t, log.LvlDebug), rpc.DefaultHTTPTimeouts)
+	require.NoError(t, srv.enableRPC(nil, httpConfig{}, m))
+	require.NoError(t, srv.setListenAddr("localhost", 0))
+	require.NoError(t, srv.start(nil))
+	defer srv.stop()
+
+	assert.Empty(t, callModules(t, srv, "rpc-key"))
+	assert.Equal(t, "rpc_modules - access denied", callModules(t, srv, "eth-key"))
+	assert.Equal(t, errInvalidAPIKey.Error(), callModules(t, srv, "revoked-key"))
+	assert.Equal(t, "missing access token", callModules(t, srv, ""))
+}
+
+func TestAPIKeys_Reload(t *testing.T) {
+	m, path := testAPIKeys(t)
+
+	// an invalid file keeps the previous keys
+	writeAPIKeys(t, path, &APIKeyConfig{ID: "rpc-client", Hash: "not a hash", Namespaces: []string{"rpc"}})
+	assert.Error(t, m.reload())
+	_, err := m.Authenticate(context.Background(), "rpc-key")
+	assert.NoError(t, err)
+
+	writeAPIKeys(t, path, &APIKeyConfig{ID: "rpc-client", Hash: HashAPIKey("rpc-key"), Namespaces: []string{"rpc"}, Revoked: true})
+	require.NoError(t, m.reload())
+	_, err = m.Authenticate(context.Background(), "rpc-key")
+	assert.Equal(t, errInvalidAPIKey, err)
+}
+
+func TestAPIKeys_Handler(t *testing.T) {
+	m, _ := testAPIKeys(t)
+	var (
+		token interface{}
+		id    string
+	)
+	handler := m.handler("graphql", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Context().Value(rpc.CtxPreauthenticatedToken)
+		id = APIKeyID(r.Context())
+	}))
+	serve := func(key string) int {
+		req := httptest.NewRequest("POST", "/graphql", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, serve("unknown-key"))
+	assert.Equal(t, http.StatusForbidden, serve("rpc-key"))
+	assert.Equal(t, http.StatusOK, serve("eth-key"))
+	require.NotNil(t, token)
+	assert.Equal(t, m.keys[HashAPIKey("eth-key")].token, token)
+	assert.Equal(t, "eth-client", id)
+	assert.Equal(t, "psi://PS1", m.keys[HashAPIKey("eth-key")].token.Authorities[2].Raw)
+}
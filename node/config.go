@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/external"
@@ -188,6 +189,70 @@ type Config struct {
 	Plugins                *plugin.Settings `toml:",omitempty"`
 	// Quorum: EnableNodePermission comes from EnableNodePermissionFlag --permissioned.
 	EnableNodePermission bool `toml:",omitempty"`
+
+	// Quorum: maximum sizes in bytes of a JSON-RPC request over HTTP, of a message
+	// over websocket and of a GraphQL request. Zero keeps the defaults, which is
+	// rpc.DefaultBodyLimit for JSON-RPC and no limit for GraphQL.
+	HTTPBodyLimit    int64 `toml:",omitempty"`
+	WSMessageLimit   int64 `toml:",omitempty"`
+	GraphQLBodyLimit int64 `toml:",omitempty"`
+
+	// Quorum: RPCDeprecationWarnings answers the calls of the legacy Quorum
+	// methods by their deprecated name with a warning, see rpc.LegacyMethodAliases.
+	RPCDeprecationWarnings bool `toml:",omitempty"`
+
+	// Quorum: WSAuthCheckInterval is how often the access token of a websocket
+	// connection with subscriptions is re-validated, zero for rpc.DefaultAuthCheckInterval.
+	WSAuthCheckInterval time.Duration `toml:",omitempty"`
+
+	// Quorum: maximum numbers of connections open at once on the HTTP and
+	// websocket servers, zero for no limit. A websocket endpoint sharing the
+	// port of the HTTP one is limited by HTTPMaxConnections.
+	HTTPMaxConnections int `toml:",omitempty"`
+	WSMaxConnections   int `toml:",omitempty"`
+
+	// Quorum: WSPingInterval is how long a websocket connection stays idle before
+	// it is pinged, and WSIdleTimeout how long it then has to answer before it is
+	// closed. Zero keeps rpc.DefaultWSPingInterval and rpc.DefaultWSIdleTimeout.
+	WSPingInterval time.Duration `toml:",omitempty"`
+	WSIdleTimeout  time.Duration `toml:",omitempty"`
+
+	// Quorum: APIKeysFile is the JSON file of the API keys authenticating the
+	// clients of the HTTP, WS and GraphQL servers, see APIKeyConfig. It cannot be
+	// used along with the security plugin.
+	APIKeysFile string `toml:",omitempty"`
+
+	// Quorum: RedactionPolicyFile is the JSON file of the rules redacting fields
+	// of the RPC and GraphQL responses served to some clients, see RedactionRule.
+	RedactionPolicyFile string `toml:",omitempty"`
+
+	// Quorum: RequestLogFile is the file the JSON-RPC and GraphQL requests served
+	// over HTTP and websocket are logged to as JSON lines, "stderr" for the
+	// standard error, see RequestLog. One in RequestLogSampleRate requests is
+	// logged, all if zero, their params only for the methods and GraphQL
+	// variables of RequestLogParams.
+	RequestLogFile       string   `toml:",omitempty"`
+	RequestLogSampleRate uint     `toml:",omitempty"`
+	RequestLogParams     []string `toml:",omitempty"`
+
+	// Quorum: GraphQLStrictChecksum rejects the mixed-case addresses of GraphQL
+	// inputs which fail the EIP-55 checksum instead of accepting them as is.
+	GraphQLStrictChecksum bool `toml:",omitempty"`
+
+	// Quorum: GraphQLAllowList is the directory of the <name>.graphql query
+	// documents the GraphQL server is restricted to, reloaded when it changes.
+	// Empty allows any query.
+	GraphQLAllowList string `toml:",omitempty"`
+
+	// Quorum: GraphQLMaxTransactionsRange is the number of transactions the
+	// GraphQL transactionsRange field returns at most, zero for
+	// graphql.DefaultMaxTransactionsRange.
+	GraphQLMaxTransactionsRange int `toml:",omitempty"`
+
+	// Quorum: GraphQLIPC serves GraphQL over HTTP on the unix socket of the IPC
+	// endpoint suffixed with .graphql, the clients being authorized by the
+	// socket file permissions rather than by API keys or access tokens.
+	GraphQLIPC bool `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
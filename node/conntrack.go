@@ -0,0 +1,160 @@
+package node
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// connTracker counts the open connections of an HTTP server, closing the ones
+// over its limit as soon as they are accepted, and counts the connections
+// closed by the timeouts of the server. A connection timing out while waiting
+// for the first request or in the middle of the headers of a request, e.g. a
+// slow-loris one, is counted as a header timeout; a keep-alive connection
+// timing out between requests as an idle timeout.
+type connTracker struct {
+	maxConns int64 // accessed atomically, 0 for no limit
+	open     int64 // accessed atomically
+
+	conns sync.Map // connection served by the http.Server -> *trackedConn
+
+	openGauge     metrics.Gauge
+	limitCounter  metrics.Counter
+	headerCounter metrics.Counter
+	idleCounter   metrics.Counter
+}
+
+// newConnTracker creates the tracker of the connections of an endpoint, http
+// or ws, with at most maxConns connections open at once if positive.
+func newConnTracker(endpoint string, maxConns int) *connTracker {
+	return &connTracker{
+		maxConns:      int64(maxConns),
+		openGauge:     metrics.GetOrRegisterGauge(fmt.Sprintf("rpc/%s/connections", endpoint), nil),
+		limitCounter:  metrics.GetOrRegisterCounter(fmt.Sprintf("rpc/%s/closed/maxconns", endpoint), nil),
+		headerCounter: metrics.GetOrRegisterCounter(fmt.Sprintf("rpc/%s/closed/header", endpoint), nil),
+		idleCounter:   metrics.GetOrRegisterCounter(fmt.Sprintf("rpc/%s/closed/idle", endpoint), nil),
+	}
+}
+
+// setMaxConnections changes the limit, the connections already open are kept.
+func (t *connTracker) setMaxConnections(maxConns int) {
+	atomic.StoreInt64(&t.maxConns, int64(maxConns))
+}
+
+// openConnections returns the number of open connections.
+func (t *connTracker) openConnections() int {
+	return int(atomic.LoadInt64(&t.open))
+}
+
+// listener wraps a TCP listener, serving TLS connections if tlsConfig is set.
+func (t *connTracker) listener(listener net.Listener, tlsConfig *tls.Config) net.Listener {
+	return &trackingListener{Listener: listener, tracker: t, tlsConfig: tlsConfig}
+}
+
+// connState is the http.Server.ConnState hook following the connections.
+func (t *connTracker) connState(c net.Conn, state http.ConnState) {
+	v, ok := t.conns.Load(c)
+	if !ok {
+		return
+	}
+	conn := v.(*trackedConn)
+	switch state {
+	case http.StateIdle:
+		atomic.StoreInt32(&conn.readSinceIdle, 0)
+	case http.StateHijacked, http.StateClosed:
+		// hijacked connections are closed by their handler
+		t.conns.Delete(c)
+	}
+	atomic.StoreInt32(&conn.state, int32(state))
+}
+
+type trackingListener struct {
+	net.Listener
+	tracker   *connTracker
+	tlsConfig *tls.Config
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		t := l.tracker
+		// there is a single accepting goroutine and closing connections only
+		// lowers the count, so the limit cannot be exceeded
+		if limit := atomic.LoadInt64(&t.maxConns); limit > 0 && atomic.LoadInt64(&t.open) >= limit {
+			c.Close()
+			t.limitCounter.Inc(1)
+			log.Debug("Rejected RPC connection over the limit", "remote", c.RemoteAddr(), "limit", limit)
+			continue
+		}
+		t.openGauge.Update(atomic.AddInt64(&t.open, 1))
+
+		conn := &trackedConn{Conn: c, tracker: t}
+		var served net.Conn = conn
+		if l.tlsConfig != nil {
+			served = tls.Server(conn, l.tlsConfig)
+		}
+		t.conns.Store(served, conn)
+		return served, nil
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+
+	state         int32 // http.ConnState, accessed atomically
+	readSinceIdle int32 // whether bytes of a request were read since the connection became idle
+	timedOut      int32
+	closeOnce     sync.Once
+}
+
+func (c *trackedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		atomic.StoreInt32(&c.readSinceIdle, 1)
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		c.timeout()
+	}
+	return n, err
+}
+
+// timeout counts the read timeout of a connection which has not been handed
+// over to a handler yet. The timeouts while a request is handled are not
+// counted, as the server also uses them to interrupt its background reads.
+func (c *trackedConn) timeout() {
+	var counter metrics.Counter
+	switch http.ConnState(atomic.LoadInt32(&c.state)) {
+	case http.StateNew:
+		counter = c.tracker.headerCounter
+	case http.StateIdle:
+		counter = c.tracker.idleCounter
+		if atomic.LoadInt32(&c.readSinceIdle) == 1 {
+			counter = c.tracker.headerCounter
+		}
+	default:
+		return
+	}
+	if atomic.CompareAndSwapInt32(&c.timedOut, 0, 1) {
+		counter.Inc(1)
+	}
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		c.tracker.openGauge.Update(atomic.AddInt64(&c.tracker.open, -1))
+	})
+	return err
+}
@@ -0,0 +1,100 @@
+package node
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTrackedServer(t *testing.T, timeouts rpc.HTTPTimeouts, maxConns int) *httpServer {
+	srv := newHTTPServer(testlog.Logger(t, log.LvlDebug), timeouts)
+	srv.conns = newConnTracker("test", maxConns)
+	// counted even with metrics disabled
+	srv.conns.limitCounter = new(metrics.StandardCounter)
+	srv.conns.headerCounter = new(metrics.StandardCounter)
+	srv.conns.idleCounter = new(metrics.StandardCounter)
+	require.NoError(t, srv.enableRPC(nil, httpConfig{}, nil))
+	require.NoError(t, srv.setListenAddr("localhost", 0))
+	require.NoError(t, srv.start(nil))
+	t.Cleanup(srv.stop)
+	return srv
+}
+
+func waitOpenConnections(t *testing.T, srv *httpServer, open int) {
+	for i := 0; i < 100 && srv.conns.openConnections() != open; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, open, srv.conns.openConnections())
+}
+
+// closedByServer checks that the connection is closed without a response.
+func closedByServer(t *testing.T, conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := conn.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestConnTracker_MaxConnections(t *testing.T) {
+	srv := startTrackedServer(t, rpc.DefaultHTTPTimeouts, 2)
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", srv.listenAddr())
+		require.NoError(t, err)
+		defer conn.Close()
+	}
+	waitOpenConnections(t, srv, 2)
+
+	rejected, err := net.Dial("tcp", srv.listenAddr())
+	require.NoError(t, err)
+	defer rejected.Close()
+	closedByServer(t, rejected)
+	assert.Equal(t, int64(1), srv.conns.limitCounter.Count())
+
+	// lifting the limit at runtime
+	srv.conns.setMaxConnections(0)
+	accepted, err := net.Dial("tcp", srv.listenAddr())
+	require.NoError(t, err)
+	defer accepted.Close()
+	waitOpenConnections(t, srv, 3)
+}
+
+func TestConnTracker_Timeouts(t *testing.T) {
+	timeouts := rpc.DefaultHTTPTimeouts
+	timeouts.ReadHeaderTimeout = 200 * time.Millisecond
+	timeouts.IdleTimeout = time.Second
+	srv := startTrackedServer(t, timeouts, 0)
+
+	// a slow-loris connection, sending the headers too slowly
+	slow, err := net.Dial("tcp", srv.listenAddr())
+	require.NoError(t, err)
+	defer slow.Close()
+	_, err = slow.Write([]byte("POST / HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+	closedByServer(t, slow)
+	assert.Equal(t, int64(1), srv.conns.headerCounter.Count())
+
+	// a keep-alive connection idle after a request
+	idle, err := net.Dial("tcp", srv.listenAddr())
+	require.NoError(t, err)
+	defer idle.Close()
+	_, err = idle.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.NoError(t, err)
+	idle.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	_, err = idle.Read(buf)
+	require.NoError(t, err)
+	for err == nil {
+		_, err = idle.Read(buf)
+	}
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, int64(1), srv.conns.idleCounter.Count())
+	assert.Equal(t, int64(1), srv.conns.headerCounter.Count())
+	waitOpenConnections(t, srv, 0)
+}
@@ -34,6 +34,8 @@ const (
 	DefaultWSPort      = 8546        // Default TCP port for the websocket RPC server
 	DefaultGraphQLHost = "localhost" // Default host interface for the GraphQL server
 	DefaultGraphQLPort = 8547        // Default TCP port for the GraphQL server
+
+	DefaultMaxConnections = 2048 // Quorum: default maximum number of open connections of the HTTP and WS servers
 )
 
 // DefaultConfig contains reasonable default settings.
@@ -46,6 +48,8 @@ var DefaultConfig = Config{
 	WSPort:              DefaultWSPort,
 	WSModules:           []string{"net", "web3"},
 	GraphQLVirtualHosts: []string{"localhost"},
+	HTTPMaxConnections:  DefaultMaxConnections,
+	WSMaxConnections:    DefaultMaxConnections,
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   50,
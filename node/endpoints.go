@@ -37,17 +37,18 @@ func StartHTTPEndpoint(endpoint string, timeouts rpc.HTTPTimeouts, handler http.
 		err          error
 		isTlsEnabled bool
 	)
-	if isTlsEnabled, listener, err = startListener(endpoint, tlsConfigSource); err != nil {
+	if isTlsEnabled, listener, err = startListener(endpoint, tlsConfigSource, nil); err != nil {
 		return nil, nil, isTlsEnabled, err
 	}
 	// make sure timeout values are meaningful
 	CheckTimeouts(&timeouts)
 	// Bundle and start the HTTP server
 	httpSrv := &http.Server{
-		Handler:      handler,
-		ReadTimeout:  timeouts.ReadTimeout,
-		WriteTimeout: timeouts.WriteTimeout,
-		IdleTimeout:  timeouts.IdleTimeout,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeaderTimeout,
+		ReadTimeout:       timeouts.ReadTimeout,
+		WriteTimeout:      timeouts.WriteTimeout,
+		IdleTimeout:       timeouts.IdleTimeout,
 
 		// Ensure to Disable HTTP/2
 		// this configuration and customized tls.Config is to follow: https://blog.bracebin.com/achieving-perfect-ssl-labs-score-with-go
@@ -78,7 +79,7 @@ func checkModuleAvailability(modules []string, apis []rpc.API) (bad, available [
 
 // Quorum
 // Produce net.Listener instance with TLS support if tlsConfigSource provides the config
-func startListener(endpoint string, tlsConfigSource security.TLSConfigurationSource) (bool, net.Listener, error) {
+func startListener(endpoint string, tlsConfigSource security.TLSConfigurationSource, tracker *connTracker) (bool, net.Listener, error) {
 	var tlsConfig *tls.Config
 	var err error
 	var listener net.Listener
@@ -91,15 +92,19 @@ func startListener(endpoint string, tlsConfigSource security.TLSConfigurationSou
 		isTlsEnabled = false
 		err = fmt.Errorf("no TLSConfigurationSource found")
 	}
-	if isTlsEnabled {
-		if listener, err = tls.Listen("tcp", endpoint, tlsConfig); err != nil {
-			return isTlsEnabled, nil, err
-		}
-	} else {
+	if !isTlsEnabled {
 		log.Info("Security: TLS not enabled", "endpoint", endpoint, "reason", err)
-		if listener, err = net.Listen("tcp", endpoint); err != nil {
-			return isTlsEnabled, nil, err
-		}
+		tlsConfig = nil
+	}
+	if listener, err = net.Listen("tcp", endpoint); err != nil {
+		return isTlsEnabled, nil, err
+	}
+	// Quorum - the tracked connections are wrapped before the TLS ones
+	if tracker != nil {
+		return isTlsEnabled, tracker.listener(listener, tlsConfig), nil
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
 	}
 	return isTlsEnabled, listener, nil
 }
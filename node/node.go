@@ -65,6 +65,10 @@ type Node struct {
 
 	// Quorum
 	pluginManager *plugin.PluginManager // Manage all plugins for this node. If plugin is not enabled, an EmptyPluginManager is set.
+	apiKeys       *apiKeyManager        // Authenticates the RPC clients if API keys are configured
+	redaction     *RedactionPolicy      // Redacts the responses served to some clients if configured
+	consistency   rpc.ConsistencyGuard  // Holds back the HTTP requests presenting a consistency token if set
+	requestLog    *RequestLog           // Logs the requests served if configured
 	// End Quorum
 }
 
@@ -117,6 +121,30 @@ func New(conf *Config) (*Node, error) {
 	// Register built-in APIs.
 	node.rpcAPIs = append(node.rpcAPIs, node.apis()...)
 
+	// Quorum
+	if conf.APIKeysFile != "" {
+		apiKeys, err := newAPIKeyManager(conf.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		node.apiKeys = apiKeys
+	}
+	if conf.RedactionPolicyFile != "" {
+		redaction, err := newRedactionPolicy(conf.RedactionPolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		node.redaction = redaction
+	}
+	if conf.RequestLogFile != "" {
+		requestLog, err := openRequestLog(conf.RequestLogFile, conf.RequestLogSampleRate, conf.RequestLogParams)
+		if err != nil {
+			return nil, err
+		}
+		node.requestLog = requestLog
+	}
+	// End Quorum
+
 	// Acquire the instance directory lock.
 	if err := node.openDataDir(); err != nil {
 		return nil, err
@@ -152,6 +180,9 @@ func New(conf *Config) (*Node, error) {
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
+	// Quorum
+	node.http.conns = newConnTracker("http", conf.HTTPMaxConnections)
+	node.ws.conns = newConnTracker("ws", conf.WSMaxConnections)
 	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
 
 	return node, nil
@@ -180,6 +211,15 @@ func (n *Node) Start() error {
 		n.doClose(nil)
 		return err
 	}
+	if n.apiKeys != nil {
+		go n.apiKeys.loop(n.stop)
+	}
+	if n.redaction != nil {
+		go n.redaction.loop(n.stop)
+	}
+	if n.requestLog != nil {
+		go n.requestLog.loop(n.stop)
+	}
 	// End Quorum
 
 	err := n.startNetworking()
@@ -376,6 +416,11 @@ func (n *Node) startRPC() error {
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
+			BodyLimit:          n.config.HTTPBodyLimit,
+			Redactor:           n.redactor(),
+			ConsistencyGuard:   n.consistency,
+			WarnDeprecated:     n.config.RPCDeprecationWarnings,
+			AccessLogger:       n.RequestLogger(),
 		}
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
 			return err
@@ -389,8 +434,15 @@ func (n *Node) startRPC() error {
 	if n.config.WSHost != "" {
 		server := n.wsServerForPort(n.config.WSPort)
 		config := wsConfig{
-			Modules: n.config.WSModules,
-			Origins: n.config.WSOrigins,
+			Modules:           n.config.WSModules,
+			Origins:           n.config.WSOrigins,
+			MessageLimit:      n.config.WSMessageLimit,
+			AuthCheckInterval: n.config.WSAuthCheckInterval,
+			PingInterval:      n.config.WSPingInterval,
+			IdleTimeout:       n.config.WSIdleTimeout,
+			Redactor:          n.redactor(),
+			WarnDeprecated:    n.config.RPCDeprecationWarnings,
+			AccessLogger:      n.RequestLogger(),
 		}
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
 			return err
@@ -406,6 +458,30 @@ func (n *Node) startRPC() error {
 	return n.ws.start(tls)
 }
 
+// Quorum
+// connectionLimits returns the connection limits in use, the caller must hold
+// n.lock.
+func (n *Node) connectionLimits() *ConnectionLimits {
+	pingInterval, idleTimeout := rpc.DefaultWSPingInterval, rpc.DefaultWSIdleTimeout
+	if n.config.WSPingInterval > 0 {
+		pingInterval = n.config.WSPingInterval
+	}
+	if n.config.WSIdleTimeout > 0 {
+		idleTimeout = n.config.WSIdleTimeout
+	}
+	return &ConnectionLimits{
+		HTTPMaxConnections:  n.config.HTTPMaxConnections,
+		WSMaxConnections:    n.config.WSMaxConnections,
+		WSPingInterval:      pingInterval.String(),
+		WSIdleTimeout:       idleTimeout.String(),
+		HTTPOpenConnections: n.http.conns.openConnections(),
+		WSOpenConnections:   n.ws.conns.openConnections(),
+		ReadHeaderTimeout:   n.config.HTTPTimeouts.ReadHeaderTimeout.String(),
+		ReadTimeout:         n.config.HTTPTimeouts.ReadTimeout.String(),
+		IdleTimeout:         n.config.HTTPTimeouts.IdleTimeout.String(),
+	}
+}
+
 func (n *Node) wsServerForPort(port int) *httpServer {
 	if n.config.HTTPHost == "" || n.http.port == port {
 		return n.http
@@ -619,6 +695,39 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer,
 	return db, err
 }
 
+// Quorum
+//
+// OpenReadOnlyDatabase opens an existing database with the given name from
+// within the node's data directory without write access, for nodes serving
+// reads from a store replicated from another node. The returned function
+// re-opens the store to pick up replicated changes. If the node is an
+// ephemeral one, a memory database with a no-op refresh is returned.
+func (n *Node) OpenReadOnlyDatabase(name string, cache, handles int) (ethdb.Database, func() error, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.state == closedState {
+		return nil, nil, ErrNodeStopped
+	}
+
+	var (
+		db      ethdb.Database
+		refresh = func() error { return nil }
+		err     error
+	)
+	if n.config.DataDir == "" {
+		db = rawdb.NewMemoryDatabase()
+	} else {
+		db, refresh, err = rawdb.NewReadOnlyLevelDBDatabase(n.ResolvePath(name), cache, handles)
+	}
+
+	if err == nil {
+		db = n.wrapDatabase(db)
+	}
+	return db, refresh, err
+}
+
+// /Quorum
+
 // ResolvePath returns the absolute path of a resource in the instance directory.
 func (n *Node) ResolvePath(x string) string {
 	return n.config.ResolvePath(x)
@@ -660,6 +769,10 @@ func (n *Node) closeDatabases() (errors []error) {
 // Quorum
 func (n *Node) getSecuritySupports() (tlsConfigSource security.TLSConfigurationSource, authManager security.AuthenticationManager, err error) {
 	if n.pluginManager.IsEnabled(plugin.SecurityPluginInterfaceName) {
+		if n.apiKeys != nil {
+			err = errors.New("API keys cannot be used along with the security plugin")
+			return
+		}
 		sp := new(plugin.SecurityPluginTemplate)
 		if err = n.pluginManager.GetPluginTemplate(plugin.SecurityPluginInterfaceName, sp); err != nil {
 			return
@@ -670,12 +783,80 @@ func (n *Node) getSecuritySupports() (tlsConfigSource security.TLSConfigurationS
 		if authManager, err = sp.AuthenticationManager(); err != nil {
 			return
 		}
+	} else if n.apiKeys != nil {
+		authManager = n.apiKeys
 	} else {
 		log.Info("Security Plugin is not enabled")
 	}
 	return
 }
 
+// Quorum
+//
+// APIKeyHandler wraps the handler of a service served outside of the RPC
+// servers, e.g. GraphQL, so that its requests need an API key allowed the
+// namespace if API keys are configured.
+func (n *Node) APIKeyHandler(namespace string, h http.Handler) http.Handler {
+	if n.apiKeys == nil {
+		return h
+	}
+	return n.apiKeys.handler(namespace, h)
+}
+
+// Quorum
+//
+// RedactionPolicy returns the redaction policy of the responses, nil if none is
+// configured.
+func (n *Node) RedactionPolicy() *RedactionPolicy {
+	return n.redaction
+}
+
+// redactor returns the redactor of the RPC servers, nil if none.
+func (n *Node) redactor() rpc.Redactor {
+	if n.redaction == nil {
+		return nil
+	}
+	return n.redaction
+}
+
+// RequestLogger returns the logger of the JSON-RPC and GraphQL requests
+// served, nil if none is configured.
+func (n *Node) RequestLogger() rpc.AccessLogger {
+	if n.requestLog == nil {
+		return nil
+	}
+	return n.requestLog
+}
+
+// Quorum
+//
+// SetConsistencyGuard sets the guard of the HTTP requests presenting a
+// consistency token, before the node is started.
+func (n *Node) SetConsistencyGuard(guard rpc.ConsistencyGuard) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != initializingState {
+		panic("can't set the consistency guard of a running/stopped node")
+	}
+	n.consistency = guard
+}
+
+// ConsistencyGuard returns the guard of the HTTP requests presenting a
+// consistency token, nil if none is set.
+func (n *Node) ConsistencyGuard() rpc.ConsistencyGuard {
+	return n.consistency
+}
+
+// Quorum
+//
+// AuthenticationManager returns the authentication manager of the security
+// plugin or of the API keys, nil if neither is configured
+func (n *Node) AuthenticationManager() (security.AuthenticationManager, error) {
+	_, authManager, err := n.getSecuritySupports()
+	return authManager, err
+}
+
 // Quorum
 //
 // delegate call to node.Config
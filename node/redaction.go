@@ -0,0 +1,284 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// The redaction policy removes privacy-sensitive fields from the responses
+// served to some clients, even when they are parties to the data. Its rules
+// select clients by identity or granted scope and list the fields to null, by
+// RPC method and JSON path of the result, and by GraphQL field coordinate. The
+// policy runs after authorization and only nulls fields, it never grants
+// access. The policy file is reloaded when it changes.
+
+const redactionReloadInterval = 5 * time.Second
+
+// RedactionRule redacts fields of the responses served to the clients it
+// selects.
+//
+// JSON paths are dot-separated keys of the result, "*" matching every key of
+// an object or element of an array, a key followed by "[*]" every element of
+// its array, and "$" the whole result, e.g. "input" or "logs[*].data".
+// GraphQL fields are coordinates Type.field, e.g. "Transaction.privateInputData".
+type RedactionRule struct {
+	Identities []string            `json:"identities,omitempty"` // identities of the clients, apikey:<id>, sub:<subject> or token:<hash>
+	Scopes     []string            `json:"scopes,omitempty"`     // raw authorities granted to the clients
+	Methods    map[string][]string `json:"methods,omitempty"`    // JSON paths of the results, by RPC method
+	GraphQL    []string            `json:"graphql,omitempty"`    // coordinates of the GraphQL fields
+}
+
+// matches reports whether the rule selects the client of the token.
+func (rule *RedactionRule) matches(identity string, token *proto.PreAuthenticatedAuthenticationToken) bool {
+	for _, id := range rule.Identities {
+		if id == identity {
+			return true
+		}
+	}
+	if token == nil {
+		return false
+	}
+	for _, scope := range rule.Scopes {
+		for _, authority := range token.Authorities {
+			if authority.Raw == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RedactionPolicy is the redaction policy of the node, implementing
+// rpc.Redactor for the RPC servers.
+type RedactionPolicy struct {
+	path string
+
+	lock    sync.RWMutex
+	rules   []*RedactionRule
+	modTime time.Time
+}
+
+func newRedactionPolicy(path string) (*RedactionPolicy, error) {
+	p := &RedactionPolicy{path: path}
+	if err := p.reload(); err != nil {
+		return nil, fmt.Errorf("invalid redaction policy %s: %v", path, err)
+	}
+	return p, nil
+}
+
+// reload reads the policy file, keeping the current rules if it is invalid.
+func (p *RedactionPolicy) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	blob, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var file struct {
+		Rules []*RedactionRule `json:"rules"`
+	}
+	if err := json.Unmarshal(blob, &file); err != nil {
+		return err
+	}
+	for i, rule := range file.Rules {
+		if len(rule.Identities) == 0 && len(rule.Scopes) == 0 {
+			return fmt.Errorf("rule %d selects no clients", i)
+		}
+		for method, paths := range rule.Methods {
+			for _, path := range paths {
+				if _, err := parseRedactionPath(path); err != nil {
+					return fmt.Errorf("rule %d, %s: %v", i, method, err)
+				}
+			}
+		}
+		for _, field := range rule.GraphQL {
+			if parts := strings.Split(field, "."); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("rule %d: invalid GraphQL field coordinate %q", i, field)
+			}
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.rules = file.Rules
+	p.modTime = info.ModTime()
+	return nil
+}
+
+// loop reloads the policy file whenever it is modified, until stop is closed.
+func (p *RedactionPolicy) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(redactionReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(p.path)
+			if err != nil {
+				log.Error("Failed to check the redaction policy", "path", p.path, "err", err)
+				continue
+			}
+			p.lock.RLock()
+			modified := !info.ModTime().Equal(p.modTime)
+			p.lock.RUnlock()
+			if !modified {
+				continue
+			}
+			if err := p.reload(); err != nil {
+				log.Error("Invalid redaction policy, keeping the previous rules", "path", p.path, "err", err)
+			} else {
+				log.Info("Reloaded the redaction policy", "path", p.path)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// client returns the identity of the client of the context and the rules
+// selecting it.
+func (p *RedactionPolicy) client(ctx context.Context) (string, []*RedactionRule) {
+	token, _ := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	identity := multitenancy.TokenIdentity(token)
+	if identity == "" {
+		return "", nil
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	var rules []*RedactionRule
+	for _, rule := range p.rules {
+		if rule.matches(identity, token) {
+			rules = append(rules, rule)
+		}
+	}
+	return identity, rules
+}
+
+// Redact implements rpc.Redactor.
+func (p *RedactionPolicy) Redact(ctx context.Context, method string, result json.RawMessage) (json.RawMessage, []string) {
+	identity, rules := p.client(ctx)
+	var paths []string
+	for _, rule := range rules {
+		paths = append(paths, rule.Methods[method]...)
+	}
+	if len(paths) == 0 {
+		return result, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return result, nil
+	}
+	var redacted []string
+	for _, path := range paths {
+		segments, _ := parseRedactionPath(path)
+		if redactPath(&value, segments) {
+			redacted = append(redacted, path)
+		}
+	}
+	if len(redacted) == 0 {
+		return result, nil
+	}
+	enc, err := json.Marshal(value)
+	if err != nil {
+		return result, nil
+	}
+	MarkRedacted(identity)
+	return enc, redacted
+}
+
+// GraphQLFields returns the coordinates of the GraphQL fields redacted for the
+// client of the context and its identity.
+func (p *RedactionPolicy) GraphQLFields(ctx context.Context) (string, map[string]bool) {
+	identity, rules := p.client(ctx)
+	var fields map[string]bool
+	for _, rule := range rules {
+		for _, field := range rule.GraphQL {
+			if fields == nil {
+				fields = make(map[string]bool)
+			}
+			fields[field] = true
+		}
+	}
+	return identity, fields
+}
+
+// MarkRedacted counts a response redacted for the identity.
+func MarkRedacted(identity string) {
+	metrics.GetOrRegisterCounter("rpc/redacted/"+identity, nil).Inc(1)
+}
+
+// parseRedactionPath splits a JSON path into keys, "*" matching every key or
+// element.
+func parseRedactionPath(path string) ([]string, error) {
+	if path == "$" {
+		return nil, nil
+	}
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		if strings.HasSuffix(part, "[*]") {
+			part = strings.TrimSuffix(part, "[*]")
+			if part == "" || strings.ContainsAny(part, "[]") {
+				return nil, fmt.Errorf("invalid JSON path %q", path)
+			}
+			segments = append(segments, part, "*")
+			continue
+		}
+		if part == "" || strings.ContainsAny(part, "[]") {
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+		segments = append(segments, part)
+	}
+	return segments, nil
+}
+
+// redactPath nulls the values at the path, returning whether any was not null.
+func redactPath(value *interface{}, segments []string) bool {
+	if *value == nil {
+		return false
+	}
+	if len(segments) == 0 {
+		*value = nil
+		return true
+	}
+	redacted := false
+	switch v := (*value).(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segments[0] != "*" && segments[0] != key {
+				continue
+			}
+			if redactPath(&child, segments[1:]) {
+				v[key] = child
+				redacted = true
+			}
+		}
+	case []interface{}:
+		if segments[0] != "*" {
+			return false
+		}
+		for i := range v {
+			if redactPath(&v[i], segments[1:]) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,94 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRedactionPolicy(t *testing.T, path string, rules ...*RedactionRule) {
+	blob, err := json.Marshal(map[string]interface{}{"rules": rules})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, blob, 0600))
+}
+
+func redactionContext(raw string, scopes ...string) context.Context {
+	token := &proto.PreAuthenticatedAuthenticationToken{RawToken: []byte(raw)}
+	for _, scope := range scopes {
+		token.Authorities = append(token.Authorities, &proto.GrantedAuthority{Raw: scope})
+	}
+	return context.WithValue(context.Background(), rpc.CtxPreauthenticatedToken, token)
+}
+
+func TestRedactionPolicy_Redact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redaction.json")
+	writeRedactionPolicy(t, path,
+		&RedactionRule{Identities: []string{"apikey:auditor"}, Methods: map[string][]string{
+			"eth_getTransactionByHash":  {"input", "missing"},
+			"eth_getTransactionReceipt": {"logs[*].data"},
+		}},
+		&RedactionRule{Scopes: []string{"private://0x0/read/contracts?owned.eoa=0x0"}, Methods: map[string][]string{
+			"eth_getTransactionByHash": {"$"},
+		}},
+	)
+	p, err := newRedactionPolicy(path)
+	require.NoError(t, err)
+
+	auditor := redactionContext("apikey:auditor")
+	result, redacted := p.Redact(auditor, "eth_getTransactionByHash", json.RawMessage(`{"input":"0x01","nonce":"0x1","value":12345678901234567890}`))
+	assert.Equal(t, []string{"input"}, redacted)
+	assert.JSONEq(t, `{"input":null,"nonce":"0x1","value":12345678901234567890}`, string(result))
+
+	result, redacted = p.Redact(auditor, "eth_getTransactionReceipt", json.RawMessage(`{"logs":[{"data":"0x01"},{"data":"0x02"}]}`))
+	assert.Equal(t, []string{"logs[*].data"}, redacted)
+	assert.JSONEq(t, `{"logs":[{"data":null},{"data":null}]}`, string(result))
+
+	// fields already null are not reported as redacted
+	original := json.RawMessage(`{"input":null}`)
+	result, redacted = p.Redact(auditor, "eth_getTransactionByHash", original)
+	assert.Nil(t, redacted)
+	assert.Equal(t, original, result)
+
+	result, redacted = p.Redact(redactionContext("apikey:other", "private://0x0/read/contracts?owned.eoa=0x0"), "eth_getTransactionByHash", original)
+	assert.Equal(t, []string{"$"}, redacted)
+	assert.Equal(t, "null", string(result))
+
+	// unmatched and unauthenticated clients are served unredacted
+	_, redacted = p.Redact(redactionContext("apikey:other"), "eth_getTransactionByHash", json.RawMessage(`{"input":"0x01"}`))
+	assert.Nil(t, redacted)
+	_, redacted = p.Redact(context.Background(), "eth_getTransactionByHash", json.RawMessage(`{"input":"0x01"}`))
+	assert.Nil(t, redacted)
+}
+
+func TestRedactionPolicy_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redaction.json")
+	writeRedactionPolicy(t, path, &RedactionRule{Identities: []string{"apikey:auditor"}, GraphQL: []string{"Transaction.privateInputData"}})
+	p, err := newRedactionPolicy(path)
+	require.NoError(t, err)
+
+	identity, fields := p.GraphQLFields(redactionContext("apikey:auditor"))
+	assert.Equal(t, "apikey:auditor", identity)
+	assert.Equal(t, map[string]bool{"Transaction.privateInputData": true}, fields)
+
+	// invalid policies are rejected, keeping the current rules
+	writeRedactionPolicy(t, path, &RedactionRule{GraphQL: []string{"Transaction.inputData"}})
+	assert.EqualError(t, p.reload(), "rule 0 selects no clients")
+	writeRedactionPolicy(t, path, &RedactionRule{Identities: []string{"apikey:auditor"}, Methods: map[string][]string{"eth_call": {"logs[0]"}}})
+	assert.Error(t, p.reload())
+	writeRedactionPolicy(t, path, &RedactionRule{Identities: []string{"apikey:auditor"}, GraphQL: []string{"inputData"}})
+	assert.Error(t, p.reload())
+	_, fields = p.GraphQLFields(redactionContext("apikey:auditor"))
+	assert.True(t, fields["Transaction.privateInputData"])
+
+	writeRedactionPolicy(t, path)
+	require.NoError(t, p.reload())
+	_, fields = p.GraphQLFields(redactionContext("apikey:auditor"))
+	assert.Empty(t, fields)
+}
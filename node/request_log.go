@@ -0,0 +1,125 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/multitenancy"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// The request log writes the JSON-RPC and GraphQL requests served as JSON
+// lines, see rpc.AccessLogEntry, for capacity planning. The entries are queued
+// to a single writer and dropped once the queue is full, so that a slow log
+// never stalls the requests. The clients are identified as for the redaction
+// policy, the params and variables are only logged if allowed by name.
+
+const requestLogQueue = 1024
+
+var requestLogDroppedMeter = metrics.NewRegisteredMeter("rpc/requestlog/dropped", nil)
+
+// RequestLog implements rpc.AccessLogger, writing the entries to a writer.
+type RequestLog struct {
+	w          io.Writer
+	sampleRate uint64
+	params     map[string]bool
+
+	seen    uint64 // requests sampled from, accessed atomically
+	dropped uint64 // entries dropped, accessed atomically
+	queue   chan *rpc.AccessLogEntry
+}
+
+// NewRequestLog creates a log of one in sampleRate requests, all if 0, the
+// params being logged for the methods and GraphQL variables listed.
+func NewRequestLog(w io.Writer, sampleRate uint, params []string) *RequestLog {
+	l := &RequestLog{
+		w:          w,
+		sampleRate: uint64(sampleRate),
+		params:     make(map[string]bool, len(params)),
+		queue:      make(chan *rpc.AccessLogEntry, requestLogQueue),
+	}
+	for _, name := range params {
+		l.params[name] = true
+	}
+	return l
+}
+
+// openRequestLog opens the log appending to a file, or the standard error.
+func openRequestLog(path string, sampleRate uint, params []string) (*RequestLog, error) {
+	if path == "stderr" {
+		return NewRequestLog(os.Stderr, sampleRate, params), nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewRequestLog(file, sampleRate, params), nil
+}
+
+// Sample implements rpc.AccessLogger.
+func (l *RequestLog) Sample() bool {
+	if l.sampleRate <= 1 {
+		return true
+	}
+	return (atomic.AddUint64(&l.seen, 1)-1)%l.sampleRate == 0
+}
+
+// LogsParams implements rpc.AccessLogger.
+func (l *RequestLog) LogsParams(name string) bool {
+	return l.params[name]
+}
+
+// LogAccess implements rpc.AccessLogger.
+func (l *RequestLog) LogAccess(ctx context.Context, e *rpc.AccessLogEntry) {
+	if token, ok := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken); ok {
+		e.Identity = multitenancy.TokenIdentity(token)
+	}
+	select {
+	case l.queue <- e:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+		requestLogDroppedMeter.Mark(1)
+	}
+}
+
+// Dropped returns the number of entries dropped as the queue was full.
+func (l *RequestLog) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// loop writes the queued entries until stopped, then the entries left, and
+// closes the writer.
+func (l *RequestLog) loop(stop <-chan struct{}) {
+	enc := json.NewEncoder(l.w)
+	write := func(e *rpc.AccessLogEntry) {
+		if err := enc.Encode(e); err != nil {
+			log.Warn("Failed to write the request log", "err", err)
+		}
+	}
+	for {
+		select {
+		case e := <-l.queue:
+			write(e)
+		case <-stop:
+			for {
+				select {
+				case e := <-l.queue:
+					write(e)
+				default:
+					if closer, ok := l.w.(io.Closer); ok && l.w != os.Stderr {
+						closer.Close()
+					}
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLog_Sample(t *testing.T) {
+	l := NewRequestLog(ioutil.Discard, 3, nil)
+	var sampled []bool
+	for i := 0; i < 6; i++ {
+		sampled = append(sampled, l.Sample())
+	}
+	assert.Equal(t, []bool{true, false, false, true, false, false}, sampled)
+	assert.True(t, NewRequestLog(ioutil.Discard, 0, nil).Sample())
+}
+
+func TestRequestLog_Write(t *testing.T) {
+	var w bytes.Buffer
+	l := NewRequestLog(&w, 0, []string{"eth_call"})
+	assert.True(t, l.LogsParams("eth_call"))
+	assert.False(t, l.LogsParams("eth_sendRawTransaction"))
+
+	// the entries past the queue are dropped until written
+	ctx := redactionContext("apikey:ops")
+	for i := 0; i < requestLogQueue+2; i++ {
+		l.LogAccess(ctx, &rpc.AccessLogEntry{Protocol: "http", Operations: []string{"eth_call"}})
+	}
+	assert.Equal(t, uint64(2), l.Dropped())
+	l.LogAccess(context.Background(), &rpc.AccessLogEntry{Protocol: "ws", Operations: []string{}})
+	assert.Equal(t, uint64(3), l.Dropped())
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		l.loop(stop)
+		close(done)
+	}()
+	close(stop)
+	<-done
+
+	// the entries queued are written once stopped
+	scanner := bufio.NewScanner(&w)
+	lines := 0
+	for scanner.Scan() {
+		var e rpc.AccessLogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		assert.Equal(t, "apikey:ops", e.Identity)
+		lines++
+	}
+	assert.Equal(t, requestLogQueue, lines)
+}
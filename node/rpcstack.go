@@ -28,6 +28,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/plugin/security"
@@ -40,12 +41,24 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
+	BodyLimit          int64                // Quorum
+	Redactor           rpc.Redactor         // Quorum
+	ConsistencyGuard   rpc.ConsistencyGuard // Quorum
+	WarnDeprecated     bool                 // Quorum
+	AccessLogger       rpc.AccessLogger     // Quorum
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
-	Origins []string
-	Modules []string
+	Origins           []string
+	Modules           []string
+	MessageLimit      int64            // Quorum
+	AuthCheckInterval time.Duration    // Quorum
+	PingInterval      time.Duration    // Quorum
+	IdleTimeout       time.Duration    // Quorum
+	Redactor          rpc.Redactor     // Quorum
+	WarnDeprecated    bool             // Quorum
+	AccessLogger      rpc.AccessLogger // Quorum
 }
 
 type rpcHandler struct {
@@ -76,6 +89,9 @@ type httpServer struct {
 	port     int
 
 	handlerNames map[string]string
+
+	// Quorum - counts and limits the connections, nil if not tracked
+	conns *connTracker
 }
 
 func newHTTPServer(log log.Logger, timeouts rpc.HTTPTimeouts) *httpServer {
@@ -125,13 +141,17 @@ func (h *httpServer) start(tlsConfigSource security.TLSConfigurationSource) erro
 	h.server = &http.Server{Handler: h}
 	if h.timeouts != (rpc.HTTPTimeouts{}) {
 		CheckTimeouts(&h.timeouts)
+		h.server.ReadHeaderTimeout = h.timeouts.ReadHeaderTimeout
 		h.server.ReadTimeout = h.timeouts.ReadTimeout
 		h.server.WriteTimeout = h.timeouts.WriteTimeout
 		h.server.IdleTimeout = h.timeouts.IdleTimeout
 	}
+	if h.conns != nil {
+		h.server.ConnState = h.conns.connState
+	}
 
 	// Start the server.
-	isTls, listener, err := startListener(h.endpoint, tlsConfigSource)
+	isTls, listener, err := startListener(h.endpoint, tlsConfigSource, h.conns)
 	if err != nil {
 		// If the server fails to start, we need to clear out the RPC and WS
 		// configuration so they can be configured another time.
@@ -242,6 +262,11 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig, authManager se
 	if err := RegisterApisFromWhitelist(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	srv.SetBodyLimit(config.BodyLimit)
+	srv.SetRedactor(config.Redactor)
+	srv.SetConsistencyGuard(config.ConsistencyGuard)
+	srv.SetDeprecationWarnings(config.WarnDeprecated)
+	srv.SetAccessLogger(config.AccessLogger)
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
 		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts),
@@ -275,6 +300,12 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig, authManager secur
 	if err := RegisterApisFromWhitelist(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	srv.SetBodyLimit(config.MessageLimit)
+	srv.SetAuthCheckInterval(config.AuthCheckInterval)
+	srv.SetWebsocketLiveness(config.PingInterval, config.IdleTimeout)
+	srv.SetRedactor(config.Redactor)
+	srv.SetDeprecationWarnings(config.WarnDeprecated)
+	srv.SetAccessLogger(config.AccessLogger)
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
 		Handler: srv.WebsocketHandler(config.Origins),
@@ -283,6 +314,19 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig, authManager secur
 	return nil
 }
 
+// Quorum
+// setWebsocketLiveness changes the websocket liveness settings of the server
+// and of the connections it serves.
+func (h *httpServer) setWebsocketLiveness(pingInterval, idleTimeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.wsConfig.PingInterval, h.wsConfig.IdleTimeout = pingInterval, idleTimeout
+	if ws := h.wsHandler.Load().(*rpcHandler); ws != nil {
+		ws.server.SetWebsocketLiveness(pingInterval, idleTimeout)
+	}
+}
+
 // stopWS disables JSON-RPC over WebSocket and also stops the server if it only serves WebSocket.
 func (h *httpServer) stopWS() {
 	h.mu.Lock()
@@ -413,7 +457,8 @@ func (w *gzipResponseWriter) Write(b []byte) (int, error) {
 
 func newGzipHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		// Quorum: the upgraded connections must be hijacked from the writer
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -491,6 +536,10 @@ func RegisterApisFromWhitelist(apis []rpc.API, modules []string, srv *rpc.Server
 			if err := srv.RegisterName(api.Namespace, api.Service); err != nil {
 				return err
 			}
+			// Quorum
+			if len(api.SubscriptionScopes) > 0 {
+				srv.RequireSubscriptionScopes(api.Namespace, api.SubscriptionScopes)
+			}
 		}
 	}
 	return nil
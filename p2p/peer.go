@@ -214,23 +214,27 @@ func (p *Peer) Log() log.Logger {
 
 func (p *Peer) run() (remoteRequested bool, err error) {
 	var (
-		writeStart = make(chan struct{}, 1)
-		writeErr   = make(chan error, 1)
-		readErr    = make(chan error, 1)
-		reason     DiscReason // sent to the peer
+		writes   = newWriteScheduler() // Quorum: priority messages are written first
+		writeErr = make(chan error, 1)
+		readErr  = make(chan error, 1)
+		reason   DiscReason // sent to the peer
 	)
 	p.wg.Add(2)
 	go p.readLoop(readErr)
 	go p.pingLoop()
 
 	// Start all protocol handlers.
-	writeStart <- struct{}{}
-	p.startProtocols(writeStart, writeErr)
+	p.startProtocols(writes.bulk, writes.priority, writeErr)
 
 	// Wait for an error or disconnect.
 loop:
 	for {
+		priorityStart, bulkStart := writes.offer()
 		select {
+		case priorityStart <- struct{}{}:
+			writes.granted(true)
+		case bulkStart <- struct{}{}:
+			writes.granted(false)
 		case err = <-writeErr:
 			// A write finished. Allow the next write to start if
 			// there was no error.
@@ -238,7 +242,7 @@ loop:
 				reason = DiscNetworkError
 				break loop
 			}
-			writeStart <- struct{}{}
+			writes.done()
 		case err = <-readErr:
 			if r, ok := err.(DiscReason); ok {
 				remoteRequested = true
@@ -368,12 +372,13 @@ outer:
 	return result
 }
 
-func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error) {
+func (p *Peer) startProtocols(writeStart, priorityWriteStart <-chan struct{}, writeErr chan<- error) {
 	p.wg.Add(len(p.running))
 	for _, proto := range p.running {
 		proto := proto
 		proto.closed = p.closed
 		proto.wstart = writeStart
+		proto.wprio = priorityWriteStart
 		proto.werr = writeErr
 		var rw MsgReadWriter = proto
 		if p.events != nil {
@@ -410,6 +415,7 @@ type protoRW struct {
 	in     chan Msg        // receives read messages
 	closed <-chan struct{} // receives when peer is shutting down
 	wstart <-chan struct{} // receives when write may start
+	wprio  <-chan struct{} // Quorum: receives when a priority write may start
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
@@ -422,10 +428,20 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	msg.meterCap = rw.cap()
 	msg.meterCode = msg.Code
 
+	// Quorum: the latency sensitive messages wait in the priority queue
+	wstart, queued, wait := rw.wstart, bulkWriteQueueGauge, bulkWriteWaitTimer
+	if rw.Prioritize != nil && rw.Prioritize(msg.Code) {
+		wstart, queued, wait = rw.wprio, priorityWriteQueueGauge, priorityWriteWaitTimer
+	}
+
 	msg.Code += rw.offset
 
+	queued.Inc(1)
+	queuedAt := time.Now()
 	select {
-	case <-rw.wstart:
+	case <-wstart:
+		queued.Dec(1)
+		wait.UpdateSince(queuedAt)
 		err = rw.w.WriteMsg(msg)
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
@@ -433,6 +449,7 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 		// as well but we don't want to rely on that.
 		rw.werr <- err
 	case <-rw.closed:
+		queued.Dec(1)
 		err = ErrShuttingDown
 	}
 	return err
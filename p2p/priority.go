@@ -0,0 +1,84 @@
+package p2p
+
+import "github.com/ethereum/go-ethereum/metrics"
+
+// Quorum
+//
+// The writes to a peer are serialized, every message waiting for its turn to
+// be written. The messages of the protocols prioritizing them, e.g. consensus
+// messages, wait in a queue of their own which is served first, so that they
+// wait for the message being written rather than for all the gossip queued
+// before them. A message being written is never interrupted, the bulk data
+// holding the connection for a single message while priority messages wait.
+// Conversely, a bulk message waiting goes first after maxPriorityRun priority
+// messages in a row, so that a flood of priority messages never starves the
+// gossip. The scheduling is local, the messages on the wire being unchanged.
+
+// maxPriorityRun is the number of priority messages written in a row while a
+// bulk message waits.
+const maxPriorityRun = 16
+
+var (
+	priorityWriteQueueGauge = metrics.NewRegisteredGauge("p2p/write/priority/queued", nil)
+	bulkWriteQueueGauge     = metrics.NewRegisteredGauge("p2p/write/bulk/queued", nil)
+	// the time waited for the connection, i.e. the head-of-line blocking
+	priorityWriteWaitTimer = metrics.NewRegisteredTimer("p2p/write/priority/wait", nil)
+	bulkWriteWaitTimer     = metrics.NewRegisteredTimer("p2p/write/bulk/wait", nil)
+)
+
+// writeScheduler grants the turns to write to the connection of a peer, the
+// writers receiving from the queue of their priority.
+type writeScheduler struct {
+	bulk, priority chan struct{}
+
+	writing bool // a message is being written
+	run     int  // priority messages granted in a row
+}
+
+func newWriteScheduler() *writeScheduler {
+	return &writeScheduler{
+		bulk:     make(chan struct{}),
+		priority: make(chan struct{}),
+	}
+}
+
+// offer grants the turn to a waiting writer, a priority one first. If none is
+// waiting, it returns the queues on which to offer the turn, both nil while a
+// message is being written.
+func (s *writeScheduler) offer() (priority, bulk chan<- struct{}) {
+	if s.writing {
+		return nil, nil
+	}
+	first, second, firstPriority := s.priority, s.bulk, true
+	if s.run >= maxPriorityRun {
+		first, second, firstPriority = s.bulk, s.priority, false
+	}
+	select {
+	case first <- struct{}{}:
+		s.granted(firstPriority)
+		return nil, nil
+	default:
+	}
+	select {
+	case second <- struct{}{}:
+		s.granted(!firstPriority)
+		return nil, nil
+	default:
+	}
+	return s.priority, s.bulk
+}
+
+// granted records the turn given to a writer.
+func (s *writeScheduler) granted(priority bool) {
+	s.writing = true
+	if priority {
+		s.run++
+	} else {
+		s.run = 0
+	}
+}
+
+// done records the end of the write.
+func (s *writeScheduler) done() {
+	s.writing = false
+}
@@ -0,0 +1,136 @@
+package p2p
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteScheduler(t *testing.T) {
+	s := newWriteScheduler()
+	priority, bulk := s.offer()
+	if priority == nil || bulk == nil {
+		t.Fatal("no queue offered while idle")
+	}
+	s.granted(false)
+	if priority, bulk = s.offer(); priority != nil || bulk != nil {
+		t.Fatal("queue offered while writing")
+	}
+	s.done()
+
+	// a waiting bulk writer goes first after a run of priority writes
+	waiting := make(chan bool, 1)
+	go func() {
+		<-s.bulk
+		waiting <- true
+	}()
+	for i := 0; i < maxPriorityRun; i++ {
+		s.granted(true)
+		s.done()
+	}
+	for {
+		if priority, _ := s.offer(); priority == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	<-waiting
+	if s.run != 0 {
+		t.Errorf("priority run not reset by the bulk write: %d", s.run)
+	}
+}
+
+// TestPeerPriorityWrites saturates a peer with bulk gossip while sending it
+// consensus messages, which must be delayed less once prioritized.
+func TestPeerPriorityWrites(t *testing.T) {
+	fifo := consensusDelay(t, false)
+	prioritized := consensusDelay(t, true)
+	t.Logf("mean consensus message delay: %v in order, %v prioritized", fifo, prioritized)
+	if prioritized*2 > fifo {
+		t.Errorf("prioritized consensus messages delayed %v, %v in order", prioritized, fifo)
+	}
+}
+
+// consensusDelay returns the mean delay of the consensus messages written to a
+// peer while several writers saturate it with bulk messages.
+func consensusDelay(t *testing.T, prioritize bool) time.Duration {
+	const (
+		bulkWriters   = 8
+		consensusMsgs = 30
+	)
+	var (
+		done    = make(chan struct{})
+		payload = make([]byte, 32*1024)
+	)
+	bulk := Protocol{
+		Name:   "bulk",
+		Length: 1,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			var wg sync.WaitGroup
+			for i := 0; i < bulkWriters; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						if err := Send(rw, 0, payload); err != nil {
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			return nil
+		},
+	}
+	consensus := Protocol{
+		Name:   "consensus",
+		Length: 1,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for i := 0; i < consensusMsgs; i++ {
+				if err := Send(rw, 0, uint64(time.Now().UnixNano())); err != nil {
+					return err
+				}
+				time.Sleep(2 * time.Millisecond)
+			}
+			<-done
+			return nil
+		},
+	}
+	if prioritize {
+		consensus.Prioritize = func(uint64) bool { return true }
+	}
+	closer, rw, _, errc := testPeer([]Protocol{bulk, consensus})
+	defer func() {
+		closer()
+		<-errc
+	}()
+	// the protocols are ordered by name: bulk, then consensus
+	consensusCode := uint64(baseProtocolLength + 1)
+
+	var total time.Duration
+	for received := 0; received < consensusMsgs; {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Code != consensusCode {
+			// a slow link
+			time.Sleep(200 * time.Microsecond)
+			msg.Discard()
+			continue
+		}
+		var sent uint64
+		if err := msg.Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		total += time.Since(time.Unix(0, int64(sent)))
+		received++
+	}
+	close(done)
+	return total / consensusMsgs
+}
@@ -61,6 +61,12 @@ type Protocol struct {
 
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
+
+	// Quorum
+	// Prioritize, if set, reports whether the messages with the given code are
+	// latency sensitive, e.g. consensus messages. They are written before the
+	// other messages waiting to be written to the peer.
+	Prioritize func(code uint64) bool
 }
 
 func (p Protocol) cap() Cap {
@@ -240,19 +240,19 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil, false, 32, 35, big.NewInt(0), big.NewInt(0), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil, false, 32, 35, big.NewInt(0), big.NewInt(0), nil, nil, nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil, false, 32, 32, big.NewInt(0), big.NewInt(0), nil, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil, false, 32, 32, big.NewInt(0), big.NewInt(0), nil, nil, nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(10), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil, false, 32, 32, big.NewInt(0), big.NewInt(0), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(10), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil, false, 32, 32, big.NewInt(0), big.NewInt(0), nil, nil, nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 
-	QuorumTestChainConfig = &ChainConfig{big.NewInt(10), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil, true, 64, 32, big.NewInt(0), big.NewInt(0), nil, big.NewInt(0)}
+	QuorumTestChainConfig = &ChainConfig{big.NewInt(10), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil, true, 64, 32, big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), nil, nil}
 )
 
 // TrustedCheckpoint represents a set of post-processed trie roots (CHT and
@@ -348,6 +348,30 @@ type ChainConfig struct {
 	MaxCodeSizeConfig []MaxCodeConfigStruct `json:"maxCodeSizeConfig,omitempty"`
 	// Quorum
 	PrivacyEnhancementsBlock *big.Int `json:"privacyEnhancementsBlock,omitempty"`
+	// Quorum
+	//
+	// PrivatePayloadHashBlock is the block from which private transactions whose
+	// data is not an encrypted payload hash are invalid (nil = no fork). Such
+	// transactions included by earlier blocks remain valid.
+	PrivatePayloadHashBlock *big.Int `json:"privatePayloadHashBlock,omitempty"`
+	// Quorum
+	//
+	// DeployerAllowList restricts the creation of contracts by transactions to
+	// designated deployers (nil = no restriction).
+	DeployerAllowList *DeployerAllowListConfig `json:"deployerAllowList,omitempty"`
+}
+
+// Quorum
+//
+// DeployerAllowListConfig restricts, from a block, the senders of the public
+// and private contract creation transactions to either a static list of
+// deployers or the deployers allowed by a registry contract. The registry is
+// consulted at the state of the parent of the block through a view call of
+// isAllowedDeployer(address) returning a bool.
+type DeployerAllowListConfig struct {
+	Block     *big.Int         `json:"block"`
+	Deployers []common.Address `json:"deployers,omitempty"`
+	Registry  *common.Address  `json:"registry,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -372,9 +396,12 @@ func (c *CliqueConfig) String() string {
 
 // IstanbulConfig is the consensus engine configs for Istanbul based sealing.
 type IstanbulConfig struct {
-	Epoch          uint64   `json:"epoch"`                    // Epoch length to reset votes and checkpoint
-	ProposerPolicy uint64   `json:"policy"`                   // The policy for proposer selection
-	Ceil2Nby3Block *big.Int `json:"ceil2Nby3Block,omitempty"` // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
+	Epoch            uint64   `json:"epoch"`                      // Epoch length to reset votes and checkpoint
+	ProposerPolicy   uint64   `json:"policy"`                     // The policy for proposer selection
+	Ceil2Nby3Block   *big.Int `json:"ceil2Nby3Block,omitempty"`   // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
+	KeyRotationBlock *big.Int `json:"keyRotationBlock,omitempty"` // Block from which validators may rotate their keys (nil = disabled)
+	// Max time (in seconds) from current time allowed for blocks before they're considered future blocks (nil = node setting)
+	AllowedFutureBlockTime *uint64 `json:"allowedFutureBlockTime,omitempty"`
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -428,6 +455,15 @@ func (c *ChainConfig) IsValid() error {
 		return errors.New("Genesis max code size must be between 24 and 128")
 	}
 
+	if c.DeployerAllowList != nil {
+		if c.DeployerAllowList.Block == nil {
+			return errors.New("Genesis deployer allow-list must have an activation block")
+		}
+		if (len(c.DeployerAllowList.Deployers) == 0) == (c.DeployerAllowList.Registry == nil) {
+			return errors.New("Genesis deployer allow-list must have either deployers or a registry")
+		}
+	}
+
 	return nil
 }
 
@@ -614,6 +650,25 @@ func (c *ChainConfig) IsPrivacyEnhancementsEnabled(num *big.Int) bool {
 	return isForked(c.PrivacyEnhancementsBlock, num)
 }
 
+// IsPrivatePayloadHashEnforced returns whether num is either equal to the
+// PrivatePayloadHashBlock fork block or greater.
+func (c *ChainConfig) IsPrivatePayloadHashEnforced(num *big.Int) bool {
+	return isForked(c.PrivatePayloadHashBlock, num)
+}
+
+func (c *ChainConfig) deployerAllowListBlock() *big.Int {
+	if c.DeployerAllowList == nil {
+		return nil
+	}
+	return c.DeployerAllowList.Block
+}
+
+// IsDeployerAllowListEnabled returns whether num is either equal to the block
+// of the deployer allow-list or greater.
+func (c *ChainConfig) IsDeployerAllowListEnabled(num *big.Int) bool {
+	return c.DeployerAllowList != nil && isForked(c.DeployerAllowList.Block, num)
+}
+
 // /Quorum
 
 // CheckCompatible checks whether scheduled fork transitions have been imported
@@ -737,6 +792,9 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int, isQuor
 	if c.Istanbul != nil && newcfg.Istanbul != nil && isForkIncompatible(c.Istanbul.Ceil2Nby3Block, newcfg.Istanbul.Ceil2Nby3Block, head) {
 		return newCompatError("Ceil 2N/3 fork block", c.Istanbul.Ceil2Nby3Block, newcfg.Istanbul.Ceil2Nby3Block)
 	}
+	if c.Istanbul != nil && newcfg.Istanbul != nil && isForkIncompatible(c.Istanbul.KeyRotationBlock, newcfg.Istanbul.KeyRotationBlock, head) {
+		return newCompatError("istanbul key rotation fork block", c.Istanbul.KeyRotationBlock, newcfg.Istanbul.KeyRotationBlock)
+	}
 	if isForkIncompatible(c.QIP714Block, newcfg.QIP714Block, head) {
 		return newCompatError("permissions fork block", c.QIP714Block, newcfg.QIP714Block)
 	}
@@ -746,6 +804,12 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int, isQuor
 	if isForkIncompatible(c.PrivacyEnhancementsBlock, newcfg.PrivacyEnhancementsBlock, head) {
 		return newCompatError("Privacy Enhancements fork block", c.PrivacyEnhancementsBlock, newcfg.PrivacyEnhancementsBlock)
 	}
+	if isForkIncompatible(c.PrivatePayloadHashBlock, newcfg.PrivatePayloadHashBlock, head) {
+		return newCompatError("private payload hash fork block", c.PrivatePayloadHashBlock, newcfg.PrivatePayloadHashBlock)
+	}
+	if isForkIncompatible(c.deployerAllowListBlock(), newcfg.deployerAllowListBlock(), head) {
+		return newCompatError("deployer allow-list fork block", c.deployerAllowListBlock(), newcfg.deployerAllowListBlock())
+	}
 	return nil
 }
 
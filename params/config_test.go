@@ -192,6 +192,34 @@ func TestCheckCompatible(t *testing.T) {
 			head:    4,
 			wantErr: nil,
 		},
+		{
+			stored: &ChainConfig{PrivatePayloadHashBlock: big.NewInt(10)},
+			new:    &ChainConfig{},
+			head:   30,
+			wantErr: &ConfigCompatError{
+				What:         "private payload hash fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    nil,
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{},
+			new:     &ChainConfig{PrivatePayloadHashBlock: big.NewInt(20)},
+			head:    4,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{DeployerAllowList: &DeployerAllowListConfig{Block: big.NewInt(10)}},
+			new:    &ChainConfig{DeployerAllowList: &DeployerAllowListConfig{Block: big.NewInt(20)}},
+			head:   30,
+			wantErr: &ConfigCompatError{
+				What:         "deployer allow-list fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
 		{
 			stored: &ChainConfig{QIP714Block: big.NewInt(10)},
 			new:    &ChainConfig{QIP714Block: big.NewInt(20)},
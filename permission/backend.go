@@ -36,6 +36,7 @@ type PermissionCtrl struct {
 	errorChan          chan error      // channel to capture error when starting aysnc
 	networkInitialized bool
 	controlService     ptype.ControlService
+	txSigner           bind.SignerFn // signs the transactions in place of the wallets of the accounts if set
 }
 
 var permissionService *PermissionCtrl
@@ -279,6 +280,9 @@ func (p *PermissionCtrl) getTxParams(txa ethapi.SendTxArgs) (*bind.TransactOpts,
 		transactOpts.GasLimit = uint64(*txa.Gas)
 	}
 	transactOpts.From = fromAcct.Address
+	if p.txSigner != nil {
+		transactOpts.Signer = p.txSigner
+	}
 
 	return transactOpts, nil
 }
@@ -0,0 +1,637 @@
+package permission
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	pcore "github.com/ethereum/go-ethereum/permission/core"
+	ptype "github.com/ethereum/go-ethereum/permission/core/types"
+)
+
+// Quorum
+//
+// The permission model is exported as a single document listing the orgs,
+// roles, nodes and accounts of the contracts. Importing a document plans the
+// contract calls adding what the contracts lack and simulates them, failing
+// unless the simulated permissions are the ones of the document; the calls are
+// then sent, unless dry running. The permissions already in the contracts are
+// never changed: a document differing from the contracts on any of them is a
+// conflict, and importing the current model plans no call.
+//
+// The network admin importing the model sends the calls of the network admins,
+// adding and approving the top level orgs, assigning and approving the admin
+// roles and changing the status of the orgs, the approvals needing it to be the
+// sole voter. The calls scoped to an org are sent by an active admin account of
+// its ultimate parent in the document, which must be unlocked on the node.
+
+// modelCallTimeout bounds the wait for a call of an import to be mined.
+const modelCallTimeout = 5 * time.Minute
+
+// PermissionModel is the permission model of the network, as exported and
+// imported. The sub-org lists, levels and ultimate parents of the orgs are
+// derived from their full ids when imported.
+type PermissionModel struct {
+	Orgs     []pcore.OrgInfo     `json:"orgs"`
+	Roles    []pcore.RoleInfo    `json:"roles"`
+	Nodes    []pcore.NodeInfo    `json:"nodes"`
+	Accounts []pcore.AccountInfo `json:"accounts"`
+}
+
+// ModelCall is a permission action of an import, named and taking its
+// arguments the way the simulated ones do.
+type ModelCall struct {
+	Action string         `json:"action"`
+	From   common.Address `json:"from"`
+	Args   SimulationArgs `json:"args"`
+}
+
+// ImportResult is the calls of an import, in the order they are sent.
+type ImportResult struct {
+	Calls []ModelCall `json:"calls"`
+	Sent  bool        `json:"sent"`
+}
+
+// modelServices are the contract services the calls of an import are sent with.
+type modelServices struct {
+	org     ptype.OrgService
+	role    ptype.RoleService
+	node    ptype.NodeService
+	account ptype.AccountService
+}
+
+// modelActions are the permission actions of the imports, by the name of their
+// API method.
+var modelActions = map[string]func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error){
+	"addOrg": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.org.AddOrg(args)
+	},
+	"approveOrg": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.org.ApproveOrg(args)
+	},
+	"addSubOrg": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.org.AddSubOrg(args)
+	},
+	"updateOrgStatus": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.org.UpdateOrgStatus(args)
+	},
+	"approveOrgStatus": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.org.ApproveOrgStatus(args)
+	},
+	"addNewRole": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.role.AddNewRole(args)
+	},
+	"removeRole": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.role.RemoveRole(args)
+	},
+	"addNode": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.node.AddNode(args)
+	},
+	"updateNodeStatus": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.node.UpdateNodeStatus(args)
+	},
+	"assignAdminRole": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.account.AssignAdminRole(args)
+	},
+	"approveAdminRole": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.account.ApproveAdminRole(args)
+	},
+	"addAccountToOrg": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.account.AssignAccountRole(args)
+	},
+	"updateAccountStatus": func(s *modelServices, args ptype.TxArgs) (*types.Transaction, error) {
+		return s.account.UpdateAccountStatus(args)
+	},
+}
+
+// waitModelCall waits for the transaction of a call to be mined, the calls of
+// the next sender depending on its effect. Replaced in the tests.
+var waitModelCall = func(p *PermissionCtrl, tx *types.Transaction) error {
+	backend, ok := p.ethClnt.(bind.DeployBackend)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), modelCallTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, backend, tx)
+	if err != nil {
+		return err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return fmt.Errorf("transaction %s failed", tx.Hash().Hex())
+	}
+	return nil
+}
+
+// ExportModel returns the permission model of the contracts.
+func (q *QuorumControlsAPI) ExportModel() (*PermissionModel, error) {
+	perms, err := readPermissions(q.permCtrl.contract)
+	if err != nil {
+		return nil, err
+	}
+	return perms.model(), nil
+}
+
+// ImportModel sends the calls adding the permissions of the model which the
+// contracts lack, or only returns them when dry running. The import fails if
+// the model is invalid, conflicts with the contracts or is not reproduced by
+// the calls when simulated.
+func (q *QuorumControlsAPI) ImportModel(model PermissionModel, txa ethapi.SendTxArgs, dryRun *bool) (*ImportResult, error) {
+	want, err := modelPermissions(model)
+	if err != nil {
+		return nil, err
+	}
+	current, err := readPermissions(q.permCtrl.contract)
+	if err != nil {
+		return nil, err
+	}
+	if conflicts := permissionDiffs(current.entities(), want.entities(), false); len(conflicts) > 0 {
+		return nil, fmt.Errorf("permission model conflicts with the contracts: %s", strings.Join(conflicts, "; "))
+	}
+	calls, err := q.planImport(current, want, txa.From)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{Calls: calls}
+	if len(calls) == 0 {
+		return result, nil
+	}
+	if err := q.simulateImport(calls, want); err != nil {
+		return nil, err
+	}
+	if dryRun != nil && *dryRun {
+		return result, nil
+	}
+	if err := q.sendImport(calls, txa); err != nil {
+		return nil, err
+	}
+	result.Sent = true
+	return result, nil
+}
+
+// planImport returns the calls adding the permissions of the model missing
+// from the current ones, each kind of entity being added once its orgs and
+// roles are, and the statuses changed last.
+func (q *QuorumControlsAPI) planImport(current, want *permissions, nwAdmin common.Address) ([]ModelCall, error) {
+	config := q.permCtrl.permConfig
+	if a, ok := current.accounts[nwAdmin]; !ok || a.RoleId != config.NwAdminRole || a.Status != pcore.AcctActive {
+		return nil, fmt.Errorf("account %s is not an active network admin", nwAdmin.Hex())
+	}
+	var calls []ModelCall
+	plan := func(action string, from common.Address, args SimulationArgs) {
+		calls = append(calls, ModelCall{Action: action, From: from, Args: args})
+	}
+	admin := func(orgId string) (common.Address, error) {
+		return want.adminOf(orgId, nwAdmin)
+	}
+
+	// the orgs, top level ones first, adding a node along and their admin for
+	// the top level ones
+	var (
+		orgs     = want.missingOrgs(current)
+		nodes    = make(map[string]bool)
+		roles    = make(map[pcore.RoleKey]bool)
+		accounts = make(map[common.Address]bool)
+	)
+	for _, o := range orgs {
+		url := want.firstNode(o.FullOrgId)
+		if url != "" {
+			nodes[url] = true
+		}
+		if o.ParentOrgId == "" {
+			if url == "" {
+				return nil, fmt.Errorf("org %s: no node to add it with", o.FullOrgId)
+			}
+			acct, ok := want.roleAccount(o.FullOrgId, config.OrgAdminRole)
+			if !ok {
+				return nil, fmt.Errorf("org %s: no %s account to add it with", o.FullOrgId, config.OrgAdminRole)
+			}
+			args := SimulationArgs{OrgId: o.OrgId, Url: url, Account: acct}
+			plan("addOrg", nwAdmin, args)
+			plan("approveOrg", nwAdmin, args)
+			roles[pcore.RoleKey{OrgId: o.FullOrgId, RoleId: config.OrgAdminRole}] = true
+			accounts[acct] = true
+			continue
+		}
+		from, err := admin(o.FullOrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("addSubOrg", from, SimulationArgs{ParentOrgId: o.ParentOrgId, OrgId: o.OrgId, Url: url})
+	}
+
+	for _, r := range want.sortedRoles() {
+		key := pcore.RoleKey{OrgId: r.OrgId, RoleId: r.RoleId}
+		if _, ok := current.roles[key]; ok || roles[key] {
+			continue
+		}
+		from, err := admin(r.OrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("addNewRole", from, SimulationArgs{OrgId: r.OrgId, RoleId: r.RoleId, Access: uint8(r.Access), IsVoter: r.IsVoter, IsAdmin: r.IsAdmin})
+	}
+	for _, n := range want.sortedNodes() {
+		if _, ok := current.nodes[pcore.NodeKey{OrgId: n.OrgId, Url: n.Url}]; ok || nodes[n.Url] {
+			continue
+		}
+		from, err := admin(n.OrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("addNode", from, SimulationArgs{OrgId: n.OrgId, Url: n.Url})
+	}
+	for _, a := range want.sortedAccounts() {
+		if _, ok := current.accounts[a.AcctId]; ok || accounts[a.AcctId] {
+			continue
+		}
+		if a.RoleId == config.OrgAdminRole || a.RoleId == config.NwAdminRole {
+			plan("assignAdminRole", nwAdmin, SimulationArgs{OrgId: a.OrgId, Account: a.AcctId, RoleId: a.RoleId})
+			plan("approveAdminRole", nwAdmin, SimulationArgs{OrgId: a.OrgId, Account: a.AcctId})
+			continue
+		}
+		from, err := admin(a.OrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("addAccountToOrg", from, SimulationArgs{Account: a.AcctId, OrgId: a.OrgId, RoleId: a.RoleId})
+	}
+
+	// the statuses of the entities added, the orgs last as their suspension
+	// prevents the other changes
+	for _, r := range want.sortedRoles() {
+		if _, ok := current.roles[pcore.RoleKey{OrgId: r.OrgId, RoleId: r.RoleId}]; ok || r.Active {
+			continue
+		}
+		from, err := admin(r.OrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("removeRole", from, SimulationArgs{OrgId: r.OrgId, RoleId: r.RoleId})
+	}
+	for _, a := range want.sortedAccounts() {
+		if _, ok := current.accounts[a.AcctId]; ok || a.Status != pcore.AcctSuspended {
+			continue
+		}
+		from, err := admin(a.OrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("updateAccountStatus", from, SimulationArgs{OrgId: a.OrgId, Account: a.AcctId, Action: uint8(SuspendAccount)})
+	}
+	for _, n := range want.sortedNodes() {
+		if _, ok := current.nodes[pcore.NodeKey{OrgId: n.OrgId, Url: n.Url}]; ok || n.Status != pcore.NodeDeactivated {
+			continue
+		}
+		from, err := admin(n.OrgId)
+		if err != nil {
+			return nil, err
+		}
+		plan("updateNodeStatus", from, SimulationArgs{OrgId: n.OrgId, Url: n.Url, Action: uint8(SuspendNode)})
+	}
+	for _, o := range orgs {
+		if o.Status != pcore.OrgSuspended {
+			continue
+		}
+		args := SimulationArgs{OrgId: o.FullOrgId, Action: uint8(SuspendOrg)}
+		plan("updateOrgStatus", nwAdmin, args)
+		plan("approveOrgStatus", nwAdmin, args)
+	}
+	return calls, nil
+}
+
+// simulateImport runs the calls against a copy of the state at the head of the
+// chain and fails unless the resulting permissions are the wanted ones.
+func (q *QuorumControlsAPI) simulateImport(calls []ModelCall, want *permissions) error {
+	p := q.permCtrl
+	backend, err := newSimulationBackend(simulationChainOf(p), common.Address{})
+	if err != nil {
+		return err
+	}
+	contract := NewPermissionContractService(backend, p.IsV2Permission(), p.key, p.permConfig, p.isRaft, p.useDns)
+	if err := contract.BindContracts(); err != nil {
+		return err
+	}
+	contractBackend := p.getContractBackend()
+	contractBackend.EthClnt = backend
+	for i, call := range calls {
+		opts := &bind.TransactOpts{From: call.From, Signer: backend.sign, GasLimit: defaultGasLimit, GasPrice: defaultGasPrice}
+		if _, err := p.sendModelCall(call, opts, contractBackend); err != nil {
+			return fmt.Errorf("permission model import: simulated call %d (%s) failed: %v", i, call.Action, err)
+		}
+	}
+	have, err := readPermissions(contract)
+	if err != nil {
+		return err
+	}
+	if diffs := permissionDiffs(have.entities(), want.entities(), true); len(diffs) > 0 {
+		return fmt.Errorf("permission model import does not reproduce the model: %s", strings.Join(diffs, "; "))
+	}
+	return nil
+}
+
+// sendImport sends the calls, waiting for the last transaction of a sender to
+// be mined before sending the calls of the next one.
+func (q *QuorumControlsAPI) sendImport(calls []ModelCall, txa ethapi.SendTxArgs) error {
+	p := q.permCtrl
+	var last *types.Transaction
+	for i, call := range calls {
+		if last != nil && call.From != calls[i-1].From {
+			if err := waitModelCall(p, last); err != nil {
+				return fmt.Errorf("permission model import: call %d (%s) not mined, the previous calls were sent: %v", i-1, calls[i-1].Action, err)
+			}
+		}
+		callTxa := txa
+		callTxa.From = call.From
+		opts, err := p.getTxParams(callTxa)
+		if err != nil {
+			return fmt.Errorf("permission model import: call %d (%s) from %s: %v", i, call.Action, call.From.Hex(), err)
+		}
+		tx, err := p.sendModelCall(call, opts, p.getContractBackend())
+		if err != nil {
+			log.Error("Failed to execute permission action", "action", call.Action, "err", err)
+			return fmt.Errorf("permission model import: call %d (%s) failed, the previous calls were sent: %v", i, call.Action, err)
+		}
+		log.Debug("executed permission action", "action", call.Action, "tx", tx)
+		last = tx
+	}
+	return nil
+}
+
+// sendModelCall sends the call of an import with the transactor through the
+// contract backend.
+func (p *PermissionCtrl) sendModelCall(call ModelCall, opts *bind.TransactOpts, contractBackend ptype.ContractBackend) (*types.Transaction, error) {
+	run, ok := modelActions[call.Action]
+	if !ok {
+		return nil, fmt.Errorf("permission action %q cannot be imported", call.Action)
+	}
+	var (
+		s   modelServices
+		err error
+	)
+	if s.org, err = p.backend.GetOrgService(opts, contractBackend); err != nil {
+		return nil, err
+	}
+	if s.role, err = p.backend.GetRoleService(opts, contractBackend); err != nil {
+		return nil, err
+	}
+	if s.node, err = p.backend.GetNodeService(opts, contractBackend); err != nil {
+		return nil, err
+	}
+	if s.account, err = p.backend.GetAccountService(opts, contractBackend); err != nil {
+		return nil, err
+	}
+	a := call.Args
+	return run(&s, ptype.TxArgs{OrgId: a.OrgId, POrgId: a.ParentOrgId, Url: a.Url, RoleId: a.RoleId, IsVoter: a.IsVoter,
+		IsAdmin: a.IsAdmin, AcctId: a.Account, AccessType: a.Access, Action: a.Action})
+}
+
+// modelPermissions validates the model and returns its permissions, deriving
+// the sub-org lists, levels and ultimate parents of the orgs.
+func modelPermissions(model PermissionModel) (*permissions, error) {
+	perms := &permissions{
+		orgs:     make(map[string]pcore.OrgInfo),
+		nodes:    make(map[pcore.NodeKey]pcore.NodeInfo),
+		roles:    make(map[pcore.RoleKey]pcore.RoleInfo),
+		accounts: make(map[common.Address]pcore.AccountInfo),
+	}
+	for _, o := range model.Orgs {
+		if o.OrgId == "" || !isStringAlphaNumeric(o.OrgId) {
+			return nil, fmt.Errorf("invalid permission model: org id %q is not alphanumeric", o.OrgId)
+		}
+		fullOrgId := o.OrgId
+		if o.ParentOrgId != "" {
+			fullOrgId = o.ParentOrgId + "." + o.OrgId
+		}
+		if o.FullOrgId != fullOrgId {
+			return nil, fmt.Errorf("invalid permission model: org %s has full id %s", fullOrgId, o.FullOrgId)
+		}
+		if _, ok := perms.orgs[fullOrgId]; ok {
+			return nil, fmt.Errorf("invalid permission model: org %s is listed twice", fullOrgId)
+		}
+		if o.Status != pcore.OrgApproved && o.Status != pcore.OrgSuspended {
+			return nil, fmt.Errorf("invalid permission model: org %s has status %d, neither approved nor suspended", fullOrgId, o.Status)
+		}
+		ids := strings.Split(fullOrgId, ".")
+		perms.orgs[fullOrgId] = pcore.OrgInfo{OrgId: o.OrgId, FullOrgId: fullOrgId, ParentOrgId: o.ParentOrgId, UltimateParent: ids[0],
+			Level: big.NewInt(int64(len(ids))), SubOrgList: []string{}, Status: o.Status}
+	}
+	for _, id := range perms.sortedOrgIds() {
+		o := perms.orgs[id]
+		if o.ParentOrgId == "" {
+			continue
+		}
+		parent, ok := perms.orgs[o.ParentOrgId]
+		if !ok {
+			return nil, fmt.Errorf("invalid permission model: org %s has unknown parent %s", id, o.ParentOrgId)
+		}
+		parent.SubOrgList = append(parent.SubOrgList, id)
+		perms.orgs[o.ParentOrgId] = parent
+	}
+
+	for _, r := range model.Roles {
+		key := pcore.RoleKey{OrgId: r.OrgId, RoleId: r.RoleId}
+		if _, ok := perms.orgs[r.OrgId]; !ok {
+			return nil, fmt.Errorf("invalid permission model: role %s has unknown org %s", r.RoleId, r.OrgId)
+		}
+		if _, ok := perms.roles[key]; ok {
+			return nil, fmt.Errorf("invalid permission model: role %s of org %s is listed twice", r.RoleId, r.OrgId)
+		}
+		perms.roles[key] = r
+	}
+	urls := make(map[string]bool)
+	for _, n := range model.Nodes {
+		if _, ok := perms.orgs[n.OrgId]; !ok {
+			return nil, fmt.Errorf("invalid permission model: node %s has unknown org %s", n.Url, n.OrgId)
+		}
+		if urls[n.Url] {
+			return nil, fmt.Errorf("invalid permission model: node %s is listed twice", n.Url)
+		}
+		if n.Status != pcore.NodeApproved && n.Status != pcore.NodeDeactivated {
+			return nil, fmt.Errorf("invalid permission model: node %s has status %d, neither approved nor deactivated", n.Url, n.Status)
+		}
+		urls[n.Url] = true
+		perms.nodes[pcore.NodeKey{OrgId: n.OrgId, Url: n.Url}] = n
+	}
+	for _, a := range model.Accounts {
+		o, ok := perms.orgs[a.OrgId]
+		if !ok {
+			return nil, fmt.Errorf("invalid permission model: account %s has unknown org %s", a.AcctId.Hex(), a.OrgId)
+		}
+		_, inOrg := perms.roles[pcore.RoleKey{OrgId: a.OrgId, RoleId: a.RoleId}]
+		_, inUltimateParent := perms.roles[pcore.RoleKey{OrgId: o.UltimateParent, RoleId: a.RoleId}]
+		if !inOrg && !inUltimateParent {
+			return nil, fmt.Errorf("invalid permission model: account %s has unknown role %s", a.AcctId.Hex(), a.RoleId)
+		}
+		if _, ok := perms.accounts[a.AcctId]; ok {
+			return nil, fmt.Errorf("invalid permission model: account %s is listed twice", a.AcctId.Hex())
+		}
+		if a.Status != pcore.AcctActive && a.Status != pcore.AcctSuspended {
+			return nil, fmt.Errorf("invalid permission model: account %s has status %d, neither active nor suspended", a.AcctId.Hex(), a.Status)
+		}
+		perms.accounts[a.AcctId] = a
+	}
+	return perms, nil
+}
+
+// model returns the permissions as a model, in the order of their keys.
+func (perms *permissions) model() *PermissionModel {
+	model := &PermissionModel{
+		Orgs:     []pcore.OrgInfo{},
+		Roles:    perms.sortedRoles(),
+		Nodes:    perms.sortedNodes(),
+		Accounts: perms.sortedAccounts(),
+	}
+	for _, id := range perms.sortedOrgIds() {
+		model.Orgs = append(model.Orgs, perms.orgs[id])
+	}
+	return model
+}
+
+// missingOrgs returns the orgs missing from the current permissions, parents
+// first.
+func (perms *permissions) missingOrgs(current *permissions) []pcore.OrgInfo {
+	var orgs []pcore.OrgInfo
+	for _, id := range perms.sortedOrgIds() {
+		if _, ok := current.orgs[id]; !ok {
+			orgs = append(orgs, perms.orgs[id])
+		}
+	}
+	sort.SliceStable(orgs, func(i, j int) bool {
+		return orgs[i].Level.Cmp(orgs[j].Level) < 0
+	})
+	return orgs
+}
+
+// firstNode returns the url of the first node of the org, empty if it has none.
+func (perms *permissions) firstNode(orgId string) string {
+	for _, n := range perms.sortedNodes() {
+		if n.OrgId == orgId {
+			return n.Url
+		}
+	}
+	return ""
+}
+
+// roleAccount returns the first active account of the org having the role.
+func (perms *permissions) roleAccount(orgId, roleId string) (common.Address, bool) {
+	for _, a := range perms.sortedAccounts() {
+		if a.OrgId == orgId && a.RoleId == roleId && a.Status == pcore.AcctActive {
+			return a.AcctId, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// adminOf returns the admin account sending the calls scoped to the org, an
+// active admin of its ultimate parent, the preferred account if it is one.
+func (perms *permissions) adminOf(orgId string, preferred common.Address) (common.Address, error) {
+	ultimateParent := strings.Split(orgId, ".")[0]
+	var admins []common.Address
+	for _, a := range perms.sortedAccounts() {
+		if a.OrgId == ultimateParent && isActiveAdmin(a) {
+			if a.AcctId == preferred {
+				return preferred, nil
+			}
+			admins = append(admins, a.AcctId)
+		}
+	}
+	if len(admins) == 0 {
+		return common.Address{}, fmt.Errorf("org %s: no active admin account of %s to send its calls", orgId, ultimateParent)
+	}
+	return admins[0], nil
+}
+
+// entities describes the orgs, roles, nodes and accounts by their keys, the
+// entities being the same if their descriptions are.
+func (perms *permissions) entities() map[string]string {
+	entities := make(map[string]string)
+	for id, o := range perms.orgs {
+		entities["org "+id] = fmt.Sprintf("parent %q, ultimate parent %s, level %v, status %d", o.ParentOrgId, o.UltimateParent, o.Level, o.Status)
+	}
+	for key, r := range perms.roles {
+		entities["role "+key.OrgId+"/"+key.RoleId] = fmt.Sprintf("access %d, voter %t, admin %t, active %t", r.Access, r.IsVoter, r.IsAdmin, r.Active)
+	}
+	for _, n := range perms.nodes {
+		entities["node "+n.Url] = fmt.Sprintf("org %s, status %d", n.OrgId, n.Status)
+	}
+	for acct, a := range perms.accounts {
+		entities["account "+acct.Hex()] = fmt.Sprintf("org %s, role %s, org admin %t, status %d", a.OrgId, a.RoleId, a.IsOrgAdmin, a.Status)
+	}
+	return entities
+}
+
+// permissionDiffs lists the entities differing between the permissions, in
+// the order of their keys, and those missing from them if asked to.
+func permissionDiffs(have, want map[string]string, missing bool) []string {
+	var diffs []string
+	for key, h := range have {
+		w, ok := want[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s is not in the model", key))
+		case h != w:
+			diffs = append(diffs, fmt.Sprintf("%s has %s in the contracts, %s in the model", key, h, w))
+		}
+	}
+	if missing {
+		for key := range want {
+			if _, ok := have[key]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s is not in the contracts", key))
+			}
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func (perms *permissions) sortedOrgIds() []string {
+	ids := make([]string, 0, len(perms.orgs))
+	for id := range perms.orgs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (perms *permissions) sortedRoles() []pcore.RoleInfo {
+	roles := make([]pcore.RoleInfo, 0, len(perms.roles))
+	for _, r := range perms.roles {
+		roles = append(roles, r)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].OrgId < roles[j].OrgId || (roles[i].OrgId == roles[j].OrgId && roles[i].RoleId < roles[j].RoleId)
+	})
+	return roles
+}
+
+func (perms *permissions) sortedNodes() []pcore.NodeInfo {
+	nodes := make([]pcore.NodeInfo, 0, len(perms.nodes))
+	for _, n := range perms.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].OrgId < nodes[j].OrgId || (nodes[i].OrgId == nodes[j].OrgId && nodes[i].Url < nodes[j].Url)
+	})
+	return nodes
+}
+
+func (perms *permissions) sortedAccounts() []pcore.AccountInfo {
+	accounts := make([]pcore.AccountInfo, 0, len(perms.accounts))
+	for _, a := range perms.accounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].AcctId[:], accounts[j].AcctId[:]) < 0
+	})
+	return accounts
+}
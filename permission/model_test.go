@@ -0,0 +1,124 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	pcore "github.com/ethereum/go-ethereum/permission/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func modelActionsOf(calls []ModelCall) []string {
+	actions := make([]string, 0, len(calls))
+	for _, call := range calls {
+		actions = append(actions, call.Action)
+	}
+	return actions
+}
+
+func TestQuorumControlsAPI_ImportModel(t *testing.T) {
+	testObject := typicalQuorumControlsAPI(t)
+	defer func(f func(*PermissionCtrl) simulationChain) { simulationChainOf = f }(simulationChainOf)
+	simulationChainOf = func(*PermissionCtrl) simulationChain {
+		sb := contrBackend.(*backends.SimulatedBackend)
+		return &pendingChain{BlockChain: sb.Blockchain(), backend: sb}
+	}
+	defer func(f func(*PermissionCtrl, *types.Transaction) error) { waitModelCall = f }(waitModelCall)
+	waitModelCall = func(*PermissionCtrl, *types.Transaction) error { return nil }
+
+	ksbackend := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	orgAdminKey, _ := crypto.GenerateKey()
+	orgAdmin, err := ksbackend.ImportECDSA(orgAdminKey, "foo")
+	require.NoError(t, err)
+	require.NoError(t, ksbackend.TimedUnlock(orgAdmin, "foo", 0))
+	txa := ethapi.SendTxArgs{From: guardianAddress}
+
+	model, err := testObject.ExportModel()
+	require.NoError(t, err)
+	require.Len(t, model.Orgs, 1)
+	result, err := testObject.ImportModel(*model, txa, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Calls, "current model")
+
+	subOrg := arbitraryOrgToAdd + "." + arbitrarySubOrg
+	member := common.Address{0x01}
+	model.Orgs = append(model.Orgs,
+		pcore.OrgInfo{OrgId: arbitraryOrgToAdd, FullOrgId: arbitraryOrgToAdd, Status: pcore.OrgApproved},
+		pcore.OrgInfo{OrgId: arbitrarySubOrg, FullOrgId: subOrg, ParentOrgId: arbitraryOrgToAdd, Status: pcore.OrgApproved})
+	model.Roles = append(model.Roles,
+		pcore.RoleInfo{OrgId: arbitraryOrgToAdd, RoleId: arbitraryOrgAdminRole, IsVoter: true, IsAdmin: true, Access: pcore.FullAccess, Active: true},
+		pcore.RoleInfo{OrgId: subOrg, RoleId: arbitrartNewRole1, Access: pcore.Transact, Active: true})
+	model.Nodes = append(model.Nodes,
+		pcore.NodeInfo{OrgId: arbitraryOrgToAdd, Url: arbitraryNode1, Status: pcore.NodeApproved},
+		pcore.NodeInfo{OrgId: subOrg, Url: arbitraryNode2, Status: pcore.NodeDeactivated})
+	model.Accounts = append(model.Accounts,
+		pcore.AccountInfo{OrgId: arbitraryOrgToAdd, RoleId: arbitraryOrgAdminRole, AcctId: orgAdmin.Address, IsOrgAdmin: true, Status: pcore.AcctActive},
+		pcore.AccountInfo{OrgId: subOrg, RoleId: arbitrartNewRole1, AcctId: member, Status: pcore.AcctActive})
+
+	dryRun := true
+	result, err = testObject.ImportModel(*model, txa, &dryRun)
+	require.NoError(t, err)
+	assert.False(t, result.Sent)
+	assert.Equal(t, []string{"addOrg", "approveOrg", "addSubOrg", "addNewRole", "addAccountToOrg", "updateNodeStatus"}, modelActionsOf(result.Calls))
+	assert.Equal(t, orgAdmin.Address, result.Calls[2].From, "sent by the org admin")
+	exported, err := testObject.ExportModel()
+	require.NoError(t, err)
+	assert.Len(t, exported.Orgs, 1, "nothing sent")
+
+	result, err = testObject.ImportModel(*model, txa, nil)
+	require.NoError(t, err)
+	assert.True(t, result.Sent)
+	want, err := modelPermissions(*model)
+	require.NoError(t, err)
+	exported, err = testObject.ExportModel()
+	require.NoError(t, err)
+	have, err := modelPermissions(*exported)
+	require.NoError(t, err)
+	assert.Equal(t, want.entities(), have.entities(), "round trip")
+
+	result, err = testObject.ImportModel(*exported, txa, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Calls, "imported again")
+
+	model.Nodes[len(model.Nodes)-1].Status = pcore.NodeApproved
+	_, err = testObject.ImportModel(*model, txa, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "node "+arbitraryNode2)
+}
+
+func TestModelPermissions_Invalid(t *testing.T) {
+	valid := PermissionModel{
+		Orgs: []pcore.OrgInfo{
+			{OrgId: arbitraryOrgToAdd, FullOrgId: arbitraryOrgToAdd, Status: pcore.OrgApproved},
+			{OrgId: arbitrarySubOrg, FullOrgId: arbitraryOrgToAdd + "." + arbitrarySubOrg, ParentOrgId: arbitraryOrgToAdd, Status: pcore.OrgApproved},
+		},
+		Roles:    []pcore.RoleInfo{{OrgId: arbitraryOrgToAdd, RoleId: arbitraryOrgAdminRole, Active: true}},
+		Nodes:    []pcore.NodeInfo{{OrgId: arbitraryOrgToAdd, Url: arbitraryNode1, Status: pcore.NodeApproved}},
+		Accounts: []pcore.AccountInfo{{OrgId: arbitraryOrgToAdd + "." + arbitrarySubOrg, RoleId: arbitraryOrgAdminRole, AcctId: common.Address{0x01}, Status: pcore.AcctActive}},
+	}
+	perms, err := modelPermissions(valid)
+	require.NoError(t, err)
+	assert.Equal(t, []string{arbitraryOrgToAdd + "." + arbitrarySubOrg}, perms.orgs[arbitraryOrgToAdd].SubOrgList)
+	assert.Equal(t, int64(2), perms.orgs[arbitraryOrgToAdd+"."+arbitrarySubOrg].Level.Int64())
+
+	for name, invalidate := range map[string]func(m *PermissionModel){
+		"full id":           func(m *PermissionModel) { m.Orgs[1].FullOrgId = arbitrarySubOrg },
+		"unknown parent":    func(m *PermissionModel) { m.Orgs[1].ParentOrgId, m.Orgs[1].FullOrgId = "ORG2", "ORG2."+arbitrarySubOrg },
+		"pending org":       func(m *PermissionModel) { m.Orgs[0].Status = pcore.OrgPendingApproval },
+		"unknown role":      func(m *PermissionModel) { m.Accounts[0].RoleId = arbitrartNewRole1 },
+		"node listed twice": func(m *PermissionModel) { m.Nodes = append(m.Nodes, m.Nodes[0]) },
+	} {
+		m := valid
+		m.Orgs = append([]pcore.OrgInfo{}, valid.Orgs...)
+		m.Accounts = append([]pcore.AccountInfo{}, valid.Accounts...)
+		invalidate(&m)
+		_, err := modelPermissions(m)
+		assert.Error(t, err, name)
+	}
+}
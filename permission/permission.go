@@ -220,11 +220,31 @@ func (p *PermissionCtrl) bootupNetwork() error {
 
 // populates the account access details from contract into cache
 func (p *PermissionCtrl) populateAccountsFromContract() error {
-	if numberOfRoles, err := p.contract.GetNumberOfAccounts(); err == nil {
+	return populateAccounts(p.contract, pcore.AcctInfoMap)
+}
+
+// populates the role details from contract into cache
+func (p *PermissionCtrl) populateRolesFromContract() error {
+	return populateRoles(p.contract, pcore.RoleInfoMap)
+}
+
+// populates the Node details from contract into cache
+func (p *PermissionCtrl) populateNodesFromContract() error {
+	return populateNodes(p.contract, pcore.NodeInfoMap)
+}
+
+// populates the org details from contract into cache
+func (p *PermissionCtrl) populateOrgsFromContract() error {
+	return populateOrgs(p.contract, pcore.OrgInfoMap)
+}
+
+// populates the account access details from the contract into the given cache
+func populateAccounts(contract ptype.InitService, accounts *pcore.AcctCache) error {
+	if numberOfRoles, err := contract.GetNumberOfAccounts(); err == nil {
 		iOrgNum := numberOfRoles.Uint64()
 		for k := uint64(0); k < iOrgNum; k++ {
-			if addr, org, role, status, orgAdmin, err := p.contract.GetAccountDetailsFromIndex(big.NewInt(int64(k))); err == nil {
-				pcore.AcctInfoMap.UpsertAccount(org, role, addr, orgAdmin, pcore.AcctStatus(int(status.Int64())))
+			if addr, org, role, status, orgAdmin, err := contract.GetAccountDetailsFromIndex(big.NewInt(int64(k))); err == nil {
+				accounts.UpsertAccount(org, role, addr, orgAdmin, pcore.AcctStatus(int(status.Int64())))
 			}
 		}
 	} else {
@@ -233,13 +253,13 @@ func (p *PermissionCtrl) populateAccountsFromContract() error {
 	return nil
 }
 
-// populates the role details from contract into cache
-func (p *PermissionCtrl) populateRolesFromContract() error {
-	if numberOfRoles, err := p.contract.GetNumberOfRoles(); err == nil {
+// populates the role details from the contract into the given cache
+func populateRoles(contract ptype.InitService, roles *pcore.RoleCache) error {
+	if numberOfRoles, err := contract.GetNumberOfRoles(); err == nil {
 		iOrgNum := numberOfRoles.Uint64()
 		for k := uint64(0); k < iOrgNum; k++ {
-			if roleStruct, err := p.contract.GetRoleDetailsFromIndex(big.NewInt(int64(k))); err == nil {
-				pcore.RoleInfoMap.UpsertRole(roleStruct.OrgId, roleStruct.RoleId, roleStruct.Voter, roleStruct.Admin, pcore.AccessType(int(roleStruct.AccessType.Int64())), roleStruct.Active)
+			if roleStruct, err := contract.GetRoleDetailsFromIndex(big.NewInt(int64(k))); err == nil {
+				roles.UpsertRole(roleStruct.OrgId, roleStruct.RoleId, roleStruct.Voter, roleStruct.Admin, pcore.AccessType(int(roleStruct.AccessType.Int64())), roleStruct.Active)
 			}
 		}
 
@@ -249,13 +269,13 @@ func (p *PermissionCtrl) populateRolesFromContract() error {
 	return nil
 }
 
-// populates the Node details from contract into cache
-func (p *PermissionCtrl) populateNodesFromContract() error {
-	if numberOfNodes, err := p.contract.GetNumberOfNodes(); err == nil {
+// populates the Node details from the contract into the given cache
+func populateNodes(contract ptype.InitService, nodes *pcore.NodeCache) error {
+	if numberOfNodes, err := contract.GetNumberOfNodes(); err == nil {
 		iOrgNum := numberOfNodes.Uint64()
 		for k := uint64(0); k < iOrgNum; k++ {
-			if orgId, url, status, err := p.contract.GetNodeDetailsFromIndex(big.NewInt(int64(k))); err == nil {
-				pcore.NodeInfoMap.UpsertNode(orgId, url, pcore.NodeStatus(int(status.Int64())))
+			if orgId, url, status, err := contract.GetNodeDetailsFromIndex(big.NewInt(int64(k))); err == nil {
+				nodes.UpsertNode(orgId, url, pcore.NodeStatus(int(status.Int64())))
 			}
 		}
 	} else {
@@ -264,14 +284,14 @@ func (p *PermissionCtrl) populateNodesFromContract() error {
 	return nil
 }
 
-// populates the org details from contract into cache
-func (p *PermissionCtrl) populateOrgsFromContract() error {
+// populates the org details from the contract into the given cache
+func populateOrgs(contract ptype.InitService, orgs *pcore.OrgCache) error {
 
-	if numberOfOrgs, err := p.contract.GetNumberOfOrgs(); err == nil {
+	if numberOfOrgs, err := contract.GetNumberOfOrgs(); err == nil {
 		iOrgNum := numberOfOrgs.Uint64()
 		for k := uint64(0); k < iOrgNum; k++ {
-			if orgId, porgId, ultParent, level, status, err := p.contract.GetOrgInfo(big.NewInt(int64(k))); err == nil {
-				pcore.OrgInfoMap.UpsertOrg(orgId, porgId, ultParent, level, pcore.OrgStatus(int(status.Int64())))
+			if orgId, porgId, ultParent, level, status, err := contract.GetOrgInfo(big.NewInt(int64(k))); err == nil {
+				orgs.UpsertOrg(orgId, porgId, ultParent, level, pcore.OrgStatus(int(status.Int64())))
 			}
 		}
 	} else {
@@ -0,0 +1,553 @@
+package permission
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	pcore "github.com/ethereum/go-ethereum/permission/core"
+	ptype "github.com/ethereum/go-ethereum/permission/core/types"
+)
+
+// Quorum
+//
+// The permission actions are simulated by running them, validations and
+// bindings included, against a contract backend applying their transactions to
+// a copy of the state at the head of the chain. The permissions are read from
+// the contracts into fresh caches before and after the action to tell its effect.
+
+var errSimulationNoLogs = errors.New("permission simulation: logs are not supported")
+
+// SimulationArgs are the arguments of the permission action to simulate, the
+// action only using those of its own API method.
+type SimulationArgs struct {
+	OrgId       string         `json:"orgId"`
+	ParentOrgId string         `json:"parentOrgId"`
+	Url         string         `json:"url"`
+	Account     common.Address `json:"account"`
+	RoleId      string         `json:"roleId"`
+	Access      uint8          `json:"access"`
+	IsVoter     bool           `json:"isVoter"`
+	IsAdmin     bool           `json:"isAdmin"`
+	Action      uint8          `json:"action"`
+}
+
+// OrgChange is an org before and after a simulated action, nil when it does not exist.
+type OrgChange struct {
+	Before *pcore.OrgInfo `json:"before"`
+	After  *pcore.OrgInfo `json:"after"`
+}
+
+// NodeChange is a node before and after a simulated action, nil when it does not exist.
+type NodeChange struct {
+	Before *pcore.NodeInfo `json:"before"`
+	After  *pcore.NodeInfo `json:"after"`
+}
+
+// RoleChange is a role before and after a simulated action, nil when it does not exist.
+type RoleChange struct {
+	Before *pcore.RoleInfo `json:"before"`
+	After  *pcore.RoleInfo `json:"after"`
+}
+
+// AccountChange is an account before and after a simulated action, nil when it does not exist.
+type AccountChange struct {
+	Before *pcore.AccountInfo `json:"before"`
+	After  *pcore.AccountInfo `json:"after"`
+}
+
+// SimulationResult is the effect of a simulated permission action, with the
+// warnings about the outcomes leaving the network hard to administer.
+type SimulationResult struct {
+	Orgs     []OrgChange     `json:"orgs"`
+	Nodes    []NodeChange    `json:"nodes"`
+	Roles    []RoleChange    `json:"roles"`
+	Accounts []AccountChange `json:"accounts"`
+	Warnings []string        `json:"warnings"`
+}
+
+// simulatedActions are the permission actions which can be simulated, by the
+// name of their API method.
+var simulatedActions = map[string]func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error){
+	"addOrg": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.AddOrg(args.OrgId, args.Url, args.Account, txa)
+	},
+	"approveOrg": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.ApproveOrg(args.OrgId, args.Url, args.Account, txa)
+	},
+	"addSubOrg": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.AddSubOrg(args.ParentOrgId, args.OrgId, args.Url, txa)
+	},
+	"updateOrgStatus": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.UpdateOrgStatus(args.OrgId, args.Action, txa)
+	},
+	"approveOrgStatus": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.ApproveOrgStatus(args.OrgId, args.Action, txa)
+	},
+	"addNode": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.AddNode(args.OrgId, args.Url, txa)
+	},
+	"updateNodeStatus": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.UpdateNodeStatus(args.OrgId, args.Url, args.Action, txa)
+	},
+	"assignAdminRole": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.AssignAdminRole(args.OrgId, args.Account, args.RoleId, txa)
+	},
+	"approveAdminRole": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.ApproveAdminRole(args.OrgId, args.Account, txa)
+	},
+	"addNewRole": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.AddNewRole(args.OrgId, args.RoleId, args.Access, args.IsVoter, args.IsAdmin, txa)
+	},
+	"removeRole": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.RemoveRole(args.OrgId, args.RoleId, txa)
+	},
+	"addAccountToOrg": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.AddAccountToOrg(args.Account, args.OrgId, args.RoleId, txa)
+	},
+	"changeAccountRole": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.ChangeAccountRole(args.Account, args.OrgId, args.RoleId, txa)
+	},
+	"updateAccountStatus": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.UpdateAccountStatus(args.OrgId, args.Account, args.Action, txa)
+	},
+	"recoverBlackListedNode": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.RecoverBlackListedNode(args.OrgId, args.Url, txa)
+	},
+	"approveBlackListedNodeRecovery": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.ApproveBlackListedNodeRecovery(args.OrgId, args.Url, txa)
+	},
+	"recoverBlackListedAccount": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.RecoverBlackListedAccount(args.OrgId, args.Account, txa)
+	},
+	"approveBlackListedAccountRecovery": func(q *QuorumControlsAPI, args SimulationArgs, txa ethapi.SendTxArgs) (string, error) {
+		return q.ApproveBlackListedAccountRecovery(args.OrgId, args.Account, txa)
+	},
+}
+
+// Simulate runs the permission action against a copy of the state at the head
+// of the chain and returns its effect on the permissions. No transaction is sent.
+func (q *QuorumControlsAPI) Simulate(action string, args SimulationArgs, txa ethapi.SendTxArgs) (*SimulationResult, error) {
+	run, ok := simulatedActions[action]
+	if !ok {
+		return nil, fmt.Errorf("permission action %q cannot be simulated", action)
+	}
+	backend, err := newSimulationBackend(simulationChainOf(q.permCtrl), txa.From)
+	if err != nil {
+		return nil, err
+	}
+	p := q.permCtrl
+	contract := NewPermissionContractService(backend, p.IsV2Permission(), p.key, p.permConfig, p.isRaft, p.useDns)
+	if err := contract.BindContracts(); err != nil {
+		return nil, err
+	}
+	before, err := readPermissions(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	simulated := *p
+	simulated.ethClnt = backend
+	simulated.txSigner = backend.sign
+	if _, err := run(NewQuorumControlsAPI(&simulated), args, txa); err != nil {
+		return nil, err
+	}
+
+	after, err := readPermissions(contract)
+	if err != nil {
+		return nil, err
+	}
+	result := diffPermissions(before, after)
+	result.Warnings = permissionWarnings(before, after, txa.From)
+	return result, nil
+}
+
+// simulationChain is the chain the permission actions are simulated on top of.
+type simulationChain interface {
+	core.ChainContext
+	Config() *params.ChainConfig
+	CurrentBlock() *types.Block
+	State() (*state.StateDB, *state.StateDB, error)
+}
+
+// simulationChainOf returns the chain of the permission service, replaced in the tests.
+var simulationChainOf = func(p *PermissionCtrl) simulationChain {
+	return p.eth.BlockChain()
+}
+
+// simulationBackend is a contract backend applying the transactions to its copy
+// of the state, the calls seeing their effects.
+type simulationBackend struct {
+	chain  simulationChain
+	header *types.Header
+	from   common.Address // sender of the transactions not signed by the backend
+
+	mu           sync.Mutex
+	publicState  *state.StateDB
+	privateState *state.StateDB
+	senders      map[common.Hash]common.Address // senders of the transactions signed
+}
+
+func newSimulationBackend(chain simulationChain, from common.Address) (*simulationBackend, error) {
+	publicState, privateState, err := chain.State()
+	if err != nil {
+		return nil, err
+	}
+	return &simulationBackend{
+		chain:        chain,
+		header:       chain.CurrentBlock().Header(),
+		from:         from,
+		publicState:  publicState,
+		privateState: privateState,
+		senders:      make(map[common.Hash]common.Address),
+	}, nil
+}
+
+// sign is the signer of the transactions sent to the backend, returning them
+// unsigned and recording their sender.
+func (b *simulationBackend) sign(_ types.Signer, from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.senders[tx.Hash()] = from
+	return tx, nil
+}
+
+func (b *simulationBackend) apply(msg types.Message) ([]byte, error) {
+	evm := vm.NewEVM(core.NewEVMContext(msg, b.header, b.chain, nil), b.publicState, b.privateState, b.chain.Config(), vm.Config{})
+	res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Revert()) > 0 {
+		if reason, err := abi.UnpackRevert(res.Revert()); err == nil {
+			return nil, fmt.Errorf("execution reverted: %v", reason)
+		}
+		return nil, errors.New("execution reverted")
+	}
+	return res.Return(), res.Err
+}
+
+func (b *simulationBackend) call(call goethereum.CallMsg) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.publicState.RevertToSnapshot(b.publicState.Snapshot())
+	defer b.privateState.RevertToSnapshot(b.privateState.Snapshot())
+
+	gas, gasPrice, value := call.Gas, call.GasPrice, call.Value
+	if gas == 0 {
+		gas = b.header.GasLimit
+	}
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	return b.apply(types.NewMessage(call.From, call.To, 0, value, gas, gasPrice, call.Data, false))
+}
+
+func (b *simulationBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return b.PendingCodeAt(ctx, contract)
+}
+
+func (b *simulationBackend) CallContract(ctx context.Context, call goethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return b.call(call)
+}
+
+func (b *simulationBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.publicState.GetCode(contract), nil
+}
+
+func (b *simulationBackend) PendingCallContract(ctx context.Context, call goethereum.CallMsg) ([]byte, error) {
+	return b.call(call)
+}
+
+func (b *simulationBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.publicState.GetNonce(account), nil
+}
+
+func (b *simulationBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (b *simulationBackend) EstimateGas(ctx context.Context, call goethereum.CallMsg) (uint64, error) {
+	return b.header.GasLimit, nil
+}
+
+// SendTransaction applies the transaction to the state, as sent by the sender
+// it was signed for, or the sender of the backend whatever its signature.
+func (b *simulationBackend) SendTransaction(ctx context.Context, tx *types.Transaction, args bind.PrivateTxArgs) error {
+	if args.PrivateFor != nil {
+		return errors.New("permission simulation: private transactions are not supported")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	from, ok := b.senders[tx.Hash()]
+	if !ok {
+		from = b.from
+	}
+	delete(b.senders, tx.Hash())
+	_, err := b.apply(types.NewMessage(from, tx.To(), tx.Nonce(), tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data(), false))
+	return err
+}
+
+func (b *simulationBackend) PreparePrivateTransaction(data []byte, privateFrom string) (common.EncryptedPayloadHash, error) {
+	return common.EncryptedPayloadHash{}, errors.New("permission simulation: private transactions are not supported")
+}
+
+func (b *simulationBackend) FilterLogs(ctx context.Context, query goethereum.FilterQuery) ([]types.Log, error) {
+	return nil, errSimulationNoLogs
+}
+
+func (b *simulationBackend) SubscribeFilterLogs(ctx context.Context, query goethereum.FilterQuery, ch chan<- types.Log) (goethereum.Subscription, error) {
+	return nil, errSimulationNoLogs
+}
+
+// permissions are the permissions read from the contracts.
+type permissions struct {
+	orgs     map[string]pcore.OrgInfo
+	nodes    map[pcore.NodeKey]pcore.NodeInfo
+	roles    map[pcore.RoleKey]pcore.RoleInfo
+	accounts map[common.Address]pcore.AccountInfo
+}
+
+// readPermissions reads the permissions from the contracts into fresh caches,
+// the way the permission service populates its own.
+func readPermissions(contract ptype.InitService) (*permissions, error) {
+	orgs := pcore.NewOrgCache(params.DEFAULT_ORGCACHE_SIZE)
+	nodes := pcore.NewNodeCache(params.DEFAULT_NODECACHE_SIZE)
+	roles := pcore.NewRoleCache(params.DEFAULT_ROLECACHE_SIZE)
+	accounts := pcore.NewAcctCache(params.DEFAULT_ACCOUNTCACHE_SIZE)
+	for _, f := range []func() error{
+		func() error { return populateOrgs(contract, orgs) },
+		func() error { return populateNodes(contract, nodes) },
+		func() error { return populateRoles(contract, roles) },
+		func() error { return populateAccounts(contract, accounts) },
+	} {
+		if err := f(); err != nil {
+			return nil, err
+		}
+	}
+
+	perms := &permissions{
+		orgs:     make(map[string]pcore.OrgInfo),
+		nodes:    make(map[pcore.NodeKey]pcore.NodeInfo),
+		roles:    make(map[pcore.RoleKey]pcore.RoleInfo),
+		accounts: make(map[common.Address]pcore.AccountInfo),
+	}
+	for _, o := range orgs.GetOrgList() {
+		perms.orgs[o.FullOrgId] = o
+	}
+	for _, n := range nodes.GetNodeList() {
+		perms.nodes[pcore.NodeKey{OrgId: n.OrgId, Url: n.Url}] = n
+	}
+	for _, r := range roles.GetRoleList() {
+		perms.roles[pcore.RoleKey{OrgId: r.OrgId, RoleId: r.RoleId}] = r
+	}
+	for _, a := range accounts.GetAcctList() {
+		perms.accounts[a.AcctId] = a
+	}
+	return perms, nil
+}
+
+// diffPermissions returns the orgs, nodes, roles and accounts which differ, in
+// the order of their keys.
+func diffPermissions(before, after *permissions) *SimulationResult {
+	result := &SimulationResult{}
+
+	var orgIds []string
+	for id := range before.orgs {
+		orgIds = append(orgIds, id)
+	}
+	for id := range after.orgs {
+		if _, ok := before.orgs[id]; !ok {
+			orgIds = append(orgIds, id)
+		}
+	}
+	sort.Strings(orgIds)
+	for _, id := range orgIds {
+		b, inBefore := before.orgs[id]
+		a, inAfter := after.orgs[id]
+		if inBefore && inAfter && reflect.DeepEqual(b, a) {
+			continue
+		}
+		var change OrgChange
+		if inBefore {
+			change.Before = &b
+		}
+		if inAfter {
+			change.After = &a
+		}
+		result.Orgs = append(result.Orgs, change)
+	}
+
+	var nodeKeys []pcore.NodeKey
+	for key := range before.nodes {
+		nodeKeys = append(nodeKeys, key)
+	}
+	for key := range after.nodes {
+		if _, ok := before.nodes[key]; !ok {
+			nodeKeys = append(nodeKeys, key)
+		}
+	}
+	sort.Slice(nodeKeys, func(i, j int) bool {
+		return nodeKeys[i].OrgId < nodeKeys[j].OrgId || (nodeKeys[i].OrgId == nodeKeys[j].OrgId && nodeKeys[i].Url < nodeKeys[j].Url)
+	})
+	for _, key := range nodeKeys {
+		b, inBefore := before.nodes[key]
+		a, inAfter := after.nodes[key]
+		if inBefore && inAfter && b == a {
+			continue
+		}
+		var change NodeChange
+		if inBefore {
+			change.Before = &b
+		}
+		if inAfter {
+			change.After = &a
+		}
+		result.Nodes = append(result.Nodes, change)
+	}
+
+	var roleKeys []pcore.RoleKey
+	for key := range before.roles {
+		roleKeys = append(roleKeys, key)
+	}
+	for key := range after.roles {
+		if _, ok := before.roles[key]; !ok {
+			roleKeys = append(roleKeys, key)
+		}
+	}
+	sort.Slice(roleKeys, func(i, j int) bool {
+		return roleKeys[i].OrgId < roleKeys[j].OrgId || (roleKeys[i].OrgId == roleKeys[j].OrgId && roleKeys[i].RoleId < roleKeys[j].RoleId)
+	})
+	for _, key := range roleKeys {
+		b, inBefore := before.roles[key]
+		a, inAfter := after.roles[key]
+		if inBefore && inAfter && b == a {
+			continue
+		}
+		var change RoleChange
+		if inBefore {
+			change.Before = &b
+		}
+		if inAfter {
+			change.After = &a
+		}
+		result.Roles = append(result.Roles, change)
+	}
+
+	var accts []common.Address
+	for acct := range before.accounts {
+		accts = append(accts, acct)
+	}
+	for acct := range after.accounts {
+		if _, ok := before.accounts[acct]; !ok {
+			accts = append(accts, acct)
+		}
+	}
+	sort.Slice(accts, func(i, j int) bool {
+		return bytes.Compare(accts[i][:], accts[j][:]) < 0
+	})
+	for _, acct := range accts {
+		b, inBefore := before.accounts[acct]
+		a, inAfter := after.accounts[acct]
+		if inBefore && inAfter && b == a {
+			continue
+		}
+		var change AccountChange
+		if inBefore {
+			change.Before = &b
+		}
+		if inAfter {
+			change.After = &a
+		}
+		result.Accounts = append(result.Accounts, change)
+	}
+	return result
+}
+
+// permissionWarnings warns about the orgs left without admin or approved node,
+// and the caller losing its own admin role.
+func permissionWarnings(before, after *permissions, caller common.Address) []string {
+	var warnings []string
+
+	beforeAdmins, afterAdmins := activeAdmins(before), activeAdmins(after)
+	for _, orgId := range sortedCounts(beforeAdmins) {
+		if afterAdmins[orgId] == 0 {
+			warnings = append(warnings, fmt.Sprintf("org %s is left without an active admin account", orgId))
+		}
+	}
+
+	beforeNodes, afterNodes := approvedNodes(before), approvedNodes(after)
+	for _, orgId := range sortedCounts(beforeNodes) {
+		if afterNodes[orgId] == 0 {
+			warnings = append(warnings, fmt.Sprintf("org %s is left without an approved node", orgId))
+		}
+	}
+	if len(beforeNodes) > 0 && len(afterNodes) == 0 {
+		warnings = append(warnings, "the network is left without an approved node")
+	}
+
+	if b, ok := before.accounts[caller]; ok && isActiveAdmin(b) {
+		if a, ok := after.accounts[caller]; !ok || !isActiveAdmin(a) {
+			warnings = append(warnings, fmt.Sprintf("account %s loses its own admin role", caller.Hex()))
+		}
+	}
+	return warnings
+}
+
+func isActiveAdmin(a pcore.AccountInfo) bool {
+	return a.IsOrgAdmin && a.Status == pcore.AcctActive
+}
+
+// activeAdmins counts the active admin accounts of the orgs having any.
+func activeAdmins(perms *permissions) map[string]int {
+	admins := make(map[string]int)
+	for _, a := range perms.accounts {
+		if isActiveAdmin(a) {
+			admins[a.OrgId]++
+		}
+	}
+	return admins
+}
+
+// approvedNodes counts the approved nodes of the orgs having any.
+func approvedNodes(perms *permissions) map[string]int {
+	nodes := make(map[string]int)
+	for _, n := range perms.nodes {
+		if n.Status == pcore.NodeApproved {
+			nodes[n.OrgId]++
+		}
+	}
+	return nodes
+}
+
+func sortedCounts(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
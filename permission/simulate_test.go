@@ -0,0 +1,151 @@
+package permission
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	pcore "github.com/ethereum/go-ethereum/permission/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingChain is the pending block and state of the simulated backend the
+// permission contracts are deployed to, as they are never committed.
+type pendingChain struct {
+	*core.BlockChain
+	backend *backends.SimulatedBackend
+}
+
+func (c *pendingChain) CurrentBlock() *types.Block {
+	block, _ := c.backend.PendingBlockAndState()
+	return block
+}
+
+func (c *pendingChain) State() (*state.StateDB, *state.StateDB, error) {
+	_, publicState := c.backend.PendingBlockAndState()
+	_, privateState := c.backend.PendingBlockAndState()
+	return publicState, privateState, nil
+}
+
+func TestQuorumControlsAPI_Simulate(t *testing.T) {
+	testObject := typicalQuorumControlsAPI(t)
+	defer func(f func(*PermissionCtrl) simulationChain) { simulationChainOf = f }(simulationChainOf)
+	simulationChainOf = func(*PermissionCtrl) simulationChain {
+		sb := contrBackend.(*backends.SimulatedBackend)
+		return &pendingChain{BlockChain: sb.Blockchain(), backend: sb}
+	}
+	txa := ethapi.SendTxArgs{From: guardianAddress}
+	orgAdminKey, _ := crypto.GenerateKey()
+	orgAdminAddress := crypto.PubkeyToAddress(orgAdminKey.PublicKey)
+	args := SimulationArgs{OrgId: arbitraryOrgToAdd, Url: arbitraryNode1, Account: orgAdminAddress}
+
+	_, err := testObject.Simulate("addOrgs", args, txa)
+	assert.Error(t, err)
+
+	_, err = testObject.Simulate("addOrg", args, ethapi.SendTxArgs{From: getArbitraryAccount()})
+	assert.Equal(t, errors.New("Invalid account id"), err, "validated as the action")
+
+	result, err := testObject.Simulate("addOrg", args, txa)
+	require.NoError(t, err)
+	require.Len(t, result.Orgs, 1)
+	assert.Nil(t, result.Orgs[0].Before)
+	assert.Equal(t, arbitraryOrgToAdd, result.Orgs[0].After.OrgId)
+	assert.Equal(t, pcore.OrgPendingApproval, result.Orgs[0].After.Status)
+	require.Len(t, result.Nodes, 1)
+	assert.Nil(t, result.Nodes[0].Before)
+	assert.Equal(t, arbitraryOrgToAdd, result.Nodes[0].After.OrgId)
+	require.Len(t, result.Accounts, 1)
+	assert.Nil(t, result.Accounts[0].Before)
+	assert.Equal(t, orgAdminAddress, result.Accounts[0].After.AcctId)
+	assert.Empty(t, result.Warnings)
+
+	// nothing sent
+	perms, err := readPermissions(testObject.permCtrl.contract)
+	require.NoError(t, err)
+	assert.Len(t, perms.orgs, 1)
+	_, err = testObject.Simulate("addOrg", args, txa)
+	assert.NoError(t, err, "no approval pending")
+}
+
+func TestPermissionWarnings(t *testing.T) {
+	caller, other := common.Address{0x01}, common.Address{0x02}
+	newPermissions := func(accounts []pcore.AccountInfo, nodes []pcore.NodeInfo) *permissions {
+		perms := &permissions{
+			orgs:     make(map[string]pcore.OrgInfo),
+			nodes:    make(map[pcore.NodeKey]pcore.NodeInfo),
+			roles:    make(map[pcore.RoleKey]pcore.RoleInfo),
+			accounts: make(map[common.Address]pcore.AccountInfo),
+		}
+		for _, a := range accounts {
+			perms.accounts[a.AcctId] = a
+		}
+		for _, n := range nodes {
+			perms.nodes[pcore.NodeKey{OrgId: n.OrgId, Url: n.Url}] = n
+		}
+		return perms
+	}
+	before := newPermissions([]pcore.AccountInfo{
+		{OrgId: "ORG1", AcctId: caller, IsOrgAdmin: true, Status: pcore.AcctActive},
+		{OrgId: "ORG2", AcctId: other, IsOrgAdmin: true, Status: pcore.AcctActive},
+	}, []pcore.NodeInfo{
+		{OrgId: "ORG1", Url: arbitraryNode1, Status: pcore.NodeApproved},
+	})
+
+	assert.Empty(t, permissionWarnings(before, before, caller))
+
+	after := newPermissions([]pcore.AccountInfo{
+		{OrgId: "ORG1", AcctId: caller, IsOrgAdmin: true, Status: pcore.AcctSuspended},
+		{OrgId: "ORG2", AcctId: other, IsOrgAdmin: true, Status: pcore.AcctActive},
+	}, []pcore.NodeInfo{
+		{OrgId: "ORG1", Url: arbitraryNode1, Status: pcore.NodeDeactivated},
+	})
+	assert.Equal(t, []string{
+		"org ORG1 is left without an active admin account",
+		"org ORG1 is left without an approved node",
+		"the network is left without an approved node",
+		"account " + caller.Hex() + " loses its own admin role",
+	}, permissionWarnings(before, after, caller))
+}
+
+func TestDiffPermissions(t *testing.T) {
+	before := &permissions{
+		orgs: map[string]pcore.OrgInfo{
+			"ORG1": {OrgId: "ORG1", FullOrgId: "ORG1", Level: big.NewInt(1), Status: pcore.OrgApproved},
+			"ORG2": {OrgId: "ORG2", FullOrgId: "ORG2", Level: big.NewInt(1), Status: pcore.OrgApproved},
+		},
+		roles: map[pcore.RoleKey]pcore.RoleInfo{
+			{OrgId: "ORG1", RoleId: "R1"}: {OrgId: "ORG1", RoleId: "R1", Active: true},
+		},
+	}
+	after := &permissions{
+		orgs: map[string]pcore.OrgInfo{
+			"ORG1": {OrgId: "ORG1", FullOrgId: "ORG1", Level: big.NewInt(1), Status: pcore.OrgApproved},
+			"ORG2": {OrgId: "ORG2", FullOrgId: "ORG2", Level: big.NewInt(1), Status: pcore.OrgPendingSuspension},
+			"ORG3": {OrgId: "ORG3", FullOrgId: "ORG3", Level: big.NewInt(1), Status: pcore.OrgPendingApproval},
+		},
+		roles: map[pcore.RoleKey]pcore.RoleInfo{
+			{OrgId: "ORG1", RoleId: "R1"}: {OrgId: "ORG1", RoleId: "R1", Active: false},
+		},
+	}
+
+	result := diffPermissions(before, after)
+
+	require.Len(t, result.Orgs, 2)
+	assert.Equal(t, pcore.OrgApproved, result.Orgs[0].Before.Status)
+	assert.Equal(t, pcore.OrgPendingSuspension, result.Orgs[0].After.Status)
+	assert.Nil(t, result.Orgs[1].Before)
+	assert.Equal(t, "ORG3", result.Orgs[1].After.OrgId)
+	require.Len(t, result.Roles, 1)
+	assert.True(t, result.Roles[0].Before.Active)
+	assert.False(t, result.Roles[0].After.Active)
+	assert.Empty(t, result.Nodes)
+	assert.Empty(t, result.Accounts)
+}
@@ -21,6 +21,7 @@ func (*PluginConnector) GRPCServer(_ *plugin.GRPCBroker, _ *grpc.Server) error {
 
 func (*PluginConnector) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, cc *grpc.ClientConn) (interface{}, error) {
 	return &service{
-		client: proto.NewAccountServiceClient(cc),
+		client:   proto.NewAccountServiceClient(cc),
+		hdClient: NewHDServiceClient(cc),
 	}, nil
 }
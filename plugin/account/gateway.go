@@ -18,6 +18,7 @@ import (
 
 type service struct {
 	client      proto.AccountServiceClient
+	hdClient    HDServiceClient // Quorum - nil if the plugin has no HD wallets
 	mu          sync.Mutex
 	isStreaming bool
 }
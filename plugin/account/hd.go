@@ -0,0 +1,122 @@
+package account
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/jpmorganchase/quorum-account-plugin-sdk-go/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Quorum
+//
+// The HD wallet methods extend the AccountService of the account plugin
+// definitions with:
+//
+//	rpc DeriveAccount(DeriveAccountRequest) returns (DeriveAccountResponse);
+//	rpc OpenWallet(OpenWalletRequest) returns (OpenWalletResponse);
+//
+// The plugins built before them answer codes.Unimplemented, reported as
+// ErrNotSupportedByPlugin rather than as a transport error.
+
+var ErrNotSupportedByPlugin = errors.New("not supported by plugin")
+
+type DeriveAccountRequest struct {
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Pin  bool   `protobuf:"varint,2,opt,name=pin,proto3" json:"pin,omitempty"`
+}
+
+func (m *DeriveAccountRequest) Reset()         { *m = DeriveAccountRequest{} }
+func (m *DeriveAccountRequest) String() string { return protobuf.CompactTextString(m) }
+func (*DeriveAccountRequest) ProtoMessage()    {}
+
+type DeriveAccountResponse struct {
+	Account *proto.Account `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+}
+
+func (m *DeriveAccountResponse) Reset()         { *m = DeriveAccountResponse{} }
+func (m *DeriveAccountResponse) String() string { return protobuf.CompactTextString(m) }
+func (*DeriveAccountResponse) ProtoMessage()    {}
+
+type OpenWalletRequest struct {
+	Url        string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Passphrase string `protobuf:"bytes,2,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+}
+
+func (m *OpenWalletRequest) Reset()         { *m = OpenWalletRequest{} }
+func (m *OpenWalletRequest) String() string { return protobuf.CompactTextString(m) }
+func (*OpenWalletRequest) ProtoMessage()    {}
+
+type OpenWalletResponse struct{}
+
+func (m *OpenWalletResponse) Reset()         { *m = OpenWalletResponse{} }
+func (m *OpenWalletResponse) String() string { return protobuf.CompactTextString(m) }
+func (*OpenWalletResponse) ProtoMessage()    {}
+
+// HDServiceClient is the client of the HD wallet methods of the AccountService.
+type HDServiceClient interface {
+	DeriveAccount(ctx context.Context, in *DeriveAccountRequest, opts ...grpc.CallOption) (*DeriveAccountResponse, error)
+	OpenWallet(ctx context.Context, in *OpenWalletRequest, opts ...grpc.CallOption) (*OpenWalletResponse, error)
+}
+
+type hdServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewHDServiceClient(cc *grpc.ClientConn) HDServiceClient {
+	return &hdServiceClient{cc}
+}
+
+func (c *hdServiceClient) DeriveAccount(ctx context.Context, in *DeriveAccountRequest, opts ...grpc.CallOption) (*DeriveAccountResponse, error) {
+	out := new(DeriveAccountResponse)
+	err := c.cc.Invoke(ctx, "/proto.AccountService/DeriveAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hdServiceClient) OpenWallet(ctx context.Context, in *OpenWalletRequest, opts ...grpc.CallOption) (*OpenWalletResponse, error) {
+	out := new(OpenWalletResponse)
+	err := c.cc.Invoke(ctx, "/proto.AccountService/OpenWallet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (g *service) DeriveAccount(ctx context.Context, path string, pin bool) (accounts.Account, error) {
+	if g.hdClient == nil {
+		return accounts.Account{}, ErrNotSupportedByPlugin
+	}
+	resp, err := g.hdClient.DeriveAccount(ctx, &DeriveAccountRequest{Path: path, Pin: pin})
+	if err != nil {
+		return accounts.Account{}, asNotSupported(err)
+	}
+	if resp == nil || resp.Account == nil {
+		return accounts.Account{}, errors.New("empty response from plugin")
+	}
+
+	return asAccount(resp.Account)
+}
+
+func (g *service) OpenWallet(ctx context.Context, url string, passphrase string) error {
+	if g.hdClient == nil {
+		return ErrNotSupportedByPlugin
+	}
+	_, err := g.hdClient.OpenWallet(ctx, &OpenWalletRequest{Url: url, Passphrase: passphrase})
+	return asNotSupported(err)
+}
+
+// asNotSupported returns ErrNotSupportedByPlugin for the methods the plugin
+// does not implement.
+func asNotSupported(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return ErrNotSupportedByPlugin
+	}
+	return err
+}
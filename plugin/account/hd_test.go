@@ -0,0 +1,114 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/jpmorganchase/quorum-account-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// hdServer implements the HD wallet methods of a plugin.
+type hdServer struct {
+	derived map[string]*proto.Account // by path
+	opened  *OpenWalletRequest
+}
+
+func (s *hdServer) deriveAccount(ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(DeriveAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	acct, ok := s.derived[in.Path]
+	if !ok || !in.Pin {
+		return nil, errors.New("unknown path")
+	}
+	return &DeriveAccountResponse{Account: acct}, nil
+}
+
+func (s *hdServer) openWallet(ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(OpenWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	s.opened = in
+	return &OpenWalletResponse{}, nil
+}
+
+var hdServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.AccountService",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeriveAccount",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				return srv.(*hdServer).deriveAccount(ctx, dec)
+			},
+		},
+		{
+			MethodName: "OpenWallet",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				return srv.(*hdServer).openWallet(ctx, dec)
+			},
+		},
+	},
+}
+
+// dialPlugin returns the gateway of a plugin served by register.
+func dialPlugin(t *testing.T, register func(s *grpc.Server)) *service {
+	lis := bufconn.Listen(1 << 16)
+	s := grpc.NewServer()
+	register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { cc.Close() })
+	return &service{client: proto.NewAccountServiceClient(cc), hdClient: NewHDServiceClient(cc)}
+}
+
+func TestPluginGateway_DeriveAccount(t *testing.T) {
+	srv := &hdServer{derived: map[string]*proto.Account{
+		"m/44'/60'/0'/0/1": {Address: acct1.Address.Bytes(), Url: "scheme://acctUri1"},
+	}}
+	g := dialPlugin(t, func(s *grpc.Server) { s.RegisterService(&hdServiceDesc, srv) })
+
+	got, err := g.DeriveAccount(context.Background(), "m/44'/60'/0'/0/1", true)
+	require.NoError(t, err)
+	assert.Equal(t, acct1, got)
+
+	_, err = g.DeriveAccount(context.Background(), "m/44'/60'/0'/0/2", true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown path")
+}
+
+func TestPluginGateway_OpenWallet(t *testing.T) {
+	srv := &hdServer{}
+	g := dialPlugin(t, func(s *grpc.Server) { s.RegisterService(&hdServiceDesc, srv) })
+
+	require.NoError(t, g.OpenWallet(context.Background(), "plugin://account/hd", "pwd"))
+	assert.Equal(t, "plugin://account/hd", srv.opened.Url)
+	assert.Equal(t, "pwd", srv.opened.Passphrase)
+}
+
+func TestPluginGateway_HD_NotSupportedByPlugin(t *testing.T) {
+	// a plugin built before the HD wallet methods
+	g := dialPlugin(t, func(s *grpc.Server) { proto.RegisterAccountServiceServer(s, &proto.UnimplementedAccountServiceServer{}) })
+
+	_, err := g.DeriveAccount(context.Background(), accounts.DefaultBaseDerivationPath.String(), true)
+	assert.Equal(t, ErrNotSupportedByPlugin, err)
+	assert.Equal(t, ErrNotSupportedByPlugin, g.OpenWallet(context.Background(), "plugin://account/hd", "pwd"))
+
+	// other methods still reach the plugin
+	_, err = g.Status(context.Background())
+	assert.NotEqual(t, ErrNotSupportedByPlugin, err)
+}
@@ -103,3 +103,19 @@ func (am *ReloadableService) ImportRawKey(ctx context.Context, rawKey string, ne
 	}
 	return s.ImportRawKey(ctx, rawKey, newAccountConfig)
 }
+
+func (am *ReloadableService) DeriveAccount(ctx context.Context, path string, pin bool) (accounts.Account, error) {
+	s, err := am.DispenseFunc()
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return s.DeriveAccount(ctx, path, pin)
+}
+
+func (am *ReloadableService) OpenWallet(ctx context.Context, url string, passphrase string) error {
+	s, err := am.DispenseFunc()
+	if err != nil {
+		return err
+	}
+	return s.OpenWallet(ctx, url, passphrase)
+}
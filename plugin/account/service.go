@@ -18,9 +18,20 @@ type Service interface {
 	TimedUnlock(ctx context.Context, account accounts.Account, password string, duration time.Duration) error
 	Lock(ctx context.Context, account accounts.Account) error
 	CreatorService
+	HDService
 }
 
 type CreatorService interface {
 	NewAccount(ctx context.Context, newAccountConfig interface{}) (accounts.Account, error)
 	ImportRawKey(ctx context.Context, rawKey string, newAccountConfig interface{}) (accounts.Account, error)
 }
+
+// HDService derives the accounts of the hierarchical deterministic wallets of
+// the plugin. The plugins not implementing it return ErrNotSupportedByPlugin.
+type HDService interface {
+	// DeriveAccount derives the account at the path, e.g. m/44'/60'/0'/0/1, of
+	// the open HD wallet, adding it to the accounts of the plugin if pinned.
+	DeriveAccount(ctx context.Context, path string, pin bool) (accounts.Account, error)
+	// OpenWallet opens the HD wallet at the url to derive accounts from.
+	OpenWallet(ctx context.Context, url string, passphrase string) error
+}
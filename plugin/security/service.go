@@ -17,6 +17,13 @@ type AuthenticationManager interface {
 	IsEnabled(ctx context.Context) (bool, error)
 }
 
+// HeaderAuthenticationManager is implemented by the authentication managers
+// reading the token of a request from another HTTP header than Authorization.
+type HeaderAuthenticationManager interface {
+	AuthenticationManager
+	TokenHeader() string
+}
+
 type AuthenticationManagerDeferFunc func() (AuthenticationManager, error)
 
 type DeferredAuthenticationManager struct {
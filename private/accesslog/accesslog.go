@@ -0,0 +1,285 @@
+// Package accesslog records who read the state of selected private contracts
+// and when, through a hook receiving the individual accesses and in-memory
+// summaries of them.
+package accesslog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+const (
+	// DefaultRetention is how long access summaries are kept by default.
+	DefaultRetention = 24 * time.Hour
+
+	// bucketSize is the granularity of the summaries.
+	bucketSize = time.Minute
+)
+
+var (
+	accessMeter  = metrics.NewRegisteredMeter("accesslog/accesses", nil)
+	loggedMeter  = metrics.NewRegisteredMeter("accesslog/logged", nil)
+	sampledMeter = metrics.NewRegisteredMeter("accesslog/sampled", nil)
+	cappedMeter  = metrics.NewRegisteredMeter("accesslog/capped", nil)
+)
+
+// Entry is a read of the state of a private contract.
+type Entry struct {
+	Time        time.Time
+	Identity    string // digest of the access token, empty if unauthenticated
+	RemoteAddr  string
+	Source      string // RPC method or GraphQL field reading the state
+	Contract    common.Address
+	Method      string // selector of the contract method called, if any
+	BlockNumber uint64
+	BlockHash   common.Hash
+}
+
+// Hook receives the accesses retained after sampling and rate capping, e.g. to
+// forward them to an audit system.
+type Hook interface {
+	LogAccess(e *Entry)
+}
+
+// LogHook writes the accesses to the node log.
+type LogHook struct{}
+
+func (LogHook) LogAccess(e *Entry) {
+	log.Info("Private contract accessed", "contract", e.Contract, "source", e.Source, "method", e.Method,
+		"identity", e.Identity, "remote", e.RemoteAddr, "number", e.BlockNumber, "hash", e.BlockHash)
+}
+
+// Config selects the contracts whose accesses are logged and bounds the
+// amount of entries written.
+type Config struct {
+	Contracts    []common.Address
+	SampleRate   uint          // one in SampleRate accesses is logged, all if 0 or 1
+	MaxPerSecond uint          // maximum entries logged per contract and second, unlimited if 0
+	Retention    time.Duration // how long summaries are kept, DefaultRetention if 0
+}
+
+// Summary aggregates the accesses to a contract over a time range. It counts
+// every access, whether or not it was logged, and does not reveal who made
+// them.
+type Summary struct {
+	Contract  common.Address    `json:"contract"`
+	From      uint64            `json:"from"`
+	To        uint64            `json:"to"`
+	Total     uint64            `json:"total"`
+	Logged    uint64            `json:"logged"`
+	Accessors int               `json:"accessors"`
+	BySource  map[string]uint64 `json:"bySource"`
+	ByMethod  map[string]uint64 `json:"byMethod"`
+}
+
+// Logger records the accesses to the state of the configured contracts.
+type Logger struct {
+	config Config
+	hook   Hook
+
+	lock      sync.Mutex
+	contracts map[common.Address]*contractLog
+}
+
+// contractLog tracks the accesses to a single contract.
+type contractLog struct {
+	seen    uint64    // accesses counted, for sampling
+	second  time.Time // second logged entries are capped for
+	inCap   uint      // entries logged during the second
+	buckets []*bucket // oldest first
+}
+
+type bucket struct {
+	start     time.Time
+	total     uint64
+	logged    uint64
+	accessors map[string]struct{}
+	bySource  map[string]uint64
+	byMethod  map[string]uint64
+}
+
+// New creates a logger of the accesses to the configured contracts, passing
+// the entries retained to the hook.
+func New(config Config, hook Hook) *Logger {
+	if config.Retention == 0 {
+		config.Retention = DefaultRetention
+	}
+	l := &Logger{
+		config:    config,
+		hook:      hook,
+		contracts: make(map[common.Address]*contractLog, len(config.Contracts)),
+	}
+	for _, contract := range config.Contracts {
+		l.contracts[contract] = new(contractLog)
+	}
+	return l
+}
+
+// Enabled reports whether the accesses to the contract are logged.
+func (l *Logger) Enabled(contract common.Address) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.contracts[contract]
+	return ok
+}
+
+// Record counts an access to the contract of the entry, completing it with the
+// caller details found in the RPC context, and passes it to the hook unless it
+// is sampled out or the rate cap of the contract is reached.
+func (l *Logger) Record(ctx context.Context, e *Entry) {
+	cl, ok := l.contracts[e.Contract]
+	if !ok {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if authToken, ok := ctx.Value(rpc.CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken); ok && authToken != nil {
+		digest := sha256.Sum256(authToken.RawToken)
+		e.Identity = hex.EncodeToString(digest[:8])
+	}
+	if remote, ok := ctx.Value("remote").(string); ok {
+		e.RemoteAddr = remote
+	}
+	accessMeter.Mark(1)
+
+	l.lock.Lock()
+	logged := l.admit(cl, e.Time)
+	l.count(cl, e, logged)
+	l.lock.Unlock()
+
+	if logged {
+		loggedMeter.Mark(1)
+		l.hook.LogAccess(e)
+	}
+}
+
+// admit applies the sampling and the rate cap, the caller must hold the lock.
+func (l *Logger) admit(cl *contractLog, now time.Time) bool {
+	cl.seen++
+	if rate := uint64(l.config.SampleRate); rate > 1 && (cl.seen-1)%rate != 0 {
+		sampledMeter.Mark(1)
+		return false
+	}
+	if l.config.MaxPerSecond == 0 {
+		return true
+	}
+	if second := now.Truncate(time.Second); !second.Equal(cl.second) {
+		cl.second, cl.inCap = second, 0
+	}
+	if cl.inCap >= l.config.MaxPerSecond {
+		cappedMeter.Mark(1)
+		return false
+	}
+	cl.inCap++
+	return true
+}
+
+// count adds the access to the summaries, the caller must hold the lock.
+func (l *Logger) count(cl *contractLog, e *Entry, logged bool) {
+	start := e.Time.Truncate(bucketSize)
+	var b *bucket
+	if n := len(cl.buckets); n > 0 && !cl.buckets[n-1].start.Before(start) {
+		b = cl.buckets[n-1]
+	} else {
+		b = &bucket{
+			start:     start,
+			accessors: make(map[string]struct{}),
+			bySource:  make(map[string]uint64),
+			byMethod:  make(map[string]uint64),
+		}
+		cl.buckets = append(cl.buckets, b)
+	}
+	b.total++
+	if logged {
+		b.logged++
+	}
+	accessor := e.Identity
+	if accessor == "" {
+		accessor = e.RemoteAddr
+	}
+	b.accessors[accessor] = struct{}{}
+	b.bySource[e.Source]++
+	if e.Method != "" {
+		b.byMethod[e.Method]++
+	}
+
+	// drop the buckets beyond the retention
+	expired := 0
+	for expired < len(cl.buckets) && e.Time.Sub(cl.buckets[expired].start) > l.config.Retention {
+		expired++
+	}
+	cl.buckets = cl.buckets[expired:]
+}
+
+// Summary aggregates the accesses to the contract between the given times,
+// nil if the accesses to the contract are not logged. The summary has the
+// granularity of a minute.
+func (l *Logger) Summary(contract common.Address, from, to time.Time) *Summary {
+	if l == nil {
+		return nil
+	}
+	cl, ok := l.contracts[contract]
+	if !ok {
+		return nil
+	}
+	summary := &Summary{
+		Contract: contract,
+		From:     uint64(from.Unix()),
+		To:       uint64(to.Unix()),
+		BySource: make(map[string]uint64),
+		ByMethod: make(map[string]uint64),
+	}
+	accessors := make(map[string]struct{})
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, b := range cl.buckets {
+		if b.start.Before(from.Truncate(bucketSize)) || b.start.After(to) {
+			continue
+		}
+		summary.Total += b.total
+		summary.Logged += b.logged
+		for accessor := range b.accessors {
+			accessors[accessor] = struct{}{}
+		}
+		for source, n := range b.bySource {
+			summary.BySource[source] += n
+		}
+		for method, n := range b.byMethod {
+			summary.ByMethod[method] += n
+		}
+	}
+	summary.Accessors = len(accessors)
+	return summary
+}
+
+var (
+	current     *Logger
+	currentLock sync.RWMutex
+)
+
+// Set installs the logger used by the state read paths, nil disables logging.
+func Set(l *Logger) {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	current = l
+}
+
+// Get returns the installed logger, nil if access logging is disabled.
+func Get() *Logger {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
@@ -0,0 +1,101 @@
+package accesslog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHook struct {
+	entries []*Entry
+}
+
+func (h *recordingHook) LogAccess(e *Entry) {
+	h.entries = append(h.entries, e)
+}
+
+var (
+	logged    = common.Address{0x01}
+	notLogged = common.Address{0x02}
+)
+
+func TestLogger_Record(t *testing.T) {
+	hook := new(recordingHook)
+	l := New(Config{Contracts: []common.Address{logged}}, hook)
+
+	ctx := context.WithValue(context.Background(), rpc.CtxPreauthenticatedToken, &proto.PreAuthenticatedAuthenticationToken{RawToken: []byte("token")})
+	ctx = context.WithValue(ctx, "remote", "10.0.0.1:1234")
+	l.Record(ctx, &Entry{Source: "eth_call", Contract: logged, Method: "0x12345678", BlockNumber: 3})
+	l.Record(ctx, &Entry{Source: "eth_call", Contract: notLogged})
+
+	require.Len(t, hook.entries, 1)
+	e := hook.entries[0]
+	assert.Equal(t, logged, e.Contract)
+	assert.Equal(t, "10.0.0.1:1234", e.RemoteAddr)
+	assert.Len(t, e.Identity, 16)
+	assert.NotContains(t, e.Identity, "token")
+	assert.False(t, e.Time.IsZero())
+
+	assert.True(t, l.Enabled(logged))
+	assert.False(t, l.Enabled(notLogged))
+	assert.False(t, (*Logger)(nil).Enabled(logged))
+}
+
+func TestLogger_SamplingAndCap(t *testing.T) {
+	hook := new(recordingHook)
+	l := New(Config{Contracts: []common.Address{logged}, SampleRate: 2, MaxPerSecond: 3}, hook)
+
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		l.Record(context.Background(), &Entry{Time: now, Source: "eth_getCode", Contract: logged})
+	}
+	// one in two accesses is sampled, three of them are logged within the second
+	assert.Len(t, hook.entries, 3)
+
+	l.Record(context.Background(), &Entry{Time: now.Add(time.Second), Source: "eth_getCode", Contract: logged})
+	assert.Len(t, hook.entries, 4)
+
+	summary := l.Summary(logged, now.Add(-time.Minute), now.Add(time.Minute))
+	assert.Equal(t, uint64(11), summary.Total)
+	assert.Equal(t, uint64(4), summary.Logged)
+}
+
+func TestLogger_Summary(t *testing.T) {
+	l := New(Config{Contracts: []common.Address{logged}, Retention: time.Hour}, new(recordingHook))
+
+	start := time.Unix(1599999960, 0)
+	record := func(at time.Duration, source, method, remote string) {
+		ctx := context.WithValue(context.Background(), "remote", remote)
+		l.Record(ctx, &Entry{Time: start.Add(at), Source: source, Contract: logged, Method: method})
+	}
+	record(0, "eth_call", "0x01020304", "a")
+	record(time.Minute, "eth_call", "0x01020304", "b")
+	record(2*time.Minute, "eth_getStorageAt", "", "a")
+	record(10*time.Minute, "graphql:call", "0x0a0b0c0d", "c")
+
+	summary := l.Summary(logged, start, start.Add(5*time.Minute))
+	assert.Equal(t, &Summary{
+		Contract:  logged,
+		From:      uint64(start.Unix()),
+		To:        uint64(start.Add(5 * time.Minute).Unix()),
+		Total:     3,
+		Logged:    3,
+		Accessors: 2,
+		BySource:  map[string]uint64{"eth_call": 2, "eth_getStorageAt": 1},
+		ByMethod:  map[string]uint64{"0x01020304": 2},
+	}, summary)
+
+	// the accesses beyond the retention are forgotten
+	record(70*time.Minute, "eth_call", "", "a")
+	summary = l.Summary(logged, start, start.Add(2*time.Hour))
+	assert.Equal(t, uint64(2), summary.Total)
+
+	assert.Nil(t, l.Summary(notLogged, start, start.Add(time.Hour)))
+	assert.Nil(t, (*Logger)(nil).Summary(logged, start, start.Add(time.Hour)))
+}
@@ -0,0 +1,42 @@
+package private
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/private/engine"
+)
+
+// receiveWorkers is the number of payloads received concurrently from the
+// private transaction managers without batch endpoint
+const receiveWorkers = 8
+
+// ReceiveBatch receives the payloads of the hashes from the private transaction
+// manager, in their order. The payloads are received in one request if the
+// transaction manager supports it, concurrently one by one otherwise. A payload
+// which cannot be received has its Err set, the others being received still.
+func ReceiveBatch(ctx context.Context, ptm PrivateTransactionManager, hashes []common.EncryptedPayloadHash) ([]engine.ReceivedPayload, error) {
+	if ptm.HasFeature(engine.BatchReceive) {
+		return ptm.ReceiveBatch(ctx, hashes)
+	}
+	results := make([]engine.ReceivedPayload, len(hashes))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < receiveWorkers && w < len(hashes); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				sender, managedParties, payload, extra, err := ptm.Receive(ctx, hashes[i])
+				results[i] = engine.ReceivedPayload{Sender: sender, ManagedParties: managedParties, Payload: payload, Extra: extra, Err: err}
+			}
+		}()
+	}
+	for i := range hashes {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, nil
+}
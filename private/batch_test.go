@@ -0,0 +1,81 @@
+package private
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBatchReceiver struct {
+	notinuse.PrivateTransactionManager
+	batch    bool
+	received int32
+}
+
+func (s *stubBatchReceiver) HasFeature(f engine.PrivateTransactionManagerFeature) bool {
+	return s.batch && f == engine.BatchReceive
+}
+
+func (s *stubBatchReceiver) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	atomic.AddInt32(&s.received, 1)
+	switch hash {
+	case common.BytesToEncryptedPayloadHash([]byte("unknown")):
+		return "", nil, nil, nil, nil
+	case common.BytesToEncryptedPayloadHash([]byte("failing")):
+		return "", nil, nil, nil, errors.New("payload cannot be decrypted")
+	}
+	return "sender", nil, hash.Bytes()[:8], &engine.ExtraMetadata{}, nil
+}
+
+func (s *stubBatchReceiver) ReceiveBatch(ctx context.Context, hashes []common.EncryptedPayloadHash) ([]engine.ReceivedPayload, error) {
+	results := make([]engine.ReceivedPayload, len(hashes))
+	for i, hash := range hashes {
+		results[i] = engine.ReceivedPayload{Payload: hash.Bytes()[:8]}
+	}
+	return results, nil
+}
+
+func TestReceiveBatch_whenNoBatchEndpoint(t *testing.T) {
+	ptm := &stubBatchReceiver{}
+	hashes := make([]common.EncryptedPayloadHash, 3*receiveWorkers)
+	for i := range hashes {
+		hashes[i] = common.BytesToEncryptedPayloadHash([]byte{byte(i + 1)})
+	}
+	hashes[3] = common.BytesToEncryptedPayloadHash([]byte("unknown"))
+	hashes[5] = common.BytesToEncryptedPayloadHash([]byte("failing"))
+
+	received, err := ReceiveBatch(context.Background(), ptm, hashes)
+	require.NoError(t, err)
+	require.Len(t, received, len(hashes))
+	assert.EqualValues(t, len(hashes), ptm.received)
+	for i, payload := range received {
+		switch i {
+		case 3:
+			assert.Nil(t, payload.Payload)
+			assert.NoError(t, payload.Err)
+		case 5:
+			assert.Error(t, payload.Err, "entry failing alone")
+		default:
+			assert.NoError(t, payload.Err)
+			assert.Equal(t, hashes[i].Bytes()[:8], payload.Payload, "payloads in the order of the hashes")
+			assert.Equal(t, "sender", payload.Sender)
+		}
+	}
+}
+
+func TestReceiveBatch_whenBatchEndpoint(t *testing.T) {
+	ptm := &stubBatchReceiver{batch: true}
+	hashes := []common.EncryptedPayloadHash{{0x01}, {0x02}}
+
+	received, err := ReceiveBatch(context.Background(), ptm, hashes)
+	require.NoError(t, err)
+	assert.Zero(t, ptm.received, "no payload received one by one")
+	assert.Equal(t, []byte{0x02, 0, 0, 0, 0, 0, 0, 0}, received[1].Payload)
+}
@@ -10,6 +10,13 @@ import (
 const (
 	DefaultExpiration = 5 * time.Minute
 	CleanupInterval   = 5 * time.Minute
+
+	// DefaultSize is the number of payloads cached in memory by default
+	DefaultSize = 4096
+	// DefaultMissingExpiration is the default time the payloads the node is
+	// not a party to are known missing for, short since the node can become a
+	// recipient later on
+	DefaultMissingExpiration = 10 * time.Second
 )
 
 func NewDefaultCache() *gocache.Cache {
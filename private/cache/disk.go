@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	diskKeyFile  = "key"      // node-local encryption key of the entries
+	diskDataDir  = "payloads" // database of the entries
+	diskKeySize  = 32
+	diskSeqSize  = 8
+	diskDBCache  = 16
+	diskDBHandle = 16
+)
+
+var (
+	diskEntryPrefix = []byte("e") // diskEntryPrefix + hash(key) -> seq + nonce + sealed item
+	diskOrderPrefix = []byte("o") // diskOrderPrefix + seq + hash(key) -> size of the entry
+	diskMetaKey     = []byte("m") // total size of the entries + next seq
+
+	diskHitMeter     = metrics.NewRegisteredMeter("private/cache/disk/hits", nil)
+	diskMissMeter    = metrics.NewRegisteredMeter("private/cache/disk/misses", nil)
+	diskCorruptMeter = metrics.NewRegisteredMeter("private/cache/disk/corrupt", nil)
+	diskStoredGauge  = metrics.NewRegisteredGauge("private/cache/disk/stored", nil)
+
+	errCorruptEntry = errors.New("corrupt payload cache entry")
+)
+
+// diskItem is the encoding of a PrivateCacheItem on disk.
+type diskItem struct {
+	Payload        []byte
+	ACHashes       []common.EncryptedPayloadHash
+	ACMerkleRoot   common.Hash
+	PrivacyFlag    uint64
+	ManagedParties []string
+	Sender         string
+	ExecutionHints []common.Address
+}
+
+// DiskCache is the persistent tier of a PayloadCache, keeping the decrypted
+// payloads across restarts in a size-capped database. The entries are sealed
+// with a key generated by, and never leaving, the node, and are evicted
+// oldest first beyond the size limit. Opening the cache only reads its total
+// size, the entries are read when missed in memory.
+type DiskCache struct {
+	db    ethdb.KeyValueStore
+	aead  cipher.AEAD
+	limit int64
+
+	lock    sync.Mutex
+	size    int64
+	nextSeq uint64
+}
+
+// OpenDiskCache opens, or creates, the disk cache in the directory, keeping
+// at most limit bytes of entries.
+func OpenDiskCache(dir string, limit int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	key, err := loadDiskKey(filepath.Join(dir, diskKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	db, err := leveldb.New(filepath.Join(dir, diskDataDir), diskDBCache, diskDBHandle, "")
+	if err != nil {
+		return nil, err
+	}
+	c := &DiskCache{db: db, aead: aead, limit: limit}
+	if meta, err := db.Get(diskMetaKey); err == nil && len(meta) == 16 {
+		c.size = int64(binary.BigEndian.Uint64(meta[:8]))
+		c.nextSeq = binary.BigEndian.Uint64(meta[8:])
+	}
+	diskStoredGauge.Update(c.size)
+	return c, nil
+}
+
+// loadDiskKey reads the encryption key, generating it on first use.
+func loadDiskKey(path string) ([]byte, error) {
+	key, err := ioutil.ReadFile(path)
+	if err == nil {
+		if len(key) != diskKeySize {
+			return nil, fmt.Errorf("invalid payload cache key %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key = make([]byte, diskKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func diskHash(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+	return hash[:]
+}
+
+func diskEntryKey(hash []byte) []byte {
+	return append(common.CopyBytes(diskEntryPrefix), hash...)
+}
+
+func diskOrderKey(seq uint64, hash []byte) []byte {
+	key := make([]byte, 0, len(diskOrderPrefix)+diskSeqSize+len(hash))
+	key = append(key, diskOrderPrefix...)
+	key = append(key, make([]byte, diskSeqSize)...)
+	binary.BigEndian.PutUint64(key[len(diskOrderPrefix):], seq)
+	return append(key, hash...)
+}
+
+// Get returns the item stored under the key. Entries which cannot be read or
+// decrypted are deleted and reported as missing.
+func (c *DiskCache) Get(key string) (PrivateCacheItem, bool) {
+	hash := diskHash(key)
+	blob, err := c.db.Get(diskEntryKey(hash))
+	if err != nil {
+		diskMissMeter.Mark(1)
+		return PrivateCacheItem{}, false
+	}
+	item, err := c.open(hash, blob)
+	if err != nil {
+		log.Warn("Dropping unreadable private payload cache entry", "err", err)
+		diskCorruptMeter.Mark(1)
+		diskMissMeter.Mark(1)
+		c.Delete(key)
+		return PrivateCacheItem{}, false
+	}
+	diskHitMeter.Mark(1)
+	return item, true
+}
+
+func (c *DiskCache) open(hash, blob []byte) (PrivateCacheItem, error) {
+	var item PrivateCacheItem
+	nonceSize := c.aead.NonceSize()
+	if len(blob) < diskSeqSize+nonceSize {
+		return item, errCorruptEntry
+	}
+	nonce := blob[diskSeqSize : diskSeqSize+nonceSize]
+	plain, err := c.aead.Open(nil, nonce, blob[diskSeqSize+nonceSize:], hash)
+	if err != nil {
+		return item, err
+	}
+	var stored diskItem
+	if err := rlp.DecodeBytes(plain, &stored); err != nil {
+		return item, err
+	}
+	item = PrivateCacheItem{
+		Payload: stored.Payload,
+		Extra: engine.ExtraMetadata{
+			ACMerkleRoot:   stored.ACMerkleRoot,
+			PrivacyFlag:    engine.PrivacyFlagType(stored.PrivacyFlag),
+			ManagedParties: stored.ManagedParties,
+			Sender:         stored.Sender,
+			ExecutionHints: stored.ExecutionHints,
+		},
+	}
+	if len(stored.ManagedParties) == 0 {
+		item.Extra.ManagedParties = nil
+	}
+	if len(stored.ExecutionHints) == 0 {
+		item.Extra.ExecutionHints = nil
+	}
+	if len(stored.ACHashes) > 0 {
+		item.Extra.ACHashes = make(common.EncryptedPayloadHashes, len(stored.ACHashes))
+		for _, hash := range stored.ACHashes {
+			item.Extra.ACHashes.Add(hash)
+		}
+	}
+	return item, nil
+}
+
+// Put stores the item under the key, replacing any previous one and evicting
+// the oldest entries beyond the size limit.
+func (c *DiskCache) Put(key string, item PrivateCacheItem) error {
+	stored := diskItem{
+		Payload:        item.Payload,
+		ACMerkleRoot:   item.Extra.ACMerkleRoot,
+		PrivacyFlag:    uint64(item.Extra.PrivacyFlag),
+		ManagedParties: item.Extra.ManagedParties,
+		Sender:         item.Extra.Sender,
+		ExecutionHints: item.Extra.ExecutionHints,
+	}
+	for hash := range item.Extra.ACHashes {
+		stored.ACHashes = append(stored.ACHashes, hash)
+	}
+	plain, err := rlp.EncodeToBytes(&stored)
+	if err != nil {
+		return err
+	}
+	hash := diskHash(key)
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	batch := c.db.NewBatch()
+	c.delete(batch, hash)
+	seq := c.nextSeq
+	c.nextSeq++
+	blob := make([]byte, diskSeqSize, diskSeqSize+len(nonce)+len(plain)+c.aead.Overhead())
+	binary.BigEndian.PutUint64(blob, seq)
+	blob = append(blob, nonce...)
+	blob = c.aead.Seal(blob, nonce, plain, hash)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(blob)))
+	batch.Put(diskEntryKey(hash), blob)
+	batch.Put(diskOrderKey(seq, hash), size)
+	c.size += int64(len(blob))
+	if err := c.write(batch); err != nil {
+		return err
+	}
+	if c.size <= c.limit {
+		return nil
+	}
+	batch = c.db.NewBatch()
+	c.evict(batch)
+	return c.write(batch)
+}
+
+// Delete removes the item stored under the key.
+func (c *DiskCache) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	batch := c.db.NewBatch()
+	c.delete(batch, diskHash(key))
+	if err := c.write(batch); err != nil {
+		log.Warn("Failed to delete private payload cache entry", "err", err)
+	}
+}
+
+// delete adds the deletion of the entry of the hash, if any, to the batch.
+func (c *DiskCache) delete(batch ethdb.Batch, hash []byte) {
+	entryKey := diskEntryKey(hash)
+	blob, err := c.db.Get(entryKey)
+	if err != nil {
+		return
+	}
+	batch.Delete(entryKey)
+	if len(blob) < diskSeqSize {
+		return
+	}
+	orderKey := diskOrderKey(binary.BigEndian.Uint64(blob), hash)
+	if size, err := c.db.Get(orderKey); err == nil && len(size) == 8 {
+		c.size -= int64(binary.BigEndian.Uint64(size))
+	}
+	batch.Delete(orderKey)
+}
+
+// evict adds the deletion of the oldest entries beyond the limit to the batch.
+func (c *DiskCache) evict(batch ethdb.Batch) {
+	it := c.db.NewIterator(diskOrderPrefix, nil)
+	defer it.Release()
+	for c.size > c.limit && it.Next() {
+		hash := it.Key()[len(diskOrderPrefix)+diskSeqSize:]
+		batch.Delete(diskEntryKey(hash))
+		batch.Delete(common.CopyBytes(it.Key()))
+		if len(it.Value()) == 8 {
+			c.size -= int64(binary.BigEndian.Uint64(it.Value()))
+		}
+	}
+}
+
+// write commits the batch along with the size and next sequence number.
+func (c *DiskCache) write(batch ethdb.Batch) error {
+	meta := make([]byte, 16)
+	binary.BigEndian.PutUint64(meta[:8], uint64(c.size))
+	binary.BigEndian.PutUint64(meta[8:], c.nextSeq)
+	batch.Put(diskMetaKey, meta)
+	diskStoredGauge.Update(c.size)
+	return batch.Write()
+}
+
+// Close closes the database of the cache.
+func (c *DiskCache) Close() error {
+	return c.db.Close()
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/private/engine"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCache_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := OpenDiskCache(dir, 1024*1024)
+	require.NoError(t, err)
+	item := PrivateCacheItem{
+		Payload: []byte("private payload"),
+		Extra: engine.ExtraMetadata{
+			ACHashes:       common.EncryptedPayloadHashes{common.BytesToEncryptedPayloadHash([]byte("ac")): struct{}{}},
+			PrivacyFlag:    engine.PrivacyFlagStateValidation,
+			ManagedParties: []string{"A"},
+			Sender:         "A",
+		},
+	}
+	require.NoError(t, disk.Put("hash", item))
+	require.NoError(t, disk.Close())
+
+	info, err := os.Stat(filepath.Join(dir, diskKeyFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	disk, err = OpenDiskCache(dir, 1024*1024)
+	require.NoError(t, err)
+	defer disk.Close()
+	stored, found := disk.Get("hash")
+	require.True(t, found)
+	assert.Equal(t, item, stored)
+	_, found = disk.Get("unknown")
+	assert.False(t, found)
+
+	// the entries are sealed with their key
+	blob, err := disk.db.Get(diskEntryKey(diskHash("hash")))
+	require.NoError(t, err)
+	assert.NotContains(t, string(blob), "private payload")
+	require.NoError(t, disk.db.Put(diskEntryKey(diskHash("other")), blob))
+	_, found = disk.Get("other")
+	assert.False(t, found)
+}
+
+func TestDiskCache_Corrupt(t *testing.T) {
+	disk, err := OpenDiskCache(t.TempDir(), 1024*1024)
+	require.NoError(t, err)
+	defer disk.Close()
+	require.NoError(t, disk.Put("hash", PrivateCacheItem{Payload: []byte("private payload")}))
+
+	blob, err := disk.db.Get(diskEntryKey(diskHash("hash")))
+	require.NoError(t, err)
+	blob[len(blob)-1] ^= 0xff
+	require.NoError(t, disk.db.Put(diskEntryKey(diskHash("hash")), blob))
+
+	_, found := disk.Get("hash")
+	assert.False(t, found)
+	assert.Zero(t, disk.size)
+	has, err := disk.db.Has(diskEntryKey(diskHash("hash")))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestDiskCache_Limit(t *testing.T) {
+	disk, err := OpenDiskCache(t.TempDir(), 1024)
+	require.NoError(t, err)
+	defer disk.Close()
+	payload := make([]byte, 200)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, disk.Put(key, PrivateCacheItem{Payload: payload}))
+	}
+	assert.LessOrEqual(t, disk.size, int64(1024))
+
+	// the oldest entries are evicted
+	_, found := disk.Get("a")
+	assert.False(t, found)
+	for _, key := range []string{"c", "d"} {
+		_, found := disk.Get(key)
+		assert.True(t, found, key)
+	}
+}
+
+func TestPayloadCache_Disk(t *testing.T) {
+	disk, err := OpenDiskCache(t.TempDir(), 1024*1024)
+	require.NoError(t, err)
+	defer disk.Close()
+	c := NewPayloadCache()
+	c.SetDisk(disk)
+
+	// expired entries spill to disk, from where the misses are served
+	c.Set("expired", PrivateCacheItem{Payload: []byte("expired payload")}, time.Millisecond)
+	c.Set("deleted", PrivateCacheItem{Payload: []byte("deleted payload")}, gocache.DefaultExpiration)
+	time.Sleep(5 * time.Millisecond)
+	c.entries.DeleteExpired()
+	_, found := disk.Get("expired")
+	require.True(t, found)
+	item, found := c.Get("expired")
+	require.True(t, found)
+	assert.Equal(t, []byte("expired payload"), item.Payload)
+
+	// deleted entries do not spill
+	c.Delete("deleted")
+	_, found = disk.Get("deleted")
+	assert.False(t, found)
+
+	c.Set("flushed", PrivateCacheItem{Payload: []byte("flushed payload")}, gocache.DefaultExpiration)
+	c.Flush()
+	item, found = disk.Get("flushed")
+	require.True(t, found)
+	assert.Equal(t, []byte("flushed payload"), item.Payload)
+}
@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/private/engine"
+	lru "github.com/hashicorp/golang-lru"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+var (
+	hitMeter         = metrics.NewRegisteredMeter("private/cache/hits", nil)
+	missMeter        = metrics.NewRegisteredMeter("private/cache/misses", nil)
+	missingHitMeter  = metrics.NewRegisteredMeter("private/cache/missing/hits", nil)
+	dedupHitMeter    = metrics.NewRegisteredMeter("private/cache/dedup/hits", nil)
+	dedupSavedGauge  = metrics.NewRegisteredGauge("private/cache/dedup/saved", nil)
+	storedBytesGauge = metrics.NewRegisteredGauge("private/cache/stored", nil)
+)
+
+// PayloadCache caches decrypted private payloads by encrypted payload hash.
+// Payloads are content addressed: identical plaintexts sent to different sets
+// of recipients share a single stored copy, referenced by every entry, while
+// the extra metadata is kept per entry. A copy is released once the last entry
+// referencing it is deleted or expires.
+//
+// The entries expire, and the least recently used ones are evicted past the
+// size of the cache. With a disk tier, the memory misses are looked up on disk
+// and the expired or evicted entries spill to disk.
+//
+// The payloads missing from the transaction manager, the node not being a
+// party, are cached apart for a shorter time.
+type PayloadCache struct {
+	entries *gocache.Cache
+	recency *lru.Cache // keys of the entries, evicting them past the size
+	setLock sync.Mutex // serialises replacements of entries
+	missing *gocache.Cache
+
+	lock       sync.Mutex
+	contents   map[common.Hash]*sharedPayload
+	nextRef    uint64
+	disk       *DiskCache    // second tier, nil if none
+	ttl        time.Duration // of the entries set with gocache.DefaultExpiration
+	missingTTL time.Duration
+}
+
+// sharedPayload is a stored plaintext along with the entries referencing it.
+type sharedPayload struct {
+	payload []byte
+	refs    map[uint64]struct{}
+}
+
+// payloadEntry is the cached value of an encrypted payload hash. Each entry
+// has a unique reference so that releasing a replaced or already evicted
+// entry twice is harmless.
+type payloadEntry struct {
+	ref     uint64
+	content common.Hash
+	extra   engine.ExtraMetadata
+	deleted int32 // 1 if deleted rather than expired, not to be spilled
+}
+
+// NewPayloadCache creates a payload cache with the default size and
+// expirations.
+func NewPayloadCache() *PayloadCache {
+	c := &PayloadCache{
+		entries:    gocache.New(DefaultExpiration, CleanupInterval),
+		missing:    gocache.New(DefaultMissingExpiration, CleanupInterval),
+		contents:   make(map[common.Hash]*sharedPayload),
+		ttl:        DefaultExpiration,
+		missingTTL: DefaultMissingExpiration,
+	}
+	c.recency, _ = lru.NewWithEvict(DefaultSize, func(key, _ interface{}) {
+		// spilling the entry, unless expired or deleted already
+		c.entries.Delete(key.(string))
+	})
+	c.entries.OnEvicted(func(key string, value interface{}) {
+		entry := value.(*payloadEntry)
+		if atomic.LoadInt32(&entry.deleted) == 0 {
+			c.spill(key, entry)
+		}
+		c.release(entry)
+	})
+	return c
+}
+
+// Configure sets the number of items cached in memory, the time they expire
+// after, and the time the missing items are known missing for, the zero
+// values keeping the defaults.
+func (c *PayloadCache) Configure(size int, ttl, missingTTL time.Duration) {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultExpiration
+	}
+	if missingTTL <= 0 {
+		missingTTL = DefaultMissingExpiration
+	}
+	c.lock.Lock()
+	c.ttl, c.missingTTL = ttl, missingTTL
+	c.lock.Unlock()
+
+	c.setLock.Lock()
+	defer c.setLock.Unlock()
+	c.recency.Resize(size)
+}
+
+// Set caches an item under the given key, replacing any previous item. The
+// item expires after d, or the expiration of the cache if
+// gocache.DefaultExpiration.
+func (c *PayloadCache) Set(key string, item PrivateCacheItem, d time.Duration) {
+	c.setLock.Lock()
+	defer c.setLock.Unlock()
+
+	previous, replaced := c.entries.Get(key)
+
+	c.lock.Lock()
+	if d == gocache.DefaultExpiration {
+		d = c.ttl
+	}
+	content := crypto.Keccak256Hash(item.Payload)
+	shared, ok := c.contents[content]
+	if !ok {
+		shared = &sharedPayload{payload: item.Payload, refs: make(map[uint64]struct{})}
+		c.contents[content] = shared
+		storedBytesGauge.Inc(int64(len(item.Payload)))
+	} else {
+		dedupHitMeter.Mark(1)
+		dedupSavedGauge.Inc(int64(len(item.Payload)))
+	}
+	c.nextRef++
+	entry := &payloadEntry{ref: c.nextRef, content: content, extra: item.Extra}
+	shared.refs[entry.ref] = struct{}{}
+	c.lock.Unlock()
+
+	// replacing an entry does not trigger the eviction callback
+	c.entries.Set(key, entry, d)
+	if replaced {
+		c.release(previous.(*payloadEntry))
+	}
+	c.recency.Add(key, nil)
+	c.missing.Delete(key)
+}
+
+// SetMissing caches that the item of the key is missing from the transaction
+// manager.
+func (c *PayloadCache) SetMissing(key string) {
+	c.lock.Lock()
+	d := c.missingTTL
+	c.lock.Unlock()
+	c.missing.Set(key, struct{}{}, d)
+}
+
+// Missing returns whether the item of the key was found missing from the
+// transaction manager recently.
+func (c *PayloadCache) Missing(key string) bool {
+	_, missing := c.missing.Get(key)
+	if missing {
+		missingHitMeter.Mark(1)
+	}
+	return missing
+}
+
+// Get returns the item cached under the given key.
+func (c *PayloadCache) Get(key string) (PrivateCacheItem, bool) {
+	value, found := c.entries.Get(key)
+	if !found {
+		missMeter.Mark(1)
+		disk := c.diskTier()
+		if disk == nil {
+			return PrivateCacheItem{}, false
+		}
+		item, found := disk.Get(key)
+		if found {
+			c.Set(key, item, gocache.DefaultExpiration)
+		}
+		return item, found
+	}
+	entry := value.(*payloadEntry)
+	c.recency.Get(key)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	shared, ok := c.contents[entry.content]
+	if !ok {
+		// evicted concurrently
+		missMeter.Mark(1)
+		return PrivateCacheItem{}, false
+	}
+	hitMeter.Mark(1)
+	return PrivateCacheItem{Payload: shared.payload, Extra: entry.extra}, true
+}
+
+// Delete removes the item cached under the given key.
+func (c *PayloadCache) Delete(key string) {
+	if value, found := c.entries.Get(key); found {
+		atomic.StoreInt32(&value.(*payloadEntry).deleted, 1)
+	}
+	c.entries.Delete(key)
+	c.recency.Remove(key)
+	c.missing.Delete(key)
+	if disk := c.diskTier(); disk != nil {
+		disk.Delete(key)
+	}
+}
+
+// SetDisk sets the disk tier of the cache, nil to remove it.
+func (c *PayloadCache) SetDisk(disk *DiskCache) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.disk = disk
+}
+
+func (c *PayloadCache) diskTier() *DiskCache {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.disk
+}
+
+// Flush spills all the items cached in memory to the disk tier, keeping them
+// in memory.
+func (c *PayloadCache) Flush() {
+	for key, item := range c.entries.Items() {
+		c.spill(key, item.Object.(*payloadEntry))
+	}
+}
+
+// spill writes the entry to the disk tier, if any.
+func (c *PayloadCache) spill(key string, entry *payloadEntry) {
+	c.lock.Lock()
+	disk, shared := c.disk, c.contents[entry.content]
+	c.lock.Unlock()
+	if disk == nil || shared == nil {
+		return
+	}
+	if err := disk.Put(key, PrivateCacheItem{Payload: shared.payload, Extra: entry.extra}); err != nil {
+		log.Warn("Failed to spill private payload to the disk cache", "err", err)
+	}
+}
+
+// release drops the reference of an entry to its payload, deleting the stored
+// copy once no entry references it anymore.
+func (c *PayloadCache) release(entry *payloadEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	shared, ok := c.contents[entry.content]
+	if !ok {
+		return
+	}
+	if _, ok := shared.refs[entry.ref]; !ok {
+		return
+	}
+	delete(shared.refs, entry.ref)
+	if len(shared.refs) == 0 {
+		delete(c.contents, entry.content)
+		storedBytesGauge.Dec(int64(len(shared.payload)))
+	} else {
+		dedupSavedGauge.Dec(int64(len(shared.payload)))
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/private/engine"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadCache_Dedup(t *testing.T) {
+	c := NewPayloadCache()
+	payload := []byte("identical private payload")
+
+	c.Set("hash1", PrivateCacheItem{Payload: payload, Extra: engine.ExtraMetadata{Sender: "A", PrivacyFlag: engine.PrivacyFlagStandardPrivate}}, gocache.DefaultExpiration)
+	c.Set("hash2", PrivateCacheItem{Payload: append([]byte{}, payload...), Extra: engine.ExtraMetadata{Sender: "B", PrivacyFlag: engine.PrivacyFlagPartyProtection}}, gocache.DefaultExpiration)
+	c.Set("hash3", PrivateCacheItem{Payload: []byte("other payload")}, gocache.DefaultExpiration)
+
+	assert.Len(t, c.contents, 2)
+
+	// payload is shared while the metadata is kept per entry
+	item1, found := c.Get("hash1")
+	require.True(t, found)
+	item2, found := c.Get("hash2")
+	require.True(t, found)
+	assert.Equal(t, payload, item1.Payload)
+	assert.Equal(t, payload, item2.Payload)
+	assert.Equal(t, "A", item1.Extra.Sender)
+	assert.Equal(t, engine.PrivacyFlagStandardPrivate, item1.Extra.PrivacyFlag)
+	assert.Equal(t, "B", item2.Extra.Sender)
+	assert.Equal(t, engine.PrivacyFlagPartyProtection, item2.Extra.PrivacyFlag)
+
+	// the copy is kept until the last reference is dropped
+	c.Delete("hash1")
+	_, found = c.Get("hash1")
+	assert.False(t, found)
+	item2, found = c.Get("hash2")
+	require.True(t, found)
+	assert.Equal(t, payload, item2.Payload)
+
+	// replacing an entry releases its previous payload
+	c.Set("hash2", PrivateCacheItem{Payload: []byte("replaced")}, gocache.DefaultExpiration)
+	assert.Len(t, c.contents, 2)
+
+	c.Delete("hash2")
+	c.Delete("hash3")
+	assert.Empty(t, c.contents)
+}
+
+func TestPayloadCache_LRU(t *testing.T) {
+	disk, err := OpenDiskCache(t.TempDir(), 1024*1024)
+	require.NoError(t, err)
+	defer disk.Close()
+	c := NewPayloadCache()
+	c.Configure(2, 0, 0)
+	c.SetDisk(disk)
+
+	c.Set("hash1", PrivateCacheItem{Payload: []byte("payload1")}, gocache.DefaultExpiration)
+	c.Set("hash2", PrivateCacheItem{Payload: []byte("payload2")}, gocache.DefaultExpiration)
+	_, found := c.Get("hash1")
+	require.True(t, found)
+	c.Set("hash3", PrivateCacheItem{Payload: []byte("payload3")}, gocache.DefaultExpiration)
+
+	// the least recently used entry is evicted, spilling to disk
+	_, found = c.entries.Get("hash2")
+	assert.False(t, found)
+	_, found = c.entries.Get("hash1")
+	assert.True(t, found)
+	assert.Len(t, c.contents, 2)
+	item, found := disk.Get("hash2")
+	require.True(t, found)
+	assert.Equal(t, []byte("payload2"), item.Payload)
+
+	// shrinking the cache evicts the entries past its size
+	c.Configure(1, 0, 0)
+	assert.Equal(t, 1, c.entries.ItemCount())
+	assert.Len(t, c.contents, 1)
+}
+
+func TestPayloadCache_Missing(t *testing.T) {
+	c := NewPayloadCache()
+	c.Configure(0, time.Hour, 5*time.Millisecond)
+
+	c.SetMissing("hash1")
+	assert.True(t, c.Missing("hash1"))
+	assert.False(t, c.Missing("hash2"))
+
+	// the node becoming a party later on
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, c.Missing("hash1"))
+
+	c.SetMissing("hash1")
+	c.Set("hash1", PrivateCacheItem{Payload: []byte("payload1")}, gocache.DefaultExpiration)
+	assert.False(t, c.Missing("hash1"))
+	_, found := c.Get("hash1")
+	assert.True(t, found)
+}
@@ -0,0 +1,127 @@
+package private
+
+import (
+	"time"
+
+	http2 "github.com/ethereum/go-ethereum/common/http"
+)
+
+// Config is the privacy configuration of a node. Geth populates it from the
+// legacy PRIVATE_CONFIG environment variable and the ptm flags, the embedders
+// construct it.
+type Config struct {
+	// TransactionManager is the connection to the private transaction manager,
+	// http2.NoConnectionConfig disabling the private transactions.
+	TransactionManager http2.Config
+
+	// CacheSize is the number of private payloads cached in memory, CacheTTL
+	// the time they are cached for and CacheMissingTTL the time the payloads
+	// the node is not a party to are known missing for, the zero values
+	// keeping the defaults of the cache package.
+	CacheSize       int
+	CacheTTL        time.Duration
+	CacheMissingTTL time.Duration
+
+	// CacheDiskSize is the size in bytes of the disk tier of the cache of the
+	// private payloads, zero disabling it. CacheDir is the directory of the
+	// disk tier, the ptmcache directory of the node if empty.
+	CacheDiskSize int64
+	CacheDir      string
+
+	// PrivateFromCheck selects how the privateFrom of the private transactions
+	// sent is checked to be a key of the transaction manager, warn if empty.
+	PrivateFromCheck PrivateFromCheck
+
+	// HealthCheckInterval is how often the transaction manager is upchecked
+	// for the quorum/ptm gauges, zero disabling the checks.
+	HealthCheckInterval time.Duration
+}
+
+// DisabledConfig disables the private transactions.
+var DisabledConfig = Config{TransactionManager: http2.NoConnectionConfig}
+
+// LegacyConfig returns the configuration of the legacy PRIVATE_CONFIG
+// environment variable, the private transactions being disabled if unset.
+func LegacyConfig() (*Config, error) {
+	cfg, err := GetLegacyEnvironmentConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{TransactionManager: cfg}, nil
+}
+
+// Service is run by the node for as long as it runs, like node.Lifecycle.
+type Service interface {
+	Start() error
+	Stop() error
+}
+
+// Privacy is the private transaction manager of a configuration, with the
+// services it needs the node to run.
+type Privacy struct {
+	PTM PrivateTransactionManager
+
+	router      *http2.Router    // nil unless the transaction manager is clustered
+	cache       *PersistentCache // nil without disk tier
+	privateFrom *keyChecker      // nil if the privateFrom is not checked
+	monitor     *healthMonitor   // nil if the transaction manager is not upchecked
+	enabled     bool
+}
+
+// Open connects to the private transaction manager of the configuration. It
+// leaves P, and the environment of the process, untouched.
+func Open(cfg *Config) (*Privacy, error) {
+	ptm, router, err := newPrivateTxManager(cfg.TransactionManager)
+	if err != nil {
+		return nil, err
+	}
+	privacy := &Privacy{
+		PTM:     ptm,
+		router:  router,
+		enabled: cfg.TransactionManager.ConnectionType != http2.NoConnection,
+	}
+	if privacy.enabled {
+		if privacy.privateFrom, err = newKeyChecker(ptm, cfg.PrivateFromCheck); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := ptm.(Upchecker); ok && privacy.enabled && cfg.HealthCheckInterval > 0 {
+		privacy.monitor = newHealthMonitor(ptm, cfg.HealthCheckInterval)
+	}
+	if cacher, ok := ptm.(PayloadCacher); ok {
+		cacher.PayloadCache().Configure(cfg.CacheSize, cfg.CacheTTL, cfg.CacheMissingTTL)
+	}
+	if cfg.CacheDiskSize > 0 && cfg.CacheDir != "" {
+		if privacy.cache, err = enablePersistentCache(ptm, cfg.CacheDir, cfg.CacheDiskSize); err != nil {
+			return nil, err
+		}
+	}
+	return privacy, nil
+}
+
+// Enabled returns whether the private transactions are enabled.
+func (p *Privacy) Enabled() bool {
+	return p.enabled
+}
+
+// Install makes the transaction manager the one of the process, P, the private
+// transactions being applied with it.
+func (p *Privacy) Install() {
+	P, router, isPrivacyEnabled = p.PTM, p.router, p.enabled
+	privateFromChecker = p.privateFrom
+}
+
+// Services returns the services the node runs for the transaction manager.
+func (p *Privacy) Services() []Service {
+	var services []Service
+	if p.router != nil {
+		services = append(services, p.router)
+	}
+	if p.cache != nil {
+		services = append(services, p.cache)
+	}
+	if p.monitor != nil {
+		services = append(services, p.monitor)
+	}
+	return services
+}
@@ -13,6 +13,7 @@ var (
 	ErrPrivateTxManagerNotReady                          = errors.New("private transaction manager is not ready")
 	ErrPrivateTxManagerNotSupported                      = errors.New("private transaction manager does not support this operation")
 	ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements = errors.New("private transaction manager does not support privacy enhancements")
+	ErrPrivateTxManagerDoesNotSupportMandatoryRecipients = errors.New("private transaction manager does not support mandatory recipients")
 )
 
 // Additional information for the private transaction that Private Transaction Manager carries
@@ -21,13 +22,31 @@ type ExtraMetadata struct {
 	ACHashes common.EncryptedPayloadHashes
 	// Root Hash of a Merkle Trie containing all affected contract account in state objects
 	ACMerkleRoot common.Hash
-	// Privacy flag for contract: standardPrivate, partyProtection, psv
+	// Privacy flag for contract: standardPrivate, partyProtection, mandatoryRecipients, psv
 	PrivacyFlag PrivacyFlagType
+	// Recipients which must always receive the payloads of the contract,
+	// with PrivacyFlagMandatoryRecipients
+	MandatoryRecipients []string
 	// Contract participants that are managed by the corresponding Tessera.
 	// Being used in Multi Tenancy
 	ManagedParties []string
 	// the sender of the transaction
 	Sender string
+	// Contracts the transaction is expected to touch, letting party nodes
+	// execute private transactions with disjoint hints concurrently. Hints are
+	// never trusted: a transaction touching more is executed serially.
+	ExecutionHints []common.Address
+}
+
+// ReceivedPayload is a payload of a batch received from the Private
+// Transaction Manager, Payload being nil if it is unknown and Err set if it
+// could not be received
+type ReceivedPayload struct {
+	Sender         string
+	ManagedParties []string
+	Payload        []byte
+	Extra          *ExtraMetadata
+	Err            error
 }
 
 type Client struct {
@@ -50,9 +69,10 @@ func (c *Client) Get(path string) (*http.Response, error) {
 type PrivacyFlagType uint64
 
 const (
-	PrivacyFlagStandardPrivate PrivacyFlagType = iota                              // 0
-	PrivacyFlagPartyProtection PrivacyFlagType = 1 << PrivacyFlagType(iota-1)      // 1
-	PrivacyFlagStateValidation                 = iota | PrivacyFlagPartyProtection // 3 which includes PrivacyFlagPartyProtection
+	PrivacyFlagStandardPrivate     PrivacyFlagType = iota                              // 0
+	PrivacyFlagPartyProtection     PrivacyFlagType = 1 << PrivacyFlagType(iota-1)      // 1
+	PrivacyFlagMandatoryRecipients PrivacyFlagType = iota                              // 2, party protection enforcing the mandatory recipients
+	PrivacyFlagStateValidation                     = iota | PrivacyFlagPartyProtection // 3 which includes PrivacyFlagPartyProtection
 )
 
 func (f PrivacyFlagType) IsNotStandardPrivate() bool {
@@ -76,7 +96,7 @@ func (f PrivacyFlagType) HasAll(others ...PrivacyFlagType) bool {
 }
 
 func (f PrivacyFlagType) Validate() error {
-	if f == PrivacyFlagStandardPrivate || f == PrivacyFlagPartyProtection || f == PrivacyFlagStateValidation {
+	if f == PrivacyFlagStandardPrivate || f == PrivacyFlagPartyProtection || f == PrivacyFlagMandatoryRecipients || f == PrivacyFlagStateValidation {
 		return nil
 	}
 	return fmt.Errorf("invalid privacy flag")
@@ -88,6 +108,8 @@ const (
 	None                PrivateTransactionManagerFeature = iota                                          // 0
 	PrivacyEnhancements PrivateTransactionManagerFeature = 1 << PrivateTransactionManagerFeature(iota-1) // 1
 	MultiTenancy        PrivateTransactionManagerFeature = 1 << PrivateTransactionManagerFeature(iota-1) // 2
+	BatchReceive        PrivateTransactionManagerFeature = 1 << PrivateTransactionManagerFeature(iota-1) // 4
+	MandatoryRecipients PrivateTransactionManagerFeature = 1 << PrivateTransactionManagerFeature(iota-1) // 8
 )
 
 type FeatureSet struct {
@@ -54,6 +54,16 @@ func TestPrivacyFlag_whenPrivateStateValidation(t *testing.T) {
 	assert.True(PrivacyFlagStateValidation.Has(PrivacyFlagPartyProtection), "State Validation must have party protection by default")
 }
 
+func TestPrivacyFlag_whenMandatoryRecipients(t *testing.T) {
+	assert := assert.New(t)
+
+	flag := PrivacyFlagMandatoryRecipients
+
+	assert.True(flag.IsNotStandardPrivate())
+	assert.False(flag.Has(PrivacyFlagStateValidation), "Mandatory Recipients must not validate the private state")
+	assert.NoError(flag.Validate())
+}
+
 func TestPrivacyFlagType_Validate_whenSuccess(t *testing.T) {
 	assert := assert.New(t)
 
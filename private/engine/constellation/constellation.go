@@ -1,19 +1,17 @@
 package constellation
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/ethereum/go-ethereum/private/engine"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/private/cache"
-
-	gocache "github.com/patrickmn/go-cache"
 )
 
 type constellation struct {
 	node *Client
-	c    *gocache.Cache
+	c    *cache.PayloadCache
 }
 
 func Is(ptm interface{}) bool {
@@ -26,15 +24,20 @@ func New(client *engine.Client) *constellation {
 		node: &Client{
 			httpClient: client.HttpClient,
 		},
-		c: gocache.New(cache.DefaultExpiration, cache.CleanupInterval),
+		c: cache.NewPayloadCache(),
 	}
 }
 
-func (g *constellation) Send(data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
+// PayloadCache returns the cache of the decrypted payloads.
+func (g *constellation) PayloadCache() *cache.PayloadCache {
+	return g.c
+}
+
+func (g *constellation) Send(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
 	if extra.PrivacyFlag.IsNotStandardPrivate() {
 		return "", nil, common.EncryptedPayloadHash{}, engine.ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements
 	}
-	out, err := g.node.SendPayload(data, from, to, extra.ACHashes, extra.ACMerkleRoot)
+	out, err := g.node.SendPayload(ctx, data, from, to, extra.ACHashes, extra.ACMerkleRoot)
 	if err != nil {
 		return "", nil, common.EncryptedPayloadHash{}, err
 	}
@@ -46,35 +49,43 @@ func (g *constellation) Send(data []byte, from string, to []string, extra *engin
 	return "", nil, out, nil
 }
 
-func (g *constellation) EncryptPayload(data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
+func (g *constellation) EncryptPayload(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
 	return nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) DecryptPayload(payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
+func (g *constellation) DecryptPayload(ctx context.Context, payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
 	return nil, nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) StoreRaw(data []byte, from string) (common.EncryptedPayloadHash, error) {
+func (g *constellation) StoreRaw(ctx context.Context, data []byte, from string) (common.EncryptedPayloadHash, error) {
 	return common.EncryptedPayloadHash{}, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) SendSignedTx(data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
+func (g *constellation) SendSignedTx(ctx context.Context, data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
 	return "", nil, nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) ReceiveRaw(data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+func (g *constellation) ReceiveRaw(ctx context.Context, data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
 	return nil, "", nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) IsSender(txHash common.EncryptedPayloadHash) (bool, error) {
+func (g *constellation) IsSender(ctx context.Context, txHash common.EncryptedPayloadHash) (bool, error) {
 	return false, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) GetParticipants(txHash common.EncryptedPayloadHash) ([]string, error) {
+func (g *constellation) GetParticipants(ctx context.Context, txHash common.EncryptedPayloadHash) ([]string, error) {
+	return nil, engine.ErrPrivateTxManagerNotSupported
+}
+
+func (g *constellation) Resend(ctx context.Context, txHash common.EncryptedPayloadHash, recipient string) error {
+	return engine.ErrPrivateTxManagerNotSupported
+}
+
+func (g *constellation) ReceiveBatch(ctx context.Context, data []common.EncryptedPayloadHash) ([]engine.ReceivedPayload, error) {
 	return nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (g *constellation) Receive(data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+func (g *constellation) Receive(ctx context.Context, data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
 	if common.EmptyEncryptedPayloadHash(data) {
 		return "", nil, nil, nil, nil
 	}
@@ -83,15 +94,10 @@ func (g *constellation) Receive(data common.EncryptedPayloadHash) (string, []str
 	// TODO: Return an error if it's anything OTHER than
 	// 'you are not a recipient.'
 	cacheKey := string(data.Bytes())
-	x, found := g.c.Get(cacheKey)
-	if found {
-		cacheItem, ok := x.(cache.PrivateCacheItem)
-		if !ok {
-			return "", nil, nil, nil, fmt.Errorf("unknown cache item. expected type PrivateCacheItem")
-		}
+	if cacheItem, found := g.c.Get(cacheKey); found {
 		return "", nil, cacheItem.Payload, &cacheItem.Extra, nil
 	}
-	privatePayload, acHashes, acMerkleRoot, err := g.node.ReceivePayload(data)
+	privatePayload, acHashes, acMerkleRoot, err := g.node.ReceivePayload(ctx, data)
 	if nil != err {
 		return "", nil, nil, nil, err
 	}
@@ -106,6 +112,10 @@ func (g *constellation) Receive(data common.EncryptedPayloadHash) (string, []str
 	return "", nil, privatePayload, &extra, nil
 }
 
+func (g *constellation) Upcheck() error {
+	return g.node.Upcheck()
+}
+
 func (g *constellation) Name() string {
 	return "Constellation"
 }
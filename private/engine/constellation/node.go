@@ -2,6 +2,7 @@ package constellation
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
@@ -15,11 +16,11 @@ type Client struct {
 	httpClient *http.Client
 }
 
-func (c *Client) SendPayload(pl []byte, b64From string, b64To []string, acHashes common.EncryptedPayloadHashes, acMerkleRoot common.Hash) (common.EncryptedPayloadHash, error) {
+func (c *Client) SendPayload(ctx context.Context, pl []byte, b64From string, b64To []string, acHashes common.EncryptedPayloadHashes, acMerkleRoot common.Hash) (common.EncryptedPayloadHash, error) {
 	method := "POST"
 	url := "http+unix://c/sendraw"
 	buf := bytes.NewBuffer(pl)
-	req, err := http.NewRequest(method, url, buf)
+	req, err := http.NewRequestWithContext(ctx, method, url, buf)
 	if err != nil {
 		return common.EncryptedPayloadHash{}, fmt.Errorf("unable to build request for (method:%s,path:%s). Cause: %v", method, url, err)
 	}
@@ -47,10 +48,10 @@ func (c *Client) SendPayload(pl []byte, b64From string, b64To []string, acHashes
 	return common.BytesToEncryptedPayloadHash(hashBytes), nil
 }
 
-func (c *Client) ReceivePayload(key common.EncryptedPayloadHash) ([]byte, common.EncryptedPayloadHashes, common.Hash, error) {
+func (c *Client) ReceivePayload(ctx context.Context, key common.EncryptedPayloadHash) ([]byte, common.EncryptedPayloadHashes, common.Hash, error) {
 	method := "GET"
 	url := "http+unix://c/receiveraw"
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, nil, common.Hash{}, fmt.Errorf("unable to build request for (method:%s,url:%s). Cause: %v", method, url, err)
 	}
@@ -75,3 +76,16 @@ func (c *Client) ReceivePayload(key common.EncryptedPayloadHash) ([]byte, common
 
 	return payload, nil, common.Hash{}, nil
 }
+
+func (c *Client) Upcheck() error {
+	url := "http+unix://c/upcheck"
+	res, err := c.httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("unable to submit request (method:GET,url:%s). Cause: %v", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("Non-200 status code: %+v", res)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package notinuse
 
 import (
+	"context"
 	"errors"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -13,43 +14,51 @@ var ErrPrivateTxManagerNotInUse = errors.New("private transaction manager is not
 // stating that no private transaction manager is being used by the node
 type PrivateTransactionManager struct{}
 
-func (ptm *PrivateTransactionManager) IsSender(txHash common.EncryptedPayloadHash) (bool, error) {
+func (ptm *PrivateTransactionManager) IsSender(ctx context.Context, txHash common.EncryptedPayloadHash) (bool, error) {
 	panic("implement me")
 }
 
-func (ptm *PrivateTransactionManager) GetParticipants(txHash common.EncryptedPayloadHash) ([]string, error) {
+func (ptm *PrivateTransactionManager) GetParticipants(ctx context.Context, txHash common.EncryptedPayloadHash) ([]string, error) {
 	panic("implement me")
 }
 
-func (ptm *PrivateTransactionManager) Send(data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
+func (ptm *PrivateTransactionManager) Send(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
 	return "", nil, common.EncryptedPayloadHash{}, engine.ErrPrivateTxManagerNotinUse
 }
 
-func (ptm *PrivateTransactionManager) EncryptPayload(data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
+func (ptm *PrivateTransactionManager) EncryptPayload(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
 	return nil, engine.ErrPrivateTxManagerNotinUse
 }
 
-func (ptm *PrivateTransactionManager) DecryptPayload(payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
+func (ptm *PrivateTransactionManager) DecryptPayload(ctx context.Context, payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
 	return nil, nil, engine.ErrPrivateTxManagerNotSupported
 }
 
-func (ptm *PrivateTransactionManager) StoreRaw(data []byte, from string) (common.EncryptedPayloadHash, error) {
+func (ptm *PrivateTransactionManager) StoreRaw(ctx context.Context, data []byte, from string) (common.EncryptedPayloadHash, error) {
 	return common.EncryptedPayloadHash{}, engine.ErrPrivateTxManagerNotinUse
 }
 
-func (ptm *PrivateTransactionManager) SendSignedTx(data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
+func (ptm *PrivateTransactionManager) SendSignedTx(ctx context.Context, data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
 	return "", nil, nil, engine.ErrPrivateTxManagerNotinUse
 }
 
-func (ptm *PrivateTransactionManager) Receive(data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+func (ptm *PrivateTransactionManager) Receive(ctx context.Context, data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
 	//error not thrown here, acts as though no private data to fetch
 	return "", nil, nil, nil, nil
 }
 
-func (ptm *PrivateTransactionManager) ReceiveRaw(data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+func (ptm *PrivateTransactionManager) ReceiveRaw(ctx context.Context, data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
 	return nil, "", nil, engine.ErrPrivateTxManagerNotinUse
 }
 
+func (ptm *PrivateTransactionManager) ReceiveBatch(ctx context.Context, data []common.EncryptedPayloadHash) ([]engine.ReceivedPayload, error) {
+	return nil, engine.ErrPrivateTxManagerNotinUse
+}
+
+func (ptm *PrivateTransactionManager) Resend(ctx context.Context, txHash common.EncryptedPayloadHash, recipient string) error {
+	return engine.ErrPrivateTxManagerNotinUse
+}
+
 func (ptm *PrivateTransactionManager) Name() string {
 	return "NotInUse"
 }
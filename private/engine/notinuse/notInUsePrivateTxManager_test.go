@@ -1,6 +1,7 @@
 package notinuse
 
 import (
+	"context"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -18,7 +19,7 @@ func TestName(t *testing.T) {
 func TestSendReturnsError(t *testing.T) {
 	ptm := &PrivateTransactionManager{}
 
-	_, _, _, err := ptm.Send([]byte{}, "", []string{}, nil)
+	_, _, _, err := ptm.Send(context.Background(), []byte{}, "", []string{}, nil)
 
 	assert.Equal(t, err, engine.ErrPrivateTxManagerNotinUse, "got wrong error in 'send'")
 }
@@ -26,7 +27,7 @@ func TestSendReturnsError(t *testing.T) {
 func TestStoreRawReturnsError(t *testing.T) {
 	ptm := &PrivateTransactionManager{}
 
-	_, err := ptm.StoreRaw([]byte{}, "")
+	_, err := ptm.StoreRaw(context.Background(), []byte{}, "")
 
 	assert.Equal(t, err, engine.ErrPrivateTxManagerNotinUse, "got wrong error in 'storeraw'")
 }
@@ -34,7 +35,7 @@ func TestStoreRawReturnsError(t *testing.T) {
 func TestReceiveReturnsEmpty(t *testing.T) {
 	ptm := &PrivateTransactionManager{}
 
-	_, _, data, metadata, err := ptm.Receive(common.EncryptedPayloadHash{})
+	_, _, data, metadata, err := ptm.Receive(context.Background(), common.EncryptedPayloadHash{})
 
 	assert.Nil(t, err, "got unexpected error in 'receive'")
 	assert.Nil(t, data, "got unexpected data in 'receive'")
@@ -44,7 +45,7 @@ func TestReceiveReturnsEmpty(t *testing.T) {
 func TestReceiveRawReturnsError(t *testing.T) {
 	ptm := &PrivateTransactionManager{}
 
-	_, _, _, err := ptm.ReceiveRaw(common.EncryptedPayloadHash{})
+	_, _, _, err := ptm.ReceiveRaw(context.Background(), common.EncryptedPayloadHash{})
 
 	assert.Equal(t, err, engine.ErrPrivateTxManagerNotinUse, "got wrong error in 'send'")
 }
@@ -52,7 +53,7 @@ func TestReceiveRawReturnsError(t *testing.T) {
 func TestSendSignedTxReturnsError(t *testing.T) {
 	ptm := &PrivateTransactionManager{}
 
-	_, _, _, err := ptm.SendSignedTx(common.EncryptedPayloadHash{}, []string{}, nil)
+	_, _, _, err := ptm.SendSignedTx(context.Background(), common.EncryptedPayloadHash{}, []string{}, nil)
 
 	assert.Equal(t, err, engine.ErrPrivateTxManagerNotinUse, "got wrong error in 'SendSignedTx'")
 }
@@ -1,6 +1,9 @@
 package tessera
 
-import "github.com/ethereum/go-ethereum/private/engine"
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/private/engine"
+)
 
 // request object for /send API
 type sendRequest struct {
@@ -18,6 +21,12 @@ type sendRequest struct {
 	ExecHash string `json:"execHash,omitempty"`
 
 	PrivacyFlag engine.PrivacyFlagType `json:"privacyFlag"`
+
+	// Public keys which must always receive the payloads of the contract
+	MandatoryRecipients []string `json:"mandatoryRecipients,omitempty"`
+
+	// Contracts the transaction is expected to touch
+	ExecutionHints []common.Address `json:"executionHints,omitempty"`
 }
 
 // request object for /send API
@@ -49,12 +58,27 @@ type receiveResponse struct {
 
 	PrivacyFlag engine.PrivacyFlagType `json:"privacyFlag"`
 
+	// Contracts the transaction is expected to touch
+	ExecutionHints []common.Address `json:"executionHints,omitempty"`
+
 	// Public Keys
 	ManagedParties []string `json:"managedParties"`
 	// Sender tessera public key
 	SenderKey string `json:"senderKey"`
 }
 
+// request object for /resend API
+type resendRequest struct {
+	// INDIVIDUAL resends the single payload identified by Key
+	Type string `json:"type"`
+
+	// base64-encoded
+	PublicKey string `json:"publicKey"`
+
+	// base64-encoded
+	Key string `json:"key"`
+}
+
 type sendSignedTxRequest struct {
 	Hash []byte   `json:"hash"`
 	To   []string `json:"to"`
@@ -64,6 +88,12 @@ type sendSignedTxRequest struct {
 	ExecHash string `json:"execHash,omitempty"`
 
 	PrivacyFlag engine.PrivacyFlagType `json:"privacyFlag"`
+
+	// Public keys which must always receive the payloads of the contract
+	MandatoryRecipients []string `json:"mandatoryRecipients,omitempty"`
+
+	// Contracts the transaction is expected to touch
+	ExecutionHints []common.Address `json:"executionHints,omitempty"`
 }
 
 type sendSignedTxResponse struct {
@@ -92,3 +122,29 @@ type decryptPayloadRequest struct {
 	RecipientNonce  []byte   `json:"recipientNonce"`
 	RecipientKeys   []string `json:"recipientKeys"`
 }
+
+// keysResponse is the response of /keys, listing the public keys of Tessera.
+type keysResponse struct {
+	Keys []struct {
+		Key string `json:"key"`
+	} `json:"keys"`
+}
+
+// request object for /receive/batch API
+type receiveBatchRequest struct {
+	// Encrypted payload hashes, base64 encoded
+	Keys []string `json:"keys"`
+}
+
+// response object for /receive/batch API, the unknown payloads being omitted
+type receiveBatchResponse struct {
+	Payloads []receiveBatchPayload `json:"payloads"`
+}
+
+type receiveBatchPayload struct {
+	receiveResponse
+	// Encrypted payload hash, base64 encoded
+	Key string `json:"key"`
+	// Error receiving the payload, if any
+	Error string `json:"error,omitempty"`
+}
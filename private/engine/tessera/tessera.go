@@ -2,6 +2,7 @@ package tessera
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -22,7 +23,7 @@ import (
 type tesseraPrivateTxManager struct {
 	features *engine.FeatureSet
 	client   *engine.Client
-	cache    *gocache.Cache
+	cache    *cache.PayloadCache
 }
 
 func Is(ptm interface{}) bool {
@@ -38,16 +39,21 @@ func New(client *engine.Client, version []byte) *tesseraPrivateTxManager {
 	return &tesseraPrivateTxManager{
 		features: engine.NewFeatureSet(tesseraVersionFeatures(ptmVersion)...),
 		client:   client,
-		cache:    gocache.New(cache.DefaultExpiration, cache.CleanupInterval),
+		cache:    cache.NewPayloadCache(),
 	}
 }
 
-func (t *tesseraPrivateTxManager) submitJSON(method, path string, request interface{}, response interface{}) (int, error) {
+// PayloadCache returns the cache of the decrypted payloads.
+func (t *tesseraPrivateTxManager) PayloadCache() *cache.PayloadCache {
+	return t.cache
+}
+
+func (t *tesseraPrivateTxManager) submitJSON(ctx context.Context, method, path string, request interface{}, response interface{}) (int, error) {
 	apiVersion := ""
 	if t.features.HasFeature(engine.MultiTenancy) {
 		apiVersion = "vnd.tessera-2.1+"
 	}
-	req, err := newOptionalJSONRequest(method, t.client.FullPath(path), request, apiVersion)
+	req, err := newOptionalJSONRequest(ctx, method, t.client.FullPath(path), request, apiVersion)
 	if err != nil {
 		return -1, fmt.Errorf("unable to build json request for (method:%s,path:%s). Cause: %v", method, path, err)
 	}
@@ -66,9 +72,9 @@ func (t *tesseraPrivateTxManager) submitJSON(method, path string, request interf
 	return res.StatusCode, nil
 }
 
-func (t *tesseraPrivateTxManager) submitJSONOld(method, path string, request interface{}, response interface{}) (int, error) {
+func (t *tesseraPrivateTxManager) submitJSONOld(ctx context.Context, method, path string, request interface{}, response interface{}) (int, error) {
 	apiVersion := ""
-	req, err := newOptionalJSONRequest(method, t.client.FullPath(path), request, apiVersion)
+	req, err := newOptionalJSONRequest(ctx, method, t.client.FullPath(path), request, apiVersion)
 	if err != nil {
 		return -1, fmt.Errorf("unable to build json request for (method:%s,path:%s). Cause: %v", method, path, err)
 	}
@@ -87,22 +93,27 @@ func (t *tesseraPrivateTxManager) submitJSONOld(method, path string, request int
 	return res.StatusCode, nil
 }
 
-func (t *tesseraPrivateTxManager) Send(data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
+func (t *tesseraPrivateTxManager) Send(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error) {
 	if extra.PrivacyFlag.IsNotStandardPrivate() && !t.features.HasFeature(engine.PrivacyEnhancements) {
 		return "", nil, common.EncryptedPayloadHash{}, engine.ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements
 	}
+	if extra.PrivacyFlag == engine.PrivacyFlagMandatoryRecipients && !t.features.HasFeature(engine.MandatoryRecipients) {
+		return "", nil, common.EncryptedPayloadHash{}, engine.ErrPrivateTxManagerDoesNotSupportMandatoryRecipients
+	}
 	response := new(sendResponse)
 	acMerkleRoot := ""
 	if !common.EmptyHash(extra.ACMerkleRoot) {
 		acMerkleRoot = extra.ACMerkleRoot.ToBase64()
 	}
-	if _, err := t.submitJSON("POST", "/send", &sendRequest{
+	if _, err := t.submitJSON(ctx, "POST", "/send", &sendRequest{
 		Payload:                      data,
 		From:                         from,
 		To:                           to,
 		AffectedContractTransactions: extra.ACHashes.ToBase64s(),
 		ExecHash:                     acMerkleRoot,
 		PrivacyFlag:                  extra.PrivacyFlag,
+		MandatoryRecipients:          extra.MandatoryRecipients,
+		ExecutionHints:               extra.ExecutionHints,
 	}, response); err != nil {
 		return "", nil, common.EncryptedPayloadHash{}, err
 	}
@@ -116,31 +127,34 @@ func (t *tesseraPrivateTxManager) Send(data []byte, from string, to []string, ex
 	t.cache.Set(cacheKey, cache.PrivateCacheItem{
 		Payload: data,
 		Extra: engine.ExtraMetadata{
-			ACHashes:       extra.ACHashes,
-			ACMerkleRoot:   extra.ACMerkleRoot,
-			PrivacyFlag:    extra.PrivacyFlag,
-			ManagedParties: response.ManagedParties,
-			Sender:         response.SenderKey,
+			ACHashes:            extra.ACHashes,
+			ACMerkleRoot:        extra.ACMerkleRoot,
+			PrivacyFlag:         extra.PrivacyFlag,
+			MandatoryRecipients: extra.MandatoryRecipients,
+			ManagedParties:      response.ManagedParties,
+			Sender:              response.SenderKey,
+			ExecutionHints:      extra.ExecutionHints,
 		},
 	}, gocache.DefaultExpiration)
 
 	return response.SenderKey, response.ManagedParties, eph, nil
 }
 
-func (t *tesseraPrivateTxManager) EncryptPayload(data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
+func (t *tesseraPrivateTxManager) EncryptPayload(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error) {
 	response := new(encryptPayloadResponse)
 	acMerkleRoot := ""
 	if !common.EmptyHash(extra.ACMerkleRoot) {
 		acMerkleRoot = extra.ACMerkleRoot.ToBase64()
 	}
 
-	if _, err := t.submitJSON("POST", "/encodedpayload/create", &sendRequest{
+	if _, err := t.submitJSON(ctx, "POST", "/encodedpayload/create", &sendRequest{
 		Payload:                      data,
 		From:                         from,
 		To:                           to,
 		AffectedContractTransactions: extra.ACHashes.ToBase64s(),
 		ExecHash:                     acMerkleRoot,
 		PrivacyFlag:                  extra.PrivacyFlag,
+		MandatoryRecipients:          extra.MandatoryRecipients,
 	}, response); err != nil {
 		return nil, err
 	}
@@ -149,11 +163,11 @@ func (t *tesseraPrivateTxManager) EncryptPayload(data []byte, from string, to []
 	return output, nil
 }
 
-func (t *tesseraPrivateTxManager) StoreRaw(data []byte, from string) (common.EncryptedPayloadHash, error) {
+func (t *tesseraPrivateTxManager) StoreRaw(ctx context.Context, data []byte, from string) (common.EncryptedPayloadHash, error) {
 
 	response := new(sendResponse)
 
-	if _, err := t.submitJSON("POST", "/storeraw", &storerawRequest{
+	if _, err := t.submitJSON(ctx, "POST", "/storeraw", &storerawRequest{
 		Payload: data,
 		From:    from,
 	}, response); err != nil {
@@ -177,9 +191,9 @@ func (t *tesseraPrivateTxManager) StoreRaw(data []byte, from string) (common.Enc
 }
 
 // allow new quorum to send raw transactions when connected to an old tessera
-func (c *tesseraPrivateTxManager) sendSignedPayloadOctetStream(signedPayload []byte, b64To []string) (string, []string, []byte, error) {
+func (c *tesseraPrivateTxManager) sendSignedPayloadOctetStream(ctx context.Context, signedPayload []byte, b64To []string) (string, []string, []byte, error) {
 	buf := bytes.NewBuffer(signedPayload)
-	req, err := http.NewRequest("POST", c.client.FullPath("/sendsignedtx"), buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.client.FullPath("/sendsignedtx"), buf)
 	if err != nil {
 		return "", nil, nil, err
 	}
@@ -207,10 +221,13 @@ func (c *tesseraPrivateTxManager) sendSignedPayloadOctetStream(signedPayload []b
 }
 
 // also populate cache item with additional extra metadata
-func (t *tesseraPrivateTxManager) SendSignedTx(data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
+func (t *tesseraPrivateTxManager) SendSignedTx(ctx context.Context, data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error) {
 	if extra.PrivacyFlag.IsNotStandardPrivate() && !t.features.HasFeature(engine.PrivacyEnhancements) {
 		return "", nil, nil, engine.ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements
 	}
+	if extra.PrivacyFlag == engine.PrivacyFlagMandatoryRecipients && !t.features.HasFeature(engine.MandatoryRecipients) {
+		return "", nil, nil, engine.ErrPrivateTxManagerDoesNotSupportMandatoryRecipients
+	}
 	response := new(sendSignedTxResponse)
 	acMerkleRoot := ""
 	if !common.EmptyHash(extra.ACMerkleRoot) {
@@ -219,17 +236,19 @@ func (t *tesseraPrivateTxManager) SendSignedTx(data common.EncryptedPayloadHash,
 	// The /sendsignedtx has been updated as part of privacy enhancements to support a json payload.
 	// If an older tessera is used - invoke the octetstream version of the /sendsignedtx
 	if t.features.HasFeature(engine.PrivacyEnhancements) {
-		if _, err := t.submitJSON("POST", "/sendsignedtx", &sendSignedTxRequest{
+		if _, err := t.submitJSON(ctx, "POST", "/sendsignedtx", &sendSignedTxRequest{
 			Hash:                         data.Bytes(),
 			To:                           to,
 			AffectedContractTransactions: extra.ACHashes.ToBase64s(),
 			ExecHash:                     acMerkleRoot,
 			PrivacyFlag:                  extra.PrivacyFlag,
+			MandatoryRecipients:          extra.MandatoryRecipients,
+			ExecutionHints:               extra.ExecutionHints,
 		}, response); err != nil {
 			return "", nil, nil, err
 		}
 	} else {
-		sender, managedParties, returnedHash, err := t.sendSignedPayloadOctetStream(data.Bytes(), to)
+		sender, managedParties, returnedHash, err := t.sendSignedPayloadOctetStream(ctx, data.Bytes(), to)
 		if err != nil {
 			return "", nil, nil, err
 		}
@@ -245,37 +264,37 @@ func (t *tesseraPrivateTxManager) SendSignedTx(data common.EncryptedPayloadHash,
 	// pull incomplete cache item and inject new cache item with complete information
 	cacheKey := data.Hex()
 	cacheKeyTemp := fmt.Sprintf("%s-incomplete", cacheKey)
-	if item, found := t.cache.Get(cacheKeyTemp); found {
-		if incompleteCacheItem, ok := item.(cache.PrivateCacheItem); ok {
-			t.cache.Set(cacheKey, cache.PrivateCacheItem{
-				Payload: incompleteCacheItem.Payload,
-				Extra: engine.ExtraMetadata{
-					ACHashes:       extra.ACHashes,
-					ACMerkleRoot:   extra.ACMerkleRoot,
-					PrivacyFlag:    extra.PrivacyFlag,
-					ManagedParties: response.ManagedParties,
-					Sender:         response.SenderKey,
-				},
-			}, gocache.DefaultExpiration)
-			t.cache.Delete(cacheKeyTemp)
-		}
+	if incompleteCacheItem, found := t.cache.Get(cacheKeyTemp); found {
+		t.cache.Set(cacheKey, cache.PrivateCacheItem{
+			Payload: incompleteCacheItem.Payload,
+			Extra: engine.ExtraMetadata{
+				ACHashes:            extra.ACHashes,
+				ACMerkleRoot:        extra.ACMerkleRoot,
+				PrivacyFlag:         extra.PrivacyFlag,
+				MandatoryRecipients: extra.MandatoryRecipients,
+				ManagedParties:      response.ManagedParties,
+				Sender:              response.SenderKey,
+				ExecutionHints:      extra.ExecutionHints,
+			},
+		}, gocache.DefaultExpiration)
+		t.cache.Delete(cacheKeyTemp)
 	}
 	return response.SenderKey, response.ManagedParties, hashBytes, err
 }
 
-func (t *tesseraPrivateTxManager) Receive(hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
-	return t.receive(hash, false)
+func (t *tesseraPrivateTxManager) Receive(ctx context.Context, hash common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error) {
+	return t.receive(ctx, hash, false)
 }
 
 // retrieve raw will not return information about medata.
 // Related to SendSignedTx
-func (t *tesseraPrivateTxManager) ReceiveRaw(hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
-	sender, _, data, extra, err := t.receive(hash, true)
+func (t *tesseraPrivateTxManager) ReceiveRaw(ctx context.Context, hash common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error) {
+	sender, _, data, extra, err := t.receive(ctx, hash, true)
 	return data, sender, extra, err
 }
 
 // retrieve raw will not return information about medata
-func (t *tesseraPrivateTxManager) receive(data common.EncryptedPayloadHash, isRaw bool) (string, []string, []byte, *engine.ExtraMetadata, error) {
+func (t *tesseraPrivateTxManager) receive(ctx context.Context, data common.EncryptedPayloadHash, isRaw bool) (string, []string, []byte, *engine.ExtraMetadata, error) {
 	if common.EmptyEncryptedPayloadHash(data) {
 		return "", nil, nil, nil, nil
 	}
@@ -284,17 +303,17 @@ func (t *tesseraPrivateTxManager) receive(data common.EncryptedPayloadHash, isRa
 		// indicate the cache item is incomplete, this will be fulfilled in SendSignedTx
 		cacheKey = fmt.Sprintf("%s-incomplete", cacheKey)
 	}
-	if item, found := t.cache.Get(cacheKey); found {
-		cacheItem, ok := item.(cache.PrivateCacheItem)
-		if !ok {
-			return "", nil, nil, nil, fmt.Errorf("unknown cache item. expected type PrivateCacheItem")
-		}
+	if cacheItem, found := t.cache.Get(cacheKey); found {
 		return cacheItem.Extra.Sender, cacheItem.Extra.ManagedParties, cacheItem.Payload, &cacheItem.Extra, nil
 	}
+	if t.cache.Missing(cacheKey) {
+		return "", nil, nil, nil, nil
+	}
 
 	response := new(receiveResponse)
-	if statusCode, err := t.submitJSON("GET", fmt.Sprintf("/transaction/%s?isRaw=%v", url.PathEscape(data.ToBase64()), isRaw), nil, response); err != nil {
+	if statusCode, err := t.submitJSON(ctx, "GET", fmt.Sprintf("/transaction/%s?isRaw=%v", url.PathEscape(data.ToBase64()), isRaw), nil, response); err != nil {
 		if statusCode == http.StatusNotFound {
+			t.cache.SetMissing(cacheKey)
 			return "", nil, nil, nil, nil
 		} else {
 			return "", nil, nil, nil, err
@@ -302,20 +321,9 @@ func (t *tesseraPrivateTxManager) receive(data common.EncryptedPayloadHash, isRa
 	}
 	var extra engine.ExtraMetadata
 	if !isRaw {
-		acHashes, err := common.Base64sToEncryptedPayloadHashes(response.AffectedContractTransactions)
-		if err != nil {
-			return "", nil, nil, nil, fmt.Errorf("unable to decode ACOTHs %v. Cause: %v", response.AffectedContractTransactions, err)
-		}
-		acMerkleRoot, err := common.Base64ToHash(response.ExecHash)
-		if err != nil {
-			return "", nil, nil, nil, fmt.Errorf("unable to decode execution hash %s. Cause: %v", response.ExecHash, err)
-		}
-		extra = engine.ExtraMetadata{
-			ACHashes:       acHashes,
-			ACMerkleRoot:   acMerkleRoot,
-			PrivacyFlag:    response.PrivacyFlag,
-			ManagedParties: response.ManagedParties,
-			Sender:         response.SenderKey,
+		var err error
+		if extra, err = receivedExtra(response); err != nil {
+			return "", nil, nil, nil, err
 		}
 	}
 
@@ -327,10 +335,85 @@ func (t *tesseraPrivateTxManager) receive(data common.EncryptedPayloadHash, isRa
 	return response.SenderKey, response.ManagedParties, response.Payload, &extra, nil
 }
 
+// receivedExtra decodes the extra metadata of a received payload
+func receivedExtra(response *receiveResponse) (engine.ExtraMetadata, error) {
+	acHashes, err := common.Base64sToEncryptedPayloadHashes(response.AffectedContractTransactions)
+	if err != nil {
+		return engine.ExtraMetadata{}, fmt.Errorf("unable to decode ACOTHs %v. Cause: %v", response.AffectedContractTransactions, err)
+	}
+	acMerkleRoot, err := common.Base64ToHash(response.ExecHash)
+	if err != nil {
+		return engine.ExtraMetadata{}, fmt.Errorf("unable to decode execution hash %s. Cause: %v", response.ExecHash, err)
+	}
+	return engine.ExtraMetadata{
+		ACHashes:       acHashes,
+		ACMerkleRoot:   acMerkleRoot,
+		PrivacyFlag:    response.PrivacyFlag,
+		ManagedParties: response.ManagedParties,
+		Sender:         response.SenderKey,
+		ExecutionHints: response.ExecutionHints,
+	}, nil
+}
+
+// ReceiveBatch receives the payloads not cached in one request to the
+// /receive/batch endpoint of Tessera, the payloads it does not know being nil.
+func (t *tesseraPrivateTxManager) ReceiveBatch(ctx context.Context, data []common.EncryptedPayloadHash) ([]engine.ReceivedPayload, error) {
+	if !t.features.HasFeature(engine.BatchReceive) {
+		return nil, engine.ErrPrivateTxManagerNotSupported
+	}
+	results := make([]engine.ReceivedPayload, len(data))
+	pending := make(map[string][]int) // indexes of each hash requested
+	request := new(receiveBatchRequest)
+	for i, hash := range data {
+		if common.EmptyEncryptedPayloadHash(hash) || t.cache.Missing(hash.Hex()) {
+			continue
+		}
+		if cacheItem, found := t.cache.Get(hash.Hex()); found {
+			extra := cacheItem.Extra
+			results[i] = engine.ReceivedPayload{Sender: extra.Sender, ManagedParties: extra.ManagedParties, Payload: cacheItem.Payload, Extra: &extra}
+			continue
+		}
+		key := hash.ToBase64()
+		if _, requested := pending[key]; !requested {
+			request.Keys = append(request.Keys, key)
+		}
+		pending[key] = append(pending[key], i)
+	}
+	if len(request.Keys) == 0 {
+		return results, nil
+	}
+	response := new(receiveBatchResponse)
+	if _, err := t.submitJSON(ctx, "POST", "/receive/batch", request, response); err != nil {
+		return nil, err
+	}
+	for _, payload := range response.Payloads {
+		received := engine.ReceivedPayload{Sender: payload.SenderKey, ManagedParties: payload.ManagedParties, Payload: payload.Payload}
+		if payload.Error != "" {
+			received = engine.ReceivedPayload{Err: fmt.Errorf("unable to receive payload %s. Cause: %s", payload.Key, payload.Error)}
+		} else if extra, err := receivedExtra(&payload.receiveResponse); err != nil {
+			received = engine.ReceivedPayload{Err: err}
+		} else {
+			received.Extra = &extra
+		}
+		for _, i := range pending[payload.Key] {
+			results[i] = received
+			if received.Err == nil {
+				t.cache.Set(data[i].Hex(), cache.PrivateCacheItem{Payload: received.Payload, Extra: *received.Extra}, gocache.DefaultExpiration)
+			}
+		}
+		delete(pending, payload.Key)
+	}
+	// the payloads omitted are unknown
+	for _, indexes := range pending {
+		t.cache.SetMissing(data[indexes[0]].Hex())
+	}
+	return results, nil
+}
+
 // retrieve raw will not return information about medata
-func (t *tesseraPrivateTxManager) DecryptPayload(payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
+func (t *tesseraPrivateTxManager) DecryptPayload(ctx context.Context, payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error) {
 	response := new(receiveResponse)
-	if _, err := t.submitJSON("POST", "/encodedpayload/decrypt", &decryptPayloadRequest{
+	if _, err := t.submitJSON(ctx, "POST", "/encodedpayload/decrypt", &decryptPayloadRequest{
 		SenderKey:       payload.SenderKey,
 		CipherText:      payload.CipherText,
 		CipherTextNonce: payload.CipherTextNonce,
@@ -359,9 +442,9 @@ func (t *tesseraPrivateTxManager) DecryptPayload(payload common.DecryptRequest)
 	return response.Payload, &extra, nil
 }
 
-func (t *tesseraPrivateTxManager) IsSender(txHash common.EncryptedPayloadHash) (bool, error) {
+func (t *tesseraPrivateTxManager) IsSender(ctx context.Context, txHash common.EncryptedPayloadHash) (bool, error) {
 	requestUrl := "/transaction/" + url.PathEscape(txHash.ToBase64()) + "/isSender"
-	req, err := http.NewRequest("GET", t.client.FullPath(requestUrl), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", t.client.FullPath(requestUrl), nil)
 	if err != nil {
 		return false, err
 	}
@@ -389,9 +472,9 @@ func (t *tesseraPrivateTxManager) IsSender(txHash common.EncryptedPayloadHash) (
 	return strconv.ParseBool(string(out))
 }
 
-func (t *tesseraPrivateTxManager) GetParticipants(txHash common.EncryptedPayloadHash) ([]string, error) {
+func (t *tesseraPrivateTxManager) GetParticipants(ctx context.Context, txHash common.EncryptedPayloadHash) ([]string, error) {
 	requestUrl := "/transaction/" + url.PathEscape(txHash.ToBase64()) + "/participants"
-	req, err := http.NewRequest("GET", t.client.FullPath(requestUrl), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", t.client.FullPath(requestUrl), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -421,6 +504,72 @@ func (t *tesseraPrivateTxManager) GetParticipants(txHash common.EncryptedPayload
 	return split, nil
 }
 
+// Resend asks Tessera to push the payload to the recipient again. Only the
+// sender of the payload can resend it to a recipient it was not sent to.
+func (t *tesseraPrivateTxManager) Resend(ctx context.Context, txHash common.EncryptedPayloadHash, recipient string) error {
+	req, err := newOptionalJSONRequest(ctx, "POST", t.client.FullPath("/resend"), &resendRequest{
+		Type:      "INDIVIDUAL",
+		PublicKey: recipient,
+		Key:       txHash.ToBase64(),
+	}, "")
+	if err != nil {
+		return fmt.Errorf("unable to build json request for (method:%s,path:%s). Cause: %v", "POST", "/resend", err)
+	}
+	res, err := t.client.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to submit request (method:%s,path:%s). Cause: %v", "POST", "/resend", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("%d status: %s", res.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Upcheck reports whether Tessera is up, using its /upcheck endpoint.
+func (t *tesseraPrivateTxManager) Upcheck() error {
+	res, err := t.client.Get("/upcheck")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return engine.ErrPrivateTxManagerNotReady
+	}
+	return nil
+}
+
+// Version returns the version Tessera reports on its /version endpoint.
+func (t *tesseraPrivateTxManager) Version() (string, error) {
+	res, err := t.client.Get("/version")
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%d status: %s", res.StatusCode, string(body))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Keys returns the public keys Tessera controls, using its /keys endpoint.
+func (t *tesseraPrivateTxManager) Keys() ([]string, error) {
+	var response keysResponse
+	if _, err := t.submitJSONOld(context.Background(), "GET", "/keys", nil, &response); err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(response.Keys))
+	for i, key := range response.Keys {
+		keys[i] = key.Key
+	}
+	return keys, nil
+}
+
 func (t *tesseraPrivateTxManager) Name() string {
 	return "Tessera"
 }
@@ -430,7 +579,7 @@ func (t *tesseraPrivateTxManager) HasFeature(f engine.PrivateTransactionManagerF
 }
 
 // don't serialize body if nil
-func newOptionalJSONRequest(method string, path string, body interface{}, apiVersion string) (*http.Request, error) {
+func newOptionalJSONRequest(ctx context.Context, method string, path string, body interface{}, apiVersion string) (*http.Request, error) {
 	buf := new(bytes.Buffer)
 	if body != nil {
 		err := json.NewEncoder(buf).Encode(body)
@@ -438,7 +587,7 @@ func newOptionalJSONRequest(method string, path string, body interface{}, apiVer
 			return nil, err
 		}
 	}
-	request, err := http.NewRequest(method, path, buf)
+	request, err := http.NewRequestWithContext(ctx, method, path, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package tessera
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -39,6 +40,7 @@ var (
 	receiveRequestCaptor                 = make(chan *capturedRequest)
 	sendSignedTxRequestCaptor            = make(chan *capturedRequest)
 	sendSignedTxOctetStreamRequestCaptor = make(chan *capturedRequest)
+	resendRequestCaptor                  = make(chan *capturedRequest)
 )
 
 type capturedRequest struct {
@@ -66,6 +68,14 @@ func setup() {
 	mux.HandleFunc("/send", MockSendAPIHandlerFunc)
 	mux.HandleFunc("/transaction/", MockReceiveAPIHandlerFunc)
 	mux.HandleFunc("/sendsignedtx", MockSendSignedTxAPIHandlerFunc)
+	mux.HandleFunc("/resend", MockResendAPIHandlerFunc)
+	mux.HandleFunc("/receive/batch", MockReceiveBatchAPIHandlerFunc)
+	mux.HandleFunc("/keys", func(response http.ResponseWriter, request *http.Request) {
+		response.Write([]byte(`{"keys": [{"key": "` + arbitraryFrom + `"}]}`))
+	})
+	mux.HandleFunc("/version", func(response http.ResponseWriter, request *http.Request) {
+		response.Write([]byte("21.7.1\n"))
+	})
 
 	testServer = httptest.NewServer(mux)
 
@@ -121,6 +131,36 @@ func MockReceiveAPIHandlerFunc(response http.ResponseWriter, request *http.Reque
 	}
 }
 
+func MockReceiveBatchAPIHandlerFunc(response http.ResponseWriter, request *http.Request) {
+	actualRequest := new(receiveBatchRequest)
+	if err := json.NewDecoder(request.Body).Decode(actualRequest); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	batch := new(receiveBatchResponse)
+	for _, key := range actualRequest.Keys {
+		switch key {
+		case arbitraryNotFoundHash.ToBase64():
+			continue
+		case arbitraryHashNoPrivateMetadata.ToBase64():
+			batch.Payloads = append(batch.Payloads, receiveBatchPayload{Key: key, Error: "payload cannot be decrypted"})
+		default:
+			batch.Payloads = append(batch.Payloads, receiveBatchPayload{
+				receiveResponse: receiveResponse{
+					Payload:                      []byte(key),
+					ExecHash:                     arbitraryExtra.ACMerkleRoot.ToBase64(),
+					AffectedContractTransactions: arbitraryExtra.ACHashes.ToBase64s(),
+					PrivacyFlag:                  arbitraryPrivacyFlag,
+					ManagedParties:               []string{"ArbitraryPublicKey"},
+				},
+				Key: key,
+			})
+		}
+	}
+	data, _ := json.Marshal(batch)
+	response.Write(data)
+}
+
 func MockSendSignedTxAPIHandlerFunc(response http.ResponseWriter, request *http.Request) {
 	actualRequest := new(sendSignedTxRequest)
 	if err := json.NewDecoder(request.Body).Decode(actualRequest); err != nil {
@@ -134,6 +174,19 @@ func MockSendSignedTxAPIHandlerFunc(response http.ResponseWriter, request *http.
 	}
 }
 
+func MockResendAPIHandlerFunc(response http.ResponseWriter, request *http.Request) {
+	actualRequest := new(resendRequest)
+	if err := json.NewDecoder(request.Body).Decode(actualRequest); err != nil {
+		go func(o *capturedRequest) { resendRequestCaptor <- o }(&capturedRequest{err: err})
+		return
+	}
+	go func(o *capturedRequest) { resendRequestCaptor <- o }(&capturedRequest{request: actualRequest, header: request.Header})
+	if actualRequest.Key == arbitraryNotFoundHash.ToBase64() {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte("Message with hash not found"))
+	}
+}
+
 func MockSendSignedTxOctetStreamAPIHandlerFunc(response http.ResponseWriter, request *http.Request) {
 	actualRequest := new(sendSignedTxRequest)
 	reqHash, err := ioutil.ReadAll(request.Body)
@@ -175,7 +228,7 @@ func verifyRequestHeaderMultiTenancy(h http.Header, t *testing.T) {
 func TestSend_whenTypical(t *testing.T) {
 	assert := testifyassert.New(t)
 
-	_, _, actualHash, err := testObject.Send(arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
+	_, _, actualHash, err := testObject.Send(context.Background(), arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -206,7 +259,7 @@ func TestSend_whenTypical_MultiTenancy(t *testing.T) {
 		BaseURL:    testServer.URL,
 	}, []byte("2.1"))
 
-	_, _, actualHash, err := testObjectWithMT.Send(arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
+	_, _, actualHash, err := testObjectWithMT.Send(context.Background(), arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -240,12 +293,56 @@ func TestSend_whenTesseraVersionDoesNotSupportPrivacyEnhancements(t *testing.T)
 	assert.False(testObjectNoPE.HasFeature(engine.PrivacyEnhancements), "the supplied version does not support privacy enhancements")
 
 	// trying to send a party protection transaction
-	_, _, _, err := testObjectNoPE.Send(arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
+	_, _, _, err := testObjectNoPE.Send(context.Background(), arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
 	if err != engine.ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements {
 		t.Fatal("Expecting send to raise ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements")
 	}
 }
 
+func TestSend_whenMandatoryRecipients(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	testObjectWithMR := New(&engine.Client{
+		HttpClient: &http.Client{},
+		BaseURL:    testServer.URL,
+	}, []byte("21.4.0"))
+	extra := &engine.ExtraMetadata{
+		ACHashes:            arbitraryExtra.ACHashes,
+		PrivacyFlag:         engine.PrivacyFlagMandatoryRecipients,
+		MandatoryRecipients: []string{"arbitraryTo1"},
+	}
+
+	_, _, _, err := testObjectWithMR.Send(context.Background(), arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, extra)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	capturedRequest := <-sendRequestCaptor
+
+	if capturedRequest.err != nil {
+		t.Fatalf("%s", capturedRequest.err)
+	}
+
+	actualRequest := capturedRequest.request.(*sendRequest)
+
+	assert.Equal(engine.PrivacyFlagMandatoryRecipients, actualRequest.PrivacyFlag, "request.privacyFlag")
+	assert.Equal([]string{"arbitraryTo1"}, actualRequest.MandatoryRecipients, "request.mandatoryRecipients")
+	assert.Equal(arbitraryExtra.ACHashes.ToBase64s(), actualRequest.AffectedContractTransactions, "request.affectedContractTransactions")
+}
+
+func TestSend_whenTesseraVersionDoesNotSupportMandatoryRecipients(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	assert.True(testObject.HasFeature(engine.PrivacyEnhancements))
+	assert.False(testObject.HasFeature(engine.MandatoryRecipients), "the supplied version does not support mandatory recipients")
+
+	extra := &engine.ExtraMetadata{PrivacyFlag: engine.PrivacyFlagMandatoryRecipients, MandatoryRecipients: []string{"arbitraryTo1"}}
+	_, _, _, err := testObject.Send(context.Background(), arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, extra)
+	assert.Equal(engine.ErrPrivateTxManagerDoesNotSupportMandatoryRecipients, err)
+	_, _, _, err = testObject.SendSignedTx(context.Background(), arbitraryHash, arbitraryTo, extra)
+	assert.Equal(engine.ErrPrivateTxManagerDoesNotSupportMandatoryRecipients, err)
+	assert.Empty(sendRequestCaptor, "no request is actually sent")
+}
+
 func TestSendRaw_whenTesseraVersionDoesNotSupportPrivacyEnhancements(t *testing.T) {
 	assert := testifyassert.New(t)
 
@@ -265,7 +362,7 @@ func TestSendRaw_whenTesseraVersionDoesNotSupportPrivacyEnhancements(t *testing.
 	assert.False(testObjectNoPE.HasFeature(engine.PrivacyEnhancements), "the supplied version does not support privacy enhancements")
 
 	// trying to send a party protection transaction
-	_, _, _, err := testObjectNoPE.SendSignedTx(arbitraryHash, arbitraryTo, arbitraryExtra)
+	_, _, _, err := testObjectNoPE.SendSignedTx(context.Background(), arbitraryHash, arbitraryTo, arbitraryExtra)
 	if err != engine.ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements {
 		t.Fatal("Expecting send to raise ErrPrivateTxManagerDoesNotSupportPrivacyEnhancements")
 	}
@@ -273,14 +370,14 @@ func TestSendRaw_whenTesseraVersionDoesNotSupportPrivacyEnhancements(t *testing.
 	// send a standard private transaction and check that the old version of the /sendsignedtx is used (using octetstream content type)
 
 	// caching incomplete item
-	_, _, _, err = testObjectNoPE.ReceiveRaw(arbitraryHashNoPrivateMetadata)
+	_, _, _, err = testObjectNoPE.ReceiveRaw(context.Background(), arbitraryHashNoPrivateMetadata)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
 	<-receiveRequestCaptor
 
 	// caching complete item
-	_, _, _, err = testObjectNoPE.SendSignedTx(arbitraryHashNoPrivateMetadata, arbitraryTo, &engine.ExtraMetadata{
+	_, _, _, err = testObjectNoPE.SendSignedTx(context.Background(), arbitraryHashNoPrivateMetadata, arbitraryTo, &engine.ExtraMetadata{
 		PrivacyFlag: engine.PrivacyFlagStandardPrivate})
 	if err != nil {
 		t.Fatalf("%s", err)
@@ -288,7 +385,7 @@ func TestSendRaw_whenTesseraVersionDoesNotSupportPrivacyEnhancements(t *testing.
 	req := <-sendSignedTxOctetStreamRequestCaptor
 	assert.Equal("application/octet-stream", req.header["Content-Type"][0])
 
-	_, _, _, actualExtra, err := testObjectNoPE.Receive(arbitraryHashNoPrivateMetadata)
+	_, _, _, actualExtra, err := testObjectNoPE.Receive(context.Background(), arbitraryHashNoPrivateMetadata)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -296,10 +393,23 @@ func TestSendRaw_whenTesseraVersionDoesNotSupportPrivacyEnhancements(t *testing.
 
 }
 
+func TestSend_whenContextCancelled(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, _, err := testObject.Send(ctx, arbitraryPrivatePayload, arbitraryFrom, arbitraryTo, arbitraryExtra)
+
+	if assert.Error(err, "cancelled send") {
+		assert.Contains(err.Error(), context.Canceled.Error())
+	}
+	assert.Empty(sendRequestCaptor, "no request is actually sent")
+}
+
 func TestReceive_whenTypical(t *testing.T) {
 	assert := testifyassert.New(t)
 
-	_, _, _, actualExtra, err := testObject.Receive(arbitraryHash1)
+	_, _, _, actualExtra, err := testObject.Receive(context.Background(), arbitraryHash1)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -327,7 +437,7 @@ func TestReceive_whenTypical_Multitenancy(t *testing.T) {
 		BaseURL:    testServer.URL,
 	}, []byte("2.1"))
 
-	_, _, _, actualExtra, err := testObjectWithMT.Receive(arbitraryHash1)
+	_, _, _, actualExtra, err := testObjectWithMT.Receive(context.Background(), arbitraryHash1)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -350,7 +460,7 @@ func TestReceive_whenTypical_Multitenancy(t *testing.T) {
 func TestReceive_whenPayloadNotFound(t *testing.T) {
 	assert := testifyassert.New(t)
 
-	_, _, data, _, err := testObject.Receive(arbitraryNotFoundHash)
+	_, _, data, _, err := testObject.Receive(context.Background(), arbitraryNotFoundHash)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -366,12 +476,19 @@ func TestReceive_whenPayloadNotFound(t *testing.T) {
 
 	assert.Equal(arbitraryNotFoundHash.ToBase64(), actualRequest, "requested hash")
 	assert.Nil(data, "returned payload when not found")
+
+	_, _, data, _, err = testObject.Receive(context.Background(), arbitraryNotFoundHash)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	assert.Empty(receiveRequestCaptor, "payload known missing")
+	assert.Nil(data, "returned payload when not found")
 }
 
 func TestReceive_whenEncryptedPayloadHashIsEmpty(t *testing.T) {
 	assert := testifyassert.New(t)
 
-	_, _, data, _, err := testObject.Receive(emptyHash)
+	_, _, data, _, err := testObject.Receive(context.Background(), emptyHash)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -382,7 +499,7 @@ func TestReceive_whenEncryptedPayloadHashIsEmpty(t *testing.T) {
 func TestReceive_whenHavingPayloadButNoPrivateExtraMetadata(t *testing.T) {
 	assert := testifyassert.New(t)
 
-	_, _, _, actualExtra, err := testObject.Receive(arbitraryHashNoPrivateMetadata)
+	_, _, _, actualExtra, err := testObject.Receive(context.Background(), arbitraryHashNoPrivateMetadata)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -404,7 +521,7 @@ func TestReceive_whenHavingPayloadButNoPrivateExtraMetadata(t *testing.T) {
 func TestSendSignedTx_whenTypical(t *testing.T) {
 	assert := testifyassert.New(t)
 
-	_, _, _, err := testObject.SendSignedTx(arbitraryHash, arbitraryTo, arbitraryExtra)
+	_, _, _, err := testObject.SendSignedTx(context.Background(), arbitraryHash, arbitraryTo, arbitraryExtra)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -423,24 +540,53 @@ func TestSendSignedTx_whenTypical(t *testing.T) {
 	assert.Equal(arbitraryExtra.ACMerkleRoot.ToBase64(), actualRequest.ExecHash, "request.execHash")
 }
 
+func TestResend_whenTypical(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	err := testObject.Resend(context.Background(), arbitraryHash, arbitraryFrom)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	capturedRequest := <-resendRequestCaptor
+
+	if capturedRequest.err != nil {
+		t.Fatalf("%s", capturedRequest.err)
+	}
+
+	actualRequest := capturedRequest.request.(*resendRequest)
+
+	assert.Equal("INDIVIDUAL", actualRequest.Type, "request.type")
+	assert.Equal(arbitraryFrom, actualRequest.PublicKey, "request.publicKey")
+	assert.Equal(arbitraryHash.ToBase64(), actualRequest.Key, "request.key")
+}
+
+func TestResend_whenPayloadNotFound(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	err := testObject.Resend(context.Background(), arbitraryNotFoundHash, arbitraryFrom)
+	<-resendRequestCaptor
+
+	assert.EqualError(err, "404 status: Message with hash not found")
+}
+
 func TestReceive_whenCachingRawPayload(t *testing.T) {
 	assert := testifyassert.New(t)
 
 	// caching incomplete item
-	_, _, _, err := testObject.ReceiveRaw(arbitraryHashNoPrivateMetadata)
+	_, _, _, err := testObject.ReceiveRaw(context.Background(), arbitraryHashNoPrivateMetadata)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
 	<-receiveRequestCaptor
 
 	// caching complete item
-	_, _, _, err = testObject.SendSignedTx(arbitraryHashNoPrivateMetadata, arbitraryTo, arbitraryExtra)
+	_, _, _, err = testObject.SendSignedTx(context.Background(), arbitraryHashNoPrivateMetadata, arbitraryTo, arbitraryExtra)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
 	<-sendSignedTxRequestCaptor
 
-	_, _, _, actualExtra, err := testObject.Receive(arbitraryHashNoPrivateMetadata)
+	_, _, _, actualExtra, err := testObject.Receive(context.Background(), arbitraryHashNoPrivateMetadata)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -449,3 +595,58 @@ func TestReceive_whenCachingRawPayload(t *testing.T) {
 	assert.Equal(arbitraryExtra.ACMerkleRoot, actualExtra.ACMerkleRoot, "cached merkle root")
 	assert.Equal(arbitraryExtra.PrivacyFlag, actualExtra.PrivacyFlag, "cached privacy flag")
 }
+
+func TestKeys_whenTypical(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	keys, err := testObject.Keys()
+	assert.NoError(err)
+	assert.Equal([]string{arbitraryFrom}, keys)
+}
+
+func TestVersion_whenTypical(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	version, err := testObject.Version()
+	assert.NoError(err)
+	assert.Equal("21.7.1", version)
+}
+
+func TestReceiveBatch_whenTypical(t *testing.T) {
+	assert := testifyassert.New(t)
+	testObjectWithBatch := New(&engine.Client{
+		HttpClient: &http.Client{},
+		BaseURL:    testServer.URL,
+	}, []byte("21.10.0"))
+	batchHash1 := common.BytesToEncryptedPayloadHash([]byte("batch1"))
+	batchHash2 := common.BytesToEncryptedPayloadHash([]byte("batch2"))
+
+	received, err := testObjectWithBatch.ReceiveBatch(context.Background(), []common.EncryptedPayloadHash{batchHash1, arbitraryNotFoundHash, emptyHash, arbitraryHashNoPrivateMetadata, batchHash2, batchHash1})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if !assert.Len(received, 6) {
+		return
+	}
+	assert.Equal([]byte(batchHash1.ToBase64()), received[0].Payload, "payloads in the order of the hashes")
+	assert.Equal(arbitraryExtra.ACMerkleRoot, received[0].Extra.ACMerkleRoot, "returned merkle root")
+	assert.Nil(received[1].Payload, "returned payload when not found")
+	assert.NoError(received[1].Err)
+	assert.Nil(received[2].Payload, "returned payload when hash is empty")
+	assert.Error(received[3].Err, "entry failing alone")
+	assert.Equal([]byte(batchHash2.ToBase64()), received[4].Payload)
+	assert.Equal(received[0], received[5], "duplicated hash")
+
+	_, _, data, _, err := testObjectWithBatch.Receive(context.Background(), batchHash2)
+	assert.NoError(err)
+	assert.Equal([]byte(batchHash2.ToBase64()), data, "cached payload")
+	assert.Empty(receiveRequestCaptor, "no request is actually sent")
+}
+
+func TestReceiveBatch_whenNotSupported(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	_, err := testObject.ReceiveBatch(context.Background(), []common.EncryptedPayloadHash{arbitraryHash})
+	assert.Equal(engine.ErrPrivateTxManagerNotSupported, err)
+}
@@ -16,10 +16,14 @@ var (
 	zero                       = Version{0, 0, 0}
 	privacyEnhancementsVersion = Version{2, 0, 0}
 	multitenancyVersion        = Version{2, 1, 0}
+	batchReceiveVersion        = Version{21, 10, 0}
+	mandatoryRecipientsVersion = Version{21, 4, 0}
 
 	featureVersions = map[engine.PrivateTransactionManagerFeature]Version{
 		engine.PrivacyEnhancements: privacyEnhancementsVersion,
 		engine.MultiTenancy:        multitenancyVersion,
+		engine.BatchReceive:        batchReceiveVersion,
+		engine.MandatoryRecipients: mandatoryRecipientsVersion,
 	}
 )
 
@@ -71,6 +71,13 @@ func TestTesseraVersionFeatures(t *testing.T) {
 	res = tesseraVersionFeatures(Version{2, 1, 1})
 	assert.Contains(t, res, engine.PrivacyEnhancements)
 	assert.Contains(t, res, engine.MultiTenancy)
+	assert.NotContains(t, res, engine.BatchReceive)
+	assert.NotContains(t, res, engine.MandatoryRecipients)
+	res = tesseraVersionFeatures(Version{21, 4, 0})
+	assert.Contains(t, res, engine.MandatoryRecipients)
+	assert.NotContains(t, res, engine.BatchReceive)
+	res = tesseraVersionFeatures(Version{21, 10, 0})
+	assert.Contains(t, res, engine.BatchReceive)
 	res = tesseraVersionFeatures(zero)
 	assert.NotContains(t, res, engine.PrivacyEnhancements)
 	assert.NotContains(t, res, engine.MultiTenancy)
@@ -0,0 +1,123 @@
+package private
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// PrivateFromCheck selects how the privateFrom of the private transactions
+// sent is checked to be a key of the private transaction manager. A payload
+// sent from the key of another party is accepted by the transaction manager
+// knowing it, but never readable by the node sending it.
+type PrivateFromCheck string
+
+const (
+	PrivateFromCheckOff    PrivateFromCheck = "off"
+	PrivateFromCheckWarn   PrivateFromCheck = "warn"   // logs the mismatches, the default until strict is
+	PrivateFromCheckStrict PrivateFromCheck = "strict" // rejects the mismatches
+)
+
+const (
+	keysRefreshInterval = time.Minute // interval the keys of the transaction manager are listed again at
+	keysMissInterval    = time.Second // minimum interval between the listings of an unknown key
+)
+
+// KeyLister is implemented by the private transaction managers which list the
+// public keys they control
+type KeyLister interface {
+	Keys() ([]string, error)
+}
+
+// privateFromChecker of P, nil if the privateFrom is not checked
+var privateFromChecker *keyChecker
+
+// CheckPrivateFrom checks that the privateFrom of a private transaction sent
+// is a key of P, the default key of P being used if it is empty. It returns
+// an error listing the keys of P on a mismatch if the check is strict.
+func CheckPrivateFrom(from string) error {
+	return privateFromChecker.check(from)
+}
+
+// keyChecker caches the keys of a transaction manager, listing them again
+// when they expire or a key is unknown, in case it was added.
+type keyChecker struct {
+	lister KeyLister
+	strict bool
+
+	lock   sync.Mutex
+	keys   map[string]bool
+	sorted []string
+	listed time.Time
+	now    func() time.Time
+}
+
+// newKeyChecker returns the checker of the privateFrom of the transaction
+// manager, nil if it is not checked or cannot be.
+func newKeyChecker(ptm PrivateTransactionManager, mode PrivateFromCheck) (*keyChecker, error) {
+	switch mode {
+	case PrivateFromCheckOff:
+		return nil, nil
+	case "", PrivateFromCheckWarn, PrivateFromCheckStrict:
+	default:
+		return nil, fmt.Errorf("invalid privateFrom check %q, expected %s, %s or %s", mode, PrivateFromCheckOff, PrivateFromCheckWarn, PrivateFromCheckStrict)
+	}
+	lister, ok := ptm.(KeyLister)
+	if !ok {
+		log.Info("The private transaction manager cannot list its keys, privateFrom is not checked", "name", ptm.Name())
+		return nil, nil
+	}
+	return &keyChecker{lister: lister, strict: mode == PrivateFromCheckStrict, now: time.Now}, nil
+}
+
+func (c *keyChecker) check(from string) error {
+	if c == nil || from == "" {
+		return nil
+	}
+	owned, keys, err := c.owns(from)
+	if err != nil {
+		// the transaction manager failing, sending fails too
+		log.Warn("Could not list the keys of the private transaction manager", "err", err)
+		return nil
+	}
+	if owned {
+		return nil
+	}
+	err = fmt.Errorf("privateFrom %s is not a key of the private transaction manager, whose keys are %s", from, strings.Join(keys, ", "))
+	if c.strict {
+		return err
+	}
+	log.Warn("Private transaction sent from a key of another party, the node will not read it", "err", err)
+	return nil
+}
+
+// owns returns whether the key is one of the transaction manager, and its
+// keys.
+func (c *keyChecker) owns(key string) (bool, []string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	expired := c.keys == nil || now.Sub(c.listed) >= keysRefreshInterval
+	if expired || (!c.keys[key] && now.Sub(c.listed) >= keysMissInterval) {
+		keys, err := c.lister.Keys()
+		if err != nil && c.keys == nil {
+			return false, nil, err
+		}
+		if err == nil {
+			c.keys = make(map[string]bool, len(keys))
+			for _, k := range keys {
+				c.keys[k] = true
+			}
+			c.sorted = append([]string(nil), keys...)
+			sort.Strings(c.sorted)
+		}
+		// listed again at most every keysMissInterval if failing
+		c.listed = now
+	}
+	return c.keys[key], c.sorted, nil
+}
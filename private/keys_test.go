@@ -0,0 +1,100 @@
+package private
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubKeyLister struct {
+	notinuse.PrivateTransactionManager
+	keys   []string
+	err    error
+	listed int
+}
+
+func (s *stubKeyLister) Keys() ([]string, error) {
+	s.listed++
+	return s.keys, s.err
+}
+
+func newTestKeyChecker(t *testing.T, lister *stubKeyLister, mode PrivateFromCheck) (*keyChecker, *time.Time) {
+	checker, err := newKeyChecker(lister, mode)
+	require.NoError(t, err)
+	require.NotNil(t, checker)
+	now := time.Unix(0, 0)
+	checker.now = func() time.Time { return now }
+	return checker, &now
+}
+
+func TestKeyChecker_whenStrict(t *testing.T) {
+	lister := &stubKeyLister{keys: []string{"BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=", "QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc="}}
+	checker, _ := newTestKeyChecker(t, lister, PrivateFromCheckStrict)
+
+	assert.NoError(t, checker.check("QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc="))
+	assert.NoError(t, checker.check(""), "the default key of the transaction manager")
+	err := checker.check("1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg=")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo=, QfeDAys9MPDs2XHExtc84jKGHxZg/aj52DTh0vtA3Xc=")
+	assert.Equal(t, 1, lister.listed, "the unknown key is not listed again before keysMissInterval")
+}
+
+func TestKeyChecker_whenWarn(t *testing.T) {
+	lister := &stubKeyLister{keys: []string{"BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="}}
+	checker, _ := newTestKeyChecker(t, lister, "")
+
+	assert.NoError(t, checker.check("1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="))
+}
+
+func TestKeyChecker_whenKeysChange(t *testing.T) {
+	lister := &stubKeyLister{keys: []string{"BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="}}
+	checker, now := newTestKeyChecker(t, lister, PrivateFromCheckStrict)
+
+	assert.Error(t, checker.check("1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="))
+	lister.keys = append(lister.keys, "1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg=")
+	assert.Error(t, checker.check("1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="))
+
+	*now = now.Add(keysMissInterval)
+	assert.NoError(t, checker.check("1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="))
+	assert.Equal(t, 2, lister.listed)
+
+	// the removed keys are forgotten once the keys expire
+	lister.keys = lister.keys[1:]
+	assert.NoError(t, checker.check("BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="))
+	*now = now.Add(keysRefreshInterval)
+	assert.Error(t, checker.check("BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="))
+	assert.Equal(t, 3, lister.listed)
+}
+
+func TestKeyChecker_whenListingFails(t *testing.T) {
+	lister := &stubKeyLister{err: errors.New("connection refused")}
+	checker, now := newTestKeyChecker(t, lister, PrivateFromCheckStrict)
+
+	assert.NoError(t, checker.check("BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="))
+
+	// the keys last listed are kept
+	lister.keys, lister.err = []string{"BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="}, nil
+	assert.NoError(t, checker.check("BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="))
+	lister.err = errors.New("connection refused")
+	*now = now.Add(keysRefreshInterval)
+	assert.NoError(t, checker.check("BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="))
+	assert.Error(t, checker.check("1iTZde/ndBHvzhcl7V68x44Vx7pl8nwx9LqnM/AfJUg="))
+}
+
+func TestNewKeyChecker(t *testing.T) {
+	checker, err := newKeyChecker(&stubKeyLister{}, PrivateFromCheckOff)
+	assert.NoError(t, err)
+	assert.Nil(t, checker)
+	assert.NoError(t, checker.check("BULeR8JyUWhiuuCMU/HLA0Q5pzkYT+cHII3ZKBey3Bo="))
+
+	checker, err = newKeyChecker(&notinuse.PrivateTransactionManager{}, PrivateFromCheckStrict)
+	assert.NoError(t, err)
+	assert.Nil(t, checker, "the transaction manager cannot list its keys")
+
+	_, err = newKeyChecker(&stubKeyLister{}, "always")
+	assert.Error(t, err)
+}
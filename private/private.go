@@ -1,6 +1,8 @@
 package private
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -8,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	http2 "github.com/ethereum/go-ethereum/common/http"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/private/cache"
 	"github.com/ethereum/go-ethereum/private/engine"
 	"github.com/ethereum/go-ethereum/private/engine/constellation"
 	"github.com/ethereum/go-ethereum/private/engine/notinuse"
@@ -19,6 +22,12 @@ var (
 	// singleton gateway to interact with private transaction manager
 	P                PrivateTransactionManager
 	isPrivacyEnabled = false
+
+	// router of the requests between the endpoints of a clustered private
+	// transaction manager, nil for a single endpoint
+	router *http2.Router
+
+	ErrSingleEndpoint = errors.New("private transaction manager has a single endpoint")
 )
 
 type Identifiable interface {
@@ -26,21 +35,82 @@ type Identifiable interface {
 	HasFeature(f engine.PrivateTransactionManagerFeature) bool
 }
 
-// Interacting with Private Transaction Manager APIs
+// Interacting with Private Transaction Manager APIs. The calls are cancelled
+// with their context, and retried as configured on connection errors and 5xx
+// statuses, see http.Retry.
 type PrivateTransactionManager interface {
 	Identifiable
 
-	Send(data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error)
-	StoreRaw(data []byte, from string) (common.EncryptedPayloadHash, error)
-	SendSignedTx(data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error)
+	Send(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) (string, []string, common.EncryptedPayloadHash, error)
+	StoreRaw(ctx context.Context, data []byte, from string) (common.EncryptedPayloadHash, error)
+	SendSignedTx(ctx context.Context, data common.EncryptedPayloadHash, to []string, extra *engine.ExtraMetadata) (string, []string, []byte, error)
 	// Returns nil payload if not found
-	Receive(data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error)
+	Receive(ctx context.Context, data common.EncryptedPayloadHash) (string, []string, []byte, *engine.ExtraMetadata, error)
+	// Receives the payloads in one request, if HasFeature(engine.BatchReceive),
+	// in the order of the hashes
+	ReceiveBatch(ctx context.Context, data []common.EncryptedPayloadHash) ([]engine.ReceivedPayload, error)
 	// Returns nil payload if not found
-	ReceiveRaw(data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error)
-	IsSender(txHash common.EncryptedPayloadHash) (bool, error)
-	GetParticipants(txHash common.EncryptedPayloadHash) ([]string, error)
-	EncryptPayload(data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error)
-	DecryptPayload(payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error)
+	ReceiveRaw(ctx context.Context, data common.EncryptedPayloadHash) ([]byte, string, *engine.ExtraMetadata, error)
+	IsSender(ctx context.Context, txHash common.EncryptedPayloadHash) (bool, error)
+	GetParticipants(ctx context.Context, txHash common.EncryptedPayloadHash) ([]string, error)
+	EncryptPayload(ctx context.Context, data []byte, from string, to []string, extra *engine.ExtraMetadata) ([]byte, error)
+	DecryptPayload(ctx context.Context, payload common.DecryptRequest) ([]byte, *engine.ExtraMetadata, error)
+	// Pushes the payload of txHash again to the recipient, which must be a party
+	// of it, e.g. after the recipient was added to an existing contract
+	Resend(ctx context.Context, txHash common.EncryptedPayloadHash, recipient string) error
+}
+
+// Upchecker is implemented by the private transaction managers which can be
+// probed for being up
+type Upchecker interface {
+	Upcheck() error
+}
+
+// PayloadCacher is implemented by the private transaction managers caching
+// the decrypted payloads
+type PayloadCacher interface {
+	PayloadCache() *cache.PayloadCache
+}
+
+// PersistentCache is the disk tier of the payload cache of a private
+// transaction manager. It is a node.Lifecycle, spilling the payloads cached in
+// memory to disk when the node stops.
+type PersistentCache struct {
+	memory *cache.PayloadCache
+	disk   *cache.DiskCache
+}
+
+// EnablePersistentCache adds a disk tier of at most limit bytes in the
+// directory to the payload cache of P, nil if P has none.
+func EnablePersistentCache(dir string, limit int64) (*PersistentCache, error) {
+	return enablePersistentCache(P, dir, limit)
+}
+
+func enablePersistentCache(ptm PrivateTransactionManager, dir string, limit int64) (*PersistentCache, error) {
+	cacher, ok := ptm.(PayloadCacher)
+	if !ok {
+		return nil, nil
+	}
+	disk, err := cache.OpenDiskCache(dir, limit)
+	if err != nil {
+		return nil, err
+	}
+	memory := cacher.PayloadCache()
+	memory.SetDisk(disk)
+	return &PersistentCache{memory: memory, disk: disk}, nil
+}
+
+// Start implements node.Lifecycle.
+func (c *PersistentCache) Start() error {
+	return nil
+}
+
+// Stop implements node.Lifecycle, spilling the payloads cached in memory and
+// closing the disk tier.
+func (c *PersistentCache) Stop() error {
+	c.memory.Flush()
+	c.memory.SetDisk(nil)
+	return c.disk.Close()
 }
 
 // This loads any config specified via the legacy environment variable
@@ -59,9 +129,19 @@ func FromEnvironmentOrNil(name string) (http2.Config, error) {
 }
 
 func InitialiseConnection(cfg http2.Config) error {
-	var err error
-	P, err = NewPrivateTxManager(cfg)
-	return err
+	privacy, err := Open(&Config{TransactionManager: cfg})
+	if err != nil {
+		return err
+	}
+	privacy.Install()
+	return nil
+}
+
+// EndpointRouter returns the router between the endpoints of the private
+// transaction manager, nil unless it is clustered. It is a node.Lifecycle
+// probing the endpoints.
+func EndpointRouter() *http2.Router {
+	return router
 }
 
 func IsQuorumPrivacyEnabled() bool {
@@ -69,24 +149,41 @@ func IsQuorumPrivacyEnabled() bool {
 }
 
 func NewPrivateTxManager(cfg http2.Config) (PrivateTransactionManager, error) {
+	ptm, r, err := newPrivateTxManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ConnectionType != http2.NoConnection {
+		router = r
+		isPrivacyEnabled = true
+	}
+	return ptm, nil
+}
 
+// newPrivateTxManager connects to the private transaction manager, returning
+// the router between its endpoints if it is clustered.
+func newPrivateTxManager(cfg http2.Config) (PrivateTransactionManager, *http2.Router, error) {
 	if cfg.ConnectionType == http2.NoConnection {
 		log.Info("Running with private transaction manager disabled - quorum private transactions will not be supported")
-		return &notinuse.PrivateTransactionManager{}, nil
+		return &notinuse.PrivateTransactionManager{}, nil, nil
 	}
 
 	client, err := http2.CreateClient(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create connection to private tx manager due to: %s", err)
+		return nil, nil, fmt.Errorf("unable to create connection to private tx manager due to: %s", err)
+	}
+	var router *http2.Router
+	if r, ok := client.HttpClient.Transport.(*http2.Router); ok {
+		// measure the latencies before the first requests
+		r.Start()
+		router = r
 	}
 
 	ptm, err := selectPrivateTxManager(client)
 	if err != nil {
-		return nil, fmt.Errorf("unable to connect to private tx manager due to: %s", err)
+		return nil, nil, fmt.Errorf("unable to connect to private tx manager due to: %s", err)
 	}
-
-	isPrivacyEnabled = true
-	return ptm, nil
+	return ptm, router, nil
 }
 
 // First call /upcheck to make sure the private tx manager is up
@@ -0,0 +1,158 @@
+package private
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/private/engine"
+)
+
+// Quorum
+//
+// The status of the private transaction manager tells whether it answers its
+// upcheck and how fast, with the version it reports and the features the node
+// detected. The health monitor checks it at an interval for the quorum/ptm/up
+// and quorum/ptm/latency gauges, backing off while the transaction manager is
+// down, and logs only the transitions between up and down.
+
+// healthMaxBackoff is the longest delay between the checks of a transaction
+// manager down, in intervals.
+const healthMaxBackoff = 8
+
+var (
+	ptmUpGauge      = metrics.NewRegisteredGauge("quorum/ptm/up", nil)
+	ptmLatencyGauge = metrics.NewRegisteredGauge("quorum/ptm/latency", nil) // of the last upcheck, in microseconds
+)
+
+// VersionReporter is implemented by the private transaction managers which
+// report their version
+type VersionReporter interface {
+	Version() (string, error)
+}
+
+// featureNames are the names of the features a transaction manager may have.
+var featureNames = []struct {
+	feature engine.PrivateTransactionManagerFeature
+	name    string
+}{
+	{engine.PrivacyEnhancements, "privacyEnhancements"},
+	{engine.MultiTenancy, "multiTenancy"},
+	{engine.BatchReceive, "batchReceive"},
+	{engine.MandatoryRecipients, "mandatoryRecipients"},
+}
+
+// Status is the status of a private transaction manager.
+type Status struct {
+	Name      string   `json:"name"`
+	Reachable bool     `json:"reachable"`
+	LatencyMs float64  `json:"latencyMs"`         // round trip of the upcheck
+	Version   string   `json:"version,omitempty"` // empty if not reported
+	Features  []string `json:"features"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// CheckStatus upchecks the transaction manager, asking its version if up.
+func CheckStatus(ptm PrivateTransactionManager) *Status {
+	status := &Status{Name: ptm.Name(), Features: []string{}}
+	for _, f := range featureNames {
+		if ptm.HasFeature(f.feature) {
+			status.Features = append(status.Features, f.name)
+		}
+	}
+	upchecker, ok := ptm.(Upchecker)
+	if !ok {
+		status.Error = ptm.Name() + " cannot be probed"
+		return status
+	}
+	start := time.Now()
+	err := upchecker.Upcheck()
+	status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Reachable = true
+	if reporter, ok := ptm.(VersionReporter); ok {
+		if version, err := reporter.Version(); err == nil {
+			status.Version = version
+		} else {
+			log.Debug("Failed to get the version of the private transaction manager", "err", err)
+		}
+	}
+	return status
+}
+
+// healthMonitor checks the status of a transaction manager for the gauges.
+type healthMonitor struct {
+	ptm      PrivateTransactionManager
+	interval time.Duration
+	clock    mclock.Clock
+
+	up      bool          // whether the last check found it up, assumed up before the first
+	backoff time.Duration // delay before the next check while down
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newHealthMonitor(ptm PrivateTransactionManager, interval time.Duration) *healthMonitor {
+	return &healthMonitor{ptm: ptm, interval: interval, clock: mclock.System{}, up: true, quit: make(chan struct{})}
+}
+
+// Start implements Service, checking the transaction manager right away.
+func (m *healthMonitor) Start() error {
+	m.wg.Add(1)
+	go m.loop()
+	return nil
+}
+
+// Stop implements Service.
+func (m *healthMonitor) Stop() error {
+	close(m.quit)
+	m.wg.Wait()
+	return nil
+}
+
+func (m *healthMonitor) loop() {
+	defer m.wg.Done()
+
+	timer := m.clock.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			timer.Reset(m.update(CheckStatus(m.ptm)))
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// update records the status in the gauges, logging whether the transaction
+// manager went up or down, and returns the delay before the next check.
+func (m *healthMonitor) update(status *Status) time.Duration {
+	ptmLatencyGauge.Update(int64(status.LatencyMs * 1000))
+	if status.Reachable {
+		ptmUpGauge.Update(1)
+		if !m.up {
+			log.Info("Private transaction manager is up again", "name", status.Name, "latency", time.Duration(status.LatencyMs*float64(time.Millisecond)))
+			m.up = true
+		}
+		return m.interval
+	}
+	ptmUpGauge.Update(0)
+	if m.up {
+		log.Warn("Private transaction manager is down", "name", status.Name, "err", status.Error)
+		m.up = false
+		m.backoff = m.interval
+	} else if m.backoff < healthMaxBackoff*m.interval {
+		m.backoff *= 2
+		if m.backoff > healthMaxBackoff*m.interval {
+			m.backoff = healthMaxBackoff * m.interval
+		}
+	}
+	return m.backoff
+}
@@ -0,0 +1,98 @@
+package private
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/private/engine"
+	"github.com/ethereum/go-ethereum/private/engine/notinuse"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubUpchecker struct {
+	notinuse.PrivateTransactionManager
+	upErr      error
+	versionErr error
+	checked    chan struct{} // signalled on the upchecks if not nil
+}
+
+func (s *stubUpchecker) Name() string { return "Tessera" }
+
+func (s *stubUpchecker) HasFeature(f engine.PrivateTransactionManagerFeature) bool {
+	return f == engine.PrivacyEnhancements || f == engine.BatchReceive
+}
+
+func (s *stubUpchecker) Upcheck() error {
+	if s.checked != nil {
+		s.checked <- struct{}{}
+	}
+	return s.upErr
+}
+
+func (s *stubUpchecker) Version() (string, error) { return "21.7.1", s.versionErr }
+
+func TestCheckStatus(t *testing.T) {
+	ptm := &stubUpchecker{}
+	status := CheckStatus(ptm)
+	assert.Equal(t, &Status{Name: "Tessera", Reachable: true, LatencyMs: status.LatencyMs, Version: "21.7.1", Features: []string{"privacyEnhancements", "batchReceive"}}, status)
+
+	ptm.versionErr = errors.New("404 status")
+	assert.Empty(t, CheckStatus(ptm).Version, "the version is optional")
+
+	ptm.upErr = engine.ErrPrivateTxManagerNotReady
+	status = CheckStatus(ptm)
+	assert.False(t, status.Reachable)
+	assert.Equal(t, engine.ErrPrivateTxManagerNotReady.Error(), status.Error)
+	assert.Empty(t, status.Version)
+
+	status = CheckStatus(&notinuse.PrivateTransactionManager{})
+	assert.False(t, status.Reachable)
+	assert.Equal(t, []string{}, status.Features)
+	assert.Equal(t, "NotInUse cannot be probed", status.Error)
+}
+
+func TestHealthMonitor_backsOffWhileDown(t *testing.T) {
+	m := newHealthMonitor(&stubUpchecker{}, time.Second)
+	up := &Status{Name: "Tessera", Reachable: true}
+	down := &Status{Name: "Tessera", Error: "connection refused"}
+
+	assert.Equal(t, time.Second, m.update(up))
+	var delays []time.Duration
+	for i := 0; i < 6; i++ {
+		delays = append(delays, m.update(down))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second, 8 * time.Second}, delays)
+	assert.False(t, m.up)
+	assert.Equal(t, time.Second, m.update(up), "the interval is restored once up")
+	assert.True(t, m.up)
+	assert.Equal(t, time.Second, m.update(down), "the back off starts over")
+}
+
+func TestHealthMonitor_StartStop(t *testing.T) {
+	clock := new(mclock.Simulated)
+	ptm := &stubUpchecker{checked: make(chan struct{})}
+	m := newHealthMonitor(ptm, time.Hour)
+	m.clock = clock
+	assert.NoError(t, m.Start())
+	clock.WaitForTimers(1)
+	clock.Run(0)
+	select {
+	case <-ptm.checked:
+	case <-time.After(time.Second):
+		t.Fatal("not checked when started")
+	}
+
+	// checked again once the interval passed
+	clock.WaitForTimers(1)
+	clock.Run(time.Hour - time.Second)
+	assert.Equal(t, 1, clock.ActiveTimers(), "checked before the interval")
+	clock.Run(time.Second)
+	select {
+	case <-ptm.checked:
+	case <-time.After(time.Second):
+		t.Fatal("not checked after the interval")
+	}
+	assert.NoError(t, m.Stop())
+}
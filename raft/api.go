@@ -75,7 +75,7 @@ func (s *PublicRaftAPI) Leader() (string, error) {
 
 	addr, err := s.raftService.raftProtocolManager.LeaderAddress()
 	if err != nil {
-		return "", err
+		return "", newProposalError(err, "")
 	}
 	return addr.NodeId.String(), nil
 }
@@ -114,7 +114,15 @@ func (s *PublicRaftAPI) Cluster() ([]ClusterInfo, error) {
 				role = "verifier"
 			}
 		}
-		clustInfo[i] = ClusterInfo{*a, role, s.checkIfNodeIsActive(a.RaftId)}
+		active := s.checkIfNodeIsActive(a.RaftId)
+		clustInfo[i] = ClusterInfo{Address: *a, Role: role, NodeActive: active}
+		// Quorum
+		if catchingUp, err := s.raftService.raftProtocolManager.catchUpStatus(a.RaftId); catchingUp && active {
+			clustInfo[i].CatchingUp = true
+			if err != nil {
+				clustInfo[i].CatchUpError = err.Error()
+			}
+		}
 	}
 	return clustInfo, nil
 }
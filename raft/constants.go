@@ -19,17 +19,21 @@ const (
 	// We use a bounded channel of constant size buffering incoming messages
 	//msgChanSize = 1000
 
-	// Snapshot after this many raft messages
-	//
-	// TODO: measure and get this as low as possible without affecting performance
-	//
-	snapshotPeriod = 250
-
 	//peerUrlKeyPrefix = "peerUrl-"
 
 	chainExtensionMessage = "Successfully extended chain"
+
+	// DefaultLearnerMaxLag is the number of entries a learner may lag behind
+	// the leader to be promoted to voter by default
+	DefaultLearnerMaxLag = 100
 )
 
 var (
 	appliedDbKey = []byte("applied")
+
+	// Snapshot after this many raft messages, lowered by the tests
+	//
+	// TODO: measure and get this as low as possible without affecting performance
+	//
+	snapshotPeriod uint64 = 250
 )
@@ -0,0 +1,110 @@
+package raft
+
+import (
+	"errors"
+	"fmt"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// The failures of the requests to the raft cluster caused by the leadership
+// are reported as a *ProposalError matching one of the sentinel errors below,
+// which tells the clients whether and where to retry them.
+
+var (
+	// ErrNotLeader is matched by the requests to be served by the leader
+	// received by another node.
+	ErrNotLeader = errors.New("node is not the raft leader")
+	// ErrProposalDropped is matched by the proposals raft dropped, e.g. while
+	// the leadership changes.
+	ErrProposalDropped = errors.New("raft proposal dropped")
+	// ErrClusterUnavailable is matched by the requests received while no
+	// leader is elected.
+	ErrClusterUnavailable = errors.New("no leader is currently elected")
+)
+
+const (
+	notLeaderCode          = -32020
+	proposalDroppedCode    = -32021
+	clusterUnavailableCode = -32022
+)
+
+var (
+	notLeaderMeter          = metrics.NewRegisteredMeter("raft/errors/notleader", nil)
+	proposalDroppedMeter    = metrics.NewRegisteredMeter("raft/errors/proposaldropped", nil)
+	clusterUnavailableMeter = metrics.NewRegisteredMeter("raft/errors/clusterunavailable", nil)
+)
+
+// ProposalError is a request to the raft cluster failed because of the
+// leadership.
+type ProposalError struct {
+	Err    error  // ErrNotLeader, ErrProposalDropped or ErrClusterUnavailable
+	Leader string // enode of the leader, if known
+}
+
+// ProposalErrorData is the data of the JSON-RPC errors of the failures.
+type ProposalErrorData struct {
+	Action string `json:"action"`           // what the client should do
+	Leader string `json:"leader,omitempty"` // enode of the leader to retry against
+}
+
+// newProposalError returns the error of a failure, counting it.
+func newProposalError(err error, leader string) *ProposalError {
+	switch err {
+	case ErrNotLeader:
+		notLeaderMeter.Mark(1)
+	case ErrProposalDropped:
+		proposalDroppedMeter.Mark(1)
+	case ErrClusterUnavailable:
+		clusterUnavailableMeter.Mark(1)
+	}
+	return &ProposalError{Err: err, Leader: leader}
+}
+
+// proposalFailure returns the error of a proposal raft failed, the other
+// errors being returned as they are.
+func proposalFailure(err error) error {
+	if err == etcdRaft.ErrProposalDropped {
+		return newProposalError(ErrProposalDropped, "")
+	}
+	return err
+}
+
+func (e *ProposalError) Error() string {
+	if e.Leader != "" {
+		return fmt.Sprintf("%v, leader %s", e.Err, e.Leader)
+	}
+	return e.Err.Error()
+}
+
+func (e *ProposalError) Unwrap() error { return e.Err }
+
+// ErrorCode returns the JSON-RPC error code of the failure.
+func (e *ProposalError) ErrorCode() int {
+	switch e.Err {
+	case ErrNotLeader:
+		return notLeaderCode
+	case ErrProposalDropped:
+		return proposalDroppedCode
+	default:
+		return clusterUnavailableCode
+	}
+}
+
+// ErrorData returns what the client should do, and the leader to retry
+// against.
+func (e *ProposalError) ErrorData() interface{} {
+	data := ProposalErrorData{Leader: e.Leader}
+	switch e.Err {
+	case ErrNotLeader:
+		data.Action = "retry against the leader"
+	case ErrProposalDropped:
+		data.Action = "retry"
+	default:
+		data.Action = "retry once a leader is elected"
+	}
+	return data
+}
@@ -0,0 +1,19 @@
+package raft
+
+import (
+	"errors"
+	"testing"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalError(t *testing.T) {
+	err := newProposalError(ErrNotLeader, "enode://leader")
+	assert.True(t, errors.Is(err, ErrNotLeader))
+	assert.Equal(t, notLeaderCode, err.ErrorCode())
+	assert.Equal(t, ProposalErrorData{Action: "retry against the leader", Leader: "enode://leader"}, err.ErrorData())
+
+	assert.Equal(t, proposalDroppedCode, proposalFailure(etcdRaft.ErrProposalDropped).(*ProposalError).ErrorCode())
+	assert.Equal(t, clusterUnavailableCode, newProposalError(ErrClusterUnavailable, "").ErrorCode())
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/event"
@@ -51,15 +52,27 @@ type ProtocolManager struct {
 	appliedIndex  uint64 // The index of the last-applied raft entry
 	snapshotIndex uint64 // The index of the latest snapshot.
 
+	// Quorum: syncing the chain to the head of a raft snapshot, and the last
+	// failure to do so
+	catchingUp bool
+	catchUpErr error
+
 	// Remote peer state (protected by mu vs concurrent access via JS)
 	leader       uint16
 	peers        map[uint16]*Peer
 	removedPeers mapset.Set // *Permanently removed* peers
+	// Quorum: the peers the last raft snapshot could not be sent to
+	snapshotFailures map[uint16]bool
 
 	// P2P transport
 	p2pServer *p2p.Server
 	useDns    bool
 
+	// Quorum: entries a learner may lag behind the leader to be promoted
+	learnerMaxLag uint64
+	// Quorum: hands the local transactions over to the new leaders, nil not to
+	relay *txRelay
+
 	// Blockchain services
 	blockchain *core.BlockChain
 	downloader *downloader.Downloader
@@ -93,13 +106,11 @@ type ProtocolManager struct {
 	raftStorage  *etcdRaft.MemoryStorage // Volatile raft storage
 }
 
-var errNoLeaderElected = errors.New("no leader is currently elected")
-
 //
 // Public interface
 //
 
-func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockChain, mux *event.TypeMux, bootstrapNodes []*enode.Node, joinExisting bool, datadir string, minter *minter, downloader *downloader.Downloader, useDns bool, p2pServer *p2p.Server) (*ProtocolManager, error) {
+func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockChain, mux *event.TypeMux, bootstrapNodes []*enode.Node, joinExisting bool, datadir string, minter *minter, downloader *downloader.Downloader, useDns bool, p2pServer *p2p.Server, learnerMaxLag uint64) (*ProtocolManager, error) {
 	waldir := fmt.Sprintf("%s/raft-wal", datadir)
 	snapdir := fmt.Sprintf("%s/raft-snap", datadir)
 	quorumRaftDbLoc := fmt.Sprintf("%s/quorum-raft-state", datadir)
@@ -127,6 +138,7 @@ func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockCh
 		downloader:          downloader,
 		useDns:              useDns,
 		p2pServer:           p2pServer,
+		learnerMaxLag:       learnerMaxLag,
 	}
 
 	if db, err := openQuorumRaftDb(quorumRaftDbLoc); err != nil {
@@ -387,10 +399,19 @@ func (pm *ProtocolManager) PromoteToPeer(raftId uint16) (bool, error) {
 		return false, errors.New("learner node can't promote to peer")
 	}
 
+	// Quorum: promoting a voter again is a no-op, e.g. a retried call
+	if pm.isVerifier(raftId) {
+		return true, nil
+	}
+
 	if !pm.isLearner(raftId) {
 		return false, fmt.Errorf("%d is not a learner. only learner can be promoted to peer", raftId)
 	}
 
+	if err := pm.checkPromotion(raftId, pm.rawNode().Status()); err != nil {
+		return false, err
+	}
+
 	pm.confChangeProposalC <- raftpb.ConfChange{
 		Type:   raftpb.ConfChangeAddNode,
 		NodeID: uint64(raftId),
@@ -398,6 +419,43 @@ func (pm *ProtocolManager) PromoteToPeer(raftId uint16) (bool, error) {
 	return true, nil
 }
 
+// Quorum
+// checkPromotion fails unless the raft status is the leader's, the learner
+// replicated the log to within learnerMaxLag entries of the applied index of
+// the leader, and the voters recently active along with the learner make up
+// the quorum of the configuration it joins, which would otherwise stall until
+// enough of the voters come back.
+func (pm *ProtocolManager) checkPromotion(raftId uint16, status etcdRaft.Status) error {
+	if status.RaftState != etcdRaft.StateLeader {
+		if status.Lead == etcdRaft.None {
+			return newProposalError(ErrClusterUnavailable, "")
+		}
+		// learners are promoted on the leader
+		return newProposalError(ErrNotLeader, pm.peerEnode(uint16(status.Lead)))
+	}
+	learner, ok := status.Progress[uint64(raftId)]
+	if !ok || !learner.RecentActive {
+		return fmt.Errorf("cannot promote learner %d: not recently active", raftId)
+	}
+	if learner.Match < status.Applied && status.Applied-learner.Match > pm.learnerMaxLag {
+		return fmt.Errorf("cannot promote learner %d: %d entries behind the leader, at most %d allowed", raftId, status.Applied-learner.Match, pm.learnerMaxLag)
+	}
+	voters, active := 0, 0
+	for id, progress := range status.Progress {
+		if progress.IsLearner {
+			continue
+		}
+		voters++
+		if id == status.ID || progress.RecentActive {
+			active++
+		}
+	}
+	if quorum := (voters+1)/2 + 1; active+1 < quorum {
+		return fmt.Errorf("cannot promote learner %d: %d of the %d voters are active, the %d voters would need %d", raftId, active, voters, voters+1, quorum)
+	}
+	return nil
+}
+
 //
 // MsgWriter interface (necessary for p2p.Send)
 //
@@ -434,6 +492,7 @@ func (pm *ProtocolManager) ReportSnapshot(id uint64, status etcdRaft.SnapshotSta
 	} else if status == etcdRaft.SnapshotFinish {
 		log.Info("finished sending snapshot", "raft peer", id)
 	}
+	pm.recordSnapshotSent(uint16(id), status == etcdRaft.SnapshotFinish)
 
 	pm.rawNode().ReportSnapshot(id, status)
 }
@@ -603,6 +662,51 @@ func (pm *ProtocolManager) startRaft() {
 	go pm.serveLocalProposals()
 	go pm.eventLoop()
 	go pm.handleRoleChange(pm.rawNode().RoleChan().Out())
+	go pm.handleEmergencyReadOnly()
+}
+
+// Quorum
+// handleEmergencyReadOnly hands the leadership over when the node enters the
+// emergency read-only mode, in which it does not mint.
+func (pm *ProtocolManager) handleEmergencyReadOnly() {
+	ch := make(chan emergency.State, 1)
+	sub := emergency.Subscribe(ch)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case state := <-ch:
+			if state.Enabled {
+				pm.transferLeadership()
+			}
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
+// transferLeadership transfers the leadership, if the node has it, to the
+// voter whose log is the most up to date.
+func (pm *ProtocolManager) transferLeadership() {
+	status := pm.rawNode().Status()
+	if status.RaftState != etcdRaft.StateLeader {
+		return
+	}
+	var transferee, match uint64
+	for id, progress := range status.Progress {
+		if id == status.ID || !pm.isVerifier(uint16(id)) {
+			continue
+		}
+		if transferee == 0 || progress.Match > match || (progress.Match == match && id < transferee) {
+			transferee, match = id, progress.Match
+		}
+	}
+	if transferee == 0 {
+		log.Warn("No other voter to transfer the raft leadership to, the cluster stops minting in emergency read-only mode")
+		return
+	}
+	log.Info("Transferring the raft leadership in emergency read-only mode", "transferee", transferee)
+	pm.rawNode().TransferLeadership(context.TODO(), status.ID, transferee)
 }
 
 func (pm *ProtocolManager) setLocalAddress(addr *Address) {
@@ -681,6 +785,9 @@ func (pm *ProtocolManager) handleRoleChange(roleC <-chan interface{}) {
 			if intRole == minterRole {
 				log.EmitCheckpoint(log.BecameMinter)
 				pm.minter.start()
+				if emergency.Check() != nil {
+					go pm.transferLeadership()
+				}
 			} else { // verifier
 				if pm.isVerifierNode() {
 					log.EmitCheckpoint(log.BecameVerifier)
@@ -735,7 +842,9 @@ func (pm *ProtocolManager) serveLocalProposals() {
 			r.Read(buffer)
 
 			// blocks until accepted by the raft state machine
-			pm.rawNode().Propose(context.TODO(), buffer)
+			if err := proposalFailure(pm.rawNode().Propose(context.TODO(), buffer)); err != nil {
+				log.Warn("Failed to propose block", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+			}
 		case cc, ok := <-pm.confChangeProposalC:
 			if !ok {
 				log.Info("error: read from confChangeProposalC failed")
@@ -744,7 +853,9 @@ func (pm *ProtocolManager) serveLocalProposals() {
 
 			confChangeCount++
 			cc.ID = confChangeCount
-			pm.rawNode().ProposeConfChange(context.TODO(), cc)
+			if err := proposalFailure(pm.rawNode().ProposeConfChange(context.TODO(), cc)); err != nil {
+				log.Warn("Failed to propose configuration change", "type", cc.Type, "raft id", cc.NodeID, "err", err)
+			}
 		case <-pm.quitSync:
 			return
 		}
@@ -923,7 +1034,7 @@ func (pm *ProtocolManager) eventLoop() {
 							forceSnapshot = true
 							//if raft id exists as peer, you are promoting learner to peer
 							if pm.isRaftIdUsed(raftId) {
-								log.Info("promote learner node to voter node", "raft id", raftId)
+								log.Info("promoted learner node to voter node", "raft id", raftId, "index", entry.Index)
 							} else {
 								//if raft id does not exist, you are adding peer/learner
 								log.Info("add peer/learner -> "+confChangeTypeName, "raft id", raftId)
@@ -1066,9 +1177,27 @@ func (pm *ProtocolManager) updateLeader(leader uint64) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
+	// Quorum: hand the local transactions over to a new leader once
+	if changed := pm.leader != uint16(leader); changed && leader != etcdRaft.None && uint16(leader) != pm.raftId && pm.relay != nil {
+		if peer, ok := pm.peers[uint16(leader)]; ok {
+			go pm.relay.reroute(peer.p2pNode)
+		}
+	}
 	pm.leader = uint16(leader)
 }
 
+// Quorum
+// peerEnode returns the enode of the peer, empty if unknown.
+func (pm *ProtocolManager) peerEnode(raftId uint16) string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if peer, ok := pm.peers[raftId]; ok {
+		return peer.p2pNode.String()
+	}
+	return ""
+}
+
 // The Address for the current leader, or an error if no leader is elected.
 func (pm *ProtocolManager) LeaderAddress() (*Address, error) {
 	pm.mu.RLock()
@@ -1080,7 +1209,7 @@ func (pm *ProtocolManager) LeaderAddress() (*Address, error) {
 		return l.address, nil
 	}
 	// We expect to reach this if pm.leader is 0, which is how etcd denotes the lack of a leader.
-	return nil, errNoLeaderElected
+	return nil, ErrClusterUnavailable
 }
 
 // Returns the raft id for a given enodeId
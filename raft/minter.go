@@ -25,8 +25,10 @@ import (
 	"github.com/eapache/channels"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/emergency"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -63,6 +65,7 @@ type minter struct {
 	minting          int32 // Atomic status counter
 	shouldMine       *channels.RingChannel
 	blockTime        time.Duration
+	clock            mclock.Clock // Quorum - times the minting
 	speculativeChain *speculativeChain
 
 	invalidRaftOrderingChan chan InvalidRaftOrdering
@@ -86,6 +89,7 @@ func newMinter(config *params.ChainConfig, eth *RaftService, blockTime time.Dura
 		chain:            eth.BlockChain(),
 		shouldMine:       channels.NewRingChannel(1),
 		blockTime:        blockTime,
+		clock:            mclock.System{},
 		speculativeChain: newSpeculativeChain(),
 
 		invalidRaftOrderingChan: make(chan InvalidRaftOrdering, 1),
@@ -203,15 +207,15 @@ func (minter *minter) eventLoop() {
 //
 // TODO(joel): this has a small bug in that you can't call it *immediately* when
 // first allocated.
-func throttle(rate time.Duration, f func()) func() {
+func throttle(clock mclock.Clock, rate time.Duration, f func()) func() {
 	request := channels.NewRingChannel(1)
 
 	// every tick, block waiting for another request. then serve it immediately
 	go func() {
-		ticker := time.NewTicker(rate)
+		ticker := clock.NewTicker(rate)
 		defer ticker.Stop()
 
-		for range ticker.C {
+		for range ticker.C() {
 			<-request.Out()
 			f()
 		}
@@ -229,7 +233,7 @@ func throttle(rate time.Duration, f func()) func() {
 //      requested.
 //   2. We never mint a block more frequently than `blockTime`.
 func (minter *minter) mintingLoop() {
-	throttledMintNewBlock := throttle(minter.blockTime, func() {
+	throttledMintNewBlock := throttle(minter.clock, minter.blockTime, func() {
 		if atomic.LoadInt32(&minter.minting) == 1 {
 			minter.mintNewBlock()
 		}
@@ -310,6 +314,11 @@ func (minter *minter) mintNewBlock() {
 	minter.mu.Lock()
 	defer minter.mu.Unlock()
 
+	if emergency.Check() != nil {
+		log.Info("Not minting a new block in emergency read-only mode")
+		return
+	}
+
 	work := minter.createWork()
 	transactions := minter.getTransactions()
 
@@ -354,6 +363,7 @@ func (minter *minter) mintNewBlock() {
 	}
 
 	minter.speculativeChain.extend(block)
+	minter.eth.txPool.Latency().Proposed(block) // Quorum
 
 	minter.mux.Post(core.NewMinedBlockEvent{Block: block})
 
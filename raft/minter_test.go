@@ -1,16 +1,19 @@
 package raft
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 	"testing"
 	"time"
 
+	etcdRaft "github.com/coreos/etcd/raft"
 	"github.com/coreos/etcd/raft/raftpb"
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/node"
@@ -125,6 +128,75 @@ func TestPromoteLearnerToPeer_whenTypical(t *testing.T) {
 	}
 }
 
+func TestPromoteLearnerToPeer_whenLagging(t *testing.T) {
+	learnerRaftId := uint16(3)
+	raftService := newTestRaftService(t, 2, []uint64{2}, []uint64{uint64(learnerRaftId)})
+	status := raftService.raftProtocolManager.rawNode().(*statusNode).status
+	learner := status.Progress[uint64(learnerRaftId)]
+	learner.Match = status.Applied - DefaultLearnerMaxLag - 1
+	status.Progress[uint64(learnerRaftId)] = learner
+
+	_, err := raftService.raftProtocolManager.PromoteToPeer(learnerRaftId)
+
+	if err == nil || !strings.Contains(err.Error(), "entries behind the leader") {
+		t.Errorf("expected lagging learner to be refused, got: %v", err)
+	}
+}
+
+func TestPromoteLearnerToPeer_whenQuorumLost(t *testing.T) {
+	learnerRaftId := uint16(4)
+	raftService := newTestRaftService(t, 1, []uint64{1, 2, 3}, []uint64{uint64(learnerRaftId)})
+	status := raftService.raftProtocolManager.rawNode().(*statusNode).status
+	for _, id := range []uint64{2, 3} {
+		progress := status.Progress[id]
+		progress.RecentActive = false
+		status.Progress[id] = progress
+	}
+
+	_, err := raftService.raftProtocolManager.PromoteToPeer(learnerRaftId)
+
+	if err == nil || !strings.Contains(err.Error(), "voters are active") {
+		t.Errorf("expected promotion losing the quorum to be refused, got: %v", err)
+	}
+}
+
+func TestPromoteLearnerToPeer_whenNotLeader(t *testing.T) {
+	learnerRaftId := uint16(3)
+	raftService := newTestRaftService(t, 2, []uint64{1, 2}, []uint64{uint64(learnerRaftId)})
+	node := raftService.raftProtocolManager.rawNode().(*statusNode)
+	node.status.RaftState = etcdRaft.StateFollower
+	node.status.Lead = 1
+
+	_, err := raftService.raftProtocolManager.PromoteToPeer(learnerRaftId)
+
+	var proposalErr *ProposalError
+	if !errors.As(err, &proposalErr) || proposalErr.Err != ErrNotLeader {
+		t.Errorf("expected not leader error, got: %v", err)
+	}
+}
+
+func TestPromoteLearnerToPeer_whenAlreadyPeer(t *testing.T) {
+	raftService := newTestRaftService(t, 2, []uint64{2, 3}, []uint64{})
+	done := make(chan error, 1)
+	go func() {
+		ok, err := raftService.raftProtocolManager.PromoteToPeer(3)
+		if err == nil && !ok {
+			err = fmt.Errorf("not promoted")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("re-promotion of a peer failed %v", err)
+		}
+	case confChange := <-raftService.raftProtocolManager.confChangeProposalC:
+		t.Errorf("unexpected conf change %s for a peer", confChange.Type.String())
+	case <-time.After(time.Millisecond * 200):
+		t.Errorf("re-promotion of a peer did not return")
+	}
+}
+
 func TestAddLearnerOrPeer_fromLearner(t *testing.T) {
 
 	raftService := newTestRaftService(t, 3, []uint64{2}, []uint64{3})
@@ -202,7 +274,79 @@ func newTestRaftService(t *testing.T, raftId uint16, nodes []uint64, learners []
 		removedPeers:        mapset.NewSet(),
 		confState:           raftpb.ConfState{Nodes: nodes, Learners: learners},
 		p2pServer:           mockp2p,
+		unsafeRawNode:       &statusNode{status: newLeaderStatus(raftId, nodes, learners)},
+		learnerMaxLag:       DefaultLearnerMaxLag,
 	}
 	raftService := &RaftService{nodeKey: nodeKey, raftProtocolManager: raftProtocolManager}
 	return raftService
 }
+
+// statusNode is a raft node only reporting its status.
+type statusNode struct {
+	etcdRaft.Node
+	status etcdRaft.Status
+}
+
+func (n *statusNode) Status() etcdRaft.Status {
+	return n.status
+}
+
+// newLeaderStatus returns the status of the leader of a cluster whose members
+// are all active and caught up.
+func newLeaderStatus(raftId uint16, nodes []uint64, learners []uint64) etcdRaft.Status {
+	status := etcdRaft.Status{ID: uint64(raftId), Applied: 1000, Progress: make(map[uint64]etcdRaft.Progress)}
+	status.RaftState = etcdRaft.StateLeader
+	status.Lead = uint64(raftId)
+	for _, id := range nodes {
+		status.Progress[id] = etcdRaft.Progress{Match: status.Applied, RecentActive: true}
+	}
+	for _, id := range learners {
+		status.Progress[id] = etcdRaft.Progress{Match: status.Applied, RecentActive: true, IsLearner: true}
+	}
+	return status
+}
+
+func TestThrottle(t *testing.T) {
+	clock := new(mclock.Simulated)
+	calls := make(chan struct{}, 4)
+	throttled := throttle(clock, time.Second, func() { calls <- struct{}{} })
+	called := func() bool {
+		select {
+		case <-calls:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+
+	// the requests before a tick are served once
+	throttled()
+	throttled()
+	throttled()
+	clock.WaitForTimers(1)
+	if called() {
+		t.Fatal("served before the tick")
+	}
+	clock.Run(time.Second)
+	if !called() {
+		t.Fatal("not served on the tick")
+	}
+	if called() {
+		t.Fatal("served more than once per tick")
+	}
+
+	// a request after an idle tick is served right away
+	clock.Run(time.Second)
+	throttled()
+	if !called() {
+		t.Fatal("not served after an idle tick")
+	}
+	throttled()
+	if called() {
+		t.Fatal("served more than once per tick")
+	}
+	clock.Run(time.Second)
+	if !called() {
+		t.Fatal("not served on the next tick")
+	}
+}
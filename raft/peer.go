@@ -31,6 +31,9 @@ type ClusterInfo struct {
 	Address
 	Role       string `json:"role"`
 	NodeActive bool   `json:"nodeActive"`
+	// Quorum: syncing the chain from a raft snapshot, see catchUpStatus
+	CatchingUp   bool   `json:"catchingUp"`
+	CatchUpError string `json:"catchUpError,omitempty"`
 }
 
 func newAddress(raftId uint16, raftPort int, node *enode.Node, useDns bool) *Address {
@@ -0,0 +1,77 @@
+package raft
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// Quorum
+//
+// The transactions submitted to a follower only reach the leader through the
+// transaction gossip, which does not send them again to the peers known to
+// have them: those the leader dropped while losing the leadership, or never
+// received from a flapping one, would wait in the pool of the follower. The
+// followers hand their pending local transactions over to each new leader
+// directly, each transaction at most once.
+
+const (
+	maxReroutedTxs     = 4096  // transactions handed over to a new leader at most
+	reroutedCacheLimit = 65536 // transactions handed over remembered
+)
+
+var reroutedTxsMeter = metrics.NewRegisteredMeter("raft/relay/rerouted", nil)
+
+// txPool is the part of the transaction pool the relay reads.
+type txPool interface {
+	Pending() (map[common.Address]types.Transactions, error)
+	Locals() []common.Address
+}
+
+// txRelay hands the local transactions of a follower over to the new leaders.
+type txRelay struct {
+	pool     txPool
+	send     func(id enode.ID, txs types.Transactions) bool // reports whether the peer is connected
+	rerouted *lru.Cache                                     // hashes of the transactions handed over
+}
+
+func newTxRelay(pool txPool, send func(id enode.ID, txs types.Transactions) bool) *txRelay {
+	rerouted, _ := lru.New(reroutedCacheLimit)
+	return &txRelay{pool: pool, send: send, rerouted: rerouted}
+}
+
+// reroute sends the pending local transactions not handed over yet to the
+// leader.
+func (r *txRelay) reroute(leader *enode.Node) {
+	pending, err := r.pool.Pending()
+	if err != nil {
+		log.Warn("Failed to get the pending transactions to reroute", "err", err)
+		return
+	}
+	var txs types.Transactions
+	for _, account := range r.pool.Locals() {
+		for _, tx := range pending[account] {
+			if len(txs) == maxReroutedTxs {
+				break
+			}
+			if !r.rerouted.Contains(tx.Hash()) {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+	if !r.send(leader.ID(), txs) {
+		log.Debug("Raft leader not connected, transactions not rerouted", "leader", leader.ID())
+		return
+	}
+	for _, tx := range txs {
+		r.rerouted.Add(tx.Hash(), struct{}{})
+	}
+	reroutedTxsMeter.Mark(int64(len(txs)))
+	log.Info("Rerouted local transactions to the new raft leader", "leader", leader.ID(), "count", len(txs))
+}
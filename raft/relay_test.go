@@ -0,0 +1,59 @@
+package raft
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubTxPool struct {
+	pending map[common.Address]types.Transactions
+	locals  []common.Address
+}
+
+func (p *stubTxPool) Pending() (map[common.Address]types.Transactions, error) { return p.pending, nil }
+func (p *stubTxPool) Locals() []common.Address                                { return p.locals }
+
+func TestTxRelay_ReroutesLocalTransactionsOnce(t *testing.T) {
+	local, remote := common.Address{0x01}, common.Address{0x02}
+	newTx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{0x03}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	}
+	pool := &stubTxPool{
+		pending: map[common.Address]types.Transactions{
+			local:  {newTx(0), newTx(1)},
+			remote: {newTx(2)},
+		},
+		locals: []common.Address{local},
+	}
+	var sent []types.Transactions
+	connected := false
+	relay := newTxRelay(pool, func(id enode.ID, txs types.Transactions) bool {
+		if connected {
+			sent = append(sent, txs)
+		}
+		return connected
+	})
+	leader := enode.NewV4(&mustNewNodeKey(t).PublicKey, nil, 0, 0)
+
+	// the transactions are handed over once the leader is connected
+	relay.reroute(leader)
+	assert.Empty(t, sent)
+	connected = true
+	relay.reroute(leader)
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, pool.pending[local], sent[0])
+	}
+
+	relay.reroute(leader)
+	assert.Len(t, sent, 1, "transactions rerouted again")
+	pool.pending[local] = append(pool.pending[local], newTx(4))
+	relay.reroute(leader)
+	if assert.Len(t, sent, 2) {
+		assert.Equal(t, types.Transactions{pool.pending[local][2]}, sent[1])
+	}
+}
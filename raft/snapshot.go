@@ -2,6 +2,7 @@ package raft
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -9,6 +10,7 @@ import (
 	"sort"
 	"time"
 
+	etcdRaft "github.com/coreos/etcd/raft"
 	"github.com/coreos/etcd/raft/raftpb"
 	"github.com/coreos/etcd/snap"
 	"github.com/coreos/etcd/wal/walpb"
@@ -23,6 +25,9 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// Quorum
+var errSnapshotNotSent = errors.New("failed to send the raft snapshot")
+
 type SnapshotWithHostnames struct {
 	Addresses      []Address
 	RemovedRaftIds []uint16
@@ -330,7 +335,11 @@ func (pm *ProtocolManager) applyRaftSnapshot(raftSnapshot raftpb.Snapshot) {
 	preSyncHead := pm.blockchain.CurrentBlock()
 
 	if latestBlock := pm.blockchain.GetBlockByHash(latestBlockHash); latestBlock == nil {
+		// Quorum: the node does not tick, hence cannot be elected and mint,
+		// until it has caught up
+		pm.setCatchingUp(true, nil)
 		pm.syncBlockchainUntil(latestBlockHash)
+		pm.setCatchingUp(false, nil)
 		pm.logNewlyAcceptedTransactions(preSyncHead)
 
 		log.Info(chainExtensionMessage, "hash", pm.blockchain.CurrentBlock().Hash())
@@ -364,6 +373,7 @@ func (pm *ProtocolManager) syncBlockchainUntil(hash common.Hash) {
 
 			if err := pm.downloader.Synchronise(peerIdPrefix, hash, big.NewInt(0), downloader.BoundedFullSync); err != nil {
 				log.Info("failed to synchronize with peer", "peer id", peerId)
+				pm.setCatchingUp(true, fmt.Errorf("failed to synchronize with peer %s: %v", peerIdPrefix, err))
 
 				time.Sleep(500 * time.Millisecond)
 			} else {
@@ -373,6 +383,63 @@ func (pm *ProtocolManager) syncBlockchainUntil(hash common.Hash) {
 	}
 }
 
+// Quorum
+func (pm *ProtocolManager) setCatchingUp(catchingUp bool, err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.catchingUp = catchingUp
+	pm.catchUpErr = err
+}
+
+// Quorum
+// recordSnapshotSent records whether the leader sent its raft snapshot to the
+// peer.
+func (pm *ProtocolManager) recordSnapshotSent(raftId uint16, sent bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if sent {
+		delete(pm.snapshotFailures, raftId)
+		return
+	}
+	if pm.snapshotFailures == nil {
+		pm.snapshotFailures = make(map[uint16]bool)
+	}
+	pm.snapshotFailures[raftId] = true
+}
+
+// Quorum
+// catchUpStatus returns whether the node is catching up from a raft snapshot,
+// with the last failure to do so. The other nodes are only known to catch up
+// by the leader, which sends them its snapshot once they lag behind the
+// compacted log.
+func (pm *ProtocolManager) catchUpStatus(raftId uint16) (bool, error) {
+	if raftId == pm.raftId {
+		pm.mu.RLock()
+		defer pm.mu.RUnlock()
+		return pm.catchingUp, pm.catchUpErr
+	}
+	status := pm.rawNode().Status()
+	if status.RaftState != etcdRaft.StateLeader {
+		return false, nil
+	}
+	progress, ok := status.Progress[uint64(raftId)]
+	if !ok {
+		return false, nil
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if progress.State != etcdRaft.ProgressStateSnapshot && progress.Match >= pm.snapshotIndex {
+		return false, nil
+	}
+	if pm.snapshotFailures[raftId] {
+		return true, errSnapshotNotSent
+	}
+	return true, nil
+}
+
 func (pm *ProtocolManager) logNewlyAcceptedTransactions(preSyncHead *types.Block) {
 	newHead := pm.blockchain.CurrentBlock()
 	numBlocks := newHead.NumberU64() - preSyncHead.NumberU64()
@@ -0,0 +1,226 @@
+package raft
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TestProtocolManager_catchUpFromSnapshot stops a node for longer than the
+// snapshot period, so that it can only catch up through a raft snapshot and a
+// chain sync once restarted.
+func TestProtocolManager_catchUpFromSnapshot(t *testing.T) {
+	defer func(period uint64) { snapshotPeriod = period }(snapshotPeriod)
+	snapshotPeriod = 4
+
+	tmpWorkingDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpWorkingDir)
+
+	const count = 3
+	var (
+		raftPorts = make([]uint16, count)
+		nodeKeys  = make([]*ecdsa.PrivateKey, count)
+		peers     = make([]*enode.Node, count)
+		stacks    = make([]*node.Node, count)
+		services  = make([]*RaftService, count)
+	)
+	for i := 0; i < count; i++ {
+		raftPorts[i] = uint16(freePort(t))
+		nodeKeys[i] = mustNewNodeKey(t)
+		peers[i] = enode.NewV4Hostname(&nodeKeys[i].PublicKey, net.IPv4(127, 0, 0, 1).String(), freePort(t), 0, int(raftPorts[i]))
+	}
+	start := func(i int) {
+		stack, s, err := startSyncingRaftNode(uint16(i+1), raftPorts[i], tmpWorkingDir, nodeKeys[i], peers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stacks[i], services[i] = stack, s
+	}
+	for i := 0; i < count; i++ {
+		start(i)
+	}
+	defer func() {
+		for _, stack := range stacks {
+			stack.Close()
+		}
+	}()
+
+	waitFor := func(what string, cond func() bool) {
+		for deadline := time.Now().Add(30 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+	leader := func() int {
+		for i, s := range services {
+			if s != nil && s.raftProtocolManager.NodeInfo().Role == "minter" {
+				return i
+			}
+		}
+		return -1
+	}
+	waitFor("a leader", func() bool { return leader() >= 0 })
+
+	// mint a block at a time until the blocks are part of a snapshot
+	sender, _ := crypto.GenerateKey()
+	signer := types.HomesteadSigner{}
+	var nonce uint64
+	mint := func(s *RaftService) {
+		head := s.blockchain.CurrentBlock().NumberU64()
+		tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{1}, common.Big0, 21000, common.Big0, nil), signer, sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce++
+		if err := s.txPool.AddLocal(tx); err != nil {
+			t.Fatal(err)
+		}
+		waitFor("a block", func() bool { return s.blockchain.CurrentBlock().NumberU64() > head })
+	}
+	first := leader()
+	mint(services[first])
+
+	lagging := (first + 1) % count
+	stacks[lagging].Close()
+	waitForWalUnlocked(tmpWorkingDir, lagging)
+	services[lagging] = nil
+
+	minter := services[first]
+	for i := uint64(0); i < 3*snapshotPeriod; i++ {
+		mint(minter)
+	}
+	head := minter.blockchain.CurrentBlock()
+	if snapshotIndex := minter.raftProtocolManager.NodeInfo().SnapshotIndex; snapshotIndex < 3*snapshotPeriod {
+		t.Fatalf("log of the leader compacted to %d", snapshotIndex)
+	}
+
+	start(lagging)
+	rejoined := services[lagging]
+	waitFor("the node to catch up", func() bool {
+		return rejoined.blockchain.CurrentBlock().Hash() == head.Hash()
+	})
+	mint(minter)
+	waitFor("the node to follow", func() bool {
+		return rejoined.blockchain.CurrentBlock().Hash() == minter.blockchain.CurrentBlock().Hash()
+	})
+	for _, s := range []*RaftService{minter, rejoined} {
+		cluster, err := NewPublicRaftAPI(s).Cluster()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, info := range cluster {
+			if info.CatchingUp || info.CatchUpError != "" {
+				t.Errorf("node %d still catching up: %+v", info.RaftId, info)
+			}
+		}
+	}
+}
+
+func TestProtocolManager_catchUpStatus(t *testing.T) {
+	s := newTestRaftService(t, 1, []uint64{1, 2, 3}, nil)
+	pm := s.raftProtocolManager
+	status := newLeaderStatus(1, []uint64{1, 2, 3}, nil)
+	status.Progress[2] = etcdRaft.Progress{Match: 400, State: etcdRaft.ProgressStateProbe}
+	status.Progress[3] = etcdRaft.Progress{Match: 100, State: etcdRaft.ProgressStateSnapshot, PendingSnapshot: 500}
+	pm.unsafeRawNode = &statusNode{status: status}
+	pm.snapshotIndex = 500
+
+	for raftId, want := range map[uint16]bool{1: false, 2: true, 3: true} {
+		if catchingUp, err := pm.catchUpStatus(raftId); catchingUp != want || err != nil {
+			t.Errorf("node %d: catching up %v, %v, want %v", raftId, catchingUp, err, want)
+		}
+	}
+	pm.recordSnapshotSent(3, false)
+	if _, err := pm.catchUpStatus(3); err != errSnapshotNotSent {
+		t.Errorf("failure to send the snapshot not reported: %v", err)
+	}
+	pm.recordSnapshotSent(3, true)
+	if _, err := pm.catchUpStatus(3); err != nil {
+		t.Errorf("failure reported once sent: %v", err)
+	}
+
+	syncErr := errors.New("no peer")
+	pm.setCatchingUp(true, syncErr)
+	if catchingUp, err := pm.catchUpStatus(1); !catchingUp || err != syncErr {
+		t.Errorf("local catch up not reported: %v, %v", catchingUp, err)
+	}
+	// only the leader knows of the other nodes catching up
+	status.RaftState = etcdRaft.StateFollower
+	pm.unsafeRawNode = &statusNode{status: status}
+	if catchingUp, _ := pm.catchUpStatus(3); catchingUp {
+		t.Error("follower reports another node catching up")
+	}
+}
+
+// freePort returns a port no longer listened on.
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func waitForWalUnlocked(tmpWorkingDir string, i int) {
+	for isWalDirStillLocked(fmt.Sprintf("%s/node%d/raft-wal", tmpWorkingDir, i+1)) {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startSyncingRaftNode starts a raft node persisting its chain, connected to
+// its peers over p2p to sync from them.
+func startSyncingRaftNode(id, port uint16, tmpWorkingDir string, key *ecdsa.PrivateKey, nodes []*enode.Node) (*node.Node, *RaftService, error) {
+	datadir := fmt.Sprintf("%s/node%d", tmpWorkingDir, id)
+
+	stack, err := node.New(&node.Config{
+		Name:    "geth",
+		DataDir: datadir,
+		P2P: p2p.Config{
+			PrivateKey:  key,
+			ListenAddr:  fmt.Sprintf("127.0.0.1:%d", nodes[id-1].TCP()),
+			NoDiscovery: true,
+			MaxPeers:    len(nodes),
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := eth.New(stack, &eth.Config{
+		Genesis:   &core.Genesis{Config: params.QuorumTestChainConfig, GasLimit: 700000000, Difficulty: big.NewInt(0)},
+		RaftMode:  true,
+		NetworkId: 10,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := New(stack, params.QuorumTestChainConfig, id, port, false, 50*time.Millisecond, e, nodes, datadir, false, DefaultLearnerMaxLag)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := stack.Start(); err != nil {
+		return nil, nil, err
+	}
+	return stack, s, nil
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Quorum
+//
+// An AccessLogger records the requests served, one entry per request or batch:
+// the methods called, the size of the response, how long it took and the class
+// of the first error. Whether a request is logged is decided before it is
+// served, so that the sampled out requests cost nothing. The params are only
+// logged for the methods the logger allows.
+
+// AccessLogEntry is a request served, GraphQL requests being logged with the
+// names of their operations.
+type AccessLogEntry struct {
+	Time       time.Time                  `json:"time"`
+	Protocol   string                     `json:"protocol"` // http, ws, ipc or graphql
+	RequestID  string                     `json:"requestId,omitempty"`
+	Remote     string                     `json:"remote,omitempty"`
+	Identity   string                     `json:"identity,omitempty"`
+	Operations []string                   `json:"operations"`
+	Size       int                        `json:"size"`
+	DurationMs float64                    `json:"durationMs"`
+	ErrorClass string                     `json:"errorClass,omitempty"`
+	Params     map[string]json.RawMessage `json:"params,omitempty"` // by method or GraphQL variable
+}
+
+// AccessLogger records the requests served.
+type AccessLogger interface {
+	// Sample reports whether the request starting is logged.
+	Sample() bool
+	// LogsParams reports whether the params of a method, or a GraphQL
+	// variable, are logged.
+	LogsParams(name string) bool
+	// LogAccess records the entry of a request served with the context,
+	// dropping it rather than blocking.
+	LogAccess(ctx context.Context, e *AccessLogEntry)
+}
+
+// SetAccessLogger sets the logger of the requests served, nil logs none.
+func (s *Server) SetAccessLogger(logger AccessLogger) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.accessLogger = logger
+}
+
+func (r *serviceRegistry) requestLogger() AccessLogger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.accessLogger
+}
+
+// ErrorClass returns the class of a JSON-RPC error code.
+func ErrorClass(code int) string {
+	switch {
+	case code == -32700:
+		return "parse"
+	case code == -32600:
+		return "invalid_request"
+	case code == -32601:
+		return "method_not_found"
+	case code == -32602:
+		return "invalid_params"
+	case code == -32603:
+		return "internal"
+	case code == (&securityError{}).ErrorCode():
+		return "unauthorized"
+	case code <= -32000 && code >= -32099:
+		return "server"
+	}
+	return "application"
+}
+
+// accessRecord collects the entry of a request, nil if it is not logged.
+type accessRecord struct {
+	logger AccessLogger
+	entry  *AccessLogEntry
+}
+
+// startAccess returns the record of a request starting, nil unless sampled.
+func (h *handler) startAccess() *accessRecord {
+	logger := h.reg.requestLogger()
+	if logger == nil || !logger.Sample() {
+		return nil
+	}
+	protocol := "ipc"
+	if _, ok := h.conn.(*websocketCodec); ok {
+		protocol = "ws"
+	} else if _, ok := h.rootCtx.Value("scheme").(string); ok {
+		protocol = "http"
+	}
+	return &accessRecord{logger: logger, entry: &AccessLogEntry{Time: time.Now(), Protocol: protocol, Operations: []string{}}}
+}
+
+// call adds a call of the request and its answer.
+func (a *accessRecord) call(msg, answer *jsonrpcMessage) {
+	if a == nil {
+		return
+	}
+	a.entry.Operations = append(a.entry.Operations, msg.Method)
+	if a.logger.LogsParams(msg.Method) && len(msg.Params) > 0 {
+		if a.entry.Params == nil {
+			a.entry.Params = make(map[string]json.RawMessage)
+		}
+		if _, ok := a.entry.Params[msg.Method]; !ok {
+			a.entry.Params[msg.Method] = msg.Params
+		}
+	}
+	if answer != nil && answer.Error != nil && a.entry.ErrorClass == "" {
+		a.entry.ErrorClass = ErrorClass(answer.Error.Code)
+	}
+}
+
+// finish logs the request once its response was written.
+func (a *accessRecord) finish(ctx context.Context, response interface{}) {
+	if a == nil {
+		return
+	}
+	if response != nil {
+		if enc, err := json.Marshal(response); err == nil {
+			a.entry.Size = len(enc)
+		}
+	}
+	a.entry.DurationMs = float64(time.Since(a.entry.Time).Microseconds()) / 1000
+	a.entry.RequestID = log.RequestID(ctx)
+	if remote, ok := ctx.Value("remote").(string); ok {
+		a.entry.Remote = remote
+	}
+	a.logger.LogAccess(ctx, a.entry)
+}
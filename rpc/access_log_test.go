@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAccessLogger struct {
+	sample  bool
+	params  map[string]bool
+	entries chan *AccessLogEntry
+}
+
+func (l *testAccessLogger) Sample() bool                { return l.sample }
+func (l *testAccessLogger) LogsParams(name string) bool { return l.params[name] }
+
+func (l *testAccessLogger) LogAccess(ctx context.Context, e *AccessLogEntry) {
+	l.entries <- e
+}
+
+func TestHTTPAccessLog(t *testing.T) {
+	logger := &testAccessLogger{sample: true, params: map[string]bool{"test_echo": true}, entries: make(chan *AccessLogEntry, 4)}
+	s := newTestServer()
+	s.SetAccessLogger(logger)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	defer s.Stop()
+
+	post := func(body string) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set(RequestIDHeader, "req-1")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	post(`[{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":2,"method":"test_returnError","params":[]},{"jsonrpc":"2.0","id":3,"method":"test_rets","params":[]}]`)
+	e := <-logger.entries
+	assert.Equal(t, "http", e.Protocol)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.NotEmpty(t, e.Remote)
+	assert.Equal(t, []string{"test_echo", "test_returnError", "test_rets"}, e.Operations)
+	assert.Equal(t, "application", e.ErrorClass)
+	assert.True(t, e.Size > 0)
+	// only the params of the methods allowed are logged
+	require.Len(t, e.Params, 1)
+	assert.JSONEq(t, `["x",1]`, string(e.Params["test_echo"]))
+
+	post(`{"jsonrpc":"2.0","id":1,"method":"test_unknown","params":[]}`)
+	e = <-logger.entries
+	assert.Equal(t, []string{"test_unknown"}, e.Operations)
+	assert.Equal(t, "method_not_found", e.ErrorClass)
+	assert.Empty(t, e.Params)
+
+	// the requests sampled out are not logged
+	s.SetAccessLogger(&testAccessLogger{entries: logger.entries})
+	post(`{"jsonrpc":"2.0","id":1,"method":"test_rets","params":[]}`)
+	select {
+	case e := <-logger.entries:
+		t.Fatalf("request sampled out logged: %+v", e)
+	default:
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	for code, class := range map[int]string{
+		-32700: "parse",
+		-32600: "invalid_request",
+		-32601: "method_not_found",
+		-32602: "invalid_params",
+		-32603: "internal",
+		-32001: "unauthorized",
+		-32000: "server",
+		-32050: "server",
+		3:      "application",
+	} {
+		assert.Equal(t, class, ErrorClass(code), "code %d", code)
+	}
+}
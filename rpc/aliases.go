@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Quorum
+//
+// The legacy Quorum methods are also served under normalized names of the
+// quorum namespace, wherever the legacy method is served. A call by the legacy
+// name marks its deprecation meter and, if the server warns of deprecations,
+// is answered with a warning, in the response and in a header of the HTTP
+// responses. Renaming a method only takes an entry of LegacyMethodAliases.
+
+// DeprecationHeader is the HTTP response header listing the legacy methods
+// called by the request, if the server warns of deprecations.
+const DeprecationHeader = "X-Deprecated-Method"
+
+// LegacyMethodAliases maps the legacy names of the Quorum methods to their
+// normalized names. A normalized name may neither be registered by a service
+// nor be the legacy name of another method.
+var LegacyMethodAliases = map[string]string{
+	"eth_getQuorumPayload":                     "quorum_getPayload",
+	"eth_storageRoot":                          "quorum_storageRoot",
+	"eth_getContractPrivacyMetadata":           "quorum_getContractPrivacyMetadata",
+	"eth_sendRawPrivateTransaction":            "quorum_sendRawPrivateTransaction",
+	"eth_sendTransactionAsync":                 "quorum_sendTransactionAsync",
+	"quorumExtension_activeExtensionContracts": "quorum_activeExtensionContracts",
+	"quorumExtension_approveExtension":         "quorum_approveExtension",
+	"quorumExtension_extendContract":           "quorum_extendContract",
+	"quorumExtension_dryRun":                   "quorum_dryRunExtension",
+	"quorumExtension_cancelExtension":          "quorum_cancelExtension",
+	"quorumExtension_getExtensionStatus":       "quorum_getExtensionStatus",
+	"quorumExtension_requestHistoricResend":    "quorum_requestHistoricExtensionResend",
+	"quorumExtension_historicResendStatus":     "quorum_historicExtensionResendStatus",
+}
+
+var legacyAliases = mustMethodAliases(LegacyMethodAliases)
+
+// methodAliases indexes an alias table both ways.
+type methodAliases struct {
+	legacy     map[string]string // normalized name -> legacy name
+	normalized map[string]string // legacy name -> normalized name
+}
+
+func newMethodAliases(table map[string]string) (*methodAliases, error) {
+	aliases := &methodAliases{
+		legacy:     make(map[string]string, len(table)),
+		normalized: make(map[string]string, len(table)),
+	}
+	for legacy, normalized := range table {
+		for _, name := range []string{legacy, normalized} {
+			if elem := strings.SplitN(name, serviceMethodSeparator, 2); len(elem) != 2 || elem[0] == "" || elem[1] == "" {
+				return nil, fmt.Errorf("invalid method name %q", name)
+			}
+		}
+		if other, ok := aliases.legacy[normalized]; ok {
+			return nil, fmt.Errorf("%s and %s both aliased to %s", other, legacy, normalized)
+		}
+		aliases.legacy[normalized] = legacy
+		aliases.normalized[legacy] = normalized
+	}
+	for normalized := range aliases.legacy {
+		if _, ok := aliases.normalized[normalized]; ok {
+			return nil, fmt.Errorf("alias %s is a legacy name", normalized)
+		}
+	}
+	return aliases, nil
+}
+
+func mustMethodAliases(table map[string]string) *methodAliases {
+	aliases, err := newMethodAliases(table)
+	if err != nil {
+		panic(err)
+	}
+	return aliases
+}
+
+// SetDeprecationWarnings sets whether the calls by a legacy name are answered
+// with a deprecation warning.
+func (s *Server) SetDeprecationWarnings(enabled bool) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.deprecationWarnings = enabled
+}
+
+// methodAliases returns the alias table of the registry, the caller holding
+// the lock.
+func (r *serviceRegistry) methodAliases() *methodAliases {
+	if r.aliases == nil {
+		return legacyAliases
+	}
+	return r.aliases
+}
+
+// checkAliases fails if a method of the service takes the normalized name of a
+// legacy method. The caller must hold the lock.
+func (r *serviceRegistry) checkAliases(service string, callbacks map[string]*callback) error {
+	aliases := r.methodAliases()
+	for name := range callbacks {
+		method := service + serviceMethodSeparator + name
+		if legacy, ok := aliases.legacy[method]; ok {
+			return fmt.Errorf("method %s conflicts with the alias of %s", method, legacy)
+		}
+	}
+	return nil
+}
+
+// deprecation returns the normalized name of a legacy method, and whether
+// deprecations are warned of.
+func (r *serviceRegistry) deprecation(method string) (normalized string, legacy, warn bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	normalized, legacy = r.methodAliases().normalized[method]
+	return normalized, legacy, r.deprecationWarnings
+}
+
+type deprecationHeaderKey struct{}
+
+// withDeprecationHeader makes the calls served with the context list the
+// legacy methods called in the header.
+func withDeprecationHeader(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, deprecationHeaderKey{}, header)
+}
+
+// warnDeprecated records a call by a legacy name, warning of it if enabled.
+func (h *handler) warnDeprecated(ctx context.Context, msg, answer *jsonrpcMessage) {
+	normalized, legacy, warn := h.reg.deprecation(msg.Method)
+	if !legacy {
+		return
+	}
+	metrics.GetOrRegisterMeter("rpc/deprecated/"+msg.Method, nil).Mark(1)
+	if !warn || answer == nil {
+		return
+	}
+	answer.Deprecated = fmt.Sprintf("%s is deprecated, use %s", msg.Method, normalized)
+	if header, ok := ctx.Value(deprecationHeaderKey{}).(http.Header); ok {
+		header.Add(DeprecationHeader, msg.Method)
+	}
+}
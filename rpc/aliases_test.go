@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyMethodAliases(t *testing.T) {
+	_, err := newMethodAliases(LegacyMethodAliases)
+	require.NoError(t, err)
+
+	_, err = newMethodAliases(map[string]string{"test_rets": "rets"})
+	assert.EqualError(t, err, `invalid method name "rets"`)
+	_, err = newMethodAliases(map[string]string{"test_a": "quorum_b", "test_c": "test_a"})
+	assert.EqualError(t, err, "alias test_a is a legacy name")
+	_, err = newMethodAliases(map[string]string{"test_a": "quorum_b", "test_c": "quorum_b"})
+	assert.Error(t, err)
+}
+
+func TestMethodAliasConflict(t *testing.T) {
+	s := NewServer()
+	defer s.Stop()
+	s.services.aliases = mustMethodAliases(map[string]string{"other_rets": "test_rets"})
+
+	err := s.RegisterName("test", new(testService))
+	assert.EqualError(t, err, "method test_rets conflicts with the alias of other_rets")
+}
+
+func TestHTTPDeprecatedMethod(t *testing.T) {
+	s := newTestServer()
+	s.services.aliases = mustMethodAliases(map[string]string{"test_echo": "quorum_echo"})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	defer s.Stop()
+
+	post := func(method, params string) (*http.Response, *jsonrpcMessage) {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}`
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var msg jsonrpcMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+		require.Nil(t, msg.Error)
+		return resp, &msg
+	}
+
+	const echo = `["x",1,{"S":"y"}]`
+	// both names answer the same, without warning by default
+	resp, legacy := post("test_echo", echo)
+	assert.Empty(t, resp.Header.Get(DeprecationHeader))
+	assert.Empty(t, legacy.Deprecated)
+	resp, normalized := post("quorum_echo", echo)
+	assert.Empty(t, resp.Header.Get(DeprecationHeader))
+	assert.Equal(t, string(legacy.Result), string(normalized.Result))
+
+	s.SetDeprecationWarnings(true)
+	resp, legacy = post("test_echo", echo)
+	assert.Equal(t, "test_echo", resp.Header.Get(DeprecationHeader))
+	assert.Equal(t, "test_echo is deprecated, use quorum_echo", legacy.Deprecated)
+	assert.Equal(t, string(normalized.Result), string(legacy.Result))
+	resp, normalized = post("quorum_echo", echo)
+	assert.Empty(t, resp.Header.Get(DeprecationHeader))
+	assert.Empty(t, normalized.Deprecated)
+	_, other := post("test_rets", "[]")
+	assert.Empty(t, other.Deprecated)
+}
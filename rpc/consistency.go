@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Quorum
+//
+// Consistency tokens give the clients of load balanced nodes read-your-writes
+// consistency over HTTP. The writes served return a token in the response
+// header, which the clients present in the header of their next requests: a
+// node holds the calls of a request back until it caught up with the token,
+// or fails them once a bound is over.
+
+// ConsistencyTokenHeader is the header of the consistency tokens of the
+// requests and responses.
+const ConsistencyTokenHeader = "X-Consistency-Token"
+
+// A ConsistencyGuard holds the requests back until the node is consistent with
+// the token they present.
+type ConsistencyGuard interface {
+	// Await returns once the node is consistent with the token, an error if it
+	// is invalid or the node does not catch up in time.
+	Await(ctx context.Context, token string) error
+}
+
+type consistencyKey struct{}
+
+// consistency is the consistency of a request.
+type consistency struct {
+	guard     ConsistencyGuard
+	presented string // token of the request
+
+	await sync.Once
+	err   error // outcome of the wait, the same for all the calls of a batch
+
+	lock   sync.Mutex
+	issued string // token of the response
+}
+
+// WithConsistency returns the context of an HTTP request, the guard holding it
+// back if it presents a token. The token issued while serving it, if any, must
+// be set in the header of the response by ConsistencyTokenIssued.
+func WithConsistency(ctx context.Context, guard ConsistencyGuard, r *http.Request) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, &consistency{guard: guard, presented: r.Header.Get(ConsistencyTokenHeader)})
+}
+
+func consistencyOf(ctx context.Context) *consistency {
+	c, _ := ctx.Value(consistencyKey{}).(*consistency)
+	return c
+}
+
+// ConsistencyGuardOf returns the guard of the request of the context, nil if
+// none.
+func ConsistencyGuardOf(ctx context.Context) ConsistencyGuard {
+	if c := consistencyOf(ctx); c != nil {
+		return c.guard
+	}
+	return nil
+}
+
+// AwaitConsistency waits for the node to catch up with the token presented by
+// the request of the context, once for all its calls.
+func AwaitConsistency(ctx context.Context) error {
+	c := consistencyOf(ctx)
+	if c == nil || c.guard == nil || c.presented == "" {
+		return nil
+	}
+	c.await.Do(func() { c.err = c.guard.Await(ctx, c.presented) })
+	return c.err
+}
+
+// IssueConsistencyToken sets the token of the response to the request of the
+// context, the last one issued winning in a batch.
+func IssueConsistencyToken(ctx context.Context, token string) {
+	if c := consistencyOf(ctx); c != nil {
+		c.lock.Lock()
+		c.issued = token
+		c.lock.Unlock()
+	}
+}
+
+// ConsistencyTokenIssued returns the token issued while serving the request of
+// the context, empty if none.
+func ConsistencyTokenIssued(ctx context.Context) string {
+	if c := consistencyOf(ctx); c != nil {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		return c.issued
+	}
+	return ""
+}
+
+// SetConsistencyGuard sets the guard of the HTTP requests presenting a
+// consistency token.
+func (s *Server) SetConsistencyGuard(guard ConsistencyGuard) {
+	s.consistencyGuard = guard
+}
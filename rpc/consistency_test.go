@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubConsistencyGuard fails the tokens other than "caught-up".
+type stubConsistencyGuard struct {
+	awaits int32
+}
+
+func (g *stubConsistencyGuard) Await(_ context.Context, token string) error {
+	atomic.AddInt32(&g.awaits, 1)
+	if token != "caught-up" {
+		return errors.New("behind")
+	}
+	return nil
+}
+
+type consistencyTestService struct{}
+
+func (consistencyTestService) Write(ctx context.Context, token string) string {
+	IssueConsistencyToken(ctx, token)
+	return token
+}
+
+func TestHTTPConsistency(t *testing.T) {
+	s := newTestServer()
+	require.NoError(t, s.RegisterName("consistency", consistencyTestService{}))
+	guard := new(stubConsistencyGuard)
+	s.SetConsistencyGuard(guard)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	defer s.Stop()
+
+	post := func(token, body string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if token != "" {
+			req.Header.Set(ConsistencyTokenHeader, token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		reply, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(reply)
+	}
+
+	// the writes return their token, the requests without one are not held back
+	resp, _ := post("", `{"jsonrpc":"2.0","id":1,"method":"consistency_write","params":["t1"]}`)
+	assert.Equal(t, "t1", resp.Header.Get(ConsistencyTokenHeader))
+	resp, _ = post("", `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]}`)
+	assert.Empty(t, resp.Header.Get(ConsistencyTokenHeader))
+	assert.Zero(t, atomic.LoadInt32(&guard.awaits))
+
+	// a batch waits once, the last token issued winning
+	resp, reply := post("caught-up", `[{"jsonrpc":"2.0","id":1,"method":"consistency_write","params":["t2"]},{"jsonrpc":"2.0","id":2,"method":"consistency_write","params":["t3"]}]`)
+	assert.Contains(t, reply, `"result":"t2"`)
+	assert.Equal(t, "t3", resp.Header.Get(ConsistencyTokenHeader))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&guard.awaits))
+
+	// the calls of a request the node did not catch up with fail
+	resp, reply = post("ahead", `[{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":2,"method":"consistency_write","params":["t4"]}]`)
+	assert.Equal(t, 2, strings.Count(reply, `"message":"behind"`))
+	assert.Empty(t, resp.Header.Get(ConsistencyTokenHeader))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&guard.awaits))
+}
@@ -22,6 +22,14 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
+// Quorum
+//
+// IPCListen creates the IPC listener of the endpoint, a unix socket only
+// accessible to the user or a named pipe on Windows.
+func IPCListen(endpoint string) (net.Listener, error) {
+	return ipcListen(endpoint)
+}
+
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
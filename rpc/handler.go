@@ -64,6 +64,11 @@ type handler struct {
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
+
+	// Quorum
+	authLock   sync.Mutex // serialises the re-authentications of the connection
+	authWatch  sync.Once  // starts watchToken
+	requestIDs requestIDs // identifies the calls without request ID
 }
 
 type callProc struct {
@@ -73,6 +78,10 @@ type callProc struct {
 
 func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
 	rootCtx, cancelRoot := context.WithCancel(connCtx)
+	// Quorum - the remote address identifies the clients of the connection
+	if _, ok := connCtx.Value("remote").(string); !ok && conn.remoteAddr() != "" {
+		rootCtx = context.WithValue(rootCtx, "remote", conn.remoteAddr())
+	}
 	h := &handler{
 		reg:            reg,
 		idgen:          idgen,
@@ -88,6 +97,12 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
 	}
+	// Quorum - the request IDs of the HTTP and websocket clients are echoed
+	if r, ok := conn.(requestIDer); ok {
+		h.requestIDs.prefix, h.requestIDs.echo = r.requestID(), true
+	} else if log.RequestID(connCtx) != "" {
+		h.requestIDs.echo = true
+	}
 	h.unsubscribeCb = newCallback(reflect.Value{}, reflect.ValueOf(h.unsubscribe))
 	return h
 }
@@ -114,15 +129,21 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	}
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
+		access := h.startAccess() // Quorum
 		answers := make([]*jsonrpcMessage, 0, len(msgs))
 		for _, msg := range calls {
-			if answer := h.handleCallMsg(cp, msg); answer != nil {
+			answer := h.handleCallMsg(cp, msg)
+			access.call(msg, answer)
+			if answer != nil {
 				answers = append(answers, answer)
 			}
 		}
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
 			h.conn.writeJSON(cp.ctx, answers)
+			access.finish(cp.ctx, answers)
+		} else {
+			access.finish(cp.ctx, nil)
 		}
 		for _, n := range cp.notifiers {
 			n.activate()
@@ -136,10 +157,15 @@ func (h *handler) handleMsg(msg *jsonrpcMessage) {
 		return
 	}
 	h.startCallProc(func(cp *callProc) {
+		access := h.startAccess() // Quorum
 		answer := h.handleCallMsg(cp, msg)
+		access.call(msg, answer)
 		h.addSubscriptions(cp.notifiers)
 		if answer != nil {
 			h.conn.writeJSON(cp.ctx, answer)
+			access.finish(cp.ctx, answer)
+		} else {
+			access.finish(cp.ctx, nil)
 		}
 		for _, n := range cp.notifiers {
 			n.activate()
@@ -199,7 +225,13 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 
 	for _, n := range nn {
 		if sub := n.takeSubscription(); sub != nil {
+			// Quorum - the server ended it while subscribing
+			if n.isStopped() {
+				continue
+			}
 			h.serverSubs[sub.ID] = sub
+			// Quorum
+			h.authWatch.Do(func() { go h.watchToken() })
 		}
 	}
 }
@@ -220,7 +252,7 @@ func (h *handler) cancelServerSubscriptions(err error) {
 func (h *handler) startCallProc(fn func(*callProc)) {
 	h.callWG.Add(1)
 	go func() {
-		ctx, cancel := context.WithCancel(h.rootCtx)
+		ctx, cancel := context.WithCancel(h.requestIDs.withRequestID(h.rootCtx)) // Quorum - with a request ID
 		defer h.callWG.Done()
 		defer cancel()
 		fn(&callProc{ctx: ctx})
@@ -254,6 +286,14 @@ func (h *handler) handleSubscriptionResult(msg *jsonrpcMessage) {
 		h.log.Debug("Dropping invalid subscription message")
 		return
 	}
+	// Quorum - the server ended the subscription
+	if result.Error != nil {
+		if sub := h.clientSubs[result.ID]; sub != nil {
+			delete(h.clientSubs, result.ID)
+			sub.quitWithError(false, result.Error)
+		}
+		return
+	}
 	if h.clientSubs[result.ID] != nil {
 		h.clientSubs[result.ID].deliver(result.Result)
 	}
@@ -296,9 +336,15 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 		return nil
 	case msg.isCall():
 		resp := h.handleCall(ctx, msg)
+		requestID := log.RequestID(ctx.ctx)
 		var ctx []interface{}
-		ctx = append(ctx, "reqid", idForLog{msg.ID}, "t", time.Since(start))
+		ctx = append(ctx, "reqid", idForLog{msg.ID}, "requestid", requestID, "t", time.Since(start))
 		if resp.Error != nil {
+			// Quorum
+			if h.requestIDs.echo {
+				resp.RequestID = requestID
+			}
+			// End Quorum
 			ctx = append(ctx, "err", resp.Error.Message)
 			if resp.Error.Data != nil {
 				ctx = append(ctx, "errdata", resp.Error.Data)
@@ -321,6 +367,10 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 //   before the actual processing of the call. It also populates context with preauthenticated
 //   token so the responsible RPC method can leverage if needed (e.g: in multi tenancy)
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	// Quorum - the token may be refreshed once expired
+	if msg.Method == RefreshTokenMethod {
+		return h.handleRefreshToken(msg)
+	}
 	if r, ok := h.conn.(securityContextResolver); ok {
 		if err := secureCall(r, msg); err != nil {
 			return securityErrorMessage(msg, err)
@@ -345,8 +395,15 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
+	// Quorum
+	if err := AwaitConsistency(cp.ctx); err != nil {
+		return msg.errorResponse(err)
+	}
 	start := time.Now()
 	answer := h.runMethod(cp.ctx, msg, callb, args)
+	// Quorum
+	h.redact(cp.ctx, msg, answer)
+	h.warnDeprecated(cp.ctx, msg, answer)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -388,8 +445,16 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	}
 	args = args[1:]
 
+	// Quorum - check the authority required by the subscription, if any
+	scope := h.reg.subscriptionScope(namespace, name)
+	if r, ok := h.conn.(securityContextResolver); ok {
+		if err := verifyScope(r.Resolve(), scope); err != nil {
+			return securityErrorMessage(msg, err)
+		}
+	}
+
 	// Install notifier in context so the subscription handler can find it.
-	n := &Notifier{h: h, namespace: namespace}
+	n := &Notifier{h: h, namespace: namespace, scope: scope}
 	cp.notifiers = append(cp.notifiers, n)
 	ctx := context.WithValue(cp.ctx, notifierKey{}, n)
 
@@ -423,6 +488,25 @@ func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	return true, nil
 }
 
+// Quorum
+// removeStoppedSubscription removes the subscription of the notifier stopped by
+// the server, closing its error channel.
+func (h *handler) removeStoppedSubscription(n *Notifier, err error) {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	n.mu.Lock()
+	sub := n.sub
+	n.mu.Unlock()
+	if sub == nil || h.serverSubs[sub.ID] != sub {
+		// added as stopped, if ever, when the subscribe call returns
+		return
+	}
+	sub.err <- err
+	close(sub.err)
+	delete(h.serverSubs, sub.ID)
+}
+
 type idForLog struct{ json.RawMessage }
 
 func (id idForLog) String() string {
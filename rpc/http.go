@@ -107,14 +107,21 @@ type HTTPTimeouts struct {
 	// is zero, the value of ReadTimeout is used. If both are
 	// zero, ReadHeaderTimeout is used.
 	IdleTimeout time.Duration
+
+	// Quorum
+	// ReadHeaderTimeout is the amount of time allowed to read
+	// request headers, e.g. to close slow-loris connections early.
+	// If it is zero, the value of ReadTimeout is used.
+	ReadHeaderTimeout time.Duration
 }
 
 // DefaultHTTPTimeouts represents the default timeout values used if further
 // configuration is not provided.
 var DefaultHTTPTimeouts = HTTPTimeouts{
-	ReadTimeout:  30 * time.Second,
-	WriteTimeout: 30 * time.Second,
-	IdleTimeout:  120 * time.Second,
+	ReadTimeout:       30 * time.Second,
+	WriteTimeout:      30 * time.Second,
+	IdleTimeout:       120 * time.Second,
+	ReadHeaderTimeout: 10 * time.Second, // Quorum
 }
 
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
@@ -234,14 +241,35 @@ type httpServerConn struct {
 	io.Reader
 	io.Writer
 	r *http.Request
+
+	// Quorum
+	body        *limitReader
+	w           http.ResponseWriter
+	wroteHeader bool
+	ctx         context.Context // of the request, carrying its consistency
 }
 
-func newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
-	body := io.LimitReader(r.Body, maxRequestContentLength)
-	conn := &httpServerConn{Reader: body, Writer: w, r: r}
+func newHTTPServerConn(ctx context.Context, r *http.Request, w http.ResponseWriter, limit int64) ServerCodec {
+	body := newLimitReader(http.MaxBytesReader(w, r.Body, limit+1), "http", limit)
+	conn := &httpServerConn{Reader: body, Writer: w, r: r, body: body, w: w, ctx: ctx}
 	return NewCodec(conn)
 }
 
+// Write replies with status 413 to a request whose body exceeded the limit,
+// and with the consistency token issued while serving it, if any.
+func (t *httpServerConn) Write(p []byte) (int, error) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		if token := ConsistencyTokenIssued(t.ctx); token != "" {
+			t.w.Header().Set(ConsistencyTokenHeader, token)
+		}
+		if t.body.exceeded {
+			t.w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}
+	return t.Writer.Write(p)
+}
+
 // Close does nothing and always returns nil.
 func (t *httpServerConn) Close() error { return nil }
 
@@ -260,7 +288,12 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	if code, err := validateRequest(r); err != nil {
+	if code, err := validateRequest(r, s.requestLimit()); err != nil {
+		if tooLarge, ok := err.(*RequestTooLargeError); ok {
+			MarkRequestTooLarge(tooLarge.Endpoint)
+			WriteRequestTooLarge(w, tooLarge)
+			return
+		}
 		http.Error(w, err.Error(), code)
 		return
 	}
@@ -277,8 +310,17 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if origin := r.Header.Get("Origin"); origin != "" {
 		ctx = context.WithValue(ctx, "Origin", origin)
 	}
+	// Quorum
+	requestID := HTTPRequestID(r)
+	ctx = log.WithRequestID(ctx, requestID)
+	w.Header().Set(RequestIDHeader, requestID)
+	ctx = withDeprecationHeader(ctx, w.Header())
+	if s.consistencyGuard != nil {
+		ctx = WithConsistency(ctx, s.consistencyGuard, r)
+	}
+	// End Quorum
 	w.Header().Set("content-type", contentType)
-	codec := newHTTPServerConn(r, w)
+	codec := newHTTPServerConn(ctx, r, w, s.requestLimit())
 	defer codec.close()
 	s.authenticateHttpRequest(r, codec)
 	s.serveSingleRequest(ctx, codec)
@@ -286,13 +328,12 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
-func validateRequest(r *http.Request) (int, error) {
+func validateRequest(r *http.Request, limit int64) (int, error) {
 	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
 		return http.StatusMethodNotAllowed, errors.New("method not allowed")
 	}
-	if r.ContentLength > maxRequestContentLength {
-		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxRequestContentLength)
-		return http.StatusRequestEntityTooLarge, err
+	if r.ContentLength > limit {
+		return http.StatusRequestEntityTooLarge, &RequestTooLargeError{Endpoint: "http", Limit: limit}
 	}
 	// Allow OPTIONS (regardless of content-type)
 	if r.Method == http.MethodOptions {
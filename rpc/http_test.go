@@ -17,6 +17,8 @@
 package rpc
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -42,7 +44,7 @@ func confirmRequestValidationCode(t *testing.T, method, contentType, body string
 	if len(contentType) > 0 {
 		request.Header.Set("Content-Type", contentType)
 	}
-	code, err := validateRequest(request)
+	code, err := validateRequest(request, maxRequestContentLength)
 	if code == 0 {
 		if err != nil {
 			t.Errorf("validation: got error %v, expected nil", err)
@@ -98,3 +100,48 @@ func confirmHTTPRequestYieldsStatusCode(t *testing.T, method, contentType, body
 func TestHTTPResponseWithEmptyGet(t *testing.T) {
 	confirmHTTPRequestYieldsStatusCode(t, http.MethodGet, "", "", http.StatusOK)
 }
+
+func TestHTTPRequestTooLarge(t *testing.T) {
+	s := newTestServer()
+	s.SetBodyLimit(100)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	defer s.Stop()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["` + strings.Repeat("x", 200) + `"]}`
+	for _, chunked := range []bool{false, true} {
+		request, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to create a valid HTTP request: %v", err)
+		}
+		if chunked {
+			// the size is only known once the limit is crossed
+			request.Body = ioutil.NopCloser(strings.NewReader(body))
+			request.ContentLength = -1
+		}
+		request.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		confirmStatusCode(t, resp.StatusCode, http.StatusRequestEntityTooLarge)
+
+		var msg struct {
+			Error struct {
+				Code int
+				Data struct {
+					Endpoint string
+					Limit    int64
+				}
+			}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&msg)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("chunked %t: invalid response: %v", chunked, err)
+		}
+		if msg.Error.Code != -32600 || msg.Error.Data.Endpoint != "http" || msg.Error.Data.Limit != 100 {
+			t.Fatalf("chunked %t: wrong error %+v", chunked, msg.Error)
+		}
+	}
+}
@@ -44,6 +44,7 @@ var null = json.RawMessage("null")
 type subscriptionResult struct {
 	ID     string          `json:"subscription"`
 	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonError      `json:"error,omitempty"` // Quorum - set when the server ends the subscription
 }
 
 // A value of this type can a JSON-RPC request, notification, successful response or
@@ -55,6 +56,13 @@ type jsonrpcMessage struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 	Error   *jsonError      `json:"error,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
+
+	// Quorum - paths of the fields of the result redacted for the client
+	Redacted []string `json:"redacted,omitempty"`
+	// Quorum - ID of the request of an error response, see RequestIDHeader
+	RequestID string `json:"requestId,omitempty"`
+	// Quorum - warns of a call by a legacy name, see LegacyMethodAliases
+	Deprecated string `json:"deprecated,omitempty"`
 }
 
 func (msg *jsonrpcMessage) isNotification() bool {
@@ -174,6 +182,7 @@ type jsonCodec struct {
 
 	// Quorum
 	// holding the security context for underlying connection
+	secMu  sync.RWMutex
 	secCtx securityContext
 }
 
@@ -243,10 +252,14 @@ func (c *jsonCodec) closed() <-chan interface{} {
 }
 
 func (c *jsonCodec) Configure(secCtx securityContext) {
+	c.secMu.Lock()
+	defer c.secMu.Unlock()
 	c.secCtx = secCtx
 }
 
 func (c *jsonCodec) Resolve() securityContext {
+	c.secMu.RLock()
+	defer c.secMu.RUnlock()
 	return c.secCtx
 }
 
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// DefaultBodyLimit is the default maximum size, in bytes, of a JSON-RPC
+// request body over HTTP and of a message over websocket.
+const DefaultBodyLimit = maxRequestContentLength
+
+// RequestTooLargeError is returned when a request exceeds the size limit of
+// the endpoint it was sent to.
+type RequestTooLargeError struct {
+	Endpoint string // http, ws or graphql
+	Limit    int64  // maximum size in bytes
+}
+
+func (e *RequestTooLargeError) Error() string {
+	return fmt.Sprintf("%s request too large, the limit is %d bytes", e.Endpoint, e.Limit)
+}
+
+func (e *RequestTooLargeError) ErrorCode() int { return -32600 }
+
+func (e *RequestTooLargeError) ErrorData() interface{} {
+	return map[string]interface{}{"endpoint": e.Endpoint, "limit": e.Limit}
+}
+
+// MarkRequestTooLarge counts a request rejected by the size limit of the
+// given endpoint.
+func MarkRequestTooLarge(endpoint string) {
+	metrics.GetOrRegisterMeter("rpc/toolarge/"+endpoint, nil).Mark(1)
+}
+
+// WriteRequestTooLarge replies to an HTTP request exceeding the size limit
+// with status 413 and a JSON-RPC error naming the limit.
+func WriteRequestTooLarge(w http.ResponseWriter, err *RequestTooLargeError) {
+	w.Header().Set("content-type", contentType)
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(errorMessage(err))
+}
+
+// limitReader fails reads once more than limit bytes were read. The
+// decoder reading requests from it stops as soon as the limit is crossed,
+// without buffering the rest of the request.
+type limitReader struct {
+	r        io.Reader
+	read     int64
+	err      *RequestTooLargeError
+	exceeded bool
+}
+
+func newLimitReader(r io.Reader, endpoint string, limit int64) *limitReader {
+	return &limitReader{
+		r:   io.LimitReader(r, limit+1),
+		err: &RequestTooLargeError{Endpoint: endpoint, Limit: limit},
+	}
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.err
+	}
+	n, err := l.r.Read(p)
+	if l.read += int64(n); l.read > l.err.Limit {
+		l.exceeded = true
+		MarkRequestTooLarge(l.err.Endpoint)
+		return 0, l.err
+	}
+	return n, err
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Quorum
+//
+// A Redactor removes fields from the results served to some clients. It runs
+// on the results of the successful calls, once the call was authorized, so it
+// can only narrow what a client is served.
+
+// Redactor returns the result of a method call with the fields the client of
+// the context must not be served nulled, along with the paths of the redacted
+// fields, nil if none.
+type Redactor interface {
+	Redact(ctx context.Context, method string, result json.RawMessage) (json.RawMessage, []string)
+}
+
+// SetRedactor sets the redactor of the results of the calls served. Responses
+// with redacted fields list their paths under "redacted".
+func (s *Server) SetRedactor(redactor Redactor) {
+	s.services.setRedactor(redactor)
+}
+
+func (r *serviceRegistry) setRedactor(redactor Redactor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redactor = redactor
+}
+
+func (r *serviceRegistry) resultRedactor() Redactor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.redactor
+}
+
+// redact applies the redactor of the server, if any, to the answer of a call.
+func (h *handler) redact(ctx context.Context, msg, answer *jsonrpcMessage) {
+	redactor := h.reg.resultRedactor()
+	if redactor == nil || answer.Error != nil || answer.Result == nil {
+		return
+	}
+	if result, paths := redactor.Redact(ctx, msg.Method, answer.Result); len(paths) > 0 {
+		answer.Result, answer.Redacted = result, paths
+	}
+}
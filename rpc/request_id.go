@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Quorum
+//
+// Every request served is identified by the X-Request-ID of the client, or a
+// generated ID, logged while serving it and echoed in the error responses. The
+// ID of a websocket handshake names the connection, each of its calls being
+// identified by a sequence number under it.
+
+const (
+	// RequestIDHeader is the header of the ID of a request.
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength is the length the IDs supplied are truncated to.
+	maxRequestIDLength = 64
+)
+
+// SanitizeRequestID returns the ID supplied by a client with the characters
+// other than letters, digits and -_.:/ dropped, truncated to the maximum
+// length.
+func SanitizeRequestID(id string) string {
+	sanitized := make([]byte, 0, len(id))
+	for i := 0; i < len(id) && len(sanitized) < maxRequestIDLength; i++ {
+		switch c := id[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == ':', c == '/':
+			sanitized = append(sanitized, c)
+		}
+	}
+	return string(sanitized)
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// HTTPRequestID returns the sanitized request ID of the header of the request,
+// a new one if it supplied none.
+func HTTPRequestID(r *http.Request) string {
+	if id := SanitizeRequestID(r.Header.Get(RequestIDHeader)); id != "" {
+		return id
+	}
+	return NewRequestID()
+}
+
+// requestIDs identifies the calls of a connection without request ID.
+type requestIDs struct {
+	seq    uint64 // accessed atomically, first for its alignment
+	prefix string // ID of the websocket handshake, if any
+	echo   bool   // whether the IDs are echoed in the error responses
+}
+
+// withRequestID returns the context of a call, carrying a new request ID unless
+// the context of the connection has one.
+func (ids *requestIDs) withRequestID(ctx context.Context) context.Context {
+	if log.RequestID(ctx) != "" {
+		return ctx
+	}
+	if ids.prefix == "" {
+		return log.WithRequestID(ctx, NewRequestID())
+	}
+	return log.WithRequestID(ctx, ids.prefix+"."+strconv.FormatUint(atomic.AddUint64(&ids.seq, 1), 10))
+}
+
+// requestIDer is implemented by the codecs of the connections named by the
+// client.
+type requestIDer interface {
+	requestID() string
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeRequestID(t *testing.T) {
+	assert.Equal(t, "dapp-1/req_2.a:b", SanitizeRequestID("dapp-1/req_2.a:b"))
+	assert.Equal(t, "abinjected", SanitizeRequestID("ab\n\"injected\" "))
+	assert.Equal(t, strings.Repeat("x", maxRequestIDLength), SanitizeRequestID(strings.Repeat("x", 200)))
+	assert.Empty(t, SanitizeRequestID("{}"))
+	assert.Len(t, NewRequestID(), 32)
+}
+
+func TestHTTPRequestID(t *testing.T) {
+	s := newTestServer()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	defer s.Stop()
+
+	post := func(requestID, body string) (*http.Response, *jsonrpcMessage) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var msg jsonrpcMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+		return resp, &msg
+	}
+
+	resp, msg := post("dapp 42!", `{"jsonrpc":"2.0","id":1,"method":"test_returnError"}`)
+	assert.Equal(t, "dapp42", resp.Header.Get(RequestIDHeader))
+	require.NotNil(t, msg.Error)
+	assert.Equal(t, "dapp42", msg.RequestID)
+
+	// generated when absent, only echoed in the error responses
+	resp, msg = post("", `{"jsonrpc":"2.0","id":1,"method":"test_returnError"}`)
+	generated := resp.Header.Get(RequestIDHeader)
+	assert.Len(t, generated, 32)
+	assert.Equal(t, generated, msg.RequestID)
+	resp, msg = post("dapp-43", `{"jsonrpc":"2.0","id":1,"method":"test_echoCtxId"}`)
+	assert.Equal(t, "dapp-43", resp.Header.Get(RequestIDHeader))
+	assert.Nil(t, msg.Error)
+	assert.Empty(t, msg.RequestID)
+}
+
+func TestWebsocketRequestID(t *testing.T) {
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{RequestIDHeader: {"dapp-ws"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "dapp-ws", resp.Header.Get(RequestIDHeader))
+
+	// the calls of the connection are numbered under the ID of the handshake
+	for _, want := range []string{"dapp-ws.1", "dapp-ws.2"} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_returnError"}`)))
+		var msg jsonrpcMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.NotNil(t, msg.Error)
+		assert.Equal(t, want, msg.RequestID)
+	}
+}
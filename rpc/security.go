@@ -22,8 +22,20 @@ const (
 	// keys used to save values in request context
 	ctxAuthenticationError   = securityContextKey("AUTHENTICATION_ERROR")   // key to save error during authentication before processing the request body
 	CtxPreauthenticatedToken = securityContextKey("PREAUTHENTICATED_TOKEN") // key to save the preauthenticated token once authenticated
+	// keys used to re-authenticate long-lived connections
+	ctxAccessToken        = securityContextKey("ACCESS_TOKEN")        // key to save the access token the connection was authenticated with
+	ctxTokenAuthenticator = securityContextKey("TOKEN_AUTHENTICATOR") // key to save the tokenAuthenticator of the server
+	ctxAuthCheckInterval  = securityContextKey("AUTH_CHECK_INTERVAL") // key to save how often the token of a connection is re-validated
 )
 
+// DefaultAuthCheckInterval is how often the token of a websocket connection with
+// subscriptions is re-validated by default.
+const DefaultAuthCheckInterval = time.Minute
+
+// tokenAuthenticator returns the security context of a connection authenticated
+// with the given access token.
+type tokenAuthenticator func(token string) securityContext
+
 type securityContextConfigurer interface {
 	Configure(secCtx securityContext)
 }
@@ -103,6 +115,30 @@ func secureCall(resolver securityContextResolver, msg *jsonrpcMessage) error {
 	return nil
 }
 
+// verifyScope checks that the security context grants the scope, given as the
+// service_method authority, for subscriptions requiring it. The token expiration
+// is checked as well, so that it can be called again for live subscriptions.
+func verifyScope(secCtx securityContext, scope string) error {
+	if secCtx == nil || scope == "" {
+		return nil
+	}
+	if err, hasError := secCtx.Value(ctxAuthenticationError).(error); hasError {
+		return err
+	}
+	authToken, isPreauthenticated := secCtx.Value(CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken)
+	if !isPreauthenticated {
+		return nil
+	}
+	if err := verifyExpiration(authToken); err != nil {
+		return err
+	}
+	elem := strings.SplitN(scope, serviceMethodSeparator, 2)
+	if len(elem) != 2 {
+		return &securityError{fmt.Sprintf("unsupported scope %s", scope)}
+	}
+	return verifyAccess(elem[0], elem[1], authToken.Authorities)
+}
+
 // construct JSON RPC error message which has the ID of the request
 func securityErrorMessage(forMsg *jsonrpcMessage, err error) *jsonrpcMessage {
 	msg := &jsonrpcMessage{Version: vsn, ID: forMsg.ID, Error: &jsonError{
@@ -21,6 +21,7 @@ import (
 	"io"
 	"net/http"
 	"sync/atomic"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/log"
@@ -52,6 +53,16 @@ type Server struct {
 	// Quorum
 	// The implementation would authenticate the token coming from a request
 	authenticationManager security.AuthenticationManager
+	// maximum size of a request body over HTTP or of a websocket message, 0 for DefaultBodyLimit
+	bodyLimit int64
+	// how often the token of a websocket connection is re-validated, 0 for DefaultAuthCheckInterval
+	authCheckInterval time.Duration
+	// websocket liveness settings in nanoseconds, accessed atomically as they
+	// can be changed while serving, 0 for the defaults
+	wsPingInterval int64
+	wsIdleTimeout  int64
+	// holds the HTTP requests presenting a consistency token back, nil if none
+	consistencyGuard ConsistencyGuard
 }
 
 // Quorum
@@ -74,6 +85,63 @@ func NewServer() *Server {
 	return server
 }
 
+// Quorum
+//
+// SetBodyLimit sets the maximum size of request bodies served over HTTP and of
+// messages received over websocket. A limit of 0 keeps DefaultBodyLimit.
+func (s *Server) SetBodyLimit(limit int64) {
+	s.bodyLimit = limit
+}
+
+// Quorum
+//
+// SetAuthCheckInterval sets how often the access token of a websocket connection
+// with subscriptions is checked for expiration and revocation. An interval of 0
+// keeps DefaultAuthCheckInterval.
+func (s *Server) SetAuthCheckInterval(interval time.Duration) {
+	s.authCheckInterval = interval
+}
+
+// Quorum
+//
+// RequireSubscriptionScopes sets the authorities, as service_method, required
+// on top of <namespace>_subscribe to create the subscriptions of the given
+// names, e.g. those streaming private data.
+func (s *Server) RequireSubscriptionScopes(namespace string, scopes map[string]string) {
+	s.services.requireScopes(namespace, scopes)
+}
+
+// Quorum
+//
+// SetWebsocketLiveness sets how long a websocket connection stays idle before
+// it is pinged, and how long it then has to answer before it is closed. Zero
+// keeps DefaultWSPingInterval and DefaultWSIdleTimeout. It can be called while
+// serving, the connections pick the new settings up with their next ping.
+func (s *Server) SetWebsocketLiveness(pingInterval, idleTimeout time.Duration) {
+	atomic.StoreInt64(&s.wsPingInterval, int64(pingInterval))
+	atomic.StoreInt64(&s.wsIdleTimeout, int64(idleTimeout))
+}
+
+// WebsocketLiveness returns the websocket ping interval and idle timeout in
+// use.
+func (s *Server) WebsocketLiveness() (pingInterval, idleTimeout time.Duration) {
+	pingInterval, idleTimeout = DefaultWSPingInterval, DefaultWSIdleTimeout
+	if interval := atomic.LoadInt64(&s.wsPingInterval); interval > 0 {
+		pingInterval = time.Duration(interval)
+	}
+	if timeout := atomic.LoadInt64(&s.wsIdleTimeout); timeout > 0 {
+		idleTimeout = time.Duration(timeout)
+	}
+	return pingInterval, idleTimeout
+}
+
+func (s *Server) requestLimit() int64 {
+	if s.bodyLimit > 0 {
+		return s.bodyLimit
+	}
+	return DefaultBodyLimit
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -119,7 +187,9 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	reqs, batch, err := codec.readBatch()
 	if err != nil {
-		if err != io.EOF {
+		if tooLarge, ok := err.(*RequestTooLargeError); ok {
+			codec.writeJSON(ctx, errorMessage(tooLarge))
+		} else if err != io.EOF {
 			codec.writeJSON(ctx, errorMessage(&invalidMessageError{"parse error"}))
 		}
 		return
@@ -148,27 +218,42 @@ func (s *Server) Stop() {
 // Perform authentication on the HTTP request. Populate security context with necessary information
 // for subsequent authorization-related activities
 func (s *Server) authenticateHttpRequest(r *http.Request, cfg securityContextConfigurer) {
+	token, _ := extractToken(r)
+	if m, ok := s.authenticationManager.(security.HeaderAuthenticationManager); ok {
+		token = r.Header.Get(m.TokenHeader())
+	}
+	cfg.Configure(s.authenticateToken(token))
+}
+
+// Quorum
+// Authenticate the access token of a connection, when it is opened or when the client of a
+// long-lived connection refreshes it. The security context keeps what is needed to authenticate
+// the token again.
+func (s *Server) authenticateToken(token string) securityContext {
 	securityContext := context.Background()
-	defer func() {
-		cfg.Configure(securityContext)
-	}()
 	if isAuthEnabled, err := s.authenticationManager.IsEnabled(context.Background()); err != nil {
 		// this indicates a failure in the plugin. We don't want any subsequent request unchecked
 		log.Error("failure when checking if authentication manager is enabled", "err", err)
-		securityContext = context.WithValue(securityContext, ctxAuthenticationError, &securityError{"internal error"})
-		return
+		return context.WithValue(securityContext, ctxAuthenticationError, &securityError{"internal error"})
 	} else if !isAuthEnabled {
-		return
+		return securityContext
 	}
-	if token, hasToken := extractToken(r); hasToken {
-		if authToken, err := s.authenticationManager.Authenticate(context.Background(), token); err != nil {
-			securityContext = context.WithValue(securityContext, ctxAuthenticationError, &securityError{err.Error()})
-		} else {
-			securityContext = context.WithValue(securityContext, CtxPreauthenticatedToken, authToken)
-		}
+	interval := s.authCheckInterval
+	if interval <= 0 {
+		interval = DefaultAuthCheckInterval
+	}
+	securityContext = context.WithValue(securityContext, ctxTokenAuthenticator, tokenAuthenticator(s.authenticateToken))
+	securityContext = context.WithValue(securityContext, ctxAuthCheckInterval, interval)
+	if token == "" {
+		return context.WithValue(securityContext, ctxAuthenticationError, &securityError{"missing access token"})
+	}
+	securityContext = context.WithValue(securityContext, ctxAccessToken, token)
+	if authToken, err := s.authenticationManager.Authenticate(context.Background(), token); err != nil {
+		securityContext = context.WithValue(securityContext, ctxAuthenticationError, &securityError{err.Error()})
 	} else {
-		securityContext = context.WithValue(securityContext, ctxAuthenticationError, &securityError{"missing access token"})
+		securityContext = context.WithValue(securityContext, CtxPreauthenticatedToken, authToken)
 	}
+	return securityContext
 }
 
 // RPCService gives meta information about the server.
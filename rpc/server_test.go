@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -274,3 +275,41 @@ func TestServerContextIdCaptured(t *testing.T) {
 	assert.NoErrorf(t, err, "read error:", err)
 	assert.Equalf(t, buf[:n], []byte(wantResp), "wrong response: %s", buf[:n])
 }
+
+type fieldRedactor struct{}
+
+func (fieldRedactor) Redact(ctx context.Context, method string, result json.RawMessage) (json.RawMessage, []string) {
+	if method != "test_echo" {
+		return result, nil
+	}
+	return json.RawMessage(`{"String":null,"Int":1,"Args":null}`), []string{"String"}
+}
+
+func TestServerRedactor(t *testing.T) {
+	var (
+		request  = `[{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["secret",1]},{"jsonrpc":"2.0","id":2,"method":"test_rets"}]` + "\n"
+		wantResp = `[{"jsonrpc":"2.0","id":1,"result":{"String":null,"Int":1,"Args":null},"redacted":["String"]},{"jsonrpc":"2.0","id":2,"result":""}]` + "\n"
+	)
+
+	server := newTestServer()
+	defer server.Stop()
+	server.SetRedactor(fieldRedactor{})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("can't listen:", err)
+	}
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal("can't dial:", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(request))
+	conn.(*net.TCPConn).CloseWrite()
+	resp, err := ioutil.ReadAll(conn)
+
+	assert.NoError(t, err)
+	assert.Equal(t, wantResp, string(resp))
+}
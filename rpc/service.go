@@ -39,6 +39,14 @@ var (
 type serviceRegistry struct {
 	mu       sync.Mutex
 	services map[string]service
+	scopes   map[string]string // Quorum - authorities required to create subscriptions, by namespace_name
+	redactor Redactor          // Quorum - redacts the results of the calls, nil if none
+
+	// Quorum - the normalized names of the legacy methods, nil for LegacyMethodAliases
+	aliases             *methodAliases
+	deprecationWarnings bool // Quorum - warn of the calls by a legacy name
+
+	accessLogger AccessLogger // Quorum - logs the requests served, nil if none
 }
 
 // service represents a registered object.
@@ -70,6 +78,10 @@ func (r *serviceRegistry) registerName(name string, rcvr interface{}) error {
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	// Quorum
+	if err := r.checkAliases(name, callbacks); err != nil {
+		return err
+	}
 	if r.services == nil {
 		r.services = make(map[string]service)
 	}
@@ -100,7 +112,15 @@ func (r *serviceRegistry) callback(method string) *callback {
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.services[elem[0]].callbacks[elem[1]]
+	if cb := r.services[elem[0]].callbacks[elem[1]]; cb != nil {
+		return cb
+	}
+	// Quorum - a normalized name is served by its legacy method
+	if legacy, ok := r.methodAliases().legacy[method]; ok {
+		elem = strings.SplitN(legacy, serviceMethodSeparator, 2)
+		return r.services[elem[0]].callbacks[elem[1]]
+	}
+	return nil
 }
 
 // subscription returns a subscription callback in the given service.
@@ -110,6 +130,27 @@ func (r *serviceRegistry) subscription(service, name string) *callback {
 	return r.services[service].subscriptions[name]
 }
 
+// Quorum
+// requireScopes sets the authorities required to create the subscriptions of the namespace.
+func (r *serviceRegistry) requireScopes(namespace string, scopes map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scopes == nil {
+		r.scopes = make(map[string]string)
+	}
+	for name, scope := range scopes {
+		r.scopes[namespace+serviceMethodSeparator+name] = scope
+	}
+}
+
+// Quorum
+// subscriptionScope returns the authority required to create the subscription, "" if none.
+func (r *serviceRegistry) subscriptionScope(namespace, name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.scopes[namespace+serviceMethodSeparator+name]
+}
+
 // suitableCallbacks iterates over the methods of the given type. It determines if a method
 // satisfies the criteria for a RPC callback or a subscription callback and adds it to the
 // collection of callbacks. See server documentation for a summary of these criteria.
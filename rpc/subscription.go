@@ -36,6 +36,9 @@ var (
 	ErrNotificationsUnsupported = errors.New("notifications not supported")
 	// ErrNotificationNotFound is returned when the notification for the given id is not found
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// Quorum
+	// ErrSubscriptionStopped is returned when notifying a subscription the server ended
+	ErrSubscriptionStopped = errors.New("subscription stopped")
 )
 
 var globalGen = randomIDGenerator()
@@ -93,12 +96,15 @@ func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
 type Notifier struct {
 	h         *handler
 	namespace string
+	scope     string // Quorum - authority required to keep the subscription, "" if none
 
 	mu           sync.Mutex
 	sub          *Subscription
 	buffer       []json.RawMessage
 	callReturned bool
 	activated    bool
+	stopped      bool       // Quorum - ended by the server, see handler.stopUnauthorizedSubscriptions
+	stopErr      *jsonError // Quorum - reason sent to the client when stopped
 }
 
 // CreateSubscription returns a new subscription that is coupled to the
@@ -114,7 +120,7 @@ func (n *Notifier) CreateSubscription() *Subscription {
 	} else if n.callReturned {
 		panic("can't create subscription after subscribe call has returned")
 	}
-	n.sub = &Subscription{ID: n.h.idgen(), namespace: n.namespace, err: make(chan error, 1)}
+	n.sub = &Subscription{ID: n.h.idgen(), namespace: n.namespace, err: make(chan error, 1), notifier: n}
 	return n.sub
 }
 
@@ -134,6 +140,9 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	} else if n.sub.ID != id {
 		panic("Notify with wrong ID")
 	}
+	if n.stopped {
+		return ErrSubscriptionStopped
+	}
 	if n.activated {
 		return n.send(n.sub, enc)
 	}
@@ -141,6 +150,15 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// Quorum
+// Stop ends the subscription from the server side, sending the error to the
+// client as its last notification. Notify fails with ErrSubscriptionStopped
+// afterwards.
+func (n *Notifier) Stop(err error) {
+	n.stop(err)
+	n.h.removeStoppedSubscription(n, err)
+}
+
 // Closed returns a channel that is closed when the RPC connection is closed.
 // Deprecated: use subscription error channel
 func (n *Notifier) Closed() <-chan interface{} {
@@ -163,6 +181,11 @@ func (n *Notifier) activate() error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	// Quorum - stopped before the client got the subscription ID
+	if n.stopped {
+		n.activated = true
+		return n.sendStopped()
+	}
 	for _, data := range n.buffer {
 		if err := n.send(n.sub, data); err != nil {
 			return err
@@ -188,6 +211,7 @@ type Subscription struct {
 	ID        ID
 	namespace string
 	err       chan error // closed on unsubscribe
+	notifier  *Notifier  // Quorum
 }
 
 // Err returns a channel that is closed when the client send an unsubscribe request.
@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+)
+
+// Quorum
+//
+// Long-lived websocket connections are authenticated once, when they are opened.
+// Their token is re-validated every auth check interval, subscriptions requiring
+// a scope are ended with an error notification once it is not granted anymore,
+// and clients replace the token with RefreshTokenMethod without dropping their
+// subscriptions.
+
+// RefreshTokenMethod is the method called with a new access token to replace the
+// one a connection was authenticated with. It is served even if the current
+// token expired.
+const RefreshTokenMethod = MetadataApi + serviceMethodSeparator + "refreshToken"
+
+// securityContextHolder is a connection which security context can be replaced.
+type securityContextHolder interface {
+	securityContextConfigurer
+	securityContextResolver
+}
+
+// RefreshToken replaces the access token the connection of the client was
+// authenticated with, keeping its subscriptions.
+func (c *Client) RefreshToken(ctx context.Context, token string) error {
+	var refreshed bool
+	return c.CallContext(ctx, &refreshed, RefreshTokenMethod, token)
+}
+
+// handleRefreshToken authenticates the token of a RefreshTokenMethod call and
+// replaces the security context of the connection on success. Subscriptions
+// for which the new token lacks the scope are stopped.
+func (h *handler) handleRefreshToken(msg *jsonrpcMessage) *jsonrpcMessage {
+	var params []string
+	if err := json.Unmarshal(msg.Params, &params); err != nil || len(params) != 1 || params[0] == "" {
+		return msg.errorResponse(&invalidParamsError{"expected the access token"})
+	}
+	holder, ok := h.conn.(securityContextHolder)
+	if !ok {
+		return msg.errorResponse(&securityError{"authentication is not enabled"})
+	}
+
+	h.authLock.Lock()
+	defer h.authLock.Unlock()
+
+	authenticate, ok := holder.Resolve().Value(ctxTokenAuthenticator).(tokenAuthenticator)
+	if !ok {
+		return msg.errorResponse(&securityError{"authentication is not enabled"})
+	}
+	refreshed := authenticate(params[0])
+	// the connection keeps its credentials if the new token is refused
+	if err, hasError := refreshed.Value(ctxAuthenticationError).(error); hasError {
+		return securityErrorMessage(msg, err)
+	}
+	if authToken, ok := refreshed.Value(CtxPreauthenticatedToken).(*proto.PreAuthenticatedAuthenticationToken); ok {
+		if err := verifyExpiration(authToken); err != nil {
+			return securityErrorMessage(msg, err)
+		}
+	}
+	holder.Configure(refreshed)
+	h.log.Debug("Refreshed access token of connection")
+	h.stopUnauthorizedSubscriptions(refreshed)
+	return msg.response(true)
+}
+
+// watchToken re-validates the token of the connection every auth check interval
+// until the connection is closed.
+func (h *handler) watchToken() {
+	holder, ok := h.conn.(securityContextHolder)
+	if !ok {
+		return
+	}
+	interval, ok := holder.Resolve().Value(ctxAuthCheckInterval).(time.Duration)
+	if !ok {
+		// authentication is disabled
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.revalidateToken(holder)
+		case <-h.rootCtx.Done():
+			return
+		}
+	}
+}
+
+// revalidateToken authenticates the token of the connection again, so that a
+// revoked token is refused for subsequent calls, and stops the subscriptions
+// no longer authorized.
+func (h *handler) revalidateToken(holder securityContextHolder) {
+	h.authLock.Lock()
+	defer h.authLock.Unlock()
+
+	secCtx := holder.Resolve()
+	token, hasToken := secCtx.Value(ctxAccessToken).(string)
+	authenticate, canAuthenticate := secCtx.Value(ctxTokenAuthenticator).(tokenAuthenticator)
+	if hasToken && canAuthenticate && secCtx.Value(ctxAuthenticationError) == nil {
+		if revalidated := authenticate(token); revalidated.Value(ctxAuthenticationError) != nil {
+			h.log.Debug("Access token of connection no longer valid", "err", revalidated.Value(ctxAuthenticationError))
+			holder.Configure(revalidated)
+			secCtx = revalidated
+		}
+	}
+	h.stopUnauthorizedSubscriptions(secCtx)
+}
+
+// stopUnauthorizedSubscriptions ends the subscriptions which scope the security
+// context does not grant anymore, notifying the client with the reason.
+// Subscriptions without scope are left running.
+func (h *handler) stopUnauthorizedSubscriptions(secCtx securityContext) {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	for id, sub := range h.serverSubs {
+		if sub.notifier == nil || sub.notifier.scope == "" {
+			continue
+		}
+		if err := verifyScope(secCtx, sub.notifier.scope); err != nil {
+			h.log.Debug("Stopping unauthorized subscription", "id", id, "err", err)
+			sub.notifier.stop(err)
+			sub.err <- err
+			close(sub.err)
+			delete(h.serverSubs, id)
+		}
+	}
+}
+
+// stop ends the subscription of the notifier, sending the error notification
+// once the subscription is activated.
+func (n *Notifier) stop(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.stopped {
+		return
+	}
+	n.stopped = true
+	n.stopErr = &jsonError{Code: defaultErrorCode, Message: err.Error()}
+	if ec, ok := err.(Error); ok {
+		n.stopErr.Code = ec.ErrorCode()
+	}
+	if n.activated {
+		n.sendStopped()
+	}
+}
+
+func (n *Notifier) isStopped() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stopped
+}
+
+func (n *Notifier) sendStopped() error {
+	params, _ := json.Marshal(&subscriptionResult{ID: string(n.sub.ID), Error: n.stopErr})
+	return n.h.conn.writeJSON(context.Background(), &jsonrpcMessage{
+		Version: vsn,
+		Method:  n.namespace + notificationMethodSuffix,
+		Params:  params,
+	})
+}
@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jpmorganchase/quorum-security-plugin-sdk-go/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamTestService notifies increasing numbers until unsubscribed.
+type streamTestService struct{}
+
+func (s *streamTestService) stream(ctx context.Context) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-subscription.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-time.After(5 * time.Millisecond):
+				notifier.Notify(subscription.ID, i)
+			}
+		}
+	}()
+	return subscription, nil
+}
+
+func (s *streamTestService) Heads(ctx context.Context) (*Subscription, error) {
+	return s.stream(ctx)
+}
+
+func (s *streamTestService) PrivateLogs(ctx context.Context) (*Subscription, error) {
+	return s.stream(ctx)
+}
+
+type stubToken struct {
+	expiredAt   time.Time
+	authorities []*proto.GrantedAuthority
+	revoked     bool
+}
+
+// tokensAuthenticationManager authenticates the tokens it was given.
+type tokensAuthenticationManager struct {
+	lock   sync.Mutex
+	tokens map[string]*stubToken
+}
+
+func (m *tokensAuthenticationManager) Authenticate(_ context.Context, token string) (*proto.PreAuthenticatedAuthenticationToken, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	t, ok := m.tokens[token]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	if t.revoked {
+		return nil, errors.New("token revoked")
+	}
+	expiredAt, err := ptypes.TimestampProto(t.expiredAt)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.PreAuthenticatedAuthenticationToken{ExpiredAt: expiredAt, Authorities: t.authorities}, nil
+}
+
+func (m *tokensAuthenticationManager) IsEnabled(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *tokensAuthenticationManager) revoke(token string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.tokens[token].revoked = true
+}
+
+var (
+	subscribeAuthority = &proto.GrantedAuthority{Service: "stream", Method: "subscribe"}
+	privateAuthority   = &proto.GrantedAuthority{Service: "stream", Method: "readPrivate"}
+)
+
+func newStreamTestServer(t *testing.T, tokens map[string]*stubToken) (*tokensAuthenticationManager, func(token string) *Client) {
+	authManager := &tokensAuthenticationManager{tokens: tokens}
+	srv := NewProtectedServer(authManager)
+	require.NoError(t, srv.RegisterName("stream", new(streamTestService)))
+	srv.RequireSubscriptionScopes("stream", map[string]string{"privateLogs": "stream_readPrivate"})
+	srv.SetAuthCheckInterval(20 * time.Millisecond)
+	httpsrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+	t.Cleanup(func() {
+		httpsrv.Close()
+		srv.Stop()
+	})
+
+	dial := func(token string) *Client {
+		ctx := context.WithValue(context.Background(), CtxCredentialsProvider, HttpCredentialsProviderFunc(func(context.Context) (string, error) {
+			return token, nil
+		}))
+		client, err := DialWebsocket(ctx, "ws:"+strings.TrimPrefix(httpsrv.URL, "http:"), "")
+		require.NoError(t, err)
+		t.Cleanup(client.Close)
+		return client
+	}
+	return authManager, dial
+}
+
+func subscribeStream(t *testing.T, client *Client, name string) (*ClientSubscription, chan int) {
+	ch := make(chan int, 1000)
+	sub, err := client.Subscribe(context.Background(), "stream", ch, name)
+	require.NoError(t, err)
+	return sub, ch
+}
+
+// waitStopped waits for the subscription to be ended by the server, returning the error.
+func waitStopped(t *testing.T, sub *ClientSubscription) error {
+	select {
+	case err := <-sub.Err():
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription not stopped")
+		return nil
+	}
+}
+
+func assertStreaming(t *testing.T, ch chan int) {
+	for len(ch) > 0 {
+		<-ch
+	}
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("subscription not streaming")
+	}
+}
+
+func TestSubscriptionScope_checkedAtCreation(t *testing.T) {
+	_, dial := newStreamTestServer(t, map[string]*stubToken{
+		"public": {expiredAt: time.Now().Add(time.Hour), authorities: []*proto.GrantedAuthority{subscribeAuthority}},
+	})
+	client := dial("public")
+
+	_, err := client.Subscribe(context.Background(), "stream", make(chan int), "privateLogs")
+	assert.EqualError(t, err, "stream_readPrivate - access denied")
+
+	_, ch := subscribeStream(t, client, "heads")
+	assertStreaming(t, ch)
+}
+
+func TestSubscriptionScope_stoppedOnExpiry(t *testing.T) {
+	_, dial := newStreamTestServer(t, map[string]*stubToken{
+		"short": {expiredAt: time.Now().Add(300 * time.Millisecond), authorities: []*proto.GrantedAuthority{subscribeAuthority, privateAuthority}},
+	})
+	client := dial("short")
+	privateSub, _ := subscribeStream(t, client, "privateLogs")
+	_, headsCh := subscribeStream(t, client, "heads")
+
+	assert.EqualError(t, waitStopped(t, privateSub), "token expired")
+	// subscriptions without scope outlive the token
+	assertStreaming(t, headsCh)
+}
+
+func TestSubscriptionScope_stoppedOnRevocation(t *testing.T) {
+	authManager, dial := newStreamTestServer(t, map[string]*stubToken{
+		"revoked": {expiredAt: time.Now().Add(time.Hour), authorities: []*proto.GrantedAuthority{subscribeAuthority, privateAuthority}},
+	})
+	client := dial("revoked")
+	privateSub, privateCh := subscribeStream(t, client, "privateLogs")
+	assertStreaming(t, privateCh)
+
+	authManager.revoke("revoked")
+	assert.EqualError(t, waitStopped(t, privateSub), "token revoked")
+	// calls are refused as well
+	_, err := client.Subscribe(context.Background(), "stream", make(chan int), "heads")
+	assert.EqualError(t, err, "token revoked")
+}
+
+func TestRefreshToken(t *testing.T) {
+	_, dial := newStreamTestServer(t, map[string]*stubToken{
+		"short":  {expiredAt: time.Now().Add(300 * time.Millisecond), authorities: []*proto.GrantedAuthority{subscribeAuthority, privateAuthority}},
+		"long":   {expiredAt: time.Now().Add(time.Hour), authorities: []*proto.GrantedAuthority{subscribeAuthority, privateAuthority}},
+		"public": {expiredAt: time.Now().Add(time.Hour), authorities: []*proto.GrantedAuthority{subscribeAuthority}},
+	})
+	client := dial("short")
+	privateSub, privateCh := subscribeStream(t, client, "privateLogs")
+
+	// an invalid token keeps the credentials of the connection
+	assert.EqualError(t, client.RefreshToken(context.Background(), "unknown"), "invalid token")
+
+	require.NoError(t, client.RefreshToken(context.Background(), "long"))
+	time.Sleep(400 * time.Millisecond)
+	assertStreaming(t, privateCh)
+
+	// a token without the scope stops the subscription
+	require.NoError(t, client.RefreshToken(context.Background(), "public"))
+	assert.EqualError(t, waitStopped(t, privateSub), "stream_readPrivate - access denied")
+}
@@ -33,6 +33,11 @@ type API struct {
 	Version   string      // api version for DApp's
 	Service   interface{} // receiver instance which holds the methods
 	Public    bool        // indication if the methods must be considered safe for public use
+
+	// Quorum
+	// SubscriptionScopes are the authorities, as service_method, required on top of
+	// <namespace>_subscribe to create the subscriptions of the given names
+	SubscriptionScopes map[string]string
 }
 
 // Error wraps RPC errors, which contain an error code in addition to the message.
@@ -194,6 +199,24 @@ func (bnh *BlockNumberOrHash) Hash() (common.Hash, bool) {
 	return common.Hash{}, false
 }
 
+// Quorum
+// String returns the block number or hash as in error messages.
+func (bnh *BlockNumberOrHash) String() string {
+	if bnh.BlockNumber != nil {
+		switch *bnh.BlockNumber {
+		case LatestBlockNumber:
+			return "latest"
+		case PendingBlockNumber:
+			return "pending"
+		}
+		return fmt.Sprintf("#%d", *bnh.BlockNumber)
+	}
+	if bnh.BlockHash != nil {
+		return bnh.BlockHash.Hex()
+	}
+	return "nil"
+}
+
 func BlockNumberOrHashWithNumber(blockNr BlockNumber) BlockNumberOrHash {
 	return BlockNumberOrHash{
 		BlockNumber:      &blockNr,
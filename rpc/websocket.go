@@ -20,16 +20,21 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/gorilla/websocket"
 )
 
@@ -40,7 +45,21 @@ const (
 	wsPingWriteTimeout = 5 * time.Second
 )
 
-var wsBufferPool = new(sync.Pool)
+// Quorum
+const (
+	// DefaultWSPingInterval is how long a served websocket connection stays idle
+	// before it is pinged.
+	DefaultWSPingInterval = wsPingInterval
+	// DefaultWSIdleTimeout is how long a pinged websocket connection has to
+	// answer before it is closed.
+	DefaultWSIdleTimeout = 30 * time.Second
+)
+
+var (
+	wsBufferPool = new(sync.Pool)
+
+	wsIdleClosedCounter = metrics.NewRegisteredCounter("rpc/ws/closed/unresponsive", nil) // Quorum
+)
 
 // WebsocketHandler returns a handler that serves JSON-RPC to WebSocket connections.
 //
@@ -54,12 +73,19 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 		CheckOrigin:     wsHandshakeValidator(allowedOrigins),
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
+		// Quorum - the request ID of the handshake names the calls of the connection
+		requestID := SanitizeRequestID(r.Header.Get(RequestIDHeader))
+		var header http.Header
+		if requestID != "" {
+			header = http.Header{RequestIDHeader: {requestID}}
+		}
+		conn, err := upgrader.Upgrade(w, r, header)
 		if err != nil {
 			log.Debug("WebSocket upgrade failed", "err", err)
 			return
 		}
-		codec := newWebsocketCodec(conn)
+		codec := newWebsocketCodec(conn, s.requestLimit(), true, s.WebsocketLiveness)
+		codec.(*websocketCodec).handshakeID = requestID
 		s.authenticateHttpRequest(r, codec)
 		s.ServeCodec(codec, 0)
 	})
@@ -174,7 +200,7 @@ func DialWebsocketWithCustomTLS(ctx context.Context, endpoint, origin string, tl
 			}
 			return nil, hErr
 		}
-		return newWebsocketCodec(conn), nil
+		return newWebsocketCodec(conn, DefaultBodyLimit, false, nil), nil
 	})
 }
 
@@ -215,20 +241,72 @@ type websocketCodec struct {
 
 	wg        sync.WaitGroup
 	pingReset chan struct{}
+
+	// Quorum
+	limit    int64                             // maximum size of a received message
+	server   bool                              // whether the codec serves requests
+	liveness func() (ping, idle time.Duration) // nil to ping every wsPingInterval without closing
+	awaiting int32                             // whether a ping is not answered yet, accessed atomically
+	// ID of the handshake request naming the calls of the connection
+	handshakeID string
 }
 
-func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
-	conn.SetReadLimit(maxRequestContentLength)
+func newWebsocketCodec(conn *websocket.Conn, limit int64, server bool, liveness func() (time.Duration, time.Duration)) ServerCodec {
 	wc := &websocketCodec{
-		jsonCodec: NewFuncCodec(conn, conn.WriteJSON, conn.ReadJSON).(*jsonCodec),
 		conn:      conn,
 		pingReset: make(chan struct{}, 1),
+		limit:     limit,
+		server:    server,
+		liveness:  liveness,
 	}
+	wc.jsonCodec = NewFuncCodec(conn, conn.WriteJSON, wc.readJSON).(*jsonCodec)
+	// Quorum - an answer to a ping lifts the read deadline it set
+	conn.SetPongHandler(func(string) error {
+		atomic.StoreInt32(&wc.awaiting, 0)
+		return conn.SetReadDeadline(time.Time{})
+	})
 	wc.wg.Add(1)
 	go wc.pingLoop()
 	return wc
 }
 
+// readJSON decodes the next message, failing as soon as it exceeds the limit.
+func (wc *websocketCodec) readJSON(v interface{}) error {
+	_, r, err := wc.conn.NextReader()
+	if err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			wsIdleClosedCounter.Inc(1)
+			log.Debug("Closing unresponsive websocket connection", "remote", wc.conn.RemoteAddr())
+		}
+		return err
+	}
+	err = json.NewDecoder(newLimitReader(r, "ws", wc.limit)).Decode(v)
+	if err == io.EOF {
+		// a message is a single value, finding none is unexpected
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readBatch closes the connection with a message naming the limit when a
+// message is too large.
+func (wc *websocketCodec) readBatch() ([]*jsonrpcMessage, bool, error) {
+	msgs, batch, err := wc.jsonCodec.readBatch()
+	if tooLarge, ok := err.(*RequestTooLargeError); ok {
+		if wc.server {
+			wc.writeJSON(context.Background(), errorMessage(tooLarge))
+		}
+		reason := websocket.FormatCloseMessage(websocket.CloseMessageTooBig, tooLarge.Error())
+		wc.conn.WriteControl(websocket.CloseMessage, reason, time.Now().Add(wsPingWriteTimeout))
+	}
+	return msgs, batch, err
+}
+
+// Quorum
+func (wc *websocketCodec) requestID() string {
+	return wc.handshakeID
+}
+
 func (wc *websocketCodec) close() {
 	wc.jsonCodec.close()
 	wc.wg.Wait()
@@ -246,9 +324,21 @@ func (wc *websocketCodec) writeJSON(ctx context.Context, v interface{}) error {
 	return err
 }
 
+// Quorum
+// livenessTimeouts returns the ping interval and the time a pinged connection
+// has to answer, zero if it is never closed.
+func (wc *websocketCodec) livenessTimeouts() (time.Duration, time.Duration) {
+	if wc.liveness == nil {
+		return wsPingInterval, 0
+	}
+	return wc.liveness()
+}
+
 // pingLoop sends periodic ping frames when the connection is idle.
+// Quorum - served connections not answering a ping in time are closed.
 func (wc *websocketCodec) pingLoop() {
-	var timer = time.NewTimer(wsPingInterval)
+	ping, _ := wc.livenessTimeouts()
+	var timer = time.NewTimer(ping)
 	defer wc.wg.Done()
 	defer timer.Stop()
 
@@ -260,13 +350,19 @@ func (wc *websocketCodec) pingLoop() {
 			if !timer.Stop() {
 				<-timer.C
 			}
-			timer.Reset(wsPingInterval)
+			ping, _ := wc.livenessTimeouts()
+			timer.Reset(ping)
 		case <-timer.C:
+			ping, idle := wc.livenessTimeouts()
 			wc.jsonCodec.encMu.Lock()
 			wc.conn.SetWriteDeadline(time.Now().Add(wsPingWriteTimeout))
 			wc.conn.WriteMessage(websocket.PingMessage, nil)
+			// the deadline is not pushed back by the pings sent while waiting
+			if idle > 0 && atomic.CompareAndSwapInt32(&wc.awaiting, 0, 1) {
+				wc.conn.SetReadDeadline(time.Now().Add(idle))
+			}
 			wc.jsonCodec.encMu.Unlock()
-			timer.Reset(wsPingInterval)
+			timer.Reset(ping)
 		}
 	}
 }
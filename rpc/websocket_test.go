@@ -113,6 +113,81 @@ func TestWebsocketLargeCall(t *testing.T) {
 	}
 }
 
+// This test checks that messages exceeding the configured limit are answered
+// with an error naming the limit before the connection is closed.
+func TestWebsocketMessageLimit(t *testing.T) {
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	srv.SetBodyLimit(100)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer conn.Close()
+
+	msg := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["` + strings.Repeat("x", 200) + `"]}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	var resp jsonrpcMessage
+	if err := conn.ReadJSON(&resp); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != -32600 {
+		t.Fatalf("wrong response: %+v", resp)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("expected close for too large message, got %v", err)
+	}
+}
+
+// This test checks that served connections not answering pings are closed.
+func TestWebsocketLiveness(t *testing.T) {
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	srv.SetWebsocketLiveness(50*time.Millisecond, 100*time.Millisecond)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	dial := func(ignorePings bool) <-chan error {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("can't dial: %v", err)
+		}
+		if ignorePings {
+			conn.SetPingHandler(func(string) error { return nil })
+		}
+		errc := make(chan error, 1)
+		go func() {
+			defer conn.Close()
+			_, _, err := conn.ReadMessage()
+			errc <- err
+		}()
+		return errc
+	}
+	responsive, unresponsive := dial(false), dial(true)
+	select {
+	case <-unresponsive:
+	case <-time.After(2 * time.Second):
+		t.Fatal("unresponsive connection not closed")
+	}
+	select {
+	case err := <-responsive:
+		t.Fatalf("responsive connection closed: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
 // This test checks that client handles WebSocket ping frames correctly.
 func TestClientWebsocketPing(t *testing.T) {
 	t.Parallel()